@@ -0,0 +1,372 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/container-census/container-census/internal/agent"
+	"github.com/container-census/container-census/internal/broker"
+	"github.com/container-census/container-census/internal/listen"
+	"github.com/container-census/container-census/internal/models"
+)
+
+const (
+	// cliTimeout limits each request of the status, scan and token commands to the running agent
+	cliTimeout = 30 * time.Second
+	// tokenReloadInterval is how often the running agent checks its token file for a new token
+	tokenReloadInterval = 5 * time.Second
+)
+
+// runCLI runs the status, scan and token commands, which take the agent's flags to find the
+// running agent and its token
+func runCLI(command string, args []string) error {
+	switch command {
+	case "status":
+		return runStatus(args)
+	case "scan":
+		return runScan(args)
+	case "token":
+		return runToken(args)
+	}
+	return fmt.Errorf("unknown command %q", command)
+}
+
+// runStatus shows what the running agent reports about itself and whether it accepts the token
+func runStatus(args []string) error {
+	opts := &options{}
+	fs := newFlagSet("status", opts)
+	fs.Parse(args)
+	opts.visit(fs)
+
+	token, source, tokenErr := currentToken(opts)
+	a, err := newLocalAgent(opts, token)
+	if err != nil {
+		return err
+	}
+
+	var health struct {
+		Status      string `json:"status"`
+		DockerError string `json:"docker_error"`
+	}
+	if _, err := a.get("/health", false, &health); err != nil {
+		return fmt.Errorf("agent not reachable at %s: %w", a.baseURL, err)
+	}
+	var info agent.Info
+	if _, err := a.get("/info", false, &info); err != nil {
+		return fmt.Errorf("failed to get the agent's info: %w", err)
+	}
+
+	var problems []string
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Agent:\t%s\n", a.baseURL)
+	fmt.Fprintf(w, "Version:\t%s (%s, %s/%s)\n", info.Version, info.Hostname, info.OS, info.Arch)
+	fmt.Fprintf(w, "Started:\t%s (up %s)\n", info.StartedAt.Local().Format(time.RFC3339), time.Since(info.StartedAt).Round(time.Second))
+	if health.Status == "healthy" {
+		fmt.Fprintf(w, "Docker:\treachable, version %s\n", info.DockerVersion)
+	} else {
+		fmt.Fprintf(w, "Docker:\tNOT REACHABLE: %s\n", health.DockerError)
+		problems = append(problems, "Docker isn't reachable")
+	}
+	fmt.Fprintf(w, "Read-only:\t%s\n", yesNo(info.ReadOnly))
+	if !info.ReadOnly && info.Permissions != nil {
+		fmt.Fprintf(w, "Allowed:\t%s\n", strings.Join(info.Permissions, ", "))
+	}
+	if info.DaemonLogs != "" {
+		fmt.Fprintf(w, "Daemon logs:\t%s\n", info.DaemonLogs)
+	}
+	fmt.Fprintf(w, "Trivy:\t%s\n", yesNo(info.TrivyAvailable))
+
+	if tokenErr != nil {
+		fmt.Fprintf(w, "Token:\t%v\n", tokenErr)
+		problems = append(problems, "no token")
+	} else {
+		var metrics models.AgentHealth
+		status, err := a.get("/api/metrics", true, &metrics)
+		switch {
+		case status == http.StatusUnauthorized:
+			fmt.Fprintf(w, "Token:\tREJECTED (from %s)\n", source)
+			problems = append(problems, "the agent rejects the token")
+		case err != nil:
+			fmt.Fprintf(w, "Token:\tunknown (from %s): %v\n", source, err)
+		default:
+			fmt.Fprintf(w, "Token:\taccepted (from %s)\n", source)
+			fmt.Fprintf(w, "Scans served:\t%d (%d failed, last %.2fs, average %.2fs)\n", metrics.Scans, metrics.ScanErrors, metrics.LastScanSeconds, metrics.AvgScanSeconds)
+			if metrics.LastDockerError != "" {
+				fmt.Fprintf(w, "Last Docker error:\t%s\n", metrics.LastDockerError)
+			}
+		}
+	}
+	w.Flush()
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// runScan lists the containers the running agent reports to the server
+func runScan(args []string) error {
+	opts := &options{}
+	fs := newFlagSet("scan", opts)
+	asJSON := fs.Bool("json", false, "Print the containers as the server receives them")
+	fs.Parse(args)
+	opts.visit(fs)
+
+	token, _, err := currentToken(opts)
+	if err != nil {
+		return err
+	}
+	a, err := newLocalAgent(opts, token)
+	if err != nil {
+		return err
+	}
+	var raw json.RawMessage
+	if _, err := a.get("/api/containers", true, &raw); err != nil {
+		return err
+	}
+
+	if *asJSON {
+		var out bytes.Buffer
+		if err := json.Indent(&out, raw, "", "  "); err != nil {
+			return err
+		}
+		out.WriteByte('\n')
+		_, err := out.WriteTo(os.Stdout)
+		return err
+	}
+
+	var containers []models.Container
+	if err := json.Unmarshal(raw, &containers); err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tIMAGE\tSTATE\tSTATUS")
+	for _, c := range containers {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Name, c.Image, c.State, c.Status)
+	}
+	w.Flush()
+	fmt.Fprintf(os.Stderr, "%d containers\n", len(containers))
+	return nil
+}
+
+// runToken shows or rotates the agent's token: token show|rotate [flags]
+func runToken(args []string) error {
+	if len(args) == 0 || (args[0] != "show" && args[0] != "rotate") {
+		return errors.New("usage: token show|rotate [flags]")
+	}
+	opts := &options{}
+	fs := newFlagSet("token "+args[0], opts)
+	fs.Parse(args[1:])
+	opts.visit(fs)
+
+	if args[0] == "show" {
+		token, source, err := currentToken(opts)
+		if err != nil {
+			return err
+		}
+		fmt.Println(token)
+		fmt.Fprintf(os.Stderr, "(from %s)\n", source)
+		return nil
+	}
+
+	if opts.apiToken != "" || os.Getenv("API_TOKEN") != "" {
+		return errors.New("the token is set by -token or API_TOKEN: change it there and restart the agent")
+	}
+	newToken := agent.GenerateToken()
+	if err := writeTokenFile(opts.tokenFile, newToken); err != nil {
+		return err
+	}
+	fmt.Println(newToken)
+	fmt.Fprintf(os.Stderr, "New token saved to %s. Update the host's agent token in Container Census.\n", opts.tokenFile)
+
+	// Wait for the running agent to pick the new token up from the file
+	a, err := newLocalAgent(opts, newToken)
+	if err != nil {
+		return nil
+	}
+	if _, err := a.get("/health", false, nil); err != nil {
+		fmt.Fprintf(os.Stderr, "The agent isn't reachable at %s; it uses the new token once it starts.\n", a.baseURL)
+		return nil
+	}
+	deadline := time.Now().Add(3 * tokenReloadInterval)
+	for {
+		status, err := a.get("/api/metrics", true, nil)
+		if err == nil {
+			fmt.Fprintln(os.Stderr, "The running agent accepts the new token.")
+			return nil
+		}
+		if status != http.StatusUnauthorized || time.Now().After(deadline) {
+			return fmt.Errorf("the running agent doesn't accept the new token (does it use %s?): %v", opts.tokenFile, err)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// currentToken returns the token the agent uses and where it comes from, in the order of
+// resolveToken, without generating one
+func currentToken(opts *options) (token, source string, err error) {
+	if opts.apiToken != "" {
+		return opts.apiToken, "-token", nil
+	}
+	if envToken := os.Getenv("API_TOKEN"); envToken != "" {
+		return envToken, "API_TOKEN", nil
+	}
+	token, err = readTokenFile(opts.tokenFile)
+	if err != nil {
+		return "", "", fmt.Errorf("no token: -token and API_TOKEN aren't set and %w", err)
+	}
+	if token == "" {
+		return "", "", fmt.Errorf("no token: %s is empty", opts.tokenFile)
+	}
+	return token, opts.tokenFile, nil
+}
+
+// readTokenFile reads a token file, ignoring surrounding whitespace
+func readTokenFile(tokenFile string) (string, error) {
+	data, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// writeTokenFile replaces the token file, so the running agent never reads half a token
+func writeTokenFile(tokenFile, token string) error {
+	if err := os.MkdirAll(filepath.Dir(tokenFile), 0755); err != nil {
+		return err
+	}
+	tmp := tokenFile + ".tmp"
+	if err := os.WriteFile(tmp, []byte(token), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, tokenFile)
+}
+
+// watchTokenFile switches the agent to the token in tokenFile when it changes, so token rotate
+// takes effect without a restart
+func watchTokenFile(ctx context.Context, tokenFile string, a *agent.Agent) {
+	ticker := time.NewTicker(tokenReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			token, err := readTokenFile(tokenFile)
+			if err != nil || token == "" || token == a.Token() {
+				continue
+			}
+			a.SetToken(token)
+			log.Printf("API token changed in %s: requests now need the new token", tokenFile)
+		}
+	}
+}
+
+// localAgent reaches the running agent from its flags: the first -listen address, else -port on
+// localhost, else the broker of -broker for an agent that opens no port
+type localAgent struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+func newLocalAgent(opts *options, token string) (*localAgent, error) {
+	a := &localAgent{token: token, client: &http.Client{Timeout: cliTimeout}}
+
+	addr := ""
+	for _, listenAddr := range listen.ParseList(opts.listenAddrs) {
+		if listenAddr != listen.Systemd {
+			addr = listenAddr
+			break
+		}
+	}
+	switch {
+	case strings.HasPrefix(addr, "unix:"):
+		path := strings.TrimPrefix(addr, "unix:")
+		a.baseURL = "http://agent"
+		a.client.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		}
+	case addr != "":
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -listen address %q: %w", addr, err)
+		}
+		switch host {
+		case "", "0.0.0.0":
+			host = "127.0.0.1"
+		case "::":
+			host = "::1"
+		}
+		a.baseURL = "http://" + net.JoinHostPort(host, port)
+	case opts.brokerURL != "" && !opts.set["port"]:
+		if _, _, err := broker.ParseAddress(opts.brokerURL); err != nil {
+			return nil, err
+		}
+		a.baseURL = strings.TrimSuffix(opts.brokerURL, "/")
+		broker.SetAllowPlaintext(opts.brokerPlaintext)
+		a.client.Transport = broker.NewTransport()
+	default:
+		a.baseURL = fmt.Sprintf("http://127.0.0.1:%d", opts.port)
+	}
+	return a, nil
+}
+
+// get requests path and decodes the JSON response into out. The status is returned with the
+// error of a failed request; /health answers 503 with its JSON when Docker isn't reachable.
+func (a *localAgent) get(path string, withToken bool, out interface{}) (int, error) {
+	req, err := http.NewRequest(http.MethodGet, a.baseURL+path, nil)
+	if err != nil {
+		return 0, err
+	}
+	if withToken {
+		req.Header.Set("X-API-Token", a.token)
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+
+	if resp.StatusCode != http.StatusOK && !(path == "/health" && resp.StatusCode == http.StatusServiceUnavailable) {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Error != "" {
+			return resp.StatusCode, fmt.Errorf("%s: %s", resp.Status, apiErr.Error)
+		}
+		return resp.StatusCode, fmt.Errorf("%s", resp.Status)
+	}
+	if out != nil {
+		if err := json.Unmarshal(body, out); err != nil {
+			return resp.StatusCode, fmt.Errorf("invalid response from %s: %w", path, err)
+		}
+	}
+	return resp.StatusCode, nil
+}
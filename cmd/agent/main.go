@@ -5,38 +5,147 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/container-census/container-census/internal/agent"
+	"github.com/container-census/container-census/internal/broker"
+	"github.com/container-census/container-census/internal/listen"
+	"github.com/container-census/container-census/internal/models"
 	"github.com/container-census/container-census/internal/version"
 )
 
+// options are the agent's command line flags
+type options struct {
+	port        int
+	apiToken    string
+	serverURL   string
+	dockerHost  string
+	tokenFile   string
+	listenAddrs string
+	logFile     string
+	readOnly    bool
+	allow       string
+	daemonLogs  string
+	brokerURL   string
+
+	brokerPlaintext bool // allows a -broker without TLS
+
+	set map[string]bool // flags given on the command line
+}
+
+// parseFlags parses the agent flags. install-service takes the same flags, which it passes on
+// to the installed service.
+func parseFlags(name string, args []string) *options {
+	opts := &options{}
+	fs := newFlagSet(name, opts)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [flags]\n       %s install-service [flags]\n       %s uninstall-service\n       %s status|scan|token [flags]\n\nFlags:\n", name, name, name, name)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	opts.visit(fs)
+	return opts
+}
+
+// newFlagSet returns a flag set with the agent flags, parsed into opts
+func newFlagSet(name string, opts *options) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.IntVar(&opts.port, "port", 9876, "Port to listen on")
+	fs.StringVar(&opts.apiToken, "token", "", "API token for authentication")
+	fs.StringVar(&opts.serverURL, "server", "", "Optional: URL of the central server to register with")
+	fs.StringVar(&opts.dockerHost, "docker-host", "", "Docker daemon host (default: DOCKER_HOST, else the platform's default socket or named pipe)")
+	fs.StringVar(&opts.tokenFile, "token-file", defaultTokenFile, "Path to token file for persistence")
+	fs.StringVar(&opts.listenAddrs, "listen", "", "Comma-separated listen addresses (host:port, [::]:port, unix:/path.sock or systemd); overrides -port")
+	fs.StringVar(&opts.logFile, "log-file", "", "Optional: append logs to this file instead of stderr")
+	fs.BoolVar(&opts.readOnly, "read-only", envReadOnly(), "Disable Docker operations (start/stop/remove/update/prune) and only report (default: READ_ONLY)")
+	fs.StringVar(&opts.allow, "allow", envOr("AGENT_ALLOW", "all"), "Docker operations the server may run, comma-separated: lifecycle (start/stop/restart), remove, update (pull/recreate), images (remove/prune), all or none; -read-only allows none (default: AGENT_ALLOW, else all)")
+	fs.StringVar(&opts.daemonLogs, "daemon-logs", os.Getenv("DAEMON_LOGS"), "Optional: watch the kernel and Docker daemon logs for OOM kills and daemon errors, from \"journald\" or a syslog file path (default: DAEMON_LOGS)")
+	fs.StringVar(&opts.brokerURL, "broker", os.Getenv("BROKER_URL"), "Optional: also take requests over a NATS or MQTT broker, as tls:// (NATS) or mqtts://[user:pass@]host:port/<agent-id>; without -listen or -port the agent then opens no port (default: BROKER_URL)")
+	fs.BoolVar(&opts.brokerPlaintext, "broker-allow-plaintext", envBool("BROKER_ALLOW_PLAINTEXT"), "Allow a -broker without TLS (nats:// to a server that doesn't require TLS, or mqtt://); the broker credentials and API token then cross the network unencrypted (default: BROKER_ALLOW_PLAINTEXT)")
+	return fs
+}
+
+// visit records the flags given on the command line
+func (opts *options) visit(fs *flag.FlagSet) {
+	opts.set = make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { opts.set[f.Name] = true })
+}
+
+// envOr returns an environment variable, or fallback when it is empty
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// envReadOnly reports whether READ_ONLY is set, the default of -read-only
+func envReadOnly() bool {
+	return envBool("READ_ONLY")
+}
+
+// envBool reports whether an environment variable is set to true, 1 or yes
+func envBool(name string) bool {
+	value := os.Getenv(name)
+	return value == "true" || value == "1" || value == "yes"
+}
+
 func main() {
-	// Command line flags
-	port := flag.Int("port", 9876, "Port to listen on")
-	apiToken := flag.String("token", "", "API token for authentication")
-	serverURL := flag.String("server", "", "Optional: URL of the central server to register with")
-	dockerHost := flag.String("docker-host", "unix:///var/run/docker.sock", "Docker daemon host")
-	tokenFile := flag.String("token-file", "/app/data/agent-token", "Path to token file for persistence")
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "install-service":
+			if err := installService(os.Args[2:]); err != nil {
+				log.Fatalf("Failed to install service: %v", err)
+			}
+			return
+		case "uninstall-service":
+			if err := uninstallService(); err != nil {
+				log.Fatalf("Failed to uninstall service: %v", err)
+			}
+			return
+		case "status", "scan", "token":
+			if err := runCLI(os.Args[1], os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[1], err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
 
-	flag.Parse()
+	opts := parseFlags(os.Args[0], os.Args[1:])
+	if opts.brokerURL != "" {
+		if _, _, err := broker.ParseAddress(opts.brokerURL); err != nil {
+			log.Fatalf("Invalid -broker: %v", err)
+		}
+		broker.SetAllowPlaintext(opts.brokerPlaintext)
+	}
+	permissions, err := agent.ParsePermissions(opts.allow)
+	if err != nil {
+		log.Fatalf("Invalid -allow: %v", err)
+	}
+	if opts.logFile != "" {
+		os.MkdirAll(filepath.Dir(opts.logFile), 0755)
+		f, err := os.OpenFile(opts.logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			log.Fatalf("Failed to open log file: %v", err)
+		}
+		defer f.Close()
+		log.SetOutput(f)
+	}
 
 	// Load or generate token
-	// Priority: 1. Command-line flag, 2. Environment variable, 3. File, 4. Generate new
-	if *apiToken == "" {
-		// Check environment variable first
-		if envToken := os.Getenv("API_TOKEN"); envToken != "" {
-			*apiToken = envToken
-			log.Printf("Using API token from API_TOKEN environment variable")
-		} else {
-			*apiToken = loadOrGenerateToken(*tokenFile)
-		}
+	apiToken := resolveToken(opts)
+	dockerHost := opts.dockerHost
+	if dockerHost == "" {
+		dockerHost = agent.DefaultDockerHost()
 	}
 
 	// Get hostname
@@ -50,39 +159,58 @@ func main() {
 
 	// Create agent info
 	agentInfo := agent.Info{
-		Version:   agentVersion,
-		Hostname:  hostname,
-		OS:        runtime.GOOS,
-		Arch:      runtime.GOARCH,
-		StartedAt: time.Now(),
+		Version:     agentVersion,
+		Hostname:    hostname,
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		StartedAt:   time.Now(),
+		ReadOnly:    opts.readOnly || len(permissions) == 0,
+		Permissions: permissions,
+		DaemonLogs:  opts.daemonLogs,
 	}
 
 	log.Printf("Starting Container Census Agent v%s", agentVersion)
 	log.Printf("Hostname: %s", hostname)
 	log.Printf("OS: %s/%s", runtime.GOOS, runtime.GOARCH)
-	log.Printf("Docker Host: %s", *dockerHost)
+	log.Printf("Docker Host: %s", dockerHost)
+	if agentInfo.ReadOnly {
+		log.Printf("Read-only mode: Docker operations are disabled")
+	} else if len(permissions) < len(models.AgentPermissions) {
+		log.Printf("Allowed Docker operations: %s", strings.Join(permissions, ", "))
+	}
 
 	// Create agent server
-	agentServer, err := agent.New(*dockerHost, *apiToken, agentInfo)
+	agentServer, err := agent.New(dockerHost, apiToken, agentInfo)
 	if err != nil {
 		log.Fatalf("Failed to create agent: %v", err)
 	}
 
 	// Register with central server if URL provided
-	if *serverURL != "" {
+	if opts.serverURL != "" {
 		go func() {
-			if err := agentServer.RegisterWithServer(*serverURL); err != nil {
+			if err := agentServer.RegisterWithServer(opts.serverURL); err != nil {
 				log.Printf("Failed to register with server: %v", err)
 			} else {
-				log.Printf("Successfully registered with server: %s", *serverURL)
+				log.Printf("Successfully registered with server: %s", opts.serverURL)
 			}
 		}()
 	}
 
+	// Listeners: -listen, else sockets from systemd socket activation, else -port on all interfaces
+	// (unless the agent is only reached through a broker)
+	var listeners []net.Listener
+	brokerOnly := opts.brokerURL != "" && !opts.set["port"]
+	if addrs := listen.ParseList(opts.listenAddrs); len(addrs) > 0 {
+		listeners, err = listen.Listen(addrs)
+	} else if listeners, err = listen.Activated(); err == nil && len(listeners) == 0 && !brokerOnly {
+		listeners, err = listen.Listen([]string{fmt.Sprintf(":%d", opts.port)})
+	}
+	if err != nil {
+		log.Fatalf("Failed to listen: %v", err)
+	}
+
 	// HTTP server
-	addr := fmt.Sprintf(":%d", *port)
 	server := &http.Server{
-		Addr:         addr,
 		Handler:      agentServer.Router(),
 		ReadTimeout:  30 * time.Second,
 		WriteTimeout: 30 * time.Second,
@@ -99,33 +227,68 @@ func main() {
 	// Start daily version check
 	go runDailyVersionCheck(ctx)
 
+	// Take a token rotated in the token file without a restart, and save tokens the server rotates there
+	if opts.apiToken == "" && os.Getenv("API_TOKEN") == "" {
+		agentServer.SetTokenStore(func(token string) error { return writeTokenFile(opts.tokenFile, token) })
+		go watchTokenFile(ctx, opts.tokenFile, agentServer)
+	}
+
+	// Watch the daemon logs for OOM kills and daemon errors
+	if opts.daemonLogs != "" {
+		go agentServer.WatchDaemonLogs(ctx, opts.daemonLogs)
+	}
+
+	// Take requests over the broker
+	if opts.brokerURL != "" {
+		go broker.Serve(ctx, opts.brokerURL, agentServer.Router())
+	}
+
 	// Start server
-	go func() {
-		log.Printf("Agent listening on http://0.0.0.0%s", addr)
-		log.Printf("Health check: http://localhost%s/health", addr)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
+	for _, l := range listeners {
+		log.Printf("Agent listening on %s (health check: /health)", listen.URL(l, "http"))
+		go func(l net.Listener) {
+			if err := server.Serve(l); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Failed to start server: %v", err)
+			}
+		}(l)
+	}
+
+	// Wait for an interrupt signal, or the service manager stopping the service
+	runUntilStopped(func() {
+		cancel() // Cancel background tasks
+
+		log.Println("Shutting down agent...")
+
+		// Graceful shutdown
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer shutdownCancel()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Agent forced to shutdown: %v", err)
 		}
-	}()
 
-	// Wait for interrupt signal
+		log.Println("Agent stopped")
+	})
+}
+
+// waitForSignal blocks until the process is interrupted or terminated
+func waitForSignal() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
+}
 
-	cancel() // Cancel background tasks
-
-	log.Println("Shutting down agent...")
-
-	// Graceful shutdown
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer shutdownCancel()
-
-	if err := server.Shutdown(shutdownCtx); err != nil {
-		log.Printf("Agent forced to shutdown: %v", err)
+// resolveToken returns the API token from, in order, the -token flag, the API_TOKEN environment
+// variable or the token file, generating and saving a new token if there is none
+func resolveToken(opts *options) string {
+	if opts.apiToken != "" {
+		return opts.apiToken
 	}
-
-	log.Println("Agent stopped")
+	if envToken := os.Getenv("API_TOKEN"); envToken != "" {
+		log.Printf("Using API token from API_TOKEN environment variable")
+		return envToken
+	}
+	return loadOrGenerateToken(opts.tokenFile)
 }
 
 // checkForUpdates checks for new versions and logs a warning if an update is available
@@ -162,8 +325,7 @@ func runDailyVersionCheck(ctx context.Context) {
 // loadOrGenerateToken loads a token from file or generates a new one if it doesn't exist
 func loadOrGenerateToken(tokenFile string) string {
 	// Try to read existing token
-	if data, err := os.ReadFile(tokenFile); err == nil {
-		token := string(data)
+	if token, err := readTokenFile(tokenFile); err == nil {
 		if len(token) > 0 {
 			log.Printf("Using existing API token from %s", tokenFile)
 			log.Printf("API Token: %s", token)
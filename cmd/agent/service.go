@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/container-census/container-census/internal/agent"
+)
+
+// Names of the installed service
+const (
+	serviceName        = "census-agent"
+	serviceDisplayName = "Container Census Agent"
+	serviceDescription = "Reports the containers of this host to Container Census"
+)
+
+// serviceCommand returns the executable and flags of the service installed by install-service,
+// adding defaults for the flags not given in args. The Docker host and the token are resolved
+// now, in the installing user's environment: the service runs as another user (with another home
+// directory and without DOCKER_HOST), and a generated token is shown to the user once.
+func serviceCommand(args []string, defaults map[string]string) (string, []string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to find the agent executable: %w", err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return "", nil, fmt.Errorf("failed to find the agent executable: %w", err)
+	}
+
+	opts := parseFlags("install-service", args)
+	if !opts.set["docker-host"] {
+		args = append(args, "-docker-host", agent.DefaultDockerHost())
+	}
+	names := make([]string, 0, len(defaults))
+	for name := range defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !opts.set[name] {
+			args = append(args, "-"+name, defaults[name])
+		}
+	}
+
+	opts = parseFlags("install-service", args)
+	if opts.apiToken == "" {
+		loadOrGenerateToken(opts.tokenFile)
+	}
+	return exe, args, nil
+}
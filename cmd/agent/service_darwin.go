@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+)
+
+const (
+	defaultTokenFile = "/Library/Application Support/Container Census/agent-token"
+	launchdLabel     = "com.container-census.agent"
+	launchdPlistPath = "/Library/LaunchDaemons/" + launchdLabel + ".plist"
+	launchdLogPath   = "/Library/Logs/" + serviceName + ".log"
+)
+
+// installService installs and starts a launchd daemon running the agent with args. The daemon
+// starts at boot, before Docker Desktop is running; the agent reconnects once it is.
+func installService(args []string) error {
+	exe, args, err := serviceCommand(args, nil)
+	if err != nil {
+		return err
+	}
+
+	var programArgs bytes.Buffer
+	for _, arg := range append([]string{exe}, args...) {
+		programArgs.WriteString("\t\t<string>")
+		xml.EscapeText(&programArgs, []byte(arg))
+		programArgs.WriteString("</string>\n")
+	}
+	plist := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>%s</string>
+	<key>ProgramArguments</key>
+	<array>
+%s	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<true/>
+	<key>StandardOutPath</key>
+	<string>%s</string>
+	<key>StandardErrorPath</key>
+	<string>%s</string>
+</dict>
+</plist>
+`, launchdLabel, programArgs.String(), launchdLogPath, launchdLogPath)
+
+	// Replace a daemon that is already loaded
+	runCommand("launchctl", "bootout", "system/"+launchdLabel)
+
+	if err := os.WriteFile(launchdPlistPath, []byte(plist), 0644); err != nil {
+		return err
+	}
+	if err := runCommand("launchctl", "bootstrap", "system", launchdPlistPath); err != nil {
+		return err
+	}
+	fmt.Printf("Installed %s (%s); logs: %s\n", launchdLabel, launchdPlistPath, launchdLogPath)
+	return nil
+}
+
+// uninstallService stops and removes the launchd daemon
+func uninstallService() error {
+	if _, err := os.Stat(launchdPlistPath); err != nil {
+		return fmt.Errorf("%s is not installed: %w", launchdLabel, err)
+	}
+	if err := runCommand("launchctl", "bootout", "system/"+launchdLabel); err != nil {
+		return err
+	}
+	if err := os.Remove(launchdPlistPath); err != nil {
+		return err
+	}
+	fmt.Printf("Uninstalled %s\n", launchdLabel)
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	defaultTokenFile = "/app/data/agent-token"
+	systemdUnitPath  = "/etc/systemd/system/" + serviceName + ".service"
+)
+
+// installService installs and starts a systemd unit running the agent with args
+func installService(args []string) error {
+	exe, args, err := serviceCommand(args, map[string]string{
+		"token-file": "/var/lib/" + serviceName + "/agent-token",
+	})
+	if err != nil {
+		return err
+	}
+
+	quoted := []string{systemdQuote(exe)}
+	for _, arg := range args {
+		quoted = append(quoted, systemdQuote(arg))
+	}
+	unit := fmt.Sprintf(`[Unit]
+Description=%s
+Wants=network-online.target
+After=network-online.target docker.service
+
+[Service]
+ExecStart=%s
+Restart=on-failure
+RestartSec=5
+
+[Install]
+WantedBy=multi-user.target
+`, serviceDisplayName, strings.Join(quoted, " "))
+
+	if err := os.WriteFile(systemdUnitPath, []byte(unit), 0644); err != nil {
+		return err
+	}
+	if err := runCommand("systemctl", "daemon-reload"); err != nil {
+		return err
+	}
+	if err := runCommand("systemctl", "enable", "--now", serviceName); err != nil {
+		return err
+	}
+	fmt.Printf("Installed %s (%s); logs: journalctl -u %s\n", serviceName, systemdUnitPath, serviceName)
+	return nil
+}
+
+// uninstallService stops and removes the systemd unit
+func uninstallService() error {
+	if _, err := os.Stat(systemdUnitPath); err != nil {
+		return fmt.Errorf("%s is not installed: %w", serviceName, err)
+	}
+	if err := runCommand("systemctl", "disable", "--now", serviceName); err != nil {
+		return err
+	}
+	if err := os.Remove(systemdUnitPath); err != nil {
+		return err
+	}
+	if err := runCommand("systemctl", "daemon-reload"); err != nil {
+		return err
+	}
+	fmt.Printf("Uninstalled %s\n", serviceName)
+	return nil
+}
+
+// systemdQuote quotes an ExecStart word, escaping systemd's specifiers and variables
+func systemdQuote(arg string) string {
+	arg = strings.NewReplacer("%", "%%", "$", "$$").Replace(arg)
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\;") {
+		return arg
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
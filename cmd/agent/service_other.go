@@ -0,0 +1,18 @@
+//go:build !linux && !darwin && !windows
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+const defaultTokenFile = "/app/data/agent-token"
+
+func installService(args []string) error {
+	return fmt.Errorf("service installation is not supported on %s", runtime.GOOS)
+}
+
+func uninstallService() error {
+	return fmt.Errorf("service installation is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// runUntilStopped blocks until the process is interrupted or terminated, then calls shutdown
+func runUntilStopped(shutdown func()) {
+	waitForSignal()
+	shutdown()
+}
+
+// runCommand runs a service manager command, including its output in the error
+func runCommand(name string, args ...string) error {
+	if out, err := exec.Command(name, args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
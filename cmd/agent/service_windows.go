@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// dataDir holds the token file and logs of the service
+var dataDir = filepath.Join(programData(), "Container Census")
+
+var defaultTokenFile = filepath.Join(dataDir, "agent-token")
+
+func programData() string {
+	if dir := os.Getenv("ProgramData"); dir != "" {
+		return dir
+	}
+	return `C:\ProgramData`
+}
+
+// runUntilStopped blocks until the service manager stops the service or, when not running as a
+// service, until the process is interrupted, then calls shutdown
+func runUntilStopped(shutdown func()) {
+	isService, err := svc.IsWindowsService()
+	if err != nil || !isService {
+		waitForSignal()
+		shutdown()
+		return
+	}
+	if err := svc.Run(serviceName, &serviceHandler{shutdown: shutdown}); err != nil {
+		log.Printf("Service failed: %v", err)
+	}
+}
+
+// serviceHandler reports the agent's state to the service manager
+type serviceHandler struct {
+	shutdown func()
+}
+
+func (h *serviceHandler) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	const accepted = svc.AcceptStop | svc.AcceptShutdown
+	status <- svc.Status{State: svc.Running, Accepts: accepted}
+
+	for request := range requests {
+		switch request.Cmd {
+		case svc.Interrogate:
+			status <- request.CurrentStatus
+		case svc.Stop, svc.Shutdown:
+			status <- svc.Status{State: svc.StopPending, WaitHint: 30000}
+			h.shutdown()
+			return false, 0
+		}
+	}
+	return false, 0
+}
+
+// installService registers and starts a Windows service running the agent with args. The
+// service logs to a file, since services have no console.
+func installService(args []string) error {
+	exe, args, err := serviceCommand(args, map[string]string{
+		"log-file": filepath.Join(dataDir, "agent.log"),
+	})
+	if err != nil {
+		return err
+	}
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the service manager (run as administrator): %w", err)
+	}
+	defer m.Disconnect()
+
+	if s, err := m.OpenService(serviceName); err == nil {
+		s.Close()
+		return fmt.Errorf("%s is already installed; run uninstall-service first", serviceName)
+	}
+
+	s, err := m.CreateService(serviceName, exe, mgr.Config{
+		DisplayName: serviceDisplayName,
+		Description: serviceDescription,
+		StartType:   mgr.StartAutomatic,
+		// Start after the other automatic services, including the Docker engine
+		DelayedAutoStart: true,
+	}, args...)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	// Restart after failures, like Restart=on-failure in the systemd unit
+	recovery := []mgr.RecoveryAction{{Type: mgr.ServiceRestart, Delay: 5 * time.Second}}
+	if err := s.SetRecoveryActions(recovery, uint32((24 * time.Hour).Seconds())); err != nil {
+		log.Printf("Warning: could not set the service recovery actions: %v", err)
+	}
+
+	if err := s.Start(); err != nil {
+		return fmt.Errorf("installed %s but failed to start it: %w", serviceName, err)
+	}
+	fmt.Printf("Installed %s; logs: %s\n", serviceName, filepath.Join(dataDir, "agent.log"))
+	return nil
+}
+
+// uninstallService stops and removes the Windows service
+func uninstallService() error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the service manager (run as administrator): %w", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(serviceName)
+	if err != nil {
+		return fmt.Errorf("%s is not installed: %w", serviceName, err)
+	}
+	defer s.Close()
+
+	// Stop it first; deleting only marks a running service for deletion
+	if status, err := s.Control(svc.Stop); err == nil {
+		deadline := time.Now().Add(35 * time.Second)
+		for status.State != svc.Stopped && time.Now().Before(deadline) {
+			time.Sleep(500 * time.Millisecond)
+			if status, err = s.Query(); err != nil {
+				break
+			}
+		}
+	}
+
+	if err := s.Delete(); err != nil {
+		return err
+	}
+	fmt.Printf("Uninstalled %s\n", serviceName)
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/scanner"
+	"github.com/container-census/container-census/internal/storage"
+)
+
+// daemonEventRetentionDays is how long OOM kills and daemon errors read from agents' host logs
+// are kept
+const daemonEventRetentionDays = 90
+
+// collectDaemonEvents stores the OOM kills and daemon errors an agent read from its host's logs
+// since the latest stored one, and notifies the new ones. Agents without -daemon-logs have none.
+func collectDaemonEvents(ctx context.Context, db *storage.DB, scan *scanner.Scanner, host models.Host) {
+	since, err := db.LatestDaemonEventTime(host.ID)
+	if err != nil {
+		scanner.Logf(ctx, "Failed to get the latest daemon event of host %s: %v", host.Name, err)
+		return
+	}
+	events, err := scan.FetchDaemonEvents(ctx, host, since)
+	if err != nil {
+		scanner.Logf(ctx, "Failed to get daemon events of host %s: %v", host.Name, err)
+		return
+	}
+	saved, err := db.SaveDaemonEvents(host.ID, host.Name, events)
+	if err != nil {
+		scanner.Logf(ctx, "Failed to save daemon events of host %s: %v", host.Name, err)
+		return
+	}
+	if len(saved) == 0 {
+		return
+	}
+
+	scanner.Logf(ctx, "Host %s reported %d new daemon events", host.Name, len(saved))
+	if notificationServiceGlobal != nil {
+		if err := notificationServiceGlobal.SendDaemonEvents(ctx, saved); err != nil {
+			scanner.Logf(ctx, "Failed to send daemon event notifications for %s: %v", host.Name, err)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"context"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/scanner"
+	"github.com/container-census/container-census/internal/storage"
+)
+
+// collectDockerObjects replaces a host's object inventory with what the host has now. A failed
+// listing keeps the previous inventory.
+func collectDockerObjects(ctx context.Context, db *storage.DB, scan *scanner.Scanner, host models.Host) {
+	objects, err := scan.ListDockerObjects(ctx, host)
+	if err != nil {
+		scanner.Logf(ctx, "Failed to list Docker objects of host %s: %v", host.Name, err)
+		return
+	}
+	if err := db.ReplaceDockerObjects(host.ID, objects); err != nil {
+		scanner.Logf(ctx, "Failed to save Docker objects of host %s: %v", host.Name, err)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/scanner"
+	"github.com/container-census/container-census/internal/storage"
+)
+
+// hostHeartbeatRetentionDays is how long per-scan host heartbeats are kept; downtimes are kept
+// until their host is deleted
+const hostHeartbeatRetentionDays = 30
+
+// recordHostHeartbeat saves the outcome of a host's scan and sends host_down and host_recovered
+// notifications when the host's status changed
+func recordHostHeartbeat(ctx context.Context, db *storage.DB, host models.Host, at time.Time, scanErr error, downAfter, upAfter int) {
+	// A scan cut short by shutdown says nothing about the host
+	if ctx.Err() != nil {
+		return
+	}
+
+	heartbeat := models.HostHeartbeat{HostID: host.ID, Timestamp: at, Online: scanErr == nil}
+	if scanErr != nil {
+		heartbeat.Error = scanErr.Error()
+	}
+	change, err := db.RecordHostHeartbeat(heartbeat, host.Name, downAfter, upAfter)
+	if err != nil {
+		scanner.Logf(ctx, "Failed to record heartbeat for host %s: %v", host.Name, err)
+		return
+	}
+	if change == nil {
+		return
+	}
+
+	if change.EventType == models.EventTypeHostDown {
+		scanner.Logf(ctx, "Host %s is down since %s", host.Name, change.Downtime.StartedAt.Format(time.RFC3339))
+	} else {
+		scanner.Logf(ctx, "Host %s recovered after %s", host.Name, change.Downtime.Duration(time.Now()).Round(time.Second))
+	}
+	if notificationServiceGlobal != nil {
+		if err := notificationServiceGlobal.SendHostStatus(ctx, *change); err != nil {
+			scanner.Logf(ctx, "Failed to send host status notification for %s: %v", host.Name, err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"log"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/container-census/container-census/internal/sysinfo"
+)
+
+// Lite mode is a low-resource profile for Raspberry Pis and other small ARM boards: no
+// vulnerability scanning, shorter stats retention, fewer database writes and longer intervals
+const (
+	// liteMemoryThreshold is the total memory below which an ARM device runs in lite mode
+	liteMemoryThreshold = 2 << 30 // 2 GiB
+	// liteMinScanInterval is the shortest scan interval in seconds in lite mode
+	liteMinScanInterval = 300
+	// liteStatsRetentionDays is how long hourly stats are kept in lite mode
+	liteStatsRetentionDays = 7
+	// liteRedundantScanDays is the age at which redundant scans are removed in lite mode
+	liteRedundantScanDays = 2
+)
+
+// liteMode is set once at startup, see detectLiteMode
+var liteMode bool
+
+// detectLiteMode reports whether to run in lite mode and why. LITE_MODE=true or false forces
+// it on or off; by default (LITE_MODE=auto) it is on for ARM devices with less than 2 GiB.
+func detectLiteMode() (bool, string) {
+	switch strings.ToLower(os.Getenv("LITE_MODE")) {
+	case "true", "1", "yes":
+		return true, "LITE_MODE"
+	case "false", "0", "no":
+		return false, ""
+	}
+
+	if runtime.GOARCH != "arm" && runtime.GOARCH != "arm64" {
+		return false, ""
+	}
+	memTotal, err := sysinfo.MemTotal()
+	if err != nil {
+		log.Printf("Warning: Failed to read total memory for lite mode detection: %v", err)
+		return false, ""
+	}
+	if memTotal < liteMemoryThreshold {
+		return true, "low-memory " + runtime.GOARCH + " device"
+	}
+	return false, ""
+}
+
+// statsRetentionDays returns how many days of hourly stats to keep, 0 for forever.
+// STATS_RETENTION_DAYS overrides the default of lite mode.
+func statsRetentionDays() int {
+	if liteMode {
+		return getEnvInt("STATS_RETENTION_DAYS", liteStatsRetentionDays)
+	}
+	return getEnvInt("STATS_RETENTION_DAYS", 0)
+}
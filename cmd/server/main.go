@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -15,11 +17,21 @@ import (
 
 	"github.com/container-census/container-census/internal/api"
 	"github.com/container-census/container-census/internal/auth"
+	"github.com/container-census/container-census/internal/broker"
+	"github.com/container-census/container-census/internal/certs"
+	"github.com/container-census/container-census/internal/changelog"
+	"github.com/container-census/container-census/internal/containerops"
+	"github.com/container-census/container-census/internal/demo"
+	"github.com/container-census/container-census/internal/eventbus"
+	"github.com/container-census/container-census/internal/incus"
+	"github.com/container-census/container-census/internal/listen"
 	"github.com/container-census/container-census/internal/migration"
 	"github.com/container-census/container-census/internal/models"
 	"github.com/container-census/container-census/internal/notifications"
+	"github.com/container-census/container-census/internal/plugins"
 	"github.com/container-census/container-census/internal/registry"
 	"github.com/container-census/container-census/internal/scanner"
+	"github.com/container-census/container-census/internal/scripting"
 	"github.com/container-census/container-census/internal/storage"
 	"github.com/container-census/container-census/internal/telemetry"
 	"github.com/container-census/container-census/internal/version"
@@ -37,6 +49,12 @@ var (
 var (
 	notificationServiceGlobal       *notifications.NotificationService
 	vulnerabilitySchedulerGlobal    *vulnerability.Scheduler
+	pluginRunnerGlobal              *plugins.Runner
+	scriptEngineGlobal              *scripting.Engine
+	changelogFetcherGlobal          *changelog.Fetcher
+	containerOpsGlobal              *containerops.Tracker
+	queryCacheGlobal                *api.QueryCache
+	eventBusGlobal                  *eventbus.Bus
 )
 
 // serviceRefs holds references to services that need hot-reload
@@ -106,10 +124,10 @@ func reloadSettings() error {
 
 	// Update notification service settings if it exists
 	if services.notificationService != nil {
-		// Note: Notification service settings are loaded from environment variables on startup
-		// and cannot be hot-reloaded. This would require refactoring the notification service
-		// to support UpdateSettings() method. For now, log a warning.
-		log.Printf("Note: Notification settings require restart to take effect")
+		notificationSettings := notificationSettingsWithEnv(settings.Notification)
+		services.notificationService.UpdateSettings(notificationSettings)
+		log.Printf("✓ Notification settings updated (rate limit: %d/hour, batch interval: %ds, threshold duration: %ds)",
+			notificationSettings.RateLimitMax, notificationSettings.RateLimitBatchInterval, notificationSettings.ThresholdDuration)
 	}
 
 	log.Println("✅ Settings reloaded successfully")
@@ -123,6 +141,11 @@ func getScanInterval() int {
 }
 
 func setScanInterval(val int) {
+	if liteMode && val < liteMinScanInterval {
+		log.Printf("Lite mode: raising scan interval from %ds to %ds", val, liteMinScanInterval)
+		val = liteMinScanInterval
+	}
+
 	scanIntervalMu.Lock()
 	scanIntervalValue = val
 	scanIntervalMu.Unlock()
@@ -149,8 +172,16 @@ func main() {
 		log.Fatalf("Failed to create database directory: %v", err)
 	}
 
+	// Lite mode has to be known before the database is opened
+	var liteReason string
+	liteMode, liteReason = detectLiteMode()
+	if liteMode {
+		log.Printf("Lite mode enabled (%s): vulnerability scanning off, scan interval at least %ds, %d days of stats",
+			liteReason, liteMinScanInterval, statsRetentionDays())
+	}
+
 	// Initialize database
-	db, err := storage.New(dbPath)
+	db, err := storage.NewWithOptions(dbPath, storage.Options{LowWrite: liteMode})
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
@@ -160,8 +191,17 @@ func main() {
 	// Store database reference for hot-reload
 	services.db = db
 
-	// Auto-import YAML config on first run (if config file exists)
-	if db.IsFirstRun() {
+	// Canary rollouts run in the server; those cut off by the last shutdown won't continue
+	if n, err := db.InterruptCanaryUpdates(); err != nil {
+		log.Printf("Failed to mark interrupted canary rollouts: %v", err)
+	} else if n > 0 {
+		log.Printf("Marked %d canary rollouts interrupted by the last shutdown", n)
+	}
+
+	demoMode := isDemoMode()
+
+	// Auto-import YAML config on first run (if config file exists); its hosts would replace the demo hosts
+	if db.IsFirstRun() && !demoMode {
 		configPath := os.Getenv("CONFIG_PATH")
 		if configPath == "" {
 			configPath = "./config/config.yaml"
@@ -186,6 +226,11 @@ func main() {
 		log.Printf("Warning: Failed to initialize default notifications: %v", err)
 	}
 
+	// Demo mode fills an empty database with synthetic hosts and history instead of scanning real hosts
+	if demoMode {
+		seedDemoData(db)
+	}
+
 	// Initialize default telemetry endpoints (community collector)
 	if err := db.InitializeDefaultTelemetryEndpoints(); err != nil {
 		log.Printf("Warning: Failed to initialize default telemetry endpoints: %v", err)
@@ -195,12 +240,25 @@ func main() {
 	scan := scanner.New(settings.Scanner.TimeoutSeconds)
 	log.Println("Scanner initialized")
 
+	// Agents behind NATS or MQTT brokers need TLS unless plaintext is allowed
+	if isBrokerPlaintextAllowed() {
+		log.Println("WARNING: BROKER_ALLOW_PLAINTEXT is set: agent tokens may cross the network unencrypted")
+		broker.SetAllowPlaintext(true)
+	}
+
+	// Client certificate for Incus/LXD hosts, created on first start
+	if cert, err := incus.LoadOrCreateCertificate(filepath.Join(dbDir, "incus")); err != nil {
+		log.Printf("Warning: Incus hosts can't be scanned: %v", err)
+	} else {
+		scan.SetIncusCertificate(cert)
+	}
+
 	// Store scanner reference for hot-reload
 	services.scanner = scan
 
 	// Initialize scan interval (from database settings)
 	setScanInterval(settings.Scanner.IntervalSeconds)
-	log.Printf("Scan interval set to %d seconds", settings.Scanner.IntervalSeconds)
+	log.Printf("Scan interval set to %d seconds", getScanInterval())
 
 	// Get authentication config from environment variables
 	authConfig := getAuthConfigFromEnv()
@@ -217,6 +275,9 @@ func main() {
 		log.Println("WARNING: Using auto-generated SESSION_SECRET. Set SESSION_SECRET environment variable for production.")
 	}
 	auth.InitSessionStore(sessionSecret)
+	if basePath := api.NormalizeBasePath(os.Getenv("BASE_PATH")); basePath != "" {
+		auth.SetCookiePath(basePath + "/")
+	}
 
 	// Get server host and port from environment variables
 	serverHost := os.Getenv("SERVER_HOST")
@@ -229,16 +290,49 @@ func main() {
 	}
 
 	apiServer := api.New(db, scan, settings.Scanner.IntervalSeconds, authConfig)
+	apiServer.SetProxyConfig(getProxyConfigFromEnv())
+	if isReadOnlyForced() {
+		log.Println("Read-only mode forced by READ_ONLY: Docker operations are disabled")
+		apiServer.SetReadOnly(true)
+	}
+	apiServer.SetLiteMode(liteMode)
 	apiServer.SetScanIntervalCallback(setScanInterval) // Allow API to update scan interval dynamically
 	apiServer.SetReloadSettingsCallback(reloadSettings) // Allow API to trigger hot-reload
 	addr := fmt.Sprintf("%s:%s", serverHost, serverPort)
+	// SERVER_LISTEN replaces SERVER_HOST/SERVER_PORT with a list of addresses, e.g.
+	// "0.0.0.0:8080,[::]:8080,unix:/run/census/census.sock" or "systemd"
+	listenAddrs := []string{addr}
+	if value := os.Getenv("SERVER_LISTEN"); value != "" {
+		listenAddrs = listen.ParseList(value)
+	}
 
 	// Store API server reference for hot-reload
 	services.apiServer = apiServer
+	containerOpsGlobal = apiServer.Operations()
+	queryCacheGlobal = apiServer.Cache()
+
+	// Load collector plugins (executables in PLUGINS_DIR, run after every host scan)
+	if pluginsDir := os.Getenv("PLUGINS_DIR"); pluginsDir != "" {
+		collectors, err := plugins.Discover(pluginsDir)
+		if err != nil {
+			log.Printf("Warning: Failed to load plugins from %s: %v", pluginsDir, err)
+		} else {
+			timeout := time.Duration(getEnvInt("PLUGIN_TIMEOUT_SECONDS", int(plugins.DefaultTimeout.Seconds()))) * time.Second
+			pluginRunnerGlobal = plugins.NewRunner(db, collectors, timeout)
+			apiServer.SetPluginRunner(pluginRunnerGlobal)
+			log.Printf("Loaded %d collector plugins from %s (timeout: %v)", len(collectors), pluginsDir, timeout)
+		}
+	}
+
+	// Fetch upstream release notes (GitHub releases) when image updates are detected
+	if changelogs := os.Getenv("CHANGELOG_FETCH"); changelogs != "false" && changelogs != "0" && !demoMode {
+		changelogFetcherGlobal = changelog.NewFetcher(db, os.Getenv("GITHUB_TOKEN"))
+		apiServer.SetChangelogFetcher(changelogFetcherGlobal)
+	}
 
 	server := &http.Server{
 		Addr:         addr,
-		Handler:      apiServer.Router(),
+		Handler:      apiServer.Handler(),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
@@ -248,7 +342,11 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	go runPeriodicScans(ctx, db, scan, settings.Scanner.IntervalSeconds)
+	if demoMode {
+		log.Println("Demo mode: periodic scans disabled")
+	} else {
+		go runPeriodicScans(ctx, db, scan, settings.Scanner.IntervalSeconds)
+	}
 
 	// Start telemetry scheduler if any endpoint is enabled
 	endpoints, err := db.GetTelemetryEndpoints()
@@ -290,15 +388,25 @@ func main() {
 	go runDailyVersionCheck(ctx)
 
 	// Start daily database cleanup
-	go runDailyDatabaseCleanup(ctx, db)
+	go runDailyDatabaseCleanup(ctx, db, statsRetentionDays())
 
 	// Start hourly stats aggregation
 	go runHourlyStatsAggregation(ctx, db)
 
+	// Start daily environment snapshots (used for long-range changes diffs)
+	go runDailyEnvironmentSnapshot(ctx, db, getEnvInt("SNAPSHOT_RETENTION_DAYS", 365))
+
+	// Start the event bus (scan, container and update events delivered to event subscriptions)
+	eventBusGlobal = eventbus.New(db, time.Duration(getEnvInt("EVENT_RETENTION_DAYS", 7))*24*time.Hour)
+	apiServer.SetEventBus(eventBusGlobal)
+	go eventBusGlobal.Run(ctx)
+
 	// Initialize notification system (settings from database, with env var overrides)
-	maxNotificationsPerHour := getEnvInt("NOTIFICATION_RATE_LIMIT_MAX", settings.Notification.RateLimitMax)
-	batchIntervalSeconds := getEnvInt("NOTIFICATION_RATE_LIMIT_BATCH_INTERVAL", settings.Notification.RateLimitBatchInterval)
+	notificationSettings := notificationSettingsWithEnv(settings.Notification)
+	maxNotificationsPerHour := notificationSettings.RateLimitMax
+	batchIntervalSeconds := notificationSettings.RateLimitBatchInterval
 	notificationService := notifications.NewNotificationService(db, maxNotificationsPerHour, time.Duration(batchIntervalSeconds)*time.Second)
+	notificationService.UpdateSettings(notificationSettings)
 	notificationServiceGlobal = notificationService // Set global reference for scanner
 	services.notificationService = notificationService // Store for hot-reload
 	log.Printf("Notification service initialized (rate limit: %d/hour, batch interval: %ds)", maxNotificationsPerHour, batchIntervalSeconds)
@@ -306,6 +414,19 @@ func main() {
 	// Pass notification service to API server
 	apiServer.SetNotificationService(notificationService)
 
+	// Load event scripts (Lua files in SCRIPTS_DIR/<event>/, run on scan events)
+	if scriptsDir := os.Getenv("SCRIPTS_DIR"); scriptsDir != "" {
+		timeout := time.Duration(getEnvInt("SCRIPT_TIMEOUT_SECONDS", int(scripting.DefaultTimeout.Seconds()))) * time.Second
+		engine, err := scripting.Load(scriptsDir, timeout, notificationService, db)
+		if err != nil {
+			log.Printf("Warning: Failed to load event scripts from %s: %v", scriptsDir, err)
+		} else {
+			scriptEngineGlobal = engine
+			apiServer.SetScriptEngine(engine)
+			log.Printf("Loaded %d event scripts from %s (timeout: %v)", engine.Count(), scriptsDir, timeout)
+		}
+	}
+
 	// Start baseline stats collector
 	baselineCollector := notifications.NewBaselineCollector(db)
 	go baselineCollector.StartPeriodicUpdates(ctx)
@@ -314,15 +435,59 @@ func main() {
 	// Start hourly notification cleanup
 	go runHourlyNotificationCleanup(ctx, db)
 
+	// Start daily idle container digest (delivered to rules subscribed to idle_containers)
+	go runDailyIdleDigest(ctx, db, notificationService)
+
+	// Start daily CIS Docker Benchmark audit of all hosts (demo hosts cannot be reached)
+	if !demoMode {
+		go runDailyComplianceAudit(ctx, apiServer)
+	}
+
+	// Start daily security score recording (the trend of the security scores)
+	go runDailySecurityScores(ctx, apiServer)
+
+	// Start Uptime Kuma monitor sync (checks settings every minute, syncs when enabled)
+	go runUptimeKumaSync(ctx, db, apiServer)
+
+	// Start Proxmox VE host mapping (runs only while the integration is enabled)
+	go runProxmoxSync(ctx, db, apiServer)
+
+	// Start DNS and certificate checks of the proxy routes' hostnames (delivered to rules
+	// subscribed to cert_expiring and dns_failure); ENDPOINT_CHECK_INTERVAL_HOURS=0 disables them
+	apiServer.SetCertWarningDays(getEnvInt("CERT_WARNING_DAYS", models.DefaultCertWarningDays))
+	if hours := getEnvInt("ENDPOINT_CHECK_INTERVAL_HOURS", 6); hours > 0 && !demoMode {
+		go runEndpointChecks(ctx, apiServer, time.Duration(hours)*time.Hour)
+	}
+
+	// Start scheduled agent token rotation; AGENT_TOKEN_MAX_AGE_DAYS=0 (the default) only
+	// reports token ages and leaves rotation to the UI
+	apiServer.SetAgentTokenPolicy(
+		time.Duration(getEnvInt("AGENT_TOKEN_MAX_AGE_DAYS", 0))*24*time.Hour,
+		time.Duration(getEnvInt("AGENT_TOKEN_OVERLAP_MINUTES", 60))*time.Minute)
+	if getEnvInt("AGENT_TOKEN_MAX_AGE_DAYS", 0) > 0 && !demoMode {
+		go runHourlyAgentTokenRotation(ctx, apiServer)
+	}
+
+	// Start hourly backup check (delivered to rules subscribed to backup_overdue)
+	go runHourlyBackupCheck(ctx, notificationService)
+
+	// Start daily memory leak trend analysis (delivered to rules subscribed to memory_leak)
+	go runDailyMemoryLeakCheck(ctx, notificationService)
+
 	// Initialize vulnerability scanner (check database settings only)
 	vulnConfig, err := db.LoadVulnerabilitySettings()
-	if err != nil {
+	if liteMode {
+		log.Println("Vulnerability scanning disabled in lite mode")
+	} else if err != nil {
 		log.Printf("Failed to load vulnerability settings from database: %v", err)
 		log.Println("Vulnerability scanning disabled")
 	} else if vulnConfig.GetEnabled() {
 		log.Printf("Loaded vulnerability settings from database (cache_dir: %s)", vulnConfig.GetCacheDir())
 
 		vulnScanner := vulnerability.NewScanner(vulnConfig, db)
+		vulnScanner.SetRemoteScanner(scanner.NewAgentVulnerabilityScanner(scan, func(imageID string) ([]models.Host, error) {
+			return imageHosts(db, imageID)
+		}))
 		vulnScheduler := vulnerability.NewScheduler(vulnScanner, vulnConfig)
 		vulnScheduler.Start()
 		log.Printf("Vulnerability scanner initialized (%d workers, auto-scan: %v)", vulnConfig.GetWorkerPoolSize(), vulnConfig.GetAutoScanNewImages())
@@ -334,7 +499,12 @@ func main() {
 		vulnerabilitySchedulerGlobal = vulnScheduler
 
 		// Start daily Trivy DB update
-		go runDailyTrivyDBUpdate(ctx, vulnScanner, vulnConfig)
+		go runDailyTrivyDBUpdate(ctx, db, vulnScanner, vulnConfig)
+
+		// Rescan the images of running containers after manual database updates too
+		apiServer.SetTrivyDBUpdatedCallback(func() {
+			rescanAfterTrivyDBUpdate(ctx, db, vulnScanner, vulnConfig)
+		})
 
 		// Start daily vulnerability cleanup
 		go runDailyVulnerabilityCleanup(ctx, db, vulnConfig)
@@ -342,16 +512,49 @@ func main() {
 		log.Println("Vulnerability scanning disabled")
 	}
 
-	// Start image update checker
-	go runImageUpdateChecker(ctx, db, scan, notificationService)
+	// Start image update checker (demo hosts cannot be reached)
+	if !demoMode {
+		go runImageUpdateChecker(ctx, db, scan, notificationService)
+	}
 
-	// Start HTTP server
-	go func() {
-		log.Printf("Server listening on http://%s", addr)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
-		}
-	}()
+	// Built-in HTTPS from certificate files or ACME (optional)
+	tlsConfig, challengeServer, err := setupTLS(ctx, filepath.Dir(dbPath))
+	if err != nil {
+		log.Fatalf("Failed to configure TLS: %v", err)
+	}
+	server.TLSConfig = tlsConfig
+	if challengeServer != nil {
+		go func() {
+			log.Printf("ACME challenge server listening on http://%s", challengeServer.Addr)
+			if err := challengeServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Failed to start ACME challenge server: %v", err)
+			}
+		}()
+	}
+
+	// Start HTTP server on every listen address
+	listeners, err := listen.Listen(listenAddrs)
+	if err != nil {
+		log.Fatalf("Failed to start server: %v", err)
+	}
+	scheme := "http"
+	if server.TLSConfig != nil {
+		scheme = "https"
+	}
+	for _, l := range listeners {
+		log.Printf("Server listening on %s", listen.URL(l, scheme))
+		go func(l net.Listener) {
+			var err error
+			if server.TLSConfig != nil {
+				err = server.ServeTLS(l, "", "")
+			} else {
+				err = server.Serve(l)
+			}
+			if err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Failed to start server: %v", err)
+			}
+		}(l)
+	}
 
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
@@ -369,10 +572,53 @@ func main() {
 	if err := server.Shutdown(shutdownCtx); err != nil {
 		log.Printf("Server forced to shutdown: %v", err)
 	}
+	if challengeServer != nil {
+		challengeServer.Shutdown(shutdownCtx)
+	}
 
 	log.Println("Server stopped")
 }
 
+// isDemoMode reports whether DEMO_MODE is set
+func isDemoMode() bool {
+	demoMode := os.Getenv("DEMO_MODE")
+	return demoMode == "true" || demoMode == "1" || demoMode == "yes"
+}
+
+// isReadOnlyForced reports whether READ_ONLY is set, which keeps read-only mode on regardless
+// of the setting
+func isReadOnlyForced() bool {
+	readOnly := os.Getenv("READ_ONLY")
+	return readOnly == "true" || readOnly == "1" || readOnly == "yes"
+}
+
+// isBrokerPlaintextAllowed reports whether BROKER_ALLOW_PLAINTEXT is set
+func isBrokerPlaintextAllowed() bool {
+	allow := os.Getenv("BROKER_ALLOW_PLAINTEXT")
+	return allow == "true" || allow == "1" || allow == "yes"
+}
+
+// seedDemoData loads the synthetic demo environment, unless the database already has hosts
+func seedDemoData(db *storage.DB) {
+	hosts, err := db.GetHosts()
+	if err != nil {
+		log.Printf("Warning: Failed to check for existing hosts: %v", err)
+		return
+	}
+	if len(hosts) > 0 {
+		log.Printf("Demo mode: database already has %d hosts, not adding demo data", len(hosts))
+		return
+	}
+
+	log.Println("Demo mode: generating synthetic hosts, containers and history...")
+	data := demo.Generate(demo.DefaultOptions())
+	if err := demo.Seed(db, data); err != nil {
+		log.Printf("Warning: Failed to load demo data: %v", err)
+		return
+	}
+	log.Printf("Demo mode: loaded %d hosts, %d scans and %d image vulnerability scans", len(data.Hosts), len(data.Scans), len(data.Vulnerabilities))
+}
+
 // getAuthConfigFromEnv loads authentication config from environment variables
 func getAuthConfigFromEnv() auth.Config {
 	authEnabled := os.Getenv("AUTH_ENABLED")
@@ -391,6 +637,87 @@ func getAuthConfigFromEnv() auth.Config {
 	}
 }
 
+// getProxyConfigFromEnv reads the reverse proxy settings: BASE_PATH serves the UI and API under
+// a sub-path, TRUSTED_PROXIES lists the proxies whose X-Forwarded-* headers are honored
+func getProxyConfigFromEnv() api.ProxyConfig {
+	cfg := api.ProxyConfig{BasePath: api.NormalizeBasePath(os.Getenv("BASE_PATH"))}
+	if cfg.BasePath != "" {
+		log.Printf("Serving under base path %s", cfg.BasePath)
+	}
+
+	proxies, err := api.ParseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		log.Printf("Warning: Ignoring TRUSTED_PROXIES: %v", err)
+		return cfg
+	}
+	cfg.TrustedProxies = proxies
+	if len(proxies) > 0 {
+		log.Printf("Trusting X-Forwarded-* headers from %d proxy range(s)", len(proxies))
+	}
+	return cfg
+}
+
+// setupTLS returns the TLS configuration for built-in HTTPS, or nil to serve plain HTTP.
+// TLS_CERT_FILE/TLS_KEY_FILE serve a certificate from files (reloaded when they change);
+// ACME_DOMAINS obtains and renews one from Let's Encrypt (or ACME_DIRECTORY_URL). With the
+// http-01 challenge, the returned server answers challenges on ACME_HTTP_ADDR and redirects
+// other requests to HTTPS.
+func setupTLS(ctx context.Context, dataDir string) (*tls.Config, *http.Server, error) {
+	certFile, keyFile := os.Getenv("TLS_CERT_FILE"), os.Getenv("TLS_KEY_FILE")
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return nil, nil, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must both be set")
+		}
+		source, err := certs.NewFileSource(certFile, keyFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		return source.TLSConfig(), nil, nil
+	}
+
+	var domains []string
+	for _, domain := range strings.Split(os.Getenv("ACME_DOMAINS"), ",") {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	if len(domains) == 0 {
+		return nil, nil, nil
+	}
+
+	cacheDir := os.Getenv("ACME_CACHE_DIR")
+	if cacheDir == "" {
+		cacheDir = filepath.Join(dataDir, "acme")
+	}
+	manager, err := certs.NewACMEManager(certs.ACMEConfig{
+		Domains:      domains,
+		Email:        os.Getenv("ACME_EMAIL"),
+		DirectoryURL: os.Getenv("ACME_DIRECTORY_URL"),
+		CacheDir:     cacheDir,
+		Challenge:    os.Getenv("ACME_CHALLENGE"),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	go manager.Run(ctx)
+	log.Printf("HTTPS certificate for %s managed with ACME (%s challenge)", strings.Join(domains, ", "), manager.Challenge())
+
+	var challengeServer *http.Server
+	if manager.Challenge() == certs.ChallengeHTTP01 {
+		httpAddr := os.Getenv("ACME_HTTP_ADDR")
+		if httpAddr == "" {
+			httpAddr = ":80"
+		}
+		challengeServer = &http.Server{
+			Addr:         httpAddr,
+			Handler:      manager.HTTPHandler(),
+			ReadTimeout:  15 * time.Second,
+			WriteTimeout: 15 * time.Second,
+		}
+	}
+	return manager.TLSConfig(), challengeServer, nil
+}
+
 // detectHostType determines the host type from its address
 func detectHostType(address string) string {
 	switch {
@@ -402,12 +729,16 @@ func detectHostType(address string) string {
 		return "agent"
 	case len(address) >= 8 && address[:8] == "https://":
 		return "agent"
+	case broker.IsAddress(address):
+		return "agent"
 	case len(address) >= 7 && address[:7] == "unix://":
 		return "unix"
 	case len(address) >= 6 && address[:6] == "tcp://":
 		return "tcp"
 	case len(address) >= 6 && address[:6] == "ssh://":
 		return "ssh"
+	case incus.IsAddress(address):
+		return models.HostTypeIncus
 	default:
 		return "unknown"
 	}
@@ -448,55 +779,124 @@ func performScan(ctx context.Context, db *storage.DB, scan *scanner.Scanner) {
 		return
 	}
 
+	// Scans in a row before a host is reported down or recovered
+	downAfter, upAfter := models.DefaultHostDownAfterFailures, models.DefaultHostRecoveredAfterSuccesses
+	inventoryObjects := false
+	if settings, err := db.LoadSystemSettings(); err == nil {
+		downAfter, upAfter = settings.Notification.DownAfterFailures(), settings.Notification.RecoveredAfterSuccesses()
+		inventoryObjects = settings.Scanner.InventoryObjects
+	}
+	if !inventoryObjects {
+		if err := db.DeleteDockerObjects(); err != nil {
+			log.Printf("Failed to clear the object inventory: %v", err)
+		}
+	}
+
+	// Lite mode saves the scans of all hosts in one transaction after the last one
+	var liteScans []liteHostScan
+
 	for _, host := range hosts {
 		if !host.Enabled {
 			continue
 		}
 
+		// Don't record containers halfway through a start, stop or update; the next scan catches up
+		busy, version := containerOpsGlobal.HostState(host.ID)
+		if busy {
+			log.Printf("Skipping scan of host %s: container operation in progress", host.Name)
+			continue
+		}
+
+		// The scan ID goes with the agent requests, container rows, scan result and log lines
+		scanID := scanner.NewScanID()
+		scanCtx := scanner.WithScanID(ctx, scanID)
 		result := models.ScanResult{
+			ScanID:    scanID,
 			HostID:    host.ID,
 			HostName:  host.Name,
 			StartedAt: time.Now(),
 		}
 
-		containers, err := scan.ScanHost(ctx, host)
+		containers, err := scan.ScanHost(scanCtx, host)
 		result.CompletedAt = time.Now()
+		recordHostHeartbeat(scanCtx, db, host, result.StartedAt, err, downAfter, upAfter)
+		var previous []models.Container
+
+		if err == nil {
+			if busy, after := containerOpsGlobal.HostState(host.ID); busy || after != version {
+				scanner.Logf(scanCtx, "Discarding scan of host %s: container operation ran during the scan", host.Name)
+				continue
+			}
+		}
 
 		if err != nil {
 			result.Success = false
 			result.Error = err.Error()
-			log.Printf("Scan failed for host %s: %v", host.Name, err)
+			scanner.Logf(scanCtx, "Scan failed for host %s: %v", host.Name, err)
+
+			if scriptEngineGlobal != nil {
+				go scriptEngineGlobal.Fire(ctx, scripting.Event{Event: scripting.EventScanFailed, Host: host, Error: err.Error()})
+			}
 
 			// Update agent status if this is an auth failure
 			if host.HostType == "agent" && strings.Contains(err.Error(), "401") {
 				host.AgentStatus = "auth_failed"
 				if updateErr := db.UpdateHost(host); updateErr != nil {
-					log.Printf("Failed to update host status for %s: %v", host.Name, updateErr)
+					scanner.Logf(scanCtx, "Failed to update host status for %s: %v", host.Name, updateErr)
 				}
-			} else if host.HostType == "agent" {
-				// Other failure - mark as offline
+			} else if host.HostType == "agent" || host.AgentStatus != "offline" {
+				// Other failure - mark as offline (the local host and other direct hosts too)
 				host.AgentStatus = "offline"
 				if updateErr := db.UpdateHost(host); updateErr != nil {
-					log.Printf("Failed to update host status for %s: %v", host.Name, updateErr)
+					scanner.Logf(scanCtx, "Failed to update host status for %s: %v", host.Name, updateErr)
 				}
 			}
 		} else {
 			result.Success = true
 			result.ContainersFound = len(containers)
-			log.Printf("Scan completed for host %s: found %d containers", host.Name, len(containers))
+			result.Warnings = models.CollectionWarnings(containers)
+			scanner.Logf(scanCtx, "Scan completed for host %s: found %d containers", host.Name, len(containers))
+			if len(result.Warnings) > 0 {
+				scanner.Logf(scanCtx, "Scan of host %s had %d collection warnings", host.Name, len(result.Warnings))
+			}
 
-			// Update agent status to online on successful scan
-			if host.HostType == "agent" && host.AgentStatus != "online" {
+			// Update host status to online on successful scan
+			if host.AgentStatus != "online" {
 				host.AgentStatus = "online"
 				host.LastSeen = time.Now()
 				if updateErr := db.UpdateHost(host); updateErr != nil {
-					log.Printf("Failed to update host status for %s: %v", host.Name, updateErr)
+					scanner.Logf(scanCtx, "Failed to update host status for %s: %v", host.Name, updateErr)
 				}
 			}
 
+			// The host's containers at the previous scan, for event scripts and the event bus
+			var appeared []models.Container
+			if scriptEngineGlobal != nil || eventBusGlobal != nil {
+				previous = previousContainers(db, host.ID)
+			}
+			if scriptEngineGlobal != nil {
+				appeared = appearedContainers(previous, containers)
+			}
+
 			// Save containers
-			if err := db.SaveContainers(containers); err != nil {
-				log.Printf("Failed to save containers for host %s: %v", host.Name, err)
+			if !liteMode {
+				if err := db.SaveContainers(containers); err != nil {
+					scanner.Logf(scanCtx, "Failed to save containers for host %s: %v", host.Name, err)
+				}
+				queryCacheGlobal.Invalidate()
+			}
+
+			if len(appeared) > 0 {
+				go func(host models.Host, appeared []models.Container) {
+					for i := range appeared {
+						scriptEngineGlobal.Fire(ctx, scripting.Event{Event: scripting.EventContainerAppeared, Host: host, Container: &appeared[i]})
+					}
+				}(host, appeared)
+			}
+
+			// Run collector plugins against the scanned containers
+			if pluginRunnerGlobal != nil {
+				pluginRunnerGlobal.Run(ctx, host, containers)
 			}
 
 			// Queue unique images for vulnerability scanning
@@ -504,21 +904,122 @@ func performScan(ctx context.Context, db *storage.DB, scan *scanner.Scanner) {
 				queueImagesForScanning(containers, host.ID, db)
 			}
 
-			// Process notifications for this host
-			if notificationServiceGlobal != nil {
-				if err := notificationServiceGlobal.ProcessEvents(ctx, host.ID); err != nil {
-					log.Printf("Failed to process notifications for host %s: %v", host.Name, err)
-				}
+			// Process notifications for this host (in lite mode once its containers are saved)
+			if !liteMode {
+				processHostNotifications(ctx, scanCtx, host)
+			}
+
+			// OOM kills and daemon errors from the agent's host logs
+			if host.HostType == "agent" {
+				collectDaemonEvents(scanCtx, db, scan, host)
+			}
+
+			// Plugins, Swarm secrets and configs, and builder cache
+			if inventoryObjects {
+				collectDockerObjects(scanCtx, db, scan, host)
 			}
 		}
 
 		// Save scan result
-		if _, err := db.SaveScanResult(result); err != nil {
-			log.Printf("Failed to save scan result for host %s: %v", host.Name, err)
+		if liteMode {
+			liteScans = append(liteScans, liteHostScan{ctx: scanCtx, host: host, version: version, result: result, containers: containers})
+		} else if _, err := db.SaveScanResult(result); err != nil {
+			scanner.Logf(scanCtx, "Failed to save scan result for host %s: %v", host.Name, err)
 		}
+		eventBusGlobal.Publish(eventbus.ScanEvents(host, result, previous, containers)...)
+	}
+	if liteMode {
+		saveLiteScans(ctx, db, liteScans)
+	}
+
+	// Containers of host migrations that now run on their destination
+	if n, err := db.SyncHostMigrations(); err != nil {
+		log.Printf("Failed to update host migrations: %v", err)
+	} else if n > 0 {
+		log.Printf("Host migrations: %d containers now run on their destination", n)
 	}
 }
 
+// liteHostScan is a host's scan held back in lite mode until every host is scanned
+type liteHostScan struct {
+	ctx        context.Context // carries the scan ID for log lines
+	host       models.Host
+	version    uint64 // the host's container operation version during the scan
+	result     models.ScanResult
+	containers []models.Container
+}
+
+// saveLiteScans saves the containers and scan results of all hosts in one transaction, then
+// processes the hosts' notifications. Containers of a host on which a container operation
+// started since its scan are discarded; the next scan catches up.
+func saveLiteScans(ctx context.Context, db *storage.DB, scans []liteHostScan) {
+	var containers []models.Container
+	var results []models.ScanResult
+	var saved []liteHostScan
+	for _, s := range scans {
+		if s.result.Success {
+			if busy, after := containerOpsGlobal.HostState(s.host.ID); busy || after != s.version {
+				scanner.Logf(s.ctx, "Discarding scan of host %s: container operation ran during the scan", s.host.Name)
+				continue
+			}
+			containers = append(containers, s.containers...)
+			saved = append(saved, s)
+		}
+		results = append(results, s.result)
+	}
+
+	if err := db.SaveScan(containers, results); err != nil {
+		log.Printf("Failed to save scan: %v", err)
+		return
+	}
+	queryCacheGlobal.Invalidate()
+	for _, s := range saved {
+		processHostNotifications(ctx, s.ctx, s.host)
+	}
+}
+
+// processHostNotifications processes the notification events of a host's saved scan
+func processHostNotifications(ctx, scanCtx context.Context, host models.Host) {
+	if notificationServiceGlobal == nil {
+		return
+	}
+	if err := notificationServiceGlobal.ProcessEvents(ctx, host.ID); err != nil {
+		scanner.Logf(scanCtx, "Failed to process notifications for host %s: %v", host.Name, err)
+	}
+}
+
+// previousContainers returns a host's containers at its latest scan, before the new scan is saved
+func previousContainers(db *storage.DB, hostID int64) []models.Container {
+	previous, err := db.GetContainersByHost(hostID)
+	if err != nil {
+		log.Printf("Failed to get previous containers for host %d: %v", hostID, err)
+		return nil
+	}
+	return previous
+}
+
+// appearedContainers returns the scanned containers whose names weren't on the host at its previous
+// scan. Nothing is reported for a host's first scan.
+func appearedContainers(previous, containers []models.Container) []models.Container {
+	if len(previous) == 0 {
+		return nil
+	}
+
+	// Renamed containers keep their ID and aren't new
+	known := make(map[string]bool, 2*len(previous))
+	for _, c := range previous {
+		known[c.Name] = true
+		known[c.ID] = true
+	}
+	var appeared []models.Container
+	for _, c := range containers {
+		if !known[c.Name] && !known[c.ID] {
+			appeared = append(appeared, c)
+		}
+	}
+	return appeared
+}
+
 // queueImagesForScanning queues unique images found in containers for vulnerability scanning
 func queueImagesForScanning(containers []models.Container, hostID int64, db *storage.DB) {
 	// Track unique images
@@ -551,6 +1052,31 @@ func queueImagesForScanning(containers []models.Container, hostID int64, db *sto
 	// Security tab to see actual scanning activity.
 }
 
+// imageHosts returns the hosts on which an image was recently seen, for agent vulnerability scans
+func imageHosts(db *storage.DB, imageID string) ([]models.Host, error) {
+	containers, err := db.GetContainersForImage(imageID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int]bool)
+	hosts := make([]models.Host, 0)
+	for _, c := range containers {
+		if seen[c.HostID] {
+			continue
+		}
+		seen[c.HostID] = true
+
+		host, err := db.GetHost(int64(c.HostID))
+		if err != nil {
+			continue // Host was removed since the image was seen
+		}
+		hosts = append(hosts, *host)
+	}
+
+	return hosts, nil
+}
+
 // checkForUpdates checks for new versions and logs a warning if an update is available
 func checkForUpdates() {
 	info := version.CheckLatestVersion()
@@ -582,8 +1108,9 @@ func runDailyVersionCheck(ctx context.Context) {
 	}
 }
 
-// runDailyDatabaseCleanup performs database cleanup of redundant scans once per day
-func runDailyDatabaseCleanup(ctx context.Context, db *storage.DB) {
+// runDailyDatabaseCleanup performs database cleanup of redundant scans once per day, and removes
+// hourly stats older than statsRetentionDays (0 keeps them)
+func runDailyDatabaseCleanup(ctx context.Context, db *storage.DB, statsRetentionDays int) {
 	// Run first cleanup after 1 hour (let system stabilize)
 	time.Sleep(1 * time.Hour)
 
@@ -592,6 +1119,9 @@ func runDailyDatabaseCleanup(ctx context.Context, db *storage.DB) {
 
 	// Cleanup scans older than 7 days
 	cleanupOlderThan := 7
+	if liteMode {
+		cleanupOlderThan = liteRedundantScanDays
+	}
 
 	for {
 		select {
@@ -605,6 +1135,37 @@ func runDailyDatabaseCleanup(ctx context.Context, db *storage.DB) {
 			} else {
 				log.Printf("Database cleanup completed: removed %d redundant scan records", deleted)
 			}
+
+			if statsRetentionDays > 0 {
+				if deleted, err := db.CleanupOldStatsAggregates(statsRetentionDays); err != nil {
+					log.Printf("Stats retention cleanup failed: %v", err)
+				} else if deleted > 0 {
+					log.Printf("Stats retention cleanup completed: removed %d hourly stats older than %d days", deleted, statsRetentionDays)
+				}
+			}
+
+			heartbeatDays := hostHeartbeatRetentionDays
+			if liteMode {
+				heartbeatDays = liteStatsRetentionDays
+			}
+			if deleted, err := db.CleanupOldHostHeartbeats(heartbeatDays); err != nil {
+				log.Printf("Host heartbeat cleanup failed: %v", err)
+			} else if deleted > 0 {
+				log.Printf("Host heartbeat cleanup completed: removed %d heartbeats older than %d days", deleted, heartbeatDays)
+			}
+
+			if deleted, err := db.CleanupOldDaemonEvents(daemonEventRetentionDays); err != nil {
+				log.Printf("Daemon event cleanup failed: %v", err)
+			} else if deleted > 0 {
+				log.Printf("Daemon event cleanup completed: removed %d events older than %d days", deleted, daemonEventRetentionDays)
+			}
+
+			// Rows of deleted hosts and containers, and image mappings not seen for 30 days
+			if report, err := db.CollectOrphans(30, false); err != nil {
+				log.Printf("Orphaned data cleanup failed: %v", err)
+			} else if report.Total > 0 {
+				log.Printf("Orphaned data cleanup completed: removed %d rows %v", report.Total, report.Orphans)
+			}
 		}
 	}
 }
@@ -634,8 +1195,257 @@ func runHourlyStatsAggregation(ctx context.Context, db *storage.DB) {
 	}
 }
 
+// runDailyEnvironmentSnapshot persists a snapshot of the container inventory once per day
+// and removes snapshots older than the retention period
+func runDailyEnvironmentSnapshot(ctx context.Context, db *storage.DB, retentionDays int) {
+	// Take the first snapshot after 10 minutes so at least one scan has completed
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(10 * time.Minute):
+	}
+
+	takeSnapshot := func() {
+		snapshot, err := db.CreateEnvironmentSnapshot(time.Now())
+		if err != nil {
+			log.Printf("Environment snapshot failed: %v", err)
+			return
+		}
+		log.Printf("Environment snapshot for %s saved (%d containers on %d hosts)", snapshot.SnapshotDate, snapshot.ContainerCount, snapshot.HostCount)
+
+		if retentionDays > 0 {
+			if deleted, err := db.CleanupOldEnvironmentSnapshots(retentionDays); err != nil {
+				log.Printf("Environment snapshot cleanup failed: %v", err)
+			} else if deleted > 0 {
+				log.Printf("Removed %d environment snapshots older than %d days", deleted, retentionDays)
+			}
+		}
+	}
+
+	takeSnapshot()
+
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			takeSnapshot()
+		}
+	}
+}
+
+// runDailyIdleDigest reports likely idle containers once per day using the default criteria
+func runDailyIdleDigest(ctx context.Context, db *storage.DB, notifier *notifications.NotificationService) {
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := db.GetIdleContainers(storage.DefaultIdleContainerCriteria())
+			if err != nil {
+				log.Printf("Idle container detection failed: %v", err)
+				continue
+			}
+			if err := notifier.SendIdleDigest(ctx, report); err != nil {
+				log.Printf("Failed to send idle container digest: %v", err)
+			}
+		}
+	}
+}
+
+// runDailyComplianceAudit audits every host against the CIS Docker Benchmark once per day
+func runDailyComplianceAudit(ctx context.Context, apiServer *api.Server) {
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			apiServer.AuditAllHosts(ctx)
+		}
+	}
+}
+
+// runDailySecurityScores records the security scores shortly after startup and then once per day
+func runDailySecurityScores(ctx context.Context, apiServer *api.Server) {
+	// Give the first scan and the vulnerability scheduler time to populate the database
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(10 * time.Minute):
+	}
+	apiServer.RecordSecurityScores()
+
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			apiServer.RecordSecurityScores()
+		}
+	}
+}
+
+// runUptimeKumaSync syncs Uptime Kuma monitors and ingests their status at the configured interval.
+// Settings are re-read every minute so enabling the integration or changing the interval needs no restart.
+func runUptimeKumaSync(ctx context.Context, db *storage.DB, apiServer *api.Server) {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	var lastSync time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			settings, err := db.GetUptimeKumaSettings()
+			if err != nil {
+				log.Printf("Failed to load Uptime Kuma settings: %v", err)
+				continue
+			}
+			if !settings.Enabled || time.Since(lastSync) < time.Duration(settings.SyncIntervalMinutes)*time.Minute {
+				continue
+			}
+
+			lastSync = time.Now()
+			result, err := apiServer.SyncUptimeKuma(ctx)
+			if err != nil {
+				log.Printf("Uptime Kuma sync failed: %v", err)
+				continue
+			}
+			for _, syncErr := range result.Errors {
+				log.Printf("Uptime Kuma sync: %s", syncErr)
+			}
+		}
+	}
+}
+
+// runProxmoxSync maps hosts to Proxmox VMs, containers and nodes at the configured interval.
+// Like runUptimeKumaSync, it re-reads the settings every minute.
+func runProxmoxSync(ctx context.Context, db *storage.DB, apiServer *api.Server) {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	var lastSync time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			settings, err := db.GetProxmoxSettings()
+			if err != nil {
+				log.Printf("Failed to load Proxmox settings: %v", err)
+				continue
+			}
+			if !settings.Enabled || time.Since(lastSync) < time.Duration(settings.SyncIntervalMinutes)*time.Minute {
+				continue
+			}
+
+			lastSync = time.Now()
+			result, err := apiServer.SyncProxmox(ctx)
+			if err != nil {
+				log.Printf("Proxmox sync failed: %v", err)
+				continue
+			}
+			for _, syncErr := range result.Errors {
+				log.Printf("Proxmox sync: %s", syncErr)
+			}
+		}
+	}
+}
+
+// runEndpointChecks checks the hostnames of the proxy routes at the interval, starting shortly
+// after startup once the first scans have found the routes
+func runEndpointChecks(ctx context.Context, apiServer *api.Server, interval time.Duration) {
+	timer := time.NewTimer(5 * time.Minute)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			alerts, err := apiServer.CheckEndpoints(ctx)
+			if err != nil {
+				log.Printf("Endpoint check failed: %v", err)
+			} else if len(alerts) > 0 {
+				log.Printf("Endpoint check raised %d alerts", len(alerts))
+			}
+			timer.Reset(interval)
+		}
+	}
+}
+
+// runHourlyAgentTokenRotation rotates the agent tokens older than the maximum age every hour
+func runHourlyAgentTokenRotation(ctx context.Context, apiServer *api.Server) {
+	ticker := time.NewTicker(1 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			rotated, failed := apiServer.RotateExpiredAgentTokens(ctx)
+			if rotated > 0 || failed > 0 {
+				log.Printf("Agent token rotation: %d rotated, %d failed", rotated, failed)
+			}
+		}
+	}
+}
+
+// runHourlyBackupCheck alerts about backup jobs that became overdue since the previous check
+func runHourlyBackupCheck(ctx context.Context, notifier *notifications.NotificationService) {
+	ticker := time.NewTicker(1 * time.Hour)
+	defer ticker.Stop()
+
+	lastCheck := time.Now().Add(-1 * time.Hour)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now()
+			if err := notifier.DetectOverdueBackups(ctx, lastCheck); err != nil {
+				log.Printf("Backup check failed: %v", err)
+				continue
+			}
+			lastCheck = now
+		}
+	}
+}
+
+// runDailyMemoryLeakCheck analyzes daily memory trends once per day and notifies about likely leaks
+func runDailyMemoryLeakCheck(ctx context.Context, notifier *notifications.NotificationService) {
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := notifier.DetectMemoryLeaks(ctx); err != nil {
+				log.Printf("Memory leak detection failed: %v", err)
+			}
+		}
+	}
+}
+
 // runHourlyNotificationCleanup performs notification log cleanup every hour
-// Removes old notifications based on 7-day retention and 100-notification limit
+// Removes old notifications based on the configured retention (notification.log_retention_days/count)
+// and notification counters older than models.NotificationStatsRetentionDays
 func runHourlyNotificationCleanup(ctx context.Context, db *storage.DB) {
 	// Run first cleanup after 1 hour
 	time.Sleep(1 * time.Hour)
@@ -651,10 +1461,21 @@ func runHourlyNotificationCleanup(ctx context.Context, db *storage.DB) {
 			if err := db.CleanupOldNotifications(); err != nil {
 				log.Printf("Notification cleanup failed: %v", err)
 			}
+			if err := db.CleanupNotificationStats(); err != nil {
+				log.Printf("Notification stats cleanup failed: %v", err)
+			}
 		}
 	}
 }
 
+// notificationSettingsWithEnv applies the NOTIFICATION_RATE_LIMIT_MAX and
+// NOTIFICATION_RATE_LIMIT_BATCH_INTERVAL overrides to the notification settings
+func notificationSettingsWithEnv(settings models.NotificationSettings) models.NotificationSettings {
+	settings.RateLimitMax = getEnvInt("NOTIFICATION_RATE_LIMIT_MAX", settings.RateLimitMax)
+	settings.RateLimitBatchInterval = getEnvInt("NOTIFICATION_RATE_LIMIT_BATCH_INTERVAL", settings.RateLimitBatchInterval)
+	return settings
+}
+
 // getEnvInt gets an integer from environment variable with a default value
 func getEnvInt(key string, defaultValue int) int {
 	if val := os.Getenv(key); val != "" {
@@ -666,8 +1487,9 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
-// runDailyTrivyDBUpdate updates the Trivy database daily
-func runDailyTrivyDBUpdate(ctx context.Context, scanner *vulnerability.Scanner, config *vulnerability.Config) {
+// runDailyTrivyDBUpdate updates the Trivy database daily, then rescans the images of running
+// containers for vulnerabilities the update added
+func runDailyTrivyDBUpdate(ctx context.Context, db *storage.DB, scanner *vulnerability.Scanner, config *vulnerability.Config) {
 	// Calculate time until next 2 AM
 	now := time.Now()
 	next2AM := time.Date(now.Year(), now.Month(), now.Day(), 2, 0, 0, 0, now.Location())
@@ -686,6 +1508,7 @@ func runDailyTrivyDBUpdate(ctx context.Context, scanner *vulnerability.Scanner,
 		log.Printf("Trivy database update failed: %v", err)
 	} else {
 		log.Println("Trivy database updated successfully")
+		rescanAfterTrivyDBUpdate(ctx, db, scanner, config)
 	}
 	cancel()
 
@@ -704,6 +1527,7 @@ func runDailyTrivyDBUpdate(ctx context.Context, scanner *vulnerability.Scanner,
 				log.Printf("Trivy database update failed: %v", err)
 			} else {
 				log.Println("Trivy database updated successfully")
+				rescanAfterTrivyDBUpdate(ctx, db, scanner, config)
 			}
 			cancel()
 		}
@@ -831,10 +1655,17 @@ func runImageUpdateChecker(ctx context.Context, db *storage.DB, scan *scanner.Sc
 				continue
 			}
 
-			// Filter to only running containers with :latest tag if configured
+			pins, err := db.GetContainerPins()
+			if err != nil {
+				log.Printf("Failed to get container pins for update check: %v", err)
+				continue
+			}
+
+			// Filter to only running, unpinned containers with :latest tag if configured
 			var toCheck []models.Container
 			for _, c := range containers {
-				if c.State != "running" {
+				// Containers without an image ID (Incus/LXD instances) have no registry image
+				if c.State != "running" || c.ImageID == "" || models.PinFor(c, pins) != nil {
 					continue
 				}
 
@@ -868,6 +1699,27 @@ func runImageUpdateChecker(ctx context.Context, db *storage.DB, scan *scanner.Sc
 					log.Printf("Failed to save update status for %s: %v", container.Name, err)
 					continue
 				}
+				if err := db.SaveUpdateLag(container.HostID, container.Name, container.ImageID, updateInfo.Available, updateInfo.RemoteCreated, time.Now()); err != nil {
+					log.Printf("Failed to save update lag for %s: %v", container.Name, err)
+				}
+
+				// Fetch release notes before notifications are processed, so they can link to them
+				if updateInfo.Available && !container.UpdateAvailable && changelogFetcherGlobal != nil {
+					if _, err := changelogFetcherGlobal.Resolve(ctx, container); err != nil {
+						log.Printf("Failed to fetch changelog for %s: %v", container.Image, err)
+					}
+				}
+
+				if updateInfo.Available && !container.UpdateAvailable {
+					if host, err := db.GetHost(container.HostID); err == nil && host != nil {
+						c := container
+						c.UpdateAvailable = true
+						eventBusGlobal.Publish(eventbus.UpdateAvailableEvent(*host, c))
+						if scriptEngineGlobal != nil {
+							go scriptEngineGlobal.Fire(ctx, scripting.Event{Event: scripting.EventUpdateAvailable, Host: *host, Container: &c})
+						}
+					}
+				}
 
 				if updateInfo.Available {
 					updateCount++
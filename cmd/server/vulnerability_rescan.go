@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/storage"
+	"github.com/container-census/container-census/internal/vulnerability"
+)
+
+// trivyDBRescanRunning keeps the scheduled and manual database updates from rescanning at once
+var trivyDBRescanRunning atomic.Bool
+
+// rescanAfterTrivyDBUpdate rescans the already-scanned images of running containers after a
+// vulnerability database update, so vulnerabilities the update adds to them are found now rather
+// than at their next scheduled rescan. Rules subscribed to new_vulnerability are notified of the
+// containers running an image with new critical findings (with alert_on_critical) or new high
+// ones (with alert_on_high).
+func rescanAfterTrivyDBUpdate(ctx context.Context, db *storage.DB, scanner *vulnerability.Scanner, config *vulnerability.Config) {
+	if !trivyDBRescanRunning.CompareAndSwap(false, true) {
+		log.Println("Skipping rescan after Trivy database update: one is already running")
+		return
+	}
+	defer trivyDBRescanRunning.Store(false)
+
+	containers, err := db.GetLatestContainers()
+	if err != nil {
+		log.Printf("Rescan after Trivy database update failed: %v", err)
+		return
+	}
+	running := make(map[string][]models.Container)
+	var imageIDs []string
+	for _, c := range containers {
+		if c.State != "running" || c.ImageID == "" {
+			continue
+		}
+		if _, ok := running[c.ImageID]; !ok {
+			imageIDs = append(imageIDs, c.ImageID)
+		}
+		running[c.ImageID] = append(running[c.ImageID], c)
+	}
+
+	var severities []string
+	if config.GetAlertOnCritical() {
+		severities = append(severities, "CRITICAL")
+	}
+	if config.GetAlertOnHigh() {
+		severities = append(severities, "HIGH")
+	}
+	exceptions, err := db.GetVulnerabilityExceptions()
+	if err != nil {
+		log.Printf("Failed to get vulnerability exceptions: %v", err)
+	}
+
+	var alerts []models.VulnerabilityAlert
+	rescanned := 0
+	for _, imageID := range imageIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		previous, err := db.GetVulnerabilityScan(imageID)
+		if err != nil || previous == nil || !previous.Success {
+			continue // never scanned successfully: the scheduler scans it
+		}
+		// Details are kept for the detailed retention only; without them nothing can be compared
+		previousVulns, err := db.GetVulnerabilities(imageID)
+		if err != nil || (len(previousVulns) == 0 && previous.TotalVulnerabilities > 0) {
+			continue
+		}
+
+		imageName := running[imageID][0].Image
+		result, err := scanner.ScanImage(ctx, imageID, imageName)
+		if err != nil {
+			log.Printf("Rescan of %s after Trivy database update failed: %v", imageName, err)
+			continue
+		}
+		rescanned++
+
+		findings := vulnerability.NewFindings(previousVulns, result.Vulnerabilities, severities, exceptions, imageName, time.Now())
+		if len(findings) == 0 {
+			continue
+		}
+		ids := make([]string, 0, len(findings))
+		counts := vulnerability.CalculateSeverityCounts(findings)
+		for _, v := range findings {
+			ids = append(ids, v.VulnerabilityID)
+		}
+		log.Printf("Trivy database update: %s has %d new vulnerabilities (%d running containers)", imageName, len(findings), len(running[imageID]))
+		for _, c := range running[imageID] {
+			alerts = append(alerts, models.VulnerabilityAlert{
+				HostID:           c.HostID,
+				HostName:         c.HostName,
+				ContainerID:      c.ID,
+				ContainerName:    c.Name,
+				Image:            c.Image,
+				ImageID:          imageID,
+				VulnerabilityIDs: ids,
+				Critical:         counts.Critical,
+				High:             counts.High,
+				TrivyDBVersion:   result.Scan.TrivyDBVersion,
+			})
+		}
+	}
+	log.Printf("Rescanned %d images after Trivy database update, %d containers affected by new vulnerabilities", rescanned, len(alerts))
+
+	if notificationServiceGlobal != nil {
+		if err := notificationServiceGlobal.SendVulnerabilityAlerts(ctx, alerts); err != nil {
+			log.Printf("Failed to send new vulnerability notifications: %v", err)
+		}
+	}
+}
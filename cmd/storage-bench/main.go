@@ -0,0 +1,121 @@
+// storage-bench simulates hosts × containers × days of scans against the storage layer and
+// reports the database size and the latency of writes, maintenance and the queries behind the
+// main API endpoints. With -max-write-p95 or -max-query-p95 it exits 1 when a p95 is over the
+// limit, so CI can catch storage regressions before a release.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/container-census/container-census/internal/soak"
+	"github.com/container-census/container-census/internal/storage"
+)
+
+func main() {
+	opts := soak.DefaultOptions()
+	flag.IntVar(&opts.Hosts, "hosts", opts.Hosts, "Simulated hosts")
+	flag.IntVar(&opts.Containers, "containers", opts.Containers, "Containers per host")
+	flag.IntVar(&opts.Days, "days", opts.Days, "Days of scan history")
+	flag.DurationVar(&opts.Interval, "interval", opts.Interval, "Time between two scans of a host")
+	flag.Int64Var(&opts.Seed, "seed", opts.Seed, "Seed of the simulation; the same flags write the same data")
+	flag.IntVar(&opts.QueryRuns, "runs", opts.QueryRuns, "Times each query is measured")
+	dbPath := flag.String("db", "", "Database file to write (must not exist; default: a temporary file, removed afterwards)")
+	lowWrite := flag.Bool("low-write", false, "Open the database like lite mode (synchronous=NORMAL)")
+	jsonOutput := flag.Bool("json", false, "Print the report as JSON")
+	maxWriteP95 := flag.Duration("max-write-p95", 0, "Exit 1 when the p95 of SaveContainers is over this (0: no limit)")
+	maxQueryP95 := flag.Duration("max-query-p95", 0, "Exit 1 when the p95 of any query is over this (0: no limit)")
+	flag.Parse()
+	if err := opts.Validate(); err != nil {
+		log.Fatalf("Invalid options: %v", err)
+	}
+
+	path := *dbPath
+	if path == "" {
+		dir, err := os.MkdirTemp("", "census-storage-bench-*")
+		if err != nil {
+			log.Fatalf("Failed to create temporary directory: %v", err)
+		}
+		defer os.RemoveAll(dir)
+		path = filepath.Join(dir, "census.db")
+	} else if _, err := os.Stat(path); err == nil {
+		log.Fatalf("%s exists; the benchmark needs a new database", path)
+	}
+
+	db, err := storage.NewWithOptions(path, storage.Options{LowWrite: *lowWrite})
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("Simulating %d hosts × %d containers × %d days, scanned every %v...", opts.Hosts, opts.Containers, opts.Days, opts.Interval)
+	report, err := soak.Run(ctx, db, path, opts, func(day int) {
+		log.Printf("Day %d/%d written", day, opts.Days)
+	})
+	if err != nil {
+		log.Fatalf("Benchmark failed: %v", err)
+	}
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		printReport(report)
+	}
+
+	var failures []string
+	for _, m := range report.Writes {
+		if m.Name == "SaveContainers" && overLimit(m.Latency, *maxWriteP95) {
+			failures = append(failures, fmt.Sprintf("%s p95 %.1fms > %v", m.Name, m.Latency.P95, *maxWriteP95))
+		}
+	}
+	for _, m := range report.Queries {
+		if overLimit(m.Latency, *maxQueryP95) {
+			failures = append(failures, fmt.Sprintf("%s p95 %.1fms > %v", m.Name, m.Latency.P95, *maxQueryP95))
+		}
+	}
+	for _, f := range failures {
+		fmt.Fprintln(os.Stderr, "FAIL: "+f)
+	}
+	if len(failures) > 0 {
+		db.Close()
+		os.Exit(1)
+	}
+}
+
+// overLimit reports whether a p95 is over a limit; a zero limit is no limit
+func overLimit(l soak.Latency, limit time.Duration) bool {
+	return limit > 0 && l.P95 > float64(limit.Microseconds())/1000
+}
+
+func printReport(r *soak.Report) {
+	fmt.Printf("\n%d scans, %d container rows in %.1fs\n", r.Scans, r.ContainerRows, r.DurationSeconds)
+	fmt.Printf("Database: %.1f MB (+ %.1f MB WAL)\n\n", float64(r.DBBytes)/1e6, float64(r.WALBytes)/1e6)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	section := func(title string, measurements []soak.Measurement) {
+		fmt.Fprintf(w, "%s\tcount\tp50 ms\tp95 ms\tp99 ms\tmax ms\t\n", title)
+		for _, m := range measurements {
+			l := m.Latency
+			fmt.Fprintf(w, "%s\t%d\t%.2f\t%.2f\t%.2f\t%.2f\t\n", m.Name, l.Count, l.P50, l.P95, l.P99, l.Max)
+		}
+		fmt.Fprintln(w, "\t\t\t\t\t\t")
+	}
+	section("Writes", r.Writes)
+	section("Maintenance", r.Maintenance)
+	section("Queries", r.Queries)
+	w.Flush()
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/notifications/channels"
+)
+
+// eventTypeIngestAlert is the event type of the collector's own alerts in webhook payloads
+const eventTypeIngestAlert = "ingest_alert"
+
+// baselineWindow is how far back the expected ingest volume is averaged
+const baselineWindow = 7 * 24 * time.Hour
+
+// AlertConfig configures the collector's alerts on its own ingest health
+type AlertConfig struct {
+	WebhookURL         string
+	NtfyServer         string
+	NtfyTopic          string
+	NtfyToken          string
+	CheckInterval      time.Duration
+	VolumeDropPercent  int // alert when a window has this much fewer reports than expected
+	VolumeSpikePercent int // alert when a window has this percentage of the expected reports or more
+	ErrorRatePercent   int // alert when this percentage of ingest requests fail
+	MinReports         int // expected reports (or requests, for the error rate) below which nothing is judged
+}
+
+// loadAlertConfig reads the alert configuration from the environment
+func loadAlertConfig() AlertConfig {
+	return AlertConfig{
+		WebhookURL:         getEnv("ALERT_WEBHOOK_URL", ""),
+		NtfyServer:         getEnv("ALERT_NTFY_SERVER", "https://ntfy.sh"),
+		NtfyTopic:          getEnv("ALERT_NTFY_TOPIC", ""),
+		NtfyToken:          getEnv("ALERT_NTFY_TOKEN", ""),
+		CheckInterval:      time.Duration(getEnvInt("ALERT_CHECK_MINUTES", 60)) * time.Minute,
+		VolumeDropPercent:  getEnvInt("ALERT_VOLUME_DROP_PERCENT", 50),
+		VolumeSpikePercent: getEnvInt("ALERT_VOLUME_SPIKE_PERCENT", 300),
+		ErrorRatePercent:   getEnvInt("ALERT_ERROR_RATE_PERCENT", 10),
+		MinReports:         getEnvInt("ALERT_MIN_REPORTS", 10),
+	}
+}
+
+// ingestWindow is what the collector saw in one check interval
+type ingestWindow struct {
+	Reports  int     // reports saved
+	Expected float64 // average reports per interval over the baseline window, 0 without enough history
+	Requests int     // ingest requests answered
+	Failures int     // ingest requests answered with an error
+}
+
+// evaluate returns a message for each alert the window triggers, by alert kind
+func (c AlertConfig) evaluate(w ingestWindow, interval time.Duration) map[string]string {
+	alerts := make(map[string]string)
+	minutes := int(interval.Minutes())
+
+	if w.Expected >= float64(c.MinReports) {
+		if c.VolumeDropPercent > 0 && float64(w.Reports) <= w.Expected*float64(100-c.VolumeDropPercent)/100 {
+			alerts["volume_drop"] = fmt.Sprintf("Ingest volume dropped: %d reports in the last %d minutes, about %.0f expected",
+				w.Reports, minutes, w.Expected)
+		}
+		if c.VolumeSpikePercent > 0 && float64(w.Reports) >= w.Expected*float64(c.VolumeSpikePercent)/100 {
+			alerts["volume_spike"] = fmt.Sprintf("Ingest volume spiked: %d reports in the last %d minutes, about %.0f expected",
+				w.Reports, minutes, w.Expected)
+		}
+	}
+
+	if c.ErrorRatePercent > 0 && w.Requests >= c.MinReports && w.Failures*100 >= w.Requests*c.ErrorRatePercent {
+		alerts["error_rate"] = fmt.Sprintf("Ingest error rate %d%%: %d of %d requests to /api/ingest failed in the last %d minutes",
+			w.Failures*100/w.Requests, w.Failures, w.Requests, minutes)
+	}
+
+	return alerts
+}
+
+// ingestAlerter counts the requests to /api/ingest and, every check interval, compares the
+// reports saved with the collector's usual volume and the failed requests with the error rate
+// threshold. An alert is sent when a condition starts and again when it clears.
+type ingestAlerter struct {
+	db       *sql.DB
+	config   AlertConfig
+	channels []channels.Channel
+
+	mu       sync.Mutex
+	requests int
+	failures int
+	active   map[string]bool
+}
+
+// newIngestAlerter returns nil when no webhook or ntfy topic is configured
+func newIngestAlerter(db *sql.DB, config AlertConfig) (*ingestAlerter, error) {
+	var chans []channels.Channel
+	if config.WebhookURL != "" {
+		ch, err := channels.NewWebhookChannel(&models.NotificationChannel{
+			Name:   "collector-alerts",
+			Type:   models.ChannelTypeWebhook,
+			Config: map[string]interface{}{"url": config.WebhookURL},
+		})
+		if err != nil {
+			return nil, err
+		}
+		chans = append(chans, ch)
+	}
+	if config.NtfyTopic != "" {
+		ch, err := channels.NewNtfyChannel(&models.NotificationChannel{
+			Name: "collector-alerts",
+			Type: models.ChannelTypeNtfy,
+			Config: map[string]interface{}{
+				"server_url": config.NtfyServer,
+				"topic":      config.NtfyTopic,
+				"token":      config.NtfyToken,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		chans = append(chans, ch)
+	}
+	if len(chans) == 0 {
+		return nil, nil
+	}
+	if config.CheckInterval < time.Minute {
+		return nil, fmt.Errorf("ALERT_CHECK_MINUTES must be at least 1")
+	}
+
+	return &ingestAlerter{
+		db:       db,
+		config:   config,
+		channels: chans,
+		active:   make(map[string]bool),
+	}, nil
+}
+
+// statusRecorder keeps the status code a handler answered with
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// countIngest wraps the ingest handler to count its requests and failures
+func (a *ingestAlerter) countIngest(next http.HandlerFunc) http.HandlerFunc {
+	if a == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+
+		a.mu.Lock()
+		a.requests++
+		if rec.status >= 400 {
+			a.failures++
+		}
+		a.mu.Unlock()
+	}
+}
+
+// run checks the ingest health every check interval until ctx is done
+func (a *ingestAlerter) run(ctx context.Context) {
+	ticker := time.NewTicker(a.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.check(ctx)
+		}
+	}
+}
+
+// check evaluates the interval that just ended and notifies the alerts that started or cleared
+func (a *ingestAlerter) check(ctx context.Context) {
+	a.mu.Lock()
+	window := ingestWindow{Requests: a.requests, Failures: a.failures}
+	a.requests, a.failures = 0, 0
+	a.mu.Unlock()
+
+	var err error
+	window.Reports, window.Expected, err = a.volume(ctx, time.Now())
+	if err != nil {
+		log.Printf("Ingest alert check failed: %v", err)
+		return
+	}
+
+	alerts := a.config.evaluate(window, a.config.CheckInterval)
+	for kind, message := range alerts {
+		if !a.active[kind] {
+			a.active[kind] = true
+			a.notify(ctx, kind, "⚠️ "+message, window)
+		}
+	}
+	for kind := range a.active {
+		if _, ok := alerts[kind]; !ok {
+			delete(a.active, kind)
+			a.notify(ctx, kind, fmt.Sprintf("✅ Resolved %s: %d reports saved and %d of %d ingest requests failed in the last %d minutes",
+				kind, window.Reports, window.Failures, window.Requests, int(a.config.CheckInterval.Minutes())), window)
+		}
+	}
+}
+
+// volume counts the reports saved in the interval before now and the average per interval over
+// the baseline window before that. Without a day of history the average is 0.
+func (a *ingestAlerter) volume(ctx context.Context, now time.Time) (int, float64, error) {
+	windowStart := now.Add(-a.config.CheckInterval)
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE created_at >= $1),
+			COUNT(*) FILTER (WHERE created_at < $1),
+			MIN(created_at)
+		FROM submission_events
+		WHERE created_at >= $2
+	`
+
+	var reports, previous int
+	var oldest sql.NullTime
+	if err := a.db.QueryRowContext(ctx, query, windowStart, windowStart.Add(-baselineWindow)).Scan(&reports, &previous, &oldest); err != nil {
+		return 0, 0, err
+	}
+
+	history := baselineWindow
+	if oldest.Valid && oldest.Time.After(windowStart.Add(-baselineWindow)) {
+		history = windowStart.Sub(oldest.Time)
+	}
+	if !oldest.Valid || history < 24*time.Hour {
+		return reports, 0, nil
+	}
+	return reports, float64(previous) / (float64(history) / float64(a.config.CheckInterval)), nil
+}
+
+// notify sends one alert to every configured channel
+func (a *ingestAlerter) notify(ctx context.Context, kind, message string, window ingestWindow) {
+	log.Printf("Ingest alert: %s", message)
+
+	event := models.NotificationEvent{
+		EventType: eventTypeIngestAlert,
+		Timestamp: time.Now(),
+		Metadata: map[string]interface{}{
+			"alert":    kind,
+			"resolved": !a.active[kind],
+			"reports":  window.Reports,
+			"expected": window.Expected,
+			"requests": window.Requests,
+			"failures": window.Failures,
+		},
+	}
+	for _, ch := range a.channels {
+		if err := ch.Send(ctx, message, event); err != nil {
+			log.Printf("Failed to send ingest alert via %s: %v", ch.Type(), err)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAlertConfigEvaluate(t *testing.T) {
+	config := AlertConfig{VolumeDropPercent: 50, VolumeSpikePercent: 300, ErrorRatePercent: 10, MinReports: 10}
+
+	tests := []struct {
+		name   string
+		window ingestWindow
+		want   []string
+	}{
+		{"normal", ingestWindow{Reports: 18, Expected: 20, Requests: 20, Failures: 1}, nil},
+		{"drop", ingestWindow{Reports: 10, Expected: 20, Requests: 10}, []string{"volume_drop"}},
+		{"spike", ingestWindow{Reports: 60, Expected: 20, Requests: 60}, []string{"volume_spike"}},
+		{"errors", ingestWindow{Reports: 18, Expected: 20, Requests: 20, Failures: 2}, []string{"error_rate"}},
+		{"too little history", ingestWindow{Reports: 0, Expected: 5, Requests: 0}, nil},
+		{"too few requests", ingestWindow{Reports: 2, Expected: 0, Requests: 4, Failures: 4}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alerts := config.evaluate(tt.window, time.Hour)
+			if len(alerts) != len(tt.want) {
+				t.Fatalf("Expected alerts %v, got %v", tt.want, alerts)
+			}
+			for _, kind := range tt.want {
+				if alerts[kind] == "" {
+					t.Errorf("Expected a %s alert, got %v", kind, alerts)
+				}
+			}
+		})
+	}
+}
+
+func TestCountIngest(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("fail") != "" {
+			respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		respondJSON(w, http.StatusCreated, map[string]string{"status": "success"})
+	}
+
+	var disabled *ingestAlerter
+	if disabled.countIngest(handler) == nil {
+		t.Fatal("Expected the handler itself without an alerter")
+	}
+
+	a := &ingestAlerter{active: make(map[string]bool)}
+	counted := a.countIngest(handler)
+	for _, target := range []string{"/api/ingest", "/api/ingest", "/api/ingest?fail=1"} {
+		counted(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, target, nil))
+	}
+	if a.requests != 3 || a.failures != 1 {
+		t.Errorf("Expected 3 requests and 1 failure, got %d and %d", a.requests, a.failures)
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+// API scopes. Aggregate statistics, the per-installation records behind them, and key management
+// are granted separately, so community stats can be shared without the raw data.
+const (
+	scopeStats = "stats" // /api/stats/* aggregates
+	scopeRaw   = "raw"   // recent submissions and the database view, which show installation IDs
+	scopeAdmin = "admin" // managing API keys
+)
+
+var allScopes = []string{scopeStats, scopeRaw, scopeAdmin}
+
+// apiKeyPrefix starts every generated key, so leaked keys are easy to spot
+const apiKeyPrefix = "ccs_"
+
+// APIKey is a named key for the stats API. The key itself is only shown when it is created;
+// the collector keeps its SHA-256.
+type APIKey struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // first characters of the key, to recognize it
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	UseCount   int64      `json:"use_count"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	Key        string     `json:"key,omitempty"` // only in the response that created it
+}
+
+// viewer is who a stats API request is made by
+type viewer struct {
+	Name   string
+	Scopes []string
+}
+
+func (v viewer) has(scope string) bool {
+	return slices.Contains(v.Scopes, scope)
+}
+
+type viewerKey struct{}
+
+// viewerFromContext returns the viewer set by apiKeyMiddleware
+func viewerFromContext(ctx context.Context) viewer {
+	v, _ := ctx.Value(viewerKey{}).(viewer)
+	return v
+}
+
+// generateAPIKey returns a new random key
+func generateAPIKey() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + hex.EncodeToString(b), nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// parseScopes validates requested scopes and returns them in canonical order
+func parseScopes(requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return nil, fmt.Errorf("at least one scope is required")
+	}
+	for _, s := range requested {
+		if !slices.Contains(allScopes, s) {
+			return nil, fmt.Errorf("unknown scope %q (valid: %s)", s, strings.Join(allScopes, ", "))
+		}
+	}
+	var scopes []string
+	for _, s := range allScopes {
+		if slices.Contains(requested, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes, nil
+}
+
+// requestAPIKey returns the key sent in the X-API-Key header or as a Bearer token
+func requestAPIKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if authHeader := r.Header.Get("Authorization"); len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+		return authHeader[7:]
+	}
+	return ""
+}
+
+// authenticate identifies the viewer of a request: an API key (STATS_API_KEY has every scope),
+// an OIDC session, or the dashboard's basic auth credentials. ok is false when credentials were
+// sent but are invalid, or none were sent.
+func (s *Server) authenticate(r *http.Request) (viewer, bool, error) {
+	if key := requestAPIKey(r); key != "" {
+		if s.config.StatsAPIKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(s.config.StatsAPIKey)) == 1 {
+			return viewer{Name: "STATS_API_KEY", Scopes: allScopes}, true, nil
+		}
+		return s.useAPIKey(key)
+	}
+
+	if s.oidc != nil {
+		if v, ok := s.oidc.sessionViewer(r); ok {
+			return v, true, nil
+		}
+	}
+
+	if s.config.AuthEnabled {
+		if username, password, ok := r.BasicAuth(); ok && s.validBasicAuth(username, password) {
+			return viewer{Name: username, Scopes: allScopes}, true, nil
+		}
+	}
+
+	return viewer{}, false, nil
+}
+
+// statsProtected reports whether the stats API requires credentials: once STATS_API_KEY, OIDC
+// or a named key is set up. Before that it is public, as it always was.
+func (s *Server) statsProtected() (bool, error) {
+	if s.config.StatsAPIKey != "" || s.oidc != nil {
+		return true, nil
+	}
+	var exists bool
+	err := s.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM api_keys WHERE revoked_at IS NULL)`).Scan(&exists)
+	return exists, err
+}
+
+// useAPIKey looks up an active named key and counts its use
+func (s *Server) useAPIKey(key string) (viewer, bool, error) {
+	var v viewer
+	var scopes string
+	err := s.db.QueryRow(`
+		UPDATE api_keys SET last_used_at = NOW(), use_count = use_count + 1
+		WHERE key_hash = $1 AND revoked_at IS NULL
+		RETURNING name, scopes
+	`, hashAPIKey(key)).Scan(&v.Name, &scopes)
+	if err == sql.ErrNoRows {
+		return viewer{}, false, nil
+	}
+	if err != nil {
+		return viewer{}, false, err
+	}
+	v.Scopes = strings.Split(scopes, ",")
+	return v, true, nil
+}
+
+// handleListAPIKeys lists the named keys, revoked ones last
+func (s *Server) handleListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	rows, err := s.db.Query(`
+		SELECT id, name, key_prefix, scopes, created_by, created_at, last_used_at, use_count, revoked_at
+		FROM api_keys
+		ORDER BY revoked_at IS NOT NULL, name
+	`)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Query failed: "+err.Error())
+		return
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		var k APIKey
+		var scopes string
+		var lastUsed, revoked pq.NullTime
+		if err := rows.Scan(&k.ID, &k.Name, &k.Prefix, &scopes, &k.CreatedBy, &k.CreatedAt, &lastUsed, &k.UseCount, &revoked); err != nil {
+			log.Printf("Scan error: %v", err)
+			continue
+		}
+		k.Scopes = strings.Split(scopes, ",")
+		if lastUsed.Valid {
+			k.LastUsedAt = &lastUsed.Time
+		}
+		if revoked.Valid {
+			k.RevokedAt = &revoked.Time
+		}
+		keys = append(keys, k)
+	}
+
+	respondJSON(w, http.StatusOK, keys)
+}
+
+// handleCreateAPIKey creates a named key with {"name", "scopes"} and returns it, the only time
+// the key itself is shown
+func (s *Server) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		respondError(w, http.StatusBadRequest, "name is required (up to 100 characters)")
+		return
+	}
+	scopes, err := parseScopes(req.Scopes)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	key, err := generateAPIKey()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to generate key: "+err.Error())
+		return
+	}
+	k := APIKey{
+		Name:      req.Name,
+		Prefix:    key[:len(apiKeyPrefix)+6],
+		Scopes:    scopes,
+		CreatedBy: viewerFromContext(r.Context()).Name,
+		Key:       key,
+	}
+	err = s.db.QueryRow(`
+		INSERT INTO api_keys (name, key_hash, key_prefix, scopes, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`, k.Name, hashAPIKey(key), k.Prefix, strings.Join(scopes, ","), k.CreatedBy).Scan(&k.ID, &k.CreatedAt)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		respondError(w, http.StatusConflict, "A key with this name already exists")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create key: "+err.Error())
+		return
+	}
+
+	log.Printf("API key %q (%s) created by %s", k.Name, strings.Join(scopes, ","), k.CreatedBy)
+	respondJSON(w, http.StatusCreated, k)
+}
+
+// handleRevokeAPIKey revokes a named key; it stays listed with its usage
+func (s *Server) handleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid key ID")
+		return
+	}
+
+	var name string
+	err = s.db.QueryRow(`
+		UPDATE api_keys SET revoked_at = NOW()
+		WHERE id = $1 AND revoked_at IS NULL
+		RETURNING name
+	`, id).Scan(&name)
+	if err == sql.ErrNoRows {
+		respondError(w, http.StatusNotFound, "Key not found or already revoked")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to revoke key: "+err.Error())
+		return
+	}
+
+	log.Printf("API key %q revoked by %s", name, viewerFromContext(r.Context()).Name)
+	respondJSON(w, http.StatusOK, map[string]string{"status": "revoked"})
+}
+
+// handleAuthMe tells the dashboard who is signed in and what they may do
+func (s *Server) handleAuthMe(w http.ResponseWriter, r *http.Request) {
+	v, ok, err := s.authenticate(r)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to check credentials: "+err.Error())
+		return
+	}
+	response := map[string]interface{}{
+		"oidc":          s.oidc != nil,
+		"authenticated": ok,
+	}
+	if ok {
+		response["name"] = v.Name
+		response["scopes"] = v.Scopes
+	}
+	respondJSON(w, http.StatusOK, response)
+}
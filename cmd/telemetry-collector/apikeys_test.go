@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseScopes(t *testing.T) {
+	scopes, err := parseScopes([]string{"admin", "stats"})
+	if err != nil || !slices.Equal(scopes, []string{scopeStats, scopeAdmin}) {
+		t.Errorf("Expected stats and admin in order, got %v (%v)", scopes, err)
+	}
+	if _, err := parseScopes(nil); err == nil {
+		t.Error("Expected an error without scopes")
+	}
+	if _, err := parseScopes([]string{"stats", "write"}); err == nil {
+		t.Error("Expected an error for an unknown scope")
+	}
+}
+
+func TestGenerateAPIKey(t *testing.T) {
+	a, err := generateAPIKey()
+	if err != nil {
+		t.Fatalf("generateAPIKey failed: %v", err)
+	}
+	b, _ := generateAPIKey()
+	if !strings.HasPrefix(a, apiKeyPrefix) || a == b {
+		t.Errorf("Expected distinct %s keys, got %q and %q", apiKeyPrefix, a, b)
+	}
+	if hashAPIKey(a) == hashAPIKey(b) || len(hashAPIKey(a)) != 64 {
+		t.Errorf("Expected distinct SHA-256 hashes, got %q", hashAPIKey(a))
+	}
+}
+
+func TestOIDCConfigAccess(t *testing.T) {
+	if (OIDCConfig{}).allowed("anyone@example.com") {
+		t.Error("Expected nobody to be allowed when nothing is configured")
+	}
+	if _, err := newOIDCProvider(context.Background(), OIDCConfig{ClientID: "census", RedirectURL: "https://collector.example.com/auth/callback"}); err == nil {
+		t.Error("Expected OIDC without an allow-list to be refused")
+	}
+	adminsOnly := OIDCConfig{AdminEmails: []string{"owner@example.com"}}
+	if !adminsOnly.allowed("owner@example.com") || adminsOnly.allowed("anyone@example.com") {
+		t.Error("Expected only admins allowed without OIDC_ALLOWED_EMAILS/OIDC_ALLOWED_DOMAINS")
+	}
+
+	config := OIDCConfig{
+		AllowedEmails:  []string{"friend@gmail.com"},
+		AllowedDomains: []string{"selfhosters.cc"},
+		AdminEmails:    []string{"owner@example.com"},
+	}
+	for email, want := range map[string]bool{
+		"friend@gmail.com":      true,
+		"Dev@Selfhosters.cc":    true,
+		"owner@example.com":     true,
+		"stranger@gmail.com":    false,
+		"dev@selfhosters.cc.io": false,
+	} {
+		if got := config.allowed(email); got != want {
+			t.Errorf("allowed(%q) = %v, want %v", email, got, want)
+		}
+	}
+	if !slices.Contains(config.scopes("Owner@example.com"), scopeAdmin) || slices.Contains(config.scopes("friend@gmail.com"), scopeAdmin) {
+		t.Error("Expected only the admin email to get the admin scope")
+	}
+}
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	s := &Server{config: Config{StatsAPIKey: "secret", AuthEnabled: true, AuthUsername: "admin", AuthPassword: "pw"}}
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		respondJSON(w, http.StatusOK, map[string]string{"viewer": viewerFromContext(r.Context()).Name})
+	}
+
+	tests := []struct {
+		name  string
+		scope string
+		setup func(r *http.Request)
+		want  int
+	}{
+		{"no credentials", scopeStats, func(r *http.Request) {}, http.StatusUnauthorized},
+		{"STATS_API_KEY", scopeAdmin, func(r *http.Request) { r.Header.Set("X-API-Key", "secret") }, http.StatusOK},
+		{"bearer", scopeRaw, func(r *http.Request) { r.Header.Set("Authorization", "Bearer secret") }, http.StatusOK},
+		{"dashboard basic auth", scopeStats, func(r *http.Request) { r.SetBasicAuth("admin", "pw") }, http.StatusOK},
+		{"wrong basic auth", scopeStats, func(r *http.Request) { r.SetBasicAuth("admin", "nope") }, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/stats/summary", nil)
+			tt.setup(req)
+			w := httptest.NewRecorder()
+			s.apiKeyMiddleware(tt.scope, handler)(w, req)
+			if w.Code != tt.want {
+				t.Errorf("Expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	s.apiKeyMiddleware(scopeStats, handler)(w, httptest.NewRequest(http.MethodOptions, "/api/stats/summary", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("Expected preflight to pass without credentials, got %d", w.Code)
+	}
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gorilla/sessions"
+)
+
+const (
+	sessionCookie  = "collector-session"
+	oidcFlowCookie = "collector-oidc" // state and PKCE verifier of a login in progress
+)
+
+// OIDCConfig configures dashboard login through an OpenID Connect provider
+type OIDCConfig struct {
+	Issuer         string
+	ClientID       string
+	ClientSecret   string
+	RedirectURL    string   // e.g. https://collector.example.com/auth/callback
+	AllowedEmails  []string // who may sign in besides AdminEmails
+	AllowedDomains []string
+	AdminEmails    []string // may also manage API keys
+	SessionSecret  string
+}
+
+// loadOIDCConfig reads the OIDC configuration from the environment
+func loadOIDCConfig() OIDCConfig {
+	return OIDCConfig{
+		Issuer:         strings.TrimSuffix(getEnv("OIDC_ISSUER", ""), "/"),
+		ClientID:       getEnv("OIDC_CLIENT_ID", ""),
+		ClientSecret:   getEnv("OIDC_CLIENT_SECRET", ""),
+		RedirectURL:    getEnv("OIDC_REDIRECT_URL", ""),
+		AllowedEmails:  splitList(getEnv("OIDC_ALLOWED_EMAILS", "")),
+		AllowedDomains: splitList(getEnv("OIDC_ALLOWED_DOMAINS", "")),
+		AdminEmails:    splitList(getEnv("OIDC_ADMIN_EMAILS", "")),
+		SessionSecret:  getEnv("SESSION_SECRET", ""),
+	}
+}
+
+// splitList splits a comma-separated list into lower-case entries
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// allowed reports whether a user may sign in
+func (c OIDCConfig) allowed(email string) bool {
+	email = strings.ToLower(email)
+	if slices.Contains(c.AllowedEmails, email) || slices.Contains(c.AdminEmails, email) {
+		return true
+	}
+	_, domain, ok := strings.Cut(email, "@")
+	return ok && slices.Contains(c.AllowedDomains, domain)
+}
+
+// scopes returns the API scopes of a signed-in user
+func (c OIDCConfig) scopes(email string) []string {
+	if slices.Contains(c.AdminEmails, strings.ToLower(email)) {
+		return allScopes
+	}
+	return []string{scopeStats, scopeRaw}
+}
+
+// oidcProvider signs dashboard viewers in with the authorization code flow (with PKCE) and
+// keeps them in a cookie session. The user is taken from the provider's userinfo endpoint.
+type oidcProvider struct {
+	config      OIDCConfig
+	authURL     string
+	tokenURL    string
+	userinfoURL string
+	client      *http.Client
+	store       *sessions.CookieStore
+}
+
+// newOIDCProvider reads the provider's discovery document
+func newOIDCProvider(ctx context.Context, config OIDCConfig) (*oidcProvider, error) {
+	if config.ClientID == "" || config.RedirectURL == "" {
+		return nil, fmt.Errorf("OIDC_CLIENT_ID and OIDC_REDIRECT_URL are required with OIDC_ISSUER")
+	}
+	if u, err := url.Parse(config.RedirectURL); err != nil || u.Path != "/auth/callback" {
+		return nil, fmt.Errorf("OIDC_REDIRECT_URL must be the collector's /auth/callback URL")
+	}
+	if len(config.AllowedEmails) == 0 && len(config.AllowedDomains) == 0 && len(config.AdminEmails) == 0 {
+		return nil, fmt.Errorf("OIDC_ALLOWED_EMAILS, OIDC_ALLOWED_DOMAINS or OIDC_ADMIN_EMAILS is required with OIDC_ISSUER, otherwise every user of the provider could sign in")
+	}
+
+	secret := []byte(config.SessionSecret)
+	if len(secret) == 0 {
+		log.Println("Warning: No SESSION_SECRET set - dashboard sessions end on restart and aren't shared between replicas")
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+	}
+
+	p := &oidcProvider{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+		store:  sessions.NewCookieStore(secret),
+	}
+	p.store.Options = &sessions.Options{
+		Path:     "/",
+		MaxAge:   86400 * 7, // 7 days
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(config.RedirectURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	}
+
+	var discovery struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	}
+	if err := p.getJSON(ctx, config.Issuer+"/.well-known/openid-configuration", "", &discovery); err != nil {
+		return nil, fmt.Errorf("failed to read OIDC discovery document: %w", err)
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != config.Issuer {
+		return nil, fmt.Errorf("OIDC discovery document is for issuer %q, not %q", discovery.Issuer, config.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.UserinfoEndpoint == "" {
+		return nil, fmt.Errorf("OIDC provider has no authorization, token or userinfo endpoint")
+	}
+	p.authURL, p.tokenURL, p.userinfoURL = discovery.AuthorizationEndpoint, discovery.TokenEndpoint, discovery.UserinfoEndpoint
+
+	return p, nil
+}
+
+// sessionViewer returns the signed-in viewer of a request. Access is checked against the current
+// configuration, so removing a user from the allowed lists ends their session.
+func (p *oidcProvider) sessionViewer(r *http.Request) (viewer, bool) {
+	session, _ := p.store.Get(r, sessionCookie)
+	email, _ := session.Values["email"].(string)
+	if email == "" || !p.config.allowed(email) {
+		return viewer{}, false
+	}
+	return viewer{Name: email, Scopes: p.config.scopes(email)}, true
+}
+
+// handleLogin sends the browser to the provider
+func (p *oidcProvider) handleLogin(w http.ResponseWriter, r *http.Request) {
+	state, err := randomToken()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to start login")
+		return
+	}
+	verifier, err := randomToken()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to start login")
+		return
+	}
+
+	flow, _ := p.store.Get(r, oidcFlowCookie)
+	flow.Values["state"] = state
+	flow.Values["verifier"] = verifier
+	flow.Options = &sessions.Options{Path: "/auth/", MaxAge: 600, HttpOnly: true, Secure: p.store.Options.Secure, SameSite: http.SameSiteLaxMode}
+	if err := flow.Save(r, w); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to start login")
+		return
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {"openid email profile"},
+		"state":                 {state},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(p.authURL, "?") {
+		sep = "&"
+	}
+	http.Redirect(w, r, p.authURL+sep+params.Encode(), http.StatusFound)
+}
+
+// handleCallback completes the login the provider redirected back from
+func (p *oidcProvider) handleCallback(w http.ResponseWriter, r *http.Request) {
+	flow, _ := p.store.Get(r, oidcFlowCookie)
+	state, _ := flow.Values["state"].(string)
+	verifier, _ := flow.Values["verifier"].(string)
+	flow.Options = &sessions.Options{Path: "/auth/", MaxAge: -1}
+	flow.Save(r, w)
+
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		http.Error(w, "Login failed: "+e+" "+q.Get("error_description"), http.StatusUnauthorized)
+		return
+	}
+	if state == "" || q.Get("state") != state || q.Get("code") == "" {
+		http.Error(w, "Login failed: invalid or expired login, please try again", http.StatusBadRequest)
+		return
+	}
+
+	accessToken, err := p.exchange(r.Context(), q.Get("code"), verifier)
+	if err != nil {
+		log.Printf("OIDC code exchange failed: %v", err)
+		http.Error(w, "Login failed: could not exchange the authorization code", http.StatusBadGateway)
+		return
+	}
+
+	var user struct {
+		Subject       string      `json:"sub"`
+		Email         string      `json:"email"`
+		EmailVerified interface{} `json:"email_verified"` // some providers send a string
+	}
+	if err := p.getJSON(r.Context(), p.userinfoURL, accessToken, &user); err != nil {
+		log.Printf("OIDC userinfo failed: %v", err)
+		http.Error(w, "Login failed: could not read the user", http.StatusBadGateway)
+		return
+	}
+	if user.Email == "" || (user.EmailVerified != true && user.EmailVerified != "true") {
+		http.Error(w, "Login failed: the provider sent no verified email address", http.StatusForbidden)
+		return
+	}
+	if !p.config.allowed(user.Email) {
+		log.Printf("OIDC login refused for %s", user.Email)
+		http.Error(w, "Login failed: "+user.Email+" may not view this dashboard", http.StatusForbidden)
+		return
+	}
+
+	session, _ := p.store.Get(r, sessionCookie)
+	session.Values["email"] = user.Email
+	if err := session.Save(r, w); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save session")
+		return
+	}
+
+	log.Printf("Dashboard login: %s", user.Email)
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// handleLogout ends the dashboard session
+func (p *oidcProvider) handleLogout(w http.ResponseWriter, r *http.Request) {
+	session, _ := p.store.Get(r, sessionCookie)
+	delete(session.Values, "email")
+	session.Options.MaxAge = -1
+	session.Save(r, w)
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// exchange trades an authorization code for an access token
+func (p *oidcProvider) exchange(ctx context.Context, code, verifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"client_id":     {p.config.ClientID},
+		"code_verifier": {verifier},
+	}
+	if p.config.ClientSecret != "" {
+		form.Set("client_secret", p.config.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("HTTP %d: %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || token.AccessToken == "" {
+		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, token.Error)
+	}
+	return token.AccessToken, nil
+}
+
+// getJSON fetches a JSON document, with a bearer token when one is given
+func (p *oidcProvider) getJSON(ctx context.Context, target, token string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned HTTP %d", target, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// randomToken returns 32 random bytes, URL-safe encoded
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+const (
+	// queueLease is how long a claimed report stays invisible to other workers; a report whose
+	// worker died is picked up again after it
+	queueLease = 5 * time.Minute
+	// queuePollInterval is how often idle workers look for reports queued by other replicas
+	queuePollInterval = 2 * time.Second
+)
+
+// ingestQueue decouples /api/ingest from saving: reports are queued in the ingest_queue table and
+// saved by workers, so a burst of submissions only costs one INSERT per request. Workers claim
+// reports with FOR UPDATE SKIP LOCKED, so any number of collector replicas can share the queue.
+type ingestQueue struct {
+	db          *sql.DB
+	save        func(models.TelemetryReport) error
+	workers     int
+	maxAttempts int
+	wake        chan struct{}
+}
+
+func newIngestQueue(db *sql.DB, save func(models.TelemetryReport) error, workers, maxAttempts int) *ingestQueue {
+	return &ingestQueue{
+		db:          db,
+		save:        save,
+		workers:     workers,
+		maxAttempts: maxAttempts,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// enqueue stores a report for the workers and wakes one of this replica's
+func (q *ingestQueue) enqueue(report models.TelemetryReport) error {
+	payload, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+	if _, err := q.db.Exec(`INSERT INTO ingest_queue (installation_id, payload) VALUES ($1, $2)`, report.InstallationID, payload); err != nil {
+		return fmt.Errorf("failed to queue report: %w", err)
+	}
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// run starts the workers; they stop when ctx is done
+func (q *ingestQueue) run(ctx context.Context) {
+	for i := 0; i < q.workers; i++ {
+		go q.work(ctx)
+	}
+}
+
+// work saves queued reports until the queue is empty, then waits for a new one
+func (q *ingestQueue) work(ctx context.Context) {
+	ticker := time.NewTicker(queuePollInterval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			processed, err := q.processNext(ctx)
+			if err != nil {
+				log.Printf("Ingest queue error: %v", err)
+				break
+			}
+			if !processed {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// processNext claims the oldest available report and saves it. Reports of an installation are
+// saved in the order they were received: one waits while an earlier one is pending. A report that
+// fails to save is retried with a growing delay, and kept as failed after maxAttempts.
+func (q *ingestQueue) processNext(ctx context.Context) (bool, error) {
+	claimQuery := `
+		UPDATE ingest_queue SET attempts = attempts + 1, available_at = NOW() + make_interval(secs => $1)
+		WHERE id = (
+			SELECT id FROM ingest_queue q
+			WHERE available_at <= NOW() AND attempts < $2
+			  AND NOT EXISTS (
+				SELECT 1 FROM ingest_queue earlier
+				WHERE earlier.installation_id = q.installation_id AND earlier.id < q.id AND earlier.attempts < $2
+			  )
+			ORDER BY id
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING id, payload, attempts
+	`
+
+	var id int64
+	var payload []byte
+	var attempts int
+	err := q.db.QueryRowContext(ctx, claimQuery, queueLease.Seconds(), q.maxAttempts).Scan(&id, &payload, &attempts)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to claim report: %w", err)
+	}
+
+	var report models.TelemetryReport
+	if err := json.Unmarshal(payload, &report); err != nil {
+		err = fmt.Errorf("invalid payload: %w", err)
+		q.fail(id, attempts, err)
+		return true, nil
+	}
+	if err := q.save(report); err != nil {
+		q.fail(id, attempts, err)
+		return true, nil
+	}
+
+	if _, err := q.db.Exec(`DELETE FROM ingest_queue WHERE id = $1`, id); err != nil {
+		return true, fmt.Errorf("failed to remove saved report %d: %w", id, err)
+	}
+	log.Printf("Saved telemetry from installation %s: %d containers, %d images",
+		report.InstallationID, report.TotalContainers, len(report.ImageStats))
+	return true, nil
+}
+
+// fail records a failed attempt and schedules the retry after retryDelay
+func (q *ingestQueue) fail(id int64, attempts int, cause error) {
+	if attempts >= q.maxAttempts {
+		log.Printf("Giving up on queued report %d after %d attempts: %v", id, attempts, cause)
+	} else {
+		log.Printf("Failed to save queued report %d (attempt %d of %d): %v", id, attempts, q.maxAttempts, cause)
+	}
+
+	delay := retryDelay(attempts)
+	_, err := q.db.Exec(`
+		UPDATE ingest_queue SET available_at = NOW() + make_interval(secs => $2), last_error = $3
+		WHERE id = $1
+	`, id, delay.Seconds(), cause.Error())
+	if err != nil {
+		log.Printf("Failed to record failure of queued report %d: %v", id, err)
+	}
+}
+
+// retryDelay is the wait after a report's failed attempt: 30s, 1m, 2m, ... up to an hour. The
+// shift is capped so a large INGEST_MAX_ATTEMPTS can't overflow it.
+func retryDelay(attempts int) time.Duration {
+	return min(30*time.Second<<min(max(attempts-1, 0), 7), time.Hour)
+}
+
+// stats counts the reports waiting to be saved and those given up on
+func (q *ingestQueue) stats() (pending, failed int, err error) {
+	err = q.db.QueryRow(`
+		SELECT COUNT(*) FILTER (WHERE attempts < $1), COUNT(*) FILTER (WHERE attempts >= $1)
+		FROM ingest_queue
+	`, q.maxAttempts).Scan(&pending, &failed)
+	return pending, failed, err
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	for attempts, want := range map[int]time.Duration{
+		1:   30 * time.Second,
+		2:   time.Minute,
+		3:   2 * time.Minute,
+		8:   time.Hour,
+		30:  time.Hour,
+		64:  time.Hour,
+		100: time.Hour,
+	} {
+		if got := retryDelay(attempts); got != want {
+			t.Errorf("retryDelay(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
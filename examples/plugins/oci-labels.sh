@@ -0,0 +1,7 @@
+#!/bin/sh
+# Example Container Census collector plugin (requires jq).
+# Reports the license and source repository declared in each container's OCI image labels.
+# Copy into the directory set in PLUGINS_DIR and make it executable.
+exec jq '{results: [.containers[] | .name as $name | (.labels // {}) | to_entries[]
+    | select(.key == "org.opencontainers.image.licenses" or .key == "org.opencontainers.image.source")
+    | {container_name: $name, key: (.key | ltrimstr("org.opencontainers.image.")), value: .value}]}'
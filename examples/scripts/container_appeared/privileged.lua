@@ -0,0 +1,10 @@
+-- Example Container Census event script.
+-- Alerts when a new container runs privileged or uses host networking, and tags it for review.
+-- Copy into SCRIPTS_DIR/container_appeared/.
+local c = event.container
+local config = c.config or {}
+
+if config.privileged or config.network_mode == "host" then
+  census.notify(c.name .. " on " .. event.host.name .. " runs privileged or with host networking")
+  census.tag("review", "privileged")
+end
@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
 	"encoding/hex"
@@ -9,11 +10,18 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"os"
+	"os/exec"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/container-census/container-census/internal/compliance"
+	"github.com/container-census/container-census/internal/containerstats"
+	"github.com/container-census/container-census/internal/inspect"
 	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/updatehooks"
+	"github.com/distribution/reference"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/image"
@@ -21,26 +29,52 @@ import (
 	"github.com/gorilla/mux"
 )
 
+// maxScanTimeout caps the scan time a server may ask for, as the server's scan timeout setting does
+const maxScanTimeout = 60 * time.Minute
+
 // Info represents agent information
 type Info struct {
-	Version       string    `json:"version"`
-	Hostname      string    `json:"hostname"`
-	OS            string    `json:"os"`
-	Arch          string    `json:"arch"`
-	DockerVersion string    `json:"docker_version"`
-	StartedAt     time.Time `json:"started_at"`
+	Version        string    `json:"version"`
+	Hostname       string    `json:"hostname"`
+	OS             string    `json:"os"`
+	Arch           string    `json:"arch"`
+	DockerVersion  string    `json:"docker_version"`
+	TrivyAvailable bool      `json:"trivy_available"`       // agent can run vulnerability scans
+	ReadOnly       bool      `json:"read_only,omitempty"`   // Docker operations are disabled
+	Permissions    []string  `json:"permissions"`           // Docker operations allowed unless ReadOnly, see -allow
+	DaemonLogs     string    `json:"daemon_logs,omitempty"` // journald or the syslog file watched for OOM kills and daemon errors
+	StartedAt      time.Time `json:"started_at"`
 }
 
 // Agent handles Docker operations on a single host
 type Agent struct {
 	dockerClient *client.Client
+	tokenMu      sync.RWMutex
 	apiToken     string
+	oldToken     string // the token before a rotation, accepted until oldExpires
+	oldExpires   time.Time
+	saveToken    func(token string) error // persists rotated tokens; nil refuses rotation
 	info         Info
 	router       *mux.Router
+	dockerHost   string
+
+	trivyMu       sync.Mutex // Trivy can't share its vulnerability DB between concurrent scans
+	trivyCacheDir string
+	cgroupRoot    string // for memory stats when the Docker stats API reports zeros
+	metrics       *metrics
+	daemonEvents  *daemonEvents // read from the host's logs when DaemonLogs is set
+	hostRoot      string        // for reboot markers, installed kernels and dockerd
+
+	systemMu sync.Mutex
+	system   *models.HostSystem // cached by hostSystem
 }
 
-// New creates a new agent
+// New creates a new agent. An empty dockerHost uses DefaultDockerHost.
 func New(dockerHost string, apiToken string, info Info) (*Agent, error) {
+	if dockerHost == "" {
+		dockerHost = DefaultDockerHost()
+	}
+
 	// Create Docker client
 	dockerClient, err := createDockerClient(dockerHost)
 	if err != nil {
@@ -59,11 +93,35 @@ func New(dockerHost string, apiToken string, info Info) (*Agent, error) {
 		info.DockerVersion = serverVersion.Version
 	}
 
+	_, err = exec.LookPath("trivy")
+	info.TrivyAvailable = err == nil
+
+	trivyCacheDir := "/app/data/.trivy"
+	if envCacheDir := os.Getenv("TRIVY_CACHE_DIR"); envCacheDir != "" {
+		trivyCacheDir = envCacheDir
+	}
+
+	cgroupRoot := defaultCgroupRoot
+	if envCgroupRoot := os.Getenv("CGROUP_ROOT"); envCgroupRoot != "" {
+		cgroupRoot = envCgroupRoot
+	}
+
+	hostRoot := defaultHostRoot
+	if envHostRoot := os.Getenv("HOST_ROOT"); envHostRoot != "" {
+		hostRoot = envHostRoot
+	}
+
 	a := &Agent{
-		dockerClient: dockerClient,
-		apiToken:     apiToken,
-		info:         info,
-		router:       mux.NewRouter(),
+		dockerClient:  dockerClient,
+		apiToken:      apiToken,
+		info:          info,
+		router:        mux.NewRouter(),
+		dockerHost:    dockerHost,
+		trivyCacheDir: trivyCacheDir,
+		cgroupRoot:    cgroupRoot,
+		hostRoot:      hostRoot,
+		metrics:       newMetrics(),
+		daemonEvents:  &daemonEvents{},
 	}
 
 	a.setupRoutes()
@@ -72,13 +130,19 @@ func New(dockerHost string, apiToken string, info Info) (*Agent, error) {
 
 // setupRoutes configures API routes
 func (a *Agent) setupRoutes() {
+	a.router.Use(scanIDMiddleware)
+
 	// Public routes
 	a.router.HandleFunc("/health", a.handleHealth).Methods("GET")
 	a.router.HandleFunc("/info", a.handleInfo).Methods("GET")
 
+	// Prometheus metrics of the agent itself (token as Authorization: Bearer)
+	a.router.Handle("/metrics", a.authMiddleware(http.HandlerFunc(a.handleMetrics))).Methods("GET")
+
 	// Protected routes (require authentication)
 	api := a.router.PathPrefix("/api").Subrouter()
 	api.Use(a.authMiddleware)
+	api.Use(a.permissionMiddleware)
 
 	api.HandleFunc("/containers", a.handleListContainers).Methods("GET")
 	api.HandleFunc("/containers/{id}/start", a.handleStartContainer).Methods("POST")
@@ -86,17 +150,38 @@ func (a *Agent) setupRoutes() {
 	api.HandleFunc("/containers/{id}/restart", a.handleRestartContainer).Methods("POST")
 	api.HandleFunc("/containers/{id}/remove", a.handleRemoveContainer).Methods("DELETE")
 	api.HandleFunc("/containers/{id}/logs", a.handleGetLogs).Methods("GET")
+	api.HandleFunc("/containers/{id}/stats/live", a.handleStreamStats).Methods("GET")
 
 	api.HandleFunc("/images", a.handleListImages).Methods("GET")
 	api.HandleFunc("/images/{id}/remove", a.handleRemoveImage).Methods("DELETE")
 	api.HandleFunc("/images/prune", a.handlePruneImages).Methods("POST")
+	api.HandleFunc("/images/{id}/layers", a.handleGetImageLayers).Methods("GET")
 	api.HandleFunc("/images/pull", a.handlePullImage).Methods("POST")
+	api.HandleFunc("/images/tag", a.handleTagImage).Methods("POST")
+
+	// Plugins, Swarm secrets and configs, and builder cache for the object inventory
+	api.HandleFunc("/objects", a.handleListObjects).Methods("GET")
 
 	// Container update operations
 	api.HandleFunc("/containers/{id}/recreate", a.handleRecreateContainer).Methods("POST")
 
+	// Vulnerability scanning (requires Trivy in the agent image)
+	api.HandleFunc("/vulnerabilities/scan", a.handleScanImage).Methods("POST")
+
+	// CIS Docker Benchmark audit
+	api.HandleFunc("/compliance/audit", a.handleComplianceAudit).Methods("GET")
+
 	// Telemetry endpoint
 	api.HandleFunc("/telemetry", a.handleGetTelemetry).Methods("GET")
+
+	// Agent health for the server's host view
+	api.HandleFunc("/metrics", a.handleMetricsJSON).Methods("GET")
+
+	// OOM kills and daemon errors from the host's logs (-daemon-logs)
+	api.HandleFunc("/daemon-events", a.handleGetDaemonEvents).Methods("GET")
+
+	// Token rotation by the server
+	api.HandleFunc("/token/rotate", a.handleRotateToken).Methods("POST")
 }
 
 // Router returns the configured router
@@ -116,7 +201,7 @@ func (a *Agent) authMiddleware(next http.Handler) http.Handler {
 			}
 		}
 
-		if token != a.apiToken {
+		if !a.validToken(token) {
 			respondError(w, http.StatusUnauthorized, "Invalid or missing API token")
 			return
 		}
@@ -125,6 +210,28 @@ func (a *Agent) authMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+type scanIDKey struct{}
+
+// scanIDMiddleware keeps the ID of the server's scan a request belongs to for the log lines
+// about it (logf) and sends it back
+func scanIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if scanID := r.Header.Get(models.ScanIDHeader); scanID != "" {
+			w.Header().Set(models.ScanIDHeader, scanID)
+			r = r.WithContext(context.WithValue(r.Context(), scanIDKey{}, scanID))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// logf logs like log.Printf, prefixed with the server's scan ID if the request has one
+func logf(ctx context.Context, format string, args ...interface{}) {
+	if scanID, _ := ctx.Value(scanIDKey{}).(string); scanID != "" {
+		format = "[scan " + scanID + "] " + format
+	}
+	log.Printf(format, args...)
+}
+
 // Health check
 func (a *Agent) handleHealth(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -136,6 +243,7 @@ func (a *Agent) handleHealth(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err != nil {
+		a.metrics.dockerError("ping", err)
 		health["status"] = "unhealthy"
 		health["docker_error"] = err.Error()
 		respondJSON(w, http.StatusServiceUnavailable, health)
@@ -153,11 +261,15 @@ func (a *Agent) handleInfo(w http.ResponseWriter, r *http.Request) {
 // Container operations
 func (a *Agent) handleListContainers(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	started := time.Now()
 
 	containers, err := a.dockerClient.ContainerList(ctx, container.ListOptions{
 		All: true,
 	})
 	if err != nil {
+		a.metrics.dockerError("list", err)
+		a.metrics.scanDone(time.Since(started), true)
+		logf(ctx, "Failed to list containers: %v", err)
 		respondError(w, http.StatusInternalServerError, "Failed to list containers: "+err.Error())
 		return
 	}
@@ -165,7 +277,9 @@ func (a *Agent) handleListContainers(w http.ResponseWriter, r *http.Request) {
 	// Get image information for tags and version labels
 	imageTagsMap := make(map[string][]string) // imageID -> all tags (including version from labels)
 	images, err := a.dockerClient.ImageList(ctx, image.ListOptions{})
-	if err == nil {
+	if err != nil {
+		a.metrics.dockerError("image_list", err)
+	} else {
 		for _, img := range images {
 			// Start with RepoTags
 			tags := make([]string, 0)
@@ -224,6 +338,8 @@ func (a *Agent) handleListContainers(w http.ResponseWriter, r *http.Request) {
 		var volumes []models.VolumeMount
 		var links []string
 		var composeProject string
+		var envEndpoints []string
+		var config *models.ContainerConfig
 
 		containerJSON, err := a.dockerClient.ContainerInspect(ctx, c.ID)
 		if err == nil {
@@ -264,6 +380,17 @@ func (a *Agent) handleListContainers(w http.ResponseWriter, r *http.Request) {
 					composeProject = project
 				}
 			}
+
+			// Extract cross-host endpoint references from environment variables
+			if containerJSON.Config != nil {
+				envEndpoints = models.ExtractEnvEndpoints(containerJSON.Config.Env)
+			}
+
+			// Capture sanitized configuration for the inspect view
+			config = inspect.Sanitize(containerJSON)
+		} else {
+			a.metrics.dockerError("inspect", err)
+			logf(ctx, "Failed to inspect container %s: %v", name, err)
 		}
 
 		container := models.Container{
@@ -283,6 +410,12 @@ func (a *Agent) handleListContainers(w http.ResponseWriter, r *http.Request) {
 			Volumes:        volumes,
 			Links:          links,
 			ComposeProject: composeProject,
+			EnvEndpoints:   envEndpoints,
+			Config:         config,
+		}
+
+		if err != nil {
+			container.AddCollectionWarning(models.CollectionStageInspect, fmt.Errorf("inspect failed: %w", err))
 		}
 
 		result = append(result, container)
@@ -294,7 +427,7 @@ func (a *Agent) handleListContainers(w http.ResponseWriter, r *http.Request) {
 		var mu sync.Mutex
 
 		for i := range result {
-			if result[i].State != "running" {
+			if result[i].State != "running" || models.StatsDisabledByLabels(result[i].Labels) {
 				continue
 			}
 
@@ -308,7 +441,11 @@ func (a *Agent) handleListContainers(w http.ResponseWriter, r *http.Request) {
 				// Use streaming stats to get two samples
 				statsStream, err := a.dockerClient.ContainerStats(ctx, containerID, true)
 				if err != nil {
-					log.Printf("Failed to collect stats for container %s: %v", containerName, err)
+					a.metrics.dockerError("stats", err)
+					logf(ctx, "Failed to collect stats for container %s: %v", containerName, err)
+					mu.Lock()
+					result[idx].AddCollectionWarning(models.CollectionStageStats, fmt.Errorf("stats call failed: %w", err))
+					mu.Unlock()
 					return
 				}
 				defer statsStream.Body.Close()
@@ -317,59 +454,63 @@ func (a *Agent) handleListContainers(w http.ResponseWriter, r *http.Request) {
 				var baseline container.StatsResponse
 				decoder := json.NewDecoder(statsStream.Body)
 				if err := decoder.Decode(&baseline); err != nil {
-					log.Printf("Failed to decode first sample for container %s: %v", containerName, err)
+					logf(ctx, "Failed to decode first sample for container %s: %v", containerName, err)
+					mu.Lock()
+					result[idx].AddCollectionWarning(models.CollectionStageStats, fmt.Errorf("reading the first stats sample failed: %w", err))
+					mu.Unlock()
 					return
 				}
 
 				// Read second sample (current)
 				var current container.StatsResponse
 				if err := decoder.Decode(&current); err != nil {
-					log.Printf("Failed to decode second sample for container %s: %v", containerName, err)
+					logf(ctx, "Failed to decode second sample for container %s: %v", containerName, err)
+					mu.Lock()
+					result[idx].AddCollectionWarning(models.CollectionStageStats, fmt.Errorf("reading the second stats sample failed: %w", err))
+					mu.Unlock()
 					return
 				}
 
-				// Calculate CPU percentage using delta between the two samples
-				cpuDelta := float64(current.CPUStats.CPUUsage.TotalUsage - baseline.CPUStats.CPUUsage.TotalUsage)
-				systemDelta := float64(current.CPUStats.SystemUsage - baseline.CPUStats.SystemUsage)
-
-				// Get number of CPUs - try multiple sources
-				numCPUs := uint64(len(current.CPUStats.CPUUsage.PercpuUsage))
-				if numCPUs == 0 && current.CPUStats.OnlineCPUs > 0 {
-					numCPUs = uint64(current.CPUStats.OnlineCPUs)
-				}
-				if numCPUs == 0 {
-					// Fallback: assume at least 1 CPU for calculation
-					numCPUs = 1
-				}
-
-				// Debug logging for CPU calculation
-				log.Printf("DEBUG %s: cpuDelta=%.0f, systemDelta=%.0f, numCPUs=%d, OnlineCPUs=%d, PercpuLen=%d",
-					containerName, cpuDelta, systemDelta, numCPUs,
-					current.CPUStats.OnlineCPUs, len(current.CPUStats.CPUUsage.PercpuUsage))
-
-				var cpuPercent float64
-				if systemDelta > 0 && cpuDelta > 0 {
-					cpuPercent = (cpuDelta / systemDelta) * float64(numCPUs) * 100.0
-				}
+				cpuPercent := cpuUsagePercent(baseline, current)
 
 				// Memory stats (from the latest sample)
 				memoryUsage := int64(current.MemoryStats.Usage)
 				memoryLimit := int64(current.MemoryStats.Limit)
+				var zeroStatsErr error
+				if memoryUsage == 0 && memoryLimit == 0 {
+					// Docker couldn't read the memory cgroup; read it ourselves
+					usage, limit, err := cgroupMemory(a.cgroupRoot, containerID)
+					if err != nil {
+						zeroStatsErr = fmt.Errorf("Docker reported zero memory usage and limit, and reading the cgroup failed: %w", err)
+					} else {
+						memoryUsage, memoryLimit = usage, limit
+					}
+				}
 				var memoryPercent float64
-				if current.MemoryStats.Limit > 0 {
-					memoryPercent = float64(current.MemoryStats.Usage) / float64(current.MemoryStats.Limit) * 100.0
+				if memoryLimit > 0 {
+					memoryPercent = float64(memoryUsage) / float64(memoryLimit) * 100.0
 				}
 
 				// Debug logging
-				log.Printf("Stats collected for %s: CPU=%.2f%%, Memory=%dMB/%dMB (%.1f%%)",
+				logf(ctx, "Stats collected for %s: CPU=%.2f%%, Memory=%dMB/%dMB (%.1f%%)",
 					containerName, cpuPercent, memoryUsage/1024/1024, memoryLimit/1024/1024, memoryPercent)
 
+				// Network and block I/O rates from the same two samples
+				rxRate, txRate, readRate, writeRate := containerstats.IORates(baseline, current)
+
 				// Update the container in the result slice (thread-safe)
 				mu.Lock()
 				result[idx].CPUPercent = cpuPercent
 				result[idx].MemoryUsage = memoryUsage
 				result[idx].MemoryLimit = memoryLimit
 				result[idx].MemoryPercent = memoryPercent
+				result[idx].NetworkRxRate = rxRate
+				result[idx].NetworkTxRate = txRate
+				result[idx].BlockReadRate = readRate
+				result[idx].BlockWriteRate = writeRate
+				if zeroStatsErr != nil {
+					result[idx].AddCollectionWarning(models.CollectionStageZeroStats, zeroStatsErr)
+				}
 				mu.Unlock()
 			}(i)
 		}
@@ -377,9 +518,45 @@ func (a *Agent) handleListContainers(w http.ResponseWriter, r *http.Request) {
 		wg.Wait()
 	}
 
+	a.metrics.scanDone(time.Since(started), false)
 	respondJSON(w, http.StatusOK, result)
 }
 
+// cpuUsagePercent returns the CPU usage between two stats samples, where 100% is one full CPU
+func cpuUsagePercent(previous, current container.StatsResponse) float64 {
+	if current.CPUStats.CPUUsage.TotalUsage <= previous.CPUStats.CPUUsage.TotalUsage {
+		return 0
+	}
+	cpuDelta := float64(current.CPUStats.CPUUsage.TotalUsage - previous.CPUStats.CPUUsage.TotalUsage)
+
+	// Windows containers report no system usage; their CPU time is counted in 100ns intervals
+	// and compared with the time elapsed between the two reads
+	if current.CPUStats.SystemUsage == 0 {
+		intervals := float64(current.Read.Sub(previous.Read).Nanoseconds() / 100)
+		if intervals <= 0 {
+			return 0
+		}
+		return cpuDelta / intervals * 100.0
+	}
+
+	if current.CPUStats.SystemUsage <= previous.CPUStats.SystemUsage {
+		return 0
+	}
+	systemDelta := float64(current.CPUStats.SystemUsage - previous.CPUStats.SystemUsage)
+
+	// Get number of CPUs - try multiple sources
+	numCPUs := uint64(len(current.CPUStats.CPUUsage.PercpuUsage))
+	if numCPUs == 0 && current.CPUStats.OnlineCPUs > 0 {
+		numCPUs = uint64(current.CPUStats.OnlineCPUs)
+	}
+	if numCPUs == 0 {
+		// Fallback: assume at least 1 CPU for calculation
+		numCPUs = 1
+	}
+
+	return (cpuDelta / systemDelta) * float64(numCPUs) * 100.0
+}
+
 func (a *Agent) handleStartContainer(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	containerID := vars["id"]
@@ -494,6 +671,43 @@ func (a *Agent) handleGetLogs(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, map[string]string{"logs": string(buf)})
 }
 
+// handleStreamStats relays the raw Docker stats stream for a single container until the
+// server disconnects. The server decodes and throttles the samples.
+func (a *Agent) handleStreamStats(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	containerID := vars["id"]
+
+	stats, err := a.dockerClient.ContainerStats(r.Context(), containerID, true)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get stats: "+err.Error())
+		return
+	}
+	defer stats.Body.Close()
+
+	// The stream outlives the server's write timeout
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := stats.Body.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return
+			}
+			if ferr := rc.Flush(); ferr != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
 // Image operations
 func (a *Agent) handleListImages(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -507,6 +721,16 @@ func (a *Agent) handleListImages(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, images)
 }
 
+func (a *Agent) handleListObjects(w http.ResponseWriter, r *http.Request) {
+	objects, err := inspect.DockerObjects(r.Context(), a.dockerClient)
+	if err != nil {
+		a.metrics.dockerError("objects", err)
+		respondError(w, http.StatusInternalServerError, "Failed to list objects: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, objects)
+}
+
 func (a *Agent) handleRemoveImage(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	imageID := vars["id"]
@@ -540,6 +764,26 @@ func (a *Agent) handlePruneImages(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (a *Agent) handleGetImageLayers(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	imageID := vars["id"]
+
+	ctx := r.Context()
+	info, err := a.dockerClient.ImageInspect(ctx, imageID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to inspect image: "+err.Error())
+		return
+	}
+
+	history, err := a.dockerClient.ImageHistory(ctx, info.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get image history: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, inspect.ImageLayers(info, history))
+}
+
 // Pull image handler
 func (a *Agent) handlePullImage(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -579,6 +823,127 @@ func (a *Agent) handlePullImage(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// Tag image handler
+func (a *Agent) handleTagImage(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Source string `json:"source"`
+		Target string `json:"target"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Source == "" || req.Target == "" {
+		respondError(w, http.StatusBadRequest, "Source and target are required")
+		return
+	}
+
+	if err := a.dockerClient.ImageTag(r.Context(), req.Source, req.Target); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to tag image: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{
+		"message": "Image tagged successfully",
+		"image":   req.Target,
+	})
+}
+
+// Vulnerability scan handler - runs Trivy against the local Docker daemon and returns its JSON report
+func (a *Agent) handleScanImage(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Image          string `json:"image"`
+		TimeoutSeconds int    `json:"timeout_seconds"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Image == "" {
+		respondError(w, http.StatusBadRequest, "Image name is required")
+		return
+	}
+	if err := validateImageRef(req.Image); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !a.info.TrivyAvailable {
+		respondError(w, http.StatusNotImplemented, "Trivy is not installed on this agent")
+		return
+	}
+
+	timeout := 10 * time.Minute
+	if req.TimeoutSeconds > 0 {
+		timeout = min(time.Duration(req.TimeoutSeconds)*time.Second, maxScanTimeout)
+	}
+	// Scans (and the first DB download) outlast the server write timeout
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + time.Minute))
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	a.trivyMu.Lock()
+	defer a.trivyMu.Unlock()
+
+	cmd := exec.CommandContext(ctx, "trivy", "image",
+		"--format", "json",
+		"--quiet",
+		"--no-progress",
+		"--image-src", "docker", // Only scan from the local Docker daemon
+		"--cache-dir", a.trivyCacheDir,
+		"--", // Never read the image as a flag
+		req.Image,
+	)
+	if a.dockerHost != "" && a.dockerHost != "local" {
+		cmd.Env = append(os.Environ(), "DOCKER_HOST="+a.dockerHost)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		stderrStr := stderr.String()
+		if strings.Contains(stderrStr, "unable to find the specified image") || strings.Contains(stderrStr, "No such image") {
+			respondError(w, http.StatusInternalServerError, "image not available for scanning")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("Trivy scan failed: %v (stderr: %s)", err, stderrStr))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(stdout.Bytes())
+}
+
+// validateImageRef accepts image names, digests and IDs, so nothing else reaches the trivy command line
+func validateImageRef(image string) error {
+	if strings.HasPrefix(image, "-") {
+		return fmt.Errorf("invalid image reference %q", image)
+	}
+	if _, err := reference.ParseAnyReference(image); err != nil {
+		return fmt.Errorf("invalid image reference %q: %v", image, err)
+	}
+	return nil
+}
+
+// Compliance audit handler
+func (a *Agent) handleComplianceAudit(w http.ResponseWriter, r *http.Request) {
+	audit, err := compliance.Audit(r.Context(), a.dockerClient, compliance.LocalFiles(a.dockerClient.DaemonHost()))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to run compliance audit: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, audit)
+}
+
 // Recreate container handler
 func (a *Agent) handleRecreateContainer(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -587,6 +952,20 @@ func (a *Agent) handleRecreateContainer(w http.ResponseWriter, r *http.Request)
 
 	dryRun := r.URL.Query().Get("dry_run") == "true"
 
+	// Optional update hooks (older servers send no body)
+	var hooks *models.UpdateHooks
+	if r.ContentLength > 0 {
+		hooks = &models.UpdateHooks{}
+		if err := json.NewDecoder(r.Body).Decode(hooks); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid update hooks: "+err.Error())
+			return
+		}
+		if hooks.WaitForHealthy {
+			// Extend the write deadline so the health wait isn't cut off by the server timeout
+			http.NewResponseController(w).SetWriteDeadline(time.Now().Add(updatehooks.HealthTimeout(hooks) + time.Minute))
+		}
+	}
+
 	// Inspect the container to get its configuration
 	containerJSON, err := a.dockerClient.ContainerInspect(ctx, containerID)
 	if err != nil {
@@ -639,6 +1018,17 @@ func (a *Agent) handleRecreateContainer(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Run the pre-update command in the old container, aborting the update if it fails
+	var preUpdateOutput string
+	if hooks != nil && len(hooks.PreUpdateCommand) > 0 {
+		output, err := updatehooks.RunPreUpdateCommand(ctx, a.dockerClient, containerID, hooks.PreUpdateCommand)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Pre-update command failed, container left unchanged: "+err.Error()+" (output: "+output+")")
+			return
+		}
+		preUpdateOutput = output
+	}
+
 	// Stop the container
 	timeout := 10
 	stopOptions := container.StopOptions{
@@ -703,15 +1093,19 @@ func (a *Agent) handleRecreateContainer(w http.ResponseWriter, r *http.Request)
 	newImageID := newContainerJSON.Image
 
 	result := models.ContainerRecreateResult{
-		Success:        true,
-		OldContainerID: containerID,
-		NewContainerID: newContainerID,
-		OldImageID:     oldImageID,
-		NewImageID:     newImageID,
-		KeptOldImage:   true, // We don't remove the old image
-		Config:         config,
+		Success:         true,
+		OldContainerID:  containerID,
+		NewContainerID:  newContainerID,
+		OldImageID:      oldImageID,
+		NewImageID:      newImageID,
+		KeptOldImage:    true, // We don't remove the old image
+		Config:          config,
+		PreUpdateOutput: preUpdateOutput,
 	}
 
+	// Wait for the new container to become healthy, rolling back if requested
+	updatehooks.VerifyUpdate(ctx, a.dockerClient, hooks, containerJSON, &result)
+
 	respondJSON(w, http.StatusOK, result)
 }
 
@@ -0,0 +1,232 @@
+package agent
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/docker/docker/api/types/container"
+	"github.com/gorilla/mux"
+)
+
+func statsSample(read time.Time, total, system uint64, onlineCPUs, numProcs uint32) container.StatsResponse {
+	var s container.StatsResponse
+	s.Read = read
+	s.CPUStats.CPUUsage.TotalUsage = total
+	s.CPUStats.SystemUsage = system
+	s.CPUStats.OnlineCPUs = onlineCPUs
+	s.NumProcs = numProcs
+	return s
+}
+
+func TestCPUUsagePercent(t *testing.T) {
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// Linux: half of the system time across 4 CPUs is two full CPUs
+	linux := cpuUsagePercent(
+		statsSample(start, 1000, 10000, 4, 0),
+		statsSample(start.Add(time.Second), 6000, 20000, 4, 0),
+	)
+	if math.Abs(linux-200) > 0.001 {
+		t.Errorf("Expected 200%% on Linux, got %.3f", linux)
+	}
+
+	// Windows: 0.5s of CPU time (in 100ns units) over one second is half a CPU
+	windows := cpuUsagePercent(
+		statsSample(start, 0, 0, 0, 8),
+		statsSample(start.Add(time.Second), 5_000_000, 0, 0, 8),
+	)
+	if math.Abs(windows-50) > 0.001 {
+		t.Errorf("Expected 50%% on Windows, got %.3f", windows)
+	}
+
+	// A restarted container's counters go backwards
+	if p := cpuUsagePercent(statsSample(start, 6000, 20000, 4, 0), statsSample(start.Add(time.Second), 1000, 30000, 4, 0)); p != 0 {
+		t.Errorf("Expected 0%% after a restart, got %.3f", p)
+	}
+}
+
+func TestDefaultDockerHost(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "tcp://10.0.0.5:2376")
+	if host := DefaultDockerHost(); host != "tcp://10.0.0.5:2376" {
+		t.Errorf("Expected DOCKER_HOST to win, got %s", host)
+	}
+}
+
+func TestPermissionMiddleware(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	a := &Agent{info: Info{ReadOnly: true, Permissions: models.AgentPermissions}}
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api").Subrouter()
+	api.Use(a.permissionMiddleware)
+	api.HandleFunc("/containers/{id}/stop", ok).Methods("POST")
+	api.HandleFunc("/containers/{id}/remove", ok).Methods("DELETE")
+	api.HandleFunc("/images/prune", ok).Methods("POST")
+	api.HandleFunc("/containers", ok).Methods("GET")
+
+	do := func(method, path string) int {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec.Code
+	}
+
+	if code := do("POST", "/api/containers/abc/stop"); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for stop on a read-only agent, got %d", code)
+	}
+	if code := do("POST", "/api/images/prune"); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for prune on a read-only agent, got %d", code)
+	}
+	if code := do("GET", "/api/containers"); code != http.StatusOK {
+		t.Errorf("Expected listing containers to work on a read-only agent, got %d", code)
+	}
+
+	a.info.ReadOnly = false
+	if code := do("POST", "/api/containers/abc/stop"); code != http.StatusOK {
+		t.Errorf("Expected stop to work on a writable agent, got %d", code)
+	}
+
+	a.info.Permissions = []string{models.AgentPermissionLifecycle}
+	if code := do("POST", "/api/containers/abc/stop"); code != http.StatusOK {
+		t.Errorf("Expected stop to work with the lifecycle permission, got %d", code)
+	}
+	if code := do("DELETE", "/api/containers/abc/remove"); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for remove without the remove permission, got %d", code)
+	}
+	if code := do("POST", "/api/images/prune"); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for prune without the images permission, got %d", code)
+	}
+}
+
+func TestParsePermissions(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"all", "lifecycle,remove,update,images"},
+		{"none", ""},
+		{"", ""},
+		{" Lifecycle, update,lifecycle", "lifecycle,update"},
+	}
+	for _, tt := range tests {
+		got, err := ParsePermissions(tt.value)
+		if err != nil || strings.Join(got, ",") != tt.want {
+			t.Errorf("ParsePermissions(%q) = %v, %v; want %s", tt.value, got, err, tt.want)
+		}
+	}
+	if _, err := ParsePermissions("lifecycle,exec"); err == nil {
+		t.Error("Expected an unknown permission to be rejected")
+	}
+}
+
+func TestScanIDMiddleware(t *testing.T) {
+	var got string
+	handler := scanIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = r.Context().Value(scanIDKey{}).(string)
+	}))
+
+	req := httptest.NewRequest("GET", "/api/containers", nil)
+	req.Header.Set(models.ScanIDHeader, "0123456789abcdef")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got != "0123456789abcdef" || rec.Header().Get(models.ScanIDHeader) != "0123456789abcdef" {
+		t.Errorf("Expected the scan ID in the context and the response, got %q/%q", got, rec.Header().Get(models.ScanIDHeader))
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/containers", nil))
+	if got != "" || rec.Header().Get(models.ScanIDHeader) != "" {
+		t.Errorf("Expected no scan ID without the header, got %q", got)
+	}
+}
+
+func TestAuthMiddlewareSetToken(t *testing.T) {
+	a := &Agent{apiToken: "old"}
+	handler := a.authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	do := func(token string) int {
+		req := httptest.NewRequest("GET", "/api/containers", nil)
+		req.Header.Set("X-API-Token", token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := do("old"); code != http.StatusOK {
+		t.Errorf("Expected the token to be accepted, got %d", code)
+	}
+	a.SetToken("new")
+	if code := do("old"); code != http.StatusUnauthorized {
+		t.Errorf("Expected the replaced token to be rejected, got %d", code)
+	}
+	if code := do("new"); code != http.StatusOK {
+		t.Errorf("Expected the new token to be accepted, got %d", code)
+	}
+}
+
+func TestRotateToken(t *testing.T) {
+	a := &Agent{apiToken: "old"}
+	rotate := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/token/rotate", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		a.handleRotateToken(rec, req)
+		return rec
+	}
+
+	if rec := rotate(`{"overlap_seconds":60}`); rec.Code != http.StatusConflict {
+		t.Errorf("Expected rotation without a token store to be refused, got %d", rec.Code)
+	}
+
+	var saved string
+	a.SetTokenStore(func(token string) error {
+		saved = token
+		return nil
+	})
+	if rec := rotate(`{"overlap_seconds":-1}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected a negative overlap to be rejected, got %d", rec.Code)
+	}
+	rec := rotate(`{"overlap_seconds":60}`)
+	if rec.Code != http.StatusOK || saved == "" || saved != a.Token() || !strings.Contains(rec.Body.String(), saved) {
+		t.Fatalf("Expected the new token to be saved and returned, got %d %s", rec.Code, rec.Body.String())
+	}
+	if !a.validToken("old") || !a.validToken(saved) {
+		t.Error("Expected both tokens to be accepted during the overlap")
+	}
+
+	previous := saved
+	if rec := rotate(""); rec.Code != http.StatusOK {
+		t.Fatalf("Expected the rotation to succeed, got %d", rec.Code)
+	}
+	if a.validToken("old") || a.validToken(previous) || !a.validToken(saved) {
+		t.Error("Expected only the new token to be accepted without an overlap")
+	}
+}
+
+func TestValidateImageRef(t *testing.T) {
+	for _, image := range []string{
+		"nginx",
+		"nginx:1.27-alpine",
+		"ghcr.io/selfhosters-cc/container-census:latest",
+		"registry.local:5000/team/app@sha256:" + strings.Repeat("a", 64),
+		"sha256:" + strings.Repeat("b", 64),
+	} {
+		if err := validateImageRef(image); err != nil {
+			t.Errorf("Expected %q to be accepted, got %v", image, err)
+		}
+	}
+	for _, image := range []string{"--server=http://attacker", "-q", "--config=/etc/shadow", "nginx latest", "Nginx"} {
+		if err := validateImageRef(image); err == nil {
+			t.Errorf("Expected %q to be rejected", image)
+		}
+	}
+
+	// Rejected before trivy is looked for
+	a := &Agent{info: Info{TrivyAvailable: true}}
+	rec := httptest.NewRecorder()
+	a.handleScanImage(rec, httptest.NewRequest("POST", "/api/vulnerabilities/scan", strings.NewReader(`{"image":"--server=http://attacker"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a flag as image, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/container-census/container-census/internal/sysinfo"
+)
+
+// defaultCgroupRoot is where the agent looks for container cgroups; in a container the host's
+// /sys/fs/cgroup has to be mounted (read-only is enough) and CGROUP_ROOT pointed at it
+const defaultCgroupRoot = "/sys/fs/cgroup"
+
+// unlimitedCgroupMemory is at or above what cgroup v1 reports for a container without a memory limit
+const unlimitedCgroupMemory = 1 << 62
+
+// cgroupMemoryFiles are the usage and limit files of a container's memory cgroup, relative to
+// the cgroup root, for cgroup v2 and v1 with the systemd and cgroupfs drivers
+func cgroupMemoryFiles(containerID string) [][2]string {
+	scope := "docker-" + containerID + ".scope"
+	return [][2]string{
+		{filepath.Join("system.slice", scope, "memory.current"), filepath.Join("system.slice", scope, "memory.max")},
+		{filepath.Join("docker", containerID, "memory.current"), filepath.Join("docker", containerID, "memory.max")},
+		{filepath.Join("memory", "system.slice", scope, "memory.usage_in_bytes"), filepath.Join("memory", "system.slice", scope, "memory.limit_in_bytes")},
+		{filepath.Join("memory", "docker", containerID, "memory.usage_in_bytes"), filepath.Join("memory", "docker", containerID, "memory.limit_in_bytes")},
+	}
+}
+
+// cgroupMemory reads a container's memory usage and limit directly from its cgroup, for hosts
+// where the Docker stats API reports zeros (seen with some cgroup v2 setups, e.g. Raspberry Pi
+// OS without the memory controller enabled for Docker). Like Docker, a container without a
+// limit gets the host's total memory as its limit.
+func cgroupMemory(root, containerID string) (usage, limit int64, err error) {
+	for _, files := range cgroupMemoryFiles(containerID) {
+		usage, err = readCgroupValue(filepath.Join(root, files[0]))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+
+		limit, err = readCgroupValue(filepath.Join(root, files[1]))
+		if err != nil && !os.IsNotExist(err) {
+			return 0, 0, err
+		}
+		if limit <= 0 || limit >= unlimitedCgroupMemory {
+			if limit, err = sysinfo.MemTotal(); err != nil {
+				return 0, 0, err
+			}
+		}
+		return usage, limit, nil
+	}
+	return 0, 0, fmt.Errorf("no memory cgroup found for the container under %s", root)
+}
+
+// readCgroupValue reads a number from a cgroup file; "max" (no limit) reads as 0
+func readCgroupValue(path string) (int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	value := strings.TrimSpace(string(data))
+	if value == "max" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value in %s: %w", path, err)
+	}
+	return n, nil
+}
@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/container-census/container-census/internal/sysinfo"
+)
+
+func writeCgroupFile(t *testing.T, path, value string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(value+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCgroupMemory(t *testing.T) {
+	root := t.TempDir()
+
+	// cgroup v2 with the systemd driver and a limit
+	scope := filepath.Join(root, "system.slice", "docker-abc.scope")
+	writeCgroupFile(t, filepath.Join(scope, "memory.current"), "52428800")
+	writeCgroupFile(t, filepath.Join(scope, "memory.max"), "268435456")
+	usage, limit, err := cgroupMemory(root, "abc")
+	if err != nil {
+		t.Fatalf("cgroupMemory failed: %v", err)
+	}
+	if usage != 52428800 || limit != 268435456 {
+		t.Errorf("Expected 50MB/256MB, got %d/%d", usage, limit)
+	}
+
+	// cgroup v1 with the cgroupfs driver
+	v1 := filepath.Join(root, "memory", "docker", "def")
+	writeCgroupFile(t, filepath.Join(v1, "memory.usage_in_bytes"), "1048576")
+	writeCgroupFile(t, filepath.Join(v1, "memory.limit_in_bytes"), "536870912")
+	if usage, limit, err = cgroupMemory(root, "def"); err != nil || usage != 1048576 || limit != 536870912 {
+		t.Errorf("Expected 1MB/512MB from cgroup v1, got %d/%d (%v)", usage, limit, err)
+	}
+
+	if _, _, err := cgroupMemory(root, "missing"); err == nil {
+		t.Error("Expected an error for a container without a cgroup")
+	}
+
+	// No limit: the host's memory, like Docker reports it
+	if _, err := os.Stat("/proc/meminfo"); err == nil {
+		unlimited := filepath.Join(root, "docker", "ghi")
+		writeCgroupFile(t, filepath.Join(unlimited, "memory.current"), "4096")
+		writeCgroupFile(t, filepath.Join(unlimited, "memory.max"), "max")
+		total, err := sysinfo.MemTotal()
+		if err != nil {
+			t.Fatalf("MemTotal failed: %v", err)
+		}
+		if usage, limit, err = cgroupMemory(root, "ghi"); err != nil || usage != 4096 || limit != total {
+			t.Errorf("Expected the host's memory as limit %d, got %d/%d (%v)", total, usage, limit, err)
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// DaemonLogsJournald selects journald as the source of the kernel and Docker daemon logs;
+// any other -daemon-logs value is the path of a syslog file
+const DaemonLogsJournald = "journald"
+
+// maxDaemonEvents is how many daemon events the agent keeps for the server to collect
+const maxDaemonEvents = 500
+
+// daemonLogRetry is how long the agent waits before reading the logs again after journalctl
+// exits or the syslog file can't be read
+const daemonLogRetry = 30 * time.Second
+
+// daemonEvents keeps the latest daemon events, oldest first
+type daemonEvents struct {
+	mu     sync.Mutex
+	events []models.DaemonEvent
+}
+
+func (d *daemonEvents) add(event models.DaemonEvent) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.events = append(d.events, event)
+	if len(d.events) > maxDaemonEvents {
+		d.events = append([]models.DaemonEvent(nil), d.events[len(d.events)-maxDaemonEvents:]...)
+	}
+}
+
+// since returns the events that occurred at or after t
+func (d *daemonEvents) since(t time.Time) []models.DaemonEvent {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	events := make([]models.DaemonEvent, 0)
+	for _, e := range d.events {
+		if !e.OccurredAt.Before(t) {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// handleGetDaemonEvents returns the daemon events read from the host's logs at or after ?since=
+// (RFC 3339), for the server to collect after each scan
+func (a *Agent) handleGetDaemonEvents(w http.ResponseWriter, r *http.Request) {
+	var since time.Time
+	if value := r.URL.Query().Get("since"); value != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid since parameter: "+err.Error())
+			return
+		}
+		since = parsed
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"source": a.info.DaemonLogs,
+		"events": a.daemonEvents.since(since),
+	})
+}
+
+// WatchDaemonLogs follows the kernel and Docker daemon logs from journald or a syslog file until
+// ctx is done, keeping the OOM kills of containers and daemon errors it finds
+func (a *Agent) WatchDaemonLogs(ctx context.Context, source string) {
+	log.Printf("Watching kernel and Docker daemon logs from %s", source)
+	for {
+		var err error
+		if source == DaemonLogsJournald {
+			err = a.followJournald(ctx)
+		} else {
+			err = a.followSyslog(ctx, source)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Reading daemon logs from %s stopped: %v (retrying in %s)", source, err, daemonLogRetry)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(daemonLogRetry):
+		}
+	}
+}
+
+// followJournald reads new kernel, dockerd and containerd journal entries
+func (a *Agent) followJournald(ctx context.Context) error {
+	cmd := exec.CommandContext(ctx, "journalctl", "--follow", "--lines=0", "--output=json",
+		"_TRANSPORT=kernel", "+", "_SYSTEMD_UNIT=docker.service", "+", "_SYSTEMD_UNIT=containerd.service")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start journalctl: %w", err)
+	}
+
+	lines := bufio.NewScanner(stdout)
+	lines.Buffer(make([]byte, 64*1024), 1024*1024)
+	for lines.Scan() {
+		if source, message, at, ok := parseJournalEntry(lines.Bytes()); ok {
+			a.recordDaemonLog(ctx, source, message, at)
+		}
+	}
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("journalctl exited: %w", err)
+	}
+	return errors.New("journalctl exited")
+}
+
+// parseJournalEntry reads the source, message and time of a journalctl JSON line
+func parseJournalEntry(line []byte) (source, message string, at time.Time, ok bool) {
+	var entry struct {
+		Message    interface{} `json:"MESSAGE"`
+		Transport  string      `json:"_TRANSPORT"`
+		Identifier string      `json:"SYSLOG_IDENTIFIER"`
+		Timestamp  string      `json:"__REALTIME_TIMESTAMP"` // microseconds since the epoch
+	}
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return "", "", time.Time{}, false
+	}
+
+	switch m := entry.Message.(type) {
+	case string:
+		message = m
+	case []interface{}: // messages that aren't valid UTF-8 come as byte arrays
+		b := make([]byte, 0, len(m))
+		for _, v := range m {
+			n, _ := v.(float64)
+			b = append(b, byte(n))
+		}
+		message = string(b)
+	default:
+		return "", "", time.Time{}, false
+	}
+
+	source = entry.Identifier
+	if entry.Transport == "kernel" {
+		source = "kernel"
+	}
+	at = time.Now()
+	if micros, err := strconv.ParseInt(entry.Timestamp, 10, 64); err == nil {
+		at = time.UnixMicro(micros)
+	}
+	return source, message, at, true
+}
+
+// followSyslog reads the lines appended to a syslog file, reopening it when it is rotated
+func (a *Agent) followSyslog(ctx context.Context, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { f.Close() }()
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(f)
+	var partial string
+	for {
+		line, err := reader.ReadString('\n')
+		if err == nil {
+			if source, message, ok := parseSyslogLine(partial + line); ok {
+				a.recordDaemonLog(ctx, source, message, time.Now())
+			}
+			partial = ""
+			continue
+		}
+		if err != io.EOF {
+			return err
+		}
+		partial += line
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+
+		if syslogRotated(f, path) {
+			rotated, err := os.Open(path)
+			if err != nil {
+				continue // the new file isn't there yet
+			}
+			f.Close()
+			f = rotated
+			reader.Reset(f)
+			partial = ""
+		}
+	}
+}
+
+// syslogRotated reports whether the file at path was replaced or truncated since f was opened
+func syslogRotated(f *os.File, path string) bool {
+	current, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	opened, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	if !os.SameFile(current, opened) {
+		return true
+	}
+	offset, err := f.Seek(0, io.SeekCurrent)
+	return err == nil && current.Size() < offset
+}
+
+// syslogLinePattern finds the program and message of a kernel, dockerd or containerd syslog line
+var syslogLinePattern = regexp.MustCompile(`\s(kernel|dockerd|containerd)(?:\[\d+\])?:\s(.*)$`)
+
+// parseSyslogLine reads the source and message of a syslog line
+func parseSyslogLine(line string) (source, message string, ok bool) {
+	m := syslogLinePattern.FindStringSubmatch(strings.TrimRight(line, "\r\n"))
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2], true
+}
+
+var (
+	// Kernels since 4.19: "oom-kill:constraint=CONSTRAINT_MEMCG,...,task_memcg=/system.slice/docker-<id>.scope,task=java,pid=1234,uid=0"
+	oomKillPattern = regexp.MustCompile(`oom-kill:.*task_memcg=([^,]*),task=([^,]*),pid=(\d+)`)
+	// Older kernels: "Task in /docker/<id> killed as a result of limit of /docker/<id>"
+	oomTaskPattern = regexp.MustCompile(`Task in (\S+) killed as a result of limit`)
+	// The container of a memory cgroup, with the systemd and cgroupfs drivers
+	memcgContainerPattern = regexp.MustCompile(`(?:docker-|/docker/)([0-9a-f]{64})`)
+
+	logLevelPattern     = regexp.MustCompile(`\blevel=(\w+)`)
+	logMessagePattern   = regexp.MustCompile(`\bmsg="((?:[^"\\]|\\.)*)"`)
+	logContainerPattern = regexp.MustCompile(`(?i)container(?:id)?[ =:"]+([0-9a-f]{64})`)
+)
+
+// parseDaemonMessage turns a kernel OOM kill of a container's process or a dockerd/containerd
+// error into a daemon event; other messages return nil
+func parseDaemonMessage(source, message string, at time.Time) *models.DaemonEvent {
+	switch source {
+	case "kernel":
+		if m := oomKillPattern.FindStringSubmatch(message); m != nil {
+			id := memcgContainerID(m[1])
+			if id == "" {
+				return nil // not a container
+			}
+			pid, _ := strconv.Atoi(m[3])
+			return &models.DaemonEvent{
+				Kind:        models.DaemonEventOOMKill,
+				Source:      source,
+				ContainerID: id,
+				Process:     m[2],
+				PID:         pid,
+				Message:     fmt.Sprintf("Out of memory: killed process %s (%s)", m[3], m[2]),
+				OccurredAt:  at,
+			}
+		}
+		if m := oomTaskPattern.FindStringSubmatch(message); m != nil {
+			id := memcgContainerID(m[1])
+			if id == "" {
+				return nil
+			}
+			return &models.DaemonEvent{
+				Kind:        models.DaemonEventOOMKill,
+				Source:      source,
+				ContainerID: id,
+				Message:     "Out of memory: a process was killed",
+				OccurredAt:  at,
+			}
+		}
+
+	case "dockerd", "containerd":
+		level := logLevelPattern.FindStringSubmatch(message)
+		if level == nil || (level[1] != "error" && level[1] != "fatal" && level[1] != "panic") {
+			return nil
+		}
+		text := message
+		if m := logMessagePattern.FindStringSubmatch(message); m != nil {
+			if unquoted, err := strconv.Unquote(`"` + m[1] + `"`); err == nil {
+				text = unquoted
+			} else {
+				text = m[1]
+			}
+		}
+		// Errors answered to API clients (e.g. "No such container") aren't daemon problems
+		if strings.HasPrefix(text, "Handler for ") {
+			return nil
+		}
+		event := &models.DaemonEvent{
+			Kind:       models.DaemonEventError,
+			Source:     source,
+			Message:    text,
+			OccurredAt: at,
+		}
+		if m := logContainerPattern.FindStringSubmatch(message); m != nil {
+			event.ContainerID = m[1]
+		}
+		return event
+	}
+	return nil
+}
+
+// memcgContainerID returns the ID of the container a memory cgroup belongs to, or ""
+func memcgContainerID(memcg string) string {
+	if m := memcgContainerPattern.FindStringSubmatch(memcg); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// recordDaemonLog keeps the daemon event of a log message, if it is one, with the name of its
+// container
+func (a *Agent) recordDaemonLog(ctx context.Context, source, message string, at time.Time) {
+	event := parseDaemonMessage(source, message, at)
+	if event == nil {
+		return
+	}
+	if event.ContainerID != "" {
+		inspectCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		if info, err := a.dockerClient.ContainerInspect(inspectCtx, event.ContainerID); err == nil {
+			event.ContainerName = strings.TrimPrefix(info.Name, "/")
+		}
+		cancel()
+	}
+	log.Printf("Daemon event (%s): %s %s", event.Kind, event.ContainerName, event.Message)
+	a.daemonEvents.add(*event)
+}
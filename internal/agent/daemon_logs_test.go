@@ -0,0 +1,85 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+const testContainerID = "4f2d6c1e9a0b3c5d7e8f9a0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d"
+
+func TestParseDaemonMessage(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	// cgroup v2 with the systemd driver
+	e := parseDaemonMessage("kernel", "oom-kill:constraint=CONSTRAINT_MEMCG,nodemask=(null),cpuset=docker-"+testContainerID+
+		".scope,mems_allowed=0,oom_memcg=/system.slice/docker-"+testContainerID+".scope,task_memcg=/system.slice/docker-"+
+		testContainerID+".scope,task=java,pid=4321,uid=0", at)
+	if e == nil || e.Kind != models.DaemonEventOOMKill || e.ContainerID != testContainerID || e.Process != "java" || e.PID != 4321 {
+		t.Fatalf("Expected an OOM kill of java (4321) in the container, got %+v", e)
+	}
+	if !e.OccurredAt.Equal(at) {
+		t.Errorf("Expected the log time, got %v", e.OccurredAt)
+	}
+
+	// cgroup v1 with the cgroupfs driver, on older kernels
+	e = parseDaemonMessage("kernel", "Task in /docker/"+testContainerID+" killed as a result of limit of /docker/"+testContainerID, at)
+	if e == nil || e.Kind != models.DaemonEventOOMKill || e.ContainerID != testContainerID {
+		t.Fatalf("Expected an OOM kill in the container, got %+v", e)
+	}
+
+	// OOM kills outside containers aren't reported
+	if e := parseDaemonMessage("kernel", "oom-kill:constraint=CONSTRAINT_NONE,task_memcg=/user.slice,task=firefox,pid=99,uid=1000", at); e != nil {
+		t.Errorf("Expected no event for a process outside containers, got %+v", e)
+	}
+
+	// Daemon errors, with their container
+	e = parseDaemonMessage("dockerd", `time="2024-05-01T12:00:00Z" level=error msg="failed to start container: \"no space left\"" container=`+testContainerID, at)
+	if e == nil || e.Kind != models.DaemonEventError || e.Message != `failed to start container: "no space left"` || e.ContainerID != testContainerID {
+		t.Fatalf("Expected a daemon error of the container, got %+v", e)
+	}
+	if e := parseDaemonMessage("containerd", `level=info msg="loading plugin"`, at); e != nil {
+		t.Errorf("Expected no event for info messages, got %+v", e)
+	}
+	if e := parseDaemonMessage("dockerd", `level=error msg="Handler for GET /containers/abc/json returned error: No such container: abc"`, at); e != nil {
+		t.Errorf("Expected no event for API errors, got %+v", e)
+	}
+}
+
+func TestParseLogLines(t *testing.T) {
+	source, message, at, ok := parseJournalEntry([]byte(`{"MESSAGE":"oom-kill:task_memcg=/docker/x,task=a,pid=1","_TRANSPORT":"kernel","__REALTIME_TIMESTAMP":"1714564800000000"}`))
+	if !ok || source != "kernel" || !strings.HasPrefix(message, "oom-kill:") || !at.Equal(time.Unix(1714564800, 0)) {
+		t.Errorf("Unexpected journal entry: %q %q %v %v", source, message, at, ok)
+	}
+	source, message, _, ok = parseJournalEntry([]byte(`{"MESSAGE":[104,105],"_TRANSPORT":"stdout","SYSLOG_IDENTIFIER":"dockerd"}`))
+	if !ok || source != "dockerd" || message != "hi" {
+		t.Errorf("Unexpected journal entry: %q %q %v", source, message, ok)
+	}
+
+	source, message, ok = parseSyslogLine("May  1 12:00:00 host kernel: [12345.678] Task in /docker/x killed as a result of limit\n")
+	if !ok || source != "kernel" || message != "[12345.678] Task in /docker/x killed as a result of limit" {
+		t.Errorf("Unexpected syslog line: %q %q %v", source, message, ok)
+	}
+	if source, _, ok = parseSyslogLine("May  1 12:00:00 host dockerd[812]: level=error msg=x"); !ok || source != "dockerd" {
+		t.Errorf("Unexpected syslog line: %q %v", source, ok)
+	}
+	if _, _, ok = parseSyslogLine("May  1 12:00:00 host sshd[1]: Accepted publickey"); ok {
+		t.Error("Expected other programs to be skipped")
+	}
+}
+
+func TestDaemonEventsSince(t *testing.T) {
+	d := &daemonEvents{}
+	start := time.Now()
+	for i := 0; i < maxDaemonEvents+10; i++ {
+		d.add(models.DaemonEvent{Kind: models.DaemonEventError, OccurredAt: start.Add(time.Duration(i) * time.Second)})
+	}
+	if all := d.since(time.Time{}); len(all) != maxDaemonEvents || !all[0].OccurredAt.Equal(start.Add(10*time.Second)) {
+		t.Fatalf("Expected the latest %d events, got %d", maxDaemonEvents, len(all))
+	}
+	if recent := d.since(start.Add(time.Duration(maxDaemonEvents+8) * time.Second)); len(recent) != 2 {
+		t.Errorf("Expected 2 events since the time, got %d", len(recent))
+	}
+}
@@ -0,0 +1,50 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// Default Docker daemon endpoints
+const (
+	DefaultUnixHost    = "unix:///var/run/docker.sock"
+	DefaultWindowsHost = "npipe:////./pipe/docker_engine"
+)
+
+// desktopSockets are the per-user sockets of Docker daemons on macOS, relative to the home
+// directory. Docker Desktop only links /var/run/docker.sock when "Allow the default Docker
+// socket to be used" is enabled.
+var desktopSockets = []string{
+	".docker/run/docker.sock",     // Docker Desktop 4.13+
+	".colima/default/docker.sock", // Colima
+	".orbstack/run/docker.sock",   // OrbStack
+	".rd/docker.sock",             // Rancher Desktop
+}
+
+// DefaultDockerHost returns the Docker endpoint to use when none is configured: DOCKER_HOST if
+// set, the named pipe on Windows, the first existing socket on macOS, and the standard socket
+// elsewhere
+func DefaultDockerHost() string {
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		return host
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		return DefaultWindowsHost
+	case "darwin":
+		if _, err := os.Stat("/var/run/docker.sock"); err == nil {
+			return DefaultUnixHost
+		}
+		if home, err := os.UserHomeDir(); err == nil {
+			for _, socket := range desktopSockets {
+				path := filepath.Join(home, socket)
+				if _, err := os.Stat(path); err == nil {
+					return "unix://" + path
+				}
+			}
+		}
+	}
+	return DefaultUnixHost
+}
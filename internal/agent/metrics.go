@@ -0,0 +1,158 @@
+package agent
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// metrics counts the agent's scans and failed Docker API calls for /metrics and /api/metrics
+type metrics struct {
+	mu                sync.Mutex
+	scans             int64
+	scanErrors        int64
+	scanSeconds       float64 // total, for the average
+	lastScanSeconds   float64
+	dockerErrors      map[string]int64 // by operation
+	lastDockerError   string
+	lastDockerErrorAt time.Time
+}
+
+func newMetrics() *metrics {
+	return &metrics{dockerErrors: make(map[string]int64)}
+}
+
+// scanDone records a container listing (a scan by the server) and how long it took
+func (m *metrics) scanDone(duration time.Duration, failed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.scans++
+	if failed {
+		m.scanErrors++
+	}
+	m.scanSeconds += duration.Seconds()
+	m.lastScanSeconds = duration.Seconds()
+}
+
+// dockerError records a failed Docker API call
+func (m *metrics) dockerError(operation string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dockerErrors[operation]++
+	m.lastDockerError = fmt.Sprintf("%s: %v", operation, err)
+	m.lastDockerErrorAt = time.Now()
+}
+
+// snapshot returns the agent's health; Docker reachability is filled in by the caller
+func (m *metrics) snapshot(info Info) models.AgentHealth {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	health := models.AgentHealth{
+		Version:         info.Version,
+		UptimeSeconds:   int64(time.Since(info.StartedAt).Seconds()),
+		Scans:           m.scans,
+		ScanErrors:      m.scanErrors,
+		LastScanSeconds: m.lastScanSeconds,
+		DockerAPIErrors: make(map[string]int64, len(m.dockerErrors)),
+		LastDockerError: m.lastDockerError,
+		MemoryHeapBytes: int64(mem.HeapAlloc),
+		MemorySysBytes:  int64(mem.Sys),
+		Goroutines:      runtime.NumGoroutine(),
+	}
+	if m.scans > 0 {
+		health.AvgScanSeconds = m.scanSeconds / float64(m.scans)
+	}
+	for op, n := range m.dockerErrors {
+		health.DockerAPIErrors[op] = n
+	}
+	if !m.lastDockerErrorAt.IsZero() {
+		at := m.lastDockerErrorAt
+		health.LastDockerErrorAt = &at
+	}
+	return health
+}
+
+// health returns the agent's metrics after checking that Docker answers
+func (a *Agent) health(r *http.Request) models.AgentHealth {
+	_, err := a.dockerClient.Ping(r.Context())
+	if err != nil {
+		a.metrics.dockerError("ping", err)
+	}
+	health := a.metrics.snapshot(a.info)
+	health.DockerReachable = err == nil
+	health.Permissions = a.permissions()
+	if err == nil {
+		health.System = a.hostSystem(r.Context())
+	}
+	return health
+}
+
+// handleMetricsJSON serves the agent's health to the server
+func (a *Agent) handleMetricsJSON(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, a.health(r))
+}
+
+// handleMetrics serves the agent's health in the Prometheus text format
+func (a *Agent) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(prometheusMetrics(a.health(r))))
+}
+
+// prometheusMetrics renders an agent's health in the Prometheus text format
+func prometheusMetrics(h models.AgentHealth) string {
+	var b strings.Builder
+	metric := func(name, help, kind string, value interface{}) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n%s %v\n", name, help, name, kind, name, value)
+	}
+
+	fmt.Fprintf(&b, "# HELP census_agent_info Agent version\n# TYPE census_agent_info gauge\ncensus_agent_info{version=%q} 1\n", h.Version)
+	metric("census_agent_uptime_seconds", "Seconds since the agent started", "gauge", h.UptimeSeconds)
+	metric("census_agent_scans_total", "Container listings served to the server", "counter", h.Scans)
+	metric("census_agent_scan_errors_total", "Container listings that failed", "counter", h.ScanErrors)
+	metric("census_agent_last_scan_duration_seconds", "Duration of the latest container listing", "gauge", h.LastScanSeconds)
+	metric("census_agent_scan_duration_seconds_avg", "Average duration of container listings", "gauge", h.AvgScanSeconds)
+
+	b.WriteString("# HELP census_agent_docker_api_errors_total Failed Docker API calls by operation\n")
+	b.WriteString("# TYPE census_agent_docker_api_errors_total counter\n")
+	operations := make([]string, 0, len(h.DockerAPIErrors))
+	for op := range h.DockerAPIErrors {
+		operations = append(operations, op)
+	}
+	sort.Strings(operations)
+	for _, op := range operations {
+		fmt.Fprintf(&b, "census_agent_docker_api_errors_total{operation=%q} %d\n", op, h.DockerAPIErrors[op])
+	}
+
+	reachable := 0
+	if h.DockerReachable {
+		reachable = 1
+	}
+	metric("census_agent_docker_up", "Whether the Docker daemon answers a ping", "gauge", reachable)
+	metric("census_agent_memory_heap_bytes", "Live heap of the agent process", "gauge", h.MemoryHeapBytes)
+	metric("census_agent_memory_sys_bytes", "Memory the agent process obtained from the OS", "gauge", h.MemorySysBytes)
+	metric("census_agent_goroutines", "Goroutines of the agent process", "gauge", h.Goroutines)
+
+	if h.System != nil {
+		flag := func(v bool) int {
+			if v {
+				return 1
+			}
+			return 0
+		}
+		fmt.Fprintf(&b, "# HELP census_host_engine_info Docker engine and kernel of the host\n# TYPE census_host_engine_info gauge\ncensus_host_engine_info{docker_version=%q,kernel_version=%q} 1\n",
+			h.System.DockerVersion, h.System.KernelVersion)
+		metric("census_host_reboot_required", "Whether the host waits for a reboot", "gauge", flag(h.System.RebootRequired))
+		metric("census_host_docker_restart_required", "Whether an installed Docker update waits for a daemon restart", "gauge", flag(h.System.DaemonRestartRequired))
+	}
+	return b.String()
+}
@@ -0,0 +1,54 @@
+package agent
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsSnapshot(t *testing.T) {
+	m := newMetrics()
+	m.scanDone(200*time.Millisecond, false)
+	m.scanDone(400*time.Millisecond, true)
+	m.dockerError("list", errors.New("Cannot connect to the Docker daemon"))
+	m.dockerError("stats", errors.New("context deadline exceeded"))
+	m.dockerError("stats", errors.New("context deadline exceeded"))
+
+	health := m.snapshot(Info{Version: "1.2.3", StartedAt: time.Now().Add(-time.Minute)})
+	if health.Scans != 2 || health.ScanErrors != 1 {
+		t.Errorf("Expected 2 scans with 1 error, got %d/%d", health.Scans, health.ScanErrors)
+	}
+	if health.AvgScanSeconds < 0.299 || health.AvgScanSeconds > 0.301 || health.LastScanSeconds < 0.399 {
+		t.Errorf("Expected an average of 0.3s and a last scan of 0.4s, got %.3f/%.3f", health.AvgScanSeconds, health.LastScanSeconds)
+	}
+	if health.DockerAPIErrors["list"] != 1 || health.DockerAPIErrors["stats"] != 2 {
+		t.Errorf("Expected Docker API errors by operation, got %v", health.DockerAPIErrors)
+	}
+	if health.LastDockerError != "stats: context deadline exceeded" || health.LastDockerErrorAt == nil {
+		t.Errorf("Expected the latest Docker error, got %q", health.LastDockerError)
+	}
+	if health.UptimeSeconds < 59 || health.MemorySysBytes == 0 {
+		t.Errorf("Expected uptime and memory usage, got %d/%d", health.UptimeSeconds, health.MemorySysBytes)
+	}
+
+	// The snapshot is a copy
+	m.dockerError("list", errors.New("again"))
+	if health.DockerAPIErrors["list"] != 1 {
+		t.Error("Expected the snapshot not to change with later errors")
+	}
+
+	health.DockerReachable = true
+	text := prometheusMetrics(health)
+	for _, want := range []string{
+		`census_agent_info{version="1.2.3"} 1`,
+		"census_agent_scans_total 2\n",
+		`census_agent_docker_api_errors_total{operation="list"} 1`,
+		`census_agent_docker_api_errors_total{operation="stats"} 2`,
+		"census_agent_docker_up 1\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in the Prometheus output:\n%s", want, text)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// controlRoutes are the routes that change containers or images, as "METHOD template", with
+// the permission each needs
+var controlRoutes = map[string]string{
+	"POST /api/containers/{id}/start":    models.AgentPermissionLifecycle,
+	"POST /api/containers/{id}/stop":     models.AgentPermissionLifecycle,
+	"POST /api/containers/{id}/restart":  models.AgentPermissionLifecycle,
+	"DELETE /api/containers/{id}/remove": models.AgentPermissionRemove,
+	"POST /api/containers/{id}/recreate": models.AgentPermissionUpdate,
+	"POST /api/images/pull":              models.AgentPermissionUpdate,
+	"POST /api/images/tag":               models.AgentPermissionUpdate,
+	"DELETE /api/images/{id}/remove":     models.AgentPermissionImages,
+	"POST /api/images/prune":             models.AgentPermissionImages,
+}
+
+// ParsePermissions parses -allow: a comma-separated list of models.AgentPermissions, "all" or
+// "none"
+func ParsePermissions(value string) ([]string, error) {
+	permissions := []string{}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "":
+		case "all":
+			return append([]string{}, models.AgentPermissions...), nil
+		case "none":
+			return []string{}, nil
+		default:
+			known := false
+			for _, p := range models.AgentPermissions {
+				known = known || p == name
+			}
+			if !known {
+				return nil, fmt.Errorf("unknown permission %q (use %s, all or none)", name, strings.Join(models.AgentPermissions, ", "))
+			}
+			if !containsString(permissions, name) {
+				permissions = append(permissions, name)
+			}
+		}
+	}
+	return permissions, nil
+}
+
+// permissions returns the Docker operations the agent allows
+func (a *Agent) permissions() []string {
+	if a.info.ReadOnly {
+		return []string{}
+	}
+	return append([]string{}, a.info.Permissions...)
+}
+
+// permissionMiddleware rejects the routes in controlRoutes whose permission the agent wasn't
+// given, so the agent refuses them even if the server is compromised or its Docker socket is
+// writable
+func (a *Agent) permissionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if route := mux.CurrentRoute(r); route != nil {
+			template, err := route.GetPathTemplate()
+			if permission, ok := controlRoutes[r.Method+" "+template]; err == nil && ok {
+				if a.info.ReadOnly {
+					respondError(w, http.StatusForbidden, "Agent is read-only: Docker operations are disabled")
+					return
+				}
+				if !containsString(a.info.Permissions, permission) {
+					respondError(w, http.StatusForbidden, "Agent doesn't allow "+permission+" operations (see its -allow flag)")
+					return
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
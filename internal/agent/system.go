@@ -0,0 +1,142 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// defaultHostRoot is where the agent looks for the host's reboot markers, kernels and dockerd; in
+// a container the host's /run, /lib/modules and /usr/bin have to be mounted under one directory
+// (read-only is enough) and HOST_ROOT pointed at it
+const defaultHostRoot = "/"
+
+// hostSystemTTL is how long a host system report is reused; it changes only with package updates
+const hostSystemTTL = 5 * time.Minute
+
+// rebootRequiredFiles are the markers Debian and Ubuntu leave when an update needs a reboot
+var rebootRequiredFiles = []string{"run/reboot-required", "var/run/reboot-required"}
+
+// dockerdVersionPattern finds the version in `dockerd --version` (Docker version 27.3.1, build 41ca978)
+var dockerdVersionPattern = regexp.MustCompile(`version ([0-9][^\s,]*)`)
+
+// hostSystem returns the engine and OS versions of the agent's host and whether a reboot or
+// daemon restart is pending, from a cached report when it is recent
+func (a *Agent) hostSystem(ctx context.Context) *models.HostSystem {
+	a.systemMu.Lock()
+	defer a.systemMu.Unlock()
+	if a.system != nil && time.Since(a.system.CollectedAt) < hostSystemTTL {
+		return a.system
+	}
+
+	system := &models.HostSystem{DockerVersion: a.info.DockerVersion, CollectedAt: time.Now()}
+	if info, err := a.dockerClient.Info(ctx); err != nil {
+		a.metrics.dockerError("info", err)
+	} else {
+		system.DockerVersion = info.ServerVersion
+		system.OperatingSystem = info.OperatingSystem
+		system.KernelVersion = info.KernelVersion
+	}
+	if version, err := a.dockerClient.ServerVersion(ctx); err == nil {
+		system.APIVersion = version.APIVersion
+	}
+
+	system.RebootReasons = rebootReasons(a.hostRoot, system.KernelVersion)
+	system.RebootRequired = len(system.RebootReasons) > 0
+	system.InstalledDockerVersion = installedDockerVersion(ctx, a.hostRoot)
+	system.DaemonRestartRequired = system.InstalledDockerVersion != "" && system.DockerVersion != "" &&
+		system.InstalledDockerVersion != system.DockerVersion
+
+	a.system = system
+	return system
+}
+
+// rebootReasons tells why the host waits for a reboot: the OS's reboot-required marker (with the
+// packages asking for it) or a newer kernel installed than the running one
+func rebootReasons(root, runningKernel string) []string {
+	var reasons []string
+	for _, file := range rebootRequiredFiles {
+		path := filepath.Join(root, file)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		reason := "reboot-required marker present"
+		if pkgs, err := os.ReadFile(path + ".pkgs"); err == nil {
+			if names := strings.Fields(string(pkgs)); len(names) > 0 {
+				reason += " (" + strings.Join(uniqueStrings(names), ", ") + ")"
+			}
+		}
+		reasons = append(reasons, reason)
+		break
+	}
+
+	if kernel := newestKernel(filepath.Join(root, "lib/modules")); kernel != "" && runningKernel != "" &&
+		models.CompareVersions(kernel, runningKernel) > 0 {
+		reasons = append(reasons, "kernel "+kernel+" installed, running "+runningKernel)
+	}
+	return reasons
+}
+
+// newestKernel returns the newest kernel release with modules installed, or "" when none are found
+func newestKernel(modulesDir string) string {
+	entries, err := os.ReadDir(modulesDir)
+	if err != nil {
+		return ""
+	}
+	newest := ""
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if newest == "" || models.CompareVersions(entry.Name(), newest) > 0 {
+			newest = entry.Name()
+		}
+	}
+	return newest
+}
+
+// installedDockerVersion returns the version of the dockerd binary on disk, which differs from
+// the running engine after a package update until the daemon restarts. Returns "" when dockerd
+// isn't found.
+func installedDockerVersion(ctx context.Context, root string) string {
+	for _, dir := range []string{"usr/bin", "usr/local/bin", "usr/sbin"} {
+		path := filepath.Join(root, dir, "dockerd")
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		out, err := exec.CommandContext(ctx, path, "--version").Output()
+		cancel()
+		if err != nil {
+			return ""
+		}
+		return parseDockerdVersion(string(out))
+	}
+	return ""
+}
+
+// parseDockerdVersion extracts the version from the output of `dockerd --version`
+func parseDockerdVersion(output string) string {
+	if m := dockerdVersionPattern.FindStringSubmatch(output); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := values[:0]
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,54 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRebootReasons(t *testing.T) {
+	root := t.TempDir()
+	if reasons := rebootReasons(root, "6.8.0-45-generic"); len(reasons) != 0 {
+		t.Fatalf("expected no reasons on a clean host, got %v", reasons)
+	}
+
+	for _, kernel := range []string{"6.8.0-45-generic", "6.8.0-47-generic", "6.5.0-9-generic"} {
+		if err := os.MkdirAll(filepath.Join(root, "lib/modules", kernel), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(root, "run"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(root, "run/reboot-required"), []byte("*** System restart required ***\n"), 0o644)
+	os.WriteFile(filepath.Join(root, "run/reboot-required.pkgs"), []byte("linux-image-6.8.0-47-generic\nlibc6\nlibc6\n"), 0o644)
+
+	reasons := rebootReasons(root, "6.8.0-45-generic")
+	if len(reasons) != 2 {
+		t.Fatalf("expected the marker and the kernel as reasons, got %v", reasons)
+	}
+	if reasons[0] != "reboot-required marker present (linux-image-6.8.0-47-generic, libc6)" {
+		t.Errorf("unexpected marker reason %q", reasons[0])
+	}
+	if reasons[1] != "kernel 6.8.0-47-generic installed, running 6.8.0-45-generic" {
+		t.Errorf("unexpected kernel reason %q", reasons[1])
+	}
+
+	if reasons := rebootReasons(root, "6.8.0-47-generic"); len(reasons) != 1 {
+		t.Errorf("expected only the marker when running the newest kernel, got %v", reasons)
+	}
+}
+
+func TestParseDockerdVersion(t *testing.T) {
+	tests := map[string]string{
+		"Docker version 27.3.1, build 41ca978\n":         "27.3.1",
+		"Docker version 20.10.24+dfsg1, build 297e128\n": "20.10.24+dfsg1",
+		"Docker version 24.0.7-ce, build 311b9ff0aa93\n": "24.0.7-ce",
+		"dockerd: command not found\n":                   "",
+	}
+	for output, want := range tests {
+		if got := parseDockerdVersion(output); got != want {
+			t.Errorf("parseDockerdVersion(%q) = %q, want %q", output, got, want)
+		}
+	}
+}
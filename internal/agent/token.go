@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// MaxTokenOverlap is the longest the previous token stays valid after a rotation
+const MaxTokenOverlap = 7 * 24 * time.Hour
+
+// SetToken replaces the API token requests must carry. A token rotated by the server before
+// keeps its overlap.
+func (a *Agent) SetToken(token string) {
+	a.tokenMu.Lock()
+	defer a.tokenMu.Unlock()
+	a.apiToken = token
+}
+
+// Token returns the current API token
+func (a *Agent) Token() string {
+	a.tokenMu.RLock()
+	defer a.tokenMu.RUnlock()
+	return a.apiToken
+}
+
+// SetTokenStore sets where tokens rotated by the server are saved, so they survive a restart.
+// Without one the agent refuses rotation: its token comes from -token or API_TOKEN.
+func (a *Agent) SetTokenStore(save func(token string) error) {
+	a.tokenMu.Lock()
+	defer a.tokenMu.Unlock()
+	a.saveToken = save
+}
+
+// validToken reports whether a request's token is the current one, or the one before the last
+// rotation during its overlap
+func (a *Agent) validToken(token string) bool {
+	a.tokenMu.RLock()
+	defer a.tokenMu.RUnlock()
+	if token == "" {
+		return false
+	}
+	return token == a.apiToken || (token == a.oldToken && time.Now().Before(a.oldExpires))
+}
+
+// handleRotateToken replaces the token with a new one, saved before it is returned. The
+// previous token stays valid for overlap_seconds (at most MaxTokenOverlap), so requests the
+// server sent with it and other servers using it keep working until they have the new one.
+func (a *Agent) handleRotateToken(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		OverlapSeconds int64 `json:"overlap_seconds"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+			return
+		}
+	}
+	overlap := time.Duration(req.OverlapSeconds) * time.Second
+	if overlap < 0 || overlap > MaxTokenOverlap {
+		respondError(w, http.StatusBadRequest, "overlap_seconds must be between 0 and 604800")
+		return
+	}
+
+	a.tokenMu.Lock()
+	defer a.tokenMu.Unlock()
+	if a.saveToken == nil {
+		respondError(w, http.StatusConflict, "The agent's token is set by -token or API_TOKEN and can't be rotated")
+		return
+	}
+	token := GenerateToken()
+	if err := a.saveToken(token); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save the new token: "+err.Error())
+		return
+	}
+	a.oldToken, a.oldExpires = a.apiToken, time.Now().Add(overlap)
+	a.apiToken = token
+	logf(r.Context(), "API token rotated by the server; the previous token is accepted until %s", a.oldExpires.Format(time.RFC3339))
+
+	respondJSON(w, http.StatusOK, models.RotatedAgentToken{Token: token, PreviousExpiresAt: a.oldExpires})
+}
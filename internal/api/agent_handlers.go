@@ -8,14 +8,17 @@ import (
 	"strings"
 	"time"
 
+	"github.com/container-census/container-census/internal/broker"
+	"github.com/container-census/container-census/internal/incus"
 	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/registry"
 	"github.com/gorilla/mux"
 )
 
 // detectHostType determines host type from address
 func detectHostType(address string) string {
 	switch {
-	case strings.HasPrefix(address, "agent://"), strings.HasPrefix(address, "http://"), strings.HasPrefix(address, "https://"):
+	case strings.HasPrefix(address, "agent://"), strings.HasPrefix(address, "http://"), strings.HasPrefix(address, "https://"), broker.IsAddress(address):
 		return "agent"
 	case strings.HasPrefix(address, "unix://"):
 		return "unix"
@@ -23,6 +26,8 @@ func detectHostType(address string) string {
 		return "tcp"
 	case strings.HasPrefix(address, "ssh://"):
 		return "ssh"
+	case incus.IsAddress(address):
+		return models.HostTypeIncus
 	case address == "" || address == "local":
 		return "unix"
 	default:
@@ -33,11 +38,16 @@ func detectHostType(address string) string {
 // handleAddAgentHost adds a new agent-based host
 func (s *Server) handleAddAgentHost(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Name         string `json:"name"`
-		Address      string `json:"address"`
-		Description  string `json:"description"`
-		AgentToken   string `json:"agent_token"`
-		CollectStats bool   `json:"collect_stats"`
+		Name           string `json:"name"`
+		Address        string `json:"address"`
+		Description    string `json:"description"`
+		AgentToken     string `json:"agent_token"`
+		CollectStats   *bool  `json:"collect_stats"`
+		RegistryMirror string `json:"registry_mirror"`
+		Site           string `json:"site"`
+		// Optional: start from a host template, or from the settings of an existing host
+		TemplateID  int64 `json:"template_id"`
+		CloneHostID int64 `json:"clone_host_id"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -59,6 +69,12 @@ func (s *Server) handleAddAgentHost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	template, status, err := s.addHostTemplate(r, req.TemplateID, req.CloneHostID)
+	if err != nil {
+		respondError(w, status, err.Error())
+		return
+	}
+
 	// Detect host type
 	hostType := detectHostType(req.Address)
 
@@ -67,14 +83,22 @@ func (s *Server) handleAddAgentHost(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	host := models.Host{
-		Name:         req.Name,
-		Address:      req.Address,
-		Description:  req.Description,
-		HostType:     hostType,
-		AgentToken:   req.AgentToken,
-		AgentStatus:  "unknown",
-		Enabled:      true,
-		CollectStats: req.CollectStats,
+		Name:           req.Name,
+		Address:        req.Address,
+		Description:    req.Description,
+		HostType:       hostType,
+		AgentToken:     req.AgentToken,
+		AgentStatus:    "unknown",
+		Enabled:        true,
+		RegistryMirror: registry.NormalizeMirror(req.RegistryMirror),
+		Site:           strings.TrimSpace(req.Site),
+		TenantID:       identity(r).TenantID,
+	}
+	if template != nil {
+		template.ApplyTo(&host)
+	}
+	if req.CollectStats != nil {
+		host.CollectStats = *req.CollectStats
 	}
 
 	// Try to ping the agent
@@ -106,6 +130,7 @@ func (s *Server) handleAddAgentHost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	host.ID = id
+	s.createHostTemplateRules(template, host)
 	respondJSON(w, http.StatusCreated, host)
 }
 
@@ -197,11 +222,19 @@ func (s *Server) handleGetAgentInfo(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, info)
 }
 
-// getAgentInfo fetches agent information
+// getAgentInfo fetches agent information, with the Docker operations the agent allows
 func (s *Server) getAgentInfo(ctx context.Context, host models.Host) (*models.AgentInfo, error) {
-	// We would need to add this method to the scanner or create an HTTP client here
-	// For now, return a placeholder
-	return &models.AgentInfo{
-		Hostname: host.Name,
-	}, nil
+	return s.scanner.GetAgentInfo(ctx, host)
+}
+
+// attachAgentHealth adds what each agent reported about itself after its latest scan
+func (s *Server) attachAgentHealth(hosts []models.Host) {
+	if s.scanner == nil {
+		return
+	}
+	for i := range hosts {
+		if hosts[i].HostType == "agent" {
+			hosts[i].AgentHealth = s.scanner.AgentHealth(hosts[i].ID)
+		}
+	}
 }
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// DefaultAgentTokenOverlap is how long an agent accepts its previous token after a rotation
+	DefaultAgentTokenOverlap = time.Hour
+	// agentTokenRetryInterval is how long a scheduled rotation that failed waits for the next try
+	agentTokenRetryInterval = 6 * time.Hour
+)
+
+// SetAgentTokenPolicy sets the age after which RotateExpiredAgentTokens rotates agent tokens
+// (zero: never) and how long agents accept the previous token after a rotation
+func (s *Server) SetAgentTokenPolicy(maxAge, overlap time.Duration) {
+	s.agentTokenMaxAge = maxAge
+	s.agentTokenOverlap = overlap
+}
+
+// agentTokenOverlapOrDefault returns how long agents accept the previous token after a rotation
+func (s *Server) agentTokenOverlapOrDefault() time.Duration {
+	if s.agentTokenOverlap > 0 {
+		return s.agentTokenOverlap
+	}
+	return DefaultAgentTokenOverlap
+}
+
+// handleGetAgentTokenAges returns the token age of the agent hosts a request may see, oldest first
+func (s *Server) handleGetAgentTokenAges(w http.ResponseWriter, r *http.Request) {
+	var hostIDs []int64
+	if !identity(r).IsAdmin() {
+		hosts, err := s.db.GetHosts()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to get hosts: "+err.Error())
+			return
+		}
+		hostIDs = []int64{}
+		for _, host := range visibleHosts(r, hosts) {
+			hostIDs = append(hostIDs, host.ID)
+		}
+	}
+
+	ages, err := s.db.GetAgentTokenAges(hostIDs)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get agent token ages: "+err.Error())
+		return
+	}
+	now := time.Now()
+	for i := range ages {
+		age := now.Sub(ages[i].ChangedAt)
+		ages[i].AgeDays = int(age.Hours() / 24)
+		ages[i].Expired = s.agentTokenMaxAge > 0 && age > s.agentTokenMaxAge
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"max_age_days":    int(s.agentTokenMaxAge.Hours() / 24),
+		"overlap_minutes": int(s.agentTokenOverlapOrDefault().Minutes()),
+		"hosts":           ages,
+	})
+}
+
+// handleRotateAgentToken has an agent issue a new token and saves it to the host. The body may
+// set overlap_minutes, how long the agent still accepts the previous token.
+func (s *Server) handleRotateAgentToken(w http.ResponseWriter, r *http.Request) {
+	hostID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid host ID")
+		return
+	}
+	var req struct {
+		OverlapMinutes *int `json:"overlap_minutes"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+			return
+		}
+	}
+	overlap := s.agentTokenOverlapOrDefault()
+	if req.OverlapMinutes != nil {
+		if *req.OverlapMinutes < 0 || *req.OverlapMinutes > 7*24*60 {
+			respondError(w, http.StatusBadRequest, "overlap_minutes must be between 0 and 10080")
+			return
+		}
+		overlap = time.Duration(*req.OverlapMinutes) * time.Minute
+	}
+
+	host, err := s.db.GetHost(hostID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Host not found")
+		return
+	}
+	if host.HostType != models.HostTypeAgent {
+		respondError(w, http.StatusBadRequest, "Host is not an agent")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	rotated, err := s.rotateAgentToken(ctx, *host, models.TokenRotationManual, overlap)
+	if err != nil {
+		if rotated != nil {
+			// The agent uses the new token already: show it, so it can be entered by hand
+			respondJSON(w, http.StatusInternalServerError, map[string]interface{}{
+				"error": err.Error(),
+				"token": rotated.Token,
+			})
+			return
+		}
+		respondError(w, http.StatusBadGateway, "Failed to rotate the agent token: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"host_id":             host.ID,
+		"token":               rotated.Token,
+		"previous_expires_at": rotated.PreviousExpiresAt,
+	})
+}
+
+// rotateAgentToken has a host's agent issue a new token, then saves it to the host while the
+// agent still accepts the previous one. Every attempt is recorded. If saving fails after the
+// agent rotated, the new token is returned with the error.
+func (s *Server) rotateAgentToken(ctx context.Context, host models.Host, source string, overlap time.Duration) (*models.RotatedAgentToken, error) {
+	rotation := models.AgentTokenRotation{HostID: host.ID, RotatedAt: time.Now(), Source: source}
+	rotated, err := s.scanner.RotateAgentToken(ctx, host, overlap)
+	if err != nil {
+		rotation.Error = err.Error()
+		if recordErr := s.db.RecordAgentTokenRotation(rotation); recordErr != nil {
+			log.Printf("Failed to record the agent token rotation of %s: %v", host.Name, recordErr)
+		}
+		return nil, err
+	}
+
+	rotation.PreviousExpiresAt = &rotated.PreviousExpiresAt
+	if err := s.db.SaveRotatedAgentToken(host.ID, host.AgentToken, rotated.Token, rotation); err != nil {
+		err = fmt.Errorf("the agent of %s rotated its token but saving it failed: %w; the previous token works until %s",
+			host.Name, err, rotated.PreviousExpiresAt.Format(time.RFC3339))
+		log.Print(err)
+		rotation.Error = err.Error()
+		if recordErr := s.db.RecordAgentTokenRotation(rotation); recordErr != nil {
+			log.Printf("Failed to record the agent token rotation of %s: %v", host.Name, recordErr)
+		}
+		return rotated, err
+	}
+	s.cache.Invalidate()
+	log.Printf("Rotated the agent token of %s (%s); the previous token works until %s", host.Name, source, rotated.PreviousExpiresAt.Format(time.RFC3339))
+	return rotated, nil
+}
+
+// RotateExpiredAgentTokens rotates the tokens of enabled agent hosts older than the maximum age
+// of SetAgentTokenPolicy. Hosts whose last attempt failed are retried after
+// agentTokenRetryInterval. It returns how many tokens were rotated and how many failed.
+func (s *Server) RotateExpiredAgentTokens(ctx context.Context) (rotated, failed int) {
+	if s.agentTokenMaxAge <= 0 {
+		return 0, 0
+	}
+	ages, err := s.db.GetAgentTokenAges(nil)
+	if err != nil {
+		log.Printf("Failed to get agent token ages: %v", err)
+		return 0, 0
+	}
+
+	now := time.Now()
+	for _, age := range ages {
+		if !age.Enabled || now.Sub(age.ChangedAt) <= s.agentTokenMaxAge {
+			continue
+		}
+		if last := age.LastRotation; last != nil && !last.Success && now.Sub(last.RotatedAt) < agentTokenRetryInterval {
+			continue
+		}
+		host, err := s.db.GetHost(age.HostID)
+		if err != nil {
+			continue
+		}
+		rotateCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		_, err = s.rotateAgentToken(rotateCtx, *host, models.TokenRotationScheduled, s.agentTokenOverlapOrDefault())
+		cancel()
+		if err != nil {
+			log.Printf("Scheduled agent token rotation of %s failed: %v", host.Name, err)
+			failed++
+			continue
+		}
+		rotated++
+	}
+	return rotated, failed
+}
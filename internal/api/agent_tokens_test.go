@@ -0,0 +1,89 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/auth"
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/scanner"
+	"github.com/gorilla/mux"
+)
+
+func TestRotateAgentToken(t *testing.T) {
+	server, db := setupTestServer(t)
+	server.scanner = scanner.New(5)
+
+	token := "old"
+	agentServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Token") != token {
+			http.Error(w, `{"error":"Invalid or missing API token"}`, http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			OverlapSeconds int64 `json:"overlap_seconds"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		token = "new"
+		json.NewEncoder(w).Encode(models.RotatedAgentToken{Token: token, PreviousExpiresAt: time.Now().Add(time.Duration(req.OverlapSeconds) * time.Second)})
+	}))
+	defer agentServer.Close()
+
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: agentServer.URL, HostType: models.HostTypeAgent, AgentToken: "old", Enabled: true})
+	if err != nil {
+		t.Fatalf("AddHost failed: %v", err)
+	}
+
+	call := func(handler http.HandlerFunc, method, target, body string, vars map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req = mux.SetURLVars(req, vars)
+		req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{Username: "admin"}))
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+	vars := map[string]string{"id": itoa(hostID)}
+
+	w := call(server.handleRotateAgentToken, http.MethodPost, "/api/hosts/1/agent-token/rotate", `{"overlap_minutes":30}`, vars)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"token":"new"`) {
+		t.Fatalf("Expected the new token, got %d: %s", w.Code, w.Body.String())
+	}
+	if host, _ := db.GetHost(hostID); host.AgentToken != "new" {
+		t.Errorf("Expected the host to have the new token, got %q", host.AgentToken)
+	}
+
+	// The agent rejects the old token the host no longer has: the attempt is recorded
+	db.UpdateHost(models.Host{ID: hostID, Name: "nas", Address: agentServer.URL, HostType: models.HostTypeAgent, AgentToken: "stale", Enabled: true})
+	if w := call(server.handleRotateAgentToken, http.MethodPost, "/api/hosts/1/agent-token/rotate", "", vars); w.Code != http.StatusBadGateway {
+		t.Errorf("Expected 502 for a rejected token, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(server.handleRotateAgentToken, http.MethodPost, "/api/hosts/1/agent-token/rotate", `{"overlap_minutes":-1}`, vars); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a negative overlap, got %d", w.Code)
+	}
+
+	server.SetAgentTokenPolicy(24*time.Hour, 0)
+	w = call(server.handleGetAgentTokenAges, http.MethodGet, "/api/hosts/agent-tokens", "", nil)
+	var audit struct {
+		MaxAgeDays     int                    `json:"max_age_days"`
+		OverlapMinutes int                    `json:"overlap_minutes"`
+		Hosts          []models.AgentTokenAge `json:"hosts"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &audit); err != nil || len(audit.Hosts) != 1 {
+		t.Fatalf("Expected the audit of one host, got %s", w.Body.String())
+	}
+	if audit.MaxAgeDays != 1 || audit.OverlapMinutes != 60 || audit.Hosts[0].Expired {
+		t.Errorf("Unexpected audit %+v", audit)
+	}
+	if r := audit.Hosts[0].LastRotation; r == nil || r.Success || r.Source != models.TokenRotationManual {
+		t.Errorf("Expected the failed manual rotation last, got %+v", r)
+	}
+
+	// Young tokens aren't rotated on a schedule
+	if rotated, failed := server.RotateExpiredAgentTokens(t.Context()); rotated != 0 || failed != 0 {
+		t.Errorf("Expected nothing to rotate, got %d rotated and %d failed", rotated, failed)
+	}
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// handleGetAnnotations lists the annotations overlapping a period, oldest first. Query: start
+// and end (RFC3339, open when missing), host_id (its annotations and the global ones) and
+// container_name (leaves out those of other containers). Tenant users see the global
+// annotations and those of their hosts.
+func (s *Server) handleGetAnnotations(w http.ResponseWriter, r *http.Request) {
+	hostIDs, ok := s.queryHostIDs(w, r)
+	if !ok {
+		return
+	}
+	filter := models.AnnotationFilter{HostIDs: hostIDs, ContainerName: r.URL.Query().Get("container_name")}
+	for param, t := range map[string]*time.Time{"start": &filter.Start, "end": &filter.End} {
+		if v := r.URL.Query().Get(param); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				respondError(w, http.StatusBadRequest, "Invalid "+param+" time format")
+				return
+			}
+			*t = parsed
+		}
+	}
+
+	annotations, err := s.db.GetAnnotations(filter)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get annotations: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, annotations)
+}
+
+// handleCreateAnnotation adds an annotation ({"at", "end_at", "text", "host_id",
+// "container_name"}); a missing time is now
+func (s *Server) handleCreateAnnotation(w http.ResponseWriter, r *http.Request) {
+	annotation, ok := s.decodeAnnotation(w, r)
+	if !ok {
+		return
+	}
+	annotation.CreatedAt = time.Now()
+	annotation.CreatedBy = identity(r).Username
+
+	id, err := s.db.CreateAnnotation(annotation)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create annotation: "+err.Error())
+		return
+	}
+	s.respondAnnotation(w, http.StatusCreated, id)
+}
+
+// handleUpdateAnnotation replaces the time, text and target of an annotation
+func (s *Server) handleUpdateAnnotation(w http.ResponseWriter, r *http.Request) {
+	id, ok := annotationID(w, r)
+	if !ok {
+		return
+	}
+	annotation, ok := s.decodeAnnotation(w, r)
+	if !ok {
+		return
+	}
+	annotation.ID = id
+
+	if err := s.db.UpdateAnnotation(annotation); err == sql.ErrNoRows {
+		respondError(w, http.StatusNotFound, "Annotation not found")
+		return
+	} else if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update annotation: "+err.Error())
+		return
+	}
+	s.respondAnnotation(w, http.StatusOK, id)
+}
+
+// handleDeleteAnnotation removes an annotation
+func (s *Server) handleDeleteAnnotation(w http.ResponseWriter, r *http.Request) {
+	id, ok := annotationID(w, r)
+	if !ok {
+		return
+	}
+	if err := s.db.DeleteAnnotation(id); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete annotation: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Annotation deleted"})
+}
+
+// decodeAnnotation reads and checks the annotation of a request body; its host must exist
+func (s *Server) decodeAnnotation(w http.ResponseWriter, r *http.Request) (models.Annotation, bool) {
+	var annotation models.Annotation
+	if err := json.NewDecoder(r.Body).Decode(&annotation); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return annotation, false
+	}
+	if annotation.At.IsZero() {
+		annotation.At = time.Now()
+	}
+	if err := annotation.Normalize(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return annotation, false
+	}
+	if annotation.HostID != 0 {
+		if _, err := s.db.GetHost(annotation.HostID); err != nil {
+			respondError(w, http.StatusBadRequest, "Host not found")
+			return annotation, false
+		}
+	}
+	return annotation, true
+}
+
+// respondAnnotation answers with an annotation as stored, with its host name
+func (s *Server) respondAnnotation(w http.ResponseWriter, status int, id int64) {
+	annotation, err := s.db.GetAnnotation(id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get annotation: "+err.Error())
+		return
+	}
+	respondJSON(w, status, annotation)
+}
+
+// annotationID returns the id route variable
+func annotationID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid annotation ID")
+		return 0, false
+	}
+	return id, true
+}
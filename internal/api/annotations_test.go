@@ -0,0 +1,89 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/container-census/container-census/internal/auth"
+	"github.com/container-census/container-census/internal/models"
+	"github.com/gorilla/mux"
+)
+
+func TestAnnotationHandlers(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: "unix:///var/run/docker.sock", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	call := func(handler http.HandlerFunc, method, target, body string, vars map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req = mux.SetURLVars(req, vars)
+		req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{Username: "alice"}))
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	w := call(server.handleCreateAnnotation, http.MethodPost, "/api/annotations",
+		`{"at":"2026-10-01T02:00:00Z","end_at":"2026-10-01T05:00:00Z","text":"  Power outage  "}`, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var outage models.Annotation
+	if err := json.Unmarshal(w.Body.Bytes(), &outage); err != nil {
+		t.Fatalf("Failed to decode annotation: %v", err)
+	}
+	if outage.ID == 0 || outage.Text != "Power outage" || outage.CreatedBy != "alice" || outage.EndAt == nil {
+		t.Errorf("Unexpected annotation %+v", outage)
+	}
+
+	w = call(server.handleCreateAnnotation, http.MethodPost, "/api/annotations",
+		`{"at":"2026-10-02T10:00:00Z","text":"Migrated to new NAS","host_id":`+itoa(hostID)+`}`, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	for name, body := range map[string]string{
+		"no text":           `{"at":"2026-10-02T10:00:00Z"}`,
+		"end before start":  `{"at":"2026-10-02T10:00:00Z","end_at":"2026-10-02T09:00:00Z","text":"x"}`,
+		"container no host": `{"text":"x","container_name":"postgres"}`,
+		"unknown host":      `{"text":"x","host_id":999}`,
+	} {
+		if w := call(server.handleCreateAnnotation, http.MethodPost, "/api/annotations", body, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, w.Code)
+		}
+	}
+
+	w = call(server.handleGetAnnotations, http.MethodGet, "/api/annotations?start=2026-10-02T00:00:00Z", "", nil)
+	var annotations []models.Annotation
+	if err := json.Unmarshal(w.Body.Bytes(), &annotations); err != nil {
+		t.Fatalf("Failed to decode annotations: %v", err)
+	}
+	if len(annotations) != 1 || annotations[0].HostName != "nas" {
+		t.Errorf("Expected the migration only, got %+v", annotations)
+	}
+	if w := call(server.handleGetAnnotations, http.MethodGet, "/api/annotations?start=yesterday", "", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid start, got %d", w.Code)
+	}
+
+	vars := map[string]string{"id": itoa(outage.ID)}
+	if w := call(server.handleUpdateAnnotation, http.MethodPut, "/api/annotations/1",
+		`{"at":"2026-10-01T02:00:00Z","text":"Power outage (whole rack)"}`, vars); w.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(server.handleUpdateAnnotation, http.MethodPut, "/api/annotations/999",
+		`{"text":"x"}`, map[string]string{"id": "999"}); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown annotation, got %d", w.Code)
+	}
+	if w := call(server.handleDeleteAnnotation, http.MethodDelete, "/api/annotations/1", "", vars); w.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", w.Code)
+	}
+	if _, err := db.GetAnnotation(outage.ID); err == nil {
+		t.Error("Expected the annotation to be deleted")
+	}
+}
@@ -21,14 +21,15 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate credentials against environment variables
-	if req.Username != s.authConfig.Username || req.Password != s.authConfig.Password {
+	// Validate credentials against environment variables, then the tenant users
+	id, ok := auth.Authenticate(s.authConfig, req.Username, req.Password)
+	if !ok {
 		respondError(w, http.StatusUnauthorized, "Invalid credentials")
 		return
 	}
 
 	// Create session cookie
-	if err := auth.CreateSession(w, r); err != nil {
+	if err := auth.CreateSession(w, r, id); err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to create session")
 		return
 	}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// handleGetBackupJobs lists backup containers with their last run and whether they are on schedule.
+// Filters: host_id and status (ok, running, failed, overdue, pending).
+func (s *Server) handleGetBackupJobs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var hostID int64
+	if hostIDStr := query.Get("host_id"); hostIDStr != "" {
+		id, err := strconv.ParseInt(hostIDStr, 10, 64)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid host ID")
+			return
+		}
+		hostID = id
+	}
+	status := query.Get("status")
+
+	jobs, err := s.db.GetBackupJobs(time.Now())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get backup jobs: "+err.Error())
+		return
+	}
+
+	filtered := make([]models.BackupJob, 0, len(jobs))
+	for _, job := range jobs {
+		if hostID != 0 && job.HostID != hostID {
+			continue
+		}
+		if status != "" && job.Status != status {
+			continue
+		}
+		filtered = append(filtered, job)
+	}
+
+	respondJSON(w, http.StatusOK, filtered)
+}
+
+// handleGetBackupRuns returns the recorded runs of one backup job, newest first
+func (s *Server) handleGetBackupRuns(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	hostID, err := strconv.ParseInt(query.Get("host_id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid host ID")
+		return
+	}
+	containerName := query.Get("container_name")
+	if containerName == "" {
+		respondError(w, http.StatusBadRequest, "container_name is required")
+		return
+	}
+
+	limit := 50
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 500 {
+			limit = l
+		}
+	}
+
+	runs, err := s.db.GetBackupRuns(hostID, containerName, limit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get backup runs: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, runs)
+}
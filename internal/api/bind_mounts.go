@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// handleGetBindMountReport indexes the bind mount sources of the latest scans per host, flagging
+// paths several running containers write to and mounts of sensitive host paths. Query: host_id,
+// and flagged=true to leave out paths without findings.
+func (s *Server) handleGetBindMountReport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var hostID int64
+	if v := query.Get("host_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid host_id parameter")
+			return
+		}
+		hostID = id
+	}
+
+	report, err := s.db.GetBindMountReport(hostID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to index bind mounts: "+err.Error())
+		return
+	}
+	if query.Get("flagged") == "true" {
+		flagged := make([]models.BindMountPath, 0)
+		for _, p := range report.Paths {
+			if p.Overlap || p.Sensitive != "" {
+				flagged = append(flagged, p)
+			}
+		}
+		report.Paths = flagged
+	}
+
+	respondJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/container-census/container-census/internal/migration"
+)
+
+// maxBundleSize limits the size of an uploaded configuration bundle
+const maxBundleSize = 10 << 20
+
+// handleExportBundle exports the full configuration (settings, hosts, notification channels and
+// rules, telemetry endpoints, vulnerability settings) as a JSON download.
+// ?include_secrets=true adds agent tokens and API keys.
+func (s *Server) handleExportBundle(w http.ResponseWriter, r *http.Request) {
+	includeSecrets := r.URL.Query().Get("include_secrets") == "true"
+	bundle, err := migration.ExportBundle(s.db, includeSecrets)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to export configuration: "+err.Error())
+		return
+	}
+	if includeSecrets {
+		log.Printf("Configuration bundle with secrets exported by %s", identity(r).Username)
+	}
+
+	filename := fmt.Sprintf("container-census-bundle-%s.json", time.Now().Format("2006-01-02"))
+	w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+	respondJSON(w, http.StatusOK, bundle)
+}
+
+// handleImportBundle imports a configuration bundle from the request body.
+// ?sections=hosts,notification_rules imports only those sections; ?dry_run=true only reports
+// what would change.
+func (s *Server) handleImportBundle(w http.ResponseWriter, r *http.Request) {
+	var bundle migration.Bundle
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBundleSize)).Decode(&bundle); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid bundle: "+err.Error())
+		return
+	}
+
+	var sections []string
+	for _, section := range strings.Split(r.URL.Query().Get("sections"), ",") {
+		if section = strings.TrimSpace(section); section != "" {
+			sections = append(sections, section)
+		}
+	}
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	report, err := migration.ImportBundle(s.db, &bundle, sections, dryRun)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Failed to import bundle: "+err.Error())
+		return
+	}
+	if dryRun {
+		respondJSON(w, http.StatusOK, report)
+		return
+	}
+	log.Printf("Configuration bundle imported by %s (sections: %s)", identity(r).Username, strings.Join(report.Sections, ", "))
+
+	// Apply the imported configuration to running services
+	if s.notificationService != nil {
+		s.notificationService.RefreshChannels()
+	}
+	if s.vulnScanner != nil {
+		if config, err := s.db.LoadVulnerabilitySettings(); err != nil {
+			log.Printf("Warning: Failed to reload vulnerability settings: %v", err)
+		} else if err := s.vulnScanner.GetConfig().Update(config); err != nil {
+			log.Printf("Warning: Failed to apply imported vulnerability settings: %v", err)
+		}
+	}
+	if s.reloadSettingsFunc != nil {
+		if err := s.reloadSettingsFunc(); err != nil {
+			log.Printf("Warning: Hot-reload failed: %v", err)
+		}
+	}
+	s.cache.Invalidate()
+
+	respondJSON(w, http.StatusOK, report)
+}
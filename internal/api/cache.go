@@ -0,0 +1,171 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/vulnerability"
+)
+
+// cacheTTL is how long a cached query result is served. Scans invalidate the cache as soon as they
+// save new containers, so this only bounds how stale data written elsewhere can get.
+const cacheTTL = 15 * time.Second
+
+// Cache keys
+const (
+	cacheKeyContainers           = "containers"
+	cacheKeyContainerDetails     = "container_details"
+	cacheKeyVulnerabilitySummary = "vulnerability_summary"
+)
+
+// QueryCache keeps the results of heavy database reads for a short time, so dashboards polling
+// several tabs don't each hit SQLite. Cached values are shared and must not be modified.
+type QueryCache struct {
+	mu         sync.Mutex
+	entries    map[string]cacheEntry
+	generation uint64 // bumped by Invalidate, so loads that raced with it aren't stored
+	ttl        time.Duration
+}
+
+type cacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// NewQueryCache returns an empty cache
+func NewQueryCache(ttl time.Duration) *QueryCache {
+	return &QueryCache{
+		entries: make(map[string]cacheEntry),
+		ttl:     ttl,
+	}
+}
+
+// Get returns the cached value for key, or calls load and caches its result. A nil cache
+// always loads.
+func (c *QueryCache) Get(key string, load func() (interface{}, error)) (interface{}, error) {
+	if c == nil {
+		return load()
+	}
+
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok && time.Now().Before(entry.expires) {
+		c.mu.Unlock()
+		return entry.value, nil
+	}
+	generation := c.generation
+	c.mu.Unlock()
+
+	value, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.generation == generation {
+		c.entries[key] = cacheEntry{value: value, expires: time.Now().Add(c.ttl)}
+	}
+	c.mu.Unlock()
+	return value, nil
+}
+
+// Invalidate drops all cached values; call it after a scan saves containers
+func (c *QueryCache) Invalidate() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]cacheEntry)
+	c.generation++
+}
+
+// latestContainers returns a copy of the latest containers of all hosts, which callers may modify
+func (s *Server) latestContainers() ([]models.Container, error) {
+	value, err := s.cache.Get(cacheKeyContainers, func() (interface{}, error) {
+		return s.db.GetLatestContainers()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]models.Container(nil), value.([]models.Container)...), nil
+}
+
+// containerDetails returns a copy of the latest containers of all hosts with their uptime status,
+// pin, zero stats, service metadata, notes and owner attached, which callers may modify. The
+// details are cached with the containers, so a dashboard poll that ends in 304 Not Modified
+// doesn't query them again; handlers that change them invalidate the cache.
+func (s *Server) containerDetails() ([]models.Container, error) {
+	value, err := s.cache.Get(cacheKeyContainerDetails, func() (interface{}, error) {
+		containers, err := s.latestContainers()
+		if err != nil {
+			return nil, err
+		}
+		s.attachUptime(containers)
+		s.attachPins(containers)
+		s.attachZeroStats(containers)
+		attachServiceMetadata(containers)
+		s.attachNotes(containers)
+		s.attachOwners(containers)
+		return containers, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]models.Container(nil), value.([]models.Container)...), nil
+}
+
+// vulnerabilitySummary returns the cached vulnerability summary
+func (s *Server) vulnerabilitySummary() (*vulnerability.ScanSummary, error) {
+	value, err := s.cache.Get(cacheKeyVulnerabilitySummary, func() (interface{}, error) {
+		return s.db.GetVulnerabilitySummary()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*vulnerability.ScanSummary), nil
+}
+
+// respondCachedJSON writes a JSON response with an ETag computed from its body, and answers
+// 304 Not Modified when the client already has that body (If-None-Match)
+func respondCachedJSON(w http.ResponseWriter, r *http.Request, data interface{}) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(data); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	// Let browsers keep the response but always revalidate it
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// etagMatches reports whether an If-None-Match header lists etag (weak comparison)
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/containerops"
+	"github.com/container-census/container-census/internal/models"
+	"github.com/gorilla/mux"
+)
+
+func TestQueryCache(t *testing.T) {
+	cache := NewQueryCache(time.Minute)
+	loads := 0
+	load := func() (interface{}, error) {
+		loads++
+		return loads, nil
+	}
+
+	if v, _ := cache.Get("containers", load); v != 1 {
+		t.Fatalf("Expected the first load, got %v", v)
+	}
+	if v, _ := cache.Get("containers", load); v != 1 || loads != 1 {
+		t.Errorf("Expected the cached value, got %v after %d loads", v, loads)
+	}
+
+	cache.Invalidate()
+	if v, _ := cache.Get("containers", load); v != 2 {
+		t.Errorf("Expected a reload after invalidation, got %v", v)
+	}
+
+	// A load that races with an invalidation isn't cached
+	cache.Get("summary", func() (interface{}, error) {
+		cache.Invalidate()
+		return "stale", nil
+	})
+	if v, _ := cache.Get("summary", func() (interface{}, error) { return "fresh", nil }); v != "fresh" {
+		t.Errorf("Expected the raced load to be dropped, got %v", v)
+	}
+
+	expiring := NewQueryCache(time.Nanosecond)
+	expiring.Get("containers", load)
+	time.Sleep(time.Millisecond)
+	if expiring.Get("containers", load); loads != 4 {
+		t.Errorf("Expected an expired value to be reloaded, got %d loads", loads)
+	}
+}
+
+func TestRespondCachedJSON(t *testing.T) {
+	data := map[string]int{"containers": 3}
+
+	rec := httptest.NewRecorder()
+	respondCachedJSON(rec, httptest.NewRequest(http.MethodGet, "/api/containers", nil), data)
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag == "" || rec.Body.String() != "{\"containers\":3}\n" {
+		t.Fatalf("Expected a JSON response with an ETag, got %d %q %q", rec.Code, etag, rec.Body.String())
+	}
+
+	for _, header := range []string{etag, "W/" + etag, `"other", ` + etag} {
+		req := httptest.NewRequest(http.MethodGet, "/api/containers", nil)
+		req.Header.Set("If-None-Match", header)
+		rec = httptest.NewRecorder()
+		respondCachedJSON(rec, req, data)
+		if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+			t.Errorf("If-None-Match %s: expected 304 without a body, got %d", header, rec.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/containers", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	respondCachedJSON(rec, req, map[string]int{"containers": 4})
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Errorf("Expected changed data to get a new ETag, got %d", rec.Code)
+	}
+}
+
+func TestGetContainersCachesDetails(t *testing.T) {
+	server, db := setupTestServer(t)
+	server.cache = NewQueryCache(time.Minute)
+	server.operations = containerops.NewTracker()
+
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: "unix:///var/run/docker.sock", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	if err := db.SaveContainers([]models.Container{
+		{ID: "pg123", Name: "postgres", Image: "postgres:16", State: "running", HostID: hostID, HostName: "nas", ScannedAt: time.Now()},
+	}); err != nil {
+		t.Fatalf("Failed to save containers: %v", err)
+	}
+
+	get := func() models.Container {
+		w := httptest.NewRecorder()
+		server.handleGetContainers(w, httptest.NewRequest(http.MethodGet, "/api/containers", nil))
+		var containers []models.Container
+		if err := json.Unmarshal(w.Body.Bytes(), &containers); err != nil || len(containers) != 1 {
+			t.Fatalf("Expected one container, got %d %s", w.Code, w.Body.String())
+		}
+		return containers[0]
+	}
+	if c := get(); c.Notes != nil {
+		t.Fatalf("Expected no notes yet, got %+v", c.Notes)
+	}
+
+	// Written behind the API's back, so only a reload of the details shows it
+	if err := db.SaveNotes(hostID, "postgres", models.Notes{Text: "primary"}); err != nil {
+		t.Fatalf("SaveNotes failed: %v", err)
+	}
+	if c := get(); c.Notes != nil {
+		t.Error("Expected the cached details to be served without querying notes")
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/api/notes", strings.NewReader(`{"text":"replica"}`))
+	req = mux.SetURLVars(req, map[string]string{"host_id": itoa(hostID), "container_id": "postgres"})
+	w := httptest.NewRecorder()
+	server.handleUpdateContainerNotes(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if c := get(); c.Notes == nil || c.Notes.Text != "replica" {
+		t.Errorf("Expected saving notes to invalidate the details, got %+v", c.Notes)
+	}
+}
@@ -0,0 +1,452 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/container-census/container-census/internal/containerops"
+	"github.com/container-census/container-census/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// canarySoakPollInterval is how often the canary is checked during the soak period
+var canarySoakPollInterval = 30 * time.Second
+
+// canaryMaxScanFailures is how many checks in a row may fail to reach the canary's host before
+// the rollout halts
+const canaryMaxScanFailures = 3
+
+// canaryRuns are the rollouts running in this server
+type canaryRuns struct {
+	mu   sync.Mutex
+	runs map[int64]*canaryRun
+}
+
+type canaryRun struct {
+	cancel      context.CancelFunc
+	cancelledBy string
+}
+
+// canaryTarget is a container of a rollout with what the runner needs to update it
+type canaryTarget struct {
+	host      models.Host
+	container models.Container
+}
+
+// handleGetCanaryUpdates lists the latest canary rollouts, newest first (?limit=, default 50)
+func (s *Server) handleGetCanaryUpdates(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l <= 0 {
+			respondError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = l
+	}
+
+	rollouts, err := s.db.GetCanaryUpdates(limit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get canary rollouts: "+err.Error())
+		return
+	}
+	if rollouts == nil {
+		rollouts = []models.CanaryUpdate{}
+	}
+	respondJSON(w, http.StatusOK, rollouts)
+}
+
+// handleGetCanaryUpdate returns one canary rollout
+func (s *Server) handleGetCanaryUpdate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid rollout ID")
+		return
+	}
+
+	rollout, err := s.db.GetCanaryUpdate(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusNotFound, "Canary rollout not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get canary rollout: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, rollout)
+}
+
+// handleStartCanaryUpdate starts a canary rollout of an image. JSON: {"image": "nginx:latest",
+// "canary": {"host_id", "container_name"}, "soak_minutes": 10, "max_restarts": 0, "hooks": {...},
+// "accept_vulnerabilities": false}.
+// All containers running the image on enabled Docker hosts take part; pinned ones are left out.
+// The canary defaults to the first of them by host name. Answers 202 with the rollout, which
+// then runs in the background.
+func (s *Server) handleStartCanaryUpdate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Image  string `json:"image"`
+		Canary *struct {
+			HostID        int64  `json:"host_id"`
+			ContainerName string `json:"container_name"`
+		} `json:"canary,omitempty"`
+		SoakMinutes           int                 `json:"soak_minutes"`
+		MaxRestarts           int                 `json:"max_restarts"`
+		Hooks                 *models.UpdateHooks `json:"hooks,omitempty"`
+		AcceptVulnerabilities bool                `json:"accept_vulnerabilities"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	req.Image = strings.TrimSpace(req.Image)
+	if req.Image == "" {
+		respondError(w, http.StatusBadRequest, "image is required")
+		return
+	}
+	if req.SoakMinutes == 0 {
+		req.SoakMinutes = models.DefaultCanarySoakMinutes
+	}
+	if req.SoakMinutes < 1 || req.SoakMinutes > models.MaxCanarySoakMinutes {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("soak_minutes must be between 1 and %d", models.MaxCanarySoakMinutes))
+		return
+	}
+	if req.MaxRestarts < 0 {
+		respondError(w, http.StatusBadRequest, "max_restarts can't be negative")
+		return
+	}
+
+	hosts, err := s.db.GetHosts()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get hosts: "+err.Error())
+		return
+	}
+	containers, err := s.db.GetLatestContainers()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get containers: "+err.Error())
+		return
+	}
+
+	targets := canaryTargets(req.Image, hosts, containers, s.containerPin)
+	if len(targets) < 2 {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("A canary rollout needs the image running on at least two unpinned containers; %s runs on %d", req.Image, len(targets)))
+		return
+	}
+	if req.Canary != nil {
+		found := false
+		for i, t := range targets {
+			if t.host.ID == req.Canary.HostID && t.container.Name == req.Canary.ContainerName {
+				targets[0], targets[i] = targets[i], targets[0]
+				found = true
+				break
+			}
+		}
+		if !found {
+			respondError(w, http.StatusBadRequest, "The canary must be an unpinned container running "+req.Image)
+			return
+		}
+	}
+
+	rollout := &models.CanaryUpdate{
+		Image:       req.Image,
+		SoakMinutes: req.SoakMinutes,
+		MaxRestarts: req.MaxRestarts,
+		Hooks:       req.Hooks,
+		Status:      models.CanaryStatusUpdating,
+		TriggeredBy: identity(r).Username,
+		CreatedAt:   time.Now(),
+
+		AcceptVulnerabilities: req.AcceptVulnerabilities,
+	}
+	for _, t := range targets {
+		rollout.Targets = append(rollout.Targets, models.CanaryTarget{
+			HostID:        t.host.ID,
+			HostName:      t.host.Name,
+			ContainerName: t.container.Name,
+			Status:        models.CanaryTargetPending,
+		})
+	}
+	if err := s.db.SaveCanaryUpdate(rollout); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save canary rollout: "+err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &canaryRun{cancel: cancel}
+	s.canaries.mu.Lock()
+	if s.canaries.runs == nil {
+		s.canaries.runs = make(map[int64]*canaryRun)
+	}
+	s.canaries.runs[rollout.ID] = run
+	s.canaries.mu.Unlock()
+
+	log.Printf("Canary rollout %d of %s started by %s: canary %s on %s, %d more containers", rollout.ID, rollout.Image, rollout.TriggeredBy, targets[0].container.Name, targets[0].host.Name, len(targets)-1)
+	started := *rollout
+	started.Targets = append([]models.CanaryTarget(nil), rollout.Targets...)
+	go func() {
+		defer func() {
+			cancel()
+			s.canaries.mu.Lock()
+			delete(s.canaries.runs, rollout.ID)
+			s.canaries.mu.Unlock()
+		}()
+		s.runCanaryUpdate(ctx, run, rollout, targets)
+	}()
+
+	respondJSON(w, http.StatusAccepted, started)
+}
+
+// handleCancelCanaryUpdate halts a running rollout. A container being updated finishes its update;
+// the ones not updated yet are skipped.
+func (s *Server) handleCancelCanaryUpdate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid rollout ID")
+		return
+	}
+
+	s.canaries.mu.Lock()
+	run, ok := s.canaries.runs[id]
+	if ok {
+		run.cancelledBy = identity(r).Username
+		run.cancel()
+	}
+	s.canaries.mu.Unlock()
+
+	if !ok {
+		respondError(w, http.StatusConflict, "Canary rollout is not running")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Canary rollout cancelled"})
+}
+
+// canaryTargets returns the unpinned containers running an image on enabled Docker hosts,
+// ordered by host and container name
+func canaryTargets(image string, hosts []models.Host, containers []models.Container, pinOf func(models.Container) *models.ContainerPin) []canaryTarget {
+	hostsByID := make(map[int64]models.Host, len(hosts))
+	for _, h := range hosts {
+		if h.Enabled && h.IsDocker() {
+			hostsByID[h.ID] = h
+		}
+	}
+
+	var targets []canaryTarget
+	for _, c := range containers {
+		host, ok := hostsByID[c.HostID]
+		if !ok || !runsImage(c, image) || pinOf(c) != nil {
+			continue
+		}
+		targets = append(targets, canaryTarget{host: host, container: c})
+	}
+	sort.SliceStable(targets, func(i, j int) bool {
+		if targets[i].host.Name != targets[j].host.Name {
+			return targets[i].host.Name < targets[j].host.Name
+		}
+		return targets[i].container.Name < targets[j].container.Name
+	})
+	return targets
+}
+
+// runsImage reports whether a container was created from an image reference
+func runsImage(c models.Container, image string) bool {
+	if c.Image == image {
+		return true
+	}
+	for _, tag := range c.ImageTags {
+		if tag == image {
+			return true
+		}
+	}
+	return false
+}
+
+// canaryProblem returns why a canary in its soak period isn't doing well, or "" if it is: gone,
+// not running, unhealthy, or restarted more than maxRestarts times since its update
+func canaryProblem(c *models.Container, maxRestarts int) string {
+	switch {
+	case c == nil:
+		return "the canary container is gone"
+	case c.State == "restarting":
+		return "the canary container is restarting"
+	case c.State != "running":
+		return "the canary container is " + c.State
+	case strings.Contains(c.Status, "(unhealthy)"):
+		return "the canary container is unhealthy"
+	case c.RestartCount > maxRestarts:
+		return fmt.Sprintf("the canary container restarted %d times", c.RestartCount)
+	}
+	return ""
+}
+
+// runCanaryUpdate updates the canary, watches it for the soak period and then updates the other
+// containers, saving the rollout after every step
+func (s *Server) runCanaryUpdate(ctx context.Context, run *canaryRun, rollout *models.CanaryUpdate, targets []canaryTarget) {
+	save := func() {
+		if err := s.db.SaveCanaryUpdate(rollout); err != nil {
+			log.Printf("Failed to save canary rollout %d: %v", rollout.ID, err)
+		}
+	}
+	halt := func(reason string) {
+		now := time.Now()
+		rollout.Status = models.CanaryStatusHalted
+		rollout.Reason = reason
+		rollout.FinishedAt = &now
+		for i := range rollout.Targets {
+			if rollout.Targets[i].Status == models.CanaryTargetPending {
+				rollout.Targets[i].Status = models.CanaryTargetSkipped
+			}
+		}
+		save()
+		log.Printf("Canary rollout %d of %s halted: %s", rollout.ID, rollout.Image, reason)
+	}
+	cancelled := func() string {
+		s.canaries.mu.Lock()
+		defer s.canaries.mu.Unlock()
+		if run.cancelledBy != "" {
+			return "Cancelled by " + run.cancelledBy
+		}
+		return "Cancelled"
+	}
+
+	// The canary always waits for its health check
+	canaryHooks := &models.UpdateHooks{WaitForHealthy: true}
+	if rollout.Hooks != nil {
+		hooks := *rollout.Hooks
+		hooks.WaitForHealthy = true
+		canaryHooks = &hooks
+	}
+	if err := s.updateCanaryTarget(rollout, 0, targets[0], canaryHooks); err != nil {
+		halt("Canary update failed: " + err.Error())
+		return
+	}
+
+	soakUntil := time.Now().Add(time.Duration(rollout.SoakMinutes) * time.Minute)
+	rollout.Status = models.CanaryStatusSoaking
+	rollout.SoakUntil = &soakUntil
+	save()
+
+	ticker := time.NewTicker(canarySoakPollInterval)
+	defer ticker.Stop()
+	scanFailures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			halt(cancelled())
+			return
+		case <-ticker.C:
+		}
+
+		canary := targets[0]
+		containers, err := s.scanner.ScanHost(ctx, canary.host)
+		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			scanFailures++
+			log.Printf("Canary rollout %d: failed to check %s on %s: %v", rollout.ID, canary.container.Name, canary.host.Name, err)
+			if scanFailures >= canaryMaxScanFailures {
+				halt("Can't reach the canary's host: " + err.Error())
+				return
+			}
+			continue
+		}
+		scanFailures = 0
+
+		var current *models.Container
+		for i := range containers {
+			if containers[i].Name == canary.container.Name {
+				current = &containers[i]
+				break
+			}
+		}
+		if problem := canaryProblem(current, rollout.MaxRestarts); problem != "" {
+			halt("During the soak period " + problem)
+			return
+		}
+		if !time.Now().Before(soakUntil) {
+			break
+		}
+	}
+
+	rollout.Status = models.CanaryStatusRollingOut
+	save()
+	for i := 1; i < len(targets); i++ {
+		if ctx.Err() != nil {
+			halt(cancelled())
+			return
+		}
+		if s.readOnly() {
+			halt("Read-only mode was switched on")
+			return
+		}
+		if err := s.updateCanaryTarget(rollout, i, targets[i], rollout.Hooks); err != nil {
+			log.Printf("Canary rollout %d: update of %s on %s failed: %v", rollout.ID, targets[i].container.Name, targets[i].host.Name, err)
+		}
+		save()
+	}
+
+	now := time.Now()
+	rollout.Status = models.CanaryStatusCompleted
+	rollout.FinishedAt = &now
+	save()
+	log.Printf("Canary rollout %d of %s completed", rollout.ID, rollout.Image)
+}
+
+// updateCanaryTarget pulls the image and recreates one container of a rollout, recording the
+// outcome in the rollout and the update history. It isn't cancelled with the rollout, so a
+// container isn't left half-recreated.
+func (s *Server) updateCanaryTarget(rollout *models.CanaryUpdate, i int, t canaryTarget, hooks *models.UpdateHooks) error {
+	fail := func(err error) error {
+		rollout.Targets[i].Status = models.CanaryTargetFailed
+		rollout.Targets[i].Error = err.Error()
+		return err
+	}
+
+	done, running, ok := s.operations.Begin(t.host.ID, t.container.Name, containerops.ActionUpdate)
+	if !ok {
+		return fail(errors.New(busyMessage(running)))
+	}
+	defer done()
+
+	ctx := context.Background()
+	started := time.Now()
+	log.Printf("Pulling image %s on host %s", rollout.Image, t.host.Name)
+	if err := s.pullImage(ctx, t.host, rollout.Image); err != nil {
+		err = fmt.Errorf("failed to pull image: %w", err)
+		s.recordContainerUpdate(rollout.TriggeredBy, models.UpdateTriggerCanary, t.host, t.container, rollout.Image, started, nil, err)
+		return fail(err)
+	}
+
+	if gate := s.checkVulnerabilityGate(ctx, t.host, t.container, rollout.Image); gate.Refuses(rollout.AcceptVulnerabilities) {
+		err := errors.New(gate.Message())
+		if gate.Mode == models.VulnerabilityGateBlock {
+			s.recordContainerUpdate(rollout.TriggeredBy, models.UpdateTriggerCanary, t.host, t.container, rollout.Image, started, nil, err)
+		}
+		return fail(err)
+	}
+
+	result, err := s.scanner.RecreateContainer(ctx, t.host, t.container.Name, false, hooks)
+	s.recordContainerUpdate(rollout.TriggeredBy, models.UpdateTriggerCanary, t.host, t.container, rollout.Image, started, result, err)
+	if err != nil {
+		return fail(fmt.Errorf("failed to recreate container: %w", err))
+	}
+	if !result.Success {
+		if result.RolledBack {
+			return fail(errors.New(result.Error + " (rolled back)"))
+		}
+		return fail(errors.New(result.Error))
+	}
+
+	rollout.Targets[i].Status = models.CanaryTargetSucceeded
+	return nil
+}
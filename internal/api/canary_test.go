@@ -0,0 +1,110 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestCanaryTargets(t *testing.T) {
+	hosts := []models.Host{
+		{ID: 1, Name: "vps", Enabled: true},
+		{ID: 2, Name: "nas", Enabled: true},
+		{ID: 3, Name: "old", Enabled: false},
+		{ID: 4, Name: "lxd", Enabled: true, HostType: models.HostTypeIncus},
+	}
+	containers := []models.Container{
+		{HostID: 1, Name: "exporter", Image: "prom/node-exporter:latest"},
+		{HostID: 2, Name: "exporter", Image: "sha256:abc", ImageTags: []string{"prom/node-exporter:latest"}},
+		{HostID: 2, Name: "exporter-pinned", Image: "prom/node-exporter:latest", Labels: map[string]string{models.PinLabel: "true"}},
+		{HostID: 3, Name: "exporter", Image: "prom/node-exporter:latest"},
+		{HostID: 4, Name: "exporter", Image: "prom/node-exporter:latest"},
+		{HostID: 1, Name: "web", Image: "nginx:latest"},
+	}
+	pinOf := func(c models.Container) *models.ContainerPin { return models.PinFor(c, nil) }
+
+	targets := canaryTargets("prom/node-exporter:latest", hosts, containers, pinOf)
+	if len(targets) != 2 {
+		t.Fatalf("Expected the exporters on the enabled Docker hosts, got %+v", targets)
+	}
+	if targets[0].host.Name != "nas" || targets[1].host.Name != "vps" {
+		t.Errorf("Expected targets ordered by host name, got %s, %s", targets[0].host.Name, targets[1].host.Name)
+	}
+}
+
+func TestCanaryProblem(t *testing.T) {
+	tests := []struct {
+		name      string
+		container *models.Container
+		want      string
+	}{
+		{"healthy", &models.Container{State: "running", Status: "Up 5 minutes (healthy)"}, ""},
+		{"restarts allowed", &models.Container{State: "running", Status: "Up 1 minute", RestartCount: 1}, ""},
+		{"gone", nil, "gone"},
+		{"exited", &models.Container{State: "exited", Status: "Exited (1) 10 seconds ago"}, "exited"},
+		{"restarting", &models.Container{State: "restarting"}, "restarting"},
+		{"unhealthy", &models.Container{State: "running", Status: "Up 5 minutes (unhealthy)"}, "unhealthy"},
+		{"too many restarts", &models.Container{State: "running", Status: "Up 3 seconds", RestartCount: 2}, "restarted 2 times"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := canaryProblem(tt.container, 1)
+			if (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+				t.Errorf("canaryProblem() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartCanaryUpdate_Validation(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "vps", Address: "agent://vps:9876", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	if err := db.SaveContainers([]models.Container{
+		{ID: "abc", Name: "exporter", Image: "prom/node-exporter:latest", State: "running", HostID: hostID, HostName: "vps", ScannedAt: time.Now()},
+	}); err != nil {
+		t.Fatalf("Failed to save containers: %v", err)
+	}
+
+	tests := []struct {
+		body     string
+		contains string
+	}{
+		{`{}`, "image is required"},
+		{`{"image": "prom/node-exporter:latest", "soak_minutes": -5}`, "soak_minutes"},
+		{`{"image": "prom/node-exporter:latest", "max_restarts": -1}`, "max_restarts"},
+		{`{"image": "prom/node-exporter:latest"}`, "at least two"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/api/updates/canary", strings.NewReader(tt.body))
+		w := httptest.NewRecorder()
+		server.handleStartCanaryUpdate(w, req)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), tt.contains) {
+			t.Errorf("%s: expected 400 with %q, got %d %s", tt.body, tt.contains, w.Code, w.Body.String())
+		}
+	}
+
+	rollouts, err := db.GetCanaryUpdates(10)
+	if err != nil || len(rollouts) != 0 {
+		t.Errorf("Expected no rollouts to be saved, got %+v (%v)", rollouts, err)
+	}
+}
+
+func TestCancelCanaryUpdate_NotRunning(t *testing.T) {
+	server, _ := setupTestServer(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/updates/canary/1/cancel", nil)
+	w := httptest.NewRecorder()
+	server.router.HandleFunc("/api/updates/canary/{id}/cancel", server.handleCancelCanaryUpdate)
+	server.router.ServeHTTP(w, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a rollout that isn't running, got %d", w.Code)
+	}
+}
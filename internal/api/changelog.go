@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// resolveChangelog fetches and caches the release notes of a container's image, logging failures
+func (s *Server) resolveChangelog(ctx context.Context, container models.Container) {
+	if s.changelogFetcher == nil {
+		return
+	}
+	if _, err := s.changelogFetcher.Resolve(ctx, container); err != nil {
+		log.Printf("Failed to fetch changelog for %s: %v", container.Image, err)
+	}
+}
+
+// handleGetContainerChangelog returns the upstream release notes for a container's image.
+// The changelog is null when the image doesn't declare a GitHub source repository.
+func (s *Server) handleGetContainerChangelog(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	hostID, err := strconv.ParseInt(vars["host_id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid host ID")
+		return
+	}
+	containerID := vars["container_id"]
+
+	containers, err := s.db.GetContainersByHost(hostID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get containers: "+err.Error())
+		return
+	}
+	var container *models.Container
+	for i := range containers {
+		if containers[i].ID == containerID || containers[i].Name == containerID {
+			container = &containers[i]
+			break
+		}
+	}
+	if container == nil {
+		respondError(w, http.StatusNotFound, "Container not found")
+		return
+	}
+
+	var changelog *models.ImageChangelog
+	if s.changelogFetcher != nil {
+		// Failures fall back to a stale cached changelog, if any
+		changelog, err = s.changelogFetcher.Resolve(r.Context(), *container)
+		if err != nil {
+			log.Printf("Failed to fetch changelog for %s: %v", container.Image, err)
+		}
+	} else {
+		changelog, err = s.db.GetImageChangelog(container.Image)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to get changelog: "+err.Error())
+			return
+		}
+		if changelog != nil && changelog.Source == "" {
+			changelog = nil
+		}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"image":     container.Image,
+		"changelog": changelog,
+	})
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// handleGetComplianceAudits returns the latest CIS benchmark audit of every audited host
+func (s *Server) handleGetComplianceAudits(w http.ResponseWriter, r *http.Request) {
+	audits, err := s.db.GetLatestComplianceAudits()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get compliance audits: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, audits)
+}
+
+// handleGetHostComplianceAudit returns the latest audit of a host
+func (s *Server) handleGetHostComplianceAudit(w http.ResponseWriter, r *http.Request) {
+	hostID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid host ID")
+		return
+	}
+
+	audit, err := s.db.GetLatestComplianceAudit(hostID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get compliance audit: "+err.Error())
+		return
+	}
+	if audit == nil {
+		respondError(w, http.StatusNotFound, "Host has not been audited yet")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, audit)
+}
+
+// handleGetHostComplianceHistory returns a host's past audits, newest first
+func (s *Server) handleGetHostComplianceHistory(w http.ResponseWriter, r *http.Request) {
+	hostID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid host ID")
+		return
+	}
+
+	limit := 30
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+
+	audits, err := s.db.GetComplianceAuditHistory(hostID, limit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get compliance history: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, audits)
+}
+
+// handleAuditHost runs the benchmark checks against one host and returns the stored audit
+func (s *Server) handleAuditHost(w http.ResponseWriter, r *http.Request) {
+	hostID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid host ID")
+		return
+	}
+
+	host, err := s.db.GetHost(hostID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Host not found")
+		return
+	}
+
+	audit, err := s.auditHost(r.Context(), *host)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to audit host: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, audit)
+}
+
+// handleAuditAllHosts audits every enabled host in the background
+func (s *Server) handleAuditAllHosts(w http.ResponseWriter, r *http.Request) {
+	go s.AuditAllHosts(context.Background())
+
+	respondJSON(w, http.StatusAccepted, map[string]string{"message": "Compliance audit triggered"})
+}
+
+// AuditAllHosts runs the CIS benchmark checks against every enabled host and stores the results.
+// Hosts that fail to audit are logged and skipped.
+func (s *Server) AuditAllHosts(ctx context.Context) {
+	hosts, err := s.db.GetHosts()
+	if err != nil {
+		log.Printf("Failed to get hosts for compliance audit: %v", err)
+		return
+	}
+
+	for _, host := range hosts {
+		if !host.Enabled || !host.IsDocker() {
+			continue
+		}
+		if _, err := s.auditHost(ctx, host); err != nil {
+			log.Printf("Compliance audit failed for host %s: %v", host.Name, err)
+		}
+	}
+}
+
+// auditHost audits a host and saves the result to its history
+func (s *Server) auditHost(ctx context.Context, host models.Host) (*models.ComplianceAudit, error) {
+	audit, err := s.scanner.AuditHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.SaveComplianceAudit(audit); err != nil {
+		return nil, err
+	}
+	return audit, nil
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// maxDaemonEventHours is the longest window of GET /api/daemon-events (the retention period)
+const maxDaemonEventHours = 90 * 24
+
+// handleGetDaemonEvents returns the OOM kills and daemon errors agents read from their hosts'
+// logs, newest first. Query: host_id, kind (oom_kill or daemon_error), hours (default 24) and
+// limit (default 100, at most 1000).
+func (s *Server) handleGetDaemonEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	kind := query.Get("kind")
+	if kind != "" && kind != models.DaemonEventOOMKill && kind != models.DaemonEventError {
+		respondError(w, http.StatusBadRequest, "Invalid kind. Use: oom_kill or daemon_error")
+		return
+	}
+	hours := 24
+	if v := query.Get("hours"); v != "" {
+		var err error
+		hours, err = strconv.Atoi(v)
+		if err != nil || hours < 1 || hours > maxDaemonEventHours {
+			respondError(w, http.StatusBadRequest, "hours must be between 1 and "+strconv.Itoa(maxDaemonEventHours))
+			return
+		}
+	}
+	limit := 100
+	if v := query.Get("limit"); v != "" {
+		var err error
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > 1000 {
+			respondError(w, http.StatusBadRequest, "limit must be between 1 and 1000")
+			return
+		}
+	}
+
+	hostIDs, ok := s.queryHostIDs(w, r)
+	if !ok {
+		return
+	}
+
+	events, err := s.db.GetDaemonEvents(hostIDs, kind, time.Now().Add(-time.Duration(hours)*time.Hour), limit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get daemon events: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, events)
+}
+
+// queryHostIDs returns the hosts a list request covers: the visible host of its host_id
+// parameter, all hosts of a tenant user, or nil for all hosts of an administrator. It responds
+// with an error and returns false for a host the user can't see.
+func (s *Server) queryHostIDs(w http.ResponseWriter, r *http.Request) ([]int64, bool) {
+	hosts, err := s.db.GetHosts()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get hosts: "+err.Error())
+		return nil, false
+	}
+	var hostIDs []int64 // nil gets all hosts
+	if v := r.URL.Query().Get("host_id"); v != "" {
+		hostID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid host ID")
+			return nil, false
+		}
+		hostIDs = []int64{}
+		for _, host := range visibleHosts(r, hosts) {
+			if host.ID == hostID {
+				hostIDs = append(hostIDs, host.ID)
+			}
+		}
+		if len(hostIDs) == 0 {
+			respondError(w, http.StatusNotFound, "Host not found")
+			return nil, false
+		}
+	} else if !identity(r).IsAdmin() {
+		hostIDs = []int64{}
+		for _, host := range visibleHosts(r, hosts) {
+			hostIDs = append(hostIDs, host.ID)
+		}
+	}
+	return hostIDs, true
+}
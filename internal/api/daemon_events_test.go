@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/auth"
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestGetDaemonEvents_Tenants(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	ownID, err := db.AddHost(models.Host{Name: "own", Address: "agent://own:9876", Enabled: true, TenantID: 1})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	otherID, err := db.AddHost(models.Host{Name: "other", Address: "agent://other:9876", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	at := time.Now().Add(-time.Hour)
+	for _, hostID := range []int64{ownID, otherID} {
+		if _, err := db.SaveDaemonEvents(hostID, "", []models.DaemonEvent{
+			{Kind: models.DaemonEventOOMKill, Source: "kernel", ContainerName: "web", Message: "Out of memory", OccurredAt: at},
+		}); err != nil {
+			t.Fatalf("SaveDaemonEvents failed: %v", err)
+		}
+	}
+
+	get := func(url string) (int, []models.DaemonEvent) {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{Username: "tenant", TenantID: 1}))
+		w := httptest.NewRecorder()
+		server.handleGetDaemonEvents(w, req)
+		var events []models.DaemonEvent
+		json.Unmarshal(w.Body.Bytes(), &events)
+		return w.Code, events
+	}
+
+	if code, events := get("/api/daemon-events?kind=oom_kill"); code != http.StatusOK || len(events) != 1 || events[0].HostID != ownID {
+		t.Errorf("Expected the tenant's event only, got %d %+v", code, events)
+	}
+	if code, _ := get("/api/daemon-events?host_id=" + itoa(otherID)); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for another tenant's host, got %d", code)
+	}
+	if code, _ := get("/api/daemon-events?kind=restart"); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid kind, got %d", code)
+	}
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// handleGetDockerObjects returns the object inventory of the latest scans: plugins, Swarm secrets
+// and configs, and builder cache. Query: host_id and kind (plugin, secret, config or
+// build_cache). Empty unless the scanner's inventory_objects setting is on.
+func (s *Server) handleGetDockerObjects(w http.ResponseWriter, r *http.Request) {
+	kind := r.URL.Query().Get("kind")
+	switch kind {
+	case "", models.DockerObjectPlugin, models.DockerObjectSecret, models.DockerObjectConfig, models.DockerObjectBuildCache:
+	default:
+		respondError(w, http.StatusBadRequest, "Invalid kind. Use: plugin, secret, config or build_cache")
+		return
+	}
+	hostIDs, ok := s.queryHostIDs(w, r)
+	if !ok {
+		return
+	}
+
+	objects, err := s.db.GetDockerObjects(hostIDs, kind)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get Docker objects: "+err.Error())
+		return
+	}
+	enabled := false
+	if settings, err := s.db.LoadSystemSettings(); err == nil {
+		enabled = settings.Scanner.InventoryObjects
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"enabled": enabled,
+		"hosts":   summarizeDockerObjects(objects),
+		"objects": objects,
+	})
+}
+
+// summarizeDockerObjects counts inventoried objects per host, in the order of the objects
+func summarizeDockerObjects(objects []models.DockerObject) []models.DockerObjectSummary {
+	summaries := make([]models.DockerObjectSummary, 0)
+	index := make(map[int64]int)
+	for _, o := range objects {
+		i, ok := index[o.HostID]
+		if !ok {
+			i = len(summaries)
+			index[o.HostID] = i
+			summaries = append(summaries, models.DockerObjectSummary{HostID: o.HostID, HostName: o.HostName})
+		}
+		summary := &summaries[i]
+		switch o.Kind {
+		case models.DockerObjectPlugin:
+			summary.Plugins++
+		case models.DockerObjectSecret:
+			summary.Secrets++
+		case models.DockerObjectConfig:
+			summary.Configs++
+		case models.DockerObjectBuildCache:
+			summary.BuildCacheBytes += o.SizeBytes
+			summary.BuildCacheReclaimableBytes += o.ReclaimableBytes
+		}
+		if o.ScannedAt.After(summary.ScannedAt) {
+			summary.ScannedAt = o.ScannedAt
+		}
+	}
+	return summaries
+}
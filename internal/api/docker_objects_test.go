@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/auth"
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestGetDockerObjects_Tenants(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	ownID, err := db.AddHost(models.Host{Name: "own", Address: "agent://own:9876", Enabled: true, TenantID: 1})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	otherID, err := db.AddHost(models.Host{Name: "other", Address: "agent://other:9876", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	now := time.Now()
+	for _, hostID := range []int64{ownID, otherID} {
+		if err := db.ReplaceDockerObjects(hostID, []models.DockerObject{
+			{Kind: models.DockerObjectPlugin, ObjectID: "p1", Name: "vieux/sshfs:latest", ScannedAt: now},
+			{Kind: models.DockerObjectBuildCache, ObjectID: "regular", Name: "regular", SizeBytes: 500, ReclaimableBytes: 200, ScannedAt: now},
+			{Kind: models.DockerObjectBuildCache, ObjectID: "exec.cachemount", Name: "exec.cachemount", SizeBytes: 100, ScannedAt: now},
+		}); err != nil {
+			t.Fatalf("ReplaceDockerObjects failed: %v", err)
+		}
+	}
+
+	type response struct {
+		Enabled bool                         `json:"enabled"`
+		Hosts   []models.DockerObjectSummary `json:"hosts"`
+		Objects []models.DockerObject        `json:"objects"`
+	}
+	get := func(url string) (int, response) {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{Username: "tenant", TenantID: 1}))
+		w := httptest.NewRecorder()
+		server.handleGetDockerObjects(w, req)
+		var resp response
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	code, resp := get("/api/docker-objects")
+	if code != http.StatusOK || len(resp.Objects) != 3 || len(resp.Hosts) != 1 {
+		t.Fatalf("Expected the tenant's objects only, got %d %+v", code, resp)
+	}
+	if summary := resp.Hosts[0]; summary.HostID != ownID || summary.Plugins != 1 || summary.BuildCacheBytes != 600 || summary.BuildCacheReclaimableBytes != 200 {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+	if code, resp := get("/api/docker-objects?kind=plugin"); code != http.StatusOK || len(resp.Objects) != 1 {
+		t.Errorf("Expected the plugin only, got %d %+v", code, resp.Objects)
+	}
+	if code, _ := get("/api/docker-objects?host_id=" + itoa(otherID)); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for another tenant's host, got %d", code)
+	}
+	if code, _ := get("/api/docker-objects?kind=volume"); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid kind, got %d", code)
+	}
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/container-census/container-census/internal/endpoints"
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/proxyroutes"
+)
+
+// SetCertWarningDays sets how many days before expiry certificates are reported (see CheckEndpoints)
+func (s *Server) SetCertWarningDays(days int) {
+	s.certWarningDays = days
+}
+
+// handleGetEndpointChecks returns the latest DNS and certificate checks of the proxy routes'
+// hostnames, soonest expiring certificate first
+func (s *Server) handleGetEndpointChecks(w http.ResponseWriter, r *http.Request) {
+	checks, err := s.db.GetEndpointChecks()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get endpoint checks: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, checks)
+}
+
+// handleRunEndpointChecks checks the hostnames right away
+func (s *Server) handleRunEndpointChecks(w http.ResponseWriter, r *http.Request) {
+	if _, err := s.CheckEndpoints(r.Context()); err != nil {
+		respondError(w, http.StatusInternalServerError, "Endpoint check failed: "+err.Error())
+		return
+	}
+
+	checks, err := s.db.GetEndpointChecks()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get endpoint checks: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, checks)
+}
+
+// CheckEndpoints resolves every hostname of the proxy routes and reads the certificates of
+// those served over https, stores the results and notifies about certificates nearing expiry
+// and hostnames that stopped resolving. It returns the alerts raised.
+func (s *Server) CheckEndpoints(ctx context.Context) ([]models.EndpointAlert, error) {
+	settings, err := s.db.GetTraefikSettings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Traefik settings: %w", err)
+	}
+	containers, err := s.proxyRouteContainers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get containers: %w", err)
+	}
+	report := proxyroutes.BuildReport(ctx, settings, containers, nil)
+	for _, routeErr := range report.Errors {
+		log.Printf("Endpoint check: %s", routeErr)
+	}
+
+	checker := endpoints.NewChecker(s.certWarningDays)
+	checks := checker.CheckAll(ctx, endpoints.Targets(report.Routes))
+
+	previous, err := s.db.GetEndpointChecks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get previous endpoint checks: %w", err)
+	}
+	alerts := endpoints.Evaluate(previous, checks, checker.WarningDays)
+	if err := s.db.ReplaceEndpointChecks(checks); err != nil {
+		return nil, fmt.Errorf("failed to save endpoint checks: %w", err)
+	}
+
+	if s.notificationService != nil {
+		if err := s.notificationService.SendEndpointAlerts(ctx, alerts); err != nil {
+			log.Printf("Failed to send endpoint alerts: %v", err)
+		}
+	}
+	return alerts, nil
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// handleGetHostEngines returns the Docker engine of every host the user can see: versions, pending
+// reboots and daemon restarts, and the hosts whose engine is a major version or more behind the
+// newest engine of the fleet
+func (s *Server) handleGetHostEngines(w http.ResponseWriter, r *http.Request) {
+	hosts, err := s.db.GetHosts()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get hosts: "+err.Error())
+		return
+	}
+	system := func(int64) *models.HostSystem { return nil }
+	if s.scanner != nil {
+		system = s.scanner.HostSystem
+	}
+	respondJSON(w, http.StatusOK, buildEngineReport(visibleHosts(r, hosts), system))
+}
+
+// buildEngineReport puts together the fleet engine report from each host's latest system report.
+// Incus hosts have no Docker engine and are left out.
+func buildEngineReport(hosts []models.Host, system func(hostID int64) *models.HostSystem) models.EngineReport {
+	report := models.EngineReport{Versions: make(map[string]int), Hosts: make([]models.HostEngine, 0, len(hosts))}
+	for _, host := range hosts {
+		if host.HostType == "incus" {
+			continue
+		}
+		engine := models.HostEngine{HostID: host.ID, HostName: host.Name, HostType: host.HostType, System: system(host.ID), Warnings: []string{}}
+		if engine.System != nil && engine.System.DockerVersion != "" {
+			report.Versions[engine.System.DockerVersion]++
+			if report.LatestVersion == "" || models.CompareVersions(engine.System.DockerVersion, report.LatestVersion) > 0 {
+				report.LatestVersion = engine.System.DockerVersion
+			}
+		}
+		report.Hosts = append(report.Hosts, engine)
+	}
+
+	latestMajor := models.MajorVersion(report.LatestVersion)
+	for i := range report.Hosts {
+		engine := &report.Hosts[i]
+		if engine.System == nil {
+			continue
+		}
+		if engine.System.RebootRequired {
+			engine.Warnings = append(engine.Warnings, models.EngineWarningRebootRequired)
+		}
+		if engine.System.DaemonRestartRequired {
+			engine.Warnings = append(engine.Warnings, models.EngineWarningDaemonRestartRequired)
+		}
+		if major := models.MajorVersion(engine.System.DockerVersion); major >= 0 && latestMajor > major {
+			engine.MajorsBehind = latestMajor - major
+			engine.Warnings = append(engine.Warnings, models.EngineWarningOutdated)
+		}
+	}
+
+	// Hosts with warnings first, then by name
+	sort.SliceStable(report.Hosts, func(i, j int) bool {
+		a, b := report.Hosts[i], report.Hosts[j]
+		if (len(a.Warnings) > 0) != (len(b.Warnings) > 0) {
+			return len(a.Warnings) > 0
+		}
+		return a.HostName < b.HostName
+	})
+	return report
+}
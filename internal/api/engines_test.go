@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestBuildEngineReport(t *testing.T) {
+	hosts := []models.Host{
+		{ID: 1, Name: "alpha", HostType: "agent"},
+		{ID: 2, Name: "beta", HostType: "agent"},
+		{ID: 3, Name: "gamma", HostType: "unix"},
+		{ID: 4, Name: "delta", HostType: "incus"},
+		{ID: 5, Name: "epsilon", HostType: "agent"},
+	}
+	systems := map[int64]*models.HostSystem{
+		1: {DockerVersion: "27.3.1", KernelVersion: "6.8.0-47-generic"},
+		2: {DockerVersion: "27.1.2", RebootRequired: true, RebootReasons: []string{"reboot-required marker present"}},
+		3: {DockerVersion: "24.0.7", InstalledDockerVersion: "27.3.1", DaemonRestartRequired: true},
+	}
+	report := buildEngineReport(hosts, func(id int64) *models.HostSystem { return systems[id] })
+
+	if report.LatestVersion != "27.3.1" {
+		t.Errorf("expected latest version 27.3.1, got %q", report.LatestVersion)
+	}
+	if report.Versions["27.3.1"] != 1 || report.Versions["27.1.2"] != 1 || report.Versions["24.0.7"] != 1 {
+		t.Errorf("unexpected version counts %v", report.Versions)
+	}
+	if len(report.Hosts) != 4 {
+		t.Fatalf("expected 4 hosts without the Incus one, got %d", len(report.Hosts))
+	}
+
+	// Hosts with warnings come first
+	if report.Hosts[0].HostName != "beta" || report.Hosts[1].HostName != "gamma" {
+		t.Fatalf("expected beta and gamma first, got %s and %s", report.Hosts[0].HostName, report.Hosts[1].HostName)
+	}
+	if w := report.Hosts[0].Warnings; len(w) != 1 || w[0] != models.EngineWarningRebootRequired {
+		t.Errorf("expected beta to need a reboot only, got %v", w)
+	}
+	gamma := report.Hosts[1]
+	if len(gamma.Warnings) != 2 || gamma.Warnings[0] != models.EngineWarningDaemonRestartRequired || gamma.Warnings[1] != models.EngineWarningOutdated {
+		t.Errorf("expected gamma to need a daemon restart and be outdated, got %v", gamma.Warnings)
+	}
+	if gamma.MajorsBehind != 3 {
+		t.Errorf("expected gamma 3 majors behind, got %d", gamma.MajorsBehind)
+	}
+	if report.Hosts[3].HostName != "epsilon" || report.Hosts[3].System != nil || len(report.Hosts[3].Warnings) != 0 {
+		t.Errorf("expected the unscanned host last without warnings, got %+v", report.Hosts[3])
+	}
+}
+
+func TestHandleGetHostEngines(t *testing.T) {
+	server, db := setupTestServer(t)
+	if _, err := db.AddHost(models.Host{Name: "alpha", Address: "agent://alpha:9876", Enabled: true}); err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/api/hosts/engines", nil)
+	w := httptest.NewRecorder()
+	server.handleGetHostEngines(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var report models.EngineReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(report.Hosts) != 1 || report.Hosts[0].HostName != "alpha" || report.Hosts[0].System != nil {
+		t.Errorf("expected alpha without a system report, got %+v", report.Hosts)
+	}
+}
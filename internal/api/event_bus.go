@@ -0,0 +1,223 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// handleGetBusEvents returns event bus events after an event ID, oldest first, for consumers
+// that poll instead of subscribing. Filters: after (event ID), type (comma-separated), host_id
+// and limit (default 100, at most 1000).
+func (s *Server) handleGetBusEvents(w http.ResponseWriter, r *http.Request) {
+	hostIDs, ok := s.queryHostIDs(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+
+	var after int64
+	if v := query.Get("after"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id < 0 {
+			respondError(w, http.StatusBadRequest, "Invalid after event ID")
+			return
+		}
+		after = id
+	}
+	var types []string
+	if v := query.Get("type"); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			t = strings.TrimSpace(t)
+			if !models.ValidBusEventType(t) {
+				respondError(w, http.StatusBadRequest, "Unknown event type: "+t)
+				return
+			}
+			types = append(types, t)
+		}
+	}
+	limit := 100
+	if v := query.Get("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 {
+			limit = l
+		}
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+
+	events, err := s.db.GetBusEvents(after, types, hostIDs, limit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get events: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, events)
+}
+
+// handleGetEventSubscriptions returns the event subscriptions with secrets masked
+func (s *Server) handleGetEventSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subs, err := s.db.GetEventSubscriptions()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get event subscriptions: "+err.Error())
+		return
+	}
+	redacted := make([]*models.EventSubscription, len(subs))
+	for i := range subs {
+		redacted[i] = subs[i].Redacted()
+	}
+	respondJSON(w, http.StatusOK, redacted)
+}
+
+// handleCreateEventSubscription creates a subscription. It gets the events published from now
+// on; older events can be replayed.
+func (s *Server) handleCreateEventSubscription(w http.ResponseWriter, r *http.Request) {
+	var sub models.EventSubscription
+	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	if err := sub.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cursor, err := s.db.GetLastBusEventID()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get events: "+err.Error())
+		return
+	}
+	sub.ID = 0
+	sub.Cursor = cursor
+	sub.LastDeliveryAt = nil
+	sub.LastError = ""
+	sub.CreatedAt = time.Now()
+	if err := s.db.SaveEventSubscription(&sub); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save event subscription: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusCreated, sub.Redacted())
+}
+
+// handleUpdateEventSubscription updates a subscription's name, URL, event types, secret and
+// enabled flag. Masked secrets sent back from handleGetEventSubscriptions keep the stored values.
+func (s *Server) handleUpdateEventSubscription(w http.ResponseWriter, r *http.Request) {
+	current, ok := s.eventSubscription(w, r)
+	if !ok {
+		return
+	}
+	var sub models.EventSubscription
+	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	if sub.Secret == models.MaskedSecret {
+		sub.Secret = current.Secret
+	}
+	sub.URL = keepURLPassword(sub.URL, current.URL)
+	if err := sub.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sub.ID = current.ID
+	if err := s.db.SaveEventSubscription(&sub); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save event subscription: "+err.Error())
+		return
+	}
+	saved, err := s.db.GetEventSubscription(sub.ID)
+	if err != nil || saved == nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get event subscription")
+		return
+	}
+	respondJSON(w, http.StatusOK, saved.Redacted())
+}
+
+// handleDeleteEventSubscription removes a subscription
+func (s *Server) handleDeleteEventSubscription(w http.ResponseWriter, r *http.Request) {
+	sub, ok := s.eventSubscription(w, r)
+	if !ok {
+		return
+	}
+	if err := s.db.DeleteEventSubscription(sub.ID); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete event subscription: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Event subscription deleted"})
+}
+
+// handleReplayEventSubscription redelivers a subscription's events from the event ID
+// from_event_id on, as far as they are still kept
+func (s *Server) handleReplayEventSubscription(w http.ResponseWriter, r *http.Request) {
+	sub, ok := s.eventSubscription(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		FromEventID int64 `json:"from_event_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	if req.FromEventID < 1 {
+		respondError(w, http.StatusBadRequest, "from_event_id must be a positive event ID")
+		return
+	}
+
+	if err := s.db.SetEventSubscriptionCursor(sub.ID, req.FromEventID-1); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to replay events: "+err.Error())
+		return
+	}
+	if s.eventBus != nil {
+		go s.eventBus.Dispatch(context.Background())
+	}
+	respondJSON(w, http.StatusAccepted, map[string]interface{}{"message": "Replay started", "from_event_id": req.FromEventID})
+}
+
+// eventSubscription returns the subscription of the request's {id}, responding with an error
+// when there is none
+func (s *Server) eventSubscription(w http.ResponseWriter, r *http.Request) (*models.EventSubscription, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid subscription ID")
+		return nil, false
+	}
+	sub, err := s.db.GetEventSubscription(id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get event subscription: "+err.Error())
+		return nil, false
+	}
+	if sub == nil {
+		respondError(w, http.StatusNotFound, "Event subscription not found")
+		return nil, false
+	}
+	return sub, true
+}
+
+// keepURLPassword puts the password of current back into rawURL when rawURL has the masked
+// password of the same user
+func keepURLPassword(rawURL, current string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil {
+		return rawURL
+	}
+	if password, ok := u.User.Password(); !ok || password != models.MaskedSecret {
+		return rawURL
+	}
+	c, err := url.Parse(current)
+	if err != nil || c.User == nil || c.User.Username() != u.User.Username() {
+		return rawURL
+	}
+	if password, ok := c.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), password)
+		return u.String()
+	}
+	return rawURL
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/auth"
+	"github.com/container-census/container-census/internal/models"
+	"github.com/gorilla/mux"
+)
+
+func TestEventSubscriptions(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	if err := db.InsertBusEvents([]models.BusEvent{
+		{Type: models.BusEventScanCompleted, Timestamp: time.Now(), HostID: 1},
+		{Type: models.BusEventContainerStopped, Timestamp: time.Now(), HostID: 1, ContainerName: "web"},
+	}); err != nil {
+		t.Fatalf("Failed to insert events: %v", err)
+	}
+
+	call := func(handler http.HandlerFunc, method, target, body string, vars map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req = mux.SetURLVars(req, vars)
+		req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{Username: "admin"}))
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	w := call(server.handleGetBusEvents, http.MethodGet, "/api/events?after=1&type=container_stopped", "", nil)
+	var events []models.BusEvent
+	if err := json.Unmarshal(w.Body.Bytes(), &events); err != nil || len(events) != 1 || events[0].ContainerName != "web" {
+		t.Fatalf("Expected the stopped container event, got %s", w.Body.String())
+	}
+	if w := call(server.handleGetBusEvents, http.MethodGet, "/api/events?type=nope", "", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown type, got %d", w.Code)
+	}
+
+	// A new subscription starts after the latest event
+	w = call(server.handleCreateEventSubscription, http.MethodPost, "/api/event-subscriptions",
+		`{"name":"nats","url":"nats://census:pw@nats.lan:4222/homelab","secret":"s3cret","enabled":true}`, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var sub models.EventSubscription
+	json.Unmarshal(w.Body.Bytes(), &sub)
+	if sub.Cursor != 2 || sub.Secret != models.MaskedSecret || strings.Contains(sub.URL, "pw") {
+		t.Errorf("Unexpected subscription %+v", sub)
+	}
+	if w := call(server.handleCreateEventSubscription, http.MethodPost, "/api/event-subscriptions", `{"name":"x","url":"ftp://x"}`, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unsupported URL, got %d", w.Code)
+	}
+
+	// Masked secrets sent back keep the stored ones
+	vars := map[string]string{"id": itoa(sub.ID)}
+	body, _ := json.Marshal(models.EventSubscription{Name: "automation", URL: sub.URL, Secret: sub.Secret, EventTypes: []string{"scan_failed"}})
+	if w := call(server.handleUpdateEventSubscription, http.MethodPut, "/api/event-subscriptions/1", string(body), vars); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	saved, _ := db.GetEventSubscription(sub.ID)
+	if saved.Name != "automation" || saved.Secret != "s3cret" || saved.URL != "nats://census:pw@nats.lan:4222/homelab" || saved.Enabled || saved.Cursor != 2 {
+		t.Errorf("Unexpected saved subscription %+v", saved)
+	}
+
+	if w := call(server.handleReplayEventSubscription, http.MethodPost, "/api/event-subscriptions/1/replay", `{"from_event_id":1}`, vars); w.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+	if saved, _ := db.GetEventSubscription(sub.ID); saved.Cursor != 0 {
+		t.Errorf("Expected the cursor before event 1, got %d", saved.Cursor)
+	}
+
+	if w := call(server.handleDeleteEventSubscription, http.MethodDelete, "/api/event-subscriptions/1", "", vars); w.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", w.Code)
+	}
+	if w := call(server.handleDeleteEventSubscription, http.MethodDelete, "/api/event-subscriptions/1", "", vars); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 once deleted, got %d", w.Code)
+	}
+}
@@ -0,0 +1,566 @@
+package api
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// filterContainers returns the containers matching the predicate
+func filterContainers(containers []models.Container, keep func(models.Container) bool) []models.Container {
+	filtered := make([]models.Container, 0, len(containers))
+	for _, c := range containers {
+		if keep(c) {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+// buildContainerGraph builds container/network nodes and their connection edges
+func buildContainerGraph(containers []models.Container) models.ContainerGraph {
+	// Build graph nodes and edges
+	graph := models.ContainerGraph{
+		Nodes: make([]models.ContainerGraphNode, 0, len(containers)),
+		Edges: make([]models.ContainerGraphEdge, 0),
+	}
+
+	// Create container nodes
+	for _, c := range containers {
+		node := models.ContainerGraphNode{
+			ID:             c.ID,
+			Name:           c.Name,
+			NodeType:       "container",
+			Image:          c.Image,
+			State:          c.State,
+			HostID:         c.HostID,
+			HostName:       c.HostName,
+			ComposeProject: c.ComposeProject,
+		}
+		graph.Nodes = append(graph.Nodes, node)
+	}
+
+	// Create network nodes by collecting all unique networks
+	networkMap := make(map[string]map[int64]bool) // network name -> set of host IDs
+	for _, c := range containers {
+		for _, network := range c.Networks {
+			if networkMap[network] == nil {
+				networkMap[network] = make(map[int64]bool)
+			}
+			networkMap[network][c.HostID] = true
+		}
+	}
+
+	// Create a network node for each unique network+host combination
+	networkNodeIDs := make(map[string]string) // network+host -> node ID
+	for networkName, hostIDs := range networkMap {
+		for hostID := range hostIDs {
+			// Create a unique ID for this network on this host
+			networkNodeID := fmt.Sprintf("net-%d-%s", hostID, networkName)
+			networkNodeIDs[fmt.Sprintf("%d-%s", hostID, networkName)] = networkNodeID
+
+			// Find host name for this network node
+			var hostName string
+			for _, c := range containers {
+				if c.HostID == hostID {
+					hostName = c.HostName
+					break
+				}
+			}
+
+			graph.Nodes = append(graph.Nodes, models.ContainerGraphNode{
+				ID:       networkNodeID,
+				Name:     networkName,
+				NodeType: "network",
+				HostID:   hostID,
+				HostName: hostName,
+			})
+		}
+	}
+
+	// Build edges by analyzing connections
+	// Track which connections we've already added to avoid duplicates
+	edgeMap := make(map[string]bool)
+
+	for i, c1 := range containers {
+		// Network connections - connect each container to its network nodes
+		for _, network := range c1.Networks {
+			// Get the network node ID for this network on this host
+			networkKey := fmt.Sprintf("%d-%s", c1.HostID, network)
+			if networkNodeID, exists := networkNodeIDs[networkKey]; exists {
+				edgeKey := c1.ID + "-" + networkNodeID + "-network"
+				if !edgeMap[edgeKey] {
+					graph.Edges = append(graph.Edges, models.ContainerGraphEdge{
+						Source: c1.ID,
+						Target: networkNodeID,
+						Type:   "network",
+						Label:  "", // No label needed since network node itself has the name
+					})
+					edgeMap[edgeKey] = true
+				}
+			}
+		}
+
+		// Volume connections (shared volumes)
+		for _, vol1 := range c1.Volumes {
+			if vol1.Type != "volume" || vol1.Name == "" {
+				continue // Only process named volumes
+			}
+			// Find other containers with the same volume on the same host
+			for j, c2 := range containers {
+				if i >= j {
+					continue
+				}
+				// Volumes are isolated per Docker daemon - only connect containers on same host
+				if c1.HostID != c2.HostID {
+					continue
+				}
+				for _, vol2 := range c2.Volumes {
+					if vol1.Name == vol2.Name && vol1.Type == vol2.Type {
+						edgeKey := c1.ID + "-" + c2.ID + "-volume-" + vol1.Name
+						if !edgeMap[edgeKey] {
+							graph.Edges = append(graph.Edges, models.ContainerGraphEdge{
+								Source: c1.ID,
+								Target: c2.ID,
+								Type:   "volume",
+								Label:  vol1.Name,
+							})
+							edgeMap[edgeKey] = true
+						}
+					}
+				}
+			}
+		}
+
+		// Legacy links
+		for _, link := range c1.Links {
+			// Links are in format: /container_name:/alias
+			// Extract the target container name
+			parts := strings.Split(link, ":")
+			if len(parts) > 0 {
+				targetName := strings.TrimPrefix(parts[0], "/")
+				// Find the target container by name on the same host
+				for _, c2 := range containers {
+					// Links only work on same host
+					if c1.HostID != c2.HostID {
+						continue
+					}
+					if c2.Name == targetName {
+						edgeKey := c1.ID + "-" + c2.ID + "-link"
+						if !edgeMap[edgeKey] {
+							graph.Edges = append(graph.Edges, models.ContainerGraphEdge{
+								Source: c1.ID,
+								Target: c2.ID,
+								Type:   "link",
+								Label:  "linked",
+							})
+							edgeMap[edgeKey] = true
+						}
+						break
+					}
+				}
+			}
+		}
+
+		// Docker Compose depends_on from labels
+		if dependsOn, ok := c1.Labels["com.docker.compose.depends_on"]; ok && dependsOn != "" {
+			// Format: "service1:condition:required,service2:condition:required"
+			dependencies := strings.Split(dependsOn, ",")
+			for _, dep := range dependencies {
+				// Parse "service:condition:required"
+				depParts := strings.Split(strings.TrimSpace(dep), ":")
+				if len(depParts) > 0 {
+					targetService := depParts[0]
+					// Find container with matching compose service name on same host
+					for _, c2 := range containers {
+						if serviceName, ok := c2.Labels["com.docker.compose.service"]; ok && serviceName == targetService {
+							// Only create edge if same compose project AND same host
+							if c1.ComposeProject != "" && c1.ComposeProject == c2.ComposeProject && c1.HostID == c2.HostID {
+								edgeKey := c1.ID + "-" + c2.ID + "-depends"
+								if !edgeMap[edgeKey] {
+									graph.Edges = append(graph.Edges, models.ContainerGraphEdge{
+										Source: c1.ID,
+										Target: c2.ID,
+										Type:   "depends",
+										Label:  "depends on",
+									})
+									edgeMap[edgeKey] = true
+								}
+							}
+							break
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return graph
+}
+
+// hostAddressNames returns the lowercase names a host can be reached by: the hostname
+// or IP from its address, the host's display name, and short forms of both
+func hostAddressNames(host models.Host) []string {
+	var names []string
+	add := func(name string) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			return
+		}
+		names = append(names, name)
+		// "nas.lan" should also match references to "nas"
+		if net.ParseIP(name) == nil {
+			if short, _, ok := strings.Cut(name, "."); ok && short != "" {
+				names = append(names, short)
+			}
+		}
+	}
+
+	if u, err := url.Parse(host.Address); err == nil && u.Scheme != "unix" {
+		add(u.Hostname())
+	}
+	add(host.Name)
+	return names
+}
+
+// addCrossHostEdges infers service connections between hosts by matching host:port
+// references found in container environment variables against ports published by
+// containers on other hosts
+func addCrossHostEdges(graph *models.ContainerGraph, containers []models.Container, hosts []models.Host) {
+	// Index host names -> host IDs
+	hostsByName := make(map[string][]int64)
+	for _, host := range hosts {
+		for _, name := range hostAddressNames(host) {
+			hostsByName[name] = append(hostsByName[name], host.ID)
+		}
+	}
+
+	// Index published ports: "hostID:port" -> containers publishing it.
+	// Ports bound to a specific IP are also reachable through that IP.
+	published := make(map[string][]models.Container)
+	for _, c := range containers {
+		for _, p := range c.Ports {
+			if p.PublicPort == 0 {
+				continue
+			}
+			key := fmt.Sprintf("%d:%d", c.HostID, p.PublicPort)
+			published[key] = append(published[key], c)
+			if p.IP != "" && p.IP != "0.0.0.0" && p.IP != "::" {
+				hostsByName[strings.ToLower(p.IP)] = append(hostsByName[strings.ToLower(p.IP)], c.HostID)
+			}
+		}
+	}
+
+	edgeMap := make(map[string]bool)
+	for _, c1 := range containers {
+		for _, endpoint := range c1.EnvEndpoints {
+			hostname, port, err := net.SplitHostPort(endpoint)
+			if err != nil {
+				continue
+			}
+			for _, hostID := range hostsByName[strings.ToLower(hostname)] {
+				// Same-host references are already covered by networks and links
+				if hostID == c1.HostID {
+					continue
+				}
+				for _, c2 := range published[fmt.Sprintf("%d:%s", hostID, port)] {
+					edgeKey := c1.ID + "-" + c2.ID + "-cross_host-" + port
+					if edgeMap[edgeKey] {
+						continue
+					}
+					graph.Edges = append(graph.Edges, models.ContainerGraphEdge{
+						Source: c1.ID,
+						Target: c2.ID,
+						Type:   "cross_host",
+						Label:  endpoint,
+					})
+					edgeMap[edgeKey] = true
+				}
+			}
+		}
+	}
+}
+
+// buildHostServiceMap collapses a container graph into one node per host with
+// aggregated host-to-host edges for each cross-host connection
+func buildHostServiceMap(graph models.ContainerGraph) models.ContainerGraph {
+	serviceMap := models.ContainerGraph{
+		Nodes: make([]models.ContainerGraphNode, 0),
+		Edges: make([]models.ContainerGraphEdge, 0),
+	}
+
+	nodeHosts := make(map[string]int64, len(graph.Nodes))
+	seenHosts := make(map[int64]bool)
+	for _, node := range graph.Nodes {
+		nodeHosts[node.ID] = node.HostID
+		if node.NodeType != "container" || seenHosts[node.HostID] {
+			continue
+		}
+		seenHosts[node.HostID] = true
+		serviceMap.Nodes = append(serviceMap.Nodes, models.ContainerGraphNode{
+			ID:       fmt.Sprintf("host-%d", node.HostID),
+			Name:     node.HostName,
+			NodeType: "host",
+			HostID:   node.HostID,
+			HostName: node.HostName,
+		})
+	}
+
+	// Count connections per host pair, keeping first-seen order for stable output
+	type hostPair struct{ source, target int64 }
+	var order []hostPair
+	counts := make(map[hostPair]int)
+	for _, edge := range graph.Edges {
+		if edge.Type != "cross_host" {
+			continue
+		}
+		pair := hostPair{nodeHosts[edge.Source], nodeHosts[edge.Target]}
+		if counts[pair] == 0 {
+			order = append(order, pair)
+		}
+		counts[pair]++
+	}
+
+	for _, pair := range order {
+		label := "1 connection"
+		if counts[pair] > 1 {
+			label = fmt.Sprintf("%d connections", counts[pair])
+		}
+		serviceMap.Edges = append(serviceMap.Edges, models.ContainerGraphEdge{
+			Source: fmt.Sprintf("host-%d", pair.source),
+			Target: fmt.Sprintf("host-%d", pair.target),
+			Type:   "host",
+			Label:  label,
+		})
+	}
+
+	return serviceMap
+}
+
+// graphNodeLabel returns a display label for a graph node
+func graphNodeLabel(node models.ContainerGraphNode) string {
+	if node.NodeType == "network" {
+		return fmt.Sprintf("%s (%s)", node.Name, node.HostName)
+	}
+	return node.Name
+}
+
+// renderGraphDOT renders the graph in Graphviz DOT format, clustered by host
+func renderGraphDOT(graph models.ContainerGraph) string {
+	var b strings.Builder
+	b.WriteString("digraph containers {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [fontname=\"Helvetica\"];\n")
+
+	// Group nodes by host so each host renders as a cluster
+	hostNodes := make(map[int64][]models.ContainerGraphNode)
+	hostNames := make(map[int64]string)
+	for _, node := range graph.Nodes {
+		hostNodes[node.HostID] = append(hostNodes[node.HostID], node)
+		if node.HostName != "" {
+			hostNames[node.HostID] = node.HostName
+		}
+	}
+
+	hostIDs := make([]int64, 0, len(hostNodes))
+	for hostID := range hostNodes {
+		hostIDs = append(hostIDs, hostID)
+	}
+	sort.Slice(hostIDs, func(i, j int) bool { return hostIDs[i] < hostIDs[j] })
+
+	for _, hostID := range hostIDs {
+		fmt.Fprintf(&b, "  subgraph \"cluster_host_%d\" {\n", hostID)
+		fmt.Fprintf(&b, "    label=%s;\n", dotQuote(hostNames[hostID]))
+		for _, node := range hostNodes[hostID] {
+			shape := "box"
+			if node.NodeType == "network" {
+				shape = "ellipse"
+			}
+			fmt.Fprintf(&b, "    %s [label=%s, shape=%s];\n", dotQuote(node.ID), dotQuote(graphNodeLabel(node)), shape)
+		}
+		b.WriteString("  }\n")
+	}
+
+	for _, edge := range graph.Edges {
+		label := edge.Type
+		if edge.Label != "" {
+			label = edge.Label
+		}
+		attrs := fmt.Sprintf("label=%s", dotQuote(label))
+		if edge.Type == "network" {
+			attrs += ", style=dashed, arrowhead=none"
+		}
+		fmt.Fprintf(&b, "  %s -> %s [%s];\n", dotQuote(edge.Source), dotQuote(edge.Target), attrs)
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotQuote quotes a string as a DOT identifier
+func dotQuote(s string) string {
+	return "\"" + strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(s) + "\""
+}
+
+// renderGraphMermaid renders the graph as a Mermaid flowchart, with a subgraph per host
+func renderGraphMermaid(graph models.ContainerGraph) string {
+	// Mermaid IDs must be simple identifiers, so map each node ID to a sequential one
+	ids := make(map[string]string, len(graph.Nodes))
+	for i, node := range graph.Nodes {
+		ids[node.ID] = fmt.Sprintf("n%d", i)
+	}
+
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+
+	hostOrder := make([]int64, 0)
+	hostNodes := make(map[int64][]models.ContainerGraphNode)
+	hostNames := make(map[int64]string)
+	for _, node := range graph.Nodes {
+		if _, seen := hostNodes[node.HostID]; !seen {
+			hostOrder = append(hostOrder, node.HostID)
+		}
+		hostNodes[node.HostID] = append(hostNodes[node.HostID], node)
+		if node.HostName != "" {
+			hostNames[node.HostID] = node.HostName
+		}
+	}
+
+	for _, hostID := range hostOrder {
+		fmt.Fprintf(&b, "  subgraph host_%d[%s]\n", hostID, mermaidQuote(hostNames[hostID]))
+		for _, node := range hostNodes[hostID] {
+			if node.NodeType == "network" {
+				fmt.Fprintf(&b, "    %s((%s))\n", ids[node.ID], mermaidQuote(node.Name))
+			} else {
+				fmt.Fprintf(&b, "    %s[%s]\n", ids[node.ID], mermaidQuote(node.Name))
+			}
+		}
+		b.WriteString("  end\n")
+	}
+
+	for _, edge := range graph.Edges {
+		source, ok1 := ids[edge.Source]
+		target, ok2 := ids[edge.Target]
+		if !ok1 || !ok2 {
+			continue
+		}
+		switch {
+		case edge.Type == "network":
+			fmt.Fprintf(&b, "  %s -.- %s\n", source, target)
+		case edge.Label != "":
+			fmt.Fprintf(&b, "  %s -->|%s| %s\n", source, mermaidQuote(edge.Label), target)
+		default:
+			fmt.Fprintf(&b, "  %s --> %s\n", source, target)
+		}
+	}
+
+	return b.String()
+}
+
+// mermaidQuote wraps a label in quotes, escaping characters Mermaid treats specially
+func mermaidQuote(s string) string {
+	return "\"" + strings.ReplaceAll(s, "\"", "#quot;") + "\""
+}
+
+// GEXF document structures (https://gexf.net/schema.html)
+type gexfDocument struct {
+	XMLName xml.Name  `xml:"gexf"`
+	XMLNS   string    `xml:"xmlns,attr"`
+	Version string    `xml:"version,attr"`
+	Graph   gexfGraph `xml:"graph"`
+}
+
+type gexfGraph struct {
+	DefaultEdgeType string         `xml:"defaultedgetype,attr"`
+	Attributes      gexfAttributes `xml:"attributes"`
+	Nodes           []gexfNode     `xml:"nodes>node"`
+	Edges           []gexfEdge     `xml:"edges>edge"`
+}
+
+type gexfAttributes struct {
+	Class      string          `xml:"class,attr"`
+	Attributes []gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfNode struct {
+	ID        string         `xml:"id,attr"`
+	Label     string         `xml:"label,attr"`
+	AttValues []gexfAttValue `xml:"attvalues>attvalue"`
+}
+
+type gexfAttValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type gexfEdge struct {
+	ID     string `xml:"id,attr"`
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+	Label  string `xml:"label,attr,omitempty"`
+	Kind   string `xml:"kind,attr,omitempty"`
+}
+
+// renderGraphGEXF renders the graph as a GEXF 1.3 document (Gephi and similar tools)
+func renderGraphGEXF(graph models.ContainerGraph) ([]byte, error) {
+	doc := gexfDocument{
+		XMLNS:   "http://gexf.net/1.3",
+		Version: "1.3",
+		Graph: gexfGraph{
+			DefaultEdgeType: "directed",
+			Attributes: gexfAttributes{
+				Class: "node",
+				Attributes: []gexfAttribute{
+					{ID: "node_type", Title: "node_type", Type: "string"},
+					{ID: "host", Title: "host", Type: "string"},
+					{ID: "image", Title: "image", Type: "string"},
+					{ID: "state", Title: "state", Type: "string"},
+					{ID: "compose_project", Title: "compose_project", Type: "string"},
+				},
+			},
+			Nodes: make([]gexfNode, 0, len(graph.Nodes)),
+			Edges: make([]gexfEdge, 0, len(graph.Edges)),
+		},
+	}
+
+	for _, node := range graph.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, gexfNode{
+			ID:    node.ID,
+			Label: graphNodeLabel(node),
+			AttValues: []gexfAttValue{
+				{For: "node_type", Value: node.NodeType},
+				{For: "host", Value: node.HostName},
+				{For: "image", Value: node.Image},
+				{For: "state", Value: node.State},
+				{For: "compose_project", Value: node.ComposeProject},
+			},
+		})
+	}
+
+	for i, edge := range graph.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, gexfEdge{
+			ID:     fmt.Sprintf("e%d", i),
+			Source: edge.Source,
+			Target: edge.Target,
+			Label:  edge.Label,
+			Kind:   edge.Type,
+		})
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
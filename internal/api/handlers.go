@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -13,13 +14,23 @@ import (
 	"time"
 
 	"github.com/container-census/container-census/internal/auth"
+	"github.com/container-census/container-census/internal/changelog"
+	"github.com/container-census/container-census/internal/containerops"
+	"github.com/container-census/container-census/internal/eventbus"
+	"github.com/container-census/container-census/internal/imageprune"
+	"github.com/container-census/container-census/internal/inspect"
+	"github.com/container-census/container-census/internal/mcp"
 	"github.com/container-census/container-census/internal/models"
 	"github.com/container-census/container-census/internal/notifications"
+	"github.com/container-census/container-census/internal/plugins"
 	"github.com/container-census/container-census/internal/registry"
 	"github.com/container-census/container-census/internal/scanner"
+	"github.com/container-census/container-census/internal/scripting"
 	"github.com/container-census/container-census/internal/storage"
 	"github.com/container-census/container-census/internal/telemetry"
+	"github.com/container-census/container-census/internal/updatehooks"
 	"github.com/container-census/container-census/internal/version"
+	imagetypes "github.com/docker/docker/api/types/image"
 	"github.com/gorilla/mux"
 )
 
@@ -37,9 +48,31 @@ type Server struct {
 	authConfig            auth.Config
 	setScanIntervalFunc   func(int)   // Callback to update scan interval
 	reloadSettingsFunc    func() error // Callback to reload all settings
+	trivyDBUpdatedFunc    func()       // Rescan after a manual Trivy database update
 	notificationService   *notifications.NotificationService
 	vulnScanner           VulnerabilityScanner
 	vulnScheduler         VulnerabilityScheduler
+	pluginRunner          *plugins.Runner
+	scriptEngine          *scripting.Engine
+	eventBus              *eventbus.Bus
+	mcpServer             *mcp.Server
+	changelogFetcher      *changelog.Fetcher
+	operations            *containerops.Tracker
+	canaries              canaryRuns
+	cache                 *QueryCache
+	proxyConfig           ProxyConfig
+	readOnlyForced        bool // READ_ONLY=true, see readOnly
+	liteMode              bool // low-resource profile of the server, see SetLiteMode
+	certWarningDays       int  // see SetCertWarningDays; zero uses models.DefaultCertWarningDays
+	agentTokenMaxAge      time.Duration // see SetAgentTokenPolicy; zero doesn't rotate on a schedule
+	agentTokenOverlap     time.Duration
+	liveStats             liveStatsHub // samples of /api/stats/live, taken while it has clients
+}
+
+// SetLiteMode tells the server it runs in lite mode (reported by /api/health, vulnerability
+// scanning is unavailable)
+func (s *Server) SetLiteMode(enabled bool) {
+	s.liteMode = enabled
 }
 
 // TelemetryScheduler interface for submitting telemetry on demand
@@ -57,12 +90,26 @@ func New(db *storage.DB, scanner *scanner.Scanner, scanInterval int, authConfig
 		router:         mux.NewRouter(),
 		scanInterval:   scanInterval,
 		authConfig:     authConfig,
+		mcpServer:      mcp.NewServer(db),
+		operations:     containerops.NewTracker(),
+		cache:          NewQueryCache(cacheTTL),
 	}
+	s.authConfig.Users = tenantUsers{db: db}
 
 	s.setupRoutes()
 	return s
 }
 
+// Operations returns the tracker of container actions started through the API
+func (s *Server) Operations() *containerops.Tracker {
+	return s.operations
+}
+
+// Cache returns the cache of heavy queries, which scans invalidate when they save containers
+func (s *Server) Cache() *QueryCache {
+	return s.cache
+}
+
 // SetScanIntervalCallback sets the callback function to update scan interval dynamically
 func (s *Server) SetScanIntervalCallback(callback func(int)) {
 	s.setScanIntervalFunc = callback
@@ -73,6 +120,12 @@ func (s *Server) SetReloadSettingsCallback(callback func() error) {
 	s.reloadSettingsFunc = callback
 }
 
+// SetTrivyDBUpdatedCallback sets the function run in the background after a manual Trivy
+// database update, which rescans the images of running containers
+func (s *Server) SetTrivyDBUpdatedCallback(callback func()) {
+	s.trivyDBUpdatedFunc = callback
+}
+
 // SetTelemetryScheduler sets the telemetry scheduler for on-demand submissions
 func (s *Server) SetTelemetryScheduler(scheduler *telemetry.Scheduler, ctx context.Context, cancel context.CancelFunc) {
 	s.telemetryMutex.Lock()
@@ -88,6 +141,26 @@ func (s *Server) SetNotificationService(ns *notifications.NotificationService) {
 	s.notificationService = ns
 }
 
+// SetPluginRunner sets the runner of collector plugins
+func (s *Server) SetPluginRunner(runner *plugins.Runner) {
+	s.pluginRunner = runner
+}
+
+// SetScriptEngine sets the engine running event scripts
+func (s *Server) SetScriptEngine(engine *scripting.Engine) {
+	s.scriptEngine = engine
+}
+
+// SetEventBus sets the event bus that scans and updates publish to
+func (s *Server) SetEventBus(bus *eventbus.Bus) {
+	s.eventBus = bus
+}
+
+// SetChangelogFetcher sets the fetcher of upstream release notes for available updates
+func (s *Server) SetChangelogFetcher(fetcher *changelog.Fetcher) {
+	s.changelogFetcher = fetcher
+}
+
 // RestartTelemetry stops and restarts the telemetry scheduler with new configuration
 func (s *Server) RestartTelemetry() error {
 	s.telemetryMutex.Lock()
@@ -171,15 +244,48 @@ func (s *Server) setupRoutes() {
 	// Protected API routes
 	api := s.router.PathPrefix("/api").Subrouter()
 	api.Use(sessionMiddleware)
+	api.Use(s.tenantMiddleware)
+	api.Use(s.timezoneMiddleware)
+	api.Use(s.readOnlyMiddleware)
 
 	// Host endpoints
 	api.HandleFunc("/hosts", s.handleGetHosts).Methods("GET")
+	api.HandleFunc("/sites", s.handleGetSites).Methods("GET")
+	api.HandleFunc("/hosts/engines", s.handleGetHostEngines).Methods("GET")
+	api.HandleFunc("/hosts/agent-tokens", s.handleGetAgentTokenAges).Methods("GET")
 	api.HandleFunc("/hosts/{id}", s.handleGetHost).Methods("GET")
 	api.HandleFunc("/hosts/{id}", s.handleUpdateHost).Methods("PUT")
 	api.HandleFunc("/hosts/{id}", s.handleDeleteHost).Methods("DELETE")
+	api.HandleFunc("/hosts/{id}/name", s.handleRenameHost).Methods("PUT")
+	api.HandleFunc("/hosts/{id}/uptime", s.handleGetHostUptime).Methods("GET")
+	api.HandleFunc("/daemon-events", s.handleGetDaemonEvents).Methods("GET")
+	api.HandleFunc("/docker-objects", s.handleGetDockerObjects).Methods("GET")
+	api.HandleFunc("/hosts/local", s.handleAddLocalHost).Methods("POST")
+	api.HandleFunc("/hosts/import", s.handleImportHosts).Methods("POST")
 	api.HandleFunc("/hosts/agent", s.handleAddAgentHost).Methods("POST")
 	api.HandleFunc("/hosts/agent/test", s.handleTestAgentConnection).Methods("POST")
+	api.HandleFunc("/hosts/incus", s.handleAddIncusHost).Methods("POST")
+	api.HandleFunc("/hosts/incus/test", s.handleTestIncusConnection).Methods("POST")
+	api.HandleFunc("/hosts/incus/certificate", s.handleGetIncusCertificate).Methods("GET")
 	api.HandleFunc("/hosts/agent/{id}/info", s.handleGetAgentInfo).Methods("GET")
+	api.HandleFunc("/hosts/{id}/agent-token/rotate", s.handleRotateAgentToken).Methods("POST")
+	api.HandleFunc("/hosts/{id}/registry-mirror/test", s.handleTestRegistryMirror).Methods("POST")
+	api.HandleFunc("/hosts/{id}/tenant", s.handleSetHostTenant).Methods("PUT")
+	api.HandleFunc("/hosts/{id}/apply-template", s.handleApplyHostTemplate).Methods("POST")
+
+	// Host template endpoints (administrator only)
+	api.HandleFunc("/host-templates", s.handleGetHostTemplates).Methods("GET")
+	api.HandleFunc("/host-templates", s.handleCreateHostTemplate).Methods("POST")
+	api.HandleFunc("/host-templates/{id}", s.handleUpdateHostTemplate).Methods("PUT")
+	api.HandleFunc("/host-templates/{id}", s.handleDeleteHostTemplate).Methods("DELETE")
+
+	// Tenant endpoints (administrator only, see tenantRoutes)
+	api.HandleFunc("/me", s.handleGetMe).Methods("GET")
+	api.HandleFunc("/tenants", s.handleGetTenants).Methods("GET")
+	api.HandleFunc("/tenants", s.handleCreateTenant).Methods("POST")
+	api.HandleFunc("/tenants/{id}", s.handleDeleteTenant).Methods("DELETE")
+	api.HandleFunc("/tenants/{id}/users", s.handleAddTenantUser).Methods("POST")
+	api.HandleFunc("/tenants/{id}/users/{user_id}", s.handleDeleteTenantUser).Methods("DELETE")
 
 	// Container endpoints
 	api.HandleFunc("/containers", s.handleGetContainers).Methods("GET")
@@ -188,12 +294,22 @@ func (s *Server) setupRoutes() {
 	api.HandleFunc("/containers/history", s.handleGetContainersHistory).Methods("GET")
 	api.HandleFunc("/containers/lifecycle", s.handleGetContainerLifecycles).Methods("GET")
 	api.HandleFunc("/containers/lifecycle/{host_id}/{container_name}", s.handleGetContainerLifecycleEvents).Methods("GET")
+	api.HandleFunc("/containers/image-history/{host_id}/{container_name}", s.handleGetContainerImageHistory).Methods("GET")
+	api.HandleFunc("/containers/stats/batch", s.handleGetContainerStatsBatch).Methods("POST")
 	api.HandleFunc("/containers/{host_id}/{container_id}/stats", s.handleGetContainerStats).Methods("GET")
+	api.HandleFunc("/containers/{host_id}/{container_id}/stats/live", s.handleStreamContainerStats).Methods("GET")
+	api.HandleFunc("/stats/top-consumers", s.handleGetTopConsumers).Methods("GET")
+	api.HandleFunc("/stats/overview", s.handleGetStatsOverview).Methods("GET")
+	api.HandleFunc("/stats/live", s.handleStreamFleetStats).Methods("GET")
 	api.HandleFunc("/containers/{host_id}/{container_id}/start", s.handleStartContainer).Methods("POST")
 	api.HandleFunc("/containers/{host_id}/{container_id}/stop", s.handleStopContainer).Methods("POST")
 	api.HandleFunc("/containers/{host_id}/{container_id}/restart", s.handleRestartContainer).Methods("POST")
 	api.HandleFunc("/containers/{host_id}/{container_id}", s.handleRemoveContainer).Methods("DELETE")
 	api.HandleFunc("/containers/{host_id}/{container_id}/logs", s.handleGetLogs).Methods("GET")
+	api.HandleFunc("/containers/{host_id}/{container_id}/inspect", s.handleGetContainerInspection).Methods("GET")
+	api.HandleFunc("/containers/{host_id}/{container_id}/recreate-spec", s.handleGetRecreateSpec).Methods("GET")
+	api.HandleFunc("/services", s.handleGetServices).Methods("GET")
+	api.HandleFunc("/operations", s.handleGetOperations).Methods("GET")
 
 	// Prometheus metrics endpoint (protected)
 	api.HandleFunc("/metrics", s.handlePrometheusMetrics).Methods("GET")
@@ -202,30 +318,89 @@ func (s *Server) setupRoutes() {
 	api.HandleFunc("/images", s.handleGetImages).Methods("GET")
 	api.HandleFunc("/images/host/{id}", s.handleGetImagesByHost).Methods("GET")
 	api.HandleFunc("/images/{host_id}/{image_id}", s.handleRemoveImage).Methods("DELETE")
+	api.HandleFunc("/images/{host_id}/{image_id}/layers", s.handleGetImageLayers).Methods("GET")
 	api.HandleFunc("/images/host/{id}/prune", s.handlePruneImages).Methods("POST")
+	api.HandleFunc("/images/host/{id}/usage", s.handleGetImageUsage).Methods("GET")
+	api.HandleFunc("/images/usage", s.handleSearchImageUsage).Methods("GET")
+	api.HandleFunc("/images/host/{id}/prune-policy", s.handlePolicyPruneImages).Methods("POST")
 
 	// Image update endpoints
 	api.HandleFunc("/image-updates/settings", s.handleGetImageUpdateSettings).Methods("GET")
 	api.HandleFunc("/image-updates/settings", s.handleUpdateImageUpdateSettings).Methods("PUT")
 	api.HandleFunc("/containers/{host_id}/{container_id}/check-update", s.handleCheckContainerUpdate).Methods("POST")
 	api.HandleFunc("/containers/{host_id}/{container_id}/update", s.handleUpdateContainer).Methods("POST")
+	api.HandleFunc("/containers/{host_id}/{container_id}/changelog", s.handleGetContainerChangelog).Methods("GET")
+	api.HandleFunc("/containers/{host_id}/{container_id}/pin", s.handlePinContainer).Methods("PUT")
+	api.HandleFunc("/containers/{host_id}/{container_id}/pin", s.handleUnpinContainer).Methods("DELETE")
+	api.HandleFunc("/pins", s.handleGetPins).Methods("GET")
+	api.HandleFunc("/containers/{host_id}/{container_id}/notes", s.handleGetContainerNotes).Methods("GET")
+	api.HandleFunc("/containers/{host_id}/{container_id}/notes", s.handleUpdateContainerNotes).Methods("PUT")
+	api.HandleFunc("/containers/{host_id}/{container_id}/notes", s.handleDeleteContainerNotes).Methods("DELETE")
+	api.HandleFunc("/hosts/{id}/notes", s.handleGetHostNotes).Methods("GET")
+	api.HandleFunc("/hosts/{id}/notes", s.handleUpdateHostNotes).Methods("PUT")
+	api.HandleFunc("/hosts/{id}/notes", s.handleDeleteHostNotes).Methods("DELETE")
+	api.HandleFunc("/notes", s.handleGetNotes).Methods("GET")
+	api.HandleFunc("/annotations", s.handleGetAnnotations).Methods("GET")
+	api.HandleFunc("/annotations", s.handleCreateAnnotation).Methods("POST")
+	api.HandleFunc("/annotations/{id}", s.handleUpdateAnnotation).Methods("PUT")
+	api.HandleFunc("/annotations/{id}", s.handleDeleteAnnotation).Methods("DELETE")
+	api.HandleFunc("/containers/{host_id}/{container_id}/owner", s.handleSetContainerOwner).Methods("PUT")
+	api.HandleFunc("/containers/{host_id}/{container_id}/owner", s.handleDeleteContainerOwner).Methods("DELETE")
+	api.HandleFunc("/stacks/{stack}/owner", s.handleSetStackOwner).Methods("PUT")
+	api.HandleFunc("/stacks/{stack}/owner", s.handleDeleteStackOwner).Methods("DELETE")
+	api.HandleFunc("/owners", s.handleGetOwners).Methods("GET")
 	api.HandleFunc("/containers/bulk-check-updates", s.handleBulkCheckUpdates).Methods("POST")
 	api.HandleFunc("/containers/bulk-update", s.handleBulkUpdate).Methods("POST")
+	api.HandleFunc("/updates", s.handleGetContainerUpdates).Methods("GET")
+	api.HandleFunc("/updates/canary", s.handleGetCanaryUpdates).Methods("GET")
+	api.HandleFunc("/updates/canary", s.handleStartCanaryUpdate).Methods("POST")
+	api.HandleFunc("/updates/canary/{id}", s.handleGetCanaryUpdate).Methods("GET")
+	api.HandleFunc("/updates/canary/{id}/cancel", s.handleCancelCanaryUpdate).Methods("POST")
+
+	// Event bus: raw events for polling, and subscriptions that get them pushed
+	api.HandleFunc("/events", s.handleGetBusEvents).Methods("GET")
+	api.HandleFunc("/event-subscriptions", s.handleGetEventSubscriptions).Methods("GET")
+	api.HandleFunc("/event-subscriptions", s.handleCreateEventSubscription).Methods("POST")
+	api.HandleFunc("/event-subscriptions/{id}", s.handleUpdateEventSubscription).Methods("PUT")
+	api.HandleFunc("/event-subscriptions/{id}", s.handleDeleteEventSubscription).Methods("DELETE")
+	api.HandleFunc("/event-subscriptions/{id}/replay", s.handleReplayEventSubscription).Methods("POST")
+
+	// Host migrations
+	api.HandleFunc("/migrations/plan", s.handlePlanHostMigration).Methods("POST")
+	api.HandleFunc("/migrations", s.handleGetHostMigrations).Methods("GET")
+	api.HandleFunc("/migrations", s.handleCreateHostMigration).Methods("POST")
+	api.HandleFunc("/migrations/{id}", s.handleGetHostMigration).Methods("GET")
+	api.HandleFunc("/migrations/{id}", s.handleDeleteHostMigration).Methods("DELETE")
+	api.HandleFunc("/migrations/{id}/items/{name}", s.handleUpdateHostMigrationItem).Methods("PUT")
 
 	// Scan endpoints
 	api.HandleFunc("/scan", s.handleTriggerScan).Methods("POST")
 	api.HandleFunc("/scan/results", s.handleGetScanResults).Methods("GET")
+	api.HandleFunc("/scan/results/{scan_id}", s.handleGetScan).Methods("GET")
+	api.HandleFunc("/scan/diagnostics", s.handleGetScanDiagnostics).Methods("GET")
 
 	// Activity log (scans + telemetry)
 	api.HandleFunc("/activity-log", s.handleGetActivityLog).Methods("GET")
 
 	// Reports endpoints
 	api.HandleFunc("/reports/changes", s.handleGetChangesReport).Methods("GET")
+	api.HandleFunc("/reports/snapshots", s.handleGetEnvironmentSnapshots).Methods("GET")
+	api.HandleFunc("/reports/snapshots", s.handleCreateEnvironmentSnapshot).Methods("POST")
+	api.HandleFunc("/reports/snapshots/diff", s.handleDiffEnvironmentSnapshots).Methods("GET")
+	api.HandleFunc("/reports/idle", s.handleGetIdleContainers).Methods("GET")
+	api.HandleFunc("/reports/restart-policies", s.handleGetRestartPolicyReport).Methods("GET")
+	api.HandleFunc("/reports/update-lag", s.handleGetUpdateLag).Methods("GET")
+	api.HandleFunc("/reports/bind-mounts", s.handleGetBindMountReport).Methods("GET")
+	api.HandleFunc("/reports/proxy-routes", s.handleGetProxyRouteReport).Methods("GET")
+	api.HandleFunc("/reports/endpoints", s.handleGetEndpointChecks).Methods("GET")
+	api.HandleFunc("/reports/endpoints/check", s.handleRunEndpointChecks).Methods("POST")
 
 	// Telemetry endpoints
 	api.HandleFunc("/telemetry/submit", s.handleSubmitTelemetry).Methods("POST")
 	api.HandleFunc("/telemetry/status", s.handleGetTelemetryStatus).Methods("GET")
 	api.HandleFunc("/telemetry/schedule", s.handleGetTelemetrySchedule).Methods("GET")
+	api.HandleFunc("/telemetry/preview", s.handleGetTelemetryPreview).Methods("GET")
+	api.HandleFunc("/telemetry/rotate-installation-id", s.handleRotateInstallationID).Methods("POST")
 	api.HandleFunc("/telemetry/reset-circuit-breaker/{name}", s.handleResetCircuitBreaker).Methods("POST")
 	api.HandleFunc("/telemetry/endpoints", s.handleGetTelemetryEndpoints).Methods("GET")
 	api.HandleFunc("/telemetry/endpoints", s.handleAddTelemetryEndpoint).Methods("POST")
@@ -250,12 +425,17 @@ func (s *Server) setupRoutes() {
 	api.HandleFunc("/notifications/logs/{id}/read", s.handleMarkNotificationRead).Methods("PUT")
 	api.HandleFunc("/notifications/logs/read-all", s.handleMarkAllNotificationsRead).Methods("PUT")
 	api.HandleFunc("/notifications/logs/clear", s.handleClearNotifications).Methods("DELETE")
+	api.HandleFunc("/notifications/logs/bulk", s.handleBulkNotifications).Methods("POST")
+	api.HandleFunc("/notifications/groups", s.handleGetNotificationGroups).Methods("GET")
+	api.HandleFunc("/notifications/stream", s.handleNotificationStream).Methods("GET")
 
 	api.HandleFunc("/notifications/silences", s.handleGetNotificationSilences).Methods("GET")
 	api.HandleFunc("/notifications/silences", s.handleCreateNotificationSilence).Methods("POST")
 	api.HandleFunc("/notifications/silences/{id}", s.handleDeleteNotificationSilence).Methods("DELETE")
 
 	api.HandleFunc("/notifications/status", s.handleGetNotificationStatus).Methods("GET")
+	api.HandleFunc("/notifications/stats", s.handleGetNotificationStats).Methods("GET")
+	api.HandleFunc("/notifications/baselines/{host_id}/{container_name}", s.handleGetSeasonalBaselines).Methods("GET")
 
 	// Vulnerability endpoints
 	api.HandleFunc("/vulnerabilities/summary", s.handleGetVulnerabilitySummary).Methods("GET")
@@ -266,14 +446,62 @@ func (s *Server) setupRoutes() {
 	api.HandleFunc("/vulnerabilities/scan-all", s.handleTriggerScanAll).Methods("POST")
 	api.HandleFunc("/vulnerabilities/queue", s.handleGetScanQueue).Methods("GET")
 	api.HandleFunc("/vulnerabilities/update-db", s.handleUpdateTrivyDB).Methods("POST")
+	api.HandleFunc("/vulnerabilities/server/health", s.handleGetTrivyServerHealth).Methods("GET")
+	api.HandleFunc("/vulnerabilities/results", s.handleIngestVulnerabilityResult).Methods("POST")
+	api.HandleFunc("/vulnerabilities/report", s.handleGetVulnerabilityReport).Methods("GET")
+	api.HandleFunc("/supply-chain/timeline", s.handleGetSupplyChainTimeline).Methods("GET")
+	api.HandleFunc("/vulnerabilities/exceptions", s.handleGetVulnerabilityExceptions).Methods("GET")
+	api.HandleFunc("/vulnerabilities/exceptions", s.handleCreateVulnerabilityException).Methods("POST")
+	api.HandleFunc("/vulnerabilities/exceptions/{id}", s.handleDeleteVulnerabilityException).Methods("DELETE")
 	api.HandleFunc("/vulnerabilities/settings", s.handleGetVulnerabilitySettings).Methods("GET")
 	api.HandleFunc("/vulnerabilities/settings", s.handleUpdateVulnerabilitySettings).Methods("PUT")
 
+	// Container privilege and port exposure audits
+	api.HandleFunc("/security/containers", s.handleGetContainerSecurity).Methods("GET")
+	api.HandleFunc("/security/ports", s.handleGetPortExposure).Methods("GET")
+	api.HandleFunc("/security/scores", s.handleGetSecurityScores).Methods("GET")
+	api.HandleFunc("/security/scores/history", s.handleGetSecurityScoreHistory).Methods("GET")
+
+	// Compliance endpoints (CIS Docker Benchmark host audits)
+	api.HandleFunc("/compliance/hosts", s.handleGetComplianceAudits).Methods("GET")
+	api.HandleFunc("/compliance/hosts/{id}", s.handleGetHostComplianceAudit).Methods("GET")
+	api.HandleFunc("/compliance/hosts/{id}/history", s.handleGetHostComplianceHistory).Methods("GET")
+	api.HandleFunc("/compliance/hosts/{id}/audit", s.handleAuditHost).Methods("POST")
+	api.HandleFunc("/compliance/audit", s.handleAuditAllHosts).Methods("POST")
+
+	// Backup endpoints (backup containers and their runs)
+	api.HandleFunc("/backups", s.handleGetBackupJobs).Methods("GET")
+	api.HandleFunc("/backups/runs", s.handleGetBackupRuns).Methods("GET")
+
+	// Plugin endpoints (custom per-scan collectors)
+	api.HandleFunc("/plugins", s.handleGetPlugins).Methods("GET")
+	api.HandleFunc("/plugins/results", s.handleGetPluginResults).Methods("GET")
+	api.HandleFunc("/scripts", s.handleGetScripts).Methods("GET")
+
+	// Model Context Protocol endpoint for AI assistants (read-only)
+	api.HandleFunc("/mcp", s.handleMCP).Methods("POST", "GET")
+
+	// Integration endpoints (Uptime Kuma monitor sync and status)
+	api.HandleFunc("/integrations/uptime-kuma/settings", s.handleGetUptimeKumaSettings).Methods("GET")
+	api.HandleFunc("/integrations/uptime-kuma/settings", s.handleUpdateUptimeKumaSettings).Methods("PUT")
+	api.HandleFunc("/integrations/uptime-kuma/sync", s.handleSyncUptimeKuma).Methods("POST")
+	api.HandleFunc("/integrations/uptime-kuma/status", s.handleGetUptimeStatuses).Methods("GET")
+	api.HandleFunc("/integrations/proxmox/settings", s.handleGetProxmoxSettings).Methods("GET")
+	api.HandleFunc("/integrations/proxmox/settings", s.handleUpdateProxmoxSettings).Methods("PUT")
+	api.HandleFunc("/integrations/proxmox/sync", s.handleSyncProxmox).Methods("POST")
+	api.HandleFunc("/integrations/proxmox/guests", s.handleGetProxmoxGuests).Methods("GET")
+	api.HandleFunc("/integrations/traefik/settings", s.handleGetTraefikSettings).Methods("GET")
+	api.HandleFunc("/integrations/traefik/settings", s.handleUpdateTraefikSettings).Methods("PUT")
+
 	// Settings endpoints (new database-first configuration)
 	api.HandleFunc("/settings", s.handleGetSettings).Methods("GET")
 	api.HandleFunc("/settings", s.handleUpdateSettings).Methods("PUT")
 	api.HandleFunc("/settings/export", s.handleExportSettings).Methods("GET")
+	api.HandleFunc("/settings/read-only", s.handleGetReadOnly).Methods("GET")
+	api.HandleFunc("/settings/read-only", s.handleUpdateReadOnly).Methods("PUT")
 	api.HandleFunc("/settings/import", s.handleImportSettings).Methods("POST")
+	api.HandleFunc("/settings/bundle", s.handleExportBundle).Methods("GET")
+	api.HandleFunc("/settings/bundle/import", s.handleImportBundle).Methods("POST")
 	api.HandleFunc("/settings/migration-status", s.handleGetMigrationStatus).Methods("GET")
 	api.HandleFunc("/settings/migration-ack", s.handleAcknowledgeMigration).Methods("POST")
 
@@ -284,6 +512,10 @@ func (s *Server) setupRoutes() {
 	api.HandleFunc("/settings/clear-activity", s.handleClearActivityLog).Methods("POST")
 	api.HandleFunc("/settings/nuclear-reset", s.handleNuclearReset).Methods("POST")
 
+	// Maintenance endpoints
+	api.HandleFunc("/maintenance/orphans", s.handleGetOrphans).Methods("GET")
+	api.HandleFunc("/maintenance/orphans/cleanup", s.handleCleanupOrphans).Methods("POST")
+
 	// User preferences endpoints
 	api.HandleFunc("/preferences", s.handleGetPreferences).Methods("GET")
 	api.HandleFunc("/preferences", s.handleUpdatePreferences).Methods("PUT")
@@ -296,19 +528,18 @@ func (s *Server) setupRoutes() {
 	s.router.PathPrefix("/").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Redirect root path to login page if auth is enabled and no session
 		if r.URL.Path == "/" && s.authConfig.Enabled {
-			session, _ := auth.GetSession(r)
-			if auth, ok := session.Values["authenticated"].(bool); !ok || !auth {
+			if !auth.SessionAuthenticated(s.authConfig, r) {
 				// Check if Basic Auth is provided
 				_, _, hasBasicAuth := r.BasicAuth()
 				if !hasBasicAuth {
-					http.Redirect(w, r, "/login.html", http.StatusFound)
+					http.Redirect(w, r, s.publicPath(r, "/login.html"), http.StatusFound)
 					return
 				}
 			}
 		}
 
 		// Allow login page and its dependencies without authentication
-		if r.URL.Path == "/login.html" || r.URL.Path == "/login.js" || r.URL.Path == "/styles.css" {
+		if r.URL.Path == "/login.html" || r.URL.Path == "/login.js" || r.URL.Path == "/basepath.js" || r.URL.Path == "/styles.css" {
 			http.FileServer(http.Dir("./web")).ServeHTTP(w, r)
 			return
 		}
@@ -331,6 +562,19 @@ func (s *Server) handleGetHosts(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusInternalServerError, "Failed to get hosts: "+err.Error())
 		return
 	}
+	hosts = visibleHosts(r, hosts)
+	if site, ok := siteFilter(r); ok {
+		inSite := make([]models.Host, 0, len(hosts))
+		for _, host := range hosts {
+			if host.Site == site {
+				inSite = append(inSite, host)
+			}
+		}
+		hosts = inSite
+	}
+	s.attachProxmox(hosts)
+	s.attachAgentHealth(hosts)
+	s.attachHostNotes(hosts)
 
 	respondJSON(w, http.StatusOK, hosts)
 }
@@ -348,6 +592,12 @@ func (s *Server) handleGetHost(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusNotFound, "Host not found")
 		return
 	}
+	if s.scanner != nil && host.HostType == "agent" {
+		host.AgentHealth = s.scanner.AgentHealth(host.ID)
+	}
+	if notes, err := s.db.GetNotes(host.ID, ""); err == nil {
+		host.Notes = notes
+	}
 
 	respondJSON(w, http.StatusOK, host)
 }
@@ -366,11 +616,34 @@ func (s *Server) handleUpdateHost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	existing, err := s.db.GetHost(id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Host not found")
+		return
+	}
+
 	host.ID = id
+	host.RegistryMirror = registry.NormalizeMirror(host.RegistryMirror)
+	host.Site = strings.TrimSpace(host.Site)
+	if host.HostType == "" {
+		host.HostType = existing.HostType
+	}
 	if err := s.db.UpdateHost(host); err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			respondError(w, http.StatusConflict, "A host with this name already exists")
+			return
+		}
 		respondError(w, http.StatusInternalServerError, "Failed to update host: "+err.Error())
 		return
 	}
+	// Containers and stats carry the host's name too
+	if host.Name != existing.Name {
+		if err := s.db.RenameHost(id, host.Name); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to rename host: "+err.Error())
+			return
+		}
+	}
+	s.cache.Invalidate()
 
 	respondJSON(w, http.StatusOK, map[string]string{"message": "Host updated successfully"})
 }
@@ -387,18 +660,40 @@ func (s *Server) handleDeleteHost(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusInternalServerError, "Failed to delete host: "+err.Error())
 		return
 	}
+	s.cache.Invalidate()
 
 	respondJSON(w, http.StatusOK, map[string]string{"message": "Host deleted successfully"})
 }
 
 func (s *Server) handleGetContainers(w http.ResponseWriter, r *http.Request) {
-	containers, err := s.db.GetLatestContainers()
+	containers, err := s.containerDetails()
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to get containers: "+err.Error())
 		return
 	}
+	if containers, err = s.visibleContainers(r, containers); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get hosts: "+err.Error())
+		return
+	}
+	if site, ok := siteFilter(r); ok {
+		hosts, err := s.db.GetHosts()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to get hosts: "+err.Error())
+			return
+		}
+		inSite := siteHostIDs(hosts, site)
+		filtered := make([]models.Container, 0, len(containers))
+		for _, c := range containers {
+			if inSite[c.HostID] {
+				filtered = append(filtered, c)
+			}
+		}
+		containers = filtered
+	}
+	// Operations in progress change without a scan and aren't cached
+	s.attachOperations(containers)
 
-	respondJSON(w, http.StatusOK, containers)
+	respondCachedJSON(w, r, containers)
 }
 
 func (s *Server) handleGetContainersByHost(w http.ResponseWriter, r *http.Request) {
@@ -414,8 +709,15 @@ func (s *Server) handleGetContainersByHost(w http.ResponseWriter, r *http.Reques
 		respondError(w, http.StatusInternalServerError, "Failed to get containers: "+err.Error())
 		return
 	}
+	s.attachUptime(containers)
+	s.attachPins(containers)
+	s.attachOperations(containers)
+	s.attachZeroStats(containers)
+	attachServiceMetadata(containers)
+	s.attachNotes(containers)
+	s.attachOwners(containers)
 
-	respondJSON(w, http.StatusOK, containers)
+	respondCachedJSON(w, r, containers)
 }
 
 func (s *Server) handleGetContainersHistory(w http.ResponseWriter, r *http.Request) {
@@ -506,191 +808,69 @@ func (s *Server) handleGetContainerLifecycleEvents(w http.ResponseWriter, r *htt
 	respondJSON(w, http.StatusOK, events)
 }
 
+// handleGetContainerGraph returns the container connection graph (or the host-level service map) as JSON, DOT, Mermaid or GEXF
 func (s *Server) handleGetContainerGraph(w http.ResponseWriter, r *http.Request) {
 	// Get latest containers with all connection details
-	containers, err := s.db.GetLatestContainers()
+	containers, err := s.latestContainers()
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to get containers: "+err.Error())
 		return
 	}
 
-	// Build graph nodes and edges
-	graph := models.ContainerGraph{
-		Nodes: make([]models.ContainerGraphNode, 0, len(containers)),
-		Edges: make([]models.ContainerGraphEdge, 0),
-	}
-
-	// Create container nodes
-	for _, c := range containers {
-		node := models.ContainerGraphNode{
-			ID:             c.ID,
-			Name:           c.Name,
-			NodeType:       "container",
-			Image:          c.Image,
-			State:          c.State,
-			HostID:         c.HostID,
-			HostName:       c.HostName,
-			ComposeProject: c.ComposeProject,
+	// Optional filters
+	if hostIDStr := r.URL.Query().Get("host_id"); hostIDStr != "" {
+		hostID, err := strconv.ParseInt(hostIDStr, 10, 64)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid host_id parameter")
+			return
 		}
-		graph.Nodes = append(graph.Nodes, node)
+		containers = filterContainers(containers, func(c models.Container) bool { return c.HostID == hostID })
 	}
-
-	// Create network nodes by collecting all unique networks
-	networkMap := make(map[string]map[int64]bool) // network name -> set of host IDs
-	for _, c := range containers {
-		for _, network := range c.Networks {
-			if networkMap[network] == nil {
-				networkMap[network] = make(map[int64]bool)
-			}
-			networkMap[network][c.HostID] = true
-		}
+	if project := r.URL.Query().Get("compose_project"); project != "" {
+		containers = filterContainers(containers, func(c models.Container) bool { return c.ComposeProject == project })
 	}
 
-	// Create a network node for each unique network+host combination
-	networkNodeIDs := make(map[string]string) // network+host -> node ID
-	for networkName, hostIDs := range networkMap {
-		for hostID := range hostIDs {
-			// Create a unique ID for this network on this host
-			networkNodeID := fmt.Sprintf("net-%d-%s", hostID, networkName)
-			networkNodeIDs[fmt.Sprintf("%d-%s", hostID, networkName)] = networkNodeID
-
-			// Find host name for this network node
-			var hostName string
-			for _, c := range containers {
-				if c.HostID == hostID {
-					hostName = c.HostName
-					break
-				}
-			}
-
-			graph.Nodes = append(graph.Nodes, models.ContainerGraphNode{
-				ID:       networkNodeID,
-				Name:     networkName,
-				NodeType: "network",
-				HostID:   hostID,
-				HostName: hostName,
-			})
-		}
+	hosts, err := s.db.GetHosts()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get hosts: "+err.Error())
+		return
 	}
 
-	// Build edges by analyzing connections
-	// Track which connections we've already added to avoid duplicates
-	edgeMap := make(map[string]bool)
-
-	for i, c1 := range containers {
-		// Network connections - connect each container to its network nodes
-		for _, network := range c1.Networks {
-			// Get the network node ID for this network on this host
-			networkKey := fmt.Sprintf("%d-%s", c1.HostID, network)
-			if networkNodeID, exists := networkNodeIDs[networkKey]; exists {
-				edgeKey := c1.ID + "-" + networkNodeID + "-network"
-				if !edgeMap[edgeKey] {
-					graph.Edges = append(graph.Edges, models.ContainerGraphEdge{
-						Source: c1.ID,
-						Target: networkNodeID,
-						Type:   "network",
-						Label:  "", // No label needed since network node itself has the name
-					})
-					edgeMap[edgeKey] = true
-				}
-			}
-		}
-
-		// Volume connections (shared volumes)
-		for _, vol1 := range c1.Volumes {
-			if vol1.Type != "volume" || vol1.Name == "" {
-				continue // Only process named volumes
-			}
-			// Find other containers with the same volume on the same host
-			for j, c2 := range containers {
-				if i >= j {
-					continue
-				}
-				// Volumes are isolated per Docker daemon - only connect containers on same host
-				if c1.HostID != c2.HostID {
-					continue
-				}
-				for _, vol2 := range c2.Volumes {
-					if vol1.Name == vol2.Name && vol1.Type == vol2.Type {
-						edgeKey := c1.ID + "-" + c2.ID + "-volume-" + vol1.Name
-						if !edgeMap[edgeKey] {
-							graph.Edges = append(graph.Edges, models.ContainerGraphEdge{
-								Source: c1.ID,
-								Target: c2.ID,
-								Type:   "volume",
-								Label:  vol1.Name,
-							})
-							edgeMap[edgeKey] = true
-						}
-					}
-				}
-			}
-		}
-
-		// Legacy links
-		for _, link := range c1.Links {
-			// Links are in format: /container_name:/alias
-			// Extract the target container name
-			parts := strings.Split(link, ":")
-			if len(parts) > 0 {
-				targetName := strings.TrimPrefix(parts[0], "/")
-				// Find the target container by name on the same host
-				for _, c2 := range containers {
-					// Links only work on same host
-					if c1.HostID != c2.HostID {
-						continue
-					}
-					if c2.Name == targetName {
-						edgeKey := c1.ID + "-" + c2.ID + "-link"
-						if !edgeMap[edgeKey] {
-							graph.Edges = append(graph.Edges, models.ContainerGraphEdge{
-								Source: c1.ID,
-								Target: c2.ID,
-								Type:   "link",
-								Label:  "linked",
-							})
-							edgeMap[edgeKey] = true
-						}
-						break
-					}
-				}
-			}
-		}
+	graph := buildContainerGraph(containers)
+	addCrossHostEdges(&graph, containers, hosts)
 
-		// Docker Compose depends_on from labels
-		if dependsOn, ok := c1.Labels["com.docker.compose.depends_on"]; ok && dependsOn != "" {
-			// Format: "service1:condition:required,service2:condition:required"
-			dependencies := strings.Split(dependsOn, ",")
-			for _, dep := range dependencies {
-				// Parse "service:condition:required"
-				depParts := strings.Split(strings.TrimSpace(dep), ":")
-				if len(depParts) > 0 {
-					targetService := depParts[0]
-					// Find container with matching compose service name on same host
-					for _, c2 := range containers {
-						if serviceName, ok := c2.Labels["com.docker.compose.service"]; ok && serviceName == targetService {
-							// Only create edge if same compose project AND same host
-							if c1.ComposeProject != "" && c1.ComposeProject == c2.ComposeProject && c1.HostID == c2.HostID {
-								edgeKey := c1.ID + "-" + c2.ID + "-depends"
-								if !edgeMap[edgeKey] {
-									graph.Edges = append(graph.Edges, models.ContainerGraphEdge{
-										Source: c1.ID,
-										Target: c2.ID,
-										Type:   "depends",
-										Label:  "depends on",
-									})
-									edgeMap[edgeKey] = true
-								}
-							}
-							break
-						}
-					}
-				}
-			}
+	switch view := r.URL.Query().Get("view"); view {
+	case "", "containers":
+	case "hosts":
+		graph = buildHostServiceMap(graph)
+	default:
+		respondError(w, http.StatusBadRequest, "Invalid view parameter. Must be 'containers' or 'hosts'")
+		return
+	}
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		respondCachedJSON(w, r, graph)
+	case "dot":
+		w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		w.Header().Set("Content-Disposition", "attachment; filename=container-graph.dot")
+		w.Write([]byte(renderGraphDOT(graph)))
+	case "mermaid":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Disposition", "attachment; filename=container-graph.mmd")
+		w.Write([]byte(renderGraphMermaid(graph)))
+	case "gexf":
+		data, err := renderGraphGEXF(graph)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to render GEXF: "+err.Error())
+			return
 		}
+		w.Header().Set("Content-Type", "application/gexf+xml")
+		w.Header().Set("Content-Disposition", "attachment; filename=container-graph.gexf")
+		w.Write(data)
+	default:
+		respondError(w, http.StatusBadRequest, "Invalid format parameter. Must be 'json', 'dot', 'mermaid', or 'gexf'")
 	}
-
-	respondJSON(w, http.StatusOK, graph)
 }
 
 func (s *Server) handleTriggerScan(w http.ResponseWriter, r *http.Request) {
@@ -701,15 +881,38 @@ func (s *Server) handleTriggerScan(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Each host's scan gets its ID up front so the caller can follow it
+	type triggeredScan struct {
+		HostID   int64  `json:"host_id"`
+		HostName string `json:"host_name"`
+		ScanID   string `json:"scan_id"`
+	}
+	scans := make([]triggeredScan, 0, len(hosts))
+	for _, host := range hosts {
+		if host.Enabled {
+			scans = append(scans, triggeredScan{HostID: host.ID, HostName: host.Name, ScanID: scanner.NewScanID()})
+		}
+	}
+	hostsByID := make(map[int64]models.Host, len(hosts))
+	for _, host := range hosts {
+		hostsByID[host.ID] = host
+	}
+
 	// Trigger scan in background
 	go func() {
-		ctx := context.Background()
-		for _, host := range hosts {
-			if !host.Enabled {
+		for _, scan := range scans {
+			host := hostsByID[scan.HostID]
+			ctx := scanner.WithScanID(context.Background(), scan.ScanID)
+
+			// Don't record containers halfway through a start, stop or update
+			busy, version := s.operations.HostState(host.ID)
+			if busy {
+				scanner.Logf(ctx, "Skipping scan of host %s: container operation in progress", host.Name)
 				continue
 			}
 
 			result := models.ScanResult{
+				ScanID:    scan.ScanID,
 				HostID:    host.ID,
 				HostName:  host.Name,
 				StartedAt: time.Now(),
@@ -717,29 +920,47 @@ func (s *Server) handleTriggerScan(w http.ResponseWriter, r *http.Request) {
 
 			containers, err := s.scanner.ScanHost(ctx, host)
 			result.CompletedAt = time.Now()
+			var previous []models.Container
+
+			if err == nil {
+				if busy, after := s.operations.HostState(host.ID); busy || after != version {
+					scanner.Logf(ctx, "Discarding scan of host %s: container operation ran during the scan", host.Name)
+					continue
+				}
+			}
 
 			if err != nil {
 				result.Success = false
 				result.Error = err.Error()
-				log.Printf("Scan failed for host %s: %v", host.Name, err)
+				scanner.Logf(ctx, "Scan failed for host %s: %v", host.Name, err)
 			} else {
 				result.Success = true
 				result.ContainersFound = len(containers)
+				result.Warnings = models.CollectionWarnings(containers)
+
+				// The previous scan's containers, for the event bus
+				if s.eventBus != nil {
+					if previous, err = s.db.GetContainersByHost(host.ID); err != nil {
+						scanner.Logf(ctx, "Failed to get previous containers for host %s: %v", host.Name, err)
+					}
+				}
 
 				// Save containers
 				if err := s.db.SaveContainers(containers); err != nil {
-					log.Printf("Failed to save containers for host %s: %v", host.Name, err)
+					scanner.Logf(ctx, "Failed to save containers for host %s: %v", host.Name, err)
 				}
+				s.cache.Invalidate()
 			}
 
 			// Save scan result
 			if _, err := s.db.SaveScanResult(result); err != nil {
-				log.Printf("Failed to save scan result for host %s: %v", host.Name, err)
+				scanner.Logf(ctx, "Failed to save scan result for host %s: %v", host.Name, err)
 			}
+			s.eventBus.Publish(eventbus.ScanEvents(host, result, previous, containers)...)
 		}
 	}()
 
-	respondJSON(w, http.StatusAccepted, map[string]string{"message": "Scan triggered"})
+	respondJSON(w, http.StatusAccepted, map[string]interface{}{"message": "Scan triggered", "scans": scans})
 }
 
 func (s *Server) handleGetScanResults(w http.ResponseWriter, r *http.Request) {
@@ -761,6 +982,33 @@ func (s *Server) handleGetScanResults(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, results)
 }
 
+// handleGetScanDiagnostics returns the latest scan result of each host the request may see,
+// with its per-container collection warnings
+func (s *Server) handleGetScanDiagnostics(w http.ResponseWriter, r *http.Request) {
+	results, err := s.db.GetLatestScanResults()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get scan results: "+err.Error())
+		return
+	}
+	hosts, err := s.db.GetHosts()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get hosts: "+err.Error())
+		return
+	}
+	visible := make(map[int64]bool)
+	for _, host := range visibleHosts(r, hosts) {
+		visible[host.ID] = true
+	}
+
+	diagnostics := make([]models.ScanResult, 0, len(results))
+	for _, result := range results {
+		if visible[result.HostID] {
+			diagnostics = append(diagnostics, result)
+		}
+	}
+	respondJSON(w, http.StatusOK, diagnostics)
+}
+
 func (s *Server) handleGetActivityLog(w http.ResponseWriter, r *http.Request) {
 	limitStr := r.URL.Query().Get("limit")
 	limit := 50 // default
@@ -797,6 +1045,9 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 		"version": version.Get(),
 		"time":    time.Now().Format(time.RFC3339),
 	}
+	if s.liteMode {
+		response["lite_mode"] = true
+	}
 
 	// Add update information if available
 	updateInfo := version.GetUpdateInfo()
@@ -843,6 +1094,12 @@ func (s *Server) handleStartContainer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	done, ok := s.beginOperation(w, hostID, containerID, containerops.ActionStart)
+	if !ok {
+		return
+	}
+	defer done()
+
 	ctx := r.Context()
 	if err := s.scanner.StartContainer(ctx, *host, containerID); err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to start container: "+err.Error())
@@ -876,6 +1133,12 @@ func (s *Server) handleStopContainer(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	done, ok := s.beginOperation(w, hostID, containerID, containerops.ActionStop)
+	if !ok {
+		return
+	}
+	defer done()
+
 	ctx := r.Context()
 	if err := s.scanner.StopContainer(ctx, *host, containerID, timeout); err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to stop container: "+err.Error())
@@ -909,6 +1172,12 @@ func (s *Server) handleRestartContainer(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	done, ok := s.beginOperation(w, hostID, containerID, containerops.ActionRestart)
+	if !ok {
+		return
+	}
+	defer done()
+
 	ctx := r.Context()
 	if err := s.scanner.RestartContainer(ctx, *host, containerID, timeout); err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to restart container: "+err.Error())
@@ -937,6 +1206,12 @@ func (s *Server) handleRemoveContainer(w http.ResponseWriter, r *http.Request) {
 	// Get force from query param (default false)
 	force := r.URL.Query().Get("force") == "true"
 
+	done, ok := s.beginOperation(w, hostID, containerID, containerops.ActionRemove)
+	if !ok {
+		return
+	}
+	defer done()
+
 	ctx := r.Context()
 	if err := s.scanner.RemoveContainer(ctx, *host, containerID, force); err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to remove container: "+err.Error())
@@ -978,33 +1253,61 @@ func (s *Server) handleGetLogs(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, map[string]string{"logs": logs})
 }
 
+// handleGetContainerInspection returns the sanitized container configuration collected at the last scan
+func (s *Server) handleGetContainerInspection(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	hostID, err := strconv.ParseInt(vars["host_id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid host ID")
+		return
+	}
+
+	inspection, err := s.db.GetContainerInspection(hostID, vars["container_id"])
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get container configuration: "+err.Error())
+		return
+	}
+	if inspection == nil {
+		respondError(w, http.StatusNotFound, "No configuration collected for this container yet")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, inspection)
+}
+
 // Image Management Handlers
 
+// hostImages is a host's entry in the images of all hosts. Hosts that couldn't be reached are
+// listed with an error and no images rather than left out.
+type hostImages struct {
+	models.HostResult
+	Images []imagetypes.Summary `json:"images"`
+}
+
 func (s *Server) handleGetImages(w http.ResponseWriter, r *http.Request) {
 	hosts, err := s.db.GetHosts()
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to get hosts: "+err.Error())
 		return
 	}
+	hosts = dockerHosts(enabledHosts(visibleHosts(r, hosts)))
 
-	ctx := r.Context()
-	allImages := make(map[string]interface{})
-
-	for _, host := range hosts {
-		if !host.Enabled {
-			continue
-		}
+	images := make([][]imagetypes.Summary, len(hosts))
+	results := forEachHost(r.Context(), hosts, func(ctx context.Context, i int, host models.Host) error {
+		var err error
+		images[i], err = s.scanner.ListImages(ctx, host)
+		return err
+	})
 
-		images, err := s.scanner.ListImages(ctx, host)
-		if err != nil {
-			log.Printf("Failed to list images for host %s: %v", host.Name, err)
-			continue
+	allImages := make(map[string]hostImages, len(hosts))
+	for i, result := range results {
+		if result.Status != models.HostResultOK {
+			log.Printf("Failed to list images for host %s: %s", result.HostName, result.Error)
 		}
-
-		allImages[host.Name] = map[string]interface{}{
-			"host_id": host.ID,
-			"images":  images,
+		if images[i] == nil {
+			images[i] = []imagetypes.Summary{}
 		}
+		allImages[result.HostName] = hostImages{HostResult: result, Images: images[i]}
 	}
 
 	respondJSON(w, http.StatusOK, allImages)
@@ -1062,6 +1365,66 @@ func (s *Server) handleRemoveImage(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, map[string]string{"message": "Image removed"})
 }
 
+// handleGetImageLayers returns the layer sizes and build history of an image. Results are
+// cached by image ID since fetching history is expensive and an image ID never changes content.
+// Pass compare={image_id} to flag the layers shared with another image on the same host.
+func (s *Server) handleGetImageLayers(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	hostID, err := strconv.ParseInt(vars["host_id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid host ID")
+		return
+	}
+
+	host, err := s.db.GetHost(hostID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Host not found")
+		return
+	}
+
+	refresh := r.URL.Query().Get("refresh") == "true"
+
+	layers, err := s.getImageLayers(r.Context(), *host, vars["image_id"], refresh)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get image layers: "+err.Error())
+		return
+	}
+
+	if compareID := r.URL.Query().Get("compare"); compareID != "" {
+		other, err := s.getImageLayers(r.Context(), *host, compareID, refresh)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to get layers of comparison image: "+err.Error())
+			return
+		}
+		inspect.MarkSharedLayers(layers, other)
+	}
+
+	respondJSON(w, http.StatusOK, layers)
+}
+
+// getImageLayers serves image layers from the cache, fetching and caching them on a miss
+func (s *Server) getImageLayers(ctx context.Context, host models.Host, imageID string, refresh bool) (*models.ImageLayers, error) {
+	if !refresh {
+		cached, err := s.db.GetCachedImageLayers(host.ID, imageID)
+		if err != nil {
+			log.Printf("Failed to read cached layers for image %s: %v", imageID, err)
+		} else if cached != nil {
+			return cached, nil
+		}
+	}
+
+	layers, err := s.scanner.GetImageLayers(ctx, host, imageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.SaveImageLayers(layers); err != nil {
+		log.Printf("Failed to cache layers for image %s: %v", layers.ImageID, err)
+	}
+
+	return layers, nil
+}
+
 func (s *Server) handlePruneImages(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	hostID, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -1089,44 +1452,178 @@ func (s *Server) handlePruneImages(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// handleSubmitTelemetry triggers an immediate telemetry submission
-func (s *Server) handleSubmitTelemetry(w http.ResponseWriter, r *http.Request) {
-	s.telemetryMutex.Lock()
-	scheduler := s.telemetryScheduler
-	s.telemetryMutex.Unlock()
+// handleGetImageUsage lists the images on a host with when each last had a running container.
+// Pass unused_days=N to only return images in use by no container for at least N days.
+func (s *Server) handleGetImageUsage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	hostID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid host ID")
+		return
+	}
 
-	if scheduler == nil {
-		// Check if there are any enabled endpoints configured (load from database)
-		endpoints, err := s.db.GetTelemetryEndpoints()
-		if err != nil {
-			respondError(w, http.StatusInternalServerError, "Failed to load telemetry endpoints: "+err.Error())
+	minUnusedDays := 0
+	if v := r.URL.Query().Get("unused_days"); v != "" {
+		minUnusedDays, err = strconv.Atoi(v)
+		if err != nil || minUnusedDays < 0 {
+			respondError(w, http.StatusBadRequest, "Invalid unused_days")
 			return
 		}
+	}
 
-		// Count enabled endpoints
-		enabledCount := 0
-		for _, ep := range endpoints {
-			if ep.Enabled {
-				enabledCount++
+	host, err := s.db.GetHost(hostID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Host not found")
+		return
+	}
+
+	usage, err := s.getImageUsage(r.Context(), *host)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get image usage: "+err.Error())
+		return
+	}
+
+	if minUnusedDays > 0 {
+		filtered := make([]models.ImageUsage, 0)
+		for _, u := range usage {
+			if !u.InUse && u.UnusedDays >= minUnusedDays {
+				filtered = append(filtered, u)
 			}
 		}
+		usage = filtered
+	}
 
-		if enabledCount == 0 {
-			respondError(w, http.StatusServiceUnavailable, "No telemetry endpoints configured or enabled. Please enable community telemetry or add a private collector in Settings.")
-			return
-		}
+	respondJSON(w, http.StatusOK, usage)
+}
 
-		// Scheduler not running - try to start it
-		log.Printf("Telemetry scheduler not running, attempting to start with %d enabled endpoint(s)...", enabledCount)
-		if err := s.RestartTelemetry(); err != nil {
-			respondError(w, http.StatusInternalServerError, "Failed to initialize telemetry: "+err.Error())
+// handlePolicyPruneImages removes unused images selected by a prune policy instead of pruning
+// everything Docker considers unused. An empty body uses models.DefaultImagePrunePolicy.
+func (s *Server) handlePolicyPruneImages(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	hostID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid host ID")
+		return
+	}
+
+	policy := models.DefaultImagePrunePolicy()
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request body")
 			return
 		}
+	}
+	if policy.KeepTagsPerRepo < 0 || policy.MinUnusedDays < 0 {
+		respondError(w, http.StatusBadRequest, "keep_tags_per_repo and min_unused_days must not be negative")
+		return
+	}
 
-		// Get the scheduler again after restart
-		s.telemetryMutex.Lock()
-		scheduler = s.telemetryScheduler
-		s.telemetryMutex.Unlock()
+	host, err := s.db.GetHost(hostID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Host not found")
+		return
+	}
+
+	ctx := r.Context()
+	usage, err := s.getImageUsage(ctx, *host)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get image usage: "+err.Error())
+		return
+	}
+
+	result := models.ImagePruneResult{
+		DryRun:     policy.DryRun,
+		Policy:     policy,
+		Candidates: imageprune.SelectCandidates(usage, policy),
+	}
+
+	if !policy.DryRun {
+		for _, candidate := range result.Candidates {
+			// Never force: Docker refuses images that gained a container since the last scan
+			if err := s.scanner.RemoveImage(ctx, *host, candidate.ImageID, false); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", candidate.ImageID, err))
+				continue
+			}
+			result.Removed++
+			result.SpaceReclaimed += candidate.Size
+		}
+		log.Printf("Policy prune on host %s removed %d of %d candidate images", host.Name, result.Removed, len(result.Candidates))
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+// getImageUsage lists a host's images and joins them with recorded usage and the latest scan
+func (s *Server) getImageUsage(ctx context.Context, host models.Host) ([]models.ImageUsage, error) {
+	images, err := s.scanner.ListImages(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	imageIDs := make([]string, 0, len(images))
+	for _, img := range images {
+		imageIDs = append(imageIDs, img.ID)
+	}
+	if err := s.db.RecordImagesSeen(host.ID, imageIDs, now); err != nil {
+		log.Printf("Failed to record images seen on host %s: %v", host.Name, err)
+	}
+
+	records, err := s.db.GetImageUsage(host.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	containers, err := s.db.GetContainersByHost(host.ID)
+	if err != nil {
+		return nil, err
+	}
+	inUse := make(map[string]bool)
+	for _, c := range containers {
+		inUse[c.ImageID] = true
+	}
+
+	return imageprune.BuildUsage(images, records, inUse, now), nil
+}
+
+// handleSubmitTelemetry triggers an immediate telemetry submission
+func (s *Server) handleSubmitTelemetry(w http.ResponseWriter, r *http.Request) {
+	s.telemetryMutex.Lock()
+	scheduler := s.telemetryScheduler
+	s.telemetryMutex.Unlock()
+
+	if scheduler == nil {
+		// Check if there are any enabled endpoints configured (load from database)
+		endpoints, err := s.db.GetTelemetryEndpoints()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to load telemetry endpoints: "+err.Error())
+			return
+		}
+
+		// Count enabled endpoints
+		enabledCount := 0
+		for _, ep := range endpoints {
+			if ep.Enabled {
+				enabledCount++
+			}
+		}
+
+		if enabledCount == 0 {
+			respondError(w, http.StatusServiceUnavailable, "No telemetry endpoints configured or enabled. Please enable community telemetry or add a private collector in Settings.")
+			return
+		}
+
+		// Scheduler not running - try to start it
+		log.Printf("Telemetry scheduler not running, attempting to start with %d enabled endpoint(s)...", enabledCount)
+		if err := s.RestartTelemetry(); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to initialize telemetry: "+err.Error())
+			return
+		}
+
+		// Get the scheduler again after restart
+		s.telemetryMutex.Lock()
+		scheduler = s.telemetryScheduler
+		s.telemetryMutex.Unlock()
 
 		if scheduler == nil {
 			respondError(w, http.StatusServiceUnavailable, "Telemetry scheduler failed to initialize")
@@ -1460,6 +1957,58 @@ func (s *Server) handleGetTelemetrySchedule(w http.ResponseWriter, r *http.Reque
 	respondJSON(w, http.StatusOK, scheduleInfo)
 }
 
+// handleGetTelemetryPreview returns the exact payload the next telemetry submission would contain
+func (s *Server) handleGetTelemetryPreview(w http.ResponseWriter, r *http.Request) {
+	s.telemetryMutex.Lock()
+	scheduler := s.telemetryScheduler
+	s.telemetryMutex.Unlock()
+
+	if scheduler == nil {
+		// No scheduler running - build a throwaway one so the preview still reflects live data
+		var err error
+		scheduler, err = telemetry.NewScheduler(s.db, s.scanner, models.TelemetryConfig{}, s.scanInterval)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to initialize telemetry: "+err.Error())
+			return
+		}
+	}
+
+	report, err := scheduler.PreviewReport(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to build telemetry preview: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, report)
+}
+
+// handleRotateInstallationID generates a new anonymous installation ID
+func (s *Server) handleRotateInstallationID(w http.ResponseWriter, r *http.Request) {
+	newID, err := telemetry.RotateInstallationID()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to rotate installation ID: "+err.Error())
+		return
+	}
+
+	log.Printf("Telemetry installation ID rotated")
+
+	// Restart the scheduler so future submissions use the new ID
+	s.telemetryMutex.Lock()
+	running := s.telemetryScheduler != nil
+	s.telemetryMutex.Unlock()
+
+	if running {
+		if err := s.RestartTelemetry(); err != nil {
+			log.Printf("Warning: Failed to restart telemetry after ID rotation: %v", err)
+		}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{
+		"message":         "Installation ID rotated",
+		"installation_id": newID,
+	})
+}
+
 // handleGetContainerStats returns time-series stats for a specific container
 func (s *Server) handleGetContainerStats(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -1473,30 +2022,139 @@ func (s *Server) handleGetContainerStats(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Parse time range parameter
-	rangeParam := r.URL.Query().Get("range")
-	var hoursBack int
+	hoursBack, ok := statsRangeHours(r.URL.Query().Get("range"))
+	if !ok {
+		respondError(w, http.StatusBadRequest, "Invalid range parameter. Use: 1h, 24h, 7d, or all")
+		return
+	}
+
+	// Optional resampling, gap filling and downsampling for charts
+	query := r.URL.Query()
+	maxPoints, err := parseMaxPoints(query.Get("max_points"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	opts, err := parseStatsOptions(query.Get("resolution"), query.Get("agg"), query.Get("fill"), maxPoints)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	stats, err := s.db.GetContainerStats(containerID, hostID, hoursBack)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get container stats: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, opts.apply(stats, hoursBack, time.Now()))
+}
+
+// handleStreamContainerStats streams real-time stats for a single container as server-sent events
+// at 1-2s resolution. The stream ends when the client disconnects (e.g. the stats panel is closed).
+func (s *Server) handleStreamContainerStats(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	hostID, err := strconv.ParseInt(vars["host_id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid host ID")
+		return
+	}
+
+	containerID := vars["container_id"]
+
+	interval := time.Second
+	switch r.URL.Query().Get("interval") {
+	case "", "1":
+	case "2":
+		interval = 2 * time.Second
+	default:
+		respondError(w, http.StatusBadRequest, "Invalid interval parameter. Use: 1 or 2")
+		return
+	}
+
+	host, err := s.db.GetHost(hostID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Host not found")
+		return
+	}
+
+	// The stream outlives the server's write timeout
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	started := false
+	err = s.scanner.StreamContainerStats(r.Context(), *host, containerID, interval, func(sample models.LiveContainerStats) error {
+		if !started {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("Connection", "keep-alive")
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+
+		data, err := json.Marshal(sample)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+			return err
+		}
+		return rc.Flush()
+	})
 
-	switch rangeParam {
+	if err != nil && r.Context().Err() == nil {
+		if !started {
+			respondError(w, http.StatusBadGateway, "Failed to stream stats: "+err.Error())
+			return
+		}
+		log.Printf("Live stats stream for %s on host %d ended: %v", containerID, hostID, err)
+	}
+}
+
+// handleGetTopConsumers returns the containers using the most CPU, memory and memory growth over a window
+func (s *Server) handleGetTopConsumers(w http.ResponseWriter, r *http.Request) {
+	var windowHours int
+	switch r.URL.Query().Get("range") {
 	case "1h":
-		hoursBack = 1
-	case "24h":
-		hoursBack = 24
+		windowHours = 1
+	case "24h", "":
+		windowHours = 24
 	case "7d":
-		hoursBack = 24 * 7 // 168 hours
-	case "all", "":
-		hoursBack = 0 // 0 means all data
+		windowHours = 24 * 7
+	case "30d":
+		windowHours = 24 * 30
 	default:
-		respondError(w, http.StatusBadRequest, "Invalid range parameter. Use: 1h, 24h, 7d, or all")
+		respondError(w, http.StatusBadRequest, "Invalid range parameter. Use: 1h, 24h, 7d, or 30d")
 		return
 	}
 
-	stats, err := s.db.GetContainerStats(containerID, hostID, hoursBack)
+	limit := 5
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > 100 {
+			respondError(w, http.StatusBadRequest, "Invalid limit parameter. Use a number between 1 and 100")
+			return
+		}
+		limit = parsed
+	}
+
+	var hostFilter int64
+	if hostIDStr := r.URL.Query().Get("host_id"); hostIDStr != "" {
+		parsed, err := strconv.ParseInt(hostIDStr, 10, 64)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid host_id parameter")
+			return
+		}
+		hostFilter = parsed
+	}
+
+	report, err := s.db.GetTopConsumers(windowHours, limit, hostFilter)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get container stats: "+err.Error())
+		respondError(w, http.StatusInternalServerError, "Failed to get top consumers: "+err.Error())
 		return
 	}
 
-	respondJSON(w, http.StatusOK, stats)
+	respondJSON(w, http.StatusOK, report)
 }
 
 // handlePrometheusMetrics returns Prometheus-compatible metrics for all running containers
@@ -1547,6 +2205,59 @@ func (s *Server) handlePrometheusMetrics(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	// Network and block I/O rates
+	ioMetrics := []struct {
+		name  string
+		help  string
+		value func(models.Container) float64
+	}{
+		{"census_container_network_rx_bytes_per_second", "Container network receive rate in bytes per second", func(c models.Container) float64 { return c.NetworkRxRate }},
+		{"census_container_network_tx_bytes_per_second", "Container network transmit rate in bytes per second", func(c models.Container) float64 { return c.NetworkTxRate }},
+		{"census_container_block_read_bytes_per_second", "Container block device read rate in bytes per second", func(c models.Container) float64 { return c.BlockReadRate }},
+		{"census_container_block_write_bytes_per_second", "Container block device write rate in bytes per second", func(c models.Container) float64 { return c.BlockWriteRate }},
+	}
+
+	for _, m := range ioMetrics {
+		metrics.WriteString(fmt.Sprintf("\n# HELP %s %s\n", m.name, m.help))
+		metrics.WriteString(fmt.Sprintf("# TYPE %s gauge\n", m.name))
+
+		for _, c := range containers {
+			if c.MemoryLimit > 0 {
+				metrics.WriteString(fmt.Sprintf(
+					"%s{container_name=\"%s\",container_id=\"%s\",host_name=\"%s\",image=\"%s\"} %.2f\n",
+					m.name, c.Name, c.ID[:12], c.HostName, c.Image, m.value(c),
+				))
+			}
+		}
+	}
+
+	// Health the agents reported after their latest scan
+	if hosts, err := s.db.GetHosts(); err == nil {
+		s.attachAgentHealth(hosts)
+		metrics.WriteString("\n# HELP census_agent_docker_up Whether an agent reached its Docker daemon at its latest scan (-1 when the agent didn't answer)\n")
+		metrics.WriteString("# TYPE census_agent_docker_up gauge\n")
+		for _, h := range hosts {
+			if h.AgentHealth == nil {
+				continue
+			}
+			up := -1
+			switch h.AgentHealth.Status {
+			case models.AgentHealthHealthy:
+				up = 1
+			case models.AgentHealthDockerError:
+				up = 0
+			}
+			metrics.WriteString(fmt.Sprintf("census_agent_docker_up{host_name=\"%s\"} %d\n", h.Name, up))
+		}
+		metrics.WriteString("\n# HELP census_agent_scan_duration_seconds_avg Average container listing time an agent reported\n")
+		metrics.WriteString("# TYPE census_agent_scan_duration_seconds_avg gauge\n")
+		for _, h := range hosts {
+			if h.AgentHealth != nil && h.AgentHealth.Status != models.AgentHealthAgentError {
+				metrics.WriteString(fmt.Sprintf("census_agent_scan_duration_seconds_avg{host_name=\"%s\"} %.3f\n", h.Name, h.AgentHealth.AvgScanSeconds))
+			}
+		}
+	}
+
 	// Write response with Prometheus content type
 	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
 	w.WriteHeader(http.StatusOK)
@@ -1599,8 +2310,11 @@ func (s *Server) handleGetChangesReport(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
-	// Generate report
-	report, err := s.db.GetChangesReport(start, end, hostFilter)
+	// Generate report, optionally for one compose project (?stack=)
+	report, err := s.db.GetFilteredChangesReport(start, end, models.ChangesReportFilter{
+		HostID:         hostFilter,
+		ComposeProject: r.URL.Query().Get("stack"),
+	})
 	if err != nil {
 		log.Printf("Error generating changes report: %v", err)
 		respondError(w, http.StatusInternalServerError, "Failed to generate report: "+err.Error())
@@ -1610,9 +2324,138 @@ func (s *Server) handleGetChangesReport(w http.ResponseWriter, r *http.Request)
 	respondJSON(w, http.StatusOK, report)
 }
 
-// handleGetPreferences returns all user preferences
+// handleGetEnvironmentSnapshots lists the persisted daily environment snapshots
+func (s *Server) handleGetEnvironmentSnapshots(w http.ResponseWriter, r *http.Request) {
+	snapshots, err := s.db.GetEnvironmentSnapshots()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get snapshots: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, snapshots)
+}
+
+// handleCreateEnvironmentSnapshot takes (or replaces) today's environment snapshot
+func (s *Server) handleCreateEnvironmentSnapshot(w http.ResponseWriter, r *http.Request) {
+	snapshot, err := s.db.CreateEnvironmentSnapshot(time.Now())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create snapshot: "+err.Error())
+		return
+	}
+
+	// Don't send the full inventory back
+	snapshot.Containers = nil
+	respondJSON(w, http.StatusCreated, snapshot)
+}
+
+// handleDiffEnvironmentSnapshots compares the snapshots in effect on two dates
+func (s *Server) handleDiffEnvironmentSnapshots(w http.ResponseWriter, r *http.Request) {
+	from, err := parseSnapshotDate(r.URL.Query().Get("from"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid from date (use YYYY-MM-DD or RFC3339): "+err.Error())
+		return
+	}
+	to, err := parseSnapshotDate(r.URL.Query().Get("to"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid to date (use YYYY-MM-DD or RFC3339): "+err.Error())
+		return
+	}
+	if to.Before(from) {
+		respondError(w, http.StatusBadRequest, "To date must be after from date")
+		return
+	}
+
+	var hostFilter int64
+	if hostFilterStr := r.URL.Query().Get("host_id"); hostFilterStr != "" {
+		hostFilter, err = strconv.ParseInt(hostFilterStr, 10, 64)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid host_id parameter: "+err.Error())
+			return
+		}
+	}
+
+	report, err := s.db.DiffEnvironmentSnapshots(from, to, hostFilter)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to diff snapshots: "+err.Error())
+		return
+	}
+	if report == nil {
+		respondError(w, http.StatusNotFound, "No snapshot exists on or before the requested dates")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, report)
+}
+
+// handleGetIdleContainers returns running containers that look unused over a window
+func (s *Server) handleGetIdleContainers(w http.ResponseWriter, r *http.Request) {
+	criteria := storage.DefaultIdleContainerCriteria()
+	query := r.URL.Query()
+
+	if v := query.Get("window_hours"); v != "" {
+		hours, err := strconv.Atoi(v)
+		if err != nil || hours < 1 {
+			respondError(w, http.StatusBadRequest, "Invalid window_hours parameter")
+			return
+		}
+		criteria.WindowHours = hours
+	}
+	if v := query.Get("cpu_threshold"); v != "" {
+		threshold, err := strconv.ParseFloat(v, 64)
+		if err != nil || threshold < 0 {
+			respondError(w, http.StatusBadRequest, "Invalid cpu_threshold parameter")
+			return
+		}
+		criteria.CPUThreshold = threshold
+	}
+	if v := query.Get("peak_cpu_threshold"); v != "" {
+		threshold, err := strconv.ParseFloat(v, 64)
+		if err != nil || threshold < 0 {
+			respondError(w, http.StatusBadRequest, "Invalid peak_cpu_threshold parameter")
+			return
+		}
+		criteria.PeakCPUThreshold = threshold
+	}
+	if v := query.Get("include_published"); v != "" {
+		include, err := strconv.ParseBool(v)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid include_published parameter")
+			return
+		}
+		criteria.IncludePublished = include
+	}
+	if v := query.Get("host_id"); v != "" {
+		hostID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid host_id parameter")
+			return
+		}
+		criteria.HostID = hostID
+	}
+
+	report, err := s.db.GetIdleContainers(criteria)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get idle containers: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, report)
+}
+
+// parseSnapshotDate parses a YYYY-MM-DD or RFC3339 date
+func parseSnapshotDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("date is required")
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// handleGetPreferences returns the preferences of the logged-in user
 func (s *Server) handleGetPreferences(w http.ResponseWriter, r *http.Request) {
-	prefs, err := s.db.GetAllPreferences()
+	prefs, err := s.db.GetUserPreferences(identity(r).Username)
 	if err != nil {
 		log.Printf("Error getting preferences: %v", err)
 		respondError(w, http.StatusInternalServerError, "Failed to get preferences: "+err.Error())
@@ -1622,17 +2465,22 @@ func (s *Server) handleGetPreferences(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, prefs)
 }
 
-// handleUpdatePreferences updates user preferences
+// handleUpdatePreferences updates preferences of the logged-in user
 func (s *Server) handleUpdatePreferences(w http.ResponseWriter, r *http.Request) {
 	var prefs map[string]string
 	if err := json.NewDecoder(r.Body).Decode(&prefs); err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
 		return
 	}
+	if err := validatePreferences(prefs); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	// Update each preference
+	username := identity(r).Username
 	for key, value := range prefs {
-		if err := s.db.SetPreference(key, value); err != nil {
+		if err := s.db.SetUserPreference(username, key, value); err != nil {
 			log.Printf("Error setting preference %s: %v", key, err)
 			respondError(w, http.StatusInternalServerError, "Failed to set preference: "+err.Error())
 			return
@@ -1715,11 +2563,15 @@ func (s *Server) handleCheckContainerUpdate(w http.ResponseWriter, r *http.Reque
 	}
 
 	// Get host
-	_, err = s.db.GetHost(hostID)
+	host, err := s.db.GetHost(hostID)
 	if err != nil {
 		respondError(w, http.StatusNotFound, "Host not found")
 		return
 	}
+	if !host.IsDocker() {
+		respondError(w, http.StatusBadRequest, "Incus/LXD containers have no registry image to check for updates")
+		return
+	}
 
 	// Get latest containers for this host
 	containers, err := s.db.GetLatestContainers()
@@ -1742,6 +2594,16 @@ func (s *Server) handleCheckContainerUpdate(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if pin := s.containerPin(*container); pin != nil {
+		respondJSON(w, http.StatusOK, map[string]interface{}{
+			"available": false,
+			"pinned":    true,
+			"message":   pinnedMessage(pin) + "; update checks are skipped",
+			"image":     container.Image,
+		})
+		return
+	}
+
 	// Check if image uses :latest tag
 	imageName := container.Image
 	if !strings.HasSuffix(imageName, ":latest") && !strings.Contains(imageName, ":") {
@@ -1769,16 +2631,24 @@ func (s *Server) handleCheckContainerUpdate(w http.ResponseWriter, r *http.Reque
 	if err := s.db.SaveContainerUpdateStatus(container.ID, hostID, updateInfo.Available); err != nil {
 		log.Printf("Failed to save update status: %v", err)
 	}
+	if err := s.db.SaveUpdateLag(hostID, container.Name, container.ImageID, updateInfo.Available, updateInfo.RemoteCreated, time.Now()); err != nil {
+		log.Printf("Failed to save update lag: %v", err)
+	}
 
 	// Trigger notification detection by processing events for this host
 	// The notification service will detect the UpdateAvailable flag in the next scan
-	if updateInfo.Available && s.notificationService != nil {
-		go func() {
+	if updateInfo.Available {
+		go func(c models.Container) {
 			ctx := context.Background()
+			// Fetch release notes first so the notification can include them
+			s.resolveChangelog(ctx, c)
+			if s.notificationService == nil {
+				return
+			}
 			if err := s.notificationService.ProcessEvents(ctx, hostID); err != nil {
 				log.Printf("Failed to process notifications for update event: %v", err)
 			}
-		}()
+		}(*container)
 	}
 
 	respondJSON(w, http.StatusOK, updateInfo)
@@ -1799,6 +2669,17 @@ func (s *Server) handleUpdateContainer(w http.ResponseWriter, r *http.Request) {
 	// Check for dry_run parameter
 	dryRun := r.URL.Query().Get("dry_run") == "true"
 
+	// Optional pre/post update hooks
+	var hooks *models.UpdateHooks
+	if r.ContentLength > 0 {
+		hooks = &models.UpdateHooks{}
+		if err := json.NewDecoder(r.Body).Decode(hooks); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid update hooks: "+err.Error())
+			return
+		}
+		extendWriteDeadlineForHooks(w, hooks)
+	}
+
 	// Get host
 	host, err := s.db.GetHost(hostID)
 	if err != nil {
@@ -1827,22 +2708,55 @@ func (s *Server) handleUpdateContainer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if pin := s.containerPin(*container); pin != nil {
+		respondError(w, http.StatusConflict, pinnedMessage(pin)+"; unpin it to update")
+		return
+	}
+
+	// A dry run changes nothing, so it doesn't need to wait for other actions
 	if !dryRun {
-		// Pull the new image first
-		// Use the first image tag if available (container.Image might be a digest like sha256:...)
-		imageToPull := container.Image
-		if len(container.ImageTags) > 0 {
-			imageToPull = container.ImageTags[0]
+		done, ok := s.beginOperation(w, hostID, container.Name, containerops.ActionUpdate)
+		if !ok {
+			return
 		}
+		defer done()
+	}
+
+	// Use the first image tag if available (container.Image might be a digest like sha256:...)
+	imageToPull := container.Image
+	if len(container.ImageTags) > 0 {
+		imageToPull = container.ImageTags[0]
+	}
+	started := time.Now()
+
+	if !dryRun {
+		// Pull the new image first
 		log.Printf("Pulling image %s on host %s", imageToPull, host.Name)
-		if err := s.scanner.PullImage(r.Context(), *host, imageToPull); err != nil {
+		if err := s.pullImage(r.Context(), *host, imageToPull); err != nil {
+			s.recordContainerUpdate(identity(r).Username, models.UpdateTriggerManual, *host, *container, imageToPull, started, nil, fmt.Errorf("failed to pull image: %w", err))
 			respondError(w, http.StatusInternalServerError, "Failed to pull image: "+err.Error())
 			return
 		}
+
+		// Don't move to an image with more critical vulnerabilities unless the policy allows it
+		gate := s.checkVulnerabilityGate(r.Context(), *host, *container, imageToPull)
+		if gate.Refuses(r.URL.Query().Get("accept_vulnerabilities") == "true") {
+			if gate.Mode == models.VulnerabilityGateBlock {
+				s.recordContainerUpdate(identity(r).Username, models.UpdateTriggerManual, *host, *container, imageToPull, started, nil, errors.New(gate.Message()))
+			}
+			respondJSON(w, http.StatusConflict, map[string]interface{}{
+				"error":              gate.Message(),
+				"vulnerability_gate": gate,
+			})
+			return
+		}
 	}
 
 	// Recreate the container using the container name (more reliable than short ID)
-	result, err := s.scanner.RecreateContainer(r.Context(), *host, container.Name, dryRun)
+	result, err := s.scanner.RecreateContainer(r.Context(), *host, container.Name, dryRun, hooks)
+	if !dryRun {
+		s.recordContainerUpdate(identity(r).Username, models.UpdateTriggerManual, *host, *container, imageToPull, started, result, err)
+	}
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to recreate container: "+err.Error())
 		return
@@ -1851,10 +2765,10 @@ func (s *Server) handleUpdateContainer(w http.ResponseWriter, r *http.Request) {
 	// If not a dry run, trigger a scan to update the container state with the new image ID
 	if !dryRun {
 		go func() {
-			ctx := context.Background()
-			log.Printf("Triggering scan for host %s after container update", host.Name)
+			ctx := scanner.WithScanID(context.Background(), scanner.NewScanID())
+			scanner.Logf(ctx, "Triggering scan for host %s after container update", host.Name)
 			if _, err := s.scanner.ScanHost(ctx, *host); err != nil {
-				log.Printf("Failed to scan host after update: %v", err)
+				scanner.Logf(ctx, "Failed to scan host after update: %v", err)
 			}
 		}()
 	}
@@ -1862,6 +2776,100 @@ func (s *Server) handleUpdateContainer(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, result)
 }
 
+// pullImage pulls an image on a host, going through the host's registry mirror when one is
+// configured and it serves the image. The mirrored image is tagged with the original name so
+// recreated containers pick it up; any mirror failure falls back to pulling directly.
+func (s *Server) pullImage(ctx context.Context, host models.Host, imageName string) error {
+	if mirrorRef, ok := registry.MirrorReference(host.RegistryMirror, imageName); ok {
+		if err := s.pullThroughMirror(ctx, host, mirrorRef, imageName); err != nil {
+			log.Printf("Registry mirror pull of %s on host %s failed, pulling directly: %v", imageName, host.Name, err)
+		} else {
+			log.Printf("Pulled %s on host %s through mirror as %s", imageName, host.Name, mirrorRef)
+			return nil
+		}
+	}
+
+	return s.scanner.PullImage(ctx, host, imageName)
+}
+
+func (s *Server) pullThroughMirror(ctx context.Context, host models.Host, mirrorRef, imageName string) error {
+	if _, err := s.registryClient.CheckImageAvailable(ctx, mirrorRef); err != nil {
+		return fmt.Errorf("mirror does not serve %s: %w", mirrorRef, err)
+	}
+	if err := s.scanner.PullImage(ctx, host, mirrorRef); err != nil {
+		return err
+	}
+	return s.scanner.TagImage(ctx, host, mirrorRef, imageName)
+}
+
+// handleTestRegistryMirror checks that a host's registry mirror (or the mirror in the request,
+// for validating before saving) serves a given image
+func (s *Server) handleTestRegistryMirror(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	hostID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid host ID")
+		return
+	}
+
+	var req struct {
+		Mirror string `json:"mirror"`
+		Image  string `json:"image"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Image == "" {
+		req.Image = "library/alpine:latest"
+	}
+
+	host, err := s.db.GetHost(hostID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Host not found")
+		return
+	}
+
+	mirror := req.Mirror
+	if mirror == "" {
+		mirror = host.RegistryMirror
+	}
+	if mirror == "" {
+		respondError(w, http.StatusBadRequest, "No registry mirror configured for this host")
+		return
+	}
+
+	mirrorRef, ok := registry.MirrorReference(mirror, req.Image)
+	if !ok {
+		respondError(w, http.StatusBadRequest, "Only Docker Hub images are pulled through a registry mirror")
+		return
+	}
+
+	result := map[string]interface{}{
+		"mirror":    registry.NormalizeMirror(mirror),
+		"image":     req.Image,
+		"reference": mirrorRef,
+		"available": false,
+	}
+	digest, err := s.registryClient.CheckImageAvailable(r.Context(), mirrorRef)
+	if err != nil {
+		result["error"] = err.Error()
+	} else {
+		result["available"] = true
+		result["digest"] = digest
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+// extendWriteDeadlineForHooks gives the response time to cover a post-update health wait
+func extendWriteDeadlineForHooks(w http.ResponseWriter, hooks *models.UpdateHooks) {
+	if hooks == nil || !hooks.WaitForHealthy {
+		return
+	}
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(updatehooks.HealthTimeout(hooks) + time.Minute))
+}
+
 // handleBulkCheckUpdates checks multiple containers for updates
 func (s *Server) handleBulkCheckUpdates(w http.ResponseWriter, r *http.Request) {
 	var req struct {
@@ -1912,6 +2920,15 @@ func (s *Server) handleBulkCheckUpdates(w http.ResponseWriter, r *http.Request)
 			continue
 		}
 
+		if pin := s.containerPin(*container); pin != nil {
+			results[fmt.Sprintf("%d-%s", c.HostID, c.ContainerID)] = map[string]interface{}{
+				"available": false,
+				"pinned":    true,
+				"message":   pinnedMessage(pin),
+			}
+			continue
+		}
+
 		// Check if image uses :latest tag
 		imageName := container.Image
 		if !strings.HasSuffix(imageName, ":latest") && !strings.Contains(imageName, ":") {
@@ -1939,15 +2956,23 @@ func (s *Server) handleBulkCheckUpdates(w http.ResponseWriter, r *http.Request)
 		if err := s.db.SaveContainerUpdateStatus(c.ContainerID, c.HostID, updateInfo.Available); err != nil {
 			log.Printf("Failed to save update status: %v", err)
 		}
+		if err := s.db.SaveUpdateLag(c.HostID, container.Name, container.ImageID, updateInfo.Available, updateInfo.RemoteCreated, time.Now()); err != nil {
+			log.Printf("Failed to save update lag: %v", err)
+		}
 
 		// Trigger notification detection by processing events for this host (async)
-		if updateInfo.Available && s.notificationService != nil {
-			go func(hostID int64) {
+		if updateInfo.Available {
+			go func(container models.Container) {
 				ctx := context.Background()
-				if err := s.notificationService.ProcessEvents(ctx, hostID); err != nil {
+				// Fetch release notes first so the notification can include them
+				s.resolveChangelog(ctx, container)
+				if s.notificationService == nil {
+					return
+				}
+				if err := s.notificationService.ProcessEvents(ctx, container.HostID); err != nil {
 					log.Printf("Failed to process notifications for update event: %v", err)
 				}
-			}(c.HostID)
+			}(*container)
 		}
 
 		results[fmt.Sprintf("%d-%s", c.HostID, c.ContainerID)] = updateInfo
@@ -1956,13 +2981,17 @@ func (s *Server) handleBulkCheckUpdates(w http.ResponseWriter, r *http.Request)
 	respondJSON(w, http.StatusOK, results)
 }
 
-// handleBulkUpdate updates multiple containers
+// handleBulkUpdate updates multiple containers. Containers of a compose project are updated
+// after the services they depend on (depends_on); those another container of the batch depends
+// on are waited for until healthy, and when one fails its dependents are skipped.
 func (s *Server) handleBulkUpdate(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		Containers []struct {
 			HostID      int64  `json:"host_id"`
 			ContainerID string `json:"container_id"`
 		} `json:"containers"`
+		Hooks                 *models.UpdateHooks `json:"hooks,omitempty"`                  // Applied to every container
+		AcceptVulnerabilities bool                `json:"accept_vulnerabilities,omitempty"` // Go ahead despite vulnerability gate warnings
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -1972,27 +3001,27 @@ func (s *Server) handleBulkUpdate(w http.ResponseWriter, r *http.Request) {
 
 	results := make(map[string]interface{})
 
+	// Get container info
+	containers, err := s.db.GetLatestContainers()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get containers")
+		return
+	}
+
+	var targets []bulkUpdateTarget
 	for _, c := range req.Containers {
+		key := fmt.Sprintf("%d-%s", c.HostID, c.ContainerID)
+
 		// Get host
 		host, err := s.db.GetHost(c.HostID)
 		if err != nil {
-			results[fmt.Sprintf("%d-%s", c.HostID, c.ContainerID)] = map[string]interface{}{
+			results[key] = map[string]interface{}{
 				"success": false,
 				"error":   "Host not found",
 			}
 			continue
 		}
 
-		// Get container info
-		containers, err := s.db.GetLatestContainers()
-		if err != nil {
-			results[fmt.Sprintf("%d-%s", c.HostID, c.ContainerID)] = map[string]interface{}{
-				"success": false,
-				"error":   "Failed to get containers",
-			}
-			continue
-		}
-
 		var container *models.Container
 		for i := range containers {
 			if containers[i].ID == c.ContainerID && containers[i].HostID == c.HostID {
@@ -2002,39 +3031,131 @@ func (s *Server) handleBulkUpdate(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if container == nil {
-			results[fmt.Sprintf("%d-%s", c.HostID, c.ContainerID)] = map[string]interface{}{
+			results[key] = map[string]interface{}{
 				"success": false,
 				"error":   "Container not found",
 			}
 			continue
 		}
 
+		targets = append(targets, bulkUpdateTarget{key: key, host: *host, container: *container})
+	}
+	targets = orderBulkUpdates(targets)
+
+	// Containers with dependents in the batch wait for their health check even without hooks
+	dependencyHooks := &models.UpdateHooks{WaitForHealthy: true}
+	if req.Hooks != nil {
+		hooks := *req.Hooks
+		hooks.WaitForHealthy = true
+		dependencyHooks = &hooks
+	}
+	waiting := 0
+	for _, t := range targets {
+		if t.dependent || (req.Hooks != nil && req.Hooks.WaitForHealthy) {
+			waiting++
+		}
+	}
+	if waiting > 0 {
+		// Each container may wait for its health check in turn
+		timeout := updatehooks.HealthTimeout(dependencyHooks)
+		http.NewResponseController(w).SetWriteDeadline(time.Now().Add(time.Duration(waiting) * (timeout + time.Minute)))
+	}
+
+	failed := make([]bool, len(targets))
+	for i, t := range targets {
+		host, container := t.host, t.container
+
+		skipped := ""
+		for _, dep := range t.dependsOn {
+			if failed[dep] {
+				skipped = targets[dep].container.Name
+				break
+			}
+		}
+		if skipped != "" {
+			failed[i] = true
+			results[t.key] = map[string]interface{}{
+				"success": false,
+				"skipped": true,
+				"error":   "Skipped: dependency " + skipped + " failed to update",
+			}
+			continue
+		}
+
+		if pin := s.containerPin(container); pin != nil {
+			results[t.key] = map[string]interface{}{
+				"success": false,
+				"pinned":  true,
+				"error":   pinnedMessage(pin),
+			}
+			continue
+		}
+
+		done, running, ok := s.operations.Begin(host.ID, container.Name, containerops.ActionUpdate)
+		if !ok {
+			results[t.key] = map[string]interface{}{
+				"success":   false,
+				"error":     busyMessage(running),
+				"operation": running,
+			}
+			continue
+		}
+
 		// Pull the new image first
 		// Use the first image tag if available (container.Image might be a digest like sha256:...)
 		imageToPull := container.Image
 		if len(container.ImageTags) > 0 {
 			imageToPull = container.ImageTags[0]
 		}
+		started := time.Now()
 		log.Printf("Pulling image %s on host %s", imageToPull, host.Name)
-		if err := s.scanner.PullImage(r.Context(), *host, imageToPull); err != nil {
-			results[fmt.Sprintf("%d-%s", c.HostID, c.ContainerID)] = map[string]interface{}{
+		if err := s.pullImage(r.Context(), host, imageToPull); err != nil {
+			done()
+			failed[i] = true
+			s.recordContainerUpdate(identity(r).Username, models.UpdateTriggerBulk, host, container, imageToPull, started, nil, fmt.Errorf("failed to pull image: %w", err))
+			results[t.key] = map[string]interface{}{
 				"success": false,
 				"error":   "Failed to pull image: " + err.Error(),
 			}
 			continue
 		}
 
+		if gate := s.checkVulnerabilityGate(r.Context(), host, container, imageToPull); gate.Refuses(req.AcceptVulnerabilities) {
+			done()
+			failed[i] = true
+			if gate.Mode == models.VulnerabilityGateBlock {
+				s.recordContainerUpdate(identity(r).Username, models.UpdateTriggerBulk, host, container, imageToPull, started, nil, errors.New(gate.Message()))
+			}
+			results[t.key] = map[string]interface{}{
+				"success":            false,
+				"error":              gate.Message(),
+				"vulnerability_gate": gate,
+			}
+			continue
+		}
+
+		hooks := req.Hooks
+		if t.dependent {
+			hooks = dependencyHooks
+		}
+
 		// Recreate the container using the container name (more reliable than short ID)
-		result, err := s.scanner.RecreateContainer(r.Context(), *host, container.Name, false)
+		result, err := s.scanner.RecreateContainer(r.Context(), host, container.Name, false, hooks)
+		done()
+		s.recordContainerUpdate(identity(r).Username, models.UpdateTriggerBulk, host, container, imageToPull, started, result, err)
 		if err != nil {
-			results[fmt.Sprintf("%d-%s", c.HostID, c.ContainerID)] = map[string]interface{}{
+			failed[i] = true
+			results[t.key] = map[string]interface{}{
 				"success": false,
 				"error":   "Failed to recreate container: " + err.Error(),
 			}
 			continue
 		}
+		if !result.Success {
+			failed[i] = true
+		}
 
-		results[fmt.Sprintf("%d-%s", c.HostID, c.ContainerID)] = result
+		results[t.key] = result
 	}
 
 	respondJSON(w, http.StatusOK, results)
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/container-census/container-census/internal/migration"
+	"github.com/container-census/container-census/internal/models"
+)
+
+// maxHostImportSize limits the size of an uploaded host import document
+const maxHostImportSize = 1 << 20
+
+// hostImportConcurrency is how many connection tests of an import run at the same time
+const hostImportConcurrency = 8
+
+// Statuses of a row in a host import report
+const (
+	hostImportAdded       = "added"
+	hostImportReady       = "ready" // would be added (dry run)
+	hostImportInvalid     = "invalid"
+	hostImportUnreachable = "unreachable"
+	hostImportFailed      = "failed"
+)
+
+// hostImportResult is what happened to one row of a host import
+type hostImportResult struct {
+	Line    int    `json:"line"`
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+	HostID  int64  `json:"host_id,omitempty"`
+}
+
+// handleImportHosts adds many hosts at once from a CSV or YAML document in the request body
+// (see migration.ParseHostImport). Every valid row's connection is tested first (agents also
+// their token) unless ?test=false; hosts that fail it aren't added. ?dry_run=true only reports.
+// ?format=csv|yaml overrides the Content-Type and detection. Rows naming a host template start
+// from it; their own values take precedence.
+func (s *Server) handleImportHosts(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHostImportSize))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Failed to read the document: "+err.Error())
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = hostImportFormat(r.Header.Get("Content-Type"))
+	}
+	rows, err := migration.ParseHostImport(data, format)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid host list: "+err.Error())
+		return
+	}
+
+	existing, err := s.db.GetHosts()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get hosts: "+err.Error())
+		return
+	}
+	migration.ValidateHostImport(rows, existing)
+
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+	testConnections := r.URL.Query().Get("test") != "false"
+
+	templates, err := s.db.GetHostTemplates()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get host templates: "+err.Error())
+		return
+	}
+	templatesByName := make(map[string]*models.HostTemplate, len(templates))
+	for i := range templates {
+		templatesByName[templates[i].Name] = &templates[i]
+	}
+
+	// Tenant users can only add hosts through agents and Incus, as with the single-host dialogs
+	tenantID := identity(r).TenantID
+	results := make([]hostImportResult, len(rows))
+	for i, row := range rows {
+		results[i] = hostImportResult{Line: row.Line, Name: row.Name, Address: row.Address, Type: row.Type, Status: hostImportReady}
+		if row.Error == "" && tenantID != 0 && row.Type != models.HostTypeAgent && row.Type != models.HostTypeIncus {
+			row.Error = "only agent and Incus hosts can be added"
+		}
+		if row.Error == "" && row.Template != "" {
+			if tenantID != 0 {
+				row.Error = "host templates can only be used by administrators"
+			} else if templatesByName[row.Template] == nil {
+				row.Error = "unknown host template " + row.Template
+			}
+		}
+		if row.Error != "" {
+			results[i].Status = hostImportInvalid
+			results[i].Error = row.Error
+		}
+	}
+
+	if testConnections {
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, hostImportConcurrency)
+		for i := range rows {
+			if results[i].Status != hostImportReady {
+				continue
+			}
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				if err := s.testHostConnection(r.Context(), rows[i].Host()); err != nil {
+					results[i].Status = hostImportUnreachable
+					results[i].Error = err.Error()
+				}
+			}(i)
+		}
+		wg.Wait()
+	}
+
+	added, failed := 0, 0
+	for i := range rows {
+		if results[i].Status != hostImportReady {
+			failed++
+			continue
+		}
+		if dryRun {
+			continue
+		}
+
+		host := rows[i].Host()
+		template := templatesByName[rows[i].Template]
+		if template != nil {
+			template.ApplyTo(&host)
+			if rows[i].CollectStats != nil {
+				host.CollectStats = *rows[i].CollectStats
+			}
+		}
+		host.TenantID = tenantID
+		if testConnections {
+			host.AgentStatus = "online"
+			host.LastSeen = time.Now()
+		}
+		id, err := s.db.AddHost(host)
+		if err != nil {
+			results[i].Status = hostImportFailed
+			results[i].Error = err.Error()
+			if strings.Contains(err.Error(), "UNIQUE") {
+				results[i].Error = "a host with this name already exists"
+			}
+			failed++
+			continue
+		}
+		host.ID = id
+		s.createHostTemplateRules(template, host)
+		results[i].Status = hostImportAdded
+		results[i].HostID = id
+		added++
+	}
+
+	if added > 0 {
+		s.cache.Invalidate()
+		log.Printf("%d hosts imported by %s", added, identity(r).Username)
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"dry_run": dryRun,
+		"total":   len(rows),
+		"added":   added,
+		"failed":  failed,
+		"rows":    results,
+	})
+}
+
+// testHostConnection checks that a host to be added answers, and for agents that its token works
+func (s *Server) testHostConnection(ctx context.Context, host models.Host) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if err := s.scanner.VerifyConnection(ctx, host.Address); err != nil {
+		return errors.New("connection failed: " + err.Error())
+	}
+	if host.HostType == models.HostTypeAgent {
+		if err := s.scanner.VerifyAgentAuth(ctx, host); err != nil {
+			if strings.Contains(err.Error(), "401") {
+				return errors.New("authentication failed - invalid API token")
+			}
+			return errors.New("authentication check failed: " + err.Error())
+		}
+	}
+	return nil
+}
+
+// hostImportFormat returns the import format of a Content-Type, or "" to detect it
+func hostImportFormat(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv":
+		return migration.HostImportCSV
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return migration.HostImportYAML
+	}
+	return ""
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestImportHosts(t *testing.T) {
+	server, db := setupTestServer(t)
+	if _, err := db.AddHost(models.Host{Name: "local", Address: "unix:///var/run/docker.sock", Enabled: true}); err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	doc := `name,address,type,token
+vps1,vps1.example.com:9876,agent,secret1
+vps2,vps2.example.com:9876,agent,
+local,tcp://local.lan:2376,,
+`
+	type report struct {
+		DryRun bool               `json:"dry_run"`
+		Total  int                `json:"total"`
+		Added  int                `json:"added"`
+		Failed int                `json:"failed"`
+		Rows   []hostImportResult `json:"rows"`
+	}
+	do := func(query string) report {
+		req := httptest.NewRequest("POST", "/api/hosts/import?test=false"+query, strings.NewReader(doc))
+		req.Header.Set("Content-Type", "text/csv")
+		rec := httptest.NewRecorder()
+		server.handleImportHosts(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var r report
+		if err := json.Unmarshal(rec.Body.Bytes(), &r); err != nil {
+			t.Fatalf("Failed to decode report: %v", err)
+		}
+		return r
+	}
+
+	dry := do("&dry_run=true")
+	if !dry.DryRun || dry.Total != 3 || dry.Added != 0 || dry.Failed != 2 || dry.Rows[0].Status != hostImportReady {
+		t.Errorf("Unexpected dry run report %+v", dry)
+	}
+	if dry.Rows[1].Status != hostImportInvalid || dry.Rows[2].Error != "a host with this name already exists" {
+		t.Errorf("Expected the missing token and the existing name to be reported, got %+v", dry.Rows)
+	}
+	if hosts, _ := db.GetHosts(); len(hosts) != 1 {
+		t.Errorf("Expected the dry run not to add hosts, got %d", len(hosts))
+	}
+
+	result := do("")
+	if result.Added != 1 || result.Rows[0].Status != hostImportAdded || result.Rows[0].HostID == 0 {
+		t.Fatalf("Expected vps1 to be added, got %+v", result)
+	}
+	host, err := db.GetHost(result.Rows[0].HostID)
+	if err != nil || host.Address != "agent://vps1.example.com:9876" || host.HostType != models.HostTypeAgent || host.AgentToken != "secret1" {
+		t.Errorf("Expected the imported agent host, got %+v (%v)", host, err)
+	}
+
+	// Importing again finds it
+	if again := do(""); again.Added != 0 || again.Rows[0].Error != "a host with this name already exists" {
+		t.Errorf("Expected vps1 to exist on a second import, got %+v", again.Rows[0])
+	}
+
+	req := httptest.NewRequest("POST", "/api/hosts/import", strings.NewReader("name,port\nvps1,9876"))
+	rec := httptest.NewRecorder()
+	server.handleImportHosts(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unusable document, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,464 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/container-census/container-census/internal/inspect"
+	"github.com/container-census/container-census/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// migrationCopyImage is the image running tar to copy a named volume between hosts
+const migrationCopyImage = "alpine"
+
+// hostMigrationRequest selects what to move: the containers by name, all of the source host's
+// when none are given
+type hostMigrationRequest struct {
+	SourceHostID int64    `json:"source_host_id"`
+	DestHostID   int64    `json:"dest_host_id"`
+	Containers   []string `json:"containers"`
+}
+
+// handlePlanHostMigration returns the plan of a host migration without saving it.
+// JSON: {"source_host_id": 1, "dest_host_id": 2, "containers": ["web", "db"]}.
+func (s *Server) handlePlanHostMigration(w http.ResponseWriter, r *http.Request) {
+	migration, ok := s.planHostMigration(w, r)
+	if !ok {
+		return
+	}
+	respondJSON(w, http.StatusOK, migration)
+}
+
+// handleCreateHostMigration plans a host migration like handlePlanHostMigration and saves it to
+// track its progress
+func (s *Server) handleCreateHostMigration(w http.ResponseWriter, r *http.Request) {
+	migration, ok := s.planHostMigration(w, r)
+	if !ok {
+		return
+	}
+	migration.CreatedBy = identity(r).Username
+	if err := s.db.SaveHostMigration(migration); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save host migration: "+err.Error())
+		return
+	}
+	log.Printf("Host migration %d of %d containers from %s to %s created by %s", migration.ID, len(migration.Items), migration.SourceHostName, migration.DestHostName, migration.CreatedBy)
+	respondJSON(w, http.StatusCreated, migration)
+}
+
+// handleGetHostMigrations lists the latest host migrations, newest first (?limit=, default 50)
+func (s *Server) handleGetHostMigrations(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l <= 0 {
+			respondError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = l
+	}
+
+	migrations, err := s.db.GetHostMigrations(limit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get host migrations: "+err.Error())
+		return
+	}
+	if migrations == nil {
+		migrations = []models.HostMigration{}
+	}
+	respondJSON(w, http.StatusOK, migrations)
+}
+
+// handleGetHostMigration returns one host migration, with the state each container has on
+// both hosts as of their latest scans
+func (s *Server) handleGetHostMigration(w http.ResponseWriter, r *http.Request) {
+	migration, ok := s.hostMigration(w, r)
+	if !ok {
+		return
+	}
+
+	source, err := s.db.GetContainersByHost(migration.SourceHostID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get containers: "+err.Error())
+		return
+	}
+	dest, err := s.db.GetContainersByHost(migration.DestHostID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get containers: "+err.Error())
+		return
+	}
+	observeHostMigration(migration, source, dest)
+	respondJSON(w, http.StatusOK, migration)
+}
+
+// handleUpdateHostMigrationItem records the progress of one container of a migration.
+// JSON: {"status": "migrated", "notes": "moved the database by hand"}; both are optional.
+func (s *Server) handleUpdateHostMigrationItem(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Status *string `json:"status"`
+		Notes  *string `json:"notes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	if req.Status != nil {
+		switch *req.Status {
+		case models.MigrationItemPending, models.MigrationItemInProgress, models.MigrationItemMigrated,
+			models.MigrationItemSkipped, models.MigrationItemFailed:
+		default:
+			respondError(w, http.StatusBadRequest, "Invalid status: "+*req.Status)
+			return
+		}
+	}
+
+	migration, ok := s.hostMigration(w, r)
+	if !ok {
+		return
+	}
+	name := mux.Vars(r)["name"]
+	var item *models.MigrationItem
+	for i := range migration.Items {
+		if migration.Items[i].ContainerName == name {
+			item = &migration.Items[i]
+		}
+	}
+	if item == nil {
+		respondError(w, http.StatusNotFound, "Container not part of the migration: "+name)
+		return
+	}
+
+	now := time.Now()
+	if req.Status != nil {
+		item.Status = *req.Status
+	}
+	if req.Notes != nil {
+		item.Notes = strings.TrimSpace(*req.Notes)
+	}
+	item.UpdatedAt = &now
+	migration.UpdateStatus()
+	if err := s.db.SaveHostMigration(migration); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save host migration: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, migration)
+}
+
+// handleDeleteHostMigration removes a host migration
+func (s *Server) handleDeleteHostMigration(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid migration ID")
+		return
+	}
+	if err := s.db.DeleteHostMigration(id); errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusNotFound, "Host migration not found")
+		return
+	} else if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete host migration: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Host migration deleted"})
+}
+
+// hostMigration loads the migration named by the {id} route variable, answering the request
+// when it can't
+func (s *Server) hostMigration(w http.ResponseWriter, r *http.Request) (*models.HostMigration, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid migration ID")
+		return nil, false
+	}
+	migration, err := s.db.GetHostMigration(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusNotFound, "Host migration not found")
+		return nil, false
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get host migration: "+err.Error())
+		return nil, false
+	}
+	return migration, true
+}
+
+// planHostMigration reads a hostMigrationRequest and builds its plan from the latest scans of
+// both hosts, answering the request when it can't
+func (s *Server) planHostMigration(w http.ResponseWriter, r *http.Request) (*models.HostMigration, bool) {
+	var req hostMigrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return nil, false
+	}
+	if req.SourceHostID == req.DestHostID {
+		respondError(w, http.StatusBadRequest, "The source and destination hosts must differ")
+		return nil, false
+	}
+
+	source, err := s.db.GetHost(req.SourceHostID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Source host not found")
+		return nil, false
+	}
+	dest, err := s.db.GetHost(req.DestHostID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Destination host not found")
+		return nil, false
+	}
+
+	sourceContainers, err := s.db.GetContainersByHost(source.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get containers: "+err.Error())
+		return nil, false
+	}
+	destContainers, err := s.db.GetContainersByHost(dest.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get containers: "+err.Error())
+		return nil, false
+	}
+
+	selected := sourceContainers
+	if len(req.Containers) > 0 {
+		byName := make(map[string]models.Container, len(sourceContainers))
+		for _, c := range sourceContainers {
+			byName[c.Name] = c
+		}
+		selected = nil
+		for _, name := range req.Containers {
+			c, ok := byName[name]
+			if !ok {
+				respondError(w, http.StatusBadRequest, fmt.Sprintf("No container %s on %s", name, source.Name))
+				return nil, false
+			}
+			selected = append(selected, c)
+		}
+	}
+	if len(selected) == 0 {
+		respondError(w, http.StatusBadRequest, "No containers to migrate")
+		return nil, false
+	}
+
+	configs := make(map[string]*models.ContainerConfig, len(selected))
+	for _, c := range selected {
+		inspection, err := s.db.GetContainerInspection(source.ID, c.ID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to get container configuration: "+err.Error())
+			return nil, false
+		}
+		if inspection != nil {
+			configs[c.Name] = &inspection.Config
+		}
+	}
+
+	migration, err := buildHostMigration(*source, *dest, selected, destContainers, configs)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to plan host migration: "+err.Error())
+		return nil, false
+	}
+	return migration, true
+}
+
+// buildHostMigration plans moving the selected containers from source to dest: for each, the
+// commands that stop it, copy its named volumes and bind-mounted paths over ssh and recreate it
+// on the destination, and what could get in the way there. Containers without a stored
+// configuration are left out with a warning.
+func buildHostMigration(source, dest models.Host, selected, destContainers []models.Container, configs map[string]*models.ContainerConfig) (*models.HostMigration, error) {
+	now := time.Now()
+	m := &models.HostMigration{
+		SourceHostID:   source.ID,
+		SourceHostName: source.Name,
+		DestHostID:     dest.ID,
+		DestHostName:   dest.Name,
+		SSHTarget:      sshTarget(dest.Address),
+		Status:         models.HostMigrationPlanned,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		Items:          []models.MigrationItem{},
+	}
+	if m.SSHTarget == "" {
+		m.Warnings = append(m.Warnings, fmt.Sprintf("Set DEST to the ssh target of %s (user@host) before running the copy commands", dest.Name))
+	}
+
+	destNames := make(map[string]bool, len(destContainers))
+	destNetworks := map[string]bool{}
+	destPorts := map[string]string{}
+	for _, c := range destContainers {
+		destNames[c.Name] = true
+		for _, n := range c.Networks {
+			destNetworks[n] = true
+		}
+		for _, p := range c.Ports {
+			if p.PublicPort > 0 {
+				destPorts[migrationPortKey(p)] = c.Name
+			}
+		}
+	}
+
+	needNetworks := map[string]bool{}
+	for _, c := range selected {
+		cfg := configs[c.Name]
+		if cfg == nil {
+			m.Warnings = append(m.Warnings, fmt.Sprintf("%s has no stored configuration yet and was left out; scan %s and plan again", c.Name, source.Name))
+			continue
+		}
+		item, err := migrationItem(c, *cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		if destNames[c.Name] {
+			item.Warnings = append(item.Warnings, fmt.Sprintf("A container named %s already exists on %s", c.Name, dest.Name))
+		}
+		warned := map[string]bool{}
+		for _, p := range c.Ports {
+			key := migrationPortKey(p)
+			if other, ok := destPorts[key]; ok && p.PublicPort > 0 && !warned[key] {
+				warned[key] = true
+				item.Warnings = append(item.Warnings, fmt.Sprintf("Port %d/%s is already published by %s on %s", p.PublicPort, migrationPortType(p), other, dest.Name))
+			}
+		}
+		for _, n := range item.Networks {
+			if !destNetworks[n] {
+				needNetworks[n] = true
+			}
+		}
+		m.Items = append(m.Items, *item)
+	}
+
+	var networks []string
+	for n := range needNetworks {
+		networks = append(networks, n)
+	}
+	sort.Strings(networks)
+	for _, n := range networks {
+		m.Networks = append(m.Networks, models.MigrationStep{
+			Host:    models.MigrationOnDestination,
+			Command: "docker network create " + inspect.ShellQuote(n),
+		})
+	}
+	return m, nil
+}
+
+// migrationItem generates the steps moving one container: pull its image on the destination,
+// stop it on the source, copy its data, recreate it on the destination and, once it runs there,
+// remove it from the source
+func migrationItem(c models.Container, cfg models.ContainerConfig) (*models.MigrationItem, error) {
+	compose, err := inspect.ComposeService(c, cfg)
+	if err != nil {
+		return nil, err
+	}
+	item := &models.MigrationItem{
+		ContainerName:  c.Name,
+		Image:          inspect.SpecImage(c),
+		ComposeProject: c.ComposeProject,
+		Networks:       inspect.SpecNetworks(cfg),
+		Ports:          inspect.SpecPorts(c),
+		RunCommand:     inspect.RunCommand(c, cfg),
+		ComposeService: compose,
+		Status:         models.MigrationItemPending,
+	}
+	onSource := func(command string) {
+		item.Steps = append(item.Steps, models.MigrationStep{Host: models.MigrationOnSource, Command: command})
+	}
+	onDest := func(command string) {
+		item.Steps = append(item.Steps, models.MigrationStep{Host: models.MigrationOnDestination, Command: command})
+	}
+
+	onDest("docker pull " + inspect.ShellQuote(item.Image))
+	onSource("docker stop " + inspect.ShellQuote(c.Name))
+
+	for _, mount := range cfg.Mounts {
+		switch mount.Type {
+		case "volume":
+			name := ""
+			for _, v := range c.Volumes {
+				if v.Type == "volume" && v.Destination == mount.Destination {
+					name = v.Name
+				}
+			}
+			if name == "" {
+				item.Warnings = append(item.Warnings, fmt.Sprintf("The anonymous volume at %s isn't copied; the new container starts with an empty one", mount.Destination))
+				continue
+			}
+			item.Volumes = append(item.Volumes, name)
+			vol := inspect.ShellQuote(name)
+			onDest("docker volume create " + vol)
+			onSource(fmt.Sprintf(`docker run --rm -v %s:/from:ro %s tar -C /from -cf - . | ssh "$DEST" docker run --rm -i -v %s:/to %s tar -C /to -xf -`,
+				vol, migrationCopyImage, vol, migrationCopyImage))
+		case "bind":
+			if inspect.SensitiveMountPath(mount.Source) != "" {
+				item.Warnings = append(item.Warnings, fmt.Sprintf("%s is a system path of the host and isn't copied; check it exists on the destination", mount.Source))
+				continue
+			}
+			item.BindMounts = append(item.BindMounts, mount.Source)
+			onSource(fmt.Sprintf(`rsync -aHR --numeric-ids %s "$DEST":/`, inspect.ShellQuote(mount.Source)))
+		}
+	}
+
+	var masked []string
+	for _, e := range cfg.Env {
+		if e.Masked {
+			masked = append(masked, e.Name)
+		}
+	}
+	if len(masked) > 0 {
+		item.Warnings = append(item.Warnings, fmt.Sprintf("Secret environment values are masked (%s); export them on the destination before running", strings.Join(masked, ", ")))
+	}
+	if strings.HasPrefix(cfg.NetworkMode, "container:") {
+		item.Warnings = append(item.Warnings, fmt.Sprintf("Shares the network of %s, which has to be moved first", strings.TrimPrefix(cfg.NetworkMode, "container:")))
+	}
+
+	for _, line := range strings.Split(item.RunCommand, "\n") {
+		onDest(line)
+	}
+	onSource("docker rm " + inspect.ShellQuote(c.Name))
+	return item, nil
+}
+
+// observeHostMigration fills in the state of each container on both hosts; "" when it's not there
+func observeHostMigration(m *models.HostMigration, source, dest []models.Container) {
+	sourceStates := make(map[string]string, len(source))
+	for _, c := range source {
+		sourceStates[c.Name] = c.State
+	}
+	destStates := make(map[string]string, len(dest))
+	for _, c := range dest {
+		destStates[c.Name] = c.State
+	}
+	for i := range m.Items {
+		m.Items[i].SourceState = sourceStates[m.Items[i].ContainerName]
+		m.Items[i].DestState = destStates[m.Items[i].ContainerName]
+	}
+}
+
+// sshTarget derives the ssh target of a host from its address: the user and host of ssh://
+// addresses, the host name of TCP and agent addresses, "" for local sockets
+func sshTarget(address string) string {
+	u, err := url.Parse(address)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+	if u.Scheme == "ssh" && u.User != nil {
+		return u.User.Username() + "@" + u.Hostname()
+	}
+	return u.Hostname()
+}
+
+func migrationPortKey(p models.PortMapping) string {
+	return strconv.Itoa(p.PublicPort) + "/" + migrationPortType(p)
+}
+
+func migrationPortType(p models.PortMapping) string {
+	if p.Type == "" {
+		return "tcp"
+	}
+	return p.Type
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestBuildHostMigration(t *testing.T) {
+	source := models.Host{ID: 1, Name: "old-nas", Address: "unix:///var/run/docker.sock"}
+	dest := models.Host{ID: 2, Name: "new-nas", Address: "ssh://admin@new-nas.lan"}
+
+	selected := []models.Container{
+		{
+			ID: "a1", Name: "gitea", Image: "gitea/gitea:1.22",
+			Ports:   []models.PortMapping{{PrivatePort: 3000, PublicPort: 3000, Type: "tcp"}, {PrivatePort: 3000, PublicPort: 3000, Type: "tcp", IP: "::"}},
+			Volumes: []models.VolumeMount{{Name: "gitea_data", Destination: "/data", Type: "volume", RW: true}},
+		},
+		{ID: "b1", Name: "unscanned", Image: "busybox"},
+	}
+	configs := map[string]*models.ContainerConfig{
+		"gitea": {
+			Env: []models.EnvVar{{Name: "GITEA__database__PASSWD", Value: "********", Masked: true}},
+			Mounts: []models.ConfigMount{
+				{Type: "volume", Source: "/var/lib/docker/volumes/gitea_data/_data", Destination: "/data", RW: true},
+				{Type: "bind", Source: "/srv/gitea/config", Destination: "/etc/gitea", RW: true},
+				{Type: "bind", Source: "/etc/localtime", Destination: "/etc/localtime"},
+				{Type: "volume", Source: "/var/lib/docker/volumes/4f1e/_data", Destination: "/cache", RW: true},
+			},
+			RestartPolicy: "always",
+			NetworkMode:   "git",
+			Networks:      []models.ConfigNetwork{{Name: "git"}},
+		},
+	}
+	destContainers := []models.Container{
+		{Name: "gitea", Networks: []string{"bridge"}},
+		{Name: "grafana", Networks: []string{"monitoring"}, Ports: []models.PortMapping{{PrivatePort: 3000, PublicPort: 3000, Type: "tcp"}}},
+	}
+
+	m, err := buildHostMigration(source, dest, selected, destContainers, configs)
+	if err != nil {
+		t.Fatalf("buildHostMigration failed: %v", err)
+	}
+	if m.SSHTarget != "admin@new-nas.lan" || m.Status != models.HostMigrationPlanned {
+		t.Errorf("Unexpected migration %+v", m)
+	}
+	if len(m.Items) != 1 || len(m.Warnings) != 1 || !strings.Contains(m.Warnings[0], "unscanned") {
+		t.Fatalf("Expected the container without a configuration left out with a warning, got %+v / %v", m.Items, m.Warnings)
+	}
+	if len(m.Networks) != 1 || m.Networks[0].Command != "docker network create git" || m.Networks[0].Host != models.MigrationOnDestination {
+		t.Errorf("Expected the missing network created on the destination, got %+v", m.Networks)
+	}
+
+	item := m.Items[0]
+	if item.Status != models.MigrationItemPending || len(item.Volumes) != 1 || len(item.BindMounts) != 1 || item.BindMounts[0] != "/srv/gitea/config" {
+		t.Errorf("Unexpected item %+v", item)
+	}
+
+	var commands []string
+	for _, step := range item.Steps {
+		commands = append(commands, step.Host+": "+step.Command)
+	}
+	want := []string{
+		"destination: docker pull gitea/gitea:1.22",
+		"source: docker stop gitea",
+		"destination: docker volume create gitea_data",
+		`source: docker run --rm -v gitea_data:/from:ro alpine tar -C /from -cf - . | ssh "$DEST" docker run --rm -i -v gitea_data:/to alpine tar -C /to -xf -`,
+		`source: rsync -aHR --numeric-ids /srv/gitea/config "$DEST":/`,
+		"destination: " + item.RunCommand,
+		"source: docker rm gitea",
+	}
+	if strings.Join(commands, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Steps =\n%s\nwant\n%s", strings.Join(commands, "\n"), strings.Join(want, "\n"))
+	}
+
+	warnings := strings.Join(item.Warnings, "\n")
+	for _, w := range []string{"already exists on new-nas", "Port 3000/tcp is already published by grafana", "/etc/localtime", "anonymous volume at /cache", "GITEA__database__PASSWD"} {
+		if !strings.Contains(warnings, w) {
+			t.Errorf("Expected a warning about %q, got:\n%s", w, warnings)
+		}
+	}
+	if strings.Count(warnings, "Port 3000") != 1 {
+		t.Errorf("Expected one warning per conflicting port, got:\n%s", warnings)
+	}
+}
+
+func TestSSHTarget(t *testing.T) {
+	tests := map[string]string{
+		"ssh://admin@nas.lan":          "admin@nas.lan",
+		"ssh://nas.lan:2222":           "nas.lan",
+		"tcp://10.0.0.5:2376":          "10.0.0.5",
+		"agent://vps.example.com:9876": "vps.example.com",
+		"unix:///var/run/docker.sock":  "",
+	}
+	for address, want := range tests {
+		if got := sshTarget(address); got != want {
+			t.Errorf("sshTarget(%q) = %q, want %q", address, got, want)
+		}
+	}
+}
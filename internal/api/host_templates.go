@@ -0,0 +1,218 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/registry"
+	"github.com/gorilla/mux"
+)
+
+// handleGetHostTemplates lists the host templates
+func (s *Server) handleGetHostTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := s.db.GetHostTemplates()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get host templates: "+err.Error())
+		return
+	}
+	if templates == nil {
+		templates = []models.HostTemplate{}
+	}
+	respondJSON(w, http.StatusOK, templates)
+}
+
+// handleCreateHostTemplate saves a new host template from the request body, or with
+// ?from_host=ID takes it from that host (its settings and notification rules) under the name in
+// the body
+func (s *Server) handleCreateHostTemplate(w http.ResponseWriter, r *http.Request) {
+	var t models.HostTemplate
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	if from := r.URL.Query().Get("from_host"); from != "" {
+		hostID, err := strconv.ParseInt(from, 10, 64)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid host ID")
+			return
+		}
+		taken, err := s.db.HostTemplateFromHost(hostID)
+		if err != nil {
+			respondError(w, http.StatusNotFound, "Host not found")
+			return
+		}
+		taken.Name = t.Name
+		t = *taken
+	}
+	t.ID = 0
+
+	s.saveHostTemplate(w, &t, http.StatusCreated)
+}
+
+// handleUpdateHostTemplate replaces a host template; hosts created from it don't change
+func (s *Server) handleUpdateHostTemplate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid template ID")
+		return
+	}
+
+	var t models.HostTemplate
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	t.ID = id
+
+	s.saveHostTemplate(w, &t, http.StatusOK)
+}
+
+func (s *Server) saveHostTemplate(w http.ResponseWriter, t *models.HostTemplate, status int) {
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" {
+		respondError(w, http.StatusBadRequest, "Name is required")
+		return
+	}
+	t.Site = strings.TrimSpace(t.Site)
+	t.RegistryMirror = registry.NormalizeMirror(t.RegistryMirror)
+
+	if err := s.db.SaveHostTemplate(t); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			respondError(w, http.StatusNotFound, "Host template not found")
+		case strings.Contains(err.Error(), "UNIQUE"):
+			respondError(w, http.StatusConflict, "A host template named "+t.Name+" already exists")
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to save host template: "+err.Error())
+		}
+		return
+	}
+	respondJSON(w, status, t)
+}
+
+// handleDeleteHostTemplate deletes a host template
+func (s *Server) handleDeleteHostTemplate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid template ID")
+		return
+	}
+
+	if err := s.db.DeleteHostTemplate(id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(w, http.StatusNotFound, "Host template not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to delete host template: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Host template deleted"})
+}
+
+// handleApplyHostTemplate applies a template (JSON: {"template_id": 1}) or the settings of
+// another host ({"clone_host_id": 2}) to a host and gives it their notification rules
+func (s *Server) handleApplyHostTemplate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid host ID")
+		return
+	}
+
+	var req struct {
+		TemplateID  int64 `json:"template_id"`
+		CloneHostID int64 `json:"clone_host_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	if req.TemplateID == 0 && req.CloneHostID == 0 {
+		respondError(w, http.StatusBadRequest, "template_id or clone_host_id is required")
+		return
+	}
+	if req.CloneHostID == id {
+		respondError(w, http.StatusBadRequest, "A host can't be cloned onto itself")
+		return
+	}
+
+	t, status, err := s.resolveHostTemplate(req.TemplateID, req.CloneHostID)
+	if err != nil {
+		respondError(w, status, err.Error())
+		return
+	}
+
+	rules, err := s.db.ApplyHostTemplate(t, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusNotFound, "Host not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to apply template: "+err.Error())
+		return
+	}
+	s.cache.Invalidate()
+
+	host, err := s.db.GetHost(id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get host: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"host":          host,
+		"rules_created": rules,
+	})
+}
+
+// resolveHostTemplate returns a saved template, or one taken from the host to clone, with the
+// HTTP status to answer if it can't be found
+func (s *Server) resolveHostTemplate(templateID, cloneHostID int64) (*models.HostTemplate, int, error) {
+	if templateID != 0 {
+		t, err := s.db.GetHostTemplate(templateID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, http.StatusNotFound, errors.New("host template not found")
+		}
+		if err != nil {
+			return nil, http.StatusInternalServerError, errors.New("failed to get host template: " + err.Error())
+		}
+		return t, 0, nil
+	}
+
+	t, err := s.db.HostTemplateFromHost(cloneHostID)
+	if err != nil {
+		return nil, http.StatusNotFound, errors.New("host to clone not found")
+	}
+	return t, 0, nil
+}
+
+// addHostTemplate returns the template a host being added starts from (template_id or
+// clone_host_id of the add request), or nil when neither is set. Templates are kept by the
+// administrator, so tenant users can't use them.
+func (s *Server) addHostTemplate(r *http.Request, templateID, cloneHostID int64) (*models.HostTemplate, int, error) {
+	if templateID == 0 && cloneHostID == 0 {
+		return nil, 0, nil
+	}
+	if identity(r).TenantID != 0 {
+		return nil, http.StatusForbidden, errors.New("host templates can only be used by administrators")
+	}
+	return s.resolveHostTemplate(templateID, cloneHostID)
+}
+
+// createHostTemplateRules gives a newly added host the template's notification rules. The host
+// is already added, so failures are only logged.
+func (s *Server) createHostTemplateRules(t *models.HostTemplate, host models.Host) int {
+	if t == nil {
+		return 0
+	}
+	created, err := s.db.CreateHostTemplateRules(t, host)
+	if err != nil {
+		log.Printf("Failed to create notification rules for host %s from template: %v", host.Name, err)
+	}
+	return created
+}
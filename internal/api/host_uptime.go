@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// maxUptimeHours limits the uptime window to the heartbeat retention
+const maxUptimeHours = 30 * 24
+
+// handleGetHostUptime returns a host's reachability timeline: a heartbeat per scan, the
+// downtimes and the uptime percentage over the last ?hours= hours (default 24)
+func (s *Server) handleGetHostUptime(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid host ID")
+		return
+	}
+
+	hours := 24
+	if v := r.URL.Query().Get("hours"); v != "" {
+		hours, err = strconv.Atoi(v)
+		if err != nil || hours < 1 || hours > maxUptimeHours {
+			respondError(w, http.StatusBadRequest, "hours must be between 1 and "+strconv.Itoa(maxUptimeHours))
+			return
+		}
+	}
+
+	host, err := s.db.GetHost(id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Host not found")
+		return
+	}
+
+	uptime, err := s.db.GetHostUptime(*host, time.Now().Add(-time.Duration(hours)*time.Hour))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get host uptime: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, uptime)
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/container-census/container-census/internal/incus"
+	"github.com/container-census/container-census/internal/models"
+)
+
+// handleGetIncusCertificate returns the client certificate Census presents to Incus/LXD hosts,
+// which has to be added to their trust store
+func (s *Server) handleGetIncusCertificate(w http.ResponseWriter, r *http.Request) {
+	cert := s.scanner.IncusCertificate()
+	if cert == nil {
+		respondError(w, http.StatusServiceUnavailable, "Incus client certificate is not available; check the server logs")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{
+		"certificate": string(incus.CertificatePEM(cert)),
+		"fingerprint": incus.Fingerprint(cert.Certificate[0]),
+	})
+}
+
+// handleTestIncusConnection checks that an Incus/LXD host is reachable and trusts Census
+func (s *Server) handleTestIncusConnection(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Address string `json:"address"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	if !incus.IsAddress(req.Address) {
+		respondError(w, http.StatusBadRequest, "Address must start with incus:// or lxd://")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := s.scanner.VerifyConnection(ctx, req.Address); err != nil {
+		respondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Incus host is reachable and trusts the Census certificate",
+	})
+}
+
+// handleAddIncusHost adds an Incus/LXD host after checking that it trusts Census
+func (s *Server) handleAddIncusHost(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name         string `json:"name"`
+		Address      string `json:"address"`
+		Description  string `json:"description"`
+		CollectStats *bool  `json:"collect_stats"`
+		Site         string `json:"site"`
+		// Optional: start from a host template, or from the settings of an existing host
+		TemplateID  int64 `json:"template_id"`
+		CloneHostID int64 `json:"clone_host_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	if req.Name == "" {
+		respondError(w, http.StatusBadRequest, "Name is required")
+		return
+	}
+	if !incus.IsAddress(req.Address) {
+		respondError(w, http.StatusBadRequest, "Address must start with incus:// or lxd://")
+		return
+	}
+	template, status, err := s.addHostTemplate(r, req.TemplateID, req.CloneHostID)
+	if err != nil {
+		respondError(w, status, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := s.scanner.VerifyConnection(ctx, req.Address); err != nil {
+		respondError(w, http.StatusBadGateway, "Failed to connect to Incus host: "+err.Error())
+		return
+	}
+
+	host := models.Host{
+		Name:        req.Name,
+		Address:     req.Address,
+		Description: req.Description,
+		HostType:    models.HostTypeIncus,
+		Enabled:     true,
+		Site:        strings.TrimSpace(req.Site),
+		TenantID:    identity(r).TenantID,
+		LastSeen:    time.Now(),
+	}
+	if template != nil {
+		template.ApplyTo(&host)
+	}
+	if req.CollectStats != nil {
+		host.CollectStats = *req.CollectStats
+	}
+	id, err := s.db.AddHost(host)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to add host: "+err.Error())
+		return
+	}
+
+	host.ID = id
+	s.createHostTemplateRules(template, host)
+	respondJSON(w, http.StatusCreated, host)
+}
@@ -0,0 +1,82 @@
+//go:build integration
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/container-census/container-census/internal/auth"
+	"github.com/container-census/container-census/internal/integration"
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/scanner"
+)
+
+// TestIntegrationContainerOperations runs container operations through the full router against
+// a real daemon, directly and through an agent that only allows lifecycle operations
+func TestIntegrationContainerOperations(t *testing.T) {
+	h := integration.New(t)
+	_, db := setupTestServer(t)
+	server := New(db, scanner.New(30), 300, auth.Config{})
+	ts := httptest.NewServer(server.Router())
+	defer ts.Close()
+
+	do := func(method, path string, out interface{}) int {
+		t.Helper()
+		req, _ := http.NewRequest(method, ts.URL+path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		if out != nil {
+			json.NewDecoder(resp.Body).Decode(out)
+		}
+		return resp.StatusCode
+	}
+
+	directID, err := db.AddHost(h.Host())
+	if err != nil {
+		t.Fatalf("AddHost failed: %v", err)
+	}
+	agentID, err := db.AddHost(h.StartAgent("integration-token", models.AgentPermissionLifecycle))
+	if err != nil {
+		t.Fatalf("AddHost failed: %v", err)
+	}
+
+	for _, hostID := range []int64{directID, agentID} {
+		c := h.Run(integration.ContainerOptions{Name: fmt.Sprintf("ops-%d", hostID)})
+		base := fmt.Sprintf("/api/containers/%d/%s", hostID, c.ID)
+		if code := do("POST", base+"/stop?timeout=0", nil); code != http.StatusOK {
+			t.Fatalf("Expected stop on host %d to succeed, got %d", hostID, code)
+		}
+		if state := h.State(c); state != "exited" {
+			t.Errorf("Expected the container exited, got %s", state)
+		}
+		if code := do("POST", base+"/start", nil); code != http.StatusOK {
+			t.Fatalf("Expected start on host %d to succeed, got %d", hostID, code)
+		}
+		if state := h.State(c); state != "running" {
+			t.Errorf("Expected the container running, got %s", state)
+		}
+
+		code := do("DELETE", base+"?force=true", nil)
+		if hostID == agentID && code == http.StatusOK {
+			t.Error("Expected the agent to refuse removing without the remove permission")
+		}
+		if hostID == directID && code != http.StatusOK {
+			t.Errorf("Expected remove on the direct host to succeed, got %d", code)
+		}
+	}
+
+	var info models.AgentInfo
+	if code := do("GET", fmt.Sprintf("/api/hosts/agent/%d/info", agentID), &info); code != http.StatusOK {
+		t.Fatalf("Expected the agent info, got %d", code)
+	}
+	if len(info.Permissions) != 1 || info.Permissions[0] != models.AgentPermissionLifecycle || info.Version != "integration" {
+		t.Errorf("Expected the agent to report its lifecycle permission, got %+v", info)
+	}
+}
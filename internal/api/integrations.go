@@ -0,0 +1,303 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/proxmox"
+	"github.com/container-census/container-census/internal/uptimekuma"
+)
+
+// errUptimeKumaDisabled is returned when a sync is requested while the integration is off
+var errUptimeKumaDisabled = errors.New("Uptime Kuma integration is disabled")
+
+// errProxmoxDisabled is returned when a sync is requested while the integration is off
+var errProxmoxDisabled = errors.New("Proxmox integration is disabled")
+
+// handleGetUptimeKumaSettings returns the Uptime Kuma settings with secrets masked
+func (s *Server) handleGetUptimeKumaSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := s.db.GetUptimeKumaSettings()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get Uptime Kuma settings: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, settings.Redacted())
+}
+
+// handleUpdateUptimeKumaSettings saves the Uptime Kuma settings. Masked secrets sent back from
+// handleGetUptimeKumaSettings keep the stored values.
+func (s *Server) handleUpdateUptimeKumaSettings(w http.ResponseWriter, r *http.Request) {
+	var settings models.UptimeKumaSettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	current, err := s.db.GetUptimeKumaSettings()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get Uptime Kuma settings: "+err.Error())
+		return
+	}
+	if settings.Password == models.MaskedSecret {
+		settings.Password = current.Password
+	}
+	if settings.APIKey == models.MaskedSecret {
+		settings.APIKey = current.APIKey
+	}
+
+	if err := settings.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.db.SaveUptimeKumaSettings(&settings); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save Uptime Kuma settings: "+err.Error())
+		return
+	}
+	s.cache.Invalidate()
+
+	respondJSON(w, http.StatusOK, settings.Redacted())
+}
+
+// handleSyncUptimeKuma syncs monitors with Uptime Kuma and ingests their status right away
+func (s *Server) handleSyncUptimeKuma(w http.ResponseWriter, r *http.Request) {
+	result, err := s.SyncUptimeKuma(r.Context())
+	if errors.Is(err, errUptimeKumaDisabled) {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Uptime Kuma sync failed: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+// handleGetUptimeStatuses returns the latest monitor status and 24h availability of monitored containers
+func (s *Server) handleGetUptimeStatuses(w http.ResponseWriter, r *http.Request) {
+	statuses, err := s.db.GetUptimeStatuses()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get uptime statuses: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, statuses)
+}
+
+// SyncUptimeKuma creates or updates Uptime Kuma monitors for the current containers and stores
+// the status of those monitors
+func (s *Server) SyncUptimeKuma(ctx context.Context) (*models.UptimeKumaSyncResult, error) {
+	settings, err := s.db.GetUptimeKumaSettings()
+	if err != nil {
+		return nil, err
+	}
+	if !settings.Enabled {
+		return nil, errUptimeKumaDisabled
+	}
+
+	containers, err := s.db.GetLatestContainers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get containers: %w", err)
+	}
+	hosts, err := s.db.GetHosts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hosts: %w", err)
+	}
+
+	result, statuses := uptimekuma.Sync(ctx, settings, containers, hosts, nil)
+	if err := s.db.SaveUptimeStatuses(statuses); err != nil {
+		return nil, fmt.Errorf("failed to save uptime statuses: %w", err)
+	}
+	s.cache.Invalidate()
+
+	return result, nil
+}
+
+// attachUptime sets the Uptime Kuma status on containers that have a monitor
+func (s *Server) attachUptime(containers []models.Container) {
+	settings, err := s.db.GetUptimeKumaSettings()
+	if err != nil || !settings.Enabled {
+		return
+	}
+
+	statuses, err := s.db.GetUptimeStatuses()
+	if err != nil {
+		log.Printf("Failed to get uptime statuses: %v", err)
+		return
+	}
+
+	byContainer := make(map[string]models.UptimeMonitorStatus, len(statuses))
+	for _, status := range statuses {
+		byContainer[fmt.Sprintf("%d/%s", status.HostID, status.ContainerName)] = status
+	}
+	for i := range containers {
+		if status, ok := byContainer[fmt.Sprintf("%d/%s", containers[i].HostID, containers[i].Name)]; ok {
+			containers[i].Uptime = &status
+		}
+	}
+}
+
+// handleGetProxmoxSettings returns the Proxmox settings with the token secret masked
+func (s *Server) handleGetProxmoxSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := s.db.GetProxmoxSettings()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get Proxmox settings: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, settings.Redacted())
+}
+
+// handleUpdateProxmoxSettings saves the Proxmox settings. A masked token secret sent back from
+// handleGetProxmoxSettings keeps the stored value.
+func (s *Server) handleUpdateProxmoxSettings(w http.ResponseWriter, r *http.Request) {
+	var settings models.ProxmoxSettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	current, err := s.db.GetProxmoxSettings()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get Proxmox settings: "+err.Error())
+		return
+	}
+	if settings.TokenSecret == models.MaskedSecret {
+		settings.TokenSecret = current.TokenSecret
+	}
+
+	if err := settings.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.db.SaveProxmoxSettings(&settings); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save Proxmox settings: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, settings.Redacted())
+}
+
+// handleSyncProxmox maps hosts to Proxmox guests right away
+func (s *Server) handleSyncProxmox(w http.ResponseWriter, r *http.Request) {
+	result, err := s.SyncProxmox(r.Context())
+	if errors.Is(err, errProxmoxDisabled) {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Proxmox sync failed: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+// handleGetProxmoxGuests returns the Proxmox guest of every mapped host
+func (s *Server) handleGetProxmoxGuests(w http.ResponseWriter, r *http.Request) {
+	guests, err := s.db.GetProxmoxGuests()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get Proxmox guests: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, guests)
+}
+
+// handleGetTraefikSettings returns the Traefik settings with the password masked
+func (s *Server) handleGetTraefikSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := s.db.GetTraefikSettings()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get Traefik settings: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, settings.Redacted())
+}
+
+// handleUpdateTraefikSettings saves the Traefik settings. A masked password sent back from
+// handleGetTraefikSettings keeps the stored value.
+func (s *Server) handleUpdateTraefikSettings(w http.ResponseWriter, r *http.Request) {
+	var settings models.TraefikSettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	current, err := s.db.GetTraefikSettings()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get Traefik settings: "+err.Error())
+		return
+	}
+	if settings.Password == models.MaskedSecret {
+		settings.Password = current.Password
+	}
+
+	if err := settings.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.db.SaveTraefikSettings(&settings); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save Traefik settings: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, settings.Redacted())
+}
+
+// SyncProxmox maps the hosts to the Proxmox VMs, containers and nodes they run on and stores the
+// mapping. When Proxmox can't be read at all the previous mapping is kept.
+func (s *Server) SyncProxmox(ctx context.Context) (*models.ProxmoxSyncResult, error) {
+	settings, err := s.db.GetProxmoxSettings()
+	if err != nil {
+		return nil, err
+	}
+	if !settings.Enabled {
+		return nil, errProxmoxDisabled
+	}
+
+	hosts, err := s.db.GetHosts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hosts: %w", err)
+	}
+
+	result, guests := proxmox.Sync(ctx, settings, hosts, nil)
+	if guests == nil {
+		return result, nil
+	}
+	if err := s.db.ReplaceProxmoxGuests(guests); err != nil {
+		return nil, fmt.Errorf("failed to save Proxmox guests: %w", err)
+	}
+
+	return result, nil
+}
+
+// attachProxmox sets the Proxmox guest on hosts that were mapped to one
+func (s *Server) attachProxmox(hosts []models.Host) {
+	settings, err := s.db.GetProxmoxSettings()
+	if err != nil || !settings.Enabled {
+		return
+	}
+
+	guests, err := s.db.GetProxmoxGuests()
+	if err != nil {
+		log.Printf("Failed to get Proxmox guests: %v", err)
+		return
+	}
+
+	byHost := make(map[int64]models.ProxmoxGuest, len(guests))
+	for _, guest := range guests {
+		byHost[guest.HostID] = guest
+	}
+	for i := range hosts {
+		if guest, ok := byHost[hosts[i].ID]; ok {
+			hosts[i].Proxmox = &guest
+		}
+	}
+}
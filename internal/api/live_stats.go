@@ -0,0 +1,292 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/scanner"
+)
+
+// liveStatsInterval is how often the fleet is sampled while /api/stats/live has clients. A round
+// that takes longer is followed by the next one right away.
+const liveStatsInterval = 5 * time.Second
+
+// liveStatsHub samples the fleet while at least one client streams /api/stats/live; the clients
+// share the samples, so watching from several browsers doesn't multiply the load on the hosts
+type liveStatsHub struct {
+	mu          sync.Mutex
+	subscribers map[chan models.FleetLiveStats]struct{}
+	cancel      context.CancelFunc
+	last        *models.FleetLiveStats
+}
+
+// liveHostSample is what sampling one host returned
+type liveHostSample struct {
+	containers []models.Container
+	err        error
+}
+
+// subscribe adds a client. The first one starts run in the background, which publishes samples
+// until the last client unsubscribes. The latest sample, if any, is sent right away.
+func (h *liveStatsHub) subscribe(run func(ctx context.Context, publish func(models.FleetLiveStats))) (<-chan models.FleetLiveStats, func()) {
+	ch := make(chan models.FleetLiveStats, 1)
+
+	h.mu.Lock()
+	if h.subscribers == nil {
+		h.subscribers = make(map[chan models.FleetLiveStats]struct{})
+	}
+	h.subscribers[ch] = struct{}{}
+	if h.last != nil {
+		ch <- *h.last
+	}
+	if h.cancel == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		h.cancel = cancel
+		go run(ctx, func(sample models.FleetLiveStats) {
+			h.publish(ctx, sample)
+		})
+	}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.subscribers, ch)
+		if len(h.subscribers) == 0 && h.cancel != nil {
+			h.cancel()
+			h.cancel = nil
+			h.last = nil
+		}
+	}
+}
+
+// publish sends a sample to every subscriber without blocking; a client that hasn't read the
+// previous sample yet gets the new one instead. Samples of a stopped run are dropped.
+func (h *liveStatsHub) publish(ctx context.Context, sample models.FleetLiveStats) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if ctx.Err() != nil {
+		return
+	}
+	h.last = &sample
+	for ch := range h.subscribers {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- sample
+	}
+}
+
+// handleStreamFleetStats streams the CPU and memory of the running containers of every enabled
+// host as server-sent events, every liveStatsInterval, while the client is connected (?host_id=
+// narrows it to one host). Tenant users see their own hosts.
+func (s *Server) handleStreamFleetStats(w http.ResponseWriter, r *http.Request) {
+	var visible map[int64]bool
+	if v := r.URL.Query().Get("host_id"); v != "" {
+		hostID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid host ID")
+			return
+		}
+		visible = map[int64]bool{hostID: true}
+	}
+	if !identity(r).IsAdmin() {
+		hosts, err := s.db.GetHosts()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to get hosts: "+err.Error())
+			return
+		}
+		own := make(map[int64]bool)
+		for _, host := range visibleHosts(r, hosts) {
+			if visible == nil || visible[host.ID] {
+				own[host.ID] = true
+			}
+		}
+		visible = own
+	}
+
+	samples, unsubscribe := s.liveStats.subscribe(s.sampleFleetStats)
+	defer unsubscribe()
+
+	// The stream outlives the server's write timeout
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case sample := <-samples:
+			data, err := json.Marshal(filterFleetLiveStats(sample, visible))
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// sampleFleetStats publishes a sample of the fleet every liveStatsInterval until ctx is cancelled
+func (s *Server) sampleFleetStats(ctx context.Context, publish func(models.FleetLiveStats)) {
+	for {
+		started := time.Now()
+		sample, err := s.fleetLiveStats(ctx)
+		if err != nil {
+			log.Printf("Failed to sample live fleet stats: %v", err)
+		} else if ctx.Err() == nil {
+			publish(sample)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(liveStatsInterval - time.Since(started)):
+		}
+	}
+}
+
+// fleetLiveStats scans every enabled host at once with stats collection on, without saving
+// anything. Containers opted out of stats by label are left out, as in periodic scans.
+func (s *Server) fleetLiveStats(ctx context.Context) (models.FleetLiveStats, error) {
+	hosts, err := s.db.GetHosts()
+	if err != nil {
+		return models.FleetLiveStats{}, err
+	}
+
+	ctx = scanner.WithQuietLog(ctx)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	samples := make(map[int64]liveHostSample, len(hosts))
+	for _, host := range hosts {
+		if !host.Enabled {
+			continue
+		}
+		host.CollectStats = true
+		wg.Add(1)
+		go func(host models.Host) {
+			defer wg.Done()
+			containers, err := s.scanner.ScanHost(ctx, host)
+			mu.Lock()
+			samples[host.ID] = liveHostSample{containers: containers, err: err}
+			mu.Unlock()
+		}(host)
+	}
+	wg.Wait()
+
+	return summarizeFleetLiveStats(time.Now().UTC(), hosts, samples), nil
+}
+
+// summarizeFleetLiveStats turns the scans of a sampling round into a fleet sample: the running
+// containers, busiest CPU first, and per host totals. Hosts without a sample were disabled.
+func summarizeFleetLiveStats(now time.Time, hosts []models.Host, samples map[int64]liveHostSample) models.FleetLiveStats {
+	sample := models.FleetLiveStats{
+		Timestamp:  now,
+		Hosts:      []models.FleetLiveHost{},
+		Containers: []models.FleetLiveContainer{},
+	}
+	for _, host := range hosts {
+		hostSample, ok := samples[host.ID]
+		if !ok {
+			continue
+		}
+		rollup := models.FleetLiveHost{HostID: host.ID, HostName: host.Name}
+		if hostSample.err != nil {
+			rollup.Error = hostSample.err.Error()
+		}
+		for _, c := range hostSample.containers {
+			if c.State != "running" || models.StatsDisabledByLabels(c.Labels) {
+				continue
+			}
+			sample.Containers = append(sample.Containers, models.FleetLiveContainer{
+				HostID:      host.ID,
+				HostName:    host.Name,
+				ContainerID: c.ID,
+				Name:        c.Name,
+				Image:       c.Image,
+				LiveContainerStats: models.LiveContainerStats{
+					Timestamp:      now,
+					CPUPercent:     c.CPUPercent,
+					MemoryUsage:    c.MemoryUsage,
+					MemoryLimit:    c.MemoryLimit,
+					MemoryPercent:  c.MemoryPercent,
+					NetworkRxRate:  c.NetworkRxRate,
+					NetworkTxRate:  c.NetworkTxRate,
+					BlockReadRate:  c.BlockReadRate,
+					BlockWriteRate: c.BlockWriteRate,
+				},
+			})
+			rollup.Containers++
+			rollup.CPUPercent += c.CPUPercent
+			rollup.MemoryUsage += c.MemoryUsage
+		}
+		sample.CPUPercent += rollup.CPUPercent
+		sample.MemoryUsage += rollup.MemoryUsage
+		sample.Hosts = append(sample.Hosts, rollup)
+	}
+
+	sort.SliceStable(sample.Hosts, func(i, j int) bool {
+		return sample.Hosts[i].HostName < sample.Hosts[j].HostName
+	})
+	sort.SliceStable(sample.Containers, func(i, j int) bool {
+		a, b := sample.Containers[i], sample.Containers[j]
+		if a.CPUPercent != b.CPUPercent {
+			return a.CPUPercent > b.CPUPercent
+		}
+		if a.MemoryUsage != b.MemoryUsage {
+			return a.MemoryUsage > b.MemoryUsage
+		}
+		if a.HostName != b.HostName {
+			return a.HostName < b.HostName
+		}
+		return a.Name < b.Name
+	})
+	return sample
+}
+
+// filterFleetLiveStats keeps the hosts in visible, and their containers, with the totals
+// recomputed; a nil visible keeps everything
+func filterFleetLiveStats(sample models.FleetLiveStats, visible map[int64]bool) models.FleetLiveStats {
+	if visible == nil {
+		return sample
+	}
+	filtered := models.FleetLiveStats{
+		Timestamp:  sample.Timestamp,
+		Hosts:      []models.FleetLiveHost{},
+		Containers: []models.FleetLiveContainer{},
+	}
+	for _, host := range sample.Hosts {
+		if visible[host.HostID] {
+			filtered.Hosts = append(filtered.Hosts, host)
+			filtered.CPUPercent += host.CPUPercent
+			filtered.MemoryUsage += host.MemoryUsage
+		}
+	}
+	for _, c := range sample.Containers {
+		if visible[c.HostID] {
+			filtered.Containers = append(filtered.Containers, c)
+		}
+	}
+	return filtered
+}
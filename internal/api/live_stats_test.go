@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestSummarizeFleetLiveStats(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	hosts := []models.Host{
+		{ID: 1, Name: "pi"},
+		{ID: 2, Name: "nas"},
+		{ID: 3, Name: "off"},
+		{ID: 4, Name: "gone"},
+	}
+	samples := map[int64]liveHostSample{
+		1: {containers: []models.Container{
+			{ID: "a", Name: "dns", Image: "pihole", State: "running", CPUPercent: 5, MemoryUsage: 100},
+			{ID: "b", Name: "quiet", State: "running", CPUPercent: 50, Labels: map[string]string{models.StatsOptOutLabel: "false"}},
+		}},
+		2: {containers: []models.Container{
+			{ID: "c", Name: "db", State: "running", CPUPercent: 30, MemoryUsage: 400},
+			{ID: "d", Name: "web", State: "running", CPUPercent: 5, MemoryUsage: 200},
+			{ID: "e", Name: "job", State: "exited"},
+		}},
+		4: {err: errors.New("connection refused")},
+	}
+
+	sample := summarizeFleetLiveStats(now, hosts, samples)
+
+	if !sample.Timestamp.Equal(now) || sample.CPUPercent != 40 || sample.MemoryUsage != 700 {
+		t.Errorf("Unexpected totals: %+v", sample)
+	}
+	if len(sample.Hosts) != 3 || sample.Hosts[0].HostName != "gone" || sample.Hosts[1].HostName != "nas" || sample.Hosts[2].HostName != "pi" {
+		t.Fatalf("Expected gone, nas and pi, got %+v", sample.Hosts)
+	}
+	if sample.Hosts[0].Error != "connection refused" || sample.Hosts[0].Containers != 0 {
+		t.Errorf("Expected the failed host with its error, got %+v", sample.Hosts[0])
+	}
+	if nas := sample.Hosts[1]; nas.Containers != 2 || nas.CPUPercent != 35 || nas.MemoryUsage != 600 {
+		t.Errorf("Unexpected nas totals: %+v", nas)
+	}
+
+	var names []string
+	for _, c := range sample.Containers {
+		names = append(names, c.Name)
+	}
+	// Busiest CPU first; the tie goes to the larger memory
+	if len(names) != 3 || names[0] != "db" || names[1] != "web" || names[2] != "dns" {
+		t.Errorf("Expected db, web, dns, got %v", names)
+	}
+	if dns := sample.Containers[2]; dns.HostID != 1 || dns.HostName != "pi" || dns.ContainerID != "a" || dns.Image != "pihole" || !dns.Timestamp.Equal(now) {
+		t.Errorf("Unexpected container sample: %+v", dns)
+	}
+
+	filtered := filterFleetLiveStats(sample, map[int64]bool{1: true})
+	if len(filtered.Hosts) != 1 || len(filtered.Containers) != 1 || filtered.Containers[0].Name != "dns" {
+		t.Errorf("Expected only pi, got %+v", filtered)
+	}
+	if filtered.CPUPercent != 5 || filtered.MemoryUsage != 100 {
+		t.Errorf("Expected the totals of pi, got %+v", filtered)
+	}
+	if all := filterFleetLiveStats(sample, nil); len(all.Containers) != 3 {
+		t.Errorf("Expected everything without a filter, got %+v", all)
+	}
+}
+
+func TestLiveStatsHub(t *testing.T) {
+	var hub liveStatsHub
+	var runs int32
+	stopped := make(chan struct{}, 2)
+	publishers := make(chan func(models.FleetLiveStats), 2)
+	run := func(ctx context.Context, publish func(models.FleetLiveStats)) {
+		atomic.AddInt32(&runs, 1)
+		publishers <- publish
+		<-ctx.Done()
+		stopped <- struct{}{}
+	}
+
+	first, unsubscribeFirst := hub.subscribe(run)
+	second, unsubscribeSecond := hub.subscribe(run)
+	publish := <-publishers
+	if n := atomic.LoadInt32(&runs); n != 1 {
+		t.Fatalf("Expected one sampler for both clients, got %d", n)
+	}
+
+	publish(models.FleetLiveStats{CPUPercent: 1})
+	publish(models.FleetLiveStats{CPUPercent: 2})
+	for _, ch := range []<-chan models.FleetLiveStats{first, second} {
+		select {
+		case sample := <-ch:
+			if sample.CPUPercent != 2 {
+				t.Errorf("Expected the newest sample to replace the unread one, got %+v", sample)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected a sample")
+		}
+	}
+
+	// A late client gets the latest sample right away
+	third, unsubscribeThird := hub.subscribe(run)
+	select {
+	case sample := <-third:
+		if sample.CPUPercent != 2 {
+			t.Errorf("Expected the latest sample, got %+v", sample)
+		}
+	default:
+		t.Error("Expected the latest sample on subscribe")
+	}
+
+	unsubscribeFirst()
+	unsubscribeSecond()
+	select {
+	case <-stopped:
+		t.Fatal("The sampler stopped while a client was still connected")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unsubscribeThird()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the sampler to stop with the last client")
+	}
+
+	// Samples of the stopped run are dropped, and the next client starts a new run
+	publish(models.FleetLiveStats{CPUPercent: 3})
+	fourth, unsubscribeFourth := hub.subscribe(run)
+	defer unsubscribeFourth()
+	<-publishers
+	if n := atomic.LoadInt32(&runs); n != 2 {
+		t.Errorf("Expected a new sampler, got %d runs", n)
+	}
+	select {
+	case sample := <-fourth:
+		t.Errorf("Expected no sample of the stopped run, got %+v", sample)
+	default:
+	}
+}
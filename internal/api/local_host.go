@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/container-census/container-census/internal/migration"
+	"github.com/gorilla/mux"
+)
+
+// handleAddLocalHost adds the local Docker daemon as a host after checking that its socket is
+// reachable, e.g. after the local host created on first run was deleted
+func (s *Server) handleAddLocalHost(w http.ResponseWriter, r *http.Request) {
+	host := migration.NewLocalHost()
+
+	hosts, err := s.db.GetHosts()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get hosts: "+err.Error())
+		return
+	}
+	for _, existing := range hosts {
+		if existing.Address == host.Address {
+			respondError(w, http.StatusConflict, "The local Docker daemon is already added as host "+existing.Name)
+			return
+		}
+		if existing.Name == host.Name {
+			respondError(w, http.StatusConflict, "A host named "+host.Name+" already exists; rename it first")
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := s.scanner.VerifyConnection(ctx, host.Address); err != nil {
+		respondError(w, http.StatusBadGateway, "Failed to connect to the local Docker daemon: "+err.Error())
+		return
+	}
+
+	host.AgentStatus = "online"
+	host.LastSeen = time.Now()
+	id, err := s.db.AddHost(*host)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to add host: "+err.Error())
+		return
+	}
+	s.cache.Invalidate()
+
+	host.ID = id
+	respondJSON(w, http.StatusCreated, host)
+}
+
+// handleRenameHost renames a host; its containers and stats follow it
+func (s *Server) handleRenameHost(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid host ID")
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		respondError(w, http.StatusBadRequest, "Name is required")
+		return
+	}
+
+	if _, err := s.db.GetHost(id); err != nil {
+		respondError(w, http.StatusNotFound, "Host not found")
+		return
+	}
+	if err := s.db.RenameHost(id, name); err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			respondError(w, http.StatusConflict, "A host with this name already exists")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to rename host: "+err.Error())
+		return
+	}
+	s.cache.Invalidate()
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Host renamed successfully"})
+}
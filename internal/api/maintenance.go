@@ -0,0 +1,44 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// defaultOrphanStaleDays is how long image-container mappings are kept after their container
+// was last seen
+const defaultOrphanStaleDays = 30
+
+// handleGetOrphans reports the rows that a cleanup would delete, without deleting them
+func (s *Server) handleGetOrphans(w http.ResponseWriter, r *http.Request) {
+	s.collectOrphans(w, r, true)
+}
+
+// handleCleanupOrphans deletes rows left behind by deleted hosts and containers
+func (s *Server) handleCleanupOrphans(w http.ResponseWriter, r *http.Request) {
+	s.collectOrphans(w, r, false)
+}
+
+func (s *Server) collectOrphans(w http.ResponseWriter, r *http.Request, dryRun bool) {
+	staleDays := defaultOrphanStaleDays
+	if v := r.URL.Query().Get("stale_days"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 1 {
+			respondError(w, http.StatusBadRequest, "stale_days must be a positive number")
+			return
+		}
+		staleDays = days
+	}
+
+	report, err := s.db.CollectOrphans(staleDays, dryRun)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to collect orphaned data: "+err.Error())
+		return
+	}
+	if !dryRun && report.Total > 0 {
+		log.Printf("Removed %d orphaned rows: %v", report.Total, report.Orphans)
+		s.cache.Invalidate()
+	}
+	respondJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// maxMCPRequestSize caps the body of an MCP request
+const maxMCPRequestSize = 1 << 20
+
+// handleMCP answers Model Context Protocol requests (streamable HTTP transport, JSON responses only).
+// Assistants authenticate like any other API client, e.g. with Basic Auth.
+func (s *Server) handleMCP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		// No server-initiated messages, so there is no event stream to open
+		w.Header().Set("Allow", "POST")
+		respondError(w, http.StatusMethodNotAllowed, "This MCP server only accepts POST requests")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxMCPRequestSize))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	resp := s.mcpServer.Handle(r.Context(), body)
+	if resp == nil {
+		// Notifications are acknowledged without a body
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
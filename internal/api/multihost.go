@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// hostRequestTimeout bounds the time a single host may take in a multi-host request, so one
+// unreachable host doesn't hold up the others
+const hostRequestTimeout = 20 * time.Second
+
+// forEachHost calls fn for every host concurrently and reports how each one answered. Results are
+// in the same order as hosts; a failing host is reported instead of failing the whole request.
+func forEachHost(ctx context.Context, hosts []models.Host, fn func(ctx context.Context, i int, host models.Host) error) []models.HostResult {
+	results := make([]models.HostResult, len(hosts))
+
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		go func(i int, host models.Host) {
+			defer wg.Done()
+
+			hostCtx, cancel := context.WithTimeout(ctx, hostRequestTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := fn(hostCtx, i, host)
+
+			results[i] = models.HostResult{
+				HostID:     host.ID,
+				HostName:   host.Name,
+				Status:     models.HostResultOK,
+				DurationMS: time.Since(start).Milliseconds(),
+			}
+			if err != nil {
+				results[i].Status = models.HostResultError
+				results[i].Error = err.Error()
+			}
+		}(i, host)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// enabledHosts returns the hosts that are enabled
+func enabledHosts(hosts []models.Host) []models.Host {
+	enabled := make([]models.Host, 0, len(hosts))
+	for _, host := range hosts {
+		if host.Enabled {
+			enabled = append(enabled, host)
+		}
+	}
+	return enabled
+}
+
+// dockerHosts returns the hosts that run Docker
+func dockerHosts(hosts []models.Host) []models.Host {
+	docker := make([]models.Host, 0, len(hosts))
+	for _, host := range hosts {
+		if host.IsDocker() {
+			docker = append(docker, host)
+		}
+	}
+	return docker
+}
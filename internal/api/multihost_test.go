@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestForEachHost(t *testing.T) {
+	hosts := []models.Host{{ID: 1, Name: "nas"}, {ID: 2, Name: "pi"}, {ID: 3, Name: "vps"}}
+
+	counts := make([]int, len(hosts))
+	results := forEachHost(context.Background(), hosts, func(ctx context.Context, i int, host models.Host) error {
+		if host.Name == "pi" {
+			return errors.New("connection refused")
+		}
+		counts[i] = int(host.ID) * 10
+		return nil
+	})
+
+	if len(results) != 3 {
+		t.Fatalf("Expected a result per host, got %+v", results)
+	}
+	for i, result := range results {
+		if result.HostID != hosts[i].ID || result.HostName != hosts[i].Name {
+			t.Errorf("Expected results in host order, got %+v at %d", result, i)
+		}
+	}
+	if results[1].Status != models.HostResultError || results[1].Error != "connection refused" {
+		t.Errorf("Expected the failing host to be reported, got %+v", results[1])
+	}
+	if results[0].Status != models.HostResultOK || results[2].Status != models.HostResultOK || counts[2] != 30 {
+		t.Errorf("Expected the other hosts to succeed, got %+v (%v)", results, counts)
+	}
+}
+
+func TestForEachHostDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	results := forEachHost(ctx, []models.Host{{ID: 1, Name: "slow"}}, func(ctx context.Context, i int, host models.Host) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if results[0].Status != models.HostResultError || results[0].Error != context.DeadlineExceeded.Error() {
+		t.Errorf("Expected the slow host to time out, got %+v", results[0])
+	}
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// noteKey identifies the notes of a container, or of a host with an empty container name
+type noteKey struct {
+	hostID        int64
+	containerName string
+}
+
+// allNotes returns every stored note by host and container name. Errors are logged and treated
+// as no notes, like attachPins.
+func (s *Server) allNotes() map[noteKey]models.Notes {
+	all, err := s.db.GetAllNotes()
+	if err != nil {
+		log.Printf("Failed to get notes: %v", err)
+		return nil
+	}
+	notes := make(map[noteKey]models.Notes, len(all))
+	for _, n := range all {
+		notes[noteKey{n.HostID, n.ContainerName}] = n.Notes
+	}
+	return notes
+}
+
+// attachNotes sets the notes on containers that have some
+func (s *Server) attachNotes(containers []models.Container) {
+	notes := s.allNotes()
+	for i := range containers {
+		if n, ok := notes[noteKey{containers[i].HostID, containers[i].Name}]; ok {
+			containers[i].Notes = &n
+		}
+	}
+}
+
+// attachHostNotes sets the notes on hosts that have some
+func (s *Server) attachHostNotes(hosts []models.Host) {
+	notes := s.allNotes()
+	for i := range hosts {
+		if n, ok := notes[noteKey{hosts[i].ID, ""}]; ok {
+			hosts[i].Notes = &n
+		}
+	}
+}
+
+// handleGetNotes lists the notes of the visible hosts and their containers. Query: host_id.
+func (s *Server) handleGetNotes(w http.ResponseWriter, r *http.Request) {
+	hostIDs, ok := s.queryHostIDs(w, r)
+	if !ok {
+		return
+	}
+	var visible map[int64]bool // nil allows all hosts
+	if hostIDs != nil {
+		visible = make(map[int64]bool, len(hostIDs))
+		for _, id := range hostIDs {
+			visible[id] = true
+		}
+	}
+
+	all, err := s.db.GetAllNotes()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get notes: "+err.Error())
+		return
+	}
+	notes := make([]models.ContainerNotes, 0, len(all))
+	for _, n := range all {
+		if visible == nil || visible[n.HostID] {
+			notes = append(notes, n)
+		}
+	}
+	respondJSON(w, http.StatusOK, notes)
+}
+
+// handleGetContainerNotes returns a container's notes; empty notes when it has none
+func (s *Server) handleGetContainerNotes(w http.ResponseWriter, r *http.Request) {
+	container, ok := s.pinTarget(w, r)
+	if !ok {
+		return
+	}
+	s.respondNotes(w, container.HostID, container.Name)
+}
+
+// handleUpdateContainerNotes replaces a container's notes ({"text", "fields"}); empty notes
+// delete them
+func (s *Server) handleUpdateContainerNotes(w http.ResponseWriter, r *http.Request) {
+	container, ok := s.pinTarget(w, r)
+	if !ok {
+		return
+	}
+	s.saveNotes(w, r, container.HostID, container.Name)
+}
+
+// handleDeleteContainerNotes removes a container's notes
+func (s *Server) handleDeleteContainerNotes(w http.ResponseWriter, r *http.Request) {
+	container, ok := s.pinTarget(w, r)
+	if !ok {
+		return
+	}
+	if err := s.db.DeleteNotes(container.HostID, container.Name); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete notes: "+err.Error())
+		return
+	}
+	s.cache.Invalidate()
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Notes deleted"})
+}
+
+// handleGetHostNotes returns a host's own notes; empty notes when it has none
+func (s *Server) handleGetHostNotes(w http.ResponseWriter, r *http.Request) {
+	hostID, ok := s.notesHost(w, r)
+	if !ok {
+		return
+	}
+	s.respondNotes(w, hostID, "")
+}
+
+// handleUpdateHostNotes replaces a host's own notes; empty notes delete them
+func (s *Server) handleUpdateHostNotes(w http.ResponseWriter, r *http.Request) {
+	hostID, ok := s.notesHost(w, r)
+	if !ok {
+		return
+	}
+	s.saveNotes(w, r, hostID, "")
+}
+
+// handleDeleteHostNotes removes a host's own notes
+func (s *Server) handleDeleteHostNotes(w http.ResponseWriter, r *http.Request) {
+	hostID, ok := s.notesHost(w, r)
+	if !ok {
+		return
+	}
+	if err := s.db.DeleteNotes(hostID, ""); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete notes: "+err.Error())
+		return
+	}
+	s.cache.Invalidate()
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Notes deleted"})
+}
+
+// notesHost returns the existing host named by the id route variable
+func (s *Server) notesHost(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	hostID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid host ID")
+		return 0, false
+	}
+	if _, err := s.db.GetHost(hostID); err != nil {
+		respondError(w, http.StatusNotFound, "Host not found")
+		return 0, false
+	}
+	return hostID, true
+}
+
+func (s *Server) respondNotes(w http.ResponseWriter, hostID int64, containerName string) {
+	notes, err := s.db.GetNotes(hostID, containerName)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get notes: "+err.Error())
+		return
+	}
+	if notes == nil {
+		notes = &models.Notes{}
+	}
+	respondJSON(w, http.StatusOK, notes)
+}
+
+func (s *Server) saveNotes(w http.ResponseWriter, r *http.Request, hostID int64, containerName string) {
+	var notes models.Notes
+	if err := json.NewDecoder(r.Body).Decode(&notes); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	if err := notes.Normalize(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	notes.UpdatedAt = time.Now()
+	notes.UpdatedBy = identity(r).Username
+
+	if err := s.db.SaveNotes(hostID, containerName, notes); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save notes: "+err.Error())
+		return
+	}
+	s.cache.Invalidate()
+	respondJSON(w, http.StatusOK, notes)
+}
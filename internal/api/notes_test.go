@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/auth"
+	"github.com/container-census/container-census/internal/models"
+	"github.com/gorilla/mux"
+)
+
+func TestContainerNotes(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: "unix:///var/run/docker.sock", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	if err := db.SaveContainers([]models.Container{
+		{ID: "pg123", Name: "postgres", Image: "postgres:16", State: "running", HostID: hostID, HostName: "nas", ScannedAt: time.Now()},
+	}); err != nil {
+		t.Fatalf("Failed to save containers: %v", err)
+	}
+
+	call := func(handler http.HandlerFunc, method, body string, vars map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/notes", strings.NewReader(body))
+		req = mux.SetURLVars(req, vars)
+		req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{Username: "alice"}))
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+	containerVars := map[string]string{"host_id": itoa(hostID), "container_id": "postgres"}
+
+	w := call(server.handleUpdateContainerNotes, http.MethodPut,
+		`{"text":"  Don't restart during backups  ","fields":{"owner":"alice","credentials":"vault:kv/pg","empty":" "}}`, containerVars)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var notes models.Notes
+	if err := json.Unmarshal(w.Body.Bytes(), &notes); err != nil {
+		t.Fatalf("Failed to decode notes: %v", err)
+	}
+	if notes.Text != "Don't restart during backups" || len(notes.Fields) != 2 || notes.UpdatedBy != "alice" {
+		t.Errorf("Unexpected notes %+v", notes)
+	}
+
+	if w := call(server.handleUpdateContainerNotes, http.MethodPut, `{"fields":{"":"value"}}`, containerVars); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unnamed field, got %d", w.Code)
+	}
+	if w := call(server.handleUpdateContainerNotes, http.MethodPut, `{"text":"x"}`,
+		map[string]string{"host_id": itoa(hostID), "container_id": "missing"}); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown container, got %d", w.Code)
+	}
+	if w := call(server.handleUpdateHostNotes, http.MethodPut, `{"text":"Rack 2, UPS backed"}`,
+		map[string]string{"id": itoa(hostID)}); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for host notes, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The notes are attached to the container list and listed together
+	containers, err := db.GetLatestContainers()
+	if err != nil {
+		t.Fatalf("Failed to get containers: %v", err)
+	}
+	server.attachNotes(containers)
+	if len(containers) != 1 || containers[0].Notes == nil || containers[0].Notes.Fields["owner"] != "alice" {
+		t.Errorf("Expected the notes on the container, got %+v", containers)
+	}
+
+	w = call(server.handleGetNotes, http.MethodGet, "", nil)
+	var all []models.ContainerNotes
+	if err := json.Unmarshal(w.Body.Bytes(), &all); err != nil {
+		t.Fatalf("Failed to decode notes: %v", err)
+	}
+	if len(all) != 2 || all[0].ContainerName != "" || all[0].Text != "Rack 2, UPS backed" || all[1].ContainerName != "postgres" || all[1].HostName != "nas" {
+		t.Errorf("Unexpected notes list %+v", all)
+	}
+
+	// Clearing the text and fields deletes the notes
+	if w := call(server.handleUpdateContainerNotes, http.MethodPut, `{"text":""}`, containerVars); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+	if stored, err := db.GetNotes(hostID, "postgres"); err != nil || stored != nil {
+		t.Errorf("Expected the notes to be deleted, got %+v, %v", stored, err)
+	}
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// handleGetNotificationStats returns the sent/failed/suppressed/rate-limited counters per rule,
+// per channel and over time. ?hours= sets the period (default 168, at most the 90 days the
+// counters are kept), ?interval=hour|day the series resolution (default hour up to 3 days).
+// Tenant users only see their own rules and channels.
+func (s *Server) handleGetNotificationStats(w http.ResponseWriter, r *http.Request) {
+	hours := 168
+	if v := r.URL.Query().Get("hours"); v != "" {
+		h, err := strconv.Atoi(v)
+		if err != nil || h <= 0 {
+			respondError(w, http.StatusBadRequest, "Invalid hours")
+			return
+		}
+		hours = h
+	}
+	if max := models.NotificationStatsRetentionDays * 24; hours > max {
+		hours = max
+	}
+
+	interval := r.URL.Query().Get("interval")
+	switch interval {
+	case "":
+		interval = "hour"
+		if hours > 72 {
+			interval = "day"
+		}
+	case "hour", "day":
+	default:
+		respondError(w, http.StatusBadRequest, "interval must be hour or day")
+		return
+	}
+
+	until := time.Now().UTC()
+	since := until.Add(-time.Duration(hours) * time.Hour).Truncate(time.Hour)
+	counts, err := s.db.GetNotificationStatCounts(since)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get notification stats: "+err.Error())
+		return
+	}
+	rules, err := s.db.GetNotificationRules(false)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get notification rules: "+err.Error())
+		return
+	}
+	channels, err := s.db.GetNotificationChannels()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get notification channels: "+err.Error())
+		return
+	}
+
+	// Tenant users only see the counters of their rules
+	if id := identity(r); !id.IsAdmin() {
+		owned := make(map[int64]bool)
+		for _, rule := range rules {
+			if rule.TenantID == id.TenantID {
+				owned[rule.ID] = true
+			}
+		}
+		filtered := counts[:0]
+		for _, c := range counts {
+			if owned[c.RuleID] {
+				filtered = append(filtered, c)
+			}
+		}
+		counts = filtered
+	}
+
+	respondJSON(w, http.StatusOK, buildNotificationStats(counts, rules, channels, since, until, interval))
+}
+
+// buildNotificationStats sums hourly counters into totals, per-rule and per-channel counts
+// (busiest first) and a gap-free series of the interval
+func buildNotificationStats(counts []models.NotificationStatCount, rules []models.NotificationRule, channels []models.NotificationChannel, since, until time.Time, interval string) models.NotificationStats {
+	step := time.Hour
+	if interval == "day" {
+		step = 24 * time.Hour
+	}
+	bucketOf := func(t time.Time) time.Time {
+		return t.UTC().Truncate(step)
+	}
+
+	stats := models.NotificationStats{
+		Since:    since,
+		Until:    until,
+		Interval: interval,
+		Rules:    []models.NotificationRuleStats{},
+		Channels: []models.NotificationChannelStats{},
+		Series:   []models.NotificationStatsPoint{},
+	}
+
+	series := make(map[time.Time]*models.NotificationCounts)
+	for t := bucketOf(since); !t.After(until); t = t.Add(step) {
+		stats.Series = append(stats.Series, models.NotificationStatsPoint{Time: t})
+	}
+	for i := range stats.Series {
+		series[stats.Series[i].Time] = &stats.Series[i].NotificationCounts
+	}
+
+	byRule := make(map[int64]*models.NotificationRuleStats)
+	byChannel := make(map[int64]*models.NotificationChannelStats)
+	for _, c := range counts {
+		stats.Totals.Add(c.Outcome, c.Count)
+		if point, ok := series[bucketOf(c.Bucket)]; ok {
+			point.Add(c.Outcome, c.Count)
+		}
+
+		rule, ok := byRule[c.RuleID]
+		if !ok {
+			rule = &models.NotificationRuleStats{RuleID: c.RuleID}
+			byRule[c.RuleID] = rule
+		}
+		rule.Add(c.Outcome, c.Count)
+
+		channel, ok := byChannel[c.ChannelID]
+		if !ok {
+			channel = &models.NotificationChannelStats{ChannelID: c.ChannelID}
+			byChannel[c.ChannelID] = channel
+		}
+		channel.Add(c.Outcome, c.Count)
+	}
+
+	for _, rule := range rules {
+		if stat, ok := byRule[rule.ID]; ok {
+			stat.RuleName = rule.Name
+		}
+	}
+	for _, ch := range channels {
+		if stat, ok := byChannel[ch.ID]; ok {
+			stat.ChannelName = ch.Name
+			stat.ChannelType = ch.Type
+		}
+	}
+
+	for _, rule := range byRule {
+		stats.Rules = append(stats.Rules, *rule)
+	}
+	sort.Slice(stats.Rules, func(i, j int) bool {
+		if stats.Rules[i].Total != stats.Rules[j].Total {
+			return stats.Rules[i].Total > stats.Rules[j].Total
+		}
+		return stats.Rules[i].RuleID < stats.Rules[j].RuleID
+	})
+	for _, channel := range byChannel {
+		channel.FailureRate = channel.NotificationCounts.FailureRate()
+		stats.Channels = append(stats.Channels, *channel)
+	}
+	sort.Slice(stats.Channels, func(i, j int) bool {
+		if stats.Channels[i].Total != stats.Channels[j].Total {
+			return stats.Channels[i].Total > stats.Channels[j].Total
+		}
+		return stats.Channels[i].ChannelID < stats.Channels[j].ChannelID
+	})
+
+	return stats
+}
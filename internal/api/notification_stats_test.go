@@ -0,0 +1,89 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/auth"
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestGetNotificationStats(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	channel := &models.NotificationChannel{Name: "ntfy", Type: models.ChannelTypeInApp, Config: map[string]interface{}{}, Enabled: true}
+	if err := db.SaveNotificationChannel(channel); err != nil {
+		t.Fatalf("Failed to save channel: %v", err)
+	}
+	noisy := &models.NotificationRule{Name: "noisy", Enabled: true, EventTypes: []string{"state_change"}, ChannelIDs: []int64{channel.ID}}
+	quiet := &models.NotificationRule{Name: "quiet", Enabled: true, EventTypes: []string{"new_image"}, ChannelIDs: []int64{channel.ID}, TenantID: 7}
+	for _, rule := range []*models.NotificationRule{noisy, quiet} {
+		if err := db.SaveNotificationRule(rule); err != nil {
+			t.Fatalf("Failed to save rule: %v", err)
+		}
+	}
+
+	now := time.Now()
+	record := func(ruleID int64, outcome string, n int) {
+		for i := 0; i < n; i++ {
+			if err := db.RecordNotificationOutcome(ruleID, channel.ID, outcome, now); err != nil {
+				t.Fatalf("RecordNotificationOutcome failed: %v", err)
+			}
+		}
+	}
+	record(noisy.ID, models.NotificationOutcomeSent, 3)
+	record(noisy.ID, models.NotificationOutcomeFailed, 1)
+	record(noisy.ID, models.NotificationOutcomeRateLimited, 2)
+	record(quiet.ID, models.NotificationOutcomeSuppressed, 1)
+
+	get := func(id auth.Identity, query string) (int, models.NotificationStats) {
+		req := httptest.NewRequest("GET", "/api/notifications/stats"+query, nil)
+		req = req.WithContext(auth.WithIdentity(req.Context(), id))
+		rec := httptest.NewRecorder()
+		server.handleGetNotificationStats(rec, req)
+		var stats models.NotificationStats
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &stats); err != nil {
+				t.Fatalf("Failed to decode stats: %v", err)
+			}
+		}
+		return rec.Code, stats
+	}
+
+	code, stats := get(auth.Identity{Username: "admin"}, "?hours=24")
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if stats.Totals.Total != 7 || stats.Totals.Sent != 3 || stats.Totals.RateLimited != 2 || stats.Totals.Suppressed != 1 {
+		t.Errorf("Unexpected totals %+v", stats.Totals)
+	}
+	if len(stats.Rules) != 2 || stats.Rules[0].RuleName != "noisy" || stats.Rules[0].Total != 6 {
+		t.Errorf("Expected the noisy rule first, got %+v", stats.Rules)
+	}
+	if len(stats.Channels) != 1 || stats.Channels[0].ChannelName != "ntfy" || stats.Channels[0].FailureRate != 25 {
+		t.Errorf("Expected a 25%% failure rate on the channel, got %+v", stats.Channels)
+	}
+	if stats.Interval != "hour" || len(stats.Series) < 24 {
+		t.Errorf("Expected an hourly series over the day, got %s with %d points", stats.Interval, len(stats.Series))
+	}
+	seriesTotal := 0
+	for _, point := range stats.Series {
+		seriesTotal += point.Total
+	}
+	if seriesTotal != 7 {
+		t.Errorf("Expected the series to add up to 7, got %d", seriesTotal)
+	}
+
+	// Tenant users only see their own rules
+	_, stats = get(auth.Identity{Username: "kid", TenantID: 7}, "")
+	if stats.Interval != "day" || len(stats.Rules) != 1 || stats.Rules[0].RuleName != "quiet" || stats.Totals.Total != 1 {
+		t.Errorf("Expected only the tenant's rule over a week, got %+v", stats)
+	}
+
+	if code, _ := get(auth.Identity{Username: "admin"}, "?interval=week"); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown interval, got %d", code)
+	}
+}
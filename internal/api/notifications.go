@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
@@ -18,6 +19,15 @@ func (s *Server) handleGetNotificationChannels(w http.ResponseWriter, r *http.Re
 		respondError(w, http.StatusInternalServerError, "Failed to get notification channels: "+err.Error())
 		return
 	}
+	if id := identity(r); !id.IsAdmin() {
+		owned := make([]models.NotificationChannel, 0, len(channels))
+		for _, ch := range channels {
+			if ch.TenantID == id.TenantID {
+				owned = append(owned, ch)
+			}
+		}
+		channels = owned
+	}
 
 	respondJSON(w, http.StatusOK, channels)
 }
@@ -48,6 +58,12 @@ func (s *Server) handleCreateNotificationChannel(w http.ResponseWriter, r *http.
 		}
 	}
 
+	if err := validateChannelRouting(channel); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid channel routing: "+err.Error())
+		return
+	}
+
+	channel.TenantID = identity(r).TenantID
 	if err := s.db.SaveNotificationChannel(&channel); err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to create notification channel: "+err.Error())
 		return
@@ -68,6 +84,10 @@ func (s *Server) handleUpdateNotificationChannel(w http.ResponseWriter, r *http.
 		respondError(w, http.StatusBadRequest, "Invalid channel ID")
 		return
 	}
+	if !s.ownsChannel(r, id) {
+		respondError(w, http.StatusNotFound, "Channel not found")
+		return
+	}
 
 	var channel models.NotificationChannel
 	if err := json.NewDecoder(r.Body).Decode(&channel); err != nil {
@@ -77,6 +97,11 @@ func (s *Server) handleUpdateNotificationChannel(w http.ResponseWriter, r *http.
 
 	channel.ID = id
 
+	if err := validateChannelRouting(channel); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid channel routing: "+err.Error())
+		return
+	}
+
 	if err := s.db.SaveNotificationChannel(&channel); err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to update notification channel: "+err.Error())
 		return
@@ -97,6 +122,10 @@ func (s *Server) handleDeleteNotificationChannel(w http.ResponseWriter, r *http.
 		respondError(w, http.StatusBadRequest, "Invalid channel ID")
 		return
 	}
+	if !s.ownsChannel(r, id) {
+		respondError(w, http.StatusNotFound, "Channel not found")
+		return
+	}
 
 	if err := s.db.DeleteNotificationChannel(id); err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to delete notification channel: "+err.Error())
@@ -121,7 +150,7 @@ func (s *Server) handleTestNotificationChannel(w http.ResponseWriter, r *http.Re
 
 	// Get the channel
 	channel, err := s.db.GetNotificationChannel(id)
-	if err != nil {
+	if err != nil || !s.ownsChannel(r, id) {
 		respondError(w, http.StatusNotFound, "Channel not found")
 		return
 	}
@@ -144,6 +173,68 @@ func (s *Server) handleTestNotificationChannel(w http.ResponseWriter, r *http.Re
 	}
 }
 
+// ownsChannel reports whether a request may use a channel. Tenant users only have their own
+// channels; the administrator may change every channel.
+func (s *Server) ownsChannel(r *http.Request, channelID int64) bool {
+	id := identity(r)
+	if id.IsAdmin() {
+		return true
+	}
+	channel, err := s.db.GetNotificationChannel(channelID)
+	return err == nil && channel.TenantID == id.TenantID
+}
+
+// ownsRule reports whether a request may change a rule, like ownsChannel
+func (s *Server) ownsRule(r *http.Request, ruleID int64) (bool, error) {
+	id := identity(r)
+	if id.IsAdmin() {
+		return true, nil
+	}
+	rules, err := s.db.GetNotificationRules(false)
+	if err != nil {
+		return false, err
+	}
+	for _, rule := range rules {
+		if rule.ID == ruleID {
+			return rule.TenantID == id.TenantID, nil
+		}
+	}
+	return false, nil
+}
+
+// checkRuleScope makes sure a tenant user's rule only uses the tenant's channels and hosts
+func (s *Server) checkRuleScope(r *http.Request, rule models.NotificationRule) error {
+	id := identity(r)
+	if id.IsAdmin() {
+		return nil
+	}
+	for _, channelID := range rule.ChannelIDs {
+		if !s.ownsChannel(r, channelID) {
+			return fmt.Errorf("channel %d not found", channelID)
+		}
+	}
+	if rule.HostID != nil {
+		host, err := s.db.GetHost(*rule.HostID)
+		if err != nil || host.TenantID != id.TenantID {
+			return fmt.Errorf("host %d not found", *rule.HostID)
+		}
+	}
+	return nil
+}
+
+// validateChannelRouting checks a channel's severity threshold and quiet hours
+func validateChannelRouting(channel models.NotificationChannel) error {
+	if channel.MinSeverity != "" && !models.ValidSeverity(channel.MinSeverity) {
+		return fmt.Errorf("min_severity must be info, warning, or critical")
+	}
+	if channel.QuietHours != nil {
+		if err := channel.QuietHours.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Notification Rule Handlers
 
 func (s *Server) handleGetNotificationRules(w http.ResponseWriter, r *http.Request) {
@@ -152,6 +243,15 @@ func (s *Server) handleGetNotificationRules(w http.ResponseWriter, r *http.Reque
 		respondError(w, http.StatusInternalServerError, "Failed to get notification rules: "+err.Error())
 		return
 	}
+	if id := identity(r); !id.IsAdmin() {
+		owned := make([]models.NotificationRule, 0, len(rules))
+		for _, rule := range rules {
+			if rule.TenantID == id.TenantID {
+				owned = append(owned, rule)
+			}
+		}
+		rules = owned
+	}
 
 	respondJSON(w, http.StatusOK, rules)
 }
@@ -175,6 +275,15 @@ func (s *Server) handleCreateNotificationRule(w http.ResponseWriter, r *http.Req
 		models.EventTypeContainerStopped:      true,
 		models.EventTypeContainerPaused:       true,
 		models.EventTypeContainerResumed:      true,
+		models.EventTypePrivilegedContainer:   true,
+		models.EventTypeBackupOverdue:         true,
+		models.EventTypeScriptAlert:           true,
+		models.EventTypeOOMKill:               true,
+		models.EventTypeDaemonError:           true,
+		models.EventTypeRestartPolicy:         true,
+		models.EventTypeCertExpiring:          true,
+		models.EventTypeDNSFailure:            true,
+		models.EventTypeNewVulnerability:      true,
 	}
 
 	for _, et := range rule.EventTypes {
@@ -192,6 +301,11 @@ func (s *Server) handleCreateNotificationRule(w http.ResponseWriter, r *http.Req
 		rule.CooldownSeconds = 300
 	}
 
+	if err := s.checkRuleScope(r, rule); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid rule: "+err.Error())
+		return
+	}
+	rule.TenantID = identity(r).TenantID
 	if err := s.db.SaveNotificationRule(&rule); err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to create notification rule: "+err.Error())
 		return
@@ -216,6 +330,14 @@ func (s *Server) handleUpdateNotificationRule(w http.ResponseWriter, r *http.Req
 
 	rule.ID = id
 
+	if owned, err := s.ownsRule(r, id); err != nil || !owned {
+		respondError(w, http.StatusNotFound, "Rule not found")
+		return
+	}
+	if err := s.checkRuleScope(r, rule); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid rule: "+err.Error())
+		return
+	}
 	if err := s.db.SaveNotificationRule(&rule); err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to update notification rule: "+err.Error())
 		return
@@ -231,6 +353,10 @@ func (s *Server) handleDeleteNotificationRule(w http.ResponseWriter, r *http.Req
 		respondError(w, http.StatusBadRequest, "Invalid rule ID")
 		return
 	}
+	if owned, err := s.ownsRule(r, id); err != nil || !owned {
+		respondError(w, http.StatusNotFound, "Rule not found")
+		return
+	}
 
 	if err := s.db.DeleteNotificationRule(id); err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to delete notification rule: "+err.Error())
@@ -252,10 +378,13 @@ func (s *Server) handleGetNotificationLogs(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
-	unreadOnlyStr := r.URL.Query().Get("unread")
-	unreadOnly := unreadOnlyStr == "true"
+	filter, err := parseNotificationLogFilter(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	logs, err := s.db.GetNotificationLogs(limit, unreadOnly)
+	logs, err := s.db.QueryNotificationLogs(filter, limit)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to get notification logs: "+err.Error())
 		return
@@ -271,6 +400,12 @@ func (s *Server) handleMarkNotificationRead(w http.ResponseWriter, r *http.Reque
 		respondError(w, http.StatusBadRequest, "Invalid notification ID")
 		return
 	}
+	if tenantID := identity(r).TenantID; tenantID != 0 {
+		if owner, err := s.db.GetNotificationLogTenant(id); err != nil || owner != tenantID {
+			respondError(w, http.StatusNotFound, "Notification not found")
+			return
+		}
+	}
 
 	if err := s.db.MarkNotificationRead(id); err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to mark notification as read: "+err.Error())
@@ -281,6 +416,14 @@ func (s *Server) handleMarkNotificationRead(w http.ResponseWriter, r *http.Reque
 }
 
 func (s *Server) handleMarkAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	if tenantID := identity(r).TenantID; tenantID != 0 {
+		if _, err := s.db.MarkNotificationsRead(models.NotificationLogFilter{TenantID: tenantID}); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to mark all notifications as read: "+err.Error())
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]string{"message": "All notifications marked as read"})
+		return
+	}
 	if err := s.db.MarkAllNotificationsRead(); err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to mark all notifications as read: "+err.Error())
 		return
@@ -298,6 +441,154 @@ func (s *Server) handleClearNotifications(w http.ResponseWriter, r *http.Request
 	respondJSON(w, http.StatusOK, map[string]string{"message": "All notifications cleared"})
 }
 
+// handleGetNotificationGroups summarizes the notification log by container (by=container) or
+// event type (by=event_type), with unread counts per group. Takes the same filters as the log.
+func (s *Server) handleGetNotificationGroups(w http.ResponseWriter, r *http.Request) {
+	groupBy := r.URL.Query().Get("by")
+	if groupBy == "" {
+		groupBy = models.NotificationGroupByContainer
+	}
+	if groupBy != models.NotificationGroupByContainer && groupBy != models.NotificationGroupByEventType {
+		respondError(w, http.StatusBadRequest, "Invalid by parameter. Use: container or event_type")
+		return
+	}
+
+	filter, err := parseNotificationLogFilter(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	groups, err := s.db.GetNotificationGroups(groupBy, filter)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get notification groups: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, groups)
+}
+
+// handleBulkNotifications marks read or deletes every notification matching a filter.
+// Body: {"action": "read"|"delete", "event_type": ..., "host_id": ..., "container_name": ..., "unread_only": ...}
+func (s *Server) handleBulkNotifications(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Action string `json:"action"`
+		models.NotificationLogFilter
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	req.TenantID = identity(r).TenantID
+
+	var affected int64
+	var err error
+	switch req.Action {
+	case "read":
+		affected, err = s.db.MarkNotificationsRead(req.NotificationLogFilter)
+	case "delete":
+		affected, err = s.db.DeleteNotifications(req.NotificationLogFilter)
+	default:
+		respondError(w, http.StatusBadRequest, "Invalid action. Must be: read or delete")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update notifications: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"action":   req.Action,
+		"affected": affected,
+	})
+}
+
+// handleNotificationStream pushes every new notification log entry to the client as Server-Sent Events
+func (s *Server) handleNotificationStream(w http.ResponseWriter, r *http.Request) {
+	if s.notificationService == nil {
+		respondError(w, http.StatusServiceUnavailable, "Notification service not available")
+		return
+	}
+
+	updates, unsubscribe := s.notificationService.Subscribe()
+	defer unsubscribe()
+
+	// The stream outlives the server's write timeout
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	// Comments keep proxies from closing an idle stream
+	keepalive := time.NewTicker(30 * time.Second)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case entry := <-updates:
+			if !s.notificationVisible(r, entry) {
+				continue
+			}
+			data, err := json.Marshal(entry)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+		case <-keepalive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// notificationVisible reports whether a tenant user may see a notification, which is about one of
+// the tenant's hosts
+func (s *Server) notificationVisible(r *http.Request, entry models.NotificationLog) bool {
+	id := identity(r)
+	if id.IsAdmin() {
+		return true
+	}
+	if entry.HostID == nil {
+		return false
+	}
+	host, err := s.db.GetHost(*entry.HostID)
+	return err == nil && host.TenantID == id.TenantID
+}
+
+// parseNotificationLogFilter reads the event_type, host_id, container_name and unread query
+// parameters and limits tenant users to their tenant
+func parseNotificationLogFilter(r *http.Request) (models.NotificationLogFilter, error) {
+	query := r.URL.Query()
+	filter := models.NotificationLogFilter{
+		EventType:     query.Get("event_type"),
+		ContainerName: query.Get("container_name"),
+		UnreadOnly:    query.Get("unread") == "true",
+		TenantID:      identity(r).TenantID,
+	}
+	if hostIDStr := query.Get("host_id"); hostIDStr != "" {
+		hostID, err := strconv.ParseInt(hostIDStr, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("Invalid host_id parameter")
+		}
+		filter.HostID = hostID
+	}
+	return filter, nil
+}
+
 // Notification Silence Handlers
 
 func (s *Server) handleGetNotificationSilences(w http.ResponseWriter, r *http.Request) {
@@ -392,9 +683,39 @@ func (s *Server) handleDeleteNotificationSilence(w http.ResponseWriter, r *http.
 	respondJSON(w, http.StatusOK, map[string]string{"message": "Silence deleted successfully"})
 }
 
+// Seasonal Baseline Handler
+
+// handleGetSeasonalBaselines returns the day-of-week/hour-of-day baselines used for anomaly detection
+func (s *Server) handleGetSeasonalBaselines(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	hostID, err := strconv.ParseInt(vars["host_id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid host ID")
+		return
+	}
+
+	baselines, err := s.db.GetSeasonalBaselines(vars["container_name"], hostID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get seasonal baselines: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, baselines)
+}
+
 // Notification Status Handler
 
 func (s *Server) handleGetNotificationStatus(w http.ResponseWriter, r *http.Request) {
+	if tenantID := identity(r).TenantID; tenantID != 0 {
+		status, err := s.tenantNotificationStatus(tenantID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to get notification status: "+err.Error())
+			return
+		}
+		respondJSON(w, http.StatusOK, status)
+		return
+	}
+
 	status, err := s.db.GetNotificationStatus()
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to get notification status: "+err.Error())
@@ -410,3 +731,40 @@ func (s *Server) handleGetNotificationStatus(w http.ResponseWriter, r *http.Requ
 
 	respondJSON(w, http.StatusOK, status)
 }
+
+// tenantNotificationStatus counts a tenant's unread notifications, channels and rules. Rate limits
+// and silences are server-wide and left out.
+func (s *Server) tenantNotificationStatus(tenantID int64) (*models.NotificationStatus, error) {
+	var status models.NotificationStatus
+	var err error
+	if status.UnreadCount, err = s.db.CountNotifications(models.NotificationLogFilter{TenantID: tenantID, UnreadOnly: true}); err != nil {
+		return nil, err
+	}
+
+	channels, err := s.db.GetNotificationChannels()
+	if err != nil {
+		return nil, err
+	}
+	for _, ch := range channels {
+		if ch.TenantID == tenantID {
+			status.TotalChannels++
+			if ch.Enabled {
+				status.EnabledChannels++
+			}
+		}
+	}
+
+	rules, err := s.db.GetNotificationRules(false)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		if rule.TenantID == tenantID {
+			status.TotalRules++
+			if rule.Enabled {
+				status.EnabledRules++
+			}
+		}
+	}
+	return &status, nil
+}
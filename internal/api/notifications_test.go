@@ -352,6 +352,58 @@ func TestCreateSilenceValidation(t *testing.T) {
 }
 
 // Helper function to check if string contains substring
+// TestBulkNotifications tests filtered bulk read and delete, and grouping parameter validation
+func TestBulkNotifications(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "test-host", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	for _, name := range []string{"web", "web", "db"} {
+		if err := db.SaveNotificationLog(models.NotificationLog{
+			EventType: models.EventTypeContainerStopped, ContainerName: name, HostID: &hostID,
+			Message: "stopped", SentAt: time.Now(), Success: true,
+		}); err != nil {
+			t.Fatalf("Failed to save log: %v", err)
+		}
+	}
+
+	bulk := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/notifications/logs/bulk", bytes.NewBufferString(body))
+		rec := httptest.NewRecorder()
+		server.handleBulkNotifications(rec, req)
+		return rec
+	}
+
+	rec := bulk(`{"action": "read", "container_name": "web"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &result)
+	if result["affected"] != float64(2) {
+		t.Errorf("Expected 2 notifications marked read, got %v", result["affected"])
+	}
+
+	if rec := bulk(`{"action": "archive"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for unknown action, got %d", rec.Code)
+	}
+
+	rec = bulk(`{"action": "delete", "unread_only": true}`)
+	json.Unmarshal(rec.Body.Bytes(), &result)
+	if result["affected"] != float64(1) {
+		t.Errorf("Expected the unread notification deleted, got %v", result["affected"])
+	}
+
+	req := httptest.NewRequest("GET", "/api/notifications/groups?by=rule", nil)
+	rec = httptest.NewRecorder()
+	server.handleGetNotificationGroups(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for unknown grouping, got %d", rec.Code)
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) && (s[:len(substr)] == substr || s[len(s)-len(substr):] == substr || containsMiddle(s, substr)))
 }
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// beginOperation marks an action on a container as running. It answers 409 Conflict, naming the
+// running action, when another action on the same container hasn't finished.
+func (s *Server) beginOperation(w http.ResponseWriter, hostID int64, containerID, action string) (func(), bool) {
+	name := s.containerName(hostID, containerID)
+	done, running, ok := s.operations.Begin(hostID, name, action)
+	if !ok {
+		respondJSON(w, http.StatusConflict, map[string]interface{}{
+			"error":     busyMessage(running),
+			"operation": running,
+		})
+		return nil, false
+	}
+	return done, true
+}
+
+// busyMessage explains which action a container is busy with
+func busyMessage(op models.ContainerOperation) string {
+	return fmt.Sprintf("Container %s is busy: %s in progress since %s", op.ContainerName, op.Action, op.StartedAt.Format(time.Kitchen))
+}
+
+// containerName returns the name of the container with the given ID (full or short) or name on a
+// host. Operations are tracked by name because updates give containers a new ID; unknown
+// containers fall back to the identifier they were requested with.
+func (s *Server) containerName(hostID int64, containerID string) string {
+	containers, err := s.db.GetContainersByHost(hostID)
+	if err != nil {
+		log.Printf("Failed to get containers for host %d: %v", hostID, err)
+		return containerID
+	}
+	for _, c := range containers {
+		if c.Name == containerID || c.ID == containerID ||
+			(len(containerID) >= 12 && (strings.HasPrefix(c.ID, containerID) || strings.HasPrefix(containerID, c.ID))) {
+			return c.Name
+		}
+	}
+	return containerID
+}
+
+// attachOperations sets the action in progress on containers that are busy
+func (s *Server) attachOperations(containers []models.Container) {
+	for i := range containers {
+		if op, ok := s.operations.Get(containers[i].HostID, containers[i].Name); ok {
+			containers[i].Operation = &op
+		}
+	}
+}
+
+// handleGetOperations lists the container actions in progress
+func (s *Server) handleGetOperations(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, s.operations.Active())
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// attachOwners sets the owner of containers that have one, from their label or the owners
+// assigned to them or their compose stack
+func (s *Server) attachOwners(containers []models.Container) {
+	assignments, err := s.db.GetOwnerAssignments()
+	if err != nil {
+		log.Printf("Failed to get owners: %v", err)
+	}
+	for i := range containers {
+		containers[i].Owner = models.OwnerFor(containers[i], assignments)
+	}
+}
+
+// ownerList is the response of GET /api/owners
+type ownerList struct {
+	Containers []models.OwnerAssignment `json:"containers"`
+	Stacks     []models.OwnerAssignment `json:"stacks"`
+}
+
+// handleGetOwners lists the owners assigned to containers and compose stacks. Owners set by
+// label show up on the containers themselves.
+func (s *Server) handleGetOwners(w http.ResponseWriter, r *http.Request) {
+	assignments, err := s.db.GetOwnerAssignments()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get owners: "+err.Error())
+		return
+	}
+
+	list := ownerList{
+		Containers: make([]models.OwnerAssignment, 0, len(assignments.Containers)),
+		Stacks:     make([]models.OwnerAssignment, 0, len(assignments.Stacks)),
+	}
+	for _, a := range assignments.Containers {
+		list.Containers = append(list.Containers, a)
+	}
+	for _, a := range assignments.Stacks {
+		list.Stacks = append(list.Stacks, a)
+	}
+	sort.Slice(list.Containers, func(i, j int) bool {
+		if list.Containers[i].HostName != list.Containers[j].HostName {
+			return list.Containers[i].HostName < list.Containers[j].HostName
+		}
+		return list.Containers[i].ContainerName < list.Containers[j].ContainerName
+	})
+	sort.Slice(list.Stacks, func(i, j int) bool { return list.Stacks[i].Stack < list.Stacks[j].Stack })
+	respondJSON(w, http.StatusOK, list)
+}
+
+// handleSetContainerOwner assigns an owner to a container (JSON: {"owner", "contact"})
+func (s *Server) handleSetContainerOwner(w http.ResponseWriter, r *http.Request) {
+	container, ok := s.pinTarget(w, r)
+	if !ok {
+		return
+	}
+	a, ok := decodeOwnerAssignment(w, r)
+	if !ok {
+		return
+	}
+	a.HostID, a.HostName, a.ContainerName = container.HostID, container.HostName, container.Name
+
+	if err := s.db.SetContainerOwner(a); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to set owner: "+err.Error())
+		return
+	}
+	s.cache.Invalidate()
+	respondJSON(w, http.StatusOK, a)
+}
+
+// handleDeleteContainerOwner removes a container's assigned owner. An owner set by label stays.
+func (s *Server) handleDeleteContainerOwner(w http.ResponseWriter, r *http.Request) {
+	container, ok := s.pinTarget(w, r)
+	if !ok {
+		return
+	}
+	if err := s.db.DeleteContainerOwner(container.HostID, container.Name); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to remove owner: "+err.Error())
+		return
+	}
+	s.cache.Invalidate()
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Owner removed"})
+}
+
+// handleSetStackOwner assigns an owner to a compose stack's containers on every host
+func (s *Server) handleSetStackOwner(w http.ResponseWriter, r *http.Request) {
+	stack := strings.TrimSpace(mux.Vars(r)["stack"])
+	if stack == "" {
+		respondError(w, http.StatusBadRequest, "Stack name is required")
+		return
+	}
+	a, ok := decodeOwnerAssignment(w, r)
+	if !ok {
+		return
+	}
+	a.Stack = stack
+
+	if err := s.db.SetStackOwner(a); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to set owner: "+err.Error())
+		return
+	}
+	s.cache.Invalidate()
+	respondJSON(w, http.StatusOK, a)
+}
+
+// handleDeleteStackOwner removes a compose stack's assigned owner
+func (s *Server) handleDeleteStackOwner(w http.ResponseWriter, r *http.Request) {
+	if err := s.db.DeleteStackOwner(mux.Vars(r)["stack"]); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to remove owner: "+err.Error())
+		return
+	}
+	s.cache.Invalidate()
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Owner removed"})
+}
+
+// decodeOwnerAssignment reads and checks an owner assignment from the request body
+func decodeOwnerAssignment(w http.ResponseWriter, r *http.Request) (models.OwnerAssignment, bool) {
+	var a models.OwnerAssignment
+	if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return a, false
+	}
+	if err := a.Normalize(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return a, false
+	}
+	a.AssignedAt = time.Now()
+	a.AssignedBy = identity(r).Username
+	return a, true
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/auth"
+	"github.com/container-census/container-census/internal/models"
+	"github.com/gorilla/mux"
+)
+
+func TestOwners(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: "unix:///var/run/docker.sock", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	now := time.Now()
+	if err := db.SaveContainers([]models.Container{
+		{ID: "jf123", Name: "jellyfin", Image: "jellyfin/jellyfin", State: "running", HostID: hostID, HostName: "nas", ComposeProject: "media", ScannedAt: now},
+		{ID: "pg123", Name: "postgres", Image: "postgres:16", State: "running", HostID: hostID, HostName: "nas", ComposeProject: "media", ScannedAt: now},
+	}); err != nil {
+		t.Fatalf("Failed to save containers: %v", err)
+	}
+
+	call := func(handler http.HandlerFunc, method, body string, vars map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/owners", strings.NewReader(body))
+		req = mux.SetURLVars(req, vars)
+		req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{Username: "admin"}))
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	if w := call(server.handleSetStackOwner, http.MethodPut, `{"owner":"media-team"}`, map[string]string{"stack": "media"}); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	w := call(server.handleSetContainerOwner, http.MethodPut, `{"owner":" dba@example.com "}`,
+		map[string]string{"host_id": itoa(hostID), "container_id": "postgres"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var a models.OwnerAssignment
+	if err := json.Unmarshal(w.Body.Bytes(), &a); err != nil {
+		t.Fatalf("Failed to decode owner: %v", err)
+	}
+	if a.Owner != "dba@example.com" || a.Contact != "dba@example.com" || a.AssignedBy != "admin" || a.ContainerName != "postgres" {
+		t.Errorf("Unexpected assignment %+v", a)
+	}
+	if w := call(server.handleSetContainerOwner, http.MethodPut, `{"owner":" "}`,
+		map[string]string{"host_id": itoa(hostID), "container_id": "postgres"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without an owner, got %d", w.Code)
+	}
+
+	containers, err := db.GetLatestContainers()
+	if err != nil {
+		t.Fatalf("Failed to get containers: %v", err)
+	}
+	server.attachOwners(containers)
+	owners := make(map[string]*models.Owner)
+	for _, c := range containers {
+		owners[c.Name] = c.Owner
+	}
+	if o := owners["jellyfin"]; o == nil || o.Name != "media-team" || o.Source != models.OwnerSourceStack {
+		t.Errorf("Expected the stack owner on jellyfin, got %+v", o)
+	}
+	if o := owners["postgres"]; o == nil || o.Name != "dba@example.com" || o.Source != models.OwnerSourceContainer {
+		t.Errorf("Expected the container owner on postgres, got %+v", o)
+	}
+
+	w = call(server.handleGetOwners, http.MethodGet, "", nil)
+	var list ownerList
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("Failed to decode owners: %v", err)
+	}
+	if len(list.Containers) != 1 || list.Containers[0].HostName != "nas" || len(list.Stacks) != 1 || list.Stacks[0].Stack != "media" {
+		t.Errorf("Unexpected owners %+v", list)
+	}
+
+	if w := call(server.handleDeleteStackOwner, http.MethodDelete, "", map[string]string{"stack": "media"}); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+	if assignments, _ := db.GetOwnerAssignments(); len(assignments.Stacks) != 0 {
+		t.Errorf("Expected the stack owner removed, got %+v", assignments.Stacks)
+	}
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// maxPinReasonLength caps the reason stored with a pin
+const maxPinReasonLength = 500
+
+// attachPins sets the pin on containers pinned by label or through the API
+func (s *Server) attachPins(containers []models.Container) {
+	pins, err := s.db.GetContainerPins()
+	if err != nil {
+		log.Printf("Failed to get container pins: %v", err)
+		pins = nil
+	}
+	for i := range containers {
+		containers[i].Pin = models.PinFor(containers[i], pins)
+	}
+}
+
+// containerPin returns a container's pin, or nil. Errors are logged and treated as not pinned.
+func (s *Server) containerPin(c models.Container) *models.ContainerPin {
+	pins, err := s.db.GetContainerPins()
+	if err != nil {
+		log.Printf("Failed to get container pins: %v", err)
+	}
+	return models.PinFor(c, pins)
+}
+
+// pinnedMessage explains why a pinned container is left alone
+func pinnedMessage(pin *models.ContainerPin) string {
+	if pin.Source == models.PinSourceLabel {
+		return "Container is pinned by its " + models.PinLabel + " label"
+	}
+	if pin.Reason != "" {
+		return "Container is pinned: " + pin.Reason
+	}
+	return "Container is pinned"
+}
+
+// handleGetPins lists the pins set through the API. Label pins show up on the containers themselves.
+func (s *Server) handleGetPins(w http.ResponseWriter, r *http.Request) {
+	pins, err := s.db.GetContainerPins()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get pins: "+err.Error())
+		return
+	}
+
+	list := make([]models.ContainerPin, 0, len(pins))
+	for _, pin := range pins {
+		list = append(list, pin)
+	}
+	respondJSON(w, http.StatusOK, list)
+}
+
+// handlePinContainer pins a container so it is skipped by update checks and updates
+func (s *Server) handlePinContainer(w http.ResponseWriter, r *http.Request) {
+	container, ok := s.pinTarget(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if len(reason) > maxPinReasonLength {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("Reason must be at most %d characters", maxPinReasonLength))
+		return
+	}
+
+	pin := models.ContainerPin{
+		HostID:        container.HostID,
+		ContainerName: container.Name,
+		Reason:        reason,
+		Source:        models.PinSourceAPI,
+		PinnedAt:      time.Now(),
+	}
+	if err := s.db.SetContainerPin(pin); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to pin container: "+err.Error())
+		return
+	}
+	s.cache.Invalidate()
+
+	respondJSON(w, http.StatusOK, models.PinFor(*container, map[string]models.ContainerPin{models.PinKey(pin.HostID, pin.ContainerName): pin}))
+}
+
+// handleUnpinContainer removes a container's API pin; label pins must be removed from the container
+func (s *Server) handleUnpinContainer(w http.ResponseWriter, r *http.Request) {
+	container, ok := s.pinTarget(w, r)
+	if !ok {
+		return
+	}
+	if models.PinnedByLabels(container.Labels) {
+		respondError(w, http.StatusConflict, "Container is pinned by its "+models.PinLabel+" label; remove the label to unpin it")
+		return
+	}
+
+	if err := s.db.DeleteContainerPin(container.HostID, container.Name); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to unpin container: "+err.Error())
+		return
+	}
+	s.cache.Invalidate()
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Container unpinned"})
+}
+
+// pinTarget finds the container named by the host_id and container_id (ID or name) route variables
+func (s *Server) pinTarget(w http.ResponseWriter, r *http.Request) (*models.Container, bool) {
+	vars := mux.Vars(r)
+	hostID, err := strconv.ParseInt(vars["host_id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid host ID")
+		return nil, false
+	}
+	containerID := vars["container_id"]
+
+	containers, err := s.db.GetContainersByHost(hostID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get containers: "+err.Error())
+		return nil, false
+	}
+	for i := range containers {
+		if containers[i].ID == containerID || containers[i].Name == containerID {
+			return &containers[i], true
+		}
+	}
+	respondError(w, http.StatusNotFound, "Container not found")
+	return nil, false
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// handleGetPlugins lists the collector plugins and the outcome of their latest runs.
+// The list is empty when no plugins directory is configured.
+func (s *Server) handleGetPlugins(w http.ResponseWriter, r *http.Request) {
+	if s.pluginRunner == nil {
+		respondJSON(w, http.StatusOK, []models.PluginStatus{})
+		return
+	}
+	respondJSON(w, http.StatusOK, s.pluginRunner.Status())
+}
+
+// handleGetPluginResults returns what plugins reported for one container at the latest scan
+func (s *Server) handleGetPluginResults(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	hostID, err := strconv.ParseInt(query.Get("host_id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid host ID")
+		return
+	}
+	containerName := query.Get("container_name")
+	if containerName == "" {
+		respondError(w, http.StatusBadRequest, "container_name is required")
+		return
+	}
+
+	results, err := s.db.GetPluginResults(hostID, containerName)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get plugin results: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, results)
+}
+
+// handleGetScripts lists the event scripts and the outcome of their latest runs.
+// The list is empty when no scripts directory is configured.
+func (s *Server) handleGetScripts(w http.ResponseWriter, r *http.Request) {
+	if s.scriptEngine == nil {
+		respondJSON(w, http.StatusOK, []models.ScriptStatus{})
+		return
+	}
+	respondJSON(w, http.StatusOK, s.scriptEngine.Status())
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ProxyConfig describes how the server is reached through a reverse proxy
+type ProxyConfig struct {
+	// BasePath serves the UI and API under a sub-path such as /census (empty for the root)
+	BasePath string
+	// TrustedProxies are the peers whose X-Forwarded-* headers are honored
+	TrustedProxies []*net.IPNet
+}
+
+type prefixContextKey struct{}
+
+// SetProxyConfig configures the base path and trusted proxies used by Handler
+func (s *Server) SetProxyConfig(cfg ProxyConfig) {
+	cfg.BasePath = NormalizeBasePath(cfg.BasePath)
+	s.proxyConfig = cfg
+}
+
+// Handler returns the HTTP handler for the server: the router behind the base path, with
+// forwarded headers from trusted proxies applied
+func (s *Server) Handler() http.Handler {
+	var handler http.Handler = s.router
+	if basePath := s.proxyConfig.BasePath; basePath != "" {
+		handler = stripBasePath(basePath, handler)
+	}
+	return forwardedHeaders(s.proxyConfig, handler)
+}
+
+// publicPath returns the path a browser uses to reach a server path, including the base path
+// (or the prefix a trusted proxy strips and reports in X-Forwarded-Prefix)
+func (s *Server) publicPath(r *http.Request, path string) string {
+	if prefix, ok := r.Context().Value(prefixContextKey{}).(string); ok {
+		return prefix + path
+	}
+	return s.proxyConfig.BasePath + path
+}
+
+// NormalizeBasePath returns the base path with a leading slash and no trailing slash ("" for the root)
+func NormalizeBasePath(path string) string {
+	path = strings.Trim(strings.TrimSpace(path), "/")
+	if path == "" {
+		return ""
+	}
+	return "/" + path
+}
+
+// ParseTrustedProxies parses a comma-separated list of IP addresses and CIDR ranges
+func ParseTrustedProxies(value string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// stripBasePath serves requests under basePath with the prefix removed. /api/health stays
+// reachable at the root for container health checks.
+func stripBasePath(basePath string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == basePath {
+			target := basePath + "/"
+			if r.URL.RawQuery != "" {
+				target += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, target, http.StatusMovedPermanently)
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, basePath+"/") {
+			http.StripPrefix(basePath, next).ServeHTTP(w, r)
+			return
+		}
+		if r.URL.Path == "/api/health" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		http.NotFound(w, r)
+	})
+}
+
+// forwardedHeaders applies X-Forwarded-For, -Proto, -Host and -Prefix from trusted proxies:
+// the client IP becomes RemoteAddr, the scheme is set on the URL (which marks session cookies
+// Secure) and the prefix is used for redirects. Headers from other peers are ignored.
+func forwardedHeaders(cfg ProxyConfig, next http.Handler) http.Handler {
+	if len(cfg.TrustedProxies) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !trusted(cfg.TrustedProxies, remoteIP(r.RemoteAddr)) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if client := forwardedClient(cfg.TrustedProxies, r.Header.Values("X-Forwarded-For")); client != "" {
+			r.RemoteAddr = client
+		}
+		if proto := strings.ToLower(firstValue(r.Header.Get("X-Forwarded-Proto"))); proto == "https" || proto == "http" {
+			r.URL.Scheme = proto
+		}
+		if host := firstValue(r.Header.Get("X-Forwarded-Host")); host != "" {
+			r.Host = host
+		}
+		if prefix := r.Header.Get("X-Forwarded-Prefix"); prefix != "" {
+			r = r.WithContext(context.WithValue(r.Context(), prefixContextKey{}, NormalizeBasePath(prefix)+cfg.BasePath))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// forwardedClient returns the client address from X-Forwarded-For: the rightmost address that
+// isn't a trusted proxy, since addresses left of it may be forged by the client
+func forwardedClient(trustedNets []*net.IPNet, headers []string) string {
+	var hops []string
+	for _, header := range headers {
+		for _, hop := range strings.Split(header, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(hops[i])
+		if ip == nil {
+			return ""
+		}
+		if i == 0 || !trusted(trustedNets, ip) {
+			return ip.String()
+		}
+	}
+	return ""
+}
+
+func trusted(nets []*net.IPNet, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func remoteIP(remoteAddr string) net.IP {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+// firstValue returns the first of comma-separated header values (proxies chain them)
+func firstValue(header string) string {
+	value, _, _ := strings.Cut(header, ",")
+	return strings.TrimSpace(value)
+}
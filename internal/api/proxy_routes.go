@@ -0,0 +1,118 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/proxyroutes"
+)
+
+// handleGetProxyRouteReport maps the public URLs of the reverse proxies to the containers serving
+// them, from Traefik labels, nginx-proxy VIRTUAL_HOST variables and, when the Traefik integration
+// is enabled, Traefik's API. Query: host_id, and flagged=true to leave out routes whose container
+// is running.
+func (s *Server) handleGetProxyRouteReport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var hostID int64
+	if v := query.Get("host_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid host_id parameter")
+			return
+		}
+		hostID = id
+	}
+
+	settings, err := s.db.GetTraefikSettings()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get Traefik settings: "+err.Error())
+		return
+	}
+	containers, err := s.proxyRouteContainers()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get containers: "+err.Error())
+		return
+	}
+
+	// Routes are matched against every host, since Traefik may proxy to containers elsewhere
+	report := proxyroutes.BuildReport(r.Context(), settings, containers, nil)
+	flaggedOnly := query.Get("flagged") == "true"
+	if hostID != 0 || flaggedOnly {
+		routes := make([]models.ProxyRoute, 0)
+		report.Stopped, report.Unmatched = 0, 0
+		for _, route := range report.Routes {
+			if hostID != 0 && route.HostID != hostID {
+				continue
+			}
+			if flaggedOnly && route.Status == models.RouteStatusOK {
+				continue
+			}
+			switch route.Status {
+			case models.RouteStatusStopped:
+				report.Stopped++
+			case models.RouteStatusUnmatched:
+				report.Unmatched++
+			}
+			routes = append(routes, route)
+		}
+		report.Routes = routes
+	}
+
+	respondJSON(w, http.StatusOK, report)
+}
+
+// proxyRouteContainers returns the containers of the latest scans with the network addresses of
+// their stored configuration and the addresses of their hosts
+func (s *Server) proxyRouteContainers() ([]proxyroutes.Container, error) {
+	latest, err := s.latestContainers()
+	if err != nil {
+		return nil, err
+	}
+	hosts, err := s.db.GetHosts()
+	if err != nil {
+		return nil, err
+	}
+	inspections, err := s.db.GetLatestContainerInspections(0)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := make(map[int64]string, len(hosts))
+	for _, h := range hosts {
+		addresses[h.ID] = h.Address
+	}
+	type containerKey struct {
+		hostID int64
+		id     string
+	}
+	configs := make(map[containerKey]models.ContainerConfig, len(inspections))
+	for _, inspection := range inspections {
+		configs[containerKey{inspection.HostID, inspection.ContainerID}] = inspection.Config
+	}
+
+	containers := make([]proxyroutes.Container, 0, len(latest))
+	for _, c := range latest {
+		pc := proxyroutes.Container{
+			HostID:      c.HostID,
+			HostName:    c.HostName,
+			HostAddress: addresses[c.HostID],
+			Name:        c.Name,
+			Image:       c.Image,
+			State:       c.State,
+			Labels:      c.Labels,
+			Ports:       c.Ports,
+		}
+		if config, ok := configs[containerKey{c.HostID, c.ID}]; ok {
+			pc.Env = config.Env
+			for _, n := range config.Networks {
+				if n.IPAddress != "" {
+					pc.IPs = append(pc.IPs, n.IPAddress)
+				}
+			}
+		}
+		containers = append(containers, pc)
+	}
+	return containers, nil
+}
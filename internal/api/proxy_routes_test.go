@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestGetProxyRouteReport(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: "agent://nas.lan:9876", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	now := time.Now()
+	if err := db.SaveContainers([]models.Container{
+		{
+			ID: "jf123", Name: "jellyfin", Image: "jellyfin/jellyfin", State: "running", HostID: hostID, HostName: "nas", ScannedAt: now,
+			Labels: map[string]string{"traefik.http.routers.jf.rule": "Host(`jellyfin.example.com`)", "traefik.http.routers.jf.tls": "true"},
+		},
+		{
+			ID: "wiki123", Name: "wiki", Image: "requarks/wiki", State: "exited", HostID: hostID, HostName: "nas", ScannedAt: now,
+			Config: &models.ContainerConfig{Env: []models.EnvVar{{Name: "VIRTUAL_HOST", Value: "wiki.example.com"}}},
+		},
+	}); err != nil {
+		t.Fatalf("Failed to save containers: %v", err)
+	}
+
+	get := func(query string) models.ProxyRouteReport {
+		req := httptest.NewRequest(http.MethodGet, "/api/reports/proxy-routes"+query, nil)
+		w := httptest.NewRecorder()
+		server.handleGetProxyRouteReport(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var report models.ProxyRouteReport
+		if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+			t.Fatalf("Failed to decode report: %v", err)
+		}
+		return report
+	}
+
+	report := get("")
+	if report.TraefikEnabled || len(report.Routes) != 2 || report.Stopped != 1 {
+		t.Fatalf("Expected 2 routes with 1 stopped, got %+v", report)
+	}
+	if r := report.Routes[0]; r.URL != "http://wiki.example.com" || r.ContainerName != "wiki" || r.Status != models.RouteStatusStopped {
+		t.Errorf("Unexpected route %+v", r)
+	}
+	if r := report.Routes[1]; r.URL != "https://jellyfin.example.com" || r.ContainerName != "jellyfin" || r.Status != models.RouteStatusOK {
+		t.Errorf("Unexpected route %+v", r)
+	}
+
+	if report = get("?flagged=true"); len(report.Routes) != 1 || report.Routes[0].ContainerName != "wiki" {
+		t.Errorf("Expected only the stopped route, got %+v", report.Routes)
+	}
+	if report = get("?host_id=" + itoa(hostID+1)); len(report.Routes) != 0 || report.Stopped != 0 {
+		t.Errorf("Expected no routes for another host, got %+v", report)
+	}
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	nets, err := ParseTrustedProxies(" 10.0.0.0/8, 192.168.1.5 ,::1,")
+	if err != nil || len(nets) != 3 {
+		t.Fatalf("Expected 3 ranges, got %v (%v)", nets, err)
+	}
+	if nets[1].String() != "192.168.1.5/32" || nets[2].String() != "::1/128" {
+		t.Errorf("Expected single addresses as host ranges, got %v", nets)
+	}
+	if _, err := ParseTrustedProxies("10.0.0.0/8,proxy.local"); err == nil {
+		t.Error("Expected an invalid address to be rejected")
+	}
+}
+
+func TestNormalizeBasePath(t *testing.T) {
+	for in, want := range map[string]string{"": "", "/": "", "census": "/census", "/census/": "/census", " /a/b/ ": "/a/b"} {
+		if got := NormalizeBasePath(in); got != want {
+			t.Errorf("NormalizeBasePath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// newProxyTestServer returns a server whose routes echo what the handler sees
+func newProxyTestServer(cfg ProxyConfig) *Server {
+	s := &Server{router: mux.NewRouter()}
+	s.SetProxyConfig(cfg)
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Path", r.URL.Path)
+		w.Header().Set("X-Remote", r.RemoteAddr)
+		w.Header().Set("X-Scheme", r.URL.Scheme)
+		w.Header().Set("X-Login", s.publicPath(r, "/login.html"))
+	}
+	s.router.HandleFunc("/api/health", echo)
+	s.router.PathPrefix("/").HandlerFunc(echo)
+	return s
+}
+
+func TestBasePath(t *testing.T) {
+	handler := newProxyTestServer(ProxyConfig{BasePath: "/census/"}).Handler()
+
+	tests := []struct {
+		path     string
+		code     int
+		seen     string
+		location string
+	}{
+		{"/census/api/containers", http.StatusOK, "/api/containers", ""},
+		{"/census/", http.StatusOK, "/", ""},
+		{"/census", http.StatusMovedPermanently, "", "/census/"},
+		{"/api/health", http.StatusOK, "/api/health", ""},
+		{"/api/containers", http.StatusNotFound, "", ""},
+		{"/censusx/api/containers", http.StatusNotFound, "", ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.code || rec.Header().Get("X-Path") != tt.seen || rec.Header().Get("Location") != tt.location {
+			t.Errorf("%s: got %d path %q location %q", tt.path, rec.Code, rec.Header().Get("X-Path"), rec.Header().Get("Location"))
+		}
+		if tt.seen == "/" && rec.Header().Get("X-Login") != "/census/login.html" {
+			t.Errorf("Expected the login page under the base path, got %q", rec.Header().Get("X-Login"))
+		}
+	}
+}
+
+func TestForwardedHeaders(t *testing.T) {
+	nets, _ := ParseTrustedProxies("10.0.0.0/8")
+	handler := newProxyTestServer(ProxyConfig{TrustedProxies: nets}).Handler()
+
+	request := func(remote string, headers map[string]string) http.Header {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remote
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Header()
+	}
+
+	forwarded := map[string]string{
+		"X-Forwarded-For":    "1.2.3.4, 203.0.113.9, 10.0.0.7",
+		"X-Forwarded-Proto":  "https",
+		"X-Forwarded-Prefix": "/census/",
+	}
+
+	// The rightmost untrusted address is the client; the forged one before it is ignored
+	h := request("10.0.0.2:5000", forwarded)
+	if h.Get("X-Remote") != "203.0.113.9" || h.Get("X-Scheme") != "https" || h.Get("X-Login") != "/census/login.html" {
+		t.Errorf("Expected the forwarded headers applied, got remote %q scheme %q login %q", h.Get("X-Remote"), h.Get("X-Scheme"), h.Get("X-Login"))
+	}
+
+	// Untrusted peers can't spoof their address or scheme
+	h = request("198.51.100.1:5000", forwarded)
+	if h.Get("X-Remote") != "198.51.100.1:5000" || h.Get("X-Scheme") != "" || h.Get("X-Login") != "/login.html" {
+		t.Errorf("Expected the forwarded headers ignored, got remote %q scheme %q login %q", h.Get("X-Remote"), h.Get("X-Scheme"), h.Get("X-Login"))
+	}
+
+	// A chain of trusted proxies only
+	h = request("10.0.0.2:5000", map[string]string{"X-Forwarded-For": "10.1.1.1, 10.0.0.7"})
+	if h.Get("X-Remote") != "10.1.1.1" {
+		t.Errorf("Expected the leftmost address when every hop is trusted, got %q", h.Get("X-Remote"))
+	}
+}
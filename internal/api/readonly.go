@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// readOnlyRoutes are the API routes that change containers or images on a host, as
+// "METHOD template". Read-only mode rejects them; inventory, stats, logs and everything
+// Container Census only records in its own database keep working.
+var readOnlyRoutes = map[string]bool{
+	"POST /api/containers/{host_id}/{container_id}/start":   true,
+	"POST /api/containers/{host_id}/{container_id}/stop":    true,
+	"POST /api/containers/{host_id}/{container_id}/restart": true,
+	"DELETE /api/containers/{host_id}/{container_id}":       true,
+	"POST /api/containers/{host_id}/{container_id}/update":  true,
+	"POST /api/containers/bulk-update":                      true,
+	"POST /api/updates/canary":                              true,
+	"DELETE /api/images/{host_id}/{image_id}":               true,
+	"POST /api/images/host/{id}/prune":                      true,
+	"POST /api/images/host/{id}/prune-policy":               true,
+}
+
+// SetReadOnly forces read-only mode on, regardless of the setting (READ_ONLY=true)
+func (s *Server) SetReadOnly(forced bool) {
+	s.readOnlyForced = forced
+}
+
+// readOnly reports whether Docker operations are disabled
+func (s *Server) readOnly() bool {
+	if s.readOnlyForced {
+		return true
+	}
+	enabled, err := s.db.GetReadOnlyMode()
+	if err != nil {
+		// Fail closed: a broken settings table shouldn't turn a monitoring-only setup writable
+		log.Printf("Failed to get read-only mode: %v", err)
+		return true
+	}
+	return enabled
+}
+
+// readOnlyMiddleware rejects the routes in readOnlyRoutes while read-only mode is on
+func (s *Server) readOnlyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		template, err := route.GetPathTemplate()
+		if err != nil || !readOnlyRoutes[r.Method+" "+template] || !s.readOnly() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		respondError(w, http.StatusForbidden, "Read-only mode is enabled: Docker operations are disabled")
+	})
+}
+
+// handleGetReadOnly returns whether read-only mode is on and whether READ_ONLY forces it
+func (s *Server) handleGetReadOnly(w http.ResponseWriter, r *http.Request) {
+	enabled, err := s.db.GetReadOnlyMode()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get read-only mode: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]bool{
+		"read_only":     enabled || s.readOnlyForced,
+		"forced_by_env": s.readOnlyForced,
+	})
+}
+
+// handleUpdateReadOnly switches read-only mode on or off
+func (s *Server) handleUpdateReadOnly(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ReadOnly bool `json:"read_only"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	if s.readOnlyForced && !req.ReadOnly {
+		respondError(w, http.StatusConflict, "Read-only mode is forced by the READ_ONLY environment variable")
+		return
+	}
+	if err := s.db.SetReadOnlyMode(req.ReadOnly); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save read-only mode: "+err.Error())
+		return
+	}
+	if req.ReadOnly {
+		log.Printf("Read-only mode enabled by %s", identity(r).Username)
+	} else {
+		log.Printf("Read-only mode disabled by %s", identity(r).Username)
+	}
+	s.handleGetReadOnly(w, r)
+}
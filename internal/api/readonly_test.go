@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestReadOnlyMiddleware(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api").Subrouter()
+	api.Use(server.readOnlyMiddleware)
+	api.HandleFunc("/containers/{host_id}/{container_id}/stop", ok).Methods("POST")
+	api.HandleFunc("/images/host/{id}/prune", ok).Methods("POST")
+	api.HandleFunc("/containers/{host_id}/{container_id}/logs", ok).Methods("GET")
+	api.HandleFunc("/settings/read-only", server.handleGetReadOnly).Methods("GET")
+	api.HandleFunc("/settings/read-only", server.handleUpdateReadOnly).Methods("PUT")
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do("POST", "/api/containers/1/abc/stop", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected stop to work while read-only mode is off, got %d", rec.Code)
+	}
+
+	if rec := do("PUT", "/api/settings/read-only", `{"read_only": true}`); rec.Code != http.StatusOK {
+		t.Fatalf("Failed to enable read-only mode: %d %s", rec.Code, rec.Body.String())
+	}
+	if enabled, err := db.GetReadOnlyMode(); err != nil || !enabled {
+		t.Fatalf("Expected read-only mode to be saved, got %v (%v)", enabled, err)
+	}
+	if rec := do("POST", "/api/containers/1/abc/stop", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for stop in read-only mode, got %d", rec.Code)
+	}
+	if rec := do("POST", "/api/images/host/1/prune", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for prune in read-only mode, got %d", rec.Code)
+	}
+	if rec := do("GET", "/api/containers/1/abc/logs", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected logs to keep working in read-only mode, got %d", rec.Code)
+	}
+
+	if rec := do("PUT", "/api/settings/read-only", `{"read_only": false}`); rec.Code != http.StatusOK {
+		t.Fatalf("Failed to disable read-only mode: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do("POST", "/api/containers/1/abc/stop", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected stop to work again, got %d", rec.Code)
+	}
+
+	// READ_ONLY can't be switched off from the settings
+	server.SetReadOnly(true)
+	if rec := do("PUT", "/api/settings/read-only", `{"read_only": false}`); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 when disabling forced read-only mode, got %d", rec.Code)
+	}
+	if rec := do("GET", "/api/settings/read-only", ""); !strings.Contains(rec.Body.String(), `"forced_by_env":true`) {
+		t.Errorf("Expected forced read-only mode to be reported, got %s", rec.Body.String())
+	}
+	if rec := do("POST", "/api/containers/1/abc/stop", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for stop in forced read-only mode, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/container-census/container-census/internal/inspect"
+	"github.com/container-census/container-census/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// handleGetRecreateSpec exports a container as the `docker run` command and compose service
+// that recreate it, from the configuration collected at its last scan. The container is looked
+// up by ID or name. ?format=run or ?format=compose answer with just that text, for saving it
+// straight into a file; the default is JSON with both.
+func (s *Server) handleGetRecreateSpec(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	hostID, err := strconv.ParseInt(vars["host_id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid host ID")
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "run" && format != "compose" {
+		respondError(w, http.StatusBadRequest, "Invalid format: use json, run or compose")
+		return
+	}
+
+	containers, err := s.db.GetContainersByHost(hostID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get containers: "+err.Error())
+		return
+	}
+	var container *models.Container
+	for i := range containers {
+		if containers[i].ID == vars["container_id"] || containers[i].Name == vars["container_id"] {
+			container = &containers[i]
+			break
+		}
+	}
+	if container == nil {
+		respondError(w, http.StatusNotFound, "Container not found")
+		return
+	}
+
+	inspection, err := s.db.GetContainerInspection(hostID, container.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get container configuration: "+err.Error())
+		return
+	}
+	if inspection == nil {
+		respondError(w, http.StatusNotFound, "No configuration collected for this container yet")
+		return
+	}
+
+	spec, err := recreateSpec(*container, *inspection)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to generate recreate spec: "+err.Error())
+		return
+	}
+
+	switch format {
+	case "run":
+		w.Header().Set("Content-Type", "text/x-shellscript; charset=utf-8")
+		w.Write([]byte(spec.RunCommand + "\n"))
+	case "compose":
+		w.Header().Set("Content-Type", "application/yaml; charset=utf-8")
+		w.Write([]byte(spec.Compose))
+	default:
+		respondJSON(w, http.StatusOK, spec)
+	}
+}
+
+// recreateSpec generates the run command and compose service of a container from its scan row
+// and stored configuration
+func recreateSpec(c models.Container, inspection models.ContainerInspection) (*models.RecreateSpec, error) {
+	compose, err := inspect.ComposeService(c, inspection.Config)
+	if err != nil {
+		return nil, err
+	}
+	spec := &models.RecreateSpec{
+		ContainerID:   c.ID,
+		ContainerName: c.Name,
+		HostID:        inspection.HostID,
+		HostName:      inspection.HostName,
+		CollectedAt:   inspection.CollectedAt,
+		RunCommand:    inspect.RunCommand(c, inspection.Config),
+		Compose:       compose,
+	}
+	for _, e := range inspection.Config.Env {
+		if e.Masked {
+			spec.MaskedEnv = append(spec.MaskedEnv, e.Name)
+		}
+	}
+	return spec, nil
+}
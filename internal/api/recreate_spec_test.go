@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/gorilla/mux"
+)
+
+func TestGetRecreateSpec(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	if err := db.SaveContainers([]models.Container{{
+		ID: "abc123def456", Name: "uptime-kuma", Image: "louislam/uptime-kuma:1", State: "running",
+		HostID: hostID, HostName: "nas", ScannedAt: time.Now(),
+		Ports:   []models.PortMapping{{PrivatePort: 3001, PublicPort: 3001, Type: "tcp"}},
+		Volumes: []models.VolumeMount{{Name: "kuma", Destination: "/app/data", Type: "volume", RW: true}},
+		Config: &models.ContainerConfig{
+			Env: []models.EnvVar{{Name: "TZ", Value: "UTC"}, {Name: "SMTP_PASSWORD", Value: "********", Masked: true}},
+			Mounts: []models.ConfigMount{
+				{Type: "volume", Source: "/var/lib/docker/volumes/kuma/_data", Destination: "/app/data", RW: true},
+			},
+			RestartPolicy: "always",
+			NetworkMode:   "bridge",
+		},
+	}}); err != nil {
+		t.Fatalf("Failed to save containers: %v", err)
+	}
+
+	get := func(containerID, format string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/containers/"+itoa(hostID)+"/"+containerID+"/recreate-spec?format="+format, nil)
+		req = mux.SetURLVars(req, map[string]string{"host_id": itoa(hostID), "container_id": containerID})
+		w := httptest.NewRecorder()
+		server.handleGetRecreateSpec(w, req)
+		return w
+	}
+
+	w := get("uptime-kuma", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var spec models.RecreateSpec
+	if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("Failed to decode spec: %v", err)
+	}
+	want := "docker run -d --name uptime-kuma --restart always -p 3001:3001 -v kuma:/app/data -e TZ=UTC -e SMTP_PASSWORD louislam/uptime-kuma:1"
+	if spec.RunCommand != want {
+		t.Errorf("RunCommand = %q, want %q", spec.RunCommand, want)
+	}
+	if len(spec.MaskedEnv) != 1 || spec.MaskedEnv[0] != "SMTP_PASSWORD" || !strings.Contains(spec.Compose, "container_name: uptime-kuma") {
+		t.Errorf("Unexpected spec %+v", spec)
+	}
+
+	w = get("abc123def456", "compose")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/yaml") || !strings.HasPrefix(w.Body.String(), "services:\n") {
+		t.Errorf("Expected the compose file by container ID, got %d %s: %s", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+	if w = get("uptime-kuma", "run"); w.Body.String() != want+"\n" {
+		t.Errorf("Expected the run command as text, got %q", w.Body.String())
+	}
+	if w = get("uptime-kuma", "xml"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown format, got %d", w.Code)
+	}
+	if w = get("missing", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown container, got %d", w.Code)
+	}
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// handleGetRestartPolicyReport audits the restart policies of the latest scans: running services
+// without a restart policy, and policies that changed recently. Query: host_id, min_uptime_hours
+// (default 24) and changed_days (default 7).
+func (s *Server) handleGetRestartPolicyReport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var hostID int64
+	if v := query.Get("host_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid host_id parameter")
+			return
+		}
+		hostID = id
+	}
+	minUptimeHours := models.DefaultRestartPolicyMinUptimeHours
+	if v := query.Get("min_uptime_hours"); v != "" {
+		hours, err := strconv.Atoi(v)
+		if err != nil || hours < 0 {
+			respondError(w, http.StatusBadRequest, "Invalid min_uptime_hours parameter")
+			return
+		}
+		minUptimeHours = hours
+	}
+	changedDays := models.DefaultRestartPolicyChangedDays
+	if v := query.Get("changed_days"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 1 || days > 365 {
+			respondError(w, http.StatusBadRequest, "changed_days must be between 1 and 365")
+			return
+		}
+		changedDays = days
+	}
+
+	report, err := s.db.GetRestartPolicyReport(hostID, minUptimeHours, changedDays)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to audit restart policies: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, report)
+}
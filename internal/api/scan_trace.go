@@ -0,0 +1,39 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// handleGetScan returns the result of one scan with the container rows it recorded, by the scan
+// ID found in the logs of the server and agents
+func (s *Server) handleGetScan(w http.ResponseWriter, r *http.Request) {
+	scanID := mux.Vars(r)["scan_id"]
+	result, err := s.db.GetScanResultByScanID(scanID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusNotFound, "Scan not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get scan result: "+err.Error())
+		return
+	}
+
+	containers, err := s.db.GetContainersByScanID(scanID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get containers: "+err.Error())
+		return
+	}
+	if containers == nil {
+		containers = []models.Container{}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"result":     result,
+		"containers": containers,
+	})
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/container-census/container-census/internal/exposure"
+	"github.com/container-census/container-census/internal/inspect"
+	"github.com/container-census/container-census/internal/models"
+)
+
+// handleGetContainerSecurity lists the privilege settings and risk score of current containers.
+// Filters: host_id, min_score, level (minimum risk level) and finding (e.g. "privileged").
+func (s *Server) handleGetContainerSecurity(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var filter models.ContainerSecurityFilter
+
+	if hostIDStr := query.Get("host_id"); hostIDStr != "" {
+		hostID, err := strconv.ParseInt(hostIDStr, 10, 64)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid host ID")
+			return
+		}
+		filter.HostID = hostID
+	}
+
+	if minScoreStr := query.Get("min_score"); minScoreStr != "" {
+		minScore, err := strconv.Atoi(minScoreStr)
+		if err != nil || minScore < 0 {
+			respondError(w, http.StatusBadRequest, "Invalid min_score")
+			return
+		}
+		filter.MinScore = minScore
+	}
+
+	if level := query.Get("level"); level != "" {
+		minScore, ok := inspect.RiskLevelMinScore(level)
+		if !ok {
+			respondError(w, http.StatusBadRequest, "Invalid level: must be low, medium, high or critical")
+			return
+		}
+		if minScore > filter.MinScore {
+			filter.MinScore = minScore
+		}
+	}
+
+	filter.Finding = query.Get("finding")
+
+	containers, err := s.db.GetContainerSecurity(filter)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get container security: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, containers)
+}
+
+// handleGetPortExposure reports the ports published by current containers and whether they are
+// bound to all interfaces or loopback. With probe=true the server also tries to connect to each
+// port that could be reachable from other machines.
+func (s *Server) handleGetPortExposure(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var hostID int64
+	if hostIDStr := query.Get("host_id"); hostIDStr != "" {
+		id, err := strconv.ParseInt(hostIDStr, 10, 64)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid host ID")
+			return
+		}
+		hostID = id
+	}
+
+	containers, err := s.db.GetLatestContainers()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get containers: "+err.Error())
+		return
+	}
+	if hostID != 0 {
+		filtered := make([]models.Container, 0, len(containers))
+		for _, c := range containers {
+			if c.HostID == hostID {
+				filtered = append(filtered, c)
+			}
+		}
+		containers = filtered
+	}
+
+	report := exposure.BuildReport(containers, time.Now())
+
+	if query.Get("probe") == "true" {
+		hosts, err := s.db.GetHosts()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to get hosts: "+err.Error())
+			return
+		}
+
+		opts := exposure.ProbeOptions{}
+		if timeoutMS, err := strconv.Atoi(query.Get("timeout_ms")); err == nil && timeoutMS > 0 && timeoutMS <= 10000 {
+			opts.Timeout = time.Duration(timeoutMS) * time.Millisecond
+		}
+		exposure.Probe(r.Context(), report, hosts, opts)
+	}
+
+	respondJSON(w, http.StatusOK, report)
+}
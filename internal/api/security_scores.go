@@ -0,0 +1,153 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/securityscore"
+)
+
+// buildSecurityScoreboard scores the fleet, hosts and stacks from their running containers
+func (s *Server) buildSecurityScoreboard() (*models.SecurityScoreboard, error) {
+	latest, err := s.db.GetLatestContainers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get containers: %w", err)
+	}
+	containers := filterContainers(latest, func(c models.Container) bool { return c.State == "running" })
+
+	images := make(map[string]securityscore.Image)
+	for _, c := range containers {
+		if c.ImageID == "" {
+			continue
+		}
+		if _, ok := images[c.ImageID]; ok {
+			continue
+		}
+		image := securityscore.Image{Name: c.Image}
+		image.Scan, err = s.db.GetVulnerabilityScan(c.ImageID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get scan: %w", err)
+		}
+		if image.Scan != nil && image.Scan.Success {
+			image.Vulnerabilities, err = s.db.GetVulnerabilities(c.ImageID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get vulnerabilities: %w", err)
+			}
+		}
+		images[c.ImageID] = image
+	}
+
+	security, err := s.db.GetContainerSecurity(models.ContainerSecurityFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container security: %w", err)
+	}
+	privileged := make(map[string]bool)
+	for _, c := range security {
+		if c.Privileged {
+			privileged[securityscore.Key(c.HostID, c.ContainerName)] = true
+		}
+	}
+
+	exceptions, err := s.db.GetVulnerabilityExceptions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exceptions: %w", err)
+	}
+
+	return securityscore.BuildScoreboard(containers, images, privileged, exceptions, time.Now()), nil
+}
+
+// RecordSecurityScores records today's security scores for their trend
+func (s *Server) RecordSecurityScores() {
+	board, err := s.buildSecurityScoreboard()
+	if err != nil {
+		log.Printf("Failed to compute security scores: %v", err)
+		return
+	}
+	if err := s.db.SaveSecurityScores(board); err != nil {
+		log.Printf("Failed to save security scores: %v", err)
+	}
+}
+
+// handleGetSecurityScores returns the security score of the fleet and the leaderboard of its hosts
+// and compose stacks, worst first, each with its change since the score recorded trend_days
+// (default 7) days ago
+func (s *Server) handleGetSecurityScores(w http.ResponseWriter, r *http.Request) {
+	trendDays := 7
+	if v := r.URL.Query().Get("trend_days"); v != "" {
+		d, err := strconv.Atoi(v)
+		if err != nil || d < 1 || d > 365 {
+			respondError(w, http.StatusBadRequest, "trend_days must be between 1 and 365")
+			return
+		}
+		trendDays = d
+	}
+
+	board, err := s.buildSecurityScoreboard()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to compute security scores: "+err.Error())
+		return
+	}
+	board.TrendDays = trendDays
+
+	previous, err := s.db.GetSecurityScoresAsOf(board.GeneratedAt.AddDate(0, 0, -trendDays))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get recorded security scores: "+err.Error())
+		return
+	}
+	setChange := func(score *models.SecurityScore) {
+		if before, ok := previous[models.SecurityScoreKey(score.HostID, score.Stack)]; ok {
+			change := score.Score - before
+			score.Change = &change
+		}
+	}
+	setChange(&board.Fleet)
+	for i := range board.Hosts {
+		setChange(&board.Hosts[i])
+	}
+	for i := range board.Stacks {
+		setChange(&board.Stacks[i])
+	}
+
+	respondJSON(w, http.StatusOK, board)
+}
+
+// handleGetSecurityScoreHistory returns the daily scores of the fleet, a host (?host_id=) or a
+// stack on a host (?host_id=&stack=) over the last `days` days (default 90)
+func (s *Server) handleGetSecurityScoreHistory(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var hostID int64
+	if v := q.Get("host_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id < 1 {
+			respondError(w, http.StatusBadRequest, "Invalid host_id")
+			return
+		}
+		hostID = id
+	}
+	stack := strings.TrimSpace(q.Get("stack"))
+	if stack != "" && hostID == 0 {
+		respondError(w, http.StatusBadRequest, "stack requires host_id")
+		return
+	}
+	days := 90
+	if v := q.Get("days"); v != "" {
+		d, err := strconv.Atoi(v)
+		if err != nil || d < 1 {
+			respondError(w, http.StatusBadRequest, "Invalid days")
+			return
+		}
+		days = d
+	}
+
+	points, err := s.db.GetSecurityScoreHistory(hostID, stack, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get security score history: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, points)
+}
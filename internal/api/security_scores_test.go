@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/vulnerability"
+)
+
+func TestSecurityScoreHandlers(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: "unix:///var/run/docker.sock", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	now := time.Now()
+	if err := db.SaveContainers([]models.Container{
+		{ID: "a1", Name: "app", Image: "app:1", ImageID: "sha256:app", State: "running", ComposeProject: "web", HostID: hostID, HostName: "nas", ScannedAt: now},
+		{ID: "o1", Name: "old", Image: "old:1", ImageID: "sha256:old", State: "exited", HostID: hostID, HostName: "nas", ScannedAt: now},
+	}); err != nil {
+		t.Fatalf("Failed to save containers: %v", err)
+	}
+	if err := db.SaveVulnerabilityScan(&vulnerability.VulnerabilityScan{
+		ImageID: "sha256:app", ImageName: "app:1", ScannedAt: time.Now(), Success: true,
+		TotalVulnerabilities: 1, SeverityCounts: vulnerability.SeverityCounts{Critical: 1},
+	}, []vulnerability.Vulnerability{
+		{ImageID: "sha256:app", VulnerabilityID: "CVE-2024-0001", PkgName: "openssl", Severity: "CRITICAL"},
+	}); err != nil {
+		t.Fatalf("Failed to save vulnerability scan: %v", err)
+	}
+
+	// A week ago the fleet scored 100
+	if err := db.SaveSecurityScores(&models.SecurityScoreboard{GeneratedAt: time.Now().AddDate(0, 0, -7), Fleet: models.SecurityScore{Score: 100}}); err != nil {
+		t.Fatalf("Failed to save scores: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	server.handleGetSecurityScores(w, httptest.NewRequest(http.MethodGet, "/api/security/scores?trend_days=0", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for trend_days=0, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleGetSecurityScores(w, httptest.NewRequest(http.MethodGet, "/api/security/scores", nil))
+	var board models.SecurityScoreboard
+	if err := json.Unmarshal(w.Body.Bytes(), &board); err != nil {
+		t.Fatalf("Failed to decode scoreboard: %v", err)
+	}
+	// Only the running app counts: one critical vulnerability
+	if board.TrendDays != 7 || board.Fleet.Containers != 1 || board.Fleet.Critical != 1 || board.Fleet.Score != 91 {
+		t.Errorf("Unexpected fleet score: %+v", board)
+	}
+	if board.Fleet.Change == nil || *board.Fleet.Change != -9 {
+		t.Errorf("Expected a change of -9 since a week ago, got %v", board.Fleet.Change)
+	}
+	if len(board.Hosts) != 1 || board.Hosts[0].Change != nil || len(board.Stacks) != 1 || board.Stacks[0].Stack != "web" {
+		t.Errorf("Expected the host and the web stack without a trend, got %+v %+v", board.Hosts, board.Stacks)
+	}
+
+	server.RecordSecurityScores()
+	w = httptest.NewRecorder()
+	server.handleGetSecurityScoreHistory(w, httptest.NewRequest(http.MethodGet, "/api/security/scores/history?host_id="+itoa(hostID)+"&stack=web", nil))
+	var points []models.SecurityScorePoint
+	if err := json.Unmarshal(w.Body.Bytes(), &points); err != nil {
+		t.Fatalf("Failed to decode history: %v", err)
+	}
+	if len(points) != 1 || points[0].Score != 91 {
+		t.Errorf("Expected today's web stack score, got %+v", points)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleGetSecurityScoreHistory(w, httptest.NewRequest(http.MethodGet, "/api/security/scores/history?stack=web", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a stack without host_id, got %d", w.Code)
+	}
+}
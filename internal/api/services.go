@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// attachServiceMetadata sets the service metadata read from the containers' labels
+func attachServiceMetadata(containers []models.Container) {
+	for i := range containers {
+		containers[i].Service = models.ServiceMetadataFor(containers[i].Labels)
+	}
+}
+
+// handleGetServices lists the services of the latest scans for a dashboard of service cards:
+// the containers whose labels give them a name or a URL to open (Homepage's homepage.* labels or
+// Traefik routers), by group and name
+func (s *Server) handleGetServices(w http.ResponseWriter, r *http.Request) {
+	containers, err := s.latestContainers()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get containers: "+err.Error())
+		return
+	}
+	if containers, err = s.visibleContainers(r, containers); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get hosts: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, serviceCards(containers))
+}
+
+// serviceCards returns the cards of the containers with a service name or URL, sorted by group
+// (ungrouped last), name and host
+func serviceCards(containers []models.Container) []models.ServiceCard {
+	cards := []models.ServiceCard{}
+	for _, c := range containers {
+		meta := models.ServiceMetadataFor(c.Labels)
+		if meta == nil || (meta.Name == "" && meta.URL == "") {
+			continue
+		}
+		if meta.Name == "" {
+			meta.Name = c.Name
+		}
+		cards = append(cards, models.ServiceCard{
+			HostID:          c.HostID,
+			HostName:        c.HostName,
+			ContainerName:   c.Name,
+			Image:           c.Image,
+			State:           c.State,
+			ServiceMetadata: *meta,
+		})
+	}
+
+	sort.SliceStable(cards, func(i, j int) bool {
+		a, b := cards[i], cards[j]
+		if (a.Group == "") != (b.Group == "") {
+			return b.Group == ""
+		}
+		if !strings.EqualFold(a.Group, b.Group) {
+			return strings.ToLower(a.Group) < strings.ToLower(b.Group)
+		}
+		if !strings.EqualFold(a.Name, b.Name) {
+			return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+		}
+		return a.HostName < b.HostName
+	})
+	return cards
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestTraefikRouterURLs(t *testing.T) {
+	labels := map[string]string{
+		"traefik.enable":                                          "true",
+		"traefik.http.routers.jellyfin.rule":                      "Host(`jellyfin.example.com`) || Host(`tv.example.com`)",
+		"traefik.http.routers.jellyfin.entrypoints":               "websecure",
+		"traefik.http.routers.jellyfin-lan.rule":                  "Host(`jellyfin.lan`) && PathPrefix(`/web`)",
+		"traefik.http.routers.jellyfin-lan.entrypoints":           "web",
+		"traefik.http.routers.jellyfin-api.rule":                  "Host(`api.example.com`)",
+		"traefik.http.routers.jellyfin-api.tls.certresolver":      "letsencrypt",
+		"traefik.http.services.jellyfin.loadbalancer.server.port": "8096",
+	}
+	want := []string{
+		"http://jellyfin.lan/web",
+		"https://api.example.com",
+		"https://jellyfin.example.com",
+		"https://tv.example.com",
+	}
+	if got := models.TraefikRouterURLs(labels); !reflect.DeepEqual(got, want) {
+		t.Errorf("TraefikRouterURLs() = %v, want %v", got, want)
+	}
+
+	labels["traefik.enable"] = "false"
+	if got := models.TraefikRouterURLs(labels); got != nil {
+		t.Errorf("Expected no URLs for a disabled container, got %v", got)
+	}
+}
+
+func TestServiceMetadataFor(t *testing.T) {
+	if m := models.ServiceMetadataFor(map[string]string{"com.docker.compose.project": "media"}); m != nil {
+		t.Errorf("Expected no metadata without service labels, got %+v", m)
+	}
+
+	m := models.ServiceMetadataFor(map[string]string{
+		"homepage.group":                         "Media",
+		"homepage.icon":                          "jellyfin.svg",
+		"traefik.http.routers.jf.rule":           "Host(`jellyfin.example.com`)",
+		"traefik.http.routers.jf.tls":            "true",
+		"org.opencontainers.image.description":   "The Free Software Media System",
+		"org.opencontainers.image.url":           "https://jellyfin.org",
+		"org.opencontainers.image.source":        "https://github.com/jellyfin/jellyfin",
+		"org.opencontainers.image.documentation": "https://jellyfin.org/docs",
+	})
+	want := &models.ServiceMetadata{
+		Group:       "Media",
+		Description: "The Free Software Media System",
+		Icon:        "jellyfin.svg",
+		IconURL:     "https://cdn.jsdelivr.net/gh/walkxcode/dashboard-icons/svg/jellyfin.svg",
+		URL:         "https://jellyfin.example.com",
+		URLs:        []string{"https://jellyfin.example.com"},
+		DocsURL:     "https://jellyfin.org/docs",
+		SourceURL:   "https://github.com/jellyfin/jellyfin",
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("ServiceMetadataFor() = %+v, want %+v", m, want)
+	}
+
+	m = models.ServiceMetadataFor(map[string]string{"homepage.href": "http://nas.lan:8080", "homepage.icon": "mdi-server"})
+	if m == nil || m.URL != "http://nas.lan:8080" || m.IconURL != "" {
+		t.Errorf("Expected homepage.href as the URL and no image for an mdi icon, got %+v", m)
+	}
+}
+
+func TestServiceCards(t *testing.T) {
+	containers := []models.Container{
+		{HostID: 1, HostName: "nas", Name: "sonarr", State: "running", Labels: map[string]string{"homepage.name": "Sonarr", "homepage.group": "media"}},
+		{HostID: 1, HostName: "nas", Name: "postgres", State: "running", Labels: map[string]string{"org.opencontainers.image.source": "https://github.com/docker-library/postgres"}},
+		{HostID: 2, HostName: "vps", Name: "whoami", State: "exited", Labels: map[string]string{"traefik.http.routers.whoami.rule": "Host(`whoami.example.com`)"}},
+		{HostID: 1, HostName: "nas", Name: "jellyfin", State: "running", Labels: map[string]string{"homepage.name": "Jellyfin", "homepage.group": "Media"}},
+		{HostID: 2, HostName: "vps", Name: "grafana", State: "running", Labels: map[string]string{"homepage.name": "Grafana", "homepage.group": "Monitoring"}},
+	}
+
+	cards := serviceCards(containers)
+	var names []string
+	for _, c := range cards {
+		names = append(names, c.Name)
+	}
+	want := []string{"Jellyfin", "Sonarr", "Grafana", "whoami"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("Expected cards by group and name with ungrouped last, got %v", names)
+	}
+	if cards[3].URL != "http://whoami.example.com" || cards[3].State != "exited" || cards[3].HostName != "vps" {
+		t.Errorf("Unexpected card %+v", cards[3])
+	}
+}
@@ -31,8 +31,13 @@ func (s *Server) handleGetSettings(w http.ResponseWriter, r *http.Request) {
 	response := map[string]interface{}{
 		"scanner": settings.Scanner,
 		"telemetry": map[string]interface{}{
-			"interval_hours": settings.Telemetry.IntervalHours,
-			"endpoints":      endpoints,
+			"interval_hours":               settings.Telemetry.IntervalHours,
+			"exclude_resource_stats":       settings.Telemetry.ExcludeResourceStats,
+			"exclude_image_list":           settings.Telemetry.ExcludeImageList,
+			"exclude_architecture_metrics": settings.Telemetry.ExcludeArchitectureMetrics,
+			"exclude_timezone":             settings.Telemetry.ExcludeTimezone,
+			"share_update_lag":             settings.Telemetry.ShareUpdateLag,
+			"endpoints":                    endpoints,
 		},
 		"notification": settings.Notification,
 		"ui":           settings.UI,
@@ -140,6 +145,20 @@ func (s *Server) handleImportSettings(w http.ResponseWriter, r *http.Request) {
 		},
 	}
 
+	// Preserve the object inventory switch, telemetry opt-outs, notification retention and host down thresholds, they are not part of the YAML config
+	if current, err := s.db.LoadSystemSettings(); err == nil {
+		settings.Scanner.InventoryObjects = current.Scanner.InventoryObjects
+		settings.Telemetry.ExcludeResourceStats = current.Telemetry.ExcludeResourceStats
+		settings.Telemetry.ExcludeImageList = current.Telemetry.ExcludeImageList
+		settings.Telemetry.ExcludeArchitectureMetrics = current.Telemetry.ExcludeArchitectureMetrics
+		settings.Telemetry.ExcludeTimezone = current.Telemetry.ExcludeTimezone
+		settings.Telemetry.ShareUpdateLag = current.Telemetry.ShareUpdateLag
+		settings.Notification.LogRetentionDays = current.Notification.LogRetentionDays
+		settings.Notification.LogRetentionCount = current.Notification.LogRetentionCount
+		settings.Notification.HostDownAfterFailures = current.Notification.HostDownAfterFailures
+		settings.Notification.HostRecoveredAfterSuccesses = current.Notification.HostRecoveredAfterSuccesses
+	}
+
 	// Validate settings
 	if err := settings.Validate(); err != nil {
 		http.Error(w, fmt.Sprintf("Invalid settings in YAML: %v", err), http.StatusBadRequest)
@@ -271,6 +290,7 @@ func (s *Server) handleClearVulnerabilities(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	s.cache.Invalidate()
 	log.Println("✅ All vulnerability data cleared")
 
 	w.Header().Set("Content-Type", "application/json")
@@ -331,6 +351,7 @@ func (s *Server) handleNuclearReset(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	stats["hosts_deleted"] = len(hosts)
+	s.cache.Invalidate()
 
 	// 5. Delete all telemetry endpoints
 	endpoints, _ := s.db.GetTelemetryEndpoints()
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// handleGetSites returns the health and resource usage of every site, including one with an
+// empty name for hosts without a site
+func (s *Server) handleGetSites(w http.ResponseWriter, r *http.Request) {
+	hosts, err := s.db.GetHosts()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get hosts: "+err.Error())
+		return
+	}
+	hosts = visibleHosts(r, hosts)
+	containers, err := s.latestContainers()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get containers: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, summarizeSites(hosts, containers))
+}
+
+// siteFilter returns the site selected by a request's site parameter. ?site= (present but empty)
+// selects the hosts without a site; ok is false when the parameter is absent.
+func siteFilter(r *http.Request) (site string, ok bool) {
+	query := r.URL.Query()
+	if !query.Has("site") {
+		return "", false
+	}
+	return query.Get("site"), true
+}
+
+// siteHostIDs returns the IDs of the hosts in a site
+func siteHostIDs(hosts []models.Host, site string) map[int64]bool {
+	ids := make(map[int64]bool)
+	for _, host := range hosts {
+		if host.Site == site {
+			ids[host.ID] = true
+		}
+	}
+	return ids
+}
+
+// hostOnline reports whether a host is enabled and reachable. Other hosts than agents count as
+// online until a scan fails.
+func hostOnline(host models.Host) bool {
+	if !host.Enabled {
+		return false
+	}
+	if host.HostType == "agent" {
+		return host.AgentStatus == "online"
+	}
+	return host.AgentStatus != "offline"
+}
+
+// summarizeSites aggregates hosts and their latest containers per site, sorted by name with the
+// hosts without a site last. A site is down when none of its enabled hosts is online and
+// degraded when some are offline; disabled hosts don't count.
+func summarizeSites(hosts []models.Host, containers []models.Container) []models.SiteSummary {
+	bySite := make(map[string]*models.SiteSummary)
+	hostSite := make(map[int64]string, len(hosts))
+	for _, host := range hosts {
+		summary, ok := bySite[host.Site]
+		if !ok {
+			summary = &models.SiteSummary{Name: host.Site}
+			bySite[host.Site] = summary
+		}
+		hostSite[host.ID] = host.Site
+
+		summary.Hosts++
+		switch {
+		case !host.Enabled:
+			summary.HostsDisabled++
+		case hostOnline(host):
+			summary.HostsOnline++
+		}
+	}
+
+	stacks := make(map[string]map[string]bool)
+	for _, c := range containers {
+		site, ok := hostSite[c.HostID]
+		if !ok {
+			continue
+		}
+		summary := bySite[site]
+		summary.Containers++
+		if c.State == "running" {
+			summary.Running++
+			summary.CPUPercent += c.CPUPercent
+			summary.MemoryUsage += c.MemoryUsage
+		} else {
+			summary.Stopped++
+		}
+		if c.UpdateAvailable {
+			summary.UpdatesAvailable++
+		}
+		if c.ComposeProject != "" {
+			if stacks[site] == nil {
+				stacks[site] = make(map[string]bool)
+			}
+			// The same project name on two hosts is two stacks
+			stacks[site][c.HostName+"/"+c.ComposeProject] = true
+		}
+	}
+
+	summaries := make([]models.SiteSummary, 0, len(bySite))
+	for site, summary := range bySite {
+		summary.Stacks = len(stacks[site])
+
+		enabled := summary.Hosts - summary.HostsDisabled
+		switch {
+		case enabled > 0 && summary.HostsOnline == 0:
+			summary.Health = models.SiteDown
+		case summary.HostsOnline < enabled:
+			summary.Health = models.SiteDegraded
+		default:
+			summary.Health = models.SiteHealthy
+		}
+		summaries = append(summaries, *summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		if (summaries[i].Name == "") != (summaries[j].Name == "") {
+			return summaries[j].Name == ""
+		}
+		return summaries[i].Name < summaries[j].Name
+	})
+	return summaries
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestSummarizeSites(t *testing.T) {
+	hosts := []models.Host{
+		{ID: 1, Name: "nas", Site: "home", HostType: "unix", Enabled: true},
+		{ID: 2, Name: "pi", Site: "home", HostType: "agent", AgentStatus: "offline", Enabled: true},
+		{ID: 3, Name: "vps", Site: "cloud", HostType: "agent", AgentStatus: "offline", Enabled: true},
+		{ID: 4, Name: "old", Site: "cloud", HostType: "tcp", Enabled: false},
+		{ID: 5, Name: "laptop", HostType: "agent", AgentStatus: "online", Enabled: true},
+	}
+	containers := []models.Container{
+		{HostID: 1, HostName: "nas", Name: "web", State: "running", ComposeProject: "media", CPUPercent: 10, MemoryUsage: 100},
+		{HostID: 1, HostName: "nas", Name: "db", State: "running", ComposeProject: "media", CPUPercent: 5, MemoryUsage: 50, UpdateAvailable: true},
+		{HostID: 2, HostName: "pi", Name: "dns", State: "exited", ComposeProject: "media"},
+		{HostID: 5, HostName: "laptop", Name: "dev", State: "running"},
+		{HostID: 99, HostName: "gone", Name: "orphan", State: "running"}, // host was deleted
+	}
+
+	sites := summarizeSites(hosts, containers)
+	if len(sites) != 3 || sites[0].Name != "cloud" || sites[1].Name != "home" || sites[2].Name != "" {
+		t.Fatalf("Expected cloud, home and the unassigned hosts last, got %+v", sites)
+	}
+
+	cloud, home, unassigned := sites[0], sites[1], sites[2]
+	if cloud.Health != models.SiteDown || cloud.Hosts != 2 || cloud.HostsDisabled != 1 || cloud.HostsOnline != 0 {
+		t.Errorf("Expected cloud to be down with one disabled host, got %+v", cloud)
+	}
+	if home.Health != models.SiteDegraded || home.HostsOnline != 1 || home.Containers != 3 || home.Running != 2 || home.Stopped != 1 {
+		t.Errorf("Expected home to be degraded with 2 of 3 containers running, got %+v", home)
+	}
+	// media on nas and media on pi are separate stacks
+	if home.Stacks != 2 || home.UpdatesAvailable != 1 || home.CPUPercent != 15 || home.MemoryUsage != 150 {
+		t.Errorf("Unexpected home aggregates: %+v", home)
+	}
+	if unassigned.Health != models.SiteHealthy || unassigned.Containers != 1 {
+		t.Errorf("Expected the unassigned laptop to be healthy, got %+v", unassigned)
+	}
+}
+
+func TestSiteFilter(t *testing.T) {
+	if _, ok := siteFilter(httptest.NewRequest("GET", "/api/containers", nil)); ok {
+		t.Error("Expected no filter without a site parameter")
+	}
+	if site, ok := siteFilter(httptest.NewRequest("GET", "/api/containers?site=home", nil)); !ok || site != "home" {
+		t.Errorf("Expected the home site, got %q (%v)", site, ok)
+	}
+	if site, ok := siteFilter(httptest.NewRequest("GET", "/api/containers?site=", nil)); !ok || site != "" {
+		t.Errorf("Expected an empty site parameter to select unassigned hosts, got %q (%v)", site, ok)
+	}
+}
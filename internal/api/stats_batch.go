@@ -0,0 +1,89 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// maxStatsBatchContainers caps the containers of one batch stats request
+const maxStatsBatchContainers = 200
+
+// handleGetContainerStatsBatch returns the stats of several containers in one call, for
+// overviews with a sparkline per container. JSON: {"containers": [{"host_id", "container_id"}],
+// "range": "1h|24h|7d|all", "max_points": 20} plus the resolution, agg and fill options of the
+// single container endpoint. The answer maps "<host_id>-<container_id>" to
+// its points; containers of hosts the user can't see are reported in "errors".
+func (s *Server) handleGetContainerStatsBatch(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Containers []models.ContainerStatsKey `json:"containers"`
+		Range      string                     `json:"range"`
+		MaxPoints  int                        `json:"max_points"`
+		Resolution string                     `json:"resolution"`
+		Agg        string                     `json:"agg"`
+		Fill       string                     `json:"fill"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	hoursBack, ok := statsRangeHours(req.Range)
+	if !ok {
+		respondError(w, http.StatusBadRequest, "Invalid range. Use: 1h, 24h, 7d, or all")
+		return
+	}
+	opts, err := parseStatsOptions(req.Resolution, req.Agg, req.Fill, req.MaxPoints)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(req.Containers) > maxStatsBatchContainers {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("At most %d containers per request", maxStatsBatchContainers))
+		return
+	}
+
+	hosts, err := s.db.GetHosts()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get hosts: "+err.Error())
+		return
+	}
+	visible := make(map[int64]bool)
+	for _, host := range visibleHosts(r, hosts) {
+		visible[host.ID] = true
+	}
+
+	errs := make(map[string]string)
+	keys := make([]models.ContainerStatsKey, 0, len(req.Containers))
+	for _, key := range req.Containers {
+		if !visible[key.HostID] {
+			errs[statsBatchKey(key)] = "Host not found"
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	stats, err := s.db.GetContainerStatsBatch(keys, hoursBack)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get container stats: "+err.Error())
+		return
+	}
+
+	now := time.Now()
+	response := make(map[string][]models.ContainerStatsPoint, len(stats))
+	for key, points := range stats {
+		response[statsBatchKey(key)] = opts.apply(points, hoursBack, now)
+	}
+	result := map[string]interface{}{"stats": response}
+	if len(errs) > 0 {
+		result["errors"] = errs
+	}
+	respondJSON(w, http.StatusOK, result)
+}
+
+func statsBatchKey(key models.ContainerStatsKey) string {
+	return fmt.Sprintf("%d-%s", key.HostID, key.ContainerID)
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/auth"
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestGetContainerStatsBatch_Tenants(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	ownID, err := db.AddHost(models.Host{Name: "own", Address: "agent://own:9876", Enabled: true, TenantID: 1})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	otherID, err := db.AddHost(models.Host{Name: "other", Address: "agent://other:9876", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	now := time.Now()
+	for i := 0; i < 30; i++ {
+		scannedAt := now.Add(time.Duration(i-30) * time.Minute)
+		containers := []models.Container{
+			{ID: "aaaaaaaaaaaa", Name: "web", Image: "nginx", State: "running", HostID: ownID, ScannedAt: scannedAt, CPUPercent: 5, MemoryUsage: 100, MemoryLimit: 1000},
+		}
+		if err := db.SaveContainers(containers); err != nil {
+			t.Fatalf("Failed to save containers: %v", err)
+		}
+		other := models.Container{ID: "bbbbbbbbbbbb", Name: "db", Image: "postgres", State: "running", HostID: otherID, ScannedAt: scannedAt, CPUPercent: 5, MemoryUsage: 100, MemoryLimit: 1000}
+		if err := db.SaveContainers([]models.Container{other}); err != nil {
+			t.Fatalf("Failed to save containers: %v", err)
+		}
+	}
+
+	body := `{"range": "1h", "max_points": 10, "containers": [{"host_id": ` + itoa(ownID) + `, "container_id": "aaaaaaaaaaaa"}, {"host_id": ` + itoa(otherID) + `, "container_id": "bbbbbbbbbbbb"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/containers/stats/batch", strings.NewReader(body))
+	req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{Username: "tenant", TenantID: 1}))
+	w := httptest.NewRecorder()
+	server.handleGetContainerStatsBatch(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Stats  map[string][]models.ContainerStatsPoint `json:"stats"`
+		Errors map[string]string                       `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	own := itoa(ownID) + "-aaaaaaaaaaaa"
+	if n := len(resp.Stats[own]); n == 0 || n > 10 {
+		t.Errorf("Expected up to 10 points for the tenant's container, got %d", n)
+	}
+	other := itoa(otherID) + "-bbbbbbbbbbbb"
+	if _, ok := resp.Stats[other]; ok || resp.Errors[other] == "" {
+		t.Errorf("Expected the other tenant's container to be refused, got %+v", resp)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/containers/stats/batch", strings.NewReader(`{"range": "2h"}`))
+	w = httptest.NewRecorder()
+	server.handleGetContainerStatsBatch(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid range, got %d", w.Code)
+	}
+}
@@ -0,0 +1,302 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// Aggregations combining the points of a bucket
+const (
+	statsAggAvg = "avg"
+	statsAggMax = "max"
+	statsAggP95 = "p95"
+	statsAggP99 = "p99"
+)
+
+// Gap filling of buckets without data
+const (
+	statsFillNone  = ""
+	statsFillNull  = "null"  // gap points with null values
+	statsFillCarry = "carry" // the previous bucket's values
+)
+
+// statsOptions shapes a stats series on the server: bucketing to a fixed resolution, the
+// aggregation of each bucket, gap filling and a cap on the number of points. The zero value
+// returns the series as stored.
+type statsOptions struct {
+	Resolution  time.Duration // 0 keeps the raw points
+	Aggregation string
+	Fill        string
+	MaxPoints   int
+}
+
+// statsRangeHours returns the hours back of a stats range parameter (0 for all data)
+func statsRangeHours(rangeParam string) (int, bool) {
+	switch rangeParam {
+	case "1h":
+		return 1, true
+	case "24h":
+		return 24, true
+	case "7d":
+		return 24 * 7, true
+	case "all", "":
+		return 0, true
+	}
+	return 0, false
+}
+
+// parseMaxPoints reads the max_points parameter; 0 means all points
+func parseMaxPoints(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("max_points must be a positive number")
+	}
+	return n, nil
+}
+
+// parseStatsOptions validates the resolution (raw, 5m, 1h), agg (avg, max, p95, p99) and fill
+// (null, carry) parameters. Gap filling needs a resolution to know where the gaps are.
+func parseStatsOptions(resolution, agg, fill string, maxPoints int) (statsOptions, error) {
+	opts := statsOptions{Aggregation: statsAggAvg, Fill: fill, MaxPoints: maxPoints}
+	if maxPoints < 0 {
+		return opts, fmt.Errorf("max_points must be a positive number")
+	}
+
+	switch resolution {
+	case "", "raw":
+	case "5m":
+		opts.Resolution = 5 * time.Minute
+	case "1h":
+		opts.Resolution = time.Hour
+	default:
+		return opts, fmt.Errorf("Invalid resolution. Use: raw, 5m, or 1h")
+	}
+
+	switch agg {
+	case "":
+	case statsAggAvg, statsAggMax, statsAggP95, statsAggP99:
+		opts.Aggregation = agg
+	default:
+		return opts, fmt.Errorf("Invalid agg. Use: avg, max, p95, or p99")
+	}
+
+	switch fill {
+	case statsFillNone:
+	case statsFillNull, statsFillCarry:
+		if opts.Resolution == 0 {
+			return opts, fmt.Errorf("fill needs a resolution of 5m or 1h")
+		}
+	default:
+		return opts, fmt.Errorf("Invalid fill. Use: null or carry")
+	}
+	return opts, nil
+}
+
+// apply shapes a series sorted by time. Gap filling starts at the beginning of the range when it
+// has one (hoursBack > 0), else at the first point, and runs to now.
+func (o statsOptions) apply(points []models.ContainerStatsPoint, hoursBack int, now time.Time) []models.ContainerStatsPoint {
+	if o.Resolution > 0 {
+		points = resampleStats(points, o.Resolution, o.Aggregation)
+		if o.Fill != statsFillNone {
+			var from time.Time
+			if hoursBack > 0 {
+				from = now.Add(-time.Duration(hoursBack) * time.Hour)
+			}
+			points = fillStatsGaps(points, o.Resolution, from, now, o.Fill)
+		}
+	}
+	return downsampleStats(points, o.MaxPoints, o.Aggregation)
+}
+
+// resampleStats combines the points of each resolution bucket into one point at the start of
+// the bucket
+func resampleStats(points []models.ContainerStatsPoint, resolution time.Duration, agg string) []models.ContainerStatsPoint {
+	var sampled []models.ContainerStatsPoint
+	for start := 0; start < len(points); {
+		bucket := points[start].Timestamp.Truncate(resolution)
+		end := start + 1
+		for end < len(points) && points[end].Timestamp.Truncate(resolution).Equal(bucket) {
+			end++
+		}
+		point := aggregateStats(points[start:end], agg)
+		point.Timestamp = bucket
+		sampled = append(sampled, point)
+		start = end
+	}
+	return sampled
+}
+
+// fillStatsGaps adds a point for every bucket between from (or the first point when from is zero)
+// and to that has none. Carry-forward copies the previous values; gaps before the first point
+// stay null since there is nothing to carry.
+func fillStatsGaps(points []models.ContainerStatsPoint, resolution time.Duration, from, to time.Time, fill string) []models.ContainerStatsPoint {
+	if from.IsZero() {
+		if len(points) == 0 {
+			return points
+		}
+		from = points[0].Timestamp
+	}
+	from = from.Truncate(resolution)
+	to = to.Truncate(resolution)
+	if len(points) > 0 && points[len(points)-1].Timestamp.After(to) {
+		to = points[len(points)-1].Timestamp
+	}
+
+	filled := make([]models.ContainerStatsPoint, 0, int(to.Sub(from)/resolution)+1)
+	i := 0
+	// Points before the range start (e.g. an hourly aggregate) only seed carry-forward
+	var previous *models.ContainerStatsPoint
+	for ; i < len(points) && points[i].Timestamp.Before(from); i++ {
+		previous = &points[i]
+	}
+	for t := from; !t.After(to); t = t.Add(resolution) {
+		if i < len(points) && points[i].Timestamp.Equal(t) {
+			filled = append(filled, points[i])
+			previous = &points[i]
+			i++
+			continue
+		}
+		if fill == statsFillCarry && previous != nil {
+			point := *previous
+			point.Timestamp = t
+			filled = append(filled, point)
+			continue
+		}
+		filled = append(filled, models.ContainerStatsPoint{Timestamp: t, Gap: true})
+	}
+	return filled
+}
+
+// downsampleStats reduces a series to at most maxPoints points by aggregating runs of consecutive
+// points; each point keeps the time of the first of its run. maxPoints 0 keeps the series as it is.
+func downsampleStats(points []models.ContainerStatsPoint, maxPoints int, agg string) []models.ContainerStatsPoint {
+	if maxPoints <= 0 || len(points) <= maxPoints {
+		return points
+	}
+
+	size := (len(points) + maxPoints - 1) / maxPoints
+	sampled := make([]models.ContainerStatsPoint, 0, maxPoints)
+	for start := 0; start < len(points); start += size {
+		end := start + size
+		if end > len(points) {
+			end = len(points)
+		}
+		point := aggregateStats(points[start:end], agg)
+		point.Timestamp = points[start].Timestamp
+		sampled = append(sampled, point)
+	}
+	return sampled
+}
+
+// aggregateStats combines points with avg, max, p95 or p99; memory limits always take the largest.
+// Hourly aggregates bring the max and percentiles of their hour for CPU and memory, which are used
+// instead of their average. Gap points are left out, and a run of only gaps stays a gap.
+func aggregateStats(run []models.ContainerStatsPoint, agg string) models.ContainerStatsPoint {
+	var data []models.ContainerStatsPoint
+	for _, p := range run {
+		if !p.Gap {
+			data = append(data, p)
+		}
+	}
+	if len(data) == 0 {
+		return models.ContainerStatsPoint{Timestamp: run[0].Timestamp, Gap: true}
+	}
+
+	metric := func(value func(models.ContainerStatsPoint) float64) float64 {
+		values := make([]float64, len(data))
+		for i, p := range data {
+			values[i] = value(p)
+		}
+		return aggregateValues(values, agg)
+	}
+	point := models.ContainerStatsPoint{
+		Timestamp:      data[0].Timestamp,
+		CPUPercent:     metric(func(p models.ContainerStatsPoint) float64 { return hourlyCPU(p, agg) }),
+		MemoryUsage:    int64(metric(func(p models.ContainerStatsPoint) float64 { return hourlyMemory(p, agg) })),
+		MemoryPercent:  metric(func(p models.ContainerStatsPoint) float64 { return p.MemoryPercent }),
+		NetworkRxRate:  metric(func(p models.ContainerStatsPoint) float64 { return p.NetworkRxRate }),
+		NetworkTxRate:  metric(func(p models.ContainerStatsPoint) float64 { return p.NetworkTxRate }),
+		BlockReadRate:  metric(func(p models.ContainerStatsPoint) float64 { return p.BlockReadRate }),
+		BlockWriteRate: metric(func(p models.ContainerStatsPoint) float64 { return p.BlockWriteRate }),
+	}
+	for _, p := range data {
+		if p.MemoryLimit > point.MemoryLimit {
+			point.MemoryLimit = p.MemoryLimit
+		}
+	}
+	return point
+}
+
+// hourlyCPU returns the CPU of a point for an aggregation, from the hour's spread when it has one
+func hourlyCPU(p models.ContainerStatsPoint, agg string) float64 {
+	var v *float64
+	switch agg {
+	case statsAggMax:
+		v = p.MaxCPUPercent
+	case statsAggP95:
+		v = p.P95CPUPercent
+	case statsAggP99:
+		v = p.P99CPUPercent
+	}
+	if v != nil {
+		return *v
+	}
+	return p.CPUPercent
+}
+
+// hourlyMemory returns the memory usage of a point for an aggregation, from the hour's spread
+// when it has one
+func hourlyMemory(p models.ContainerStatsPoint, agg string) float64 {
+	var v *int64
+	switch agg {
+	case statsAggMax:
+		v = p.MaxMemoryUsage
+	case statsAggP95:
+		v = p.P95MemoryUsage
+	case statsAggP99:
+		v = p.P99MemoryUsage
+	}
+	if v != nil {
+		return float64(*v)
+	}
+	return float64(p.MemoryUsage)
+}
+
+// aggregateValues reduces values to their average, maximum or 95th/99th percentile (nearest rank)
+func aggregateValues(values []float64, agg string) float64 {
+	switch agg {
+	case statsAggMax:
+		max := values[0]
+		for _, v := range values[1:] {
+			if v > max {
+				max = v
+			}
+		}
+		return max
+	case statsAggP95, statsAggP99:
+		percentile := 0.95
+		if agg == statsAggP99 {
+			percentile = 0.99
+		}
+		sorted := append([]float64(nil), values...)
+		sort.Float64s(sorted)
+		rank := int(math.Ceil(percentile*float64(len(sorted)))) - 1
+		if rank < 0 {
+			rank = 0
+		}
+		return sorted[rank]
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestDownsampleStats(t *testing.T) {
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	var points []models.ContainerStatsPoint
+	for i := 0; i < 10; i++ {
+		points = append(points, models.ContainerStatsPoint{
+			Timestamp:   start.Add(time.Duration(i) * time.Minute),
+			CPUPercent:  float64(i),
+			MemoryUsage: int64(100 * i),
+			MemoryLimit: int64(1000 + i),
+		})
+	}
+
+	if got := downsampleStats(points, 0, statsAggAvg); len(got) != 10 {
+		t.Errorf("max 0 should keep all points, got %d", len(got))
+	}
+	if got := downsampleStats(points, 20, statsAggAvg); len(got) != 10 {
+		t.Errorf("a short series should be kept, got %d", len(got))
+	}
+
+	got := downsampleStats(points, 4, statsAggAvg)
+	if len(got) != 4 {
+		t.Fatalf("Expected 4 points, got %d", len(got))
+	}
+	// Runs of 3: [0 1 2] [3 4 5] [6 7 8] [9]
+	if got[0].CPUPercent != 1 || got[0].MemoryUsage != 100 || got[0].MemoryLimit != 1002 || !got[0].Timestamp.Equal(start) {
+		t.Errorf("Unexpected first point %+v", got[0])
+	}
+	if got[3].CPUPercent != 9 || !got[3].Timestamp.Equal(start.Add(9*time.Minute)) {
+		t.Errorf("Unexpected last point %+v", got[3])
+	}
+}
+
+func TestParseStatsOptions(t *testing.T) {
+	valid := []struct{ resolution, agg, fill string }{
+		{"", "", ""},
+		{"raw", "max", ""},
+		{"5m", "p95", "null"},
+		{"5m", "p99", ""},
+		{"1h", "avg", "carry"},
+	}
+	for _, v := range valid {
+		if _, err := parseStatsOptions(v.resolution, v.agg, v.fill, 0); err != nil {
+			t.Errorf("%+v: unexpected error %v", v, err)
+		}
+	}
+
+	invalid := []struct{ resolution, agg, fill string }{
+		{"10m", "", ""},
+		{"5m", "min", ""},
+		{"5m", "", "zero"},
+		{"raw", "", "null"}, // gaps need buckets
+	}
+	for _, v := range invalid {
+		if _, err := parseStatsOptions(v.resolution, v.agg, v.fill, 0); err == nil {
+			t.Errorf("%+v: expected an error", v)
+		}
+	}
+	if _, err := parseStatsOptions("", "", "", -1); err == nil {
+		t.Error("Expected an error for negative max_points")
+	}
+}
+
+func TestResampleStats(t *testing.T) {
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	var points []models.ContainerStatsPoint
+	// 20 points a minute apart: buckets 12:00 (0-4), 12:05 (5-9), 12:10 (10-14), 12:15 (15-19)
+	for i := 0; i < 20; i++ {
+		points = append(points, models.ContainerStatsPoint{
+			Timestamp:  start.Add(time.Duration(i)*time.Minute + 30*time.Second),
+			CPUPercent: float64(i + 1),
+		})
+	}
+
+	tests := []struct {
+		agg  string
+		want float64 // CPU of the first bucket, values 1..5
+	}{
+		{statsAggAvg, 3},
+		{statsAggMax, 5},
+		{statsAggP95, 5},
+	}
+	for _, tt := range tests {
+		got := resampleStats(points, 5*time.Minute, tt.agg)
+		if len(got) != 4 {
+			t.Fatalf("%s: expected 4 buckets, got %d", tt.agg, len(got))
+		}
+		if !got[0].Timestamp.Equal(start) || !got[1].Timestamp.Equal(start.Add(5*time.Minute)) {
+			t.Errorf("%s: buckets should start on the resolution, got %v %v", tt.agg, got[0].Timestamp, got[1].Timestamp)
+		}
+		if got[0].CPUPercent != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.agg, tt.want, got[0].CPUPercent)
+		}
+	}
+
+	// p95 of 1..100 is 95
+	values := make([]float64, 100)
+	for i := range values {
+		values[i] = float64(i + 1)
+	}
+	if got := aggregateValues(values, statsAggP95); got != 95 {
+		t.Errorf("Expected p95 95, got %v", got)
+	}
+	if got := aggregateValues(values, statsAggP99); got != 99 {
+		t.Errorf("Expected p99 99, got %v", got)
+	}
+
+	// Hourly aggregates bring their own spread
+	p95, max := 80.0, 95.0
+	hourly := []models.ContainerStatsPoint{{Timestamp: start, CPUPercent: 20, P95CPUPercent: &p95, MaxCPUPercent: &max}}
+	if got := resampleStats(hourly, time.Hour, statsAggP95); got[0].CPUPercent != 80 {
+		t.Errorf("Expected the stored p95, got %v", got[0].CPUPercent)
+	}
+	if got := resampleStats(hourly, time.Hour, statsAggMax); got[0].CPUPercent != 95 {
+		t.Errorf("Expected the stored max, got %v", got[0].CPUPercent)
+	}
+	if got := resampleStats(hourly, time.Hour, statsAggP99); got[0].CPUPercent != 20 {
+		t.Errorf("Expected the average without a stored p99, got %v", got[0].CPUPercent)
+	}
+}
+
+func TestFillStatsGaps(t *testing.T) {
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	points := []models.ContainerStatsPoint{
+		{Timestamp: start.Add(5 * time.Minute), CPUPercent: 10},
+		{Timestamp: start.Add(20 * time.Minute), CPUPercent: 20},
+	}
+	to := start.Add(27 * time.Minute)
+
+	filled := fillStatsGaps(points, 5*time.Minute, start, to, statsFillNull)
+	if len(filled) != 6 {
+		t.Fatalf("Expected 6 buckets from 12:00 to 12:25, got %d", len(filled))
+	}
+	for i, gap := range []bool{true, false, true, true, false, true} {
+		if filled[i].Gap != gap {
+			t.Errorf("Bucket %d: expected gap %v, got %+v", i, gap, filled[i])
+		}
+		if !filled[i].Timestamp.Equal(start.Add(time.Duration(i) * 5 * time.Minute)) {
+			t.Errorf("Bucket %d at %v", i, filled[i].Timestamp)
+		}
+	}
+
+	carried := fillStatsGaps(points, 5*time.Minute, start, to, statsFillCarry)
+	if !carried[0].Gap {
+		t.Error("Nothing to carry before the first point")
+	}
+	if carried[2].Gap || carried[2].CPUPercent != 10 || carried[5].CPUPercent != 20 {
+		t.Errorf("Expected carried values, got %+v", carried)
+	}
+
+	// Without a range start the series starts at its first point
+	if got := fillStatsGaps(points, 5*time.Minute, time.Time{}, to, statsFillNull); len(got) != 5 || got[0].Gap {
+		t.Errorf("Expected 5 buckets starting with data, got %+v", got)
+	}
+}
+
+func TestStatsGapJSON(t *testing.T) {
+	data, err := json.Marshal([]models.ContainerStatsPoint{
+		{Timestamp: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC), CPUPercent: 1.5},
+		{Timestamp: time.Date(2026, 10, 1, 12, 5, 0, 0, time.UTC), Gap: true},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	out := string(data)
+	if !strings.Contains(out, `"cpu_percent":1.5`) || !strings.Contains(out, `"cpu_percent":null`) {
+		t.Errorf("Expected a value and a null, got %s", out)
+	}
+	if strings.Contains(out, "gap") {
+		t.Errorf("The gap flag should not be written, got %s", out)
+	}
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// handleGetStatsOverview returns fleet totals, per-host rollups and the top consumers of the
+// latest scan in one call (?limit= top consumers, default 5). Respects ?site= and tenants.
+func (s *Server) handleGetStatsOverview(w http.ResponseWriter, r *http.Request) {
+	limit := 5
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > 100 {
+			respondError(w, http.StatusBadRequest, "Invalid limit parameter. Use a number between 1 and 100")
+			return
+		}
+		limit = parsed
+	}
+
+	hosts, err := s.db.GetHosts()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get hosts: "+err.Error())
+		return
+	}
+	hosts = visibleHosts(r, hosts)
+	if site, ok := siteFilter(r); ok {
+		inSite := siteHostIDs(hosts, site)
+		filtered := make([]models.Host, 0, len(hosts))
+		for _, host := range hosts {
+			if inSite[host.ID] {
+				filtered = append(filtered, host)
+			}
+		}
+		hosts = filtered
+	}
+
+	containers, err := s.latestContainers()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get containers: "+err.Error())
+		return
+	}
+
+	respondCachedJSON(w, r, summarizeStatsOverview(hosts, containers, limit))
+}
+
+// summarizeStatsOverview rolls the latest containers up per host and for the fleet. Containers
+// of hosts not in the list are left out; hosts are sorted by name.
+func summarizeStatsOverview(hosts []models.Host, containers []models.Container, limit int) models.StatsOverview {
+	overview := models.StatsOverview{
+		Totals:    models.StatsTotals{StatsRollup: models.StatsRollup{States: make(map[string]int)}},
+		Hosts:     make([]models.HostStatsRollup, 0, len(hosts)),
+		TopCPU:    make([]models.ContainerUsage, 0),
+		TopMemory: make([]models.ContainerUsage, 0),
+	}
+
+	byHost := make(map[int64]*models.HostStatsRollup, len(hosts))
+	for _, host := range hosts {
+		online := hostOnline(host)
+		overview.Hosts = append(overview.Hosts, models.HostStatsRollup{
+			HostID:      host.ID,
+			HostName:    host.Name,
+			Online:      online,
+			StatsRollup: models.StatsRollup{States: make(map[string]int)},
+		})
+		overview.Totals.Hosts++
+		if online {
+			overview.Totals.HostsOnline++
+		}
+	}
+	for i := range overview.Hosts {
+		byHost[overview.Hosts[i].HostID] = &overview.Hosts[i]
+	}
+
+	var usage []models.ContainerUsage
+	var scannedAt time.Time
+	for _, c := range containers {
+		host, ok := byHost[c.HostID]
+		if !ok {
+			continue
+		}
+		if c.ScannedAt.After(scannedAt) {
+			scannedAt = c.ScannedAt
+		}
+		for _, rollup := range []*models.StatsRollup{&overview.Totals.StatsRollup, &host.StatsRollup} {
+			rollup.Containers++
+			rollup.States[c.State]++
+			if c.State == "running" {
+				rollup.CPUPercent += c.CPUPercent
+				rollup.MemoryUsage += c.MemoryUsage
+				rollup.MemoryLimit += c.MemoryLimit
+			}
+		}
+		// Containers without stats (collection off, not running) have no limit
+		if c.State == "running" && c.MemoryLimit > 0 {
+			usage = append(usage, models.ContainerUsage{
+				HostID:        c.HostID,
+				HostName:      host.HostName,
+				ContainerID:   c.ID,
+				ContainerName: c.Name,
+				CPUPercent:    c.CPUPercent,
+				MemoryUsage:   c.MemoryUsage,
+				MemoryLimit:   c.MemoryLimit,
+				MemoryPercent: c.MemoryPercent,
+			})
+		}
+	}
+	if !scannedAt.IsZero() {
+		overview.ScannedAt = &scannedAt
+	}
+
+	sort.Slice(overview.Hosts, func(i, j int) bool {
+		return overview.Hosts[i].HostName < overview.Hosts[j].HostName
+	})
+	overview.TopCPU = topContainerUsage(usage, limit, func(c models.ContainerUsage) float64 { return c.CPUPercent })
+	overview.TopMemory = topContainerUsage(usage, limit, func(c models.ContainerUsage) float64 { return float64(c.MemoryUsage) })
+	return overview
+}
+
+// topContainerUsage returns the limit containers with the highest value, highest first
+func topContainerUsage(usage []models.ContainerUsage, limit int, value func(models.ContainerUsage) float64) []models.ContainerUsage {
+	sorted := append([]models.ContainerUsage(nil), usage...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return value(sorted[i]) > value(sorted[j])
+	})
+	if len(sorted) > limit {
+		sorted = sorted[:limit]
+	}
+	if sorted == nil {
+		sorted = make([]models.ContainerUsage, 0)
+	}
+	return sorted
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestSummarizeStatsOverview(t *testing.T) {
+	scanned := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	hosts := []models.Host{
+		{ID: 1, Name: "nas", HostType: "unix", Enabled: true},
+		{ID: 2, Name: "pi", HostType: "agent", AgentStatus: "offline", Enabled: true},
+	}
+	containers := []models.Container{
+		{HostID: 1, ID: "a", Name: "web", State: "running", CPUPercent: 10, MemoryUsage: 300, MemoryLimit: 1000, ScannedAt: scanned},
+		{HostID: 1, ID: "b", Name: "db", State: "running", CPUPercent: 30, MemoryUsage: 100, MemoryLimit: 1000, ScannedAt: scanned},
+		{HostID: 1, ID: "c", Name: "job", State: "exited", ScannedAt: scanned},
+		{HostID: 2, ID: "d", Name: "dns", State: "running", CPUPercent: 20, MemoryUsage: 200, MemoryLimit: 500, ScannedAt: scanned.Add(-time.Hour)},
+		{HostID: 2, ID: "e", Name: "nostats", State: "running"},
+		{HostID: 99, ID: "f", Name: "orphan", State: "running", CPUPercent: 99}, // host not visible
+	}
+
+	overview := summarizeStatsOverview(hosts, containers, 2)
+
+	totals := overview.Totals
+	if totals.Hosts != 2 || totals.HostsOnline != 1 || totals.Containers != 5 {
+		t.Errorf("Unexpected totals: %+v", totals)
+	}
+	if totals.States["running"] != 4 || totals.States["exited"] != 1 {
+		t.Errorf("Unexpected states: %+v", totals.States)
+	}
+	if totals.CPUPercent != 60 || totals.MemoryUsage != 600 || totals.MemoryLimit != 2500 {
+		t.Errorf("Unexpected resource totals: %+v", totals)
+	}
+
+	if len(overview.Hosts) != 2 || overview.Hosts[0].HostName != "nas" || overview.Hosts[1].HostName != "pi" {
+		t.Fatalf("Expected nas and pi, got %+v", overview.Hosts)
+	}
+	nas, pi := overview.Hosts[0], overview.Hosts[1]
+	if !nas.Online || nas.Containers != 3 || nas.CPUPercent != 40 || nas.MemoryUsage != 400 {
+		t.Errorf("Unexpected nas rollup: %+v", nas)
+	}
+	if pi.Online || pi.Containers != 2 || pi.States["running"] != 2 {
+		t.Errorf("Unexpected pi rollup: %+v", pi)
+	}
+
+	if len(overview.TopCPU) != 2 || overview.TopCPU[0].ContainerName != "db" || overview.TopCPU[1].ContainerName != "dns" {
+		t.Errorf("Unexpected top CPU: %+v", overview.TopCPU)
+	}
+	if len(overview.TopMemory) != 2 || overview.TopMemory[0].ContainerName != "web" || overview.TopMemory[0].HostName != "nas" {
+		t.Errorf("Unexpected top memory: %+v", overview.TopMemory)
+	}
+	if overview.ScannedAt == nil || !overview.ScannedAt.Equal(scanned) {
+		t.Errorf("Expected the latest scan time, got %v", overview.ScannedAt)
+	}
+}
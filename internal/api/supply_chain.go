@@ -0,0 +1,94 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// handleGetContainerImageHistory returns the images a container has run, oldest first, with the
+// scans that saw each first and last and its latest vulnerability scan
+func (s *Server) handleGetContainerImageHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	hostID, err := strconv.ParseInt(vars["host_id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid host ID")
+		return
+	}
+
+	runs, err := s.db.GetContainerImageHistory(hostID, vars["container_name"])
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get image history: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, runs)
+}
+
+// handleGetSupplyChainTimeline lists the image runs of every container matching the query,
+// oldest first. Query: vulnerability (e.g. CVE-2024-3094), package, image (part of the name),
+// image_id (prefix), container_name and host_id. At least one of the first four is required.
+func (s *Server) handleGetSupplyChainTimeline(w http.ResponseWriter, r *http.Request) {
+	hostIDs, ok := s.queryHostIDs(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	filter := models.SupplyChainFilter{
+		HostIDs:       hostIDs,
+		ContainerName: strings.TrimSpace(q.Get("container_name")),
+		Image:         strings.TrimSpace(q.Get("image")),
+		ImageID:       strings.TrimSpace(q.Get("image_id")),
+		Vulnerability: strings.TrimSpace(q.Get("vulnerability")),
+		Package:       strings.TrimSpace(q.Get("package")),
+	}
+	if filter.Image == "" && filter.ImageID == "" && filter.Vulnerability == "" && filter.Package == "" {
+		respondError(w, http.StatusBadRequest, "One of vulnerability, package, image or image_id is required")
+		return
+	}
+
+	timeline, err := s.db.GetSupplyChainTimeline(filter)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get supply-chain timeline: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, timeline)
+}
+
+// handleSearchImageUsage lists every container seen running an image in the last `days` days
+// (default 7), current ones first, for answering "where do we run this?" when a bad image is
+// announced. Query: image (reference, tag or version, or image ID) and host_id.
+func (s *Server) handleSearchImageUsage(w http.ResponseWriter, r *http.Request) {
+	hostIDs, ok := s.queryHostIDs(w, r)
+	if !ok {
+		return
+	}
+	image := strings.TrimSpace(r.URL.Query().Get("image"))
+	if image == "" {
+		respondError(w, http.StatusBadRequest, "image is required")
+		return
+	}
+	days := 7
+	if v := r.URL.Query().Get("days"); v != "" {
+		d, err := strconv.Atoi(v)
+		if err != nil || d < 1 {
+			respondError(w, http.StatusBadRequest, "Invalid days")
+			return
+		}
+		days = d
+	}
+
+	result, err := s.db.SearchImageUsage(models.ImageSearch{
+		Image:   image,
+		Since:   time.Now().AddDate(0, 0, -days),
+		HostIDs: hostIDs,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to search image usage: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, result)
+}
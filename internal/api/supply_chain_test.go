@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/gorilla/mux"
+)
+
+func TestSupplyChainHandlers(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: "unix:///var/run/docker.sock", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	if err := db.SaveContainers([]models.Container{
+		{ID: "pg", Name: "postgres", Image: "postgres:16", ImageID: "sha256:pg16", State: "running", HostID: hostID, HostName: "nas", ScannedAt: time.Now(), ScanID: "s1"},
+	}); err != nil {
+		t.Fatalf("Failed to save containers: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/containers/image-history/"+itoa(hostID)+"/postgres", nil)
+	req = mux.SetURLVars(req, map[string]string{"host_id": itoa(hostID), "container_name": "postgres"})
+	w := httptest.NewRecorder()
+	server.handleGetContainerImageHistory(w, req)
+	var runs []models.ImageRun
+	if err := json.Unmarshal(w.Body.Bytes(), &runs); err != nil {
+		t.Fatalf("Failed to decode image history: %v", err)
+	}
+	if len(runs) != 1 || runs[0].ImageID != "sha256:pg16" || !runs[0].Current {
+		t.Errorf("Expected the current postgres run, got %+v", runs)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleGetSupplyChainTimeline(w, httptest.NewRequest(http.MethodGet, "/api/supply-chain/timeline?host_id="+itoa(hostID), nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without a vulnerability, package or image, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleGetSupplyChainTimeline(w, httptest.NewRequest(http.MethodGet, "/api/supply-chain/timeline?image_id=pg16", nil))
+	var timeline models.SupplyChainTimeline
+	if err := json.Unmarshal(w.Body.Bytes(), &timeline); err != nil {
+		t.Fatalf("Failed to decode timeline: %v", err)
+	}
+	if len(timeline.Runs) != 1 || timeline.Containers != 1 || timeline.Current != 1 {
+		t.Errorf("Expected one matching run, got %+v", timeline)
+	}
+}
+
+func TestSearchImageUsageHandler(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: "unix:///var/run/docker.sock", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	if err := db.SaveContainers([]models.Container{
+		{ID: "p1", Name: "proxy", Image: "nginx:1.27", ImageID: "sha256:ngx", State: "running", HostID: hostID, HostName: "nas", ScannedAt: time.Now()},
+	}); err != nil {
+		t.Fatalf("Failed to save containers: %v", err)
+	}
+
+	for _, target := range []string{"/api/images/usage", "/api/images/usage?image=nginx&days=0"} {
+		w := httptest.NewRecorder()
+		server.handleSearchImageUsage(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", target, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	server.handleSearchImageUsage(w, httptest.NewRequest(http.MethodGet, "/api/images/usage?image=nginx", nil))
+	var result models.ImageSearchResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if len(result.Containers) != 1 || result.Running != 1 || result.Containers[0].ContainerName != "proxy" {
+		t.Errorf("Expected the running proxy, got %+v", result)
+	}
+}
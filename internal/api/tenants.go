@@ -0,0 +1,413 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/container-census/container-census/internal/auth"
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/storage"
+	"github.com/gorilla/mux"
+)
+
+// tenantRoutes are the API routes tenant users may call, as "METHOD template". Everything else
+// (settings, scans, reports, security, telemetry, integrations, ...) is global and reserved for
+// the administrator. Host-scoped routes are checked by tenantMiddleware; list handlers filter
+// their results with visibleHosts and the notification handlers check ownership themselves.
+var tenantRoutes = map[string]bool{
+	"GET /api/me":                               true,
+	"GET /api/hosts":                            true,
+	"GET /api/sites":                            true,
+	"GET /api/hosts/engines":                    true,
+	"GET /api/hosts/agent-tokens":               true,
+	"GET /api/hosts/{id}":                       true,
+	"PUT /api/hosts/{id}":                       true,
+	"DELETE /api/hosts/{id}":                    true,
+	"PUT /api/hosts/{id}/name":                  true,
+	"GET /api/hosts/{id}/uptime":                true,
+	"GET /api/hosts/{id}/notes":                 true,
+	"PUT /api/hosts/{id}/notes":                 true,
+	"DELETE /api/hosts/{id}/notes":              true,
+	"GET /api/notes":                            true,
+	"GET /api/annotations":                      true,
+	"GET /api/daemon-events":                    true,
+	"GET /api/events":                           true,
+	"GET /api/docker-objects":                   true,
+	"POST /api/hosts/agent":                     true,
+	"POST /api/hosts/import":                    true,
+	"POST /api/hosts/agent/test":                true,
+	"POST /api/hosts/incus":                     true,
+	"POST /api/hosts/incus/test":                true,
+	"GET /api/hosts/incus/certificate":          true,
+	"GET /api/hosts/agent/{id}/info":            true,
+	"POST /api/hosts/{id}/agent-token/rotate":   true,
+	"GET /api/scan/diagnostics":                 true,
+	"POST /api/hosts/{id}/registry-mirror/test": true,
+
+	"GET /api/containers":                                        true,
+	"GET /api/containers/host/{id}":                              true,
+	"GET /api/containers/lifecycle/{host_id}/{container_name}":   true,
+	"POST /api/containers/stats/batch":                           true,
+	"GET /api/containers/{host_id}/{container_id}/stats":         true,
+	"GET /api/containers/{host_id}/{container_id}/stats/live":    true,
+	"GET /api/stats/overview":                                    true,
+	"GET /api/stats/live":                                        true,
+	"GET /api/services":                                          true,
+	"POST /api/containers/{host_id}/{container_id}/start":        true,
+	"POST /api/containers/{host_id}/{container_id}/stop":         true,
+	"POST /api/containers/{host_id}/{container_id}/restart":      true,
+	"DELETE /api/containers/{host_id}/{container_id}":            true,
+	"GET /api/containers/{host_id}/{container_id}/logs":          true,
+	"GET /api/containers/{host_id}/{container_id}/inspect":       true,
+	"GET /api/containers/{host_id}/{container_id}/recreate-spec": true,
+	"POST /api/containers/{host_id}/{container_id}/check-update": true,
+	"POST /api/containers/{host_id}/{container_id}/update":       true,
+	"GET /api/containers/{host_id}/{container_id}/changelog":     true,
+	"PUT /api/containers/{host_id}/{container_id}/pin":           true,
+	"DELETE /api/containers/{host_id}/{container_id}/pin":        true,
+	"GET /api/containers/{host_id}/{container_id}/notes":         true,
+	"PUT /api/containers/{host_id}/{container_id}/notes":         true,
+	"DELETE /api/containers/{host_id}/{container_id}/notes":      true,
+	"PUT /api/containers/{host_id}/{container_id}/owner":         true,
+	"DELETE /api/containers/{host_id}/{container_id}/owner":      true,
+	"GET /api/updates": true,
+
+	"GET /api/images":                             true,
+	"GET /api/images/host/{id}":                   true,
+	"DELETE /api/images/{host_id}/{image_id}":     true,
+	"GET /api/images/{host_id}/{image_id}/layers": true,
+	"POST /api/images/host/{id}/prune":            true,
+	"GET /api/images/host/{id}/usage":             true,
+	"GET /api/images/usage":                       true,
+	"POST /api/images/host/{id}/prune-policy":     true,
+
+	"GET /api/notifications/channels":            true,
+	"POST /api/notifications/channels":           true,
+	"PUT /api/notifications/channels/{id}":       true,
+	"DELETE /api/notifications/channels/{id}":    true,
+	"POST /api/notifications/channels/{id}/test": true,
+	"GET /api/notifications/rules":               true,
+	"POST /api/notifications/rules":              true,
+	"PUT /api/notifications/rules/{id}":          true,
+	"DELETE /api/notifications/rules/{id}":       true,
+	"GET /api/notifications/logs":                true,
+	"PUT /api/notifications/logs/{id}/read":      true,
+	"PUT /api/notifications/logs/read-all":       true,
+	"POST /api/notifications/logs/bulk":          true,
+	"GET /api/notifications/groups":              true,
+	"GET /api/notifications/stream":              true,
+	"GET /api/notifications/status":              true,
+	"GET /api/notifications/stats":               true,
+
+	"GET /api/settings":    true,
+	"GET /api/preferences": true,
+	"PUT /api/preferences": true,
+	"GET /api/changelog":   true,
+}
+
+// tenantHostRoutePrefixes are the route templates whose {id} variable is a host ID
+var tenantHostRoutePrefixes = []string{"/api/hosts/", "/api/containers/host/", "/api/images/host/"}
+
+// tenantUsers authenticates tenant users stored in the database for the auth middleware
+type tenantUsers struct {
+	db *storage.DB
+}
+
+// Authenticate implements auth.Users
+func (u tenantUsers) Authenticate(username, password string) (int64, bool) {
+	user, hash, ok, err := u.db.GetTenantUserLogin(username)
+	if err != nil {
+		log.Printf("Failed to look up tenant user %s: %v", username, err)
+		return 0, false
+	}
+	if !ok || !auth.CheckPassword(hash, password) {
+		return 0, false
+	}
+	return user.TenantID, true
+}
+
+// Tenant implements auth.Users
+func (u tenantUsers) Tenant(username string) (int64, bool) {
+	user, _, ok, err := u.db.GetTenantUserLogin(username)
+	if err != nil || !ok {
+		return 0, false
+	}
+	return user.TenantID, true
+}
+
+// identity returns the user a request is authenticated as
+func identity(r *http.Request) auth.Identity {
+	return auth.IdentityFromContext(r.Context())
+}
+
+// tenantMiddleware keeps tenant users to the routes in tenantRoutes and to their own hosts.
+// Hosts of other tenants answer 404, as if they didn't exist.
+func (s *Server) tenantMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := identity(r)
+		if id.IsAdmin() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		template, err := route.GetPathTemplate()
+		if err != nil || !tenantRoutes[r.Method+" "+template] {
+			respondError(w, http.StatusForbidden, "Not available to tenant users")
+			return
+		}
+
+		vars := mux.Vars(r)
+		hostVar := vars["host_id"]
+		for _, prefix := range tenantHostRoutePrefixes {
+			if strings.HasPrefix(template, prefix) && vars["id"] != "" {
+				hostVar = vars["id"]
+			}
+		}
+		if hostVar != "" {
+			hostID, err := strconv.ParseInt(hostVar, 10, 64)
+			if err != nil {
+				respondError(w, http.StatusBadRequest, "Invalid host ID")
+				return
+			}
+			host, err := s.db.GetHost(hostID)
+			if err != nil || host.TenantID != id.TenantID {
+				respondError(w, http.StatusNotFound, "Host not found")
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// visibleHosts returns the hosts a request may see: all of them for the administrator, the
+// tenant's hosts for tenant users
+func visibleHosts(r *http.Request, hosts []models.Host) []models.Host {
+	id := identity(r)
+	if id.IsAdmin() {
+		return hosts
+	}
+	visible := make([]models.Host, 0, len(hosts))
+	for _, host := range hosts {
+		if host.TenantID == id.TenantID {
+			visible = append(visible, host)
+		}
+	}
+	return visible
+}
+
+// visibleContainers keeps the containers of the hosts a request may see
+func (s *Server) visibleContainers(r *http.Request, containers []models.Container) ([]models.Container, error) {
+	if identity(r).IsAdmin() {
+		return containers, nil
+	}
+	hosts, err := s.db.GetHosts()
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[int64]bool)
+	for _, host := range visibleHosts(r, hosts) {
+		ids[host.ID] = true
+	}
+	filtered := make([]models.Container, 0, len(containers))
+	for _, c := range containers {
+		if ids[c.HostID] {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered, nil
+}
+
+// handleGetMe returns the identity of the logged-in user
+func (s *Server) handleGetMe(w http.ResponseWriter, r *http.Request) {
+	id := identity(r)
+	response := map[string]interface{}{
+		"username":  id.Username,
+		"admin":     id.IsAdmin(),
+		"read_only": s.readOnly(),
+	}
+	if !id.IsAdmin() {
+		response["tenant_id"] = id.TenantID
+	}
+	respondJSON(w, http.StatusOK, response)
+}
+
+// handleGetTenants lists the tenants with their users and hosts
+func (s *Server) handleGetTenants(w http.ResponseWriter, r *http.Request) {
+	tenants, err := s.db.GetTenants()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get tenants: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, tenants)
+}
+
+// handleCreateTenant adds a tenant
+func (s *Server) handleCreateTenant(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		respondError(w, http.StatusBadRequest, "Tenant name is required")
+		return
+	}
+
+	id, err := s.db.CreateTenant(name)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create tenant: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusCreated, models.Tenant{ID: id, Name: name, Users: []models.TenantUser{}, HostIDs: []int64{}})
+}
+
+// handleDeleteTenant removes a tenant with its users and notification settings; its hosts go
+// back to the administrator
+func (s *Server) handleDeleteTenant(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.tenantFromPath(w, r)
+	if !ok {
+		return
+	}
+	if err := s.db.DeleteTenant(id); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete tenant: "+err.Error())
+		return
+	}
+	if s.notificationService != nil {
+		s.notificationService.RefreshChannels()
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Tenant deleted successfully"})
+}
+
+// handleAddTenantUser adds a login to a tenant
+func (s *Server) handleAddTenantUser(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := s.tenantFromPath(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	username := strings.TrimSpace(req.Username)
+	if username == "" || len(req.Password) < 8 {
+		respondError(w, http.StatusBadRequest, "Username and a password of at least 8 characters are required")
+		return
+	}
+	if username == s.authConfig.Username {
+		respondError(w, http.StatusConflict, "Username is taken by the administrator")
+		return
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to hash password: "+err.Error())
+		return
+	}
+	id, err := s.db.AddTenantUser(tenantID, username, hash)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			respondError(w, http.StatusConflict, "Username already exists")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to add user: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusCreated, models.TenantUser{ID: id, TenantID: tenantID, Username: username})
+}
+
+// handleDeleteTenantUser removes a login of a tenant, which also ends its sessions
+func (s *Server) handleDeleteTenantUser(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := s.tenantFromPath(w, r)
+	if !ok {
+		return
+	}
+	userID, err := strconv.ParseInt(mux.Vars(r)["user_id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+	if err := s.db.DeleteTenantUser(tenantID, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to delete user: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"message": "User deleted successfully"})
+}
+
+// handleSetHostTenant assigns a host to a tenant ({"tenant_id": 0} gives it back to the administrator)
+func (s *Server) handleSetHostTenant(w http.ResponseWriter, r *http.Request) {
+	hostID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid host ID")
+		return
+	}
+	var req struct {
+		TenantID int64 `json:"tenant_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	if _, err := s.db.GetHost(hostID); err != nil {
+		respondError(w, http.StatusNotFound, "Host not found")
+		return
+	}
+	if req.TenantID != 0 {
+		exists, err := s.db.TenantExists(req.TenantID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to get tenant: "+err.Error())
+			return
+		}
+		if !exists {
+			respondError(w, http.StatusBadRequest, "Tenant not found")
+			return
+		}
+	}
+
+	if err := s.db.SetHostTenant(hostID, req.TenantID); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to assign host: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]int64{"host_id": hostID, "tenant_id": req.TenantID})
+}
+
+// tenantFromPath parses the {id} of a tenant route and checks the tenant exists, answering
+// the request if it doesn't
+func (s *Server) tenantFromPath(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid tenant ID")
+		return 0, false
+	}
+	exists, err := s.db.TenantExists(id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get tenant: "+err.Error())
+		return 0, false
+	}
+	if !exists {
+		respondError(w, http.StatusNotFound, "Tenant not found")
+		return 0, false
+	}
+	return id, true
+}
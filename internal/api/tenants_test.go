@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/container-census/container-census/internal/auth"
+	"github.com/container-census/container-census/internal/models"
+	"github.com/gorilla/mux"
+)
+
+func TestTenantMiddlewareIsolatesHosts(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	tenantID, err := db.CreateTenant("family")
+	if err != nil {
+		t.Fatalf("CreateTenant failed: %v", err)
+	}
+	ownID, err := db.AddHost(models.Host{Name: "family-nas", Address: "agent://nas:9876", Enabled: true, TenantID: tenantID})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	otherID, err := db.AddHost(models.Host{Name: "homelab", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	if _, err := db.AddHost(models.Host{Name: "client", Address: "unix:///", Enabled: true, TenantID: tenantID + 1}); err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	if err := db.SaveNotificationChannel(&models.NotificationChannel{Name: "admin-ntfy", Type: models.ChannelTypeInApp, Config: map[string]interface{}{}, Enabled: true}); err != nil {
+		t.Fatalf("Failed to save channel: %v", err)
+	}
+
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api").Subrouter()
+	api.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := auth.Identity{Username: r.Header.Get("X-Test-User")}
+			if id.Username == "kid" {
+				id.TenantID = tenantID
+			}
+			next.ServeHTTP(w, r.WithContext(auth.WithIdentity(r.Context(), id)))
+		})
+	})
+	api.Use(server.tenantMiddleware)
+	api.HandleFunc("/hosts", server.handleGetHosts).Methods("GET")
+	api.HandleFunc("/hosts/{id}", server.handleGetHost).Methods("GET")
+	api.HandleFunc("/notifications/channels", server.handleGetNotificationChannels).Methods("GET")
+	api.HandleFunc("/settings/reset", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}).Methods("POST")
+
+	do := func(user, method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("X-Test-User", user)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	var hosts []models.Host
+	rec := do("kid", "GET", "/api/hosts")
+	if err := json.Unmarshal(rec.Body.Bytes(), &hosts); err != nil {
+		t.Fatalf("Failed to decode hosts: %v (%s)", err, rec.Body.String())
+	}
+	if len(hosts) != 1 || hosts[0].ID != ownID {
+		t.Errorf("Expected only the tenant's host, got %+v", hosts)
+	}
+	rec = do("admin", "GET", "/api/hosts")
+	if err := json.Unmarshal(rec.Body.Bytes(), &hosts); err != nil || len(hosts) != 3 {
+		t.Errorf("Expected the administrator to see all 3 hosts, got %d (%v)", len(hosts), err)
+	}
+
+	if rec := do("kid", "GET", "/api/hosts/"+itoa(ownID)); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 for the tenant's host, got %d", rec.Code)
+	}
+	if rec := do("kid", "GET", "/api/hosts/"+itoa(otherID)); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for another tenant's host, got %d", rec.Code)
+	}
+	if rec := do("kid", "POST", "/api/settings/reset"); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a global route, got %d", rec.Code)
+	}
+	if rec := do("admin", "POST", "/api/settings/reset"); rec.Code != http.StatusOK {
+		t.Errorf("Expected the administrator to reach global routes, got %d", rec.Code)
+	}
+
+	var channels []models.NotificationChannel
+	rec = do("kid", "GET", "/api/notifications/channels")
+	if err := json.Unmarshal(rec.Body.Bytes(), &channels); err != nil || len(channels) != 0 {
+		t.Errorf("Expected no channels for the tenant, got %+v (%v)", channels, err)
+	}
+}
+
+func itoa(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
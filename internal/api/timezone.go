@@ -0,0 +1,197 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Preference keys the server understands; other preferences are opaque UI state
+const (
+	// prefTimezone is an IANA timezone (e.g. "Europe/Berlin") used by ?tz=user and the UI
+	prefTimezone = "timezone"
+	// prefLocale is a BCP 47 language tag (e.g. "de-DE") the UI formats dates and numbers with
+	prefLocale = "locale"
+)
+
+var localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// validatePreferences rejects timezone and locale preferences the UI couldn't use; empty
+// values clear them
+func validatePreferences(prefs map[string]string) error {
+	if tz := prefs[prefTimezone]; tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			return fmt.Errorf("Unknown timezone %q", tz)
+		}
+	}
+	if locale := prefs[prefLocale]; locale != "" && !localePattern.MatchString(locale) {
+		return fmt.Errorf("Invalid locale %q", locale)
+	}
+	return nil
+}
+
+// timezoneMiddleware renders the timestamps in JSON responses in another timezone when the
+// request asks for one with ?tz=<IANA name>, or ?tz=user for the logged-in user's timezone
+// preference. Timestamps stay RFC 3339 and denote the same instant, only with the zone's
+// offset. Streams and other non-JSON responses pass through unchanged.
+func (s *Server) timezoneMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("tz")
+		if name == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		loc, err := s.requestLocation(r, name)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if loc == nil {
+			// ?tz=user without a timezone preference
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// ETags are computed before conversion, so a cached response in another timezone
+		// would still match; always send the full response
+		r.Header.Del("If-None-Match")
+
+		tw := &timezoneWriter{ResponseWriter: w, loc: loc}
+		next.ServeHTTP(tw, r)
+		tw.finish()
+	})
+}
+
+// requestLocation resolves the tz query parameter; nil means no conversion
+func (s *Server) requestLocation(r *http.Request, name string) (*time.Location, error) {
+	if name == "user" {
+		prefs, err := s.db.GetUserPreferences(identity(r).Username)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get preferences: %v", err)
+		}
+		name = prefs[prefTimezone]
+		if name == "" {
+			return nil, nil
+		}
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("Unknown timezone %q", name)
+	}
+	return loc, nil
+}
+
+// timezoneWriter buffers JSON responses so their timestamps can be converted
+type timezoneWriter struct {
+	http.ResponseWriter
+	loc       *time.Location
+	status    int
+	started   bool
+	buffering bool
+	buf       bytes.Buffer
+}
+
+func (tw *timezoneWriter) WriteHeader(status int) {
+	if tw.started {
+		return
+	}
+	tw.started = true
+	tw.status = status
+	tw.buffering = strings.HasPrefix(tw.Header().Get("Content-Type"), "application/json")
+	if tw.buffering {
+		tw.Header().Del("ETag")
+		tw.Header().Del("Content-Length")
+		return
+	}
+	tw.ResponseWriter.WriteHeader(status)
+}
+
+func (tw *timezoneWriter) Write(p []byte) (int, error) {
+	if !tw.started {
+		tw.WriteHeader(http.StatusOK)
+	}
+	if tw.buffering {
+		return tw.buf.Write(p)
+	}
+	return tw.ResponseWriter.Write(p)
+}
+
+// Flush keeps event streams working; buffered JSON is written by finish
+func (tw *timezoneWriter) Flush() {
+	if !tw.started {
+		tw.WriteHeader(http.StatusOK)
+	}
+	if tw.buffering {
+		return
+	}
+	if f, ok := tw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer (e.g. for write deadlines)
+func (tw *timezoneWriter) Unwrap() http.ResponseWriter {
+	return tw.ResponseWriter
+}
+
+// finish writes a buffered response with its timestamps converted
+func (tw *timezoneWriter) finish() {
+	if !tw.buffering {
+		return
+	}
+	body := tw.buf.Bytes()
+	if converted, err := convertTimestamps(body, tw.loc); err == nil {
+		body = converted
+	}
+	tw.ResponseWriter.WriteHeader(tw.status)
+	tw.ResponseWriter.Write(body)
+}
+
+// convertTimestamps rewrites every RFC 3339 timestamp string in a JSON document to loc. Zero
+// times are left alone so clients keep recognizing them.
+func convertTimestamps(body []byte, loc *time.Location) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(convertValue(doc, loc)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func convertValue(v interface{}, loc *time.Location) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = convertValue(value, loc)
+		}
+		return v
+	case []interface{}:
+		for i, value := range v {
+			v[i] = convertValue(value, loc)
+		}
+		return v
+	case string:
+		// Cheap shape check before parsing: "2006-01-02T15:04:05Z" is the shortest form
+		if len(v) < 20 || len(v) > 40 || v[4] != '-' || v[10] != 'T' {
+			return v
+		}
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil || t.IsZero() {
+			return v
+		}
+		return t.In(loc).Format(time.RFC3339Nano)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimezoneMiddleware(t *testing.T) {
+	s := &Server{}
+	data := map[string]interface{}{
+		"timestamp": time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		"created":   time.Time{},
+		"name":      "2026-03-01T12:00:00Z-backup",
+		"count":     12345678901234,
+	}
+	handler := s.timezoneMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		respondCachedJSON(w, r, data)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/containers?tz=Europe/Berlin", nil))
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, `"timestamp":"2026-03-01T13:00:00+01:00"`) {
+		t.Fatalf("Expected the timestamp in Berlin time, got %d %s", rec.Code, body)
+	}
+	for _, unchanged := range []string{`"created":"0001-01-01T00:00:00Z"`, `"name":"2026-03-01T12:00:00Z-backup"`, `"count":12345678901234`} {
+		if !strings.Contains(body, unchanged) {
+			t.Errorf("Expected %s to be left alone, got %s", unchanged, body)
+		}
+	}
+	if rec.Header().Get("ETag") != "" {
+		t.Errorf("Expected converted responses to drop the ETag")
+	}
+
+	// Without tz the response is untouched
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/containers", nil))
+	if !strings.Contains(rec.Body.String(), `"timestamp":"2026-03-01T12:00:00Z"`) || rec.Header().Get("ETag") == "" {
+		t.Errorf("Expected the original response, got %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/containers?tz=Mars/Olympus", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown timezone, got %d", rec.Code)
+	}
+}
+
+func TestValidatePreferences(t *testing.T) {
+	valid := []map[string]string{
+		{"timezone": "America/Toronto", "locale": "en-CA"},
+		{"timezone": "", "locale": ""},
+		{"onboarding_completed": "true"},
+	}
+	for _, prefs := range valid {
+		if err := validatePreferences(prefs); err != nil {
+			t.Errorf("Expected %v to be valid, got %v", prefs, err)
+		}
+	}
+	for _, prefs := range []map[string]string{{"timezone": "Nowhere/City"}, {"locale": "en_US; drop"}} {
+		if err := validatePreferences(prefs); err == nil {
+			t.Errorf("Expected %v to be rejected", prefs)
+		}
+	}
+}
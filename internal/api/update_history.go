@@ -0,0 +1,121 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/container-census/container-census/internal/eventbus"
+	"github.com/container-census/container-census/internal/models"
+)
+
+// recordContainerUpdate saves a performed update in the update history. err is the error that
+// stopped the update (pull or recreate), result what the recreate returned otherwise.
+func (s *Server) recordContainerUpdate(triggeredBy, trigger string, host models.Host, container models.Container, image string, started time.Time, result *models.ContainerRecreateResult, err error) {
+	finished := time.Now()
+	update := models.ContainerUpdate{
+		HostID:        host.ID,
+		HostName:      host.Name,
+		ContainerName: container.Name,
+		Image:         image,
+		OldImageID:    container.ImageID,
+		TriggeredBy:   triggeredBy,
+		Trigger:       trigger,
+		Status:        models.UpdateStatusSucceeded,
+		StartedAt:     started,
+		FinishedAt:    finished,
+		DurationMs:    finished.Sub(started).Milliseconds(),
+	}
+
+	switch {
+	case err != nil:
+		update.Status = models.UpdateStatusFailed
+		update.Error = err.Error()
+	case result != nil:
+		if result.OldImageID != "" {
+			update.OldImageID = result.OldImageID
+		}
+		update.NewImageID = result.NewImageID
+		update.HealthStatus = result.HealthStatus
+		update.Error = result.Error
+		if result.RolledBack {
+			update.Status = models.UpdateStatusRolledBack
+		} else if !result.Success {
+			update.Status = models.UpdateStatusFailed
+		}
+	}
+
+	if err := s.db.SaveContainerUpdate(&update); err != nil {
+		log.Printf("Failed to record update of %s on %s: %v", container.Name, host.Name, err)
+	}
+	s.eventBus.Publish(eventbus.UpdatePerformedEvent(update))
+}
+
+// handleGetContainerUpdates returns the update history, newest first. Filters: host_id,
+// container_name, status (succeeded, failed, rolled_back), start/end (RFC3339) and limit
+// (default 500). Tenant users only see their hosts' updates.
+func (s *Server) handleGetContainerUpdates(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.ContainerUpdateFilter{
+		ContainerName: query.Get("container_name"),
+		Status:        query.Get("status"),
+	}
+
+	switch filter.Status {
+	case "", models.UpdateStatusSucceeded, models.UpdateStatusFailed, models.UpdateStatusRolledBack:
+	default:
+		respondError(w, http.StatusBadRequest, "status must be succeeded, failed or rolled_back")
+		return
+	}
+
+	var err error
+	if v := query.Get("start"); v != "" {
+		if filter.Start, err = time.Parse(time.RFC3339, v); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid start time format (use RFC3339): "+err.Error())
+			return
+		}
+	}
+	if v := query.Get("end"); v != "" {
+		if filter.End, err = time.Parse(time.RFC3339, v); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid end time format (use RFC3339): "+err.Error())
+			return
+		}
+	}
+	if v := query.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil || filter.Limit <= 0 {
+			respondError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+	}
+
+	var hostID int64
+	if v := query.Get("host_id"); v != "" {
+		if hostID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid host_id parameter: "+err.Error())
+			return
+		}
+	}
+
+	if hostID != 0 || !identity(r).IsAdmin() {
+		hosts, err := s.db.GetHosts()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to get hosts: "+err.Error())
+			return
+		}
+		// A host the request can't see matches nothing (-1 is never a host ID)
+		filter.HostIDs = []int64{-1}
+		for _, host := range visibleHosts(r, hosts) {
+			if hostID == 0 || host.ID == hostID {
+				filter.HostIDs = append(filter.HostIDs, host.ID)
+			}
+		}
+	}
+
+	updates, err := s.db.GetContainerUpdates(filter)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get container updates: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, updates)
+}
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// handleGetUpdateLag returns how many days each container is behind the newer build of its image
+// found by the update checks, furthest behind first, with per-host totals. Query: host_id.
+func (s *Server) handleGetUpdateLag(w http.ResponseWriter, r *http.Request) {
+	hostIDs, ok := s.queryHostIDs(w, r)
+	if !ok {
+		return
+	}
+
+	lags, err := s.db.GetUpdateLag(hostIDs)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get update lag: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, models.BuildUpdateLagReport(lags, time.Now()))
+}
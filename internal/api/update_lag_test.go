@@ -0,0 +1,55 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestUpdateLagHandler(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: "unix:///var/run/docker.sock", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	now := time.Now()
+	if err := db.SaveContainers([]models.Container{
+		{ID: "w1", Name: "web", Image: "nginx:latest", ImageID: "sha256:web", State: "running", HostID: hostID, HostName: "nas", ScannedAt: now},
+		{ID: "c1", Name: "cache", Image: "redis:latest", ImageID: "sha256:redis", State: "running", HostID: hostID, HostName: "nas", ScannedAt: now},
+	}); err != nil {
+		t.Fatalf("Failed to save containers: %v", err)
+	}
+	for name, days := range map[string]int{"web": 40, "cache": 10} {
+		imageID := map[string]string{"web": "sha256:web", "cache": "sha256:redis"}[name]
+		if err := db.SaveUpdateLag(hostID, name, imageID, true, now.Add(-time.Duration(days)*24*time.Hour), now); err != nil {
+			t.Fatalf("Failed to save update lag: %v", err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	server.handleGetUpdateLag(w, httptest.NewRequest(http.MethodGet, "/api/reports/update-lag?host_id="+itoa(hostID), nil))
+	var report models.UpdateLagReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to decode report: %v", err)
+	}
+	if report.Behind != 2 || report.MaxDays != 40 || report.AverageDays != 25 {
+		t.Errorf("Unexpected totals: %+v", report)
+	}
+	if len(report.Containers) != 2 || report.Containers[0].ContainerName != "web" || report.Containers[0].DaysBehind != 40 {
+		t.Errorf("Expected web furthest behind first, got %+v", report.Containers)
+	}
+	if len(report.Hosts) != 1 || report.Hosts[0].TotalDays != 50 || report.Hosts[0].OldestBehind != "web" {
+		t.Errorf("Unexpected host totals: %+v", report.Hosts)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleGetUpdateLag(w, httptest.NewRequest(http.MethodGet, "/api/reports/update-lag?host_id=999", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown host, got %d", w.Code)
+	}
+}
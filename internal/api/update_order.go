@@ -0,0 +1,95 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// bulkUpdateTarget is a container of a bulk update, found on its host
+type bulkUpdateTarget struct {
+	key       string // result key: "<host_id>-<container_id>"
+	host      models.Host
+	container models.Container
+	dependsOn []int // targets of the same batch this one depends on
+	dependent bool  // another target of the batch depends on this one
+}
+
+// composeDependsOn returns the services a container depends on from the
+// com.docker.compose.depends_on label ("service:condition:required,...")
+func composeDependsOn(labels map[string]string) []string {
+	var services []string
+	for _, dep := range strings.Split(labels["com.docker.compose.depends_on"], ",") {
+		if service := strings.TrimSpace(strings.Split(dep, ":")[0]); service != "" {
+			services = append(services, service)
+		}
+	}
+	return services
+}
+
+// orderBulkUpdates links the targets of a bulk update by compose depends_on (same host and
+// project) and returns them so every target comes after the ones it depends on. Otherwise the
+// request order is kept; targets in a dependency cycle are updated in request order.
+func orderBulkUpdates(targets []bulkUpdateTarget) []bulkUpdateTarget {
+	type serviceKey struct {
+		hostID  int64
+		project string
+		service string
+	}
+	services := make(map[serviceKey]int)
+	for i, t := range targets {
+		if service := t.container.Labels["com.docker.compose.service"]; service != "" && t.container.ComposeProject != "" {
+			services[serviceKey{t.host.ID, t.container.ComposeProject, service}] = i
+		}
+	}
+	for i := range targets {
+		targets[i].dependsOn = nil
+		if targets[i].container.ComposeProject == "" {
+			continue
+		}
+		for _, service := range composeDependsOn(targets[i].container.Labels) {
+			j, ok := services[serviceKey{targets[i].host.ID, targets[i].container.ComposeProject, service}]
+			if ok && j != i {
+				targets[i].dependsOn = append(targets[i].dependsOn, j)
+				targets[j].dependent = true
+			}
+		}
+	}
+
+	placed := make([]bool, len(targets))
+	position := make([]int, len(targets))
+	ordered := make([]bulkUpdateTarget, 0, len(targets))
+	for len(ordered) < len(targets) {
+		next := -1
+		for i := range targets {
+			if placed[i] {
+				continue
+			}
+			if next == -1 {
+				next = i // first unplaced target, taken when only a cycle is left
+			}
+			ready := true
+			for _, dep := range targets[i].dependsOn {
+				if !placed[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = i
+				break
+			}
+		}
+		placed[next] = true
+		position[next] = len(ordered)
+		ordered = append(ordered, targets[next])
+	}
+
+	// Refer to dependencies by their position in the returned order
+	for i := range ordered {
+		for k, dep := range ordered[i].dependsOn {
+			ordered[i].dependsOn[k] = position[dep]
+		}
+	}
+	return ordered
+}
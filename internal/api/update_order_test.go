@@ -0,0 +1,83 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func updateTarget(hostID int64, name, project, service, dependsOn string) bulkUpdateTarget {
+	labels := map[string]string{}
+	if service != "" {
+		labels["com.docker.compose.service"] = service
+	}
+	if dependsOn != "" {
+		labels["com.docker.compose.depends_on"] = dependsOn
+	}
+	return bulkUpdateTarget{
+		key:       name,
+		host:      models.Host{ID: hostID},
+		container: models.Container{Name: name, HostID: hostID, ComposeProject: project, Labels: labels},
+	}
+}
+
+func targetNames(targets []bulkUpdateTarget) []string {
+	var names []string
+	for _, t := range targets {
+		names = append(names, t.container.Name)
+	}
+	return names
+}
+
+func TestOrderBulkUpdates_DependenciesFirst(t *testing.T) {
+	ordered := orderBulkUpdates([]bulkUpdateTarget{
+		updateTarget(1, "app", "site", "app", "db:service_healthy:true,cache:service_started:true"),
+		updateTarget(1, "standalone", "", "", ""),
+		updateTarget(1, "cache", "site", "cache", "db:service_started:false"),
+		updateTarget(1, "db", "site", "db", ""),
+	})
+
+	if got, want := targetNames(ordered), []string{"standalone", "db", "cache", "app"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("order = %v, want %v", got, want)
+	}
+	if !ordered[1].dependent || !ordered[2].dependent || ordered[3].dependent || ordered[0].dependent {
+		t.Errorf("only db and cache should have dependents: %+v", ordered)
+	}
+	// Dependencies refer to positions in the returned order
+	if got := ordered[3].dependsOn; !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("app depends on %v, want [1 2]", got)
+	}
+	if got := ordered[2].dependsOn; !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("cache depends on %v, want [1]", got)
+	}
+}
+
+func TestOrderBulkUpdates_OtherHostOrProjectNotLinked(t *testing.T) {
+	ordered := orderBulkUpdates([]bulkUpdateTarget{
+		updateTarget(1, "app", "site", "app", "db:service_started:false"),
+		updateTarget(2, "db", "site", "db", ""),
+		updateTarget(1, "db-other", "other", "db", ""),
+	})
+
+	if got, want := targetNames(ordered), []string{"app", "db", "db-other"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("order = %v, want %v", got, want)
+	}
+	for _, target := range ordered {
+		if len(target.dependsOn) != 0 || target.dependent {
+			t.Errorf("%s should not be linked: %+v", target.container.Name, target)
+		}
+	}
+}
+
+func TestOrderBulkUpdates_CycleKeepsRequestOrder(t *testing.T) {
+	ordered := orderBulkUpdates([]bulkUpdateTarget{
+		updateTarget(1, "a", "site", "a", "b"),
+		updateTarget(1, "b", "site", "b", "a"),
+		updateTarget(1, "c", "site", "c", "a"),
+	})
+
+	if got, want := targetNames(ordered), []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("order = %v, want %v", got, want)
+	}
+}
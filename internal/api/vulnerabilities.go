@@ -17,6 +17,8 @@ type VulnerabilityScanner interface {
 	GetCachedScan(imageID string) (*vulnerability.VulnerabilityScan, error)
 	ScanImage(ctx context.Context, imageID string, imageName string) (*vulnerability.VulnerabilityScanResult, error)
 	UpdateTrivyDB(ctx context.Context) error
+	CheckServerHealth(ctx context.Context, force bool) vulnerability.ServerHealth
+	IngestResult(imageID, imageName string, result *vulnerability.TrivyResult, dbVersion string, duration time.Duration) (*vulnerability.VulnerabilityScanResult, error)
 	GetConfig() *vulnerability.Config
 	SetConfig(config *vulnerability.Config)
 	InvalidateCache(imageID string)
@@ -40,7 +42,7 @@ func (s *Server) SetVulnerabilityScanner(scanner VulnerabilityScanner, scheduler
 
 // handleGetVulnerabilitySummary returns an overview of all vulnerability scans
 func (s *Server) handleGetVulnerabilitySummary(w http.ResponseWriter, r *http.Request) {
-	summary, err := s.db.GetVulnerabilitySummary()
+	summary, err := s.vulnerabilitySummary()
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to get vulnerability summary: "+err.Error())
 		return
@@ -53,11 +55,11 @@ func (s *Server) handleGetVulnerabilitySummary(w http.ResponseWriter, r *http.Re
 			"summary":      summary,
 			"queue_status": queueStatus,
 		}
-		respondJSON(w, http.StatusOK, response)
+		respondCachedJSON(w, r, response)
 		return
 	}
 
-	respondJSON(w, http.StatusOK, summary)
+	respondCachedJSON(w, r, summary)
 }
 
 // handleGetImageVulnerabilities returns vulnerabilities for a specific image
@@ -252,11 +254,75 @@ func (s *Server) handleUpdateTrivyDB(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	message := "Trivy database updated successfully"
+	if s.trivyDBUpdatedFunc != nil {
+		go s.trivyDBUpdatedFunc()
+		message += "; rescanning the images of running containers"
+	}
 	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"message": "Trivy database updated successfully",
+		"message": message,
 	})
 }
 
+// handleIngestVulnerabilityResult stores a Trivy JSON report produced elsewhere, e.g. by a
+// census-agent or CI job that scanned the image next to where it runs
+func (s *Server) handleIngestVulnerabilityResult(w http.ResponseWriter, r *http.Request) {
+	if s.vulnScanner == nil {
+		respondError(w, http.StatusServiceUnavailable, "Vulnerability scanner not available")
+		return
+	}
+
+	var req struct {
+		ImageID        string          `json:"image_id"`
+		ImageName      string          `json:"image_name"`
+		TrivyDBVersion string          `json:"trivy_db_version"`
+		ScanDurationMs int64           `json:"scan_duration_ms"`
+		Report         json.RawMessage `json:"report"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	if len(req.Report) == 0 {
+		respondError(w, http.StatusBadRequest, "report is required")
+		return
+	}
+
+	report, err := vulnerability.ParseTrivyOutput(req.Report)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dbVersion := req.TrivyDBVersion
+	if dbVersion == "" {
+		dbVersion = vulnerability.AgentDBVersion
+	}
+
+	result, err := s.vulnScanner.IngestResult(req.ImageID, req.ImageName, report, dbVersion, time.Duration(req.ScanDurationMs)*time.Millisecond)
+	if err != nil {
+		if req.ImageID == "" && report.Metadata.ImageID == "" {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to save scan result: "+err.Error())
+		return
+	}
+	s.cache.Invalidate()
+
+	respondJSON(w, http.StatusCreated, result.Scan)
+}
+
+// handleGetTrivyServerHealth checks connectivity to the configured Trivy server
+func (s *Server) handleGetTrivyServerHealth(w http.ResponseWriter, r *http.Request) {
+	if s.vulnScanner == nil {
+		respondError(w, http.StatusServiceUnavailable, "Vulnerability scanner not available")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, s.vulnScanner.CheckServerHealth(r.Context(), true))
+}
+
 // handleGetVulnerabilitySettings returns the current vulnerability scanner settings
 func (s *Server) handleGetVulnerabilitySettings(w http.ResponseWriter, r *http.Request) {
 	if s.vulnScanner == nil {
@@ -265,7 +331,7 @@ func (s *Server) handleGetVulnerabilitySettings(w http.ResponseWriter, r *http.R
 	}
 
 	config := s.vulnScanner.GetConfig()
-	respondJSON(w, http.StatusOK, config)
+	respondJSON(w, http.StatusOK, config.Redacted())
 }
 
 // handleUpdateVulnerabilitySettings updates the vulnerability scanner settings
@@ -302,7 +368,7 @@ func (s *Server) handleUpdateVulnerabilitySettings(w http.ResponseWriter, r *htt
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"message": "Settings updated successfully",
-		"config":  currentConfig,
+		"config":  currentConfig.Redacted(),
 	})
 }
 
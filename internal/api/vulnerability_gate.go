@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/vulnerability"
+)
+
+// checkVulnerabilityGate compares the critical vulnerabilities of a container's current image
+// with the pulled image, scanning either when it has no recent scan. Returns nil when the gate
+// is off or vulnerability scanning isn't available.
+func (s *Server) checkVulnerabilityGate(ctx context.Context, host models.Host, container models.Container, image string) *models.VulnerabilityGateCheck {
+	settings, err := s.db.GetImageUpdateSettings()
+	if err != nil || settings.VulnerabilityGate == "" || settings.VulnerabilityGate == models.VulnerabilityGateOff {
+		return nil
+	}
+	if s.vulnScanner == nil || !s.vulnScanner.GetConfig().GetEnabled() {
+		return nil
+	}
+
+	check := &models.VulnerabilityGateCheck{
+		Mode:       settings.VulnerabilityGate,
+		Image:      image,
+		OldImageID: container.ImageID,
+	}
+	fail := func(err error) *models.VulnerabilityGateCheck {
+		check.Error = err.Error()
+		log.Printf("Vulnerability gate for %s on %s skipped: %v", container.Name, host.Name, err)
+		return check
+	}
+
+	check.NewImageID, err = s.pulledImageID(ctx, host, image)
+	if err != nil {
+		return fail(err)
+	}
+	if check.NewImageID == check.OldImageID {
+		return check
+	}
+
+	oldCounts, err := s.imageSeverityCounts(ctx, check.OldImageID, image)
+	if err != nil {
+		return fail(fmt.Errorf("failed to scan the current image: %w", err))
+	}
+	newCounts, err := s.imageSeverityCounts(ctx, check.NewImageID, image)
+	if err != nil {
+		return fail(fmt.Errorf("failed to scan the new image: %w", err))
+	}
+	check.OldCritical = oldCounts.Critical
+	check.NewCritical = newCounts.Critical
+	check.Gated = check.NewCritical > check.OldCritical
+	if check.Gated {
+		log.Printf("Vulnerability gate (%s) for %s on %s: %s has %d critical vulnerabilities, was %d",
+			check.Mode, container.Name, host.Name, image, check.NewCritical, check.OldCritical)
+	}
+	return check
+}
+
+// imageSeverityCounts returns the vulnerability counts of an image from a recent scan, scanning
+// it now when there is none
+func (s *Server) imageSeverityCounts(ctx context.Context, imageID, imageName string) (vulnerability.SeverityCounts, error) {
+	if scan, err := s.vulnScanner.GetCachedScan(imageID); err == nil && scan != nil && scan.Success {
+		return scan.SeverityCounts, nil
+	}
+	result, err := s.vulnScanner.ScanImage(ctx, imageID, imageName)
+	if err != nil {
+		return vulnerability.SeverityCounts{}, err
+	}
+	return result.Scan.SeverityCounts, nil
+}
+
+// pulledImageID returns the ID of the image a reference points to on a host
+func (s *Server) pulledImageID(ctx context.Context, host models.Host, image string) (string, error) {
+	images, err := s.scanner.ListImages(ctx, host)
+	if err != nil {
+		return "", fmt.Errorf("failed to list images: %w", err)
+	}
+	want := canonicalImageRef(image)
+	for _, img := range images {
+		for _, tag := range img.RepoTags {
+			if canonicalImageRef(tag) == want {
+				return img.ID, nil
+			}
+		}
+	}
+	return "", errors.New("pulled image " + image + " not found on the host")
+}
+
+// canonicalImageRef writes Docker Hub references the way the engine tags images
+// (docker.io/library/nginx → nginx:latest)
+func canonicalImageRef(ref string) string {
+	ref = strings.TrimPrefix(ref, "docker.io/")
+	ref = strings.TrimPrefix(ref, "index.docker.io/")
+	ref = strings.TrimPrefix(ref, "library/")
+	if !strings.Contains(ref[strings.LastIndex(ref, "/")+1:], ":") {
+		ref += ":latest"
+	}
+	return ref
+}
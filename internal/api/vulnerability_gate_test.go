@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestCanonicalImageRef(t *testing.T) {
+	tests := map[string]string{
+		"nginx":                           "nginx:latest",
+		"nginx:1.25":                      "nginx:1.25",
+		"docker.io/library/nginx:latest":  "nginx:latest",
+		"library/nginx":                   "nginx:latest",
+		"prom/node-exporter":              "prom/node-exporter:latest",
+		"ghcr.io/owner/app:v2":            "ghcr.io/owner/app:v2",
+		"registry.local:5000/team/app":    "registry.local:5000/team/app:latest",
+		"registry.local:5000/team/app:v1": "registry.local:5000/team/app:v1",
+	}
+	for ref, want := range tests {
+		if got := canonicalImageRef(ref); got != want {
+			t.Errorf("canonicalImageRef(%q) = %q, want %q", ref, got, want)
+		}
+	}
+}
+
+func TestVulnerabilityGateCheck_Refuses(t *testing.T) {
+	var none *models.VulnerabilityGateCheck
+	if none.Refuses(false) {
+		t.Error("No check must not refuse")
+	}
+
+	warn := &models.VulnerabilityGateCheck{Mode: models.VulnerabilityGateWarn, Image: "nginx:latest", OldCritical: 1, NewCritical: 3, Gated: true}
+	if !warn.Refuses(false) || warn.Refuses(true) {
+		t.Error("A warning must refuse until accepted")
+	}
+	block := &models.VulnerabilityGateCheck{Mode: models.VulnerabilityGateBlock, Gated: true}
+	if !block.Refuses(true) {
+		t.Error("A block must refuse even when accepted")
+	}
+	failed := &models.VulnerabilityGateCheck{Mode: models.VulnerabilityGateBlock, Error: "trivy scan failed"}
+	if failed.Refuses(false) {
+		t.Error("A comparison that failed must not hold the update back")
+	}
+}
+
+func TestCheckVulnerabilityGate_Disabled(t *testing.T) {
+	server, db := setupTestServer(t)
+	host := models.Host{ID: 1, Name: "nas"}
+	container := models.Container{Name: "web", ImageID: "sha256:old"}
+
+	// Off by default
+	if check := server.checkVulnerabilityGate(context.Background(), host, container, "nginx:latest"); check != nil {
+		t.Errorf("Expected no check with the gate off, got %+v", check)
+	}
+
+	settings, err := db.GetImageUpdateSettings()
+	if err != nil {
+		t.Fatalf("GetImageUpdateSettings failed: %v", err)
+	}
+	settings.VulnerabilityGate = models.VulnerabilityGateBlock
+	if err := db.SaveImageUpdateSettings(settings); err != nil {
+		t.Fatalf("SaveImageUpdateSettings failed: %v", err)
+	}
+	if saved, _ := db.GetImageUpdateSettings(); saved.VulnerabilityGate != models.VulnerabilityGateBlock {
+		t.Fatalf("Expected the gate to be saved, got %q", saved.VulnerabilityGate)
+	}
+
+	// Without a vulnerability scanner (lite mode) there is nothing to compare
+	if check := server.checkVulnerabilityGate(context.Background(), host, container, "nginx:latest"); check != nil {
+		t.Errorf("Expected no check without a vulnerability scanner, got %+v", check)
+	}
+
+	settings.VulnerabilityGate = "sometimes"
+	if err := db.SaveImageUpdateSettings(settings); err == nil {
+		t.Error("Expected an invalid gate mode to be rejected")
+	}
+}
@@ -0,0 +1,325 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/vulnerability"
+	"github.com/gorilla/mux"
+)
+
+// handleGetVulnerabilityReport builds a vulnerability compliance report for one host
+// (?host_id=) or the whole fleet, as JSON, CSV (one row per finding) or printable HTML
+func (s *Server) handleGetVulnerabilityReport(w http.ResponseWriter, r *http.Request) {
+	containers, err := s.db.GetLatestContainers()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get containers: "+err.Error())
+		return
+	}
+
+	scope := "All hosts"
+	filename := "vulnerability-report-fleet"
+	if hostIDStr := r.URL.Query().Get("host_id"); hostIDStr != "" {
+		hostID, err := strconv.ParseInt(hostIDStr, 10, 64)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid host_id")
+			return
+		}
+		host, err := s.db.GetHost(hostID)
+		if err != nil {
+			respondError(w, http.StatusNotFound, "Host not found")
+			return
+		}
+		containers = filterContainers(containers, func(c models.Container) bool { return c.HostID == hostID })
+		scope = "Host: " + host.Name
+		filename = "vulnerability-report-" + reportFilenamePart(host.Name)
+	}
+
+	// Group containers by image
+	inputs := make([]vulnerability.ReportImageInput, 0)
+	byImage := make(map[string]int)
+	imageHosts := make(map[string]map[string]bool)
+	for _, c := range containers {
+		if c.ImageID == "" {
+			continue
+		}
+		i, ok := byImage[c.ImageID]
+		if !ok {
+			i = len(inputs)
+			byImage[c.ImageID] = i
+			imageHosts[c.ImageID] = make(map[string]bool)
+			inputs = append(inputs, vulnerability.ReportImageInput{ImageID: c.ImageID, ImageName: c.Image})
+		}
+		inputs[i].Containers++
+		if !imageHosts[c.ImageID][c.HostName] {
+			imageHosts[c.ImageID][c.HostName] = true
+			inputs[i].Hosts = append(inputs[i].Hosts, c.HostName)
+		}
+	}
+
+	for i := range inputs {
+		sort.Strings(inputs[i].Hosts)
+		scan, err := s.db.GetVulnerabilityScan(inputs[i].ImageID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to get scan: "+err.Error())
+			return
+		}
+		inputs[i].Scan = scan
+		if scan != nil && scan.Success {
+			inputs[i].Vulnerabilities, err = s.db.GetVulnerabilities(inputs[i].ImageID)
+			if err != nil {
+				respondError(w, http.StatusInternalServerError, "Failed to get vulnerabilities: "+err.Error())
+				return
+			}
+		}
+	}
+
+	exceptions, err := s.db.GetVulnerabilityExceptions()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get exceptions: "+err.Error())
+		return
+	}
+
+	now := time.Now()
+	report := vulnerability.BuildReport(scope, inputs, exceptions, now)
+	filename += "-" + now.Format("2006-01-02")
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		respondJSON(w, http.StatusOK, report)
+	case "csv":
+		var buf bytes.Buffer
+		if err := renderVulnerabilityReportCSV(&buf, report); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to render CSV: "+err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", "attachment; filename="+filename+".csv")
+		w.Write(buf.Bytes())
+	case "html":
+		var buf bytes.Buffer
+		if err := renderVulnerabilityReportHTML(&buf, report); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to render report: "+err.Error())
+			return
+		}
+		// Served inline so it can be printed or saved as PDF from the browser
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if r.URL.Query().Get("download") == "true" {
+			w.Header().Set("Content-Disposition", "attachment; filename="+filename+".html")
+		}
+		w.Write(buf.Bytes())
+	default:
+		respondError(w, http.StatusBadRequest, "Invalid format parameter. Must be 'json', 'csv', or 'html'")
+	}
+}
+
+// renderVulnerabilityReportCSV writes one row per finding, open findings first
+func renderVulnerabilityReportCSV(w io.Writer, report *vulnerability.Report) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{
+		"Image", "Image ID", "Hosts", "Vulnerability", "Severity", "Package",
+		"Installed Version", "Fixed Version", "Fix Available", "Status", "Exception Reason", "Title", "URL",
+	})
+
+	for _, f := range report.Findings {
+		status := "open"
+		if f.Excepted {
+			status = "excepted"
+		}
+		cw.Write([]string{
+			f.ImageName, f.ImageID, f.Hosts, f.VulnerabilityID, f.Severity, f.PkgName,
+			f.InstalledVersion, f.FixedVersion, strconv.FormatBool(f.FixAvailable), status, f.ExceptionReason,
+			f.Title, f.PrimaryURL,
+		})
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+var vulnerabilityReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"date":  func(t time.Time) string { return t.Format("2006-01-02 15:04 MST") },
+	"lower": strings.ToLower,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Vulnerability Report - {{.Scope}}</title>
+<style>
+	body { font-family: -apple-system, "Segoe UI", Roboto, sans-serif; color: #222; margin: 2rem; font-size: 13px; }
+	h1 { margin-bottom: 0.2rem; }
+	h2 { margin-top: 2rem; border-bottom: 2px solid #ddd; padding-bottom: 0.3rem; }
+	.meta { color: #666; margin-bottom: 1.5rem; }
+	.summary { display: grid; grid-template-columns: repeat(auto-fit, minmax(150px, 1fr)); gap: 0.75rem; }
+	.card { border: 1px solid #ddd; border-radius: 6px; padding: 0.75rem; }
+	.card .value { font-size: 1.6rem; font-weight: 600; }
+	.card .label { color: #666; }
+	table { border-collapse: collapse; width: 100%; margin-top: 0.5rem; }
+	th, td { border: 1px solid #ddd; padding: 4px 6px; text-align: left; vertical-align: top; }
+	th { background: #f5f5f5; }
+	tr { page-break-inside: avoid; }
+	.sev { font-weight: 600; }
+	.critical { color: #b71c1c; } .high { color: #e65100; } .medium { color: #f9a825; } .low { color: #2e7d32; }
+	.excepted td { color: #888; }
+	.pass { color: #2e7d32; font-weight: 600; } .fail { color: #b71c1c; font-weight: 600; }
+	.actions { margin-bottom: 1rem; }
+	@media print { .actions { display: none; } body { margin: 0; } }
+</style>
+</head>
+<body>
+<div class="actions"><button onclick="window.print()">Print / Save as PDF</button></div>
+<h1>Vulnerability Report</h1>
+<div class="meta">{{.Scope}} &middot; Generated {{date .GeneratedAt}}{{with .Summary.OldestScan}} &middot; Oldest scan {{date .}}{{end}}</div>
+
+<h2>Compliance Summary</h2>
+<div class="summary">
+	<div class="card"><div class="value">{{.Summary.CompliantImages}} / {{.Summary.ScannedImages}}</div><div class="label">Scanned images without open critical/high findings</div></div>
+	<div class="card"><div class="value">{{.Summary.UnscannedImages}}</div><div class="label">Images not scanned</div></div>
+	<div class="card"><div class="value critical">{{.Summary.Counts.Critical}}</div><div class="label">Open critical ({{.Summary.FixableCounts.Critical}} fixable)</div></div>
+	<div class="card"><div class="value high">{{.Summary.Counts.High}}</div><div class="label">Open high ({{.Summary.FixableCounts.High}} fixable)</div></div>
+	<div class="card"><div class="value medium">{{.Summary.Counts.Medium}}</div><div class="label">Open medium ({{.Summary.FixableCounts.Medium}} fixable)</div></div>
+	<div class="card"><div class="value low">{{.Summary.Counts.Low}}</div><div class="label">Open low ({{.Summary.FixableCounts.Low}} fixable)</div></div>
+	<div class="card"><div class="value">{{.Summary.ExceptedCount}}</div><div class="label">Findings covered by exceptions</div></div>
+</div>
+
+<h2>Images</h2>
+<table>
+	<tr><th>Image</th><th>Hosts</th><th>Containers</th><th>Status</th><th>Critical</th><th>High</th><th>Medium</th><th>Low</th><th>Fixable</th><th>Excepted</th><th>Scanned</th></tr>
+	{{range .Images}}
+	<tr>
+		<td>{{.ImageName}}</td>
+		<td>{{range $i, $h := .Hosts}}{{if $i}}, {{end}}{{$h}}{{end}}</td>
+		<td>{{.Containers}}</td>
+		<td>{{if not .Scanned}}Not scanned{{with .ScanError}} ({{.}}){{end}}{{else if .Compliant}}<span class="pass">Pass</span>{{else}}<span class="fail">Fail</span>{{end}}</td>
+		<td>{{.Counts.Critical}}</td><td>{{.Counts.High}}</td><td>{{.Counts.Medium}}</td><td>{{.Counts.Low}}</td>
+		<td>{{.Fixable}}</td><td>{{.Excepted}}</td>
+		<td>{{with .ScannedAt}}{{date .}}{{end}}</td>
+	</tr>
+	{{else}}
+	<tr><td colspan="11">No images in scope</td></tr>
+	{{end}}
+</table>
+
+<h2>Findings</h2>
+<table>
+	<tr><th>Severity</th><th>Vulnerability</th><th>Image</th><th>Package</th><th>Installed</th><th>Fixed In</th><th>Status</th><th>Title</th></tr>
+	{{range .Findings}}
+	<tr{{if .Excepted}} class="excepted"{{end}}>
+		<td class="sev {{lower .Severity}}">{{.Severity}}</td>
+		<td>{{if .PrimaryURL}}<a href="{{.PrimaryURL}}">{{.VulnerabilityID}}</a>{{else}}{{.VulnerabilityID}}{{end}}</td>
+		<td>{{.ImageName}}</td>
+		<td>{{.PkgName}}</td>
+		<td>{{.InstalledVersion}}</td>
+		<td>{{if .FixAvailable}}{{.FixedVersion}}{{else}}No fix{{end}}</td>
+		<td>{{if .Excepted}}Excepted: {{.ExceptionReason}}{{else}}Open{{end}}</td>
+		<td>{{.Title}}</td>
+	</tr>
+	{{else}}
+	<tr><td colspan="8">No vulnerabilities found</td></tr>
+	{{end}}
+</table>
+
+<h2>Exceptions</h2>
+<table>
+	<tr><th>Vulnerability</th><th>Images</th><th>Reason</th><th>Created</th><th>Expires</th></tr>
+	{{range .Exceptions}}
+	<tr>
+		<td>{{.VulnerabilityID}}</td>
+		<td>{{if .ImagePattern}}{{.ImagePattern}}{{else}}All images{{end}}</td>
+		<td>{{.Reason}}</td>
+		<td>{{date .CreatedAt}}</td>
+		<td>{{with .ExpiresAt}}{{date .}}{{else}}Never{{end}}</td>
+	</tr>
+	{{else}}
+	<tr><td colspan="5">No active exceptions</td></tr>
+	{{end}}
+</table>
+</body>
+</html>
+`))
+
+// renderVulnerabilityReportHTML renders a self-contained, printable report
+func renderVulnerabilityReportHTML(w io.Writer, report *vulnerability.Report) error {
+	return vulnerabilityReportTemplate.Execute(w, report)
+}
+
+// reportFilenamePart makes a host name safe for use in a download filename
+func reportFilenamePart(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			return r
+		}
+		return '-'
+	}, name)
+}
+
+// handleGetVulnerabilityExceptions lists all vulnerability exceptions
+func (s *Server) handleGetVulnerabilityExceptions(w http.ResponseWriter, r *http.Request) {
+	exceptions, err := s.db.GetVulnerabilityExceptions()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get exceptions: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, exceptions)
+}
+
+// handleCreateVulnerabilityException records an accepted risk for a vulnerability
+func (s *Server) handleCreateVulnerabilityException(w http.ResponseWriter, r *http.Request) {
+	var exception vulnerability.Exception
+	if err := json.NewDecoder(r.Body).Decode(&exception); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	exception.VulnerabilityID = strings.TrimSpace(exception.VulnerabilityID)
+	exception.ImagePattern = strings.TrimSpace(exception.ImagePattern)
+	exception.Reason = strings.TrimSpace(exception.Reason)
+	if exception.VulnerabilityID == "" || exception.Reason == "" {
+		respondError(w, http.StatusBadRequest, "vulnerability_id and reason are required")
+		return
+	}
+	if _, err := path.Match(exception.ImagePattern, ""); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid image_pattern: "+err.Error())
+		return
+	}
+	if exception.ExpiresAt != nil && !exception.ExpiresAt.After(time.Now()) {
+		respondError(w, http.StatusBadRequest, "expires_at must be in the future")
+		return
+	}
+
+	exception.ID = 0
+	exception.CreatedAt = time.Now()
+	if err := s.db.AddVulnerabilityException(&exception); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save exception: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, exception)
+}
+
+// handleDeleteVulnerabilityException removes a vulnerability exception
+func (s *Server) handleDeleteVulnerabilityException(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid exception ID")
+		return
+	}
+
+	if err := s.db.DeleteVulnerabilityException(id); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete exception: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": fmt.Sprintf("Exception %d deleted", id)})
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/vulnerability"
+)
+
+func reportTestReport() *vulnerability.Report {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	return vulnerability.BuildReport("Host: <edge>", []vulnerability.ReportImageInput{{
+		ImageID: "sha256:nginx", ImageName: "nginx:1.27", Hosts: []string{"edge"}, Containers: 1,
+		Scan: &vulnerability.VulnerabilityScan{Success: true, ScannedAt: now},
+		Vulnerabilities: []vulnerability.Vulnerability{
+			{VulnerabilityID: "CVE-1", Severity: "CRITICAL", PkgName: "openssl", FixedVersion: "3.0.1", Title: "Overflow, \"bad\""},
+			{VulnerabilityID: "CVE-2", Severity: "LOW", PkgName: "zlib", Title: "<script>alert(1)</script>", PrimaryURL: "javascript:alert(1)"},
+		},
+	}}, []vulnerability.Exception{{VulnerabilityID: "CVE-2", Reason: "Not exploitable"}}, now)
+}
+
+func TestRenderVulnerabilityReportCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := renderVulnerabilityReportCSV(&buf, reportTestReport()); err != nil {
+		t.Fatalf("renderVulnerabilityReportCSV failed: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Output is not valid CSV: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected header and 2 findings, got %d rows", len(records))
+	}
+	if records[1][3] != "CVE-1" || records[1][8] != "true" || records[1][9] != "open" || records[1][11] != "Overflow, \"bad\"" {
+		t.Errorf("Unexpected open finding row: %v", records[1])
+	}
+	if records[2][9] != "excepted" || records[2][10] != "Not exploitable" {
+		t.Errorf("Unexpected excepted finding row: %v", records[2])
+	}
+}
+
+func TestRenderVulnerabilityReportHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := renderVulnerabilityReportHTML(&buf, reportTestReport()); err != nil {
+		t.Fatalf("renderVulnerabilityReportHTML failed: %v", err)
+	}
+	html := buf.String()
+
+	if strings.Contains(html, "<script>alert(1)</script>") || strings.Contains(html, "<edge>") {
+		t.Error("Expected report content to be escaped")
+	}
+	if strings.Contains(html, `href="javascript:`) {
+		t.Error("Expected unsafe URLs to be filtered")
+	}
+	for _, want := range []string{"Compliance Summary", "CVE-1", "Excepted: Not exploitable", "0 / 1"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Expected report to contain %q", want)
+		}
+	}
+}
+
+func TestReportFilenamePart(t *testing.T) {
+	if got := reportFilenamePart("edge box/1"); got != "edge-box-1" {
+		t.Errorf("Expected sanitized filename, got %q", got)
+	}
+}
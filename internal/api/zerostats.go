@@ -0,0 +1,27 @@
+package api
+
+import (
+	"log"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// attachZeroStats flags running containers whose recent scans all reported zero memory usage
+// and limit, so the UI can show a stats collection problem instead of an idle container.
+// Errors are logged and leave the containers unflagged.
+func (s *Server) attachZeroStats(containers []models.Container) {
+	zero, err := s.db.GetZeroStatsContainers()
+	if err != nil {
+		log.Printf("Failed to get containers with zero stats: %v", err)
+		return
+	}
+	if len(zero) == 0 {
+		return
+	}
+	for i := range containers {
+		c := &containers[i]
+		if c.State == "running" && !models.StatsDisabledByLabels(c.Labels) && zero[models.PinKey(c.HostID, c.Name)] {
+			c.ZeroStats = true
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"context"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Identity is the user a request is authenticated as
+type Identity struct {
+	Username string
+	// TenantID is the tenant of a tenant user; 0 for the configured administrator, who sees
+	// every tenant (and for everyone when auth is disabled)
+	TenantID int64
+}
+
+// IsAdmin reports whether the identity is the administrator rather than a tenant user
+func (id Identity) IsAdmin() bool {
+	return id.TenantID == 0
+}
+
+// Users looks up tenant users, which log in next to the configured administrator
+type Users interface {
+	// Authenticate returns the tenant of the user with these credentials
+	Authenticate(username, password string) (tenantID int64, ok bool)
+	// Tenant returns the tenant of a user, or ok false once the user was deleted
+	Tenant(username string) (tenantID int64, ok bool)
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a context carrying the authenticated identity
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// IdentityFromContext returns the identity set by SessionMiddleware. Requests that didn't
+// pass through it (auth disabled) are the administrator.
+func IdentityFromContext(ctx context.Context) Identity {
+	id, _ := ctx.Value(identityKey{}).(Identity)
+	return id
+}
+
+// Authenticate checks login credentials against the administrator, then the tenant users
+func Authenticate(config Config, username, password string) (Identity, bool) {
+	if validateCredentials(username, password, config.Username, config.Password) {
+		return Identity{Username: username}, true
+	}
+	if config.Users != nil {
+		if tenantID, ok := config.Users.Authenticate(username, password); ok {
+			return Identity{Username: username, TenantID: tenantID}, true
+		}
+	}
+	return Identity{}, false
+}
+
+const (
+	passwordHashIterations = 100000
+	passwordHashScheme     = "pbkdf2-sha256"
+)
+
+// HashPassword hashes a tenant user's password with PBKDF2-SHA256 and a random salt, as
+// "pbkdf2-sha256$<iterations>$<salt>$<hash>"
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordHashIterations, 32)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s$%d$%s$%s", passwordHashScheme, passwordHashIterations,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// CheckPassword reports whether a password matches a hash from HashPassword
+func CheckPassword(hash, password string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != passwordHashScheme {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil || len(want) == 0 {
+		return false
+	}
+	got, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(want))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
@@ -10,6 +10,8 @@ type Config struct {
 	Enabled  bool
 	Username string
 	Password string
+	// Users authenticates tenant users; nil allows only the configured administrator
+	Users Users
 }
 
 // BasicAuthMiddleware creates a middleware that enforces HTTP Basic Authentication
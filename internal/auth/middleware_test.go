@@ -486,3 +486,64 @@ func TestMiddleware_SpecialCharactersInPassword(t *testing.T) {
 		})
 	}
 }
+
+type fakeUsers map[string]int64
+
+func (f fakeUsers) Authenticate(username, password string) (int64, bool) {
+	tenantID, ok := f[username]
+	return tenantID, ok && password == username+"-pass"
+}
+
+func (f fakeUsers) Tenant(username string) (int64, bool) {
+	tenantID, ok := f[username]
+	return tenantID, ok
+}
+
+// TestSessionMiddleware_TenantIdentity tests that tenant users authenticate with Basic Auth
+// and carry their tenant in the request context
+func TestSessionMiddleware_TenantIdentity(t *testing.T) {
+	InitSessionStore("test-secret-key-for-tenants-1234")
+	config := Config{Enabled: true, Username: "admin", Password: "secret", Users: fakeUsers{"kid": 7}}
+
+	var got Identity
+	handler := SessionMiddleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = IdentityFromContext(r.Context())
+	}))
+
+	tests := []struct {
+		user, pass string
+		wantCode   int
+		want       Identity
+	}{
+		{"admin", "secret", http.StatusOK, Identity{Username: "admin"}},
+		{"kid", "kid-pass", http.StatusOK, Identity{Username: "kid", TenantID: 7}},
+		{"kid", "secret", http.StatusUnauthorized, Identity{}},
+	}
+	for _, tt := range tests {
+		got = Identity{}
+		req := httptest.NewRequest("GET", "/api/hosts", nil)
+		req.SetBasicAuth(tt.user, tt.pass)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.wantCode || got != tt.want {
+			t.Errorf("%s/%s: got %d %+v, want %d %+v", tt.user, tt.pass, rec.Code, got, tt.wantCode, tt.want)
+		}
+	}
+}
+
+// TestPasswordHash tests hashing and checking tenant user passwords
+func TestPasswordHash(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("HashPassword failed: %v", err)
+	}
+	if !CheckPassword(hash, "correct horse") {
+		t.Error("Expected the password to match")
+	}
+	if CheckPassword(hash, "wrong horse") || CheckPassword("plain", "plain") {
+		t.Error("Expected wrong passwords and malformed hashes to fail")
+	}
+	if other, _ := HashPassword("correct horse"); other == hash {
+		t.Error("Expected a random salt")
+	}
+}
@@ -20,6 +20,17 @@ func InitSessionStore(secretKey string) {
 	}
 }
 
+// SetCookiePath limits the session cookie to a path, for servers under a sub-path
+func SetCookiePath(path string) {
+	sessionStore.Options.Path = path
+}
+
+// isSecure reports whether the request came over HTTPS, directly or through a trusted proxy
+// that set X-Forwarded-Proto
+func isSecure(r *http.Request) bool {
+	return r.TLS != nil || r.URL.Scheme == "https"
+}
+
 // SessionMiddleware creates a middleware that checks for valid session or Basic Auth
 // Provides backward compatibility with Basic Auth headers
 func SessionMiddleware(config Config) func(http.Handler) http.Handler {
@@ -33,16 +44,18 @@ func SessionMiddleware(config Config) func(http.Handler) http.Handler {
 
 			// Check for valid session cookie
 			session, _ := sessionStore.Get(r, "census-session")
-			if auth, ok := session.Values["authenticated"].(bool); ok && auth {
-				next.ServeHTTP(w, r)
+			if id, ok := sessionIdentity(config, session); ok {
+				next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), id)))
 				return
 			}
 
 			// Fallback: check Basic Auth for backward compatibility
 			username, password, ok := r.BasicAuth()
-			if ok && validateCredentials(username, password, config.Username, config.Password) {
-				next.ServeHTTP(w, r)
-				return
+			if ok {
+				if id, ok := Authenticate(config, username, password); ok {
+					next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), id)))
+					return
+				}
 			}
 
 			// Unauthorized - return JSON for API calls, let browser handle redirects
@@ -53,10 +66,34 @@ func SessionMiddleware(config Config) func(http.Handler) http.Handler {
 	}
 }
 
-// CreateSession creates a new authenticated session
-func CreateSession(w http.ResponseWriter, r *http.Request) error {
+// sessionIdentity returns the identity of an authenticated session. Sessions of tenant users
+// end when the user is deleted or moved to another tenant.
+func sessionIdentity(config Config, session *sessions.Session) (Identity, bool) {
+	if auth, ok := session.Values["authenticated"].(bool); !ok || !auth {
+		return Identity{}, false
+	}
+	username, _ := session.Values["username"].(string)
+	tenantID, _ := session.Values["tenant_id"].(int64)
+	if tenantID == 0 {
+		// Sessions created before tenants existed carry no username
+		return Identity{Username: username}, true
+	}
+	if config.Users == nil {
+		return Identity{}, false
+	}
+	if current, ok := config.Users.Tenant(username); !ok || current != tenantID {
+		return Identity{}, false
+	}
+	return Identity{Username: username, TenantID: tenantID}, true
+}
+
+// CreateSession creates a new authenticated session for an identity
+func CreateSession(w http.ResponseWriter, r *http.Request, id Identity) error {
 	session, _ := sessionStore.Get(r, "census-session")
 	session.Values["authenticated"] = true
+	session.Values["username"] = id.Username
+	session.Values["tenant_id"] = id.TenantID
+	session.Options.Secure = isSecure(r)
 	return session.Save(r, w)
 }
 
@@ -64,6 +101,8 @@ func CreateSession(w http.ResponseWriter, r *http.Request) error {
 func DestroySession(w http.ResponseWriter, r *http.Request) error {
 	session, _ := sessionStore.Get(r, "census-session")
 	session.Values["authenticated"] = false
+	delete(session.Values, "username")
+	delete(session.Values, "tenant_id")
 	session.Options.MaxAge = -1 // Delete cookie
 	return session.Save(r, w)
 }
@@ -72,3 +111,10 @@ func DestroySession(w http.ResponseWriter, r *http.Request) error {
 func GetSession(r *http.Request) (*sessions.Session, error) {
 	return sessionStore.Get(r, "census-session")
 }
+
+// SessionAuthenticated reports whether a request carries a valid session
+func SessionAuthenticated(config Config, r *http.Request) bool {
+	session, _ := sessionStore.Get(r, "census-session")
+	_, ok := sessionIdentity(config, session)
+	return ok
+}
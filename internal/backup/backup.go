@@ -0,0 +1,143 @@
+// Package backup recognizes backup containers and decides whether their backups are on schedule.
+package backup
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// DefaultInterval is how often a backup is expected to succeed unless census.backup.interval says otherwise
+const DefaultInterval = 24 * time.Hour
+
+// knownTools maps keywords in image names to backup tools, most specific first
+var knownTools = []struct {
+	keyword string
+	tool    string
+}{
+	{"borgmatic", "borgmatic"},
+	{"restic", "restic"},
+	{"duplicati", "duplicati"},
+	{"duplicity", "duplicity"},
+	{"kopia", "kopia"},
+	{"borg", "borg"},
+	{"backup", "backup"}, // e.g. offen/docker-volume-backup, tiredofit/db-backup
+}
+
+// Detect reports whether a container is a backup job, which tool it runs and how often it is
+// expected to succeed. The census.backup label overrides image-based detection either way.
+func Detect(c models.Container) (tool string, interval time.Duration, ok bool) {
+	interval = ParseInterval(c.Labels[models.BackupIntervalLabel])
+
+	label := strings.ToLower(strings.TrimSpace(c.Labels[models.BackupLabel]))
+	if label == "false" {
+		return "", 0, false
+	}
+
+	repo := strings.ToLower(imageRepository(c.Image))
+	for _, known := range knownTools {
+		if strings.Contains(repo, known.keyword) {
+			return known.tool, interval, true
+		}
+	}
+
+	if label == "true" || strings.Contains(strings.ToLower(c.Name), "backup") {
+		return "backup", interval, true
+	}
+	return "", 0, false
+}
+
+// ParseInterval parses a Go duration or a number of days such as "7d". Empty or invalid values
+// fall back to DefaultInterval.
+func ParseInterval(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour
+		}
+		return DefaultInterval
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= time.Minute {
+		return d
+	}
+	return DefaultInterval
+}
+
+// Grace is the slack added to an interval before a job counts as overdue, so a backup that
+// runs a little late or is only seen at the next scan doesn't raise an alert
+func Grace(interval time.Duration) time.Duration {
+	if grace := interval / 10; grace > time.Hour {
+		return grace
+	}
+	return time.Hour
+}
+
+// Evaluate sets the due time, status and detail of a job from its kind, state and runs.
+// Services count as successful for as long as they are seen running.
+func Evaluate(job *models.BackupJob, now time.Time) {
+	interval := time.Duration(job.IntervalSeconds) * time.Second
+
+	base := job.FirstSeen
+	if job.LastSuccessAt != nil {
+		base = *job.LastSuccessAt
+	}
+	job.DueAt = base.Add(interval + Grace(interval))
+
+	switch {
+	case now.After(job.DueAt):
+		job.Status = models.BackupStatusOverdue
+		if job.LastSuccessAt == nil {
+			job.Detail = fmt.Sprintf("No successful backup seen in %s (expected every %s)", FormatDuration(now.Sub(job.FirstSeen)), FormatDuration(interval))
+		} else {
+			job.Detail = fmt.Sprintf("Last successful backup %s ago (expected every %s)", FormatDuration(now.Sub(*job.LastSuccessAt)), FormatDuration(interval))
+		}
+	case job.Kind == models.BackupKindService && job.State == "running":
+		job.Status = models.BackupStatusOK
+		job.Detail = "Backup service is running; its runs happen inside the container"
+	case job.Kind == models.BackupKindService:
+		job.Status = models.BackupStatusFailed
+		job.Detail = fmt.Sprintf("Backup service is %s", job.State)
+	case job.LastRun != nil && !job.LastRun.Success && (job.LastSuccessAt == nil || job.LastRun.FinishedAt.After(*job.LastSuccessAt)):
+		job.Status = models.BackupStatusFailed
+		job.Detail = fmt.Sprintf("Last run exited with code %d", job.LastRun.ExitCode)
+	case job.State == "running":
+		job.Status = models.BackupStatusRunning
+		job.Detail = "Backup is running"
+	case job.LastRun == nil:
+		job.Status = models.BackupStatusPending
+		job.Detail = "No completed run seen yet"
+	default:
+		job.Status = models.BackupStatusOK
+		job.Detail = fmt.Sprintf("Last run succeeded %s ago", FormatDuration(now.Sub(job.LastRun.FinishedAt)))
+	}
+}
+
+// FormatDuration formats a duration as days, hours or minutes, e.g. "2d 3h", "5h" or "12m"
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		days := int(d / (24 * time.Hour))
+		if hours := int((d % (24 * time.Hour)) / time.Hour); hours > 0 {
+			return fmt.Sprintf("%dd %dh", days, hours)
+		}
+		return fmt.Sprintf("%dd", days)
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d/time.Hour))
+	default:
+		return fmt.Sprintf("%dm", int(d/time.Minute))
+	}
+}
+
+// imageRepository strips the tag or digest from an image reference
+func imageRepository(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
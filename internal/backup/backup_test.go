@@ -0,0 +1,99 @@
+package backup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		container models.Container
+		tool      string
+		interval  time.Duration
+	}{
+		{models.Container{Name: "restic", Image: "restic/restic:0.16"}, "restic", DefaultInterval},
+		{models.Container{Name: "borg", Image: "ghcr.io/borgmatic-collective/borgmatic:latest"}, "borgmatic", DefaultInterval},
+		{models.Container{Name: "dup", Image: "lscr.io/linuxserver/duplicati", Labels: map[string]string{models.BackupIntervalLabel: "7d"}}, "duplicati", 7 * 24 * time.Hour},
+		{models.Container{Name: "volumes", Image: "offen/docker-volume-backup:v2"}, "backup", DefaultInterval},
+		{models.Container{Name: "nightly-backup", Image: "alpine"}, "backup", DefaultInterval},
+		{models.Container{Name: "dump", Image: "postgres:16", Labels: map[string]string{models.BackupLabel: "true", models.BackupIntervalLabel: "6h"}}, "backup", 6 * time.Hour},
+		{models.Container{Name: "restic", Image: "restic/restic", Labels: map[string]string{models.BackupLabel: "false"}}, "", 0},
+		{models.Container{Name: "web", Image: "registry.local:5000/nginx:1.25"}, "", 0},
+	}
+
+	for _, tt := range tests {
+		tool, interval, ok := Detect(tt.container)
+		if ok != (tt.tool != "") || tool != tt.tool || interval != tt.interval {
+			t.Errorf("Detect(%s %s) = %q, %v, %v; want %q, %v", tt.container.Name, tt.container.Image, tool, interval, ok, tt.tool, tt.interval)
+		}
+	}
+}
+
+func TestParseInterval(t *testing.T) {
+	tests := map[string]time.Duration{
+		"":      DefaultInterval,
+		"12h":   12 * time.Hour,
+		"2d":    48 * time.Hour,
+		"0d":    DefaultInterval,
+		"10s":   DefaultInterval,
+		"daily": DefaultInterval,
+	}
+	for value, want := range tests {
+		if got := ParseInterval(value); got != want {
+			t.Errorf("ParseInterval(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	day := int64((24 * time.Hour).Seconds())
+	at := func(hoursAgo int) *time.Time {
+		t := now.Add(-time.Duration(hoursAgo) * time.Hour)
+		return &t
+	}
+	run := func(hoursAgo, exitCode int) *models.BackupRun {
+		return &models.BackupRun{FinishedAt: *at(hoursAgo), ExitCode: exitCode, Success: exitCode == 0}
+	}
+
+	tests := []struct {
+		name string
+		job  models.BackupJob
+		want string
+	}{
+		{"recent success", models.BackupJob{Kind: models.BackupKindJob, State: "exited", FirstSeen: *at(100), LastRun: run(3, 0), LastSuccessAt: at(3)}, models.BackupStatusOK},
+		{"within grace", models.BackupJob{Kind: models.BackupKindJob, State: "exited", FirstSeen: *at(100), LastRun: run(26, 0), LastSuccessAt: at(26)}, models.BackupStatusOK},
+		{"overdue", models.BackupJob{Kind: models.BackupKindJob, State: "exited", FirstSeen: *at(100), LastRun: run(27, 0), LastSuccessAt: at(27)}, models.BackupStatusOverdue},
+		{"failed", models.BackupJob{Kind: models.BackupKindJob, State: "exited", FirstSeen: *at(100), LastRun: run(1, 1), LastSuccessAt: at(20)}, models.BackupStatusFailed},
+		{"running", models.BackupJob{Kind: models.BackupKindJob, State: "running", FirstSeen: *at(100), LastRun: run(20, 0), LastSuccessAt: at(20)}, models.BackupStatusRunning},
+		{"never run", models.BackupJob{Kind: models.BackupKindJob, State: "created", FirstSeen: *at(2)}, models.BackupStatusPending},
+		{"never succeeded", models.BackupJob{Kind: models.BackupKindJob, State: "exited", FirstSeen: *at(48), LastRun: run(1, 2)}, models.BackupStatusOverdue},
+		{"service running", models.BackupJob{Kind: models.BackupKindService, State: "running", FirstSeen: *at(100), LastSuccessAt: at(0)}, models.BackupStatusOK},
+		{"service stopped", models.BackupJob{Kind: models.BackupKindService, State: "exited", FirstSeen: *at(100), LastSuccessAt: at(5)}, models.BackupStatusFailed},
+	}
+
+	for _, tt := range tests {
+		job := tt.job
+		job.IntervalSeconds = day
+		Evaluate(&job, now)
+		if job.Status != tt.want || job.Detail == "" {
+			t.Errorf("%s: got %s (%s), want %s", tt.name, job.Status, job.Detail, tt.want)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		12 * time.Minute: "12m",
+		5 * time.Hour:    "5h",
+		48 * time.Hour:   "2d",
+		51 * time.Hour:   "2d 3h",
+	}
+	for d, want := range tests {
+		if got := FormatDuration(d); got != want {
+			t.Errorf("FormatDuration(%v) = %s, want %s", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,393 @@
+package broker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBroker routes messages between the connections of a fake NATS server or MQTT broker
+type fakeBroker struct {
+	listener net.Listener
+	tls      *tls.Config // NATS requires TLS after its INFO, MQTT from the start
+	mu       sync.Mutex
+	subs     map[string][]func(topic string, payload []byte)
+	connects int
+}
+
+// withPlaintext allows brokers without TLS for the test
+func withPlaintext(t *testing.T) {
+	SetAllowPlaintext(true)
+	t.Cleanup(func() { SetAllowPlaintext(false) })
+}
+
+// brokerTLS returns a server configuration with a certificate for 127.0.0.1 that clients trust
+// for the test
+func brokerTLS(t *testing.T) *tls.Config {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	rootCAs = x509.NewCertPool()
+	rootCAs.AddCert(cert)
+	t.Cleanup(func() { rootCAs = nil })
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+}
+
+func (b *fakeBroker) subscribe(topic string, deliver func(topic string, payload []byte)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs[topic] = append(b.subs[topic], deliver)
+}
+
+func (b *fakeBroker) publish(topic string, payload []byte) {
+	b.mu.Lock()
+	subs := append([]func(string, []byte){}, b.subs[topic]...)
+	b.mu.Unlock()
+	for _, deliver := range subs {
+		deliver(topic, payload)
+	}
+}
+
+func (b *fakeBroker) subscribed(topic string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs[topic]) > 0
+}
+
+func startFakeBroker(t *testing.T, serve func(b *fakeBroker, conn net.Conn), tlsConfig *tls.Config) *fakeBroker {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	b := &fakeBroker{listener: listener, tls: tlsConfig, subs: make(map[string][]func(string, []byte))}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				serve(b, conn)
+			}()
+		}
+	}()
+	return b
+}
+
+// serveNATS speaks enough of the NATS server protocol for the client
+func serveNATS(b *fakeBroker, conn net.Conn) {
+	if b.tls != nil {
+		conn.Write([]byte("INFO {\"server_id\":\"fake\",\"max_payload\":1048576,\"tls_required\":true}\r\n"))
+		conn = tls.Server(conn, b.tls)
+	} else {
+		conn.Write([]byte("INFO {\"server_id\":\"fake\",\"max_payload\":1048576}\r\n"))
+	}
+	var writeMu sync.Mutex
+	write := func(data string) {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		conn.Write([]byte(data))
+	}
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "CONNECT":
+			b.mu.Lock()
+			b.connects++
+			b.mu.Unlock()
+			if !strings.Contains(line, `"user":"census"`) {
+				write("-ERR 'Authorization Violation'\r\n")
+				return
+			}
+		case "PING":
+			write("PONG\r\n")
+		case "SUB":
+			sid := fields[2]
+			b.subscribe(fields[1], func(topic string, payload []byte) {
+				write(fmt.Sprintf("MSG %s %s %d\r\n%s\r\n", topic, sid, len(payload), payload))
+			})
+		case "PUB":
+			n, _ := strconv.Atoi(fields[2])
+			payload := make([]byte, n+2)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return
+			}
+			b.publish(fields[1], payload[:n])
+		}
+	}
+}
+
+// serveMQTT speaks enough of MQTT 3.1.1 for the client; messages are forwarded with QoS 0
+func serveMQTT(b *fakeBroker, conn net.Conn) {
+	if b.tls != nil {
+		conn = tls.Server(conn, b.tls)
+	}
+	c := &mqttConn{conn: conn, reader: bufio.NewReader(conn)}
+	for {
+		header, body, err := readMQTTPacket(c.reader)
+		if err != nil {
+			return
+		}
+		switch header >> 4 {
+		case mqttConnect:
+			b.mu.Lock()
+			b.connects++
+			b.mu.Unlock()
+			c.write(mqttConnack<<4, []byte{0, 0})
+		case mqttSubscribe:
+			n := int(binary.BigEndian.Uint16(body[2:]))
+			b.subscribe(string(body[4:4+n]), func(topic string, payload []byte) {
+				var out bytes.Buffer
+				writeMQTTString(&out, topic)
+				out.Write(payload)
+				c.write(mqttPublish<<4, out.Bytes())
+			})
+			c.write(mqttSuback<<4, []byte{body[0], body[1], 1})
+		case mqttPublish:
+			n := int(binary.BigEndian.Uint16(body))
+			topic, id, payload := string(body[2:2+n]), body[2+n:4+n], body[4+n:]
+			c.write(mqttPuback<<4, id)
+			b.publish(topic, payload)
+		case mqttPingreq:
+			c.write(mqttPingresp<<4, nil)
+		case mqttDisconnect:
+			return
+		}
+	}
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	tests := []struct {
+		name   string
+		serve  func(*fakeBroker, net.Conn)
+		secure bool
+		url    string
+		topic  string
+	}{
+		{"nats", serveNATS, false, "nats://census:pw@%s", "census.events.scan_completed"},
+		{"nats over tls", serveNATS, true, "tls://census:pw@%s", "census.events.scan_completed"},
+		{"nats requiring tls", serveNATS, true, "nats://census:pw@%s", "census.events.scan_completed"},
+		{"mqtt", serveMQTT, false, "mqtt://census:pw@%s", "census/events/scan_completed"},
+		{"mqtts", serveMQTT, true, "mqtts://census:pw@%s", "census/events/scan_completed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tlsConfig *tls.Config
+			if tt.secure {
+				tlsConfig = brokerTLS(t)
+			}
+			b := startFakeBroker(t, tt.serve, tlsConfig)
+			conn, err := Dial(context.Background(), fmt.Sprintf(tt.url, b.listener.Addr()))
+			if err != nil {
+				t.Fatalf("Dial failed: %v", err)
+			}
+			defer conn.Close()
+
+			topic := conn.Topic("census", "events", "scan_completed")
+			if topic != tt.topic {
+				t.Errorf("Expected topic %s, got %s", tt.topic, topic)
+			}
+			received := make(chan string, 1)
+			if err := conn.Subscribe(topic, func(payload []byte) { received <- string(payload) }); err != nil {
+				t.Fatalf("Subscribe failed: %v", err)
+			}
+			if err := conn.Publish(topic, []byte(`{"id":1}`)); err != nil {
+				t.Fatalf("Publish failed: %v", err)
+			}
+			select {
+			case payload := <-received:
+				if payload != `{"id":1}` {
+					t.Errorf("Unexpected payload %s", payload)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Expected the published message to be delivered")
+			}
+
+			conn.Close()
+			<-conn.Done()
+			if err := conn.Publish(topic, nil); err == nil {
+				t.Error("Expected publishing on a closed connection to fail")
+			}
+		})
+	}
+}
+
+func TestParseAddress(t *testing.T) {
+	brokerURL, agentID, err := ParseAddress("nats://census:pw@broker.lan:4222/nas-01/")
+	if err != nil || brokerURL != "nats://census:pw@broker.lan:4222" || agentID != "nas-01" {
+		t.Errorf("Unexpected %q %q %v", brokerURL, agentID, err)
+	}
+	for _, address := range []string{"nats://broker.lan", "mqtt://broker.lan/a.b", "mqtt://broker.lan/a/b", "http://nas:9876/nas"} {
+		if _, _, err := ParseAddress(address); err == nil {
+			t.Errorf("Expected %q to be rejected", address)
+		}
+	}
+	if !IsAddress("mqtt://broker/nas") || !IsAddress("tls://broker/nas") || !IsAddress("mqtts://broker/nas") || IsAddress("agent://nas:9876") {
+		t.Error("Unexpected IsAddress")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	big := bytes.Repeat([]byte("x"), 3*chunkSize+100)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Token") != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/echo":
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("X-Query", r.URL.Query().Get("q"))
+			w.Write(body)
+		case "/api/big":
+			w.Write(big)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	tests := []struct {
+		name  string
+		serve func(*fakeBroker, net.Conn)
+		url   string
+	}{
+		{"nats", serveNATS, "nats://census:pw@%s/nas"},
+		{"mqtt", serveMQTT, "mqtt://census:pw@%s/nas"},
+	}
+	withPlaintext(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := startFakeBroker(t, tt.serve, nil)
+			address := fmt.Sprintf(tt.url, b.listener.Addr())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go Serve(ctx, address, handler)
+			topic := DefaultPrefix + ".agents.nas.requests"
+			if tt.name == "mqtt" {
+				topic = DefaultPrefix + "/agents/nas/requests"
+			}
+			for i := 0; !b.subscribed(topic); i++ {
+				if i == 100 {
+					t.Fatal("Agent didn't subscribe")
+				}
+				time.Sleep(20 * time.Millisecond)
+			}
+
+			transport := NewTransport()
+			defer transport.Close()
+			client := &http.Client{Transport: transport, Timeout: 5 * time.Second}
+
+			req, _ := http.NewRequest(http.MethodPost, address+"/api/echo?q=1", strings.NewReader("hello"))
+			req.Header.Set("X-API-Token", "secret")
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != http.StatusOK || string(body) != "hello" || resp.Header.Get("X-Query") != "1" {
+				t.Errorf("Unexpected response %d %q %v", resp.StatusCode, body, resp.Header)
+			}
+
+			req, _ = http.NewRequest(http.MethodGet, address+"/api/big", nil)
+			req.Header.Set("X-API-Token", "secret")
+			if resp, err = client.Do(req); err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if body, _ = io.ReadAll(resp.Body); !bytes.Equal(body, big) {
+				t.Errorf("Expected a %d byte body in parts, got %d bytes", len(big), len(body))
+			}
+
+			req, _ = http.NewRequest(http.MethodGet, address+"/api/big", nil)
+			if resp, err = client.Do(req); err != nil || resp.StatusCode != http.StatusUnauthorized {
+				t.Errorf("Expected 401 without the token, got %v %v", resp, err)
+			}
+
+			// An agent that isn't connected doesn't answer
+			client.Timeout = 300 * time.Millisecond
+			req, _ = http.NewRequest(http.MethodGet, strings.Replace(address, "/nas", "/vps", 1)+"/health", nil)
+			if _, err := client.Do(req); err == nil {
+				t.Error("Expected no answer from an agent that isn't connected")
+			}
+		})
+	}
+}
+
+func TestDialRPCRefusesPlaintext(t *testing.T) {
+	for _, tt := range []struct {
+		serve func(*fakeBroker, net.Conn)
+		url   string
+	}{
+		{serveNATS, "nats://census:pw@%s"},
+		{serveMQTT, "mqtt://census:pw@%s"},
+	} {
+		b := startFakeBroker(t, tt.serve, nil)
+		if _, err := dialRPC(context.Background(), fmt.Sprintf(tt.url, b.listener.Addr())); !errors.Is(err, ErrPlaintext) {
+			t.Errorf("Expected %s to be refused without TLS, got %v", tt.url, err)
+		}
+		b.mu.Lock()
+		if b.connects != 0 {
+			t.Errorf("Expected no credentials to be sent to %s", tt.url)
+		}
+		b.mu.Unlock()
+
+		// Connections that carry no agent requests, like event subscriptions, may use plaintext
+		conn, err := Dial(context.Background(), fmt.Sprintf(tt.url, b.listener.Addr()))
+		if err != nil {
+			t.Fatalf("Expected Dial to allow %s, got %v", tt.url, err)
+		}
+		conn.Close()
+	}
+
+	// A certificate that isn't trusted fails the handshake
+	b := startFakeBroker(t, serveMQTT, brokerTLS(t))
+	rootCAs = nil
+	if _, err := Dial(context.Background(), "mqtts://census:pw@"+b.listener.Addr().String()); err == nil || !strings.Contains(err.Error(), "TLS handshake") {
+		t.Errorf("Expected an untrusted certificate to be rejected, got %v", err)
+	}
+}
+
+func TestDialNATSAuthError(t *testing.T) {
+	b := startFakeBroker(t, serveNATS, nil)
+	if _, err := Dial(context.Background(), "nats://intruder:pw@"+b.listener.Addr().String()); err == nil || !strings.Contains(err.Error(), "Authorization Violation") {
+		t.Errorf("Expected an authorization error, got %v", err)
+	}
+}
@@ -0,0 +1,359 @@
+package broker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MQTT 3.1.1 control packet types
+const (
+	mqttConnect    = 1
+	mqttConnack    = 2
+	mqttPublish    = 3
+	mqttPuback     = 4
+	mqttSubscribe  = 8
+	mqttSuback     = 9
+	mqttPingreq    = 12
+	mqttPingresp   = 13
+	mqttDisconnect = 14
+)
+
+const (
+	// mqttKeepAlive is the keep alive interval sent in CONNECT; the client pings at half of it
+	mqttKeepAlive = 60 * time.Second
+	// mqttMaxTopicLen is the longest topic a length-prefixed string holds
+	mqttMaxTopicLen = 65535
+)
+
+// mqttConn speaks MQTT 3.1.1. Messages are published and subscribed with QoS 1, so the
+// broker's PUBACK confirms each publish.
+type mqttConn struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	packetID uint16
+	acks     map[uint16]chan []byte // PUBACK and SUBACK bodies, by packet ID
+	subs     map[string]func([]byte)
+	err      error
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func dialMQTT(ctx context.Context, u *url.URL, plaintext bool) (*mqttConn, error) {
+	if u.Scheme != SchemeMQTTS && !plaintext {
+		return nil, ErrPlaintext
+	}
+	defaultPort := "1883"
+	if u.Scheme == SchemeMQTTS {
+		defaultPort = "8883"
+	}
+	conn, err := dial(ctx, u, defaultPort)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == SchemeMQTTS {
+		conn.SetDeadline(time.Now().Add(ioTimeout))
+		tlsConn, err := startTLS(conn, u)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn.SetDeadline(time.Time{})
+		conn = tlsConn
+	}
+	c := &mqttConn{
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+		acks:   make(map[uint16]chan []byte),
+		subs:   make(map[string]func([]byte)),
+		done:   make(chan struct{}),
+	}
+	if err := c.handshake(u); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go c.readLoop()
+	go c.keepAlive()
+	return c, nil
+}
+
+// handshake sends CONNECT with a clean session and reads the CONNACK
+func (c *mqttConn) handshake(u *url.URL) error {
+	c.conn.SetDeadline(time.Now().Add(ioTimeout))
+	defer c.conn.SetDeadline(time.Time{})
+
+	var body bytes.Buffer
+	writeMQTTString(&body, "MQTT")
+	body.WriteByte(4)   // protocol level 3.1.1
+	flags := byte(0x02) // clean session
+	if u.User != nil {
+		flags |= 0x80
+		if _, ok := u.User.Password(); ok {
+			flags |= 0x40
+		}
+	}
+	body.WriteByte(flags)
+	binary.Write(&body, binary.BigEndian, uint16(mqttKeepAlive/time.Second))
+	writeMQTTString(&body, fmt.Sprintf("container-census-%d", time.Now().UnixNano()%1e9))
+	if u.User != nil {
+		writeMQTTString(&body, u.User.Username())
+		if password, ok := u.User.Password(); ok {
+			writeMQTTString(&body, password)
+		}
+	}
+	if err := c.write(mqttConnect<<4, body.Bytes()); err != nil {
+		return err
+	}
+
+	packetType, ack, err := readMQTTPacket(c.reader)
+	if err != nil {
+		return err
+	}
+	if packetType>>4 != mqttConnack || len(ack) != 2 {
+		return fmt.Errorf("unexpected MQTT packet %d", packetType>>4)
+	}
+	if ack[1] != 0 {
+		return fmt.Errorf("MQTT connection refused (code %d)", ack[1])
+	}
+	return nil
+}
+
+func (c *mqttConn) Publish(topic string, payload []byte) error {
+	if len(topic) > mqttMaxTopicLen {
+		return errors.New("MQTT topic too long")
+	}
+	id, ack := c.nextPacket()
+	var body bytes.Buffer
+	writeMQTTString(&body, topic)
+	binary.Write(&body, binary.BigEndian, id)
+	body.Write(payload)
+	if err := c.write(mqttPublish<<4|0x02, body.Bytes()); err != nil { // QoS 1
+		return err
+	}
+	_, err := c.waitAck(id, ack)
+	return err
+}
+
+func (c *mqttConn) Subscribe(topic string, handler func(payload []byte)) error {
+	c.mu.Lock()
+	c.subs[topic] = handler
+	c.mu.Unlock()
+
+	id, ack := c.nextPacket()
+	var body bytes.Buffer
+	binary.Write(&body, binary.BigEndian, id)
+	writeMQTTString(&body, topic)
+	body.WriteByte(1) // QoS 1
+	if err := c.write(mqttSubscribe<<4|0x02, body.Bytes()); err != nil {
+		return err
+	}
+	result, err := c.waitAck(id, ack)
+	if err != nil {
+		return err
+	}
+	if len(result) < 3 || result[2] == 0x80 {
+		return fmt.Errorf("MQTT broker refused the subscription to %s", topic)
+	}
+	return nil
+}
+
+func (c *mqttConn) Topic(levels ...string) string {
+	return strings.Join(levels, "/")
+}
+
+func (c *mqttConn) Done() <-chan struct{} { return c.done }
+
+func (c *mqttConn) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *mqttConn) Close() error {
+	c.write(mqttDisconnect<<4, nil)
+	c.fail(ErrClosed)
+	return nil
+}
+
+// nextPacket returns a packet ID and the channel its acknowledgement arrives on
+func (c *mqttConn) nextPacket() (uint16, chan []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.packetID++
+	if c.packetID == 0 {
+		c.packetID = 1
+	}
+	ack := make(chan []byte, 1)
+	c.acks[c.packetID] = ack
+	return c.packetID, ack
+}
+
+// waitAck waits for the acknowledgement of a packet
+func (c *mqttConn) waitAck(id uint16, ack chan []byte) ([]byte, error) {
+	defer func() {
+		c.mu.Lock()
+		delete(c.acks, id)
+		c.mu.Unlock()
+	}()
+	select {
+	case body := <-ack:
+		return body, nil
+	case <-c.done:
+		return nil, c.Err()
+	case <-time.After(ioTimeout):
+		return nil, errors.New("timed out waiting for the MQTT broker")
+	}
+}
+
+func (c *mqttConn) readLoop() {
+	for {
+		header, body, err := readMQTTPacket(c.reader)
+		if err != nil {
+			c.fail(err)
+			return
+		}
+		switch header >> 4 {
+		case mqttPublish:
+			qos := (header >> 1) & 0x03
+			if len(body) < 2 {
+				c.fail(errors.New("malformed MQTT publish"))
+				return
+			}
+			n := int(binary.BigEndian.Uint16(body))
+			rest := body[2:]
+			if len(rest) < n {
+				c.fail(errors.New("malformed MQTT publish"))
+				return
+			}
+			topic, payload := string(rest[:n]), rest[n:]
+			if qos > 0 {
+				if len(payload) < 2 {
+					c.fail(errors.New("malformed MQTT publish"))
+					return
+				}
+				id := payload[:2]
+				payload = payload[2:]
+				if err := c.write(mqttPuback<<4, id); err != nil {
+					c.fail(err)
+					return
+				}
+			}
+			c.mu.Lock()
+			handler := c.subs[topic]
+			c.mu.Unlock()
+			if handler != nil {
+				handler(payload)
+			}
+		case mqttPuback, mqttSuback:
+			if len(body) < 2 {
+				continue
+			}
+			c.mu.Lock()
+			ack := c.acks[binary.BigEndian.Uint16(body)]
+			c.mu.Unlock()
+			if ack != nil {
+				select {
+				case ack <- body:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// keepAlive pings the broker within the keep alive interval
+func (c *mqttConn) keepAlive() {
+	ticker := time.NewTicker(mqttKeepAlive / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if err := c.write(mqttPingreq<<4, nil); err != nil {
+				c.fail(err)
+				return
+			}
+		}
+	}
+}
+
+// write sends a packet with its remaining length
+func (c *mqttConn) write(header byte, body []byte) error {
+	packet := []byte{header}
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if n == 0 {
+			break
+		}
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(ioTimeout))
+	_, err := c.conn.Write(append(packet, body...))
+	return err
+}
+
+// fail closes the connection, recording why
+func (c *mqttConn) fail(err error) {
+	c.closeOnce.Do(func() {
+		c.mu.Lock()
+		c.err = err
+		c.mu.Unlock()
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+// readMQTTPacket returns the fixed header byte and body of the next packet
+func readMQTTPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return 0, nil, errors.New("malformed MQTT packet length")
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(b&0x7f) * multiplier
+		multiplier *= 128
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header, body, nil
+}
+
+// writeMQTTString writes a length-prefixed UTF-8 string
+func writeMQTTString(buf *bytes.Buffer, s string) {
+	binary.Write(buf, binary.BigEndian, uint16(len(s)))
+	buf.WriteString(s)
+}
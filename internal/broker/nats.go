@@ -0,0 +1,258 @@
+package broker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/container-census/container-census/internal/version"
+)
+
+// natsConn speaks the NATS client protocol. Every publish and subscription is followed by a
+// PING, so the server's PONG confirms it was processed.
+type natsConn struct {
+	conn       net.Conn
+	reader     *bufio.Reader
+	writeMu    sync.Mutex
+	maxPayload int
+
+	mu      sync.Mutex
+	pongs   []chan error // waiting for a PONG, in the order the PINGs were sent
+	lastErr error        // an -ERR, reported to the next PONG's waiter
+	subs    map[string]func([]byte)
+	nextSID int
+	err     error
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func dialNATS(ctx context.Context, u *url.URL, plaintext bool) (*natsConn, error) {
+	conn, err := dial(ctx, u, "4222")
+	if err != nil {
+		return nil, err
+	}
+	c := &natsConn{
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+		subs:   make(map[string]func([]byte)),
+		done:   make(chan struct{}),
+	}
+	if err := c.handshake(u, plaintext); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go c.readLoop()
+	return c, nil
+}
+
+// handshake reads the server's INFO, sends CONNECT and waits for the PONG of a PING. Without
+// plaintext, a server that neither tls:// nor its INFO puts on TLS is refused.
+func (c *natsConn) handshake(u *url.URL, plaintext bool) error {
+	c.conn.SetDeadline(time.Now().Add(ioTimeout))
+	defer c.conn.SetDeadline(time.Time{})
+
+	line, err := c.readLine()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("unexpected NATS greeting: %s", line)
+	}
+	var info struct {
+		MaxPayload  int  `json:"max_payload"`
+		TLSRequired bool `json:"tls_required"`
+	}
+	json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info)
+	c.maxPayload = info.MaxPayload
+
+	// The TLS handshake follows the INFO, before any credentials are sent
+	if u.Scheme == SchemeNATSTLS || info.TLSRequired {
+		conn, err := startTLS(c.conn, u)
+		if err != nil {
+			return err
+		}
+		c.conn, c.reader = conn, bufio.NewReader(conn)
+	} else if !plaintext {
+		return ErrPlaintext
+	}
+
+	options := map[string]interface{}{
+		"verbose":  false,
+		"pedantic": false,
+		"name":     "container-census",
+		"lang":     "go",
+		"version":  version.Get(),
+	}
+	if u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			options["user"] = u.User.Username()
+			options["pass"] = password
+		} else {
+			options["auth_token"] = u.User.Username()
+		}
+	}
+	connect, _ := json.Marshal(options)
+	if _, err := fmt.Fprintf(c.conn, "CONNECT %s\r\nPING\r\n", connect); err != nil {
+		return err
+	}
+
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return err
+		}
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			c.conn.Write([]byte("PONG\r\n"))
+		case strings.HasPrefix(line, "-ERR"):
+			return natsError(line)
+		}
+	}
+}
+
+func (c *natsConn) Publish(topic string, payload []byte) error {
+	if c.maxPayload > 0 && len(payload) > c.maxPayload {
+		return fmt.Errorf("message of %d bytes exceeds the NATS server's max_payload of %d", len(payload), c.maxPayload)
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "PUB %s %d\r\n", topic, len(payload))
+	buf.Write(payload)
+	buf.WriteString("\r\n")
+	return c.send(buf.Bytes())
+}
+
+func (c *natsConn) Subscribe(topic string, handler func(payload []byte)) error {
+	c.mu.Lock()
+	c.nextSID++
+	sid := strconv.Itoa(c.nextSID)
+	c.subs[sid] = handler
+	c.mu.Unlock()
+	return c.send([]byte(fmt.Sprintf("SUB %s %s\r\n", topic, sid)))
+}
+
+func (c *natsConn) Topic(levels ...string) string {
+	return strings.Join(levels, ".")
+}
+
+func (c *natsConn) Done() <-chan struct{} { return c.done }
+
+func (c *natsConn) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *natsConn) Close() error {
+	c.fail(ErrClosed)
+	return nil
+}
+
+// send writes data followed by a PING and waits for the PONG
+func (c *natsConn) send(data []byte) error {
+	pong := make(chan error, 1)
+	c.writeMu.Lock()
+	c.mu.Lock()
+	c.pongs = append(c.pongs, pong)
+	c.mu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(ioTimeout))
+	_, err := c.conn.Write(append(data, "PING\r\n"...))
+	c.writeMu.Unlock()
+	if err != nil {
+		c.fail(err)
+		return err
+	}
+
+	select {
+	case err := <-pong:
+		return err
+	case <-c.done:
+		return c.Err()
+	case <-time.After(ioTimeout):
+		return errors.New("timed out waiting for the NATS server")
+	}
+}
+
+func (c *natsConn) readLoop() {
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			c.fail(err)
+			return
+		}
+		switch {
+		case strings.HasPrefix(line, "MSG "):
+			// MSG <subject> <sid> [reply-to] <size>
+			fields := strings.Fields(line)
+			size, err := strconv.Atoi(fields[len(fields)-1])
+			if len(fields) < 4 || err != nil {
+				c.fail(fmt.Errorf("malformed NATS message: %s", line))
+				return
+			}
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(c.reader, payload); err != nil {
+				c.fail(err)
+				return
+			}
+			c.mu.Lock()
+			handler := c.subs[fields[2]]
+			c.mu.Unlock()
+			if handler != nil {
+				handler(payload[:size])
+			}
+		case line == "PING":
+			c.writeMu.Lock()
+			c.conn.SetWriteDeadline(time.Now().Add(ioTimeout))
+			c.conn.Write([]byte("PONG\r\n"))
+			c.writeMu.Unlock()
+		case line == "PONG":
+			c.mu.Lock()
+			if len(c.pongs) > 0 {
+				c.pongs[0] <- c.lastErr
+				c.pongs = c.pongs[1:]
+				c.lastErr = nil
+			}
+			c.mu.Unlock()
+		case strings.HasPrefix(line, "-ERR"):
+			c.mu.Lock()
+			c.lastErr = natsError(line)
+			c.mu.Unlock()
+		}
+	}
+}
+
+func (c *natsConn) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// fail closes the connection, recording why
+func (c *natsConn) fail(err error) {
+	c.closeOnce.Do(func() {
+		c.mu.Lock()
+		c.err = err
+		c.mu.Unlock()
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+// natsError turns an -ERR line into an error
+func natsError(line string) error {
+	return fmt.Errorf("NATS: %s", strings.Trim(strings.TrimPrefix(line, "-ERR"), " '"))
+}
@@ -0,0 +1,402 @@
+package broker
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// chunkSize is the most response body sent in one message, well below NATS' default
+	// max_payload of 1 MB once base64 encoded
+	chunkSize = 256 * 1024
+	// maxRequestTime limits requests without a deadline
+	maxRequestTime = 5 * time.Minute
+	// maxConcurrentRequests limits the requests an agent handles at once
+	maxConcurrentRequests = 16
+)
+
+// Topics: the server publishes requests to <prefix>/agents/<agent-id>/requests and each server
+// process subscribes to its own <prefix>/servers/<server-id>/responses for the answers (levels
+// joined with the protocol's separator)
+func requestTopic(c Conn, agentID string) string {
+	return c.Topic(DefaultPrefix, "agents", agentID, "requests")
+}
+
+func responseTopic(c Conn, serverID string) string {
+	return c.Topic(DefaultPrefix, "servers", serverID, "responses")
+}
+
+// request is an HTTP request to an agent carried over a broker
+type request struct {
+	ID       string      `json:"id"`
+	ReplyTo  string      `json:"reply_to"`
+	Method   string      `json:"method"`
+	Path     string      `json:"path"` // with the query
+	Header   http.Header `json:"header,omitempty"`
+	Body     []byte      `json:"body,omitempty"`
+	Deadline time.Time   `json:"deadline"`
+}
+
+// response is a part of an agent's answer: the status and headers come with the first, and the
+// body is split over as many as it takes, in order
+type response struct {
+	ID     string      `json:"id"`
+	Seq    int         `json:"seq"`
+	Final  bool        `json:"final"`
+	Status int         `json:"status,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+}
+
+// Transport is an http.RoundTripper for agent URLs on a broker, tls://broker:4222/<agent-id>/api/...
+// It keeps one connection per broker, redialed after it is lost.
+type Transport struct {
+	serverID string
+
+	mu    sync.Mutex
+	conns map[string]*serverConn // by broker URL
+}
+
+// serverConn is a server's connection to a broker with the requests waiting for an answer
+type serverConn struct {
+	conn  Conn
+	topic string
+
+	mu      sync.Mutex
+	pending map[string]*pendingResponse
+}
+
+// pendingResponse collects the parts of an agent's answer
+type pendingResponse struct {
+	next int
+	resp response
+	body bytes.Buffer
+	err  error
+	done chan struct{}
+}
+
+// NewTransport creates a transport with a random server ID, so several servers can share a broker
+func NewTransport() *Transport {
+	return &Transport{serverID: randomID(), conns: make(map[string]*serverConn)}
+}
+
+// RoundTrip sends a request to the agent in the URL's first path level and waits for its answer
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	u := *req.URL
+	path := strings.TrimPrefix(u.Path, "/")
+	agentID, apiPath, _ := strings.Cut(path, "/")
+	u.Path, u.RawPath, u.RawQuery = "/"+agentID, "", ""
+	brokerURL, agentID, err := ParseAddress(u.String())
+	if err != nil {
+		return nil, err
+	}
+	apiPath = "/" + apiPath
+	if req.URL.RawQuery != "" {
+		apiPath += "?" + req.URL.RawQuery
+	}
+
+	var body []byte
+	if req.Body != nil {
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ctx := req.Context()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, maxRequestTime)
+		defer cancel()
+		deadline, _ = ctx.Deadline()
+	}
+
+	sc, err := t.conn(ctx, brokerURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to broker: %w", err)
+	}
+	r := request{
+		ID:       randomID(),
+		ReplyTo:  sc.topic,
+		Method:   req.Method,
+		Path:     apiPath,
+		Header:   req.Header,
+		Body:     body,
+		Deadline: deadline,
+	}
+	pending := &pendingResponse{done: make(chan struct{})}
+	sc.mu.Lock()
+	sc.pending[r.ID] = pending
+	sc.mu.Unlock()
+	defer func() {
+		sc.mu.Lock()
+		delete(sc.pending, r.ID)
+		sc.mu.Unlock()
+	}()
+
+	payload, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := sc.conn.Publish(requestTopic(sc.conn, agentID), payload); err != nil {
+		return nil, fmt.Errorf("failed to publish request: %w", err)
+	}
+
+	select {
+	case <-pending.done:
+	case <-sc.conn.Done():
+		return nil, fmt.Errorf("broker connection lost: %w", sc.conn.Err())
+	case <-ctx.Done():
+		return nil, fmt.Errorf("no answer from agent %s: %w", agentID, ctx.Err())
+	}
+	if pending.err != nil {
+		return nil, pending.err
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", pending.resp.Status, http.StatusText(pending.resp.Status)),
+		StatusCode:    pending.resp.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        pending.resp.Header,
+		Body:          io.NopCloser(bytes.NewReader(pending.body.Bytes())),
+		ContentLength: int64(pending.body.Len()),
+		Request:       req,
+	}, nil
+}
+
+// conn returns the connection to a broker, dialing it when there is none
+func (t *Transport) conn(ctx context.Context, brokerURL string) (*serverConn, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if sc, ok := t.conns[brokerURL]; ok {
+		select {
+		case <-sc.conn.Done():
+		default:
+			return sc, nil
+		}
+	}
+
+	conn, err := dialRPC(ctx, brokerURL)
+	if err != nil {
+		return nil, err
+	}
+	sc := &serverConn{conn: conn, topic: responseTopic(conn, t.serverID), pending: make(map[string]*pendingResponse)}
+	if err := conn.Subscribe(sc.topic, sc.handleResponse); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	t.conns[brokerURL] = sc
+	return sc, nil
+}
+
+// handleResponse adds a part of an answer to its request
+func (sc *serverConn) handleResponse(payload []byte) {
+	var part response
+	if err := json.Unmarshal(payload, &part); err != nil {
+		return
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	p, ok := sc.pending[part.ID]
+	if !ok || p.err != nil {
+		return
+	}
+	select {
+	case <-p.done:
+		return
+	default:
+	}
+
+	if part.Seq != p.next {
+		p.err = fmt.Errorf("agent answer arrived out of order (part %d, expected %d)", part.Seq, p.next)
+		close(p.done)
+		return
+	}
+	if part.Seq == 0 {
+		p.resp = part
+	}
+	p.body.Write(part.Body)
+	p.next++
+	if part.Final {
+		close(p.done)
+	}
+}
+
+// Close closes the transport's broker connections
+func (t *Transport) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for url, sc := range t.conns {
+		sc.conn.Close()
+		delete(t.conns, url)
+	}
+}
+
+// Serve answers the server's requests for an agent address (tls:// or mqtts://broker/<agent-id>)
+// with handler until ctx is done, reconnecting when the connection is lost
+func Serve(ctx context.Context, address string, handler http.Handler) error {
+	brokerURL, agentID, err := ParseAddress(address)
+	if err != nil {
+		return err
+	}
+
+	backoff := time.Second
+	for ctx.Err() == nil {
+		conn, err := dialRPC(ctx, brokerURL)
+		if err == nil {
+			err = serveConn(ctx, conn, agentID, handler)
+			backoff = time.Second
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		log.Printf("Broker connection to %s failed: %v (retrying in %v)", redactURL(brokerURL), err, backoff)
+		select {
+		case <-ctx.Done():
+		case <-time.After(backoff):
+		}
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+	}
+	return nil
+}
+
+// serveConn handles requests on one connection until it is lost or ctx is done
+func serveConn(ctx context.Context, conn Conn, agentID string, handler http.Handler) error {
+	defer conn.Close()
+	replyPrefix := conn.Topic(DefaultPrefix, "servers", "")
+	sem := make(chan struct{}, maxConcurrentRequests)
+
+	topic := requestTopic(conn, agentID)
+	err := conn.Subscribe(topic, func(payload []byte) {
+		var req request
+		if err := json.Unmarshal(payload, &req); err != nil || req.ID == "" || !strings.HasPrefix(req.ReplyTo, replyPrefix) {
+			return
+		}
+		go func() {
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+			answer(ctx, conn, req, handler)
+		}()
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("Serving requests from broker on %s", topic)
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-conn.Done():
+		return conn.Err()
+	}
+}
+
+// answer runs a request through handler and publishes the response in parts
+func answer(ctx context.Context, conn Conn, req request, handler http.Handler) {
+	if !req.Deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, req.Deadline)
+		defer cancel()
+	}
+
+	w := &responseBuffer{header: make(http.Header)}
+	r, err := http.NewRequestWithContext(ctx, req.Method, "http://broker"+req.Path, bytes.NewReader(req.Body))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+	} else {
+		r.Header = req.Header
+		if r.Header == nil {
+			r.Header = make(http.Header)
+		}
+		r.RemoteAddr = "broker"
+		handler.ServeHTTP(w, r)
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	body := w.body.Bytes()
+	for seq := 0; ; seq++ {
+		part := response{ID: req.ID, Seq: seq}
+		if seq == 0 {
+			part.Status, part.Header = w.status, w.header
+		}
+		n := len(body)
+		if n > chunkSize {
+			n = chunkSize
+		}
+		part.Body, body = body[:n], body[n:]
+		part.Final = len(body) == 0
+		payload, _ := json.Marshal(part)
+		if err := conn.Publish(req.ReplyTo, payload); err != nil {
+			log.Printf("Failed to answer %s %s over the broker: %v", req.Method, req.Path, err)
+			return
+		}
+		if part.Final {
+			return
+		}
+	}
+}
+
+// responseBuffer is an http.ResponseWriter that keeps the response for publishing
+type responseBuffer struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *responseBuffer) Header() http.Header { return w.header }
+
+func (w *responseBuffer) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *responseBuffer) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// ErrStreamingUnsupported is returned for streaming requests, which need a direct connection
+var ErrStreamingUnsupported = errors.New("streaming needs a direct HTTP connection to the agent, not a broker")
+
+// randomID returns 16 random hex characters
+func randomID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// redactURL removes the credentials of a broker URL for logging
+func redactURL(rawURL string) string {
+	if i := strings.Index(rawURL, "@"); i >= 0 {
+		if j := strings.Index(rawURL, "://"); j >= 0 && j < i {
+			return rawURL[:j+3] + rawURL[i+1:]
+		}
+	}
+	return rawURL
+}
@@ -0,0 +1,75 @@
+// Package certs provides TLS certificates for the built-in HTTPS server: from certificate files
+// (reloaded when they change) or obtained and renewed from an ACME CA such as Let's Encrypt.
+package certs
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// fileCheckInterval is how often certificate files are checked for changes
+const fileCheckInterval = time.Minute
+
+// FileSource serves a certificate from PEM files, reloading it when the files change so that
+// renewals by certbot or similar tools are picked up without a restart
+type FileSource struct {
+	certFile string
+	keyFile  string
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+// NewFileSource loads a certificate and key from PEM files
+func NewFileSource(certFile, keyFile string) (*FileSource, error) {
+	s := &FileSource{certFile: certFile, keyFile: keyFile}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileSource) load() error {
+	info, err := os.Stat(s.certFile)
+	if err != nil {
+		return fmt.Errorf("failed to read certificate: %w", err)
+	}
+	cert, err := tls.LoadX509KeyPair(s.certFile, s.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate: %w", err)
+	}
+	s.cert = &cert
+	s.modTime = info.ModTime()
+	return nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate
+func (s *FileSource) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Since(s.checkedAt) >= fileCheckInterval {
+		s.checkedAt = time.Now()
+		if info, err := os.Stat(s.certFile); err == nil && !info.ModTime().Equal(s.modTime) {
+			// The previous certificate stays in use if the new files are incomplete
+			if err := s.load(); err != nil {
+				log.Printf("Failed to reload certificate %s: %v", s.certFile, err)
+			}
+		}
+	}
+	return s.cert, nil
+}
+
+// TLSConfig returns a server TLS configuration using the source
+func (s *FileSource) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: s.GetCertificate,
+	}
+}
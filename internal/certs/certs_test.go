@@ -0,0 +1,78 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCertificate writes a self-signed certificate for name to certFile and keyFile
+func writeCertificate(t *testing.T, certFile, keyFile, name string) {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+}
+
+func TestFileSourceReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+
+	if _, err := NewFileSource(certFile, keyFile); err == nil {
+		t.Error("Expected missing files to fail")
+	}
+
+	writeCertificate(t, certFile, keyFile, "old.example.com")
+	s, err := NewFileSource(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := func() string {
+		cert, _ := s.GetCertificate(&tls.ClientHelloInfo{})
+		return cert.Leaf.Subject.CommonName
+	}
+	if name() != "old.example.com" {
+		t.Fatalf("Expected the loaded certificate, got %s", name())
+	}
+
+	// A renewed certificate is picked up at the next check
+	writeCertificate(t, certFile, keyFile, "new.example.com")
+	os.Chtimes(certFile, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	if name() != "old.example.com" {
+		t.Error("Expected files to be checked at most once per interval")
+	}
+	s.checkedAt = time.Time{}
+	if name() != "new.example.com" {
+		t.Errorf("Expected the renewed certificate, got %s", name())
+	}
+
+	// A half-written renewal keeps the current certificate
+	os.WriteFile(keyFile, []byte("garbage"), 0600)
+	os.Chtimes(certFile, time.Now().Add(2*time.Minute), time.Now().Add(2*time.Minute))
+	s.checkedAt = time.Time{}
+	if name() != "new.example.com" {
+		t.Errorf("Expected the previous certificate to be kept, got %s", name())
+	}
+}
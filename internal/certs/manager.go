@@ -0,0 +1,331 @@
+package certs
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme"
+)
+
+// Renewal timing
+const (
+	// RenewBefore is how long before expiry a certificate is renewed
+	RenewBefore = 30 * 24 * time.Hour
+	// checkInterval is how often the certificate is checked for renewal
+	checkInterval = 12 * time.Hour
+	// retryInterval is the wait after a failed attempt to obtain a certificate
+	retryInterval = time.Hour
+)
+
+// LetsEncryptURL is the ACME directory of Let's Encrypt's production CA
+const LetsEncryptURL = acme.LetsEncryptURL
+
+// Challenge types
+const (
+	ChallengeHTTP01    = "http-01"
+	ChallengeTLSALPN01 = "tls-alpn-01"
+)
+
+// ACMEConfig configures certificates obtained from an ACME CA
+type ACMEConfig struct {
+	Domains      []string
+	Email        string
+	DirectoryURL string // defaults to Let's Encrypt
+	CacheDir     string // account key and certificate
+	Challenge    string // ChallengeTLSALPN01 (default) or ChallengeHTTP01
+}
+
+// ACMEManager obtains a certificate for the configured domains and renews it before it expires.
+// For tls-alpn-01 the HTTPS listener must be reachable on port 443, for http-01 HTTPHandler must
+// be served on port 80.
+type ACMEManager struct {
+	config ACMEConfig
+
+	mu     sync.Mutex
+	cert   *tls.Certificate
+	tokens map[string]string           // http-01 token -> key authorization
+	alpn   map[string]*tls.Certificate // tls-alpn-01 domain -> validation certificate
+}
+
+// NewACMEManager validates the configuration and loads a cached certificate if there is one
+func NewACMEManager(cfg ACMEConfig) (*ACMEManager, error) {
+	if len(cfg.Domains) == 0 {
+		return nil, errors.New("at least one domain is required")
+	}
+	if cfg.DirectoryURL == "" {
+		cfg.DirectoryURL = LetsEncryptURL
+	}
+	switch cfg.Challenge {
+	case "":
+		cfg.Challenge = ChallengeTLSALPN01
+	case ChallengeTLSALPN01, ChallengeHTTP01:
+	default:
+		return nil, fmt.Errorf("unknown challenge %q (use %s or %s)", cfg.Challenge, ChallengeTLSALPN01, ChallengeHTTP01)
+	}
+	if err := os.MkdirAll(cfg.CacheDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create certificate cache: %w", err)
+	}
+
+	m := &ACMEManager{
+		config: cfg,
+		tokens: make(map[string]string),
+		alpn:   make(map[string]*tls.Certificate),
+	}
+	if cert, err := tls.LoadX509KeyPair(m.certPath(), m.certPath()); err == nil {
+		m.cert = &cert
+	}
+	return m, nil
+}
+
+// Challenge returns the challenge type used for validation
+func (m *ACMEManager) Challenge() string {
+	return m.config.Challenge
+}
+
+// Run obtains the certificate if needed and keeps it renewed until ctx is done
+func (m *ACMEManager) Run(ctx context.Context) {
+	for {
+		wait := checkInterval
+		if m.needsRenewal() {
+			if err := m.obtain(ctx); err != nil {
+				log.Printf("Failed to obtain certificate for %s: %v", strings.Join(m.config.Domains, ", "), err)
+				wait = retryInterval
+			} else {
+				log.Printf("Obtained certificate for %s", strings.Join(m.config.Domains, ", "))
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (m *ACMEManager) needsRenewal() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.cert == nil || m.cert.Leaf == nil || time.Until(m.cert.Leaf.NotAfter) < RenewBefore
+}
+
+// GetCertificate implements tls.Config.GetCertificate, answering tls-alpn-01 validation
+// connections with their challenge certificate
+func (m *ACMEManager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(hello.SupportedProtos) == 1 && hello.SupportedProtos[0] == acme.ALPNProto {
+		if cert, ok := m.alpn[strings.ToLower(hello.ServerName)]; ok {
+			return cert, nil
+		}
+		return nil, fmt.Errorf("no challenge for %q", hello.ServerName)
+	}
+	if m.cert == nil {
+		return nil, errors.New("certificate not obtained yet")
+	}
+	return m.cert, nil
+}
+
+// TLSConfig returns a server TLS configuration using the manager
+func (m *ACMEManager) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: m.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1", acme.ALPNProto},
+	}
+}
+
+// HTTPHandler answers http-01 challenges and redirects everything else to HTTPS
+func (m *ACMEManager) HTTPHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token, ok := strings.CutPrefix(r.URL.Path, "/.well-known/acme-challenge/"); ok {
+			m.mu.Lock()
+			keyAuth, found := m.tokens[token]
+			m.mu.Unlock()
+			if !found {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte(keyAuth))
+			return
+		}
+
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+			if strings.Contains(host, ":") {
+				host = "[" + host + "]"
+			}
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
+
+// obtain orders a new certificate and stores it
+func (m *ACMEManager) obtain(ctx context.Context) error {
+	accountKey, err := m.accountKey()
+	if err != nil {
+		return err
+	}
+	client := &acme.Client{Key: accountKey, DirectoryURL: m.config.DirectoryURL, UserAgent: "container-census"}
+	account := &acme.Account{}
+	if m.config.Email != "" {
+		account.Contact = []string{"mailto:" + m.config.Email}
+	}
+	if _, err := client.Register(ctx, account, acme.AcceptTOS); err != nil && !errors.Is(err, acme.ErrAccountAlreadyExists) {
+		return fmt.Errorf("failed to register account: %w", err)
+	}
+
+	order, err := client.AuthorizeOrder(ctx, acme.DomainIDs(m.config.Domains...))
+	if err != nil {
+		return fmt.Errorf("failed to create order: %w", err)
+	}
+	for _, authzURL := range order.AuthzURLs {
+		if err := m.authorize(ctx, client, authzURL); err != nil {
+			return err
+		}
+	}
+	if order, err = client.WaitOrder(ctx, order.URI); err != nil {
+		return fmt.Errorf("order failed: %w", err)
+	}
+
+	certKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: m.config.Domains[0]},
+		DNSNames: m.config.Domains,
+	}, certKey)
+	if err != nil {
+		return err
+	}
+	chain, _, err := client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return fmt.Errorf("failed to finalize order: %w", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(certKey)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	for _, der := range chain {
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})...)
+	cert, err := tls.X509KeyPair(data, data)
+	if err != nil {
+		return fmt.Errorf("invalid certificate from CA: %w", err)
+	}
+	if err := os.WriteFile(m.certPath(), data, 0600); err != nil {
+		return fmt.Errorf("failed to save certificate: %w", err)
+	}
+
+	m.mu.Lock()
+	m.cert = &cert
+	m.mu.Unlock()
+	return nil
+}
+
+// authorize completes the configured challenge of an authorization
+func (m *ACMEManager) authorize(ctx context.Context, client *acme.Client, authzURL string) error {
+	authz, err := client.GetAuthorization(ctx, authzURL)
+	if err != nil {
+		return err
+	}
+	if authz.Status == acme.StatusValid {
+		return nil
+	}
+	domain := authz.Identifier.Value
+
+	var challenge *acme.Challenge
+	for _, c := range authz.Challenges {
+		if c.Type == m.config.Challenge {
+			challenge = c
+		}
+	}
+	if challenge == nil {
+		return fmt.Errorf("CA offers no %s challenge for %s", m.config.Challenge, domain)
+	}
+
+	if m.config.Challenge == ChallengeHTTP01 {
+		keyAuth, err := client.HTTP01ChallengeResponse(challenge.Token)
+		if err != nil {
+			return err
+		}
+		m.mu.Lock()
+		m.tokens[challenge.Token] = keyAuth
+		m.mu.Unlock()
+	} else {
+		cert, err := client.TLSALPN01ChallengeCert(challenge.Token, domain)
+		if err != nil {
+			return err
+		}
+		m.mu.Lock()
+		m.alpn[strings.ToLower(domain)] = &cert
+		m.mu.Unlock()
+	}
+
+	defer func() {
+		m.mu.Lock()
+		delete(m.tokens, challenge.Token)
+		delete(m.alpn, strings.ToLower(domain))
+		m.mu.Unlock()
+	}()
+
+	if _, err := client.Accept(ctx, challenge); err != nil {
+		return fmt.Errorf("validation of %s failed: %w", domain, err)
+	}
+	if _, err := client.WaitAuthorization(ctx, authz.URI); err != nil {
+		return fmt.Errorf("validation of %s failed: %w", domain, err)
+	}
+	return nil
+}
+
+// accountKey loads the ACME account key, creating it on first use
+func (m *ACMEManager) accountKey() (*ecdsa.PrivateKey, error) {
+	path := filepath.Join(m.config.CacheDir, "account.key")
+	if data, err := os.ReadFile(path); err == nil {
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("invalid account key in %s", path)
+		}
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		return nil, fmt.Errorf("failed to save account key: %w", err)
+	}
+	return key, nil
+}
+
+// certPath is the file holding the certificate chain and its key
+func (m *ACMEManager) certPath() string {
+	return filepath.Join(m.config.CacheDir, strings.ReplaceAll(m.config.Domains[0], "*", "_")+".pem")
+}
@@ -0,0 +1,332 @@
+package certs
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/acme"
+)
+
+// idPeACMEIdentifier is the certificate extension carrying the tls-alpn-01 key authorization digest
+var idPeACMEIdentifier = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 31}
+
+// fakeCA is a minimal ACME server that checks JWS signatures and validates challenges through
+// the validate callback
+type fakeCA struct {
+	t        *testing.T
+	server   *httptest.Server
+	caKey    *ecdsa.PrivateKey
+	validate func(challengeType, domain, token string) string // returns the key authorization seen
+
+	mu         sync.Mutex
+	accountKey *ecdsa.PublicKey
+	nonces     map[string]bool
+	nonceCount int
+	token      string
+	domain     string
+	authzValid bool
+	csr        *x509.CertificateRequest
+	orders     int
+}
+
+func newFakeCA(t *testing.T) *fakeCA {
+	ca := &fakeCA{t: t, nonces: make(map[string]bool)}
+	ca.caKey, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ca.server = httptest.NewServer(http.HandlerFunc(ca.handle))
+	t.Cleanup(ca.server.Close)
+	return ca
+}
+
+func (ca *fakeCA) url(path string) string {
+	return ca.server.URL + path
+}
+
+func (ca *fakeCA) newNonce() string {
+	ca.nonceCount++
+	nonce := fmt.Sprintf("nonce-%d", ca.nonceCount)
+	ca.nonces[nonce] = true
+	return nonce
+}
+
+func (ca *fakeCA) handle(w http.ResponseWriter, r *http.Request) {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	w.Header().Set("Replay-Nonce", ca.newNonce())
+
+	switch r.URL.Path {
+	case "/directory":
+		json.NewEncoder(w).Encode(map[string]string{
+			"newNonce":   ca.url("/nonce"),
+			"newAccount": ca.url("/account"),
+			"newOrder":   ca.url("/order"),
+		})
+		return
+	case "/nonce":
+		return
+	}
+
+	payload, ok := ca.verify(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.URL.Path {
+	case "/account":
+		w.Header().Set("Location", ca.url("/account/1"))
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"status":"valid"}`))
+	case "/order":
+		var req struct {
+			Identifiers []struct{ Value string } `json:"identifiers"`
+		}
+		json.Unmarshal(payload, &req)
+		ca.domain = req.Identifiers[0].Value
+		ca.token = fmt.Sprintf("token%d", ca.orders)
+		ca.authzValid = false
+		ca.orders++
+		w.Header().Set("Location", ca.url("/order/1"))
+		w.WriteHeader(http.StatusCreated)
+		ca.writeOrder(w, "pending")
+	case "/authz/1":
+		status := "pending"
+		if ca.authzValid {
+			status = "valid"
+		}
+		fmt.Fprintf(w, `{"status":%q,"identifier":{"type":"dns","value":%q},"challenges":[
+			{"type":"http-01","url":%q,"token":%q,"status":"pending"},
+			{"type":"tls-alpn-01","url":%q,"token":%q,"status":"pending"}]}`,
+			status, ca.domain, ca.url("/chall/http-01"), ca.token, ca.url("/chall/tls-alpn-01"), ca.token)
+	case "/chall/http-01", "/chall/tls-alpn-01":
+		challengeType := strings.TrimPrefix(r.URL.Path, "/chall/")
+		// Validate outside the lock, as a real CA would connect back asynchronously
+		ca.mu.Unlock()
+		got := ca.validate(challengeType, ca.domain, ca.token)
+		ca.mu.Lock()
+		ca.authzValid = got == ca.keyAuthorization()
+		if !ca.authzValid {
+			ca.t.Errorf("Expected key authorization %q, got %q", ca.keyAuthorization(), got)
+		}
+		w.Write([]byte(`{"status":"processing"}`))
+	case "/finalize":
+		var req struct {
+			CSR string `json:"csr"`
+		}
+		json.Unmarshal(payload, &req)
+		der, _ := base64.RawURLEncoding.DecodeString(req.CSR)
+		csr, err := x509.ParseCertificateRequest(der)
+		if err != nil || csr.CheckSignature() != nil {
+			ca.t.Errorf("Invalid CSR: %v", err)
+		}
+		ca.csr = csr
+		ca.writeOrder(w, "valid")
+	case "/order/1":
+		ca.writeOrder(w, "valid")
+	case "/cert":
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(int64(ca.orders)),
+			Subject:      pkix.Name{CommonName: ca.csr.DNSNames[0]},
+			DNSNames:     ca.csr.DNSNames,
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+		}
+		der, _ := x509.CreateCertificate(rand.Reader, template, template, ca.csr.PublicKey, ca.caKey)
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		pem.Encode(w, &pem.Block{Type: "CERTIFICATE", Bytes: der})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (ca *fakeCA) writeOrder(w http.ResponseWriter, status string) {
+	fmt.Fprintf(w, `{"status":%q,"authorizations":[%q],"finalize":%q,"certificate":%q}`,
+		status, ca.url("/authz/1"), ca.url("/finalize"), ca.url("/cert"))
+}
+
+func (ca *fakeCA) keyAuthorization() string {
+	return keyAuthorization(ca.t, ca.token, ca.accountKey)
+}
+
+func keyAuthorization(t *testing.T, token string, accountKey *ecdsa.PublicKey) string {
+	thumbprint, err := acme.JWKThumbprint(accountKey)
+	if err != nil {
+		t.Errorf("Failed to compute the account key thumbprint: %v", err)
+	}
+	return token + "." + thumbprint
+}
+
+// verify checks the nonce, URL and signature of a JWS request and returns its payload
+func (ca *fakeCA) verify(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	var jws struct{ Protected, Payload, Signature string }
+	if err := json.NewDecoder(r.Body).Decode(&jws); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	header, _ := base64.RawURLEncoding.DecodeString(jws.Protected)
+	var protected struct {
+		Alg   string
+		Nonce string
+		URL   string
+		Kid   string
+		JWK   map[string]string
+	}
+	json.Unmarshal(header, &protected)
+
+	if !ca.nonces[protected.Nonce] {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"type":"urn:ietf:params:acme:error:badNonce","detail":"bad nonce"}`))
+		return nil, false
+	}
+	delete(ca.nonces, protected.Nonce)
+	if protected.URL != ca.url(r.URL.Path) || protected.Alg != "ES256" {
+		ca.t.Errorf("Unexpected protected header %s", header)
+	}
+
+	key := ca.accountKey
+	if protected.JWK != nil {
+		x, _ := base64.RawURLEncoding.DecodeString(protected.JWK["x"])
+		y, _ := base64.RawURLEncoding.DecodeString(protected.JWK["y"])
+		key = &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		ca.accountKey = key
+	} else if protected.Kid != ca.url("/account/1") {
+		ca.t.Errorf("Expected the account URL as kid, got %q", protected.Kid)
+	}
+
+	signature, _ := base64.RawURLEncoding.DecodeString(jws.Signature)
+	digest := sha256.Sum256([]byte(jws.Protected + "." + jws.Payload))
+	if key == nil || len(signature) != 64 ||
+		!ecdsa.Verify(key, digest[:], new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])) {
+		ca.t.Errorf("Invalid signature on %s", r.URL.Path)
+		http.Error(w, "bad signature", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	payload, _ := base64.RawURLEncoding.DecodeString(jws.Payload)
+	return payload, true
+}
+
+func TestACMEManagerHTTP01(t *testing.T) {
+	ca := newFakeCA(t)
+	dir := t.TempDir()
+	m, err := NewACMEManager(ACMEConfig{
+		Domains:      []string{"census.example.com"},
+		Email:        "admin@example.com",
+		DirectoryURL: ca.url("/directory"),
+		CacheDir:     dir,
+		Challenge:    ChallengeHTTP01,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: "census.example.com"}); err == nil {
+		t.Error("Expected no certificate before it is obtained")
+	}
+
+	ca.validate = func(challengeType, domain, token string) string {
+		if challengeType != ChallengeHTTP01 {
+			t.Errorf("Expected http-01, got %s", challengeType)
+		}
+		rec := httptest.NewRecorder()
+		m.HTTPHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://"+domain+"/.well-known/acme-challenge/"+token, nil))
+		return rec.Body.String()
+	}
+
+	if err := m.obtain(context.Background()); err != nil {
+		t.Fatalf("obtain failed: %v", err)
+	}
+	cert, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: "census.example.com"})
+	if err != nil || cert.Leaf == nil || cert.Leaf.DNSNames[0] != "census.example.com" {
+		t.Fatalf("Expected the issued certificate, got %v (%v)", cert, err)
+	}
+	if m.needsRenewal() {
+		t.Error("Expected a fresh certificate not to need renewal")
+	}
+	if len(m.tokens) != 0 {
+		t.Error("Expected challenge tokens to be removed")
+	}
+
+	// Everything else on port 80 is redirected to HTTPS
+	rec := httptest.NewRecorder()
+	m.HTTPHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://census.example.com:80/api/containers?x=1", nil))
+	if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "https://census.example.com/api/containers?x=1" {
+		t.Errorf("Expected a redirect to HTTPS, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+
+	// The cached certificate and account are used after a restart
+	reloaded, err := NewACMEManager(ACMEConfig{Domains: []string{"census.example.com"}, DirectoryURL: ca.url("/directory"), CacheDir: dir})
+	if err != nil || reloaded.needsRenewal() {
+		t.Fatalf("Expected the cached certificate to be loaded (%v)", err)
+	}
+	key, err := reloaded.accountKey()
+	if err != nil || !key.PublicKey.Equal(ca.accountKey) {
+		t.Errorf("Expected the cached account key to be reused (%v)", err)
+	}
+}
+
+func TestACMEManagerTLSALPN01(t *testing.T) {
+	ca := newFakeCA(t)
+	m, err := NewACMEManager(ACMEConfig{
+		Domains:      []string{"census.example.com"},
+		DirectoryURL: ca.url("/directory"),
+		CacheDir:     t.TempDir(),
+	})
+	if err != nil || m.Challenge() != ChallengeTLSALPN01 {
+		t.Fatalf("Expected tls-alpn-01 by default (%v)", err)
+	}
+
+	ca.validate = func(challengeType, domain, token string) string {
+		cert, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: domain, SupportedProtos: []string{"acme-tls/1"}})
+		if err != nil {
+			t.Errorf("Expected a challenge certificate: %v", err)
+			return ""
+		}
+		leaf, _ := x509.ParseCertificate(cert.Certificate[0])
+		for _, ext := range leaf.Extensions {
+			if ext.Id.Equal(idPeACMEIdentifier) {
+				var digest []byte
+				asn1.Unmarshal(ext.Value, &digest)
+				// Report the key authorization that matches the digest
+				want := keyAuthorization(t, token, ca.accountKey)
+				sum := sha256.Sum256([]byte(want))
+				if ext.Critical && bytes.Equal(digest, sum[:]) {
+					return want
+				}
+			}
+		}
+		return "missing acmeIdentifier extension"
+	}
+
+	if err := m.obtain(context.Background()); err != nil {
+		t.Fatalf("obtain failed: %v", err)
+	}
+	if _, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: "census.example.com", SupportedProtos: []string{"acme-tls/1"}}); err == nil {
+		t.Error("Expected the challenge certificate to be removed after validation")
+	}
+}
+
+func TestNewACMEManagerValidation(t *testing.T) {
+	if _, err := NewACMEManager(ACMEConfig{CacheDir: t.TempDir()}); err == nil {
+		t.Error("Expected domains to be required")
+	}
+	if _, err := NewACMEManager(ACMEConfig{Domains: []string{"a.example"}, CacheDir: t.TempDir(), Challenge: "dns-01"}); err == nil {
+		t.Error("Expected an unsupported challenge to be rejected")
+	}
+}
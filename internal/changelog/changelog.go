@@ -0,0 +1,231 @@
+// Package changelog finds the upstream release notes of a container image. The source repository
+// comes from the image's OCI or label-schema labels (or a ghcr.io image path), and the latest
+// GitHub release of that repository is cached per image.
+package changelog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// Defaults for NewFetcher
+const (
+	DefaultAPIURL = "https://api.github.com"
+	// DefaultTTL is how long a cached changelog (or the lack of one) is used before asking again
+	DefaultTTL = 6 * time.Hour
+	// MaxSummaryLength caps the part of the release notes kept as summary
+	MaxSummaryLength = 500
+)
+
+// sourceLabels are the image labels that may point at the source repository, most specific first
+var sourceLabels = []string{
+	"org.opencontainers.image.source",
+	"org.label-schema.vcs-url",
+	"org.opencontainers.image.url",
+	"org.label-schema.url",
+}
+
+// Store caches changelogs
+type Store interface {
+	GetImageChangelog(image string) (*models.ImageChangelog, error)
+	SaveImageChangelog(c models.ImageChangelog) error
+}
+
+// Fetcher resolves and caches the changelogs of images
+type Fetcher struct {
+	store      Store
+	httpClient *http.Client
+	apiURL     string
+	token      string
+	ttl        time.Duration
+}
+
+// NewFetcher returns a fetcher using the GitHub API. The token is optional and raises the
+// rate limit from 60 to 5000 requests per hour.
+func NewFetcher(store Store, token string) *Fetcher {
+	return &Fetcher{
+		store:      store,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		apiURL:     DefaultAPIURL,
+		token:      token,
+		ttl:        DefaultTTL,
+	}
+}
+
+// Resolve returns the changelog for a container's image, from the cache when it is fresh.
+// It returns nil when the image has no known GitHub repository.
+func (f *Fetcher) Resolve(ctx context.Context, container models.Container) (*models.ImageChangelog, error) {
+	cached, err := f.store.GetImageChangelog(container.Image)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil && time.Since(cached.FetchedAt) < f.ttl {
+		return usable(cached), nil
+	}
+
+	c := models.ImageChangelog{Image: container.Image, FetchedAt: time.Now()}
+	owner, repo, ok := SourceRepository(container.Labels, container.Image)
+	if ok {
+		c.Source = fmt.Sprintf("https://github.com/%s/%s", owner, repo)
+		if err := f.fetchLatestRelease(ctx, owner, repo, &c); err != nil {
+			// Keep serving the previous changelog rather than caching the failure
+			if cached != nil {
+				return usable(cached), err
+			}
+			return nil, err
+		}
+	}
+
+	if err := f.store.SaveImageChangelog(c); err != nil {
+		return nil, err
+	}
+	return usable(&c), nil
+}
+
+// usable returns the changelog if it has a source repository
+func usable(c *models.ImageChangelog) *models.ImageChangelog {
+	if c.Source == "" {
+		return nil
+	}
+	return c
+}
+
+// fetchLatestRelease fills in the latest release; a repository without releases is not an error
+func (f *Fetcher) fetchLatestRelease(ctx context.Context, owner, repo string, c *models.ImageChangelog) error {
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/releases/latest", f.apiURL, url.PathEscape(owner), url.PathEscape(repo))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "Container-Census")
+	if f.token != "" {
+		req.Header.Set("Authorization", "Bearer "+f.token)
+	}
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch release of %s/%s: %w", owner, repo, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch release of %s/%s: HTTP %d", owner, repo, resp.StatusCode)
+	}
+
+	var release struct {
+		TagName     string    `json:"tag_name"`
+		Name        string    `json:"name"`
+		HTMLURL     string    `json:"html_url"`
+		Body        string    `json:"body"`
+		PublishedAt time.Time `json:"published_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return fmt.Errorf("invalid release of %s/%s: %w", owner, repo, err)
+	}
+
+	c.Version = release.TagName
+	c.Name = release.Name
+	c.URL = release.HTMLURL
+	c.Summary = Summarize(release.Body)
+	c.PublishedAt = release.PublishedAt
+	return nil
+}
+
+// SourceRepository returns the GitHub repository an image is built from, using its labels or,
+// for images on ghcr.io, the image path
+func SourceRepository(labels map[string]string, image string) (owner, repo string, ok bool) {
+	for _, label := range sourceLabels {
+		if owner, repo, ok := parseGitHubURL(labels[label]); ok {
+			return owner, repo, true
+		}
+	}
+
+	if rest, found := strings.CutPrefix(image, "ghcr.io/"); found {
+		if i := strings.IndexAny(rest, ":@"); i >= 0 {
+			rest = rest[:i]
+		}
+		parts := strings.Split(rest, "/")
+		if len(parts) >= 2 && parts[0] != "" && parts[1] != "" {
+			return parts[0], parts[1], true
+		}
+	}
+	return "", "", false
+}
+
+// parseGitHubURL extracts owner and repository from URLs such as https://github.com/owner/repo.git,
+// git@github.com:owner/repo or github.com/owner/repo/tree/main
+func parseGitHubURL(raw string) (owner, repo string, ok bool) {
+	raw = strings.TrimSpace(raw)
+	if rest, found := strings.CutPrefix(raw, "git@github.com:"); found {
+		raw = "github.com/" + rest
+	}
+	if i := strings.Index(raw, "://"); i >= 0 {
+		raw = raw[i+3:]
+	}
+	raw = strings.TrimPrefix(raw, "www.")
+
+	rest, found := strings.CutPrefix(raw, "github.com/")
+	if !found {
+		return "", "", false
+	}
+	parts := strings.Split(rest, "/")
+	if len(parts) < 2 {
+		return "", "", false
+	}
+	owner = parts[0]
+	repo = strings.TrimSuffix(parts[1], ".git")
+	if i := strings.IndexAny(repo, "?#"); i >= 0 {
+		repo = repo[:i]
+	}
+	if owner == "" || repo == "" {
+		return "", "", false
+	}
+	return owner, repo, true
+}
+
+// Summarize returns the start of release notes as plain lines, without blank lines, HTML comments
+// or markdown heading markers, cut at MaxSummaryLength
+func Summarize(body string) string {
+	var lines []string
+	inComment := false
+	for _, line := range strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if inComment {
+			if strings.Contains(line, "-->") {
+				inComment = false
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "<!--") {
+			inComment = !strings.Contains(line, "-->")
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimLeft(line, "#"))
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	summary := strings.Join(lines, "\n")
+	if len(summary) <= MaxSummaryLength {
+		return summary
+	}
+	cut := summary[:MaxSummaryLength]
+	// Don't split a multi-byte character
+	for len(cut) > 0 && !utf8.RuneStart(summary[len(cut)]) {
+		cut = cut[:len(cut)-1]
+	}
+	return strings.TrimSpace(cut) + "…"
+}
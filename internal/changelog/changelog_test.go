@@ -0,0 +1,134 @@
+package changelog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+type memoryStore map[string]models.ImageChangelog
+
+func (m memoryStore) GetImageChangelog(image string) (*models.ImageChangelog, error) {
+	c, ok := m[image]
+	if !ok {
+		return nil, nil
+	}
+	return &c, nil
+}
+
+func (m memoryStore) SaveImageChangelog(c models.ImageChangelog) error {
+	m[c.Image] = c
+	return nil
+}
+
+func TestSourceRepository(t *testing.T) {
+	tests := []struct {
+		labels map[string]string
+		image  string
+		want   string
+	}{
+		{map[string]string{"org.opencontainers.image.source": "https://github.com/jellyfin/jellyfin"}, "jellyfin/jellyfin:latest", "jellyfin/jellyfin"},
+		{map[string]string{"org.opencontainers.image.source": "https://github.com/owner/repo.git"}, "x", "owner/repo"},
+		{map[string]string{"org.label-schema.vcs-url": "git@github.com:owner/repo.git"}, "x", "owner/repo"},
+		{map[string]string{"org.opencontainers.image.url": "https://www.github.com/owner/repo/tree/main#readme"}, "x", "owner/repo"},
+		// The source label wins over a less specific one
+		{map[string]string{"org.opencontainers.image.url": "https://github.com/other/site", "org.opencontainers.image.source": "https://github.com/owner/repo"}, "x", "owner/repo"},
+		{map[string]string{"org.opencontainers.image.source": "https://gitlab.com/owner/repo"}, "nginx:latest", ""},
+		{nil, "ghcr.io/home-assistant/home-assistant:stable", "home-assistant/home-assistant"},
+		{nil, "ghcr.io/owner/repo@sha256:abc", "owner/repo"},
+		{nil, "ghcr.io/lonely", ""},
+		{nil, "nginx:latest", ""},
+	}
+	for _, tt := range tests {
+		owner, repo, ok := SourceRepository(tt.labels, tt.image)
+		got := ""
+		if ok {
+			got = owner + "/" + repo
+		}
+		if got != tt.want {
+			t.Errorf("SourceRepository(%v, %q) = %q, want %q", tt.labels, tt.image, got, tt.want)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	body := "<!-- Release notes generated\nby a bot -->\r\n## What's Changed\r\n\r\n* Fix login by @dev\n\n### Security\n- Bump openssl\n"
+	if got := Summarize(body); got != "What's Changed\n* Fix login by @dev\nSecurity\n- Bump openssl" {
+		t.Errorf("Unexpected summary: %q", got)
+	}
+
+	long := strings.Repeat("é", MaxSummaryLength)
+	got := Summarize(long)
+	if !strings.HasSuffix(got, "…") || len(got) > MaxSummaryLength+len("…") || !strings.HasPrefix(long, strings.TrimSuffix(got, "…")) {
+		t.Errorf("Expected the summary cut on a character boundary, got %d bytes", len(got))
+	}
+}
+
+func TestResolve(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/repos/owner/app/releases/latest":
+			if r.Header.Get("Authorization") != "Bearer gh-token" {
+				t.Errorf("Expected the token to be sent, got %q", r.Header.Get("Authorization"))
+			}
+			w.Write([]byte(`{"tag_name":"v2.0.0","name":"Two","html_url":"https://github.com/owner/app/releases/tag/v2.0.0","body":"## Breaking\n- New config format","published_at":"2025-05-01T10:00:00Z"}`))
+		case "/repos/owner/norelease/releases/latest":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	store := memoryStore{}
+	f := NewFetcher(store, "gh-token")
+	f.apiURL = server.URL
+	ctx := context.Background()
+
+	app := models.Container{Image: "owner/app:latest", Labels: map[string]string{"org.opencontainers.image.source": "https://github.com/owner/app"}}
+	c, err := f.Resolve(ctx, app)
+	if err != nil || c == nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if c.Version != "v2.0.0" || c.Summary != "Breaking\n- New config format" || c.Source != "https://github.com/owner/app" {
+		t.Errorf("Unexpected changelog: %+v", c)
+	}
+
+	// Served from the cache
+	if _, err := f.Resolve(ctx, app); err != nil || requests != 1 {
+		t.Errorf("Expected a cached changelog, got %d requests (%v)", requests, err)
+	}
+
+	// A repository without releases still has a source link
+	c, err = f.Resolve(ctx, models.Container{Image: "ghcr.io/owner/norelease:latest"})
+	if err != nil || c == nil || c.URL != "" || c.Source != "https://github.com/owner/norelease" {
+		t.Errorf("Expected a changelog with only a source, got %+v (%v)", c, err)
+	}
+
+	// Images without a GitHub repository are cached as such without asking GitHub
+	before := requests
+	c, err = f.Resolve(ctx, models.Container{Image: "nginx:latest"})
+	if err != nil || c != nil || requests != before {
+		t.Errorf("Expected no changelog and no request, got %+v (%v)", c, err)
+	}
+	if _, ok := store["nginx:latest"]; !ok {
+		t.Error("Expected the missing changelog to be cached")
+	}
+
+	// A failed refresh keeps the stale changelog
+	stale := store["owner/app:latest"]
+	stale.FetchedAt = time.Now().Add(-2 * DefaultTTL)
+	store["owner/app:latest"] = stale
+	f.apiURL = server.URL + "/broken"
+	c, err = f.Resolve(ctx, app)
+	if err == nil || c == nil || c.Version != "v2.0.0" {
+		t.Errorf("Expected the stale changelog and an error, got %+v (%v)", c, err)
+	}
+}
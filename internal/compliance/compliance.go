@@ -0,0 +1,293 @@
+// Package compliance runs a subset of the CIS Docker Benchmark against a Docker host. Checks
+// that only need the Docker API work against any host; file checks need the daemon's socket and
+// config to be visible to the auditor, so they only run on the host itself (e.g. in an agent).
+package compliance
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/system"
+	"github.com/docker/docker/client"
+)
+
+// Benchmark is the benchmark version the check IDs refer to
+const Benchmark = "CIS Docker Benchmark v1.6.0"
+
+// Default locations of the files checked by CheckFiles
+const (
+	DefaultSocketPath       = "/var/run/docker.sock"
+	DefaultDaemonConfigPath = "/etc/docker/daemon.json"
+)
+
+// FileOptions selects the files checked by CheckFiles. Empty paths skip the check.
+type FileOptions struct {
+	SocketPath       string
+	DaemonConfigPath string
+}
+
+// LocalFiles returns the files to check for a daemon reached at daemonHost (see
+// client.DaemonHost), or nil if the daemon is remote and its files aren't visible. Docker Desktop
+// on macOS runs the daemon in a VM, so its socket is a user-owned forward and the daemon's config
+// isn't on the host either.
+func LocalFiles(daemonHost string) *FileOptions {
+	socketPath, ok := strings.CutPrefix(daemonHost, "unix://")
+	if !ok || runtime.GOOS == "darwin" {
+		return nil
+	}
+	return &FileOptions{
+		SocketPath:       socketPath,
+		DaemonConfigPath: DefaultDaemonConfigPath,
+	}
+}
+
+// Audit runs the daemon checks against a Docker client, plus the file checks if files is non-nil
+func Audit(ctx context.Context, dockerClient *client.Client, files *FileOptions) (*models.ComplianceAudit, error) {
+	info, err := dockerClient.Info(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get docker info: %w", err)
+	}
+
+	// The default bridge is missing if the daemon runs with --bridge=none
+	var bridge *network.Inspect
+	if inspect, err := dockerClient.NetworkInspect(ctx, "bridge", network.InspectOptions{}); err == nil {
+		bridge = &inspect
+	}
+
+	checks := CheckDaemon(info, bridge)
+	if files != nil {
+		checks = append(checks, CheckFiles(*files)...)
+	}
+
+	audit := &models.ComplianceAudit{
+		Benchmark: Benchmark,
+		Checks:    checks,
+		AuditedAt: time.Now(),
+	}
+	audit.Tally()
+	return audit, nil
+}
+
+// CheckDaemon evaluates the daemon configuration recommendations from section 2 of the benchmark
+func CheckDaemon(info system.Info, bridge *network.Inspect) []models.ComplianceCheck {
+	checks := make([]models.ComplianceCheck, 0, 8)
+
+	// 2.2 Inter-container communication on the default bridge
+	icc := models.ComplianceCheck{ID: "2.2", Title: "Network traffic is restricted between containers on the default bridge"}
+	if bridge == nil {
+		skip(&icc, "default bridge network not found")
+	} else if bridge.Options["com.docker.network.bridge.enable_icc"] == "false" {
+		pass(&icc, "inter-container communication is disabled")
+	} else {
+		fail(&icc, "inter-container communication is enabled; set \"icc\": false in daemon.json")
+	}
+	checks = append(checks, icc)
+
+	// 2.3 Logging level
+	logging := models.ComplianceCheck{ID: "2.3", Title: "Logging level is set to info"}
+	if info.Debug {
+		fail(&logging, "daemon runs in debug mode")
+	} else {
+		pass(&logging, "debug mode is disabled")
+	}
+	checks = append(checks, logging)
+
+	// 2.5 Insecure registries
+	registries := models.ComplianceCheck{ID: "2.5", Title: "Insecure registries are not used"}
+	if insecure := insecureRegistries(info); len(insecure) > 0 {
+		fail(&registries, "insecure registries: "+strings.Join(insecure, ", "))
+	} else {
+		pass(&registries, "no insecure registries configured")
+	}
+	checks = append(checks, registries)
+
+	// 2.6 aufs storage driver
+	driver := models.ComplianceCheck{ID: "2.6", Title: "aufs storage driver is not used"}
+	if info.Driver == "aufs" {
+		fail(&driver, "storage driver is aufs")
+	} else {
+		pass(&driver, "storage driver is "+info.Driver)
+	}
+	checks = append(checks, driver)
+
+	// 2.9 User namespace remapping
+	userns := models.ComplianceCheck{ID: "2.9", Title: "User namespace support is enabled"}
+	if hasSecurityOption(info.SecurityOptions, "userns") {
+		pass(&userns, "userns-remap is enabled")
+	} else if hasSecurityOption(info.SecurityOptions, "rootless") {
+		pass(&userns, "daemon runs rootless")
+	} else {
+		fail(&userns, "containers run as root on the host; configure \"userns-remap\" in daemon.json")
+	}
+	checks = append(checks, userns)
+
+	// 2.14 Live restore
+	liveRestore := models.ComplianceCheck{ID: "2.14", Title: "Live restore is enabled"}
+	if info.LiveRestoreEnabled {
+		pass(&liveRestore, "containers keep running while the daemon restarts")
+	} else {
+		fail(&liveRestore, "containers stop when the daemon restarts; set \"live-restore\": true in daemon.json")
+	}
+	checks = append(checks, liveRestore)
+
+	// 2.16 Default seccomp profile
+	seccomp := models.ComplianceCheck{ID: "2.16", Title: "Daemon-wide seccomp profile is applied"}
+	if profile, ok := securityOptionValue(info.SecurityOptions, "seccomp", "profile"); !ok {
+		fail(&seccomp, "seccomp is not supported by the daemon")
+	} else if profile == "unconfined" {
+		fail(&seccomp, "default seccomp profile is unconfined")
+	} else {
+		pass(&seccomp, "seccomp profile is "+profile)
+	}
+	checks = append(checks, seccomp)
+
+	// 2.17 Experimental features
+	experimental := models.ComplianceCheck{ID: "2.17", Title: "Experimental features are not used in production"}
+	if info.ExperimentalBuild {
+		fail(&experimental, "experimental features are enabled")
+	} else {
+		pass(&experimental, "experimental features are disabled")
+	}
+	checks = append(checks, experimental)
+
+	return checks
+}
+
+// CheckFiles evaluates the file ownership and permission recommendations from section 3 of the
+// benchmark. Files that can't be read are skipped rather than failed.
+func CheckFiles(opts FileOptions) []models.ComplianceCheck {
+	checks := make([]models.ComplianceCheck, 0, 4)
+
+	if opts.SocketPath != "" {
+		// 3.15/3.16 expect root:docker and 660; only the owner is checked since the docker
+		// group ID differs between hosts and is rarely visible inside a container
+		checks = append(checks,
+			checkFileOwner("3.15", "Docker socket is owned by root", opts.SocketPath, false),
+			checkFileMode("3.16", "Docker socket permissions are 660 or more restrictive", opts.SocketPath, 0660),
+		)
+	}
+	if opts.DaemonConfigPath != "" {
+		checks = append(checks,
+			checkFileOwner("3.17", "daemon.json is owned by root:root", opts.DaemonConfigPath, true),
+			checkFileMode("3.18", "daemon.json permissions are 644 or more restrictive", opts.DaemonConfigPath, 0644),
+		)
+	}
+
+	return checks
+}
+
+func checkFileOwner(id, title, path string, rootGroup bool) models.ComplianceCheck {
+	check := models.ComplianceCheck{ID: id, Title: title}
+	info, err := os.Stat(path)
+	if err != nil {
+		skip(&check, fmt.Sprintf("%s is not accessible", path))
+		return check
+	}
+	uid, gid, ok := fileOwner(info)
+	if !ok {
+		skip(&check, "file ownership is not available on this platform")
+		return check
+	}
+
+	switch {
+	case uid != 0:
+		fail(&check, fmt.Sprintf("%s is owned by uid %d", path, uid))
+	case rootGroup && gid != 0:
+		fail(&check, fmt.Sprintf("%s is owned by gid %d", path, gid))
+	default:
+		pass(&check, fmt.Sprintf("%s is owned by uid %d, gid %d", path, uid, gid))
+	}
+	return check
+}
+
+func checkFileMode(id, title, path string, maxMode os.FileMode) models.ComplianceCheck {
+	check := models.ComplianceCheck{ID: id, Title: title}
+	info, err := os.Stat(path)
+	if err != nil {
+		skip(&check, fmt.Sprintf("%s is not accessible", path))
+		return check
+	}
+
+	mode := info.Mode().Perm()
+	if mode&^maxMode != 0 {
+		fail(&check, fmt.Sprintf("%s has mode %04o", path, mode))
+	} else {
+		pass(&check, fmt.Sprintf("%s has mode %04o", path, mode))
+	}
+	return check
+}
+
+// insecureRegistries lists the registries the daemon talks to without TLS. Docker always
+// marks loopback registries insecure, so those are not reported.
+func insecureRegistries(info system.Info) []string {
+	if info.RegistryConfig == nil {
+		return nil
+	}
+
+	var insecure []string
+	for _, cidr := range info.RegistryConfig.InsecureRegistryCIDRs {
+		if cidr == nil || cidr.IP.IsLoopback() {
+			continue
+		}
+		insecure = append(insecure, cidr.String())
+	}
+	for name, index := range info.RegistryConfig.IndexConfigs {
+		if index == nil || index.Secure {
+			continue
+		}
+		host := name
+		if i := strings.LastIndex(host, ":"); i > 0 {
+			host = host[:i]
+		}
+		if host == "localhost" || strings.HasPrefix(host, "127.") {
+			continue
+		}
+		insecure = append(insecure, name)
+	}
+	return insecure
+}
+
+// hasSecurityOption reports whether the daemon lists a security option, e.g. "name=userns"
+func hasSecurityOption(options []string, name string) bool {
+	_, ok := securityOptionValue(options, name, "")
+	return ok
+}
+
+// securityOptionValue finds a security option by name and returns one of its key=value fields.
+// Options are formatted as "name=seccomp,profile=builtin".
+func securityOptionValue(options []string, name, key string) (string, bool) {
+	for _, option := range options {
+		fields := strings.Split(option, ",")
+		if fields[0] != "name="+name {
+			continue
+		}
+		for _, field := range fields[1:] {
+			if k, v, ok := strings.Cut(field, "="); ok && k == key {
+				return v, true
+			}
+		}
+		return "", true
+	}
+	return "", false
+}
+
+func pass(check *models.ComplianceCheck, detail string) {
+	check.Status = models.ComplianceStatusPass
+	check.Detail = detail
+}
+
+func fail(check *models.ComplianceCheck, detail string) {
+	check.Status = models.ComplianceStatusFail
+	check.Detail = detail
+}
+
+func skip(check *models.ComplianceCheck, detail string) {
+	check.Status = models.ComplianceStatusSkip
+	check.Detail = detail
+}
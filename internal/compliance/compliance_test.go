@@ -0,0 +1,136 @@
+package compliance
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/api/types/system"
+)
+
+func statuses(checks []models.ComplianceCheck) map[string]string {
+	result := make(map[string]string, len(checks))
+	for _, check := range checks {
+		result[check.ID] = check.Status
+	}
+	return result
+}
+
+func TestCheckDaemonHardened(t *testing.T) {
+	_, loopback, _ := net.ParseCIDR("127.0.0.0/8")
+	info := system.Info{
+		Driver:             "overlay2",
+		LiveRestoreEnabled: true,
+		SecurityOptions:    []string{"name=seccomp,profile=builtin", "name=userns"},
+		RegistryConfig: &registry.ServiceConfig{
+			InsecureRegistryCIDRs: []*registry.NetIPNet{(*registry.NetIPNet)(loopback)},
+			IndexConfigs: map[string]*registry.IndexInfo{
+				"docker.io":      {Name: "docker.io", Secure: true},
+				"localhost:5000": {Name: "localhost:5000", Secure: false},
+			},
+		},
+	}
+	bridge := &network.Inspect{Options: map[string]string{"com.docker.network.bridge.enable_icc": "false"}}
+
+	for id, status := range statuses(CheckDaemon(info, bridge)) {
+		if status != models.ComplianceStatusPass {
+			t.Errorf("Expected check %s to pass, got %s", id, status)
+		}
+	}
+}
+
+func TestCheckDaemonDefaults(t *testing.T) {
+	info := system.Info{
+		Driver:            "aufs",
+		Debug:             true,
+		ExperimentalBuild: true,
+		SecurityOptions:   []string{"name=seccomp,profile=unconfined"},
+		RegistryConfig: &registry.ServiceConfig{
+			IndexConfigs: map[string]*registry.IndexInfo{
+				"registry.lan:5000": {Name: "registry.lan:5000", Secure: false},
+			},
+		},
+	}
+	bridge := &network.Inspect{Options: map[string]string{"com.docker.network.bridge.enable_icc": "true"}}
+
+	got := statuses(CheckDaemon(info, bridge))
+	for _, id := range []string{"2.2", "2.3", "2.5", "2.6", "2.9", "2.14", "2.16", "2.17"} {
+		if got[id] != models.ComplianceStatusFail {
+			t.Errorf("Expected check %s to fail, got %q", id, got[id])
+		}
+	}
+
+	// Without a default bridge the ICC check can't be evaluated
+	if got := statuses(CheckDaemon(info, nil)); got["2.2"] != models.ComplianceStatusSkip {
+		t.Errorf("Expected ICC check to be skipped without a bridge, got %q", got["2.2"])
+	}
+}
+
+func TestCheckFiles(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "daemon.json")
+	if err := os.WriteFile(config, []byte("{}"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(config, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	checks := CheckFiles(FileOptions{
+		SocketPath:       filepath.Join(dir, "missing.sock"),
+		DaemonConfigPath: config,
+	})
+	got := statuses(checks)
+
+	if got["3.15"] != models.ComplianceStatusSkip || got["3.16"] != models.ComplianceStatusSkip {
+		t.Errorf("Expected missing socket checks to be skipped, got %v", got)
+	}
+	if got["3.18"] != models.ComplianceStatusFail {
+		t.Errorf("Expected world-writable daemon.json to fail, got %q", got["3.18"])
+	}
+
+	if err := os.Chmod(config, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := statuses(CheckFiles(FileOptions{DaemonConfigPath: config})); got["3.18"] != models.ComplianceStatusPass {
+		t.Errorf("Expected 0600 daemon.json to pass, got %q", got["3.18"])
+	}
+
+	// Empty paths are not checked at all
+	if checks := CheckFiles(FileOptions{}); len(checks) != 0 {
+		t.Errorf("Expected no checks without paths, got %d", len(checks))
+	}
+}
+
+func TestTally(t *testing.T) {
+	audit := models.ComplianceAudit{Checks: []models.ComplianceCheck{
+		{Status: models.ComplianceStatusPass},
+		{Status: models.ComplianceStatusPass},
+		{Status: models.ComplianceStatusFail},
+		{Status: models.ComplianceStatusSkip},
+	}}
+	audit.Tally()
+
+	if audit.Passed != 2 || audit.Failed != 1 || audit.Skipped != 1 {
+		t.Errorf("Unexpected tally: %d passed, %d failed, %d skipped", audit.Passed, audit.Failed, audit.Skipped)
+	}
+}
+
+func TestLocalFiles(t *testing.T) {
+	files := LocalFiles("unix:///run/user/1000/docker.sock")
+	if runtime.GOOS == "darwin" {
+		if files != nil {
+			t.Errorf("Expected no file checks for Docker Desktop, got %+v", files)
+		}
+	} else if files == nil || files.SocketPath != "/run/user/1000/docker.sock" || files.DaemonConfigPath != DefaultDaemonConfigPath {
+		t.Errorf("Unexpected files for unix socket: %+v", files)
+	}
+	if files := LocalFiles("tcp://10.0.0.5:2376"); files != nil {
+		t.Errorf("Expected no file checks for a remote daemon, got %+v", files)
+	}
+}
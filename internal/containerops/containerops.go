@@ -0,0 +1,110 @@
+// Package containerops tracks actions on containers (start, stop, update, ...) so that conflicting
+// actions on the same container are rejected instead of racing, and scans don't record the
+// half-finished state of a container that is being recreated.
+package containerops
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// Actions
+const (
+	ActionStart   = "start"
+	ActionStop    = "stop"
+	ActionRestart = "restart"
+	ActionRemove  = "remove"
+	ActionUpdate  = "update"
+)
+
+// Tracker holds the operations in progress. Containers are identified by host and name, since
+// an update recreates the container with a new ID.
+type Tracker struct {
+	mu       sync.Mutex
+	ops      map[string]models.ContainerOperation
+	versions map[int64]uint64 // per host, bumped whenever an operation starts or finishes
+}
+
+// NewTracker returns an empty tracker
+func NewTracker() *Tracker {
+	return &Tracker{
+		ops:      make(map[string]models.ContainerOperation),
+		versions: make(map[int64]uint64),
+	}
+}
+
+func key(hostID int64, containerName string) string {
+	return fmt.Sprintf("%d/%s", hostID, containerName)
+}
+
+// Begin marks an action on a container as running. If another action is already running on the
+// container, it returns that operation and ok is false. Otherwise the returned done function must
+// be called when the action finishes.
+func (t *Tracker) Begin(hostID int64, containerName, action string) (done func(), running models.ContainerOperation, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	k := key(hostID, containerName)
+	if op, busy := t.ops[k]; busy {
+		return nil, op, false
+	}
+
+	t.ops[k] = models.ContainerOperation{
+		HostID:        hostID,
+		ContainerName: containerName,
+		Action:        action,
+		StartedAt:     time.Now(),
+	}
+	t.versions[hostID]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			delete(t.ops, k)
+			t.versions[hostID]++
+		})
+	}, models.ContainerOperation{}, true
+}
+
+// Get returns the operation running on a container, if any
+func (t *Tracker) Get(hostID int64, containerName string) (models.ContainerOperation, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	op, ok := t.ops[key(hostID, containerName)]
+	return op, ok
+}
+
+// Active returns the operations in progress, oldest first
+func (t *Tracker) Active() []models.ContainerOperation {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ops := make([]models.ContainerOperation, 0, len(t.ops))
+	for _, op := range t.ops {
+		ops = append(ops, op)
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i].StartedAt.Before(ops[j].StartedAt) })
+	return ops
+}
+
+// HostState reports whether an operation is running on a host, and a version that changes
+// whenever an operation on the host starts or finishes. Scans compare the version from before
+// and after scanning to tell whether their results may be mid-operation.
+func (t *Tracker) HostState(hostID int64) (busy bool, version uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, op := range t.ops {
+		if op.HostID == hostID {
+			busy = true
+			break
+		}
+	}
+	return busy, t.versions[hostID]
+}
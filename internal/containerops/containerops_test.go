@@ -0,0 +1,94 @@
+package containerops
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestBeginConflicts(t *testing.T) {
+	tracker := NewTracker()
+
+	done, _, ok := tracker.Begin(1, "web", ActionUpdate)
+	if !ok {
+		t.Fatal("Expected the first operation to start")
+	}
+
+	_, running, ok := tracker.Begin(1, "web", ActionRestart)
+	if ok || running.Action != ActionUpdate || running.ContainerName != "web" {
+		t.Fatalf("Expected a conflict with the running update, got %+v (ok=%v)", running, ok)
+	}
+
+	// Other containers, and the same name on another host, are independent
+	doneDB, _, ok := tracker.Begin(1, "db", ActionStop)
+	if !ok {
+		t.Fatal("Expected an operation on another container to start")
+	}
+	doneOther, _, ok := tracker.Begin(2, "web", ActionStart)
+	if !ok {
+		t.Fatal("Expected an operation on another host to start")
+	}
+
+	if active := tracker.Active(); len(active) != 3 || active[0].Action != ActionUpdate {
+		t.Errorf("Expected 3 active operations oldest first, got %+v", active)
+	}
+
+	done()
+	done() // finishing twice is harmless
+	doneDB()
+	doneOther()
+
+	if _, ok := tracker.Get(1, "web"); ok {
+		t.Error("Expected the update to be finished")
+	}
+	if _, _, ok := tracker.Begin(1, "web", ActionRestart); !ok {
+		t.Error("Expected a new operation after the update finished")
+	}
+}
+
+func TestHostState(t *testing.T) {
+	tracker := NewTracker()
+
+	busy, before := tracker.HostState(1)
+	if busy {
+		t.Fatal("Expected an idle host")
+	}
+
+	done, _, _ := tracker.Begin(1, "web", ActionUpdate)
+	if busy, _ := tracker.HostState(1); !busy {
+		t.Error("Expected the host to be busy")
+	}
+	if busy, _ := tracker.HostState(2); busy {
+		t.Error("Expected other hosts to be idle")
+	}
+	done()
+
+	// An operation that started and finished during a scan still changes the version
+	busy, after := tracker.HostState(1)
+	if busy || after == before {
+		t.Errorf("Expected an idle host with a new version, got busy=%v version %d -> %d", busy, before, after)
+	}
+}
+
+func TestBeginConcurrent(t *testing.T) {
+	tracker := NewTracker()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	started := 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, ok := tracker.Begin(1, "web", ActionUpdate); ok {
+				mu.Lock()
+				started++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if started != 1 {
+		t.Errorf("Expected exactly one of the concurrent updates to start, got %d", started)
+	}
+}
@@ -0,0 +1,54 @@
+// Package containerstats derives rates from Docker container stats samples, so agents and
+// directly scanned hosts report them the same way
+package containerstats
+
+import (
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// IORates returns network rx/tx and block read/write rates in bytes/sec between two stats samples.
+// Counters that went backwards (container restarted) yield a zero rate.
+func IORates(previous, current container.StatsResponse) (rxRate, txRate, readRate, writeRate float64) {
+	elapsed := current.Read.Sub(previous.Read).Seconds()
+	if elapsed <= 0 {
+		return 0, 0, 0, 0
+	}
+
+	prevRx, prevTx := networkTotals(previous)
+	curRx, curTx := networkTotals(current)
+	prevRead, prevWrite := blockIOTotals(previous)
+	curRead, curWrite := blockIOTotals(current)
+
+	rate := func(prev, cur uint64) float64 {
+		if cur < prev {
+			return 0
+		}
+		return float64(cur-prev) / elapsed
+	}
+
+	return rate(prevRx, curRx), rate(prevTx, curTx), rate(prevRead, curRead), rate(prevWrite, curWrite)
+}
+
+// networkTotals sums received and transmitted bytes across all container interfaces
+func networkTotals(stats container.StatsResponse) (rx, tx uint64) {
+	for _, n := range stats.Networks {
+		rx += n.RxBytes
+		tx += n.TxBytes
+	}
+	return rx, tx
+}
+
+// blockIOTotals sums bytes read and written across all block devices
+func blockIOTotals(stats container.StatsResponse) (read, write uint64) {
+	for _, entry := range stats.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			read += entry.Value
+		case "write":
+			write += entry.Value
+		}
+	}
+	return read, write
+}
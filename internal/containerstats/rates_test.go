@@ -0,0 +1,41 @@
+package containerstats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+func sample(read time.Time, rx, tx, blkRead, blkWrite uint64) container.StatsResponse {
+	return container.StatsResponse{
+		Read: read,
+		Networks: map[string]container.NetworkStats{
+			"eth0": {RxBytes: rx / 2, TxBytes: tx / 2},
+			"eth1": {RxBytes: rx - rx/2, TxBytes: tx - tx/2},
+		},
+		BlkioStats: container.BlkioStats{IoServiceBytesRecursive: []container.BlkioStatEntry{
+			{Op: "Read", Value: blkRead},
+			{Op: "write", Value: blkWrite},
+			{Op: "Total", Value: blkRead + blkWrite},
+		}},
+	}
+}
+
+func TestIORates(t *testing.T) {
+	start := time.Now()
+	previous := sample(start, 1000, 2000, 4096, 8192)
+	current := sample(start.Add(2*time.Second), 3000, 2000, 8192, 4096)
+
+	rx, tx, read, write := IORates(previous, current)
+	if rx != 1000 || tx != 0 || read != 2048 {
+		t.Errorf("Expected rx 1000, tx 0, read 2048 B/s, got %v, %v, %v", rx, tx, read)
+	}
+	if write != 0 {
+		t.Errorf("Expected a counter that went backwards to yield 0, got %v", write)
+	}
+
+	if rx, tx, read, write := IORates(current, previous); rx+tx+read+write != 0 {
+		t.Errorf("Expected zero rates for samples out of order, got %v %v %v %v", rx, tx, read, write)
+	}
+}
@@ -0,0 +1,469 @@
+// Package demo generates a synthetic environment (hosts, containers, stats history, lifecycle events
+// and vulnerabilities) so the UI can be explored without a multi-host Docker setup.
+package demo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/storage"
+	"github.com/container-census/container-census/internal/vulnerability"
+)
+
+// Options controls the size and shape of the generated environment
+type Options struct {
+	Seed     int64         // the same seed and Now always produce the same dataset
+	Now      time.Time     // time of the most recent scan
+	History  time.Duration // how far back the scan history goes
+	Interval time.Duration // time between two scans of a host
+}
+
+// DefaultOptions returns a day of history, scanned every ten minutes, ending now
+func DefaultOptions() Options {
+	return Options{
+		Seed:     1,
+		Now:      time.Now(),
+		History:  24 * time.Hour,
+		Interval: 10 * time.Minute,
+	}
+}
+
+// Dataset is a generated environment, ready to be stored with Seed
+type Dataset struct {
+	Hosts           []models.Host
+	Scans           []Scan // ordered by time
+	Vulnerabilities []vulnerability.VulnerabilityScanResult
+}
+
+// Scan is one synthetic scan of a host; container host IDs are assigned when the dataset is stored
+type Scan struct {
+	HostIndex  int // index into Dataset.Hosts
+	At         time.Time
+	Containers []models.Container
+}
+
+// pattern describes how a service's resource usage evolves over time
+type pattern int
+
+const (
+	steady  pattern = iota
+	daily           // busier during the day
+	spiky           // short CPU bursts
+	leaky           // memory grows steadily
+	nightly         // one-shot job that runs at 03:00 and exits
+)
+
+// service is a container template; lifecycle times are fractions of the history (0 = start, 1 = now)
+type service struct {
+	name       string
+	image      string
+	tag        string
+	oldTag     string  // tag before the update at updatedAt
+	updatedAt  float64 // recreated with tag (zero = no update)
+	addedAt    float64 // first seen (zero = from the start)
+	removedAt  float64 // last seen (zero = still present)
+	downFrom   float64 // stopped between downFrom and downTo
+	downTo     float64
+	project    string
+	ports      []models.PortMapping
+	networks   []string
+	volumes    []models.VolumeMount
+	labels     map[string]string
+	cpu        float64 // typical CPU percent
+	memMB      int64   // typical memory usage
+	limitMB    int64   // memory limit (zero = host memory)
+	pattern    pattern
+	privileged bool
+	outdated   bool // a newer image is available
+}
+
+type hostSpec struct {
+	name        string
+	description string
+	memMB       int64
+	services    []service
+}
+
+func tcp(private, public int) models.PortMapping {
+	return models.PortMapping{PrivatePort: private, PublicPort: public, Type: "tcp", IP: "0.0.0.0"}
+}
+
+func volume(name, destination string) models.VolumeMount {
+	return models.VolumeMount{Name: name, Destination: destination, Type: "volume", RW: true}
+}
+
+var hostSpecs = []hostSpec{
+	{
+		name:        "nas",
+		description: "Media and storage server",
+		memMB:       16384,
+		services: []service{
+			{name: "plex", image: "plexinc/pms-docker", tag: "1.40.2", project: "media", ports: []models.PortMapping{tcp(32400, 32400)},
+				networks: []string{"media"}, volumes: []models.VolumeMount{volume("plex-config", "/config")}, cpu: 12, memMB: 1400, pattern: daily},
+			{name: "sonarr", image: "linuxserver/sonarr", tag: "4.0.4", oldTag: "4.0.2", updatedAt: 0.55, project: "media",
+				ports: []models.PortMapping{tcp(8989, 8989)}, networks: []string{"media"}, volumes: []models.VolumeMount{volume("sonarr-config", "/config")},
+				cpu: 2, memMB: 310, limitMB: 1024, pattern: steady},
+			{name: "radarr", image: "linuxserver/radarr", tag: "5.4.6", project: "media", ports: []models.PortMapping{tcp(7878, 7878)},
+				networks: []string{"media"}, volumes: []models.VolumeMount{volume("radarr-config", "/config")}, cpu: 2, memMB: 290, limitMB: 1024, pattern: steady},
+			{name: "qbittorrent", image: "linuxserver/qbittorrent", tag: "4.6.4", project: "media", ports: []models.PortMapping{tcp(8080, 8081)},
+				networks: []string{"media"}, cpu: 6, memMB: 520, limitMB: 2048, pattern: spiky, downFrom: 0.3, downTo: 0.38},
+			{name: "restic-backup", image: "restic/restic", tag: "0.16.4", volumes: []models.VolumeMount{volume("plex-config", "/data/plex")},
+				labels: map[string]string{models.BackupIntervalLabel: "24h"}, cpu: 0, memMB: 0, pattern: nightly},
+		},
+	},
+	{
+		name:        "homelab",
+		description: "Web apps and monitoring",
+		memMB:       32768,
+		services: []service{
+			{name: "traefik", image: "traefik", tag: "v3.0", project: "proxy", ports: []models.PortMapping{tcp(80, 80), tcp(443, 443)},
+				networks: []string{"proxy"}, cpu: 1.5, memMB: 80, limitMB: 256, pattern: daily},
+			{name: "nextcloud", image: "nextcloud", tag: "28.0.4", project: "cloud", networks: []string{"proxy", "cloud"},
+				volumes: []models.VolumeMount{volume("nextcloud-data", "/var/www/html")}, cpu: 4, memMB: 600, limitMB: 2048, pattern: daily, outdated: true},
+			{name: "nextcloud-db", image: "postgres", tag: "16.2", project: "cloud", networks: []string{"cloud"},
+				volumes: []models.VolumeMount{volume("nextcloud-db", "/var/lib/postgresql/data")}, cpu: 3, memMB: 450, limitMB: 2048, pattern: daily},
+			{name: "nextcloud-redis", image: "redis", tag: "7.2", project: "cloud", networks: []string{"cloud"}, cpu: 0.5, memMB: 40, limitMB: 256, pattern: steady},
+			{name: "grafana", image: "grafana/grafana", tag: "10.4.2", project: "monitoring", ports: []models.PortMapping{tcp(3000, 3000)},
+				networks: []string{"proxy", "monitoring"}, cpu: 1, memMB: 180, limitMB: 512, pattern: steady},
+			{name: "prometheus", image: "prom/prometheus", tag: "v2.51.2", project: "monitoring", networks: []string{"monitoring"},
+				volumes: []models.VolumeMount{volume("prometheus-data", "/prometheus")}, cpu: 5, memMB: 700, limitMB: 4096, pattern: leaky},
+			{name: "cadvisor", image: "gcr.io/cadvisor/cadvisor", tag: "v0.49.1", project: "monitoring", networks: []string{"monitoring"},
+				cpu: 3, memMB: 120, limitMB: 512, pattern: spiky, privileged: true},
+			{name: "wiki", image: "requarks/wiki", tag: "2.5", ports: []models.PortMapping{tcp(3000, 3100)}, networks: []string{"proxy"},
+				cpu: 0.2, memMB: 160, limitMB: 512, pattern: steady, addedAt: 0.7},
+		},
+	},
+	{
+		name:        "pi",
+		description: "Raspberry Pi running home automation",
+		memMB:       4096,
+		services: []service{
+			{name: "pihole", image: "pihole/pihole", tag: "2024.03.2", ports: []models.PortMapping{tcp(53, 53), tcp(80, 8053)},
+				volumes: []models.VolumeMount{volume("pihole-etc", "/etc/pihole")}, cpu: 1, memMB: 90, limitMB: 512, pattern: daily},
+			{name: "homeassistant", image: "ghcr.io/home-assistant/home-assistant", tag: "2024.4.3", oldTag: "2024.4.1", updatedAt: 0.2,
+				volumes: []models.VolumeMount{volume("ha-config", "/config")}, cpu: 4, memMB: 420, pattern: daily, privileged: true},
+			{name: "mosquitto", image: "eclipse-mosquitto", tag: "2.0.18", ports: []models.PortMapping{tcp(1883, 1883)}, cpu: 0.3, memMB: 12, limitMB: 128, pattern: steady},
+			{name: "zigbee2mqtt", image: "koenkk/zigbee2mqtt", tag: "1.36.1", cpu: 1, memMB: 140, limitMB: 512, pattern: steady, removedAt: 0.85},
+		},
+	},
+}
+
+// vulnerablePackages are the OS packages synthetic vulnerabilities are reported in
+var vulnerablePackages = []string{"openssl", "libc6", "zlib1g", "curl", "busybox", "libxml2", "ncurses", "sqlite3", "expat", "krb5"}
+
+// Generate builds a dataset from the options; it does not touch the database
+func Generate(opts Options) *Dataset {
+	rng := rand.New(rand.NewSource(opts.Seed))
+	start := opts.Now.Add(-opts.History)
+	scanCount := int(opts.History / opts.Interval)
+
+	data := &Dataset{}
+	for _, spec := range hostSpecs {
+		data.Hosts = append(data.Hosts, models.Host{
+			Name:         spec.name,
+			Address:      fmt.Sprintf("agent://%s.demo.example:9876", spec.name),
+			Description:  spec.description + " (demo)",
+			HostType:     "agent",
+			AgentStatus:  "online",
+			LastSeen:     opts.Now,
+			Enabled:      true,
+			CollectStats: true,
+			CreatedAt:    start,
+			UpdatedAt:    start,
+		})
+	}
+
+	for i := 0; i <= scanCount; i++ {
+		at := opts.Now.Add(-time.Duration(scanCount-i) * opts.Interval)
+		progress := float64(i) / float64(scanCount)
+		for hostIndex, spec := range hostSpecs {
+			scan := Scan{HostIndex: hostIndex, At: at}
+			for _, svc := range spec.services {
+				if progress < svc.addedAt || (svc.removedAt > 0 && progress > svc.removedAt) {
+					continue
+				}
+				scan.Containers = append(scan.Containers, svc.container(rng, spec, start, opts.History, at, progress))
+			}
+			data.Scans = append(data.Scans, scan)
+		}
+	}
+
+	data.Vulnerabilities = generateVulnerabilities(opts)
+	return data
+}
+
+// container is the state of a service as seen by the scan at the given time
+func (svc service) container(rng *rand.Rand, host hostSpec, start time.Time, history time.Duration, at time.Time, progress float64) models.Container {
+	tag, created := svc.tag, start.Add(-72*time.Hour)
+	generation := 0
+	if svc.updatedAt > 0 {
+		if progress < svc.updatedAt {
+			tag = svc.oldTag
+		} else {
+			created = start.Add(time.Duration(svc.updatedAt * float64(history)))
+			generation = 1
+		}
+	}
+	if svc.addedAt > 0 {
+		created = start.Add(time.Duration(svc.addedAt * float64(history)))
+	}
+	image := svc.image + ":" + tag
+
+	c := models.Container{
+		ID:             stableID(host.name, svc.name, fmt.Sprint(generation)),
+		Name:           svc.name,
+		Image:          image,
+		ImageID:        imageID(image),
+		ImageTags:      []string{image},
+		ImageSize:      int64(80+len(svc.image)*9) * 1024 * 1024,
+		Ports:          svc.ports,
+		Labels:         map[string]string{},
+		Created:        created,
+		HostName:       host.name,
+		ScannedAt:      at,
+		Networks:       svc.networks,
+		Volumes:        svc.volumes,
+		ComposeProject: svc.project,
+		Config: &models.ContainerConfig{
+			Env:           []models.EnvVar{{Name: "TZ", Value: "Europe/Berlin"}, {Name: "PUID", Value: "1000"}},
+			RestartPolicy: "unless-stopped",
+			NetworkMode:   "bridge",
+			Privileged:    svc.privileged,
+		},
+	}
+	for k, v := range svc.labels {
+		c.Labels[k] = v
+	}
+	if svc.project != "" {
+		c.Labels["com.docker.compose.project"] = svc.project
+	}
+	for _, v := range svc.volumes {
+		c.Config.Mounts = append(c.Config.Mounts, models.ConfigMount{Type: v.Type, Source: v.Name, Destination: v.Destination, RW: v.RW})
+	}
+	if svc.outdated {
+		c.UpdateAvailable = true
+		c.LastUpdateCheck = at.Add(-time.Hour)
+	}
+
+	if svc.pattern == nightly {
+		// Last run started at 03:00 and took a few minutes
+		run := time.Date(at.Year(), at.Month(), at.Day(), 3, 0, 0, 0, at.Location())
+		if run.After(at) {
+			run = run.AddDate(0, 0, -1)
+		}
+		if run.Before(created) {
+			run = created
+		}
+		c.State = "exited"
+		c.Status = fmt.Sprintf("Exited (0) %s ago", formatDuration(at.Sub(run)))
+		c.Config.StartedAt = run
+		c.Config.FinishedAt = run.Add(7 * time.Minute)
+		return c
+	}
+
+	if svc.downFrom > 0 && progress >= svc.downFrom && progress < svc.downTo {
+		c.State = "exited"
+		c.Status = "Exited (137) " + formatDuration(time.Duration((progress-svc.downFrom)*float64(history))) + " ago"
+		c.Config.ExitCode = 137
+		return c
+	}
+
+	c.State = "running"
+	c.Status = "Up " + formatDuration(at.Sub(created))
+	if svc.pattern == spiky {
+		c.RestartCount = 2
+	}
+	c.Config.StartedAt = created
+	svc.stats(rng, &c, host, progress)
+	return c
+}
+
+// stats fills in resource usage following the service's pattern, with some noise
+func (svc service) stats(rng *rand.Rand, c *models.Container, host hostSpec, progress float64) {
+	noise := func(spread float64) float64 { return 1 + (rng.Float64()*2-1)*spread }
+
+	load := 1.0
+	switch svc.pattern {
+	case daily:
+		// Peaks mid-afternoon, quietest before dawn
+		hour := float64(c.ScannedAt.Hour()) + float64(c.ScannedAt.Minute())/60
+		load = 0.6 + 0.4*math.Sin(2*math.Pi*(hour-9)/24)
+	case spiky:
+		if rng.Float64() < 0.06 {
+			load = 4 + rng.Float64()*4
+		}
+	}
+
+	limit := svc.limitMB
+	if limit == 0 {
+		limit = host.memMB
+	}
+	mem := float64(svc.memMB) * noise(0.05)
+	if svc.pattern == leaky {
+		mem = float64(svc.memMB) * (1 + 1.5*progress) * noise(0.01)
+	}
+	mem = math.Min(mem, float64(limit)*0.98)
+
+	c.CPUPercent = math.Round(svc.cpu*load*noise(0.2)*100) / 100
+	c.MemoryUsage = int64(mem * 1024 * 1024)
+	c.MemoryLimit = limit * 1024 * 1024
+	c.MemoryPercent = math.Round(float64(c.MemoryUsage)/float64(c.MemoryLimit)*10000) / 100
+	c.NetworkRxRate = math.Round(svc.cpu * load * 20000 * noise(0.3))
+	c.NetworkTxRate = math.Round(svc.cpu * load * 8000 * noise(0.3))
+	c.BlockReadRate = math.Round(svc.cpu * 4000 * noise(0.5))
+	c.BlockWriteRate = math.Round(svc.cpu * 6000 * noise(0.5))
+}
+
+// generateVulnerabilities reports a few synthetic vulnerabilities for every image in the dataset.
+// Counts depend only on the image, so an image shared by several containers has one consistent scan.
+func generateVulnerabilities(opts Options) []vulnerability.VulnerabilityScanResult {
+	images := map[string]bool{}
+	for _, spec := range hostSpecs {
+		for _, svc := range spec.services {
+			images[svc.image+":"+svc.tag] = true
+			if svc.oldTag != "" {
+				images[svc.image+":"+svc.oldTag] = true
+			}
+		}
+	}
+	names := make([]string, 0, len(images))
+	for name := range images {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	severities := []string{"CRITICAL", "HIGH", "HIGH", "MEDIUM", "MEDIUM", "MEDIUM", "LOW", "LOW", "LOW", "UNKNOWN"}
+	var results []vulnerability.VulnerabilityScanResult
+	for i, name := range names {
+		id := imageID(name)
+		rng := rand.New(rand.NewSource(opts.Seed + int64(i)))
+
+		var vulns []vulnerability.Vulnerability
+		for n := rng.Intn(14); n > 0; n-- {
+			pkg := vulnerablePackages[rng.Intn(len(vulnerablePackages))]
+			severity := severities[rng.Intn(len(severities))]
+			published := opts.Now.AddDate(0, 0, -30-rng.Intn(300))
+			v := vulnerability.Vulnerability{
+				ImageID:          id,
+				VulnerabilityID:  fmt.Sprintf("DEMO-%d-%04d", published.Year(), rng.Intn(10000)),
+				PkgName:          pkg,
+				InstalledVersion: fmt.Sprintf("1.%d.%d", rng.Intn(10), rng.Intn(20)),
+				Severity:         severity,
+				Title:            fmt.Sprintf("Synthetic %s issue in %s (demo data)", severity, pkg),
+				Description:      "Generated by demo mode; not a real advisory.",
+				PublishedDate:    published,
+				LastModifiedDate: published.AddDate(0, 0, 7),
+			}
+			if rng.Float64() < 0.6 {
+				v.FixedVersion = fmt.Sprintf("1.%d.%d", rng.Intn(10), 20+rng.Intn(10))
+			}
+			vulns = append(vulns, v)
+		}
+
+		counts := vulnerability.CalculateSeverityCounts(vulns)
+		results = append(results, vulnerability.VulnerabilityScanResult{
+			Scan: vulnerability.VulnerabilityScan{
+				ImageID:              id,
+				ImageName:            name,
+				ScannedAt:            opts.Now.Add(-time.Duration(rng.Intn(int(opts.History/time.Minute)+1)) * time.Minute),
+				ScanDurationMs:       int64(2000 + rng.Intn(20000)),
+				Success:              true,
+				TrivyDBVersion:       "demo",
+				TotalVulnerabilities: counts.GetTotal(),
+				SeverityCounts:       counts,
+			},
+			Vulnerabilities: vulns,
+		})
+	}
+	return results
+}
+
+// Seed stores a dataset. Hosts are added rather than matched, so it is meant for an empty database.
+func Seed(db *storage.DB, data *Dataset) error {
+	hostIDs := make([]int64, len(data.Hosts))
+	for i, host := range data.Hosts {
+		id, err := db.AddHost(host)
+		if err != nil {
+			return fmt.Errorf("failed to add host %s: %w", host.Name, err)
+		}
+		hostIDs[i] = id
+	}
+
+	for _, scan := range data.Scans {
+		hostID := hostIDs[scan.HostIndex]
+		containers := make([]models.Container, len(scan.Containers))
+		for i, c := range scan.Containers {
+			c.HostID = hostID
+			containers[i] = c
+		}
+		if err := db.SaveContainers(containers); err != nil {
+			return fmt.Errorf("failed to save containers: %w", err)
+		}
+		if _, err := db.SaveScanResult(models.ScanResult{
+			HostID:          hostID,
+			HostName:        data.Hosts[scan.HostIndex].Name,
+			StartedAt:       scan.At.Add(-2 * time.Second),
+			CompletedAt:     scan.At,
+			Success:         true,
+			ContainersFound: len(containers),
+		}); err != nil {
+			return fmt.Errorf("failed to save scan result: %w", err)
+		}
+	}
+
+	for _, result := range data.Vulnerabilities {
+		scan := result.Scan
+		if err := db.SaveVulnerabilityScan(&scan, result.Vulnerabilities); err != nil {
+			return fmt.Errorf("failed to save vulnerability scan for %s: %w", scan.ImageName, err)
+		}
+	}
+
+	// Link scanned images to the containers currently running them
+	containers, err := db.GetLatestContainers()
+	if err != nil {
+		return err
+	}
+	for _, c := range containers {
+		if err := db.UpdateImageContainer(c.ImageID, c.ID, int(c.HostID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stableID derives a Docker-style 64 character ID from its parts
+func stableID(parts ...string) string {
+	sum := sha256.New()
+	for _, p := range parts {
+		sum.Write([]byte(p + "\x00"))
+	}
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+func imageID(image string) string {
+	return "sha256:" + stableID("image", image)
+}
+
+// formatDuration renders a duration the way docker ps does, e.g. "3 hours"
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "Less than a minute"
+	case d < 2*time.Minute:
+		return "About a minute"
+	case d < time.Hour:
+		return fmt.Sprintf("%d minutes", int(d.Minutes()))
+	case d < 2*time.Hour:
+		return "About an hour"
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%d hours", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%d days", int(d.Hours()/24))
+	}
+}
@@ -0,0 +1,225 @@
+package demo
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/storage"
+)
+
+func testOptions() Options {
+	return Options{
+		Seed:     42,
+		Now:      time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		History:  12 * time.Hour,
+		Interval: 30 * time.Minute,
+	}
+}
+
+// lastSeen returns the container named name from the latest scan of the host that has it
+func lastSeen(data *Dataset, hostName, name string) (found bool, state, image string) {
+	for _, scan := range data.Scans {
+		if data.Hosts[scan.HostIndex].Name != hostName {
+			continue
+		}
+		found = false
+		for _, c := range scan.Containers {
+			if c.Name == name {
+				found, state, image = true, c.State, c.Image
+			}
+		}
+	}
+	return found, state, image
+}
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	a := Generate(testOptions())
+	b := Generate(testOptions())
+	if !reflect.DeepEqual(a, b) {
+		t.Error("Expected the same options to generate the same dataset")
+	}
+
+	opts := testOptions()
+	opts.Seed = 7
+	if reflect.DeepEqual(a.Scans, Generate(opts).Scans) {
+		t.Error("Expected a different seed to change the stats")
+	}
+}
+
+func TestGenerateTimeSeries(t *testing.T) {
+	opts := testOptions()
+	data := Generate(opts)
+
+	if len(data.Hosts) != len(hostSpecs) {
+		t.Fatalf("Expected %d hosts, got %d", len(hostSpecs), len(data.Hosts))
+	}
+	wantScans := (int(opts.History/opts.Interval) + 1) * len(hostSpecs)
+	if len(data.Scans) != wantScans {
+		t.Fatalf("Expected %d scans, got %d", wantScans, len(data.Scans))
+	}
+	if last := data.Scans[len(data.Scans)-1].At; !last.Equal(opts.Now) {
+		t.Errorf("Expected the last scan at %v, got %v", opts.Now, last)
+	}
+
+	var firstLeak, lastLeak int64
+	for i, scan := range data.Scans {
+		if i > 0 && scan.At.Before(data.Scans[i-1].At) {
+			t.Fatalf("Scans are not ordered by time at index %d", i)
+		}
+		for _, c := range scan.Containers {
+			if c.State != "running" {
+				if c.MemoryLimit != 0 {
+					t.Errorf("Expected no stats for %s while %s", c.Name, c.State)
+				}
+				continue
+			}
+			if c.MemoryUsage <= 0 || c.MemoryUsage > c.MemoryLimit {
+				t.Errorf("%s memory %d outside limit %d", c.Name, c.MemoryUsage, c.MemoryLimit)
+			}
+			if c.CPUPercent < 0 {
+				t.Errorf("%s has negative CPU %.2f", c.Name, c.CPUPercent)
+			}
+			if c.Name == "prometheus" {
+				if firstLeak == 0 {
+					firstLeak = c.MemoryUsage
+				}
+				lastLeak = c.MemoryUsage
+			}
+		}
+	}
+	if lastLeak < firstLeak*2 {
+		t.Errorf("Expected prometheus memory to grow steadily, went from %d to %d", firstLeak, lastLeak)
+	}
+
+	// Lifecycle: updated, added and removed containers
+	if _, _, image := lastSeen(data, "nas", "sonarr"); image != "linuxserver/sonarr:4.0.4" {
+		t.Errorf("Expected sonarr to end on the updated image, got %s", image)
+	}
+	if found, _, _ := lastSeen(data, "pi", "zigbee2mqtt"); found {
+		t.Error("Expected zigbee2mqtt to be removed by the end of the history")
+	}
+	if found, _, _ := lastSeen(data, "homelab", "wiki"); !found {
+		t.Error("Expected wiki to be deployed during the history")
+	}
+	for _, c := range data.Scans[1].Containers { // first homelab scan
+		if c.Name == "wiki" {
+			t.Error("Expected wiki to be missing from the first scan")
+		}
+	}
+
+	stopped := false
+	for _, scan := range data.Scans {
+		for _, c := range scan.Containers {
+			if c.Name == "qbittorrent" && c.State == "exited" {
+				stopped = true
+			}
+		}
+	}
+	if !stopped {
+		t.Error("Expected qbittorrent to be stopped for part of the history")
+	}
+}
+
+func TestGenerateVulnerabilities(t *testing.T) {
+	data := Generate(testOptions())
+
+	images := map[string]bool{}
+	for _, scan := range data.Scans {
+		for _, c := range scan.Containers {
+			images[c.ImageID] = true
+		}
+	}
+	for _, result := range data.Vulnerabilities {
+		if !images[result.Scan.ImageID] {
+			t.Errorf("Vulnerability scan for unknown image %s", result.Scan.ImageName)
+		}
+		if result.Scan.TotalVulnerabilities != len(result.Vulnerabilities) {
+			t.Errorf("%s: total %d does not match %d vulnerabilities", result.Scan.ImageName, result.Scan.TotalVulnerabilities, len(result.Vulnerabilities))
+		}
+		delete(images, result.Scan.ImageID)
+	}
+	if len(images) != 0 {
+		t.Errorf("Expected every image to be scanned, %d were not", len(images))
+	}
+}
+
+func TestSeed(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "demo-test-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp db: %v", err)
+	}
+	tmpfile.Close()
+	t.Cleanup(func() {
+		os.Remove(tmpfile.Name())
+	})
+
+	db, err := storage.New(tmpfile.Name())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer db.Close()
+
+	opts := testOptions()
+	data := Generate(opts)
+	if err := Seed(db, data); err != nil {
+		t.Fatalf("Seed failed: %v", err)
+	}
+
+	hosts, err := db.GetHosts()
+	if err != nil {
+		t.Fatalf("GetHosts failed: %v", err)
+	}
+	if len(hosts) != len(data.Hosts) {
+		t.Fatalf("Expected %d hosts, got %d", len(data.Hosts), len(hosts))
+	}
+
+	latest, err := db.GetLatestContainers()
+	if err != nil {
+		t.Fatalf("GetLatestContainers failed: %v", err)
+	}
+	want := 0
+	for _, scan := range data.Scans[len(data.Scans)-len(data.Hosts):] {
+		want += len(scan.Containers)
+	}
+	if len(latest) != want {
+		t.Errorf("Expected %d current containers, got %d", want, len(latest))
+	}
+
+	var nasID int64
+	for _, h := range hosts {
+		if h.Name == "nas" {
+			nasID = h.ID
+		}
+	}
+	events, err := db.GetContainerLifecycleEvents("sonarr", nasID)
+	if err != nil {
+		t.Fatalf("GetContainerLifecycleEvents failed: %v", err)
+	}
+	updated := false
+	for _, e := range events {
+		if e.EventType == "image_updated" {
+			updated = true
+		}
+	}
+	if !updated {
+		t.Error("Expected an image_updated lifecycle event for sonarr")
+	}
+
+	summary, err := db.GetVulnerabilitySummary()
+	if err != nil {
+		t.Fatalf("GetVulnerabilitySummary failed: %v", err)
+	}
+	if summary.TotalImagesScanned != len(data.Vulnerabilities) {
+		t.Errorf("Expected %d scanned images, got %d", len(data.Vulnerabilities), summary.TotalImagesScanned)
+	}
+
+	jobs, err := db.GetBackupJobs(opts.Now)
+	if err != nil {
+		t.Fatalf("GetBackupJobs failed: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].ContainerName != "restic-backup" {
+		t.Errorf("Expected the restic-backup job, got %+v", jobs)
+	}
+}
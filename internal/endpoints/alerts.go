@@ -0,0 +1,60 @@
+package endpoints
+
+import (
+	"github.com/container-census/container-census/internal/models"
+)
+
+// WarningLevel returns how urgent an expiry warning is for a certificate with days remaining:
+// 0 outside the warning days, then 1 within them, 2 within a week, 3 within a day and 4 once
+// expired. A warning is sent each time a certificate reaches a higher level.
+func WarningLevel(days, warningDays int) int {
+	switch {
+	case days < 0:
+		return 4
+	case days > warningDays:
+		return 0
+	case days <= 1:
+		return 3
+	case days <= 7:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// Evaluate compares new checks with the previous ones and returns the alerts to send:
+// cert_expiring when a certificate reaches a higher WarningLevel (renewed certificates start
+// over), and dns_failure when a hostname that resolved at the previous check no longer does.
+// Hostnames that never resolved, e.g. names only the LAN's DNS knows, don't alert. The last
+// warning sent is carried over to checks.
+func Evaluate(previous, checks []models.EndpointCheck, warningDays int) []models.EndpointAlert {
+	byHostname := make(map[string]models.EndpointCheck, len(previous))
+	for _, p := range previous {
+		byHostname[p.Hostname] = p
+	}
+
+	var alerts []models.EndpointAlert
+	for i := range checks {
+		check := &checks[i]
+		prev, seen := byHostname[check.Hostname]
+		check.CertWarningLevel = prev.CertWarningLevel
+		check.CertWarningNotAfter = prev.CertWarningNotAfter
+
+		if check.DNSError != "" && seen && prev.DNSError == "" {
+			alerts = append(alerts, models.EndpointAlert{EventType: models.EventTypeDNSFailure, Check: *check})
+		}
+		if check.CertNotAfter == nil || check.DaysRemaining == nil {
+			continue
+		}
+
+		if check.CertWarningNotAfter == nil || !check.CertWarningNotAfter.Equal(*check.CertNotAfter) {
+			check.CertWarningLevel = 0
+		}
+		if level := WarningLevel(*check.DaysRemaining, warningDays); level > check.CertWarningLevel {
+			check.CertWarningLevel = level
+			check.CertWarningNotAfter = check.CertNotAfter
+			alerts = append(alerts, models.EndpointAlert{EventType: models.EventTypeCertExpiring, Check: *check})
+		}
+	}
+	return alerts
+}
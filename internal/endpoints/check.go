@@ -0,0 +1,213 @@
+// Package endpoints checks the public hostnames of the proxy routes: whether they resolve, and
+// when the certificate they serve expires, so expiring certificates are reported before
+// browsers start rejecting them.
+package endpoints
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// maxConcurrentChecks limits the hostnames checked at once
+const maxConcurrentChecks = 8
+
+// Resolver looks up the addresses of a hostname; *net.Resolver implements it
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// Target is a hostname to check, with the route it was found in
+type Target struct {
+	Hostname      string
+	URL           string
+	Port          int // the TLS port; 0 when the hostname is only served over http
+	HostID        int64
+	HostName      string
+	ContainerName string
+}
+
+// Targets returns the hostnames of the routes, once each. A hostname with any https route is
+// checked at that route's port. IP addresses and wildcard or regexp hosts are left out.
+func Targets(routes []models.ProxyRoute) []Target {
+	byHost := make(map[string]*Target)
+	var order []string
+	for _, r := range routes {
+		u, err := url.Parse(r.URL)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		hostname := strings.ToLower(u.Hostname())
+		if net.ParseIP(hostname) != nil || strings.ContainsAny(hostname, "*{}") {
+			continue
+		}
+
+		port := 0
+		if u.Scheme == "https" {
+			port, _ = strconv.Atoi(u.Port())
+			if port == 0 {
+				port = 443
+			}
+		}
+
+		t, ok := byHost[hostname]
+		if !ok {
+			t = &Target{Hostname: hostname, URL: r.URL, HostID: r.HostID, HostName: r.HostName, ContainerName: r.ContainerName}
+			byHost[hostname] = t
+			order = append(order, hostname)
+		}
+		if t.Port == 0 && port != 0 {
+			t.Port = port
+			t.URL = r.URL
+		}
+		if t.ContainerName == "" && r.ContainerName != "" {
+			t.HostID, t.HostName, t.ContainerName = r.HostID, r.HostName, r.ContainerName
+		}
+	}
+
+	sort.Strings(order)
+	targets := make([]Target, 0, len(order))
+	for _, hostname := range order {
+		targets = append(targets, *byHost[hostname])
+	}
+	return targets
+}
+
+// Checker resolves hostnames and reads their certificates
+type Checker struct {
+	Resolver    Resolver
+	RootCAs     *x509.CertPool // nil uses the system roots
+	Timeout     time.Duration
+	WarningDays int
+}
+
+// NewChecker creates a checker using the system resolver and roots
+func NewChecker(warningDays int) *Checker {
+	if warningDays <= 0 {
+		warningDays = models.DefaultCertWarningDays
+	}
+	return &Checker{Resolver: net.DefaultResolver, Timeout: 10 * time.Second, WarningDays: warningDays}
+}
+
+// CheckAll checks the targets, a few at a time, in the order given
+func (c *Checker) CheckAll(ctx context.Context, targets []Target) []models.EndpointCheck {
+	checks := make([]models.EndpointCheck, len(targets))
+	sem := make(chan struct{}, maxConcurrentChecks)
+	var wg sync.WaitGroup
+	for i := range targets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			checks[i] = c.Check(ctx, targets[i])
+		}(i)
+	}
+	wg.Wait()
+	return checks
+}
+
+// Check resolves a target's hostname and, for https targets, connects to its first address
+// with the hostname as SNI to read the certificate. The certificate is read even when it doesn't
+// verify, so the expiry of a self-signed or mismatched certificate is still known.
+func (c *Checker) Check(ctx context.Context, target Target) models.EndpointCheck {
+	now := time.Now()
+	check := models.EndpointCheck{
+		Hostname:      target.Hostname,
+		URL:           target.URL,
+		Port:          target.Port,
+		HostID:        target.HostID,
+		HostName:      target.HostName,
+		ContainerName: target.ContainerName,
+		CheckedAt:     now,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	addresses, err := c.Resolver.LookupHost(ctx, target.Hostname)
+	if err == nil && len(addresses) == 0 {
+		err = &net.DNSError{Err: "no addresses", Name: target.Hostname, IsNotFound: true}
+	}
+	if err != nil {
+		check.DNSError = err.Error()
+		check.Status = models.EndpointStatusDNSError
+		return check
+	}
+	sort.Strings(addresses)
+	check.Addresses = addresses
+	check.Status = models.EndpointStatusOK
+	if target.Port == 0 {
+		return check
+	}
+
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{},
+		Config:    &tls.Config{ServerName: target.Hostname, InsecureSkipVerify: true},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(addresses[0], strconv.Itoa(target.Port)))
+	if err != nil {
+		check.CertError = err.Error()
+		check.Status = models.EndpointStatusUnreachable
+		return check
+	}
+	defer conn.Close()
+
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		check.CertError = "no certificate presented"
+		check.Status = models.EndpointStatusCertError
+		return check
+	}
+	leaf := certs[0]
+	notAfter := leaf.NotAfter
+	days := DaysRemaining(notAfter, now)
+	check.CertSubject = leaf.Subject.CommonName
+	check.CertIssuer = leaf.Issuer.CommonName
+	if check.CertIssuer == "" && len(leaf.Issuer.Organization) > 0 {
+		check.CertIssuer = leaf.Issuer.Organization[0]
+	}
+	check.CertDNSNames = leaf.DNSNames
+	check.CertNotAfter = &notAfter
+	check.DaysRemaining = &days
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, verifyErr := leaf.Verify(x509.VerifyOptions{
+		DNSName:       target.Hostname,
+		Roots:         c.RootCAs,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+	})
+
+	switch {
+	case now.After(notAfter):
+		check.Status = models.EndpointStatusExpired
+	case verifyErr != nil:
+		check.CertError = verifyErr.Error()
+		check.Status = models.EndpointStatusCertError
+	case days <= c.WarningDays:
+		check.Status = models.EndpointStatusExpiring
+	}
+	return check
+}
+
+// DaysRemaining returns the whole days until notAfter; negative once it has passed
+func DaysRemaining(notAfter, now time.Time) int {
+	d := notAfter.Sub(now)
+	if d < 0 {
+		return -int((-d).Hours()/24) - 1
+	}
+	return int(d.Hours() / 24)
+}
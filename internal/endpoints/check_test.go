@@ -0,0 +1,165 @@
+package endpoints
+
+import (
+	"context"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// fakeResolver resolves the hostnames it knows
+type fakeResolver map[string][]string
+
+func (r fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if addresses, ok := r[host]; ok {
+		return addresses, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func TestTargets(t *testing.T) {
+	routes := []models.ProxyRoute{
+		{URL: "http://jellyfin.example.com", HostID: 1, HostName: "nas", ContainerName: "jellyfin"},
+		{URL: "https://jellyfin.example.com:8443/web", HostID: 1, HostName: "nas", ContainerName: "jellyfin"},
+		{URL: "http://wiki.lan", ContainerName: ""},
+		{URL: "http://wiki.lan/docs", HostID: 2, HostName: "vps", ContainerName: "wiki"},
+		{URL: "https://10.0.0.5"},
+		{URL: "https://{subdomain:[a-z]+}.example.com"},
+	}
+
+	targets := Targets(routes)
+	if len(targets) != 2 {
+		t.Fatalf("Expected 2 targets, got %+v", targets)
+	}
+	if tt := targets[0]; tt.Hostname != "jellyfin.example.com" || tt.Port != 8443 || tt.URL != "https://jellyfin.example.com:8443/web" || tt.ContainerName != "jellyfin" {
+		t.Errorf("Unexpected target %+v", tt)
+	}
+	if tt := targets[1]; tt.Hostname != "wiki.lan" || tt.Port != 0 || tt.ContainerName != "wiki" || tt.HostID != 2 {
+		t.Errorf("Unexpected target %+v", tt)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(u.Port())
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	// httptest's certificate is for example.com and expires in 2084
+	checker := &Checker{
+		Resolver:    fakeResolver{"example.com": {"127.0.0.1"}, "other.example.org": {"127.0.0.1"}},
+		RootCAs:     roots,
+		Timeout:     5 * time.Second,
+		WarningDays: models.DefaultCertWarningDays,
+	}
+
+	checks := checker.CheckAll(context.Background(), []Target{
+		{Hostname: "example.com", Port: port},
+		{Hostname: "other.example.org", Port: port},
+		{Hostname: "gone.example.com", Port: port},
+		{Hostname: "example.com"},
+	})
+
+	if c := checks[0]; c.Status != models.EndpointStatusOK || c.CertNotAfter == nil || c.DaysRemaining == nil || *c.DaysRemaining < 365 {
+		t.Errorf("Expected a valid certificate, got %+v", c)
+	}
+	if c := checks[1]; c.Status != models.EndpointStatusCertError || c.CertError == "" || c.CertNotAfter == nil {
+		t.Errorf("Expected a certificate for another name to be read but flagged, got %+v", c)
+	}
+	if c := checks[2]; c.Status != models.EndpointStatusDNSError || c.DNSError == "" {
+		t.Errorf("Expected a DNS error, got %+v", c)
+	}
+	if c := checks[3]; c.Status != models.EndpointStatusOK || len(c.Addresses) != 1 || c.CertNotAfter != nil {
+		t.Errorf("Expected only DNS to be checked for an http hostname, got %+v", c)
+	}
+
+	checker.Resolver = fakeResolver{"example.com": {"127.0.0.1"}}
+	server.Close()
+	if c := checker.Check(context.Background(), Target{Hostname: "example.com", Port: port}); c.Status != models.EndpointStatusUnreachable {
+		t.Errorf("Expected an unreachable endpoint, got %+v", c)
+	}
+}
+
+func TestDaysRemaining(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		notAfter time.Time
+		want     int
+	}{
+		{now.Add(30*24*time.Hour + time.Hour), 30},
+		{now.Add(23 * time.Hour), 0},
+		{now.Add(-time.Hour), -1},
+		{now.Add(-49 * time.Hour), -3},
+	}
+	for _, tt := range tests {
+		if got := DaysRemaining(tt.notAfter, now); got != tt.want {
+			t.Errorf("DaysRemaining(%v) = %d, want %d", tt.notAfter, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	now := time.Now()
+	cert := func(hostname string, notAfter time.Time) models.EndpointCheck {
+		days := DaysRemaining(notAfter, now)
+		return models.EndpointCheck{Hostname: hostname, CertNotAfter: &notAfter, DaysRemaining: &days, Addresses: []string{"1.2.3.4"}}
+	}
+	expiry := now.Add(10*24*time.Hour + time.Hour)
+
+	// First check within the warning days warns once
+	checks := []models.EndpointCheck{cert("a.example.com", expiry), cert("b.example.com", now.Add(90*24*time.Hour))}
+	alerts := Evaluate(nil, checks, 14)
+	if len(alerts) != 1 || alerts[0].EventType != models.EventTypeCertExpiring || alerts[0].Check.Hostname != "a.example.com" {
+		t.Fatalf("Expected one expiry warning, got %+v", alerts)
+	}
+
+	// Same level again: no warning; a week left: warned again
+	previous := checks
+	checks = []models.EndpointCheck{cert("a.example.com", expiry), cert("b.example.com", now.Add(90*24*time.Hour))}
+	if alerts = Evaluate(previous, checks, 14); len(alerts) != 0 {
+		t.Errorf("Expected no repeated warning, got %+v", alerts)
+	}
+	days := 6
+	checks[0].DaysRemaining = &days
+	if alerts = Evaluate(previous, checks, 14); len(alerts) != 1 || checks[0].CertWarningLevel != 2 {
+		t.Errorf("Expected a warning within a week, got %+v", alerts)
+	}
+
+	// Renewed: starts over without a warning outside the warning days
+	previous = checks
+	checks = []models.EndpointCheck{cert("a.example.com", now.Add(90*24*time.Hour))}
+	if alerts = Evaluate(previous, checks, 14); len(alerts) != 0 || checks[0].CertWarningLevel != 0 {
+		t.Errorf("Expected a renewed certificate to reset warnings, got %+v / %+v", alerts, checks[0])
+	}
+
+	// DNS failing after resolving alerts; a hostname that never resolved doesn't
+	previous = checks
+	checks = []models.EndpointCheck{
+		{Hostname: "a.example.com", DNSError: "no such host"},
+		{Hostname: "nas.lan", DNSError: "no such host"},
+	}
+	alerts = Evaluate(previous, checks, 14)
+	if len(alerts) != 1 || alerts[0].EventType != models.EventTypeDNSFailure || alerts[0].Check.Hostname != "a.example.com" {
+		t.Errorf("Expected one DNS failure, got %+v", alerts)
+	}
+	if alerts = Evaluate(checks, checks, 14); len(alerts) != 0 {
+		t.Errorf("Expected a DNS failure to alert once, got %+v", alerts)
+	}
+}
+
+func TestWarningLevel(t *testing.T) {
+	if WarningLevel(30, 14) != 0 || WarningLevel(14, 14) != 1 || WarningLevel(7, 14) != 2 || WarningLevel(1, 14) != 3 ||
+		WarningLevel(-1, 14) != 4 || WarningLevel(10, 5) != 0 {
+		t.Error("Unexpected warning levels")
+	}
+}
@@ -0,0 +1,175 @@
+// Package eventbus publishes raw Census events (scans, container changes, updates) to external
+// automation. Events are stored with increasing IDs and delivered to each subscription in order
+// from its cursor, so a subscriber that was down catches up and one that lost events can replay
+// them.
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+const (
+	// DefaultRetention is how long events are kept for replay
+	DefaultRetention = 7 * 24 * time.Hour
+	// retryInterval is how often deliveries that failed are retried, and new events are checked
+	// for when a wake-up was missed
+	retryInterval = 30 * time.Second
+	// batchSize is how many events are read for a subscription at once
+	batchSize = 100
+	// deliveryTimeout limits each connection and delivery
+	deliveryTimeout = 10 * time.Second
+)
+
+// Store keeps the events and subscriptions; *storage.DB implements it
+type Store interface {
+	InsertBusEvents(events []models.BusEvent) error
+	GetBusEvents(afterID int64, types []string, hostIDs []int64, limit int) ([]models.BusEvent, error)
+	DeleteBusEventsBefore(before time.Time) (int64, error)
+	GetEventSubscriptions() ([]models.EventSubscription, error)
+	RecordEventDelivery(id, from, cursor int64, at time.Time, deliveryErr string) error
+}
+
+// Bus stores published events and delivers them to the subscriptions
+type Bus struct {
+	store      Store
+	retention  time.Duration
+	httpClient *http.Client
+	wake       chan struct{}
+	dispatchMu sync.Mutex // one delivery pass at a time
+}
+
+// New creates an event bus keeping events for retention (DefaultRetention when zero)
+func New(store Store, retention time.Duration) *Bus {
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+	return &Bus{
+		store:      store,
+		retention:  retention,
+		httpClient: &http.Client{Timeout: deliveryTimeout},
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+// Publish stores events and wakes the delivery loop. Errors are logged: publishing never fails
+// the scan or update that raised the events.
+func (b *Bus) Publish(events ...models.BusEvent) {
+	if b == nil || len(events) == 0 {
+		return
+	}
+	if err := b.store.InsertBusEvents(events); err != nil {
+		log.Printf("Event bus: failed to store %d events: %v", len(events), err)
+		return
+	}
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run delivers events as they are published, retries failed deliveries and drops events older
+// than the retention, until ctx is done
+func (b *Bus) Run(ctx context.Context) {
+	ticker := time.NewTicker(retryInterval)
+	defer ticker.Stop()
+	lastCleanup := time.Time{}
+
+	for {
+		b.Dispatch(ctx)
+		if time.Since(lastCleanup) > time.Hour {
+			if n, err := b.store.DeleteBusEventsBefore(time.Now().Add(-b.retention)); err != nil {
+				log.Printf("Event bus: failed to remove old events: %v", err)
+			} else if n > 0 {
+				log.Printf("Event bus: removed %d events older than %s", n, b.retention)
+			}
+			lastCleanup = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch delivers the pending events of every enabled subscription
+func (b *Bus) Dispatch(ctx context.Context) {
+	b.dispatchMu.Lock()
+	defer b.dispatchMu.Unlock()
+
+	subs, err := b.store.GetEventSubscriptions()
+	if err != nil {
+		log.Printf("Event bus: failed to get subscriptions: %v", err)
+		return
+	}
+	for _, sub := range subs {
+		if !sub.Enabled || ctx.Err() != nil {
+			continue
+		}
+		if err := b.deliver(ctx, sub); err != nil {
+			log.Printf("Event bus: delivery to subscription %q failed: %v", sub.Name, err)
+		}
+	}
+}
+
+// deliver sends a subscription's events after its cursor, in order, stopping at the first
+// failure so it is retried from there
+func (b *Bus) deliver(ctx context.Context, sub models.EventSubscription) error {
+	for {
+		events, err := b.store.GetBusEvents(sub.Cursor, sub.EventTypes, nil, batchSize)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		cursor, deliveryErr := b.deliverBatch(ctx, sub, events)
+		errText := ""
+		if deliveryErr != nil {
+			errText = deliveryErr.Error()
+		}
+		if err := b.store.RecordEventDelivery(sub.ID, sub.Cursor, cursor, time.Now(), errText); err != nil {
+			return err
+		}
+		if deliveryErr != nil {
+			return deliveryErr
+		}
+		if len(events) < batchSize {
+			return nil
+		}
+		sub.Cursor = cursor
+	}
+}
+
+// deliverBatch sends events over one connection and returns the ID of the last one delivered
+func (b *Bus) deliverBatch(ctx context.Context, sub models.EventSubscription, events []models.BusEvent) (int64, error) {
+	cursor := sub.Cursor
+	s, err := openSink(ctx, sub, b.httpClient)
+	if err != nil {
+		return cursor, err
+	}
+	defer s.Close()
+
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return cursor, fmt.Errorf("event %d: %w", event.ID, err)
+		}
+		if err := s.Publish(ctx, event, payload); err != nil {
+			return cursor, fmt.Errorf("event %d: %w", event.ID, err)
+		}
+		cursor = event.ID
+	}
+	return cursor, nil
+}
@@ -0,0 +1,273 @@
+package eventbus
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/notifications/channels"
+	"github.com/container-census/container-census/internal/storage"
+)
+
+func setupTestBus(t *testing.T) (*Bus, *storage.DB) {
+	t.Helper()
+
+	tmpfile, err := os.CreateTemp("", "eventbus-test-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp db: %v", err)
+	}
+	tmpfile.Close()
+	t.Cleanup(func() {
+		os.Remove(tmpfile.Name())
+	})
+
+	db, err := storage.New(tmpfile.Name())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	return New(db, 0), db
+}
+
+func TestScanEvents(t *testing.T) {
+	host := models.Host{ID: 1, Name: "nas"}
+	started := time.Now()
+	result := models.ScanResult{ScanID: "abc", StartedAt: started, CompletedAt: started.Add(time.Second), Success: true}
+	previous := []models.Container{
+		{ID: "1", Name: "web", Image: "nginx:1.25", ImageID: "sha256:a", State: "running"},
+		{ID: "2", Name: "db", Image: "postgres:16", ImageID: "sha256:b", State: "running"},
+		{ID: "3", Name: "old", Image: "redis:7", ImageID: "sha256:c", State: "exited"},
+		{ID: "4", Name: "wiki", Image: "wiki:2", ImageID: "sha256:d", State: "running"},
+	}
+	current := []models.Container{
+		{ID: "1", Name: "web", Image: "nginx:1.26", ImageID: "sha256:e", State: "running"},
+		{ID: "2", Name: "db", Image: "postgres:16", ImageID: "sha256:b", State: "exited"},
+		{ID: "4", Name: "docs", Image: "wiki:2", ImageID: "sha256:d", State: "running"}, // renamed
+		{ID: "5", Name: "new", Image: "alpine", ImageID: "sha256:f", State: "running"},
+	}
+
+	events := ScanEvents(host, result, previous, current)
+	var got []string
+	for _, e := range events {
+		got = append(got, e.Type+":"+e.ContainerName)
+	}
+	want := []string{"image_changed:web", "container_stopped:db", "container_appeared:new", "container_removed:old", "scan_completed:"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	if events[0].Data["old_image"] != "nginx:1.25" || events[4].Data["containers_found"] != 4 || events[4].HostName != "nas" {
+		t.Errorf("Unexpected event data %+v", events)
+	}
+
+	// A host's first scan reports no changes; a failed scan only its failure
+	if events := ScanEvents(host, result, nil, current); len(events) != 1 || events[0].Type != models.BusEventScanCompleted {
+		t.Errorf("Expected only scan_completed on a first scan, got %+v", events)
+	}
+	result.Success, result.Error = false, "connection refused"
+	if events := ScanEvents(host, result, previous, nil); len(events) != 1 || events[0].Type != models.BusEventScanFailed || events[0].Data["error"] != "connection refused" {
+		t.Errorf("Expected scan_failed, got %+v", events)
+	}
+}
+
+func TestDeliverHTTP(t *testing.T) {
+	bus, db := setupTestBus(t)
+
+	var mu sync.Mutex
+	var received []models.BusEvent
+	fail := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get(channels.SignatureHeader) != channels.Sign("s3cret", body) {
+			t.Errorf("Unexpected signature %q", r.Header.Get(channels.SignatureHeader))
+		}
+		var e models.BusEvent
+		json.Unmarshal(body, &e)
+		if r.Header.Get("X-Census-Event") != e.Type || r.Header.Get("X-Census-Event-ID") != strconv.FormatInt(e.ID, 10) {
+			t.Errorf("Unexpected headers %v", r.Header)
+		}
+		received = append(received, e)
+	}))
+	defer server.Close()
+
+	sub := &models.EventSubscription{Name: "automation", URL: server.URL, Secret: "s3cret", Enabled: true,
+		EventTypes: []string{models.BusEventContainerStopped, models.BusEventScanFailed}}
+	if err := db.SaveEventSubscription(sub); err != nil {
+		t.Fatalf("Failed to save subscription: %v", err)
+	}
+
+	bus.Publish(
+		models.BusEvent{Type: models.BusEventContainerStopped, Timestamp: time.Now(), HostID: 1, ContainerName: "web"},
+		models.BusEvent{Type: models.BusEventScanCompleted, Timestamp: time.Now(), HostID: 1},
+		models.BusEvent{Type: models.BusEventScanFailed, Timestamp: time.Now(), HostID: 2},
+	)
+
+	// A failed delivery keeps the cursor and records the error
+	bus.Dispatch(context.Background())
+	saved, _ := db.GetEventSubscription(sub.ID)
+	if saved.Cursor != 0 || !strings.Contains(saved.LastError, "503") {
+		t.Fatalf("Expected the cursor to stay after a failure, got %+v", saved)
+	}
+
+	mu.Lock()
+	fail = false
+	mu.Unlock()
+	bus.Dispatch(context.Background())
+	saved, _ = db.GetEventSubscription(sub.ID)
+	if len(received) != 2 || received[0].ContainerName != "web" || received[1].Type != models.BusEventScanFailed {
+		t.Fatalf("Expected the subscribed events in order, got %+v", received)
+	}
+	if saved.Cursor != 3 || saved.LastError != "" || saved.LastDeliveryAt == nil {
+		t.Errorf("Unexpected subscription after delivery %+v", saved)
+	}
+
+	// Nothing new: nothing is delivered again; moving the cursor back replays
+	bus.Dispatch(context.Background())
+	if len(received) != 2 {
+		t.Errorf("Expected no repeated deliveries, got %d", len(received))
+	}
+	db.SetEventSubscriptionCursor(sub.ID, 0)
+	bus.Dispatch(context.Background())
+	if len(received) != 4 {
+		t.Errorf("Expected a replay, got %d deliveries", len(received))
+	}
+}
+
+func TestDeliverNATS(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	published := make(chan string, 10)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n"))
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSpace(line)
+			switch {
+			case strings.HasPrefix(line, "CONNECT"):
+				if !strings.Contains(line, `"user":"census"`) || !strings.Contains(line, `"pass":"pw"`) {
+					conn.Write([]byte("-ERR 'Authorization Violation'\r\n"))
+				}
+			case line == "PING":
+				conn.Write([]byte("PONG\r\n"))
+			case strings.HasPrefix(line, "PUB"):
+				fields := strings.Fields(line)
+				n, _ := strconv.Atoi(fields[2])
+				payload := make([]byte, n+2)
+				io.ReadFull(reader, payload)
+				published <- fields[1] + " " + string(payload[:n])
+			}
+		}
+	}()
+
+	bus, db := setupTestBus(t)
+	db.SaveEventSubscription(&models.EventSubscription{Name: "nats", URL: "nats://census:pw@" + listener.Addr().String() + "/homelab/census", Enabled: true})
+	bus.Publish(models.BusEvent{Type: models.BusEventContainerAppeared, Timestamp: time.Now(), ContainerName: "web"})
+	bus.Dispatch(context.Background())
+
+	select {
+	case msg := <-published:
+		if !strings.HasPrefix(msg, "homelab.census.container_appeared {") || !strings.Contains(msg, `"container_name":"web"`) {
+			t.Errorf("Unexpected publish %q", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a publish")
+	}
+	if subs, _ := db.GetEventSubscriptions(); subs[0].Cursor != 1 || subs[0].LastError != "" {
+		t.Errorf("Unexpected subscription %+v", subs[0])
+	}
+}
+
+func TestDeliverMQTT(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	published := make(chan string, 10)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for {
+			// Fixed header, remaining length and body
+			header, err := reader.ReadByte()
+			if err != nil {
+				return
+			}
+			length, multiplier := 0, 1
+			for {
+				b, _ := reader.ReadByte()
+				length += int(b&0x7f) * multiplier
+				multiplier *= 128
+				if b&0x80 == 0 {
+					break
+				}
+			}
+			body := make([]byte, length)
+			if _, err := io.ReadFull(reader, body); err != nil {
+				return
+			}
+			switch header >> 4 {
+			case 1: // CONNECT
+				conn.Write([]byte{0x20, 2, 0, 0})
+			case 3: // PUBLISH with QoS 1
+				n := int(binary.BigEndian.Uint16(body))
+				published <- fmt.Sprintf("%s %s", body[2:2+n], body[4+n:])
+				conn.Write([]byte{0x40, 2, body[2+n], body[3+n]})
+			case 14: // DISCONNECT
+				return
+			}
+		}
+	}()
+
+	bus, db := setupTestBus(t)
+	db.SaveEventSubscription(&models.EventSubscription{Name: "mqtt", URL: "mqtt://" + listener.Addr().String(), Enabled: true})
+	bus.Publish(models.BusEvent{Type: models.BusEventUpdatePerformed, Timestamp: time.Now(), ContainerName: "web"})
+	bus.Dispatch(context.Background())
+
+	select {
+	case msg := <-published:
+		if !strings.HasPrefix(msg, "census/update_performed {") {
+			t.Errorf("Unexpected publish %q", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a publish")
+	}
+	if subs, _ := db.GetEventSubscriptions(); subs[0].Cursor != 1 || subs[0].LastError != "" {
+		t.Errorf("Unexpected subscription %+v", subs[0])
+	}
+}
@@ -0,0 +1,143 @@
+package eventbus
+
+import (
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// ScanEvents returns the events of a host's scan: scan_completed with the container changes
+// since the previous scan, or scan_failed. previous is the host's latest scan before this one;
+// when it is empty (the host's first scan) no container changes are reported. Renamed containers
+// keep their ID and aren't reported as removed and appeared.
+func ScanEvents(host models.Host, result models.ScanResult, previous, current []models.Container) []models.BusEvent {
+	at := result.CompletedAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+	event := func(eventType, containerName string, data map[string]interface{}) models.BusEvent {
+		return models.BusEvent{Type: eventType, Timestamp: at, HostID: host.ID, HostName: host.Name, ContainerName: containerName, Data: data}
+	}
+
+	if !result.Success {
+		return []models.BusEvent{event(models.BusEventScanFailed, "", map[string]interface{}{
+			"scan_id": result.ScanID,
+			"error":   result.Error,
+		})}
+	}
+
+	var events []models.BusEvent
+	if len(previous) > 0 {
+		byName := make(map[string]models.Container, len(previous))
+		byID := make(map[string]models.Container, len(previous))
+		for _, c := range previous {
+			byName[c.Name] = c
+			byID[c.ID] = c
+		}
+		seen := make(map[string]bool, len(current))
+		for _, c := range current {
+			prev, ok := byName[c.Name]
+			if !ok {
+				prev, ok = byID[c.ID]
+			}
+			if !ok {
+				events = append(events, event(models.BusEventContainerAppeared, c.Name, containerData(c)))
+				continue
+			}
+			seen[prev.Name] = true
+
+			if prev.ImageID != "" && c.ImageID != "" && prev.ImageID != c.ImageID {
+				data := containerData(c)
+				data["old_image"] = prev.Image
+				data["old_image_id"] = prev.ImageID
+				events = append(events, event(models.BusEventImageChanged, c.Name, data))
+			}
+			wasRunning, running := prev.State == "running", c.State == "running"
+			if wasRunning != running {
+				eventType := models.BusEventContainerStopped
+				if running {
+					eventType = models.BusEventContainerStarted
+				}
+				data := containerData(c)
+				data["old_state"] = prev.State
+				events = append(events, event(eventType, c.Name, data))
+			}
+		}
+		for _, c := range previous {
+			if !seen[c.Name] {
+				events = append(events, event(models.BusEventContainerRemoved, c.Name, containerData(c)))
+			}
+		}
+	}
+
+	running := 0
+	for _, c := range current {
+		if c.State == "running" {
+			running++
+		}
+	}
+	completed := event(models.BusEventScanCompleted, "", map[string]interface{}{
+		"scan_id":          result.ScanID,
+		"containers_found": len(current),
+		"running":          running,
+		"changes":          len(events),
+		"warnings":         len(result.Warnings),
+		"duration_ms":      result.CompletedAt.Sub(result.StartedAt).Milliseconds(),
+	})
+	return append(events, completed)
+}
+
+// UpdateAvailableEvent returns the update_available event of a container
+func UpdateAvailableEvent(host models.Host, c models.Container) models.BusEvent {
+	return models.BusEvent{
+		Type:          models.BusEventUpdateAvailable,
+		Timestamp:     time.Now(),
+		HostID:        host.ID,
+		HostName:      host.Name,
+		ContainerName: c.Name,
+		Data:          containerData(c),
+	}
+}
+
+// UpdatePerformedEvent returns the update_performed event of an update Census made
+func UpdatePerformedEvent(u models.ContainerUpdate) models.BusEvent {
+	data := map[string]interface{}{
+		"image":        u.Image,
+		"old_image_id": u.OldImageID,
+		"new_image_id": u.NewImageID,
+		"status":       u.Status,
+		"trigger":      u.Trigger,
+		"duration_ms":  u.DurationMs,
+	}
+	if u.TriggeredBy != "" {
+		data["triggered_by"] = u.TriggeredBy
+	}
+	if u.Error != "" {
+		data["error"] = u.Error
+	}
+	if u.HealthStatus != "" {
+		data["health_status"] = u.HealthStatus
+	}
+	return models.BusEvent{
+		Type:          models.BusEventUpdatePerformed,
+		Timestamp:     u.FinishedAt,
+		HostID:        u.HostID,
+		HostName:      u.HostName,
+		ContainerName: u.ContainerName,
+		Data:          data,
+	}
+}
+
+// containerData is what events say about a container
+func containerData(c models.Container) map[string]interface{} {
+	data := map[string]interface{}{
+		"container_id": c.ID,
+		"image":        c.Image,
+		"image_id":     c.ImageID,
+		"state":        c.State,
+	}
+	if c.ComposeProject != "" {
+		data["compose_project"] = c.ComposeProject
+	}
+	return data
+}
@@ -0,0 +1,98 @@
+package eventbus
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/container-census/container-census/internal/broker"
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/notifications/channels"
+	"github.com/container-census/container-census/internal/version"
+)
+
+// sink delivers events to one subscription
+type sink interface {
+	Publish(ctx context.Context, event models.BusEvent, payload []byte) error
+	Close() error
+}
+
+// openSink connects to a subscription's URL
+func openSink(ctx context.Context, sub models.EventSubscription, client *http.Client) (sink, error) {
+	u, err := url.Parse(sub.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	switch u.Scheme {
+	case models.SubscriptionSchemeHTTP, models.SubscriptionSchemeHTTPS:
+		return &httpSink{client: client, url: sub.URL, secret: sub.Secret}, nil
+	case models.SubscriptionSchemeNATS, models.SubscriptionSchemeNATSTLS, models.SubscriptionSchemeMQTT, models.SubscriptionSchemeMQTTS:
+		return dialBroker(ctx, u)
+	default:
+		return nil, fmt.Errorf("unsupported URL scheme: %s", u.Scheme)
+	}
+}
+
+// httpSink POSTs each event as JSON
+type httpSink struct {
+	client *http.Client
+	url    string
+	secret string
+}
+
+func (s *httpSink) Publish(ctx context.Context, event models.BusEvent, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Container-Census/"+version.Get())
+	req.Header.Set("X-Census-Event", event.Type)
+	req.Header.Set("X-Census-Event-ID", strconv.FormatInt(event.ID, 10))
+	if s.secret != "" {
+		req.Header.Set(channels.SignatureHeader, channels.Sign(s.secret, payload))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *httpSink) Close() error { return nil }
+
+// brokerSink publishes to <prefix>.<type> on a NATS server or <prefix>/<type> on an MQTT broker
+type brokerSink struct {
+	conn   broker.Conn
+	prefix []string
+}
+
+func dialBroker(ctx context.Context, u *url.URL) (*brokerSink, error) {
+	conn, err := broker.Dial(ctx, u.String())
+	if err != nil {
+		return nil, err
+	}
+	prefix := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if prefix[0] == "" {
+		prefix = []string{broker.DefaultPrefix}
+	}
+	return &brokerSink{conn: conn, prefix: prefix}, nil
+}
+
+func (s *brokerSink) Publish(ctx context.Context, event models.BusEvent, payload []byte) error {
+	levels := append(append([]string{}, s.prefix...), event.Type)
+	return s.conn.Publish(s.conn.Topic(levels...), payload)
+}
+
+func (s *brokerSink) Close() error { return s.conn.Close() }
@@ -0,0 +1,202 @@
+// Package exposure reports the ports containers publish on their hosts and probes from the
+// server which of them are actually reachable over the network.
+package exposure
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// DialFunc opens a connection, e.g. (&net.Dialer{}).DialContext
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// ProbeOptions controls the reachability probe
+type ProbeOptions struct {
+	Timeout     time.Duration // per connection attempt, default 2s
+	Concurrency int           // parallel connection attempts, default 16
+	Dial        DialFunc      // defaults to a plain TCP dial
+}
+
+// BuildReport lists the published ports of the given containers. Docker reports a port bound
+// to all interfaces once for IPv4 and once for IPv6; those are merged into one entry.
+func BuildReport(containers []models.Container, now time.Time) *models.PortExposureReport {
+	report := &models.PortExposureReport{
+		GeneratedAt: now,
+		Ports:       make([]models.PortExposure, 0),
+	}
+
+	seen := make(map[string]bool)
+	for _, c := range containers {
+		for _, p := range c.Ports {
+			if p.PublicPort == 0 {
+				continue
+			}
+
+			binding := ClassifyBinding(p.IP)
+			ip := p.IP
+			if binding == models.BindingAllInterfaces {
+				ip = "0.0.0.0"
+			}
+			key := fmt.Sprintf("%d/%s/%s/%d/%s", c.HostID, c.ID, ip, p.PublicPort, p.Type)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			report.Ports = append(report.Ports, models.PortExposure{
+				HostID:        c.HostID,
+				HostName:      c.HostName,
+				ContainerID:   c.ID,
+				ContainerName: c.Name,
+				Image:         c.Image,
+				IP:            ip,
+				PublicPort:    p.PublicPort,
+				PrivatePort:   p.PrivatePort,
+				Protocol:      p.Type,
+				Binding:       binding,
+			})
+
+			report.Summary.Total++
+			switch binding {
+			case models.BindingAllInterfaces:
+				report.Summary.AllInterfaces++
+			case models.BindingLoopback:
+				report.Summary.Loopback++
+			default:
+				report.Summary.Specific++
+			}
+		}
+	}
+
+	// Widest exposure first, then by host and port
+	rank := map[string]int{models.BindingAllInterfaces: 0, models.BindingSpecific: 1, models.BindingLoopback: 2}
+	sort.SliceStable(report.Ports, func(i, j int) bool {
+		a, b := report.Ports[i], report.Ports[j]
+		if rank[a.Binding] != rank[b.Binding] {
+			return rank[a.Binding] < rank[b.Binding]
+		}
+		if a.HostName != b.HostName {
+			return a.HostName < b.HostName
+		}
+		return a.PublicPort < b.PublicPort
+	})
+
+	return report
+}
+
+// ClassifyBinding classifies the host IP a port is published on
+func ClassifyBinding(ip string) string {
+	if ip == "" {
+		return models.BindingAllInterfaces
+	}
+	parsed := net.ParseIP(ip)
+	switch {
+	case parsed == nil:
+		return models.BindingSpecific
+	case parsed.IsUnspecified():
+		return models.BindingAllInterfaces
+	case parsed.IsLoopback():
+		return models.BindingLoopback
+	default:
+		return models.BindingSpecific
+	}
+}
+
+// Probe tries a TCP connection from the server to every published port that could be
+// reachable from outside its host and records the result on the report. Ports bound to
+// loopback, UDP ports and ports on hosts without a network address are skipped.
+func Probe(ctx context.Context, report *models.PortExposureReport, hosts []models.Host, opts ProbeOptions) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 2 * time.Second
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 16
+	}
+	if opts.Dial == nil {
+		opts.Dial = (&net.Dialer{}).DialContext
+	}
+
+	hostAddresses := make(map[int64]string, len(hosts))
+	for _, host := range hosts {
+		hostAddresses[host.ID] = HostAddress(host)
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, opts.Concurrency)
+
+	report.Summary.Probed = true
+	report.Summary.Reachable = 0
+	for i := range report.Ports {
+		port := &report.Ports[i]
+
+		target, reason := probeTarget(*port, hostAddresses[port.HostID])
+		if target == "" {
+			port.Probe = models.ProbeSkipped
+			port.ProbeDetail = reason
+			continue
+		}
+		port.ProbeTarget = target
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			dialCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+			defer cancel()
+
+			conn, err := opts.Dial(dialCtx, "tcp", target)
+			if err != nil {
+				port.Probe = models.ProbeUnreachable
+				port.ProbeDetail = err.Error()
+				return
+			}
+			conn.Close()
+			port.Probe = models.ProbeReachable
+		}()
+	}
+	wg.Wait()
+
+	for _, port := range report.Ports {
+		if port.Probe == models.ProbeReachable {
+			report.Summary.Reachable++
+		}
+	}
+}
+
+// probeTarget returns the address to probe a port at, or "" and the reason it can't be probed
+func probeTarget(port models.PortExposure, hostAddress string) (string, string) {
+	if port.Protocol != "" && port.Protocol != "tcp" {
+		return "", "only TCP ports can be probed"
+	}
+
+	switch port.Binding {
+	case models.BindingLoopback:
+		return "", "bound to loopback, not reachable from other machines"
+	case models.BindingSpecific:
+		return net.JoinHostPort(port.IP, strconv.Itoa(port.PublicPort)), ""
+	}
+
+	if hostAddress == "" {
+		return "", "host is connected through a local socket, so its network address is unknown"
+	}
+	return net.JoinHostPort(hostAddress, strconv.Itoa(port.PublicPort)), ""
+}
+
+// HostAddress returns the hostname or IP the server reaches a host at, or "" for local socket hosts
+func HostAddress(host models.Host) string {
+	u, err := url.Parse(host.Address)
+	if err != nil || u.Scheme == "unix" {
+		return ""
+	}
+	return u.Hostname()
+}
@@ -0,0 +1,117 @@
+package exposure
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func testContainers() []models.Container {
+	return []models.Container{
+		{
+			ID: "c1", Name: "portainer", Image: "portainer/portainer-ce", HostID: 1, HostName: "nas",
+			Ports: []models.PortMapping{
+				{PrivatePort: 9000, PublicPort: 9000, Type: "tcp", IP: "0.0.0.0"},
+				{PrivatePort: 9000, PublicPort: 9000, Type: "tcp", IP: "::"},
+				{PrivatePort: 8000, Type: "tcp"}, // exposed but not published
+			},
+		},
+		{
+			ID: "c2", Name: "db", Image: "postgres", HostID: 1, HostName: "nas",
+			Ports: []models.PortMapping{{PrivatePort: 5432, PublicPort: 5432, Type: "tcp", IP: "127.0.0.1"}},
+		},
+		{
+			ID: "c3", Name: "dns", Image: "pihole", HostID: 2, HostName: "pi",
+			Ports: []models.PortMapping{
+				{PrivatePort: 53, PublicPort: 53, Type: "udp", IP: "0.0.0.0"},
+				{PrivatePort: 80, PublicPort: 8080, Type: "tcp", IP: "192.168.1.20"},
+			},
+		},
+		{
+			ID: "c4", Name: "web", Image: "nginx", HostID: 3, HostName: "local",
+			Ports: []models.PortMapping{{PrivatePort: 80, PublicPort: 80, Type: "tcp", IP: "0.0.0.0"}},
+		},
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	report := BuildReport(testContainers(), time.Now())
+
+	if report.Summary.Total != 5 {
+		t.Fatalf("Expected 5 published ports (IPv4/IPv6 merged), got %d: %+v", report.Summary.Total, report.Ports)
+	}
+	if report.Summary.AllInterfaces != 3 || report.Summary.Loopback != 1 || report.Summary.Specific != 1 {
+		t.Errorf("Unexpected summary: %+v", report.Summary)
+	}
+	if report.Ports[0].Binding != models.BindingAllInterfaces {
+		t.Errorf("Expected ports bound to all interfaces first, got %+v", report.Ports[0])
+	}
+	if last := report.Ports[len(report.Ports)-1]; last.Binding != models.BindingLoopback {
+		t.Errorf("Expected loopback ports last, got %+v", last)
+	}
+}
+
+func TestClassifyBinding(t *testing.T) {
+	tests := map[string]string{
+		"":             models.BindingAllInterfaces,
+		"0.0.0.0":      models.BindingAllInterfaces,
+		"::":           models.BindingAllInterfaces,
+		"127.0.0.1":    models.BindingLoopback,
+		"::1":          models.BindingLoopback,
+		"192.168.1.20": models.BindingSpecific,
+	}
+	for ip, want := range tests {
+		if got := ClassifyBinding(ip); got != want {
+			t.Errorf("ClassifyBinding(%q) = %s, want %s", ip, got, want)
+		}
+	}
+}
+
+func TestProbe(t *testing.T) {
+	report := BuildReport(testContainers(), time.Now())
+	hosts := []models.Host{
+		{ID: 1, Name: "nas", Address: "agent://nas.lan:9876"},
+		{ID: 2, Name: "pi", Address: "tcp://192.168.1.20:2376"},
+		{ID: 3, Name: "local", Address: "unix:///var/run/docker.sock"},
+	}
+
+	var dialed []string
+	dial := func(ctx context.Context, network, address string) (net.Conn, error) {
+		dialed = append(dialed, address)
+		if address == "nas.lan:9000" {
+			client, server := net.Pipe()
+			server.Close()
+			return client, nil
+		}
+		return nil, errors.New("connection refused")
+	}
+
+	Probe(context.Background(), report, hosts, ProbeOptions{Dial: dial, Concurrency: 1})
+
+	results := make(map[string]models.PortExposure)
+	for _, p := range report.Ports {
+		results[p.ContainerName+"/"+p.Protocol] = p
+	}
+
+	if p := results["portainer/tcp"]; p.Probe != models.ProbeReachable || p.ProbeTarget != "nas.lan:9000" {
+		t.Errorf("Expected portainer to be reachable at nas.lan:9000, got %+v", p)
+	}
+	if p := results["dns/tcp"]; p.Probe != models.ProbeUnreachable || p.ProbeTarget != "192.168.1.20:8080" {
+		t.Errorf("Expected port bound to a specific IP to be probed on that IP, got %+v", p)
+	}
+	for _, name := range []string{"db/tcp", "dns/udp", "web/tcp"} {
+		if p := results[name]; p.Probe != models.ProbeSkipped || p.ProbeDetail == "" {
+			t.Errorf("Expected %s to be skipped with a reason, got %+v", name, p)
+		}
+	}
+	if len(dialed) != 2 {
+		t.Errorf("Expected 2 connection attempts, got %v", dialed)
+	}
+	if !report.Summary.Probed || report.Summary.Reachable != 1 {
+		t.Errorf("Unexpected probe summary: %+v", report.Summary)
+	}
+}
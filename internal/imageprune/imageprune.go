@@ -0,0 +1,118 @@
+package imageprune
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	imagetypes "github.com/docker/docker/api/types/image"
+)
+
+// BuildUsage combines the images on a host with their recorded usage. inUse holds the image IDs
+// referenced by containers in the latest scan. Images without a usage record count from now.
+func BuildUsage(images []imagetypes.Summary, records map[string]models.ImageUsageRecord, inUse map[string]bool, now time.Time) []models.ImageUsage {
+	usage := make([]models.ImageUsage, 0, len(images))
+
+	for _, img := range images {
+		u := models.ImageUsage{
+			ImageID:  img.ID,
+			RepoTags: tags(img.RepoTags),
+			Size:     img.Size,
+			Created:  time.Unix(img.Created, 0),
+			InUse:    inUse[img.ID],
+		}
+		u.Dangling = len(u.RepoTags) == 0
+
+		since := now
+		if record, ok := records[img.ID]; ok {
+			firstSeen := record.FirstSeen
+			u.FirstSeen = &firstSeen
+			u.LastUsed = record.LastUsed
+			since = firstSeen
+			if record.LastUsed != nil {
+				since = *record.LastUsed
+			}
+		}
+		if !u.InUse && now.After(since) {
+			u.UnusedDays = int(now.Sub(since).Hours() / 24)
+		}
+
+		usage = append(usage, u)
+	}
+
+	sort.Slice(usage, func(i, j int) bool {
+		return usage[i].UnusedDays > usage[j].UnusedDays
+	})
+
+	return usage
+}
+
+// SelectCandidates applies a prune policy. Images in use or used within MinUnusedDays are never
+// selected; of the remaining tagged images, the KeepTagsPerRepo newest per repository are kept.
+func SelectCandidates(usage []models.ImageUsage, policy models.ImagePrunePolicy) []models.ImagePruneCandidate {
+	// Rank tagged images per repository, newest first
+	byRepo := make(map[string][]models.ImageUsage)
+	for _, u := range usage {
+		for _, repo := range repositories(u.RepoTags) {
+			byRepo[repo] = append(byRepo[repo], u)
+		}
+	}
+	kept := make(map[string]bool)
+	for _, images := range byRepo {
+		sort.Slice(images, func(i, j int) bool {
+			return images[i].Created.After(images[j].Created)
+		})
+		for i := 0; i < len(images) && i < policy.KeepTagsPerRepo; i++ {
+			kept[images[i].ImageID] = true
+		}
+	}
+
+	candidates := make([]models.ImagePruneCandidate, 0)
+	for _, u := range usage {
+		if u.InUse || u.UnusedDays < policy.MinUnusedDays || kept[u.ImageID] {
+			continue
+		}
+
+		reason := fmt.Sprintf("unused for %d days", u.UnusedDays)
+		if u.Dangling {
+			reason = "dangling, " + reason
+		} else if policy.KeepTagsPerRepo > 0 {
+			reason += fmt.Sprintf(", older than the newest %d tags", policy.KeepTagsPerRepo)
+		}
+
+		candidates = append(candidates, models.ImagePruneCandidate{ImageUsage: u, Reason: reason})
+	}
+
+	return candidates
+}
+
+// tags drops the <none>:<none> placeholder Docker reports for untagged images
+func tags(repoTags []string) []string {
+	result := make([]string, 0, len(repoTags))
+	for _, tag := range repoTags {
+		if tag != "" && tag != "<none>:<none>" {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// repositories returns the distinct repositories of an image's tags
+func repositories(repoTags []string) []string {
+	seen := make(map[string]bool)
+	var repos []string
+	for _, tag := range repoTags {
+		repo := tag
+		// The tag separator is the last colon after the last slash, so registry ports survive
+		if i := strings.LastIndex(tag, ":"); i > strings.LastIndex(tag, "/") {
+			repo = tag[:i]
+		}
+		if !seen[repo] {
+			seen[repo] = true
+			repos = append(repos, repo)
+		}
+	}
+	return repos
+}
@@ -0,0 +1,106 @@
+package imageprune
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	imagetypes "github.com/docker/docker/api/types/image"
+)
+
+func TestBuildUsage(t *testing.T) {
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	lastUsed := now.AddDate(0, 0, -40)
+
+	images := []imagetypes.Summary{
+		{ID: "sha256:running", RepoTags: []string{"app:3"}},
+		{ID: "sha256:old", RepoTags: []string{"app:1"}},
+		{ID: "sha256:pulled", RepoTags: []string{"tool:latest"}},
+		{ID: "sha256:dangling", RepoTags: []string{"<none>:<none>"}},
+		{ID: "sha256:unknown", RepoTags: []string{"new:1"}},
+	}
+	records := map[string]models.ImageUsageRecord{
+		"sha256:running":  {FirstSeen: now.AddDate(0, 0, -100), LastUsed: &now},
+		"sha256:old":      {FirstSeen: now.AddDate(0, 0, -100), LastUsed: &lastUsed},
+		"sha256:pulled":   {FirstSeen: now.AddDate(0, 0, -10)},
+		"sha256:dangling": {FirstSeen: now.AddDate(0, 0, -60)},
+	}
+
+	usage := BuildUsage(images, records, map[string]bool{"sha256:running": true}, now)
+	byID := make(map[string]models.ImageUsage)
+	for _, u := range usage {
+		byID[u.ImageID] = u
+	}
+
+	if byID["sha256:running"].UnusedDays != 0 || !byID["sha256:running"].InUse {
+		t.Errorf("Expected in-use image to have no unused days: %+v", byID["sha256:running"])
+	}
+	if byID["sha256:old"].UnusedDays != 40 {
+		t.Errorf("Expected unused days since last use, got %d", byID["sha256:old"].UnusedDays)
+	}
+	if byID["sha256:pulled"].UnusedDays != 10 {
+		t.Errorf("Expected never-run image to count from first seen, got %d", byID["sha256:pulled"].UnusedDays)
+	}
+	if !byID["sha256:dangling"].Dangling || len(byID["sha256:dangling"].RepoTags) != 0 {
+		t.Errorf("Expected untagged image to be dangling: %+v", byID["sha256:dangling"])
+	}
+	if byID["sha256:unknown"].UnusedDays != 0 || byID["sha256:unknown"].FirstSeen != nil {
+		t.Errorf("Expected image without a record to start counting now: %+v", byID["sha256:unknown"])
+	}
+	if usage[0].ImageID != "sha256:dangling" {
+		t.Errorf("Expected longest unused image first, got %s", usage[0].ImageID)
+	}
+}
+
+func TestSelectCandidates(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	image := func(id string, tag string, ageDays, unusedDays int) models.ImageUsage {
+		u := models.ImageUsage{ImageID: id, Created: base.AddDate(0, 0, -ageDays), UnusedDays: unusedDays}
+		if tag != "" {
+			u.RepoTags = []string{tag}
+		} else {
+			u.Dangling = true
+		}
+		return u
+	}
+
+	usage := []models.ImageUsage{
+		image("v4", "registry.local:5000/app:4", 1, 0),
+		image("v3", "registry.local:5000/app:3", 10, 50),
+		image("v2", "registry.local:5000/app:2", 20, 60),
+		image("v1", "registry.local:5000/app:1", 30, 10),
+		image("none", "", 40, 90),
+		image("recent-none", "", 5, 3),
+	}
+	usage[0].InUse = true
+
+	candidates := SelectCandidates(usage, models.ImagePrunePolicy{KeepTagsPerRepo: 2, MinUnusedDays: 30})
+
+	got := make(map[string]bool)
+	for _, c := range candidates {
+		got[c.ImageID] = true
+	}
+	// v4 and v3 are the newest two tags, v1 was used recently, recent-none is too new
+	if len(candidates) != 2 || !got["v2"] || !got["none"] {
+		t.Errorf("Expected v2 and the old dangling image to be pruned, got %+v", candidates)
+	}
+
+	// Without keep-N, every image unused long enough is selected
+	all := SelectCandidates(usage, models.ImagePrunePolicy{MinUnusedDays: 30})
+	if len(all) != 3 {
+		t.Errorf("Expected 3 candidates without tag retention, got %d", len(all))
+	}
+}
+
+func TestRepositories(t *testing.T) {
+	repos := repositories([]string{"registry.local:5000/app:1", "registry.local:5000/app:2", "nginx:latest", "registry.local:5000/untagged"})
+	want := []string{"registry.local:5000/app", "nginx", "registry.local:5000/untagged"}
+	if len(repos) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, repos)
+	}
+	for i := range want {
+		if repos[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, repos)
+		}
+	}
+}
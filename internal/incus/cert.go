@@ -0,0 +1,85 @@
+package incus
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Files of the client certificate in the directory given to LoadOrCreateCertificate
+const (
+	certFileName = "client.crt"
+	keyFileName  = "client.key"
+)
+
+// LoadOrCreateCertificate returns the client certificate stored in dir, creating a self-signed
+// one on first use. Incus servers trust it once it is added with
+// `incus config trust add-certificate client.crt`.
+func LoadOrCreateCertificate(dir string) (*tls.Certificate, error) {
+	certFile := filepath.Join(dir, certFileName)
+	keyFile := filepath.Join(dir, keyFileName)
+
+	if cert, err := tls.LoadX509KeyPair(certFile, keyFile); err == nil {
+		return &cert, nil
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to load incus client certificate: %w", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 127))
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "container-census", Organization: []string{"Container Census"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(10, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(certFile, CertificatePEM(&tls.Certificate{Certificate: [][]byte{der}}), 0644); err != nil {
+		return nil, err
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
+
+// CertificatePEM returns the PEM encoding of a certificate's leaf, to add to a trust store
+func CertificatePEM(cert *tls.Certificate) []byte {
+	if cert == nil || len(cert.Certificate) == 0 {
+		return nil
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]})
+}
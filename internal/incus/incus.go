@@ -0,0 +1,280 @@
+// Package incus is a minimal client for the REST API shared by Incus and LXD, used to inventory
+// their system containers alongside Docker containers. The server authenticates Census by a
+// client certificate that has to be added to the Incus trust store.
+package incus
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultPort is the port of the Incus HTTPS API
+const DefaultPort = "8443"
+
+// IsAddress reports whether a host address points at an Incus or LXD server, e.g.
+// incus://10.0.0.2:8443, lxd://host or incus:///var/lib/incus/unix.socket
+func IsAddress(address string) bool {
+	return strings.HasPrefix(address, "incus://") || strings.HasPrefix(address, "lxd://")
+}
+
+// Client talks to one Incus or LXD server
+type Client struct {
+	baseURL    string
+	project    string
+	httpClient *http.Client
+}
+
+// NewClient returns a client for an incus:// or lxd:// address. Remote servers are reached over
+// HTTPS with the client certificate; their own certificate is usually self-signed, so it is only
+// checked when the address pins its SHA-256 fingerprint with ?fingerprint=. A path instead of a
+// host is the server's local unix socket. ?project= selects a project other than the default.
+func NewClient(address string, cert *tls.Certificate, timeout time.Duration) (*Client, error) {
+	if !IsAddress(address) {
+		return nil, fmt.Errorf("not an incus address: %s", address)
+	}
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid incus address: %w", err)
+	}
+
+	c := &Client{project: u.Query().Get("project")}
+	transport := &http.Transport{}
+
+	if u.Host == "" {
+		if u.Path == "" {
+			return nil, fmt.Errorf("incus address needs a host or a socket path: %s", address)
+		}
+		socket := u.Path
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		}
+		c.baseURL = "http://incus"
+	} else {
+		host := u.Host
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), DefaultPort)
+		}
+		tlsConfig := &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			// Incus servers use self-signed certificates; see VerifyPeerCertificate
+			InsecureSkipVerify: true,
+		}
+		if cert != nil {
+			tlsConfig.Certificates = []tls.Certificate{*cert}
+		}
+		if pin := strings.ToLower(u.Query().Get("fingerprint")); pin != "" {
+			tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+				if len(rawCerts) == 0 || Fingerprint(rawCerts[0]) != pin {
+					return errors.New("incus server certificate does not match the pinned fingerprint")
+				}
+				return nil
+			}
+		}
+		transport.TLSClientConfig = tlsConfig
+		c.baseURL = "https://" + host
+	}
+
+	c.httpClient = &http.Client{Transport: transport, Timeout: timeout}
+	return c, nil
+}
+
+// Fingerprint returns the SHA-256 fingerprint of a DER certificate, as shown by
+// `incus config trust list` and `incus info`
+func Fingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
+
+// response is the envelope of every API response
+type response struct {
+	Type       string          `json:"type"` // sync, async or error
+	StatusCode int             `json:"status_code"`
+	Error      string          `json:"error"`
+	ErrorCode  int             `json:"error_code"`
+	Operation  string          `json:"operation"`
+	Metadata   json.RawMessage `json:"metadata"`
+}
+
+// Server describes the API server
+type Server struct {
+	Auth        string `json:"auth"` // trusted or untrusted
+	APIVersion  string `json:"api_version"`
+	Environment struct {
+		Server        string `json:"server"` // incus or lxd
+		ServerName    string `json:"server_name"`
+		ServerVersion string `json:"server_version"`
+	} `json:"environment"`
+}
+
+// Instance is an Incus container or virtual machine, as returned with recursion=2
+type Instance struct {
+	Name            string                       `json:"name"`
+	Description     string                       `json:"description"`
+	Status          string                       `json:"status"` // Running, Stopped, Frozen, Error
+	Type            string                       `json:"type"`   // container or virtual-machine
+	Project         string                       `json:"project"`
+	Location        string                       `json:"location"` // cluster member
+	CreatedAt       time.Time                    `json:"created_at"`
+	Config          map[string]string            `json:"config"`
+	ExpandedConfig  map[string]string            `json:"expanded_config"`
+	ExpandedDevices map[string]map[string]string `json:"expanded_devices"`
+	State           *InstanceState               `json:"state"`
+}
+
+// InstanceState is the runtime state of an instance
+type InstanceState struct {
+	Status string `json:"status"`
+	Pid    int64  `json:"pid"`
+	Memory struct {
+		Usage int64 `json:"usage"`
+		Total int64 `json:"total"`
+	} `json:"memory"`
+}
+
+// Server returns the API server's description. Its Auth field tells whether the client
+// certificate is trusted.
+func (c *Client) Server(ctx context.Context) (*Server, error) {
+	var server Server
+	if err := c.get(ctx, "/1.0", &server); err != nil {
+		return nil, err
+	}
+	return &server, nil
+}
+
+// Ping checks that the server is reachable and trusts the client certificate
+func (c *Client) Ping(ctx context.Context) error {
+	server, err := c.Server(ctx)
+	if err != nil {
+		return err
+	}
+	if server.Auth != "trusted" {
+		return errors.New("the Census client certificate is not trusted by this server; add it with `incus config trust add-certificate`")
+	}
+	return nil
+}
+
+// Instances returns all instances of the client's project, with their state
+func (c *Client) Instances(ctx context.Context) ([]Instance, error) {
+	var instances []Instance
+	if err := c.get(ctx, "/1.0/instances?recursion=2", &instances); err != nil {
+		return nil, err
+	}
+	return instances, nil
+}
+
+// Instance returns one instance with its state
+func (c *Client) Instance(ctx context.Context, name string) (*Instance, error) {
+	var instance Instance
+	if err := c.get(ctx, "/1.0/instances/"+url.PathEscape(name)+"?recursion=1", &instance); err != nil {
+		return nil, err
+	}
+	return &instance, nil
+}
+
+// ChangeState runs a state action (start, stop, restart, freeze or unfreeze) on an instance and
+// waits for it to finish. timeout is the seconds a clean shutdown may take.
+func (c *Client) ChangeState(ctx context.Context, name, action string, timeout int, force bool) error {
+	body := map[string]interface{}{"action": action, "timeout": timeout, "force": force}
+	resp, err := c.do(ctx, http.MethodPut, "/1.0/instances/"+url.PathEscape(name)+"/state", body)
+	if err != nil {
+		return err
+	}
+	if resp.Type != "async" || resp.Operation == "" {
+		return nil
+	}
+	return c.wait(ctx, resp.Operation)
+}
+
+// wait blocks until a background operation finishes
+func (c *Client) wait(ctx context.Context, operation string) error {
+	var op struct {
+		Status string `json:"status"` // Success, Failure or Cancelled
+		Err    string `json:"err"`
+	}
+	resp, err := c.do(ctx, http.MethodGet, operation+"/wait", nil)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(resp.Metadata, &op); err != nil {
+		return fmt.Errorf("invalid incus operation: %w", err)
+	}
+	if op.Status != "Success" {
+		if op.Err != "" {
+			return errors.New(op.Err)
+		}
+		return fmt.Errorf("incus operation %s", strings.ToLower(op.Status))
+	}
+	return nil
+}
+
+func (c *Client) get(ctx context.Context, path string, out interface{}) error {
+	resp, err := c.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(resp.Metadata, out); err != nil {
+		return fmt.Errorf("invalid incus response: %w", err)
+	}
+	return nil
+}
+
+// do sends a request and returns its response envelope, or the error it reports
+func (c *Client) do(ctx context.Context, method, path string, body interface{}) (*response, error) {
+	u, err := url.Parse(c.baseURL + path)
+	if err != nil {
+		return nil, err
+	}
+	if c.project != "" {
+		q := u.Query()
+		q.Set("project", c.project)
+		u.RawQuery = q.Encode()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpResp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to incus: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	var resp response
+	if err := json.NewDecoder(io.LimitReader(httpResp.Body, 32<<20)).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("invalid incus response (HTTP %d): %w", httpResp.StatusCode, err)
+	}
+	if resp.Type == "error" || httpResp.StatusCode >= 400 {
+		if resp.Error == "" {
+			resp.Error = http.StatusText(httpResp.StatusCode)
+		}
+		return nil, fmt.Errorf("incus returned status %d: %s", httpResp.StatusCode, resp.Error)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,158 @@
+package incus
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeServer is an Incus API that trusts clients presenting a certificate
+func fakeServer(t *testing.T) (*httptest.Server, *[]string) {
+	var actions []string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("project") != "lab" {
+			t.Errorf("Expected the project on %s", r.URL)
+		}
+		sync := func(metadata interface{}) {
+			json.NewEncoder(w).Encode(map[string]interface{}{"type": "sync", "status_code": 200, "metadata": metadata})
+		}
+
+		switch {
+		case r.URL.Path == "/1.0":
+			auth := "untrusted"
+			if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+				auth = "trusted"
+			}
+			sync(map[string]interface{}{"auth": auth, "api_version": "1.0"})
+		case r.URL.Path == "/1.0/instances" && r.URL.Query().Get("recursion") == "2":
+			sync([]map[string]interface{}{
+				{"name": "web", "status": "Running", "type": "container", "state": map[string]interface{}{"memory": map[string]int64{"usage": 1024}}},
+				{"name": "win", "status": "Stopped", "type": "virtual-machine"},
+			})
+		case r.URL.Path == "/1.0/instances/web/state" && r.Method == http.MethodPut:
+			var body struct {
+				Action string `json:"action"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			actions = append(actions, body.Action)
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(map[string]interface{}{"type": "async", "status_code": 100, "operation": "/1.0/operations/op-" + body.Action})
+		case r.URL.Path == "/1.0/operations/op-stop/wait":
+			sync(map[string]string{"status": "Success"})
+		case r.URL.Path == "/1.0/operations/op-start/wait":
+			sync(map[string]string{"status": "Failure", "err": "Failed to start: no such network"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{"type": "error", "error": "not found", "error_code": 404})
+		}
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	return server, &actions
+}
+
+func TestClient(t *testing.T) {
+	server, actions := fakeServer(t)
+	defer server.Close()
+
+	cert, err := LoadOrCreateCertificate(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := "incus://" + strings.TrimPrefix(server.URL, "https://") + "?project=lab"
+	ctx := context.Background()
+
+	// Without the certificate the server doesn't trust Census
+	anonymous, err := NewClient(address, nil, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := anonymous.Ping(ctx); err == nil || !strings.Contains(err.Error(), "not trusted") {
+		t.Errorf("Expected an untrusted error, got %v", err)
+	}
+
+	c, err := NewClient(address, cert, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Ping(ctx); err != nil {
+		t.Fatalf("Expected a trusted client, got %v", err)
+	}
+
+	instances, err := c.Instances(ctx)
+	if err != nil || len(instances) != 2 || instances[0].State == nil || instances[0].State.Memory.Usage != 1024 {
+		t.Fatalf("Unexpected instances %+v (%v)", instances, err)
+	}
+
+	if err := c.ChangeState(ctx, "web", "stop", 30, false); err != nil {
+		t.Errorf("Expected the stop to succeed, got %v", err)
+	}
+	if err := c.ChangeState(ctx, "web", "start", 0, false); err == nil || !strings.Contains(err.Error(), "no such network") {
+		t.Errorf("Expected the failed operation's error, got %v", err)
+	}
+	if len(*actions) != 2 {
+		t.Errorf("Expected two state changes, got %v", *actions)
+	}
+
+	if _, err := c.Instance(ctx, "missing"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+}
+
+func TestFingerprintPin(t *testing.T) {
+	server, _ := fakeServer(t)
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "https://")
+	pinned := "incus://" + host + "?project=lab&fingerprint=" + url.QueryEscape(Fingerprint(server.Certificate().Raw))
+	c, _ := NewClient(pinned, nil, 5*time.Second)
+	if _, err := c.Server(context.Background()); err != nil {
+		t.Errorf("Expected the pinned certificate to be accepted, got %v", err)
+	}
+
+	wrong := "incus://" + host + "?project=lab&fingerprint=" + strings.Repeat("0", 64)
+	c, _ = NewClient(wrong, nil, 5*time.Second)
+	if _, err := c.Server(context.Background()); err == nil {
+		t.Error("Expected a certificate that doesn't match the pin to be rejected")
+	}
+}
+
+func TestNewClientAddresses(t *testing.T) {
+	for address, want := range map[string]string{
+		"incus://10.0.0.2":                   "https://10.0.0.2:8443",
+		"lxd://[fd00::2]:9443":               "https://[fd00::2]:9443",
+		"incus:///var/lib/incus/unix.socket": "http://incus",
+	} {
+		c, err := NewClient(address, nil, time.Second)
+		if err != nil || c.baseURL != want {
+			t.Errorf("NewClient(%q) base URL = %v (%v), want %s", address, c, err, want)
+		}
+	}
+	if _, err := NewClient("incus://", nil, time.Second); err == nil {
+		t.Error("Expected an address without host or socket to be rejected")
+	}
+}
+
+func TestLoadOrCreateCertificate(t *testing.T) {
+	dir := t.TempDir()
+	first, err := LoadOrCreateCertificate(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := LoadOrCreateCertificate(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Fingerprint(first.Certificate[0]) != Fingerprint(second.Certificate[0]) {
+		t.Error("Expected the stored certificate to be reused")
+	}
+	if !strings.HasPrefix(string(CertificatePEM(first)), "-----BEGIN CERTIFICATE-----") {
+		t.Error("Expected a PEM certificate")
+	}
+}
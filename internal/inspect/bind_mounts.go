@@ -0,0 +1,121 @@
+package inspect
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// MountedContainer is a container of a host's latest scan with the mounts of its configuration
+type MountedContainer struct {
+	HostID        int64
+	HostName      string
+	ContainerID   string
+	ContainerName string
+	State         string
+	Mounts        []models.ConfigMount
+}
+
+// BuildBindMountReport indexes the bind mount sources of the given containers per host. A path a
+// running container writes to overlaps when another running container writes to it too, or to a
+// path above or below it; containers that only read a path don't count. The Docker socket is flagged as sensitive but not as an overlap, since sharing it is
+// how it's meant to be used.
+func BuildBindMountReport(containers []MountedContainer) models.BindMountReport {
+	type pathKey struct {
+		hostID int64
+		source string
+	}
+	paths := make(map[pathKey]*models.BindMountPath)
+	for _, c := range containers {
+		for _, m := range c.Mounts {
+			if m.Type != "bind" || m.Source == "" {
+				continue
+			}
+			source := path.Clean(m.Source)
+			key := pathKey{c.HostID, source}
+			p, ok := paths[key]
+			if !ok {
+				p = &models.BindMountPath{HostID: c.HostID, HostName: c.HostName, Source: source, Mounts: []models.BindMountUse{}}
+				if strings.HasSuffix(source, "/docker.sock") {
+					p.Sensitive = models.BindMountDockerSocket
+				} else {
+					p.Sensitive = SensitiveMountPath(source)
+				}
+				paths[key] = p
+			}
+			p.Mounts = append(p.Mounts, models.BindMountUse{
+				ContainerID:   c.ContainerID,
+				ContainerName: c.ContainerName,
+				State:         c.State,
+				Destination:   m.Destination,
+				RW:            m.RW,
+			})
+		}
+	}
+
+	// Running containers writing to each host's paths
+	writers := make(map[int64]map[string][]string) // host -> source -> container names
+	for key, p := range paths {
+		if p.Sensitive == models.BindMountDockerSocket {
+			continue
+		}
+		for _, m := range p.Mounts {
+			if m.RW && m.State == "running" {
+				if writers[key.hostID] == nil {
+					writers[key.hostID] = make(map[string][]string)
+				}
+				writers[key.hostID][key.source] = append(writers[key.hostID][key.source], m.ContainerName)
+			}
+		}
+	}
+
+	report := models.BindMountReport{GeneratedAt: time.Now(), Paths: make([]models.BindMountPath, 0, len(paths))}
+	for key, p := range paths {
+		if own := writers[key.hostID][key.source]; len(own) > 0 {
+			names := make(map[string]bool)
+			for source, containers := range writers[key.hostID] {
+				if pathsNest(source, key.source) {
+					for _, name := range containers {
+						names[name] = true
+					}
+				}
+			}
+			if len(names) > 1 {
+				for name := range names {
+					p.Writers = append(p.Writers, name)
+				}
+				sort.Strings(p.Writers)
+				p.Overlap = true
+				report.Overlaps++
+			}
+		}
+		if p.Sensitive != "" {
+			report.Sensitive++
+		}
+		sort.Slice(p.Mounts, func(i, j int) bool { return p.Mounts[i].ContainerName < p.Mounts[j].ContainerName })
+		report.Paths = append(report.Paths, *p)
+	}
+
+	sort.Slice(report.Paths, func(i, j int) bool {
+		a, b := report.Paths[i], report.Paths[j]
+		if flaggedA, flaggedB := a.Overlap || a.Sensitive != "", b.Overlap || b.Sensitive != ""; flaggedA != flaggedB {
+			return flaggedA
+		}
+		if a.HostName != b.HostName {
+			return a.HostName < b.HostName
+		}
+		return a.Source < b.Source
+	})
+	return report
+}
+
+// pathsNest reports whether two clean paths are the same or one is below the other
+func pathsNest(a, b string) bool {
+	if a == b || a == "/" || b == "/" {
+		return true
+	}
+	return strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/")
+}
@@ -0,0 +1,85 @@
+package inspect
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestBuildBindMountReport(t *testing.T) {
+	bind := func(source, destination string, rw bool) models.ConfigMount {
+		return models.ConfigMount{Type: "bind", Source: source, Destination: destination, RW: rw}
+	}
+	containers := []MountedContainer{
+		{HostID: 1, HostName: "nas", ContainerName: "sonarr", State: "running", Mounts: []models.ConfigMount{
+			bind("/srv/media", "/media", true),
+			bind("/srv/config/sonarr", "/config", true),
+			bind("/var/run/docker.sock", "/var/run/docker.sock", false),
+		}},
+		{HostID: 1, HostName: "nas", ContainerName: "radarr", State: "running", Mounts: []models.ConfigMount{
+			bind("/srv/media/movies/", "/movies", true),
+			bind("/srv/config/radarr", "/config", true),
+		}},
+		{HostID: 1, HostName: "nas", ContainerName: "plex", State: "running", Mounts: []models.ConfigMount{
+			bind("/srv/media", "/data", false),
+			{Type: "volume", Source: "/var/lib/docker/volumes/plex/_data", Destination: "/config", RW: true},
+		}},
+		{HostID: 1, HostName: "nas", ContainerName: "portainer", State: "running", Mounts: []models.ConfigMount{
+			bind("/var/run/docker.sock", "/var/run/docker.sock", true),
+		}},
+		{HostID: 1, HostName: "nas", ContainerName: "old-sonarr", State: "exited", Mounts: []models.ConfigMount{
+			bind("/srv/config/sonarr", "/config", true),
+		}},
+		{HostID: 2, HostName: "vps", ContainerName: "node-exporter", State: "running", Mounts: []models.ConfigMount{
+			bind("/", "/host", false),
+			bind("/srv/media", "/media", true),
+		}},
+	}
+
+	report := BuildBindMountReport(containers)
+	byPath := make(map[string]models.BindMountPath)
+	for _, p := range report.Paths {
+		byPath[p.HostName+":"+p.Source] = p
+	}
+	if len(report.Paths) != 7 {
+		t.Fatalf("expected 7 paths, got %d: %+v", len(report.Paths), report.Paths)
+	}
+
+	media := byPath["nas:/srv/media"]
+	if !media.Overlap || !reflect.DeepEqual(media.Writers, []string{"radarr", "sonarr"}) || len(media.Mounts) != 2 {
+		t.Errorf("expected sonarr and radarr to overlap on /srv/media, got %+v", media)
+	}
+	if movies := byPath["nas:/srv/media/movies"]; !movies.Overlap {
+		t.Errorf("expected the nested path to overlap, got %+v", movies)
+	}
+	if config := byPath["nas:/srv/config/sonarr"]; config.Overlap {
+		t.Errorf("expected no overlap with a stopped container, got %+v", config)
+	}
+	if vps := byPath["vps:/srv/media"]; vps.Overlap {
+		t.Errorf("expected hosts not to overlap each other, got %+v", vps)
+	}
+
+	socket := byPath["nas:/var/run/docker.sock"]
+	if socket.Sensitive != models.BindMountDockerSocket || socket.Overlap {
+		t.Errorf("expected the socket to be sensitive without overlap, got %+v", socket)
+	}
+	if root := byPath["vps:/"]; root.Sensitive != models.BindMountHostRoot {
+		t.Errorf("expected the host root to be sensitive, got %+v", root)
+	}
+	if report.Overlaps != 2 || report.Sensitive != 2 {
+		t.Errorf("expected 2 overlaps and 2 sensitive paths, got %d and %d", report.Overlaps, report.Sensitive)
+	}
+
+	// Flagged paths come first
+	for i, p := range report.Paths {
+		if !p.Overlap && p.Sensitive == "" {
+			for _, rest := range report.Paths[i:] {
+				if rest.Overlap || rest.Sensitive != "" {
+					t.Fatalf("expected flagged paths first, got %+v", report.Paths)
+				}
+			}
+			break
+		}
+	}
+}
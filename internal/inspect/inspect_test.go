@@ -0,0 +1,118 @@
+package inspect
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+)
+
+func TestSanitizeEnv(t *testing.T) {
+	vars := SanitizeEnv([]string{
+		"POSTGRES_PASSWORD=hunter2",
+		"TZ=Europe/Berlin",
+		"DATABASE_URL=postgres://app:s3cret@db:5432/app",
+		"API_KEY=abc123",
+		"EMPTY_TOKEN=",
+		"HOME_URL=https://example.com/path",
+	})
+
+	want := map[string]struct {
+		value  string
+		masked bool
+	}{
+		"POSTGRES_PASSWORD": {MaskedValue, true},
+		"TZ":                {"Europe/Berlin", false},
+		"DATABASE_URL":      {"postgres://app:" + MaskedValue + "@db:5432/app", true},
+		"API_KEY":           {MaskedValue, true},
+		"EMPTY_TOKEN":       {"", false},
+		"HOME_URL":          {"https://example.com/path", false},
+	}
+
+	if len(vars) != len(want) {
+		t.Fatalf("Expected %d vars, got %d", len(want), len(vars))
+	}
+	for _, v := range vars {
+		w, ok := want[v.Name]
+		if !ok {
+			t.Errorf("Unexpected var %s", v.Name)
+			continue
+		}
+		if v.Value != w.value || v.Masked != w.masked {
+			t.Errorf("%s: expected %q (masked=%v), got %q (masked=%v)", v.Name, w.value, w.masked, v.Value, v.Masked)
+		}
+	}
+	if vars[0].Name != "API_KEY" {
+		t.Errorf("Expected vars sorted by name, got %s first", vars[0].Name)
+	}
+}
+
+func TestSanitizeArgs(t *testing.T) {
+	got := SanitizeArgs([]string{"server", "--db-password=hunter2", "--port=8080", "--upstream=redis://:pw@cache:6379"})
+	want := []string{"server", "--db-password=" + MaskedValue, "--port=8080", "--upstream=redis://:" + MaskedValue + "@cache:6379"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if SanitizeArgs(nil) != nil {
+		t.Error("Expected nil for no args")
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	resp := container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			HostConfig: &container.HostConfig{
+				RestartPolicy: container.RestartPolicy{Name: container.RestartPolicyOnFailure, MaximumRetryCount: 3},
+				NetworkMode:   "bridge",
+				Privileged:    true,
+			},
+			State: &container.State{
+				ExitCode:   2,
+				StartedAt:  "2024-05-01T02:00:00.123456789Z",
+				FinishedAt: "0001-01-01T00:00:00Z",
+			},
+		},
+		Mounts: []container.MountPoint{
+			{Type: mount.TypeBind, Source: "/srv/data", Destination: "/data", Mode: "rw", RW: true},
+		},
+		Config: &container.Config{
+			Env:        []string{"SECRET_KEY=x"},
+			Entrypoint: []string{"/entrypoint.sh"},
+			Cmd:        []string{"run"},
+			User:       "1000",
+		},
+		NetworkSettings: &container.NetworkSettings{
+			Networks: map[string]*network.EndpointSettings{
+				"web":     {IPAddress: "172.18.0.2", Aliases: []string{"app"}},
+				"backend": {IPAddress: "172.19.0.2"},
+			},
+		},
+	}
+
+	cfg := Sanitize(resp)
+	if cfg.RestartPolicy != "on-failure" || cfg.RestartMaxRetries != 3 || cfg.NetworkMode != "bridge" || !cfg.Privileged {
+		t.Errorf("Unexpected host config: %+v", cfg)
+	}
+	if len(cfg.Mounts) != 1 || cfg.Mounts[0].Source != "/srv/data" || !cfg.Mounts[0].RW {
+		t.Errorf("Unexpected mounts: %+v", cfg.Mounts)
+	}
+	if len(cfg.Env) != 1 || !cfg.Env[0].Masked {
+		t.Errorf("Expected masked env, got %+v", cfg.Env)
+	}
+	if len(cfg.Networks) != 2 || cfg.Networks[0].Name != "backend" || cfg.Networks[1].Aliases[0] != "app" {
+		t.Errorf("Unexpected networks: %+v", cfg.Networks)
+	}
+	if cfg.User != "1000" || cfg.Entrypoint[0] != "/entrypoint.sh" || cfg.Cmd[0] != "run" {
+		t.Errorf("Unexpected process config: %+v", cfg)
+	}
+	if cfg.ExitCode != 2 || cfg.StartedAt.Hour() != 2 || !cfg.FinishedAt.IsZero() {
+		t.Errorf("Unexpected state: exit %d, started %v, finished %v", cfg.ExitCode, cfg.StartedAt, cfg.FinishedAt)
+	}
+
+	// A sparse response must not panic
+	if empty := Sanitize(container.InspectResponse{}); len(empty.Env) != 0 {
+		t.Errorf("Expected empty config, got %+v", empty)
+	}
+}
@@ -0,0 +1,77 @@
+package inspect
+
+import (
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/docker/docker/api/types/image"
+)
+
+// ImageLayers combines an image's inspect response and build history into a layer breakdown,
+// oldest step first. Non-empty steps are matched to the image's RootFS diff IDs so layers can be
+// compared between image versions.
+func ImageLayers(info image.InspectResponse, history []image.HistoryResponseItem) *models.ImageLayers {
+	result := &models.ImageLayers{
+		ImageID:     info.ID,
+		RepoTags:    info.RepoTags,
+		Size:        info.Size,
+		Layers:      make([]models.ImageLayer, 0, len(history)),
+		CollectedAt: time.Now(),
+	}
+	if result.RepoTags == nil {
+		result.RepoTags = []string{}
+	}
+
+	// Docker returns history newest first
+	for i := len(history) - 1; i >= 0; i-- {
+		item := history[i]
+		result.Layers = append(result.Layers, models.ImageLayer{
+			CreatedBy: maskURLCredentials(item.CreatedBy),
+			Created:   time.Unix(item.Created, 0),
+			Size:      item.Size,
+			Comment:   item.Comment,
+			Tags:      item.Tags,
+			Empty:     item.Size == 0,
+		})
+	}
+
+	// History only reports sizes, so diff IDs can only be attached when the number of
+	// non-empty steps lines up with the filesystem layers
+	var diffIDs []string
+	if info.RootFS.Type == "layers" {
+		diffIDs = info.RootFS.Layers
+	}
+	nonEmpty := 0
+	for _, layer := range result.Layers {
+		if !layer.Empty {
+			nonEmpty++
+		}
+	}
+	if nonEmpty == len(diffIDs) {
+		next := 0
+		for i := range result.Layers {
+			if !result.Layers[i].Empty {
+				result.Layers[i].DiffID = diffIDs[next]
+				next++
+			}
+		}
+	}
+
+	return result
+}
+
+// MarkSharedLayers flags the layers of layers that also exist in other, so the remaining
+// non-empty layers are the ones that changed between the two images
+func MarkSharedLayers(layers, other *models.ImageLayers) {
+	known := make(map[string]bool, len(other.Layers))
+	for _, layer := range other.Layers {
+		if layer.DiffID != "" {
+			known[layer.DiffID] = true
+		}
+	}
+
+	for i := range layers.Layers {
+		layers.Layers[i].Shared = layers.Layers[i].DiffID != "" && known[layers.Layers[i].DiffID]
+	}
+	layers.ComparedTo = other.ImageID
+}
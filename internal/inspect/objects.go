@@ -0,0 +1,145 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/build"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+)
+
+// ObjectClient is the part of the Docker client the object inventory uses
+type ObjectClient interface {
+	PluginList(ctx context.Context, filter filters.Args) (types.PluginsListResponse, error)
+	SecretList(ctx context.Context, options swarm.SecretListOptions) ([]swarm.Secret, error)
+	ConfigList(ctx context.Context, options swarm.ConfigListOptions) ([]swarm.Config, error)
+	DiskUsage(ctx context.Context, options types.DiskUsageOptions) (types.DiskUsage, error)
+}
+
+// DockerObjects lists the plugins, Swarm secrets and configs, and builder cache of a Docker
+// engine. Engines that aren't Swarm managers have no secrets or configs; that isn't an error.
+func DockerObjects(ctx context.Context, c ObjectClient) ([]models.DockerObject, error) {
+	plugins, err := c.PluginList(ctx, filters.NewArgs())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list plugins: %w", err)
+	}
+	objects := Plugins(plugins)
+
+	secrets, err := c.SecretList(ctx, swarm.SecretListOptions{})
+	if err != nil && !isNotSwarmManager(err) {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+	objects = append(objects, Secrets(secrets)...)
+
+	configs, err := c.ConfigList(ctx, swarm.ConfigListOptions{})
+	if err != nil && !isNotSwarmManager(err) {
+		return nil, fmt.Errorf("failed to list configs: %w", err)
+	}
+	objects = append(objects, Configs(configs)...)
+
+	usage, err := c.DiskUsage(ctx, types.DiskUsageOptions{Types: []types.DiskUsageObject{types.BuildCacheObject}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get build cache usage: %w", err)
+	}
+	return append(objects, BuildCache(usage.BuildCache)...), nil
+}
+
+// isNotSwarmManager reports the error the engine returns for Swarm calls outside a Swarm manager
+func isNotSwarmManager(err error) bool {
+	return strings.Contains(err.Error(), "not a swarm manager")
+}
+
+// Plugins converts installed plugins to inventory objects
+func Plugins(plugins []*types.Plugin) []models.DockerObject {
+	objects := make([]models.DockerObject, 0, len(plugins))
+	for _, p := range plugins {
+		objects = append(objects, models.DockerObject{
+			Kind:     models.DockerObjectPlugin,
+			ObjectID: p.ID,
+			Name:     p.Name,
+			Detail:   p.PluginReference,
+			InUse:    p.Enabled,
+		})
+	}
+	return objects
+}
+
+// Secrets converts Swarm secrets to inventory objects; their data is never returned by the engine
+func Secrets(secrets []swarm.Secret) []models.DockerObject {
+	objects := make([]models.DockerObject, 0, len(secrets))
+	for _, s := range secrets {
+		object := models.DockerObject{
+			Kind:      models.DockerObjectSecret,
+			ObjectID:  s.ID,
+			Name:      s.Spec.Name,
+			CreatedAt: timePtr(s.CreatedAt),
+		}
+		if s.Spec.Driver != nil {
+			object.Detail = s.Spec.Driver.Name
+		}
+		objects = append(objects, object)
+	}
+	return objects
+}
+
+// Configs converts Swarm configs to inventory objects
+func Configs(configs []swarm.Config) []models.DockerObject {
+	objects := make([]models.DockerObject, 0, len(configs))
+	for _, c := range configs {
+		object := models.DockerObject{
+			Kind:      models.DockerObjectConfig,
+			ObjectID:  c.ID,
+			Name:      c.Spec.Name,
+			CreatedAt: timePtr(c.CreatedAt),
+		}
+		if c.Spec.Templating != nil {
+			object.Detail = c.Spec.Templating.Name
+		}
+		objects = append(objects, object)
+	}
+	return objects
+}
+
+// BuildCache sums up builder cache records per cache type. Records in use or shared with images
+// aren't reclaimable, as with `docker builder prune`.
+func BuildCache(records []*build.CacheRecord) []models.DockerObject {
+	byType := make(map[string]*models.DockerObject)
+	for _, r := range records {
+		object, ok := byType[r.Type]
+		if !ok {
+			object = &models.DockerObject{Kind: models.DockerObjectBuildCache, ObjectID: r.Type, Name: r.Type}
+			byType[r.Type] = object
+		}
+		object.Count++
+		object.SizeBytes += r.Size
+		if r.InUse {
+			object.InUse = true
+		} else if !r.Shared {
+			object.ReclaimableBytes += r.Size
+		}
+		if r.LastUsedAt != nil && (object.LastUsedAt == nil || r.LastUsedAt.After(*object.LastUsedAt)) {
+			lastUsed := *r.LastUsedAt
+			object.LastUsedAt = &lastUsed
+		}
+	}
+
+	objects := make([]models.DockerObject, 0, len(byType))
+	for _, object := range byType {
+		objects = append(objects, *object)
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].SizeBytes > objects[j].SizeBytes })
+	return objects
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
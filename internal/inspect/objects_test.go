@@ -0,0 +1,88 @@
+package inspect
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/build"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+)
+
+type fakeObjectClient struct {
+	plugins  types.PluginsListResponse
+	swarmErr error
+	secrets  []swarm.Secret
+	cache    []*build.CacheRecord
+}
+
+func (f fakeObjectClient) PluginList(context.Context, filters.Args) (types.PluginsListResponse, error) {
+	return f.plugins, nil
+}
+
+func (f fakeObjectClient) SecretList(context.Context, swarm.SecretListOptions) ([]swarm.Secret, error) {
+	return f.secrets, f.swarmErr
+}
+
+func (f fakeObjectClient) ConfigList(context.Context, swarm.ConfigListOptions) ([]swarm.Config, error) {
+	return nil, f.swarmErr
+}
+
+func (f fakeObjectClient) DiskUsage(context.Context, types.DiskUsageOptions) (types.DiskUsage, error) {
+	return types.DiskUsage{BuildCache: f.cache}, nil
+}
+
+func TestBuildCache(t *testing.T) {
+	older := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+	objects := BuildCache([]*build.CacheRecord{
+		{Type: "regular", Size: 100, LastUsedAt: &older},
+		{Type: "regular", Size: 50, InUse: true, LastUsedAt: &newer},
+		{Type: "regular", Size: 30, Shared: true},
+		{Type: "exec.cachemount", Size: 500},
+	})
+	if len(objects) != 2 {
+		t.Fatalf("expected 2 cache types, got %+v", objects)
+	}
+	if objects[0].Name != "exec.cachemount" || objects[0].SizeBytes != 500 || objects[0].ReclaimableBytes != 500 {
+		t.Errorf("expected the cache mount first with 500 reclaimable bytes, got %+v", objects[0])
+	}
+	regular := objects[1]
+	if regular.Count != 3 || regular.SizeBytes != 180 || regular.ReclaimableBytes != 100 || !regular.InUse {
+		t.Errorf("unexpected regular cache %+v", regular)
+	}
+	if regular.LastUsedAt == nil || !regular.LastUsedAt.Equal(newer) {
+		t.Errorf("expected the latest use, got %v", regular.LastUsedAt)
+	}
+}
+
+func TestDockerObjects(t *testing.T) {
+	client := fakeObjectClient{
+		plugins:  types.PluginsListResponse{{ID: "p1", Name: "vieux/sshfs:latest", PluginReference: "docker.io/vieux/sshfs:latest", Enabled: true}},
+		swarmErr: errors.New(`Error response from daemon: This node is not a swarm manager. Use "docker swarm init" or "docker swarm join" to connect this node to swarm and try again.`),
+		cache:    []*build.CacheRecord{{Type: "regular", Size: 10}},
+	}
+	objects, err := DockerObjects(context.Background(), client)
+	if err != nil {
+		t.Fatalf("expected no error outside a swarm, got %v", err)
+	}
+	if len(objects) != 2 || objects[0].Kind != models.DockerObjectPlugin || !objects[0].InUse || objects[1].Kind != models.DockerObjectBuildCache {
+		t.Errorf("expected the plugin and the build cache, got %+v", objects)
+	}
+
+	client.swarmErr = nil
+	client.secrets = []swarm.Secret{{ID: "s1", Spec: swarm.SecretSpec{Annotations: swarm.Annotations{Name: "db_password"}}}}
+	objects, err = DockerObjects(context.Background(), client)
+	if err != nil || len(objects) != 3 || objects[1].Kind != models.DockerObjectSecret || objects[1].Name != "db_password" {
+		t.Errorf("expected the secret to be listed, got %+v (%v)", objects, err)
+	}
+
+	client.swarmErr = errors.New("connection refused")
+	if _, err := DockerObjects(context.Background(), client); err == nil {
+		t.Error("expected other secret errors to fail the inventory")
+	}
+}
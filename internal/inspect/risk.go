@@ -0,0 +1,137 @@
+package inspect
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// capabilityWeights scores added capabilities that widen a container's reach into the host.
+// Capabilities not listed here are scored with defaultCapabilityWeight.
+var capabilityWeights = map[string]int{
+	"ALL":             60,
+	"SYS_ADMIN":       40,
+	"SYS_MODULE":      40,
+	"SYS_RAWIO":       30,
+	"SYS_PTRACE":      20,
+	"DAC_READ_SEARCH": 20,
+	"NET_ADMIN":       15,
+	"SYS_TIME":        10,
+}
+
+const defaultCapabilityWeight = 5
+
+// sensitivePaths are host paths whose bind mounts expose host configuration, credentials or devices
+var sensitivePaths = []string{"/etc", "/root", "/home", "/boot", "/dev", "/proc", "/sys", "/var/lib/docker"}
+
+// AssessRisk scores the security-relevant settings of a container configuration
+func AssessRisk(cfg *models.ContainerConfig) models.SecurityRisk {
+	risk := models.SecurityRisk{Findings: make([]models.RiskFinding, 0)}
+	if cfg == nil {
+		risk.Level = models.RiskLevelNone
+		return risk
+	}
+
+	add := func(id string, weight int, description string) {
+		risk.Findings = append(risk.Findings, models.RiskFinding{ID: id, Description: description, Weight: weight})
+		risk.Score += weight
+	}
+
+	if cfg.Privileged {
+		add("privileged", 60, "Runs privileged with access to all host devices")
+	}
+	if cfg.NetworkMode == "host" {
+		add("host_network", 15, "Shares the host network namespace")
+	}
+	if cfg.PidMode == "host" {
+		add("host_pid", 25, "Shares the host PID namespace")
+	}
+
+	for _, capability := range cfg.CapAdd {
+		name := normalizeCapability(capability)
+		weight, ok := capabilityWeights[name]
+		if !ok {
+			weight = defaultCapabilityWeight
+		}
+		add("cap_"+strings.ToLower(name), weight, "Adds capability "+name)
+	}
+
+	for _, m := range cfg.Mounts {
+		if m.Type != "bind" {
+			continue
+		}
+		// A read-only socket still allows full use of the Docker API
+		if strings.HasSuffix(m.Source, "/docker.sock") {
+			add("docker_socket", 60, fmt.Sprintf("Mounts the Docker socket at %s", m.Destination))
+			continue
+		}
+		if path := SensitiveMountPath(m.Source); path != "" {
+			weight := 10
+			if path == "/" {
+				weight = 25
+			}
+			access := "read-only"
+			if m.RW {
+				weight *= 2
+				access = "read-write"
+			}
+			add("host_mount", weight, fmt.Sprintf("Mounts host path %s %s", m.Source, access))
+		}
+	}
+
+	if risk.Score > 100 {
+		risk.Score = 100
+	}
+	risk.Level = riskLevel(risk.Score)
+	return risk
+}
+
+// SensitiveMountPath returns the sensitive host path a bind mount source exposes, or ""
+func SensitiveMountPath(source string) string {
+	if source == "/" {
+		return "/"
+	}
+	for _, path := range sensitivePaths {
+		if source == path || strings.HasPrefix(source, path+"/") {
+			return path
+		}
+	}
+	return ""
+}
+
+// normalizeCapability converts "cap_sys_admin" and "SYS_ADMIN" to the same name
+func normalizeCapability(capability string) string {
+	return strings.TrimPrefix(strings.ToUpper(capability), "CAP_")
+}
+
+// riskLevels maps the minimum score of each level, most severe first
+var riskLevels = []struct {
+	level    string
+	minScore int
+}{
+	{models.RiskLevelCritical, 60},
+	{models.RiskLevelHigh, 40},
+	{models.RiskLevelMedium, 20},
+	{models.RiskLevelLow, 1},
+	{models.RiskLevelNone, 0},
+}
+
+func riskLevel(score int) string {
+	for _, l := range riskLevels {
+		if score >= l.minScore {
+			return l.level
+		}
+	}
+	return models.RiskLevelNone
+}
+
+// RiskLevelMinScore returns the lowest score of a risk level
+func RiskLevelMinScore(level string) (int, bool) {
+	for _, l := range riskLevels {
+		if l.level == level {
+			return l.minScore, true
+		}
+	}
+	return 0, false
+}
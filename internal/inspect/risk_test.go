@@ -0,0 +1,89 @@
+package inspect
+
+import (
+	"testing"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestAssessRiskUnprivileged(t *testing.T) {
+	risk := AssessRisk(&models.ContainerConfig{
+		NetworkMode: "bridge",
+		Mounts: []models.ConfigMount{
+			{Type: "volume", Source: "/var/lib/docker/volumes/data/_data", Destination: "/data", RW: true},
+			{Type: "bind", Source: "/srv/app/config", Destination: "/config", RW: true},
+		},
+	})
+
+	if risk.Score != 0 || risk.Level != models.RiskLevelNone || len(risk.Findings) != 0 {
+		t.Errorf("Expected no risk, got %+v", risk)
+	}
+
+	if risk := AssessRisk(nil); risk.Level != models.RiskLevelNone {
+		t.Errorf("Expected no risk without a config, got %+v", risk)
+	}
+}
+
+func TestAssessRiskFindings(t *testing.T) {
+	risk := AssessRisk(&models.ContainerConfig{
+		NetworkMode: "host",
+		PidMode:     "host",
+		CapAdd:      []string{"cap_net_admin", "CHOWN"},
+		Mounts: []models.ConfigMount{
+			{Type: "bind", Source: "/etc/ssl", Destination: "/etc/ssl", RW: false},
+		},
+	})
+
+	for _, id := range []string{"host_network", "host_pid", "cap_net_admin", "cap_chown", "host_mount"} {
+		if !risk.HasFinding(id) {
+			t.Errorf("Expected finding %s, got %+v", id, risk.Findings)
+		}
+	}
+	// 15 host network + 25 host pid + 15 NET_ADMIN + 5 CHOWN + 10 read-only /etc
+	if risk.Score != 70 || risk.Level != models.RiskLevelCritical {
+		t.Errorf("Expected score 70 (critical), got %d (%s)", risk.Score, risk.Level)
+	}
+}
+
+func TestAssessRiskPrivilegedCapped(t *testing.T) {
+	risk := AssessRisk(&models.ContainerConfig{
+		Privileged: true,
+		CapAdd:     []string{"ALL"},
+		Mounts: []models.ConfigMount{
+			{Type: "bind", Source: "/var/run/docker.sock", Destination: "/var/run/docker.sock", RW: false},
+			{Type: "bind", Source: "/", Destination: "/host", RW: true},
+		},
+	})
+
+	if risk.Score != 100 {
+		t.Errorf("Expected score to be capped at 100, got %d", risk.Score)
+	}
+	if !risk.HasFinding("privileged") || !risk.HasFinding("docker_socket") || !risk.HasFinding("cap_all") {
+		t.Errorf("Missing findings: %+v", risk.Findings)
+	}
+}
+
+func TestRiskLevel(t *testing.T) {
+	tests := map[int]string{
+		0:   models.RiskLevelNone,
+		5:   models.RiskLevelLow,
+		20:  models.RiskLevelMedium,
+		40:  models.RiskLevelHigh,
+		60:  models.RiskLevelCritical,
+		100: models.RiskLevelCritical,
+	}
+	for score, want := range tests {
+		if got := riskLevel(score); got != want {
+			t.Errorf("riskLevel(%d) = %s, want %s", score, got, want)
+		}
+	}
+}
+
+func TestRiskLevelMinScore(t *testing.T) {
+	if score, ok := RiskLevelMinScore(models.RiskLevelHigh); !ok || riskLevel(score) != models.RiskLevelHigh || riskLevel(score-1) == models.RiskLevelHigh {
+		t.Errorf("Expected %d to be the lowest high score", score)
+	}
+	if _, ok := RiskLevelMinScore("severe"); ok {
+		t.Error("Expected unknown level to be rejected")
+	}
+}
@@ -0,0 +1,359 @@
+package inspect
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/container-census/container-census/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// ignoredSpecLabels are label prefixes left out of generated run specs: compose sets its own
+// when the service is brought up, and image labels come back with the image
+var ignoredSpecLabels = []string{"com.docker.compose.", "org.opencontainers.image.", "desktop.docker.io/"}
+
+// shellSafe matches words that need no quoting in a POSIX shell
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_./:=@%+,-]+$`)
+
+// defaultNetworks are the network modes Docker attaches a container to without being asked
+var defaultNetworks = map[string]bool{"": true, "default": true, "bridge": true}
+
+// RunCommand returns the `docker run` command that recreates a container from its scan row and
+// stored configuration. Masked environment values are passed as `-e NAME`, which takes the value
+// from the shell the command runs in. Networks beyond the first are joined with
+// `docker network connect` on the following lines.
+func RunCommand(c models.Container, cfg models.ContainerConfig) string {
+	args := []string{"docker", "run", "-d", "--name", c.Name}
+
+	if policy := restartPolicy(cfg); policy != "" {
+		args = append(args, "--restart", policy)
+	}
+	networks := SpecNetworks(cfg)
+	if mode := specNetworkMode(cfg); mode != "" {
+		args = append(args, "--network", mode)
+	} else if len(networks) > 0 {
+		// the network the container was created with comes first, the others are connected after
+		for i, n := range networks {
+			if n == cfg.NetworkMode {
+				networks[0], networks[i] = networks[i], networks[0]
+			}
+		}
+		args = append(args, "--network", networks[0])
+	}
+	if h := specHostname(c, cfg); h != "" {
+		args = append(args, "--hostname", h)
+	}
+	if cfg.User != "" {
+		args = append(args, "--user", cfg.User)
+	}
+	if cfg.WorkingDir != "" {
+		args = append(args, "--workdir", cfg.WorkingDir)
+	}
+	if cfg.Privileged {
+		args = append(args, "--privileged")
+	}
+	if cfg.PidMode != "" {
+		args = append(args, "--pid", cfg.PidMode)
+	}
+	for _, capability := range cfg.CapAdd {
+		args = append(args, "--cap-add", capability)
+	}
+	for _, capability := range cfg.CapDrop {
+		args = append(args, "--cap-drop", capability)
+	}
+	for _, p := range SpecPorts(c) {
+		args = append(args, "-p", p)
+	}
+	for _, m := range cfg.Mounts {
+		if m.Type == "tmpfs" {
+			args = append(args, "--tmpfs", m.Destination)
+			continue
+		}
+		if v := specVolume(c, m); v != "" {
+			args = append(args, "-v", v)
+		}
+	}
+	for _, e := range cfg.Env {
+		if e.Masked {
+			args = append(args, "-e", e.Name)
+		} else {
+			args = append(args, "-e", e.Name+"="+e.Value)
+		}
+	}
+	for _, l := range specLabels(c) {
+		args = append(args, "--label", l+"="+c.Labels[l])
+	}
+
+	entrypoint, cmd := cfg.Entrypoint, cfg.Cmd
+	if len(entrypoint) > 0 {
+		// --entrypoint takes a single executable; its arguments go in front of the command
+		args = append(args, "--entrypoint", entrypoint[0])
+		cmd = append(append([]string{}, entrypoint[1:]...), cmd...)
+	}
+	args = append(args, SpecImage(c))
+	args = append(args, cmd...)
+
+	lines := []string{joinShell(args)}
+	if specNetworkMode(cfg) == "" {
+		for _, n := range networks[min(1, len(networks)):] {
+			lines = append(lines, joinShell([]string{"docker", "network", "connect", n, c.Name}))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// composeFile is the part of a compose file a generated service needs
+type composeFile struct {
+	Services map[string]composeService  `yaml:"services"`
+	Networks map[string]composeExternal `yaml:"networks,omitempty"`
+	Volumes  map[string]composeExternal `yaml:"volumes,omitempty"`
+}
+
+type composeService struct {
+	Image         string            `yaml:"image"`
+	ContainerName string            `yaml:"container_name"`
+	Restart       string            `yaml:"restart,omitempty"`
+	NetworkMode   string            `yaml:"network_mode,omitempty"`
+	Hostname      string            `yaml:"hostname,omitempty"`
+	User          string            `yaml:"user,omitempty"`
+	WorkingDir    string            `yaml:"working_dir,omitempty"`
+	Privileged    bool              `yaml:"privileged,omitempty"`
+	Pid           string            `yaml:"pid,omitempty"`
+	CapAdd        []string          `yaml:"cap_add,omitempty"`
+	CapDrop       []string          `yaml:"cap_drop,omitempty"`
+	Ports         []string          `yaml:"ports,omitempty"`
+	Volumes       []string          `yaml:"volumes,omitempty"`
+	Tmpfs         []string          `yaml:"tmpfs,omitempty"`
+	Environment   []string          `yaml:"environment,omitempty"`
+	Labels        map[string]string `yaml:"labels,omitempty"`
+	Networks      []string          `yaml:"networks,omitempty"`
+	Entrypoint    []string          `yaml:"entrypoint,omitempty"`
+	Command       []string          `yaml:"command,omitempty"`
+}
+
+type composeExternal struct {
+	External bool `yaml:"external"`
+}
+
+// ComposeService returns a compose file with one service recreating the container. Its named
+// volumes and networks are declared external, since a migrated container keeps using the ones
+// it had; masked environment values are listed by name only, taking them from the shell.
+func ComposeService(c models.Container, cfg models.ContainerConfig) (string, error) {
+	svc := composeService{
+		Image:         SpecImage(c),
+		ContainerName: c.Name,
+		Restart:       restartPolicy(cfg),
+		NetworkMode:   specNetworkMode(cfg),
+		Hostname:      specHostname(c, cfg),
+		User:          cfg.User,
+		WorkingDir:    cfg.WorkingDir,
+		Privileged:    cfg.Privileged,
+		Pid:           cfg.PidMode,
+		CapAdd:        cfg.CapAdd,
+		CapDrop:       cfg.CapDrop,
+		Ports:         SpecPorts(c),
+		Entrypoint:    cfg.Entrypoint,
+		Command:       cfg.Cmd,
+	}
+	file := composeFile{Services: map[string]composeService{}}
+
+	for _, m := range cfg.Mounts {
+		if m.Type == "tmpfs" {
+			svc.Tmpfs = append(svc.Tmpfs, m.Destination)
+			continue
+		}
+		v := specVolume(c, m)
+		if v == "" {
+			continue
+		}
+		svc.Volumes = append(svc.Volumes, v)
+		if m.Type == "volume" {
+			if file.Volumes == nil {
+				file.Volumes = map[string]composeExternal{}
+			}
+			file.Volumes[strings.SplitN(v, ":", 2)[0]] = composeExternal{External: true}
+		}
+	}
+	for _, e := range cfg.Env {
+		if e.Masked {
+			svc.Environment = append(svc.Environment, e.Name)
+		} else {
+			svc.Environment = append(svc.Environment, e.Name+"="+e.Value)
+		}
+	}
+	for _, l := range specLabels(c) {
+		if svc.Labels == nil {
+			svc.Labels = map[string]string{}
+		}
+		svc.Labels[l] = c.Labels[l]
+	}
+	if svc.NetworkMode == "" {
+		svc.Networks = SpecNetworks(cfg)
+		for _, n := range svc.Networks {
+			if file.Networks == nil {
+				file.Networks = map[string]composeExternal{}
+			}
+			file.Networks[n] = composeExternal{External: true}
+		}
+	}
+
+	file.Services[composeServiceName(c)] = svc
+	out, err := yaml.Marshal(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode compose service: %w", err)
+	}
+	return string(out), nil
+}
+
+// SpecImage is the image reference a container is recreated from: the name it was started
+// with, or its first tag when it was started from an image ID
+func SpecImage(c models.Container) string {
+	if strings.HasPrefix(c.Image, "sha256:") && len(c.ImageTags) > 0 {
+		return c.ImageTags[0]
+	}
+	return c.Image
+}
+
+// SpecPorts returns a container's published ports in `-p` form ([ip:]public:private[/udp]),
+// with the IPv6 twin of each IPv4 binding left out
+func SpecPorts(c models.Container) []string {
+	seen := map[string]bool{}
+	var ports []string
+	for _, p := range c.Ports {
+		if p.PublicPort == 0 {
+			continue
+		}
+		spec := strconv.Itoa(p.PublicPort) + ":" + strconv.Itoa(p.PrivatePort)
+		if p.IP != "" && p.IP != "0.0.0.0" && p.IP != "::" {
+			host := p.IP
+			if strings.Contains(host, ":") {
+				host = "[" + host + "]"
+			}
+			spec = host + ":" + spec
+		}
+		if p.Type != "" && p.Type != "tcp" {
+			spec += "/" + p.Type
+		}
+		if !seen[spec] {
+			seen[spec] = true
+			ports = append(ports, spec)
+		}
+	}
+	return ports
+}
+
+// SpecNetworks returns the user-defined networks a container is attached to, sorted; empty for
+// the default bridge and for host, none and container network modes
+func SpecNetworks(cfg models.ContainerConfig) []string {
+	if specNetworkMode(cfg) != "" {
+		return nil
+	}
+	var networks []string
+	for _, n := range cfg.Networks {
+		if !defaultNetworks[n.Name] {
+			networks = append(networks, n.Name)
+		}
+	}
+	sort.Strings(networks)
+	return networks
+}
+
+// specNetworkMode returns the network mode to pass on when it isn't a plain network attachment
+func specNetworkMode(cfg models.ContainerConfig) string {
+	mode := cfg.NetworkMode
+	if mode == "host" || mode == "none" || strings.HasPrefix(mode, "container:") || strings.HasPrefix(mode, "service:") {
+		return mode
+	}
+	return ""
+}
+
+// specHostname returns the configured hostname unless it is the one Docker generates from the ID
+func specHostname(c models.Container, cfg models.ContainerConfig) string {
+	if cfg.Hostname == "" || strings.HasPrefix(c.ID, cfg.Hostname) || specNetworkMode(cfg) != "" {
+		return ""
+	}
+	return cfg.Hostname
+}
+
+func restartPolicy(cfg models.ContainerConfig) string {
+	switch cfg.RestartPolicy {
+	case "", "no":
+		return ""
+	case "on-failure":
+		if cfg.RestartMaxRetries > 0 {
+			return "on-failure:" + strconv.Itoa(cfg.RestartMaxRetries)
+		}
+	}
+	return cfg.RestartPolicy
+}
+
+// specVolume returns a mount in `-v` form: the named volume (from the scan) or host path, the
+// destination, and :ro for read-only mounts
+func specVolume(c models.Container, m models.ConfigMount) string {
+	source := m.Source
+	if m.Type == "volume" {
+		source = ""
+		for _, v := range c.Volumes {
+			if v.Destination == m.Destination && v.Type == "volume" {
+				source = v.Name
+			}
+		}
+		if source == "" {
+			// an anonymous volume the scan didn't name; recreating it gets a fresh one
+			return m.Destination
+		}
+	}
+	if source == "" {
+		return ""
+	}
+	spec := source + ":" + m.Destination
+	if !m.RW {
+		spec += ":ro"
+	}
+	return spec
+}
+
+func specLabels(c models.Container) []string {
+	var labels []string
+	for l := range c.Labels {
+		ignored := false
+		for _, prefix := range ignoredSpecLabels {
+			if strings.HasPrefix(l, prefix) {
+				ignored = true
+				break
+			}
+		}
+		if !ignored {
+			labels = append(labels, l)
+		}
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+// composeServiceName is the compose service a container came from, or its own name
+func composeServiceName(c models.Container) string {
+	if s := c.Labels["com.docker.compose.service"]; s != "" {
+		return s
+	}
+	return c.Name
+}
+
+// ShellQuote quotes a word for a POSIX shell when it needs it
+func ShellQuote(word string) string {
+	if shellSafe.MatchString(word) {
+		return word
+	}
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+}
+
+func joinShell(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = ShellQuote(a)
+	}
+	return strings.Join(quoted, " ")
+}
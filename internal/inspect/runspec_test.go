@@ -0,0 +1,118 @@
+package inspect
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func runSpecContainer() (models.Container, models.ContainerConfig) {
+	c := models.Container{
+		ID:    "3f2a9c1b7d4e5f60718293a4b5c6d7e8",
+		Name:  "nextcloud",
+		Image: "nextcloud:29",
+		Ports: []models.PortMapping{
+			{PrivatePort: 80, PublicPort: 8080, Type: "tcp", IP: "0.0.0.0"},
+			{PrivatePort: 80, PublicPort: 8080, Type: "tcp", IP: "::"},
+			{PrivatePort: 3478, PublicPort: 3478, Type: "udp", IP: "127.0.0.1"},
+			{PrivatePort: 9000, Type: "tcp"},
+		},
+		Labels: map[string]string{
+			"com.docker.compose.project":      "cloud",
+			"com.docker.compose.service":      "app",
+			"org.opencontainers.image.source": "https://github.com/nextcloud/docker",
+			"traefik.enable":                  "true",
+		},
+		Volumes: []models.VolumeMount{
+			{Name: "nextcloud_data", Destination: "/var/www/html", Type: "volume", RW: true},
+			{Name: "/srv/photos", Destination: "/photos", Type: "bind"},
+		},
+	}
+	cfg := models.ContainerConfig{
+		Env: []models.EnvVar{
+			{Name: "TZ", Value: "Europe/Berlin"},
+			{Name: "POSTGRES_PASSWORD", Value: MaskedValue, Masked: true},
+			{Name: "GREETING", Value: "it's me"},
+		},
+		Mounts: []models.ConfigMount{
+			{Type: "volume", Source: "/var/lib/docker/volumes/nextcloud_data/_data", Destination: "/var/www/html", RW: true},
+			{Type: "bind", Source: "/srv/photos", Destination: "/photos", RW: false},
+			{Type: "tmpfs", Destination: "/tmp"},
+		},
+		RestartPolicy: "unless-stopped",
+		NetworkMode:   "cloud_default",
+		Networks:      []models.ConfigNetwork{{Name: "proxy"}, {Name: "cloud_default"}},
+		Hostname:      "3f2a9c1b7d4e",
+		Cmd:           []string{"apache2-foreground"},
+	}
+	return c, cfg
+}
+
+func TestRunCommand(t *testing.T) {
+	c, cfg := runSpecContainer()
+
+	got := RunCommand(c, cfg)
+	want := "docker run -d --name nextcloud --restart unless-stopped --network cloud_default " +
+		"-p 8080:80 -p 127.0.0.1:3478:3478/udp " +
+		"-v nextcloud_data:/var/www/html -v /srv/photos:/photos:ro --tmpfs /tmp " +
+		"-e TZ=Europe/Berlin -e POSTGRES_PASSWORD -e 'GREETING=it'\\''s me' " +
+		"--label traefik.enable=true nextcloud:29 apache2-foreground\n" +
+		"docker network connect proxy nextcloud"
+	if got != want {
+		t.Errorf("RunCommand() =\n%s\nwant\n%s", got, want)
+	}
+
+	cfg.NetworkMode = "host"
+	cfg.Entrypoint = []string{"/entrypoint.sh", "--verbose"}
+	cfg.RestartPolicy = "on-failure"
+	cfg.RestartMaxRetries = 3
+	got = RunCommand(c, cfg)
+	if !strings.Contains(got, "--restart on-failure:3 --network host ") || strings.Contains(got, "network connect") {
+		t.Errorf("Expected host networking without extra networks, got %s", got)
+	}
+	if !strings.HasSuffix(got, "--entrypoint /entrypoint.sh nextcloud:29 --verbose apache2-foreground") {
+		t.Errorf("Expected the entrypoint's arguments before the command, got %s", got)
+	}
+}
+
+func TestComposeService(t *testing.T) {
+	c, cfg := runSpecContainer()
+
+	got, err := ComposeService(c, cfg)
+	if err != nil {
+		t.Fatalf("ComposeService failed: %v", err)
+	}
+	for _, want := range []string{
+		"services:\n    app:\n        image: nextcloud:29\n        container_name: nextcloud\n        restart: unless-stopped\n",
+		"- 127.0.0.1:3478:3478/udp",
+		"- /srv/photos:/photos:ro",
+		"- POSTGRES_PASSWORD\n",
+		"traefik.enable: \"true\"",
+		"networks:\n            - cloud_default\n            - proxy\n",
+		"volumes:\n    nextcloud_data:\n        external: true\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in the compose file:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "hostname") || strings.Contains(got, "com.docker.compose") {
+		t.Errorf("Expected the generated hostname and compose labels left out:\n%s", got)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"nginx:latest":   "nginx:latest",
+		"A=b":            "A=b",
+		"hello world":    "'hello world'",
+		"it's":           `'it'\''s'`,
+		"$HOME":          "'$HOME'",
+		"/srv/data path": "'/srv/data path'",
+	}
+	for in, want := range tests {
+		if got := ShellQuote(in); got != want {
+			t.Errorf("ShellQuote(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
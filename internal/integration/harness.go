@@ -0,0 +1,237 @@
+// Package integration runs throwaway Docker containers, and an in-process agent on the same
+// daemon, for end-to-end tests of the scanner, storage and API against a real Docker daemon.
+//
+// Tests using it carry the integration build tag and run with
+//
+//	go test -tags integration ./...
+//
+// They are skipped when no daemon is reachable (DOCKER_HOST or the platform's default socket),
+// unless CENSUS_INTEGRATION=required, as in CI, where that is a failure.
+package integration
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/agent"
+	"github.com/container-census/container-census/internal/models"
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	imagetypes "github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
+)
+
+const (
+	// DefaultImage is the image containers run unless CENSUS_INTEGRATION_IMAGE overrides it
+	DefaultImage = "busybox:1.36"
+	// RunLabel marks the containers of a harness with its run ID, for cleanup
+	RunLabel = "io.container-census.integration"
+
+	setupTimeout = 2 * time.Minute
+)
+
+// Harness is a connection to a real Docker daemon whose containers are removed when the test ends
+type Harness struct {
+	t          testing.TB
+	client     *client.Client
+	dockerHost string
+	image      string
+	runID      string
+}
+
+// Container is a container started by a harness
+type Container struct {
+	ID   string
+	Name string
+}
+
+// ContainerOptions configure Run. Image defaults to the harness image, Cmd to sleeping forever.
+type ContainerOptions struct {
+	Name   string // suffix of the container name, which starts with census-it-<run ID>-
+	Image  string
+	Cmd    []string
+	Env    []string
+	Labels map[string]string
+	Stop   bool // leave the container exited instead of running
+}
+
+// New connects to the Docker daemon and pulls the test image. The test is skipped when the
+// daemon isn't reachable, unless CENSUS_INTEGRATION=required.
+func New(t testing.TB) *Harness {
+	t.Helper()
+	dockerHost := agent.DefaultDockerHost()
+	cli, err := client.NewClientWithOpts(client.WithHost(dockerHost), client.WithAPIVersionNegotiation())
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, err = cli.Ping(ctx)
+		cancel()
+	}
+	if err != nil {
+		if os.Getenv("CENSUS_INTEGRATION") == "required" {
+			t.Fatalf("Docker isn't reachable at %s: %v", dockerHost, err)
+		}
+		t.Skipf("Docker isn't reachable at %s: %v", dockerHost, err)
+	}
+
+	h := &Harness{t: t, client: cli, dockerHost: dockerHost, image: DefaultImage, runID: randomID()}
+	if image := os.Getenv("CENSUS_INTEGRATION_IMAGE"); image != "" {
+		h.image = image
+	}
+	t.Cleanup(h.cleanup)
+
+	ctx, cancel := context.WithTimeout(context.Background(), setupTimeout)
+	defer cancel()
+	if _, err := cli.ImageInspect(ctx, h.image); err != nil {
+		reader, err := cli.ImagePull(ctx, h.image, imagetypes.PullOptions{})
+		if err != nil {
+			t.Fatalf("Failed to pull %s: %v", h.image, err)
+		}
+		io.Copy(io.Discard, reader)
+		reader.Close()
+	}
+	return h
+}
+
+// Client returns the harness' Docker client, for checking what a test did
+func (h *Harness) Client() *client.Client {
+	return h.client
+}
+
+// Image returns the image containers run by default
+func (h *Harness) Image() string {
+	return h.image
+}
+
+// Host returns a direct Docker host for the daemon, as the scanner connects to it
+func (h *Harness) Host() models.Host {
+	address := h.dockerHost
+	if !strings.HasPrefix(address, "unix://") && !strings.HasPrefix(address, "tcp://") {
+		address = "local"
+	}
+	return models.Host{Name: "integration", Address: address, HostType: models.HostTypeUnix, Enabled: true, CollectStats: true}
+}
+
+// Run starts a container and waits until it runs (or has exited with Stop)
+func (h *Harness) Run(opts ContainerOptions) Container {
+	h.t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), setupTimeout)
+	defer cancel()
+
+	image := opts.Image
+	if image == "" {
+		image = h.image
+	}
+	cmd := opts.Cmd
+	if cmd == nil {
+		cmd = []string{"sleep", "3600"}
+	}
+	labels := map[string]string{RunLabel: h.runID}
+	for k, v := range opts.Labels {
+		labels[k] = v
+	}
+	name := "census-it-" + h.runID
+	if opts.Name != "" {
+		name += "-" + opts.Name
+	}
+
+	created, err := h.client.ContainerCreate(ctx, &containertypes.Config{
+		Image:  image,
+		Cmd:    cmd,
+		Env:    opts.Env,
+		Labels: labels,
+	}, nil, nil, nil, name)
+	if err != nil {
+		h.t.Fatalf("Failed to create container %s: %v", name, err)
+	}
+	c := Container{ID: created.ID, Name: name}
+	if err := h.client.ContainerStart(ctx, c.ID, containertypes.StartOptions{}); err != nil {
+		h.t.Fatalf("Failed to start container %s: %v", name, err)
+	}
+	if opts.Stop {
+		h.Stop(c)
+	}
+	return c
+}
+
+// Stop stops a container right away
+func (h *Harness) Stop(c Container) {
+	h.t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), setupTimeout)
+	defer cancel()
+	timeout := 0
+	if err := h.client.ContainerStop(ctx, c.ID, containertypes.StopOptions{Timeout: &timeout}); err != nil {
+		h.t.Fatalf("Failed to stop container %s: %v", c.Name, err)
+	}
+}
+
+// State returns a container's state as Docker reports it (running, exited, ...)
+func (h *Harness) State(c Container) string {
+	h.t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	inspect, err := h.client.ContainerInspect(ctx, c.ID)
+	if err != nil {
+		h.t.Fatalf("Failed to inspect container %s: %v", c.Name, err)
+	}
+	return inspect.State.Status
+}
+
+// StartAgent serves an in-process agent on the daemon over HTTP and returns it as an agent host.
+// It allows the given permissions (all when none are given).
+func (h *Harness) StartAgent(token string, permissions ...string) models.Host {
+	h.t.Helper()
+	if len(permissions) == 0 {
+		permissions = models.AgentPermissions
+	}
+	a, err := agent.New(h.dockerHost, token, agent.Info{
+		Version:     "integration",
+		Hostname:    "integration-agent",
+		StartedAt:   time.Now(),
+		Permissions: permissions,
+	})
+	if err != nil {
+		h.t.Fatalf("Failed to create agent: %v", err)
+	}
+	server := httptest.NewServer(a.Router())
+	h.t.Cleanup(server.Close)
+	return models.Host{Name: "integration-agent", Address: server.URL, HostType: models.HostTypeAgent, AgentToken: token, Enabled: true, CollectStats: true}
+}
+
+// Owns reports whether a scanned container was started by this harness, so tests ignore the
+// other containers on the daemon
+func (h *Harness) Owns(c models.Container) bool {
+	return strings.HasPrefix(c.Name, "census-it-"+h.runID)
+}
+
+// cleanup removes the harness' containers
+func (h *Harness) cleanup() {
+	ctx, cancel := context.WithTimeout(context.Background(), setupTimeout)
+	defer cancel()
+	containers, err := h.client.ContainerList(ctx, containertypes.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", RunLabel+"="+h.runID)),
+	})
+	if err != nil {
+		h.t.Logf("Failed to list the test containers for removal: %v", err)
+	}
+	for _, c := range containers {
+		if err := h.client.ContainerRemove(ctx, c.ID, containertypes.RemoveOptions{Force: true, RemoveVolumes: true}); err != nil {
+			h.t.Logf("Failed to remove test container %s: %v", c.ID, err)
+		}
+	}
+	h.client.Close()
+}
+
+// randomID returns 8 random hex characters, so parallel runs on one daemon don't collide
+func randomID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
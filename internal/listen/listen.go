@@ -0,0 +1,162 @@
+// Package listen opens the listeners the server and agent serve on: TCP addresses (IPv4 and
+// IPv6 can be bound side by side), unix sockets, and sockets passed by systemd socket activation.
+package listen
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Systemd is the address that stands for the sockets passed by systemd socket activation
+const Systemd = "systemd"
+
+// unixPrefix marks a unix socket address, e.g. unix:/run/census/census.sock
+const unixPrefix = "unix:"
+
+// listenFDsStart is the first file descriptor passed by systemd (SD_LISTEN_FDS_START)
+var listenFDsStart = 3
+
+// ParseList splits a comma-separated list of addresses
+func ParseList(value string) []string {
+	var addrs []string
+	for _, addr := range strings.Split(value, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// Listen opens a listener for each address: host:port (an IPv6 literal such as [::]:8080 only
+// binds IPv6, so 0.0.0.0:8080 can be bound alongside it), unix:/path/to.sock or "systemd".
+// Already opened listeners are closed if one fails.
+func Listen(addrs []string) ([]net.Listener, error) {
+	var listeners []net.Listener
+	fail := func(err error) ([]net.Listener, error) {
+		for _, l := range listeners {
+			l.Close()
+		}
+		return nil, err
+	}
+
+	for _, addr := range addrs {
+		switch {
+		case addr == Systemd:
+			activated, err := Activated()
+			if err != nil {
+				return fail(err)
+			}
+			if len(activated) == 0 {
+				return fail(errors.New("no sockets passed by systemd (LISTEN_FDS not set)"))
+			}
+			listeners = append(listeners, activated...)
+		case strings.HasPrefix(addr, unixPrefix):
+			l, err := listenUnix(strings.TrimPrefix(addr, unixPrefix))
+			if err != nil {
+				return fail(err)
+			}
+			listeners = append(listeners, l)
+		default:
+			l, err := net.Listen(tcpNetwork(addr), addr)
+			if err != nil {
+				return fail(err)
+			}
+			listeners = append(listeners, l)
+		}
+	}
+	return listeners, nil
+}
+
+// tcpNetwork picks tcp4 or tcp6 for IP literals so that both families can listen on the same port
+func tcpNetwork(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "tcp"
+	}
+	ip := net.ParseIP(host)
+	switch {
+	case ip == nil:
+		return "tcp"
+	case ip.To4() != nil:
+		return "tcp4"
+	default:
+		return "tcp6"
+	}
+}
+
+// listenUnix listens on a unix socket, replacing a stale socket file left by a previous run
+func listenUnix(path string) (net.Listener, error) {
+	if path == "" {
+		return nil, errors.New("unix socket path is empty")
+	}
+	if info, err := os.Stat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is in use", path)
+		}
+		os.Remove(path)
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	// Owner and group only; add the users that may call the API to the group
+	if err := os.Chmod(path, 0660); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Activated returns the listening sockets passed by systemd socket activation, if any
+func Activated() ([]net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	// The sockets are for this process only, not for children
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	listeners := make([]net.Listener, 0, count)
+	for i := 0; i < count; i++ {
+		name := "LISTEN_FD_" + strconv.Itoa(listenFDsStart+i)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		f := os.NewFile(uintptr(listenFDsStart+i), name)
+		l, err := net.FileListener(f)
+		f.Close() // FileListener works on a duplicate
+		if err != nil {
+			for _, opened := range listeners {
+				opened.Close()
+			}
+			return nil, fmt.Errorf("socket %s from systemd: %w", name, err)
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
+// URL returns how a listener is reached, for log messages
+func URL(l net.Listener, scheme string) string {
+	if l.Addr().Network() == "unix" {
+		return "unix:" + l.Addr().String()
+	}
+	return scheme + "://" + l.Addr().String()
+}
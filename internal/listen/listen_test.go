@@ -0,0 +1,136 @@
+package listen
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestParseList(t *testing.T) {
+	got := ParseList(" 0.0.0.0:8080, [::]:8080,,unix:/run/census.sock ")
+	if len(got) != 3 || got[1] != "[::]:8080" || got[2] != "unix:/run/census.sock" {
+		t.Errorf("Unexpected addresses: %q", got)
+	}
+}
+
+func TestListenDualStack(t *testing.T) {
+	v4, err := Listen([]string{"127.0.0.1:0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer v4[0].Close()
+	port := strconv.Itoa(v4[0].Addr().(*net.TCPAddr).Port)
+
+	// IPv6 binds the same port without taking over IPv4
+	v6, err := Listen([]string{"[::1]:" + port})
+	if err != nil {
+		t.Skipf("IPv6 not available: %v", err)
+	}
+	defer v6[0].Close()
+	if v6[0].Addr().Network() != "tcp" || v6[0].Addr().(*net.TCPAddr).IP.To4() != nil {
+		t.Errorf("Expected an IPv6 listener, got %v", v6[0].Addr())
+	}
+}
+
+func TestListenFailureClosesOthers(t *testing.T) {
+	taken, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer taken.Close()
+
+	free, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	freeAddr := free.Addr().String()
+	free.Close()
+
+	if _, err := Listen([]string{freeAddr, taken.Addr().String()}); err == nil {
+		t.Fatal("Expected listening on a taken port to fail")
+	}
+	// The first listener was closed again
+	l, err := net.Listen("tcp4", freeAddr)
+	if err != nil {
+		t.Errorf("Expected %s to be released: %v", freeAddr, err)
+	} else {
+		l.Close()
+	}
+}
+
+func TestListenUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "census.sock")
+
+	listeners, err := Listen([]string{"unix:" + path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0660 {
+		t.Errorf("Expected a 0660 socket, got %v (%v)", info.Mode(), err)
+	}
+	if URL(listeners[0], "http") != "unix:"+path {
+		t.Errorf("Unexpected URL %s", URL(listeners[0], "http"))
+	}
+
+	// A socket in use is not replaced
+	if _, err := Listen([]string{"unix:" + path}); err == nil {
+		t.Error("Expected a socket in use to be refused")
+	}
+
+	// A stale socket from a previous run is
+	listeners[0].(*net.UnixListener).SetUnlinkOnClose(false)
+	listeners[0].Close()
+	listeners, err = Listen([]string{"unix:" + path})
+	if err != nil {
+		t.Fatalf("Expected a stale socket to be replaced: %v", err)
+	}
+	listeners[0].Close()
+
+	notSocket := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(notSocket, nil, 0600)
+	if _, err := Listen([]string{"unix:" + notSocket}); err == nil {
+		t.Error("Expected a regular file not to be replaced")
+	}
+}
+
+func TestActivated(t *testing.T) {
+	if listeners, err := Activated(); err != nil || listeners != nil {
+		t.Fatalf("Expected no sockets without LISTEN_FDS, got %v (%v)", listeners, err)
+	}
+
+	// Pass a socket the way systemd would
+	l, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	f, err := l.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	defer func(start int) { listenFDsStart = start }(listenFDsStart)
+	listenFDsStart = int(f.Fd())
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "1")
+	t.Setenv("LISTEN_FDNAMES", "census-agent.socket")
+
+	listeners, err := Listen([]string{Systemd})
+	if err != nil || len(listeners) != 1 {
+		t.Fatalf("Expected the passed socket, got %v (%v)", listeners, err)
+	}
+	defer listeners[0].Close()
+	if listeners[0].Addr().String() != l.Addr().String() {
+		t.Errorf("Expected %s, got %s", l.Addr(), listeners[0].Addr())
+	}
+	if os.Getenv("LISTEN_FDS") != "" {
+		t.Error("Expected the activation variables to be cleared")
+	}
+
+	if _, err := Listen([]string{Systemd}); err == nil {
+		t.Error("Expected an error once the sockets are taken")
+	}
+}
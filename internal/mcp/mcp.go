@@ -0,0 +1,480 @@
+// Package mcp answers Model Context Protocol requests so AI assistants can query census data.
+// It implements the JSON-RPC methods of the MCP "streamable HTTP" transport that a tools-only
+// server needs (initialize, ping, tools/list, tools/call) and answers every request with a
+// single JSON response.
+//
+// Tools only read through Store, which has no methods that change data.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/version"
+	"github.com/container-census/container-census/internal/vulnerability"
+)
+
+// ProtocolVersion is the MCP revision this server implements
+const ProtocolVersion = "2025-06-18"
+
+// Limits on tool arguments
+const (
+	DefaultHours = 24
+	MaxHours     = 24 * 30
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Store is the read-only part of the storage layer the tools use
+type Store interface {
+	GetHosts() ([]models.Host, error)
+	GetLatestContainers() ([]models.Container, error)
+	GetContainersWithUpdates() ([]models.Container, error)
+	GetChangesReport(start, end time.Time, hostFilter int64) (*models.ChangesReport, error)
+	GetContainerLifecycleEvents(containerName string, hostID int64) ([]models.ContainerLifecycleEvent, error)
+	GetTopConsumers(windowHours, limit int, hostFilter int64) (*models.TopConsumersReport, error)
+	GetVulnerabilitySummary() (*vulnerability.ScanSummary, error)
+	GetNotificationLogs(limit int, unreadOnly bool) ([]models.NotificationLog, error)
+}
+
+// Request is a JSON-RPC request or notification (no ID)
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// Response is a JSON-RPC response
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is a JSON-RPC error
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Tool describes a tool in tools/list
+type Tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// Content is a block of a tool result; tools return their data as JSON text
+type Content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// ToolResult is the result of tools/call. Tool failures are results with IsError set,
+// so the assistant can read the message, rather than JSON-RPC errors.
+type ToolResult struct {
+	Content []Content `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+// args are the arguments of a tools/call; every tool uses a subset
+type args struct {
+	Host            string `json:"host"`
+	Container       string `json:"container"`
+	State           string `json:"state"`
+	Hours           int    `json:"hours"`
+	Limit           int    `json:"limit"`
+	UpdateAvailable bool   `json:"update_available"`
+	UnreadOnly      bool   `json:"unread_only"`
+}
+
+type tool struct {
+	Tool
+	call func(ctx context.Context, s *Server, a args) (interface{}, error)
+}
+
+// Server answers MCP requests from a store
+type Server struct {
+	store Store
+	now   func() time.Time
+}
+
+// NewServer returns a server for the store
+func NewServer(store Store) *Server {
+	return &Server{store: store, now: time.Now}
+}
+
+// Handle answers one JSON-RPC message. It returns nil for notifications, which get no response.
+func (s *Server) Handle(ctx context.Context, body []byte) *Response {
+	var req Request
+	if err := json.Unmarshal(body, &req); err != nil {
+		return errorResponse(nil, codeParseError, "Parse error: "+err.Error())
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return errorResponse(req.ID, codeInvalidRequest, "Invalid JSON-RPC 2.0 request")
+	}
+	if len(req.ID) == 0 {
+		// Notifications such as notifications/initialized need no action
+		return nil
+	}
+
+	switch req.Method {
+	case "initialize":
+		return result(req.ID, map[string]interface{}{
+			"protocolVersion": ProtocolVersion,
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo":      map[string]string{"name": "container-census", "version": version.Get()},
+			"instructions": "Read-only access to the Container Census inventory: hosts, containers, " +
+				"changes over time (new, removed, updated and restarted containers), resource usage, " +
+				"available image updates, vulnerabilities and notifications.",
+		})
+	case "ping":
+		return result(req.ID, map[string]interface{}{})
+	case "tools/list":
+		list := make([]Tool, 0, len(tools))
+		for _, t := range tools {
+			list = append(list, t.Tool)
+		}
+		return result(req.ID, map[string]interface{}{"tools": list})
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return errorResponse(req.ID, codeInvalidParams, "Invalid params: "+err.Error())
+		}
+		t, ok := findTool(params.Name)
+		if !ok {
+			return errorResponse(req.ID, codeInvalidParams, "Unknown tool: "+params.Name)
+		}
+		var a args
+		if len(params.Arguments) > 0 && string(params.Arguments) != "null" {
+			if err := json.Unmarshal(params.Arguments, &a); err != nil {
+				return errorResponse(req.ID, codeInvalidParams, "Invalid arguments: "+err.Error())
+			}
+		}
+		return result(req.ID, s.callTool(ctx, t, a))
+	default:
+		return errorResponse(req.ID, codeMethodNotFound, "Method not found: "+req.Method)
+	}
+}
+
+func (s *Server) callTool(ctx context.Context, t tool, a args) ToolResult {
+	data, err := t.call(ctx, s, a)
+	if err != nil {
+		return ToolResult{Content: []Content{{Type: "text", Text: err.Error()}}, IsError: true}
+	}
+	text, err := json.Marshal(data)
+	if err != nil {
+		return ToolResult{Content: []Content{{Type: "text", Text: err.Error()}}, IsError: true}
+	}
+	return ToolResult{Content: []Content{{Type: "text", Text: string(text)}}}
+}
+
+func result(id json.RawMessage, v interface{}) *Response {
+	return &Response{JSONRPC: "2.0", ID: id, Result: v}
+}
+
+func errorResponse(id json.RawMessage, code int, message string) *Response {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return &Response{JSONRPC: "2.0", ID: id, Error: &Error{Code: code, Message: message}}
+}
+
+func findTool(name string) (tool, bool) {
+	for _, t := range tools {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return tool{}, false
+}
+
+// Argument schemas shared by several tools
+var (
+	hostArg      = map[string]interface{}{"type": "string", "description": "Host name or ID; omit for all hosts"}
+	containerArg = map[string]interface{}{"type": "string", "description": "Container name"}
+	hoursArg     = map[string]interface{}{"type": "integer", "description": fmt.Sprintf("How many hours back to look (default %d, max %d)", DefaultHours, MaxHours)}
+	limitArg     = map[string]interface{}{"type": "integer", "description": fmt.Sprintf("Maximum number of items (default %d, max %d)", DefaultLimit, MaxLimit)}
+)
+
+func schema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	s := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+var tools = []tool{
+	{
+		Tool: Tool{
+			Name:        "list_hosts",
+			Description: "List the Docker hosts Census scans, with their type, agent status and when they were last seen.",
+			InputSchema: schema(map[string]interface{}{}),
+		},
+		call: listHosts,
+	},
+	{
+		Tool: Tool{
+			Name:        "list_containers",
+			Description: "List containers from the latest scan of each host with image, state, compose project, ports, resource usage and update status.",
+			InputSchema: schema(map[string]interface{}{
+				"host":             hostArg,
+				"container":        map[string]interface{}{"type": "string", "description": "Only containers whose name contains this text"},
+				"state":            map[string]interface{}{"type": "string", "description": "Only containers in this state, e.g. running or exited"},
+				"update_available": map[string]interface{}{"type": "boolean", "description": "Only containers with a newer image available"},
+			}),
+		},
+		call: listContainers,
+	},
+	{
+		Tool: Tool{
+			Name: "container_changes",
+			Description: "What changed in a time window: new and removed containers, image updates, state changes " +
+				"and the containers that restarted (changed state) most. Use it for questions like " +
+				"\"which containers restarted in the last 24h on host X\".",
+			InputSchema: schema(map[string]interface{}{"host": hostArg, "hours": hoursArg}),
+		},
+		call: containerChanges,
+	},
+	{
+		Tool: Tool{
+			Name:        "container_history",
+			Description: "Lifecycle events of one container on one host: first seen, started, stopped, restarted, image updated, disappeared.",
+			InputSchema: schema(map[string]interface{}{"host": hostArg, "container": containerArg}, "host", "container"),
+		},
+		call: containerHistory,
+	},
+	{
+		Tool: Tool{
+			Name:        "top_consumers",
+			Description: "Containers using the most CPU and memory, and with the fastest memory growth, over a time window.",
+			InputSchema: schema(map[string]interface{}{"host": hostArg, "hours": hoursArg, "limit": limitArg}),
+		},
+		call: topConsumers,
+	},
+	{
+		Tool: Tool{
+			Name:        "vulnerability_summary",
+			Description: "Totals of the latest image vulnerability scans by severity.",
+			InputSchema: schema(map[string]interface{}{}),
+		},
+		call: vulnerabilitySummary,
+	},
+	{
+		Tool: Tool{
+			Name:        "recent_notifications",
+			Description: "The most recent notifications Census sent, newest first.",
+			InputSchema: schema(map[string]interface{}{
+				"limit":       limitArg,
+				"unread_only": map[string]interface{}{"type": "boolean", "description": "Only unread notifications"},
+			}),
+		},
+		call: recentNotifications,
+	},
+}
+
+// hostInfo is a host without its agent credentials
+type hostInfo struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Address     string    `json:"address"`
+	Description string    `json:"description,omitempty"`
+	HostType    string    `json:"host_type"`
+	AgentStatus string    `json:"agent_status,omitempty"`
+	LastSeen    time.Time `json:"last_seen,omitempty"`
+	Enabled     bool      `json:"enabled"`
+}
+
+// containerInfo is the part of a container assistants need; configuration such as
+// environment variables is left out because it can hold secrets
+type containerInfo struct {
+	Name            string               `json:"name"`
+	ID              string               `json:"id"`
+	Host            string               `json:"host"`
+	HostID          int64                `json:"host_id"`
+	Image           string               `json:"image"`
+	State           string               `json:"state"`
+	Status          string               `json:"status"`
+	ComposeProject  string               `json:"compose_project,omitempty"`
+	Ports           []models.PortMapping `json:"ports,omitempty"`
+	Created         time.Time            `json:"created"`
+	ScannedAt       time.Time            `json:"scanned_at"`
+	CPUPercent      float64              `json:"cpu_percent,omitempty"`
+	MemoryUsage     int64                `json:"memory_usage,omitempty"`
+	MemoryPercent   float64              `json:"memory_percent,omitempty"`
+	UpdateAvailable bool                 `json:"update_available"`
+}
+
+func listHosts(ctx context.Context, s *Server, a args) (interface{}, error) {
+	hosts, err := s.store.GetHosts()
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]hostInfo, 0, len(hosts))
+	for _, h := range hosts {
+		infos = append(infos, hostInfo{
+			ID:          h.ID,
+			Name:        h.Name,
+			Address:     h.Address,
+			Description: h.Description,
+			HostType:    h.HostType,
+			AgentStatus: h.AgentStatus,
+			LastSeen:    h.LastSeen,
+			Enabled:     h.Enabled,
+		})
+	}
+	return infos, nil
+}
+
+func listContainers(ctx context.Context, s *Server, a args) (interface{}, error) {
+	hostID, err := s.resolveHost(a.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	var containers []models.Container
+	if a.UpdateAvailable {
+		containers, err = s.store.GetContainersWithUpdates()
+	} else {
+		containers, err = s.store.GetLatestContainers()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]containerInfo, 0, len(containers))
+	for _, c := range containers {
+		if hostID > 0 && c.HostID != hostID {
+			continue
+		}
+		if a.State != "" && !strings.EqualFold(c.State, a.State) {
+			continue
+		}
+		if a.Container != "" && !strings.Contains(strings.ToLower(c.Name), strings.ToLower(a.Container)) {
+			continue
+		}
+		infos = append(infos, containerInfo{
+			Name:            c.Name,
+			ID:              c.ID,
+			Host:            c.HostName,
+			HostID:          c.HostID,
+			Image:           c.Image,
+			State:           c.State,
+			Status:          c.Status,
+			ComposeProject:  c.ComposeProject,
+			Ports:           c.Ports,
+			Created:         c.Created,
+			ScannedAt:       c.ScannedAt,
+			CPUPercent:      c.CPUPercent,
+			MemoryUsage:     c.MemoryUsage,
+			MemoryPercent:   c.MemoryPercent,
+			UpdateAvailable: c.UpdateAvailable,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Host != infos[j].Host {
+			return infos[i].Host < infos[j].Host
+		}
+		return infos[i].Name < infos[j].Name
+	})
+	return infos, nil
+}
+
+func containerChanges(ctx context.Context, s *Server, a args) (interface{}, error) {
+	hostID, err := s.resolveHost(a.Host)
+	if err != nil {
+		return nil, err
+	}
+	end := s.now()
+	return s.store.GetChangesReport(end.Add(-time.Duration(hours(a.Hours))*time.Hour), end, hostID)
+}
+
+func containerHistory(ctx context.Context, s *Server, a args) (interface{}, error) {
+	if a.Host == "" || a.Container == "" {
+		return nil, fmt.Errorf("host and container are required")
+	}
+	hostID, err := s.resolveHost(a.Host)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.GetContainerLifecycleEvents(a.Container, hostID)
+}
+
+func topConsumers(ctx context.Context, s *Server, a args) (interface{}, error) {
+	hostID, err := s.resolveHost(a.Host)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.GetTopConsumers(hours(a.Hours), limit(a.Limit), hostID)
+}
+
+func vulnerabilitySummary(ctx context.Context, s *Server, a args) (interface{}, error) {
+	return s.store.GetVulnerabilitySummary()
+}
+
+func recentNotifications(ctx context.Context, s *Server, a args) (interface{}, error) {
+	return s.store.GetNotificationLogs(limit(a.Limit), a.UnreadOnly)
+}
+
+// resolveHost turns a host name or ID into an ID; an empty host means all hosts (0)
+func (s *Server) resolveHost(host string) (int64, error) {
+	host = strings.TrimSpace(host)
+	if host == "" {
+		return 0, nil
+	}
+	hosts, err := s.store.GetHosts()
+	if err != nil {
+		return 0, err
+	}
+	id, _ := strconv.ParseInt(host, 10, 64)
+	for _, h := range hosts {
+		if strings.EqualFold(h.Name, host) || (id > 0 && h.ID == id) {
+			return h.ID, nil
+		}
+	}
+	names := make([]string, 0, len(hosts))
+	for _, h := range hosts {
+		names = append(names, h.Name)
+	}
+	return 0, fmt.Errorf("unknown host %q (known hosts: %s)", host, strings.Join(names, ", "))
+}
+
+func hours(h int) int {
+	if h <= 0 {
+		return DefaultHours
+	}
+	if h > MaxHours {
+		return MaxHours
+	}
+	return h
+}
+
+func limit(l int) int {
+	if l <= 0 {
+		return DefaultLimit
+	}
+	if l > MaxLimit {
+		return MaxLimit
+	}
+	return l
+}
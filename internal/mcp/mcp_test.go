@@ -0,0 +1,183 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/vulnerability"
+)
+
+type fakeStore struct {
+	containers   []models.Container
+	changesStart time.Time
+	changesEnd   time.Time
+	changesHost  int64
+}
+
+func (f *fakeStore) GetHosts() ([]models.Host, error) {
+	return []models.Host{
+		{ID: 1, Name: "nas", HostType: "unix", AgentToken: "secret-token", Enabled: true},
+		{ID: 2, Name: "pi", HostType: "agent", Enabled: true},
+	}, nil
+}
+
+func (f *fakeStore) GetLatestContainers() ([]models.Container, error) {
+	return f.containers, nil
+}
+
+func (f *fakeStore) GetContainersWithUpdates() ([]models.Container, error) {
+	var updates []models.Container
+	for _, c := range f.containers {
+		if c.UpdateAvailable {
+			updates = append(updates, c)
+		}
+	}
+	return updates, nil
+}
+
+func (f *fakeStore) GetChangesReport(start, end time.Time, hostFilter int64) (*models.ChangesReport, error) {
+	f.changesStart, f.changesEnd, f.changesHost = start, end, hostFilter
+	return &models.ChangesReport{
+		TopRestarted: []models.RestartSummary{{ContainerName: "flaky", HostID: 2, HostName: "pi", RestartCount: 4}},
+	}, nil
+}
+
+func (f *fakeStore) GetContainerLifecycleEvents(containerName string, hostID int64) ([]models.ContainerLifecycleEvent, error) {
+	return []models.ContainerLifecycleEvent{{EventType: "first_seen", Description: containerName}}, nil
+}
+
+func (f *fakeStore) GetTopConsumers(windowHours, limit int, hostFilter int64) (*models.TopConsumersReport, error) {
+	return &models.TopConsumersReport{WindowHours: windowHours}, nil
+}
+
+func (f *fakeStore) GetVulnerabilitySummary() (*vulnerability.ScanSummary, error) {
+	return &vulnerability.ScanSummary{TotalVulnerabilities: 7}, nil
+}
+
+func (f *fakeStore) GetNotificationLogs(limit int, unreadOnly bool) ([]models.NotificationLog, error) {
+	return []models.NotificationLog{}, nil
+}
+
+func newTestServer() (*Server, *fakeStore) {
+	store := &fakeStore{containers: []models.Container{
+		{Name: "web", HostID: 1, HostName: "nas", State: "running", Image: "nginx:1.25",
+			Config: &models.ContainerConfig{Env: []models.EnvVar{{Name: "API_KEY", Value: "hunter2"}}}},
+		{Name: "db", HostID: 1, HostName: "nas", State: "exited", UpdateAvailable: true},
+		{Name: "flaky", HostID: 2, HostName: "pi", State: "running"},
+	}}
+	s := NewServer(store)
+	s.now = func() time.Time { return time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC) }
+	return s, store
+}
+
+// call sends a tools/call request and returns the tool's text and error flag
+func call(t *testing.T, s *Server, tool string, arguments string) (string, bool) {
+	t.Helper()
+	resp := s.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"`+tool+`","arguments":`+arguments+`}}`))
+	if resp == nil || resp.Error != nil {
+		t.Fatalf("Expected a result for %s, got %+v", tool, resp)
+	}
+	result := resp.Result.(ToolResult)
+	if len(result.Content) != 1 {
+		t.Fatalf("Expected one content block, got %+v", result.Content)
+	}
+	return result.Content[0].Text, result.IsError
+}
+
+func TestProtocol(t *testing.T) {
+	s, _ := newTestServer()
+	ctx := context.Background()
+
+	resp := s.Handle(ctx, []byte(`{"jsonrpc":"2.0","id":"a","method":"initialize","params":{"protocolVersion":"2025-06-18"}}`))
+	if resp == nil || resp.Error != nil || string(resp.ID) != `"a"` {
+		t.Fatalf("Unexpected initialize response: %+v", resp)
+	}
+	if resp.Result.(map[string]interface{})["protocolVersion"] != ProtocolVersion {
+		t.Errorf("Expected protocol version %s, got %+v", ProtocolVersion, resp.Result)
+	}
+
+	if resp := s.Handle(ctx, []byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)); resp != nil {
+		t.Errorf("Expected no response to a notification, got %+v", resp)
+	}
+
+	resp = s.Handle(ctx, []byte(`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`))
+	data, _ := json.Marshal(resp.Result)
+	for _, name := range []string{"list_hosts", "list_containers", "container_changes", "container_history", "top_consumers", "vulnerability_summary", "recent_notifications"} {
+		if !strings.Contains(string(data), `"name":"`+name+`"`) {
+			t.Errorf("Expected tool %s in tools/list", name)
+		}
+	}
+
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`not json`, codeParseError},
+		{`{"id":3,"method":"ping"}`, codeInvalidRequest},
+		{`{"jsonrpc":"2.0","id":3,"method":"resources/write"}`, codeMethodNotFound},
+		{`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"delete_host"}}`, codeInvalidParams},
+		{`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"list_containers","arguments":{"hours":"many"}}}`, codeInvalidParams},
+	}
+	for _, tt := range tests {
+		resp := s.Handle(ctx, []byte(tt.body))
+		if resp == nil || resp.Error == nil || resp.Error.Code != tt.code {
+			t.Errorf("%s: expected error %d, got %+v", tt.body, tt.code, resp)
+		}
+	}
+}
+
+func TestTools(t *testing.T) {
+	s, store := newTestServer()
+
+	text, isError := call(t, s, "list_hosts", `{}`)
+	if isError || !strings.Contains(text, `"name":"nas"`) || strings.Contains(text, "secret-token") {
+		t.Errorf("Expected hosts without agent tokens, got %s", text)
+	}
+
+	text, _ = call(t, s, "list_containers", `{"host":"NAS","state":"running"}`)
+	var containers []containerInfo
+	if err := json.Unmarshal([]byte(text), &containers); err != nil {
+		t.Fatal(err)
+	}
+	if len(containers) != 1 || containers[0].Name != "web" {
+		t.Errorf("Expected only the running container on nas, got %+v", containers)
+	}
+	if strings.Contains(text, "hunter2") {
+		t.Errorf("Expected container configuration to be left out, got %s", text)
+	}
+
+	text, _ = call(t, s, "list_containers", `{"update_available":true}`)
+	if !strings.Contains(text, `"name":"db"`) || strings.Contains(text, `"name":"web"`) {
+		t.Errorf("Expected only containers with updates, got %s", text)
+	}
+
+	// "Which containers restarted in the last 24h on host pi"
+	text, isError = call(t, s, "container_changes", `{"host":"2"}`)
+	if isError || !strings.Contains(text, `"container_name":"flaky"`) {
+		t.Errorf("Expected the restarted container, got %s", text)
+	}
+	if store.changesHost != 2 || store.changesEnd.Sub(store.changesStart) != 24*time.Hour {
+		t.Errorf("Expected a 24h window on host 2, got %v-%v on %d", store.changesStart, store.changesEnd, store.changesHost)
+	}
+
+	call(t, s, "container_changes", `{"hours":100000}`)
+	if store.changesEnd.Sub(store.changesStart) != MaxHours*time.Hour || store.changesHost != 0 {
+		t.Errorf("Expected the window capped at %dh on all hosts", MaxHours)
+	}
+
+	text, isError = call(t, s, "container_changes", `{"host":"mars"}`)
+	if !isError || !strings.Contains(text, "known hosts: nas, pi") {
+		t.Errorf("Expected an unknown host error listing hosts, got %s", text)
+	}
+
+	if text, isError = call(t, s, "container_history", `{"container":"web"}`); !isError {
+		t.Errorf("Expected container_history to require a host, got %s", text)
+	}
+	if text, _ = call(t, s, "top_consumers", `null`); !strings.Contains(text, `"window_hours":24`) {
+		t.Errorf("Expected the default window, got %s", text)
+	}
+}
@@ -0,0 +1,612 @@
+package migration
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/storage"
+	"github.com/container-census/container-census/internal/vulnerability"
+)
+
+// BundleVersion is the format version of configuration bundles
+const BundleVersion = 1
+
+// Sections of a configuration bundle, in import order (rules refer to hosts and channels)
+const (
+	SectionSettings             = "settings"
+	SectionHosts                = "hosts"
+	SectionNotificationChannels = "notification_channels"
+	SectionNotificationRules    = "notification_rules"
+	SectionTelemetryEndpoints   = "telemetry_endpoints"
+	SectionVulnerability        = "vulnerability"
+)
+
+// BundleSections lists all sections in import order
+var BundleSections = []string{
+	SectionSettings,
+	SectionHosts,
+	SectionNotificationChannels,
+	SectionNotificationRules,
+	SectionTelemetryEndpoints,
+	SectionVulnerability,
+}
+
+// Bundle is the full configuration of a server, for moving it to a new one. Everything is
+// matched by name, so IDs of hosts and channels don't have to survive the move. Tenants and
+// what they own aren't part of it.
+type Bundle struct {
+	Version              int                          `json:"version"`
+	ExportedAt           time.Time                    `json:"exported_at"`
+	Settings             *models.SystemSettings       `json:"settings,omitempty"`
+	Hosts                []BundleHost                 `json:"hosts,omitempty"`
+	NotificationChannels []models.NotificationChannel `json:"notification_channels,omitempty"`
+	NotificationRules    []BundleRule                 `json:"notification_rules,omitempty"`
+	TelemetryEndpoints   []models.TelemetryEndpoint   `json:"telemetry_endpoints,omitempty"`
+	Vulnerability        *vulnerability.Config        `json:"vulnerability,omitempty"`
+}
+
+// BundleHost is a host in a bundle; the site is its grouping tag
+type BundleHost struct {
+	Name           string `json:"name"`
+	Address        string `json:"address"`
+	Description    string `json:"description,omitempty"`
+	HostType       string `json:"host_type"`
+	AgentToken     string `json:"agent_token,omitempty"`
+	Enabled        bool   `json:"enabled"`
+	CollectStats   bool   `json:"collect_stats"`
+	RegistryMirror string `json:"registry_mirror,omitempty"`
+	Site           string `json:"site,omitempty"`
+}
+
+// BundleRule is a notification rule in a bundle, referring to its host and channels by name
+type BundleRule struct {
+	models.NotificationRule
+	Host     string   `json:"host,omitempty"`
+	Channels []string `json:"channels"`
+}
+
+// BundleChange is what importing one item of a bundle does (or did)
+type BundleChange struct {
+	Section string `json:"section"`
+	Name    string `json:"name"`
+	Action  string `json:"action"` // create, update, unchanged, skip
+	// Fields that differ from the current configuration (update), or why the item is skipped
+	Fields []string `json:"fields,omitempty"`
+	Reason string   `json:"reason,omitempty"`
+}
+
+// Actions of a BundleChange
+const (
+	BundleActionCreate    = "create"
+	BundleActionUpdate    = "update"
+	BundleActionUnchanged = "unchanged"
+	BundleActionSkip      = "skip"
+)
+
+// BundleImportReport lists the changes of an import; with DryRun nothing was saved
+type BundleImportReport struct {
+	DryRun   bool           `json:"dry_run"`
+	Sections []string       `json:"sections"`
+	Changes  []BundleChange `json:"changes"`
+}
+
+// ExportBundle exports the server's configuration. Agent tokens, telemetry API keys and the
+// Trivy server token are only included with includeSecrets; notification channel settings
+// (webhook URLs and headers) always are, as channels don't work without them.
+func ExportBundle(db *storage.DB, includeSecrets bool) (*Bundle, error) {
+	bundle := &Bundle{Version: BundleVersion, ExportedAt: time.Now()}
+
+	settings, err := db.LoadSystemSettings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+	bundle.Settings = settings
+
+	hosts, err := db.GetHosts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load hosts: %w", err)
+	}
+	hostNames := make(map[int64]string)
+	for _, h := range hosts {
+		hostNames[h.ID] = h.Name
+		if h.TenantID != 0 {
+			continue
+		}
+		bh := bundleHost(h)
+		if !includeSecrets {
+			bh.AgentToken = ""
+		}
+		bundle.Hosts = append(bundle.Hosts, bh)
+	}
+
+	channels, err := db.GetNotificationChannels()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load notification channels: %w", err)
+	}
+	channelNames := make(map[int64]string)
+	for _, ch := range channels {
+		channelNames[ch.ID] = ch.Name
+		if ch.TenantID != 0 {
+			continue
+		}
+		ch.ID = 0
+		ch.CreatedAt, ch.UpdatedAt = time.Time{}, time.Time{}
+		bundle.NotificationChannels = append(bundle.NotificationChannels, ch)
+	}
+
+	rules, err := db.GetNotificationRules(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load notification rules: %w", err)
+	}
+	for _, rule := range rules {
+		if rule.TenantID != 0 {
+			continue
+		}
+		br := BundleRule{NotificationRule: rule, Channels: make([]string, 0, len(rule.ChannelIDs))}
+		if rule.HostID != nil && *rule.HostID > 0 {
+			br.Host = hostNames[*rule.HostID]
+		}
+		for _, id := range rule.ChannelIDs {
+			if name, ok := channelNames[id]; ok {
+				br.Channels = append(br.Channels, name)
+			}
+		}
+		br.ID, br.HostID, br.ChannelIDs = 0, nil, nil
+		br.CreatedAt, br.UpdatedAt = time.Time{}, time.Time{}
+		bundle.NotificationRules = append(bundle.NotificationRules, br)
+	}
+
+	endpoints, err := db.GetTelemetryEndpoints()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load telemetry endpoints: %w", err)
+	}
+	for _, ep := range endpoints {
+		ep.LastSuccess, ep.LastFailure, ep.LastFailureReason = nil, nil, ""
+		if !includeSecrets {
+			ep.APIKey = ""
+		}
+		bundle.TelemetryEndpoints = append(bundle.TelemetryEndpoints, ep)
+	}
+
+	vulnConfig, err := db.LoadVulnerabilitySettings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load vulnerability settings: %w", err)
+	}
+	if !includeSecrets {
+		// The mask keeps the target's token on import
+		vulnConfig = vulnConfig.Redacted()
+	}
+	bundle.Vulnerability = vulnConfig
+
+	return bundle, nil
+}
+
+// bundleHost converts a host to its bundle form
+func bundleHost(h models.Host) BundleHost {
+	return BundleHost{
+		Name:           h.Name,
+		Address:        h.Address,
+		Description:    h.Description,
+		HostType:       h.HostType,
+		AgentToken:     h.AgentToken,
+		Enabled:        h.Enabled,
+		CollectStats:   h.CollectStats,
+		RegistryMirror: h.RegistryMirror,
+		Site:           h.Site,
+	}
+}
+
+// ImportBundle imports the given sections of a bundle (all when empty). Items are matched by
+// name: new ones are created, existing ones updated, and nothing is deleted. Secrets missing
+// from the bundle keep their current value. With dryRun only the report is built.
+func ImportBundle(db *storage.DB, bundle *Bundle, sections []string, dryRun bool) (*BundleImportReport, error) {
+	if bundle.Version > BundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d (this server reads up to %d)", bundle.Version, BundleVersion)
+	}
+	selected := make(map[string]bool)
+	for _, section := range sections {
+		known := false
+		for _, s := range BundleSections {
+			known = known || s == section
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown section %q", section)
+		}
+		selected[section] = true
+	}
+	if len(selected) == 0 {
+		for _, s := range BundleSections {
+			selected[s] = true
+		}
+	}
+
+	im := &bundleImporter{db: db, bundle: bundle, selected: selected, dryRun: dryRun}
+	report := &BundleImportReport{DryRun: dryRun, Sections: make([]string, 0, len(selected)), Changes: make([]BundleChange, 0)}
+	steps := map[string]func() error{
+		SectionSettings:             im.importSettings,
+		SectionHosts:                im.importHosts,
+		SectionNotificationChannels: im.importChannels,
+		SectionNotificationRules:    im.importRules,
+		SectionTelemetryEndpoints:   im.importTelemetryEndpoints,
+		SectionVulnerability:        im.importVulnerability,
+	}
+	for _, section := range BundleSections {
+		if !selected[section] {
+			continue
+		}
+		report.Sections = append(report.Sections, section)
+		if err := steps[section](); err != nil {
+			return nil, fmt.Errorf("failed to import %s: %w", section, err)
+		}
+	}
+	report.Changes = append(report.Changes, im.changes...)
+	return report, nil
+}
+
+// bundleImporter holds the state of one ImportBundle call
+type bundleImporter struct {
+	db       *storage.DB
+	bundle   *Bundle
+	selected map[string]bool
+	dryRun   bool
+	changes  []BundleChange
+}
+
+// record adds a change comparing the current and the imported item; current is nil for new items
+func (im *bundleImporter) record(section, name string, current, imported interface{}) BundleChange {
+	change := BundleChange{Section: section, Name: name, Action: BundleActionCreate}
+	if current != nil {
+		change.Fields = changedFields(current, imported)
+		change.Action = BundleActionUpdate
+		if len(change.Fields) == 0 {
+			change.Action = BundleActionUnchanged
+		}
+	}
+	im.changes = append(im.changes, change)
+	return change
+}
+
+// skip records an item that can't be imported
+func (im *bundleImporter) skip(section, name, reason string) {
+	im.changes = append(im.changes, BundleChange{Section: section, Name: name, Action: BundleActionSkip, Reason: reason})
+}
+
+func (im *bundleImporter) importSettings() error {
+	if im.bundle.Settings == nil {
+		return nil
+	}
+	current, err := im.db.LoadSystemSettings()
+	if err != nil {
+		return err
+	}
+	settings := *im.bundle.Settings
+	settings.UpdatedAt = current.UpdatedAt
+	if err := settings.Validate(); err != nil {
+		im.skip(SectionSettings, SectionSettings, err.Error())
+		return nil
+	}
+	if change := im.record(SectionSettings, SectionSettings, current, &settings); im.dryRun || change.Action == BundleActionUnchanged {
+		return nil
+	}
+	return im.db.SaveSystemSettings(&settings)
+}
+
+func (im *bundleImporter) importHosts() error {
+	hosts, err := im.db.GetHosts()
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]models.Host)
+	for _, h := range hosts {
+		existing[h.Name] = h
+	}
+
+	for _, bh := range im.bundle.Hosts {
+		if bh.Name == "" || bh.Address == "" {
+			im.skip(SectionHosts, bh.Name, "name and address are required")
+			continue
+		}
+		current, ok := existing[bh.Name]
+		if !ok {
+			im.record(SectionHosts, bh.Name, nil, bh)
+			if im.dryRun {
+				continue
+			}
+			host := models.Host{
+				Name:           bh.Name,
+				Address:        bh.Address,
+				Description:    bh.Description,
+				HostType:       bh.HostType,
+				AgentToken:     bh.AgentToken,
+				AgentStatus:    "unknown",
+				Enabled:        bh.Enabled,
+				CollectStats:   bh.CollectStats,
+				RegistryMirror: bh.RegistryMirror,
+				Site:           bh.Site,
+			}
+			if _, err := im.db.AddHost(host); err != nil {
+				return fmt.Errorf("host %s: %w", bh.Name, err)
+			}
+			continue
+		}
+
+		if current.TenantID != 0 {
+			im.skip(SectionHosts, bh.Name, "a tenant's host has this name")
+			continue
+		}
+		if bh.AgentToken == "" {
+			bh.AgentToken = current.AgentToken
+		}
+		if change := im.record(SectionHosts, bh.Name, bundleHost(current), bh); im.dryRun || change.Action == BundleActionUnchanged {
+			continue
+		}
+		current.Address = bh.Address
+		current.Description = bh.Description
+		current.HostType = bh.HostType
+		current.AgentToken = bh.AgentToken
+		current.Enabled = bh.Enabled
+		current.CollectStats = bh.CollectStats
+		current.RegistryMirror = bh.RegistryMirror
+		current.Site = bh.Site
+		if err := im.db.UpdateHost(current); err != nil {
+			return fmt.Errorf("host %s: %w", bh.Name, err)
+		}
+	}
+	return nil
+}
+
+func (im *bundleImporter) importChannels() error {
+	channels, err := im.db.GetNotificationChannels()
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]models.NotificationChannel)
+	for _, ch := range channels {
+		existing[ch.Name] = ch
+	}
+
+	for _, ch := range im.bundle.NotificationChannels {
+		if ch.Name == "" {
+			im.skip(SectionNotificationChannels, ch.Name, "name is required")
+			continue
+		}
+		ch.ID, ch.TenantID = 0, 0
+		ch.CreatedAt, ch.UpdatedAt = time.Time{}, time.Time{}
+
+		var change BundleChange
+		if current, ok := existing[ch.Name]; ok {
+			if current.TenantID != 0 {
+				im.skip(SectionNotificationChannels, ch.Name, "a tenant's channel has this name")
+				continue
+			}
+			ch.ID = current.ID
+			current.CreatedAt, current.UpdatedAt = time.Time{}, time.Time{}
+			change = im.record(SectionNotificationChannels, ch.Name, current, ch)
+		} else {
+			change = im.record(SectionNotificationChannels, ch.Name, nil, ch)
+		}
+		if im.dryRun || change.Action == BundleActionUnchanged {
+			continue
+		}
+		if err := im.db.SaveNotificationChannel(&ch); err != nil {
+			return fmt.Errorf("channel %s: %w", ch.Name, err)
+		}
+	}
+	return nil
+}
+
+func (im *bundleImporter) importRules() error {
+	// Hosts and channels are looked up by name; in a dry run the ones the import would create count too
+	hostIDs, channelIDs, err := im.lookupNames()
+	if err != nil {
+		return err
+	}
+	rules, err := im.db.GetNotificationRules(false)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]models.NotificationRule)
+	for _, rule := range rules {
+		existing[rule.Name] = rule
+	}
+
+	for _, br := range im.bundle.NotificationRules {
+		rule := br.NotificationRule
+		if rule.Name == "" {
+			im.skip(SectionNotificationRules, rule.Name, "name is required")
+			continue
+		}
+		rule.ID, rule.TenantID, rule.HostID, rule.ChannelIDs = 0, 0, nil, make([]int64, 0, len(br.Channels))
+		rule.CreatedAt, rule.UpdatedAt = time.Time{}, time.Time{}
+
+		if br.Host != "" {
+			id, ok := hostIDs[br.Host]
+			if !ok {
+				im.skip(SectionNotificationRules, rule.Name, fmt.Sprintf("host %s does not exist", br.Host))
+				continue
+			}
+			rule.HostID = &id
+		}
+		missing := ""
+		for _, name := range br.Channels {
+			id, ok := channelIDs[name]
+			if !ok {
+				missing = name
+				break
+			}
+			rule.ChannelIDs = append(rule.ChannelIDs, id)
+		}
+		if missing != "" {
+			im.skip(SectionNotificationRules, rule.Name, fmt.Sprintf("channel %s does not exist", missing))
+			continue
+		}
+
+		var change BundleChange
+		if current, ok := existing[rule.Name]; ok {
+			if current.TenantID != 0 {
+				im.skip(SectionNotificationRules, rule.Name, "a tenant's rule has this name")
+				continue
+			}
+			rule.ID = current.ID
+			current.CreatedAt, current.UpdatedAt = time.Time{}, time.Time{}
+			if current.HostID != nil && *current.HostID <= 0 {
+				current.HostID = nil
+			}
+			change = im.record(SectionNotificationRules, rule.Name, current, rule)
+		} else {
+			change = im.record(SectionNotificationRules, rule.Name, nil, rule)
+		}
+		if im.dryRun || change.Action == BundleActionUnchanged {
+			continue
+		}
+		if err := im.db.SaveNotificationRule(&rule); err != nil {
+			return fmt.Errorf("rule %s: %w", rule.Name, err)
+		}
+	}
+	return nil
+}
+
+// lookupNames returns the IDs of hosts and channels by name. In a dry run, hosts and channels
+// the import would create get ID -1.
+func (im *bundleImporter) lookupNames() (map[string]int64, map[string]int64, error) {
+	hosts, err := im.db.GetHosts()
+	if err != nil {
+		return nil, nil, err
+	}
+	channels, err := im.db.GetNotificationChannels()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hostIDs := make(map[string]int64)
+	channelIDs := make(map[string]int64)
+	if im.dryRun {
+		if im.selected[SectionHosts] {
+			for _, h := range im.bundle.Hosts {
+				hostIDs[h.Name] = -1
+			}
+		}
+		if im.selected[SectionNotificationChannels] {
+			for _, ch := range im.bundle.NotificationChannels {
+				channelIDs[ch.Name] = -1
+			}
+		}
+	}
+	for _, h := range hosts {
+		if h.TenantID == 0 {
+			hostIDs[h.Name] = h.ID
+		}
+	}
+	for _, ch := range channels {
+		if ch.TenantID == 0 {
+			channelIDs[ch.Name] = ch.ID
+		}
+	}
+	return hostIDs, channelIDs, nil
+}
+
+func (im *bundleImporter) importTelemetryEndpoints() error {
+	endpoints, err := im.db.GetTelemetryEndpoints()
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]models.TelemetryEndpoint)
+	for _, ep := range endpoints {
+		ep.LastSuccess, ep.LastFailure, ep.LastFailureReason = nil, nil, ""
+		existing[ep.Name] = ep
+	}
+
+	for _, ep := range im.bundle.TelemetryEndpoints {
+		if ep.Name == "" || ep.URL == "" {
+			im.skip(SectionTelemetryEndpoints, ep.Name, "name and URL are required")
+			continue
+		}
+		ep.LastSuccess, ep.LastFailure, ep.LastFailureReason = nil, nil, ""
+
+		var change BundleChange
+		if current, ok := existing[ep.Name]; ok {
+			if ep.APIKey == "" {
+				ep.APIKey = current.APIKey
+			}
+			change = im.record(SectionTelemetryEndpoints, ep.Name, current, ep)
+		} else {
+			change = im.record(SectionTelemetryEndpoints, ep.Name, nil, ep)
+		}
+		if im.dryRun || change.Action == BundleActionUnchanged {
+			continue
+		}
+		if err := im.db.SaveTelemetryEndpoint(&ep); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (im *bundleImporter) importVulnerability() error {
+	if im.bundle.Vulnerability == nil {
+		return nil
+	}
+	current, err := im.db.LoadVulnerabilitySettings()
+	if err != nil {
+		return err
+	}
+	imported := im.bundle.Vulnerability.Clone()
+	if imported.ServerToken == "" {
+		imported.ServerToken = vulnerability.MaskedServerToken
+	}
+	config := current.Clone()
+	if err := config.Update(imported); err != nil {
+		im.skip(SectionVulnerability, SectionVulnerability, err.Error())
+		return nil
+	}
+	if change := im.record(SectionVulnerability, SectionVulnerability, current, config); im.dryRun || change.Action == BundleActionUnchanged {
+		return nil
+	}
+	return im.db.SaveVulnerabilitySettings(config)
+}
+
+// changedFields returns the JSON fields that differ between two values of the same type, with
+// nested objects as "parent.field"
+func changedFields(current, imported interface{}) []string {
+	var a, b map[string]interface{}
+	if !toJSONMap(current, &a) || !toJSONMap(imported, &b) {
+		return []string{"*"}
+	}
+	fields := make([]string, 0)
+	diffMaps("", a, b, &fields)
+	sort.Strings(fields)
+	return fields
+}
+
+func toJSONMap(v interface{}, out *map[string]interface{}) bool {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, out) == nil
+}
+
+func diffMaps(prefix string, a, b map[string]interface{}, fields *[]string) {
+	keys := make(map[string]bool)
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	for k := range keys {
+		subA, okA := a[k].(map[string]interface{})
+		subB, okB := b[k].(map[string]interface{})
+		if okA && okB {
+			diffMaps(prefix+k+".", subA, subB, fields)
+			continue
+		}
+		if !reflect.DeepEqual(a[k], b[k]) {
+			*fields = append(*fields, prefix+k)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package migration
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/storage"
+)
+
+func newBundleTestDB(t *testing.T, name string) *storage.DB {
+	t.Helper()
+	db, err := storage.New(filepath.Join(t.TempDir(), name))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func countActions(report *BundleImportReport) map[string]int {
+	counts := make(map[string]int)
+	for _, change := range report.Changes {
+		counts[change.Action]++
+	}
+	return counts
+}
+
+func TestBundleRoundtrip(t *testing.T) {
+	source := newBundleTestDB(t, "source.db")
+
+	hostID, err := source.AddHost(models.Host{Name: "pi", Address: "agent://pi:9876", HostType: "agent", AgentToken: "secret-token", Enabled: true, CollectStats: true, Site: "home"})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	channel := &models.NotificationChannel{Name: "ntfy", Type: models.ChannelTypeNtfy, Config: map[string]interface{}{"topic": "census"}, Enabled: true}
+	if err := source.SaveNotificationChannel(channel); err != nil {
+		t.Fatalf("Failed to save channel: %v", err)
+	}
+	rule := &models.NotificationRule{Name: "pi changes", Enabled: true, EventTypes: []string{"state_change"}, HostID: &hostID, CooldownSeconds: 300, ChannelIDs: []int64{channel.ID}}
+	if err := source.SaveNotificationRule(rule); err != nil {
+		t.Fatalf("Failed to save rule: %v", err)
+	}
+	if err := source.SaveTelemetryEndpoint(&models.TelemetryEndpoint{Name: "community", URL: "https://telemetry.example.com", Enabled: true, APIKey: "key"}); err != nil {
+		t.Fatalf("Failed to save endpoint: %v", err)
+	}
+
+	bundle, err := ExportBundle(source, false)
+	if err != nil {
+		t.Fatalf("ExportBundle failed: %v", err)
+	}
+	if len(bundle.Hosts) != 1 || bundle.Hosts[0].AgentToken != "" {
+		t.Errorf("Expected the host without its token, got %+v", bundle.Hosts)
+	}
+	if len(bundle.TelemetryEndpoints) != 1 || bundle.TelemetryEndpoints[0].APIKey != "" {
+		t.Errorf("Expected the endpoint without its API key, got %+v", bundle.TelemetryEndpoints)
+	}
+	if len(bundle.NotificationRules) != 1 || bundle.NotificationRules[0].Host != "pi" || len(bundle.NotificationRules[0].Channels) != 1 || bundle.NotificationRules[0].Channels[0] != "ntfy" {
+		t.Errorf("Expected the rule to refer to its host and channel by name, got %+v", bundle.NotificationRules)
+	}
+
+	withSecrets, err := ExportBundle(source, true)
+	if err != nil {
+		t.Fatalf("ExportBundle failed: %v", err)
+	}
+	if withSecrets.Hosts[0].AgentToken != "secret-token" {
+		t.Errorf("Expected the host token with secrets, got %q", withSecrets.Hosts[0].AgentToken)
+	}
+
+	// The bundle travels as JSON
+	data, err := json.Marshal(withSecrets)
+	if err != nil {
+		t.Fatalf("Failed to marshal bundle: %v", err)
+	}
+	var imported Bundle
+	if err := json.Unmarshal(data, &imported); err != nil {
+		t.Fatalf("Failed to unmarshal bundle: %v", err)
+	}
+
+	target := newBundleTestDB(t, "target.db")
+
+	// A dry run resolves the rule's host and channel from the bundle and saves nothing
+	report, err := ImportBundle(target, &imported, nil, true)
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if counts := countActions(report); counts[BundleActionCreate] != 4 || counts[BundleActionSkip] != 0 {
+		t.Errorf("Expected host, channel, rule and endpoint to be created, got %+v", report.Changes)
+	}
+	if hosts, _ := target.GetHosts(); len(hosts) != 0 {
+		t.Errorf("Expected the dry run not to add hosts, got %d", len(hosts))
+	}
+
+	// Only the rule can't be imported without its host and channel
+	report, err = ImportBundle(target, &imported, []string{SectionNotificationRules}, true)
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if len(report.Changes) != 1 || report.Changes[0].Action != BundleActionSkip {
+		t.Errorf("Expected the rule to be skipped without its host, got %+v", report.Changes)
+	}
+
+	if _, err := ImportBundle(target, &imported, nil, false); err != nil {
+		t.Fatalf("ImportBundle failed: %v", err)
+	}
+	hosts, err := target.GetHosts()
+	if err != nil || len(hosts) != 1 || hosts[0].AgentToken != "secret-token" || hosts[0].Site != "home" {
+		t.Fatalf("Expected the imported host, got %+v (%v)", hosts, err)
+	}
+	rules, err := target.GetNotificationRules(false)
+	if err != nil || len(rules) != 1 || rules[0].HostID == nil || *rules[0].HostID != hosts[0].ID || len(rules[0].ChannelIDs) != 1 {
+		t.Fatalf("Expected the imported rule linked to the new host and channel, got %+v (%v)", rules, err)
+	}
+
+	// Importing again changes nothing, and a bundle without secrets keeps the target's
+	report, err = ImportBundle(target, bundle, nil, true)
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	for _, change := range report.Changes {
+		if change.Action != BundleActionUnchanged {
+			t.Errorf("Expected %s %s to be unchanged, got %s %v", change.Section, change.Name, change.Action, change.Fields)
+		}
+	}
+
+	bundle.Hosts[0].Address = "agent://pi.lan:9876"
+	report, err = ImportBundle(target, bundle, []string{SectionHosts}, false)
+	if err != nil {
+		t.Fatalf("ImportBundle failed: %v", err)
+	}
+	if len(report.Changes) != 1 || report.Changes[0].Action != BundleActionUpdate || len(report.Changes[0].Fields) != 1 || report.Changes[0].Fields[0] != "address" {
+		t.Errorf("Expected an address update, got %+v", report.Changes)
+	}
+	if hosts, _ := target.GetHosts(); hosts[0].Address != "agent://pi.lan:9876" || hosts[0].AgentToken != "secret-token" {
+		t.Errorf("Expected the new address with the token kept, got %+v", hosts[0])
+	}
+
+	if _, err := ImportBundle(target, bundle, []string{"containers"}, true); err == nil {
+		t.Error("Expected an error for an unknown section")
+	}
+}
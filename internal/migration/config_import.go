@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/container-census/container-census/internal/config"
 	"github.com/container-census/container-census/internal/models"
@@ -189,18 +190,34 @@ func createDefaultLocalHost(db *storage.DB) error {
 	}
 
 	// Create default local host
-	localHost := &models.Host{
-		Name:         "local",
-		Address:      "unix:///var/run/docker.sock",
-		Description:  "Local Docker daemon",
-		Enabled:      true,
-		CollectStats: true,
-	}
+	localHost := NewLocalHost()
 
 	if _, err := db.AddHost(*localHost); err != nil {
 		return fmt.Errorf("failed to create local host: %w", err)
 	}
 
-	log.Println("✅ Created default local Docker host (unix:///var/run/docker.sock)")
+	log.Printf("✅ Created default local Docker host (%s)", localHost.Address)
 	return nil
 }
+
+// LocalDockerAddress is the address of the local Docker daemon: DOCKER_HOST when it points at a
+// unix socket, otherwise /var/run/docker.sock
+func LocalDockerAddress() string {
+	if dockerHost := os.Getenv("DOCKER_HOST"); strings.HasPrefix(dockerHost, "unix://") {
+		return dockerHost
+	}
+	return models.DefaultLocalAddress
+}
+
+// NewLocalHost returns the host record of the local Docker daemon, which is stored, scanned and
+// managed like any remote host
+func NewLocalHost() *models.Host {
+	return &models.Host{
+		Name:         models.LocalHostName,
+		Address:      LocalDockerAddress(),
+		HostType:     models.HostTypeUnix,
+		Description:  "Local Docker daemon",
+		Enabled:      true,
+		CollectStats: true,
+	}
+}
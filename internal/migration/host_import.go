@@ -0,0 +1,339 @@
+package migration
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/container-census/container-census/internal/broker"
+	"github.com/container-census/container-census/internal/incus"
+	"github.com/container-census/container-census/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// MaxHostImportRows limits the number of hosts in one bulk import
+const MaxHostImportRows = 500
+
+// Formats of a bulk host import document
+const (
+	HostImportCSV  = "csv"
+	HostImportYAML = "yaml"
+)
+
+// HostImportRow is one host of a bulk host import document
+type HostImportRow struct {
+	Line         int    `yaml:"-"` // in the document, for the report
+	Name         string `yaml:"name"`
+	Address      string `yaml:"address"`
+	Type         string `yaml:"type"`
+	Token        string `yaml:"token"`
+	Description  string `yaml:"description"`
+	Site         string `yaml:"site"`
+	CollectStats *bool  `yaml:"collect_stats"`
+	Template     string `yaml:"template"` // name of a host template to start from
+	// Why the host can't be imported, set while parsing and by ValidateHostImport
+	Error string `yaml:"-"`
+}
+
+// hostImportColumns are the CSV columns, with the aliases they may have in the header
+var hostImportColumns = map[string]string{
+	"name":          "name",
+	"address":       "address",
+	"type":          "type",
+	"host_type":     "type",
+	"token":         "token",
+	"agent_token":   "token",
+	"description":   "description",
+	"site":          "site",
+	"collect_stats": "collect_stats",
+	"template":      "template",
+}
+
+// ParseHostImport reads a bulk host import document: CSV with a header row (name, address,
+// type, token, and optionally description, site, collect_stats, template), or YAML with a list of hosts
+// (at the top or under "hosts:") with the same keys. An empty format is detected from the
+// document. Rows with unusable values are returned with their Error set; only a document that
+// can't be read at all is an error.
+func ParseHostImport(data []byte, format string) ([]HostImportRow, error) {
+	if format == "" {
+		format = detectHostImportFormat(data)
+	}
+
+	var rows []HostImportRow
+	var err error
+	switch format {
+	case HostImportCSV:
+		rows, err = parseHostImportCSV(data)
+	case HostImportYAML:
+		rows, err = parseHostImportYAML(data)
+	default:
+		return nil, fmt.Errorf("unknown format %q (use csv or yaml)", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, errors.New("the document has no hosts")
+	}
+	if len(rows) > MaxHostImportRows {
+		return nil, fmt.Errorf("the document has %d hosts; at most %d can be imported at once", len(rows), MaxHostImportRows)
+	}
+	return rows, nil
+}
+
+// detectHostImportFormat tells YAML from CSV by the first line that isn't empty or a comment
+func detectHostImportFormat(data []byte) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if line == "---" || strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "hosts:") {
+			return HostImportYAML
+		}
+		return HostImportCSV
+	}
+	return HostImportCSV
+}
+
+func parseHostImportCSV(data []byte) ([]HostImportRow, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("the document is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+
+	columns := make([]string, len(header))
+	seen := make(map[string]bool)
+	for i, name := range header {
+		column, ok := hostImportColumns[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q in the header (expected name, address, type, token, description, site, collect_stats, template)", name)
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("column %q appears twice in the header", column)
+		}
+		seen[column] = true
+		columns[i] = column
+	}
+	if !seen["name"] || !seen["address"] {
+		return nil, errors.New("the header needs at least the name and address columns")
+	}
+
+	var rows []HostImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := HostImportRow{Line: line}
+		if len(record) > len(columns) {
+			row.Error = fmt.Sprintf("%d values for %d columns", len(record), len(columns))
+		}
+		for i, value := range record {
+			if i >= len(columns) {
+				break
+			}
+			value = strings.TrimSpace(value)
+			switch columns[i] {
+			case "name":
+				row.Name = value
+			case "address":
+				row.Address = value
+			case "type":
+				row.Type = value
+			case "token":
+				row.Token = value
+			case "description":
+				row.Description = value
+			case "site":
+				row.Site = value
+			case "template":
+				row.Template = value
+			case "collect_stats":
+				if value == "" {
+					continue
+				}
+				collect, err := strconv.ParseBool(value)
+				if err != nil {
+					row.Error = fmt.Sprintf("invalid collect_stats %q (use true or false)", value)
+					continue
+				}
+				row.CollectStats = &collect
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func parseHostImportYAML(data []byte) ([]HostImportRow, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, errors.New("the document is empty")
+	}
+
+	list := doc.Content[0]
+	if list.Kind == yaml.MappingNode {
+		list = nil
+		for i := 0; i+1 < len(doc.Content[0].Content); i += 2 {
+			if doc.Content[0].Content[i].Value == "hosts" {
+				list = doc.Content[0].Content[i+1]
+			}
+		}
+		if list == nil {
+			return nil, errors.New(`expected a list of hosts, at the top or under "hosts:"`)
+		}
+	}
+	if list.Kind != yaml.SequenceNode {
+		return nil, errors.New(`expected a list of hosts, at the top or under "hosts:"`)
+	}
+
+	rows := make([]HostImportRow, 0, len(list.Content))
+	for _, item := range list.Content {
+		var row HostImportRow
+		if err := item.Decode(&row); err != nil {
+			row = HostImportRow{Error: "invalid host: " + err.Error()}
+		}
+		row.Line = item.Line
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// ValidateHostImport checks the rows of a bulk import against each other and the existing hosts,
+// setting Error on the rows that can't be imported. Addresses without a scheme get the one of
+// their type (agent://, tcp://, ssh://), and a missing type is taken from the address.
+func ValidateHostImport(rows []HostImportRow, existing []models.Host) {
+	names := make(map[string]bool)
+	addresses := make(map[string]bool)
+	for _, host := range existing {
+		names[host.Name] = true
+		addresses[host.Address] = true
+	}
+	importedNames := make(map[string]int)
+	importedAddresses := make(map[string]int)
+
+	for i := range rows {
+		row := &rows[i]
+		row.Name = strings.TrimSpace(row.Name)
+		row.Address = strings.TrimSpace(row.Address)
+		row.Type = strings.ToLower(strings.TrimSpace(row.Type))
+		row.Token = strings.TrimSpace(row.Token)
+		row.Site = strings.TrimSpace(row.Site)
+		row.Template = strings.TrimSpace(row.Template)
+		if row.Error != "" {
+			continue
+		}
+
+		if row.Name == "" {
+			row.Error = "name is required"
+			continue
+		}
+		if row.Address == "" {
+			row.Error = "address is required"
+			continue
+		}
+
+		if !strings.Contains(row.Address, "://") {
+			switch row.Type {
+			case models.HostTypeAgent, "tcp", "ssh":
+				row.Address = row.Type + "://" + row.Address
+			case "":
+				row.Error = "address needs a scheme (agent://, tcp://, ssh://) or the type column"
+				continue
+			}
+		}
+		detected := hostImportType(row.Address)
+		if detected == "" {
+			row.Error = fmt.Sprintf("unsupported address %q", row.Address)
+			continue
+		}
+		if row.Type == "" {
+			row.Type = detected
+		} else if row.Type != detected {
+			row.Error = fmt.Sprintf("type %s doesn't match address %s (a %s address)", row.Type, row.Address, detected)
+			continue
+		}
+		if row.Type == models.HostTypeAgent && row.Token == "" {
+			row.Error = "agent hosts need a token"
+			continue
+		}
+
+		if line, ok := importedNames[row.Name]; ok {
+			row.Error = fmt.Sprintf("name is also used on line %d", line)
+			continue
+		}
+		if line, ok := importedAddresses[row.Address]; ok {
+			row.Error = fmt.Sprintf("address is also used on line %d", line)
+			continue
+		}
+		importedNames[row.Name] = row.Line
+		importedAddresses[row.Address] = row.Line
+
+		if names[row.Name] {
+			row.Error = "a host with this name already exists"
+			continue
+		}
+		if addresses[row.Address] {
+			row.Error = "a host with this address already exists"
+			continue
+		}
+	}
+}
+
+// Host returns the host to add for a valid row
+func (row HostImportRow) Host() models.Host {
+	collectStats := true
+	if row.CollectStats != nil {
+		collectStats = *row.CollectStats
+	}
+	return models.Host{
+		Name:         row.Name,
+		Address:      row.Address,
+		Description:  row.Description,
+		HostType:     row.Type,
+		AgentToken:   row.Token,
+		AgentStatus:  "unknown",
+		Enabled:      true,
+		CollectStats: collectStats,
+		Site:         row.Site,
+	}
+}
+
+// hostImportType returns the host type of an address, or "" if it can't be scanned
+func hostImportType(address string) string {
+	switch {
+	case strings.HasPrefix(address, "agent://"), strings.HasPrefix(address, "http://"), strings.HasPrefix(address, "https://"), broker.IsAddress(address):
+		return models.HostTypeAgent
+	case strings.HasPrefix(address, "unix://"):
+		return models.HostTypeUnix
+	case strings.HasPrefix(address, "tcp://"):
+		return "tcp"
+	case strings.HasPrefix(address, "ssh://"):
+		return "ssh"
+	case incus.IsAddress(address):
+		return models.HostTypeIncus
+	}
+	return ""
+}
@@ -0,0 +1,112 @@
+package migration
+
+import (
+	"testing"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestParseHostImportCSV(t *testing.T) {
+	doc := `# VPS fleet
+name,address,type,token,site,collect_stats
+vps1,vps1.example.com:9876,agent,secret1,fra,
+vps2,agent://vps2.example.com:9876,,secret2,fra,false
+nas,tcp://nas.lan:2376,,,,maybe
+`
+	rows, err := ParseHostImport([]byte(doc), "")
+	if err != nil {
+		t.Fatalf("ParseHostImport failed: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("Expected 3 rows, got %d", len(rows))
+	}
+	if rows[0].Line != 3 || rows[0].Name != "vps1" || rows[0].Token != "secret1" || rows[0].Site != "fra" || rows[0].CollectStats != nil {
+		t.Errorf("Unexpected first row %+v", rows[0])
+	}
+	if rows[1].CollectStats == nil || *rows[1].CollectStats {
+		t.Errorf("Expected collect_stats false on the second row, got %+v", rows[1])
+	}
+	if rows[2].Error == "" {
+		t.Error("Expected an invalid collect_stats to be reported on its row")
+	}
+
+	if _, err := ParseHostImport([]byte("name,address,port\nvps1,agent://vps1:9876,1"), HostImportCSV); err == nil {
+		t.Error("Expected an error for an unknown column")
+	}
+	if _, err := ParseHostImport([]byte("name,type\nvps1,agent"), HostImportCSV); err == nil {
+		t.Error("Expected an error without the address column")
+	}
+	if _, err := ParseHostImport([]byte("name,address\n"), ""); err == nil {
+		t.Error("Expected an error for a document without hosts")
+	}
+}
+
+func TestParseHostImportYAML(t *testing.T) {
+	doc := `hosts:
+  - name: vps1
+    address: vps1.example.com:9876
+    type: agent
+    token: secret1
+  - name: nas
+    address: tcp://nas.lan:2376
+    collect_stats: false
+    template: storage
+`
+	rows, err := ParseHostImport([]byte(doc), "")
+	if err != nil {
+		t.Fatalf("ParseHostImport failed: %v", err)
+	}
+	if len(rows) != 2 || rows[0].Line != 2 || rows[0].Token != "secret1" || rows[1].Line != 6 || rows[1].CollectStats == nil || *rows[1].CollectStats || rows[1].Template != "storage" {
+		t.Errorf("Unexpected rows %+v", rows)
+	}
+
+	rows, err = ParseHostImport([]byte("- name: vps1\n  address: agent://vps1:9876\n  token: x\n"), HostImportYAML)
+	if err != nil || len(rows) != 1 {
+		t.Errorf("Expected a top-level list to work, got %+v (%v)", rows, err)
+	}
+
+	if _, err := ParseHostImport([]byte("settings:\n  a: b\n"), HostImportYAML); err == nil {
+		t.Error("Expected an error without a list of hosts")
+	}
+}
+
+func TestValidateHostImport(t *testing.T) {
+	existing := []models.Host{{Name: "local", Address: "unix:///var/run/docker.sock"}}
+	rows := []HostImportRow{
+		{Line: 2, Name: "vps1", Address: "vps1.example.com:9876", Type: "Agent", Token: "secret"},
+		{Line: 3, Name: "nas", Address: "tcp://nas.lan:2376"},
+		{Line: 4, Name: "vps2", Address: "vps2.example.com:9876", Type: "agent"},
+		{Line: 5, Name: "vps1", Address: "agent://other:9876", Token: "secret"},
+		{Line: 6, Name: "local", Address: "agent://local:9876", Token: "secret"},
+		{Line: 7, Name: "docker", Address: "unix:///var/run/docker.sock"},
+		{Line: 8, Name: "box", Address: "box.lan"},
+		{Line: 9, Name: "mixed", Address: "tcp://mixed.lan:2376", Type: "agent", Token: "secret"},
+		{Line: 10, Name: "", Address: "agent://noname:9876"},
+	}
+	ValidateHostImport(rows, existing)
+
+	if rows[0].Error != "" || rows[0].Address != "agent://vps1.example.com:9876" || rows[0].Type != models.HostTypeAgent {
+		t.Errorf("Expected the agent address to get its scheme, got %+v", rows[0])
+	}
+	if rows[1].Error != "" || rows[1].Type != "tcp" {
+		t.Errorf("Expected the type to be taken from the address, got %+v", rows[1])
+	}
+	for i, want := range map[int]string{
+		2: "agent hosts need a token",
+		3: "name is also used on line 2",
+		4: "a host with this name already exists",
+		5: "a host with this address already exists",
+		6: "address needs a scheme (agent://, tcp://, ssh://) or the type column",
+		7: "type agent doesn't match address tcp://mixed.lan:2376 (a tcp address)",
+		8: "name is required",
+	} {
+		if rows[i].Error != want {
+			t.Errorf("Line %d: expected %q, got %q", rows[i].Line, want, rows[i].Error)
+		}
+	}
+
+	host := rows[1].Host()
+	if !host.CollectStats || !host.Enabled || host.HostType != "tcp" {
+		t.Errorf("Expected an enabled host collecting stats, got %+v", host)
+	}
+}
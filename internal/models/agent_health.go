@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Agent health statuses, telling a failing agent apart from a failing Docker daemon
+const (
+	AgentHealthHealthy     = "healthy"
+	AgentHealthDockerError = "docker_error" // the agent works but can't reach Docker
+	AgentHealthAgentError  = "agent_error"  // the agent's metrics couldn't be fetched
+)
+
+// Agent permissions: the groups of Docker operations an agent allows (-allow). Everything else
+// an agent serves only reads.
+const (
+	AgentPermissionLifecycle = "lifecycle" // start, stop and restart containers
+	AgentPermissionRemove    = "remove"    // remove containers
+	AgentPermissionUpdate    = "update"    // pull and tag images, recreate containers
+	AgentPermissionImages    = "images"    // remove and prune images
+)
+
+// AgentPermissions are all agent permissions, in the order they are shown
+var AgentPermissions = []string{AgentPermissionLifecycle, AgentPermissionRemove, AgentPermissionUpdate, AgentPermissionImages}
+
+// AgentHealth is an agent's report on itself from its /api/metrics endpoint: its scans, the
+// Docker API calls that failed and its own memory use
+type AgentHealth struct {
+	Version         string  `json:"version"`
+	UptimeSeconds   int64   `json:"uptime_seconds"`
+	Scans           int64   `json:"scans"`
+	ScanErrors      int64   `json:"scan_errors"`
+	LastScanSeconds float64 `json:"last_scan_seconds"`
+	AvgScanSeconds  float64 `json:"avg_scan_seconds"`
+	// Failed Docker API calls by operation (list, inspect, stats, ...) since the agent started
+	DockerAPIErrors   map[string]int64 `json:"docker_api_errors"`
+	DockerReachable   bool             `json:"docker_reachable"`
+	LastDockerError   string           `json:"last_docker_error,omitempty"`
+	LastDockerErrorAt *time.Time       `json:"last_docker_error_at,omitempty"`
+	// Memory of the agent process: live heap and all memory obtained from the OS
+	MemoryHeapBytes int64 `json:"memory_heap_bytes"`
+	MemorySysBytes  int64 `json:"memory_sys_bytes"`
+	Goroutines      int   `json:"goroutines"`
+	// Docker operations the agent allows (empty when read-only); nil from agents before -allow
+	Permissions []string `json:"permissions"`
+	// Engine and OS versions and pending restarts of the agent's host
+	System *HostSystem `json:"system,omitempty"`
+
+	// Set by the server when it fetches the report
+	Status      string    `json:"status,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	CollectedAt time.Time `json:"collected_at"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Sources of agent token rotations
+const (
+	TokenRotationManual    = "manual"    // POST /api/hosts/{id}/agent-token/rotate
+	TokenRotationScheduled = "scheduled" // the token was older than the maximum age
+)
+
+// RotatedAgentToken is an agent's answer to a token rotation
+type RotatedAgentToken struct {
+	Token string `json:"token"`
+	// Until when the agent still accepts the previous token
+	PreviousExpiresAt time.Time `json:"previous_expires_at"`
+}
+
+// AgentTokenRotation is an attempt to rotate an agent host's token
+type AgentTokenRotation struct {
+	ID                int64      `json:"id"`
+	HostID            int64      `json:"host_id"`
+	RotatedAt         time.Time  `json:"rotated_at"`
+	Source            string     `json:"source"` // manual or scheduled
+	Success           bool       `json:"success"`
+	Error             string     `json:"error,omitempty"`
+	PreviousExpiresAt *time.Time `json:"previous_expires_at,omitempty"`
+}
+
+// AgentTokenAge is an agent host's row in the token age audit
+type AgentTokenAge struct {
+	HostID   int64  `json:"host_id"`
+	HostName string `json:"host_name"`
+	Enabled  bool   `json:"enabled"`
+	// When the token was set: by a rotation or an edit of the host, else when the host was added
+	ChangedAt    time.Time           `json:"changed_at"`
+	AgeDays      int                 `json:"age_days"`
+	Expired      bool                `json:"expired"` // older than the maximum age
+	LastRotation *AgentTokenRotation `json:"last_rotation,omitempty"`
+}
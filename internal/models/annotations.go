@@ -0,0 +1,58 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxAnnotationLength is the longest annotation text; annotations are a line, not a post-mortem
+const MaxAnnotationLength = 500
+
+// Annotation is a note on a point in time, or a period with EndAt, that explains what the data
+// can't, e.g. "migrated to new NAS" or "power outage". It applies to every host, to one host, or
+// to one container of a host (by name, so it survives recreation), and is shown on stats
+// charts, lifecycle timelines and changes reports.
+type Annotation struct {
+	ID            int64      `json:"id"`
+	At            time.Time  `json:"at"`
+	EndAt         *time.Time `json:"end_at,omitempty"`
+	Text          string     `json:"text"`
+	HostID        int64      `json:"host_id,omitempty"` // 0 for every host
+	HostName      string     `json:"host_name,omitempty"`
+	ContainerName string     `json:"container_name,omitempty"` // requires a host
+	CreatedAt     time.Time  `json:"created_at"`
+	CreatedBy     string     `json:"created_by,omitempty"`
+}
+
+// Normalize trims the text and container name and checks the annotation is complete
+func (a *Annotation) Normalize() error {
+	a.Text = strings.TrimSpace(a.Text)
+	a.ContainerName = strings.TrimSpace(a.ContainerName)
+	if a.Text == "" {
+		return fmt.Errorf("annotation text is required")
+	}
+	if len(a.Text) > MaxAnnotationLength {
+		return fmt.Errorf("annotations must be at most %d characters", MaxAnnotationLength)
+	}
+	if a.At.IsZero() {
+		return fmt.Errorf("annotation time is required")
+	}
+	if a.EndAt != nil && a.EndAt.Before(a.At) {
+		return fmt.Errorf("annotation end must not be before its start")
+	}
+	if a.ContainerName != "" && a.HostID == 0 {
+		return fmt.Errorf("container annotations require a host")
+	}
+	return nil
+}
+
+// AnnotationFilter selects the annotations overlapping a period. Zero times leave that side
+// open. HostIDs nil selects the annotations of every host, otherwise the global ones and those
+// of the given hosts. A ContainerName leaves out the annotations of other containers.
+type AnnotationFilter struct {
+	Start         time.Time
+	End           time.Time
+	HostIDs       []int64
+	ContainerName string
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Backup labels: census.backup=true marks a container as a backup job (false opts a detected one
+// out) and census.backup.interval sets how often it is expected to succeed, e.g. "24h" or "7d"
+const (
+	BackupLabel         = "census.backup"
+	BackupIntervalLabel = "census.backup.interval"
+)
+
+// Backup job kinds
+const (
+	BackupKindJob     = "job"     // one-shot container that exits after each run
+	BackupKindService = "service" // long-running container with its own scheduler
+)
+
+// Backup job statuses
+const (
+	BackupStatusOK      = "ok"
+	BackupStatusRunning = "running"
+	BackupStatusFailed  = "failed"  // the latest run exited non-zero
+	BackupStatusOverdue = "overdue" // no success within the expected interval
+	BackupStatusPending = "pending" // no run seen yet, but the first one isn't due
+)
+
+// BackupRun is one completed run of a backup job container
+type BackupRun struct {
+	ID            int64     `json:"id"`
+	HostID        int64     `json:"host_id"`
+	ContainerName string    `json:"container_name"`
+	ContainerID   string    `json:"container_id"`
+	StartedAt     time.Time `json:"started_at"`
+	FinishedAt    time.Time `json:"finished_at"`
+	ExitCode      int       `json:"exit_code"`
+	Success       bool      `json:"success"`
+}
+
+// BackupJob is a backup container and the state of its runs
+type BackupJob struct {
+	HostID          int64      `json:"host_id"`
+	HostName        string     `json:"host_name"`
+	ContainerName   string     `json:"container_name"`
+	Image           string     `json:"image"`
+	Tool            string     `json:"tool"` // restic, borgmatic, duplicati, ... or "backup" for labelled jobs
+	Kind            string     `json:"kind"`
+	State           string     `json:"state"` // container state, or "removed" when only past runs remain
+	IntervalSeconds int64      `json:"interval_seconds"`
+	FirstSeen       time.Time  `json:"first_seen"`
+	LastRun         *BackupRun `json:"last_run,omitempty"`
+	LastSuccessAt   *time.Time `json:"last_success_at,omitempty"`
+	DueAt           time.Time  `json:"due_at"` // when the job becomes overdue without a new success
+	Status          string     `json:"status"`
+	Detail          string     `json:"detail"`
+}
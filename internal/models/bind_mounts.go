@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Sensitive host paths of the bind mount report, besides the paths named after themselves
+// (/etc, /root, /home, ...)
+const (
+	BindMountDockerSocket = "docker_socket" // full control of the Docker daemon, even read-only
+	BindMountHostRoot     = "/"
+)
+
+// BindMountUse is a container's bind mount of a host path
+type BindMountUse struct {
+	ContainerID   string `json:"container_id"`
+	ContainerName string `json:"container_name"`
+	State         string `json:"state"`
+	Destination   string `json:"destination"`
+	RW            bool   `json:"rw"`
+}
+
+// BindMountPath is a host path bind mounted into containers of a host's latest scan
+type BindMountPath struct {
+	HostID   int64          `json:"host_id"`
+	HostName string         `json:"host_name"`
+	Source   string         `json:"source"`
+	Mounts   []BindMountUse `json:"mounts"`
+	// The sensitive host path the source exposes (docker_socket, /, /etc, ...), or ""
+	Sensitive string `json:"sensitive,omitempty"`
+	// Containers writing to this path, or to a path above or below it, when there are several
+	Writers []string `json:"writers,omitempty"`
+	Overlap bool     `json:"overlap"`
+}
+
+// BindMountReport indexes the bind mount sources of the hosts' latest scans, with the paths
+// several containers write to and the mounts of sensitive host paths
+type BindMountReport struct {
+	GeneratedAt time.Time       `json:"generated_at"`
+	Paths       []BindMountPath `json:"paths"` // flagged first, then by host and path
+	Overlaps    int             `json:"overlaps"`
+	Sensitive   int             `json:"sensitive"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// States of a canary rollout
+const (
+	CanaryStatusUpdating    = "updating"    // updating the canary
+	CanaryStatusSoaking     = "soaking"     // watching the updated canary for the soak period
+	CanaryStatusRollingOut  = "rolling_out" // the canary passed, updating the other containers
+	CanaryStatusCompleted   = "completed"
+	CanaryStatusHalted      = "halted"      // the canary failed, or the rollout was cancelled
+	CanaryStatusInterrupted = "interrupted" // the server stopped during the rollout
+)
+
+// States of a container in a canary rollout
+const (
+	CanaryTargetPending   = "pending"
+	CanaryTargetSucceeded = "succeeded"
+	CanaryTargetFailed    = "failed"
+	CanaryTargetSkipped   = "skipped" // not updated because the rollout halted
+)
+
+// Defaults of a canary rollout
+const (
+	DefaultCanarySoakMinutes = 10
+	MaxCanarySoakMinutes     = 24 * 60
+)
+
+// CanaryUpdate is a rollout of an image to the containers running it on several hosts: the first
+// target (the canary) is updated alone and watched for SoakMinutes; only if it keeps running,
+// healthy and without more than MaxRestarts restarts are the others updated.
+type CanaryUpdate struct {
+	ID          int64          `json:"id"`
+	Image       string         `json:"image"`
+	SoakMinutes int            `json:"soak_minutes"`
+	MaxRestarts int            `json:"max_restarts"`
+	Hooks       *UpdateHooks   `json:"hooks,omitempty"` // for the other containers; the canary always waits for healthy
+	Status      string         `json:"status"`
+	Reason      string         `json:"reason,omitempty"` // why it halted
+	TriggeredBy string         `json:"triggered_by,omitempty"`
+	Targets     []CanaryTarget `json:"targets"` // the canary first
+	CreatedAt   time.Time      `json:"created_at"`
+	SoakUntil   *time.Time     `json:"soak_until,omitempty"`
+	FinishedAt  *time.Time     `json:"finished_at,omitempty"`
+	// Go ahead despite vulnerability gate warnings
+	AcceptVulnerabilities bool `json:"accept_vulnerabilities,omitempty"`
+}
+
+// CanaryTarget is a container of a canary rollout
+type CanaryTarget struct {
+	HostID        int64  `json:"host_id"`
+	HostName      string `json:"host_name"`
+	ContainerName string `json:"container_name"`
+	Status        string `json:"status"`
+	Error         string `json:"error,omitempty"`
+}
+
+// Running reports whether the rollout hasn't finished yet
+func (c CanaryUpdate) Running() bool {
+	switch c.Status {
+	case CanaryStatusUpdating, CanaryStatusSoaking, CanaryStatusRollingOut:
+		return true
+	}
+	return false
+}
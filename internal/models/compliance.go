@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Compliance check statuses
+const (
+	ComplianceStatusPass = "pass"
+	ComplianceStatusFail = "fail"
+	ComplianceStatusSkip = "skip" // the check could not be evaluated, e.g. a file isn't visible to the auditor
+)
+
+// ComplianceCheck is the result of one CIS Docker Benchmark recommendation on a host
+type ComplianceCheck struct {
+	ID     string `json:"id"` // CIS section number, e.g. "2.14"
+	Title  string `json:"title"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// ComplianceAudit is a point-in-time run of the benchmark checks against a host
+type ComplianceAudit struct {
+	ID        int64             `json:"id"`
+	HostID    int64             `json:"host_id"`
+	HostName  string            `json:"host_name"`
+	Benchmark string            `json:"benchmark"`
+	Checks    []ComplianceCheck `json:"checks"`
+	Passed    int               `json:"passed"`
+	Failed    int               `json:"failed"`
+	Skipped   int               `json:"skipped"`
+	AuditedAt time.Time         `json:"audited_at"`
+}
+
+// Tally recounts the passed, failed and skipped checks
+func (a *ComplianceAudit) Tally() {
+	a.Passed, a.Failed, a.Skipped = 0, 0, 0
+	for _, check := range a.Checks {
+		switch check.Status {
+		case ComplianceStatusPass:
+			a.Passed++
+		case ComplianceStatusFail:
+			a.Failed++
+		default:
+			a.Skipped++
+		}
+	}
+}
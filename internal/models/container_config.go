@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+// ContainerConfig is a sanitized snapshot of a container's configuration captured at scan time.
+// Secret-looking environment values and command arguments are masked before it leaves the host.
+type ContainerConfig struct {
+	Env               []EnvVar        `json:"env"`
+	Mounts            []ConfigMount   `json:"mounts"`
+	RestartPolicy     string          `json:"restart_policy"`
+	RestartMaxRetries int             `json:"restart_max_retries,omitempty"`
+	NetworkMode       string          `json:"network_mode"`
+	PidMode           string          `json:"pid_mode,omitempty"`
+	Networks          []ConfigNetwork `json:"networks"`
+	Entrypoint        []string        `json:"entrypoint,omitempty"`
+	Cmd               []string        `json:"cmd,omitempty"`
+	User              string          `json:"user,omitempty"`
+	WorkingDir        string          `json:"working_dir,omitempty"`
+	Hostname          string          `json:"hostname,omitempty"`
+	Privileged        bool            `json:"privileged"`
+	CapAdd            []string        `json:"cap_add,omitempty"`
+	CapDrop           []string        `json:"cap_drop,omitempty"`
+	// Runtime state from the same inspect, used to track runs of one-shot jobs such as backups
+	ExitCode   int       `json:"exit_code"`
+	StartedAt  time.Time `json:"started_at,omitempty"`
+	FinishedAt time.Time `json:"finished_at,omitempty"`
+}
+
+// EnvVar is a single environment variable; Masked is set when the value was hidden
+type EnvVar struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Masked bool   `json:"masked,omitempty"`
+}
+
+// ConfigMount describes a mount in a container's configuration
+type ConfigMount struct {
+	Type        string `json:"type"`
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Mode        string `json:"mode,omitempty"`
+	RW          bool   `json:"rw"`
+}
+
+// ConfigNetwork describes a network attachment in a container's configuration
+type ConfigNetwork struct {
+	Name       string   `json:"name"`
+	IPAddress  string   `json:"ip_address,omitempty"`
+	Gateway    string   `json:"gateway,omitempty"`
+	MacAddress string   `json:"mac_address,omitempty"`
+	Aliases    []string `json:"aliases,omitempty"`
+}
+
+// ContainerInspection is the stored configuration of a container as of its last scan
+type ContainerInspection struct {
+	ContainerID   string          `json:"container_id"`
+	ContainerName string          `json:"container_name"`
+	HostID        int64           `json:"host_id"`
+	HostName      string          `json:"host_name"`
+	CollectedAt   time.Time       `json:"collected_at"`
+	Config        ContainerConfig `json:"config"`
+}
+
+// RecreateSpec is a container exported as the `docker run` command and compose service that
+// recreate it, generated from its configuration as of the last scan
+type RecreateSpec struct {
+	ContainerID   string    `json:"container_id"`
+	ContainerName string    `json:"container_name"`
+	HostID        int64     `json:"host_id"`
+	HostName      string    `json:"host_name"`
+	CollectedAt   time.Time `json:"collected_at"`
+	RunCommand    string    `json:"run_command"`
+	Compose       string    `json:"compose"`
+	// Environment variables whose values were masked as secrets; the generated specs take them
+	// from the shell or an .env file
+	MaskedEnv []string `json:"masked_env,omitempty"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Kinds of daemon events
+const (
+	DaemonEventOOMKill = "oom_kill"     // the kernel's OOM killer killed a process of a container
+	DaemonEventError   = "daemon_error" // dockerd or containerd logged an error
+)
+
+// DaemonEvent is something an agent read in the host's kernel and Docker daemon logs (journald
+// or syslog) that scans can't see, like OOM kills of processes that the container survived.
+type DaemonEvent struct {
+	ID            int64     `json:"id,omitempty"`
+	HostID        int64     `json:"host_id,omitempty"`
+	HostName      string    `json:"host_name,omitempty"`
+	Kind          string    `json:"kind"`
+	Source        string    `json:"source"` // kernel, dockerd or containerd
+	ContainerID   string    `json:"container_id,omitempty"`
+	ContainerName string    `json:"container_name,omitempty"`
+	Process       string    `json:"process,omitempty"` // the killed process of an OOM kill
+	PID           int       `json:"pid,omitempty"`
+	Message       string    `json:"message"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
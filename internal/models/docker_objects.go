@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Kinds of Docker objects in the inventory, besides containers and images
+const (
+	DockerObjectPlugin     = "plugin"
+	DockerObjectSecret     = "secret" // Swarm managers only
+	DockerObjectConfig     = "config" // Swarm managers only
+	DockerObjectBuildCache = "build_cache"
+)
+
+// DockerObject is a plugin, Swarm secret or config, or the builder cache of a host, listed by
+// scans when the scanner's inventory_objects setting is on. The builder cache is summed up per
+// cache type (regular, source.local, exec.cachemount, ...) instead of one row per record.
+type DockerObject struct {
+	HostID   int64  `json:"host_id"`
+	HostName string `json:"host_name,omitempty"`
+	Kind     string `json:"kind"`
+	ObjectID string `json:"object_id"` // the build cache type for build_cache
+	Name     string `json:"name"`
+	// Plugin reference, or secret and config driver
+	Detail string `json:"detail,omitempty"`
+	// Enabled plugin, or build cache with records in use
+	InUse bool `json:"in_use"`
+	// Disk use of build cache records and the part not in use or shared that a prune frees
+	SizeBytes        int64      `json:"size_bytes"`
+	ReclaimableBytes int64      `json:"reclaimable_bytes"`
+	Count            int        `json:"count"` // build cache records
+	CreatedAt        *time.Time `json:"created_at,omitempty"`
+	LastUsedAt       *time.Time `json:"last_used_at,omitempty"`
+	ScannedAt        time.Time  `json:"scanned_at"`
+}
+
+// DockerObjectSummary counts a host's inventoried objects
+type DockerObjectSummary struct {
+	HostID                     int64     `json:"host_id"`
+	HostName                   string    `json:"host_name"`
+	Plugins                    int       `json:"plugins"`
+	Secrets                    int       `json:"secrets"`
+	Configs                    int       `json:"configs"`
+	BuildCacheBytes            int64     `json:"build_cache_bytes"`
+	BuildCacheReclaimableBytes int64     `json:"build_cache_reclaimable_bytes"`
+	ScannedAt                  time.Time `json:"scanned_at"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// DefaultCertWarningDays is how many days before a certificate expires the first warning is sent
+const DefaultCertWarningDays = 14
+
+// Status of an endpoint check
+const (
+	EndpointStatusOK          = "ok"
+	EndpointStatusExpiring    = "expiring"    // the certificate expires within the warning days
+	EndpointStatusExpired     = "expired"     // the certificate has expired
+	EndpointStatusCertError   = "cert_error"  // the certificate doesn't verify: untrusted, or not for the hostname
+	EndpointStatusUnreachable = "unreachable" // no TLS connection could be made
+	EndpointStatusDNSError    = "dns_error"   // the hostname doesn't resolve
+)
+
+// EndpointCheck is the latest DNS and TLS certificate check of a public hostname found in the
+// proxy routes
+type EndpointCheck struct {
+	Hostname string `json:"hostname"`
+	URL      string `json:"url"`  // a route URL of the hostname
+	Port     int    `json:"port"` // the TLS port checked; 0 for hostnames only served over http
+	// The container serving the first route of the hostname
+	HostID        int64  `json:"host_id,omitempty"`
+	HostName      string `json:"host_name,omitempty"`
+	ContainerName string `json:"container_name,omitempty"`
+
+	Addresses []string `json:"addresses,omitempty"`
+	DNSError  string   `json:"dns_error,omitempty"`
+
+	CertSubject   string     `json:"cert_subject,omitempty"`
+	CertIssuer    string     `json:"cert_issuer,omitempty"`
+	CertDNSNames  []string   `json:"cert_dns_names,omitempty"`
+	CertNotAfter  *time.Time `json:"cert_not_after,omitempty"`
+	DaysRemaining *int       `json:"days_remaining,omitempty"`
+	CertError     string     `json:"cert_error,omitempty"` // the connection or verification error
+
+	Status    string    `json:"status"`
+	CheckedAt time.Time `json:"checked_at"`
+
+	// The last expiry warning sent: how urgent it was (see endpoints.WarningLevel) and the
+	// expiry of the certificate it was for, so a renewed certificate starts over
+	CertWarningLevel    int        `json:"cert_warning_level,omitempty"`
+	CertWarningNotAfter *time.Time `json:"cert_warning_not_after,omitempty"`
+}
+
+// EndpointAlert is a notification an endpoint check raised: cert_expiring or dns_failure
+type EndpointAlert struct {
+	EventType string
+	Check     EndpointCheck
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultSchemePorts maps common URL schemes to their default ports
+var defaultSchemePorts = map[string]int{
+	"http":       80,
+	"https":      443,
+	"ws":         80,
+	"wss":        443,
+	"postgres":   5432,
+	"postgresql": 5432,
+	"mysql":      3306,
+	"mariadb":    3306,
+	"redis":      6379,
+	"rediss":     6379,
+	"mongodb":    27017,
+	"amqp":       5672,
+	"amqps":      5671,
+	"mqtt":       1883,
+	"nats":       4222,
+	"smtp":       25,
+	"ldap":       389,
+	"ldaps":      636,
+}
+
+var hostPortPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9.-]*:[0-9]{1,5}$`)
+
+// ExtractEnvEndpoints finds host:port references in container environment variables.
+// It recognizes URLs, bare host:port values (including comma-separated lists) and
+// FOO_HOST/FOO_PORT pairs. Only the host and port are kept, so credentials in
+// connection strings are never returned. Loopback and wildcard hosts are skipped.
+func ExtractEnvEndpoints(env []string) []string {
+	vars := make(map[string]string, len(env))
+	for _, entry := range env {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok || value == "" {
+			continue
+		}
+		vars[key] = value
+	}
+
+	found := make(map[string]bool)
+	add := func(host string, port int) {
+		host = strings.ToLower(strings.TrimSuffix(host, "."))
+		if host == "" || port <= 0 || port > 65535 || isLocalEndpointHost(host) {
+			return
+		}
+		found[net.JoinHostPort(host, strconv.Itoa(port))] = true
+	}
+
+	for key, value := range vars {
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+
+			if strings.Contains(part, "://") {
+				u, err := url.Parse(part)
+				if err != nil || u.Hostname() == "" {
+					continue
+				}
+				port, _ := strconv.Atoi(u.Port())
+				if port == 0 {
+					port = defaultSchemePorts[strings.ToLower(u.Scheme)]
+				}
+				add(u.Hostname(), port)
+				continue
+			}
+
+			if hostPortPattern.MatchString(part) {
+				host, portStr, _ := strings.Cut(part, ":")
+				port, _ := strconv.Atoi(portStr)
+				add(host, port)
+			}
+		}
+
+		// FOO_HOST=db.lan with FOO_PORT=5432
+		if prefix, ok := strings.CutSuffix(key, "_HOST"); ok && !strings.Contains(value, ":") {
+			if port, err := strconv.Atoi(vars[prefix+"_PORT"]); err == nil {
+				add(value, port)
+			}
+		}
+	}
+
+	endpoints := make([]string, 0, len(found))
+	for endpoint := range found {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+	return endpoints
+}
+
+// isLocalEndpointHost reports whether the host refers to the container or its own host
+func isLocalEndpointHost(host string) bool {
+	switch host {
+	case "localhost", "0.0.0.0", "::", "::1", "host.docker.internal":
+		return true
+	}
+	return strings.HasPrefix(host, "127.")
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Raw events published on the event bus. Unlike notifications they aren't filtered by rules,
+// silences or rate limits: every subscription gets every event of the types it asks for.
+const (
+	BusEventScanCompleted     = "scan_completed"     // a host was scanned
+	BusEventScanFailed        = "scan_failed"        // scanning a host failed
+	BusEventContainerAppeared = "container_appeared" // a container not on the host at the previous scan
+	BusEventContainerRemoved  = "container_removed"  // a container no longer on the host
+	BusEventContainerStarted  = "container_started"  // a container is running again
+	BusEventContainerStopped  = "container_stopped"  // a running container isn't anymore
+	BusEventImageChanged      = "image_changed"      // a container runs another image than at the previous scan
+	BusEventUpdateAvailable   = "update_available"   // the update checker found a newer image
+	BusEventUpdatePerformed   = "update_performed"   // Census updated a container (succeeded, failed or rolled back)
+)
+
+// BusEventTypes lists every event bus event type
+var BusEventTypes = []string{
+	BusEventScanCompleted, BusEventScanFailed,
+	BusEventContainerAppeared, BusEventContainerRemoved, BusEventContainerStarted, BusEventContainerStopped, BusEventImageChanged,
+	BusEventUpdateAvailable, BusEventUpdatePerformed,
+}
+
+// ValidBusEventType reports whether t is a known event bus event type
+func ValidBusEventType(t string) bool {
+	for _, known := range BusEventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// BusEvent is an event on the event bus. IDs increase, so a consumer can replay every event
+// after the last one it handled.
+type BusEvent struct {
+	ID            int64                  `json:"id"`
+	Type          string                 `json:"type"`
+	Timestamp     time.Time              `json:"timestamp"`
+	HostID        int64                  `json:"host_id,omitempty"`
+	HostName      string                 `json:"host_name,omitempty"`
+	ContainerName string                 `json:"container_name,omitempty"`
+	Data          map[string]interface{} `json:"data,omitempty"`
+}
+
+// Event subscription URL schemes
+const (
+	SubscriptionSchemeHTTP    = "http"
+	SubscriptionSchemeHTTPS   = "https"
+	SubscriptionSchemeNATS    = "nats"  // nats://[user:pass@]host:4222/subject-prefix
+	SubscriptionSchemeNATSTLS = "tls"   // tls://[user:pass@]host:4222/subject-prefix, NATS over TLS
+	SubscriptionSchemeMQTT    = "mqtt"  // mqtt://[user:pass@]host:1883/topic-prefix
+	SubscriptionSchemeMQTTS   = "mqtts" // mqtts://[user:pass@]host:8883/topic-prefix, MQTT over TLS
+)
+
+// EventSubscription sends the events of some types to a URL: each event is POSTed as JSON to an
+// http(s) URL, or published to <prefix>.<type> on a NATS server or <prefix>/<type> on an MQTT
+// broker. Events are delivered in order and at least once: Cursor is the ID of the last event
+// delivered, and moving it back replays events.
+type EventSubscription struct {
+	ID         int64    `json:"id"`
+	Name       string   `json:"name"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"` // empty subscribes to every type
+	// Secret signs http(s) deliveries with HMAC-SHA256 in the X-Census-Signature-256 header
+	Secret         string     `json:"secret,omitempty"`
+	Enabled        bool       `json:"enabled"`
+	Cursor         int64      `json:"cursor"`
+	LastDeliveryAt *time.Time `json:"last_delivery_at,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// Validate checks the name, URL and event types
+func (s *EventSubscription) Validate() error {
+	s.Name = strings.TrimSpace(s.Name)
+	s.URL = strings.TrimSpace(s.URL)
+	if s.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	u, err := url.Parse(s.URL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("url must be an http(s), nats, tls, mqtt or mqtts URL")
+	}
+	switch u.Scheme {
+	case SubscriptionSchemeHTTP, SubscriptionSchemeHTTPS, SubscriptionSchemeNATS, SubscriptionSchemeNATSTLS, SubscriptionSchemeMQTT, SubscriptionSchemeMQTTS:
+	default:
+		return fmt.Errorf("url must be an http(s), nats, tls, mqtt or mqtts URL")
+	}
+	for _, t := range s.EventTypes {
+		if !ValidBusEventType(t) {
+			return fmt.Errorf("unknown event type: %s", t)
+		}
+	}
+	return nil
+}
+
+// Wants reports whether the subscription gets events of type t
+func (s *EventSubscription) Wants(t string) bool {
+	if len(s.EventTypes) == 0 {
+		return true
+	}
+	for _, want := range s.EventTypes {
+		if want == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Redacted returns a copy of the subscription that is safe to return from the API: the secret
+// and a password in the URL are masked
+func (s *EventSubscription) Redacted() *EventSubscription {
+	clone := *s
+	if clone.Secret != "" {
+		clone.Secret = MaskedSecret
+	}
+	if u, err := url.Parse(clone.URL); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), MaskedSecret)
+			clone.URL = u.String()
+		}
+	}
+	return &clone
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Port binding classifications
+const (
+	BindingAllInterfaces = "all_interfaces" // 0.0.0.0 or ::, reachable from any network the host is on
+	BindingLoopback      = "loopback"       // 127.0.0.1 or ::1, only reachable from the host itself
+	BindingSpecific      = "specific"       // a single host IP
+)
+
+// Reachability probe results
+const (
+	ProbeReachable   = "reachable"
+	ProbeUnreachable = "unreachable"
+	ProbeSkipped     = "skipped"
+)
+
+// PortExposure is a container port published on a host
+type PortExposure struct {
+	HostID        int64  `json:"host_id"`
+	HostName      string `json:"host_name"`
+	ContainerID   string `json:"container_id"`
+	ContainerName string `json:"container_name"`
+	Image         string `json:"image"`
+	IP            string `json:"ip"`
+	PublicPort    int    `json:"public_port"`
+	PrivatePort   int    `json:"private_port"`
+	Protocol      string `json:"protocol"`
+	Binding       string `json:"binding"`
+	// Set when the report was probed from the server
+	ProbeTarget string `json:"probe_target,omitempty"`
+	Probe       string `json:"probe,omitempty"`
+	ProbeDetail string `json:"probe_detail,omitempty"`
+}
+
+// PortExposureSummary counts published ports by binding and probe result
+type PortExposureSummary struct {
+	Total         int  `json:"total"`
+	AllInterfaces int  `json:"all_interfaces"`
+	Loopback      int  `json:"loopback"`
+	Specific      int  `json:"specific"`
+	Probed        bool `json:"probed"`
+	Reachable     int  `json:"reachable"`
+}
+
+// PortExposureReport lists the host-published ports across the fleet
+type PortExposureReport struct {
+	GeneratedAt time.Time           `json:"generated_at"`
+	Summary     PortExposureSummary `json:"summary"`
+	Ports       []PortExposure      `json:"ports"`
+}
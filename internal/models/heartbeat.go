@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Defaults of the host down/recovered hysteresis: a host is reported down after two failed scans
+// in a row and recovered after one successful scan
+const (
+	DefaultHostDownAfterFailures       = 2
+	DefaultHostRecoveredAfterSuccesses = 1
+)
+
+// HostHeartbeat is the outcome of one scan of a host
+type HostHeartbeat struct {
+	HostID    int64     `json:"host_id"`
+	Timestamp time.Time `json:"timestamp"`
+	Online    bool      `json:"online"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// HostDowntime is a period during which a host was unreachable. EndedAt is nil while the host
+// is still down.
+type HostDowntime struct {
+	ID        int64      `json:"id"`
+	HostID    int64      `json:"host_id"`
+	HostName  string     `json:"host_name"`
+	StartedAt time.Time  `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// Duration returns how long the host was down, up to now for an ongoing downtime
+func (d HostDowntime) Duration(now time.Time) time.Duration {
+	if d.EndedAt != nil {
+		return d.EndedAt.Sub(d.StartedAt)
+	}
+	return now.Sub(d.StartedAt)
+}
+
+// HostStatusChange is a host going down or recovering, with the downtime it opened or closed
+type HostStatusChange struct {
+	EventType string       `json:"event_type"` // host_down or host_recovered
+	Downtime  HostDowntime `json:"downtime"`
+}
+
+// HostUptime is the reachability timeline of a host over a window
+type HostUptime struct {
+	HostID        int64           `json:"host_id"`
+	HostName      string          `json:"host_name"`
+	Since         time.Time       `json:"since"`
+	Until         time.Time       `json:"until"`
+	UptimePercent float64         `json:"uptime_percent"`
+	Down          bool            `json:"down"`
+	Heartbeats    []HostHeartbeat `json:"heartbeats"`
+	Downtimes     []HostDowntime  `json:"downtimes"`
+}
@@ -0,0 +1,97 @@
+package models
+
+import "time"
+
+// States of a host migration
+const (
+	HostMigrationPlanned    = "planned"     // nothing moved yet
+	HostMigrationInProgress = "in_progress" // some containers moved or being moved
+	HostMigrationCompleted  = "completed"   // every container migrated or skipped
+)
+
+// States of a container in a host migration
+const (
+	MigrationItemPending    = "pending"
+	MigrationItemInProgress = "in_progress"
+	MigrationItemMigrated   = "migrated" // set by hand, or once a scan finds it running on the destination only
+	MigrationItemSkipped    = "skipped"
+	MigrationItemFailed     = "failed"
+)
+
+// Where a step of a migration runs
+const (
+	MigrationOnSource      = "source"
+	MigrationOnDestination = "destination"
+)
+
+// HostMigration is a plan to move containers from one host to another, with the commands for
+// each and how far the move has got. The commands are generated from the last scan; the
+// container's data is copied over ssh from the source host to $DEST, the destination's ssh target.
+type HostMigration struct {
+	ID             int64           `json:"id"`
+	SourceHostID   int64           `json:"source_host_id"`
+	SourceHostName string          `json:"source_host_name"`
+	DestHostID     int64           `json:"dest_host_id"`
+	DestHostName   string          `json:"dest_host_name"`
+	SSHTarget      string          `json:"ssh_target"` // suggested value of $DEST
+	Status         string          `json:"status"`
+	CreatedBy      string          `json:"created_by,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	Networks       []MigrationStep `json:"networks,omitempty"` // networks to create on the destination first
+	Warnings       []string        `json:"warnings,omitempty"`
+	Items          []MigrationItem `json:"items"`
+}
+
+// MigrationItem is one container of a host migration
+type MigrationItem struct {
+	ContainerName  string          `json:"container_name"`
+	Image          string          `json:"image"`
+	ComposeProject string          `json:"compose_project,omitempty"`
+	Volumes        []string        `json:"volumes,omitempty"`     // named volumes whose data is copied
+	BindMounts     []string        `json:"bind_mounts,omitempty"` // host paths whose data is copied
+	Networks       []string        `json:"networks,omitempty"`
+	Ports          []string        `json:"ports,omitempty"`
+	RunCommand     string          `json:"run_command"`
+	ComposeService string          `json:"compose_service,omitempty"`
+	Steps          []MigrationStep `json:"steps"`
+	Warnings       []string        `json:"warnings,omitempty"`
+	Status         string          `json:"status"`
+	Notes          string          `json:"notes,omitempty"`
+	UpdatedAt      *time.Time      `json:"updated_at,omitempty"`
+	// What the latest scans of both hosts show; filled in when the migration is read
+	SourceState string `json:"source_state,omitempty"`
+	DestState   string `json:"dest_state,omitempty"`
+}
+
+// MigrationStep is a shell command of a migration and the host it runs on
+type MigrationStep struct {
+	Host    string `json:"host"` // MigrationOnSource or MigrationOnDestination
+	Command string `json:"command"`
+}
+
+// Finished reports whether nothing is left to do for the item
+func (i MigrationItem) Finished() bool {
+	return i.Status == MigrationItemMigrated || i.Status == MigrationItemSkipped
+}
+
+// UpdateStatus derives the migration's status from its items
+func (m *HostMigration) UpdateStatus() {
+	finished, started := 0, 0
+	for _, item := range m.Items {
+		if item.Finished() {
+			finished++
+		}
+		if item.Status != MigrationItemPending {
+			started++
+		}
+	}
+	switch {
+	case len(m.Items) > 0 && finished == len(m.Items):
+		m.Status = HostMigrationCompleted
+	case started > 0:
+		m.Status = HostMigrationInProgress
+	default:
+		m.Status = HostMigrationPlanned
+	}
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HostSystem is what a host's Docker engine and OS report about themselves: versions, and
+// whether a restart is pending to take an installed update into use. Agents fill in the
+// pending restarts; hosts scanned over the Docker API only report versions.
+type HostSystem struct {
+	DockerVersion   string `json:"docker_version"`
+	APIVersion      string `json:"api_version,omitempty"`
+	OperatingSystem string `json:"operating_system,omitempty"`
+	KernelVersion   string `json:"kernel_version,omitempty"`
+	// The OS asks for a reboot (/run/reboot-required) or a newer kernel is installed
+	RebootRequired bool     `json:"reboot_required"`
+	RebootReasons  []string `json:"reboot_reasons,omitempty"`
+	// A different dockerd is installed than the one running, so the daemon awaits a restart
+	InstalledDockerVersion string    `json:"installed_docker_version,omitempty"`
+	DaemonRestartRequired  bool      `json:"daemon_restart_required"`
+	CollectedAt            time.Time `json:"collected_at"`
+}
+
+// Warnings of the fleet engine report
+const (
+	EngineWarningRebootRequired        = "reboot_required"
+	EngineWarningDaemonRestartRequired = "daemon_restart_required"
+	EngineWarningOutdated              = "outdated_engine"
+)
+
+// HostEngine is a host's entry in the fleet engine report
+type HostEngine struct {
+	HostID   int64       `json:"host_id"`
+	HostName string      `json:"host_name"`
+	HostType string      `json:"host_type"`
+	System   *HostSystem `json:"system,omitempty"` // nil until the host is scanned
+	// Major versions behind the newest engine of the fleet
+	MajorsBehind int      `json:"majors_behind"`
+	Warnings     []string `json:"warnings"`
+}
+
+// EngineReport lists the Docker engines of the fleet, with hosts whose engine is outdated or
+// that wait for a reboot or daemon restart
+type EngineReport struct {
+	LatestVersion string         `json:"latest_version"` // newest engine running in the fleet
+	Versions      map[string]int `json:"versions"`       // hosts by engine version
+	Hosts         []HostEngine   `json:"hosts"`
+}
+
+// CompareVersions compares version strings such as Docker engine versions (27.3.1, 20.10.24)
+// or kernel releases (6.8.0-45-generic) by their numeric parts, returning -1, 0 or 1
+func CompareVersions(a, b string) int {
+	pa, pb := versionNumbers(a), versionNumbers(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x < y {
+			return -1
+		}
+		if x > y {
+			return 1
+		}
+	}
+	return 0
+}
+
+// MajorVersion returns the leading number of a version string, or -1 without one
+func MajorVersion(version string) int {
+	numbers := versionNumbers(version)
+	if len(numbers) == 0 {
+		return -1
+	}
+	return numbers[0]
+}
+
+func versionNumbers(version string) []int {
+	fields := strings.FieldsFunc(version, func(r rune) bool { return r < '0' || r > '9' })
+	numbers := make([]int, 0, len(fields))
+	for _, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			break
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// HostTemplate holds the settings new hosts start from, to keep a fleet consistent. Templates
+// are saved on their own, or taken from an existing host to clone it.
+type HostTemplate struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	// Host settings; empty strings leave the host's own value
+	Description    string `json:"description,omitempty"`
+	CollectStats   bool   `json:"collect_stats"`
+	RegistryMirror string `json:"registry_mirror,omitempty"`
+	Site           string `json:"site,omitempty"`
+	// Notification rules every host gets a copy of, scoped to it (ID and HostID are ignored)
+	NotificationRules []NotificationRule `json:"notification_rules"`
+	// Name of the host the template was taken from; copied rule names have it replaced by the
+	// new host's name
+	SourceHost string    `json:"source_host,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// ApplyTo sets the template's stats collection on a host and fills the settings the host
+// doesn't have
+func (t *HostTemplate) ApplyTo(host *Host) {
+	host.CollectStats = t.CollectStats
+	if host.Description == "" {
+		host.Description = t.Description
+	}
+	if host.RegistryMirror == "" {
+		host.RegistryMirror = t.RegistryMirror
+	}
+	if host.Site == "" {
+		host.Site = t.Site
+	}
+}
+
+// RulesFor returns the template's notification rules as new rules scoped to a host
+func (t *HostTemplate) RulesFor(host Host) []NotificationRule {
+	rules := make([]NotificationRule, 0, len(t.NotificationRules))
+	for _, rule := range t.NotificationRules {
+		hostID := host.ID
+		rule.ID = 0
+		rule.HostID = &hostID
+		rule.TenantID = host.TenantID
+		if t.SourceHost != "" && t.SourceHost != host.Name {
+			rule.Name = strings.ReplaceAll(rule.Name, t.SourceHost, host.Name)
+		}
+		rule.ChannelIDs = append([]int64(nil), rule.ChannelIDs...)
+		rule.EventTypes = append([]string(nil), rule.EventTypes...)
+		rules = append(rules, rule)
+	}
+	return rules
+}
@@ -0,0 +1,89 @@
+package models
+
+import "time"
+
+// ImageRun is a period in which a container ran one image build, from the first to the last
+// scan that saw it. Builds are told apart by image ID, the digest of the image's content, so
+// a re-pulled :latest that changed is a new run even though the image name is the same.
+type ImageRun struct {
+	HostID        int64     `json:"host_id"`
+	HostName      string    `json:"host_name"`
+	ContainerName string    `json:"container_name"`
+	Image         string    `json:"image"` // name the container ran it under, e.g. "nginx:latest"
+	ImageID       string    `json:"image_id"`
+	FirstSeen     time.Time `json:"first_seen"`
+	LastSeen      time.Time `json:"last_seen"`
+	FirstScanID   string    `json:"first_scan_id,omitempty"`
+	LastScanID    string    `json:"last_scan_id,omitempty"`
+	Current       bool      `json:"current"` // seen in the host's latest scan
+	// Latest vulnerability scan of the image; nil when it was never scanned
+	Vulnerabilities *ImageRunVulnerabilities `json:"vulnerabilities,omitempty"`
+}
+
+// ImageRunVulnerabilities is the latest vulnerability scan of the image of an image run
+type ImageRunVulnerabilities struct {
+	ScannedAt time.Time `json:"scanned_at"`
+	Success   bool      `json:"success"`
+	Total     int       `json:"total"`
+	Critical  int       `json:"critical"`
+	High      int       `json:"high"`
+	Medium    int       `json:"medium"`
+	Low       int       `json:"low"`
+	// Vulnerability IDs found in the image that match the timeline's vulnerability or package
+	Matches []string `json:"matches,omitempty"`
+}
+
+// SupplyChainFilter selects the image runs of a supply-chain timeline; empty fields don't filter
+type SupplyChainFilter struct {
+	HostIDs       []int64 // nil for every host
+	ContainerName string
+	Image         string // part of the image name, e.g. "postgres"
+	ImageID       string // start of the image ID, with or without "sha256:"
+	Vulnerability string // runs of images whose scan found it, e.g. "CVE-2024-3094"
+	Package       string // runs of images whose scan found a vulnerable version of it, e.g. "xz-utils"
+}
+
+// SupplyChainTimeline lists the image runs matching a filter, oldest first, to answer
+// questions like "when did we first run an image with the compromised xz?"
+type SupplyChainTimeline struct {
+	Runs       []ImageRun `json:"runs"`
+	FirstSeen  *time.Time `json:"first_seen,omitempty"` // first time a matching image ran
+	LastSeen   *time.Time `json:"last_seen,omitempty"`
+	Containers int        `json:"containers"` // distinct containers (host and name)
+	Images     int        `json:"images"`     // distinct image IDs
+	Current    int        `json:"current"`    // runs still seen in their host's latest scan
+}
+
+// ImageSearch selects the containers of a fleet-wide image usage search
+type ImageSearch struct {
+	Image   string    // part of the image reference or of an image tag, or the start of the image ID
+	Since   time.Time // containers seen with the image since then
+	HostIDs []int64   // nil for every host
+}
+
+// ImageSearchContainer is a container that ran an image matching a fleet-wide image usage search,
+// as the latest scan that saw it with the image recorded it
+type ImageSearchContainer struct {
+	HostID        int64     `json:"host_id"`
+	HostName      string    `json:"host_name"`
+	ContainerID   string    `json:"container_id"`
+	ContainerName string    `json:"container_name"`
+	Image         string    `json:"image"`
+	ImageID       string    `json:"image_id"`
+	ImageTags     []string  `json:"image_tags"` // tags and org.opencontainers.image.version of the image
+	State         string    `json:"state"`
+	Status        string    `json:"status"`
+	FirstSeen     time.Time `json:"first_seen"` // from the image history, so possibly before the search window
+	LastSeen      time.Time `json:"last_seen"`
+	Current       bool      `json:"current"` // still runs the image in its host's latest scan
+}
+
+// ImageSearchResult answers "where do we run this image?", current containers first
+type ImageSearchResult struct {
+	Image      string                 `json:"image"`
+	Since      time.Time              `json:"since"`
+	Containers []ImageSearchContainer `json:"containers"`
+	Hosts      int                    `json:"hosts"`   // distinct hosts
+	Current    int                    `json:"current"` // containers still running the image
+	Running    int                    `json:"running"` // of those, in the running state
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// ImageLayers is the layer breakdown and build history of an image.
+// Image IDs are content-addressed, so a collected breakdown never goes stale for the same ID.
+type ImageLayers struct {
+	ImageID     string       `json:"image_id"`
+	HostID      int64        `json:"host_id"`
+	RepoTags    []string     `json:"repo_tags"`
+	Size        int64        `json:"size"`
+	Layers      []ImageLayer `json:"layers"`
+	CollectedAt time.Time    `json:"collected_at"`
+	Cached      bool         `json:"cached"`
+	// Set when the layers were compared against another image; see ImageLayer.Shared
+	ComparedTo string `json:"compared_to,omitempty"`
+}
+
+// ImageLayer is one Dockerfile history step, oldest first. Steps that only change
+// metadata (ENV, CMD, LABEL, ...) have Empty set and no DiffID.
+type ImageLayer struct {
+	CreatedBy string    `json:"created_by"`
+	Created   time.Time `json:"created"`
+	Size      int64     `json:"size"`
+	Comment   string    `json:"comment,omitempty"`
+	Tags      []string  `json:"tags,omitempty"`
+	Empty     bool      `json:"empty"`
+	DiffID    string    `json:"diff_id,omitempty"`
+	Shared    bool      `json:"shared,omitempty"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// ImageUsage describes how recently an image on a host was used by a running container
+type ImageUsage struct {
+	ImageID   string     `json:"image_id"`
+	RepoTags  []string   `json:"repo_tags"`
+	Size      int64      `json:"size"`
+	Created   time.Time  `json:"created"`
+	Dangling  bool       `json:"dangling"`
+	InUse     bool       `json:"in_use"`               // referenced by a container in the latest scan (running or not)
+	FirstSeen *time.Time `json:"first_seen,omitempty"` // first time census saw the image on the host
+	LastUsed  *time.Time `json:"last_used,omitempty"`  // last scan in which a running container used the image
+	// Days since the image was last used, or since it was first seen if it never ran
+	UnusedDays int `json:"unused_days"`
+}
+
+// ImageUsageRecord is the stored usage history of an image on a host
+type ImageUsageRecord struct {
+	FirstSeen time.Time
+	LastUsed  *time.Time
+}
+
+// ImagePrunePolicy controls which unused images a policy-driven prune removes
+type ImagePrunePolicy struct {
+	KeepTagsPerRepo int  `json:"keep_tags_per_repo"` // newest tagged images kept per repository
+	MinUnusedDays   int  `json:"min_unused_days"`    // images used more recently than this are never pruned
+	DryRun          bool `json:"dry_run"`
+}
+
+// DefaultImagePrunePolicy keeps the two newest tags per repository and anything used in the last 30 days
+func DefaultImagePrunePolicy() ImagePrunePolicy {
+	return ImagePrunePolicy{
+		KeepTagsPerRepo: 2,
+		MinUnusedDays:   30,
+	}
+}
+
+// ImagePruneCandidate is an image selected for removal by a prune policy
+type ImagePruneCandidate struct {
+	ImageUsage
+	Reason string `json:"reason"`
+}
+
+// ImagePruneResult reports the outcome of a policy-driven prune
+type ImagePruneResult struct {
+	DryRun         bool                  `json:"dry_run"`
+	Policy         ImagePrunePolicy      `json:"policy"`
+	Candidates     []ImagePruneCandidate `json:"candidates"`
+	Removed        int                   `json:"removed"`
+	SpaceReclaimed int64                 `json:"space_reclaimed"`
+	Errors         []string              `json:"errors,omitempty"`
+}
@@ -17,10 +17,94 @@ type Host struct {
 	LastSeen     time.Time `json:"last_seen,omitempty"`
 	Enabled      bool      `json:"enabled"`
 	CollectStats bool      `json:"collect_stats"` // whether to collect CPU/memory stats for this host
+	// Pull-through cache for Docker Hub images, e.g. "harbor.local/dockerhub" (empty pulls directly)
+	RegistryMirror string    `json:"registry_mirror,omitempty"`
+	// Site or location the host belongs to, e.g. "home" or "vps" (empty when unassigned)
+	Site string `json:"site,omitempty"`
+	// Tenant that owns the host (0 when only the administrator sees it)
+	TenantID int64 `json:"tenant_id,omitempty"`
+	// The Proxmox VM or container the host lives on, set by the API when the Proxmox integration is enabled
+	Proxmox *ProxmoxGuest `json:"proxmox,omitempty"`
+	// What an agent reported about itself after its latest scan, set by the API for agents
+	AgentHealth *AgentHealth `json:"agent_health,omitempty"`
+	// Documentation kept with the host, set by the API
+	Notes *Notes `json:"notes,omitempty"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
+// Tenant is an isolated environment on a shared server, such as a family or client. Tenant users
+// only see the tenant's hosts and their containers, images and notifications.
+type Tenant struct {
+	ID        int64        `json:"id"`
+	Name      string       `json:"name"`
+	Users     []TenantUser `json:"users"`
+	HostIDs   []int64      `json:"host_ids"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// TenantUser is a login of a tenant. Usernames are unique across tenants.
+type TenantUser struct {
+	ID        int64     `json:"id"`
+	TenantID  int64     `json:"tenant_id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OrphanReport counts the rows left behind by deleted hosts and containers, per table
+type OrphanReport struct {
+	StaleDays int              `json:"stale_days"`
+	DryRun    bool             `json:"dry_run"`
+	Orphans   map[string]int64 `json:"orphans"`
+	Total     int64            `json:"total"`
+}
+
+// SiteSummary aggregates the hosts and containers of one site. Hosts without a site are
+// summarized under an empty name.
+type SiteSummary struct {
+	Name             string  `json:"name"`
+	Health           string  `json:"health"` // healthy, degraded (some hosts offline) or down
+	Hosts            int     `json:"hosts"`
+	HostsOnline      int     `json:"hosts_online"`
+	HostsDisabled    int     `json:"hosts_disabled"`
+	Stacks           int     `json:"stacks"` // compose projects
+	Containers       int     `json:"containers"`
+	Running          int     `json:"running"`
+	Stopped          int     `json:"stopped"`
+	UpdatesAvailable int     `json:"updates_available"`
+	CPUPercent       float64 `json:"cpu_percent"`  // sum over running containers
+	MemoryUsage      int64   `json:"memory_usage"` // bytes, sum over running containers
+}
+
+// Site health values
+const (
+	SiteHealthy  = "healthy"
+	SiteDegraded = "degraded"
+	SiteDown     = "down"
+)
+
+// HostTypeIncus is the type of Incus and LXD hosts (incus:// and lxd:// addresses), whose system
+// containers are inventoried through the Incus API
+const HostTypeIncus = "incus"
+
+// Types of Docker hosts: the local socket (unix://) and census agents
+const (
+	HostTypeUnix  = "unix"
+	HostTypeAgent = "agent"
+)
+
+// The local host created on first run
+const (
+	LocalHostName       = "local"
+	DefaultLocalAddress = "unix:///var/run/docker.sock"
+)
+
+// IsDocker reports whether a host runs Docker. Incus hosts support scans and start, stop and
+// restart, but have no images, logs, updates or compliance audits.
+func (h Host) IsDocker() bool {
+	return h.HostType != HostTypeIncus
+}
+
 // Container represents a Docker container found on a host
 type Container struct {
 	ID           string            `json:"id"`
@@ -38,19 +122,103 @@ type Container struct {
 	HostID       int64             `json:"host_id"`
 	HostName     string            `json:"host_name"`
 	ScannedAt    time.Time         `json:"scanned_at"`
+	// ID of the scan that recorded this row, as in the scan result and the server and agent logs
+	ScanID string `json:"scan_id,omitempty"`
 	// Resource usage stats (may be zero if not collected or if container is idle)
-	CPUPercent    float64 `json:"cpu_percent"`
-	MemoryUsage   int64   `json:"memory_usage"`   // bytes
-	MemoryLimit   int64   `json:"memory_limit"`   // bytes
-	MemoryPercent float64 `json:"memory_percent"`
+	CPUPercent     float64 `json:"cpu_percent"`
+	MemoryUsage    int64   `json:"memory_usage"` // bytes
+	MemoryLimit    int64   `json:"memory_limit"` // bytes
+	MemoryPercent  float64 `json:"memory_percent"`
+	NetworkRxRate  float64 `json:"network_rx_rate"`  // bytes/sec received
+	NetworkTxRate  float64 `json:"network_tx_rate"`  // bytes/sec sent
+	BlockReadRate  float64 `json:"block_read_rate"`  // bytes/sec read from block devices
+	BlockWriteRate float64 `json:"block_write_rate"` // bytes/sec written to block devices
 	// Connection information for graph visualization
 	Networks       []string      `json:"networks,omitempty"`        // Network names this container is connected to
 	Volumes        []VolumeMount `json:"volumes,omitempty"`         // Volume mounts
 	Links          []string      `json:"links,omitempty"`           // Container links (legacy)
 	ComposeProject string        `json:"compose_project,omitempty"` // Docker Compose project name
+	EnvEndpoints   []string      `json:"env_endpoints,omitempty"`   // host:port references found in environment variables
+	// Sanitized configuration captured at scan time (stored separately, not in history rows)
+	Config *ContainerConfig `json:"config,omitempty"`
 	// Image update tracking
 	UpdateAvailable   bool      `json:"update_available"`
 	LastUpdateCheck   time.Time `json:"last_update_check,omitempty"`
+	// Availability from an external uptime monitor, set by the API when the Uptime Kuma integration is enabled
+	Uptime *UptimeMonitorStatus `json:"uptime,omitempty"`
+	// Set when the container is pinned against updates (by label or API); not stored with scans
+	Pin *ContainerPin `json:"pin,omitempty"`
+	// Set while a start/stop/restart/remove/update started through the API is running
+	Operation *ContainerOperation `json:"operation,omitempty"`
+	// Icon, URLs and links from the container's Homepage, Traefik and image labels; set by the API
+	Service *ServiceMetadata `json:"service,omitempty"`
+	// Documentation kept with the container (by name), set by the API
+	Notes *Notes `json:"notes,omitempty"`
+	// Who is responsible for the container (label, or assigned to it or its stack), set by the API
+	Owner *Owner `json:"owner,omitempty"`
+	// Set when the container's recent scans all reported zero memory usage and limit (a stats collection problem)
+	ZeroStats bool `json:"zero_stats,omitempty"`
+	// Problems collecting this container's details at scan time; reported with the scan result, not stored with the container
+	CollectionWarnings []CollectionWarning `json:"collection_warnings,omitempty"`
+}
+
+// CollectionWarning is a problem collecting one container's details during a scan. The
+// container is still recorded, but the values of that stage are missing (stats read as zero).
+type CollectionWarning struct {
+	ContainerID   string `json:"container_id"`
+	ContainerName string `json:"container_name"`
+	Stage         string `json:"stage"` // inspect, stats, zero_stats
+	Message       string `json:"message"`
+}
+
+// Collection warning stages
+const (
+	CollectionStageInspect = "inspect"
+	CollectionStageStats   = "stats"
+	// Stats were read, but Docker reported zero memory usage and limit (a collection problem, not an idle container)
+	CollectionStageZeroStats = "zero_stats"
+)
+
+// AddCollectionWarning records a collection problem on the container
+func (c *Container) AddCollectionWarning(stage string, err error) {
+	c.CollectionWarnings = append(c.CollectionWarnings, CollectionWarning{
+		ContainerID:   c.ID,
+		ContainerName: c.Name,
+		Stage:         stage,
+		Message:       err.Error(),
+	})
+}
+
+// CollectionWarnings returns the collection warnings of scanned containers, for the scan result
+func CollectionWarnings(containers []Container) []CollectionWarning {
+	var warnings []CollectionWarning
+	for _, c := range containers {
+		warnings = append(warnings, c.CollectionWarnings...)
+	}
+	return warnings
+}
+
+// HostResult reports how one host answered a request that spans several hosts
+type HostResult struct {
+	HostID     int64  `json:"host_id"`
+	HostName   string `json:"host_name"`
+	Status     string `json:"status"` // ok, error
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// Host result statuses
+const (
+	HostResultOK    = "ok"
+	HostResultError = "error"
+)
+
+// ContainerOperation is an action on a container that is in progress
+type ContainerOperation struct {
+	HostID        int64     `json:"host_id"`
+	ContainerName string    `json:"container_name"`
+	Action        string    `json:"action"` // start, stop, restart, remove, update
+	StartedAt     time.Time `json:"started_at"`
 }
 
 // PortMapping represents a container port mapping
@@ -73,7 +241,7 @@ type VolumeMount struct {
 type ContainerGraphNode struct {
 	ID             string `json:"id"`
 	Name           string `json:"name"`
-	NodeType       string `json:"node_type"`         // "container" or "network"
+	NodeType       string `json:"node_type"`         // "container", "network" or "host"
 	Image          string `json:"image,omitempty"`
 	State          string `json:"state,omitempty"`
 	HostID         int64  `json:"host_id"`
@@ -85,7 +253,7 @@ type ContainerGraphNode struct {
 type ContainerGraphEdge struct {
 	Source string `json:"source"` // Source container ID
 	Target string `json:"target"` // Target container ID
-	Type   string `json:"type"`   // "network", "volume", "link", "compose"
+	Type   string `json:"type"`   // "network", "volume", "link", "depends", "cross_host", "host"
 	Label  string `json:"label"`  // Human-readable label (network name, volume name, etc.)
 }
 
@@ -95,9 +263,14 @@ type ContainerGraph struct {
 	Edges []ContainerGraphEdge `json:"edges"`
 }
 
+// ScanIDHeader carries the ID of a scan on the server's requests to an agent, which logs it
+// and sends it back
+const ScanIDHeader = "X-Census-Scan-ID"
+
 // ScanResult represents a scan operation
 type ScanResult struct {
 	ID              int64     `json:"id"`
+	ScanID          string    `json:"scan_id,omitempty"` // follows the scan through logs, agent requests and container rows
 	HostID          int64     `json:"host_id"`
 	HostName        string    `json:"host_name"`
 	StartedAt       time.Time `json:"started_at"`
@@ -105,6 +278,8 @@ type ScanResult struct {
 	Success         bool      `json:"success"`
 	Error           string    `json:"error,omitempty"`
 	ContainersFound int       `json:"containers_found"`
+	// Per-container problems of a successful scan (e.g. a stats call that failed)
+	Warnings []CollectionWarning `json:"warnings,omitempty"`
 }
 
 // TelemetrySubmission represents a telemetry submission operation
@@ -198,6 +373,8 @@ type AgentInfo struct {
 	OS         string    `json:"os"`
 	Arch       string    `json:"arch"`
 	DockerVersion string `json:"docker_version"`
+	ReadOnly    bool     `json:"read_only,omitempty"`
+	Permissions []string `json:"permissions"` // Docker operations the agent allows, see AgentPermissions
 	StartedAt  time.Time `json:"started_at"`
 }
 
@@ -244,11 +421,20 @@ type SystemSettings struct {
 type ScannerSettings struct {
 	IntervalSeconds int `json:"interval_seconds" validate:"min=10,max=86400"`
 	TimeoutSeconds  int `json:"timeout_seconds" validate:"min=5,max=300"`
+	// List plugins, Swarm secrets and configs, and builder cache of each host after its scan
+	InventoryObjects bool `json:"inventory_objects"`
 }
 
 // TelemetrySettings contains runtime telemetry configuration
 type TelemetrySettings struct {
 	IntervalHours int `json:"interval_hours" validate:"min=1,max=720"`
+	// Per-category opt-outs (zero value shares everything, matching previous behavior)
+	ExcludeResourceStats       bool `json:"exclude_resource_stats"`       // CPU/memory averages and restart stats
+	ExcludeImageList           bool `json:"exclude_image_list"`           // per-image names and counts (totals are still sent)
+	ExcludeArchitectureMetrics bool `json:"exclude_architecture_metrics"` // compose, network, volume and dependency metrics
+	ExcludeTimezone            bool `json:"exclude_timezone"`
+	// Opt-in: how many containers run behind a newer upstream build, and by how many days
+	ShareUpdateLag bool `json:"share_update_lag"`
 }
 
 // NotificationSettings contains runtime notification configuration
@@ -257,6 +443,52 @@ type NotificationSettings struct {
 	RateLimitBatchInterval int `json:"rate_limit_batch_interval" validate:"min=60,max=3600"`
 	ThresholdDuration      int `json:"threshold_duration" validate:"min=30,max=600"`
 	CooldownPeriod         int `json:"cooldown_period" validate:"min=60,max=3600"`
+	// Notification log retention; zero uses the defaults (7 days, 100 most recent)
+	LogRetentionDays  int `json:"log_retention_days" validate:"min=1,max=365"`
+	LogRetentionCount int `json:"log_retention_count" validate:"min=10,max=10000"`
+	// Consecutive failed/successful scans before a host is reported down/recovered, which keeps
+	// flapping hosts from alerting on every scan; zero uses the defaults (2 and 1)
+	HostDownAfterFailures       int `json:"host_down_after_failures" validate:"min=1,max=20"`
+	HostRecoveredAfterSuccesses int `json:"host_recovered_after_successes" validate:"min=1,max=20"`
+}
+
+// Default notification log retention: entries are kept for 7 days, and the 100 most recent are kept regardless of age
+const (
+	DefaultNotificationRetentionDays  = 7
+	DefaultNotificationRetentionCount = 100
+)
+
+// RetentionDays returns the configured log retention in days, or the default
+func (n NotificationSettings) RetentionDays() int {
+	if n.LogRetentionDays == 0 {
+		return DefaultNotificationRetentionDays
+	}
+	return n.LogRetentionDays
+}
+
+// DownAfterFailures returns the failed scans in a row before a host is reported down, or the default
+func (n NotificationSettings) DownAfterFailures() int {
+	if n.HostDownAfterFailures == 0 {
+		return DefaultHostDownAfterFailures
+	}
+	return n.HostDownAfterFailures
+}
+
+// RecoveredAfterSuccesses returns the successful scans in a row before a down host is reported
+// recovered, or the default
+func (n NotificationSettings) RecoveredAfterSuccesses() int {
+	if n.HostRecoveredAfterSuccesses == 0 {
+		return DefaultHostRecoveredAfterSuccesses
+	}
+	return n.HostRecoveredAfterSuccesses
+}
+
+// RetentionCount returns the number of recent log entries always kept, or the default
+func (n NotificationSettings) RetentionCount() int {
+	if n.LogRetentionCount == 0 {
+		return DefaultNotificationRetentionCount
+	}
+	return n.LogRetentionCount
 }
 
 // UISettings contains user interface preferences
@@ -287,6 +519,18 @@ func (s *SystemSettings) Validate() error {
 	if s.Notification.CooldownPeriod < 60 || s.Notification.CooldownPeriod > 3600 {
 		return fmt.Errorf("notification cooldown period must be between 60 and 3600 seconds")
 	}
+	if s.Notification.LogRetentionDays != 0 && (s.Notification.LogRetentionDays < 1 || s.Notification.LogRetentionDays > 365) {
+		return fmt.Errorf("notification log retention must be between 1 and 365 days")
+	}
+	if s.Notification.LogRetentionCount != 0 && (s.Notification.LogRetentionCount < 10 || s.Notification.LogRetentionCount > 10000) {
+		return fmt.Errorf("notification log retention count must be between 10 and 10000")
+	}
+	if s.Notification.HostDownAfterFailures != 0 && (s.Notification.HostDownAfterFailures < 1 || s.Notification.HostDownAfterFailures > 20) {
+		return fmt.Errorf("host down threshold must be between 1 and 20 failed scans")
+	}
+	if s.Notification.HostRecoveredAfterSuccesses != 0 && (s.Notification.HostRecoveredAfterSuccesses < 1 || s.Notification.HostRecoveredAfterSuccesses > 20) {
+		return fmt.Errorf("host recovery threshold must be between 1 and 20 successful scans")
+	}
 	// Validate UI settings
 	if s.UI.CardDesign != "" && s.UI.CardDesign != "compact" && s.UI.CardDesign != "material" && s.UI.CardDesign != "dashboard" {
 		return fmt.Errorf("card design must be one of: compact, material, dashboard")
@@ -331,6 +575,10 @@ type TelemetryReport struct {
 	ContainersWithDeps   int `json:"containers_with_deps,omitempty"`   // containers with depends_on configured
 	TotalDependencies    int `json:"total_dependencies,omitempty"`     // total dependency edges
 	AvgConnectionsPerContainer float64 `json:"avg_connections_per_container,omitempty"` // avg network+volume connections
+	// Update lag (opt-in): containers running behind a newer upstream build
+	ContainersBehindUpstream int     `json:"containers_behind_upstream,omitempty"`
+	AvgDaysBehindUpstream    float64 `json:"avg_days_behind_upstream,omitempty"`
+	MaxDaysBehindUpstream    int     `json:"max_days_behind_upstream,omitempty"`
 }
 
 // ImageStat contains statistics for a container image
@@ -357,7 +605,7 @@ type TelemetryStats struct {
 // ContainerLifecycleEvent represents a single lifecycle event for a container
 type ContainerLifecycleEvent struct {
 	Timestamp    time.Time `json:"timestamp"`
-	EventType    string    `json:"event_type"` // "first_seen", "started", "stopped", "restarted", "image_updated", "disappeared"
+	EventType    string    `json:"event_type"` // "first_seen", "started", "stopped", "restarted", "image_updated", "disappeared", "renamed", "oom_killed", "annotation"
 	OldState     string    `json:"old_state,omitempty"`
 	NewState     string    `json:"new_state,omitempty"`
 	OldImage     string    `json:"old_image,omitempty"`     // Deprecated: kept for backward compatibility, contains SHA
@@ -366,8 +614,22 @@ type ContainerLifecycleEvent struct {
 	NewImageTag  string    `json:"new_image_tag,omitempty"` // Full image name with tag (e.g., "nginx:1.25.4")
 	OldImageSHA  string    `json:"old_image_sha,omitempty"` // Truncated SHA (12 chars)
 	NewImageSHA  string    `json:"new_image_sha,omitempty"` // Truncated SHA (12 chars)
+	OldName      string    `json:"old_name,omitempty"`      // Name before a rename
+	NewName      string    `json:"new_name,omitempty"`      // Name after a rename
 	Description  string    `json:"description"`
 	RestartCount int       `json:"restart_count,omitempty"`
+	AnnotationID int64     `json:"annotation_id,omitempty"` // for annotations, the text is the description
+}
+
+// ContainerRename records a container that kept its ID but changed its name between scans.
+// History under the old name is moved to the new one when the rename is detected.
+type ContainerRename struct {
+	ID          int64     `json:"id"`
+	HostID      int64     `json:"host_id"`
+	ContainerID string    `json:"container_id"`
+	OldName     string    `json:"old_name"`
+	NewName     string    `json:"new_name"`
+	RenamedAt   time.Time `json:"renamed_at"`
 }
 
 // ContainerLifecycleSummary represents a summary of a container's lifecycle
@@ -387,13 +649,31 @@ type ContainerLifecycleSummary struct {
 	TotalScans      int       `json:"total_scans"`
 }
 
+// ContainerStatsKey identifies a container in a batch stats query
+type ContainerStatsKey struct {
+	HostID      int64  `json:"host_id"`
+	ContainerID string `json:"container_id"`
+}
+
 // ContainerStatsPoint represents a single data point for container resource usage
 type ContainerStatsPoint struct {
-	Timestamp     time.Time `json:"timestamp"`
-	CPUPercent    float64   `json:"cpu_percent"`
-	MemoryUsage   int64     `json:"memory_usage"`   // bytes
-	MemoryLimit   int64     `json:"memory_limit"`   // bytes
-	MemoryPercent float64   `json:"memory_percent"`
+	Timestamp      time.Time `json:"timestamp"`
+	CPUPercent     float64   `json:"cpu_percent"`
+	MemoryUsage    int64     `json:"memory_usage"` // bytes
+	MemoryLimit    int64     `json:"memory_limit"` // bytes
+	MemoryPercent  float64   `json:"memory_percent"`
+	NetworkRxRate  float64   `json:"network_rx_rate"`  // bytes/sec
+	NetworkTxRate  float64   `json:"network_tx_rate"`  // bytes/sec
+	BlockReadRate  float64   `json:"block_read_rate"`  // bytes/sec
+	BlockWriteRate float64   `json:"block_write_rate"` // bytes/sec
+	Gap            bool      `json:"-"`                // a bucket without data, written with null values
+	// Spread within the hour; only set on hourly aggregates
+	MaxCPUPercent  *float64 `json:"max_cpu_percent,omitempty"`
+	P95CPUPercent  *float64 `json:"p95_cpu_percent,omitempty"`
+	P99CPUPercent  *float64 `json:"p99_cpu_percent,omitempty"`
+	MaxMemoryUsage *int64   `json:"max_memory_usage,omitempty"`
+	P95MemoryUsage *int64   `json:"p95_memory_usage,omitempty"`
+	P99MemoryUsage *int64   `json:"p99_memory_usage,omitempty"`
 }
 
 // Notification event types
@@ -408,6 +688,19 @@ const (
 	EventTypeContainerStopped   = "container_stopped"
 	EventTypeContainerPaused    = "container_paused"
 	EventTypeContainerResumed   = "container_resumed"
+	EventTypeIdleContainers     = "idle_containers"
+	EventTypeMemoryLeak         = "memory_leak"
+	EventTypePrivilegedContainer = "privileged_container"
+	EventTypeBackupOverdue       = "backup_overdue"
+	EventTypeScriptAlert         = "script_alert"
+	EventTypeHostDown            = "host_down"
+	EventTypeHostRecovered       = "host_recovered"
+	EventTypeOOMKill             = "oom_kill"
+	EventTypeDaemonError         = "daemon_error"
+	EventTypeRestartPolicy       = "restart_policy"
+	EventTypeCertExpiring        = "cert_expiring"
+	EventTypeDNSFailure          = "dns_failure"
+	EventTypeNewVulnerability    = "new_vulnerability"
 )
 
 // Notification channel types
@@ -424,14 +717,26 @@ type NotificationChannel struct {
 	Type      string                 `json:"type"` // webhook, ntfy, in_app
 	Config    map[string]interface{} `json:"config"`
 	Enabled   bool                   `json:"enabled"`
-	CreatedAt time.Time              `json:"created_at"`
-	UpdatedAt time.Time              `json:"updated_at"`
+	// MinSeverity drops events below this severity for the channel (empty delivers everything)
+	MinSeverity string `json:"min_severity,omitempty"`
+	// QuietHours raises the minimum severity during a daily time window
+	QuietHours *QuietHours `json:"quiet_hours,omitempty"`
+	// TenantID is the tenant that owns the channel (0 for the administrator's channels)
+	TenantID   int64       `json:"tenant_id,omitempty"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
 }
 
 // WebhookConfig represents webhook-specific configuration
 type WebhookConfig struct {
 	URL     string            `json:"url"`
 	Headers map[string]string `json:"headers,omitempty"`
+	// Secret signs each payload with HMAC-SHA256 (X-Census-Signature-256 header) when set
+	Secret string `json:"secret,omitempty"`
+	// MaxAttempts is the total number of delivery attempts (default 3, 1 disables retries)
+	MaxAttempts int `json:"max_attempts,omitempty"`
+	// RetryBackoffSeconds is the delay before the first retry, doubled for each further retry (default 1)
+	RetryBackoffSeconds int `json:"retry_backoff_seconds,omitempty"`
 }
 
 // NtfyConfig represents ntfy-specific configuration
@@ -450,11 +755,15 @@ type NotificationRule struct {
 	HostID                   *int64    `json:"host_id,omitempty"` // nil = all hosts
 	ContainerPattern         string    `json:"container_pattern,omitempty"` // glob pattern
 	ImagePattern             string    `json:"image_pattern,omitempty"` // glob pattern
+	OwnerPattern             string    `json:"owner_pattern,omitempty"` // glob pattern matched against the container's owner name or contact
 	CPUThreshold             *float64  `json:"cpu_threshold,omitempty"` // nil = no threshold
 	MemoryThreshold          *float64  `json:"memory_threshold,omitempty"` // nil = no threshold
+	LeakGrowthThreshold      *float64  `json:"leak_growth_threshold,omitempty"` // min memory growth %/day for memory_leak events, nil = default
+	LeakMinDays              int       `json:"leak_min_days,omitempty"`         // min consecutive growth days for memory_leak events, 0 = default
 	ThresholdDurationSeconds int       `json:"threshold_duration_seconds"`
 	CooldownSeconds          int       `json:"cooldown_seconds"`
 	ChannelIDs               []int64   `json:"channel_ids"` // channels to send to
+	TenantID                 int64     `json:"tenant_id,omitempty"` // owning tenant, whose hosts' events the rule matches (0 = all hosts)
 	CreatedAt                time.Time `json:"created_at"`
 	UpdatedAt                time.Time `json:"updated_at"`
 }
@@ -479,6 +788,35 @@ type NotificationLog struct {
 	Read          bool                   `json:"read"`
 }
 
+// NotificationLogFilter selects notification log entries for listing, grouping and bulk operations.
+// Zero fields match everything.
+type NotificationLogFilter struct {
+	EventType     string `json:"event_type,omitempty"`
+	HostID        int64  `json:"host_id,omitempty"`
+	ContainerName string `json:"container_name,omitempty"`
+	UnreadOnly    bool   `json:"unread_only,omitempty"`
+	// TenantID limits entries to the hosts of a tenant; set by the API for tenant users
+	TenantID int64 `json:"-"`
+}
+
+// Notification log grouping modes
+const (
+	NotificationGroupByContainer = "container"
+	NotificationGroupByEventType = "event_type"
+)
+
+// NotificationGroup summarizes the notification log entries of one container or event type
+type NotificationGroup struct {
+	EventType     string    `json:"event_type,omitempty"`
+	HostID        int64     `json:"host_id,omitempty"`
+	HostName      string    `json:"host_name,omitempty"`
+	ContainerName string    `json:"container_name,omitempty"`
+	Count         int       `json:"count"`
+	UnreadCount   int       `json:"unread_count"`
+	LatestAt      time.Time `json:"latest_at"`
+	LatestMessage string    `json:"latest_message"`
+}
+
 // NotificationSilence represents a muted container or host
 type NotificationSilence struct {
 	ID               int64      `json:"id"`
@@ -508,6 +846,63 @@ type ContainerBaselineStats struct {
 	CreatedAt         time.Time `json:"created_at"`
 }
 
+// SeasonalBaseline is the expected resource usage of a container for one time slot.
+// DayOfWeek is -1 for hour-of-day slots that span every day of the week.
+type SeasonalBaseline struct {
+	ContainerName     string    `json:"container_name"`
+	HostID            int64     `json:"host_id"`
+	DayOfWeek         int       `json:"day_of_week"` // 0 = Sunday, -1 = any day
+	HourOfDay         int       `json:"hour_of_day"` // 0-23, server local time
+	AvgCPUPercent     float64   `json:"avg_cpu_percent"`
+	StdDevCPUPercent  float64   `json:"stddev_cpu_percent"`
+	AvgMemoryUsage    int64     `json:"avg_memory_usage"`
+	StdDevMemoryUsage float64   `json:"stddev_memory_usage"`
+	SampleCount       int       `json:"sample_count"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// HourlyContainerStats is one hourly stats aggregate for a container
+type HourlyContainerStats struct {
+	ContainerName  string    `json:"container_name"`
+	HostID         int64     `json:"host_id"`
+	Hour           time.Time `json:"hour"`
+	AvgCPUPercent  float64   `json:"avg_cpu_percent"`
+	AvgMemoryUsage int64     `json:"avg_memory_usage"`
+}
+
+// DailyMemoryUsage is a container's average memory usage for one day, derived from hourly aggregates
+type DailyMemoryUsage struct {
+	ContainerID    string `json:"container_id"`
+	ContainerName  string `json:"container_name"`
+	HostID         int64  `json:"host_id"`
+	HostName       string `json:"host_name"`
+	Day            string `json:"day"` // YYYY-MM-DD (UTC)
+	AvgMemoryUsage int64  `json:"avg_memory_usage"`
+}
+
+// TopConsumer summarizes a container's resource usage over a window
+type TopConsumer struct {
+	ContainerID            string  `json:"container_id"`
+	ContainerName          string  `json:"container_name"`
+	HostID                 int64   `json:"host_id"`
+	HostName               string  `json:"host_name"`
+	AvgCPUPercent          float64 `json:"avg_cpu_percent"`
+	MaxCPUPercent          float64 `json:"max_cpu_percent"`
+	AvgMemoryUsage         int64   `json:"avg_memory_usage"` // bytes
+	MaxMemoryUsage         int64   `json:"max_memory_usage"` // bytes
+	MemoryGrowthPerHour    float64 `json:"memory_growth_per_hour"`    // bytes/hour (least-squares slope)
+	MemoryGrowthPercentDay float64 `json:"memory_growth_percent_day"` // growth per day relative to average usage
+	SampleCount            int     `json:"sample_count"`
+}
+
+// TopConsumersReport ranks containers by CPU, memory and memory growth over a window
+type TopConsumersReport struct {
+	WindowHours    int           `json:"window_hours"`
+	ByCPU          []TopConsumer `json:"by_cpu"`
+	ByMemory       []TopConsumer `json:"by_memory"`
+	ByMemoryGrowth []TopConsumer `json:"by_memory_growth"`
+}
+
 // NotificationThresholdState tracks threshold breach state for cooldowns
 type NotificationThresholdState struct {
 	ID              int64     `json:"id"`
@@ -534,6 +929,7 @@ type NotificationEvent struct {
 	NewImage      string                 `json:"new_image,omitempty"`
 	CPUPercent    float64                `json:"cpu_percent,omitempty"`
 	MemoryPercent float64                `json:"memory_percent,omitempty"`
+	Owner         *Owner                 `json:"owner,omitempty"` // the container's owner, set when rules are matched
 	Metadata      map[string]interface{} `json:"metadata,omitempty"`
 }
 
@@ -559,6 +955,92 @@ type ChangesReport struct {
 	ImageUpdates      []ImageUpdateChange `json:"image_updates"`
 	StateChanges      []StateChange       `json:"state_changes"`
 	TopRestarted      []RestartSummary    `json:"top_restarted"`
+	Stacks            []StackChange       `json:"stacks"` // changes grouped by compose project
+	Annotations       []Annotation        `json:"annotations"`
+}
+
+// ChangesReportFilter narrows a changes report to a host and/or a compose project; zero values
+// don't filter
+type ChangesReportFilter struct {
+	HostID         int64
+	ComposeProject string
+}
+
+// StackChange is the changes of one compose project (stack) on one host over a report's period,
+// so a stack whose services were updated together reads as one entry
+type StackChange struct {
+	ComposeProject    string        `json:"compose_project"`
+	HostID            int64         `json:"host_id"`
+	HostName          string        `json:"host_name"`
+	Summary           string        `json:"summary"`  // e.g. "immich stack updated (3 services)"
+	Services          []string      `json:"services"` // containers with a change, sorted
+	NewContainers     int           `json:"new_containers"`
+	RemovedContainers int           `json:"removed_containers"`
+	ImageUpdates      int           `json:"image_updates"`
+	StateChanges      int           `json:"state_changes"`
+	Updates           []StackUpdate `json:"updates"` // image updates grouped into stack updates, newest first
+	LastChangeAt      time.Time     `json:"last_change_at"`
+}
+
+// StackUpdate is image updates of a stack's services close together in time, as when the stack
+// is pulled and brought up again
+type StackUpdate struct {
+	UpdatedAt time.Time `json:"updated_at"` // the first of the updates
+	Services  []string  `json:"services"`
+}
+
+// EnvironmentSnapshot is a persisted daily copy of the container inventory,
+// used to diff the environment between two dates without scanning raw history
+type EnvironmentSnapshot struct {
+	ID             int64               `json:"id"`
+	SnapshotDate   string              `json:"snapshot_date"` // YYYY-MM-DD (UTC)
+	CreatedAt      time.Time           `json:"created_at"`
+	HostCount      int                 `json:"host_count"`
+	ContainerCount int                 `json:"container_count"`
+	Containers     []SnapshotContainer `json:"containers,omitempty"`
+}
+
+// SnapshotContainer is the per-container state captured in an environment snapshot
+type SnapshotContainer struct {
+	ContainerID   string `json:"container_id"`
+	ContainerName string `json:"container_name"`
+	Image         string `json:"image"`
+	ImageID       string `json:"image_id"`
+	State         string `json:"state"`
+	HostID        int64  `json:"host_id"`
+	HostName      string `json:"host_name"`
+}
+
+// IdleContainerCriteria controls which running containers are reported as likely idle
+type IdleContainerCriteria struct {
+	WindowHours      int     `json:"window_hours"`       // look-back window
+	CPUThreshold     float64 `json:"cpu_threshold"`      // max average CPU % over the window
+	PeakCPUThreshold float64 `json:"peak_cpu_threshold"` // max peak CPU % over the window
+	IncludePublished bool    `json:"include_published"`  // also report containers that publish ports
+	HostID           int64   `json:"host_id,omitempty"`  // 0 = all hosts
+}
+
+// IdleContainer is a running container that did nothing measurable over the window
+type IdleContainer struct {
+	ContainerID    string    `json:"container_id"`
+	ContainerName  string    `json:"container_name"`
+	Image          string    `json:"image"`
+	HostID         int64     `json:"host_id"`
+	HostName       string    `json:"host_name"`
+	ComposeProject string    `json:"compose_project,omitempty"`
+	Created        time.Time `json:"created"`
+	AvgCPUPercent  float64   `json:"avg_cpu_percent"`
+	MaxCPUPercent  float64   `json:"max_cpu_percent"`
+	AvgMemoryUsage int64     `json:"avg_memory_usage"`
+	SampleCount    int       `json:"sample_count"`
+	PublishedPorts int       `json:"published_ports"`
+}
+
+// IdleContainerReport lists likely idle containers for pruning
+type IdleContainerReport struct {
+	Criteria    IdleContainerCriteria `json:"criteria"`
+	GeneratedAt time.Time             `json:"generated_at"`
+	Containers  []IdleContainer       `json:"containers"`
 }
 
 // ReportPeriod represents the time range for a report
@@ -577,53 +1059,58 @@ type ReportSummary struct {
 	ImageUpdates      int `json:"image_updates"`
 	StateChanges      int `json:"state_changes"`
 	Restarts          int `json:"restarts"`
+	Stacks            int `json:"stacks"` // compose projects with changes
 }
 
 // ContainerChange represents a new or removed container event
 type ContainerChange struct {
-	ContainerID   string    `json:"container_id"`
-	ContainerName string    `json:"container_name"`
-	Image         string    `json:"image"`
-	HostID        int64     `json:"host_id"`
-	HostName      string    `json:"host_name"`
-	Timestamp     time.Time `json:"timestamp"` // first_seen or last_seen
-	State         string    `json:"state"`
-	IsTransient   bool      `json:"is_transient"` // true if container appeared and disappeared in same period
+	ContainerID    string    `json:"container_id"`
+	ContainerName  string    `json:"container_name"`
+	Image          string    `json:"image"`
+	HostID         int64     `json:"host_id"`
+	HostName       string    `json:"host_name"`
+	Timestamp      time.Time `json:"timestamp"` // first_seen or last_seen
+	State          string    `json:"state"`
+	IsTransient    bool      `json:"is_transient"` // true if container appeared and disappeared in same period
+	ComposeProject string    `json:"compose_project,omitempty"`
 }
 
 // ImageUpdateChange represents an image update event
 type ImageUpdateChange struct {
-	ContainerID   string    `json:"container_id"`
-	ContainerName string    `json:"container_name"`
-	HostID        int64     `json:"host_id"`
-	HostName      string    `json:"host_name"`
-	OldImage      string    `json:"old_image"`
-	NewImage      string    `json:"new_image"`
-	OldImageID    string    `json:"old_image_id"`
-	NewImageID    string    `json:"new_image_id"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ContainerID    string    `json:"container_id"`
+	ContainerName  string    `json:"container_name"`
+	HostID         int64     `json:"host_id"`
+	HostName       string    `json:"host_name"`
+	OldImage       string    `json:"old_image"`
+	NewImage       string    `json:"new_image"`
+	OldImageID     string    `json:"old_image_id"`
+	NewImageID     string    `json:"new_image_id"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	ComposeProject string    `json:"compose_project,omitempty"`
 }
 
 // StateChange represents a container state transition event
 type StateChange struct {
-	ContainerID   string    `json:"container_id"`
-	ContainerName string    `json:"container_name"`
-	HostID        int64     `json:"host_id"`
-	HostName      string    `json:"host_name"`
-	OldState      string    `json:"old_state"`
-	NewState      string    `json:"new_state"`
-	ChangedAt     time.Time `json:"changed_at"`
+	ContainerID    string    `json:"container_id"`
+	ContainerName  string    `json:"container_name"`
+	HostID         int64     `json:"host_id"`
+	HostName       string    `json:"host_name"`
+	OldState       string    `json:"old_state"`
+	NewState       string    `json:"new_state"`
+	ChangedAt      time.Time `json:"changed_at"`
+	ComposeProject string    `json:"compose_project,omitempty"`
 }
 
 // RestartSummary represents containers with the most restarts
 type RestartSummary struct {
-	ContainerID   string `json:"container_id"`
-	ContainerName string `json:"container_name"`
-	HostID        int64  `json:"host_id"`
-	HostName      string `json:"host_name"`
-	RestartCount  int    `json:"restart_count"`
-	CurrentState  string `json:"current_state"`
-	Image         string `json:"image"`
+	ContainerID    string `json:"container_id"`
+	ContainerName  string `json:"container_name"`
+	HostID         int64  `json:"host_id"`
+	HostName       string `json:"host_name"`
+	RestartCount   int    `json:"restart_count"`
+	CurrentState   string `json:"current_state"`
+	Image          string `json:"image"`
+	ComposeProject string `json:"compose_project,omitempty"`
 }
 
 // ImageUpdateInfo contains information about an image update check
@@ -637,6 +1124,20 @@ type ImageUpdateInfo struct {
 	Error         string    `json:"error,omitempty"`
 }
 
+// ImageChangelog is the latest upstream release of an image's source repository, cached when an
+// update is detected. Source is empty when the image doesn't declare a GitHub repository, and URL is
+// empty when the repository has no releases; such entries are kept to avoid asking again.
+type ImageChangelog struct {
+	Image       string    `json:"image"`
+	Source      string    `json:"source,omitempty"`  // e.g. https://github.com/owner/repo
+	Version     string    `json:"version,omitempty"` // release tag
+	Name        string    `json:"name,omitempty"`
+	URL         string    `json:"url,omitempty"`     // release page
+	Summary     string    `json:"summary,omitempty"` // start of the release notes
+	PublishedAt time.Time `json:"published_at,omitempty"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
 // ContainerRecreateResult contains the result of a container recreation
 type ContainerRecreateResult struct {
 	Success       bool                   `json:"success"`
@@ -647,6 +1148,17 @@ type ContainerRecreateResult struct {
 	NewImageID    string                 `json:"new_image_id"`
 	KeptOldImage  bool                   `json:"kept_old_image"`
 	Config        map[string]interface{} `json:"config,omitempty"` // Container config for dry-run preview
+	PreUpdateOutput string               `json:"pre_update_output,omitempty"` // Output of the pre-update command
+	HealthStatus    string               `json:"health_status,omitempty"`     // Health status observed after recreate
+	RolledBack      bool                 `json:"rolled_back,omitempty"`       // True if the previous container was restored
+}
+
+// UpdateHooks contains optional safety hooks run around a container update
+type UpdateHooks struct {
+	PreUpdateCommand     []string `json:"pre_update_command,omitempty"`     // Executed in the old container before it is stopped (e.g. a DB dump)
+	WaitForHealthy       bool     `json:"wait_for_healthy"`                 // Wait for the new container's healthcheck to pass
+	HealthTimeoutSeconds int      `json:"health_timeout_seconds,omitempty"` // Defaults to 60 seconds
+	RollbackOnFailure    bool     `json:"rollback_on_failure"`              // Restore the previous container if it never becomes healthy
 }
 
 // ImageUpdateSettings contains runtime image update configuration
@@ -654,6 +1166,9 @@ type ImageUpdateSettings struct {
 	AutoCheckEnabled     bool `json:"auto_check_enabled"`
 	CheckIntervalHours   int  `json:"check_interval_hours" validate:"min=1,max=168"`
 	OnlyCheckLatestTags  bool `json:"only_check_latest_tags"`
+	// What an update to an image with more critical vulnerabilities than the current one does:
+	// off, warn (needs to be accepted) or block
+	VulnerabilityGate string `json:"vulnerability_gate"`
 }
 
 // Validate validates image update settings
@@ -661,5 +1176,12 @@ func (s *ImageUpdateSettings) Validate() error {
 	if s.CheckIntervalHours < 1 || s.CheckIntervalHours > 168 {
 		return fmt.Errorf("check interval must be between 1 and 168 hours")
 	}
+	switch s.VulnerabilityGate {
+	case "":
+		s.VulnerabilityGate = VulnerabilityGateOff
+	case VulnerabilityGateOff, VulnerabilityGateWarn, VulnerabilityGateBlock:
+	default:
+		return fmt.Errorf("vulnerability gate must be off, warn or block")
+	}
 	return nil
 }
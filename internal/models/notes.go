@@ -0,0 +1,72 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Limits of notes, to keep them documentation rather than file storage
+const (
+	MaxNoteLength     = 20000
+	MaxNoteFields     = 50
+	MaxNoteFieldKey   = 64
+	MaxNoteFieldValue = 2000
+)
+
+// Notes is the documentation kept with a host or container: free text (markdown) and custom
+// fields, e.g. "don't restart during backups" and {"owner": "alice", "credentials": "vault:kv/nas"}.
+// Container notes are kept by container name, so they survive recreation.
+type Notes struct {
+	Text      string            `json:"text,omitempty"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	UpdatedBy string            `json:"updated_by,omitempty"`
+}
+
+// Normalize trims the text and fields, drops fields without a value and checks the limits
+func (n *Notes) Normalize() error {
+	n.Text = strings.TrimSpace(n.Text)
+	if len(n.Text) > MaxNoteLength {
+		return fmt.Errorf("notes must be at most %d characters", MaxNoteLength)
+	}
+
+	fields := make(map[string]string, len(n.Fields))
+	for key, value := range n.Fields {
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if key == "" {
+			return fmt.Errorf("field names must not be empty")
+		}
+		if len(key) > MaxNoteFieldKey {
+			return fmt.Errorf("field name %q must be at most %d characters", key, MaxNoteFieldKey)
+		}
+		if len(value) > MaxNoteFieldValue {
+			return fmt.Errorf("field %q must be at most %d characters", key, MaxNoteFieldValue)
+		}
+		fields[key] = value
+	}
+	if len(fields) > MaxNoteFields {
+		return fmt.Errorf("at most %d fields are allowed", MaxNoteFields)
+	}
+	n.Fields = fields
+	if len(n.Fields) == 0 {
+		n.Fields = nil
+	}
+	return nil
+}
+
+// Empty reports whether the notes have neither text nor fields, in which case they are deleted
+func (n *Notes) Empty() bool {
+	return n.Text == "" && len(n.Fields) == 0
+}
+
+// ContainerNotes is a container's notes as listed by GET /api/notes
+type ContainerNotes struct {
+	HostID        int64  `json:"host_id"`
+	HostName      string `json:"host_name,omitempty"`
+	ContainerName string `json:"container_name,omitempty"` // empty for the host's own notes
+	Notes
+}
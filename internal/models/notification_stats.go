@@ -0,0 +1,93 @@
+package models
+
+import "time"
+
+// Outcomes counted per notification rule and channel
+const (
+	NotificationOutcomeSent        = "sent"
+	NotificationOutcomeFailed      = "failed"
+	NotificationOutcomeSuppressed  = "suppressed"   // silenced, in cooldown, or below the channel's severity
+	NotificationOutcomeRateLimited = "rate_limited" // queued into the next batch summary
+)
+
+// NotificationStatsRetentionDays is how long the hourly counters are kept; longer than the
+// notification log so noisy rules show up over weeks
+const NotificationStatsRetentionDays = 90
+
+// NotificationStatCount is one hourly counter: how many notifications of a rule to a channel
+// had an outcome in the hour starting at Bucket
+type NotificationStatCount struct {
+	Bucket    time.Time
+	RuleID    int64
+	ChannelID int64
+	Outcome   string
+	Count     int
+}
+
+// NotificationCounts counts notifications by outcome
+type NotificationCounts struct {
+	Sent        int `json:"sent"`
+	Failed      int `json:"failed"`
+	Suppressed  int `json:"suppressed"`
+	RateLimited int `json:"rate_limited"`
+	Total       int `json:"total"`
+}
+
+// Add counts n notifications with an outcome
+func (c *NotificationCounts) Add(outcome string, n int) {
+	switch outcome {
+	case NotificationOutcomeSent:
+		c.Sent += n
+	case NotificationOutcomeFailed:
+		c.Failed += n
+	case NotificationOutcomeSuppressed:
+		c.Suppressed += n
+	case NotificationOutcomeRateLimited:
+		c.RateLimited += n
+	default:
+		return
+	}
+	c.Total += n
+}
+
+// FailureRate returns the share of delivery attempts that failed, 0-100
+func (c NotificationCounts) FailureRate() float64 {
+	if c.Sent+c.Failed == 0 {
+		return 0
+	}
+	return float64(c.Failed) * 100 / float64(c.Sent+c.Failed)
+}
+
+// NotificationRuleStats are the counters of one rule
+type NotificationRuleStats struct {
+	RuleID   int64  `json:"rule_id"`
+	RuleName string `json:"rule_name"` // empty once the rule is deleted
+	NotificationCounts
+}
+
+// NotificationChannelStats are the counters of one channel
+type NotificationChannelStats struct {
+	ChannelID   int64   `json:"channel_id"`
+	ChannelName string  `json:"channel_name"` // empty once the channel is deleted
+	ChannelType string  `json:"channel_type,omitempty"`
+	FailureRate float64 `json:"failure_rate"`
+	NotificationCounts
+}
+
+// NotificationStatsPoint are the counters of all rules and channels in one interval
+type NotificationStatsPoint struct {
+	Time time.Time `json:"time"`
+	NotificationCounts
+}
+
+// NotificationStats summarizes the notification counters over a period, busiest rules and
+// channels first
+type NotificationStats struct {
+	Since    time.Time                  `json:"since"`
+	Until    time.Time                  `json:"until"`
+	Interval string                     `json:"interval"` // of Series: hour or day
+	Totals   NotificationCounts         `json:"totals"`
+	Rules    []NotificationRuleStats    `json:"rules"`
+	Channels []NotificationChannelStats `json:"channels"`
+	Series   []NotificationStatsPoint   `json:"series"`
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// OwnerLabel names a container's owner (census.owner=media-team); OwnerContactLabel optionally
+// gives how to reach them (census.owner.contact=media@example.com). Owners can also be assigned
+// to containers and compose stacks via the API.
+const (
+	OwnerLabel        = "census.owner"
+	OwnerContactLabel = "census.owner.contact"
+)
+
+// Where an owner comes from, most specific first
+const (
+	OwnerSourceLabel     = "label"
+	OwnerSourceContainer = "container"
+	OwnerSourceStack     = "stack"
+)
+
+// MaxOwnerLength limits owner names and contacts
+const MaxOwnerLength = 200
+
+// Owner is who is responsible for a container: a user, team or email address, and an optional
+// contact such as an email address or chat handle
+type Owner struct {
+	Name    string `json:"name"`
+	Contact string `json:"contact,omitempty"`
+	Source  string `json:"source"`
+}
+
+// OwnerAssignment is an owner assigned via the API to a container (by host and container name,
+// so it survives recreation) or to a compose stack (by project name, on every host)
+type OwnerAssignment struct {
+	HostID        int64     `json:"host_id,omitempty"`
+	HostName      string    `json:"host_name,omitempty"`
+	ContainerName string    `json:"container_name,omitempty"`
+	Stack         string    `json:"stack,omitempty"`
+	Owner         string    `json:"owner"`
+	Contact       string    `json:"contact,omitempty"`
+	AssignedAt    time.Time `json:"assigned_at"`
+	AssignedBy    string    `json:"assigned_by,omitempty"`
+}
+
+// Normalize trims the owner and contact and checks them. An email address as the owner is also
+// its contact.
+func (a *OwnerAssignment) Normalize() error {
+	a.Owner = strings.TrimSpace(a.Owner)
+	a.Contact = strings.TrimSpace(a.Contact)
+	if a.Owner == "" {
+		return fmt.Errorf("owner is required")
+	}
+	if len(a.Owner) > MaxOwnerLength || len(a.Contact) > MaxOwnerLength {
+		return fmt.Errorf("owner and contact must be at most %d characters", MaxOwnerLength)
+	}
+	if a.Contact == "" && strings.Contains(a.Owner, "@") {
+		if _, err := mail.ParseAddress(a.Owner); err == nil {
+			a.Contact = a.Owner
+		}
+	}
+	return nil
+}
+
+// OwnerAssignments are the owners assigned via the API, for looking up a container's owner
+type OwnerAssignments struct {
+	Containers map[string]OwnerAssignment // keyed by PinKey
+	Stacks     map[string]OwnerAssignment // keyed by compose project
+}
+
+// OwnerFor returns a container's owner, or nil: its census.owner label, else the owner assigned
+// to the container, else the owner assigned to its compose stack
+func OwnerFor(c Container, assignments OwnerAssignments) *Owner {
+	if name := strings.TrimSpace(c.Labels[OwnerLabel]); name != "" {
+		return &Owner{Name: name, Contact: strings.TrimSpace(c.Labels[OwnerContactLabel]), Source: OwnerSourceLabel}
+	}
+	if a, ok := assignments.Containers[PinKey(c.HostID, c.Name)]; ok {
+		return &Owner{Name: a.Owner, Contact: a.Contact, Source: OwnerSourceContainer}
+	}
+	if c.ComposeProject != "" {
+		if a, ok := assignments.Stacks[c.ComposeProject]; ok {
+			return &Owner{Name: a.Owner, Contact: a.Contact, Source: OwnerSourceStack}
+		}
+	}
+	return nil
+}
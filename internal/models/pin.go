@@ -0,0 +1,49 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// PinLabel pins a container when set to a true value (census.pin=true): it is skipped by update
+// checks and cannot be updated until the label is removed. Containers can also be pinned via the API.
+const PinLabel = "census.pin"
+
+// Where a pin comes from
+const (
+	PinSourceLabel = "label"
+	PinSourceAPI   = "api"
+)
+
+// ContainerPin marks a container as "do not update"
+type ContainerPin struct {
+	HostID        int64     `json:"host_id"`
+	ContainerName string    `json:"container_name"`
+	Reason        string    `json:"reason,omitempty"`
+	Source        string    `json:"source"`
+	PinnedAt      time.Time `json:"pinned_at,omitempty"`
+}
+
+// PinKey identifies a container across recreations in pin lookups
+func PinKey(hostID int64, containerName string) string {
+	return fmt.Sprintf("%d/%s", hostID, containerName)
+}
+
+// PinnedByLabels reports whether a container is pinned by its census.pin label
+func PinnedByLabels(labels map[string]string) bool {
+	pinned, err := strconv.ParseBool(labels[PinLabel])
+	return err == nil && pinned
+}
+
+// PinFor returns a container's pin, from its label or from the API pins keyed by PinKey, or nil.
+// The label wins, since it can't be removed through the API.
+func PinFor(c Container, pins map[string]ContainerPin) *ContainerPin {
+	if PinnedByLabels(c.Labels) {
+		return &ContainerPin{HostID: c.HostID, ContainerName: c.Name, Source: PinSourceLabel, Reason: "census.pin label"}
+	}
+	if pin, ok := pins[PinKey(c.HostID, c.Name)]; ok {
+		return &pin
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// PluginResult is one key/value reported by a collector plugin for a container
+type PluginResult struct {
+	Plugin        string    `json:"plugin"`
+	HostID        int64     `json:"host_id"`
+	ContainerID   string    `json:"container_id,omitempty"` // plugins may identify the container by ID instead of name
+	ContainerName string    `json:"container_name"`
+	Key           string    `json:"key"`
+	Value         string    `json:"value"`
+	CollectedAt   time.Time `json:"collected_at"`
+}
+
+// PluginStatus describes a collector plugin and the outcome of its latest run
+type PluginStatus struct {
+	Name        string    `json:"name"`
+	Path        string    `json:"path"`
+	LastRunAt   time.Time `json:"last_run_at,omitempty"`
+	LastHost    string    `json:"last_host,omitempty"`
+	LastResults int       `json:"last_results"`
+	LastError   string    `json:"last_error,omitempty"`
+	DurationMs  int64     `json:"duration_ms"`
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Proxmox guest types. Nodes are included so that Docker installed directly on a Proxmox node
+// is recognized too.
+const (
+	ProxmoxTypeQEMU = "qemu"
+	ProxmoxTypeLXC  = "lxc"
+	ProxmoxTypeNode = "node"
+)
+
+// How a Census host was matched to a Proxmox guest, from most to least reliable
+const (
+	ProxmoxMatchIP       = "ip"       // the host address resolves to an address of the guest
+	ProxmoxMatchHostname = "hostname" // the host address' hostname is the guest name
+	ProxmoxMatchName     = "name"     // the Census host name is the guest name
+)
+
+// ProxmoxSettings configures the Proxmox VE integration
+type ProxmoxSettings struct {
+	Enabled             bool   `json:"enabled"`
+	URL                 string `json:"url"`      // e.g. https://pve.local:8006
+	TokenID             string `json:"token_id"` // USER@REALM!TOKENID, e.g. census@pve!census
+	TokenSecret         string `json:"token_secret"`
+	SkipTLSVerify       bool   `json:"skip_tls_verify"` // for the self-signed certificate Proxmox installs by default
+	SyncIntervalMinutes int    `json:"sync_interval_minutes"`
+}
+
+// DefaultProxmoxSettings returns the settings used before the integration is configured
+func DefaultProxmoxSettings() *ProxmoxSettings {
+	return &ProxmoxSettings{
+		SyncIntervalMinutes: 15,
+	}
+}
+
+// Validate validates Proxmox settings
+func (s *ProxmoxSettings) Validate() error {
+	if s.SyncIntervalMinutes < 1 || s.SyncIntervalMinutes > 1440 {
+		return fmt.Errorf("sync interval must be between 1 and 1440 minutes")
+	}
+	if !s.Enabled {
+		return nil
+	}
+
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an http(s) URL, e.g. https://pve.local:8006")
+	}
+	if user, _, ok := strings.Cut(s.TokenID, "!"); !ok || !strings.Contains(user, "@") {
+		return fmt.Errorf("token ID must look like USER@REALM!TOKENID, e.g. census@pve!census")
+	}
+	if s.TokenSecret == "" {
+		return fmt.Errorf("token secret is required")
+	}
+	return nil
+}
+
+// Redacted returns a copy of the settings that is safe to return from the API
+func (s *ProxmoxSettings) Redacted() *ProxmoxSettings {
+	clone := *s
+	if clone.TokenSecret != "" {
+		clone.TokenSecret = MaskedSecret
+	}
+	return &clone
+}
+
+// ProxmoxGuest is the Proxmox VM, container or node a Census host runs on, with the resources
+// allocated to it
+type ProxmoxGuest struct {
+	HostID        int64     `json:"host_id"`
+	HostName      string    `json:"host_name"`
+	Node          string    `json:"node"`
+	VMID          int       `json:"vmid,omitempty"` // 0 for nodes
+	Type          string    `json:"type"`           // qemu, lxc or node
+	Name          string    `json:"name"`
+	Status        string    `json:"status"` // running, stopped, online, ...
+	CPUs          float64   `json:"cpus"`
+	MaxMemory     int64     `json:"max_memory"` // bytes
+	MaxDisk       int64     `json:"max_disk"`   // bytes
+	UptimeSeconds int64     `json:"uptime_seconds"`
+	MatchedBy     string    `json:"matched_by"` // ip, hostname or name
+	SyncedAt      time.Time `json:"synced_at"`
+}
+
+// ProxmoxSyncResult summarizes one sync with Proxmox VE
+type ProxmoxSyncResult struct {
+	Guests   int       `json:"guests"`  // VMs, containers and nodes seen in the cluster
+	Matched  int       `json:"matched"` // Census hosts mapped to one of them
+	Errors   []string  `json:"errors,omitempty"`
+	SyncedAt time.Time `json:"synced_at"`
+}
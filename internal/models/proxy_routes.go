@@ -0,0 +1,85 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Reverse proxies routes are read from
+const (
+	ProxyTraefik    = "traefik"
+	ProxyNginxProxy = "nginx-proxy" // nginx-proxy / acme-companion, configured by VIRTUAL_HOST
+)
+
+// Where a route was found
+const (
+	RouteSourceLabels     = "labels"      // the container's Traefik labels
+	RouteSourceEnv        = "env"         // the container's VIRTUAL_HOST environment variable
+	RouteSourceTraefikAPI = "traefik_api" // Traefik's own API, including routes of its file provider
+)
+
+// Status of a route's container
+const (
+	RouteStatusOK        = "ok"
+	RouteStatusStopped   = "stopped"   // the container isn't running, so the route is down
+	RouteStatusUnmatched = "unmatched" // Traefik routes to a backend that is no known container
+)
+
+// TraefikSettings configures reading routes from Traefik's API (api.insecure or a router to
+// api@internal, optionally behind basic auth)
+type TraefikSettings struct {
+	Enabled       bool   `json:"enabled"`
+	URL           string `json:"url"` // e.g. http://traefik:8080
+	Username      string `json:"username,omitempty"`
+	Password      string `json:"password,omitempty"`
+	SkipTLSVerify bool   `json:"skip_tls_verify"`
+}
+
+// Validate validates Traefik settings
+func (s *TraefikSettings) Validate() error {
+	if !s.Enabled {
+		return nil
+	}
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an http(s) URL, e.g. http://traefik:8080")
+	}
+	return nil
+}
+
+// Redacted returns a copy of the settings that is safe to return from the API
+func (s *TraefikSettings) Redacted() *TraefikSettings {
+	clone := *s
+	if clone.Password != "" {
+		clone.Password = MaskedSecret
+	}
+	return &clone
+}
+
+// ProxyRoute is a public URL a reverse proxy serves and the container behind it
+type ProxyRoute struct {
+	URL    string `json:"url"`
+	Proxy  string `json:"proxy"`  // traefik or nginx-proxy
+	Source string `json:"source"` // labels, env or traefik_api
+	Router string `json:"router,omitempty"`
+	// Where Traefik sends the requests, and whether it considers them up (UP/DOWN)
+	Backend       string `json:"backend,omitempty"`
+	BackendStatus string `json:"backend_status,omitempty"`
+	// The container serving the route; unset for unmatched routes
+	HostID         int64  `json:"host_id,omitempty"`
+	HostName       string `json:"host_name,omitempty"`
+	ContainerName  string `json:"container_name,omitempty"`
+	ContainerState string `json:"container_state,omitempty"`
+	Status         string `json:"status"`
+}
+
+// ProxyRouteReport maps the public URLs of the reverse proxies to containers
+type ProxyRouteReport struct {
+	GeneratedAt    time.Time    `json:"generated_at"`
+	TraefikEnabled bool         `json:"traefik_enabled"`
+	Stopped        int          `json:"stopped"`
+	Unmatched      int          `json:"unmatched"`
+	Routes         []ProxyRoute `json:"routes"`
+	Errors         []string     `json:"errors,omitempty"` // e.g. Traefik's API couldn't be read
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Findings of the restart policy audit
+const (
+	RestartPolicyMissing = "missing" // a long-running container with restart policy "no"
+	RestartPolicyChanged = "changed" // the policy differs from the container's previous scan
+)
+
+// Defaults of the restart policy audit
+const (
+	DefaultRestartPolicyMinUptimeHours = 24 // running this long makes a container a service
+	DefaultRestartPolicyChangedDays    = 7  // how far back policy changes are reported
+)
+
+// RestartPolicyIgnoreLabel set to "true" leaves a container out of the restart policy audit,
+// for services that are meant to stay down after a reboot
+const RestartPolicyIgnoreLabel = "census.restart-policy.ignore"
+
+// RestartPolicyFinding is a container of a host's latest scan the restart policy audit warns
+// about. Containers without a restart policy don't come back after a reboot or a daemon restart.
+type RestartPolicyFinding struct {
+	Finding               string     `json:"finding"` // missing or changed
+	HostID                int64      `json:"host_id"`
+	HostName              string     `json:"host_name"`
+	ContainerID           string     `json:"container_id"`
+	ContainerName         string     `json:"container_name"`
+	Image                 string     `json:"image"`
+	State                 string     `json:"state"`
+	RestartPolicy         string     `json:"restart_policy"`
+	PreviousRestartPolicy string     `json:"previous_restart_policy,omitempty"`
+	ChangedAt             *time.Time `json:"changed_at,omitempty"`
+	StartedAt             *time.Time `json:"started_at,omitempty"`
+	CollectedAt           time.Time  `json:"collected_at"`
+}
+
+// RestartPolicyReport is the restart policy audit of the hosts' latest scans
+type RestartPolicyReport struct {
+	GeneratedAt    time.Time              `json:"generated_at"`
+	MinUptimeHours int                    `json:"min_uptime_hours"`
+	ChangedDays    int                    `json:"changed_days"`
+	Missing        int                    `json:"missing"`
+	Changed        int                    `json:"changed"`
+	Findings       []RestartPolicyFinding `json:"findings"` // missing first, then by host and name
+}
+
+// NormalizeRestartPolicy returns the name Docker uses for a restart policy; older engines report
+// no policy as ""
+func NormalizeRestartPolicy(policy string) string {
+	if policy == "" {
+		return "no"
+	}
+	return policy
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Notification severities, lowest first
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+var severityRanks = map[string]int{
+	SeverityInfo:     1,
+	SeverityWarning:  2,
+	SeverityCritical: 3,
+}
+
+// ValidSeverity reports whether s is a known severity
+func ValidSeverity(s string) bool {
+	_, ok := severityRanks[s]
+	return ok
+}
+
+// SeverityAtLeast reports whether severity meets the minimum. An empty minimum accepts everything.
+func SeverityAtLeast(severity, minimum string) bool {
+	if minimum == "" {
+		return true
+	}
+	return severityRanks[severity] >= severityRanks[minimum]
+}
+
+// EventSeverity returns the severity of an event type, used to route notifications per channel
+func EventSeverity(eventType string) string {
+	switch eventType {
+	case EventTypeContainerStopped, EventTypePrivilegedContainer, EventTypeBackupOverdue, EventTypeHostDown, EventTypeOOMKill,
+		EventTypeDNSFailure, EventTypeNewVulnerability:
+		return SeverityCritical
+	case EventTypeHighCPU, EventTypeHighMemory, EventTypeAnomalousBehavior, EventTypeMemoryLeak, EventTypeDaemonError, EventTypeRestartPolicy,
+		EventTypeCertExpiring:
+		return SeverityWarning
+	default:
+		return SeverityInfo
+	}
+}
+
+// QuietHours is a daily window, in the server's local time, during which a channel only
+// delivers events of at least MinSeverity. Start and End are "HH:MM"; a window whose end is
+// before its start spans midnight (e.g. 22:00-08:00).
+type QuietHours struct {
+	Start       string `json:"start"`
+	End         string `json:"end"`
+	MinSeverity string `json:"min_severity"`
+}
+
+// Validate checks the window times and severity
+func (q QuietHours) Validate() error {
+	if _, err := parseClock(q.Start); err != nil {
+		return fmt.Errorf("invalid quiet hours start: %w", err)
+	}
+	if _, err := parseClock(q.End); err != nil {
+		return fmt.Errorf("invalid quiet hours end: %w", err)
+	}
+	if q.Start == q.End {
+		return fmt.Errorf("quiet hours start and end must differ")
+	}
+	if !ValidSeverity(q.MinSeverity) {
+		return fmt.Errorf("invalid quiet hours severity: %q", q.MinSeverity)
+	}
+	return nil
+}
+
+// Contains reports whether t falls inside the window
+func (q QuietHours) Contains(t time.Time) bool {
+	start, err := parseClock(q.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(q.End)
+	if err != nil {
+		return false
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// MinSeverityAt returns the minimum severity the channel delivers at t
+func (ch NotificationChannel) MinSeverityAt(t time.Time) string {
+	if ch.QuietHours != nil && ch.QuietHours.Contains(t) {
+		if !SeverityAtLeast(ch.MinSeverity, ch.QuietHours.MinSeverity) {
+			return ch.QuietHours.MinSeverity
+		}
+	}
+	return ch.MinSeverity
+}
+
+// parseClock parses "HH:MM" into minutes after midnight
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM, got %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// ScriptStatus describes an event script and the outcome of its latest run
+type ScriptStatus struct {
+	Event       string    `json:"event"`
+	Name        string    `json:"name"`
+	Path        string    `json:"path"`
+	LastRunAt   time.Time `json:"last_run_at,omitempty"`
+	LastActions int       `json:"last_actions"`
+	LastError   string    `json:"last_error,omitempty"`
+	DurationMs  int64     `json:"duration_ms"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Container risk levels, from least to most severe
+const (
+	RiskLevelNone     = "none"
+	RiskLevelLow      = "low"
+	RiskLevelMedium   = "medium"
+	RiskLevelHigh     = "high"
+	RiskLevelCritical = "critical"
+)
+
+// RiskFinding is one security-relevant setting of a container
+type RiskFinding struct {
+	ID          string `json:"id"` // e.g. "privileged", "docker_socket", "cap_sys_admin"
+	Description string `json:"description"`
+	Weight      int    `json:"weight"`
+}
+
+// SecurityRisk scores how much of the host a container can reach. The score is the sum of the
+// finding weights, capped at 100.
+type SecurityRisk struct {
+	Score    int           `json:"score"`
+	Level    string        `json:"level"`
+	Findings []RiskFinding `json:"findings"`
+}
+
+// HasFinding reports whether the risk includes a finding ID
+func (r SecurityRisk) HasFinding(id string) bool {
+	for _, f := range r.Findings {
+		if f.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainerSecurity is the security posture of a container as of its last scan
+type ContainerSecurity struct {
+	ContainerID     string       `json:"container_id"`
+	ContainerName   string       `json:"container_name"`
+	HostID          int64        `json:"host_id"`
+	HostName        string       `json:"host_name"`
+	Image           string       `json:"image"`
+	State           string       `json:"state"`
+	Privileged      bool         `json:"privileged"`
+	PrivilegedSince *time.Time   `json:"privileged_since,omitempty"`
+	Risk            SecurityRisk `json:"risk"`
+	CollectedAt     time.Time    `json:"collected_at"`
+}
+
+// ContainerSecurityFilter selects containers by risk. Zero values match everything.
+type ContainerSecurityFilter struct {
+	HostID   int64
+	MinScore int
+	Finding  string // only containers with this finding ID
+}
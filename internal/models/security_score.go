@@ -0,0 +1,62 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Security score weights: the penalty points of each finding. A score is 100 * 100 / (100 +
+// points), so it is 100 without findings, 50 at 100 points, and never reaches 0.
+const (
+	SecurityScoreCriticalWeight   = 10 // per open critical vulnerability
+	SecurityScoreHighWeight       = 3  // per open high vulnerability
+	SecurityScoreStaleFixWeight   = 5  // extra per open critical or high vulnerability with a long-available fix
+	SecurityScorePrivilegedWeight = 20 // per privileged container
+	// A fix counts as long available when the vulnerability was published this many days ago
+	SecurityScoreStaleFixDays = 30
+)
+
+// SecurityScore is the security posture of the fleet, a host, or a compose stack on a host.
+// Vulnerabilities are counted once per image in scope, leaving out those covered by an exception.
+type SecurityScore struct {
+	HostID     int64  `json:"host_id,omitempty"` // 0 for the fleet
+	HostName   string `json:"host_name,omitempty"`
+	Stack      string `json:"stack,omitempty"` // compose project; empty for a host or the fleet
+	Score      int    `json:"score"`           // 0-100, higher is better
+	Grade      string `json:"grade"`           // A (90+), B (75+), C (60+), D (40+) or F
+	Containers int    `json:"containers"`
+	Images     int    `json:"images"`
+	Unscanned  int    `json:"unscanned_images"` // images without a successful scan, which add no points
+	Critical   int    `json:"critical"`
+	High       int    `json:"high"`
+	StaleFixes int    `json:"stale_fixes"` // critical and high vulnerabilities with a fix, published over SecurityScoreStaleFixDays ago
+	Privileged int    `json:"privileged"`  // privileged containers
+	// Score change since the score recorded trend_days ago; nil without one
+	Change *int `json:"change,omitempty"`
+}
+
+// SecurityScoreboard ranks the hosts and stacks of the fleet by security score, worst first
+type SecurityScoreboard struct {
+	GeneratedAt time.Time       `json:"generated_at"`
+	TrendDays   int             `json:"trend_days"`
+	Fleet       SecurityScore   `json:"fleet"`
+	Hosts       []SecurityScore `json:"hosts"`
+	Stacks      []SecurityScore `json:"stacks"`
+}
+
+// SecurityScorePoint is the score of a fleet, host or stack recorded on a day
+type SecurityScorePoint struct {
+	Day        string `json:"day"` // YYYY-MM-DD, server local time
+	Score      int    `json:"score"`
+	Critical   int    `json:"critical"`
+	High       int    `json:"high"`
+	StaleFixes int    `json:"stale_fixes"`
+	Privileged int    `json:"privileged"`
+	Containers int    `json:"containers"`
+}
+
+// SecurityScoreKey identifies the fleet (0, ""), a host (its ID, "") or a stack on a host in
+// recorded scores
+func SecurityScoreKey(hostID int64, stack string) string {
+	return fmt.Sprintf("%d/%s", hostID, stack)
+}
@@ -0,0 +1,180 @@
+package models
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// dashboardIconsURL serves the icons Homepage refers to by file name (homepage.icon=jellyfin.png)
+const dashboardIconsURL = "https://cdn.jsdelivr.net/gh/walkxcode/dashboard-icons/"
+
+var (
+	// traefikHostPattern finds the hosts of a router rule: Host(`a.example.com`, `b.example.com`)
+	traefikHostPattern = regexp.MustCompile("Host\\(([^)]*)\\)")
+	// traefikPathPattern finds a router rule's path prefix: PathPrefix(`/app`)
+	traefikPathPattern = regexp.MustCompile("PathPrefix\\(\\s*[`\"']([^`\"']*)[`\"']")
+	// quotedPattern finds the quoted arguments of a rule matcher
+	quotedPattern = regexp.MustCompile("[`\"']([^`\"']+)[`\"']")
+)
+
+// ServiceMetadata is what a container's labels say about the service it runs, for service cards
+// like Homepage's: Homepage's own labels (homepage.name, .group, .icon, .href, .description),
+// the URLs of its Traefik routers and the OCI image labels' links
+type ServiceMetadata struct {
+	Name        string   `json:"name,omitempty"`
+	Group       string   `json:"group,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Icon        string   `json:"icon,omitempty"`     // as labelled: a file name, mdi-/si- icon or URL
+	IconURL     string   `json:"icon_url,omitempty"` // when Icon resolves to an image
+	URL         string   `json:"url,omitempty"`      // where to open the service: homepage.href, else its first router URL
+	URLs        []string `json:"urls,omitempty"`     // every router URL
+	DocsURL     string   `json:"docs_url,omitempty"`
+	SourceURL   string   `json:"source_url,omitempty"`
+}
+
+// ServiceMetadataFor reads the service metadata from a container's labels; nil when they have none
+func ServiceMetadataFor(labels map[string]string) *ServiceMetadata {
+	m := &ServiceMetadata{
+		Name:        labels["homepage.name"],
+		Group:       labels["homepage.group"],
+		Description: labels["homepage.description"],
+		Icon:        labels["homepage.icon"],
+		URL:         labels["homepage.href"],
+		URLs:        TraefikRouterURLs(labels),
+		DocsURL:     labels["org.opencontainers.image.documentation"],
+		SourceURL:   labels["org.opencontainers.image.source"],
+	}
+	if m.Description == "" {
+		m.Description = labels["org.opencontainers.image.description"]
+	}
+	if m.URL == "" && len(m.URLs) > 0 {
+		m.URL = m.URLs[0]
+	}
+	if m.DocsURL == "" {
+		m.DocsURL = labels["org.opencontainers.image.url"]
+	}
+	if m.DocsURL == m.SourceURL {
+		m.DocsURL = ""
+	}
+	m.IconURL = iconURL(m.Icon)
+
+	if m.Name == "" && m.Group == "" && m.Description == "" && m.Icon == "" && m.URL == "" &&
+		m.DocsURL == "" && m.SourceURL == "" {
+		return nil
+	}
+	return m
+}
+
+// TraefikRouter is an HTTP router defined by a container's Traefik labels
+type TraefikRouter struct {
+	Name string
+	Rule string
+	URLs []string // one per host of the rule, with its path prefix
+}
+
+// TraefikRouters returns the HTTP routers a container's Traefik labels define, by name, with
+// the URLs of each: one per host of a router's rule (Host(`a`) || Host(`b`)), with its path
+// prefix. Routers with TLS or only on an entrypoint named like websecure or https are https.
+// Disabled containers (traefik.enable=false) have none.
+func TraefikRouters(labels map[string]string) []TraefikRouter {
+	if strings.EqualFold(labels["traefik.enable"], "false") {
+		return nil
+	}
+
+	const prefix = "traefik.http.routers."
+	var routers []TraefikRouter
+	for key, rule := range labels {
+		if !strings.HasPrefix(key, prefix) || !strings.HasSuffix(key, ".rule") {
+			continue
+		}
+		router := TraefikRouter{Name: strings.TrimSuffix(strings.TrimPrefix(key, prefix), ".rule"), Rule: rule}
+		tls := labels[prefix+router.Name+".tls"]
+		router.URLs = TraefikRuleURLs(rule, strings.EqualFold(tls, "true") ||
+			labels[prefix+router.Name+".tls.certresolver"] != "" || secureEntrypoints(labels[prefix+router.Name+".entrypoints"]))
+		routers = append(routers, router)
+	}
+	sort.Slice(routers, func(i, j int) bool { return routers[i].Name < routers[j].Name })
+	return routers
+}
+
+// TraefikRouterURLs returns the URLs of all routers of TraefikRouters, sorted and without duplicates
+func TraefikRouterURLs(labels map[string]string) []string {
+	seen := map[string]bool{}
+	var urls []string
+	for _, router := range TraefikRouters(labels) {
+		for _, u := range router.URLs {
+			if !seen[u] {
+				seen[u] = true
+				urls = append(urls, u)
+			}
+		}
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+// TraefikRuleURLs returns the URLs a router rule matches: one per host of its Host() matchers,
+// with the rule's path prefix
+func TraefikRuleURLs(rule string, tls bool) []string {
+	scheme := "http"
+	if tls {
+		scheme = "https"
+	}
+	path := ""
+	if m := traefikPathPattern.FindStringSubmatch(rule); m != nil && m[1] != "/" {
+		path = m[1]
+	}
+	var urls []string
+	for _, hosts := range traefikHostPattern.FindAllStringSubmatch(rule, -1) {
+		for _, host := range quotedPattern.FindAllStringSubmatch(hosts[1], -1) {
+			urls = append(urls, scheme+"://"+host[1]+path)
+		}
+	}
+	return urls
+}
+
+// secureEntrypoints reports whether a router's entrypoints are all TLS ones by their usual names
+func secureEntrypoints(entrypoints string) bool {
+	if entrypoints == "" {
+		return false
+	}
+	for _, ep := range strings.Split(entrypoints, ",") {
+		ep = strings.ToLower(strings.TrimSpace(ep))
+		if !strings.Contains(ep, "secure") && !strings.Contains(ep, "https") && ep != "443" {
+			return false
+		}
+	}
+	return true
+}
+
+// iconURL resolves a Homepage icon to an image URL: URLs as they are, file names from the
+// dashboard icons; Material Design and Simple Icons names (mdi-, si-) and paths on the Homepage
+// instance have none
+func iconURL(icon string) string {
+	switch {
+	case icon == "":
+		return ""
+	case strings.HasPrefix(icon, "http://") || strings.HasPrefix(icon, "https://"):
+		return icon
+	case strings.HasPrefix(icon, "mdi-") || strings.HasPrefix(icon, "si-") || strings.HasPrefix(icon, "/"):
+		return ""
+	}
+	name := strings.ToLower(icon)
+	for _, ext := range []string{"png", "svg", "webp"} {
+		if strings.HasSuffix(name, "."+ext) {
+			return dashboardIconsURL + ext + "/" + icon
+		}
+	}
+	return dashboardIconsURL + "png/" + icon + ".png"
+}
+
+// ServiceCard is a container with service metadata worth a card: a name or URL to open
+type ServiceCard struct {
+	HostID        int64  `json:"host_id"`
+	HostName      string `json:"host_name"`
+	ContainerName string `json:"container_name"`
+	Image         string `json:"image"`
+	State         string `json:"state"`
+	ServiceMetadata
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"strconv"
+	"time"
+)
+
+// StatsOptOutLabel is a container label that disables periodic stats collection for that
+// container when set to a false value (e.g. census.stats=false). Live stats remain available.
+const StatsOptOutLabel = "census.stats"
+
+// StatsDisabledByLabels reports whether a container opted out of periodic stats collection
+func StatsDisabledByLabels(labels map[string]string) bool {
+	value, ok := labels[StatsOptOutLabel]
+	if !ok {
+		return false
+	}
+	enabled, err := strconv.ParseBool(value)
+	return err == nil && !enabled
+}
+
+// LiveContainerStats is a single real-time stats sample streamed while a container's detail panel is open
+type LiveContainerStats struct {
+	Timestamp      time.Time `json:"timestamp"`
+	CPUPercent     float64   `json:"cpu_percent"`
+	MemoryUsage    int64     `json:"memory_usage"`
+	MemoryLimit    int64     `json:"memory_limit"`
+	MemoryPercent  float64   `json:"memory_percent"`
+	NetworkRxRate  float64   `json:"network_rx_rate"`  // bytes/sec
+	NetworkTxRate  float64   `json:"network_tx_rate"`  // bytes/sec
+	BlockReadRate  float64   `json:"block_read_rate"`  // bytes/sec
+	BlockWriteRate float64   `json:"block_write_rate"` // bytes/sec
+}
+
+// FleetLiveStats is one sample of the running containers of every enabled host, streamed by
+// /api/stats/live while a client is connected. Nothing of it is stored.
+type FleetLiveStats struct {
+	Timestamp   time.Time            `json:"timestamp"`
+	CPUPercent  float64              `json:"cpu_percent"`  // sum over the containers
+	MemoryUsage int64                `json:"memory_usage"` // sum over the containers
+	Hosts       []FleetLiveHost      `json:"hosts"`
+	Containers  []FleetLiveContainer `json:"containers"` // busiest first
+}
+
+// FleetLiveHost is a host's share of a fleet sample; Error is set when it couldn't be sampled
+type FleetLiveHost struct {
+	HostID      int64   `json:"host_id"`
+	HostName    string  `json:"host_name"`
+	Containers  int     `json:"containers"`
+	CPUPercent  float64 `json:"cpu_percent"`
+	MemoryUsage int64   `json:"memory_usage"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// FleetLiveContainer is a running container of a fleet sample
+type FleetLiveContainer struct {
+	HostID      int64  `json:"host_id"`
+	HostName    string `json:"host_name"`
+	ContainerID string `json:"container_id"`
+	Name        string `json:"name"`
+	Image       string `json:"image"`
+	LiveContainerStats
+}
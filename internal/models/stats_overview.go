@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// StatsOverview sums up the latest scan of every host for the dashboard header: fleet totals,
+// one rollup per host and the containers using the most CPU and memory right now
+type StatsOverview struct {
+	Totals    StatsTotals       `json:"totals"`
+	Hosts     []HostStatsRollup `json:"hosts"`
+	TopCPU    []ContainerUsage  `json:"top_cpu"`
+	TopMemory []ContainerUsage  `json:"top_memory"`
+	ScannedAt *time.Time        `json:"scanned_at,omitempty"` // the most recent scan
+}
+
+// StatsTotals is the rollup of all hosts
+type StatsTotals struct {
+	Hosts       int `json:"hosts"`
+	HostsOnline int `json:"hosts_online"`
+	StatsRollup
+}
+
+// StatsRollup totals the latest containers of a set of hosts. CPU and memory are summed over
+// running containers; a container without a memory limit reports its host's memory as limit.
+type StatsRollup struct {
+	Containers  int            `json:"containers"`
+	States      map[string]int `json:"states"` // containers by state (running, exited, ...)
+	CPUPercent  float64        `json:"cpu_percent"`
+	MemoryUsage int64          `json:"memory_usage"` // bytes
+	MemoryLimit int64          `json:"memory_limit"` // bytes
+}
+
+// HostStatsRollup is the rollup of one host
+type HostStatsRollup struct {
+	HostID   int64  `json:"host_id"`
+	HostName string `json:"host_name"`
+	Online   bool   `json:"online"`
+	StatsRollup
+}
+
+// ContainerUsage is the current resource usage of a container
+type ContainerUsage struct {
+	HostID        int64   `json:"host_id"`
+	HostName      string  `json:"host_name"`
+	ContainerID   string  `json:"container_id"`
+	ContainerName string  `json:"container_name"`
+	CPUPercent    float64 `json:"cpu_percent"`
+	MemoryUsage   int64   `json:"memory_usage"`
+	MemoryLimit   int64   `json:"memory_limit"`
+	MemoryPercent float64 `json:"memory_percent"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// MarshalJSON writes gap points with null values so charts break the line instead of drawing zeros
+func (p ContainerStatsPoint) MarshalJSON() ([]byte, error) {
+	if !p.Gap {
+		type point ContainerStatsPoint
+		return json.Marshal(point(p))
+	}
+	return json.Marshal(struct {
+		Timestamp      time.Time `json:"timestamp"`
+		CPUPercent     *float64  `json:"cpu_percent"`
+		MemoryUsage    *int64    `json:"memory_usage"`
+		MemoryLimit    *int64    `json:"memory_limit"`
+		MemoryPercent  *float64  `json:"memory_percent"`
+		NetworkRxRate  *float64  `json:"network_rx_rate"`
+		NetworkTxRate  *float64  `json:"network_tx_rate"`
+		BlockReadRate  *float64  `json:"block_read_rate"`
+		BlockWriteRate *float64  `json:"block_write_rate"`
+	}{Timestamp: p.Timestamp})
+}
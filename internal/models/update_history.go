@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Results of a performed container update
+const (
+	UpdateStatusSucceeded  = "succeeded"
+	UpdateStatusFailed     = "failed"
+	UpdateStatusRolledBack = "rolled_back" // failed its health check and the previous container was restored
+)
+
+// How a container update was started
+const (
+	UpdateTriggerManual = "manual" // the update button of one container
+	UpdateTriggerBulk   = "bulk"   // bulk update of several containers
+	UpdateTriggerCanary = "canary" // canary rollout of an image across hosts
+)
+
+// ContainerUpdate is one performed update of a container: which image it went from and to,
+// who started it, how long it took and how it ended. Kept separately from the lifecycle events
+// so the update history survives scan retention.
+type ContainerUpdate struct {
+	ID            int64     `json:"id"`
+	HostID        int64     `json:"host_id"`
+	HostName      string    `json:"host_name"`
+	ContainerName string    `json:"container_name"`
+	Image         string    `json:"image"`                  // reference that was pulled, e.g. nginx:latest
+	OldImageID    string    `json:"old_image_id,omitempty"` // image ID (sha256 digest) before the update
+	NewImageID    string    `json:"new_image_id,omitempty"` // after; the old one again when rolled back
+	TriggeredBy   string    `json:"triggered_by,omitempty"` // user name, empty without authentication
+	Trigger       string    `json:"trigger"`
+	Status        string    `json:"status"`
+	Error         string    `json:"error,omitempty"`
+	HealthStatus  string    `json:"health_status,omitempty"`
+	StartedAt     time.Time `json:"started_at"`
+	FinishedAt    time.Time `json:"finished_at"`
+	DurationMs    int64     `json:"duration_ms"`
+}
+
+// ContainerUpdateFilter selects container updates; zero values match everything
+type ContainerUpdateFilter struct {
+	HostIDs       []int64 // any of these hosts
+	ContainerName string
+	Status        string
+	Start         time.Time
+	End           time.Time
+	Limit         int
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// UpdateLag is how far a container is behind the newer build of its image found by the update
+// check. BehindSince is the earliest of when an update was first found for the image it runs and
+// the registry's creation time of the newer build.
+type UpdateLag struct {
+	HostID         int64     `json:"host_id"`
+	HostName       string    `json:"host_name"`
+	ContainerName  string    `json:"container_name"`
+	Image          string    `json:"image"`
+	ImageID        string    `json:"image_id"`
+	ComposeProject string    `json:"compose_project,omitempty"`
+	BehindSince    time.Time `json:"behind_since"`
+	CheckedAt      time.Time `json:"checked_at"`
+	DaysBehind     int       `json:"days_behind"`
+}
+
+// HostUpdateLag sums up the update lag of a host's containers
+type HostUpdateLag struct {
+	HostID       int64   `json:"host_id"`
+	HostName     string  `json:"host_name"`
+	Behind       int     `json:"behind"` // containers with a newer build available
+	AverageDays  float64 `json:"average_days"`
+	MaxDays      int     `json:"max_days"`
+	TotalDays    int     `json:"total_days"`
+	OldestBehind string  `json:"oldest_behind,omitempty"` // container furthest behind
+}
+
+// UpdateLagReport lists the containers behind upstream, furthest behind first, with per-host totals
+type UpdateLagReport struct {
+	GeneratedAt time.Time       `json:"generated_at"`
+	Behind      int             `json:"behind"`
+	AverageDays float64         `json:"average_days"`
+	MaxDays     int             `json:"max_days"`
+	Hosts       []HostUpdateLag `json:"hosts"`
+	Containers  []UpdateLag     `json:"containers"`
+}
+
+// BuildUpdateLagReport computes the days behind of each container at now and sums them up per
+// host, hosts with the most days behind first
+func BuildUpdateLagReport(lags []UpdateLag, now time.Time) *UpdateLagReport {
+	report := &UpdateLagReport{
+		GeneratedAt: now,
+		Hosts:       make([]HostUpdateLag, 0),
+		Containers:  make([]UpdateLag, 0, len(lags)),
+	}
+	hosts := make(map[int64]int)
+	total := 0
+	for _, lag := range lags {
+		lag.DaysBehind = 0
+		if now.After(lag.BehindSince) {
+			lag.DaysBehind = int(now.Sub(lag.BehindSince).Hours() / 24)
+		}
+		report.Containers = append(report.Containers, lag)
+		total += lag.DaysBehind
+		if lag.DaysBehind > report.MaxDays {
+			report.MaxDays = lag.DaysBehind
+		}
+
+		i, ok := hosts[lag.HostID]
+		if !ok {
+			i = len(report.Hosts)
+			hosts[lag.HostID] = i
+			report.Hosts = append(report.Hosts, HostUpdateLag{HostID: lag.HostID, HostName: lag.HostName})
+		}
+		host := &report.Hosts[i]
+		host.Behind++
+		host.TotalDays += lag.DaysBehind
+		if lag.DaysBehind > host.MaxDays || host.OldestBehind == "" {
+			host.MaxDays = lag.DaysBehind
+			host.OldestBehind = lag.ContainerName
+		}
+	}
+
+	report.Behind = len(report.Containers)
+	if report.Behind > 0 {
+		report.AverageDays = float64(total) / float64(report.Behind)
+	}
+	for i := range report.Hosts {
+		report.Hosts[i].AverageDays = float64(report.Hosts[i].TotalDays) / float64(report.Hosts[i].Behind)
+	}
+
+	sort.SliceStable(report.Containers, func(i, j int) bool {
+		return report.Containers[i].DaysBehind > report.Containers[j].DaysBehind
+	})
+	sort.SliceStable(report.Hosts, func(i, j int) bool {
+		return report.Hosts[i].TotalDays > report.Hosts[j].TotalDays
+	})
+	return report
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// UptimeLabel controls Uptime Kuma monitoring for a container: "false" opts the container out,
+// "true" monitors its first published TCP port and an http(s) URL sets the monitored URL
+const UptimeLabel = "census.uptime"
+
+// Uptime monitor statuses, matching the monitor_status values Uptime Kuma reports
+const (
+	UptimeStatusUp          = "up"
+	UptimeStatusDown        = "down"
+	UptimeStatusPending     = "pending"
+	UptimeStatusMaintenance = "maintenance"
+	UptimeStatusUnknown     = "unknown"
+)
+
+// MaskedSecret stands in for stored passwords and API keys in settings returned by the API
+const MaskedSecret = "********"
+
+// UptimeKumaSettings configures the Uptime Kuma integration
+type UptimeKumaSettings struct {
+	Enabled                bool   `json:"enabled"`
+	URL                    string `json:"url"`      // e.g. http://uptime-kuma:3001
+	Username               string `json:"username"` // used to create and update monitors
+	Password               string `json:"password"`
+	APIKey                 string `json:"api_key"` // used to read monitor status from /metrics
+	SyncMonitors           bool   `json:"sync_monitors"`
+	MonitorIntervalSeconds int    `json:"monitor_interval_seconds"`
+	SyncIntervalMinutes    int    `json:"sync_interval_minutes"`
+}
+
+// DefaultUptimeKumaSettings returns the settings used before the integration is configured
+func DefaultUptimeKumaSettings() *UptimeKumaSettings {
+	return &UptimeKumaSettings{
+		SyncMonitors:           true,
+		MonitorIntervalSeconds: 60,
+		SyncIntervalMinutes:    5,
+	}
+}
+
+// Validate validates Uptime Kuma settings
+func (s *UptimeKumaSettings) Validate() error {
+	if s.MonitorIntervalSeconds < 20 || s.MonitorIntervalSeconds > 86400 {
+		return fmt.Errorf("monitor interval must be between 20 and 86400 seconds")
+	}
+	if s.SyncIntervalMinutes < 1 || s.SyncIntervalMinutes > 1440 {
+		return fmt.Errorf("sync interval must be between 1 and 1440 minutes")
+	}
+	if !s.Enabled {
+		return nil
+	}
+
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an http(s) URL, e.g. http://uptime-kuma:3001")
+	}
+	if s.SyncMonitors && (s.Username == "" || s.Password == "") {
+		return fmt.Errorf("username and password are required to sync monitors")
+	}
+	return nil
+}
+
+// Redacted returns a copy of the settings that is safe to return from the API
+func (s *UptimeKumaSettings) Redacted() *UptimeKumaSettings {
+	clone := *s
+	if clone.Password != "" {
+		clone.Password = MaskedSecret
+	}
+	if clone.APIKey != "" {
+		clone.APIKey = MaskedSecret
+	}
+	return &clone
+}
+
+// UptimeMonitorStatus is the availability of a container as seen by its Uptime Kuma monitor
+type UptimeMonitorStatus struct {
+	HostID         int64     `json:"host_id"`
+	HostName       string    `json:"host_name"`
+	ContainerName  string    `json:"container_name"`
+	MonitorName    string    `json:"monitor_name"`
+	URL            string    `json:"url"`
+	Status         string    `json:"status"`
+	ResponseTimeMS float64   `json:"response_time_ms"`
+	Availability   float64   `json:"availability"` // percent of checks in the last 24 hours that were up
+	CheckedAt      time.Time `json:"checked_at"`
+}
+
+// UptimeKumaSyncResult summarizes one sync with Uptime Kuma
+type UptimeKumaSyncResult struct {
+	Monitors  int       `json:"monitors"` // containers with an HTTP port that should be monitored
+	Created   int       `json:"created"`
+	Updated   int       `json:"updated"`
+	Unchanged int       `json:"unchanged"`
+	Statuses  int       `json:"statuses"` // monitors whose status was ingested
+	Errors    []string  `json:"errors,omitempty"`
+	SyncedAt  time.Time `json:"synced_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+// VulnerabilityAlert is a running container whose image had new vulnerabilities when it was
+// rescanned after a vulnerability database update
+type VulnerabilityAlert struct {
+	HostID           int64
+	HostName         string
+	ContainerID      string
+	ContainerName    string
+	Image            string
+	ImageID          string
+	VulnerabilityIDs []string // new findings, most severe first
+	Critical         int      // new critical findings
+	High             int      // new high findings, when alert_on_high is on
+	TrivyDBVersion   string
+}
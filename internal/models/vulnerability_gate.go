@@ -0,0 +1,41 @@
+package models
+
+import "fmt"
+
+// Modes of the vulnerability gate for container updates (ImageUpdateSettings.VulnerabilityGate)
+const (
+	VulnerabilityGateOff   = "off"
+	VulnerabilityGateWarn  = "warn"  // refuse until the update is sent again with the risk accepted
+	VulnerabilityGateBlock = "block" // always refuse
+)
+
+// VulnerabilityGateCheck compares the critical vulnerabilities of a container's current image
+// with the image it would be updated to, after the pull and before the recreate
+type VulnerabilityGateCheck struct {
+	Mode        string `json:"mode"`
+	Image       string `json:"image"`
+	OldImageID  string `json:"old_image_id"`
+	NewImageID  string `json:"new_image_id,omitempty"`
+	OldCritical int    `json:"old_critical"`
+	NewCritical int    `json:"new_critical"`
+	Gated       bool   `json:"gated"`           // the new image has more critical vulnerabilities
+	Error       string `json:"error,omitempty"` // why the images couldn't be compared; the update isn't held back
+}
+
+// Refuses reports whether the update must not go ahead; accepted is whether the caller accepted
+// the risk of a warning
+func (c *VulnerabilityGateCheck) Refuses(accepted bool) bool {
+	if c == nil || !c.Gated {
+		return false
+	}
+	return c.Mode == VulnerabilityGateBlock || !accepted
+}
+
+// Message explains a refused update
+func (c *VulnerabilityGateCheck) Message() string {
+	msg := fmt.Sprintf("The new %s image has %d critical vulnerabilities, the current one %d", c.Image, c.NewCritical, c.OldCritical)
+	if c.Mode == VulnerabilityGateBlock {
+		return msg + "; updates to it are blocked by the vulnerability gate"
+	}
+	return msg + "; accept the risk to update anyway"
+}
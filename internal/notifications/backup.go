@@ -0,0 +1,69 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// DetectOverdueBackups sends a backup_overdue event for every backup job that became overdue
+// after since. Jobs that were already overdue at the previous check aren't reported again.
+func (ns *NotificationService) DetectOverdueBackups(ctx context.Context, since time.Time) error {
+	now := time.Now()
+	jobs, err := ns.db.GetBackupJobs(now)
+	if err != nil {
+		return fmt.Errorf("failed to get backup jobs: %w", err)
+	}
+
+	var events []models.NotificationEvent
+	for _, job := range overdueSince(jobs, since) {
+		metadata := map[string]interface{}{
+			"tool":             job.Tool,
+			"detail":           job.Detail,
+			"interval_seconds": job.IntervalSeconds,
+			"due_at":           job.DueAt,
+		}
+		if job.LastSuccessAt != nil {
+			metadata["last_success_at"] = *job.LastSuccessAt
+		}
+
+		var containerID string
+		if job.LastRun != nil {
+			containerID = job.LastRun.ContainerID
+		}
+		events = append(events, models.NotificationEvent{
+			EventType:     models.EventTypeBackupOverdue,
+			Timestamp:     now,
+			ContainerID:   containerID,
+			ContainerName: job.ContainerName,
+			HostID:        job.HostID,
+			HostName:      job.HostName,
+			Image:         job.Image,
+			Metadata:      metadata,
+		})
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	tasks, err := ns.matchRules(ctx, events)
+	if err != nil {
+		return fmt.Errorf("failed to match rules: %w", err)
+	}
+
+	return ns.sendNotifications(ctx, ns.filterSilenced(tasks))
+}
+
+// overdueSince returns the overdue jobs whose due time passed after since
+func overdueSince(jobs []models.BackupJob, since time.Time) []models.BackupJob {
+	var overdue []models.BackupJob
+	for _, job := range jobs {
+		if job.Status == models.BackupStatusOverdue && job.DueAt.After(since) {
+			overdue = append(overdue, job)
+		}
+	}
+	return overdue
+}
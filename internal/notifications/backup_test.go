@@ -0,0 +1,25 @@
+package notifications
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestOverdueSince(t *testing.T) {
+	now := time.Now()
+	lastCheck := now.Add(-time.Hour)
+
+	jobs := []models.BackupJob{
+		{ContainerName: "just-overdue", Status: models.BackupStatusOverdue, DueAt: now.Add(-10 * time.Minute)},
+		{ContainerName: "long-overdue", Status: models.BackupStatusOverdue, DueAt: now.Add(-5 * time.Hour)},
+		{ContainerName: "failed", Status: models.BackupStatusFailed, DueAt: now.Add(5 * time.Hour)},
+		{ContainerName: "ok", Status: models.BackupStatusOK, DueAt: now.Add(20 * time.Hour)},
+	}
+
+	overdue := overdueSince(jobs, lastCheck)
+	if len(overdue) != 1 || overdue[0].ContainerName != "just-overdue" {
+		t.Errorf("Expected only the job that became overdue since the last check, got %+v", overdue)
+	}
+}
@@ -50,6 +50,11 @@ func (bc *BaselineCollector) UpdateBaselines(ctx context.Context) error {
 	}
 
 	log.Printf("Baseline update complete: %d updated, %d failed", updated, failed)
+
+	if err := bc.UpdateSeasonalBaselines(); err != nil {
+		log.Printf("Failed to update seasonal baselines: %v", err)
+	}
+
 	return nil
 }
 
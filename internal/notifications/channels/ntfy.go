@@ -74,6 +74,9 @@ func (nc *NtfyChannel) Send(ctx context.Context, message string, event models.No
 
 	// Add actions/click URL if applicable
 	// (Could link back to Census UI showing the container)
+	if url, _ := event.Metadata["changelog_url"].(string); url != "" {
+		ntfyMsg["click"] = url
+	}
 
 	payloadBytes, err := json.Marshal(ntfyMsg)
 	if err != nil {
@@ -126,6 +129,22 @@ func (nc *NtfyChannel) getPriority(eventType string) int {
 		return 4 // High
 	case models.EventTypeAnomalousBehavior:
 		return 4 // High
+	case models.EventTypePrivilegedContainer:
+		return 4 // High
+	case models.EventTypeBackupOverdue:
+		return 4 // High
+	case models.EventTypeOOMKill:
+		return 5 // Max
+	case models.EventTypeDaemonError:
+		return 4 // High
+	case models.EventTypeRestartPolicy:
+		return 3 // Default
+	case models.EventTypeCertExpiring:
+		return 4 // High
+	case models.EventTypeDNSFailure:
+		return 4 // High
+	case models.EventTypeNewVulnerability:
+		return 4 // High
 	case models.EventTypeNewImage:
 		return 3 // Default
 	case models.EventTypeContainerStarted:
@@ -144,10 +163,30 @@ func (nc *NtfyChannel) getTags(eventType string) []string {
 		return []string{"octagonal_sign"}
 	case models.EventTypeNewImage:
 		return []string{"arrows_counterclockwise"}
+	case models.EventTypeImageUpdateAvailable:
+		return []string{"arrow_up"}
 	case models.EventTypeHighCPU, models.EventTypeHighMemory:
 		return []string{"warning"}
 	case models.EventTypeAnomalousBehavior:
 		return []string{"mag"}
+	case models.EventTypePrivilegedContainer:
+		return []string{"shield"}
+	case models.EventTypeBackupOverdue:
+		return []string{"floppy_disk"}
+	case models.EventTypeScriptAlert:
+		return []string{"scroll"}
+	case models.EventTypeOOMKill:
+		return []string{"boom"}
+	case models.EventTypeDaemonError:
+		return []string{"whale"}
+	case models.EventTypeRestartPolicy:
+		return []string{"repeat"}
+	case models.EventTypeCertExpiring:
+		return []string{"lock"}
+	case models.EventTypeDNSFailure:
+		return []string{"globe_with_meridians"}
+	case models.EventTypeNewVulnerability:
+		return []string{"rotating_light"}
 	default:
 		return []string{"information_source"}
 	}
@@ -3,19 +3,36 @@ package channels
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/container-census/container-census/internal/models"
 )
 
+const (
+	// SignatureHeader carries the hex HMAC-SHA256 of the request body, prefixed with "sha256="
+	SignatureHeader = "X-Census-Signature-256"
+
+	defaultWebhookAttempts = 3
+	maxWebhookAttempts     = 10
+	defaultWebhookBackoff  = time.Second
+	maxWebhookBackoff      = time.Minute
+)
+
 // WebhookChannel implements webhook notifications
 type WebhookChannel struct {
-	name   string
-	config models.WebhookConfig
-	client *http.Client
+	name     string
+	config   models.WebhookConfig
+	client   *http.Client
+	attempts int
+	backoff  time.Duration
 }
 
 // NewWebhookChannel creates a new webhook channel
@@ -34,6 +51,21 @@ func NewWebhookChannel(ch *models.NotificationChannel) (*WebhookChannel, error)
 	if webhookConfig.URL == "" {
 		return nil, fmt.Errorf("webhook URL is required")
 	}
+	if webhookConfig.MaxAttempts < 0 || webhookConfig.MaxAttempts > maxWebhookAttempts {
+		return nil, fmt.Errorf("max_attempts must be between 1 and %d", maxWebhookAttempts)
+	}
+	if webhookConfig.RetryBackoffSeconds < 0 {
+		return nil, fmt.Errorf("retry_backoff_seconds must not be negative")
+	}
+
+	attempts := webhookConfig.MaxAttempts
+	if attempts == 0 {
+		attempts = defaultWebhookAttempts
+	}
+	backoff := defaultWebhookBackoff
+	if webhookConfig.RetryBackoffSeconds > 0 {
+		backoff = time.Duration(webhookConfig.RetryBackoffSeconds) * time.Second
+	}
 
 	return &WebhookChannel{
 		name:   ch.Name,
@@ -41,6 +73,8 @@ func NewWebhookChannel(ch *models.NotificationChannel) (*WebhookChannel, error)
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		attempts: attempts,
+		backoff:  backoff,
 	}, nil
 }
 
@@ -77,6 +111,9 @@ func (wc *WebhookChannel) Send(ctx context.Context, message string, event models
 	if event.MemoryPercent > 0 {
 		payload["memory_percent"] = event.MemoryPercent
 	}
+	if event.Owner != nil {
+		payload["owner"] = event.Owner
+	}
 	if len(event.Metadata) > 0 {
 		payload["metadata"] = event.Metadata
 	}
@@ -86,10 +123,39 @@ func (wc *WebhookChannel) Send(ctx context.Context, message string, event models
 		return fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	// Create request
-	req, err := http.NewRequestWithContext(ctx, "POST", wc.config.URL, bytes.NewReader(payloadBytes))
+	attempts, backoff := wc.attempts, wc.backoff
+
+	// Retry network errors, 429 and 5xx with exponential backoff; other responses are final
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		retryable, err := wc.deliver(ctx, payloadBytes)
+		if err == nil {
+			return nil
+		}
+		lastErr = fmt.Errorf("attempt %d: %w", attempt, err)
+		if !retryable || attempt == attempts {
+			break
+		}
+
+		if !sleepContext(ctx, backoff) {
+			lastErr = fmt.Errorf("attempt %d: %w (retry cancelled: %v)", attempt, err, ctx.Err())
+			break
+		}
+		backoff = min(backoff*2, maxWebhookBackoff)
+	}
+
+	// Dead letter: keep the undelivered payload in the log so it can be replayed by hand
+	log.Printf("Webhook %q dead letter: delivery to %s failed permanently: %v; payload: %s",
+		wc.name, wc.config.URL, lastErr, payloadBytes)
+
+	return fmt.Errorf("webhook delivery failed: %w", lastErr)
+}
+
+// deliver makes one delivery attempt and reports whether a failure is worth retrying
+func (wc *WebhookChannel) deliver(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", wc.config.URL, bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return false, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -100,26 +166,44 @@ func (wc *WebhookChannel) Send(ctx context.Context, message string, event models
 		req.Header.Set(key, value)
 	}
 
-	// Send with retry logic (3 attempts)
-	var lastErr error
-	for attempt := 1; attempt <= 3; attempt++ {
-		resp, err := wc.client.Do(req)
-		if err != nil {
-			lastErr = fmt.Errorf("attempt %d failed: %w", attempt, err)
-			time.Sleep(time.Duration(attempt) * time.Second)
-			continue
-		}
-		defer resp.Body.Close()
+	if wc.config.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(wc.config.Secret, body))
+	}
 
-		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			return nil // Success
-		}
+	resp, err := wc.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
 
-		lastErr = fmt.Errorf("attempt %d: HTTP %d", attempt, resp.StatusCode)
-		time.Sleep(time.Duration(attempt) * time.Second)
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
 	}
 
-	return fmt.Errorf("webhook failed after 3 attempts: %w", lastErr)
+	retryable := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retryable, fmt.Errorf("HTTP %d", resp.StatusCode)
+}
+
+// sleepContext waits for d and returns false if ctx is cancelled first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// Sign returns the X-Census-Signature-256 value for a payload: "sha256=" followed by the hex
+// HMAC-SHA256 of the body keyed with the channel secret. Receivers should recompute it over the
+// raw request body and compare with hmac.Equal.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
 }
 
 // Test sends a test notification
@@ -2,6 +2,9 @@ package channels
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -385,3 +388,153 @@ func TestWebhookChannel_TypeAndName(t *testing.T) {
 		t.Errorf("Expected name 'my-webhook', got '%s'", wc.Name())
 	}
 }
+
+// TestWebhookChannel_Signature tests HMAC-SHA256 signing with the channel secret
+func TestWebhookChannel_Signature(t *testing.T) {
+	var signature string
+	var body []byte
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get(SignatureHeader)
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	channel := &models.NotificationChannel{
+		Name: "signed-webhook",
+		Type: "webhook",
+		Config: map[string]interface{}{
+			"url":    server.URL,
+			"secret": "s3cret",
+		},
+	}
+
+	wc, err := NewWebhookChannel(channel)
+	if err != nil {
+		t.Fatalf("NewWebhookChannel failed: %v", err)
+	}
+
+	if err := wc.Send(context.Background(), "Test", models.NotificationEvent{EventType: "test", Timestamp: time.Now()}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if signature != expected {
+		t.Errorf("Expected signature %s, got %s", expected, signature)
+	}
+}
+
+// TestWebhookChannel_NoSignatureWithoutSecret tests that unsigned channels send no signature header
+func TestWebhookChannel_NoSignatureWithoutSecret(t *testing.T) {
+	var hasSignature bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, hasSignature = r.Header[SignatureHeader]
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	wc, err := NewWebhookChannel(&models.NotificationChannel{
+		Name:   "test-webhook",
+		Type:   "webhook",
+		Config: map[string]interface{}{"url": server.URL},
+	})
+	if err != nil {
+		t.Fatalf("NewWebhookChannel failed: %v", err)
+	}
+
+	if err := wc.Send(context.Background(), "Test", models.NotificationEvent{EventType: "test", Timestamp: time.Now()}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if hasSignature {
+		t.Error("Expected no signature header without a secret")
+	}
+}
+
+// TestWebhookChannel_NoRetryOnClientError tests that 4xx responses fail without retrying
+func TestWebhookChannel_NoRetryOnClientError(t *testing.T) {
+	attemptCount := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attemptCount++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	wc, err := NewWebhookChannel(&models.NotificationChannel{
+		Name:   "test-webhook",
+		Type:   "webhook",
+		Config: map[string]interface{}{"url": server.URL},
+	})
+	if err != nil {
+		t.Fatalf("NewWebhookChannel failed: %v", err)
+	}
+
+	if err := wc.Send(context.Background(), "Test", models.NotificationEvent{EventType: "test", Timestamp: time.Now()}); err == nil {
+		t.Error("Expected error for HTTP 400")
+	}
+	if attemptCount != 1 {
+		t.Errorf("Expected 1 attempt, got %d", attemptCount)
+	}
+}
+
+// TestWebhookChannel_ConfiguredRetries tests max_attempts and that every retry resends the full body
+func TestWebhookChannel_ConfiguredRetries(t *testing.T) {
+	var bodies []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) < 5 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	wc, err := NewWebhookChannel(&models.NotificationChannel{
+		Name: "test-webhook",
+		Type: "webhook",
+		Config: map[string]interface{}{
+			"url":                   server.URL,
+			"max_attempts":          5,
+			"retry_backoff_seconds": 1,
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewWebhookChannel failed: %v", err)
+	}
+	if wc.attempts != 5 || wc.backoff != time.Second {
+		t.Fatalf("Expected 5 attempts with 1s backoff, got %d and %v", wc.attempts, wc.backoff)
+	}
+	wc.backoff = time.Millisecond // keep the test fast
+
+	if err := wc.Send(context.Background(), "Test", models.NotificationEvent{EventType: "test", Timestamp: time.Now()}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if len(bodies) != 5 {
+		t.Fatalf("Expected 5 attempts, got %d", len(bodies))
+	}
+	for i, body := range bodies {
+		if body == "" || body != bodies[0] {
+			t.Errorf("Attempt %d sent a different body: %q", i+1, body)
+		}
+	}
+}
+
+// TestWebhookChannel_InvalidRetryConfig tests validation of retry settings
+func TestWebhookChannel_InvalidRetryConfig(t *testing.T) {
+	for _, config := range []map[string]interface{}{
+		{"url": "https://example.com", "max_attempts": 11},
+		{"url": "https://example.com", "max_attempts": -1},
+		{"url": "https://example.com", "retry_backoff_seconds": -5},
+	} {
+		if _, err := NewWebhookChannel(&models.NotificationChannel{Name: "w", Type: "webhook", Config: config}); err == nil {
+			t.Errorf("Expected error for config %v", config)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// SendDaemonEvents notifies the rules subscribed to oom_kill or daemon_error of the events an
+// agent read from its host's kernel and Docker daemon logs. Rule cooldowns apply per container,
+// so a container that keeps running out of memory doesn't flood its channels.
+func (ns *NotificationService) SendDaemonEvents(ctx context.Context, daemonEvents []models.DaemonEvent) error {
+	if len(daemonEvents) == 0 {
+		return nil
+	}
+
+	events := make([]models.NotificationEvent, 0, len(daemonEvents))
+	for _, e := range daemonEvents {
+		metadata := map[string]interface{}{
+			"source":  e.Source,
+			"message": e.Message,
+		}
+		if e.Process != "" {
+			metadata["process"] = e.Process
+			metadata["pid"] = e.PID
+		}
+		eventType := models.EventTypeDaemonError
+		if e.Kind == models.DaemonEventOOMKill {
+			eventType = models.EventTypeOOMKill
+		}
+		events = append(events, models.NotificationEvent{
+			EventType:     eventType,
+			Timestamp:     e.OccurredAt,
+			ContainerID:   e.ContainerID,
+			ContainerName: e.ContainerName,
+			HostID:        e.HostID,
+			HostName:      e.HostName,
+			Metadata:      metadata,
+		})
+	}
+
+	tasks, err := ns.matchRules(ctx, events)
+	if err != nil {
+		return fmt.Errorf("failed to match rules: %w", err)
+	}
+
+	return ns.sendNotifications(ctx, ns.filterSilenced(tasks))
+}
@@ -0,0 +1,49 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// SendEndpointAlerts notifies the rules subscribed to cert_expiring or dns_failure of what an
+// endpoint check found (see endpoints.Evaluate). Events carry the container serving the
+// hostname, so host filters and silences apply to it.
+func (ns *NotificationService) SendEndpointAlerts(ctx context.Context, alerts []models.EndpointAlert) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	events := make([]models.NotificationEvent, 0, len(alerts))
+	for _, alert := range alerts {
+		check := alert.Check
+		metadata := map[string]interface{}{
+			"hostname": check.Hostname,
+			"url":      check.URL,
+		}
+		if alert.EventType == models.EventTypeDNSFailure {
+			metadata["error"] = check.DNSError
+		}
+		if check.CertNotAfter != nil {
+			metadata["not_after"] = *check.CertNotAfter
+			metadata["days_remaining"] = *check.DaysRemaining
+			metadata["issuer"] = check.CertIssuer
+		}
+		events = append(events, models.NotificationEvent{
+			EventType:     alert.EventType,
+			Timestamp:     check.CheckedAt,
+			ContainerName: check.ContainerName,
+			HostID:        check.HostID,
+			HostName:      check.HostName,
+			Metadata:      metadata,
+		})
+	}
+
+	tasks, err := ns.matchRules(ctx, events)
+	if err != nil {
+		return fmt.Errorf("failed to match rules: %w", err)
+	}
+
+	return ns.sendNotifications(ctx, ns.filterSilenced(tasks))
+}
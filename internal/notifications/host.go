@@ -0,0 +1,55 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// SendHostStatus notifies the rules subscribed to host_down or host_recovered of a host going
+// down or recovering. Flapping is already damped by the consecutive scans a change takes (see
+// storage.RecordHostHeartbeat), so rule cooldowns don't apply: a recovery always follows its
+// host_down notification.
+func (ns *NotificationService) SendHostStatus(ctx context.Context, change models.HostStatusChange) error {
+	downtime := change.Downtime
+	metadata := map[string]interface{}{
+		"down_since": downtime.StartedAt,
+	}
+	timestamp := downtime.StartedAt
+	if downtime.Error != "" {
+		metadata["error"] = downtime.Error
+	}
+	if downtime.EndedAt != nil {
+		timestamp = *downtime.EndedAt
+		metadata["recovered_at"] = *downtime.EndedAt
+		metadata["downtime_seconds"] = int64(downtime.Duration(timestamp).Seconds())
+	}
+
+	event := models.NotificationEvent{
+		EventType: change.EventType,
+		Timestamp: timestamp,
+		HostID:    downtime.HostID,
+		HostName:  downtime.HostName,
+		Metadata:  metadata,
+	}
+
+	tasks, err := ns.matchRules(ctx, []models.NotificationEvent{event})
+	if err != nil {
+		return fmt.Errorf("failed to match rules: %w", err)
+	}
+
+	return ns.sendNotifications(ctx, ns.filterSilenced(tasks))
+}
+
+// isHostStatusEvent reports whether an event is a host going down or recovering
+func isHostStatusEvent(eventType string) bool {
+	return eventType == models.EventTypeHostDown || eventType == models.EventTypeHostRecovered
+}
+
+// formatDowntime formats a downtime duration from event metadata for messages
+func formatDowntime(seconds interface{}) string {
+	s, _ := seconds.(int64)
+	return (time.Duration(s) * time.Second).String()
+}
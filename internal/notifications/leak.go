@@ -0,0 +1,165 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+const (
+	// leakHistoryDays is how many complete days of memory history the leak analyzer looks at
+	leakHistoryDays = 14
+	// leakDipTolerance is the largest day-over-day drop (fraction) still counted as steady growth,
+	// so a small GC or cache eviction doesn't reset the trend
+	leakDipTolerance = 0.01
+	// leakMinReportDays and leakMinReportGrowth keep obvious noise out of the rule pipeline;
+	// rules apply their own, usually stricter, sensitivity on top
+	leakMinReportDays   = 2
+	leakMinReportGrowth = 1.0
+	// defaultLeakGrowthThreshold and defaultLeakMinDays apply to rules without leak settings
+	defaultLeakGrowthThreshold = 5.0
+	defaultLeakMinDays         = 3
+)
+
+// memoryTrend describes the most recent run of steady memory growth for a container
+type memoryTrend struct {
+	GrowingDays         int     // consecutive days of (near-)monotonic growth
+	GrowthPercentPerDay float64 // least-squares slope over the run, relative to its first day
+	StartMemory         int64
+	CurrentMemory       int64
+}
+
+// analyzeMemoryTrend finds the trailing run of consecutive days whose average memory never
+// dropped by more than leakDipTolerance and fits a linear trend to it. Days must be sorted
+// ascending; a missing day ends the run.
+func analyzeMemoryTrend(days []models.DailyMemoryUsage) memoryTrend {
+	if len(days) < 2 {
+		return memoryTrend{}
+	}
+
+	start := len(days) - 1
+	for start > 0 {
+		prev, cur := days[start-1], days[start]
+		prevDay, err1 := time.Parse("2006-01-02", prev.Day)
+		curDay, err2 := time.Parse("2006-01-02", cur.Day)
+		if err1 != nil || err2 != nil || curDay.Sub(prevDay) != 24*time.Hour {
+			break
+		}
+		if float64(cur.AvgMemoryUsage) < float64(prev.AvgMemoryUsage)*(1-leakDipTolerance) {
+			break
+		}
+		start--
+	}
+
+	run := days[start:]
+	trend := memoryTrend{
+		GrowingDays:   len(run) - 1,
+		StartMemory:   run[0].AvgMemoryUsage,
+		CurrentMemory: run[len(run)-1].AvgMemoryUsage,
+	}
+	if trend.GrowingDays == 0 || trend.StartMemory <= 0 || trend.CurrentMemory <= trend.StartMemory {
+		return memoryTrend{}
+	}
+
+	var sumX, sumY, sumXX, sumXY float64
+	n := float64(len(run))
+	for i, d := range run {
+		x, y := float64(i), float64(d.AvgMemoryUsage)
+		sumX += x
+		sumY += y
+		sumXX += x * x
+		sumXY += x * y
+	}
+	slope := (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+	trend.GrowthPercentPerDay = slope / float64(trend.StartMemory) * 100
+
+	return trend
+}
+
+// DetectMemoryLeaks looks for running containers whose daily average memory has grown steadily
+// over the last days and sends memory_leak events. Each rule then applies its own sensitivity
+// (leak_growth_threshold %/day and leak_min_days).
+func (ns *NotificationService) DetectMemoryLeaks(ctx context.Context) error {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	usage, err := ns.db.GetDailyMemoryUsage(today.AddDate(0, 0, -leakHistoryDays), today)
+	if err != nil {
+		return fmt.Errorf("failed to get daily memory usage: %w", err)
+	}
+
+	containers, err := ns.db.GetLatestContainers()
+	if err != nil {
+		return fmt.Errorf("failed to get containers: %w", err)
+	}
+	running := make(map[string]models.Container)
+	for _, c := range containers {
+		if c.State == "running" {
+			running[fmt.Sprintf("%d/%s", c.HostID, c.Name)] = c
+		}
+	}
+
+	var events []models.NotificationEvent
+	for start := 0; start < len(usage); {
+		end := start
+		for end < len(usage) && usage[end].HostID == usage[start].HostID && usage[end].ContainerName == usage[start].ContainerName {
+			end++
+		}
+		series := usage[start:end]
+		start = end
+
+		container, ok := running[fmt.Sprintf("%d/%s", series[0].HostID, series[0].ContainerName)]
+		if !ok {
+			continue
+		}
+
+		trend := analyzeMemoryTrend(series)
+		if trend.GrowingDays < leakMinReportDays || trend.GrowthPercentPerDay < leakMinReportGrowth {
+			continue
+		}
+
+		events = append(events, models.NotificationEvent{
+			EventType:     models.EventTypeMemoryLeak,
+			Timestamp:     time.Now(),
+			ContainerID:   container.ID,
+			ContainerName: container.Name,
+			HostID:        container.HostID,
+			HostName:      container.HostName,
+			Image:         container.Image,
+			MemoryPercent: container.MemoryPercent,
+			Metadata: map[string]interface{}{
+				"growth_percent_per_day": trend.GrowthPercentPerDay,
+				"growing_days":           trend.GrowingDays,
+				"start_memory":           trend.StartMemory,
+				"current_memory":         trend.CurrentMemory,
+			},
+		})
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	tasks, err := ns.matchRules(ctx, events)
+	if err != nil {
+		return fmt.Errorf("failed to match rules: %w", err)
+	}
+
+	return ns.sendNotifications(ctx, ns.filterSilenced(tasks))
+}
+
+// leakRuleMatches checks a memory_leak event against the rule's leak sensitivity
+func leakRuleMatches(rule models.NotificationRule, event models.NotificationEvent) bool {
+	threshold := defaultLeakGrowthThreshold
+	if rule.LeakGrowthThreshold != nil {
+		threshold = *rule.LeakGrowthThreshold
+	}
+	minDays := defaultLeakMinDays
+	if rule.LeakMinDays > 0 {
+		minDays = rule.LeakMinDays
+	}
+
+	growth, _ := event.Metadata["growth_percent_per_day"].(float64)
+	days, _ := event.Metadata["growing_days"].(int)
+	return growth >= threshold && days >= minDays
+}
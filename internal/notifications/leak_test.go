@@ -0,0 +1,85 @@
+package notifications
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// dailyUsage builds consecutive daily memory samples in MB starting at 2025-09-01
+func dailyUsage(mb ...int64) []models.DailyMemoryUsage {
+	start := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	usage := make([]models.DailyMemoryUsage, len(mb))
+	for i, m := range mb {
+		usage[i] = models.DailyMemoryUsage{
+			ContainerName:  "app",
+			HostID:         1,
+			Day:            start.AddDate(0, 0, i).Format("2006-01-02"),
+			AvgMemoryUsage: m * 1024 * 1024,
+		}
+	}
+	return usage
+}
+
+func TestAnalyzeMemoryTrend(t *testing.T) {
+	gap := dailyUsage(100, 110, 120, 130)
+	gap[2].Day = "2025-09-10"
+	gap[3].Day = "2025-09-11"
+
+	tests := []struct {
+		name       string
+		days       []models.DailyMemoryUsage
+		wantDays   int
+		minPercent float64
+		maxPercent float64
+	}{
+		{"steady growth", dailyUsage(100, 110, 120, 130, 140), 4, 9.9, 10.1},
+		{"growth after restart", dailyUsage(300, 100, 110, 120), 2, 9.9, 10.1},
+		{"small dip tolerated", dailyUsage(1000, 1100, 1095, 1200), 3, 5, 7},
+		{"missing day ends run", gap, 1, 8.2, 8.4},
+		{"flat", dailyUsage(100, 100, 100), 0, 0, 0},
+		{"shrinking", dailyUsage(140, 130, 120), 0, 0, 0},
+		{"single day", dailyUsage(100), 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trend := analyzeMemoryTrend(tt.days)
+			if trend.GrowingDays != tt.wantDays {
+				t.Errorf("Expected %d growing days, got %d", tt.wantDays, trend.GrowingDays)
+			}
+			if trend.GrowthPercentPerDay < tt.minPercent || trend.GrowthPercentPerDay > tt.maxPercent {
+				t.Errorf("Expected growth between %.1f and %.1f %%/day, got %.2f", tt.minPercent, tt.maxPercent, trend.GrowthPercentPerDay)
+			}
+		})
+	}
+}
+
+func TestLeakRuleMatches(t *testing.T) {
+	event := func(growth float64, days int) models.NotificationEvent {
+		return models.NotificationEvent{
+			EventType: models.EventTypeMemoryLeak,
+			Metadata: map[string]interface{}{
+				"growth_percent_per_day": growth,
+				"growing_days":           days,
+			},
+		}
+	}
+	threshold := 2.0
+	sensitive := models.NotificationRule{LeakGrowthThreshold: &threshold, LeakMinDays: 2}
+	defaults := models.NotificationRule{}
+
+	if !leakRuleMatches(sensitive, event(2.5, 2)) {
+		t.Error("Sensitive rule should match 2.5%/day over 2 days")
+	}
+	if leakRuleMatches(defaults, event(2.5, 2)) {
+		t.Error("Default rule should not match 2.5%/day over 2 days")
+	}
+	if !leakRuleMatches(defaults, event(6, 3)) {
+		t.Error("Default rule should match 6%/day over 3 days")
+	}
+	if leakRuleMatches(sensitive, event(10, 1)) {
+		t.Error("Rule should not match growth shorter than its minimum days")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -22,6 +23,11 @@ type NotificationService struct {
 	rateLimiter    *RateLimiter
 	thresholdState map[string]*ThresholdTracker // key: containerID-hostID-type
 	thresholdMu    sync.RWMutex
+	subscribers    map[chan models.NotificationLog]struct{}
+	subscribersMu  sync.Mutex
+	// thresholdDuration is how long CPU/memory have to stay over a threshold, see UpdateSettings
+	thresholdDuration time.Duration
+	settingsMu        sync.RWMutex
 }
 
 // ThresholdTracker tracks threshold breach state for a container
@@ -38,6 +44,9 @@ func NewNotificationService(db *storage.DB, maxNotificationsPerHour int, batchIn
 		channels:       make(map[int64]channels.Channel),
 		rateLimiter:    NewRateLimiter(maxNotificationsPerHour, batchInterval),
 		thresholdState: make(map[string]*ThresholdTracker),
+		subscribers:    make(map[chan models.NotificationLog]struct{}),
+
+		thresholdDuration: 120 * time.Second,
 	}
 
 	// Set notifier reference in rate limiter for batch sending
@@ -46,6 +55,24 @@ func NewNotificationService(db *storage.DB, maxNotificationsPerHour int, batchIn
 	return ns
 }
 
+// UpdateSettings applies changed notification settings to the running service: the rate limit,
+// the batch interval and the threshold duration. Threshold breaches already being tracked are
+// measured against the new duration.
+func (ns *NotificationService) UpdateSettings(settings models.NotificationSettings) {
+	ns.rateLimiter.UpdateLimits(settings.RateLimitMax, time.Duration(settings.RateLimitBatchInterval)*time.Second)
+
+	ns.settingsMu.Lock()
+	ns.thresholdDuration = time.Duration(settings.ThresholdDuration) * time.Second
+	ns.settingsMu.Unlock()
+}
+
+// getThresholdDuration returns how long a threshold has to be breached before notifying
+func (ns *NotificationService) getThresholdDuration() time.Duration {
+	ns.settingsMu.RLock()
+	defer ns.settingsMu.RUnlock()
+	return ns.thresholdDuration
+}
+
 // ProcessEvents is the main entry point called after each scan
 func (ns *NotificationService) ProcessEvents(ctx context.Context, hostID int64) error {
 	// 1. Detect lifecycle events (state changes, image updates)
@@ -66,9 +93,23 @@ func (ns *NotificationService) ProcessEvents(ctx context.Context, hostID int64)
 		return fmt.Errorf("failed to detect anomalies: %w", err)
 	}
 
+	// 4. Detect containers that became privileged in this scan
+	securityEvents, err := ns.detectSecurityEvents(hostID)
+	if err != nil {
+		return fmt.Errorf("failed to detect security events: %w", err)
+	}
+
+	// 5. Detect restart policy changes and services without a restart policy
+	restartPolicyEvents, err := ns.detectRestartPolicyEvents(hostID)
+	if err != nil {
+		return fmt.Errorf("failed to detect restart policy events: %w", err)
+	}
+
 	// Combine all events
 	allEvents := append(lifecycleEvents, thresholdEvents...)
 	allEvents = append(allEvents, anomalyEvents...)
+	allEvents = append(allEvents, securityEvents...)
+	allEvents = append(allEvents, restartPolicyEvents...)
 
 	if len(allEvents) == 0 {
 		return nil
@@ -76,19 +117,63 @@ func (ns *NotificationService) ProcessEvents(ctx context.Context, hostID int64)
 
 	log.Printf("Notification service: Processing %d events for host %d", len(allEvents), hostID)
 
-	// 4. Match events against rules
+	// 6. Match events against rules
 	notifications, err := ns.matchRules(ctx, allEvents)
 	if err != nil {
 		return fmt.Errorf("failed to match rules: %w", err)
 	}
 
-	// 5. Apply silences
+	// 7. Apply silences
 	notifications = ns.filterSilenced(notifications)
 
-	// 6. Send notifications with rate limiting
+	// 8. Send notifications with rate limiting
 	return ns.sendNotifications(ctx, notifications)
 }
 
+// SendIdleDigest sends one digest event per host listing its likely idle containers.
+// Only rules subscribed to the idle_containers event type receive it.
+func (ns *NotificationService) SendIdleDigest(ctx context.Context, report *models.IdleContainerReport) error {
+	byHost := make(map[int64][]models.IdleContainer)
+	var hostOrder []int64
+	for _, c := range report.Containers {
+		if _, seen := byHost[c.HostID]; !seen {
+			hostOrder = append(hostOrder, c.HostID)
+		}
+		byHost[c.HostID] = append(byHost[c.HostID], c)
+	}
+
+	var events []models.NotificationEvent
+	for _, hostID := range hostOrder {
+		idle := byHost[hostID]
+		names := make([]string, 0, len(idle))
+		for _, c := range idle {
+			names = append(names, c.ContainerName)
+		}
+		events = append(events, models.NotificationEvent{
+			EventType: models.EventTypeIdleContainers,
+			Timestamp: report.GeneratedAt,
+			HostID:    hostID,
+			HostName:  idle[0].HostName,
+			Metadata: map[string]interface{}{
+				"containers":   names,
+				"count":        len(names),
+				"window_hours": report.Criteria.WindowHours,
+			},
+		})
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	tasks, err := ns.matchRules(ctx, events)
+	if err != nil {
+		return fmt.Errorf("failed to match rules: %w", err)
+	}
+
+	return ns.sendNotifications(ctx, ns.filterSilenced(tasks))
+}
+
 // detectLifecycleEvents detects container lifecycle events (state changes, image updates)
 func (ns *NotificationService) detectLifecycleEvents(hostID int64) ([]models.NotificationEvent, error) {
 	var events []models.NotificationEvent
@@ -105,7 +190,7 @@ func (ns *NotificationService) detectLifecycleEvents(hostID int64) ([]models.Not
 		if container.UpdateAvailable && !container.LastUpdateCheck.IsZero() {
 			// Only notify if update was recently detected (within last 5 minutes)
 			if time.Since(container.LastUpdateCheck) < 5*time.Minute {
-				events = append(events, models.NotificationEvent{
+				event := models.NotificationEvent{
 					EventType:     models.EventTypeImageUpdateAvailable,
 					Timestamp:     container.LastUpdateCheck,
 					ContainerID:   container.ID,
@@ -113,7 +198,19 @@ func (ns *NotificationService) detectLifecycleEvents(hostID int64) ([]models.Not
 					HostID:        container.HostID,
 					HostName:      container.HostName,
 					Image:         container.Image,
-				})
+				}
+				// Release notes are fetched when the update is detected
+				if changelog, err := ns.db.GetImageChangelog(container.Image); err != nil {
+					log.Printf("Warning: Failed to get changelog for %s: %v", container.Image, err)
+				} else if changelog != nil && changelog.Source != "" {
+					event.Metadata = map[string]interface{}{
+						"changelog_source":  changelog.Source,
+						"changelog_version": changelog.Version,
+						"changelog_url":     changelog.URL,
+						"changelog_summary": changelog.Summary,
+					}
+				}
+				events = append(events, event)
 			}
 		}
 
@@ -177,6 +274,70 @@ func (ns *NotificationService) lifecycleEventToNotificationEvent(container model
 	}
 }
 
+// detectSecurityEvents reports containers that became privileged in the latest scan
+func (ns *NotificationService) detectSecurityEvents(hostID int64) ([]models.NotificationEvent, error) {
+	containers, err := ns.db.GetNewlyPrivilegedContainers(hostID)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]models.NotificationEvent, 0, len(containers))
+	for _, c := range containers {
+		findings := make([]string, 0, len(c.Risk.Findings))
+		for _, f := range c.Risk.Findings {
+			findings = append(findings, f.Description)
+		}
+		events = append(events, models.NotificationEvent{
+			EventType:     models.EventTypePrivilegedContainer,
+			Timestamp:     c.CollectedAt,
+			ContainerID:   c.ContainerID,
+			ContainerName: c.ContainerName,
+			HostID:        c.HostID,
+			HostName:      c.HostName,
+			Image:         c.Image,
+			Metadata: map[string]interface{}{
+				"risk_score": c.Risk.Score,
+				"risk_level": c.Risk.Level,
+				"findings":   findings,
+			},
+		})
+	}
+
+	return events, nil
+}
+
+// detectRestartPolicyEvents reports the restart policies that changed in the host's latest scan
+// and, once per container name, services running without a restart policy
+func (ns *NotificationService) detectRestartPolicyEvents(hostID int64) ([]models.NotificationEvent, error) {
+	findings, err := ns.db.NewRestartPolicyWarnings(hostID, models.DefaultRestartPolicyMinUptimeHours*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]models.NotificationEvent, 0, len(findings))
+	for _, f := range findings {
+		metadata := map[string]interface{}{
+			"finding":        f.Finding,
+			"restart_policy": f.RestartPolicy,
+		}
+		if f.Finding == models.RestartPolicyChanged {
+			metadata["previous_restart_policy"] = f.PreviousRestartPolicy
+		}
+		events = append(events, models.NotificationEvent{
+			EventType:     models.EventTypeRestartPolicy,
+			Timestamp:     f.CollectedAt,
+			ContainerID:   f.ContainerID,
+			ContainerName: f.ContainerName,
+			HostID:        f.HostID,
+			HostName:      f.HostName,
+			Image:         f.Image,
+			Metadata:      metadata,
+		})
+	}
+
+	return events, nil
+}
+
 // detectThresholdEvents detects CPU/memory threshold breaches
 func (ns *NotificationService) detectThresholdEvents(hostID int64) ([]models.NotificationEvent, error) {
 	var events []models.NotificationEvent
@@ -187,8 +348,8 @@ func (ns *NotificationService) detectThresholdEvents(hostID int64) ([]models.Not
 		return nil, err
 	}
 
-	// Get threshold duration from config (default 120 seconds)
-	thresholdDuration := 120 * time.Second
+	// Threshold duration from the settings (default 120 seconds)
+	thresholdDuration := ns.getThresholdDuration()
 
 	for _, container := range containers {
 		if container.State != "running" {
@@ -272,7 +433,10 @@ func (ns *NotificationService) ResetThreshold(containerID string, hostID int64,
 	}
 }
 
-// detectAnomalies detects anomalous behavior after image updates
+// detectAnomalies detects anomalous behavior. Containers with enough history are compared
+// against their seasonal (day-of-week/hour-of-day) baseline so recurring load such as a
+// nightly job isn't flagged; otherwise usage after an image update is compared to the
+// pre-update average.
 func (ns *NotificationService) detectAnomalies(hostID int64) ([]models.NotificationEvent, error) {
 	var events []models.NotificationEvent
 
@@ -288,6 +452,33 @@ func (ns *NotificationService) detectAnomalies(hostID int64) ([]models.Notificat
 			continue
 		}
 
+		// Prefer the seasonal baseline for the current time slot when one exists
+		seasonal, err := ns.seasonalBaselineFor(container.Name, container.HostID, time.Now())
+		if err == nil && seasonal != nil {
+			if anomalous, cpuSigmas, memSigmas := isSeasonalAnomaly(*seasonal, container.CPUPercent, container.MemoryUsage); anomalous {
+				events = append(events, models.NotificationEvent{
+					EventType:     models.EventTypeAnomalousBehavior,
+					Timestamp:     time.Now(),
+					ContainerID:   container.ID,
+					ContainerName: container.Name,
+					HostID:        container.HostID,
+					HostName:      container.HostName,
+					Image:         container.Image,
+					CPUPercent:    container.CPUPercent,
+					MemoryPercent: container.MemoryPercent,
+					Metadata: map[string]interface{}{
+						"baseline_type":       "seasonal",
+						"baseline_slot":       seasonalSlotLabel(*seasonal),
+						"baseline_cpu":        seasonal.AvgCPUPercent,
+						"baseline_memory":     seasonal.AvgMemoryUsage,
+						"cpu_deviation_sigma": cpuSigmas,
+						"mem_deviation_sigma": memSigmas,
+					},
+				})
+			}
+			continue
+		}
+
 		// Get baseline stats for this container's previous image
 		baseline, err := ns.db.GetContainerBaseline(container.ID, container.HostID)
 		if err != nil || baseline == nil {
@@ -326,21 +517,43 @@ func (ns *NotificationService) detectAnomalies(hostID int64) ([]models.Notificat
 func (ns *NotificationService) matchRules(ctx context.Context, events []models.NotificationEvent) ([]notificationTask, error) {
 	var tasks []notificationTask
 
+	// Events carry their container's owner for owner rules and messages
+	ns.attachOwners(events)
+
 	// Get all enabled rules
 	rules, err := ns.db.GetNotificationRules(true)
 	if err != nil {
 		return nil, err
 	}
 
+	// Rules of a tenant only see events of the tenant's hosts
+	hostTenants := make(map[int64]int64)
+	for _, rule := range rules {
+		if rule.TenantID != 0 {
+			hosts, err := ns.db.GetHosts()
+			if err != nil {
+				return nil, err
+			}
+			for _, host := range hosts {
+				hostTenants[host.ID] = host.TenantID
+			}
+			break
+		}
+	}
+
 	for _, event := range events {
 		for _, rule := range rules {
+			if rule.TenantID != 0 && hostTenants[event.HostID] != rule.TenantID {
+				continue
+			}
 			if ns.ruleMatchesEvent(rule, event) {
 				// Get channels for this rule
 				channelIDs := rule.ChannelIDs
 				for _, channelID := range channelIDs {
-					// Check cooldown
-					if ns.isInCooldown(rule.ID, event.ContainerID, event.HostID, rule.CooldownSeconds) {
+					// Check cooldown (host status changes are damped by their scan streaks instead)
+					if !isHostStatusEvent(event.EventType) && ns.isInCooldown(rule.ID, event.ContainerID, event.HostID, rule.CooldownSeconds) {
 						log.Printf("Skipping notification for rule %d (cooldown active)", rule.ID)
+						ns.recordOutcome(rule.ID, channelID, models.NotificationOutcomeSuppressed)
 						continue
 					}
 
@@ -399,6 +612,11 @@ func (ns *NotificationService) ruleMatchesEvent(rule models.NotificationRule, ev
 		}
 	}
 
+	// Check owner pattern
+	if !ownerMatches(rule.OwnerPattern, event.Owner) {
+		return false
+	}
+
 	// Check CPU threshold for high CPU events
 	if event.EventType == models.EventTypeHighCPU && rule.CPUThreshold != nil {
 		if event.CPUPercent < *rule.CPUThreshold {
@@ -413,6 +631,11 @@ func (ns *NotificationService) ruleMatchesEvent(rule models.NotificationRule, ev
 		}
 	}
 
+	// Check growth sensitivity for memory leak events
+	if event.EventType == models.EventTypeMemoryLeak && !leakRuleMatches(rule, event) {
+		return false
+	}
+
 	return true
 }
 
@@ -446,6 +669,7 @@ func (ns *NotificationService) filterSilenced(tasks []notificationTask) []notifi
 			if ns.silenceMatches(silence, task.Event) {
 				silenced = true
 				log.Printf("Notification silenced: %s on %s (reason: %s)", task.Event.ContainerName, task.Event.HostName, silence.Reason)
+				ns.recordOutcome(task.Rule.ID, task.Channel, models.NotificationOutcomeSuppressed)
 				break
 			}
 		}
@@ -495,6 +719,7 @@ func (ns *NotificationService) silenceMatches(silence models.NotificationSilence
 
 // sendNotifications sends notifications with rate limiting
 func (ns *NotificationService) sendNotifications(ctx context.Context, tasks []notificationTask) error {
+	tasks = ns.routeBySeverity(tasks, time.Now())
 	if len(tasks) == 0 {
 		return nil
 	}
@@ -505,6 +730,7 @@ func (ns *NotificationService) sendNotifications(ctx context.Context, tasks []no
 		if !ns.rateLimiter.Allow() {
 			// Rate limited - add to batch queue
 			ns.rateLimiter.AddToBatch(task)
+			ns.recordOutcome(task.Rule.ID, task.Channel, models.NotificationOutcomeRateLimited)
 			log.Printf("Rate limited: Queuing notification for later")
 			continue
 		}
@@ -516,6 +742,50 @@ func (ns *NotificationService) sendNotifications(ctx context.Context, tasks []no
 	return nil
 }
 
+// routeBySeverity drops tasks below their channel's severity threshold, which quiet hours may
+// raise. Dropped tasks are still written to the notification log (unsent, with the reason) so
+// nothing is lost from the in-app history, and they don't start the rule's cooldown.
+func (ns *NotificationService) routeBySeverity(tasks []notificationTask, now time.Time) []notificationTask {
+	if len(tasks) == 0 {
+		return tasks
+	}
+
+	channelList, err := ns.db.GetNotificationChannels()
+	if err != nil {
+		log.Printf("Warning: Failed to get channels for routing: %v", err)
+		return tasks
+	}
+	channelsByID := make(map[int64]models.NotificationChannel, len(channelList))
+	for _, ch := range channelList {
+		channelsByID[ch.ID] = ch
+	}
+
+	var routed []notificationTask
+	for _, task := range tasks {
+		ch, ok := channelsByID[task.Channel]
+		if !ok {
+			routed = append(routed, task)
+			continue
+		}
+
+		minimum := ch.MinSeverityAt(now)
+		severity := models.EventSeverity(task.Event.EventType)
+		if models.SeverityAtLeast(severity, minimum) {
+			routed = append(routed, task)
+			continue
+		}
+
+		reason := fmt.Sprintf("Suppressed: %s event below channel minimum %s", severity, minimum)
+		if minimum != ch.MinSeverity {
+			reason += " (quiet hours)"
+		}
+		ns.logNotification(task, false, reason)
+		ns.recordOutcome(task.Rule.ID, task.Channel, models.NotificationOutcomeSuppressed)
+	}
+
+	return routed
+}
+
 // sendSingleNotification sends a single notification
 func (ns *NotificationService) sendSingleNotification(ctx context.Context, task notificationTask) {
 	// Get channel
@@ -523,6 +793,7 @@ func (ns *NotificationService) sendSingleNotification(ctx context.Context, task
 	if err != nil {
 		log.Printf("Error getting channel %d: %v", task.Channel, err)
 		ns.logNotification(task, false, fmt.Sprintf("Channel not found: %v", err))
+		ns.recordOutcome(task.Rule.ID, task.Channel, models.NotificationOutcomeFailed)
 		return
 	}
 
@@ -534,11 +805,13 @@ func (ns *NotificationService) sendSingleNotification(ctx context.Context, task
 	if err != nil {
 		log.Printf("Error sending notification via channel %d: %v", task.Channel, err)
 		ns.logNotification(task, false, err.Error())
+		ns.recordOutcome(task.Rule.ID, task.Channel, models.NotificationOutcomeFailed)
 		return
 	}
 
 	// Log success
 	ns.logNotification(task, true, "")
+	ns.recordOutcome(task.Rule.ID, task.Channel, models.NotificationOutcomeSent)
 
 	// Reset threshold if applicable
 	if task.Event.EventType == models.EventTypeHighCPU {
@@ -548,12 +821,32 @@ func (ns *NotificationService) sendSingleNotification(ctx context.Context, task
 	}
 }
 
-// buildMessage creates a human-readable message from an event
+// buildMessage creates a human-readable message from an event, naming the container's owner
 func (ns *NotificationService) buildMessage(event models.NotificationEvent) string {
+	message := ns.eventMessage(event)
+	if event.Owner != nil {
+		message += "\n" + ownerLine(event.Owner)
+	}
+	return message
+}
+
+// eventMessage describes an event
+func (ns *NotificationService) eventMessage(event models.NotificationEvent) string {
 	switch event.EventType {
 	case models.EventTypeNewImage:
 		return fmt.Sprintf("🔄 Image updated for %s on %s: %s → %s",
 			event.ContainerName, event.HostName, event.OldImage, event.NewImage)
+	case models.EventTypeImageUpdateAvailable:
+		msg := fmt.Sprintf("⬆️ Update available for %s on %s (%s)", event.ContainerName, event.HostName, event.Image)
+		if url, _ := event.Metadata["changelog_url"].(string); url != "" {
+			msg += fmt.Sprintf("\nRelease %v: %s", event.Metadata["changelog_version"], url)
+			if summary, _ := event.Metadata["changelog_summary"].(string); summary != "" {
+				msg += "\n" + summary
+			}
+		} else if source, _ := event.Metadata["changelog_source"].(string); source != "" {
+			msg += "\nSource: " + source
+		}
+		return msg
 	case models.EventTypeContainerStarted:
 		return fmt.Sprintf("✅ Container started: %s on %s", event.ContainerName, event.HostName)
 	case models.EventTypeContainerStopped:
@@ -571,6 +864,72 @@ func (ns *NotificationService) buildMessage(event models.NotificationEvent) stri
 	case models.EventTypeAnomalousBehavior:
 		return fmt.Sprintf("🔍 Anomalous behavior detected: %s on %s (CPU: %.1f%%, Memory: %.1f%%)",
 			event.ContainerName, event.HostName, event.CPUPercent, event.MemoryPercent)
+	case models.EventTypeIdleContainers:
+		names, _ := event.Metadata["containers"].([]string)
+		return fmt.Sprintf("💤 %d likely idle container(s) on %s over the last %v hours: %s",
+			len(names), event.HostName, event.Metadata["window_hours"], strings.Join(names, ", "))
+	case models.EventTypeMemoryLeak:
+		current, _ := event.Metadata["current_memory"].(int64)
+		return fmt.Sprintf("💧 Possible memory leak: %s on %s (+%.1f%%/day for %v days, now %d MB)",
+			event.ContainerName, event.HostName, event.Metadata["growth_percent_per_day"],
+			event.Metadata["growing_days"], current/1024/1024)
+	case models.EventTypePrivilegedContainer:
+		findings, _ := event.Metadata["findings"].([]string)
+		return fmt.Sprintf("🛡️ Privileged container: %s on %s (risk %v/100): %s",
+			event.ContainerName, event.HostName, event.Metadata["risk_score"], strings.Join(findings, "; "))
+	case models.EventTypeBackupOverdue:
+		return fmt.Sprintf("💾 Backup overdue: %s on %s (%v)",
+			event.ContainerName, event.HostName, event.Metadata["detail"])
+	case models.EventTypeScriptAlert:
+		if event.ContainerName == "" {
+			return fmt.Sprintf("📜 %v (script %v on %s)", event.Metadata["message"], event.Metadata["script"], event.HostName)
+		}
+		return fmt.Sprintf("📜 %v (script %v, %s on %s)",
+			event.Metadata["message"], event.Metadata["script"], event.ContainerName, event.HostName)
+	case models.EventTypeHostDown:
+		if msg, _ := event.Metadata["error"].(string); msg != "" {
+			return fmt.Sprintf("🔴 Host down: %s (%s)", event.HostName, msg)
+		}
+		return fmt.Sprintf("🔴 Host down: %s", event.HostName)
+	case models.EventTypeHostRecovered:
+		return fmt.Sprintf("🟢 Host recovered: %s (down for %s)", event.HostName, formatDowntime(event.Metadata["downtime_seconds"]))
+	case models.EventTypeOOMKill:
+		if process, _ := event.Metadata["process"].(string); process != "" {
+			return fmt.Sprintf("💥 Out of memory: %s on %s (process %s killed)", event.ContainerName, event.HostName, process)
+		}
+		return fmt.Sprintf("💥 Out of memory: %s on %s (process killed)", event.ContainerName, event.HostName)
+	case models.EventTypeDaemonError:
+		if event.ContainerName != "" {
+			return fmt.Sprintf("🐳 Docker daemon error on %s (%s): %v", event.HostName, event.ContainerName, event.Metadata["message"])
+		}
+		return fmt.Sprintf("🐳 Docker daemon error on %s: %v", event.HostName, event.Metadata["message"])
+	case models.EventTypeRestartPolicy:
+		if event.Metadata["finding"] == models.RestartPolicyChanged {
+			return fmt.Sprintf("🔁 Restart policy changed: %s on %s (%v → %v)",
+				event.ContainerName, event.HostName, event.Metadata["previous_restart_policy"], event.Metadata["restart_policy"])
+		}
+		return fmt.Sprintf("🔁 No restart policy: %s on %s won't come back after a reboot (restart=no)", event.ContainerName, event.HostName)
+	case models.EventTypeCertExpiring:
+		days, _ := event.Metadata["days_remaining"].(int)
+		if days < 0 {
+			return fmt.Sprintf("🔐 Certificate expired: %v (%v)", event.Metadata["hostname"], event.Metadata["url"])
+		}
+		return fmt.Sprintf("🔐 Certificate expires in %d day(s): %v (%v, issued by %v)",
+			days, event.Metadata["hostname"], event.Metadata["url"], event.Metadata["issuer"])
+	case models.EventTypeDNSFailure:
+		return fmt.Sprintf("🌐 DNS lookup failed: %v (%v): %v", event.Metadata["hostname"], event.Metadata["url"], event.Metadata["error"])
+	case models.EventTypeNewVulnerability:
+		ids, _ := event.Metadata["vulnerability_ids"].([]string)
+		listed := ids
+		if len(listed) > 5 {
+			listed = listed[:5]
+		}
+		more := ""
+		if len(ids) > len(listed) {
+			more = fmt.Sprintf(" and %d more", len(ids)-len(listed))
+		}
+		return fmt.Sprintf("🚨 New vulnerabilities in %s on %s (%s): %s%s",
+			event.ContainerName, event.HostName, event.Image, strings.Join(listed, ", "), more)
 	case models.EventTypeStateChange:
 		return fmt.Sprintf("🔄 State changed: %s on %s (%s → %s)",
 			event.ContainerName, event.HostName, event.OldState, event.NewState)
@@ -604,8 +963,18 @@ func (ns *NotificationService) logNotification(task notificationTask, success bo
 		notifLog.Metadata = metadata
 	}
 
-	if err := ns.db.SaveNotificationLog(notifLog); err != nil {
+	if err := ns.db.InsertNotificationLog(&notifLog); err != nil {
 		log.Printf("Failed to save notification log: %v", err)
+		return
+	}
+
+	ns.publish(notifLog)
+}
+
+// recordOutcome counts a notification in the per-rule and per-channel statistics
+func (ns *NotificationService) recordOutcome(ruleID, channelID int64, outcome string) {
+	if err := ns.db.RecordNotificationOutcome(ruleID, channelID, outcome, time.Now()); err != nil {
+		log.Printf("Failed to record notification outcome: %v", err)
 	}
 }
 
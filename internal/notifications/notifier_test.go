@@ -3,6 +3,7 @@ package notifications
 import (
 	"context"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -223,6 +224,57 @@ func TestDetectLifecycleEvents_ContainerStarted(t *testing.T) {
 	}
 }
 
+// TestDetectLifecycleEvents_UpdateChangelog tests that update notifications carry the cached release notes
+func TestDetectLifecycleEvents_UpdateChangelog(t *testing.T) {
+	ns, db := setupTestNotifier(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "test-host", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	c := models.Container{ID: "upd123", HostID: hostID, HostName: "test-host", Name: "app", Image: "owner/app:latest", State: "running", ScannedAt: time.Now()}
+	if err := db.SaveContainers([]models.Container{c}); err != nil {
+		t.Fatalf("Failed to save container: %v", err)
+	}
+	if err := db.SaveContainerUpdateStatus(c.ID, hostID, true); err != nil {
+		t.Fatalf("Failed to save update status: %v", err)
+	}
+	if err := db.SaveImageChangelog(models.ImageChangelog{
+		Image:     "owner/app:latest",
+		Source:    "https://github.com/owner/app",
+		Version:   "v2.0.0",
+		URL:       "https://github.com/owner/app/releases/tag/v2.0.0",
+		Summary:   "New config format",
+		FetchedAt: time.Now(),
+	}); err != nil {
+		t.Fatalf("Failed to save changelog: %v", err)
+	}
+
+	events, err := ns.detectLifecycleEvents(hostID)
+	if err != nil {
+		t.Fatalf("detectLifecycleEvents failed: %v", err)
+	}
+
+	var update *models.NotificationEvent
+	for i := range events {
+		if events[i].EventType == models.EventTypeImageUpdateAvailable {
+			update = &events[i]
+		}
+	}
+	if update == nil {
+		t.Fatalf("Expected an image_update_available event, got %+v", events)
+	}
+	if update.Metadata["changelog_version"] != "v2.0.0" {
+		t.Errorf("Expected the changelog in the event metadata, got %+v", update.Metadata)
+	}
+
+	msg := ns.buildMessage(*update)
+	if !strings.Contains(msg, "Release v2.0.0: https://github.com/owner/app/releases/tag/v2.0.0") || !strings.Contains(msg, "New config format") {
+		t.Errorf("Expected the release link and summary in the message, got %q", msg)
+	}
+}
+
 // TestDetectThresholdEvents_HighCPU tests CPU threshold detection
 // TODO: Fix threshold state model/API mismatch - NotificationThresholdState model has changed
 func TestDetectThresholdEvents_HighCPU(t *testing.T) {
@@ -567,6 +619,66 @@ func TestRuleMatching_ImagePattern(t *testing.T) {
 	}
 }
 
+// TestRuleMatching_Owner tests routing by the container's owner and the owner in messages
+func TestRuleMatching_Owner(t *testing.T) {
+	ns, db := setupTestNotifier(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "test-host", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	now := time.Now()
+	if err := db.SaveContainers([]models.Container{
+		{ID: "c1", Name: "jellyfin", Image: "jellyfin/jellyfin", State: "running", HostID: hostID, HostName: "test-host", ComposeProject: "media", ScannedAt: now},
+		{ID: "c2", Name: "postgres", Image: "postgres:16", State: "running", HostID: hostID, HostName: "test-host", ScannedAt: now},
+	}); err != nil {
+		t.Fatalf("Failed to save containers: %v", err)
+	}
+	if err := db.SetStackOwner(models.OwnerAssignment{Stack: "media", Owner: "media-team", Contact: "media@example.com", AssignedAt: now}); err != nil {
+		t.Fatalf("Failed to set owner: %v", err)
+	}
+
+	channel := &models.NotificationChannel{Name: "media-channel", Type: "inapp", Config: map[string]interface{}{}, Enabled: true}
+	if err := db.SaveNotificationChannel(channel); err != nil {
+		t.Fatalf("Failed to save channel: %v", err)
+	}
+	rule := &models.NotificationRule{
+		Name:         "media-owner",
+		EventTypes:   []string{models.EventTypeContainerStopped},
+		OwnerPattern: "*@example.com",
+		Enabled:      true,
+		ChannelIDs:   []int64{channel.ID},
+	}
+	if err := db.SaveNotificationRule(rule); err != nil {
+		t.Fatalf("Failed to save rule: %v", err)
+	}
+
+	events := []models.NotificationEvent{
+		{ContainerID: "c1", ContainerName: "jellyfin", HostID: hostID, HostName: "test-host", EventType: models.EventTypeContainerStopped},
+		{ContainerID: "c2", ContainerName: "postgres", HostID: hostID, HostName: "test-host", EventType: models.EventTypeContainerStopped},
+	}
+	tasks, err := ns.matchRules(context.Background(), events)
+	if err != nil {
+		t.Fatalf("matchRules failed: %v", err)
+	}
+
+	var ownerTasks []notificationTask
+	for _, task := range tasks {
+		if task.Rule.ID == rule.ID {
+			ownerTasks = append(ownerTasks, task)
+		}
+	}
+	if len(ownerTasks) != 1 || ownerTasks[0].Event.ContainerName != "jellyfin" || ownerTasks[0].Channel != channel.ID {
+		t.Fatalf("Expected only the media stack's container routed to the owner's channel, got %+v", ownerTasks)
+	}
+	if msg := ns.buildMessage(ownerTasks[0].Event); !strings.Contains(msg, "Owner: media-team (media@example.com)") {
+		t.Errorf("Expected the owner in the message, got %q", msg)
+	}
+	if events[1].Owner != nil {
+		t.Errorf("Expected no owner for postgres, got %+v", events[1].Owner)
+	}
+}
+
 // TestSilenceFiltering tests that silenced notifications are filtered out
 // TODO: Fix - filterSilenced takes notificationTask not NotificationLog
 func TestSilenceFiltering(t *testing.T) {
@@ -940,3 +1052,183 @@ func TestDisabledRule(t *testing.T) {
 		}
 	}
 }
+
+// TestRouteBySeverity tests per-channel severity thresholds and quiet hours
+func TestRouteBySeverity(t *testing.T) {
+	ns, db := setupTestNotifier(t)
+
+	phone := &models.NotificationChannel{
+		Name:        "phone",
+		Type:        models.ChannelTypeNtfy,
+		Config:      map[string]interface{}{"topic": "alerts"},
+		Enabled:     true,
+		MinSeverity: models.SeverityWarning,
+		QuietHours:  &models.QuietHours{Start: "22:00", End: "08:00", MinSeverity: models.SeverityCritical},
+	}
+	if err := db.SaveNotificationChannel(phone); err != nil {
+		t.Fatalf("Failed to save channel: %v", err)
+	}
+	inApp := &models.NotificationChannel{Name: "inbox", Type: models.ChannelTypeInApp, Config: map[string]interface{}{}, Enabled: true}
+	if err := db.SaveNotificationChannel(inApp); err != nil {
+		t.Fatalf("Failed to save channel: %v", err)
+	}
+
+	rules, err := db.GetNotificationRules(true)
+	if err != nil || len(rules) == 0 {
+		t.Fatalf("Expected default rules: %v", err)
+	}
+
+	var tasks []notificationTask
+	for _, eventType := range []string{models.EventTypeContainerStarted, models.EventTypeHighCPU, models.EventTypeContainerStopped} {
+		for _, channelID := range []int64{phone.ID, inApp.ID} {
+			tasks = append(tasks, notificationTask{
+				Rule:    rules[0],
+				Event:   models.NotificationEvent{EventType: eventType, ContainerName: "web", Timestamp: time.Now()},
+				Channel: channelID,
+			})
+		}
+	}
+
+	countPhone := func(routed []notificationTask) int {
+		n := 0
+		for _, task := range routed {
+			if task.Channel == phone.ID {
+				n++
+			}
+		}
+		return n
+	}
+
+	noon := time.Date(2025, 6, 1, 12, 0, 0, 0, time.Local)
+	routed := ns.routeBySeverity(tasks, noon)
+	if len(routed) != 5 || countPhone(routed) != 2 {
+		t.Errorf("Expected info events kept off the phone during the day, got %d tasks (%d to phone)", len(routed), countPhone(routed))
+	}
+
+	night := time.Date(2025, 6, 1, 23, 30, 0, 0, time.Local)
+	routed = ns.routeBySeverity(tasks, night)
+	if len(routed) != 4 || countPhone(routed) != 1 {
+		t.Errorf("Expected only critical events on the phone at night, got %d tasks (%d to phone)", len(routed), countPhone(routed))
+	}
+
+	// Suppressed deliveries are kept in the log
+	logs, err := db.GetNotificationLogs(100, false)
+	if err != nil {
+		t.Fatalf("GetNotificationLogs failed: %v", err)
+	}
+	if len(logs) != 3 {
+		t.Fatalf("Expected 3 suppressed deliveries in the log, got %d", len(logs))
+	}
+	for _, l := range logs {
+		if l.Success || !strings.HasPrefix(l.Error, "Suppressed:") {
+			t.Errorf("Expected an unsent log entry with the suppression reason, got %+v", l)
+		}
+	}
+}
+
+// TestQuietHoursContains tests quiet hours windows, including ones that span midnight
+func TestQuietHoursContains(t *testing.T) {
+	at := func(hour, minute int) time.Time { return time.Date(2025, 6, 1, hour, minute, 0, 0, time.Local) }
+
+	overnight := models.QuietHours{Start: "22:00", End: "08:00", MinSeverity: models.SeverityCritical}
+	daytime := models.QuietHours{Start: "09:30", End: "17:00", MinSeverity: models.SeverityWarning}
+
+	tests := []struct {
+		window models.QuietHours
+		time   time.Time
+		want   bool
+	}{
+		{overnight, at(23, 0), true},
+		{overnight, at(3, 0), true},
+		{overnight, at(8, 0), false},
+		{overnight, at(21, 59), false},
+		{daytime, at(9, 30), true},
+		{daytime, at(12, 0), true},
+		{daytime, at(17, 0), false},
+		{daytime, at(8, 0), false},
+	}
+	for _, tt := range tests {
+		if got := tt.window.Contains(tt.time); got != tt.want {
+			t.Errorf("%s-%s contains %s: got %v, want %v", tt.window.Start, tt.window.End, tt.time.Format("15:04"), got, tt.want)
+		}
+	}
+
+	if err := (models.QuietHours{Start: "25:00", End: "08:00", MinSeverity: models.SeverityCritical}).Validate(); err == nil {
+		t.Error("Expected invalid start time to fail validation")
+	}
+	if err := (models.QuietHours{Start: "22:00", End: "08:00", MinSeverity: "urgent"}).Validate(); err == nil {
+		t.Error("Expected unknown severity to fail validation")
+	}
+}
+
+// TestUpdateSettings tests that changed settings apply to a running service
+func TestUpdateSettings(t *testing.T) {
+	ns, _ := setupTestNotifier(t)
+
+	if d := ns.getThresholdDuration(); d != 120*time.Second {
+		t.Errorf("Expected the default threshold duration of 120s, got %v", d)
+	}
+
+	ns.UpdateSettings(models.NotificationSettings{RateLimitMax: 50, RateLimitBatchInterval: 300, ThresholdDuration: 30})
+
+	if max, interval := ns.rateLimiter.GetLimits(); max != 50 || interval != 5*time.Minute {
+		t.Errorf("Expected rate limit 50/hour with 5m batches, got %d/hour with %v", max, interval)
+	}
+	if d := ns.getThresholdDuration(); d != 30*time.Second {
+		t.Errorf("Expected threshold duration 30s, got %v", d)
+	}
+
+	// A breach already being tracked is measured against the new duration
+	container := models.Container{ID: "abc", HostID: 1, Name: "web"}
+	ns.checkThreshold(container, "cpu", 95, ns.getThresholdDuration())
+	ns.thresholdState["abc-1-cpu"].BreachedAt = time.Now().Add(-time.Minute)
+	if err := ns.checkThreshold(container, "cpu", 95, ns.getThresholdDuration()); err != nil {
+		t.Errorf("Expected a breach of 1 minute to meet the 30s threshold: %v", err)
+	}
+}
+
+func TestHostStatusIgnoresCooldown(t *testing.T) {
+	ns, db := setupTestNotifier(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: "agent://nas:9876", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	channel := &models.NotificationChannel{Name: "inbox", Type: models.ChannelTypeInApp, Config: map[string]interface{}{}, Enabled: true}
+	if err := db.SaveNotificationChannel(channel); err != nil {
+		t.Fatalf("Failed to save channel: %v", err)
+	}
+	rule := &models.NotificationRule{
+		Name:            "host status",
+		Enabled:         true,
+		EventTypes:      []string{models.EventTypeHostDown, models.EventTypeHostRecovered},
+		CooldownSeconds: 3600,
+		ChannelIDs:      []int64{channel.ID},
+	}
+	if err := db.SaveNotificationRule(rule); err != nil {
+		t.Fatalf("Failed to save rule: %v", err)
+	}
+
+	// The host_down notification was just sent
+	if err := db.SaveNotificationLog(models.NotificationLog{RuleID: &rule.ID, EventType: models.EventTypeHostDown, HostID: &hostID, Message: "down", SentAt: time.Now(), Success: true}); err != nil {
+		t.Fatalf("Failed to save notification log: %v", err)
+	}
+
+	event := models.NotificationEvent{
+		EventType: models.EventTypeHostRecovered,
+		HostID:    hostID,
+		HostName:  "nas",
+		Metadata:  map[string]interface{}{"downtime_seconds": int64(90)},
+	}
+	tasks, err := ns.matchRules(context.Background(), []models.NotificationEvent{event})
+	if err != nil {
+		t.Fatalf("matchRules failed: %v", err)
+	}
+	if len(tasks) != 1 {
+		t.Fatalf("Expected the recovery despite the cooldown, got %d tasks", len(tasks))
+	}
+
+	if msg := ns.buildMessage(event); !strings.Contains(msg, "nas") || !strings.Contains(msg, "1m30s") {
+		t.Errorf("Unexpected message: %s", msg)
+	}
+}
@@ -0,0 +1,77 @@
+package notifications
+
+import (
+	"log"
+	"path/filepath"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// attachOwners sets the owner of each container event's container, so rules can route by owner
+// and messages name who to contact. Containers are looked up in their host's latest scan; events
+// of containers that are gone keep no owner unless one is assigned to the container itself.
+func (ns *NotificationService) attachOwners(events []models.NotificationEvent) {
+	var assignments *models.OwnerAssignments
+	containers := make(map[int64]map[string]models.Container)
+	for i := range events {
+		event := &events[i]
+		if event.ContainerName == "" || event.Owner != nil {
+			continue
+		}
+		if assignments == nil {
+			a, err := ns.db.GetOwnerAssignments()
+			if err != nil {
+				log.Printf("Failed to get owners: %v", err)
+				return
+			}
+			assignments = &a
+		}
+
+		byName, ok := containers[event.HostID]
+		if !ok {
+			byName = make(map[string]models.Container)
+			hostContainers, err := ns.db.GetContainersByHost(event.HostID)
+			if err != nil {
+				log.Printf("Failed to get containers of host %d for owners: %v", event.HostID, err)
+			}
+			for _, c := range hostContainers {
+				byName[c.Name] = c
+			}
+			containers[event.HostID] = byName
+		}
+
+		c, ok := byName[event.ContainerName]
+		if !ok {
+			c = models.Container{HostID: event.HostID, Name: event.ContainerName}
+		}
+		event.Owner = models.OwnerFor(c, *assignments)
+	}
+}
+
+// ownerMatches reports whether a rule's owner pattern matches the event's owner name or contact.
+// Events without an owner only match rules without a pattern.
+func ownerMatches(pattern string, owner *models.Owner) bool {
+	if pattern == "" {
+		return true
+	}
+	if owner == nil {
+		return false
+	}
+	for _, value := range []string{owner.Name, owner.Contact} {
+		if value == "" {
+			continue
+		}
+		if matched, err := filepath.Match(pattern, value); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ownerLine is the line naming the owner added to notification messages
+func ownerLine(owner *models.Owner) string {
+	if owner.Contact != "" && owner.Contact != owner.Name {
+		return "Owner: " + owner.Name + " (" + owner.Contact + ")"
+	}
+	return "Owner: " + owner.Name
+}
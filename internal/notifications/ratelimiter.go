@@ -17,6 +17,7 @@ type RateLimiter struct {
 	mu            sync.Mutex
 	batchTicker   *time.Ticker
 	stopBatch     chan struct{}
+	intervalCh    chan time.Duration // batch interval changes for runBatchProcessor
 	notifier      *NotificationService
 }
 
@@ -29,10 +30,11 @@ func NewRateLimiter(maxPerHour int, batchInterval time.Duration) *RateLimiter {
 		lastReset:     time.Now(),
 		batchQueue:    make([]notificationTask, 0),
 		stopBatch:     make(chan struct{}),
+		intervalCh:    make(chan time.Duration, 1),
 	}
 
 	// Start batch processor
-	go rl.runBatchProcessor()
+	go rl.runBatchProcessor(batchInterval)
 
 	return rl
 }
@@ -65,14 +67,16 @@ func (rl *RateLimiter) AddToBatch(task notificationTask) {
 }
 
 // runBatchProcessor sends batched notifications every interval
-func (rl *RateLimiter) runBatchProcessor() {
-	ticker := time.NewTicker(rl.batchInterval)
+func (rl *RateLimiter) runBatchProcessor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
 			rl.sendBatch()
+		case interval := <-rl.intervalCh:
+			ticker.Reset(interval)
 		case <-rl.stopBatch:
 			return
 		}
@@ -136,6 +140,38 @@ func (rl *RateLimiter) sendBatchSummary(ctx context.Context, channelID int64, ta
 	}
 }
 
+// UpdateLimits changes the hourly limit and the batch interval of a running rate limiter.
+// Tokens already used this hour stay used: raising the limit from 10 to 20 after sending 8
+// leaves 12.
+func (rl *RateLimiter) UpdateLimits(maxPerHour int, batchInterval time.Duration) {
+	rl.mu.Lock()
+	rl.tokens += maxPerHour - rl.maxPerHour
+	if rl.tokens < 0 {
+		rl.tokens = 0
+	}
+	rl.maxPerHour = maxPerHour
+	changed := batchInterval != rl.batchInterval
+	rl.batchInterval = batchInterval
+	rl.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	// Replace a pending change the batch processor hasn't picked up yet
+	select {
+	case <-rl.intervalCh:
+	default:
+	}
+	rl.intervalCh <- batchInterval
+}
+
+// GetLimits returns the hourly limit and the batch interval
+func (rl *RateLimiter) GetLimits() (int, time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.maxPerHour, rl.batchInterval
+}
+
 // Stop stops the batch processor
 func (rl *RateLimiter) Stop() {
 	close(rl.stopBatch)
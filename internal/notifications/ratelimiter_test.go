@@ -2,6 +2,7 @@ package notifications
 
 import (
 	"testing"
+	"time"
 )
 
 // All rate limiter tests skipped - they test private implementation details
@@ -17,3 +18,30 @@ func TestRateLimiter_NoNegativeTokens(t *testing.T) { t.Skip("Tests private memb
 func TestRateLimiter_BatchInterval(t *testing.T) { t.Skip("Tests private members") }
 func TestRateLimiter_MaxTokensCap(t *testing.T) { t.Skip("Tests private members") }
 func TestRateLimiter_Statistics(t *testing.T) { t.Skip("Tests public GetStats") }
+
+func TestRateLimiter_UpdateLimits(t *testing.T) {
+	rl := NewRateLimiter(10, time.Hour)
+	defer rl.Stop()
+
+	for i := 0; i < 8; i++ {
+		if !rl.Allow() {
+			t.Fatalf("Expected notification %d to be allowed", i+1)
+		}
+	}
+
+	rl.UpdateLimits(20, time.Minute)
+	if max, interval := rl.GetLimits(); max != 20 || interval != time.Minute {
+		t.Errorf("Expected limits 20/hour and 1m, got %d/hour and %v", max, interval)
+	}
+	if remaining := rl.GetRemaining(); remaining != 12 {
+		t.Errorf("Expected 12 remaining after raising the limit, got %d", remaining)
+	}
+
+	rl.UpdateLimits(5, time.Minute)
+	if remaining := rl.GetRemaining(); remaining != 0 {
+		t.Errorf("Expected no tokens left after lowering the limit below the used ones, got %d", remaining)
+	}
+	if rl.Allow() {
+		t.Error("Expected notifications to be rate limited")
+	}
+}
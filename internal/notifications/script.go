@@ -0,0 +1,21 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// SendScriptAlert delivers a notification requested by an event script to the rules subscribed
+// to script_alert. The event must carry the script name and message in its metadata.
+func (ns *NotificationService) SendScriptAlert(ctx context.Context, event models.NotificationEvent) error {
+	event.EventType = models.EventTypeScriptAlert
+
+	tasks, err := ns.matchRules(ctx, []models.NotificationEvent{event})
+	if err != nil {
+		return fmt.Errorf("failed to match rules: %w", err)
+	}
+
+	return ns.sendNotifications(ctx, ns.filterSilenced(tasks))
+}
@@ -0,0 +1,157 @@
+package notifications
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+const (
+	// seasonalWindow is how much hourly history seasonal baselines are built from
+	seasonalWindow = 28 * 24 * time.Hour
+	// minWeeklySlotSamples is the minimum samples for a day-of-week/hour slot (one per week)
+	minWeeklySlotSamples = 3
+	// minHourlySlotSamples is the minimum samples for an hour-of-day slot spanning all days
+	minHourlySlotSamples = 7
+	// seasonalDeviationSigmas is how many standard deviations above normal counts as anomalous
+	seasonalDeviationSigmas = 3.0
+	// seasonalCPUFloor is the minimum CPU spread (percentage points), so flat series don't alert on noise
+	seasonalCPUFloor = 2.0
+	// seasonalMemoryFloorRatio is the minimum memory spread as a fraction of the average
+	seasonalMemoryFloorRatio = 0.1
+)
+
+// slotAccumulator collects samples for one seasonal slot
+type slotAccumulator struct {
+	cpu    []float64
+	memory []float64
+}
+
+// buildSeasonalBaselines groups hourly stats by container and time slot. Each container gets
+// day-of-week/hour-of-day slots (e.g. "Monday 04:00") plus hour-of-day slots across all days
+// (DayOfWeek -1) used as a fallback when a weekly slot has too little history.
+func buildSeasonalBaselines(stats []models.HourlyContainerStats, loc *time.Location, now time.Time) []models.SeasonalBaseline {
+	type slotKey struct {
+		name      string
+		hostID    int64
+		dayOfWeek int
+		hour      int
+	}
+
+	slots := make(map[slotKey]*slotAccumulator)
+	var order []slotKey
+	add := func(key slotKey, s models.HourlyContainerStats) {
+		acc, ok := slots[key]
+		if !ok {
+			acc = &slotAccumulator{}
+			slots[key] = acc
+			order = append(order, key)
+		}
+		acc.cpu = append(acc.cpu, s.AvgCPUPercent)
+		acc.memory = append(acc.memory, float64(s.AvgMemoryUsage))
+	}
+
+	for _, s := range stats {
+		local := s.Hour.In(loc)
+		add(slotKey{s.ContainerName, s.HostID, int(local.Weekday()), local.Hour()}, s)
+		add(slotKey{s.ContainerName, s.HostID, -1, local.Hour()}, s)
+	}
+
+	baselines := make([]models.SeasonalBaseline, 0, len(order))
+	for _, key := range order {
+		acc := slots[key]
+		minSamples := minWeeklySlotSamples
+		if key.dayOfWeek == -1 {
+			minSamples = minHourlySlotSamples
+		}
+		if len(acc.cpu) < minSamples {
+			continue
+		}
+
+		cpuMean, cpuStdDev := meanStdDev(acc.cpu)
+		memMean, memStdDev := meanStdDev(acc.memory)
+		baselines = append(baselines, models.SeasonalBaseline{
+			ContainerName:     key.name,
+			HostID:            key.hostID,
+			DayOfWeek:         key.dayOfWeek,
+			HourOfDay:         key.hour,
+			AvgCPUPercent:     cpuMean,
+			StdDevCPUPercent:  cpuStdDev,
+			AvgMemoryUsage:    int64(memMean),
+			StdDevMemoryUsage: memStdDev,
+			SampleCount:       len(acc.cpu),
+			UpdatedAt:         now,
+		})
+	}
+
+	return baselines
+}
+
+// meanStdDev returns the mean and population standard deviation of the values
+func meanStdDev(values []float64) (float64, float64) {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(values)))
+}
+
+// UpdateSeasonalBaselines rebuilds all seasonal baselines from the last four weeks of hourly stats
+func (bc *BaselineCollector) UpdateSeasonalBaselines() error {
+	now := time.Now()
+	stats, err := bc.db.GetHourlyStatsSince(now.Add(-seasonalWindow))
+	if err != nil {
+		return err
+	}
+
+	baselines := buildSeasonalBaselines(stats, time.Local, now)
+	if err := bc.db.ReplaceSeasonalBaselines(baselines); err != nil {
+		return err
+	}
+
+	log.Printf("Seasonal baselines updated: %d slots from %d hourly samples", len(baselines), len(stats))
+	return nil
+}
+
+// seasonalBaselineFor returns the baseline for the container's slot at time t, preferring the
+// day-of-week slot and falling back to the hour-of-day slot. Returns nil if neither exists.
+func (ns *NotificationService) seasonalBaselineFor(containerName string, hostID int64, t time.Time) (*models.SeasonalBaseline, error) {
+	local := t.In(time.Local)
+	baseline, err := ns.db.GetSeasonalBaseline(containerName, hostID, int(local.Weekday()), local.Hour())
+	if err != nil || baseline != nil {
+		return baseline, err
+	}
+	return ns.db.GetSeasonalBaseline(containerName, hostID, -1, local.Hour())
+}
+
+// isSeasonalAnomaly reports whether current usage is well above what is normal for the slot.
+// It returns how many (floored) standard deviations CPU and memory are above the slot average.
+func isSeasonalAnomaly(baseline models.SeasonalBaseline, cpuPercent float64, memoryUsage int64) (bool, float64, float64) {
+	cpuSpread := math.Max(baseline.StdDevCPUPercent, seasonalCPUFloor)
+	memSpread := math.Max(baseline.StdDevMemoryUsage, float64(baseline.AvgMemoryUsage)*seasonalMemoryFloorRatio)
+
+	cpuSigmas := (cpuPercent - baseline.AvgCPUPercent) / cpuSpread
+	var memSigmas float64
+	if memSpread > 0 {
+		memSigmas = (float64(memoryUsage) - float64(baseline.AvgMemoryUsage)) / memSpread
+	}
+
+	return cpuSigmas > seasonalDeviationSigmas || memSigmas > seasonalDeviationSigmas, cpuSigmas, memSigmas
+}
+
+// seasonalSlotLabel describes a slot for notification metadata (e.g. "Mon 04:00" or "daily 04:00")
+func seasonalSlotLabel(baseline models.SeasonalBaseline) string {
+	if baseline.DayOfWeek < 0 {
+		return fmt.Sprintf("daily %02d:00", baseline.HourOfDay)
+	}
+	return fmt.Sprintf("%s %02d:00", time.Weekday(baseline.DayOfWeek).String()[:3], baseline.HourOfDay)
+}
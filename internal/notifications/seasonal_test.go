@@ -0,0 +1,152 @@
+package notifications
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// plexLikeStats builds four weeks of hourly stats where CPU is high every evening (20:00)
+// and low the rest of the day
+func plexLikeStats(start time.Time) []models.HourlyContainerStats {
+	var stats []models.HourlyContainerStats
+	for h := 0; h < 28*24; h++ {
+		hour := start.Add(time.Duration(h) * time.Hour)
+		cpu := 1.0 + float64(h%3)*0.2
+		if hour.Hour() == 20 {
+			cpu = 70 + float64(h%5)
+		}
+		stats = append(stats, models.HourlyContainerStats{
+			ContainerName:  "plex",
+			HostID:         1,
+			Hour:           hour,
+			AvgCPUPercent:  cpu,
+			AvgMemoryUsage: 512 * 1024 * 1024,
+		})
+	}
+	return stats
+}
+
+func TestBuildSeasonalBaselines(t *testing.T) {
+	start := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC) // Monday
+	baselines := buildSeasonalBaselines(plexLikeStats(start), time.UTC, start.Add(28*24*time.Hour))
+
+	// 7 days x 24 hours weekly slots + 24 hour-of-day slots
+	if len(baselines) != 7*24+24 {
+		t.Fatalf("Expected %d slots, got %d", 7*24+24, len(baselines))
+	}
+
+	slot := func(day, hour int) models.SeasonalBaseline {
+		for _, b := range baselines {
+			if b.DayOfWeek == day && b.HourOfDay == hour {
+				return b
+			}
+		}
+		t.Fatalf("Missing slot day=%d hour=%d", day, hour)
+		return models.SeasonalBaseline{}
+	}
+
+	evening := slot(int(time.Monday), 20)
+	if evening.AvgCPUPercent < 70 || evening.SampleCount != 4 {
+		t.Errorf("Expected Monday 20:00 baseline around 70%% from 4 samples, got %.1f from %d", evening.AvgCPUPercent, evening.SampleCount)
+	}
+	night := slot(-1, 4)
+	if night.AvgCPUPercent > 2 || night.SampleCount != 28 {
+		t.Errorf("Expected daily 04:00 baseline below 2%% from 28 samples, got %.1f from %d", night.AvgCPUPercent, night.SampleCount)
+	}
+	if seasonalSlotLabel(evening) != "Mon 20:00" || seasonalSlotLabel(night) != "daily 04:00" {
+		t.Errorf("Unexpected slot labels: %q, %q", seasonalSlotLabel(evening), seasonalSlotLabel(night))
+	}
+}
+
+func TestBuildSeasonalBaselines_SkipsSparseSlots(t *testing.T) {
+	start := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	// Only two days of history: not enough for any weekly or hour-of-day slot
+	baselines := buildSeasonalBaselines(plexLikeStats(start)[:48], time.UTC, start)
+	if len(baselines) != 0 {
+		t.Errorf("Expected no baselines from sparse history, got %d", len(baselines))
+	}
+}
+
+func TestIsSeasonalAnomaly(t *testing.T) {
+	start := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	baselines := buildSeasonalBaselines(plexLikeStats(start), time.UTC, start)
+
+	find := func(day, hour int) models.SeasonalBaseline {
+		for _, b := range baselines {
+			if b.DayOfWeek == day && b.HourOfDay == hour {
+				return b
+			}
+		}
+		t.Fatalf("Missing slot day=%d hour=%d", day, hour)
+		return models.SeasonalBaseline{}
+	}
+
+	memory := int64(512 * 1024 * 1024)
+	tests := []struct {
+		name     string
+		slot     models.SeasonalBaseline
+		cpu      float64
+		memory   int64
+		expected bool
+	}{
+		{"evening transcoding is normal", find(int(time.Tuesday), 20), 72, memory, false},
+		{"transcoding at 4am is anomalous", find(int(time.Tuesday), 4), 72, memory, true},
+		{"small noise at 4am is normal", find(int(time.Tuesday), 4), 3, memory, false},
+		{"memory spike at 4am is anomalous", find(int(time.Tuesday), 4), 1, memory * 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			anomalous, _, _ := isSeasonalAnomaly(tt.slot, tt.cpu, tt.memory)
+			if anomalous != tt.expected {
+				t.Errorf("Expected anomalous=%v, got %v", tt.expected, anomalous)
+			}
+		})
+	}
+}
+
+func TestUpdateSeasonalBaselines(t *testing.T) {
+	bc, db := setupTestBaseline(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "test-host", Address: "unix:///", Enabled: true, CollectStats: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	// Save two weeks of hourly scans and aggregate them
+	now := time.Now().Truncate(time.Hour)
+	for h := 14 * 24; h > 1; h-- {
+		container := models.Container{
+			ID: "plex123", Name: "plex", Image: "plex:latest", State: "running",
+			HostID: hostID, HostName: "test-host",
+			CPUPercent: 5, MemoryUsage: 256 * 1024 * 1024, MemoryLimit: 1024 * 1024 * 1024,
+			Created: now.Add(-30 * 24 * time.Hour), ScannedAt: now.Add(-time.Duration(h) * time.Hour),
+		}
+		if err := db.SaveContainers([]models.Container{container}); err != nil {
+			t.Fatalf("Failed to save container: %v", err)
+		}
+	}
+	if _, err := db.AggregateOldStats(); err != nil {
+		t.Fatalf("Failed to aggregate stats: %v", err)
+	}
+
+	if err := bc.UpdateSeasonalBaselines(); err != nil {
+		t.Fatalf("UpdateSeasonalBaselines failed: %v", err)
+	}
+
+	baselines, err := db.GetSeasonalBaselines("plex", hostID)
+	if err != nil {
+		t.Fatalf("Failed to get seasonal baselines: %v", err)
+	}
+	// Two weeks isn't enough for weekly slots, but fills every hour-of-day slot
+	if len(baselines) != 24 {
+		t.Fatalf("Expected 24 hour-of-day baselines, got %d", len(baselines))
+	}
+	for _, b := range baselines {
+		if b.DayOfWeek != -1 || b.AvgCPUPercent != 5 {
+			t.Errorf("Unexpected baseline: %+v", b)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package notifications
+
+import (
+	"github.com/container-census/container-census/internal/models"
+)
+
+// subscriberBuffer is how many log entries a slow subscriber may fall behind before entries are dropped
+const subscriberBuffer = 32
+
+// Subscribe returns a channel receiving every notification log entry as it is written, and a
+// function that ends the subscription. Entries are dropped for subscribers that don't keep up.
+func (ns *NotificationService) Subscribe() (<-chan models.NotificationLog, func()) {
+	ch := make(chan models.NotificationLog, subscriberBuffer)
+
+	ns.subscribersMu.Lock()
+	ns.subscribers[ch] = struct{}{}
+	ns.subscribersMu.Unlock()
+
+	return ch, func() {
+		ns.subscribersMu.Lock()
+		delete(ns.subscribers, ch)
+		ns.subscribersMu.Unlock()
+	}
+}
+
+// publish sends a log entry to every subscriber without blocking
+func (ns *NotificationService) publish(entry models.NotificationLog) {
+	ns.subscribersMu.Lock()
+	defer ns.subscribersMu.Unlock()
+
+	for ch := range ns.subscribers {
+		select {
+		case ch <- entry:
+		default:
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package notifications
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestSubscribe(t *testing.T) {
+	ns, db := setupTestNotifier(t)
+
+	rules, err := db.GetNotificationRules(true)
+	if err != nil || len(rules) == 0 {
+		t.Fatalf("Expected default rules: %v", err)
+	}
+	channelList, err := db.GetNotificationChannels()
+	if err != nil || len(channelList) == 0 {
+		t.Fatalf("Expected default channels: %v", err)
+	}
+	task := notificationTask{
+		Rule:    rules[0],
+		Channel: channelList[0].ID,
+		Event:   models.NotificationEvent{EventType: models.EventTypeContainerStopped, ContainerName: "web", HostName: "nas", Timestamp: time.Now()},
+	}
+
+	updates, unsubscribe := ns.Subscribe()
+	ns.logNotification(task, true, "")
+
+	select {
+	case entry := <-updates:
+		if entry.ID == 0 || entry.ContainerName != "web" || entry.Message == "" {
+			t.Errorf("Unexpected streamed entry: %+v", entry)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the new log entry to be published")
+	}
+
+	unsubscribe()
+	ns.logNotification(task, true, "")
+	select {
+	case entry := <-updates:
+		t.Errorf("Expected no entries after unsubscribing, got %+v", entry)
+	default:
+	}
+
+	// A subscriber that never reads doesn't block logging
+	_, unsubscribeSlow := ns.Subscribe()
+	defer unsubscribeSlow()
+	for i := 0; i < subscriberBuffer+5; i++ {
+		ns.logNotification(task, true, "")
+	}
+}
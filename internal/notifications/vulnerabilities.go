@@ -0,0 +1,46 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// SendVulnerabilityAlerts notifies the rules subscribed to new_vulnerability of the running
+// containers whose image a rescan after a vulnerability database update found new
+// vulnerabilities in, one event per container so host filters and silences apply to it
+func (ns *NotificationService) SendVulnerabilityAlerts(ctx context.Context, alerts []models.VulnerabilityAlert) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	events := make([]models.NotificationEvent, 0, len(alerts))
+	for _, alert := range alerts {
+		events = append(events, models.NotificationEvent{
+			EventType:     models.EventTypeNewVulnerability,
+			Timestamp:     now,
+			ContainerID:   alert.ContainerID,
+			ContainerName: alert.ContainerName,
+			HostID:        alert.HostID,
+			HostName:      alert.HostName,
+			Image:         alert.Image,
+			Metadata: map[string]interface{}{
+				"image_id":          alert.ImageID,
+				"vulnerability_ids": alert.VulnerabilityIDs,
+				"critical":          alert.Critical,
+				"high":              alert.High,
+				"trivy_db_version":  alert.TrivyDBVersion,
+			},
+		})
+	}
+
+	tasks, err := ns.matchRules(ctx, events)
+	if err != nil {
+		return fmt.Errorf("failed to match rules: %w", err)
+	}
+
+	return ns.sendNotifications(ctx, ns.filterSilenced(tasks))
+}
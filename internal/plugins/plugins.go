@@ -0,0 +1,268 @@
+// Package plugins runs custom collectors after each scan. A collector is an executable in the
+// plugins directory: it receives the scanned host and its containers as JSON on stdin and prints
+// key/value results per container as JSON on stdout, which are stored and shown in container detail.
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// Limits on what a single run of a plugin may report
+const (
+	MaxResults     = 1000
+	MaxKeyLength   = 100
+	MaxValueLength = 4096
+	maxOutputBytes = 4 << 20
+)
+
+// DefaultTimeout is how long a plugin may run for one host
+const DefaultTimeout = 30 * time.Second
+
+// Input is the JSON document a collector receives on stdin
+type Input struct {
+	Host       models.Host        `json:"host"`
+	Containers []models.Container `json:"containers"`
+}
+
+// Output is the JSON document a collector prints on stdout
+type Output struct {
+	Results []models.PluginResult `json:"results"`
+}
+
+// Collector gathers extra data about the containers of a scanned host
+type Collector interface {
+	Name() string
+	Path() string
+	Collect(ctx context.Context, input Input) ([]models.PluginResult, error)
+}
+
+// ExecCollector runs an executable, writing Input to its stdin and reading Output from its stdout
+type ExecCollector struct {
+	path string
+}
+
+// NewExecCollector returns a collector for the executable at path
+func NewExecCollector(path string) *ExecCollector {
+	return &ExecCollector{path: path}
+}
+
+// Name is the executable's file name without extension
+func (c *ExecCollector) Name() string {
+	base := filepath.Base(c.path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// Path is the executable's location
+func (c *ExecCollector) Path() string {
+	return c.path
+}
+
+// Collect runs the executable once; a non-zero exit or invalid output is an error
+func (c *ExecCollector) Collect(ctx context.Context, input Input) ([]models.PluginResult, error) {
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout limitedBuffer
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.path)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = time.Second // don't wait on children that keep the output open after a timeout
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("timed out: %w", ctx.Err())
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, truncate(msg, 500))
+		}
+		return nil, err
+	}
+	if stdout.overflow {
+		return nil, fmt.Errorf("output exceeds %d bytes", maxOutputBytes)
+	}
+
+	var out Output
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return nil, fmt.Errorf("invalid output: %w", err)
+	}
+	return out.Results, nil
+}
+
+// limitedBuffer stops collecting output past maxOutputBytes
+type limitedBuffer struct {
+	bytes.Buffer
+	overflow bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > maxOutputBytes {
+		b.overflow = true
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+// Discover returns a collector for every executable file in dir, in name order.
+// Hidden files, directories and files without an execute bit are skipped.
+func Discover(dir string) ([]Collector, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	collectors := make([]Collector, 0)
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+			continue
+		}
+		collectors = append(collectors, NewExecCollector(filepath.Join(dir, entry.Name())))
+	}
+	sort.Slice(collectors, func(i, j int) bool { return collectors[i].Name() < collectors[j].Name() })
+	return collectors, nil
+}
+
+// resultStore persists plugin results
+type resultStore interface {
+	SavePluginResults(plugin string, hostID int64, results []models.PluginResult, collectedAt time.Time) error
+}
+
+// Runner runs every collector after a host scan and keeps the status of their latest runs
+type Runner struct {
+	store      resultStore
+	collectors []Collector
+	timeout    time.Duration
+
+	mu     sync.RWMutex
+	status map[string]models.PluginStatus
+}
+
+// NewRunner creates a runner; a zero timeout uses DefaultTimeout
+func NewRunner(store resultStore, collectors []Collector, timeout time.Duration) *Runner {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	r := &Runner{
+		store:      store,
+		collectors: collectors,
+		timeout:    timeout,
+		status:     make(map[string]models.PluginStatus),
+	}
+	for _, c := range collectors {
+		r.status[c.Name()] = models.PluginStatus{Name: c.Name(), Path: c.Path()}
+	}
+	return r
+}
+
+// Run passes a scanned host and its containers to every collector and stores what they report.
+// A failing plugin keeps its previous results and does not affect the others.
+func (r *Runner) Run(ctx context.Context, host models.Host, containers []models.Container) {
+	// Plugins never see the agent's credentials
+	host.AgentToken = ""
+	input := Input{Host: host, Containers: containers}
+
+	for _, c := range r.collectors {
+		started := time.Now()
+		runCtx, cancel := context.WithTimeout(ctx, r.timeout)
+		results, err := c.Collect(runCtx, input)
+		cancel()
+
+		status := models.PluginStatus{
+			Name:       c.Name(),
+			Path:       c.Path(),
+			LastRunAt:  started,
+			LastHost:   host.Name,
+			DurationMs: time.Since(started).Milliseconds(),
+		}
+		if err == nil {
+			results, err = Normalize(results, host.ID, containers)
+		}
+		if err == nil {
+			err = r.store.SavePluginResults(c.Name(), host.ID, results, started)
+		}
+		if err != nil {
+			log.Printf("Plugin %s failed for host %s: %v", c.Name(), host.Name, err)
+			status.LastError = err.Error()
+		} else {
+			status.LastResults = len(results)
+		}
+
+		r.mu.Lock()
+		r.status[c.Name()] = status
+		r.mu.Unlock()
+	}
+}
+
+// Status returns the collectors and their latest runs, in name order
+func (r *Runner) Status() []models.PluginStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	statuses := make([]models.PluginStatus, 0, len(r.status))
+	for _, s := range r.status {
+		statuses = append(statuses, s)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// Normalize resolves container IDs to names and checks results against the scanned containers
+// and the size limits. Results for containers that weren't scanned are dropped.
+func Normalize(results []models.PluginResult, hostID int64, containers []models.Container) ([]models.PluginResult, error) {
+	if len(results) > MaxResults {
+		return nil, fmt.Errorf("reported %d results, at most %d are allowed", len(results), MaxResults)
+	}
+
+	names := make(map[string]string, len(containers)*2)
+	for _, c := range containers {
+		names[c.Name] = c.Name
+		names[c.ID] = c.Name
+	}
+
+	normalized := make([]models.PluginResult, 0, len(results))
+	for _, r := range results {
+		name, ok := names[r.ContainerName]
+		if !ok {
+			name, ok = names[r.ContainerID]
+		}
+		if !ok {
+			continue
+		}
+		r.Key = strings.TrimSpace(r.Key)
+		if r.Key == "" || len(r.Key) > MaxKeyLength {
+			return nil, fmt.Errorf("invalid key %q for %s: must be 1-%d characters", r.Key, name, MaxKeyLength)
+		}
+		r.ContainerName = name
+		r.ContainerID = ""
+		r.HostID = hostID
+		r.Value = truncate(r.Value, MaxValueLength)
+		normalized = append(normalized, r)
+	}
+	return normalized, nil
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "…"
+}
@@ -0,0 +1,176 @@
+package plugins
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func writeScript(t *testing.T, dir, name, body string, mode os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), mode); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+	return path
+}
+
+func TestDiscover(t *testing.T) {
+	dir := t.TempDir()
+	writeScript(t, dir, "zeta.sh", "true", 0755)
+	writeScript(t, dir, "alpha", "true", 0755)
+	writeScript(t, dir, "readme.txt", "true", 0644)
+	writeScript(t, dir, ".hidden", "true", 0755)
+	if err := os.Mkdir(filepath.Join(dir, "subdir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	collectors, err := Discover(dir)
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	var names []string
+	for _, c := range collectors {
+		names = append(names, c.Name())
+	}
+	if strings.Join(names, ",") != "alpha,zeta" {
+		t.Errorf("Expected executable plugins alpha,zeta, got %v", names)
+	}
+}
+
+func TestExecCollector(t *testing.T) {
+	dir := t.TempDir()
+	// Echo the first container's name back, proving stdin is the scan input
+	path := writeScript(t, dir, "license", `name=$(sed 's/.*"containers":\[{"id":"[^"]*","name":"\([^"]*\)".*/\1/')
+printf '{"results":[{"container_name":"%s","key":"license","value":"MIT"}]}' "$name"`, 0755)
+
+	results, err := NewExecCollector(path).Collect(context.Background(), Input{
+		Host:       models.Host{ID: 1, Name: "nas"},
+		Containers: []models.Container{{ID: "abc", Name: "web"}},
+	})
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	if len(results) != 1 || results[0].ContainerName != "web" || results[0].Value != "MIT" {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+}
+
+func TestExecCollectorErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		body    string
+		timeout time.Duration
+		want    string
+	}{
+		{"failing", "echo 'cannot reach app' >&2; exit 3", time.Second, "cannot reach app"},
+		{"garbage", "echo not json", time.Second, "invalid output"},
+		{"slow", "sleep 5", 100 * time.Millisecond, "timed out"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeScript(t, dir, tt.name, tt.body, 0755)
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			_, err := NewExecCollector(path).Collect(ctx, Input{})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	containers := []models.Container{{ID: "abc123", Name: "web"}, {ID: "def456", Name: "db"}}
+
+	results, err := Normalize([]models.PluginResult{
+		{ContainerName: "web", Key: " version ", Value: "1.2"},
+		{ContainerID: "def456", Key: "license", Value: strings.Repeat("x", MaxValueLength+10)},
+		{ContainerName: "gone", Key: "version", Value: "1.0"},
+	}, 7, containers)
+	if err != nil {
+		t.Fatalf("Normalize failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected results for unknown containers to be dropped, got %+v", results)
+	}
+	if results[0].Key != "version" || results[0].HostID != 7 {
+		t.Errorf("Expected trimmed key and host ID, got %+v", results[0])
+	}
+	if results[1].ContainerName != "db" || results[1].ContainerID != "" {
+		t.Errorf("Expected container ID to resolve to db, got %+v", results[1])
+	}
+	if len(results[1].Value) > MaxValueLength+len("…") {
+		t.Errorf("Expected long value to be truncated, got %d bytes", len(results[1].Value))
+	}
+
+	if _, err := Normalize([]models.PluginResult{{ContainerName: "web", Key: ""}}, 7, containers); err == nil {
+		t.Error("Expected an error for an empty key")
+	}
+	if _, err := Normalize(make([]models.PluginResult, MaxResults+1), 7, containers); err == nil {
+		t.Error("Expected an error for too many results")
+	}
+}
+
+type fakeCollector struct {
+	name    string
+	results []models.PluginResult
+	err     error
+	input   Input
+}
+
+func (f *fakeCollector) Name() string { return f.name }
+func (f *fakeCollector) Path() string { return "/plugins/" + f.name }
+func (f *fakeCollector) Collect(ctx context.Context, input Input) ([]models.PluginResult, error) {
+	f.input = input
+	return f.results, f.err
+}
+
+type fakeStore struct {
+	saved map[string][]models.PluginResult
+}
+
+func (s *fakeStore) SavePluginResults(plugin string, hostID int64, results []models.PluginResult, collectedAt time.Time) error {
+	s.saved[plugin] = results
+	return nil
+}
+
+func TestRunner(t *testing.T) {
+	good := &fakeCollector{name: "good", results: []models.PluginResult{{ContainerName: "web", Key: "k", Value: "v"}}}
+	bad := &fakeCollector{name: "bad", err: context.DeadlineExceeded}
+	store := &fakeStore{saved: map[string][]models.PluginResult{}}
+
+	runner := NewRunner(store, []Collector{good, bad}, 0)
+	if status := runner.Status(); len(status) != 2 || !status[0].LastRunAt.IsZero() {
+		t.Fatalf("Expected two plugins that haven't run yet, got %+v", status)
+	}
+
+	host := models.Host{ID: 3, Name: "nas", AgentToken: "secret"}
+	runner.Run(context.Background(), host, []models.Container{{ID: "abc", Name: "web"}})
+
+	if good.input.Host.AgentToken != "" {
+		t.Error("Expected the agent token to be withheld from plugins")
+	}
+	if len(store.saved["good"]) != 1 {
+		t.Errorf("Expected good plugin results to be saved, got %+v", store.saved)
+	}
+	if _, ok := store.saved["bad"]; ok {
+		t.Error("Expected a failed plugin to keep its previous results")
+	}
+
+	status := runner.Status()
+	if status[0].Name != "bad" || status[0].LastError == "" || status[0].LastHost != "nas" {
+		t.Errorf("Expected bad plugin error status, got %+v", status[0])
+	}
+	if status[1].Name != "good" || status[1].LastResults != 1 || status[1].LastError != "" {
+		t.Errorf("Expected good plugin success status, got %+v", status[1])
+	}
+}
@@ -0,0 +1,177 @@
+// Package proxmox reads the VMs, containers and nodes of a Proxmox VE cluster and maps Census
+// hosts to the guest they run on, so the host view can show where a Docker host lives and what
+// resources it was given.
+package proxmox
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Resource is a VM, container or node from /cluster/resources
+type Resource struct {
+	ID      string  `json:"id"`   // e.g. qemu/100, lxc/101, node/pve1
+	Type    string  `json:"type"` // qemu, lxc or node
+	Node    string  `json:"node"`
+	VMID    int     `json:"vmid"`
+	Name    string  `json:"name"`
+	Status  string  `json:"status"`
+	MaxCPU  float64 `json:"maxcpu"`
+	MaxMem  int64   `json:"maxmem"`
+	MaxDisk int64   `json:"maxdisk"`
+	Uptime  int64   `json:"uptime"`
+}
+
+// DisplayName returns the guest name, or the node name for nodes
+func (r Resource) DisplayName() string {
+	if r.Name == "" && r.Type == "node" {
+		return r.Node
+	}
+	return r.Name
+}
+
+// Client is a read-only client for the Proxmox VE API, authenticated with an API token
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the Proxmox VE API at baseURL (e.g. https://pve.local:8006).
+// tokenID has the form USER@REALM!TOKENID. The token only needs the PVEAuditor role.
+func NewClient(baseURL, tokenID, secret string, skipTLSVerify bool, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if skipTLSVerify {
+			// Proxmox installs a self-signed certificate unless one was configured
+			transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		}
+		httpClient = &http.Client{Timeout: 30 * time.Second, Transport: transport}
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		token:      fmt.Sprintf("PVEAPIToken=%s=%s", tokenID, secret),
+		httpClient: httpClient,
+	}
+}
+
+// Resources returns the VMs, containers and nodes of the cluster
+func (c *Client) Resources(ctx context.Context) ([]Resource, error) {
+	var all []Resource
+	if err := c.get(ctx, "/cluster/resources", &all); err != nil {
+		return nil, err
+	}
+
+	resources := make([]Resource, 0, len(all))
+	for _, r := range all {
+		if r.Type == "qemu" || r.Type == "lxc" || r.Type == "node" {
+			resources = append(resources, r)
+		}
+	}
+	return resources, nil
+}
+
+// NodeAddresses returns the IP address of every cluster node by node name
+func (c *Client) NodeAddresses(ctx context.Context) (map[string]string, error) {
+	var status []struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+		IP   string `json:"ip"`
+	}
+	if err := c.get(ctx, "/cluster/status", &status); err != nil {
+		return nil, err
+	}
+
+	addresses := make(map[string]string)
+	for _, s := range status {
+		if s.Type == "node" && s.IP != "" {
+			addresses[s.Name] = s.IP
+		}
+	}
+	return addresses, nil
+}
+
+// GuestAddresses returns the IP addresses of a running guest. VMs report them through the QEMU
+// guest agent, which has to be installed and enabled; containers report them directly.
+func (c *Client) GuestAddresses(ctx context.Context, r Resource) ([]string, error) {
+	base := fmt.Sprintf("/nodes/%s/%s/%d", url.PathEscape(r.Node), r.Type, r.VMID)
+
+	switch r.Type {
+	case "qemu":
+		var agent struct {
+			Result []struct {
+				IPAddresses []struct {
+					IPAddress string `json:"ip-address"`
+				} `json:"ip-addresses"`
+			} `json:"result"`
+		}
+		if err := c.get(ctx, base+"/agent/network-get-interfaces", &agent); err != nil {
+			return nil, err
+		}
+		var addresses []string
+		for _, iface := range agent.Result {
+			for _, addr := range iface.IPAddresses {
+				addresses = append(addresses, addr.IPAddress)
+			}
+		}
+		return addresses, nil
+
+	case "lxc":
+		var interfaces []struct {
+			Inet  string `json:"inet"`
+			Inet6 string `json:"inet6"`
+		}
+		if err := c.get(ctx, base+"/interfaces", &interfaces); err != nil {
+			return nil, err
+		}
+		var addresses []string
+		for _, iface := range interfaces {
+			for _, cidr := range []string{iface.Inet, iface.Inet6} {
+				if ip, _, _ := strings.Cut(cidr, "/"); ip != "" {
+					addresses = append(addresses, ip)
+				}
+			}
+		}
+		return addresses, nil
+	}
+
+	return nil, nil
+}
+
+// get fetches an API path and decodes the data field of the response into out
+func (c *Client) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api2/json"+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Proxmox: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// Proxmox puts the reason in the status line, e.g. "401 authentication failure"
+		return fmt.Errorf("Proxmox returned %s for %s", resp.Status, path)
+	}
+
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 16<<20)).Decode(&envelope); err != nil {
+		return fmt.Errorf("invalid Proxmox response for %s: %w", path, err)
+	}
+	if err := json.Unmarshal(envelope.Data, out); err != nil {
+		return fmt.Errorf("invalid Proxmox response for %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package proxmox
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// Guest is a cluster resource with the IP addresses it reported
+type Guest struct {
+	Resource
+	Addresses []string
+}
+
+// Sync reads the cluster's VMs, containers and nodes and maps the hosts to them. Failures are
+// collected in the result; guests whose addresses can't be read (e.g. VMs without the QEMU guest
+// agent) can still be matched by name.
+func Sync(ctx context.Context, settings *models.ProxmoxSettings, hosts []models.Host, httpClient *http.Client) (*models.ProxmoxSyncResult, []models.ProxmoxGuest) {
+	result := &models.ProxmoxSyncResult{SyncedAt: time.Now()}
+	client := NewClient(settings.URL, settings.TokenID, settings.TokenSecret, settings.SkipTLSVerify, httpClient)
+
+	resources, err := client.Resources(ctx)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return result, nil
+	}
+	nodeAddresses, err := client.NodeAddresses(ctx)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	}
+
+	guests := make([]Guest, 0, len(resources))
+	for _, r := range resources {
+		guest := Guest{Resource: r}
+		switch {
+		case r.Type == "node":
+			if ip := nodeAddresses[r.Node]; ip != "" {
+				guest.Addresses = []string{ip}
+			}
+		case r.Status == "running":
+			guest.Addresses, _ = client.GuestAddresses(ctx, r)
+		}
+		guests = append(guests, guest)
+	}
+	result.Guests = len(guests)
+
+	lookup := func(hostname string) []string {
+		addresses, _ := net.DefaultResolver.LookupHost(ctx, hostname)
+		return addresses
+	}
+	matches := Match(hosts, guests, lookup)
+	for i := range matches {
+		matches[i].SyncedAt = result.SyncedAt
+	}
+	result.Matched = len(matches)
+
+	return result, matches
+}
+
+// Match maps each host to the guest it runs on: first by comparing the addresses its hostname
+// resolves to with the guests' addresses, then by comparing the hostname and finally the Census
+// host name with guest names. VMs and containers are preferred over nodes. lookup resolves
+// hostnames and may be nil.
+func Match(hosts []models.Host, guests []Guest, lookup func(hostname string) []string) []models.ProxmoxGuest {
+	ordered := make([]Guest, 0, len(guests))
+	for _, g := range guests {
+		if g.Type != "node" {
+			ordered = append(ordered, g)
+		}
+	}
+	for _, g := range guests {
+		if g.Type == "node" {
+			ordered = append(ordered, g)
+		}
+	}
+
+	matches := make([]models.ProxmoxGuest, 0)
+	for _, host := range hosts {
+		hostname := addressHostname(host.Address)
+
+		var ips []net.IP
+		if ip := net.ParseIP(hostname); ip != nil {
+			ips = append(ips, ip)
+		} else if hostname != "" && lookup != nil {
+			for _, addr := range lookup(hostname) {
+				if ip := net.ParseIP(addr); ip != nil {
+					ips = append(ips, ip)
+				}
+			}
+		}
+
+		guest, matchedBy, ok := matchByIP(ordered, ips)
+		if !ok && net.ParseIP(hostname) == nil {
+			guest, ok = matchByName(ordered, hostname)
+			matchedBy = models.ProxmoxMatchHostname
+		}
+		if !ok {
+			guest, ok = matchByName(ordered, host.Name)
+			matchedBy = models.ProxmoxMatchName
+		}
+		if !ok {
+			continue
+		}
+
+		matches = append(matches, models.ProxmoxGuest{
+			HostID:        host.ID,
+			HostName:      host.Name,
+			Node:          guest.Node,
+			VMID:          guest.VMID,
+			Type:          guest.Type,
+			Name:          guest.DisplayName(),
+			Status:        guest.Status,
+			CPUs:          guest.MaxCPU,
+			MaxMemory:     guest.MaxMem,
+			MaxDisk:       guest.MaxDisk,
+			UptimeSeconds: guest.Uptime,
+			MatchedBy:     matchedBy,
+		})
+	}
+	return matches
+}
+
+func matchByIP(guests []Guest, ips []net.IP) (Guest, string, bool) {
+	for _, ip := range ips {
+		if ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+			continue
+		}
+		for _, g := range guests {
+			for _, addr := range g.Addresses {
+				if guestIP := net.ParseIP(addr); guestIP != nil && guestIP.Equal(ip) {
+					return g, models.ProxmoxMatchIP, true
+				}
+			}
+		}
+	}
+	return Guest{}, "", false
+}
+
+// matchByName compares names case-insensitively, ignoring the domain of either side
+func matchByName(guests []Guest, name string) (Guest, bool) {
+	name = shortName(name)
+	if name == "" {
+		return Guest{}, false
+	}
+	for _, g := range guests {
+		if shortName(g.DisplayName()) == name {
+			return g, true
+		}
+	}
+	return Guest{}, false
+}
+
+func shortName(name string) string {
+	name, _, _ = strings.Cut(strings.ToLower(strings.TrimSpace(name)), ".")
+	return name
+}
+
+// addressHostname returns the hostname or IP of a host address such as agent://10.0.0.5:9876,
+// tcp://docker.lan:2376 or ssh://user@vm1, or "" for local sockets
+func addressHostname(address string) string {
+	if !strings.Contains(address, "://") {
+		return ""
+	}
+	u, err := url.Parse(address)
+	if err != nil || u.Scheme == "unix" {
+		return ""
+	}
+	return u.Hostname()
+}
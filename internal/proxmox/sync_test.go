@@ -0,0 +1,101 @@
+package proxmox
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestSync(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "PVEAPIToken=census@pve!census=secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api2/json/cluster/resources":
+			w.Write([]byte(`{"data":[
+				{"id":"node/pve1","type":"node","node":"pve1","status":"online","maxcpu":16,"maxmem":68719476736,"maxdisk":1000000000000,"uptime":900000},
+				{"id":"qemu/101","type":"qemu","node":"pve1","vmid":101,"name":"docker","status":"running","maxcpu":4,"maxmem":8589934592,"maxdisk":68719476736,"uptime":3600},
+				{"id":"lxc/200","type":"lxc","node":"pve1","vmid":200,"name":"media.lan","status":"running","maxcpu":2,"maxmem":2147483648,"maxdisk":8589934592},
+				{"id":"qemu/102","type":"qemu","node":"pve1","vmid":102,"name":"backup","status":"stopped","maxcpu":2,"maxmem":4294967296},
+				{"id":"storage/pve1/local","type":"storage","node":"pve1","status":"available"}
+			]}`))
+		case "/api2/json/cluster/status":
+			w.Write([]byte(`{"data":[{"type":"cluster","name":"home"},{"type":"node","name":"pve1","ip":"10.0.0.2"}]}`))
+		case "/api2/json/nodes/pve1/qemu/101/agent/network-get-interfaces":
+			w.Write([]byte(`{"data":{"result":[
+				{"name":"lo","ip-addresses":[{"ip-address":"127.0.0.1","ip-address-type":"ipv4"}]},
+				{"name":"eth0","ip-addresses":[{"ip-address":"10.0.0.5","ip-address-type":"ipv4"},{"ip-address":"fe80::1","ip-address-type":"ipv6"}]}
+			]}}`))
+		case "/api2/json/nodes/pve1/lxc/200/interfaces":
+			w.Write([]byte(`{"data":[{"name":"lo","inet":"127.0.0.1/8"},{"name":"eth0","inet":"10.0.0.7/24","inet6":"fd00::7/64"}]}`))
+		default:
+			// e.g. a VM without the guest agent
+			http.Error(w, "not found", http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	settings := &models.ProxmoxSettings{Enabled: true, URL: server.URL, TokenID: "census@pve!census", TokenSecret: "secret", SyncIntervalMinutes: 15}
+	hosts := []models.Host{
+		{ID: 1, Name: "docker-vm", Address: "agent://10.0.0.5:9876"},
+		{ID: 2, Name: "media", Address: "ssh://root@media.lan"}, // not resolvable, matched by hostname
+		{ID: 3, Name: "pve1", Address: "tcp://10.0.0.2:2376"},
+		{ID: 4, Name: "backup", Address: "unix:///var/run/docker.sock"},
+		{ID: 5, Name: "laptop", Address: "agent://10.0.0.99:9876"},
+	}
+
+	result, guests := Sync(context.Background(), settings, hosts, server.Client())
+	if len(result.Errors) != 0 {
+		t.Fatalf("Unexpected errors: %v", result.Errors)
+	}
+	if result.Guests != 4 || result.Matched != 4 {
+		t.Errorf("Expected 4 guests with 4 matches, got %+v", result)
+	}
+
+	byHost := make(map[int64]models.ProxmoxGuest)
+	for _, g := range guests {
+		byHost[g.HostID] = g
+	}
+	if g := byHost[1]; g.VMID != 101 || g.Type != models.ProxmoxTypeQEMU || g.MatchedBy != models.ProxmoxMatchIP || g.CPUs != 4 || g.MaxMemory != 8<<30 || g.SyncedAt.IsZero() {
+		t.Errorf("Expected docker-vm on VM 101 by IP, got %+v", g)
+	}
+	if g := byHost[2]; g.VMID != 200 || g.MatchedBy != models.ProxmoxMatchHostname {
+		t.Errorf("Expected media on CT 200 by hostname, got %+v", g)
+	}
+	if g := byHost[3]; g.Type != models.ProxmoxTypeNode || g.Name != "pve1" || g.MatchedBy != models.ProxmoxMatchIP {
+		t.Errorf("Expected pve1 on the node by IP, got %+v", g)
+	}
+	if g := byHost[4]; g.VMID != 102 || g.MatchedBy != models.ProxmoxMatchName {
+		t.Errorf("Expected backup on VM 102 by name, got %+v", g)
+	}
+	if _, ok := byHost[5]; ok {
+		t.Error("Expected no match for a host outside the cluster")
+	}
+
+	settings.TokenSecret = "wrong"
+	result, guests = Sync(context.Background(), settings, hosts, server.Client())
+	if len(result.Errors) == 0 || guests != nil {
+		t.Errorf("Expected an authentication error, got %+v", result)
+	}
+}
+
+func TestAddressHostname(t *testing.T) {
+	tests := map[string]string{
+		"agent://10.0.0.5:9876":       "10.0.0.5",
+		"tcp://docker.lan:2376":       "docker.lan",
+		"ssh://user@vm1":              "vm1",
+		"ssh://user@[fd00::5]:22":     "fd00::5",
+		"unix:///var/run/docker.sock": "",
+		"local":                       "",
+	}
+	for address, want := range tests {
+		if got := addressHostname(address); got != want {
+			t.Errorf("addressHostname(%q) = %q, want %q", address, got, want)
+		}
+	}
+}
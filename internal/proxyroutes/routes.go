@@ -0,0 +1,242 @@
+// Package proxyroutes maps the public URLs of reverse proxies to the containers serving them:
+// from the containers' Traefik labels and nginx-proxy VIRTUAL_HOST variables, and from Traefik's
+// API, which also knows the routes of its file provider. Routes whose container is stopped are
+// flagged, since the URL is down.
+package proxyroutes
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// Container is a container of the latest scans with what routes are matched against
+type Container struct {
+	HostID      int64
+	HostName    string
+	HostAddress string // the host's Docker address, to match backends at a published port
+	Name        string
+	Image       string
+	State       string
+	Labels      map[string]string
+	Env         []models.EnvVar
+	IPs         []string // addresses on its networks, from the stored configuration
+	Ports       []models.PortMapping
+}
+
+// BuildReport maps the routes of the containers' configuration and, when the integration is
+// enabled, of Traefik's API. An unreachable Traefik is reported in Errors, with the routes of the
+// labels still returned.
+func BuildReport(ctx context.Context, settings *models.TraefikSettings, containers []Container, httpClient *http.Client) models.ProxyRouteReport {
+	report := models.ProxyRouteReport{GeneratedAt: time.Now(), TraefikEnabled: settings != nil && settings.Enabled}
+
+	var fromTraefik []models.ProxyRoute
+	if report.TraefikEnabled {
+		client := NewClient(settings, httpClient)
+		routers, err := client.Routers(ctx)
+		if err == nil {
+			var services []Service
+			if services, err = client.Services(ctx); err == nil {
+				fromTraefik = FromTraefik(routers, services, containers)
+			}
+		}
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("Traefik API: %v", err))
+		}
+	}
+
+	report.Routes = Merge(FromContainers(containers), fromTraefik)
+	for _, r := range report.Routes {
+		switch r.Status {
+		case models.RouteStatusStopped:
+			report.Stopped++
+		case models.RouteStatusUnmatched:
+			report.Unmatched++
+		}
+	}
+	return report
+}
+
+// FromContainers returns the routes the containers configure themselves: the URLs of their
+// Traefik routers and their nginx-proxy VIRTUAL_HOST names (https when LETSENCRYPT_HOST covers
+// the name, with VIRTUAL_PATH)
+func FromContainers(containers []Container) []models.ProxyRoute {
+	var routes []models.ProxyRoute
+	for _, c := range containers {
+		for _, router := range models.TraefikRouters(c.Labels) {
+			for _, u := range router.URLs {
+				routes = append(routes, containerRoute(c, models.ProxyRoute{
+					URL: u, Proxy: models.ProxyTraefik, Source: models.RouteSourceLabels, Router: router.Name,
+				}))
+			}
+		}
+
+		env := map[string]string{}
+		for _, e := range c.Env {
+			if !e.Masked {
+				env[e.Name] = e.Value
+			}
+		}
+		secure := map[string]bool{}
+		for _, host := range splitList(env["LETSENCRYPT_HOST"]) {
+			secure[host] = true
+		}
+		path := strings.TrimSuffix(env["VIRTUAL_PATH"], "/")
+		for _, host := range splitList(env["VIRTUAL_HOST"]) {
+			scheme := "http"
+			if secure[host] {
+				scheme = "https"
+			}
+			routes = append(routes, containerRoute(c, models.ProxyRoute{
+				URL: scheme + "://" + host + path, Proxy: models.ProxyNginxProxy, Source: models.RouteSourceEnv,
+			}))
+		}
+	}
+	return routes
+}
+
+// Merge combines the routes found in the containers' configuration with those read from Traefik,
+// which win for the same URL and container, flags stopped and unmatched routes, and sorts them
+// by URL
+func Merge(fromContainers, fromTraefik []models.ProxyRoute) []models.ProxyRoute {
+	key := func(r models.ProxyRoute) string {
+		return r.URL + "\x00" + strconv.FormatInt(r.HostID, 10) + "\x00" + r.ContainerName
+	}
+	seen := map[string]bool{}
+	routes := make([]models.ProxyRoute, 0, len(fromContainers)+len(fromTraefik))
+	for _, r := range fromTraefik {
+		seen[key(r)] = true
+		routes = append(routes, r)
+	}
+	for _, r := range fromContainers {
+		if !seen[key(r)] {
+			seen[key(r)] = true
+			routes = append(routes, r)
+		}
+	}
+
+	for i := range routes {
+		r := &routes[i]
+		switch {
+		case r.ContainerName == "":
+			r.Status = models.RouteStatusUnmatched
+		case r.ContainerState != "running":
+			r.Status = models.RouteStatusStopped
+		default:
+			r.Status = models.RouteStatusOK
+		}
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].URL != routes[j].URL {
+			return routes[i].URL < routes[j].URL
+		}
+		return routes[i].HostName < routes[j].HostName
+	})
+	return routes
+}
+
+// MatchBackend finds the container behind a backend URL of Traefik (http://172.18.0.4:8096,
+// http://jellyfin:8096 or http://nas.lan:8096): by its address on a container network, its
+// container name, or a port published on a host with that address. Containers on the hosts
+// running Traefik win when several match, since that's where Traefik resolves the backend.
+func MatchBackend(backend string, containers []Container) *Container {
+	u, err := url.Parse(backend)
+	if err != nil || u.Hostname() == "" {
+		return nil
+	}
+	hostname := u.Hostname()
+	port, _ := strconv.Atoi(u.Port())
+	if port == 0 {
+		port = 80
+		if u.Scheme == "https" {
+			port = 443
+		}
+	}
+
+	proxyHosts := map[int64]bool{}
+	for _, c := range containers {
+		if c.State == "running" && strings.Contains(strings.ToLower(c.Image), "traefik") {
+			proxyHosts[c.HostID] = true
+		}
+	}
+	best := func(candidates []*Container) *Container {
+		for _, c := range candidates {
+			if proxyHosts[c.HostID] {
+				return c
+			}
+		}
+		if len(candidates) > 0 {
+			return candidates[0]
+		}
+		return nil
+	}
+
+	var byAddress, byName, byPort []*Container
+	for i := range containers {
+		c := &containers[i]
+		for _, ip := range c.IPs {
+			if ip == hostname {
+				byAddress = append(byAddress, c)
+			}
+		}
+		if strings.EqualFold(c.Name, hostname) {
+			byName = append(byName, c)
+		}
+		if addressHost(c.HostAddress) == hostname {
+			for _, p := range c.Ports {
+				if p.PublicPort == port && (p.Type == "" || p.Type == "tcp") {
+					byPort = append(byPort, c)
+					break
+				}
+			}
+		}
+	}
+	if net.ParseIP(hostname) != nil {
+		if c := best(byAddress); c != nil {
+			return c
+		}
+		return best(byPort)
+	}
+	if c := best(byName); c != nil {
+		return c
+	}
+	return best(byPort)
+}
+
+// containerRoute sets the container of a route
+func containerRoute(c Container, r models.ProxyRoute) models.ProxyRoute {
+	r.HostID = c.HostID
+	r.HostName = c.HostName
+	r.ContainerName = c.Name
+	r.ContainerState = c.State
+	return r
+}
+
+// addressHost returns the host name or IP of a Docker host address (tcp://nas.lan:2376,
+// ssh://user@nas.lan, agent://nas.lan:9876); "" for local sockets
+func addressHost(address string) string {
+	u, err := url.Parse(address)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// splitList splits a comma-separated list of host names
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
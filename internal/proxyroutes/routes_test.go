@@ -0,0 +1,142 @@
+package proxyroutes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestFromContainers(t *testing.T) {
+	containers := []Container{
+		{
+			HostID: 1, HostName: "nas", Name: "jellyfin", State: "running",
+			Labels: map[string]string{
+				"traefik.http.routers.jellyfin.rule":        "Host(`jellyfin.example.com`)",
+				"traefik.http.routers.jellyfin.entrypoints": "websecure",
+			},
+		},
+		{
+			HostID: 1, HostName: "nas", Name: "wiki", State: "exited",
+			Env: []models.EnvVar{
+				{Name: "VIRTUAL_HOST", Value: "wiki.example.com, wiki.lan"},
+				{Name: "LETSENCRYPT_HOST", Value: "wiki.example.com"},
+				{Name: "VIRTUAL_PATH", Value: "/docs/"},
+			},
+		},
+	}
+
+	routes := Merge(FromContainers(containers), nil)
+	want := []models.ProxyRoute{
+		{URL: "http://wiki.lan/docs", Proxy: models.ProxyNginxProxy, Source: models.RouteSourceEnv, HostID: 1, HostName: "nas", ContainerName: "wiki", ContainerState: "exited", Status: models.RouteStatusStopped},
+		{URL: "https://jellyfin.example.com", Proxy: models.ProxyTraefik, Source: models.RouteSourceLabels, Router: "jellyfin", HostID: 1, HostName: "nas", ContainerName: "jellyfin", ContainerState: "running", Status: models.RouteStatusOK},
+		{URL: "https://wiki.example.com/docs", Proxy: models.ProxyNginxProxy, Source: models.RouteSourceEnv, HostID: 1, HostName: "nas", ContainerName: "wiki", ContainerState: "exited", Status: models.RouteStatusStopped},
+	}
+	if len(routes) != len(want) {
+		t.Fatalf("Expected %d routes, got %+v", len(want), routes)
+	}
+	for i := range want {
+		if routes[i] != want[i] {
+			t.Errorf("Route %d: expected %+v, got %+v", i, want[i], routes[i])
+		}
+	}
+}
+
+func TestMatchBackend(t *testing.T) {
+	containers := []Container{
+		{HostID: 1, HostName: "nas", HostAddress: "agent://nas.lan:9876", Name: "jellyfin", State: "running", IPs: []string{"172.18.0.4"},
+			Ports: []models.PortMapping{{PrivatePort: 8096, PublicPort: 8096, Type: "tcp"}}},
+		{HostID: 2, HostName: "vps", HostAddress: "tcp://10.0.0.9:2376", Name: "traefik", Image: "traefik:v3", State: "running"},
+		{HostID: 2, HostName: "vps", HostAddress: "tcp://10.0.0.9:2376", Name: "whoami", State: "running", IPs: []string{"172.18.0.4"}},
+	}
+
+	tests := []struct {
+		backend string
+		want    string
+		host    int64
+	}{
+		{"http://172.18.0.4:80", "whoami", 2}, // the same network address on both hosts: Traefik's host wins
+		{"http://jellyfin:8096", "jellyfin", 1},
+		{"http://nas.lan:8096", "jellyfin", 1},
+		{"http://nas.lan:9000", "", 0},
+		{"http://192.168.1.50:8080", "", 0},
+		{"", "", 0},
+	}
+	for _, tt := range tests {
+		c := MatchBackend(tt.backend, containers)
+		switch {
+		case tt.want == "" && c != nil:
+			t.Errorf("MatchBackend(%q) = %s, want no match", tt.backend, c.Name)
+		case tt.want != "" && (c == nil || c.Name != tt.want || c.HostID != tt.host):
+			t.Errorf("MatchBackend(%q) = %+v, want %s on host %d", tt.backend, c, tt.want, tt.host)
+		}
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/http/routers":
+			w.Write([]byte(`[
+				{"name":"jellyfin@docker","provider":"docker","rule":"Host(` + "`jellyfin.example.com`" + `)","service":"jellyfin","status":"enabled","tls":{"certResolver":"le"}},
+				{"name":"nas@file","provider":"file","rule":"Host(` + "`nas.example.com`" + `)","service":"nas","status":"enabled"},
+				{"name":"old@file","provider":"file","rule":"Host(` + "`old.example.com`" + `)","service":"old","status":"enabled"},
+				{"name":"api@internal","provider":"internal","rule":"PathPrefix(` + "`/api`" + `)","service":"api@internal","status":"enabled"}
+			]`))
+		case "/api/http/services":
+			w.Write([]byte(`[
+				{"name":"jellyfin@docker","loadBalancer":{"servers":[{"url":"http://172.18.0.4:8096"}]},"serverStatus":{"http://172.18.0.4:8096":"UP"}},
+				{"name":"nas@file","loadBalancer":{"servers":[{"url":"http://nas.lan:5000"}]},"serverStatus":{"http://nas.lan:5000":"DOWN"}},
+				{"name":"old@file","loadBalancer":{"servers":[{"url":"http://192.168.1.50:8080"}]}},
+				{"name":"api@internal"}
+			]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	containers := []Container{
+		{HostID: 1, HostName: "nas", HostAddress: "agent://nas.lan:9876", Name: "jellyfin", State: "running", IPs: []string{"172.18.0.4"},
+			Labels: map[string]string{"traefik.http.routers.jellyfin.rule": "Host(`jellyfin.example.com`)", "traefik.http.routers.jellyfin.tls": "true"}},
+		{HostID: 1, HostName: "nas", HostAddress: "agent://nas.lan:9876", Name: "dsm", State: "exited",
+			Ports: []models.PortMapping{{PrivatePort: 5000, PublicPort: 5000, Type: "tcp"}}},
+	}
+	settings := &models.TraefikSettings{Enabled: true, URL: server.URL, Username: "admin", Password: "secret"}
+
+	report := BuildReport(context.Background(), settings, containers, server.Client())
+	if len(report.Errors) != 0 {
+		t.Fatalf("Unexpected errors: %v", report.Errors)
+	}
+	if len(report.Routes) != 3 || report.Stopped != 1 || report.Unmatched != 1 {
+		t.Fatalf("Expected 3 routes with 1 stopped and 1 unmatched, got %+v", report)
+	}
+
+	byURL := make(map[string]models.ProxyRoute)
+	for _, r := range report.Routes {
+		byURL[r.URL] = r
+	}
+	// The labels' route is replaced by the API's, which knows the backend
+	if r := byURL["https://jellyfin.example.com"]; r.Source != models.RouteSourceTraefikAPI || r.ContainerName != "jellyfin" ||
+		r.BackendStatus != "UP" || r.Status != models.RouteStatusOK {
+		t.Errorf("Unexpected jellyfin route %+v", r)
+	}
+	if r := byURL["http://nas.example.com"]; r.ContainerName != "dsm" || r.Status != models.RouteStatusStopped || r.Router != "nas@file" {
+		t.Errorf("Unexpected nas route %+v", r)
+	}
+	if r := byURL["http://old.example.com"]; r.ContainerName != "" || r.Status != models.RouteStatusUnmatched || r.Backend != "http://192.168.1.50:8080" {
+		t.Errorf("Unexpected old route %+v", r)
+	}
+
+	settings.Password = "wrong"
+	report = BuildReport(context.Background(), settings, containers, server.Client())
+	if len(report.Errors) != 1 || len(report.Routes) != 1 {
+		t.Errorf("Expected an error and the labels' route when Traefik is unreachable, got %+v", report)
+	}
+}
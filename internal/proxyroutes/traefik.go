@@ -0,0 +1,148 @@
+package proxyroutes
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// Router is an HTTP router from Traefik's /api/http/routers
+type Router struct {
+	Name     string          `json:"name"` // e.g. jellyfin@docker
+	Provider string          `json:"provider"`
+	Rule     string          `json:"rule"`
+	Service  string          `json:"service"`
+	Status   string          `json:"status"` // enabled, disabled or warning
+	TLS      json.RawMessage `json:"tls,omitempty"`
+}
+
+// Service is an HTTP service from Traefik's /api/http/services
+type Service struct {
+	Name         string `json:"name"`
+	LoadBalancer *struct {
+		Servers []struct {
+			URL string `json:"url"`
+		} `json:"servers"`
+	} `json:"loadBalancer,omitempty"`
+	ServerStatus map[string]string `json:"serverStatus,omitempty"` // server URL -> UP or DOWN
+}
+
+// Client is a read-only client for Traefik's API
+type Client struct {
+	baseURL    string
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the Traefik API at the settings' URL
+func NewClient(settings *models.TraefikSettings, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if settings.SkipTLSVerify {
+			transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		}
+		httpClient = &http.Client{Timeout: 15 * time.Second, Transport: transport}
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(settings.URL, "/"),
+		username:   settings.Username,
+		password:   settings.Password,
+		httpClient: httpClient,
+	}
+}
+
+// Routers returns Traefik's HTTP routers
+func (c *Client) Routers(ctx context.Context) ([]Router, error) {
+	var routers []Router
+	return routers, c.get(ctx, "/api/http/routers?per_page=1000", &routers)
+}
+
+// Services returns Traefik's HTTP services
+func (c *Client) Services(ctx context.Context) ([]Service, error) {
+	var services []Service
+	return services, c.get(ctx, "/api/http/services?per_page=1000", &services)
+}
+
+func (c *Client) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Traefik: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Traefik returned %s for %s", resp.Status, path)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 16<<20)).Decode(out); err != nil {
+		return fmt.Errorf("invalid Traefik response for %s: %w", path, err)
+	}
+	return nil
+}
+
+// FromTraefik returns a route for each URL of Traefik's routers and each server of the router's
+// service, matched to the containers with MatchBackend. Traefik's internal routers (dashboard,
+// API) are left out.
+func FromTraefik(routers []Router, services []Service, containers []Container) []models.ProxyRoute {
+	byName := make(map[string]Service, len(services))
+	for _, s := range services {
+		byName[s.Name] = s
+	}
+
+	var routes []models.ProxyRoute
+	for _, router := range routers {
+		if router.Provider == "internal" || strings.HasSuffix(router.Service, "@internal") {
+			continue
+		}
+		serviceName := router.Service
+		if !strings.Contains(serviceName, "@") && router.Provider != "" {
+			serviceName += "@" + router.Provider
+		}
+		var servers []string
+		service := byName[serviceName]
+		if service.LoadBalancer != nil {
+			for _, s := range service.LoadBalancer.Servers {
+				servers = append(servers, s.URL)
+			}
+		}
+		sort.Strings(servers)
+		if len(servers) == 0 {
+			servers = []string{""}
+		}
+
+		tls := len(router.TLS) > 0 && string(router.TLS) != "null"
+		for _, u := range models.TraefikRuleURLs(router.Rule, tls) {
+			for _, server := range servers {
+				r := models.ProxyRoute{
+					URL:           u,
+					Proxy:         models.ProxyTraefik,
+					Source:        models.RouteSourceTraefikAPI,
+					Router:        router.Name,
+					Backend:       server,
+					BackendStatus: service.ServerStatus[server],
+				}
+				if c := MatchBackend(server, containers); c != nil {
+					r = containerRoute(*c, r)
+				}
+				routes = append(routes, r)
+			}
+		}
+	}
+	return routes
+}
@@ -0,0 +1,59 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// NormalizeMirror trims the scheme and trailing slashes from a mirror address,
+// e.g. "https://harbor.local/dockerhub/" becomes "harbor.local/dockerhub"
+func NormalizeMirror(mirror string) string {
+	mirror = strings.TrimSpace(mirror)
+	mirror = strings.TrimPrefix(mirror, "https://")
+	mirror = strings.TrimPrefix(mirror, "http://")
+	return strings.TrimRight(mirror, "/")
+}
+
+// MirrorReference rewrites a Docker Hub image reference to pull through a mirror such as a
+// Harbor proxy project. Images from other registries and digest references are not rewritten,
+// matching how the Docker daemon applies registry mirrors.
+func MirrorReference(mirror, imageName string) (string, bool) {
+	mirror = NormalizeMirror(mirror)
+	if mirror == "" || strings.Contains(imageName, "@") {
+		return "", false
+	}
+
+	registry, repository, tag, err := parseImageName(imageName)
+	if err != nil {
+		return "", false
+	}
+
+	switch registry {
+	case "registry-1.docker.io", "docker.io", "index.docker.io":
+	default:
+		return "", false
+	}
+	if !strings.Contains(repository, "/") {
+		repository = "library/" + repository
+	}
+
+	return fmt.Sprintf("%s/%s:%s", mirror, repository, tag), true
+}
+
+// CheckImageAvailable verifies that a registry serves the manifest of an image reference
+// and returns its digest. Used to validate a mirror before pulling through it.
+// The reference must include a registry host, which may carry a port.
+func (c *Client) CheckImageAvailable(ctx context.Context, imageRef string) (string, error) {
+	registry, path, found := strings.Cut(imageRef, "/")
+	if !found || path == "" {
+		return "", fmt.Errorf("image reference %q has no registry", imageRef)
+	}
+
+	repository, tag := path, "latest"
+	if i := strings.LastIndex(path, ":"); i > strings.LastIndex(path, "/") {
+		repository, tag = path[:i], path[i+1:]
+	}
+
+	return c.getImageDigest(ctx, registry, repository, tag)
+}
@@ -0,0 +1,28 @@
+package registry
+
+import "testing"
+
+func TestMirrorReference(t *testing.T) {
+	tests := []struct {
+		mirror string
+		image  string
+		want   string
+		ok     bool
+	}{
+		{"harbor.local/dockerhub", "nginx:1.25", "harbor.local/dockerhub/library/nginx:1.25", true},
+		{"https://harbor.local/dockerhub/", "nginx", "harbor.local/dockerhub/library/nginx:latest", true},
+		{"mirror.local:5000", "linuxserver/plex:latest", "mirror.local:5000/linuxserver/plex:latest", true},
+		{"mirror.local:5000", "docker.io/grafana/grafana:10.0.0", "mirror.local:5000/grafana/grafana:10.0.0", true},
+		{"mirror.local:5000", "docker.io/redis:7", "mirror.local:5000/library/redis:7", true},
+		{"mirror.local:5000", "ghcr.io/home-assistant/home-assistant:stable", "", false},
+		{"mirror.local:5000", "nginx@sha256:abc", "", false},
+		{"", "nginx:latest", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := MirrorReference(tt.mirror, tt.image)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("MirrorReference(%q, %q) = %q, %v; want %q, %v", tt.mirror, tt.image, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -9,8 +9,11 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
+	"github.com/container-census/container-census/internal/broker"
 	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/updatehooks"
 	imagetypes "github.com/docker/docker/api/types/image"
 )
 
@@ -22,10 +25,14 @@ type AgentClient struct {
 func isAgentHost(address string) bool {
 	return strings.HasPrefix(address, "agent://") ||
 	       strings.HasPrefix(address, "http://") ||
-	       strings.HasPrefix(address, "https://")
+	       strings.HasPrefix(address, "https://") ||
+	       broker.IsAddress(address)
 }
 
 func normalizeAgentURL(address string) string {
+	if broker.IsAddress(address) {
+		return strings.TrimSuffix(address, "/")
+	}
 	address = strings.TrimPrefix(address, "agent://")
 	if !strings.HasPrefix(address, "http://") && !strings.HasPrefix(address, "https://") {
 		address = "http://" + address
@@ -34,6 +41,11 @@ func normalizeAgentURL(address string) string {
 }
 
 func (s *Scanner) agentRequest(ctx context.Context, host models.Host, method, path string, body interface{}) (*http.Response, error) {
+	return s.agentRequestWithTimeout(ctx, host, method, path, body, s.timeout)
+}
+
+// agentRequestWithTimeout is agentRequest with a custom client timeout for long-running operations
+func (s *Scanner) agentRequestWithTimeout(ctx context.Context, host models.Host, method, path string, body interface{}, timeout time.Duration) (*http.Response, error) {
 	agentURL := normalizeAgentURL(host.Address) + path
 
 	var reqBody io.Reader
@@ -51,15 +63,30 @@ func (s *Scanner) agentRequest(ctx context.Context, host models.Host, method, pa
 	}
 
 	req.Header.Set("X-API-Token", host.AgentToken)
+	if scanID := ScanIDFrom(ctx); scanID != "" {
+		req.Header.Set(models.ScanIDHeader, scanID)
+	}
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
 
-	client := &http.Client{Timeout: s.timeout}
-	return client.Do(req)
+	return s.agentHTTPClient(host.Address, timeout).Do(req)
+}
+
+// agentHTTPClient returns the client for requests to an agent: agents on a broker are reached
+// through the scanner's broker transport
+func (s *Scanner) agentHTTPClient(address string, timeout time.Duration) *http.Client {
+	client := &http.Client{Timeout: timeout}
+	if broker.IsAddress(address) {
+		client.Transport = s.brokers
+	}
+	return client
 }
 
 func (s *Scanner) scanAgentHost(ctx context.Context, host models.Host) ([]models.Container, error) {
+	// Whatever the outcome, find out whether the agent or its Docker daemon is at fault
+	defer s.refreshAgentHealth(ScanIDFrom(ctx), host)
+
 	// Add stats query parameter if enabled for this host
 	path := "/api/containers"
 	if host.CollectStats {
@@ -173,6 +200,26 @@ func (s *Scanner) getAgentContainerLogs(ctx context.Context, host models.Host, c
 	return result["logs"], nil
 }
 
+func (s *Scanner) streamAgentContainerStats(ctx context.Context, host models.Host, containerID string, interval time.Duration, fn func(models.LiveContainerStats) error) error {
+	if broker.IsAddress(host.Address) {
+		return broker.ErrStreamingUnsupported
+	}
+
+	// No client timeout: the stream runs until ctx is cancelled
+	resp, err := s.agentRequestWithTimeout(ctx, host, "GET", "/api/containers/"+containerID+"/stats/live", nil, 0)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("agent error: %s", string(body))
+	}
+
+	return forEachLiveStatsSample(resp.Body, interval, fn)
+}
+
 func (s *Scanner) listAgentImages(ctx context.Context, host models.Host) ([]imagetypes.Summary, error) {
 	resp, err := s.agentRequest(ctx, host, "GET", "/api/images", nil)
 	if err != nil {
@@ -232,6 +279,81 @@ func (s *Scanner) pruneAgentImages(ctx context.Context, host models.Host) (uint6
 	return 0, nil
 }
 
+func (s *Scanner) getAgentImageLayers(ctx context.Context, host models.Host, imageID string) (*models.ImageLayers, error) {
+	resp, err := s.agentRequest(ctx, host, "GET", fmt.Sprintf("/api/images/%s/layers", imageID), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("agent does not support image layer inspection - please update your census-agent to the latest version")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("agent error: %s", string(body))
+	}
+
+	var layers models.ImageLayers
+	if err := json.NewDecoder(resp.Body).Decode(&layers); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	layers.HostID = host.ID
+	return &layers, nil
+}
+
+func (s *Scanner) auditAgentHost(ctx context.Context, host models.Host) (*models.ComplianceAudit, error) {
+	resp, err := s.agentRequest(ctx, host, "GET", "/api/compliance/audit", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("agent does not support compliance audits - please update your census-agent to the latest version")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("agent error: %s", string(body))
+	}
+
+	var audit models.ComplianceAudit
+	if err := json.NewDecoder(resp.Body).Decode(&audit); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &audit, nil
+}
+
+func (s *Scanner) rotateAgentToken(ctx context.Context, host models.Host, overlap time.Duration) (*models.RotatedAgentToken, error) {
+	body := map[string]int64{"overlap_seconds": int64(overlap / time.Second)}
+	resp, err := s.agentRequest(ctx, host, "POST", "/api/token/rotate", body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+		return nil, fmt.Errorf("the agent doesn't support token rotation; update it")
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("agent error: %s", string(body))
+	}
+
+	var rotated models.RotatedAgentToken
+	if err := json.NewDecoder(resp.Body).Decode(&rotated); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if rotated.Token == "" {
+		return nil, fmt.Errorf("the agent returned no token")
+	}
+	return &rotated, nil
+}
+
 func (s *Scanner) getAgentInfo(ctx context.Context, host models.Host) (*models.AgentInfo, error) {
 	resp, err := s.agentRequest(ctx, host, "GET", "/info", nil)
 	if err != nil {
@@ -274,13 +396,78 @@ func (s *Scanner) pullAgentImage(ctx context.Context, host models.Host, imageNam
 	return nil
 }
 
-func (s *Scanner) recreateAgentContainer(ctx context.Context, host models.Host, containerID string, dryRun bool) (*models.ContainerRecreateResult, error) {
+func (s *Scanner) tagAgentImage(ctx context.Context, host models.Host, source, target string) error {
+	body := map[string]string{"source": source, "target": target}
+	resp, err := s.agentRequest(ctx, host, "POST", "/api/images/tag", body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("agent does not support image tagging - please update your census-agent to the latest version")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("agent returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	return nil
+}
+
+// scanAgentImage runs a Trivy scan on an agent and returns its JSON report. supported is false
+// when the agent predates agent scanning or has no Trivy installed.
+func (s *Scanner) scanAgentImage(ctx context.Context, host models.Host, imageName string, timeout time.Duration) ([]byte, bool, error) {
+	body := map[string]interface{}{
+		"image":           imageName,
+		"timeout_seconds": int(timeout.Seconds()),
+	}
+	resp, err := s.agentRequestWithTimeout(ctx, host, "POST", "/api/vulnerabilities/scan", body, timeout+time.Minute)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to connect to agent: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusNotImplemented {
+		return nil, false, nil
+	}
+
+	output, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to read scan result: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(output, &errResp) == nil && errResp.Error != "" {
+			return nil, true, fmt.Errorf("%s", errResp.Error)
+		}
+		return nil, true, fmt.Errorf("agent returned status %d: %s", resp.StatusCode, string(output))
+	}
+
+	return output, true, nil
+}
+
+func (s *Scanner) recreateAgentContainer(ctx context.Context, host models.Host, containerID string, dryRun bool, hooks *models.UpdateHooks) (*models.ContainerRecreateResult, error) {
 	path := fmt.Sprintf("/api/containers/%s/recreate", containerID)
 	if dryRun {
 		path += "?dry_run=true"
 	}
 
-	resp, err := s.agentRequest(ctx, host, "POST", path, nil)
+	// Allow time for the agent to wait on the health check before responding
+	timeout := s.timeout
+	var body interface{}
+	if hooks != nil {
+		body = hooks
+		if hooks.WaitForHealthy {
+			timeout += updatehooks.HealthTimeout(hooks)
+		}
+	}
+
+	resp, err := s.agentRequestWithTimeout(ctx, host, "POST", path, body, timeout)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,82 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// agentHealthTimeout bounds the metrics request made after each agent scan
+const agentHealthTimeout = 5 * time.Second
+
+// refreshAgentHealth fetches an agent's metrics and keeps them for AgentHealth. Agents older
+// than the metrics endpoint report nothing.
+func (s *Scanner) refreshAgentHealth(scanID string, host models.Host) {
+	ctx, cancel := context.WithTimeout(WithScanID(context.Background(), scanID), agentHealthTimeout)
+	defer cancel()
+
+	health, err := s.fetchAgentHealth(ctx, host)
+	if err == errNoAgentMetrics {
+		s.agentHealthMu.Lock()
+		delete(s.agentHealth, host.ID)
+		s.agentHealthMu.Unlock()
+		return
+	}
+	if err != nil {
+		Logf(ctx, "Failed to get agent metrics of host %s: %v", host.Name, err)
+		health = &models.AgentHealth{Status: models.AgentHealthAgentError, Error: err.Error()}
+	} else if !health.DockerReachable {
+		health.Status = models.AgentHealthDockerError
+	} else {
+		health.Status = models.AgentHealthHealthy
+	}
+	health.CollectedAt = time.Now()
+	if health.System != nil {
+		health.System.CollectedAt = health.CollectedAt
+		s.recordHostSystem(host.ID, health.System)
+	}
+
+	s.agentHealthMu.Lock()
+	s.agentHealth[host.ID] = *health
+	s.agentHealthMu.Unlock()
+}
+
+// errNoAgentMetrics is returned for agents without the metrics endpoint
+var errNoAgentMetrics = errors.New("agent has no metrics endpoint")
+
+func (s *Scanner) fetchAgentHealth(ctx context.Context, host models.Host) (*models.AgentHealth, error) {
+	resp, err := s.agentRequestWithTimeout(ctx, host, "GET", "/api/metrics", nil, agentHealthTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to agent: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errNoAgentMetrics
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("agent returned status %d", resp.StatusCode)
+	}
+
+	var health models.AgentHealth
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return nil, fmt.Errorf("failed to decode agent metrics: %w", err)
+	}
+	return &health, nil
+}
+
+// AgentHealth returns the health an agent reported after its latest scan, or nil
+func (s *Scanner) AgentHealth(hostID int64) *models.AgentHealth {
+	s.agentHealthMu.RLock()
+	defer s.agentHealthMu.RUnlock()
+	health, ok := s.agentHealth[hostID]
+	if !ok {
+		return nil
+	}
+	return &health
+}
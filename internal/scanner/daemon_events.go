@@ -0,0 +1,45 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// daemonEventsTimeout bounds the daemon events request made after each agent scan
+const daemonEventsTimeout = 5 * time.Second
+
+// FetchDaemonEvents returns the OOM kills and daemon errors an agent read from its host's logs at
+// or after since. Agents without -daemon-logs, or older than the endpoint, return none.
+func (s *Scanner) FetchDaemonEvents(ctx context.Context, host models.Host, since time.Time) ([]models.DaemonEvent, error) {
+	path := "/api/daemon-events"
+	if !since.IsZero() {
+		path += "?since=" + url.QueryEscape(since.Format(time.RFC3339Nano))
+	}
+	resp, err := s.agentRequestWithTimeout(ctx, host, "GET", path, nil, daemonEventsTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to agent: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("agent returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Source string               `json:"source"`
+		Events []models.DaemonEvent `json:"events"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode daemon events: %w", err)
+	}
+	return result.Events, nil
+}
@@ -0,0 +1,63 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/container-census/container-census/internal/incus"
+	"github.com/container-census/container-census/internal/inspect"
+	"github.com/container-census/container-census/internal/models"
+)
+
+// ListDockerObjects returns the plugins, Swarm secrets and configs, and builder cache of a host.
+// Incus hosts, and agents older than the objects endpoint, have none.
+func (s *Scanner) ListDockerObjects(ctx context.Context, host models.Host) ([]models.DockerObject, error) {
+	if incus.IsAddress(host.Address) {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var objects []models.DockerObject
+	if isAgentHost(host.Address) {
+		resp, err := s.agentRequest(ctx, host, "GET", "/api/objects", nil)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			return nil, fmt.Errorf("agent error: %s", string(body))
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&objects); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+	} else {
+		dockerClient, err := s.createClient(host.Address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create docker client: %w", err)
+		}
+		defer dockerClient.Close()
+
+		objects, err = inspect.DockerObjects(ctx, dockerClient)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	for i := range objects {
+		objects[i].HostID = host.ID
+		objects[i].HostName = host.Name
+		objects[i].ScannedAt = now
+	}
+	return objects, nil
+}
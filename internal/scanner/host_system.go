@@ -0,0 +1,51 @@
+package scanner
+
+import (
+	"context"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/docker/docker/client"
+)
+
+// recordHostSystem keeps a host's engine and OS report for HostSystem
+func (s *Scanner) recordHostSystem(hostID int64, system *models.HostSystem) {
+	s.agentHealthMu.Lock()
+	defer s.agentHealthMu.Unlock()
+	if system == nil {
+		delete(s.hostSystems, hostID)
+		return
+	}
+	s.hostSystems[hostID] = *system
+}
+
+// refreshDirectHostSystem asks a Docker host scanned over its API for its engine and OS versions.
+// Pending reboots and daemon restarts can only be seen by an agent on the host.
+func (s *Scanner) refreshDirectHostSystem(ctx context.Context, host models.Host, dockerClient *client.Client) {
+	info, err := dockerClient.Info(ctx)
+	if err != nil {
+		Logf(ctx, "Failed to get Docker info of host %s: %v", host.Name, err)
+		return
+	}
+	system := &models.HostSystem{
+		DockerVersion:   info.ServerVersion,
+		OperatingSystem: info.OperatingSystem,
+		KernelVersion:   info.KernelVersion,
+		CollectedAt:     time.Now(),
+	}
+	if version, err := dockerClient.ServerVersion(ctx); err == nil {
+		system.APIVersion = version.APIVersion
+	}
+	s.recordHostSystem(host.ID, system)
+}
+
+// HostSystem returns the engine and OS report of a host from its latest scan, or nil
+func (s *Scanner) HostSystem(hostID int64) *models.HostSystem {
+	s.agentHealthMu.RLock()
+	defer s.agentHealthMu.RUnlock()
+	system, ok := s.hostSystems[hostID]
+	if !ok {
+		return nil
+	}
+	return &system
+}
@@ -0,0 +1,228 @@
+package scanner
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/container-census/container-census/internal/incus"
+	"github.com/container-census/container-census/internal/models"
+)
+
+// SetIncusCertificate sets the client certificate presented to Incus and LXD hosts
+func (s *Scanner) SetIncusCertificate(cert *tls.Certificate) {
+	s.incusCert = cert
+}
+
+// IncusCertificate returns the client certificate presented to Incus and LXD hosts, if any
+func (s *Scanner) IncusCertificate() *tls.Certificate {
+	return s.incusCert
+}
+
+func (s *Scanner) incusClient(address string) (*incus.Client, error) {
+	return incus.NewClient(address, s.incusCert, s.timeout)
+}
+
+// scanIncusHost lists the system containers of an Incus or LXD host. Virtual machines are left
+// out. Instances have no registry image, so they are never checked for updates or scanned for
+// vulnerabilities.
+func (s *Scanner) scanIncusHost(ctx context.Context, host models.Host) ([]models.Container, error) {
+	c, err := s.incusClient(host.Address)
+	if err != nil {
+		return nil, err
+	}
+	instances, err := c.Instances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	result := make([]models.Container, 0, len(instances))
+	for _, instance := range instances {
+		if instance.Type != "" && instance.Type != "container" {
+			continue
+		}
+		container := incusContainer(instance, host.CollectStats)
+		container.HostID = host.ID
+		container.HostName = host.Name
+		container.ScannedAt = now
+		result = append(result, container)
+	}
+	return result, nil
+}
+
+// incusContainer converts an Incus instance to a container. Labels come from user.* config
+// keys, networks and mounts from nic and disk devices, and published ports from proxy devices.
+func incusContainer(instance incus.Instance, collectStats bool) models.Container {
+	config := instance.ExpandedConfig
+	image := config["image.description"]
+	if image == "" {
+		image = strings.TrimSpace(config["image.os"] + " " + config["image.release"])
+	}
+
+	container := models.Container{
+		ID:      instance.Name,
+		Name:    instance.Name,
+		Image:   image,
+		State:   incusState(instance.Status),
+		Status:  instance.Status,
+		Ports:   make([]models.PortMapping, 0),
+		Labels:  make(map[string]string),
+		Created: instance.CreatedAt,
+	}
+	if instance.Location != "" && instance.Location != "none" {
+		container.Status += " on " + instance.Location
+	}
+
+	for key, value := range config {
+		if label, ok := strings.CutPrefix(key, "user."); ok {
+			container.Labels[label] = value
+		}
+	}
+
+	deviceNames := make([]string, 0, len(instance.ExpandedDevices))
+	for name := range instance.ExpandedDevices {
+		deviceNames = append(deviceNames, name)
+	}
+	sort.Strings(deviceNames)
+	for _, name := range deviceNames {
+		device := instance.ExpandedDevices[name]
+		switch device["type"] {
+		case "nic":
+			if network := device["network"]; network != "" {
+				container.Networks = append(container.Networks, network)
+			} else if parent := device["parent"]; parent != "" {
+				container.Networks = append(container.Networks, parent)
+			}
+		case "disk":
+			if device["path"] == "/" {
+				continue // the root disk
+			}
+			source := device["source"]
+			mountType := "bind"
+			if device["pool"] != "" {
+				mountType = "volume"
+				source = device["pool"] + "/" + source
+			}
+			container.Volumes = append(container.Volumes, models.VolumeMount{
+				Name:        source,
+				Destination: device["path"],
+				Type:        mountType,
+				RW:          device["readonly"] != "true",
+			})
+		case "proxy":
+			if port, ok := incusProxyPort(device["listen"], device["connect"]); ok {
+				container.Ports = append(container.Ports, port)
+			}
+		}
+	}
+
+	if collectStats && instance.State != nil && container.State == "running" {
+		container.MemoryUsage = instance.State.Memory.Usage
+		container.MemoryLimit = instance.State.Memory.Total
+		if limit, ok := parseIncusMemory(config["limits.memory"]); ok {
+			container.MemoryLimit = limit
+		}
+		if container.MemoryLimit > 0 {
+			container.MemoryPercent = float64(container.MemoryUsage) / float64(container.MemoryLimit) * 100.0
+		}
+	}
+
+	return container
+}
+
+// incusState maps instance statuses to Docker container states
+func incusState(status string) string {
+	switch status {
+	case "Running":
+		return "running"
+	case "Stopped":
+		return "exited"
+	case "Frozen":
+		return "paused"
+	case "Error":
+		return "dead"
+	default:
+		return strings.ToLower(status)
+	}
+}
+
+// incusProxyPort converts a proxy device, e.g. listen=tcp:0.0.0.0:8080 connect=tcp:127.0.0.1:80,
+// to a published port. Port ranges and unix sockets are skipped.
+func incusProxyPort(listen, connect string) (models.PortMapping, bool) {
+	listenProto, listenAddr, ok := strings.Cut(listen, ":")
+	if !ok {
+		return models.PortMapping{}, false
+	}
+	_, connectAddr, ok := strings.Cut(connect, ":")
+	if !ok || (listenProto != "tcp" && listenProto != "udp") {
+		return models.PortMapping{}, false
+	}
+
+	ip, publicPort, err := net.SplitHostPort(listenAddr)
+	if err != nil {
+		return models.PortMapping{}, false
+	}
+	_, privatePort, err := net.SplitHostPort(connectAddr)
+	if err != nil {
+		return models.PortMapping{}, false
+	}
+	public, err1 := strconv.Atoi(publicPort)
+	private, err2 := strconv.Atoi(privatePort)
+	if err1 != nil || err2 != nil {
+		return models.PortMapping{}, false
+	}
+	return models.PortMapping{PrivatePort: private, PublicPort: public, Type: listenProto, IP: ip}, true
+}
+
+// parseIncusMemory parses a limits.memory value such as 512MiB, 2GB or 1073741824. Percentages
+// of the host's memory are not resolved.
+func parseIncusMemory(value string) (int64, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.HasSuffix(value, "%") {
+		return 0, false
+	}
+
+	units := []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30}, {"TiB", 1 << 40},
+		{"kB", 1000}, {"MB", 1000 * 1000}, {"GB", 1000 * 1000 * 1000}, {"TB", 1000 * 1000 * 1000 * 1000},
+		{"B", 1},
+	}
+	multiplier := int64(1)
+	for _, unit := range units {
+		if number, ok := strings.CutSuffix(value, unit.suffix); ok {
+			value, multiplier = number, unit.multiplier
+			break
+		}
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n * multiplier, true
+}
+
+// changeIncusState starts, stops or restarts an instance. Starting a frozen instance resumes it.
+func (s *Scanner) changeIncusState(ctx context.Context, host models.Host, name, action string, timeout int) error {
+	c, err := s.incusClient(host.Address)
+	if err != nil {
+		return err
+	}
+	if action == "start" {
+		if instance, err := c.Instance(ctx, name); err == nil && instance.Status == "Frozen" {
+			action = "unfreeze"
+		}
+	}
+	if err := c.ChangeState(ctx, name, action, timeout, false); err != nil {
+		return fmt.Errorf("failed to %s instance: %w", action, err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/container-census/container-census/internal/incus"
+)
+
+func TestIncusContainer(t *testing.T) {
+	instance := incus.Instance{
+		Name:   "pihole",
+		Status: "Running",
+		Type:   "container",
+		ExpandedConfig: map[string]string{
+			"image.description": "Debian bookworm amd64 (20250601_05:24)",
+			"limits.memory":     "512MiB",
+			"user.owner":        "dns-team",
+			"volatile.uuid":     "abc",
+		},
+		ExpandedDevices: map[string]map[string]string{
+			"root":  {"type": "disk", "path": "/", "pool": "default"},
+			"data":  {"type": "disk", "path": "/etc/pihole", "pool": "default", "source": "pihole-data"},
+			"media": {"type": "disk", "path": "/media", "source": "/srv/media", "readonly": "true"},
+			"eth0":  {"type": "nic", "network": "incusbr0"},
+			"dns":   {"type": "proxy", "listen": "udp:0.0.0.0:53", "connect": "udp:127.0.0.1:53"},
+			"web":   {"type": "proxy", "listen": "tcp:[::]:8080", "connect": "tcp:127.0.0.1:80"},
+			"sock":  {"type": "proxy", "listen": "unix:/run/app.sock", "connect": "unix:/run/app.sock"},
+		},
+		State: &incus.InstanceState{},
+	}
+	instance.State.Memory.Usage = 128 << 20
+
+	c := incusContainer(instance, true)
+	if c.ID != "pihole" || c.State != "running" || c.Image != "Debian bookworm amd64 (20250601_05:24)" || c.ImageID != "" {
+		t.Errorf("Unexpected container %+v", c)
+	}
+	if len(c.Labels) != 1 || c.Labels["owner"] != "dns-team" {
+		t.Errorf("Expected only user.* keys as labels, got %v", c.Labels)
+	}
+	if len(c.Networks) != 1 || c.Networks[0] != "incusbr0" {
+		t.Errorf("Unexpected networks %v", c.Networks)
+	}
+	if len(c.Volumes) != 2 || c.Volumes[0].Name != "default/pihole-data" || c.Volumes[0].Type != "volume" ||
+		c.Volumes[1].Name != "/srv/media" || c.Volumes[1].RW {
+		t.Errorf("Unexpected volumes %+v", c.Volumes)
+	}
+	if len(c.Ports) != 2 || c.Ports[0].PublicPort != 53 || c.Ports[0].Type != "udp" || c.Ports[1].IP != "::" || c.Ports[1].PrivatePort != 80 {
+		t.Errorf("Unexpected ports %+v", c.Ports)
+	}
+	if c.MemoryLimit != 512<<20 || c.MemoryPercent != 25 {
+		t.Errorf("Expected memory against limits.memory, got %d/%d (%.1f%%)", c.MemoryUsage, c.MemoryLimit, c.MemoryPercent)
+	}
+
+	// No stats unless the host collects them
+	if c := incusContainer(instance, false); c.MemoryUsage != 0 {
+		t.Errorf("Expected no stats, got %d", c.MemoryUsage)
+	}
+
+	instance.Status = "Frozen"
+	if c := incusContainer(instance, true); c.State != "paused" || c.MemoryUsage != 0 {
+		t.Errorf("Expected a paused container without stats, got %s", c.State)
+	}
+}
+
+func TestParseIncusMemory(t *testing.T) {
+	tests := map[string]int64{"1073741824": 1 << 30, "2GiB": 2 << 30, "500MB": 500e6, "1kB": 1000, "50%": 0, "": 0, "lots": 0}
+	for value, want := range tests {
+		if got, _ := parseIncusMemory(value); got != want {
+			t.Errorf("parseIncusMemory(%q) = %d, want %d", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+//go:build integration
+
+package scanner
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/container-census/container-census/internal/integration"
+	"github.com/container-census/container-census/internal/models"
+)
+
+// scanOwned scans a host and returns the harness' containers by name
+func scanOwned(t *testing.T, s *Scanner, h *integration.Harness, host models.Host) map[string]models.Container {
+	t.Helper()
+	containers, err := s.ScanHost(context.Background(), host)
+	if err != nil {
+		t.Fatalf("Scan of %s failed: %v", host.Name, err)
+	}
+	owned := make(map[string]models.Container)
+	for _, c := range containers {
+		if h.Owns(c) {
+			owned[c.Name] = c
+		}
+	}
+	return owned
+}
+
+func TestIntegrationScan(t *testing.T) {
+	h := integration.New(t)
+	running := h.Run(integration.ContainerOptions{Name: "web", Labels: map[string]string{"com.example.role": "web"}})
+	stopped := h.Run(integration.ContainerOptions{Name: "job", Stop: true})
+
+	s := New(30)
+	for _, host := range []models.Host{h.Host(), h.StartAgent("integration-token")} {
+		t.Run(host.HostType, func(t *testing.T) {
+			owned := scanOwned(t, s, h, host)
+			web, ok := owned[running.Name]
+			if !ok {
+				t.Fatalf("Expected %s in the scan, got %v", running.Name, owned)
+			}
+			if web.State != "running" || web.Labels["com.example.role"] != "web" || !strings.HasPrefix(web.Image, strings.Split(h.Image(), ":")[0]) {
+				t.Errorf("Unexpected container %+v", web)
+			}
+			if web.Created.IsZero() || web.ScannedAt.Before(web.Created) {
+				t.Errorf("Expected the creation before the scan, got created %v scanned %v", web.Created, web.ScannedAt)
+			}
+			if web.MemoryUsage <= 0 {
+				t.Errorf("Expected memory stats for a running container on a host collecting stats, got %d", web.MemoryUsage)
+			}
+			if job, ok := owned[stopped.Name]; !ok || job.State != "exited" {
+				t.Errorf("Expected %s exited in the scan, got %+v", stopped.Name, job)
+			}
+		})
+	}
+}
+
+func TestIntegrationAgentToken(t *testing.T) {
+	h := integration.New(t)
+	host := h.StartAgent("integration-token")
+	host.AgentToken = "wrong"
+	if _, err := New(30).ScanHost(context.Background(), host); err == nil {
+		t.Error("Expected the agent to reject a scan with the wrong token")
+	}
+}
+
+func TestIntegrationAgentPermissions(t *testing.T) {
+	h := integration.New(t)
+	c := h.Run(integration.ContainerOptions{Name: "guarded"})
+	host := h.StartAgent("integration-token", models.AgentPermissionLifecycle)
+	s := New(30)
+	ctx := context.Background()
+
+	if err := s.RemoveContainer(ctx, host, c.ID, true); err == nil {
+		t.Error("Expected the agent to refuse removing without the remove permission")
+	}
+	if err := s.StopContainer(ctx, host, c.ID, 0); err != nil {
+		t.Fatalf("Expected the agent to stop the container: %v", err)
+	}
+	if state := h.State(c); state != "exited" {
+		t.Errorf("Expected the container exited, got %s", state)
+	}
+}
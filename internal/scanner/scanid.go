@@ -0,0 +1,53 @@
+package scanner
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+)
+
+type scanIDKey struct{}
+
+type quietKey struct{}
+
+// NewScanID returns a new ID for one scan of one host
+func NewScanID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// WithScanID returns a context carrying the scan ID; ScanHost tags the containers it returns
+// with it and sends it to agents, and log lines about the scan include it
+func WithScanID(ctx context.Context, scanID string) context.Context {
+	return context.WithValue(ctx, scanIDKey{}, scanID)
+}
+
+// ScanIDFrom returns the scan ID carried by the context, if any
+func ScanIDFrom(ctx context.Context) string {
+	scanID, _ := ctx.Value(scanIDKey{}).(string)
+	return scanID
+}
+
+// WithQuietLog returns a context whose scans don't log, for scans repeated every few seconds
+// that nobody reads the log of
+func WithQuietLog(ctx context.Context) context.Context {
+	return context.WithValue(ctx, quietKey{}, true)
+}
+
+// Logf logs like log.Printf, prefixed with the context's scan ID if it has one. Nothing is logged
+// for a context of WithQuietLog.
+func Logf(ctx context.Context, format string, args ...interface{}) {
+	if quiet, _ := ctx.Value(quietKey{}).(bool); quiet {
+		return
+	}
+	if scanID := ScanIDFrom(ctx); scanID != "" {
+		format = "[scan " + scanID + "] " + format
+	}
+	log.Printf(format, args...)
+}
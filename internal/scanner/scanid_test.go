@@ -0,0 +1,63 @@
+package scanner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestScanIDReachesAgent(t *testing.T) {
+	var mu sync.Mutex
+	seen := make(map[string]string) // path -> scan ID header
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen[r.URL.Path] = r.Header.Get(models.ScanIDHeader)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/api/containers":
+			w.Write([]byte(`[{"id": "abc", "name": "web", "state": "running"}]`))
+		case "/api/metrics":
+			w.Write([]byte(`{"version": "1.0.0", "docker_reachable": false, "last_docker_error": "ping: Cannot connect to the Docker daemon"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer agent.Close()
+
+	s := New(5)
+	host := models.Host{ID: 7, Name: "pi", Address: "agent://" + strings.TrimPrefix(agent.URL, "http://"), HostType: "agent"}
+	scanID := NewScanID()
+	if len(scanID) != 16 || scanID == NewScanID() {
+		t.Fatalf("Expected a random 16 character scan ID, got %q", scanID)
+	}
+
+	containers, err := s.ScanHost(WithScanID(context.Background(), scanID), host)
+	if err != nil {
+		t.Fatalf("ScanHost failed: %v", err)
+	}
+	if len(containers) != 1 || containers[0].ScanID != scanID || containers[0].HostID != 7 {
+		t.Errorf("Expected the container tagged with the scan ID, got %+v", containers)
+	}
+
+	mu.Lock()
+	if seen["/api/containers"] != scanID || seen["/api/metrics"] != scanID {
+		t.Errorf("Expected the scan ID on the container and metrics requests, got %v", seen)
+	}
+	mu.Unlock()
+
+	health := s.AgentHealth(7)
+	if health == nil || health.Status != models.AgentHealthDockerError {
+		t.Errorf("Expected the agent to report a Docker error, got %+v", health)
+	}
+
+	// Without a scan ID nothing is tagged
+	containers, err = s.ScanHost(context.Background(), host)
+	if err != nil || len(containers) != 1 || containers[0].ScanID != "" {
+		t.Errorf("Expected an untagged container, got %+v (%v)", containers, err)
+	}
+}
@@ -2,7 +2,9 @@ package scanner
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -11,7 +13,13 @@ import (
 	"sync"
 	"time"
 
+	"github.com/container-census/container-census/internal/broker"
+	"github.com/container-census/container-census/internal/compliance"
+	"github.com/container-census/container-census/internal/containerstats"
+	"github.com/container-census/container-census/internal/incus"
+	"github.com/container-census/container-census/internal/inspect"
 	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/updatehooks"
 	containertypes "github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
 	imagetypes "github.com/docker/docker/api/types/image"
@@ -20,18 +28,38 @@ import (
 
 // Scanner handles Docker host scanning
 type Scanner struct {
-	timeout time.Duration
+	timeout   time.Duration
+	incusCert *tls.Certificate  // client certificate for Incus/LXD hosts
+	brokers   *broker.Transport // requests to agents on NATS or MQTT brokers
+
+	agentHealthMu sync.RWMutex
+	agentHealth   map[int64]models.AgentHealth // by host ID, refreshed after each agent scan
+	hostSystems   map[int64]models.HostSystem  // by host ID, engine and OS reports of the latest scans
 }
 
 // New creates a new Scanner
 func New(timeoutSeconds int) *Scanner {
 	return &Scanner{
-		timeout: time.Duration(timeoutSeconds) * time.Second,
+		timeout:     time.Duration(timeoutSeconds) * time.Second,
+		brokers:     broker.NewTransport(),
+		agentHealth: make(map[int64]models.AgentHealth),
+		hostSystems: make(map[int64]models.HostSystem),
 	}
 }
 
-// ScanHost scans a single Docker host and returns containers
+// ScanHost scans a single Docker host and returns containers. With a scan ID in the context
+// (WithScanID) the containers are tagged with it.
 func (s *Scanner) ScanHost(ctx context.Context, host models.Host) ([]models.Container, error) {
+	containers, err := s.scanHost(ctx, host)
+	if scanID := ScanIDFrom(ctx); scanID != "" {
+		for i := range containers {
+			containers[i].ScanID = scanID
+		}
+	}
+	return containers, err
+}
+
+func (s *Scanner) scanHost(ctx context.Context, host models.Host) ([]models.Container, error) {
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
@@ -40,6 +68,9 @@ func (s *Scanner) ScanHost(ctx context.Context, host models.Host) ([]models.Cont
 	if isAgentHost(host.Address) {
 		return s.scanAgentHost(ctx, host)
 	}
+	if incus.IsAddress(host.Address) {
+		return s.scanIncusHost(ctx, host)
+	}
 
 	// Create Docker client
 	dockerClient, err := s.createClient(host.Address)
@@ -55,6 +86,7 @@ func (s *Scanner) ScanHost(ctx context.Context, host models.Host) ([]models.Cont
 	if err != nil {
 		return nil, fmt.Errorf("failed to list containers: %w", err)
 	}
+	s.refreshDirectHostSystem(ctx, host, dockerClient)
 
 	// Get image information for size data and version labels
 	imageMap := make(map[string]int64)     // imageID -> size
@@ -126,6 +158,8 @@ func (s *Scanner) ScanHost(ctx context.Context, host models.Host) ([]models.Cont
 		var volumes []models.VolumeMount
 		var links []string
 		var composeProject string
+		var envEndpoints []string
+		var config *models.ContainerConfig
 
 		containerJSON, err := dockerClient.ContainerInspect(ctx, c.ID)
 		if err == nil {
@@ -166,6 +200,16 @@ func (s *Scanner) ScanHost(ctx context.Context, host models.Host) ([]models.Cont
 					composeProject = project
 				}
 			}
+
+			// Extract cross-host endpoint references from environment variables
+			if containerJSON.Config != nil {
+				envEndpoints = models.ExtractEnvEndpoints(containerJSON.Config.Env)
+			}
+
+			// Capture sanitized configuration for the inspect view
+			config = inspect.Sanitize(containerJSON)
+		} else {
+			Logf(ctx, "Failed to inspect container %s on host %s: %v", name, host.Name, err)
 		}
 
 		container := models.Container{
@@ -188,6 +232,12 @@ func (s *Scanner) ScanHost(ctx context.Context, host models.Host) ([]models.Cont
 			Volumes:        volumes,
 			Links:          links,
 			ComposeProject: composeProject,
+			EnvEndpoints:   envEndpoints,
+			Config:         config,
+		}
+
+		if err != nil {
+			container.AddCollectionWarning(models.CollectionStageInspect, fmt.Errorf("inspect failed: %w", err))
 		}
 
 		result = append(result, container)
@@ -199,7 +249,7 @@ func (s *Scanner) ScanHost(ctx context.Context, host models.Host) ([]models.Cont
 		var mu sync.Mutex
 
 		for i := range result {
-			if result[i].State != "running" {
+			if result[i].State != "running" || models.StatsDisabledByLabels(result[i].Labels) {
 				continue
 			}
 
@@ -213,7 +263,10 @@ func (s *Scanner) ScanHost(ctx context.Context, host models.Host) ([]models.Cont
 				// Use streaming stats to get two samples
 				statsStream, err := dockerClient.ContainerStats(ctx, containerID, true)
 				if err != nil {
-					log.Printf("Failed to collect stats for container %s on host %s: %v", containerName, host.Name, err)
+					Logf(ctx, "Failed to collect stats for container %s on host %s: %v", containerName, host.Name, err)
+					mu.Lock()
+					result[idx].AddCollectionWarning(models.CollectionStageStats, fmt.Errorf("stats call failed: %w", err))
+					mu.Unlock()
 					return
 				}
 				defer statsStream.Body.Close()
@@ -222,14 +275,20 @@ func (s *Scanner) ScanHost(ctx context.Context, host models.Host) ([]models.Cont
 				var baseline containertypes.StatsResponse
 				decoder := json.NewDecoder(statsStream.Body)
 				if err := decoder.Decode(&baseline); err != nil {
-					log.Printf("Failed to decode first sample for container %s on host %s: %v", containerName, host.Name, err)
+					Logf(ctx, "Failed to decode first sample for container %s on host %s: %v", containerName, host.Name, err)
+					mu.Lock()
+					result[idx].AddCollectionWarning(models.CollectionStageStats, fmt.Errorf("reading the first stats sample failed: %w", err))
+					mu.Unlock()
 					return
 				}
 
 				// Read second sample (current)
 				var current containertypes.StatsResponse
 				if err := decoder.Decode(&current); err != nil {
-					log.Printf("Failed to decode second sample for container %s on host %s: %v", containerName, host.Name, err)
+					Logf(ctx, "Failed to decode second sample for container %s on host %s: %v", containerName, host.Name, err)
+					mu.Lock()
+					result[idx].AddCollectionWarning(models.CollectionStageStats, fmt.Errorf("reading the second stats sample failed: %w", err))
+					mu.Unlock()
 					return
 				}
 
@@ -248,7 +307,7 @@ func (s *Scanner) ScanHost(ctx context.Context, host models.Host) ([]models.Cont
 				}
 
 				// Debug logging for CPU calculation
-				log.Printf("DEBUG %s: cpuDelta=%.0f, systemDelta=%.0f, numCPUs=%d, OnlineCPUs=%d, PercpuLen=%d",
+				Logf(ctx, "DEBUG %s: cpuDelta=%.0f, systemDelta=%.0f, numCPUs=%d, OnlineCPUs=%d, PercpuLen=%d",
 					containerName, cpuDelta, systemDelta, numCPUs,
 					current.CPUStats.OnlineCPUs, len(current.CPUStats.CPUUsage.PercpuUsage))
 
@@ -266,15 +325,25 @@ func (s *Scanner) ScanHost(ctx context.Context, host models.Host) ([]models.Cont
 				}
 
 				// Debug logging
-				log.Printf("Stats collected for %s on %s: CPU=%.2f%%, Memory=%dMB/%dMB (%.1f%%)",
+				Logf(ctx, "Stats collected for %s on %s: CPU=%.2f%%, Memory=%dMB/%dMB (%.1f%%)",
 					containerName, host.Name, cpuPercent, memoryUsage/1024/1024, memoryLimit/1024/1024, memoryPercent)
 
+				// Network and block I/O rates from the same two samples
+				rxRate, txRate, readRate, writeRate := containerstats.IORates(baseline, current)
+
 				// Update the container in the result slice (thread-safe)
 				mu.Lock()
 				result[idx].CPUPercent = cpuPercent
 				result[idx].MemoryUsage = memoryUsage
 				result[idx].MemoryLimit = memoryLimit
 				result[idx].MemoryPercent = memoryPercent
+				result[idx].NetworkRxRate = rxRate
+				result[idx].NetworkTxRate = txRate
+				result[idx].BlockReadRate = readRate
+				result[idx].BlockWriteRate = writeRate
+				if memoryUsage == 0 && memoryLimit == 0 {
+					result[idx].AddCollectionWarning(models.CollectionStageZeroStats, errors.New("Docker reported zero memory usage and limit; the memory cgroup can't be read (run an agent on the host for its cgroup fallback)"))
+				}
 				mu.Unlock()
 			}(i)
 		}
@@ -345,6 +414,9 @@ func (s *Scanner) createClient(address string) (*client.Client, error) {
 			client.WithHost(address),
 			client.WithAPIVersionNegotiation(),
 		)
+	case incus.IsAddress(address):
+		// Incus hosts only support scans and start/stop/restart
+		return nil, fmt.Errorf("not supported on Incus/LXD hosts")
 	default:
 		return nil, fmt.Errorf("unsupported address format: %s", address)
 	}
@@ -359,6 +431,13 @@ func (s *Scanner) VerifyConnection(ctx context.Context, address string) error {
 	if isAgentHost(address) {
 		return s.verifyAgentConnection(ctx, address)
 	}
+	if incus.IsAddress(address) {
+		c, err := s.incusClient(address)
+		if err != nil {
+			return err
+		}
+		return c.Ping(ctx)
+	}
 
 	dockerClient, err := s.createClient(address)
 	if err != nil {
@@ -379,8 +458,7 @@ func (s *Scanner) verifyAgentConnection(ctx context.Context, address string) err
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := s.agentHTTPClient(address, 10*time.Second).Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to connect to agent: %w", err)
 	}
@@ -432,6 +510,9 @@ func (s *Scanner) StartContainer(ctx context.Context, host models.Host, containe
 		}
 		return nil
 	}
+	if incus.IsAddress(host.Address) {
+		return s.changeIncusState(ctx, host, containerID, "start", 0)
+	}
 
 	dockerClient, err := s.createClient(host.Address)
 	if err != nil {
@@ -447,6 +528,9 @@ func (s *Scanner) StopContainer(ctx context.Context, host models.Host, container
 	if isAgentHost(host.Address) {
 		return s.stopAgentContainer(ctx, host, containerID, timeout)
 	}
+	if incus.IsAddress(host.Address) {
+		return s.changeIncusState(ctx, host, containerID, "stop", timeout)
+	}
 
 	dockerClient, err := s.createClient(host.Address)
 	if err != nil {
@@ -465,6 +549,9 @@ func (s *Scanner) RestartContainer(ctx context.Context, host models.Host, contai
 	if isAgentHost(host.Address) {
 		return s.restartAgentContainer(ctx, host, containerID, timeout)
 	}
+	if incus.IsAddress(host.Address) {
+		return s.changeIncusState(ctx, host, containerID, "restart", timeout)
+	}
 
 	dockerClient, err := s.createClient(host.Address)
 	if err != nil {
@@ -533,6 +620,93 @@ func (s *Scanner) GetContainerLogs(ctx context.Context, host models.Host, contai
 	return string(buf), nil
 }
 
+// StreamContainerStats streams real-time stats for a single container until ctx is cancelled,
+// calling fn at most once per interval. Works regardless of the host's collect_stats setting
+// or the container's stats opt-out label, since it only runs while someone is watching.
+func (s *Scanner) StreamContainerStats(ctx context.Context, host models.Host, containerID string, interval time.Duration, fn func(models.LiveContainerStats) error) error {
+	if isAgentHost(host.Address) {
+		return s.streamAgentContainerStats(ctx, host, containerID, interval, fn)
+	}
+
+	dockerClient, err := s.createClient(host.Address)
+	if err != nil {
+		return fmt.Errorf("failed to create docker client: %w", err)
+	}
+	defer dockerClient.Close()
+
+	statsStream, err := dockerClient.ContainerStats(ctx, containerID, true)
+	if err != nil {
+		return fmt.Errorf("failed to get stats: %w", err)
+	}
+	defer statsStream.Body.Close()
+
+	return forEachLiveStatsSample(statsStream.Body, interval, fn)
+}
+
+// forEachLiveStatsSample decodes a Docker stats stream (one sample per second) and calls fn
+// with samples spaced at least interval apart. Each Docker sample carries the previous CPU
+// reading, so CPU percentages can be computed per sample.
+func forEachLiveStatsSample(r io.Reader, interval time.Duration, fn func(models.LiveContainerStats) error) error {
+	decoder := json.NewDecoder(r)
+	var last containertypes.StatsResponse
+
+	for {
+		var sample containertypes.StatsResponse
+		if err := decoder.Decode(&sample); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("failed to decode stats: %w", err)
+		}
+
+		// The first sample has no previous reading to compute CPU usage from
+		if sample.PreCPUStats.SystemUsage == 0 {
+			continue
+		}
+		if !last.Read.IsZero() && sample.Read.Sub(last.Read) < interval {
+			continue
+		}
+
+		stats := liveStatsFromSample(sample)
+		if !last.Read.IsZero() {
+			stats.NetworkRxRate, stats.NetworkTxRate, stats.BlockReadRate, stats.BlockWriteRate = containerstats.IORates(last, sample)
+		}
+		last = sample
+
+		if err := fn(stats); err != nil {
+			return err
+		}
+	}
+}
+
+// liveStatsFromSample converts a Docker stats sample into a live stats point
+func liveStatsFromSample(sample containertypes.StatsResponse) models.LiveContainerStats {
+	cpuDelta := float64(sample.CPUStats.CPUUsage.TotalUsage) - float64(sample.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(sample.CPUStats.SystemUsage) - float64(sample.PreCPUStats.SystemUsage)
+
+	numCPUs := uint64(len(sample.CPUStats.CPUUsage.PercpuUsage))
+	if numCPUs == 0 && sample.CPUStats.OnlineCPUs > 0 {
+		numCPUs = uint64(sample.CPUStats.OnlineCPUs)
+	}
+	if numCPUs == 0 {
+		numCPUs = 1
+	}
+
+	stats := models.LiveContainerStats{
+		Timestamp:   sample.Read,
+		MemoryUsage: int64(sample.MemoryStats.Usage),
+		MemoryLimit: int64(sample.MemoryStats.Limit),
+	}
+	if systemDelta > 0 && cpuDelta > 0 {
+		stats.CPUPercent = (cpuDelta / systemDelta) * float64(numCPUs) * 100.0
+	}
+	if sample.MemoryStats.Limit > 0 {
+		stats.MemoryPercent = float64(sample.MemoryStats.Usage) / float64(sample.MemoryStats.Limit) * 100.0
+	}
+
+	return stats
+}
+
 // Image Management Operations
 
 // ListImages lists all images on a specific host
@@ -593,6 +767,63 @@ func (s *Scanner) PruneImages(ctx context.Context, host models.Host) (uint64, er
 	return report.SpaceReclaimed, nil
 }
 
+// GetImageLayers fetches the layer breakdown and build history of an image on a specific host.
+// This inspects every history step, so callers should cache the result by image ID.
+func (s *Scanner) GetImageLayers(ctx context.Context, host models.Host, imageID string) (*models.ImageLayers, error) {
+	if isAgentHost(host.Address) {
+		return s.getAgentImageLayers(ctx, host, imageID)
+	}
+
+	dockerClient, err := s.createClient(host.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create docker client: %w", err)
+	}
+	defer dockerClient.Close()
+
+	info, err := dockerClient.ImageInspect(ctx, imageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect image: %w", err)
+	}
+
+	history, err := dockerClient.ImageHistory(ctx, info.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image history: %w", err)
+	}
+
+	layers := inspect.ImageLayers(info, history)
+	layers.HostID = host.ID
+	return layers, nil
+}
+
+// AuditHost runs the CIS Docker Benchmark checks against a host. File permission checks only
+// run where the daemon's files are visible: on agents and on local unix socket connections.
+func (s *Scanner) AuditHost(ctx context.Context, host models.Host) (*models.ComplianceAudit, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var audit *models.ComplianceAudit
+	if isAgentHost(host.Address) {
+		var err error
+		if audit, err = s.auditAgentHost(ctx, host); err != nil {
+			return nil, err
+		}
+	} else {
+		dockerClient, err := s.createClient(host.Address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create docker client: %w", err)
+		}
+		defer dockerClient.Close()
+
+		if audit, err = compliance.Audit(ctx, dockerClient, compliance.LocalFiles(dockerClient.DaemonHost())); err != nil {
+			return nil, err
+		}
+	}
+
+	audit.HostID = host.ID
+	audit.HostName = host.Name
+	return audit, nil
+}
+
 // GetAgentInfo retrieves agent information for telemetry
 func (s *Scanner) GetAgentInfo(ctx context.Context, host models.Host) (*models.AgentInfo, error) {
 	if !isAgentHost(host.Address) {
@@ -602,6 +833,16 @@ func (s *Scanner) GetAgentInfo(ctx context.Context, host models.Host) (*models.A
 	return s.getAgentInfo(ctx, host)
 }
 
+// RotateAgentToken has an agent replace its token with a new one it returns. The agent keeps
+// accepting the previous token for overlap.
+func (s *Scanner) RotateAgentToken(ctx context.Context, host models.Host, overlap time.Duration) (*models.RotatedAgentToken, error) {
+	if !isAgentHost(host.Address) {
+		return nil, fmt.Errorf("host is not an agent")
+	}
+
+	return s.rotateAgentToken(ctx, host, overlap)
+}
+
 // Image Update Operations
 
 // CheckImageUpdate checks if a newer version of a container's image is available
@@ -641,10 +882,31 @@ func (s *Scanner) PullImage(ctx context.Context, host models.Host, imageName str
 	return nil
 }
 
+// TagImage adds a tag to an image on a specific host, e.g. to give an image pulled through a
+// registry mirror the name its containers reference
+func (s *Scanner) TagImage(ctx context.Context, host models.Host, source, target string) error {
+	if isAgentHost(host.Address) {
+		return s.tagAgentImage(ctx, host, source, target)
+	}
+
+	dockerClient, err := s.createClient(host.Address)
+	if err != nil {
+		return fmt.Errorf("failed to create docker client: %w", err)
+	}
+	defer dockerClient.Close()
+
+	if err := dockerClient.ImageTag(ctx, source, target); err != nil {
+		return fmt.Errorf("failed to tag image: %w", err)
+	}
+
+	return nil
+}
+
 // RecreateContainer recreates a container with a new image while preserving configuration
-func (s *Scanner) RecreateContainer(ctx context.Context, host models.Host, containerID string, dryRun bool) (*models.ContainerRecreateResult, error) {
+// Optional hooks run a pre-update command and verify the new container's health afterwards
+func (s *Scanner) RecreateContainer(ctx context.Context, host models.Host, containerID string, dryRun bool, hooks *models.UpdateHooks) (*models.ContainerRecreateResult, error) {
 	if isAgentHost(host.Address) {
-		return s.recreateAgentContainer(ctx, host, containerID, dryRun)
+		return s.recreateAgentContainer(ctx, host, containerID, dryRun, hooks)
 	}
 
 	dockerClient, err := s.createClient(host.Address)
@@ -702,6 +964,16 @@ func (s *Scanner) RecreateContainer(ctx context.Context, host models.Host, conta
 		}, nil
 	}
 
+	// Run the pre-update command in the old container, aborting the update if it fails
+	var preUpdateOutput string
+	if hooks != nil && len(hooks.PreUpdateCommand) > 0 {
+		output, err := updatehooks.RunPreUpdateCommand(ctx, dockerClient, containerID, hooks.PreUpdateCommand)
+		if err != nil {
+			return nil, fmt.Errorf("pre-update command failed, container left unchanged: %w (output: %s)", err, output)
+		}
+		preUpdateOutput = output
+	}
+
 	// Stop the container
 	timeout := 10
 	stopOptions := containertypes.StopOptions{
@@ -763,13 +1035,19 @@ func (s *Scanner) RecreateContainer(ctx context.Context, host models.Host, conta
 	}
 	newImageID := newContainerJSON.Image
 
-	return &models.ContainerRecreateResult{
-		Success:        true,
-		OldContainerID: containerID,
-		NewContainerID: newContainerID,
-		OldImageID:     oldImageID,
-		NewImageID:     newImageID,
-		KeptOldImage:   true, // We don't remove the old image
-		Config:         config,
-	}, nil
+	result := &models.ContainerRecreateResult{
+		Success:         true,
+		OldContainerID:  containerID,
+		NewContainerID:  newContainerID,
+		OldImageID:      oldImageID,
+		NewImageID:      newImageID,
+		KeptOldImage:    true, // We don't remove the old image
+		Config:          config,
+		PreUpdateOutput: preUpdateOutput,
+	}
+
+	// Wait for the new container to become healthy, rolling back if requested
+	updatehooks.VerifyUpdate(ctx, dockerClient, hooks, containerJSON, result)
+
+	return result, nil
 }
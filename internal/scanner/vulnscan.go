@@ -0,0 +1,72 @@
+package scanner
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// ImageHostsFunc returns the hosts on which an image was recently seen in use
+type ImageHostsFunc func(imageID string) ([]models.Host, error)
+
+// AgentVulnerabilityScanner delegates vulnerability scans to the census-agents hosting an
+// image, so the server doesn't need a local copy of it. It implements vulnerability.RemoteScanner.
+type AgentVulnerabilityScanner struct {
+	scanner    *Scanner
+	imageHosts ImageHostsFunc
+}
+
+// NewAgentVulnerabilityScanner creates a scanner that runs Trivy on agent hosts
+func NewAgentVulnerabilityScanner(scanner *Scanner, imageHosts ImageHostsFunc) *AgentVulnerabilityScanner {
+	return &AgentVulnerabilityScanner{
+		scanner:    scanner,
+		imageHosts: imageHosts,
+	}
+}
+
+// ScanRemote scans the image on the first agent that runs it and supports scanning. Images that
+// are also used on directly connected hosts are left to the server, as are images no capable
+// agent runs.
+func (a *AgentVulnerabilityScanner) ScanRemote(ctx context.Context, imageID, imageName string) ([]byte, bool, error) {
+	hosts, err := a.imageHosts(imageID)
+	if err != nil {
+		log.Printf("Failed to look up hosts for image %s, scanning locally: %v", imageName, err)
+		return nil, false, nil
+	}
+
+	agents := make([]models.Host, 0, len(hosts))
+	for _, host := range hosts {
+		if !isAgentHost(host.Address) {
+			return nil, false, nil
+		}
+		if host.Enabled {
+			agents = append(agents, host)
+		}
+	}
+
+	timeout := 10 * time.Minute
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+
+	var lastErr error
+	for _, host := range agents {
+		output, supported, err := a.scanner.scanAgentImage(ctx, host, imageName, timeout)
+		if !supported {
+			continue
+		}
+		if err != nil {
+			log.Printf("Agent scan of %s on host %s failed: %v", imageName, host.Name, err)
+			lastErr = err
+			continue
+		}
+		return output, true, nil
+	}
+
+	if lastErr != nil {
+		return nil, true, lastErr
+	}
+	return nil, false, nil
+}
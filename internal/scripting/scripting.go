@@ -0,0 +1,496 @@
+// Package scripting runs user scripts on scan events. A script is a Lua file in a subdirectory of
+// the scripts directory named after the event it handles (e.g. scripts/container_appeared/notify.lua).
+// It reads the event from the global table event and asks Census to act through the census table.
+//
+// Scripts run in an embedded Lua 5.1 interpreter with only the base, string, table and math
+// libraries: there is no io, os, require or loading of other code, so a script can't reach files,
+// the network or other processes, and can only change Census through the actions below. Every run
+// gets a fresh interpreter and is stopped at the timeout.
+package scripting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/plugins"
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+// Events scripts can handle
+const (
+	EventContainerAppeared = "container_appeared" // a container was seen for the first time on a host
+	EventUpdateAvailable   = "update_available"   // the update checker found a newer image
+	EventScanFailed        = "scan_failed"        // scanning a host failed
+)
+
+// Events lists every event in the order they are documented
+var Events = []string{EventContainerAppeared, EventUpdateAvailable, EventScanFailed}
+
+// Actions scripts can request, each through the census function of the same name
+const (
+	ActionNotify  = "notify"  // census.notify(message): send a script_alert notification
+	ActionWebhook = "webhook" // census.webhook(url [, body]): POST JSON to a URL
+	ActionTag     = "tag"     // census.tag(key, value): set a key/value tag on the event's container
+)
+
+// Limits on a single run
+const (
+	DefaultTimeout = 10 * time.Second
+	MaxActions     = 20
+	maxBodyDepth   = 32 // nesting of a webhook body table
+	// TagPlugin is the plugin name tags are stored under, next to collector plugin results
+	TagPlugin = "scripts"
+)
+
+// Event is what a script finds in its event table, with the field names of its JSON form
+type Event struct {
+	Event     string            `json:"event"`
+	Timestamp time.Time         `json:"timestamp"`
+	Host      models.Host       `json:"host"`
+	Container *models.Container `json:"container,omitempty"`
+	Error     string            `json:"error,omitempty"` // scan_failed
+}
+
+// Action is one thing a script asks Census to do
+type Action struct {
+	Action  string          `json:"action"`
+	Message string          `json:"message,omitempty"` // notify
+	URL     string          `json:"url,omitempty"`     // webhook
+	Body    json.RawMessage `json:"body,omitempty"`    // webhook; defaults to the event
+	Key     string          `json:"key,omitempty"`     // tag
+	Value   string          `json:"value,omitempty"`   // tag
+}
+
+// Notifier delivers script_alert notifications
+type Notifier interface {
+	SendScriptAlert(ctx context.Context, event models.NotificationEvent) error
+}
+
+// TagStore stores container tags
+type TagStore interface {
+	SetPluginResult(result models.PluginResult) error
+}
+
+// script is a compiled Lua file and the event it handles
+type script struct {
+	event string
+	name  string
+	path  string
+	proto *lua.FunctionProto
+}
+
+// Engine runs the scripts for an event and applies the actions they request
+type Engine struct {
+	scripts    []script
+	timeout    time.Duration
+	notifier   Notifier
+	tags       TagStore
+	httpClient *http.Client
+
+	mu     sync.RWMutex
+	status map[string]models.ScriptStatus
+}
+
+// Load finds the scripts in dir (one subdirectory per event) and returns an engine for them.
+// A zero timeout uses DefaultTimeout; the notifier may be nil, which makes notify actions fail.
+func Load(dir string, timeout time.Duration, notifier Notifier, tags TagStore) (*Engine, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	e := &Engine{
+		timeout:    timeout,
+		notifier:   notifier,
+		tags:       tags,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		status:     make(map[string]models.ScriptStatus),
+	}
+	for _, event := range Events {
+		entries, err := os.ReadDir(filepath.Join(dir, event))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || filepath.Ext(entry.Name()) != ".lua" {
+				continue
+			}
+			s := script{
+				event: event,
+				name:  strings.TrimSuffix(entry.Name(), ".lua"),
+				path:  filepath.Join(dir, event, entry.Name()),
+			}
+			status := models.ScriptStatus{Event: event, Name: s.name, Path: s.path}
+			// A script that doesn't compile is listed with the error but never run
+			if s.proto, err = compile(s.path); err != nil {
+				log.Printf("Script %s/%s not loaded: %v", event, s.name, err)
+				status.LastError = err.Error()
+			} else {
+				e.scripts = append(e.scripts, s)
+			}
+			e.status[s.path] = status
+		}
+	}
+	return e, nil
+}
+
+// compile parses a script once; each run instantiates it in a fresh interpreter
+func compile(path string) (*lua.FunctionProto, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	chunk, err := parse.Parse(f, filepath.Base(path))
+	if err != nil {
+		return nil, err
+	}
+	return lua.Compile(chunk, filepath.Base(path))
+}
+
+// Count returns how many scripts were loaded and compiled
+func (e *Engine) Count() int {
+	return len(e.scripts)
+}
+
+// Fire runs every script for the event, one after another, and applies their actions.
+// Failures are logged and kept in the script's status.
+func (e *Engine) Fire(ctx context.Context, event Event) {
+	// Scripts never see the agent's credentials
+	event.Host.AgentToken = ""
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	for _, s := range e.scripts {
+		if s.event != event.Event {
+			continue
+		}
+
+		started := time.Now()
+		actions, err := e.run(ctx, s, event)
+		if err == nil {
+			err = e.apply(ctx, s, event, actions)
+		}
+
+		status := models.ScriptStatus{
+			Event:       s.event,
+			Name:        s.name,
+			Path:        s.path,
+			LastRunAt:   started,
+			LastActions: len(actions),
+			DurationMs:  time.Since(started).Milliseconds(),
+		}
+		if err != nil {
+			log.Printf("Script %s/%s failed: %v", s.event, s.name, err)
+			status.LastError = err.Error()
+		}
+
+		e.mu.Lock()
+		e.status[s.path] = status
+		e.mu.Unlock()
+	}
+}
+
+// sandboxLibs are the only libraries scripts get: no io, os, package, debug or channel
+var sandboxLibs = []struct {
+	name string
+	open lua.LGFunction
+}{
+	{lua.BaseLibName, lua.OpenBase},
+	{lua.TabLibName, lua.OpenTable},
+	{lua.StringLibName, lua.OpenString},
+	{lua.MathLibName, lua.OpenMath},
+}
+
+// unsafeBaseFuncs are removed from the base library: they load code from files or strings,
+// reach other functions' environments, or write to the server's stdout
+var unsafeBaseFuncs = []string{"dofile", "loadfile", "load", "loadstring", "require", "module", "getfenv", "setfenv", "_printregs", "print"}
+
+// newSandbox returns an interpreter with only the sandbox libraries
+func newSandbox() *lua.LState {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true, RegistryMaxSize: 1 << 18})
+	for _, lib := range sandboxLibs {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	for _, name := range unsafeBaseFuncs {
+		L.SetGlobal(name, lua.LNil)
+	}
+	return L
+}
+
+// run executes a script in a fresh interpreter and returns the actions it requested
+func (e *Engine) run(ctx context.Context, s script, event Event) ([]Action, error) {
+	L := newSandbox()
+	defer L.Close()
+
+	eventTable, err := eventToLua(L, event)
+	if err != nil {
+		return nil, err
+	}
+	L.SetGlobal("event", eventTable)
+
+	var actions []Action
+	request := func(L *lua.LState, action Action) int {
+		if len(actions) >= MaxActions {
+			L.RaiseError("at most %d actions are allowed", MaxActions)
+		}
+		actions = append(actions, action)
+		return 0
+	}
+	logLine := func(L *lua.LState) int {
+		parts := make([]string, L.GetTop())
+		for i := range parts {
+			parts[i] = L.ToStringMeta(L.Get(i + 1)).String()
+		}
+		log.Printf("Script %s/%s: %s", s.event, s.name, strings.Join(parts, " "))
+		return 0
+	}
+
+	census := L.NewTable()
+	L.SetFuncs(census, map[string]lua.LGFunction{
+		ActionNotify: func(L *lua.LState) int {
+			return request(L, Action{Action: ActionNotify, Message: L.CheckString(1)})
+		},
+		ActionWebhook: func(L *lua.LState) int {
+			action := Action{Action: ActionWebhook, URL: L.CheckString(1)}
+			if L.GetTop() >= 2 && L.Get(2) != lua.LNil {
+				body, err := luaToJSON(L.Get(2), 0)
+				if err == nil {
+					action.Body, err = json.Marshal(body)
+				}
+				if err != nil {
+					L.ArgError(2, err.Error())
+				}
+			}
+			return request(L, action)
+		},
+		ActionTag: func(L *lua.LState) int {
+			return request(L, Action{Action: ActionTag, Key: L.CheckString(1), Value: L.CheckString(2)})
+		},
+		"log": logLine,
+	})
+	L.SetGlobal("census", census)
+	L.SetGlobal("print", L.NewFunction(logLine))
+
+	runCtx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+	L.SetContext(runCtx)
+
+	L.Push(L.NewFunctionFromProto(s.proto))
+	if err := L.PCall(0, 0, nil); err != nil {
+		if runCtx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("timed out after %v", e.timeout)
+		}
+		return nil, err
+	}
+	return actions, nil
+}
+
+// eventToLua converts the event to a table through its JSON form
+func eventToLua(L *lua.LState, event Event) (lua.LValue, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return jsonToLua(L, value), nil
+}
+
+// jsonToLua converts a decoded JSON value; arrays become tables indexed from 1
+func jsonToLua(L *lua.LState, value interface{}) lua.LValue {
+	switch v := value.(type) {
+	case bool:
+		return lua.LBool(v)
+	case float64:
+		return lua.LNumber(v)
+	case string:
+		return lua.LString(v)
+	case []interface{}:
+		t := L.CreateTable(len(v), 0)
+		for i, item := range v {
+			t.RawSetInt(i+1, jsonToLua(L, item))
+		}
+		return t
+	case map[string]interface{}:
+		t := L.CreateTable(0, len(v))
+		for key, item := range v {
+			t.RawSetString(key, jsonToLua(L, item))
+		}
+		return t
+	}
+	return lua.LNil
+}
+
+// luaToJSON converts a Lua value for encoding as JSON. Tables with a sequence become arrays,
+// other tables objects; functions and other values have no JSON form.
+func luaToJSON(value lua.LValue, depth int) (interface{}, error) {
+	switch v := value.(type) {
+	case *lua.LNilType:
+		return nil, nil
+	case lua.LBool:
+		return bool(v), nil
+	case lua.LNumber:
+		return float64(v), nil
+	case lua.LString:
+		return string(v), nil
+	case *lua.LTable:
+		if depth >= maxBodyDepth {
+			return nil, fmt.Errorf("tables nested more than %d levels deep", maxBodyDepth)
+		}
+		if n := v.Len(); n > 0 {
+			list := make([]interface{}, n)
+			for i := range list {
+				item, err := luaToJSON(v.RawGetInt(i+1), depth+1)
+				if err != nil {
+					return nil, err
+				}
+				list[i] = item
+			}
+			return list, nil
+		}
+		object := make(map[string]interface{})
+		var err error
+		v.ForEach(func(key, item lua.LValue) {
+			if err == nil {
+				object[key.String()], err = luaToJSON(item, depth+1)
+			}
+		})
+		return object, err
+	}
+	return nil, fmt.Errorf("a %s can't be sent as JSON", value.Type())
+}
+
+// apply carries out a script's actions; every action is attempted and the errors are combined
+func (e *Engine) apply(ctx context.Context, s script, event Event, actions []Action) error {
+	var errs []string
+	for i, action := range actions {
+		if err := e.applyAction(ctx, s, event, action); err != nil {
+			errs = append(errs, fmt.Sprintf("action %d (%s): %v", i+1, action.Action, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (e *Engine) applyAction(ctx context.Context, s script, event Event, action Action) error {
+	switch action.Action {
+	case ActionNotify:
+		if strings.TrimSpace(action.Message) == "" {
+			return fmt.Errorf("message is required")
+		}
+		if e.notifier == nil {
+			return fmt.Errorf("notifications are not available")
+		}
+		n := models.NotificationEvent{
+			Timestamp: event.Timestamp,
+			HostID:    event.Host.ID,
+			HostName:  event.Host.Name,
+			Metadata: map[string]interface{}{
+				"script":  s.event + "/" + s.name,
+				"trigger": event.Event,
+				"message": action.Message,
+			},
+		}
+		if event.Container != nil {
+			n.ContainerID = event.Container.ID
+			n.ContainerName = event.Container.Name
+			n.Image = event.Container.Image
+		}
+		return e.notifier.SendScriptAlert(ctx, n)
+
+	case ActionWebhook:
+		u, err := url.Parse(action.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("url must be an http(s) URL")
+		}
+		body := []byte(action.Body)
+		if len(body) == 0 {
+			if body, err = json.Marshal(event); err != nil {
+				return err
+			}
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, action.URL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := e.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+		}
+		return nil
+
+	case ActionTag:
+		if event.Container == nil {
+			return fmt.Errorf("%s events have no container to tag", event.Event)
+		}
+		key := strings.TrimSpace(action.Key)
+		if key == "" || len(key) > plugins.MaxKeyLength {
+			return fmt.Errorf("key must be 1-%d characters", plugins.MaxKeyLength)
+		}
+		value := action.Value
+		if len(value) > plugins.MaxValueLength {
+			value = value[:plugins.MaxValueLength]
+		}
+		return e.tags.SetPluginResult(models.PluginResult{
+			Plugin:        TagPlugin,
+			HostID:        event.Host.ID,
+			ContainerName: event.Container.Name,
+			Key:           key,
+			Value:         value,
+			CollectedAt:   time.Now(),
+		})
+
+	default:
+		return fmt.Errorf("unknown action")
+	}
+}
+
+// Status returns the scripts and their latest runs, ordered by event and name
+func (e *Engine) Status() []models.ScriptStatus {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	statuses := make([]models.ScriptStatus, 0, len(e.status))
+	for _, s := range e.status {
+		statuses = append(statuses, s)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Event != statuses[j].Event {
+			return statuses[i].Event < statuses[j].Event
+		}
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
@@ -0,0 +1,231 @@
+package scripting
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+type fakeNotifier struct {
+	events []models.NotificationEvent
+}
+
+func (f *fakeNotifier) SendScriptAlert(ctx context.Context, event models.NotificationEvent) error {
+	f.events = append(f.events, event)
+	return nil
+}
+
+type fakeTags struct {
+	results []models.PluginResult
+}
+
+func (f *fakeTags) SetPluginResult(result models.PluginResult) error {
+	f.results = append(f.results, result)
+	return nil
+}
+
+func writeScript(t *testing.T, dir, event, name, body string) {
+	t.Helper()
+	eventDir := filepath.Join(dir, event)
+	if err := os.MkdirAll(eventDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(eventDir, name), []byte(body+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testEvent(event string) Event {
+	return Event{
+		Event:     event,
+		Host:      models.Host{ID: 2, Name: "nas", AgentToken: "secret-token"},
+		Container: &models.Container{ID: "abc", Name: "web", Image: "nginx:1.25"},
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeScript(t, dir, EventContainerAppeared, "b.lua", "")
+	writeScript(t, dir, EventContainerAppeared, "a.lua", "")
+	writeScript(t, dir, EventContainerAppeared, "notes.txt", "not a script")
+	writeScript(t, dir, EventScanFailed, "page.lua", "")
+	writeScript(t, dir, EventScanFailed, "broken.lua", "if then")
+	writeScript(t, dir, "unknown_event", "ignored.lua", "")
+
+	engine, err := Load(dir, 0, nil, &fakeTags{})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if engine.Count() != 3 {
+		t.Fatalf("Expected 3 scripts, got %d", engine.Count())
+	}
+
+	status := engine.Status()
+	var names []string
+	for _, s := range status {
+		names = append(names, s.Event+"/"+s.Name)
+		if (s.Name == "broken") != (s.LastError != "") {
+			t.Errorf("Expected only the script that doesn't compile to have an error, got %+v", s)
+		}
+	}
+	if strings.Join(names, ",") != "container_appeared/a,container_appeared/b,scan_failed/broken,scan_failed/page" {
+		t.Errorf("Unexpected scripts: %v", names)
+	}
+
+	if _, err := Load(filepath.Join(dir, "missing"), 0, nil, &fakeTags{}); err == nil {
+		t.Error("Expected an error for a missing scripts directory")
+	}
+}
+
+func TestFireActions(t *testing.T) {
+	var webhookBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		webhookBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	// The script reads the container name from the event and uses every action
+	writeScript(t, dir, EventContainerAppeared, "act.lua", `census.notify("new container " .. event.container.name)
+census.tag("owner", "media-team")
+census.webhook("`+server.URL+`")`)
+	writeScript(t, dir, EventUpdateAvailable, "other.lua", `census.notify("wrong event")`)
+
+	notifier := &fakeNotifier{}
+	tags := &fakeTags{}
+	engine, err := Load(dir, time.Second, notifier, tags)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	engine.Fire(context.Background(), testEvent(EventContainerAppeared))
+
+	if len(notifier.events) != 1 {
+		t.Fatalf("Expected one notification from the matching script, got %+v", notifier.events)
+	}
+	n := notifier.events[0]
+	if n.Metadata["message"] != "new container web" || n.ContainerName != "web" || n.HostID != 2 {
+		t.Errorf("Unexpected notification: %+v", n)
+	}
+	if n.Metadata["script"] != "container_appeared/act" {
+		t.Errorf("Expected the script name in the notification, got %v", n.Metadata["script"])
+	}
+
+	if len(tags.results) != 1 || tags.results[0].Plugin != TagPlugin || tags.results[0].Key != "owner" ||
+		tags.results[0].ContainerName != "web" || tags.results[0].HostID != 2 {
+		t.Errorf("Unexpected tags: %+v", tags.results)
+	}
+
+	var sent Event
+	if err := json.Unmarshal(webhookBody, &sent); err != nil {
+		t.Fatalf("Expected the event as webhook body, got %q", webhookBody)
+	}
+	if sent.Event != EventContainerAppeared || sent.Host.AgentToken != "" {
+		t.Errorf("Expected the event without the agent token, got %+v", sent)
+	}
+
+	for _, s := range engine.Status() {
+		if s.Name == "act" && (s.LastError != "" || s.LastActions != 3) {
+			t.Errorf("Expected a successful run with 3 actions, got %+v", s)
+		}
+	}
+}
+
+func TestFireWebhookBody(t *testing.T) {
+	var webhookBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		webhookBody, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	writeScript(t, dir, EventScanFailed, "page.lua", `census.webhook("`+server.URL+`", {
+  text = event.host.name .. ": " .. event.error,
+  tags = {"census", "scan"},
+  retry = false,
+})`)
+
+	engine, err := Load(dir, time.Second, &fakeNotifier{}, &fakeTags{})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	engine.Fire(context.Background(), Event{Event: EventScanFailed, Host: models.Host{ID: 1, Name: "pi"}, Error: "connection refused"})
+
+	if string(webhookBody) != `{"retry":false,"tags":["census","scan"],"text":"pi: connection refused"}` {
+		t.Errorf("Unexpected webhook body: %s", webhookBody)
+	}
+}
+
+func TestFireSandbox(t *testing.T) {
+	dir := t.TempDir()
+	// Every way out of the interpreter is missing
+	writeScript(t, dir, EventScanFailed, "escape.lua", `local missing = {}
+for _, name in ipairs({"io", "os", "package", "debug", "require", "dofile", "loadfile", "load", "loadstring", "module", "setfenv", "getfenv"}) do
+  if _G[name] ~= nil then table.insert(missing, name) end
+end
+census.notify("available: " .. table.concat(missing, ","))`)
+	writeScript(t, dir, EventScanFailed, "shell.lua", `os.execute("touch /tmp/census-escaped")`)
+
+	notifier := &fakeNotifier{}
+	engine, err := Load(dir, time.Second, notifier, &fakeTags{})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	engine.Fire(context.Background(), Event{Event: EventScanFailed, Host: models.Host{ID: 1, Name: "pi"}})
+
+	if len(notifier.events) != 1 || notifier.events[0].Metadata["message"] != "available: " {
+		t.Fatalf("Expected no escape hatches in the sandbox, got %+v", notifier.events)
+	}
+	for _, s := range engine.Status() {
+		if s.Name == "shell" && s.LastError == "" {
+			t.Error("Expected os.execute to fail")
+		}
+	}
+}
+
+func TestFireErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeScript(t, dir, EventScanFailed, "crash.lua", `error("boom")`)
+	writeScript(t, dir, EventScanFailed, "slow.lua", "while true do end")
+	writeScript(t, dir, EventScanFailed, "tag.lua", `census.tag("k", "v")`)
+	writeScript(t, dir, EventScanFailed, "chatty.lua", `for i = 1, 21 do census.notify("again") end`)
+	writeScript(t, dir, EventScanFailed, "loop.lua", `local t = {}
+t.self = t
+census.webhook("http://localhost/", t)`)
+	writeScript(t, dir, EventScanFailed, "quiet.lua", "")
+
+	engine, err := Load(dir, 200*time.Millisecond, &fakeNotifier{}, &fakeTags{})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	engine.Fire(context.Background(), Event{Event: EventScanFailed, Host: models.Host{ID: 1, Name: "pi"}})
+
+	want := map[string]string{
+		"crash":  "boom",
+		"slow":   "timed out",
+		"tag":    "no container to tag",
+		"chatty": "at most 20 actions",
+		"loop":   "nested more than",
+		"quiet":  "",
+	}
+	for _, s := range engine.Status() {
+		if want[s.Name] == "" {
+			if s.LastError != "" {
+				t.Errorf("%s: expected no error, got %q", s.Name, s.LastError)
+			}
+			continue
+		}
+		if !strings.Contains(s.LastError, want[s.Name]) {
+			t.Errorf("%s: expected error containing %q, got %q", s.Name, want[s.Name], s.LastError)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Package securityscore scores the security posture of the fleet, its hosts and their compose
+// stacks from the vulnerability scans of their images and their privileged containers.
+package securityscore
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/vulnerability"
+)
+
+// Image is an image of the fleet with its latest scan, nil when it was never scanned
+type Image struct {
+	Name            string
+	Scan            *vulnerability.VulnerabilityScan
+	Vulnerabilities []vulnerability.Vulnerability
+}
+
+// imageFindings are the open findings of an image counted in scores
+type imageFindings struct {
+	scanned    bool
+	critical   int
+	high       int
+	staleFixes int
+}
+
+// BuildScoreboard scores the fleet, each host and each compose stack on a host from the given
+// current containers. images is keyed by image ID; privileged holds the "hostID/name" keys of
+// privileged containers (see Key).
+func BuildScoreboard(containers []models.Container, images map[string]Image, privileged map[string]bool, exceptions []vulnerability.Exception, now time.Time) *models.SecurityScoreboard {
+	findings := make(map[string]imageFindings, len(images))
+	for id, image := range images {
+		findings[id] = countFindings(image, exceptions, now)
+	}
+
+	type scope struct {
+		score  models.SecurityScore
+		images map[string]bool
+	}
+	fleet := &scope{images: make(map[string]bool)}
+	hosts := make(map[int64]*scope)
+	stacks := make(map[string]*scope)
+	var hostOrder []int64
+	var stackOrder []string
+
+	for _, c := range containers {
+		scopes := []*scope{fleet}
+		host, ok := hosts[c.HostID]
+		if !ok {
+			host = &scope{score: models.SecurityScore{HostID: c.HostID, HostName: c.HostName}, images: make(map[string]bool)}
+			hosts[c.HostID] = host
+			hostOrder = append(hostOrder, c.HostID)
+		}
+		scopes = append(scopes, host)
+		if c.ComposeProject != "" {
+			key := Key(c.HostID, c.ComposeProject)
+			stack, ok := stacks[key]
+			if !ok {
+				stack = &scope{score: models.SecurityScore{HostID: c.HostID, HostName: c.HostName, Stack: c.ComposeProject}, images: make(map[string]bool)}
+				stacks[key] = stack
+				stackOrder = append(stackOrder, key)
+			}
+			scopes = append(scopes, stack)
+		}
+
+		for _, s := range scopes {
+			s.score.Containers++
+			if privileged[Key(c.HostID, c.Name)] {
+				s.score.Privileged++
+			}
+			if c.ImageID == "" || s.images[c.ImageID] {
+				continue
+			}
+			s.images[c.ImageID] = true
+			s.score.Images++
+			f := findings[c.ImageID]
+			if !f.scanned {
+				s.score.Unscanned++
+				continue
+			}
+			s.score.Critical += f.critical
+			s.score.High += f.high
+			s.score.StaleFixes += f.staleFixes
+		}
+	}
+
+	board := &models.SecurityScoreboard{
+		GeneratedAt: now,
+		Fleet:       finish(fleet.score),
+		Hosts:       make([]models.SecurityScore, 0, len(hosts)),
+		Stacks:      make([]models.SecurityScore, 0, len(stacks)),
+	}
+	for _, id := range hostOrder {
+		board.Hosts = append(board.Hosts, finish(hosts[id].score))
+	}
+	for _, key := range stackOrder {
+		board.Stacks = append(board.Stacks, finish(stacks[key].score))
+	}
+	rank(board.Hosts)
+	rank(board.Stacks)
+	return board
+}
+
+// Key identifies a container or stack by host ID and name
+func Key(hostID int64, name string) string {
+	return fmt.Sprintf("%d/%s", hostID, name)
+}
+
+// Score turns penalty points into a 0-100 score
+func Score(points int) int {
+	return int(math.Round(100 * 100 / float64(100+points)))
+}
+
+// Grade is the letter grade of a score
+func Grade(score int) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 75:
+		return "B"
+	case score >= 60:
+		return "C"
+	case score >= 40:
+		return "D"
+	default:
+		return "F"
+	}
+}
+
+// finish sets the score and grade from the counts
+func finish(s models.SecurityScore) models.SecurityScore {
+	points := s.Critical*models.SecurityScoreCriticalWeight +
+		s.High*models.SecurityScoreHighWeight +
+		s.StaleFixes*models.SecurityScoreStaleFixWeight +
+		s.Privileged*models.SecurityScorePrivilegedWeight
+	s.Score = Score(points)
+	s.Grade = Grade(s.Score)
+	return s
+}
+
+// rank sorts scores worst first
+func rank(scores []models.SecurityScore) {
+	sort.SliceStable(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score < scores[j].Score
+		}
+		if scores[i].HostName != scores[j].HostName {
+			return scores[i].HostName < scores[j].HostName
+		}
+		return scores[i].Stack < scores[j].Stack
+	})
+}
+
+// countFindings counts the open critical and high findings of an image. Scans whose details were
+// removed by the detailed retention are counted from their totals, without exceptions or fixes.
+func countFindings(image Image, exceptions []vulnerability.Exception, now time.Time) imageFindings {
+	if image.Scan == nil || !image.Scan.Success {
+		return imageFindings{}
+	}
+	f := imageFindings{scanned: true}
+	if len(image.Vulnerabilities) == 0 {
+		f.critical = image.Scan.SeverityCounts.Critical
+		f.high = image.Scan.SeverityCounts.High
+		return f
+	}
+
+	staleBefore := now.AddDate(0, 0, -models.SecurityScoreStaleFixDays)
+	seen := make(map[string]bool)
+	for _, v := range image.Vulnerabilities {
+		if v.Severity != "CRITICAL" && v.Severity != "HIGH" {
+			continue
+		}
+		if seen[v.VulnerabilityID] || excepted(v, exceptions, image.Name, now) {
+			continue
+		}
+		seen[v.VulnerabilityID] = true
+		if v.Severity == "CRITICAL" {
+			f.critical++
+		} else {
+			f.high++
+		}
+		if v.FixedVersion != "" && !v.PublishedDate.IsZero() && v.PublishedDate.Before(staleBefore) {
+			f.staleFixes++
+		}
+	}
+	return f
+}
+
+// excepted reports whether an exception covers a vulnerability of an image
+func excepted(v vulnerability.Vulnerability, exceptions []vulnerability.Exception, imageName string, now time.Time) bool {
+	for _, e := range exceptions {
+		if e.Matches(v.VulnerabilityID, imageName, now) {
+			return true
+		}
+	}
+	return false
+}
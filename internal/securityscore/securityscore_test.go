@@ -0,0 +1,75 @@
+package securityscore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/vulnerability"
+)
+
+func TestBuildScoreboard(t *testing.T) {
+	now := time.Now()
+	containers := []models.Container{
+		{Name: "app", ImageID: "sha256:app", HostID: 1, HostName: "nas", ComposeProject: "media"},
+		{Name: "worker", ImageID: "sha256:app", HostID: 1, HostName: "nas", ComposeProject: "media"},
+		{Name: "db", ImageID: "sha256:db", HostID: 1, HostName: "nas"},
+		{Name: "dns", ImageID: "sha256:dns", HostID: 2, HostName: "pi"},
+	}
+	images := map[string]Image{
+		"sha256:app": {
+			Name: "app:latest",
+			Scan: &vulnerability.VulnerabilityScan{Success: true, TotalVulnerabilities: 5},
+			Vulnerabilities: []vulnerability.Vulnerability{
+				{VulnerabilityID: "CVE-1", PkgName: "openssl", Severity: "CRITICAL", FixedVersion: "3.0.2", PublishedDate: now.AddDate(0, 0, -60)},
+				{VulnerabilityID: "CVE-1", PkgName: "libssl", Severity: "CRITICAL", FixedVersion: "3.0.2", PublishedDate: now.AddDate(0, 0, -60)},
+				{VulnerabilityID: "CVE-2", PkgName: "zlib", Severity: "HIGH", PublishedDate: now.AddDate(0, 0, -60)},
+				{VulnerabilityID: "CVE-3", PkgName: "curl", Severity: "HIGH", FixedVersion: "8.1"},
+				{VulnerabilityID: "CVE-4", PkgName: "bash", Severity: "MEDIUM", FixedVersion: "5.2"},
+			},
+		},
+		// Details removed by the detailed retention
+		"sha256:db": {Name: "postgres:16", Scan: &vulnerability.VulnerabilityScan{Success: true, TotalVulnerabilities: 1, SeverityCounts: vulnerability.SeverityCounts{Critical: 1}}},
+	}
+	privileged := map[string]bool{Key(1, "db"): true}
+	exceptions := []vulnerability.Exception{{VulnerabilityID: "CVE-3"}}
+
+	board := BuildScoreboard(containers, images, privileged, exceptions, now)
+
+	fleet := board.Fleet
+	if fleet.Containers != 4 || fleet.Images != 3 || fleet.Unscanned != 1 || fleet.Critical != 2 || fleet.High != 1 ||
+		fleet.StaleFixes != 1 || fleet.Privileged != 1 {
+		t.Errorf("Unexpected fleet counts: %+v", fleet)
+	}
+	// 2 critical (20) + 1 high (3) + 1 stale fix (5) + 1 privileged (20) = 48 points
+	if fleet.Score != 68 || fleet.Grade != "C" {
+		t.Errorf("Expected score 68 (C), got %d (%s)", fleet.Score, fleet.Grade)
+	}
+
+	if len(board.Hosts) != 2 || board.Hosts[0].HostName != "nas" || board.Hosts[0].Score != 68 ||
+		board.Hosts[1].HostName != "pi" || board.Hosts[1].Score != 100 || board.Hosts[1].Unscanned != 1 {
+		t.Errorf("Expected nas ranked before pi, got %+v", board.Hosts)
+	}
+	if len(board.Stacks) != 1 {
+		t.Fatalf("Expected the media stack, got %+v", board.Stacks)
+	}
+	if stack := board.Stacks[0]; stack.Stack != "media" || stack.HostID != 1 || stack.Containers != 2 || stack.Images != 1 ||
+		stack.Critical != 1 || stack.High != 1 || stack.Score != 85 || stack.Grade != "B" {
+		t.Errorf("Unexpected media stack score: %+v", stack)
+	}
+}
+
+func TestScore(t *testing.T) {
+	tests := map[int]int{0: 100, 10: 91, 100: 50, 300: 25}
+	for points, want := range tests {
+		if got := Score(points); got != want {
+			t.Errorf("Score(%d) = %d, want %d", points, got, want)
+		}
+	}
+	grades := map[int]string{100: "A", 90: "A", 89: "B", 75: "B", 60: "C", 40: "D", 39: "F"}
+	for score, want := range grades {
+		if got := Grade(score); got != want {
+			t.Errorf("Grade(%d) = %s, want %s", score, got, want)
+		}
+	}
+}
@@ -0,0 +1,384 @@
+// Package soak simulates the scan history of a fleet (hosts × containers × days) against the
+// storage layer and measures the database size and the latency of writes, maintenance and the
+// queries behind the main API endpoints, so storage regressions show up before a release.
+package soak
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"os"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/storage"
+)
+
+// Options control the size of the simulated fleet
+type Options struct {
+	Hosts      int           `json:"hosts"`
+	Containers int           `json:"containers"` // per host
+	Days       int           `json:"days"`
+	Interval   time.Duration `json:"interval_ns"` // between two scans of a host
+	Seed       int64         `json:"seed"`        // the same options always write the same data
+	QueryRuns  int           `json:"query_runs"`
+}
+
+// DefaultOptions returns a week of ten-minute scans of 10 hosts with 25 containers each
+func DefaultOptions() Options {
+	return Options{
+		Hosts:      10,
+		Containers: 25,
+		Days:       7,
+		Interval:   10 * time.Minute,
+		Seed:       1,
+		QueryRuns:  20,
+	}
+}
+
+// Validate checks that the options describe a fleet
+func (o Options) Validate() error {
+	switch {
+	case o.Hosts < 1 || o.Containers < 1 || o.Days < 1:
+		return fmt.Errorf("hosts, containers and days must be at least 1")
+	case o.Interval < time.Minute:
+		return fmt.Errorf("interval must be at least a minute")
+	case o.QueryRuns < 1:
+		return fmt.Errorf("query runs must be at least 1")
+	}
+	return nil
+}
+
+// Latency summarizes the durations of one operation, in milliseconds
+type Latency struct {
+	Count int     `json:"count"`
+	P50   float64 `json:"p50_ms"`
+	P95   float64 `json:"p95_ms"`
+	P99   float64 `json:"p99_ms"`
+	Max   float64 `json:"max_ms"`
+	Total float64 `json:"total_ms"`
+}
+
+// Measurement is the latency of a named operation; Endpoint is the API route it serves, if any
+type Measurement struct {
+	Name     string  `json:"name"`
+	Endpoint string  `json:"endpoint,omitempty"`
+	Latency  Latency `json:"latency"`
+}
+
+// Report is the result of a soak run
+type Report struct {
+	Options         Options       `json:"options"`
+	Scans           int           `json:"scans"`
+	ContainerRows   int           `json:"container_rows"` // containers written over all scans
+	DBBytes         int64         `json:"db_bytes"`
+	WALBytes        int64         `json:"wal_bytes"`
+	Writes          []Measurement `json:"writes"`
+	Maintenance     []Measurement `json:"maintenance"`
+	Queries         []Measurement `json:"queries"`
+	DurationSeconds float64       `json:"duration_seconds"`
+}
+
+// Run writes the simulated scans to db, whose file is dbPath, runs the periodic maintenance
+// once and measures the queries. progress, if not nil, is called after each simulated day.
+func Run(ctx context.Context, db *storage.DB, dbPath string, opts Options, progress func(day int)) (*Report, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	started := time.Now()
+	report := &Report{Options: opts}
+	fleet := newFleet(opts)
+
+	hostIDs := make([]int64, len(fleet.hosts))
+	for i, host := range fleet.hosts {
+		id, err := db.AddHost(host)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add host %s: %w", host.Name, err)
+		}
+		hostIDs[i] = id
+	}
+
+	var saves, results []time.Duration
+	end := time.Now().Truncate(time.Minute)
+	scansPerDay := int(24 * time.Hour / opts.Interval)
+	total := scansPerDay * opts.Days
+	for i := 0; i < total; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		at := end.Add(-time.Duration(total-1-i) * opts.Interval)
+		for h := range fleet.hosts {
+			containers := fleet.scan(h, hostIDs[h], at)
+
+			t := time.Now()
+			if err := db.SaveContainers(containers); err != nil {
+				return nil, fmt.Errorf("failed to save containers: %w", err)
+			}
+			saves = append(saves, time.Since(t))
+
+			t = time.Now()
+			if _, err := db.SaveScanResult(models.ScanResult{
+				HostID:          hostIDs[h],
+				HostName:        fleet.hosts[h].Name,
+				StartedAt:       at.Add(-2 * time.Second),
+				CompletedAt:     at,
+				Success:         true,
+				ContainersFound: len(containers),
+			}); err != nil {
+				return nil, fmt.Errorf("failed to save scan result: %w", err)
+			}
+			results = append(results, time.Since(t))
+
+			report.Scans++
+			report.ContainerRows += len(containers)
+		}
+		if progress != nil && (i+1)%scansPerDay == 0 {
+			progress((i + 1) / scansPerDay)
+		}
+	}
+	report.Writes = []Measurement{
+		{Name: "SaveContainers", Latency: summarize(saves)},
+		{Name: "SaveScanResult", Latency: summarize(results)},
+	}
+
+	// The hourly and daily jobs of the server, once over the whole history
+	maintenance := []struct {
+		name string
+		run  func() error
+	}{
+		{"AggregateOldStats", func() error { _, err := db.AggregateOldStats(); return err }},
+		{"CleanupRedundantScans", func() error { _, err := db.CleanupRedundantScans(7); return err }},
+	}
+	for _, m := range maintenance {
+		t := time.Now()
+		if err := m.run(); err != nil {
+			return nil, fmt.Errorf("%s failed: %w", m.name, err)
+		}
+		report.Maintenance = append(report.Maintenance, Measurement{Name: m.name, Latency: summarize([]time.Duration{time.Since(t)})})
+	}
+
+	sample := fleet.containers[0][0]
+	sampleID := fleet.containerID(0, sample)
+	queries := []struct {
+		name, endpoint string
+		run            func() error
+	}{
+		{"GetHosts", "GET /api/hosts", func() error { _, err := db.GetHosts(); return err }},
+		{"GetLatestContainers", "GET /api/containers", func() error { _, err := db.GetLatestContainers(); return err }},
+		{"GetContainersByHost", "GET /api/containers/host/{id}", func() error { _, err := db.GetContainersByHost(hostIDs[0]); return err }},
+		{"GetContainerStats 24h", "GET /api/containers/{host_id}/{container_id}/stats?range=24h", func() error {
+			_, err := db.GetContainerStats(sampleID, hostIDs[0], 24)
+			return err
+		}},
+		{"GetContainerStats 7d", "GET /api/containers/{host_id}/{container_id}/stats?range=7d", func() error {
+			_, err := db.GetContainerStats(sampleID, hostIDs[0], 7*24)
+			return err
+		}},
+		{"GetContainerLifecycleSummaries", "GET /api/containers/lifecycle", func() error {
+			_, err := db.GetContainerLifecycleSummaries(100, 0)
+			return err
+		}},
+		{"GetContainerLifecycleEvents", "GET /api/containers/lifecycle/{host_id}/{container_name}", func() error {
+			_, err := db.GetContainerLifecycleEvents(sample.name, hostIDs[0])
+			return err
+		}},
+		{"GetChangesReport 7d", "GET /api/reports/changes", func() error {
+			_, err := db.GetChangesReport(end.AddDate(0, 0, -7), end, 0)
+			return err
+		}},
+		{"GetTopConsumers 24h", "GET /api/stats/top-consumers", func() error {
+			_, err := db.GetTopConsumers(24, 10, 0)
+			return err
+		}},
+		{"GetActivityLog", "GET /api/activity-log?limit=100", func() error { _, err := db.GetActivityLog(100, "all"); return err }},
+	}
+	for _, q := range queries {
+		samples := make([]time.Duration, 0, opts.QueryRuns)
+		for i := 0; i < opts.QueryRuns; i++ {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			t := time.Now()
+			if err := q.run(); err != nil {
+				return nil, fmt.Errorf("%s failed: %w", q.name, err)
+			}
+			samples = append(samples, time.Since(t))
+		}
+		report.Queries = append(report.Queries, Measurement{Name: q.name, Endpoint: q.endpoint, Latency: summarize(samples)})
+	}
+
+	report.DBBytes = fileSize(dbPath)
+	report.WALBytes = fileSize(dbPath + "-wal")
+	report.DurationSeconds = time.Since(started).Seconds()
+	return report, nil
+}
+
+// summarize computes the percentiles of a set of durations
+func summarize(samples []time.Duration) Latency {
+	if len(samples) == 0 {
+		return Latency{}
+	}
+	sorted := append([]time.Duration{}, samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	ms := func(d time.Duration) float64 { return float64(d.Microseconds()) / 1000 }
+	percentile := func(p float64) float64 {
+		return ms(sorted[int(p*float64(len(sorted)-1))])
+	}
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	return Latency{
+		Count: len(sorted),
+		P50:   percentile(0.50),
+		P95:   percentile(0.95),
+		P99:   percentile(0.99),
+		Max:   ms(sorted[len(sorted)-1]),
+		Total: ms(total),
+	}
+}
+
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// fleet generates the scans of the simulated hosts. Containers keep their ID between scans,
+// restart now and then, sometimes stop for a while and are occasionally updated to a new image.
+type fleet struct {
+	rng        *rand.Rand
+	hosts      []models.Host
+	containers [][]simContainer // by host
+}
+
+type simContainer struct {
+	name       string
+	image      string
+	version    int // bumped by an update, which also gives the container a new ID
+	created    time.Time
+	restarts   int
+	downUntil  time.Time
+	cpu        float64
+	memMB      int64
+	project    string
+	generation int
+}
+
+// images the simulated containers run, with their typical CPU percent and memory
+var images = []struct {
+	name  string
+	cpu   float64
+	memMB int64
+}{
+	{"nginx", 0.5, 30}, {"postgres", 3, 400}, {"redis", 0.8, 60}, {"grafana/grafana", 1, 180},
+	{"prom/prometheus", 5, 700}, {"traefik", 1.5, 80}, {"linuxserver/sonarr", 2, 310},
+	{"nextcloud", 4, 600}, {"pihole/pihole", 1, 90}, {"eclipse-mosquitto", 0.3, 12},
+	{"ghcr.io/home-assistant/home-assistant", 4, 420}, {"mariadb", 2.5, 350},
+}
+
+func newFleet(opts Options) *fleet {
+	f := &fleet{rng: rand.New(rand.NewSource(opts.Seed))}
+	start := time.Now().AddDate(0, 0, -opts.Days-30)
+	for h := 0; h < opts.Hosts; h++ {
+		name := fmt.Sprintf("soak-%03d", h+1)
+		f.hosts = append(f.hosts, models.Host{
+			Name:         name,
+			Address:      fmt.Sprintf("agent://%s.soak.example:9876", name),
+			HostType:     models.HostTypeAgent,
+			Enabled:      true,
+			CollectStats: true,
+		})
+		containers := make([]simContainer, opts.Containers)
+		for c := range containers {
+			img := images[f.rng.Intn(len(images))]
+			containers[c] = simContainer{
+				name:    fmt.Sprintf("%s-%d", path.Base(img.name), c+1),
+				image:   img.name,
+				version: 1 + f.rng.Intn(5),
+				created: start,
+				cpu:     img.cpu,
+				memMB:   img.memMB,
+				project: fmt.Sprintf("stack-%d", c/5+1),
+			}
+		}
+		f.containers = append(f.containers, containers)
+	}
+	return f
+}
+
+// scan returns what a scan of a host at the given time finds, advancing the simulation
+func (f *fleet) scan(h int, hostID int64, at time.Time) []models.Container {
+	host := f.hosts[h]
+	out := make([]models.Container, 0, len(f.containers[h]))
+	for i := range f.containers[h] {
+		sc := &f.containers[h][i]
+		switch r := f.rng.Float64(); {
+		case r < 0.0005: // updated to a new version
+			sc.version++
+			sc.generation++
+			sc.created = at.Add(-time.Minute)
+			sc.restarts = 0
+		case r < 0.002: // restarted
+			sc.restarts++
+		case r < 0.0025: // stopped for up to two hours
+			sc.downUntil = at.Add(time.Duration(f.rng.Intn(120)+1) * time.Minute)
+		}
+
+		image := fmt.Sprintf("%s:1.%d", sc.image, sc.version)
+		c := models.Container{
+			ID:             f.containerID(h, *sc),
+			Name:           sc.name,
+			Image:          image,
+			ImageID:        stableID(image),
+			ImageTags:      []string{image},
+			ImageSize:      int64(50+len(sc.image)*7) * 1024 * 1024,
+			RestartCount:   sc.restarts,
+			Labels:         map[string]string{"com.docker.compose.project": sc.project},
+			Created:        sc.created,
+			HostID:         hostID,
+			HostName:       host.Name,
+			ScannedAt:      at,
+			Networks:       []string{sc.project + "_default"},
+			ComposeProject: sc.project,
+		}
+		if at.Before(sc.downUntil) {
+			c.State = "exited"
+			c.Status = "Exited (0) recently"
+		} else {
+			noise := func(spread float64) float64 { return 1 + (f.rng.Float64()*2-1)*spread }
+			c.State = "running"
+			c.Status = "Up " + at.Sub(sc.created).Round(time.Hour).String()
+			c.CPUPercent = sc.cpu * noise(0.5)
+			c.MemoryUsage = int64(float64(sc.memMB*1024*1024) * noise(0.1))
+			c.MemoryLimit = 4 * 1024 * 1024 * 1024
+			c.MemoryPercent = float64(c.MemoryUsage) / float64(c.MemoryLimit) * 100
+			c.NetworkRxRate = 20000 * noise(0.9)
+			c.NetworkTxRate = 8000 * noise(0.9)
+			c.BlockReadRate = 1000 * noise(0.9)
+			c.BlockWriteRate = 4000 * noise(0.9)
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// containerID is the Docker ID of a container, which changes when it is updated
+func (f *fleet) containerID(h int, sc simContainer) string {
+	return stableID(f.hosts[h].Name, sc.name, fmt.Sprint(sc.generation))
+}
+
+// stableID derives a Docker-style 64 character ID from its parts
+func stableID(parts ...string) string {
+	sum := sha256.New()
+	for _, p := range parts {
+		sum.Write([]byte(p + "\x00"))
+	}
+	return hex.EncodeToString(sum.Sum(nil))
+}
@@ -0,0 +1,55 @@
+package soak
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/storage"
+)
+
+func TestRun(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "soak.db")
+	db, err := storage.New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	opts := Options{Hosts: 2, Containers: 3, Days: 1, Interval: time.Hour, Seed: 1, QueryRuns: 2}
+	var days []int
+	report, err := Run(context.Background(), db, dbPath, opts, func(day int) { days = append(days, day) })
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.Scans != 48 || report.ContainerRows != 144 || len(days) != 1 {
+		t.Errorf("Expected 48 scans of 3 containers over one day, got %d scans, %d rows, days %v", report.Scans, report.ContainerRows, days)
+	}
+	if report.Writes[0].Latency.Count != 48 || len(report.Queries) != 10 || report.Queries[0].Latency.Count != 2 {
+		t.Errorf("Unexpected measurements %+v %+v", report.Writes, report.Queries)
+	}
+	if report.DBBytes == 0 {
+		t.Error("Expected the database size")
+	}
+
+	containers, err := db.GetLatestContainers()
+	if err != nil || len(containers) != 6 {
+		t.Errorf("Expected the latest scan of 6 containers, got %d: %v", len(containers), err)
+	}
+
+	if _, err := Run(context.Background(), db, dbPath, Options{Hosts: 1, Containers: 1, Days: 1, Interval: time.Second, QueryRuns: 1}, nil); err == nil {
+		t.Error("Expected an interval under a minute to be rejected")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	var samples []time.Duration
+	for i := 100; i >= 1; i-- {
+		samples = append(samples, time.Duration(i)*time.Millisecond)
+	}
+	l := summarize(samples)
+	if l.Count != 100 || l.P50 != 50 || l.P95 != 95 || l.P99 != 99 || l.Max != 100 || l.Total != 5050 {
+		t.Errorf("Unexpected summary %+v", l)
+	}
+}
@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// agentTokenRotationsKept is how many rotation attempts are kept per host
+const agentTokenRotationsKept = 20
+
+// ErrAgentTokenChanged is returned when a host's token changed while it was being rotated
+var ErrAgentTokenChanged = errors.New("the host's agent token was changed meanwhile")
+
+// SaveRotatedAgentToken replaces a host's agent token with the one its agent rotated to and
+// records the rotation, in one transaction. The token is only replaced while it is still
+// oldToken, so a concurrent edit of the host isn't overwritten.
+func (db *DB) SaveRotatedAgentToken(hostID int64, oldToken, newToken string, rotation models.AgentTokenRotation) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE hosts SET agent_token = ?, agent_token_changed_at = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND COALESCE(agent_token, '') = ?
+	`, newToken, rotation.RotatedAt, hostID, oldToken)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrAgentTokenChanged
+	}
+
+	rotation.HostID = hostID
+	rotation.Success = true
+	if err := insertAgentTokenRotation(tx, rotation); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RecordAgentTokenRotation records a rotation attempt that failed
+func (db *DB) RecordAgentTokenRotation(rotation models.AgentTokenRotation) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := insertAgentTokenRotation(tx, rotation); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertAgentTokenRotation adds a rotation attempt and drops the host's oldest beyond
+// agentTokenRotationsKept
+func insertAgentTokenRotation(tx *sql.Tx, rotation models.AgentTokenRotation) error {
+	if _, err := tx.Exec(`
+		INSERT INTO agent_token_rotations (host_id, rotated_at, source, success, error, previous_expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, rotation.HostID, rotation.RotatedAt, rotation.Source, rotation.Success, rotation.Error, rotation.PreviousExpiresAt); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`
+		DELETE FROM agent_token_rotations WHERE host_id = ? AND id NOT IN (
+			SELECT id FROM agent_token_rotations WHERE host_id = ? ORDER BY id DESC LIMIT ?
+		)
+	`, rotation.HostID, rotation.HostID, agentTokenRotationsKept)
+	return err
+}
+
+// GetAgentTokenAges returns when the token of each agent host was set, with its last rotation
+// attempt, oldest token first. A nil hostIDs returns every agent host. AgeDays and Expired are
+// left to the caller, which knows the maximum age.
+func (db *DB) GetAgentTokenAges(hostIDs []int64) ([]models.AgentTokenAge, error) {
+	var visible map[int64]bool
+	if hostIDs != nil {
+		visible = make(map[int64]bool, len(hostIDs))
+		for _, id := range hostIDs {
+			visible[id] = true
+		}
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT id, name, enabled, agent_token_changed_at, created_at
+		FROM hosts WHERE host_type = ?
+	`, models.HostTypeAgent)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ages := []models.AgentTokenAge{}
+	index := make(map[int64]int)
+	for rows.Next() {
+		var age models.AgentTokenAge
+		var changedAt sql.NullTime
+		var createdAt time.Time
+		if err := rows.Scan(&age.HostID, &age.HostName, &age.Enabled, &changedAt, &createdAt); err != nil {
+			return nil, err
+		}
+		if visible != nil && !visible[age.HostID] {
+			continue
+		}
+		age.ChangedAt = createdAt
+		if changedAt.Valid {
+			age.ChangedAt = changedAt.Time
+		}
+		index[age.HostID] = len(ages)
+		ages = append(ages, age)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	rotations, err := db.conn.Query(`
+		SELECT id, host_id, rotated_at, source, success, error, previous_expires_at
+		FROM agent_token_rotations
+		WHERE id IN (SELECT MAX(id) FROM agent_token_rotations GROUP BY host_id)
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rotations.Close()
+	for rotations.Next() {
+		var r models.AgentTokenRotation
+		var previousExpires sql.NullTime
+		if err := rotations.Scan(&r.ID, &r.HostID, &r.RotatedAt, &r.Source, &r.Success, &r.Error, &previousExpires); err != nil {
+			return nil, err
+		}
+		if previousExpires.Valid {
+			r.PreviousExpiresAt = &previousExpires.Time
+		}
+		if i, ok := index[r.HostID]; ok {
+			ages[i].LastRotation = &r
+		}
+	}
+	if err := rotations.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(ages, func(i, j int) bool {
+		if !ages[i].ChangedAt.Equal(ages[j].ChangedAt) {
+			return ages[i].ChangedAt.Before(ages[j].ChangedAt)
+		}
+		return ages[i].HostName < ages[j].HostName
+	})
+	return ages, nil
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestAgentTokenRotation(t *testing.T) {
+	db := setupTestDB(t)
+
+	nasID, err := db.AddHost(models.Host{Name: "nas", Address: "agent://nas:9876", AgentToken: "old", Enabled: true})
+	if err != nil {
+		t.Fatalf("AddHost failed: %v", err)
+	}
+	vpsID, _ := db.AddHost(models.Host{Name: "vps", Address: "agent://vps:9876", AgentToken: "vps", Enabled: true})
+	db.AddHost(models.Host{Name: "local", Address: "unix:///var/run/docker.sock", Enabled: true})
+
+	ages, err := db.GetAgentTokenAges(nil)
+	if err != nil || len(ages) != 2 || ages[0].ChangedAt.IsZero() || ages[0].LastRotation != nil {
+		t.Fatalf("Expected the two agent hosts aged from their creation, got %+v (%v)", ages, err)
+	}
+
+	expires := time.Now().Add(time.Hour)
+	rotation := models.AgentTokenRotation{RotatedAt: time.Now().Add(time.Minute), Source: models.TokenRotationManual, PreviousExpiresAt: &expires}
+	if err := db.SaveRotatedAgentToken(nasID, "stale", "new", rotation); !errors.Is(err, ErrAgentTokenChanged) {
+		t.Errorf("Expected ErrAgentTokenChanged for a token that changed meanwhile, got %v", err)
+	}
+	if err := db.SaveRotatedAgentToken(nasID, "old", "new", rotation); err != nil {
+		t.Fatalf("SaveRotatedAgentToken failed: %v", err)
+	}
+	if host, _ := db.GetHost(nasID); host.AgentToken != "new" {
+		t.Errorf("Expected the new token, got %q", host.AgentToken)
+	}
+
+	db.RecordAgentTokenRotation(models.AgentTokenRotation{HostID: vpsID, RotatedAt: time.Now(), Source: models.TokenRotationScheduled, Error: "agent offline"})
+	ages, _ = db.GetAgentTokenAges([]int64{nasID, vpsID})
+	if len(ages) != 2 || ages[0].HostName != "vps" || ages[1].HostName != "nas" {
+		t.Fatalf("Expected the older vps token first, got %+v", ages)
+	}
+	if r := ages[1].LastRotation; r == nil || !r.Success || r.PreviousExpiresAt == nil {
+		t.Errorf("Expected the successful rotation of nas, got %+v", r)
+	}
+	if r := ages[0].LastRotation; r == nil || r.Success || r.Error != "agent offline" {
+		t.Errorf("Expected the failed rotation of vps, got %+v", r)
+	}
+	if ages, _ := db.GetAgentTokenAges([]int64{vpsID}); len(ages) != 1 {
+		t.Errorf("Expected only the visible host, got %+v", ages)
+	}
+
+	// Editing the token restarts its age; other edits don't
+	before, _ := db.GetAgentTokenAges([]int64{vpsID})
+	host, _ := db.GetHost(vpsID)
+	host.Description = "edited"
+	db.UpdateHost(*host)
+	if after, _ := db.GetAgentTokenAges([]int64{vpsID}); !after[0].ChangedAt.Equal(before[0].ChangedAt) {
+		t.Errorf("Expected an edit without a new token to keep its age, got %v then %v", before[0].ChangedAt, after[0].ChangedAt)
+	}
+	host.AgentToken = "edited"
+	db.UpdateHost(*host)
+	if after, _ := db.GetAgentTokenAges([]int64{vpsID}); !after[0].ChangedAt.After(before[0].ChangedAt) {
+		t.Errorf("Expected the edited token to be younger, got %v then %v", before[0].ChangedAt, after[0].ChangedAt)
+	}
+}
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// CreateAnnotation stores an annotation and returns its ID. Times are stored in UTC, so they
+// compare as text with the bounds of GetAnnotations.
+func (db *DB) CreateAnnotation(a models.Annotation) (int64, error) {
+	res, err := db.conn.Exec(`
+		INSERT INTO annotations (at, end_at, text, host_id, container_name, created_at, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, a.At.UTC(), annotationEnd(a.EndAt), a.Text, annotationHost(a.HostID), a.ContainerName, a.CreatedAt.UTC(), a.CreatedBy)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// UpdateAnnotation replaces the time, text and target of an annotation; sql.ErrNoRows when it
+// doesn't exist
+func (db *DB) UpdateAnnotation(a models.Annotation) error {
+	res, err := db.conn.Exec(`
+		UPDATE annotations SET at = ?, end_at = ?, text = ?, host_id = ?, container_name = ? WHERE id = ?
+	`, a.At.UTC(), annotationEnd(a.EndAt), a.Text, annotationHost(a.HostID), a.ContainerName, a.ID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return err
+}
+
+// DeleteAnnotation removes an annotation; deleting one that doesn't exist is not an error
+func (db *DB) DeleteAnnotation(id int64) error {
+	_, err := db.conn.Exec(`DELETE FROM annotations WHERE id = ?`, id)
+	return err
+}
+
+// GetAnnotation returns an annotation by ID; sql.ErrNoRows when it doesn't exist
+func (db *DB) GetAnnotation(id int64) (*models.Annotation, error) {
+	annotations, err := db.queryAnnotations(`WHERE a.id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(annotations) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &annotations[0], nil
+}
+
+// GetAnnotations returns the annotations selected by a filter, oldest first
+func (db *DB) GetAnnotations(filter models.AnnotationFilter) ([]models.Annotation, error) {
+	var conditions []string
+	var args []interface{}
+	if !filter.End.IsZero() {
+		conditions = append(conditions, `a.at <= ?`)
+		args = append(args, filter.End.UTC())
+	}
+	if !filter.Start.IsZero() {
+		conditions = append(conditions, `COALESCE(a.end_at, a.at) >= ?`)
+		args = append(args, filter.Start.UTC())
+	}
+	if filter.HostIDs != nil {
+		hostCondition := `a.host_id IS NULL`
+		if len(filter.HostIDs) > 0 {
+			hostCondition += ` OR a.host_id IN (` + strings.TrimSuffix(strings.Repeat("?,", len(filter.HostIDs)), ",") + `)`
+			for _, id := range filter.HostIDs {
+				args = append(args, id)
+			}
+		}
+		conditions = append(conditions, `(`+hostCondition+`)`)
+	}
+	if filter.ContainerName != "" {
+		conditions = append(conditions, `(a.container_name = '' OR a.container_name = ?)`)
+		args = append(args, filter.ContainerName)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = `WHERE ` + strings.Join(conditions, ` AND `)
+	}
+	return db.queryAnnotations(where, args...)
+}
+
+func (db *DB) queryAnnotations(where string, args ...interface{}) ([]models.Annotation, error) {
+	rows, err := db.conn.Query(`
+		SELECT a.id, a.at, a.end_at, a.text, COALESCE(a.host_id, 0), COALESCE(h.name, ''), a.container_name, a.created_at, a.created_by
+		FROM annotations a
+		LEFT JOIN hosts h ON h.id = a.host_id
+		`+where+`
+		ORDER BY a.at, a.id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	annotations := make([]models.Annotation, 0)
+	for rows.Next() {
+		var a models.Annotation
+		var endAt sql.NullTime
+		if err := rows.Scan(&a.ID, &a.At, &endAt, &a.Text, &a.HostID, &a.HostName, &a.ContainerName, &a.CreatedAt, &a.CreatedBy); err != nil {
+			return nil, err
+		}
+		if endAt.Valid {
+			a.EndAt = &endAt.Time
+		}
+		annotations = append(annotations, a)
+	}
+	return annotations, rows.Err()
+}
+
+// reportAnnotationFilter selects the annotations of a changes report's period: those of every
+// host, or the global ones and those of hostFilter
+func reportAnnotationFilter(start, end time.Time, hostFilter int64) models.AnnotationFilter {
+	filter := models.AnnotationFilter{Start: start, End: end}
+	if hostFilter > 0 {
+		filter.HostIDs = []int64{hostFilter}
+	}
+	return filter
+}
+
+// annotationEnd is the end_at column of an annotation: NULL for a point in time
+func annotationEnd(endAt *time.Time) interface{} {
+	if endAt == nil {
+		return nil
+	}
+	return endAt.UTC()
+}
+
+// annotationHost is the host_id column of an annotation: NULL for every host
+func annotationHost(hostID int64) interface{} {
+	if hostID == 0 {
+		return nil
+	}
+	return hostID
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestAnnotations(t *testing.T) {
+	db := setupTestDB(t)
+
+	nas, err := db.AddHost(models.Host{Name: "nas", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	pi, err := db.AddHost(models.Host{Name: "pi", Address: "tcp://pi:2375", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	base := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	outageEnd := base.Add(3 * time.Hour)
+	create := func(a models.Annotation) int64 {
+		t.Helper()
+		a.CreatedAt = base
+		id, err := db.CreateAnnotation(a)
+		if err != nil {
+			t.Fatalf("Failed to create annotation: %v", err)
+		}
+		return id
+	}
+	outage := create(models.Annotation{At: base, EndAt: &outageEnd, Text: "Power outage"})
+	create(models.Annotation{At: base.Add(24 * time.Hour), Text: "Migrated to new NAS", HostID: nas, CreatedBy: "admin"})
+	create(models.Annotation{At: base.Add(25 * time.Hour), Text: "Restored postgres from backup", HostID: nas, ContainerName: "postgres"})
+	create(models.Annotation{At: base.Add(26 * time.Hour), Text: "Moved the pi", HostID: pi})
+
+	texts := func(filter models.AnnotationFilter) []string {
+		t.Helper()
+		annotations, err := db.GetAnnotations(filter)
+		if err != nil {
+			t.Fatalf("GetAnnotations failed: %v", err)
+		}
+		var texts []string
+		for _, a := range annotations {
+			texts = append(texts, a.Text)
+		}
+		return texts
+	}
+
+	if all := texts(models.AnnotationFilter{}); len(all) != 4 || all[0] != "Power outage" {
+		t.Errorf("Expected every annotation oldest first, got %v", all)
+	}
+	if got := texts(models.AnnotationFilter{HostIDs: []int64{nas}, ContainerName: "postgres"}); len(got) != 3 || got[2] != "Restored postgres from backup" {
+		t.Errorf("Expected the global, nas and postgres annotations, got %v", got)
+	}
+	if got := texts(models.AnnotationFilter{HostIDs: []int64{nas}, ContainerName: "redis"}); len(got) != 2 {
+		t.Errorf("Expected no annotations of other containers, got %v", got)
+	}
+	// The outage started before the period but lasted into it
+	if got := texts(models.AnnotationFilter{Start: base.Add(time.Hour), End: base.Add(2 * time.Hour)}); len(got) != 1 || got[0] != "Power outage" {
+		t.Errorf("Expected the outage overlapping the period, got %v", got)
+	}
+	if got := texts(models.AnnotationFilter{HostIDs: []int64{}, Start: base.Add(4 * time.Hour)}); len(got) != 0 {
+		t.Errorf("Expected no annotations, got %v", got)
+	}
+
+	saved, err := db.GetAnnotation(outage)
+	if err != nil {
+		t.Fatalf("GetAnnotation failed: %v", err)
+	}
+	if saved.EndAt == nil || !saved.EndAt.Equal(outageEnd) || saved.HostID != 0 || !saved.At.Equal(base) {
+		t.Errorf("Unexpected annotation %+v", saved)
+	}
+	saved.Text = "Power outage (whole rack)"
+	saved.EndAt = nil
+	saved.HostID = nas
+	if err := db.UpdateAnnotation(*saved); err != nil {
+		t.Fatalf("UpdateAnnotation failed: %v", err)
+	}
+	if updated, _ := db.GetAnnotation(outage); updated.Text != "Power outage (whole rack)" || updated.EndAt != nil || updated.HostName != "nas" {
+		t.Errorf("Unexpected updated annotation %+v", updated)
+	}
+
+	if err := db.DeleteAnnotation(outage); err != nil {
+		t.Fatalf("DeleteAnnotation failed: %v", err)
+	}
+	if err := db.UpdateAnnotation(*saved); err == nil {
+		t.Error("Expected an error updating a deleted annotation")
+	}
+	// Annotations of a host go with it
+	if err := db.DeleteHost(pi); err != nil {
+		t.Fatalf("Failed to delete host: %v", err)
+	}
+	if all := texts(models.AnnotationFilter{}); len(all) != 2 {
+		t.Errorf("Expected the annotations of the deleted host to be gone, got %v", all)
+	}
+}
+
+func TestAnnotationsInLifecycleAndReport(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	start := time.Now().Add(-3 * time.Hour).Truncate(time.Second)
+	for i := 0; i < 3; i++ {
+		if err := db.SaveContainers([]models.Container{
+			{ID: "pg1", Name: "postgres", Image: "postgres:16", ImageID: "sha256:aaa", State: "running", HostID: hostID, HostName: "nas", ScannedAt: start.Add(time.Duration(i) * time.Hour)},
+		}); err != nil {
+			t.Fatalf("Failed to save containers: %v", err)
+		}
+	}
+
+	for _, a := range []models.Annotation{
+		{At: start.Add(-24 * time.Hour), Text: "Before the container existed"},
+		{At: start.Add(90 * time.Minute), Text: "Power outage"},
+		{At: start.Add(100 * time.Minute), Text: "Restored from backup", HostID: hostID, ContainerName: "postgres"},
+		{At: start.Add(110 * time.Minute), Text: "Other container", HostID: hostID, ContainerName: "redis"},
+	} {
+		a.CreatedAt = start
+		if _, err := db.CreateAnnotation(a); err != nil {
+			t.Fatalf("Failed to create annotation: %v", err)
+		}
+	}
+
+	events, err := db.GetContainerLifecycleEvents("postgres", hostID)
+	if err != nil {
+		t.Fatalf("GetContainerLifecycleEvents failed: %v", err)
+	}
+	var annotations []string
+	for i, e := range events {
+		if e.EventType != "annotation" {
+			continue
+		}
+		annotations = append(annotations, e.Description)
+		if e.AnnotationID == 0 || i == 0 || i == len(events)-1 {
+			t.Errorf("Expected an annotation with its ID between first and last seen, got %+v at %d", e, i)
+		}
+	}
+	if len(annotations) != 2 || annotations[0] != "Power outage" || annotations[1] != "Restored from backup" {
+		t.Errorf("Expected the outage and the restore in the timeline, got %v", annotations)
+	}
+
+	report, err := db.GetChangesReport(start.Add(-time.Hour), time.Now(), 0)
+	if err != nil {
+		t.Fatalf("GetChangesReport failed: %v", err)
+	}
+	if len(report.Annotations) != 3 || report.Annotations[0].Text != "Power outage" {
+		t.Errorf("Expected the annotations of the period, got %+v", report.Annotations)
+	}
+}
@@ -0,0 +1,221 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/container-census/container-census/internal/backup"
+	"github.com/container-census/container-census/internal/models"
+)
+
+// backupStatusRank orders jobs that need attention first
+var backupStatusRank = map[string]int{
+	models.BackupStatusOverdue: 0,
+	models.BackupStatusFailed:  1,
+	models.BackupStatusRunning: 2,
+	models.BackupStatusPending: 3,
+	models.BackupStatusOK:      4,
+}
+
+// backupRunHistory is what the recorded runs say about one job
+type backupRunHistory struct {
+	hostName    string
+	image       string
+	interval    int64
+	firstRun    time.Time
+	lastRun     *models.BackupRun
+	lastSuccess *models.BackupRun
+}
+
+// GetBackupJobs returns every current backup container, plus jobs whose containers are gone but
+// whose runs are still recorded, with their status evaluated at now
+func (db *DB) GetBackupJobs(now time.Time) ([]models.BackupJob, error) {
+	histories, err := db.getBackupRunHistories()
+	if err != nil {
+		return nil, err
+	}
+
+	containers, err := db.GetLatestContainers()
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]models.BackupJob, 0)
+	seen := make(map[string]bool)
+	for _, c := range containers {
+		tool, interval, ok := backup.Detect(c)
+		if !ok {
+			continue
+		}
+		key := backupJobKey(c.HostID, c.Name)
+		seen[key] = true
+
+		job := models.BackupJob{
+			HostID:          c.HostID,
+			HostName:        c.HostName,
+			ContainerName:   c.Name,
+			Image:           c.Image,
+			Tool:            tool,
+			Kind:            models.BackupKindJob,
+			State:           c.State,
+			IntervalSeconds: int64(interval.Seconds()),
+			FirstSeen:       c.Created,
+		}
+
+		// Containers that Docker restarts on their own run their own schedule
+		inspection, err := db.GetContainerInspection(c.HostID, c.ID)
+		if err != nil {
+			return nil, err
+		}
+		if inspection != nil && (inspection.Config.RestartPolicy == "always" || inspection.Config.RestartPolicy == "unless-stopped") {
+			job.Kind = models.BackupKindService
+		}
+
+		if job.Kind == models.BackupKindService {
+			lastRunning, err := db.lastSeenRunning(c.HostID, c.Name)
+			if err != nil {
+				return nil, err
+			}
+			job.LastSuccessAt = lastRunning
+		} else if history, ok := histories[key]; ok {
+			job.LastRun = history.lastRun
+			if history.lastSuccess != nil {
+				job.LastSuccessAt = &history.lastSuccess.FinishedAt
+			}
+		}
+
+		backup.Evaluate(&job, now)
+		jobs = append(jobs, job)
+	}
+
+	for key, history := range histories {
+		if seen[key] {
+			continue
+		}
+		job := models.BackupJob{
+			HostID:          history.lastRun.HostID,
+			HostName:        history.hostName,
+			ContainerName:   history.lastRun.ContainerName,
+			Image:           history.image,
+			Kind:            models.BackupKindJob,
+			State:           "removed",
+			IntervalSeconds: history.interval,
+			FirstSeen:       history.firstRun,
+			LastRun:         history.lastRun,
+		}
+		job.Tool, _, _ = backup.Detect(models.Container{Name: job.ContainerName, Image: job.Image})
+		if job.Tool == "" {
+			job.Tool = "backup"
+		}
+		if history.lastSuccess != nil {
+			job.LastSuccessAt = &history.lastSuccess.FinishedAt
+		}
+		backup.Evaluate(&job, now)
+		jobs = append(jobs, job)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		a, b := jobs[i], jobs[j]
+		if backupStatusRank[a.Status] != backupStatusRank[b.Status] {
+			return backupStatusRank[a.Status] < backupStatusRank[b.Status]
+		}
+		if a.HostName != b.HostName {
+			return a.HostName < b.HostName
+		}
+		return a.ContainerName < b.ContainerName
+	})
+
+	return jobs, nil
+}
+
+// GetBackupRuns returns the recorded runs of a backup job, newest first
+func (db *DB) GetBackupRuns(hostID int64, containerName string, limit int) ([]models.BackupRun, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, host_id, container_name, container_id, started_at, finished_at, exit_code
+		FROM backup_runs
+		WHERE host_id = ? AND container_name = ?
+		ORDER BY finished_at DESC
+		LIMIT ?
+	`, hostID, containerName, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := make([]models.BackupRun, 0)
+	for rows.Next() {
+		var run models.BackupRun
+		if err := rows.Scan(&run.ID, &run.HostID, &run.ContainerName, &run.ContainerID,
+			&run.StartedAt, &run.FinishedAt, &run.ExitCode); err != nil {
+			return nil, err
+		}
+		run.Success = run.ExitCode == 0
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
+
+// getBackupRunHistories summarizes the recorded runs of every job
+func (db *DB) getBackupRunHistories() (map[string]*backupRunHistory, error) {
+	rows, err := db.conn.Query(`
+		SELECT r.id, r.host_id, h.name, r.container_name, r.container_id, r.image, r.started_at, r.finished_at, r.exit_code, r.interval_seconds
+		FROM backup_runs r
+		INNER JOIN hosts h ON r.host_id = h.id
+		ORDER BY r.finished_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	histories := make(map[string]*backupRunHistory)
+	for rows.Next() {
+		var run models.BackupRun
+		var hostName, image string
+		var interval int64
+		if err := rows.Scan(&run.ID, &run.HostID, &hostName, &run.ContainerName, &run.ContainerID, &image,
+			&run.StartedAt, &run.FinishedAt, &run.ExitCode, &interval); err != nil {
+			return nil, err
+		}
+		run.Success = run.ExitCode == 0
+
+		key := backupJobKey(run.HostID, run.ContainerName)
+		history, ok := histories[key]
+		if !ok {
+			// Rows are newest first, so the first row of a job is its latest run
+			history = &backupRunHistory{hostName: hostName, image: image, interval: interval, lastRun: &run}
+			histories[key] = history
+		}
+		if run.Success && history.lastSuccess == nil {
+			history.lastSuccess = &run
+		}
+		history.firstRun = run.StartedAt
+	}
+
+	return histories, rows.Err()
+}
+
+// lastSeenRunning returns the last scan that saw a container running, or nil if none did
+func (db *DB) lastSeenRunning(hostID int64, name string) (*time.Time, error) {
+	var scannedAt time.Time
+	err := db.conn.QueryRow(`
+		SELECT scanned_at FROM containers
+		WHERE host_id = ? AND name = ? AND state = 'running'
+		ORDER BY scanned_at DESC
+		LIMIT 1
+	`, hostID, name).Scan(&scannedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &scannedAt, nil
+}
+
+func backupJobKey(hostID int64, containerName string) string {
+	return fmt.Sprintf("%d/%s", hostID, containerName)
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestBackupJobs(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	now := time.Now()
+	job := func(id, name, image, state string, scannedAt, started time.Time, exitCode int) models.Container {
+		return models.Container{
+			ID: id, Name: name, Image: image, State: state, Created: now.Add(-72 * time.Hour),
+			HostID: hostID, HostName: "nas", ScannedAt: scannedAt,
+			Config: &models.ContainerConfig{
+				RestartPolicy: "no",
+				ExitCode:      exitCode,
+				StartedAt:     started,
+				FinishedAt:    started.Add(10 * time.Minute),
+			},
+		}
+	}
+	service := func(scannedAt time.Time) models.Container {
+		return models.Container{
+			ID: "borg1", Name: "borgmatic", Image: "b3vis/borgmatic:latest", State: "running", Created: now.Add(-72 * time.Hour),
+			HostID: hostID, HostName: "nas", ScannedAt: scannedAt,
+			Config: &models.ContainerConfig{RestartPolicy: "unless-stopped"},
+		}
+	}
+	scan := func(containers ...models.Container) {
+		if err := db.SaveContainers(containers); err != nil {
+			t.Fatalf("Failed to save containers: %v", err)
+		}
+	}
+
+	// Two scans see the same successful run, then the next run fails
+	scan(
+		job("r1", "restic", "restic/restic", "exited", now.Add(-20*time.Hour), now.Add(-21*time.Hour), 0),
+		job("d1", "db-backup", "alpine", "exited", now.Add(-20*time.Hour), now.Add(-21*time.Hour), 0),
+		service(now.Add(-20*time.Hour)),
+	)
+	scan(
+		job("r1", "restic", "restic/restic", "exited", now.Add(-10*time.Hour), now.Add(-21*time.Hour), 0),
+		service(now.Add(-10*time.Hour)),
+	)
+	scan(
+		job("r1", "restic", "restic/restic", "exited", now, now.Add(-time.Hour), 3),
+		job("w1", "web", "nginx", "exited", now, now.Add(-time.Hour), 1),
+		service(now),
+	)
+
+	runs, err := db.GetBackupRuns(hostID, "restic", 10)
+	if err != nil {
+		t.Fatalf("GetBackupRuns failed: %v", err)
+	}
+	if len(runs) != 2 || runs[0].ExitCode != 3 || runs[0].Success || !runs[1].Success {
+		t.Fatalf("Expected a failed run after a successful one, got %+v", runs)
+	}
+	if web, _ := db.GetBackupRuns(hostID, "web", 10); len(web) != 0 {
+		t.Errorf("Expected runs of non-backup containers to be ignored, got %+v", web)
+	}
+
+	jobs, err := db.GetBackupJobs(now)
+	if err != nil {
+		t.Fatalf("GetBackupJobs failed: %v", err)
+	}
+	byName := make(map[string]models.BackupJob)
+	for _, j := range jobs {
+		byName[j.ContainerName] = j
+	}
+	if len(jobs) != 3 {
+		t.Fatalf("Expected restic, borgmatic and the removed db-backup job, got %+v", jobs)
+	}
+
+	if restic := byName["restic"]; restic.Status != models.BackupStatusFailed || restic.Tool != "restic" || restic.LastSuccessAt == nil {
+		t.Errorf("Unexpected restic job: %+v", restic)
+	}
+	if borg := byName["borgmatic"]; borg.Kind != models.BackupKindService || borg.Status != models.BackupStatusOK {
+		t.Errorf("Unexpected borgmatic job: %+v", borg)
+	}
+	if dump := byName["db-backup"]; dump.State != "removed" || dump.Tool != "backup" || dump.Status != models.BackupStatusOK {
+		t.Errorf("Unexpected removed job: %+v", dump)
+	}
+
+	// A day later nothing has succeeded again
+	jobs, err = db.GetBackupJobs(now.Add(24 * time.Hour))
+	if err != nil {
+		t.Fatalf("GetBackupJobs failed: %v", err)
+	}
+	if jobs[0].Status != models.BackupStatusOverdue {
+		t.Errorf("Expected an overdue job first, got %+v", jobs[0])
+	}
+}
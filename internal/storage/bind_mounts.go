@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/container-census/container-census/internal/inspect"
+	"github.com/container-census/container-census/internal/models"
+)
+
+// GetBindMountReport indexes the bind mounts of the containers in the hosts' latest scans (one
+// host, or all with hostID 0), flagging paths several containers write to and sensitive paths
+func (db *DB) GetBindMountReport(hostID int64) (*models.BindMountReport, error) {
+	rows, err := db.conn.Query(`
+		SELECT cc.container_id, cc.container_name, cc.host_id, cc.host_name, c.state, cc.config
+		FROM container_configs cc
+		INNER JOIN containers c ON c.id = cc.container_id AND c.host_id = cc.host_id
+		INNER JOIN (
+			SELECT host_id, MAX(scanned_at) as max_scan
+			FROM containers
+			WHERE (? = 0 OR host_id = ?)
+			GROUP BY host_id
+		) latest ON c.host_id = latest.host_id AND c.scanned_at = latest.max_scan
+		WHERE cc.collected_at = c.scanned_at
+	`, hostID, hostID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var containers []inspect.MountedContainer
+	for rows.Next() {
+		var c inspect.MountedContainer
+		var configJSON string
+		if err := rows.Scan(&c.ContainerID, &c.ContainerName, &c.HostID, &c.HostName, &c.State, &configJSON); err != nil {
+			return nil, err
+		}
+		var config models.ContainerConfig
+		if err := json.Unmarshal([]byte(configJSON), &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal container config: %w", err)
+		}
+		c.Mounts = config.Mounts
+		containers = append(containers, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	report := inspect.BuildBindMountReport(containers)
+	return &report, nil
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestGetBindMountReport(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "host1", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	container := func(id, name string, at time.Time, mounts ...models.ConfigMount) models.Container {
+		return models.Container{
+			ID: id, Name: name, Image: "app:latest", State: "running",
+			HostID: hostID, HostName: "host1", ScannedAt: at,
+			Config: &models.ContainerConfig{Mounts: mounts},
+		}
+	}
+	data := models.ConfigMount{Type: "bind", Source: "/srv/data", Destination: "/data", RW: true}
+	at := time.Now().Add(-time.Hour)
+
+	// An earlier scan where only one container wrote to the path
+	if err := db.SaveContainers([]models.Container{container("a1", "a", at, data)}); err != nil {
+		t.Fatalf("Failed to save containers: %v", err)
+	}
+	if report, err := db.GetBindMountReport(hostID); err != nil || report.Overlaps != 0 || len(report.Paths) != 1 {
+		t.Fatalf("Expected one path without overlap, got %+v (%v)", report, err)
+	}
+
+	later := at.Add(time.Minute)
+	if err := db.SaveContainers([]models.Container{
+		container("a1", "a", later, data),
+		container("b1", "b", later, data, models.ConfigMount{Type: "bind", Source: "/etc/localtime", Destination: "/etc/localtime"}),
+	}); err != nil {
+		t.Fatalf("Failed to save containers: %v", err)
+	}
+	report, err := db.GetBindMountReport(0)
+	if err != nil {
+		t.Fatalf("GetBindMountReport failed: %v", err)
+	}
+	if report.Overlaps != 1 || report.Sensitive != 1 || len(report.Paths) != 2 {
+		t.Fatalf("Expected the shared path and /etc/localtime to be flagged, got %+v", report)
+	}
+	if p := report.Paths[0]; p.Source != "/etc/localtime" || p.Sensitive != "/etc" {
+		t.Errorf("Expected /etc/localtime first, got %+v", p)
+	}
+	if p := report.Paths[1]; !p.Overlap || len(p.Writers) != 2 {
+		t.Errorf("Expected a and b writing to /srv/data, got %+v", p)
+	}
+}
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// SaveCanaryUpdate creates a canary rollout (ID 0) or stores its current state
+func (db *DB) SaveCanaryUpdate(c *models.CanaryUpdate) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if c.ID == 0 {
+		res, err := db.conn.Exec(`INSERT INTO canary_updates (image, status, rollout, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`,
+			c.Image, c.Status, string(data), c.CreatedAt, now)
+		if err != nil {
+			return err
+		}
+		c.ID, err = res.LastInsertId()
+		return err
+	}
+
+	res, err := db.conn.Exec(`UPDATE canary_updates SET status = ?, rollout = ?, updated_at = ? WHERE id = ?`,
+		c.Status, string(data), now, c.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetCanaryUpdate returns one canary rollout; sql.ErrNoRows when it doesn't exist
+func (db *DB) GetCanaryUpdate(id int64) (*models.CanaryUpdate, error) {
+	return scanCanaryUpdate(db.conn.QueryRow(`SELECT id, rollout FROM canary_updates WHERE id = ?`, id))
+}
+
+// GetCanaryUpdates returns the latest canary rollouts, newest first
+func (db *DB) GetCanaryUpdates(limit int) ([]models.CanaryUpdate, error) {
+	rows, err := db.conn.Query(`SELECT id, rollout FROM canary_updates ORDER BY created_at DESC, id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rollouts []models.CanaryUpdate
+	for rows.Next() {
+		c, err := scanCanaryUpdate(rows)
+		if err != nil {
+			return nil, err
+		}
+		rollouts = append(rollouts, *c)
+	}
+	return rollouts, rows.Err()
+}
+
+func scanCanaryUpdate(row interface{ Scan(...interface{}) error }) (*models.CanaryUpdate, error) {
+	var id int64
+	var data string
+	if err := row.Scan(&id, &data); err != nil {
+		return nil, err
+	}
+
+	var c models.CanaryUpdate
+	if err := json.Unmarshal([]byte(data), &c); err != nil {
+		return nil, fmt.Errorf("failed to decode canary rollout %d: %w", id, err)
+	}
+	c.ID = id
+	return &c, nil
+}
+
+// InterruptCanaryUpdates marks the rollouts that were still running as interrupted; called at
+// startup, since a rollout doesn't survive a restart of the server. Returns how many there were.
+func (db *DB) InterruptCanaryUpdates() (int, error) {
+	rows, err := db.conn.Query(`SELECT id, rollout FROM canary_updates WHERE status IN (?, ?, ?)`,
+		models.CanaryStatusUpdating, models.CanaryStatusSoaking, models.CanaryStatusRollingOut)
+	if err != nil {
+		return 0, err
+	}
+	var running []models.CanaryUpdate
+	for rows.Next() {
+		c, err := scanCanaryUpdate(rows)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		running = append(running, *c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	for i := range running {
+		c := &running[i]
+		c.Status = models.CanaryStatusInterrupted
+		c.Reason = "The server stopped during the rollout"
+		c.FinishedAt = &now
+		for j := range c.Targets {
+			if c.Targets[j].Status == models.CanaryTargetPending {
+				c.Targets[j].Status = models.CanaryTargetSkipped
+			}
+		}
+		if err := db.SaveCanaryUpdate(c); err != nil {
+			return i, err
+		}
+	}
+	return len(running), nil
+}
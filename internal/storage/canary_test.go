@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestCanaryUpdates(t *testing.T) {
+	db := setupTestDB(t)
+
+	start := time.Date(2026, 10, 10, 14, 0, 0, 0, time.UTC)
+	finished := start.Add(20 * time.Minute)
+	done := &models.CanaryUpdate{
+		Image:       "prom/node-exporter:latest",
+		SoakMinutes: 10,
+		Status:      models.CanaryStatusCompleted,
+		Targets: []models.CanaryTarget{
+			{HostID: 1, HostName: "nas", ContainerName: "exporter", Status: models.CanaryTargetSucceeded},
+			{HostID: 2, HostName: "vps", ContainerName: "exporter", Status: models.CanaryTargetSucceeded},
+		},
+		CreatedAt:  start,
+		FinishedAt: &finished,
+	}
+	running := &models.CanaryUpdate{
+		Image:       "adguard/adguardhome:latest",
+		SoakMinutes: 30,
+		Status:      models.CanaryStatusSoaking,
+		Hooks:       &models.UpdateHooks{WaitForHealthy: true},
+		Targets: []models.CanaryTarget{
+			{HostID: 1, HostName: "nas", ContainerName: "adguard", Status: models.CanaryTargetSucceeded},
+			{HostID: 2, HostName: "vps", ContainerName: "adguard", Status: models.CanaryTargetPending},
+		},
+		CreatedAt: start.Add(time.Hour),
+	}
+	for _, c := range []*models.CanaryUpdate{done, running} {
+		if err := db.SaveCanaryUpdate(c); err != nil {
+			t.Fatalf("SaveCanaryUpdate failed: %v", err)
+		}
+		if c.ID == 0 {
+			t.Fatal("Expected the rollout to get an ID")
+		}
+	}
+
+	rollouts, err := db.GetCanaryUpdates(10)
+	if err != nil {
+		t.Fatalf("GetCanaryUpdates failed: %v", err)
+	}
+	if len(rollouts) != 2 || rollouts[0].ID != running.ID || rollouts[0].Hooks == nil || !rollouts[0].Hooks.WaitForHealthy {
+		t.Errorf("Expected rollouts newest first with their hooks, got %+v", rollouts)
+	}
+
+	n, err := db.InterruptCanaryUpdates()
+	if err != nil || n != 1 {
+		t.Fatalf("InterruptCanaryUpdates = %d, %v; want 1 rollout", n, err)
+	}
+	got, err := db.GetCanaryUpdate(running.ID)
+	if err != nil {
+		t.Fatalf("GetCanaryUpdate failed: %v", err)
+	}
+	if got.Status != models.CanaryStatusInterrupted || got.FinishedAt == nil || got.Targets[1].Status != models.CanaryTargetSkipped || got.Targets[0].Status != models.CanaryTargetSucceeded {
+		t.Errorf("Expected the running rollout interrupted with its pending target skipped, got %+v", got)
+	}
+	if got, _ := db.GetCanaryUpdate(done.ID); got.Status != models.CanaryStatusCompleted {
+		t.Errorf("Expected the completed rollout to stay completed, got %s", got.Status)
+	}
+
+	if _, err := db.GetCanaryUpdate(9999); err == nil {
+		t.Error("Expected an error for an unknown rollout")
+	}
+}
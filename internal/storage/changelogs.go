@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"database/sql"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// SaveImageChangelog caches the changelog of an image, replacing any previous one
+func (db *DB) SaveImageChangelog(c models.ImageChangelog) error {
+	var publishedAt interface{}
+	if !c.PublishedAt.IsZero() {
+		publishedAt = c.PublishedAt
+	}
+	_, err := db.conn.Exec(`
+		INSERT OR REPLACE INTO image_changelogs (image, source, version, name, url, summary, published_at, fetched_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, c.Image, c.Source, c.Version, c.Name, c.URL, c.Summary, publishedAt, c.FetchedAt)
+	return err
+}
+
+// GetImageChangelog returns the cached changelog of an image, or nil if none was fetched
+func (db *DB) GetImageChangelog(image string) (*models.ImageChangelog, error) {
+	var c models.ImageChangelog
+	var publishedAt sql.NullTime
+	err := db.conn.QueryRow(`
+		SELECT image, source, version, name, url, summary, published_at, fetched_at
+		FROM image_changelogs
+		WHERE image = ?
+	`, image).Scan(&c.Image, &c.Source, &c.Version, &c.Name, &c.URL, &c.Summary, &publishedAt, &c.FetchedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if publishedAt.Valid {
+		c.PublishedAt = publishedAt.Time
+	}
+	return &c, nil
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestImageChangelogs(t *testing.T) {
+	db := setupTestDB(t)
+
+	c, err := db.GetImageChangelog("nginx:latest")
+	if err != nil || c != nil {
+		t.Fatalf("Expected no changelog, got %+v (%v)", c, err)
+	}
+
+	// An image without a source repository is cached too
+	if err := db.SaveImageChangelog(models.ImageChangelog{Image: "nginx:latest", FetchedAt: time.Now()}); err != nil {
+		t.Fatalf("SaveImageChangelog failed: %v", err)
+	}
+	c, err = db.GetImageChangelog("nginx:latest")
+	if err != nil || c == nil || c.Source != "" || !c.PublishedAt.IsZero() {
+		t.Fatalf("Expected an empty cached changelog, got %+v (%v)", c, err)
+	}
+
+	published := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+	if err := db.SaveImageChangelog(models.ImageChangelog{
+		Image:       "nginx:latest",
+		Source:      "https://github.com/nginx/nginx",
+		Version:     "release-1.29.0",
+		URL:         "https://github.com/nginx/nginx/releases/tag/release-1.29.0",
+		Summary:     "Bugfixes",
+		PublishedAt: published,
+		FetchedAt:   time.Now(),
+	}); err != nil {
+		t.Fatalf("SaveImageChangelog failed: %v", err)
+	}
+	c, err = db.GetImageChangelog("nginx:latest")
+	if err != nil || c == nil {
+		t.Fatalf("GetImageChangelog failed: %v", err)
+	}
+	if c.Version != "release-1.29.0" || c.Summary != "Bugfixes" || !c.PublishedAt.Equal(published) {
+		t.Errorf("Expected the replaced changelog, got %+v", c)
+	}
+}
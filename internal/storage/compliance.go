@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// SaveComplianceAudit stores a host audit and sets its ID
+func (db *DB) SaveComplianceAudit(audit *models.ComplianceAudit) error {
+	checksJSON, err := json.Marshal(audit.Checks)
+	if err != nil {
+		return fmt.Errorf("failed to marshal compliance checks: %w", err)
+	}
+
+	result, err := db.conn.Exec(`
+		INSERT INTO compliance_audits (host_id, benchmark, checks, passed, failed, skipped, audited_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, audit.HostID, audit.Benchmark, string(checksJSON), audit.Passed, audit.Failed, audit.Skipped, audit.AuditedAt)
+	if err != nil {
+		return err
+	}
+
+	audit.ID, err = result.LastInsertId()
+	return err
+}
+
+// GetLatestComplianceAudits returns the most recent audit of every host that has been audited
+func (db *DB) GetLatestComplianceAudits() ([]models.ComplianceAudit, error) {
+	rows, err := db.conn.Query(`
+		SELECT a.id, a.host_id, h.name, a.benchmark, a.checks, a.passed, a.failed, a.skipped, a.audited_at
+		FROM compliance_audits a
+		INNER JOIN hosts h ON a.host_id = h.id
+		WHERE a.id IN (SELECT MAX(id) FROM compliance_audits GROUP BY host_id)
+		ORDER BY h.name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanComplianceAudits(rows)
+}
+
+// GetLatestComplianceAudit returns the most recent audit of a host, or nil if it has never been audited
+func (db *DB) GetLatestComplianceAudit(hostID int64) (*models.ComplianceAudit, error) {
+	audits, err := db.GetComplianceAuditHistory(hostID, 1)
+	if err != nil || len(audits) == 0 {
+		return nil, err
+	}
+	return &audits[0], nil
+}
+
+// GetComplianceAuditHistory returns a host's audits, newest first
+func (db *DB) GetComplianceAuditHistory(hostID int64, limit int) ([]models.ComplianceAudit, error) {
+	rows, err := db.conn.Query(`
+		SELECT a.id, a.host_id, h.name, a.benchmark, a.checks, a.passed, a.failed, a.skipped, a.audited_at
+		FROM compliance_audits a
+		INNER JOIN hosts h ON a.host_id = h.id
+		WHERE a.host_id = ?
+		ORDER BY a.audited_at DESC, a.id DESC
+		LIMIT ?
+	`, hostID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanComplianceAudits(rows)
+}
+
+func scanComplianceAudits(rows *sql.Rows) ([]models.ComplianceAudit, error) {
+	audits := make([]models.ComplianceAudit, 0)
+	for rows.Next() {
+		var audit models.ComplianceAudit
+		var checksJSON string
+		if err := rows.Scan(&audit.ID, &audit.HostID, &audit.HostName, &audit.Benchmark, &checksJSON,
+			&audit.Passed, &audit.Failed, &audit.Skipped, &audit.AuditedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(checksJSON), &audit.Checks); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal compliance checks: %w", err)
+		}
+		audits = append(audits, audit)
+	}
+	return audits, rows.Err()
+}
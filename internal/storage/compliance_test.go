@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestComplianceAudits(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "host1", Address: "agent://host1:9876", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	otherID, err := db.AddHost(models.Host{Name: "host2", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	if audit, err := db.GetLatestComplianceAudit(hostID); err != nil || audit != nil {
+		t.Fatalf("Expected no audit before the first run, got %+v, %v", audit, err)
+	}
+
+	now := time.Now()
+	older := &models.ComplianceAudit{
+		HostID:    hostID,
+		Benchmark: "CIS Docker Benchmark v1.6.0",
+		Checks:    []models.ComplianceCheck{{ID: "2.14", Title: "Live restore is enabled", Status: models.ComplianceStatusFail}},
+		Failed:    1,
+		AuditedAt: now.Add(-24 * time.Hour),
+	}
+	newer := &models.ComplianceAudit{
+		HostID:    hostID,
+		Benchmark: "CIS Docker Benchmark v1.6.0",
+		Checks:    []models.ComplianceCheck{{ID: "2.14", Title: "Live restore is enabled", Status: models.ComplianceStatusPass}},
+		Passed:    1,
+		AuditedAt: now,
+	}
+	other := &models.ComplianceAudit{HostID: otherID, Benchmark: "CIS Docker Benchmark v1.6.0", AuditedAt: now}
+	for _, audit := range []*models.ComplianceAudit{older, newer, other} {
+		if err := db.SaveComplianceAudit(audit); err != nil {
+			t.Fatalf("SaveComplianceAudit failed: %v", err)
+		}
+		if audit.ID == 0 {
+			t.Error("Expected saved audit to get an ID")
+		}
+	}
+
+	latest, err := db.GetLatestComplianceAudit(hostID)
+	if err != nil {
+		t.Fatalf("GetLatestComplianceAudit failed: %v", err)
+	}
+	if latest.ID != newer.ID || latest.HostName != "host1" || latest.Checks[0].Status != models.ComplianceStatusPass {
+		t.Errorf("Unexpected latest audit: %+v", latest)
+	}
+
+	history, err := db.GetComplianceAuditHistory(hostID, 10)
+	if err != nil {
+		t.Fatalf("GetComplianceAuditHistory failed: %v", err)
+	}
+	if len(history) != 2 || history[0].ID != newer.ID || history[1].Failed != 1 {
+		t.Errorf("Expected history newest first, got %+v", history)
+	}
+
+	all, err := db.GetLatestComplianceAudits()
+	if err != nil {
+		t.Fatalf("GetLatestComplianceAudits failed: %v", err)
+	}
+	if len(all) != 2 || all[0].ID != newer.ID || all[1].HostID != otherID {
+		t.Errorf("Expected one latest audit per host, got %+v", all)
+	}
+
+	// Cleanup drops old audits but keeps each host's latest
+	if err := db.CleanupOldData(time.Hour); err != nil {
+		t.Fatalf("CleanupOldData failed: %v", err)
+	}
+	if history, _ := db.GetComplianceAuditHistory(hostID, 10); len(history) != 1 || history[0].ID != newer.ID {
+		t.Errorf("Expected only the latest audit after cleanup, got %+v", history)
+	}
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// GetContainerInspection returns the sanitized configuration captured for a container at its
+// last scan, or nil if none has been collected. Short container IDs are matched by prefix.
+func (db *DB) GetContainerInspection(hostID int64, containerID string) (*models.ContainerInspection, error) {
+	var inspection models.ContainerInspection
+	var configJSON string
+
+	err := db.conn.QueryRow(`
+		SELECT container_id, container_name, host_id, host_name, config, collected_at
+		FROM container_configs
+		WHERE host_id = ? AND (container_id = ? OR container_id LIKE ?)
+		ORDER BY collected_at DESC
+		LIMIT 1
+	`, hostID, containerID, containerID+"%").Scan(
+		&inspection.ContainerID, &inspection.ContainerName, &inspection.HostID, &inspection.HostName,
+		&configJSON, &inspection.CollectedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(configJSON), &inspection.Config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal container config: %w", err)
+	}
+
+	return &inspection, nil
+}
+
+// GetLatestContainerInspections returns the configurations collected at the latest scan of each
+// host (or only hostID when non-zero)
+func (db *DB) GetLatestContainerInspections(hostID int64) ([]models.ContainerInspection, error) {
+	rows, err := db.conn.Query(`
+		SELECT cc.container_id, cc.container_name, cc.host_id, cc.host_name, cc.config, cc.collected_at
+		FROM container_configs cc
+		INNER JOIN containers c ON c.id = cc.container_id AND c.host_id = cc.host_id
+		INNER JOIN (
+			SELECT host_id, MAX(scanned_at) as max_scan
+			FROM containers
+			WHERE (? = 0 OR host_id = ?)
+			GROUP BY host_id
+		) latest ON c.host_id = latest.host_id AND c.scanned_at = latest.max_scan
+		WHERE cc.collected_at = c.scanned_at
+		ORDER BY cc.host_id, cc.container_name
+	`, hostID, hostID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var inspections []models.ContainerInspection
+	for rows.Next() {
+		var inspection models.ContainerInspection
+		var configJSON string
+		if err := rows.Scan(&inspection.ContainerID, &inspection.ContainerName, &inspection.HostID, &inspection.HostName,
+			&configJSON, &inspection.CollectedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(configJSON), &inspection.Config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal container config: %w", err)
+		}
+		inspections = append(inspections, inspection)
+	}
+	return inspections, rows.Err()
+}
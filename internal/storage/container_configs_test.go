@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestGetContainerInspection(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "host1", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	scan := func(at time.Time, restartPolicy string) {
+		container := models.Container{
+			ID: "abcdef1234567890", Name: "web", Image: "nginx:latest", State: "running",
+			HostID: hostID, HostName: "host1", ScannedAt: at,
+			Config: &models.ContainerConfig{
+				Env:           []models.EnvVar{{Name: "DB_PASSWORD", Value: "********", Masked: true}},
+				RestartPolicy: restartPolicy,
+			},
+		}
+		if err := db.SaveContainers([]models.Container{container}); err != nil {
+			t.Fatalf("Failed to save container: %v", err)
+		}
+	}
+
+	now := time.Now()
+	scan(now.Add(-time.Hour), "no")
+	scan(now, "unless-stopped")
+
+	// Configuration is upserted, so only the latest scan is kept
+	var count int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM container_configs").Scan(&count); err != nil {
+		t.Fatalf("Failed to count configs: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 stored config, got %d", count)
+	}
+
+	inspection, err := db.GetContainerInspection(hostID, "abcdef123456")
+	if err != nil {
+		t.Fatalf("GetContainerInspection failed: %v", err)
+	}
+	if inspection == nil {
+		t.Fatal("Expected inspection for short container ID")
+	}
+	if inspection.ContainerName != "web" || inspection.Config.RestartPolicy != "unless-stopped" {
+		t.Errorf("Unexpected inspection: %+v", inspection)
+	}
+	if len(inspection.Config.Env) != 1 || !inspection.Config.Env[0].Masked {
+		t.Errorf("Expected masked env to round-trip, got %+v", inspection.Config.Env)
+	}
+
+	missing, err := db.GetContainerInspection(hostID+1, "abcdef123456")
+	if err != nil {
+		t.Fatalf("GetContainerInspection failed: %v", err)
+	}
+	if missing != nil {
+		t.Errorf("Expected nil for unknown host, got %+v", missing)
+	}
+}
+
+func TestGetLatestContainerInspections(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "host1", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	now := time.Now()
+	old := models.Container{
+		ID: "old123", Name: "removed", Image: "nginx", State: "exited", HostID: hostID, HostName: "host1",
+		ScannedAt: now.Add(-time.Hour), Config: &models.ContainerConfig{NetworkMode: "bridge"},
+	}
+	current := models.Container{
+		ID: "web123", Name: "web", Image: "nginx", State: "running", HostID: hostID, HostName: "host1",
+		ScannedAt: now, Config: &models.ContainerConfig{Networks: []models.ConfigNetwork{{Name: "proxy", IPAddress: "172.18.0.4"}}},
+	}
+	if err := db.SaveContainers([]models.Container{old}); err != nil {
+		t.Fatalf("Failed to save container: %v", err)
+	}
+	if err := db.SaveContainers([]models.Container{current}); err != nil {
+		t.Fatalf("Failed to save container: %v", err)
+	}
+
+	inspections, err := db.GetLatestContainerInspections(0)
+	if err != nil {
+		t.Fatalf("GetLatestContainerInspections failed: %v", err)
+	}
+	if len(inspections) != 1 || inspections[0].ContainerName != "web" {
+		t.Fatalf("Expected only the container of the latest scan, got %+v", inspections)
+	}
+	if len(inspections[0].Config.Networks) != 1 || inspections[0].Config.Networks[0].IPAddress != "172.18.0.4" {
+		t.Errorf("Expected the network address to round-trip, got %+v", inspections[0].Config.Networks)
+	}
+
+	inspections, err = db.GetLatestContainerInspections(hostID + 1)
+	if err != nil {
+		t.Fatalf("GetLatestContainerInspections failed: %v", err)
+	}
+	if len(inspections) != 0 {
+		t.Errorf("Expected no inspections for an unknown host, got %+v", inspections)
+	}
+}
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// SaveDaemonEvents stores the daemon events an agent read from a host's logs and returns those
+// that weren't stored yet, with their IDs. Agents return the events since the latest one stored,
+// so the first of them is usually already there.
+func (db *DB) SaveDaemonEvents(hostID int64, hostName string, events []models.DaemonEvent) ([]models.DaemonEvent, error) {
+	if len(events) == 0 {
+		return nil, nil
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT OR IGNORE INTO daemon_events
+			(host_id, host_name, kind, source, container_id, container_name, process, pid, message, occurred_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	saved := make([]models.DaemonEvent, 0, len(events))
+	for _, e := range events {
+		e.HostID = hostID
+		e.HostName = hostName
+		res, err := stmt.Exec(e.HostID, e.HostName, e.Kind, e.Source, e.ContainerID, e.ContainerName,
+			e.Process, e.PID, e.Message, e.OccurredAt)
+		if err != nil {
+			return nil, err
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			continue
+		}
+		if e.ID, err = res.LastInsertId(); err != nil {
+			return nil, err
+		}
+		saved = append(saved, e)
+	}
+	return saved, tx.Commit()
+}
+
+// LatestDaemonEventTime returns when the latest stored daemon event of a host occurred, or the
+// zero time when there is none
+func (db *DB) LatestDaemonEventTime(hostID int64) (time.Time, error) {
+	var latest time.Time
+	err := db.conn.QueryRow(`SELECT occurred_at FROM daemon_events WHERE host_id = ? ORDER BY occurred_at DESC LIMIT 1`,
+		hostID).Scan(&latest)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	return latest, err
+}
+
+// GetDaemonEvents returns the daemon events of the given hosts since a time, newest first. Nil
+// hostIDs returns those of all hosts and an empty kind those of all kinds.
+func (db *DB) GetDaemonEvents(hostIDs []int64, kind string, since time.Time, limit int) ([]models.DaemonEvent, error) {
+	query := `
+		SELECT id, host_id, host_name, kind, source, container_id, container_name, process, pid, message, occurred_at
+		FROM daemon_events
+		WHERE (? = '' OR kind = ?) AND occurred_at >= ?`
+	args := []interface{}{kind, kind, since}
+	if hostIDs != nil {
+		if len(hostIDs) == 0 {
+			return []models.DaemonEvent{}, nil
+		}
+		query += ` AND host_id IN (` + strings.TrimSuffix(strings.Repeat("?,", len(hostIDs)), ",") + `)`
+		for _, id := range hostIDs {
+			args = append(args, id)
+		}
+	}
+	query += ` ORDER BY occurred_at DESC, id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanDaemonEvents(rows)
+}
+
+// GetContainerOOMKills returns the OOM kills of a container's processes on a host, oldest first
+func (db *DB) GetContainerOOMKills(containerName string, hostID int64) ([]models.DaemonEvent, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, host_id, host_name, kind, source, container_id, container_name, process, pid, message, occurred_at
+		FROM daemon_events
+		WHERE host_id = ? AND container_name = ? AND kind = ?
+		ORDER BY occurred_at, id
+	`, hostID, containerName, models.DaemonEventOOMKill)
+	if err != nil {
+		return nil, err
+	}
+	return scanDaemonEvents(rows)
+}
+
+func scanDaemonEvents(rows *sql.Rows) ([]models.DaemonEvent, error) {
+	defer rows.Close()
+	events := make([]models.DaemonEvent, 0)
+	for rows.Next() {
+		var e models.DaemonEvent
+		if err := rows.Scan(&e.ID, &e.HostID, &e.HostName, &e.Kind, &e.Source, &e.ContainerID, &e.ContainerName,
+			&e.Process, &e.PID, &e.Message, &e.OccurredAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// CleanupOldDaemonEvents deletes daemon events older than the given number of days
+func (db *DB) CleanupOldDaemonEvents(days int) (int64, error) {
+	cutoff := time.Now().AddDate(0, 0, -days)
+	result, err := db.conn.Exec(`DELETE FROM daemon_events WHERE occurred_at < ?`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
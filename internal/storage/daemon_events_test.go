@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestDaemonEvents(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: "agent://nas:9876", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	if latest, err := db.LatestDaemonEventTime(hostID); err != nil || !latest.IsZero() {
+		t.Fatalf("Expected no latest event, got %v (%v)", latest, err)
+	}
+
+	at := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	oom := models.DaemonEvent{Kind: models.DaemonEventOOMKill, Source: "kernel", ContainerID: "abc", ContainerName: "web",
+		Process: "java", PID: 42, Message: "Out of memory: killed process 42 (java)", OccurredAt: at}
+	daemonErr := models.DaemonEvent{Kind: models.DaemonEventError, Source: "dockerd", Message: "failed to mount", OccurredAt: at.Add(time.Minute)}
+
+	saved, err := db.SaveDaemonEvents(hostID, "nas", []models.DaemonEvent{oom, daemonErr})
+	if err != nil {
+		t.Fatalf("SaveDaemonEvents failed: %v", err)
+	}
+	if len(saved) != 2 || saved[0].ID == 0 || saved[0].HostName != "nas" {
+		t.Fatalf("Expected 2 new events, got %+v", saved)
+	}
+
+	// Agents return the latest stored event again
+	saved, err = db.SaveDaemonEvents(hostID, "nas", []models.DaemonEvent{daemonErr})
+	if err != nil || len(saved) != 0 {
+		t.Fatalf("Expected the stored event to be skipped, got %+v (%v)", saved, err)
+	}
+	if latest, err := db.LatestDaemonEventTime(hostID); err != nil || !latest.Equal(daemonErr.OccurredAt) {
+		t.Errorf("Expected the latest event at %v, got %v (%v)", daemonErr.OccurredAt, latest, err)
+	}
+
+	events, err := db.GetDaemonEvents(nil, models.DaemonEventOOMKill, time.Time{}, 10)
+	if err != nil || len(events) != 1 || events[0].Process != "java" {
+		t.Fatalf("Expected the OOM kill, got %+v (%v)", events, err)
+	}
+
+	// OOM kills show in the container's lifecycle
+	if err := db.SaveContainers([]models.Container{{ID: "abc", Name: "web", Image: "nginx", State: "running",
+		HostID: hostID, HostName: "nas", ScannedAt: at.Add(-time.Minute)}}); err != nil {
+		t.Fatalf("SaveContainers failed: %v", err)
+	}
+	lifecycle, err := db.GetContainerLifecycleEvents("web", hostID)
+	if err != nil {
+		t.Fatalf("GetContainerLifecycleEvents failed: %v", err)
+	}
+	found := false
+	for _, e := range lifecycle {
+		if e.EventType == "oom_killed" {
+			found = e.Timestamp.Equal(at)
+		}
+	}
+	if !found {
+		t.Errorf("Expected an oom_killed lifecycle event, got %+v", lifecycle)
+	}
+
+	if deleted, err := db.CleanupOldDaemonEvents(0); err != nil || deleted != 2 {
+		t.Errorf("Expected 2 events cleaned up, got %d (%v)", deleted, err)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"sort"
 	"time"
 
+	"github.com/container-census/container-census/internal/backup"
+	"github.com/container-census/container-census/internal/inspect"
 	"github.com/container-census/container-census/internal/models"
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -17,13 +19,29 @@ type DB struct {
 	conn *sql.DB
 }
 
+// Options tune the database connection
+type Options struct {
+	// LowWrite trades durability of the last transactions on power loss for fewer fsyncs
+	// (synchronous=NORMAL, safe from corruption in WAL mode) and uses a smaller connection
+	// pool, for SD cards and low-memory devices
+	LowWrite bool
+}
+
 // New creates a new database connection and initializes schema
 func New(dbPath string) (*DB, error) {
+	return NewWithOptions(dbPath, Options{})
+}
+
+// NewWithOptions creates a new database connection with the given options and initializes schema
+func NewWithOptions(dbPath string, opts Options) (*DB, error) {
 	// Add SQLite parameters for better concurrency and time parsing
 	// _parseTime=true: Parse TIME columns into time.Time
 	// _busy_timeout=5000: Wait up to 5 seconds for locks
 	// _journal_mode=WAL: Enable Write-Ahead Logging for better concurrency
-	dsn := dbPath + "?_parseTime=true&_busy_timeout=5000&_journal_mode=WAL"
+	dsn := dbPath + "?_parseTime=true&_busy_timeout=5000&_journal_mode=WAL&_foreign_keys=on"
+	if opts.LowWrite {
+		dsn += "&_synchronous=NORMAL"
+	}
 	conn, err := sql.Open("sqlite3", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
@@ -31,8 +49,13 @@ func New(dbPath string) (*DB, error) {
 
 	// Set connection pool limits to prevent lock contention
 	// Max 10 open connections, WAL mode allows multiple readers + 1 writer
-	conn.SetMaxOpenConns(10)
-	conn.SetMaxIdleConns(5)
+	if opts.LowWrite {
+		conn.SetMaxOpenConns(4)
+		conn.SetMaxIdleConns(2)
+	} else {
+		conn.SetMaxOpenConns(10)
+		conn.SetMaxIdleConns(5)
+	}
 
 	// Enable foreign keys
 	if _, err := conn.Exec("PRAGMA foreign_keys = ON"); err != nil {
@@ -65,10 +88,14 @@ func (db *DB) initSchema() error {
 		description TEXT,
 		host_type TEXT DEFAULT 'unknown',
 		agent_token TEXT,
+		agent_token_changed_at TIMESTAMP,
 		agent_status TEXT DEFAULT 'unknown',
 		last_seen TIMESTAMP,
 		enabled BOOLEAN NOT NULL DEFAULT 1,
 		collect_stats BOOLEAN NOT NULL DEFAULT 1,
+		registry_mirror TEXT DEFAULT '',
+		site TEXT DEFAULT '',
+		tenant_id INTEGER NOT NULL DEFAULT 0,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	);
@@ -91,10 +118,17 @@ func (db *DB) initSchema() error {
 		volumes TEXT,
 		links TEXT,
 		compose_project TEXT,
+		env_endpoints TEXT,
 		cpu_percent REAL,
 		memory_usage INTEGER,
 		memory_limit INTEGER,
 		memory_percent REAL,
+		network_rx_rate REAL,
+		network_tx_rate REAL,
+		block_read_rate REAL,
+		block_write_rate REAL,
+		scan_id TEXT,
+		restart_count INTEGER,
 		PRIMARY KEY (id, host_id, scanned_at),
 		FOREIGN KEY (host_id) REFERENCES hosts(id) ON DELETE CASCADE
 	);
@@ -115,6 +149,14 @@ func (db *DB) initSchema() error {
 		avg_memory_usage INTEGER,
 		max_cpu_percent REAL,
 		max_memory_usage INTEGER,
+		avg_network_rx_rate REAL,
+		avg_network_tx_rate REAL,
+		avg_block_read_rate REAL,
+		avg_block_write_rate REAL,
+		p95_cpu_percent REAL,
+		p99_cpu_percent REAL,
+		p95_memory_usage INTEGER,
+		p99_memory_usage INTEGER,
 		sample_count INTEGER NOT NULL,
 		UNIQUE(container_id, host_id, timestamp_hour),
 		FOREIGN KEY (host_id) REFERENCES hosts(id) ON DELETE CASCADE
@@ -122,6 +164,203 @@ func (db *DB) initSchema() error {
 
 	CREATE INDEX IF NOT EXISTS idx_stats_aggregates ON container_stats_aggregates(container_id, host_id, timestamp_hour);
 
+	CREATE TABLE IF NOT EXISTS container_configs (
+		container_id TEXT NOT NULL,
+		host_id INTEGER NOT NULL,
+		container_name TEXT NOT NULL,
+		host_name TEXT NOT NULL,
+		config TEXT NOT NULL,
+		collected_at TIMESTAMP NOT NULL,
+		risk_score INTEGER NOT NULL DEFAULT 0,
+		privileged BOOLEAN NOT NULL DEFAULT 0,
+		privileged_since TIMESTAMP,
+		restart_policy TEXT NOT NULL DEFAULT '',
+		previous_restart_policy TEXT NOT NULL DEFAULT '',
+		restart_policy_changed_at TIMESTAMP,
+		restart_policy_warned BOOLEAN NOT NULL DEFAULT 0,
+		PRIMARY KEY (container_id, host_id),
+		FOREIGN KEY (host_id) REFERENCES hosts(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS image_usage (
+		image_id TEXT NOT NULL,
+		host_id INTEGER NOT NULL,
+		first_seen TIMESTAMP NOT NULL,
+		last_used TIMESTAMP,
+		PRIMARY KEY (image_id, host_id),
+		FOREIGN KEY (host_id) REFERENCES hosts(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS container_image_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		host_id INTEGER NOT NULL,
+		container_name TEXT NOT NULL,
+		image TEXT NOT NULL DEFAULT '',
+		image_id TEXT NOT NULL,
+		first_seen TIMESTAMP NOT NULL,
+		last_seen TIMESTAMP NOT NULL,
+		first_scan_id TEXT NOT NULL DEFAULT '',
+		last_scan_id TEXT NOT NULL DEFAULT '',
+		FOREIGN KEY (host_id) REFERENCES hosts(id) ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS idx_image_history_container ON container_image_history(host_id, container_name, last_seen);
+	CREATE INDEX IF NOT EXISTS idx_image_history_image ON container_image_history(image_id);
+
+	CREATE TABLE IF NOT EXISTS image_layers_cache (
+		image_id TEXT NOT NULL,
+		host_id INTEGER NOT NULL,
+		layers TEXT NOT NULL,
+		collected_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (image_id, host_id),
+		FOREIGN KEY (host_id) REFERENCES hosts(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS compliance_audits (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		host_id INTEGER NOT NULL,
+		benchmark TEXT NOT NULL,
+		checks TEXT NOT NULL,
+		passed INTEGER NOT NULL DEFAULT 0,
+		failed INTEGER NOT NULL DEFAULT 0,
+		skipped INTEGER NOT NULL DEFAULT 0,
+		audited_at TIMESTAMP NOT NULL,
+		FOREIGN KEY (host_id) REFERENCES hosts(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_compliance_audits_host ON compliance_audits(host_id, audited_at);
+
+	CREATE TABLE IF NOT EXISTS integration_settings (
+		name TEXT PRIMARY KEY,
+		config TEXT NOT NULL,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS uptime_checks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		host_id INTEGER NOT NULL,
+		container_name TEXT NOT NULL,
+		monitor_name TEXT NOT NULL,
+		url TEXT NOT NULL,
+		status TEXT NOT NULL,
+		response_time_ms REAL NOT NULL DEFAULT 0,
+		checked_at TIMESTAMP NOT NULL,
+		FOREIGN KEY (host_id) REFERENCES hosts(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_uptime_checks_container ON uptime_checks(host_id, container_name, checked_at);
+
+	CREATE TABLE IF NOT EXISTS host_heartbeats (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		host_id INTEGER NOT NULL,
+		timestamp TIMESTAMP NOT NULL,
+		online BOOLEAN NOT NULL,
+		error TEXT,
+		FOREIGN KEY (host_id) REFERENCES hosts(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_host_heartbeats_host ON host_heartbeats(host_id, timestamp);
+
+	CREATE TABLE IF NOT EXISTS host_downtimes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		host_id INTEGER NOT NULL,
+		host_name TEXT NOT NULL,
+		started_at TIMESTAMP NOT NULL,
+		ended_at TIMESTAMP,
+		error TEXT,
+		FOREIGN KEY (host_id) REFERENCES hosts(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_host_downtimes_host ON host_downtimes(host_id, started_at);
+
+	CREATE TABLE IF NOT EXISTS host_templates (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		template TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS proxmox_guests (
+		host_id INTEGER PRIMARY KEY,
+		node TEXT NOT NULL,
+		vmid INTEGER NOT NULL DEFAULT 0,
+		guest_type TEXT NOT NULL,
+		name TEXT NOT NULL,
+		status TEXT NOT NULL,
+		cpus REAL NOT NULL DEFAULT 0,
+		max_memory INTEGER NOT NULL DEFAULT 0,
+		max_disk INTEGER NOT NULL DEFAULT 0,
+		uptime_seconds INTEGER NOT NULL DEFAULT 0,
+		matched_by TEXT NOT NULL,
+		synced_at TIMESTAMP NOT NULL,
+		FOREIGN KEY (host_id) REFERENCES hosts(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS backup_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		host_id INTEGER NOT NULL,
+		container_name TEXT NOT NULL,
+		container_id TEXT NOT NULL,
+		image TEXT NOT NULL,
+		started_at TIMESTAMP NOT NULL,
+		finished_at TIMESTAMP NOT NULL,
+		exit_code INTEGER NOT NULL,
+		interval_seconds INTEGER NOT NULL,
+		UNIQUE(host_id, container_name, started_at),
+		FOREIGN KEY (host_id) REFERENCES hosts(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_backup_runs_finished ON backup_runs(host_id, container_name, finished_at);
+
+	CREATE TABLE IF NOT EXISTS container_updates (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		host_id INTEGER NOT NULL,
+		host_name TEXT NOT NULL,
+		container_name TEXT NOT NULL,
+		image TEXT NOT NULL,
+		old_image_id TEXT NOT NULL DEFAULT '',
+		new_image_id TEXT NOT NULL DEFAULT '',
+		triggered_by TEXT NOT NULL DEFAULT '',
+		trigger_type TEXT NOT NULL,
+		status TEXT NOT NULL,
+		error TEXT NOT NULL DEFAULT '',
+		health_status TEXT NOT NULL DEFAULT '',
+		started_at TIMESTAMP NOT NULL,
+		finished_at TIMESTAMP NOT NULL,
+		duration_ms INTEGER NOT NULL DEFAULT 0,
+		FOREIGN KEY (host_id) REFERENCES hosts(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_container_updates_container ON container_updates(host_id, container_name, started_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_container_updates_started ON container_updates(started_at DESC);
+
+	CREATE TABLE IF NOT EXISTS canary_updates (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		image TEXT NOT NULL,
+		status TEXT NOT NULL,
+		rollout TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS host_migrations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		source_host_id INTEGER NOT NULL,
+		dest_host_id INTEGER NOT NULL,
+		status TEXT NOT NULL,
+		migration TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS endpoint_checks (
+		hostname TEXT PRIMARY KEY,
+		status TEXT NOT NULL,
+		cert_not_after TIMESTAMP,
+		result TEXT NOT NULL,
+		checked_at TIMESTAMP NOT NULL
+	);
+
 	CREATE TABLE IF NOT EXISTS scan_results (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		host_id INTEGER NOT NULL,
@@ -131,6 +370,8 @@ func (db *DB) initSchema() error {
 		success BOOLEAN NOT NULL,
 		error TEXT,
 		containers_found INTEGER NOT NULL DEFAULT 0,
+		warnings TEXT,
+		scan_id TEXT,
 		FOREIGN KEY (host_id) REFERENCES hosts(id) ON DELETE CASCADE
 	);
 
@@ -168,6 +409,9 @@ func (db *DB) initSchema() error {
 		type TEXT NOT NULL,
 		config TEXT NOT NULL,
 		enabled BOOLEAN NOT NULL DEFAULT 1,
+		min_severity TEXT NOT NULL DEFAULT '',
+		quiet_hours TEXT,
+		tenant_id INTEGER NOT NULL DEFAULT 0,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	);
@@ -180,10 +424,14 @@ func (db *DB) initSchema() error {
 		host_id INTEGER,
 		container_pattern TEXT,
 		image_pattern TEXT,
+		owner_pattern TEXT NOT NULL DEFAULT '',
 		cpu_threshold REAL,
 		memory_threshold REAL,
+		leak_growth_threshold REAL,
+		leak_min_days INTEGER DEFAULT 0,
 		threshold_duration_seconds INTEGER DEFAULT 120,
 		cooldown_seconds INTEGER DEFAULT 300,
+		tenant_id INTEGER NOT NULL DEFAULT 0,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		FOREIGN KEY (host_id) REFERENCES hosts(id) ON DELETE CASCADE
@@ -255,6 +503,21 @@ func (db *DB) initSchema() error {
 
 	CREATE INDEX IF NOT EXISTS idx_baseline_stats_container ON container_baseline_stats(container_id, host_id, image_id);
 
+	CREATE TABLE IF NOT EXISTS container_seasonal_baselines (
+		container_name TEXT NOT NULL,
+		host_id INTEGER NOT NULL,
+		day_of_week INTEGER NOT NULL,
+		hour_of_day INTEGER NOT NULL,
+		avg_cpu_percent REAL NOT NULL,
+		stddev_cpu_percent REAL NOT NULL,
+		avg_memory_usage INTEGER NOT NULL,
+		stddev_memory_usage REAL NOT NULL,
+		sample_count INTEGER NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (container_name, host_id, day_of_week, hour_of_day),
+		FOREIGN KEY (host_id) REFERENCES hosts(id) ON DELETE CASCADE
+	);
+
 	CREATE TABLE IF NOT EXISTS notification_threshold_state (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		container_id TEXT NOT NULL,
@@ -269,6 +532,15 @@ func (db *DB) initSchema() error {
 
 	CREATE INDEX IF NOT EXISTS idx_threshold_state_container ON notification_threshold_state(container_id, host_id);
 
+	CREATE TABLE IF NOT EXISTS notification_stats (
+		bucket TIMESTAMP NOT NULL,
+		rule_id INTEGER NOT NULL,
+		channel_id INTEGER NOT NULL,
+		outcome TEXT NOT NULL,
+		count INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (bucket, rule_id, channel_id, outcome)
+	);
+
 	CREATE TABLE IF NOT EXISTS vulnerability_scans (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		image_id TEXT NOT NULL UNIQUE,
@@ -309,6 +581,17 @@ func (db *DB) initSchema() error {
 	CREATE INDEX IF NOT EXISTS idx_vulns_severity ON vulnerabilities(severity);
 	CREATE INDEX IF NOT EXISTS idx_vulns_cve ON vulnerabilities(vulnerability_id);
 
+	CREATE TABLE IF NOT EXISTS vulnerability_exceptions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		vulnerability_id TEXT NOT NULL,
+		image_pattern TEXT DEFAULT '',
+		reason TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		expires_at TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_vuln_exceptions_cve ON vulnerability_exceptions(vulnerability_id);
+
 	CREATE TABLE IF NOT EXISTS image_containers (
 		image_id TEXT NOT NULL,
 		container_id TEXT NOT NULL,
@@ -332,6 +615,14 @@ func (db *DB) initSchema() error {
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	);
 
+	CREATE TABLE IF NOT EXISTS user_preference_overrides (
+		username TEXT NOT NULL,
+		key TEXT NOT NULL,
+		value TEXT NOT NULL,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (username, key)
+	);
+
 	CREATE TABLE IF NOT EXISTS system_settings (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		category TEXT NOT NULL,
@@ -362,6 +653,212 @@ func (db *DB) initSchema() error {
 		value TEXT NOT NULL,
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	);
+
+	CREATE TABLE IF NOT EXISTS environment_snapshots (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		snapshot_date TEXT NOT NULL UNIQUE,
+		created_at TIMESTAMP NOT NULL,
+		host_count INTEGER NOT NULL DEFAULT 0,
+		container_count INTEGER NOT NULL DEFAULT 0,
+		containers TEXT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS plugin_results (
+		host_id INTEGER NOT NULL,
+		container_name TEXT NOT NULL,
+		plugin TEXT NOT NULL,
+		key TEXT NOT NULL,
+		value TEXT NOT NULL,
+		collected_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (host_id, container_name, plugin, key),
+		FOREIGN KEY (host_id) REFERENCES hosts(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS image_changelogs (
+		image TEXT PRIMARY KEY,
+		source TEXT NOT NULL DEFAULT '',
+		version TEXT NOT NULL DEFAULT '',
+		name TEXT NOT NULL DEFAULT '',
+		url TEXT NOT NULL DEFAULT '',
+		summary TEXT NOT NULL DEFAULT '',
+		published_at TIMESTAMP,
+		fetched_at TIMESTAMP NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS container_renames (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		host_id INTEGER NOT NULL,
+		container_id TEXT NOT NULL,
+		old_name TEXT NOT NULL,
+		new_name TEXT NOT NULL,
+		renamed_at TIMESTAMP NOT NULL,
+		FOREIGN KEY (host_id) REFERENCES hosts(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_container_renames_host ON container_renames(host_id, renamed_at);
+
+	CREATE TABLE IF NOT EXISTS daemon_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		host_id INTEGER NOT NULL,
+		host_name TEXT NOT NULL DEFAULT '',
+		kind TEXT NOT NULL,
+		source TEXT NOT NULL DEFAULT '',
+		container_id TEXT NOT NULL DEFAULT '',
+		container_name TEXT NOT NULL DEFAULT '',
+		process TEXT NOT NULL DEFAULT '',
+		pid INTEGER NOT NULL DEFAULT 0,
+		message TEXT NOT NULL DEFAULT '',
+		occurred_at TIMESTAMP NOT NULL,
+		UNIQUE (host_id, kind, occurred_at, container_id, message),
+		FOREIGN KEY (host_id) REFERENCES hosts(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_daemon_events_host ON daemon_events(host_id, occurred_at);
+
+	CREATE TABLE IF NOT EXISTS docker_objects (
+		host_id INTEGER NOT NULL,
+		kind TEXT NOT NULL,
+		object_id TEXT NOT NULL,
+		name TEXT NOT NULL DEFAULT '',
+		detail TEXT NOT NULL DEFAULT '',
+		in_use BOOLEAN NOT NULL DEFAULT 0,
+		size_bytes INTEGER NOT NULL DEFAULT 0,
+		reclaimable_bytes INTEGER NOT NULL DEFAULT 0,
+		count INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMP,
+		last_used_at TIMESTAMP,
+		scanned_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (host_id, kind, object_id),
+		FOREIGN KEY (host_id) REFERENCES hosts(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS container_pins (
+		host_id INTEGER NOT NULL,
+		container_name TEXT NOT NULL,
+		reason TEXT NOT NULL DEFAULT '',
+		pinned_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (host_id, container_name),
+		FOREIGN KEY (host_id) REFERENCES hosts(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS notes (
+		host_id INTEGER NOT NULL,
+		container_name TEXT NOT NULL DEFAULT '',
+		text TEXT NOT NULL DEFAULT '',
+		fields TEXT NOT NULL DEFAULT '{}',
+		updated_at TIMESTAMP NOT NULL,
+		updated_by TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (host_id, container_name),
+		FOREIGN KEY (host_id) REFERENCES hosts(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS annotations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		at TIMESTAMP NOT NULL,
+		end_at TIMESTAMP,
+		text TEXT NOT NULL,
+		host_id INTEGER,
+		container_name TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL,
+		created_by TEXT NOT NULL DEFAULT '',
+		FOREIGN KEY (host_id) REFERENCES hosts(id) ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS idx_annotations_at ON annotations(at);
+
+	CREATE TABLE IF NOT EXISTS container_owners (
+		host_id INTEGER NOT NULL,
+		container_name TEXT NOT NULL,
+		owner TEXT NOT NULL,
+		contact TEXT NOT NULL DEFAULT '',
+		assigned_at TIMESTAMP NOT NULL,
+		assigned_by TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (host_id, container_name),
+		FOREIGN KEY (host_id) REFERENCES hosts(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS stack_owners (
+		stack TEXT PRIMARY KEY,
+		owner TEXT NOT NULL,
+		contact TEXT NOT NULL DEFAULT '',
+		assigned_at TIMESTAMP NOT NULL,
+		assigned_by TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE TABLE IF NOT EXISTS bus_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		type TEXT NOT NULL,
+		timestamp TIMESTAMP NOT NULL,
+		host_id INTEGER NOT NULL DEFAULT 0,
+		host_name TEXT NOT NULL DEFAULT '',
+		container_name TEXT NOT NULL DEFAULT '',
+		data TEXT NOT NULL DEFAULT '{}'
+	);
+	CREATE INDEX IF NOT EXISTS idx_bus_events_timestamp ON bus_events(timestamp);
+
+	CREATE TABLE IF NOT EXISTS event_subscriptions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		url TEXT NOT NULL,
+		event_types TEXT NOT NULL DEFAULT '[]',
+		secret TEXT NOT NULL DEFAULT '',
+		enabled BOOLEAN NOT NULL DEFAULT 1,
+		cursor INTEGER NOT NULL DEFAULT 0,
+		last_delivery_at TIMESTAMP,
+		last_error TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS agent_token_rotations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		host_id INTEGER NOT NULL,
+		rotated_at TIMESTAMP NOT NULL,
+		source TEXT NOT NULL,
+		success BOOLEAN NOT NULL,
+		error TEXT NOT NULL DEFAULT '',
+		previous_expires_at TIMESTAMP,
+		FOREIGN KEY (host_id) REFERENCES hosts(id) ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS idx_agent_token_rotations_host ON agent_token_rotations(host_id, id);
+
+	CREATE TABLE IF NOT EXISTS update_lag (
+		host_id INTEGER NOT NULL,
+		container_name TEXT NOT NULL,
+		image_id TEXT NOT NULL,
+		behind_since TIMESTAMP NOT NULL,
+		checked_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (host_id, container_name),
+		FOREIGN KEY (host_id) REFERENCES hosts(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS security_scores (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		day TEXT NOT NULL,
+		host_id INTEGER,
+		stack TEXT NOT NULL DEFAULT '',
+		score INTEGER NOT NULL,
+		critical INTEGER NOT NULL DEFAULT 0,
+		high INTEGER NOT NULL DEFAULT 0,
+		stale_fixes INTEGER NOT NULL DEFAULT 0,
+		privileged INTEGER NOT NULL DEFAULT 0,
+		containers INTEGER NOT NULL DEFAULT 0,
+		FOREIGN KEY (host_id) REFERENCES hosts(id) ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS idx_security_scores_day ON security_scores(day);
+
+	CREATE TABLE IF NOT EXISTS tenants (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		created_at TIMESTAMP NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS tenant_users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		tenant_id INTEGER NOT NULL,
+		username TEXT NOT NULL UNIQUE,
+		password_hash TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE
+	);
 	`
 
 	if _, err := db.conn.Exec(schema); err != nil {
@@ -446,6 +943,40 @@ func (db *DB) runMigrations() error {
 		}
 	}
 
+	// Check if registry_mirror column exists in hosts table
+	var registryMirrorExists int
+	err = db.conn.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('hosts') WHERE name='registry_mirror'
+	`).Scan(&registryMirrorExists)
+	if err != nil {
+		return err
+	}
+
+	if registryMirrorExists == 0 {
+		if _, err := db.conn.Exec(`ALTER TABLE hosts ADD COLUMN registry_mirror TEXT DEFAULT ''`); err != nil {
+			if !isSQLiteColumnExistsError(err) {
+				return err
+			}
+		}
+	}
+
+	// Check if site column exists in hosts table (for site grouping)
+	var siteExists int
+	err = db.conn.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('hosts') WHERE name='site'
+	`).Scan(&siteExists)
+	if err != nil {
+		return err
+	}
+
+	if siteExists == 0 {
+		if _, err := db.conn.Exec(`ALTER TABLE hosts ADD COLUMN site TEXT DEFAULT ''`); err != nil {
+			if !isSQLiteColumnExistsError(err) {
+				return err
+			}
+		}
+	}
+
 	// Check if cpu_percent column exists in containers table (for stats monitoring)
 	var cpuPercentExists int
 	err = db.conn.QueryRow(`
@@ -498,6 +1029,21 @@ func (db *DB) runMigrations() error {
 		}
 	}
 
+	// Check if env_endpoints column exists (for cross-host service map)
+	var envEndpointsExists int
+	err = db.conn.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('containers') WHERE name = 'env_endpoints'`).Scan(&envEndpointsExists)
+	if err != nil {
+		return err
+	}
+
+	if envEndpointsExists == 0 {
+		if _, err := db.conn.Exec(`ALTER TABLE containers ADD COLUMN env_endpoints TEXT`); err != nil {
+			if !isSQLiteContainerColumnExistsError(err) {
+				return err
+			}
+		}
+	}
+
 	// Check if image_tags column exists
 	var imageTagsExists int
 	err = db.conn.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('containers') WHERE name = 'image_tags'`).Scan(&imageTagsExists)
@@ -513,6 +1059,266 @@ func (db *DB) runMigrations() error {
 		}
 	}
 
+	// Check if memory leak sensitivity columns exist on notification rules
+	var leakThresholdExists int
+	err = db.conn.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('notification_rules') WHERE name = 'leak_growth_threshold'`).Scan(&leakThresholdExists)
+	if err != nil {
+		return err
+	}
+
+	if leakThresholdExists == 0 {
+		migrations := []string{
+			`ALTER TABLE notification_rules ADD COLUMN leak_growth_threshold REAL`,
+			`ALTER TABLE notification_rules ADD COLUMN leak_min_days INTEGER DEFAULT 0`,
+		}
+		for _, migration := range migrations {
+			if _, err := db.conn.Exec(migration); err != nil {
+				if !isSQLiteRuleColumnExistsError(err) {
+					return err
+				}
+			}
+		}
+	}
+
+	// Check if I/O rate columns exist (for network and block I/O stats)
+	var ioRatesExists int
+	err = db.conn.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('containers') WHERE name = 'network_rx_rate'`).Scan(&ioRatesExists)
+	if err != nil {
+		return err
+	}
+
+	if ioRatesExists == 0 {
+		ioMigrations := []string{
+			`ALTER TABLE containers ADD COLUMN network_rx_rate REAL`,
+			`ALTER TABLE containers ADD COLUMN network_tx_rate REAL`,
+			`ALTER TABLE containers ADD COLUMN block_read_rate REAL`,
+			`ALTER TABLE containers ADD COLUMN block_write_rate REAL`,
+			`ALTER TABLE container_stats_aggregates ADD COLUMN avg_network_rx_rate REAL`,
+			`ALTER TABLE container_stats_aggregates ADD COLUMN avg_network_tx_rate REAL`,
+			`ALTER TABLE container_stats_aggregates ADD COLUMN avg_block_read_rate REAL`,
+			`ALTER TABLE container_stats_aggregates ADD COLUMN avg_block_write_rate REAL`,
+		}
+		for _, migration := range ioMigrations {
+			if _, err := db.conn.Exec(migration); err != nil {
+				if !isSQLiteStatsColumnExistsError(err) {
+					return err
+				}
+			}
+		}
+	}
+
+	// Check if percentile columns exist in the hourly stats aggregates
+	var percentilesExist int
+	err = db.conn.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('container_stats_aggregates') WHERE name = 'p95_cpu_percent'`).Scan(&percentilesExist)
+	if err != nil {
+		return err
+	}
+
+	if percentilesExist == 0 {
+		percentileMigrations := []string{
+			`ALTER TABLE container_stats_aggregates ADD COLUMN p95_cpu_percent REAL`,
+			`ALTER TABLE container_stats_aggregates ADD COLUMN p99_cpu_percent REAL`,
+			`ALTER TABLE container_stats_aggregates ADD COLUMN p95_memory_usage INTEGER`,
+			`ALTER TABLE container_stats_aggregates ADD COLUMN p99_memory_usage INTEGER`,
+		}
+		for _, migration := range percentileMigrations {
+			if _, err := db.conn.Exec(migration); err != nil {
+				if !isSQLiteStatsColumnExistsError(err) {
+					return err
+				}
+			}
+		}
+	}
+
+	// Check if container security columns exist (risk score and privileged tracking)
+	var riskScoreExists int
+	err = db.conn.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('container_configs') WHERE name = 'risk_score'`).Scan(&riskScoreExists)
+	if err != nil {
+		return err
+	}
+
+	if riskScoreExists == 0 {
+		securityMigrations := []string{
+			`ALTER TABLE container_configs ADD COLUMN risk_score INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE container_configs ADD COLUMN privileged BOOLEAN NOT NULL DEFAULT 0`,
+			`ALTER TABLE container_configs ADD COLUMN privileged_since TIMESTAMP`,
+		}
+		for _, migration := range securityMigrations {
+			if _, err := db.conn.Exec(migration); err != nil {
+				if !isSQLiteSecurityColumnExistsError(err) {
+					return err
+				}
+			}
+		}
+	}
+
+	// Check if restart policy audit columns exist
+	var restartPolicyExists int
+	err = db.conn.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('container_configs') WHERE name = 'restart_policy'`).Scan(&restartPolicyExists)
+	if err != nil {
+		return err
+	}
+
+	if restartPolicyExists == 0 {
+		restartPolicyMigrations := []string{
+			`ALTER TABLE container_configs ADD COLUMN restart_policy TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE container_configs ADD COLUMN previous_restart_policy TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE container_configs ADD COLUMN restart_policy_changed_at TIMESTAMP`,
+			`ALTER TABLE container_configs ADD COLUMN restart_policy_warned BOOLEAN NOT NULL DEFAULT 0`,
+		}
+		for _, migration := range restartPolicyMigrations {
+			if _, err := db.conn.Exec(migration); err != nil {
+				if !isSQLiteRestartPolicyColumnExistsError(err) {
+					return err
+				}
+			}
+		}
+		// Existing configurations are the baseline, so upgrading doesn't report every policy as changed
+		if _, err := db.conn.Exec(`UPDATE container_configs SET restart_policy = COALESCE(NULLIF(json_extract(config, '$.restart_policy'), ''), 'no')`); err != nil {
+			return err
+		}
+	}
+
+	// Check if channel routing columns exist (severity threshold and quiet hours)
+	var minSeverityExists int
+	err = db.conn.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('notification_channels') WHERE name = 'min_severity'`).Scan(&minSeverityExists)
+	if err != nil {
+		return err
+	}
+
+	if minSeverityExists == 0 {
+		routingMigrations := []string{
+			`ALTER TABLE notification_channels ADD COLUMN min_severity TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE notification_channels ADD COLUMN quiet_hours TEXT`,
+		}
+		for _, migration := range routingMigrations {
+			if _, err := db.conn.Exec(migration); err != nil {
+				if !isSQLiteChannelColumnExistsError(err) {
+					return err
+				}
+			}
+		}
+	}
+
+	// Add tenant_id to the tables tenants own rows in (multi-tenancy)
+	for _, table := range []string{"hosts", "notification_channels", "notification_rules"} {
+		var tenantExists int
+		err = db.conn.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = 'tenant_id'`, table).Scan(&tenantExists)
+		if err != nil {
+			return err
+		}
+		if tenantExists == 0 {
+			if _, err := db.conn.Exec(`ALTER TABLE ` + table + ` ADD COLUMN tenant_id INTEGER NOT NULL DEFAULT 0`); err != nil {
+				if !isSQLiteTenantColumnExistsError(err) {
+					return err
+				}
+			}
+		}
+	}
+
+	// Add per-container collection warnings to scan results
+	var scanWarningsExists int
+	err = db.conn.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('scan_results') WHERE name = 'warnings'`).Scan(&scanWarningsExists)
+	if err != nil {
+		return err
+	}
+	if scanWarningsExists == 0 {
+		if _, err := db.conn.Exec(`ALTER TABLE scan_results ADD COLUMN warnings TEXT`); err != nil {
+			if err.Error() != "duplicate column name: warnings" {
+				return err
+			}
+		}
+	}
+
+	// Add owner routing to notification rules
+	var ownerPatternExists int
+	err = db.conn.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('notification_rules') WHERE name = 'owner_pattern'`).Scan(&ownerPatternExists)
+	if err != nil {
+		return err
+	}
+	if ownerPatternExists == 0 {
+		if _, err := db.conn.Exec(`ALTER TABLE notification_rules ADD COLUMN owner_pattern TEXT NOT NULL DEFAULT ''`); err != nil {
+			if err.Error() != "duplicate column name: owner_pattern" {
+				return err
+			}
+		}
+	}
+
+	// Record the ID of the scan that wrote each scan result and container row
+	for _, table := range []string{"scan_results", "containers"} {
+		var scanIDExists int
+		err = db.conn.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('` + table + `') WHERE name = 'scan_id'`).Scan(&scanIDExists)
+		if err != nil {
+			return err
+		}
+		if scanIDExists == 0 {
+			if _, err := db.conn.Exec(`ALTER TABLE ` + table + ` ADD COLUMN scan_id TEXT`); err != nil {
+				if err.Error() != "duplicate column name: scan_id" {
+					return err
+				}
+			}
+		}
+	}
+	if _, err := db.conn.Exec(`CREATE INDEX IF NOT EXISTS idx_scan_results_scan_id ON scan_results(scan_id)`); err != nil {
+		return err
+	}
+
+	// Record Docker's restart count with each container row (for the idle containers report)
+	var restartCountExists int
+	err = db.conn.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('containers') WHERE name = 'restart_count'`).Scan(&restartCountExists)
+	if err != nil {
+		return err
+	}
+	if restartCountExists == 0 {
+		if _, err := db.conn.Exec(`ALTER TABLE containers ADD COLUMN restart_count INTEGER`); err != nil {
+			if err.Error() != "duplicate column name: restart_count" {
+				return err
+			}
+		}
+	}
+
+	// Seed image usage from scan history so existing installs don't start with every image unused
+	var usageRows int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM image_usage`).Scan(&usageRows); err != nil {
+		return err
+	}
+	if usageRows == 0 {
+		if _, err := db.conn.Exec(`
+			INSERT OR IGNORE INTO image_usage (image_id, host_id, first_seen, last_used)
+			SELECT image_id, host_id, MIN(scanned_at), MAX(CASE WHEN state = 'running' THEN scanned_at END)
+			FROM containers
+			WHERE image_id != ''
+			GROUP BY image_id, host_id
+		`); err != nil {
+			return err
+		}
+	}
+
+	// Check if agent_token_changed_at column exists in hosts table (for the token age audit)
+	var tokenChangedExists int
+	err = db.conn.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('hosts') WHERE name='agent_token_changed_at'
+	`).Scan(&tokenChangedExists)
+	if err != nil {
+		return err
+	}
+
+	if tokenChangedExists == 0 {
+		if _, err := db.conn.Exec(`ALTER TABLE hosts ADD COLUMN agent_token_changed_at TIMESTAMP`); err != nil {
+			if !isSQLiteColumnExistsError(err) {
+				return err
+			}
+		}
+	}
+
+	if err := db.backfillHostTypes(); err != nil {
+		return err
+	}
+
+	if err := db.backfillImageHistory(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -522,7 +1328,8 @@ func isSQLiteColumnExistsError(err error) bool {
 		err.Error() == "duplicate column name: host_type" ||
 		err.Error() == "duplicate column name: agent_token" ||
 		err.Error() == "duplicate column name: agent_status" ||
-		err.Error() == "duplicate column name: last_seen")
+		err.Error() == "duplicate column name: last_seen" ||
+		err.Error() == "duplicate column name: agent_token_changed_at")
 }
 
 // isSQLiteContainerColumnExistsError checks if error is about duplicate container column
@@ -531,7 +1338,8 @@ func isSQLiteContainerColumnExistsError(err error) bool {
 		err.Error() == "duplicate column name: networks" ||
 		err.Error() == "duplicate column name: volumes" ||
 		err.Error() == "duplicate column name: links" ||
-		err.Error() == "duplicate column name: compose_project")
+		err.Error() == "duplicate column name: compose_project" ||
+		err.Error() == "duplicate column name: env_endpoints")
 }
 
 // isSQLiteStatsColumnExistsError checks if error is about duplicate stats column
@@ -541,7 +1349,51 @@ func isSQLiteStatsColumnExistsError(err error) bool {
 		err.Error() == "duplicate column name: memory_usage" ||
 		err.Error() == "duplicate column name: memory_limit" ||
 		err.Error() == "duplicate column name: memory_percent" ||
-		err.Error() == "duplicate column name: collect_stats")
+		err.Error() == "duplicate column name: collect_stats" ||
+		err.Error() == "duplicate column name: network_rx_rate" ||
+		err.Error() == "duplicate column name: network_tx_rate" ||
+		err.Error() == "duplicate column name: block_read_rate" ||
+		err.Error() == "duplicate column name: block_write_rate" ||
+		err.Error() == "duplicate column name: avg_network_rx_rate" ||
+		err.Error() == "duplicate column name: avg_network_tx_rate" ||
+		err.Error() == "duplicate column name: avg_block_read_rate" ||
+		err.Error() == "duplicate column name: avg_block_write_rate")
+}
+
+// isSQLiteRuleColumnExistsError checks if error is about duplicate notification rule column
+func isSQLiteRuleColumnExistsError(err error) bool {
+	return err != nil && (
+		err.Error() == "duplicate column name: leak_growth_threshold" ||
+		err.Error() == "duplicate column name: leak_min_days")
+}
+
+// isSQLiteSecurityColumnExistsError checks if error is about duplicate container security column
+func isSQLiteSecurityColumnExistsError(err error) bool {
+	return err != nil && (
+		err.Error() == "duplicate column name: risk_score" ||
+		err.Error() == "duplicate column name: privileged" ||
+		err.Error() == "duplicate column name: privileged_since")
+}
+
+// isSQLiteRestartPolicyColumnExistsError checks if error is about duplicate restart policy audit column
+func isSQLiteRestartPolicyColumnExistsError(err error) bool {
+	return err != nil && (
+		err.Error() == "duplicate column name: restart_policy" ||
+		err.Error() == "duplicate column name: previous_restart_policy" ||
+		err.Error() == "duplicate column name: restart_policy_changed_at" ||
+		err.Error() == "duplicate column name: restart_policy_warned")
+}
+
+// isSQLiteChannelColumnExistsError checks if error is about duplicate notification channel column
+func isSQLiteChannelColumnExistsError(err error) bool {
+	return err != nil && (
+		err.Error() == "duplicate column name: min_severity" ||
+		err.Error() == "duplicate column name: quiet_hours")
+}
+
+// isSQLiteTenantColumnExistsError checks if error is about a duplicate tenant_id column
+func isSQLiteTenantColumnExistsError(err error) bool {
+	return err != nil && err.Error() == "duplicate column name: tenant_id"
 }
 
 // isSQLiteUpdateColumnExistsError checks if error is about duplicate update column
@@ -555,10 +1407,11 @@ func isSQLiteUpdateColumnExistsError(err error) bool {
 
 // AddHost adds a new host
 func (db *DB) AddHost(host models.Host) (int64, error) {
+	host = withHostType(host)
 	result, err := db.conn.Exec(
-		`INSERT INTO hosts (name, address, description, host_type, agent_token, agent_status, last_seen, enabled, collect_stats)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		host.Name, host.Address, host.Description, host.HostType, host.AgentToken, host.AgentStatus, host.LastSeen, host.Enabled, host.CollectStats,
+		`INSERT INTO hosts (name, address, description, host_type, agent_token, agent_status, last_seen, enabled, collect_stats, registry_mirror, site, tenant_id)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		host.Name, host.Address, host.Description, host.HostType, host.AgentToken, host.AgentStatus, host.LastSeen, host.Enabled, host.CollectStats, host.RegistryMirror, host.Site, host.TenantID,
 	)
 	if err != nil {
 		return 0, err
@@ -569,7 +1422,7 @@ func (db *DB) AddHost(host models.Host) (int64, error) {
 // GetHosts returns all hosts
 func (db *DB) GetHosts() ([]models.Host, error) {
 	rows, err := db.conn.Query(`
-		SELECT id, name, address, description, host_type, agent_token, agent_status, last_seen, enabled, collect_stats, created_at, updated_at
+		SELECT id, name, address, description, host_type, agent_token, agent_status, last_seen, enabled, collect_stats, registry_mirror, site, tenant_id, created_at, updated_at
 		FROM hosts
 		ORDER BY name
 	`)
@@ -582,10 +1435,10 @@ func (db *DB) GetHosts() ([]models.Host, error) {
 	for rows.Next() {
 		var h models.Host
 		var lastSeen sql.NullTime
-		var agentToken, agentStatus sql.NullString
+		var agentToken, agentStatus, registryMirror, site sql.NullString
 		var collectStats sql.NullBool
 
-		if err := rows.Scan(&h.ID, &h.Name, &h.Address, &h.Description, &h.HostType, &agentToken, &agentStatus, &lastSeen, &h.Enabled, &collectStats, &h.CreatedAt, &h.UpdatedAt); err != nil {
+		if err := rows.Scan(&h.ID, &h.Name, &h.Address, &h.Description, &h.HostType, &agentToken, &agentStatus, &lastSeen, &h.Enabled, &collectStats, &registryMirror, &site, &h.TenantID, &h.CreatedAt, &h.UpdatedAt); err != nil {
 			return nil, err
 		}
 
@@ -603,6 +1456,8 @@ func (db *DB) GetHosts() ([]models.Host, error) {
 		} else {
 			h.CollectStats = true // Default to true
 		}
+		h.RegistryMirror = registryMirror.String
+		h.Site = site.String
 
 		hosts = append(hosts, h)
 	}
@@ -614,13 +1469,13 @@ func (db *DB) GetHosts() ([]models.Host, error) {
 func (db *DB) GetHost(id int64) (*models.Host, error) {
 	var h models.Host
 	var lastSeen sql.NullTime
-	var agentToken, agentStatus sql.NullString
+	var agentToken, agentStatus, registryMirror, site sql.NullString
 	var collectStats sql.NullBool
 
 	err := db.conn.QueryRow(`
-		SELECT id, name, address, description, host_type, agent_token, agent_status, last_seen, enabled, collect_stats, created_at, updated_at
+		SELECT id, name, address, description, host_type, agent_token, agent_status, last_seen, enabled, collect_stats, registry_mirror, site, tenant_id, created_at, updated_at
 		FROM hosts WHERE id = ?
-	`, id).Scan(&h.ID, &h.Name, &h.Address, &h.Description, &h.HostType, &agentToken, &agentStatus, &lastSeen, &h.Enabled, &collectStats, &h.CreatedAt, &h.UpdatedAt)
+	`, id).Scan(&h.ID, &h.Name, &h.Address, &h.Description, &h.HostType, &agentToken, &agentStatus, &lastSeen, &h.Enabled, &collectStats, &registryMirror, &site, &h.TenantID, &h.CreatedAt, &h.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -639,49 +1494,129 @@ func (db *DB) GetHost(id int64) (*models.Host, error) {
 	} else {
 		h.CollectStats = true // Default to true
 	}
+	h.RegistryMirror = registryMirror.String
+	h.Site = site.String
 
 	return &h, nil
 }
 
-// UpdateHost updates an existing host
+// UpdateHost updates an existing host. A changed agent token restarts the token's age.
 func (db *DB) UpdateHost(host models.Host) error {
+	host = withHostType(host)
 	_, err := db.conn.Exec(`
 		UPDATE hosts
-		SET name = ?, address = ?, description = ?, host_type = ?, agent_token = ?, agent_status = ?, last_seen = ?, enabled = ?, collect_stats = ?, updated_at = CURRENT_TIMESTAMP
+		SET name = ?, address = ?, description = ?, host_type = ?, agent_token = ?, agent_status = ?, last_seen = ?, enabled = ?, collect_stats = ?, registry_mirror = ?, site = ?, updated_at = CURRENT_TIMESTAMP,
+		    agent_token_changed_at = CASE WHEN COALESCE(agent_token, '') = ? THEN agent_token_changed_at ELSE ? END
 		WHERE id = ?
-	`, host.Name, host.Address, host.Description, host.HostType, host.AgentToken, host.AgentStatus, host.LastSeen, host.Enabled, host.CollectStats, host.ID)
+	`, host.Name, host.Address, host.Description, host.HostType, host.AgentToken, host.AgentStatus, host.LastSeen, host.Enabled, host.CollectStats, host.RegistryMirror, host.Site, host.AgentToken, time.Now(), host.ID)
 	return err
 }
 
-// DeleteHost deletes a host
-func (db *DB) DeleteHost(id int64) error {
-	_, err := db.conn.Exec("DELETE FROM hosts WHERE id = ?", id)
-	return err
-}
+// SetHostTenant assigns a host to a tenant (0 leaves it to the administrator)
+func (db *DB) SetHostTenant(hostID, tenantID int64) error {
+	_, err := db.conn.Exec(`UPDATE hosts SET tenant_id = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, tenantID, hostID)
+	return err
+}
+
+// Container operations
+
+// SaveContainers saves a batch of containers from a scan
+func (db *DB) SaveContainers(containers []models.Container) error {
+	if len(containers) == 0 {
+		return nil
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := saveContainers(tx, containers); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// saveContainers writes containers of one or more hosts' scans in a transaction
+func saveContainers(tx *sql.Tx, containers []models.Container) error {
+	stmt, err := tx.Prepare(`
+		INSERT INTO containers
+		(id, name, image, image_id, image_tags, state, status, ports, labels, created, host_id, host_name, scanned_at, networks, volumes, links, compose_project, env_endpoints, cpu_percent, memory_usage, memory_limit, memory_percent, network_rx_rate, network_tx_rate, block_read_rate, block_write_rate, update_available, last_update_check, scan_id, restart_count)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	configStmt, err := tx.Prepare(`
+		INSERT INTO container_configs (container_id, host_id, container_name, host_name, config, collected_at, risk_score, privileged, privileged_since,
+			restart_policy, previous_restart_policy, restart_policy_changed_at, restart_policy_warned)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(container_id, host_id) DO UPDATE SET
+			container_name = excluded.container_name,
+			host_name = excluded.host_name,
+			config = excluded.config,
+			collected_at = excluded.collected_at,
+			risk_score = excluded.risk_score,
+			privileged = excluded.privileged,
+			privileged_since = CASE
+				WHEN NOT excluded.privileged THEN NULL
+				WHEN container_configs.privileged THEN container_configs.privileged_since
+				ELSE excluded.privileged_since
+			END,
+			restart_policy = excluded.restart_policy,
+			previous_restart_policy = CASE
+				WHEN excluded.restart_policy_changed_at IS NULL THEN container_configs.previous_restart_policy
+				ELSE excluded.previous_restart_policy
+			END,
+			restart_policy_changed_at = COALESCE(excluded.restart_policy_changed_at, container_configs.restart_policy_changed_at),
+			restart_policy_warned = excluded.restart_policy_warned
+	`)
+	if err != nil {
+		return err
+	}
+	defer configStmt.Close()
 
-// Container operations
+	// Restart policies as of the previous scans, to notice changes (also across recreation)
+	restartPolicies, err := latestRestartPolicies(tx, containers)
+	if err != nil {
+		return err
+	}
 
-// SaveContainers saves a batch of containers from a scan
-func (db *DB) SaveContainers(containers []models.Container) error {
-	if len(containers) == 0 {
-		return nil
+	usageStmt, err := tx.Prepare(imageUsageUpsert)
+	if err != nil {
+		return err
 	}
+	defer usageStmt.Close()
 
-	tx, err := db.conn.Begin()
+	imageRunExtendStmt, err := tx.Prepare(imageRunExtend)
 	if err != nil {
 		return err
 	}
-	defer tx.Rollback()
+	defer imageRunExtendStmt.Close()
+	imageRunInsertStmt, err := tx.Prepare(imageRunInsert)
+	if err != nil {
+		return err
+	}
+	defer imageRunInsertStmt.Close()
 
-	stmt, err := tx.Prepare(`
-		INSERT INTO containers
-		(id, name, image, image_id, image_tags, state, status, ports, labels, created, host_id, host_name, scanned_at, networks, volumes, links, compose_project, cpu_percent, memory_usage, memory_limit, memory_percent, update_available, last_update_check)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	// Each finished run of a backup job is recorded once, however many scans see it
+	backupRunStmt, err := tx.Prepare(`
+		INSERT OR IGNORE INTO backup_runs (host_id, container_name, container_id, image, started_at, finished_at, exit_code, interval_seconds)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer backupRunStmt.Close()
+
+	// Containers that kept their ID under a new name take their history along before the
+	// new rows are written
+	if err := applyContainerRenames(tx, containers); err != nil {
+		return err
+	}
 
 	for _, c := range containers {
 		portsJSON, err := json.Marshal(c.Ports)
@@ -714,10 +1649,16 @@ func (db *DB) SaveContainers(containers []models.Container) error {
 			return err
 		}
 
+		envEndpointsJSON, err := json.Marshal(c.EnvEndpoints)
+		if err != nil {
+			return err
+		}
+
 		// Handle nullable stats fields
 		// Store stats if memory_limit is set (indicates stats were collected)
 		var cpuPercent, memoryPercent sql.NullFloat64
 		var memoryUsage, memoryLimit sql.NullInt64
+		var networkRxRate, networkTxRate, blockReadRate, blockWriteRate sql.NullFloat64
 
 		if c.MemoryLimit > 0 {
 			// Stats were collected - store all values including 0
@@ -725,6 +1666,10 @@ func (db *DB) SaveContainers(containers []models.Container) error {
 			memoryUsage = sql.NullInt64{Int64: c.MemoryUsage, Valid: true}
 			memoryLimit = sql.NullInt64{Int64: c.MemoryLimit, Valid: true}
 			memoryPercent = sql.NullFloat64{Float64: c.MemoryPercent, Valid: true}
+			networkRxRate = sql.NullFloat64{Float64: c.NetworkRxRate, Valid: true}
+			networkTxRate = sql.NullFloat64{Float64: c.NetworkTxRate, Valid: true}
+			blockReadRate = sql.NullFloat64{Float64: c.BlockReadRate, Valid: true}
+			blockWriteRate = sql.NullFloat64{Float64: c.BlockWriteRate, Valid: true}
 			log.Printf("DB: Saving stats for container %s (id=%s, host_id=%d, scanned_at=%v): CPU=%.2f%%, Memory=%dMB",
 				c.Name, c.ID, c.HostID, c.ScannedAt, c.CPUPercent, c.MemoryUsage/1024/1024)
 		}
@@ -739,16 +1684,66 @@ func (db *DB) SaveContainers(containers []models.Container) error {
 			c.ID, c.Name, c.Image, c.ImageID, string(imageTagsJSON), c.State, c.Status,
 			string(portsJSON), string(labelsJSON), c.Created,
 			c.HostID, c.HostName, c.ScannedAt,
-			string(networksJSON), string(volumesJSON), string(linksJSON), c.ComposeProject,
+			string(networksJSON), string(volumesJSON), string(linksJSON), c.ComposeProject, string(envEndpointsJSON),
 			cpuPercent, memoryUsage, memoryLimit, memoryPercent,
-			c.UpdateAvailable, lastUpdateCheck,
+			networkRxRate, networkTxRate, blockReadRate, blockWriteRate,
+			c.UpdateAvailable, lastUpdateCheck, c.ScanID, c.RestartCount,
 		)
 		if err != nil {
 			return err
 		}
-	}
 
-	return tx.Commit()
+		if c.ImageID != "" {
+			var lastUsed sql.NullTime
+			if c.State == "running" {
+				lastUsed = sql.NullTime{Time: c.ScannedAt, Valid: true}
+			}
+			if _, err := usageStmt.Exec(c.ImageID, c.HostID, c.ScannedAt, lastUsed); err != nil {
+				return err
+			}
+			if err := recordImageRun(imageRunExtendStmt, imageRunInsertStmt, c); err != nil {
+				return err
+			}
+		}
+
+		// Keep only the latest configuration per container rather than one per scan
+		if c.Config != nil {
+			configJSON, err := json.Marshal(c.Config)
+			if err != nil {
+				return err
+			}
+			// privileged_since is only kept from the first scan that saw the container privileged
+			var privilegedSince sql.NullTime
+			if c.Config.Privileged {
+				privilegedSince = sql.NullTime{Time: c.ScannedAt, Valid: true}
+			}
+			risk := inspect.AssessRisk(c.Config)
+			policy := models.NormalizeRestartPolicy(c.Config.RestartPolicy)
+			previous, seen := restartPolicies[restartPolicyKey{c.HostID, c.Name}]
+			var previousPolicy string
+			var policyChangedAt sql.NullTime
+			if seen && previous.policy != policy {
+				previousPolicy = previous.policy
+				policyChangedAt = sql.NullTime{Time: c.ScannedAt, Valid: true}
+			}
+			// A missing policy is warned about once per container name, until the policy changes
+			warned := seen && previous.warned && !policyChangedAt.Valid
+			if _, err := configStmt.Exec(c.ID, c.HostID, c.Name, c.HostName, string(configJSON), c.ScannedAt,
+				risk.Score, c.Config.Privileged, privilegedSince, policy, previousPolicy, policyChangedAt, warned); err != nil {
+				return err
+			}
+
+			if c.State == "exited" && !c.Config.StartedAt.IsZero() && !c.Config.FinishedAt.IsZero() {
+				if _, interval, isBackup := backup.Detect(c); isBackup {
+					if _, err := backupRunStmt.Exec(c.HostID, c.Name, c.ID, c.Image,
+						c.Config.StartedAt, c.Config.FinishedAt, c.Config.ExitCode, int64(interval.Seconds())); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
 }
 
 // GetLatestContainers returns the most recent containers for all hosts
@@ -756,9 +1751,9 @@ func (db *DB) GetLatestContainers() ([]models.Container, error) {
 	query := `
 		SELECT c.id, c.name, c.image, c.image_id, c.image_tags, c.state, c.status,
 		       c.ports, c.labels, c.created, c.host_id, c.host_name, c.scanned_at,
-		       c.networks, c.volumes, c.links, c.compose_project,
+		       c.networks, c.volumes, c.links, c.compose_project, c.env_endpoints,
 		       c.cpu_percent, c.memory_usage, c.memory_limit, c.memory_percent,
-		       c.update_available, c.last_update_check
+		       c.update_available, c.last_update_check, c.scan_id
 		FROM containers c
 		INNER JOIN (
 			SELECT host_id, MAX(scanned_at) as max_scan
@@ -782,9 +1777,9 @@ func (db *DB) GetContainersByHost(hostID int64) ([]models.Container, error) {
 	query := `
 		SELECT c.id, c.name, c.image, c.image_id, c.image_tags, c.state, c.status,
 		       c.ports, c.labels, c.created, c.host_id, c.host_name, c.scanned_at,
-		       c.networks, c.volumes, c.links, c.compose_project,
+		       c.networks, c.volumes, c.links, c.compose_project, c.env_endpoints,
 		       c.cpu_percent, c.memory_usage, c.memory_limit, c.memory_percent,
-		       c.update_available, c.last_update_check
+		       c.update_available, c.last_update_check, c.scan_id
 		FROM containers c
 		INNER JOIN (
 			SELECT MAX(scanned_at) as max_scan
@@ -809,9 +1804,9 @@ func (db *DB) GetContainersHistory(start, end time.Time) ([]models.Container, er
 	query := `
 		SELECT id, name, image, image_id, image_tags, state, status,
 		       ports, labels, created, host_id, host_name, scanned_at,
-		       networks, volumes, links, compose_project,
+		       networks, volumes, links, compose_project, env_endpoints,
 		       cpu_percent, memory_usage, memory_limit, memory_percent,
-		       update_available, last_update_check
+		       update_available, last_update_check, scan_id
 		FROM containers
 		WHERE scanned_at BETWEEN ? AND ?
 		ORDER BY scanned_at DESC, host_name, name
@@ -826,6 +1821,26 @@ func (db *DB) GetContainersHistory(start, end time.Time) ([]models.Container, er
 	return db.scanContainers(rows)
 }
 
+// GetContainersByScanID returns the container rows recorded by one scan
+func (db *DB) GetContainersByScanID(scanID string) ([]models.Container, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, name, image, image_id, image_tags, state, status,
+		       ports, labels, created, host_id, host_name, scanned_at,
+		       networks, volumes, links, compose_project, env_endpoints,
+		       cpu_percent, memory_usage, memory_limit, memory_percent,
+		       update_available, last_update_check, scan_id
+		FROM containers
+		WHERE scan_id = ?
+		ORDER BY name
+	`, scanID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return db.scanContainers(rows)
+}
+
 // scanContainers helper to scan container rows
 func (db *DB) scanContainers(rows *sql.Rows) ([]models.Container, error) {
 	var containers []models.Container
@@ -834,18 +1849,19 @@ func (db *DB) scanContainers(rows *sql.Rows) ([]models.Container, error) {
 		var c models.Container
 		var portsJSON, labelsJSON, networksJSON, volumesJSON, linksJSON string
 		var imageTagsJSON sql.NullString
-		var composeProject sql.NullString
+		var composeProject, envEndpointsJSON sql.NullString
 		var cpuPercent, memoryPercent sql.NullFloat64
 		var memoryUsage, memoryLimit sql.NullInt64
 		var lastUpdateCheck sql.NullTime
+		var scanID sql.NullString
 
 		err := rows.Scan(
 			&c.ID, &c.Name, &c.Image, &c.ImageID, &imageTagsJSON, &c.State, &c.Status,
 			&portsJSON, &labelsJSON, &c.Created,
 			&c.HostID, &c.HostName, &c.ScannedAt,
-			&networksJSON, &volumesJSON, &linksJSON, &composeProject,
+			&networksJSON, &volumesJSON, &linksJSON, &composeProject, &envEndpointsJSON,
 			&cpuPercent, &memoryUsage, &memoryLimit, &memoryPercent,
-			&c.UpdateAvailable, &lastUpdateCheck,
+			&c.UpdateAvailable, &lastUpdateCheck, &scanID,
 		)
 		if err != nil {
 			return nil, err
@@ -887,6 +1903,12 @@ func (db *DB) scanContainers(rows *sql.Rows) ([]models.Container, error) {
 			c.ComposeProject = composeProject.String
 		}
 
+		if envEndpointsJSON.Valid && envEndpointsJSON.String != "" && envEndpointsJSON.String != "null" {
+			if err := json.Unmarshal([]byte(envEndpointsJSON.String), &c.EnvEndpoints); err != nil {
+				return nil, err
+			}
+		}
+
 		// Populate stats fields
 		if cpuPercent.Valid {
 			c.CPUPercent = cpuPercent.Float64
@@ -905,6 +1927,7 @@ func (db *DB) scanContainers(rows *sql.Rows) ([]models.Container, error) {
 		if lastUpdateCheck.Valid {
 			c.LastUpdateCheck = lastUpdateCheck.Time
 		}
+		c.ScanID = scanID.String
 
 		containers = append(containers, c)
 	}
@@ -916,12 +1939,53 @@ func (db *DB) scanContainers(rows *sql.Rows) ([]models.Container, error) {
 
 // SaveScanResult saves a scan result
 func (db *DB) SaveScanResult(result models.ScanResult) (int64, error) {
-	res, err := db.conn.Exec(`
+	return insertScanResult(db.conn, result)
+}
+
+// SaveScan saves the containers and results of a scan of several hosts in one transaction
+func (db *DB) SaveScan(containers []models.Container, results []models.ScanResult) error {
+	if len(containers) == 0 && len(results) == 0 {
+		return nil
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if len(containers) > 0 {
+		if err := saveContainers(tx, containers); err != nil {
+			return fmt.Errorf("failed to save containers: %w", err)
+		}
+	}
+	for _, result := range results {
+		if _, err := insertScanResult(tx, result); err != nil {
+			return fmt.Errorf("failed to save scan result for host %s: %w", result.HostName, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// insertScanResult inserts a scan result using a connection or a transaction
+func insertScanResult(exec interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}, result models.ScanResult) (int64, error) {
+	var warnings interface{}
+	if len(result.Warnings) > 0 {
+		data, err := json.Marshal(result.Warnings)
+		if err != nil {
+			return 0, err
+		}
+		warnings = string(data)
+	}
+
+	res, err := exec.Exec(`
 		INSERT INTO scan_results
-		(host_id, host_name, started_at, completed_at, success, error, containers_found)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		(host_id, host_name, started_at, completed_at, success, error, containers_found, warnings, scan_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`, result.HostID, result.HostName, result.StartedAt, result.CompletedAt,
-		result.Success, result.Error, result.ContainersFound)
+		result.Success, result.Error, result.ContainersFound, warnings, result.ScanID)
 	if err != nil {
 		return 0, err
 	}
@@ -930,12 +1994,42 @@ func (db *DB) SaveScanResult(result models.ScanResult) (int64, error) {
 
 // GetScanResults returns recent scan results
 func (db *DB) GetScanResults(limit int) ([]models.ScanResult, error) {
-	rows, err := db.conn.Query(`
-		SELECT id, host_id, host_name, started_at, completed_at, success, error, containers_found
+	return db.queryScanResults(`
+		SELECT id, host_id, host_name, started_at, completed_at, success, error, containers_found, warnings, scan_id
 		FROM scan_results
 		ORDER BY started_at DESC
 		LIMIT ?
 	`, limit)
+}
+
+// GetScanResultByScanID returns the result of the scan with the given scan ID
+func (db *DB) GetScanResultByScanID(scanID string) (*models.ScanResult, error) {
+	results, err := db.queryScanResults(`
+		SELECT id, host_id, host_name, started_at, completed_at, success, error, containers_found, warnings, scan_id
+		FROM scan_results
+		WHERE scan_id = ?
+	`, scanID)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &results[0], nil
+}
+
+// GetLatestScanResults returns the most recent scan result of each host, ordered by host name
+func (db *DB) GetLatestScanResults() ([]models.ScanResult, error) {
+	return db.queryScanResults(`
+		SELECT r.id, r.host_id, r.host_name, r.started_at, r.completed_at, r.success, r.error, r.containers_found, r.warnings, r.scan_id
+		FROM scan_results r
+		WHERE r.id = (SELECT id FROM scan_results WHERE host_id = r.host_id ORDER BY started_at DESC, id DESC LIMIT 1)
+		ORDER BY r.host_name
+	`)
+}
+
+func (db *DB) queryScanResults(query string, args ...interface{}) ([]models.ScanResult, error) {
+	rows, err := db.conn.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -944,10 +2038,10 @@ func (db *DB) GetScanResults(limit int) ([]models.ScanResult, error) {
 	var results []models.ScanResult
 	for rows.Next() {
 		var r models.ScanResult
-		var errMsg sql.NullString
+		var errMsg, warnings, scanID sql.NullString
 
 		err := rows.Scan(&r.ID, &r.HostID, &r.HostName, &r.StartedAt, &r.CompletedAt,
-			&r.Success, &errMsg, &r.ContainersFound)
+			&r.Success, &errMsg, &r.ContainersFound, &warnings, &scanID)
 		if err != nil {
 			return nil, err
 		}
@@ -955,6 +2049,12 @@ func (db *DB) GetScanResults(limit int) ([]models.ScanResult, error) {
 		if errMsg.Valid {
 			r.Error = errMsg.String
 		}
+		r.ScanID = scanID.String
+		if warnings.Valid && warnings.String != "" {
+			if err := json.Unmarshal([]byte(warnings.String), &r.Warnings); err != nil {
+				log.Printf("Failed to decode warnings of scan result %d: %v", r.ID, err)
+			}
+		}
 
 		results = append(results, r)
 	}
@@ -1084,7 +2184,36 @@ func (db *DB) GetActivityLog(limit int, activityType string) ([]models.ActivityL
 // CleanupOldData removes container records older than the specified duration
 func (db *DB) CleanupOldData(olderThan time.Duration) error {
 	cutoff := time.Now().Add(-olderThan)
-	_, err := db.conn.Exec("DELETE FROM containers WHERE scanned_at < ?", cutoff)
+	if _, err := db.conn.Exec("DELETE FROM containers WHERE scanned_at < ?", cutoff); err != nil {
+		return err
+	}
+	if _, err := db.conn.Exec("DELETE FROM container_configs WHERE collected_at < ?", cutoff); err != nil {
+		return err
+	}
+	if _, err := db.conn.Exec("DELETE FROM image_layers_cache WHERE collected_at < ?", cutoff); err != nil {
+		return err
+	}
+	if _, err := db.conn.Exec("DELETE FROM uptime_checks WHERE checked_at < ?", cutoff); err != nil {
+		return err
+	}
+	// Keep each job's latest successful run so an old success still sets its due time
+	if _, err := db.conn.Exec(`
+		DELETE FROM backup_runs
+		WHERE finished_at < ? AND id NOT IN (
+			SELECT id FROM backup_runs r
+			WHERE exit_code = 0 AND finished_at = (
+				SELECT MAX(finished_at) FROM backup_runs
+				WHERE host_id = r.host_id AND container_name = r.container_name AND exit_code = 0
+			)
+		)
+	`, cutoff); err != nil {
+		return err
+	}
+	// Keep each host's latest audit so hosts that are no longer audited still show a result
+	_, err := db.conn.Exec(`
+		DELETE FROM compliance_audits
+		WHERE audited_at < ? AND id NOT IN (SELECT MAX(id) FROM compliance_audits GROUP BY host_id)
+	`, cutoff)
 	return err
 }
 
@@ -1335,6 +2464,7 @@ func (db *DB) GetContainerLifecycleEvents(containerName string, hostID int64) ([
 
 	var events []models.ContainerLifecycleEvent
 	var firstSeen = true
+	var firstScanTime time.Time
 	var lastScanTime time.Time
 	var lastState string
 	var totalScans int
@@ -1395,6 +2525,7 @@ func (db *DB) GetContainerLifecycleEvents(containerName string, hostID int64) ([
 				Description: fmt.Sprintf("Container '%s' first detected (%s)", name, stateDesc),
 			})
 			firstSeen = false
+			firstScanTime = scannedAt
 			continue
 		}
 
@@ -1475,6 +2606,64 @@ func (db *DB) GetContainerLifecycleEvents(containerName string, hostID int64) ([
 		lastState = state
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Renames happen between scans, so they are merged into the timeline by time
+	renames, err := db.GetContainerRenames(containerName, hostID)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range renames {
+		events = append(events, models.ContainerLifecycleEvent{
+			Timestamp:   r.RenamedAt,
+			EventType:   "renamed",
+			OldName:     r.OldName,
+			NewName:     r.NewName,
+			Description: fmt.Sprintf("Container renamed from '%s' to '%s'", r.OldName, r.NewName),
+		})
+	}
+
+	// So are OOM kills read from the host's logs by the agent
+	ooms, err := db.GetContainerOOMKills(containerName, hostID)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range ooms {
+		events = append(events, models.ContainerLifecycleEvent{
+			Timestamp:   o.OccurredAt,
+			EventType:   "oom_killed",
+			Description: o.Message,
+		})
+	}
+
+	// And annotations of the container, its host or every host since it was first seen
+	var annotations []models.Annotation
+	if totalScans > 0 {
+		annotations, err = db.GetAnnotations(models.AnnotationFilter{
+			Start:         firstScanTime,
+			HostIDs:       []int64{hostID},
+			ContainerName: containerName,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, a := range annotations {
+		events = append(events, models.ContainerLifecycleEvent{
+			Timestamp:    a.At,
+			EventType:    "annotation",
+			Description:  a.Text,
+			AnnotationID: a.ID,
+		})
+	}
+	if len(renames) > 0 || len(ooms) > 0 || len(annotations) > 0 {
+		sort.SliceStable(events, func(i, j int) bool {
+			return events[i].Timestamp.Before(events[j].Timestamp)
+		})
+	}
+
 	// Add last_seen event if we have data
 	if totalScans > 0 {
 		stateDesc := lastState
@@ -1593,7 +2782,8 @@ func (db *DB) GetContainerStats(containerID string, hostID int64, hoursBack int)
 	// Get granular data from containers table (last hour or within requested range)
 	// Use LIKE to handle both short and long container IDs
 	granularQuery := `
-		SELECT scanned_at, cpu_percent, memory_usage, memory_limit, memory_percent
+		SELECT scanned_at, cpu_percent, memory_usage, memory_limit, memory_percent,
+		       network_rx_rate, network_tx_rate, block_read_rate, block_write_rate
 		FROM containers
 		WHERE (id = ? OR id LIKE ?) AND host_id = ? AND scanned_at >= ?
 		  AND (cpu_percent IS NOT NULL OR memory_usage IS NOT NULL)
@@ -1616,12 +2806,19 @@ func (db *DB) GetContainerStats(containerID string, hostID int64, hoursBack int)
 		var point models.ContainerStatsPoint
 		var cpuPercent, memoryPercent sql.NullFloat64
 		var memoryUsage, memoryLimit sql.NullInt64
+		var networkRxRate, networkTxRate, blockReadRate, blockWriteRate sql.NullFloat64
 
-		err := rows.Scan(&point.Timestamp, &cpuPercent, &memoryUsage, &memoryLimit, &memoryPercent)
+		err := rows.Scan(&point.Timestamp, &cpuPercent, &memoryUsage, &memoryLimit, &memoryPercent,
+			&networkRxRate, &networkTxRate, &blockReadRate, &blockWriteRate)
 		if err != nil {
 			return nil, err
 		}
 
+		point.NetworkRxRate = networkRxRate.Float64
+		point.NetworkTxRate = networkTxRate.Float64
+		point.BlockReadRate = blockReadRate.Float64
+		point.BlockWriteRate = blockWriteRate.Float64
+
 		if cpuPercent.Valid {
 			point.CPUPercent = cpuPercent.Float64
 		}
@@ -1647,7 +2844,9 @@ func (db *DB) GetContainerStats(containerID string, hostID int64, hoursBack int)
 	// Get aggregated data if looking back more than 1 hour
 	if hoursBack == 0 || hoursBack > 1 {
 		aggregateQuery := `
-			SELECT timestamp_hour, avg_cpu_percent, avg_memory_usage, max_memory_usage
+			SELECT timestamp_hour, avg_cpu_percent, avg_memory_usage, max_memory_usage,
+			       avg_network_rx_rate, avg_network_tx_rate, avg_block_read_rate, avg_block_write_rate,
+			       max_cpu_percent, p95_cpu_percent, p99_cpu_percent, p95_memory_usage, p99_memory_usage
 			FROM container_stats_aggregates
 			WHERE (container_id = ? OR container_id LIKE ?) AND host_id = ? AND timestamp_hour >= ?
 			ORDER BY timestamp_hour ASC
@@ -1662,11 +2861,22 @@ func (db *DB) GetContainerStats(containerID string, hostID int64, hoursBack int)
 		for aggRows.Next() {
 			var point models.ContainerStatsPoint
 			var avgCPU, avgMemory, maxMemory sql.NullFloat64
+			var networkRxRate, networkTxRate, blockReadRate, blockWriteRate sql.NullFloat64
+			var spread aggregateSpread
 
-			err := aggRows.Scan(&point.Timestamp, &avgCPU, &avgMemory, &maxMemory)
+			err := aggRows.Scan(&point.Timestamp, &avgCPU, &avgMemory, &maxMemory,
+				&networkRxRate, &networkTxRate, &blockReadRate, &blockWriteRate,
+				&spread.maxCPU, &spread.p95CPU, &spread.p99CPU, &spread.p95Memory, &spread.p99Memory)
 			if err != nil {
 				return nil, err
 			}
+			spread.maxMemory = maxMemory
+			spread.apply(&point)
+
+			point.NetworkRxRate = networkRxRate.Float64
+			point.NetworkTxRate = networkTxRate.Float64
+			point.BlockReadRate = blockReadRate.Float64
+			point.BlockWriteRate = blockWriteRate.Float64
 
 			if avgCPU.Valid {
 				point.CPUPercent = avgCPU.Float64
@@ -1691,34 +2901,111 @@ func (db *DB) GetContainerStats(containerID string, hostID int64, hoursBack int)
 	return allPoints, nil
 }
 
+// aggregateSpread holds the max and percentile columns of an hourly stats aggregate
+type aggregateSpread struct {
+	maxCPU, p95CPU, p99CPU          sql.NullFloat64
+	maxMemory, p95Memory, p99Memory sql.NullFloat64
+}
+
+// apply sets the spread on a point; aggregated before the percentile columns existed, an hour has
+// only its max
+func (a aggregateSpread) apply(point *models.ContainerStatsPoint) {
+	float := func(v sql.NullFloat64) *float64 {
+		if !v.Valid {
+			return nil
+		}
+		return &v.Float64
+	}
+	bytes := func(v sql.NullFloat64) *int64 {
+		if !v.Valid {
+			return nil
+		}
+		n := int64(v.Float64)
+		return &n
+	}
+	point.MaxCPUPercent = float(a.maxCPU)
+	point.P95CPUPercent = float(a.p95CPU)
+	point.P99CPUPercent = float(a.p99CPU)
+	point.MaxMemoryUsage = bytes(a.maxMemory)
+	point.P95MemoryUsage = bytes(a.p95Memory)
+	point.P99MemoryUsage = bytes(a.p99Memory)
+}
+
 // AggregateOldStats aggregates container stats older than 1 hour into hourly buckets
 // This reduces database size while preserving historical trends
 func (db *DB) AggregateOldStats() (int, error) {
 	// Find the cutoff time (1 hour ago)
 	cutoff := time.Now().Add(-1 * time.Hour)
 
-	// Aggregate stats into hourly buckets
+	// Aggregate stats into hourly buckets. Percentiles use the nearest rank: the samples of an hour
+	// are ranked (NULLs last) and the one at rank ceil(p * count) is kept.
 	query := `
+		WITH ranked AS (
+			SELECT
+				id,
+				host_id,
+				datetime(strftime('%Y-%m-%d %H:00:00', scanned_at)) as timestamp_hour,
+				cpu_percent,
+				memory_usage,
+				ROW_NUMBER() OVER hour_by_cpu as cpu_rank,
+				COUNT(cpu_percent) OVER hour as cpu_count,
+				ROW_NUMBER() OVER hour_by_memory as memory_rank,
+				COUNT(memory_usage) OVER hour as memory_count
+			FROM containers
+			WHERE scanned_at < ?
+			  AND (cpu_percent IS NOT NULL OR memory_usage IS NOT NULL)
+			WINDOW hour AS (PARTITION BY id, host_id, datetime(strftime('%Y-%m-%d %H:00:00', scanned_at))),
+			       hour_by_cpu AS (hour ORDER BY cpu_percent IS NULL, cpu_percent),
+			       hour_by_memory AS (hour ORDER BY memory_usage IS NULL, memory_usage)
+		),
+		percentiles AS (
+			SELECT
+				id,
+				host_id,
+				timestamp_hour,
+				MAX(CASE WHEN cpu_rank = (95 * cpu_count + 99) / 100 THEN cpu_percent END) as p95_cpu_percent,
+				MAX(CASE WHEN cpu_rank = (99 * cpu_count + 99) / 100 THEN cpu_percent END) as p99_cpu_percent,
+				MAX(CASE WHEN memory_rank = (95 * memory_count + 99) / 100 THEN memory_usage END) as p95_memory_usage,
+				MAX(CASE WHEN memory_rank = (99 * memory_count + 99) / 100 THEN memory_usage END) as p99_memory_usage
+			FROM ranked
+			GROUP BY id, host_id, timestamp_hour
+		)
 		INSERT OR REPLACE INTO container_stats_aggregates
-		(container_id, container_name, host_id, host_name, timestamp_hour, avg_cpu_percent, avg_memory_usage, max_cpu_percent, max_memory_usage, sample_count)
+		(container_id, container_name, host_id, host_name, timestamp_hour, avg_cpu_percent, avg_memory_usage, max_cpu_percent, max_memory_usage,
+		 avg_network_rx_rate, avg_network_tx_rate, avg_block_read_rate, avg_block_write_rate,
+		 p95_cpu_percent, p99_cpu_percent, p95_memory_usage, p99_memory_usage, sample_count)
 		SELECT
-			id as container_id,
-			name as container_name,
-			host_id,
-			host_name,
-			datetime(strftime('%Y-%m-%d %H:00:00', scanned_at)) as timestamp_hour,
-			AVG(cpu_percent) as avg_cpu_percent,
-			AVG(memory_usage) as avg_memory_usage,
-			MAX(cpu_percent) as max_cpu_percent,
-			MAX(memory_usage) as max_memory_usage,
-			COUNT(*) as sample_count
-		FROM containers
-		WHERE scanned_at < ?
-		  AND (cpu_percent IS NOT NULL OR memory_usage IS NOT NULL)
-		GROUP BY id, name, host_id, host_name, timestamp_hour
+			g.container_id, g.container_name, g.host_id, g.host_name, g.timestamp_hour,
+			g.avg_cpu_percent, g.avg_memory_usage, g.max_cpu_percent, g.max_memory_usage,
+			g.avg_network_rx_rate, g.avg_network_tx_rate, g.avg_block_read_rate, g.avg_block_write_rate,
+			p.p95_cpu_percent, p.p99_cpu_percent, p.p95_memory_usage, p.p99_memory_usage,
+			g.sample_count
+		FROM (
+			SELECT
+				id as container_id,
+				name as container_name,
+				host_id,
+				host_name,
+				datetime(strftime('%Y-%m-%d %H:00:00', scanned_at)) as timestamp_hour,
+				AVG(cpu_percent) as avg_cpu_percent,
+				AVG(memory_usage) as avg_memory_usage,
+				MAX(cpu_percent) as max_cpu_percent,
+				MAX(memory_usage) as max_memory_usage,
+				AVG(network_rx_rate) as avg_network_rx_rate,
+				AVG(network_tx_rate) as avg_network_tx_rate,
+				AVG(block_read_rate) as avg_block_read_rate,
+				AVG(block_write_rate) as avg_block_write_rate,
+				COUNT(*) as sample_count
+			FROM containers
+			WHERE scanned_at < ?
+			  AND (cpu_percent IS NOT NULL OR memory_usage IS NOT NULL)
+			GROUP BY id, name, host_id, host_name, timestamp_hour
+		) g
+		LEFT JOIN percentiles p
+			ON p.id = g.container_id AND p.host_id = g.host_id AND p.timestamp_hour = g.timestamp_hour
 	`
 
-	result, err := db.conn.Exec(query, cutoff)
+	result, err := db.conn.Exec(query, cutoff, cutoff)
 	if err != nil {
 		return 0, fmt.Errorf("failed to aggregate stats: %w", err)
 	}
@@ -1747,12 +3034,23 @@ func (db *DB) AggregateOldStats() (int, error) {
 	return int(rowsAffected), nil
 }
 
+// CleanupOldStatsAggregates deletes hourly stats aggregates older than the given number of days
+func (db *DB) CleanupOldStatsAggregates(olderThanDays int) (int64, error) {
+	cutoff := time.Now().Add(-time.Duration(olderThanDays) * 24 * time.Hour)
+	result, err := db.conn.Exec(`DELETE FROM container_stats_aggregates WHERE timestamp_hour < ?`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old stats aggregates: %w", err)
+	}
+	return result.RowsAffected()
+}
+
 // GetCurrentStatsForAllContainers returns the latest stats for all running containers
 // Used for Prometheus /metrics endpoint
 func (db *DB) GetCurrentStatsForAllContainers() ([]models.Container, error) {
 	query := `
 		SELECT c.id, c.name, c.image, c.host_id, c.host_name,
-		       c.cpu_percent, c.memory_usage, c.memory_limit, c.memory_percent, c.state
+		       c.cpu_percent, c.memory_usage, c.memory_limit, c.memory_percent, c.state,
+		       c.network_rx_rate, c.network_tx_rate, c.block_read_rate, c.block_write_rate
 		FROM containers c
 		INNER JOIN (
 			SELECT id, host_id, MAX(scanned_at) as max_scan
@@ -1776,15 +3074,22 @@ func (db *DB) GetCurrentStatsForAllContainers() ([]models.Container, error) {
 		var c models.Container
 		var cpuPercent, memoryPercent sql.NullFloat64
 		var memoryUsage, memoryLimit sql.NullInt64
+		var networkRxRate, networkTxRate, blockReadRate, blockWriteRate sql.NullFloat64
 
 		err := rows.Scan(
 			&c.ID, &c.Name, &c.Image, &c.HostID, &c.HostName,
 			&cpuPercent, &memoryUsage, &memoryLimit, &memoryPercent, &c.State,
+			&networkRxRate, &networkTxRate, &blockReadRate, &blockWriteRate,
 		)
 		if err != nil {
 			return nil, err
 		}
 
+		c.NetworkRxRate = networkRxRate.Float64
+		c.NetworkTxRate = networkTxRate.Float64
+		c.BlockReadRate = blockReadRate.Float64
+		c.BlockWriteRate = blockWriteRate.Float64
+
 		if cpuPercent.Valid {
 			c.CPUPercent = cpuPercent.Float64
 		}
@@ -1832,6 +3137,12 @@ func parseTimestamp(timestampStr string) (time.Time, error) {
 
 // GetChangesReport generates a comprehensive environment change report for a time period
 func (db *DB) GetChangesReport(start, end time.Time, hostFilter int64) (*models.ChangesReport, error) {
+	return db.GetFilteredChangesReport(start, end, models.ChangesReportFilter{HostID: hostFilter})
+}
+
+// GetFilteredChangesReport generates the changes report of a time period for the host and/or
+// compose project (stack) of the filter; zero values don't filter
+func (db *DB) GetFilteredChangesReport(start, end time.Time, filter models.ChangesReportFilter) (*models.ChangesReport, error) {
 	report := &models.ChangesReport{
 		Period: models.ReportPeriod{
 			Start:         start,
@@ -1843,14 +3154,24 @@ func (db *DB) GetChangesReport(start, end time.Time, hostFilter int64) (*models.
 		ImageUpdates:      make([]models.ImageUpdateChange, 0),
 		StateChanges:      make([]models.StateChange, 0),
 		TopRestarted:      make([]models.RestartSummary, 0),
+		Stacks:            make([]models.StackChange, 0),
+		Annotations:       make([]models.Annotation, 0),
 	}
 
-	// Build WHERE clause for host filtering
-	hostFilterClause := ""
-	hostFilterArgs := []interface{}{start, end}
-	if hostFilter > 0 {
-		hostFilterClause = " AND c.host_id = ?"
-		hostFilterArgs = append(hostFilterArgs, hostFilter)
+	// Build WHERE clause for host and stack filtering. Its arguments go before those of the
+	// period, since the clause is in the first CTE of the queries.
+	filterClause := ""
+	filterArgs := []interface{}{}
+	if filter.HostID > 0 {
+		filterClause += " AND c.host_id = ?"
+		filterArgs = append(filterArgs, filter.HostID)
+	}
+	if filter.ComposeProject != "" {
+		filterClause += " AND c.compose_project = ?"
+		filterArgs = append(filterArgs, filter.ComposeProject)
+	}
+	withFilter := func(args ...interface{}) []interface{} {
+		return append(append([]interface{}{}, filterArgs...), args...)
 	}
 
 	// 1. Query for new containers (first seen in period)
@@ -1866,7 +3187,7 @@ func (db *DB) GetChangesReport(start, end time.Time, hostFilter int64) (*models.
 				MIN(c.scanned_at) as first_seen
 			FROM containers c
 			INNER JOIN hosts h ON c.host_id = h.id
-			WHERE h.enabled = 1` + hostFilterClause + `
+			WHERE h.enabled = 1` + filterClause + `
 			GROUP BY c.name, c.host_id, c.host_name
 		),
 		latest_state AS (
@@ -1877,12 +3198,13 @@ func (db *DB) GetChangesReport(start, end time.Time, hostFilter int64) (*models.
 				c.state,
 				c.host_id,
 				c.scanned_at,
+				COALESCE(c.compose_project, '') as compose_project,
 				ROW_NUMBER() OVER (PARTITION BY c.name, c.host_id ORDER BY c.scanned_at DESC) as rn
 			FROM containers c
 			INNER JOIN first_appearances f ON c.name = f.container_name AND c.host_id = f.host_id
 			WHERE c.scanned_at >= f.first_seen
 		)
-		SELECT ls.container_id, ls.container_name, ls.image, f.host_id, f.host_name, f.first_seen, ls.state
+		SELECT ls.container_id, ls.container_name, ls.image, f.host_id, f.host_name, f.first_seen, ls.state, ls.compose_project
 		FROM first_appearances f
 		INNER JOIN latest_state ls ON f.container_name = ls.container_name AND f.host_id = ls.host_id
 		WHERE f.first_seen BETWEEN ? AND ?
@@ -1891,7 +3213,7 @@ func (db *DB) GetChangesReport(start, end time.Time, hostFilter int64) (*models.
 		LIMIT 100
 	`
 
-	rows, err := db.conn.Query(newContainersQuery, append([]interface{}{start, end}, hostFilterArgs[2:]...)...)
+	rows, err := db.conn.Query(newContainersQuery, withFilter(start, end)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query new containers: %w", err)
 	}
@@ -1900,7 +3222,7 @@ func (db *DB) GetChangesReport(start, end time.Time, hostFilter int64) (*models.
 	for rows.Next() {
 		var c models.ContainerChange
 		var timestampStr string
-		if err := rows.Scan(&c.ContainerID, &c.ContainerName, &c.Image, &c.HostID, &c.HostName, &timestampStr, &c.State); err != nil {
+		if err := rows.Scan(&c.ContainerID, &c.ContainerName, &c.Image, &c.HostID, &c.HostName, &timestampStr, &c.State, &c.ComposeProject); err != nil {
 			return nil, err
 		}
 		// Parse timestamp
@@ -1929,7 +3251,7 @@ func (db *DB) GetChangesReport(start, end time.Time, hostFilter int64) (*models.
 				MAX(c.scanned_at) as last_seen
 			FROM containers c
 			INNER JOIN hosts h ON c.host_id = h.id
-			WHERE h.enabled = 1` + hostFilterClause + `
+			WHERE h.enabled = 1` + filterClause + `
 			GROUP BY c.name, c.host_id, c.host_name
 		),
 		final_state AS (
@@ -1940,12 +3262,13 @@ func (db *DB) GetChangesReport(start, end time.Time, hostFilter int64) (*models.
 				c.state,
 				c.host_id,
 				c.scanned_at,
+				COALESCE(c.compose_project, '') as compose_project,
 				ROW_NUMBER() OVER (PARTITION BY c.name, c.host_id ORDER BY c.scanned_at DESC) as rn
 			FROM containers c
 			INNER JOIN last_appearances l ON c.name = l.container_name AND c.host_id = l.host_id
 			WHERE c.scanned_at = l.last_seen
 		)
-		SELECT fs.container_id, fs.container_name, fs.image, l.host_id, l.host_name, l.last_seen, fs.state
+		SELECT fs.container_id, fs.container_name, fs.image, l.host_id, l.host_name, l.last_seen, fs.state, fs.compose_project
 		FROM last_appearances l
 		INNER JOIN final_state fs ON l.container_name = fs.container_name AND l.host_id = fs.host_id
 		WHERE l.last_seen < ?
@@ -1960,7 +3283,7 @@ func (db *DB) GetChangesReport(start, end time.Time, hostFilter int64) (*models.
 		LIMIT 100
 	`
 
-	rows, err = db.conn.Query(removedContainersQuery, append([]interface{}{end, end}, hostFilterArgs[2:]...)...)
+	rows, err = db.conn.Query(removedContainersQuery, withFilter(end, end)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query removed containers: %w", err)
 	}
@@ -1969,7 +3292,7 @@ func (db *DB) GetChangesReport(start, end time.Time, hostFilter int64) (*models.
 	for rows.Next() {
 		var c models.ContainerChange
 		var timestampStr string
-		if err := rows.Scan(&c.ContainerID, &c.ContainerName, &c.Image, &c.HostID, &c.HostName, &timestampStr, &c.State); err != nil {
+		if err := rows.Scan(&c.ContainerID, &c.ContainerName, &c.Image, &c.HostID, &c.HostName, &timestampStr, &c.State, &c.ComposeProject); err != nil {
 			return nil, err
 		}
 		// Parse timestamp
@@ -1997,14 +3320,15 @@ func (db *DB) GetChangesReport(start, end time.Time, hostFilter int64) (*models.
 				c.image,
 				c.image_id,
 				c.scanned_at,
+				COALESCE(c.compose_project, '') as compose_project,
 				LAG(c.image) OVER (PARTITION BY c.name, c.host_id ORDER BY c.scanned_at) as prev_image,
 				LAG(c.image_id) OVER (PARTITION BY c.name, c.host_id ORDER BY c.scanned_at) as prev_image_id
 			FROM containers c
 			INNER JOIN hosts h ON c.host_id = h.id
-			WHERE h.enabled = 1` + hostFilterClause + `
+			WHERE h.enabled = 1` + filterClause + `
 		)
 		SELECT container_id, container_name, host_id, host_name,
-		       prev_image, image, prev_image_id, image_id, scanned_at
+		       prev_image, image, prev_image_id, image_id, scanned_at, compose_project
 		FROM image_changes
 		WHERE prev_image_id IS NOT NULL
 		  AND image_id != prev_image_id
@@ -2013,14 +3337,7 @@ func (db *DB) GetChangesReport(start, end time.Time, hostFilter int64) (*models.
 		LIMIT 100
 	`
 
-	// Build args for image updates query: [hostFilter (if any), start, end]
-	imageUpdateArgs := []interface{}{}
-	if hostFilter > 0 {
-		imageUpdateArgs = append(imageUpdateArgs, hostFilter)
-	}
-	imageUpdateArgs = append(imageUpdateArgs, start, end)
-
-	rows, err = db.conn.Query(imageUpdatesQuery, imageUpdateArgs...)
+	rows, err = db.conn.Query(imageUpdatesQuery, withFilter(start, end)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query image updates: %w", err)
 	}
@@ -2030,7 +3347,7 @@ func (db *DB) GetChangesReport(start, end time.Time, hostFilter int64) (*models.
 		var u models.ImageUpdateChange
 		var timestampStr string
 		if err := rows.Scan(&u.ContainerID, &u.ContainerName, &u.HostID, &u.HostName,
-			&u.OldImage, &u.NewImage, &u.OldImageID, &u.NewImageID, &timestampStr); err != nil {
+			&u.OldImage, &u.NewImage, &u.OldImageID, &u.NewImageID, &timestampStr, &u.ComposeProject); err != nil {
 			return nil, err
 		}
 		// Parse timestamp
@@ -2056,13 +3373,14 @@ func (db *DB) GetChangesReport(start, end time.Time, hostFilter int64) (*models.
 				c.host_name,
 				c.state,
 				c.scanned_at,
+				COALESCE(c.compose_project, '') as compose_project,
 				LAG(c.state) OVER (PARTITION BY c.name, c.host_id ORDER BY c.scanned_at) as prev_state
 			FROM containers c
 			INNER JOIN hosts h ON c.host_id = h.id
-			WHERE h.enabled = 1` + hostFilterClause + `
+			WHERE h.enabled = 1` + filterClause + `
 		)
 		SELECT container_id, container_name, host_id, host_name,
-		       prev_state, state, scanned_at
+		       prev_state, state, scanned_at, compose_project
 		FROM state_transitions
 		WHERE prev_state IS NOT NULL
 		  AND state != prev_state
@@ -2071,14 +3389,7 @@ func (db *DB) GetChangesReport(start, end time.Time, hostFilter int64) (*models.
 		LIMIT 100
 	`
 
-	// Build args for state changes query: [hostFilter (if any), start, end]
-	stateChangeArgs := []interface{}{}
-	if hostFilter > 0 {
-		stateChangeArgs = append(stateChangeArgs, hostFilter)
-	}
-	stateChangeArgs = append(stateChangeArgs, start, end)
-
-	rows, err = db.conn.Query(stateChangesQuery, stateChangeArgs...)
+	rows, err = db.conn.Query(stateChangesQuery, withFilter(start, end)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query state changes: %w", err)
 	}
@@ -2088,7 +3399,7 @@ func (db *DB) GetChangesReport(start, end time.Time, hostFilter int64) (*models.
 		var s models.StateChange
 		var timestampStr string
 		if err := rows.Scan(&s.ContainerID, &s.ContainerName, &s.HostID, &s.HostName,
-			&s.OldState, &s.NewState, &timestampStr); err != nil {
+			&s.OldState, &s.NewState, &timestampStr, &s.ComposeProject); err != nil {
 			return nil, err
 		}
 		// Parse timestamp
@@ -2103,123 +3414,64 @@ func (db *DB) GetChangesReport(start, end time.Time, hostFilter int64) (*models.
 	}
 
 	// 5. Query for top restarted/active containers (counting state changes, not scans)
-	// Build query dynamically based on host filter
 	// Only includes containers from enabled hosts.
 	// Groups by NAME to track activity across container recreations.
-	var topRestartedQuery string
-	if hostFilter > 0 {
-		topRestartedQuery = `
-			WITH state_changes AS (
-				SELECT
-					c.name as container_name,
-					c.host_id,
-					c.host_name,
-					c.image,
-					c.state,
-					c.scanned_at,
-					LAG(c.state) OVER (PARTITION BY c.name, c.host_id ORDER BY c.scanned_at) as prev_state
-				FROM containers c
-				INNER JOIN hosts h ON c.host_id = h.id
-				WHERE c.scanned_at BETWEEN ? AND ?
-				  AND c.host_id = ?
-				  AND h.enabled = 1
-			),
-			activity_counts AS (
-				SELECT
-					container_name,
-					host_id,
-					host_name,
-					MAX(image) as image,
-					MAX(state) as current_state,
-					COUNT(CASE WHEN prev_state IS NOT NULL AND state != prev_state THEN 1 END) as change_count
-				FROM state_changes
-				GROUP BY container_name, host_id, host_name
-				HAVING change_count > 0
-			),
-			latest_container_id AS (
-				SELECT
-					c.name,
-					c.host_id,
-					MAX(c.id) as container_id
-				FROM containers c
-				WHERE c.scanned_at BETWEEN ? AND ?
-				  AND c.host_id = ?
-				GROUP BY c.name, c.host_id
-			)
+	topRestartedQuery := `
+		WITH state_changes AS (
 			SELECT
-				lci.container_id,
-				ac.container_name,
-				ac.host_id,
-				ac.host_name,
-				ac.image,
-				ac.change_count as restart_count,
-				ac.current_state
-			FROM activity_counts ac
-			INNER JOIN latest_container_id lci ON ac.container_name = lci.name AND ac.host_id = lci.host_id
-			ORDER BY ac.change_count DESC
-			LIMIT 20
-		`
-	} else {
-		topRestartedQuery = `
-			WITH state_changes AS (
-				SELECT
-					c.name as container_name,
-					c.host_id,
-					c.host_name,
-					c.image,
-					c.state,
-					c.scanned_at,
-					LAG(c.state) OVER (PARTITION BY c.name, c.host_id ORDER BY c.scanned_at) as prev_state
-				FROM containers c
-				INNER JOIN hosts h ON c.host_id = h.id
-				WHERE c.scanned_at BETWEEN ? AND ?
-				  AND h.enabled = 1
-			),
-			activity_counts AS (
-				SELECT
-					container_name,
-					host_id,
-					host_name,
-					MAX(image) as image,
-					MAX(state) as current_state,
-					COUNT(CASE WHEN prev_state IS NOT NULL AND state != prev_state THEN 1 END) as change_count
-				FROM state_changes
-				GROUP BY container_name, host_id, host_name
-				HAVING change_count > 0
-			),
-			latest_container_id AS (
-				SELECT
-					c.name,
-					c.host_id,
-					MAX(c.id) as container_id
-				FROM containers c
-				WHERE c.scanned_at BETWEEN ? AND ?
-				GROUP BY c.name, c.host_id
-			)
+				c.name as container_name,
+				c.host_id,
+				c.host_name,
+				c.image,
+				c.state,
+				c.scanned_at,
+				COALESCE(c.compose_project, '') as compose_project,
+				LAG(c.state) OVER (PARTITION BY c.name, c.host_id ORDER BY c.scanned_at) as prev_state
+			FROM containers c
+			INNER JOIN hosts h ON c.host_id = h.id
+			WHERE c.scanned_at BETWEEN ? AND ?` + filterClause + `
+			  AND h.enabled = 1
+		),
+		activity_counts AS (
 			SELECT
-				lci.container_id,
-				ac.container_name,
-				ac.host_id,
-				ac.host_name,
-				ac.image,
-				ac.change_count as restart_count,
-				ac.current_state
-			FROM activity_counts ac
-			INNER JOIN latest_container_id lci ON ac.container_name = lci.name AND ac.host_id = lci.host_id
-			ORDER BY ac.change_count DESC
-			LIMIT 20
-		`
-	}
+				container_name,
+				host_id,
+				host_name,
+				MAX(image) as image,
+				MAX(state) as current_state,
+				MAX(compose_project) as compose_project,
+				COUNT(CASE WHEN prev_state IS NOT NULL AND state != prev_state THEN 1 END) as change_count
+			FROM state_changes
+			GROUP BY container_name, host_id, host_name
+			HAVING change_count > 0
+		),
+		latest_container_id AS (
+			SELECT
+				c.name,
+				c.host_id,
+				MAX(c.id) as container_id
+			FROM containers c
+			WHERE c.scanned_at BETWEEN ? AND ?` + filterClause + `
+			GROUP BY c.name, c.host_id
+		)
+		SELECT
+			lci.container_id,
+			ac.container_name,
+			ac.host_id,
+			ac.host_name,
+			ac.image,
+			ac.change_count as restart_count,
+			ac.current_state,
+			ac.compose_project
+		FROM activity_counts ac
+		INNER JOIN latest_container_id lci ON ac.container_name = lci.name AND ac.host_id = lci.host_id
+		ORDER BY ac.change_count DESC
+		LIMIT 20
+	`
 
-	// Build args for query (need start/end twice plus host filter twice if applicable)
-	topRestartArgs := []interface{}{start, end}
-	if hostFilter > 0 {
-		topRestartArgs = append(topRestartArgs, hostFilter)
-	}
-	topRestartArgs = append(topRestartArgs, start, end)
-	if hostFilter > 0 {
-		topRestartArgs = append(topRestartArgs, hostFilter)
-	}
+	// The period and filter arguments, once for each CTE
+	topRestartArgs := append([]interface{}{start, end}, filterArgs...)
+	topRestartArgs = append(topRestartArgs, topRestartArgs...)
 
 	rows, err = db.conn.Query(topRestartedQuery, topRestartArgs...)
 	if err != nil {
@@ -2230,7 +3482,7 @@ func (db *DB) GetChangesReport(start, end time.Time, hostFilter int64) (*models.
 	for rows.Next() {
 		var r models.RestartSummary
 		if err := rows.Scan(&r.ContainerID, &r.ContainerName, &r.HostID, &r.HostName,
-			&r.Image, &r.RestartCount, &r.CurrentState); err != nil {
+			&r.Image, &r.RestartCount, &r.CurrentState, &r.ComposeProject); err != nil {
 			return nil, err
 		}
 		report.TopRestarted = append(report.TopRestarted, r)
@@ -2269,23 +3521,35 @@ func (db *DB) GetChangesReport(start, end time.Time, hostFilter int64) (*models.
 		}
 	}
 
-	// 7. Build summary statistics
+	// 7. Group the changes of compose projects into stacks
+	report.Stacks = summarizeStackChanges(report)
+
+	// Annotations of the period explain what the changes don't
+	annotations, err := db.GetAnnotations(reportAnnotationFilter(start, end, filter.HostID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get annotations: %w", err)
+	}
+	report.Annotations = annotations
+
+	// 8. Build summary statistics
 	report.Summary = models.ReportSummary{
 		NewContainers:     len(report.NewContainers),
 		RemovedContainers: len(report.RemovedContainers),
 		ImageUpdates:      len(report.ImageUpdates),
 		StateChanges:      len(report.StateChanges),
 		Restarts:          len(report.TopRestarted),
+		Stacks:            len(report.Stacks),
 	}
 
 	// Get total hosts and containers
-	hostCountQuery := `SELECT COUNT(DISTINCT host_id) FROM containers WHERE scanned_at BETWEEN ? AND ?` + hostFilterClause
-	if err := db.conn.QueryRow(hostCountQuery, hostFilterArgs...).Scan(&report.Summary.TotalHosts); err != nil {
+	periodArgs := append([]interface{}{start, end}, filterArgs...)
+	hostCountQuery := `SELECT COUNT(DISTINCT c.host_id) FROM containers c WHERE c.scanned_at BETWEEN ? AND ?` + filterClause
+	if err := db.conn.QueryRow(hostCountQuery, periodArgs...).Scan(&report.Summary.TotalHosts); err != nil {
 		return nil, fmt.Errorf("failed to count hosts: %w", err)
 	}
 
-	containerCountQuery := `SELECT COUNT(DISTINCT id || '-' || host_id) FROM containers WHERE scanned_at BETWEEN ? AND ?` + hostFilterClause
-	if err := db.conn.QueryRow(containerCountQuery, hostFilterArgs...).Scan(&report.Summary.TotalContainers); err != nil {
+	containerCountQuery := `SELECT COUNT(DISTINCT c.id || '-' || c.host_id) FROM containers c WHERE c.scanned_at BETWEEN ? AND ?` + filterClause
+	if err := db.conn.QueryRow(containerCountQuery, periodArgs...).Scan(&report.Summary.TotalContainers); err != nil {
 		return nil, fmt.Errorf("failed to count containers: %w", err)
 	}
 
@@ -2342,6 +3606,51 @@ func (db *DB) GetAllPreferences() (map[string]string, error) {
 	return prefs, nil
 }
 
+// GetUserPreferences returns the preferences of a user: the shared preferences overlaid with
+// the ones the user saved. An empty username (authentication disabled) gets the shared ones.
+func (db *DB) GetUserPreferences(username string) (map[string]string, error) {
+	prefs, err := db.GetAllPreferences()
+	if err != nil || username == "" {
+		return prefs, err
+	}
+
+	rows, err := db.conn.Query(`SELECT key, value FROM user_preference_overrides WHERE username = ?`, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user preferences: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan user preference: %w", err)
+		}
+		prefs[key] = value
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating user preferences: %w", err)
+	}
+
+	return prefs, nil
+}
+
+// SetUserPreference saves a preference for one user; an empty username sets the shared preference
+func (db *DB) SetUserPreference(username, key, value string) error {
+	if username == "" {
+		return db.SetPreference(key, value)
+	}
+	_, err := db.conn.Exec(`
+		INSERT INTO user_preference_overrides (username, key, value, updated_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(username, key) DO UPDATE SET value = excluded.value, updated_at = CURRENT_TIMESTAMP
+	`, username, key, value)
+	if err != nil {
+		return fmt.Errorf("failed to set user preference: %w", err)
+	}
+	return nil
+}
+
 // ======= DANGER ZONE METHODS =======
 
 // ClearOldContainerHistory deletes container history older than specified hours (0 = delete all history)
@@ -2396,6 +3705,7 @@ func (db *DB) GetImageUpdateSettings() (*models.ImageUpdateSettings, error) {
 		AutoCheckEnabled:    false,
 		CheckIntervalHours:  24,
 		OnlyCheckLatestTags: true,
+		VulnerabilityGate:   models.VulnerabilityGateOff,
 	}
 
 	rows, err := db.conn.Query(`SELECT key, value FROM image_update_settings`)
@@ -2417,6 +3727,8 @@ func (db *DB) GetImageUpdateSettings() (*models.ImageUpdateSettings, error) {
 			fmt.Sscanf(value, "%d", &settings.CheckIntervalHours)
 		case "only_check_latest_tags":
 			settings.OnlyCheckLatestTags = value == "true" || value == "1"
+		case "vulnerability_gate":
+			settings.VulnerabilityGate = value
 		}
 	}
 
@@ -2469,6 +3781,10 @@ func (db *DB) SaveImageUpdateSettings(settings *models.ImageUpdateSettings) erro
 		return err
 	}
 
+	if _, err := stmt.Exec("vulnerability_gate", settings.VulnerabilityGate); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
@@ -2492,9 +3808,9 @@ func (db *DB) GetContainersWithUpdates() ([]models.Container, error) {
 	query := `
 		SELECT c.id, c.name, c.image, c.image_id, c.image_tags, c.state, c.status,
 		       c.ports, c.labels, c.created, c.host_id, c.host_name, c.scanned_at,
-		       c.networks, c.volumes, c.links, c.compose_project,
+		       c.networks, c.volumes, c.links, c.compose_project, c.env_endpoints,
 		       c.cpu_percent, c.memory_usage, c.memory_limit, c.memory_percent,
-		       c.update_available, c.last_update_check
+		       c.update_available, c.last_update_check, c.scan_id
 		FROM containers c
 		INNER JOIN (
 			SELECT host_id, MAX(scanned_at) as max_scan
@@ -80,6 +80,8 @@ func TestHostCRUD(t *testing.T) {
 	savedHost.Name = "updated-host"
 	savedHost.Address = "agent://remote-host:9876"
 	savedHost.CollectStats = false
+	savedHost.RegistryMirror = "harbor.local/dockerhub"
+	savedHost.Site = "parents-house"
 
 	err = db.UpdateHost(savedHost)
 	if err != nil {
@@ -102,6 +104,12 @@ func TestHostCRUD(t *testing.T) {
 	if hosts[0].CollectStats {
 		t.Error("CollectStats should be false after update")
 	}
+	if hosts[0].RegistryMirror != "harbor.local/dockerhub" {
+		t.Errorf("RegistryMirror not updated: got %q", hosts[0].RegistryMirror)
+	}
+	if hosts[0].Site != "parents-house" {
+		t.Errorf("Site not updated: got %q", hosts[0].Site)
+	}
 
 	// Delete host
 	err = db.DeleteHost(savedHost.ID)
@@ -357,6 +365,116 @@ func TestStatsAggregation(t *testing.T) {
 	t.Logf("Old granular records remaining: %d", count)
 }
 
+// TestIOStats tests that network and block I/O rates survive saving, aggregation and retrieval
+func TestIOStats(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "io-host", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to save host: %v", err)
+	}
+
+	oldScan := time.Now().Add(-3 * time.Hour).Truncate(time.Hour).Add(10 * time.Minute)
+	for i, scannedAt := range []time.Time{oldScan, oldScan.Add(time.Minute), time.Now()} {
+		container := models.Container{
+			ID:             "io123456789012",
+			HostID:         hostID,
+			Name:           "proxy",
+			Image:          "proxy:v1",
+			State:          "running",
+			ScannedAt:      scannedAt,
+			CPUPercent:     5,
+			MemoryUsage:    100000000,
+			MemoryLimit:    1073741824,
+			NetworkRxRate:  float64(1000 * (i + 1)),
+			NetworkTxRate:  500,
+			BlockReadRate:  0,
+			BlockWriteRate: 2048,
+		}
+		if err := db.SaveContainers([]models.Container{container}); err != nil {
+			t.Fatalf("Failed to save container: %v", err)
+		}
+	}
+
+	if _, err := db.AggregateOldStats(); err != nil {
+		t.Fatalf("AggregateOldStats failed: %v", err)
+	}
+
+	stats, err := db.GetContainerStats("io123456789012", hostID, 24)
+	if err != nil {
+		t.Fatalf("GetContainerStats failed: %v", err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("Expected 1 aggregated and 1 granular point, got %d", len(stats))
+	}
+
+	// Aggregated point averages the two old samples
+	if stats[0].NetworkRxRate != 1500 || stats[0].NetworkTxRate != 500 || stats[0].BlockWriteRate != 2048 {
+		t.Errorf("Unexpected aggregated I/O rates: %+v", stats[0])
+	}
+	if stats[1].NetworkRxRate != 3000 {
+		t.Errorf("Expected granular rx rate 3000, got %v", stats[1].NetworkRxRate)
+	}
+}
+
+// TestStatsAggregationPercentiles tests the p95/p99 of an hour kept by the hourly aggregation
+func TestStatsAggregationPercentiles(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "pct-host", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to save host: %v", err)
+	}
+
+	hour := time.Now().Add(-3 * time.Hour).Truncate(time.Hour)
+	for i := 1; i <= 20; i++ {
+		container := models.Container{
+			ID:          "pct123456789012",
+			HostID:      hostID,
+			Name:        "worker",
+			Image:       "worker:v1",
+			State:       "running",
+			ScannedAt:   hour.Add(time.Duration(i) * time.Minute),
+			CPUPercent:  float64(i),
+			MemoryUsage: int64(i) * 1024 * 1024,
+			MemoryLimit: 1073741824,
+		}
+		if err := db.SaveContainers([]models.Container{container}); err != nil {
+			t.Fatalf("Failed to save container: %v", err)
+		}
+	}
+
+	if _, err := db.AggregateOldStats(); err != nil {
+		t.Fatalf("AggregateOldStats failed: %v", err)
+	}
+
+	stats, err := db.GetContainerStats("pct123456789012", hostID, 24)
+	if err != nil {
+		t.Fatalf("GetContainerStats failed: %v", err)
+	}
+	if len(stats) != 1 {
+		t.Fatalf("Expected 1 aggregated point, got %d", len(stats))
+	}
+
+	// Nearest rank of 20 samples: p95 is the 19th, p99 the 20th
+	point := stats[0]
+	if point.CPUPercent != 10.5 {
+		t.Errorf("Expected avg CPU 10.5, got %v", point.CPUPercent)
+	}
+	if point.P95CPUPercent == nil || *point.P95CPUPercent != 19 {
+		t.Errorf("Expected p95 CPU 19, got %v", point.P95CPUPercent)
+	}
+	if point.P99CPUPercent == nil || *point.P99CPUPercent != 20 {
+		t.Errorf("Expected p99 CPU 20, got %v", point.P99CPUPercent)
+	}
+	if point.MaxCPUPercent == nil || *point.MaxCPUPercent != 20 {
+		t.Errorf("Expected max CPU 20, got %v", point.MaxCPUPercent)
+	}
+	if point.P95MemoryUsage == nil || *point.P95MemoryUsage != 19*1024*1024 {
+		t.Errorf("Expected p95 memory 19 MB, got %v", point.P95MemoryUsage)
+	}
+}
+
 // TestScanResults tests scan result tracking
 func TestScanResults(t *testing.T) {
 	db := setupTestDB(t)
@@ -401,6 +519,88 @@ func TestScanResults(t *testing.T) {
 	}
 }
 
+func TestScanResultWarnings(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "pi", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	now := time.Now()
+	if _, err := db.SaveScanResult(models.ScanResult{HostID: hostID, HostName: "pi", StartedAt: now.Add(-time.Hour), CompletedAt: now.Add(-time.Hour), Success: true}); err != nil {
+		t.Fatalf("SaveScanResult failed: %v", err)
+	}
+	warning := models.CollectionWarning{ContainerID: "abc", ContainerName: "web", Stage: models.CollectionStageStats, Message: "stats call failed: context deadline exceeded"}
+	if _, err := db.SaveScanResult(models.ScanResult{HostID: hostID, HostName: "pi", StartedAt: now, CompletedAt: now, Success: true, ContainersFound: 3, Warnings: []models.CollectionWarning{warning}}); err != nil {
+		t.Fatalf("SaveScanResult failed: %v", err)
+	}
+
+	results, err := db.GetLatestScanResults()
+	if err != nil {
+		t.Fatalf("GetLatestScanResults failed: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("Expected the latest scan of 1 host, got %d", len(results))
+	}
+	if results[0].ContainersFound != 3 || len(results[0].Warnings) != 1 || results[0].Warnings[0] != warning {
+		t.Errorf("Expected the latest scan with its warning, got %+v", results[0])
+	}
+
+	all, err := db.GetScanResults(10)
+	if err != nil {
+		t.Fatalf("GetScanResults failed: %v", err)
+	}
+	if len(all) != 2 || len(all[1].Warnings) != 0 {
+		t.Errorf("Expected the older scan without warnings, got %+v", all)
+	}
+}
+
+func TestScanID(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "pi", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	now := time.Now()
+	containers := []models.Container{
+		{ID: "abc", Name: "web", Image: "nginx", State: "running", Status: "Up", Created: now, HostID: hostID, HostName: "pi", ScannedAt: now, ScanID: "0123456789abcdef"},
+		{ID: "def", Name: "db", Image: "postgres", State: "running", Status: "Up", Created: now, HostID: hostID, HostName: "pi", ScannedAt: now, ScanID: "0123456789abcdef"},
+	}
+	if err := db.SaveContainers(containers); err != nil {
+		t.Fatalf("SaveContainers failed: %v", err)
+	}
+	if _, err := db.SaveScanResult(models.ScanResult{ScanID: "0123456789abcdef", HostID: hostID, HostName: "pi", StartedAt: now, CompletedAt: now, Success: true, ContainersFound: 2}); err != nil {
+		t.Fatalf("SaveScanResult failed: %v", err)
+	}
+
+	result, err := db.GetScanResultByScanID("0123456789abcdef")
+	if err != nil {
+		t.Fatalf("GetScanResultByScanID failed: %v", err)
+	}
+	if result.HostID != hostID || result.ContainersFound != 2 || result.ScanID != "0123456789abcdef" {
+		t.Errorf("Expected the scan result with its ID, got %+v", result)
+	}
+	if _, err := db.GetScanResultByScanID("unknown"); err != sql.ErrNoRows {
+		t.Errorf("Expected sql.ErrNoRows for an unknown scan, got %v", err)
+	}
+
+	recorded, err := db.GetContainersByScanID("0123456789abcdef")
+	if err != nil {
+		t.Fatalf("GetContainersByScanID failed: %v", err)
+	}
+	if len(recorded) != 2 || recorded[0].Name != "db" || recorded[0].ScanID != "0123456789abcdef" {
+		t.Errorf("Expected both containers of the scan, got %+v", recorded)
+	}
+
+	latest, err := db.GetContainersByHost(hostID)
+	if err != nil || len(latest) != 2 || latest[0].ScanID != "0123456789abcdef" {
+		t.Errorf("Expected the latest containers with their scan ID, got %+v (%v)", latest, err)
+	}
+}
+
 // TestGetContainerLifecycleEvents tests lifecycle event history
 func TestGetContainerLifecycleEvents(t *testing.T) {
 	db := setupTestDB(t)
@@ -558,3 +758,65 @@ func TestConcurrentAccess(t *testing.T) {
 		t.Errorf("Expected 10 container records, got %d", count)
 	}
 }
+
+func TestSaveScanAndAggregateRetention(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "pi", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	otherID, err := db.AddHost(models.Host{Name: "nas", Address: "tcp://nas:2375", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	now := time.Now()
+	results := []models.ScanResult{
+		{HostID: hostID, HostName: "pi", StartedAt: now.Add(-time.Minute), CompletedAt: now.Add(-time.Minute), Success: true, ContainersFound: 2},
+		{HostID: hostID, HostName: "pi", StartedAt: now, CompletedAt: now, Success: false, Error: "connection refused"},
+	}
+	containers := []models.Container{
+		{ID: "abc", Name: "web", Image: "nginx", State: "running", HostID: hostID, HostName: "pi", ScannedAt: now},
+		{ID: "def", Name: "db", Image: "postgres", State: "running", HostID: otherID, HostName: "nas", ScannedAt: now},
+	}
+	if err := db.SaveScan(containers, results); err != nil {
+		t.Fatalf("SaveScan failed: %v", err)
+	}
+	for _, id := range []int64{hostID, otherID} {
+		if saved, err := db.GetContainersByHost(id); err != nil || len(saved) != 1 {
+			t.Errorf("Expected one container on host %d, got %d (%v)", id, len(saved), err)
+		}
+	}
+	saved, err := db.GetScanResults(10)
+	if err != nil {
+		t.Fatalf("GetScanResults failed: %v", err)
+	}
+	if len(saved) != 2 || saved[0].Error != "connection refused" || saved[1].ContainersFound != 2 {
+		t.Errorf("Expected both scan results, got %+v", saved)
+	}
+
+	for _, at := range []time.Time{now.Add(-10 * 24 * time.Hour), now.Add(-2 * time.Hour)} {
+		if _, err := db.conn.Exec(`
+			INSERT INTO container_stats_aggregates
+			(container_id, container_name, host_id, host_name, timestamp_hour, avg_cpu_percent, avg_memory_usage, max_cpu_percent, max_memory_usage, sample_count)
+			VALUES ('abc', 'web', ?, 'pi', ?, 1, 1024, 2, 2048, 12)
+		`, hostID, at.UTC()); err != nil {
+			t.Fatalf("Failed to insert aggregate: %v", err)
+		}
+	}
+	deleted, err := db.CleanupOldStatsAggregates(7)
+	if err != nil {
+		t.Fatalf("CleanupOldStatsAggregates failed: %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("Expected 1 aggregate older than 7 days to be deleted, got %d", deleted)
+	}
+	var remaining int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM container_stats_aggregates`).Scan(&remaining); err != nil {
+		t.Fatal(err)
+	}
+	if remaining != 1 {
+		t.Errorf("Expected the recent aggregate to be kept, got %d rows", remaining)
+	}
+}
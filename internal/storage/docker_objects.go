@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// ReplaceDockerObjects replaces a host's object inventory with the objects of its latest scan
+func (db *DB) ReplaceDockerObjects(hostID int64, objects []models.DockerObject) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM docker_objects WHERE host_id = ?`, hostID); err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO docker_objects
+			(host_id, kind, object_id, name, detail, in_use, size_bytes, reclaimable_bytes, count, created_at, last_used_at, scanned_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, o := range objects {
+		if _, err := stmt.Exec(hostID, o.Kind, o.ObjectID, o.Name, o.Detail, o.InUse, o.SizeBytes, o.ReclaimableBytes,
+			o.Count, o.CreatedAt, o.LastUsedAt, o.ScannedAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetDockerObjects returns the inventoried objects of the given hosts by host, kind and name. Nil
+// hostIDs returns those of all hosts and an empty kind those of all kinds.
+func (db *DB) GetDockerObjects(hostIDs []int64, kind string) ([]models.DockerObject, error) {
+	query := `
+		SELECT o.host_id, COALESCE(h.name, ''), o.kind, o.object_id, o.name, o.detail, o.in_use, o.size_bytes,
+		       o.reclaimable_bytes, o.count, o.created_at, o.last_used_at, o.scanned_at
+		FROM docker_objects o
+		LEFT JOIN hosts h ON h.id = o.host_id
+		WHERE (? = '' OR o.kind = ?)`
+	args := []interface{}{kind, kind}
+	if hostIDs != nil {
+		if len(hostIDs) == 0 {
+			return []models.DockerObject{}, nil
+		}
+		query += ` AND o.host_id IN (` + strings.TrimSuffix(strings.Repeat("?,", len(hostIDs)), ",") + `)`
+		for _, id := range hostIDs {
+			args = append(args, id)
+		}
+	}
+	query += ` ORDER BY h.name, o.host_id, o.kind, o.name`
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	objects := make([]models.DockerObject, 0)
+	for rows.Next() {
+		var o models.DockerObject
+		var createdAt, lastUsedAt sql.NullTime
+		if err := rows.Scan(&o.HostID, &o.HostName, &o.Kind, &o.ObjectID, &o.Name, &o.Detail, &o.InUse, &o.SizeBytes,
+			&o.ReclaimableBytes, &o.Count, &createdAt, &lastUsedAt, &o.ScannedAt); err != nil {
+			return nil, err
+		}
+		if createdAt.Valid {
+			o.CreatedAt = &createdAt.Time
+		}
+		if lastUsedAt.Valid {
+			o.LastUsedAt = &lastUsedAt.Time
+		}
+		objects = append(objects, o)
+	}
+	return objects, rows.Err()
+}
+
+// DeleteDockerObjects clears the object inventory, for when it is switched off
+func (db *DB) DeleteDockerObjects() error {
+	_, err := db.conn.Exec(`DELETE FROM docker_objects`)
+	return err
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestDockerObjects(t *testing.T) {
+	db := setupTestDB(t)
+
+	nasID, err := db.AddHost(models.Host{Name: "nas", Address: "agent://nas:9876", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	vpsID, err := db.AddHost(models.Host{Name: "vps", Address: "tcp://vps:2376", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	created := now.Add(-24 * time.Hour)
+	if err := db.ReplaceDockerObjects(nasID, []models.DockerObject{
+		{Kind: models.DockerObjectPlugin, ObjectID: "p1", Name: "vieux/sshfs:latest", InUse: true, ScannedAt: now},
+		{Kind: models.DockerObjectBuildCache, ObjectID: "regular", Name: "regular", SizeBytes: 1000, ReclaimableBytes: 600, Count: 12, ScannedAt: now},
+	}); err != nil {
+		t.Fatalf("ReplaceDockerObjects failed: %v", err)
+	}
+	if err := db.ReplaceDockerObjects(vpsID, []models.DockerObject{
+		{Kind: models.DockerObjectSecret, ObjectID: "s1", Name: "db_password", CreatedAt: &created, ScannedAt: now},
+	}); err != nil {
+		t.Fatalf("ReplaceDockerObjects failed: %v", err)
+	}
+
+	objects, err := db.GetDockerObjects(nil, "")
+	if err != nil {
+		t.Fatalf("GetDockerObjects failed: %v", err)
+	}
+	if len(objects) != 3 || objects[0].HostName != "nas" || objects[2].HostName != "vps" {
+		t.Fatalf("Expected 3 objects by host name, got %+v", objects)
+	}
+	if objects[2].CreatedAt == nil || !objects[2].CreatedAt.Equal(created) {
+		t.Errorf("Expected the secret's creation time, got %v", objects[2].CreatedAt)
+	}
+
+	cache, err := db.GetDockerObjects([]int64{nasID}, models.DockerObjectBuildCache)
+	if err != nil || len(cache) != 1 || cache[0].Count != 12 || cache[0].ReclaimableBytes != 600 {
+		t.Errorf("Expected the nas build cache, got %+v (%v)", cache, err)
+	}
+
+	// A scan replaces the host's inventory
+	if err := db.ReplaceDockerObjects(nasID, nil); err != nil {
+		t.Fatalf("ReplaceDockerObjects failed: %v", err)
+	}
+	if objects, err := db.GetDockerObjects([]int64{nasID}, ""); err != nil || len(objects) != 0 {
+		t.Errorf("Expected the nas inventory to be empty, got %+v (%v)", objects, err)
+	}
+
+	if err := db.DeleteDockerObjects(); err != nil {
+		t.Fatalf("DeleteDockerObjects failed: %v", err)
+	}
+	if objects, err := db.GetDockerObjects(nil, ""); err != nil || len(objects) != 0 {
+		t.Errorf("Expected no objects, got %+v (%v)", objects, err)
+	}
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// ReplaceEndpointChecks stores the result of checking the proxy routes' hostnames, dropping
+// hostnames that are no longer routed
+func (db *DB) ReplaceEndpointChecks(checks []models.EndpointCheck) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM endpoint_checks`); err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(`INSERT INTO endpoint_checks (hostname, status, cert_not_after, result, checked_at) VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, check := range checks {
+		data, err := json.Marshal(check)
+		if err != nil {
+			return err
+		}
+		if _, err := stmt.Exec(check.Hostname, check.Status, check.CertNotAfter, string(data), check.CheckedAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetEndpointChecks returns the latest endpoint checks, soonest expiring certificate first and
+// hostnames without a certificate last
+func (db *DB) GetEndpointChecks() ([]models.EndpointCheck, error) {
+	rows, err := db.conn.Query(`SELECT result FROM endpoint_checks ORDER BY cert_not_after IS NULL, cert_not_after, hostname`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checks := make([]models.EndpointCheck, 0)
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var check models.EndpointCheck
+		if err := json.Unmarshal([]byte(data), &check); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal endpoint check: %w", err)
+		}
+		checks = append(checks, check)
+	}
+	return checks, rows.Err()
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestEndpointChecks(t *testing.T) {
+	db := setupTestDB(t)
+
+	now := time.Now().Truncate(time.Second)
+	soon, later := now.Add(3*24*time.Hour), now.Add(60*24*time.Hour)
+	days := 3
+	checks := []models.EndpointCheck{
+		{Hostname: "wiki.example.com", Status: models.EndpointStatusOK, CertNotAfter: &later, CheckedAt: now},
+		{Hostname: "nas.lan", Status: models.EndpointStatusDNSError, DNSError: "no such host", CheckedAt: now},
+		{Hostname: "jellyfin.example.com", Status: models.EndpointStatusExpiring, CertNotAfter: &soon, DaysRemaining: &days,
+			CertWarningLevel: 2, CertWarningNotAfter: &soon, CheckedAt: now},
+	}
+	if err := db.ReplaceEndpointChecks(checks); err != nil {
+		t.Fatalf("ReplaceEndpointChecks failed: %v", err)
+	}
+
+	saved, err := db.GetEndpointChecks()
+	if err != nil {
+		t.Fatalf("GetEndpointChecks failed: %v", err)
+	}
+	var hostnames []string
+	for _, c := range saved {
+		hostnames = append(hostnames, c.Hostname)
+	}
+	if len(saved) != 3 || hostnames[0] != "jellyfin.example.com" || hostnames[1] != "wiki.example.com" || hostnames[2] != "nas.lan" {
+		t.Fatalf("Expected soonest expiry first, got %v", hostnames)
+	}
+	if saved[0].CertWarningLevel != 2 || saved[0].CertWarningNotAfter == nil || !saved[0].CertWarningNotAfter.Equal(soon) {
+		t.Errorf("Expected the warning state to round-trip, got %+v", saved[0])
+	}
+
+	if err := db.ReplaceEndpointChecks(checks[:1]); err != nil {
+		t.Fatalf("ReplaceEndpointChecks failed: %v", err)
+	}
+	if saved, _ = db.GetEndpointChecks(); len(saved) != 1 {
+		t.Errorf("Expected hostnames no longer routed to be dropped, got %+v", saved)
+	}
+}
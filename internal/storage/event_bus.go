@@ -0,0 +1,213 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// InsertBusEvents appends events to the event bus log and sets their IDs
+func (db *DB) InsertBusEvents(events []models.BusEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO bus_events (type, timestamp, host_id, host_name, container_name, data)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for i := range events {
+		data, err := json.Marshal(events[i].Data)
+		if err != nil {
+			return fmt.Errorf("failed to marshal event data: %w", err)
+		}
+		result, err := stmt.Exec(events[i].Type, events[i].Timestamp, events[i].HostID, events[i].HostName, events[i].ContainerName, string(data))
+		if err != nil {
+			return err
+		}
+		events[i].ID, _ = result.LastInsertId()
+	}
+	return tx.Commit()
+}
+
+// GetBusEvents returns up to limit events after the event ID afterID, oldest first. Empty types
+// and a nil hostIDs match every event.
+func (db *DB) GetBusEvents(afterID int64, types []string, hostIDs []int64, limit int) ([]models.BusEvent, error) {
+	query := `SELECT id, type, timestamp, host_id, host_name, container_name, data FROM bus_events WHERE id > ?`
+	args := []interface{}{afterID}
+	if len(types) > 0 {
+		query += ` AND type IN (?` + strings.Repeat(`, ?`, len(types)-1) + `)`
+		for _, t := range types {
+			args = append(args, t)
+		}
+	}
+	if hostIDs != nil {
+		if len(hostIDs) == 0 {
+			return []models.BusEvent{}, nil
+		}
+		query += ` AND host_id IN (?` + strings.Repeat(`, ?`, len(hostIDs)-1) + `)`
+		for _, id := range hostIDs {
+			args = append(args, id)
+		}
+	}
+	query += ` ORDER BY id LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]models.BusEvent, 0)
+	for rows.Next() {
+		var e models.BusEvent
+		var data string
+		if err := rows.Scan(&e.ID, &e.Type, &e.Timestamp, &e.HostID, &e.HostName, &e.ContainerName, &data); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(data), &e.Data); err != nil {
+			return nil, fmt.Errorf("failed to parse event data: %w", err)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// GetLastBusEventID returns the ID of the newest event, 0 when there are none
+func (db *DB) GetLastBusEventID() (int64, error) {
+	var id int64
+	err := db.conn.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM bus_events`).Scan(&id)
+	return id, err
+}
+
+// DeleteBusEventsBefore removes events older than before and returns how many were removed.
+// Event IDs are never reused.
+func (db *DB) DeleteBusEventsBefore(before time.Time) (int64, error) {
+	result, err := db.conn.Exec(`DELETE FROM bus_events WHERE timestamp < ?`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// GetEventSubscriptions returns the event subscriptions by name
+func (db *DB) GetEventSubscriptions() ([]models.EventSubscription, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, name, url, event_types, secret, enabled, cursor, last_delivery_at, last_error, created_at
+		FROM event_subscriptions
+		ORDER BY name, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := make([]models.EventSubscription, 0)
+	for rows.Next() {
+		sub, err := scanEventSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, *sub)
+	}
+	return subs, rows.Err()
+}
+
+// GetEventSubscription returns one event subscription, or nil when it doesn't exist
+func (db *DB) GetEventSubscription(id int64) (*models.EventSubscription, error) {
+	sub, err := scanEventSubscription(db.conn.QueryRow(`
+		SELECT id, name, url, event_types, secret, enabled, cursor, last_delivery_at, last_error, created_at
+		FROM event_subscriptions WHERE id = ?
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return sub, err
+}
+
+func scanEventSubscription(row interface{ Scan(...interface{}) error }) (*models.EventSubscription, error) {
+	var sub models.EventSubscription
+	var eventTypes string
+	var lastDelivery sql.NullTime
+	if err := row.Scan(&sub.ID, &sub.Name, &sub.URL, &eventTypes, &sub.Secret, &sub.Enabled, &sub.Cursor,
+		&lastDelivery, &sub.LastError, &sub.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(eventTypes), &sub.EventTypes); err != nil {
+		return nil, fmt.Errorf("failed to parse event types: %w", err)
+	}
+	if lastDelivery.Valid {
+		sub.LastDeliveryAt = &lastDelivery.Time
+	}
+	return &sub, nil
+}
+
+// SaveEventSubscription creates a subscription (ID 0) or updates its name, URL, event types,
+// secret and enabled flag. The cursor of an existing subscription is only moved by delivery and
+// SetEventSubscriptionCursor.
+func (db *DB) SaveEventSubscription(sub *models.EventSubscription) error {
+	if sub.EventTypes == nil {
+		sub.EventTypes = []string{}
+	}
+	eventTypes, err := json.Marshal(sub.EventTypes)
+	if err != nil {
+		return err
+	}
+
+	if sub.ID == 0 {
+		result, err := db.conn.Exec(`
+			INSERT INTO event_subscriptions (name, url, event_types, secret, enabled, cursor, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, sub.Name, sub.URL, string(eventTypes), sub.Secret, sub.Enabled, sub.Cursor, sub.CreatedAt)
+		if err != nil {
+			return err
+		}
+		sub.ID, _ = result.LastInsertId()
+		return nil
+	}
+
+	_, err = db.conn.Exec(`
+		UPDATE event_subscriptions SET name = ?, url = ?, event_types = ?, secret = ?, enabled = ?
+		WHERE id = ?
+	`, sub.Name, sub.URL, string(eventTypes), sub.Secret, sub.Enabled, sub.ID)
+	return err
+}
+
+// DeleteEventSubscription removes a subscription
+func (db *DB) DeleteEventSubscription(id int64) error {
+	_, err := db.conn.Exec(`DELETE FROM event_subscriptions WHERE id = ?`, id)
+	return err
+}
+
+// SetEventSubscriptionCursor moves a subscription's cursor, e.g. back to replay events
+func (db *DB) SetEventSubscriptionCursor(id, cursor int64) error {
+	_, err := db.conn.Exec(`UPDATE event_subscriptions SET cursor = ? WHERE id = ?`, cursor, id)
+	return err
+}
+
+// RecordEventDelivery records a delivery attempt that started at cursor from: the cursor after
+// the events delivered and the error that stopped delivery, if any. The cursor is only moved if
+// it is still at from, so a replay requested during the delivery isn't undone.
+func (db *DB) RecordEventDelivery(id, from, cursor int64, at time.Time, deliveryErr string) error {
+	_, err := db.conn.Exec(`
+		UPDATE event_subscriptions
+		SET cursor = CASE WHEN cursor = ? THEN ? ELSE cursor END, last_delivery_at = ?, last_error = ?
+		WHERE id = ?
+	`, from, cursor, at, deliveryErr, id)
+	return err
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestBusEvents(t *testing.T) {
+	db := setupTestDB(t)
+
+	old := time.Now().Add(-10 * 24 * time.Hour)
+	events := []models.BusEvent{
+		{Type: models.BusEventScanCompleted, Timestamp: old, HostID: 1, HostName: "nas", Data: map[string]interface{}{"containers_found": 3}},
+		{Type: models.BusEventContainerStopped, Timestamp: time.Now(), HostID: 1, HostName: "nas", ContainerName: "web"},
+		{Type: models.BusEventContainerStopped, Timestamp: time.Now(), HostID: 2, HostName: "vps", ContainerName: "db"},
+	}
+	if err := db.InsertBusEvents(events); err != nil {
+		t.Fatalf("InsertBusEvents failed: %v", err)
+	}
+	if events[0].ID == 0 || events[2].ID <= events[1].ID {
+		t.Fatalf("Expected increasing IDs, got %+v", events)
+	}
+
+	got, err := db.GetBusEvents(0, nil, nil, 10)
+	if err != nil || len(got) != 3 || got[0].Data["containers_found"] != float64(3) {
+		t.Fatalf("Expected all events, got %+v (%v)", got, err)
+	}
+	if got, _ := db.GetBusEvents(events[0].ID, []string{models.BusEventContainerStopped}, []int64{2}, 10); len(got) != 1 || got[0].ContainerName != "db" {
+		t.Errorf("Expected the filtered event, got %+v", got)
+	}
+	if got, _ := db.GetBusEvents(0, nil, []int64{}, 10); len(got) != 0 {
+		t.Errorf("Expected no events for no hosts, got %+v", got)
+	}
+
+	if n, err := db.DeleteBusEventsBefore(time.Now().Add(-7 * 24 * time.Hour)); err != nil || n != 1 {
+		t.Errorf("Expected one old event removed, got %d (%v)", n, err)
+	}
+	if last, _ := db.GetLastBusEventID(); last != events[2].ID {
+		t.Errorf("Expected the last event ID %d, got %d", events[2].ID, last)
+	}
+}
+
+func TestEventSubscriptions(t *testing.T) {
+	db := setupTestDB(t)
+
+	sub := &models.EventSubscription{Name: "n8n", URL: "https://n8n.lan/webhook/census", EventTypes: []string{models.BusEventScanFailed},
+		Enabled: true, Cursor: 5, CreatedAt: time.Now()}
+	if err := db.SaveEventSubscription(sub); err != nil || sub.ID == 0 {
+		t.Fatalf("SaveEventSubscription failed: %v", err)
+	}
+
+	// Delivery only moves a cursor that wasn't moved meanwhile
+	if err := db.RecordEventDelivery(sub.ID, 5, 9, time.Now(), ""); err != nil {
+		t.Fatalf("RecordEventDelivery failed: %v", err)
+	}
+	db.SetEventSubscriptionCursor(sub.ID, 2)
+	db.RecordEventDelivery(sub.ID, 9, 12, time.Now(), "timeout")
+	saved, err := db.GetEventSubscription(sub.ID)
+	if err != nil || saved.Cursor != 2 || saved.LastError != "timeout" || saved.LastDeliveryAt == nil {
+		t.Fatalf("Expected the replayed cursor to be kept, got %+v (%v)", saved, err)
+	}
+
+	// Updates don't touch the cursor
+	saved.Name, saved.Cursor, saved.EventTypes = "automation", 100, nil
+	db.SaveEventSubscription(saved)
+	if subs, _ := db.GetEventSubscriptions(); len(subs) != 1 || subs[0].Name != "automation" || subs[0].Cursor != 2 || len(subs[0].EventTypes) != 0 {
+		t.Errorf("Unexpected subscriptions %+v", subs)
+	}
+
+	db.DeleteEventSubscription(sub.ID)
+	if saved, _ := db.GetEventSubscription(sub.ID); saved != nil {
+		t.Errorf("Expected the subscription to be deleted, got %+v", saved)
+	}
+}
@@ -0,0 +1,242 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// RecordHostHeartbeat saves the outcome of a scan and opens or closes the host's downtime. A
+// host goes down after downAfter failed scans in a row, starting at the first of them, and
+// recovers after upAfter successful scans in a row, so a flapping host doesn't open a downtime
+// for every failed scan. It returns the change, or nil when the host's status didn't change.
+func (db *DB) RecordHostHeartbeat(hb models.HostHeartbeat, hostName string, downAfter, upAfter int) (*models.HostStatusChange, error) {
+	if downAfter < 1 {
+		downAfter = models.DefaultHostDownAfterFailures
+	}
+	if upAfter < 1 {
+		upAfter = models.DefaultHostRecoveredAfterSuccesses
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT INTO host_heartbeats (host_id, timestamp, online, error) VALUES (?, ?, ?, ?)`,
+		hb.HostID, hb.Timestamp, hb.Online, hb.Error); err != nil {
+		return nil, err
+	}
+
+	open, err := openHostDowntime(tx, hb.HostID)
+	if err != nil {
+		return nil, err
+	}
+
+	var change *models.HostStatusChange
+	switch {
+	case open == nil && !hb.Online:
+		streak, err := heartbeatStreak(tx, hb.HostID, false, downAfter)
+		if err != nil {
+			return nil, err
+		}
+		if streak == nil {
+			break
+		}
+		downtime := models.HostDowntime{HostID: hb.HostID, HostName: hostName, StartedAt: *streak, Error: hb.Error}
+		res, err := tx.Exec(`INSERT INTO host_downtimes (host_id, host_name, started_at, error) VALUES (?, ?, ?, ?)`,
+			downtime.HostID, downtime.HostName, downtime.StartedAt, downtime.Error)
+		if err != nil {
+			return nil, err
+		}
+		if downtime.ID, err = res.LastInsertId(); err != nil {
+			return nil, err
+		}
+		change = &models.HostStatusChange{EventType: models.EventTypeHostDown, Downtime: downtime}
+	case open != nil && hb.Online:
+		streak, err := heartbeatStreak(tx, hb.HostID, true, upAfter)
+		if err != nil {
+			return nil, err
+		}
+		if streak == nil {
+			break
+		}
+		if _, err := tx.Exec(`UPDATE host_downtimes SET ended_at = ? WHERE id = ?`, *streak, open.ID); err != nil {
+			return nil, err
+		}
+		open.EndedAt = streak
+		change = &models.HostStatusChange{EventType: models.EventTypeHostRecovered, Downtime: *open}
+	}
+
+	return change, tx.Commit()
+}
+
+// heartbeatStreak returns the time of the first of the host's last n heartbeats when all of them
+// have the given status, or nil
+func heartbeatStreak(tx *sql.Tx, hostID int64, online bool, n int) (*time.Time, error) {
+	rows, err := tx.Query(`SELECT timestamp, online FROM host_heartbeats WHERE host_id = ? ORDER BY timestamp DESC, id DESC LIMIT ?`, hostID, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var first time.Time
+	count := 0
+	for rows.Next() {
+		var ts time.Time
+		var status bool
+		if err := rows.Scan(&ts, &status); err != nil {
+			return nil, err
+		}
+		if status != online {
+			return nil, rows.Err()
+		}
+		first = ts
+		count++
+	}
+	if err := rows.Err(); err != nil || count < n {
+		return nil, err
+	}
+	return &first, nil
+}
+
+// openHostDowntime returns the ongoing downtime of a host, or nil
+func openHostDowntime(q interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}, hostID int64) (*models.HostDowntime, error) {
+	var d models.HostDowntime
+	var errMsg sql.NullString
+	err := q.QueryRow(`
+		SELECT id, host_id, host_name, started_at, error FROM host_downtimes
+		WHERE host_id = ? AND ended_at IS NULL
+		ORDER BY started_at DESC LIMIT 1
+	`, hostID).Scan(&d.ID, &d.HostID, &d.HostName, &d.StartedAt, &errMsg)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	d.Error = errMsg.String
+	return &d, nil
+}
+
+// GetHostHeartbeats returns the heartbeats of a host since a time, oldest first
+func (db *DB) GetHostHeartbeats(hostID int64, since time.Time) ([]models.HostHeartbeat, error) {
+	rows, err := db.conn.Query(`
+		SELECT host_id, timestamp, online, error FROM host_heartbeats
+		WHERE host_id = ? AND timestamp >= ?
+		ORDER BY timestamp, id
+	`, hostID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	heartbeats := []models.HostHeartbeat{}
+	for rows.Next() {
+		var hb models.HostHeartbeat
+		var errMsg sql.NullString
+		if err := rows.Scan(&hb.HostID, &hb.Timestamp, &hb.Online, &errMsg); err != nil {
+			return nil, err
+		}
+		hb.Error = errMsg.String
+		heartbeats = append(heartbeats, hb)
+	}
+	return heartbeats, rows.Err()
+}
+
+// GetHostDowntimes returns the downtimes of a host that were ongoing at or after since, oldest
+// first. A hostID of 0 returns those of all hosts.
+func (db *DB) GetHostDowntimes(hostID int64, since time.Time) ([]models.HostDowntime, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, host_id, host_name, started_at, ended_at, error FROM host_downtimes
+		WHERE (? = 0 OR host_id = ?) AND (ended_at IS NULL OR ended_at >= ?)
+		ORDER BY started_at, id
+	`, hostID, hostID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	downtimes := []models.HostDowntime{}
+	for rows.Next() {
+		var d models.HostDowntime
+		var endedAt sql.NullTime
+		var errMsg sql.NullString
+		if err := rows.Scan(&d.ID, &d.HostID, &d.HostName, &d.StartedAt, &endedAt, &errMsg); err != nil {
+			return nil, err
+		}
+		if endedAt.Valid {
+			d.EndedAt = &endedAt.Time
+		}
+		d.Error = errMsg.String
+		downtimes = append(downtimes, d)
+	}
+	return downtimes, rows.Err()
+}
+
+// GetHostUptime returns the heartbeats and downtimes of a host between since and now, and the
+// share of that window the host was up
+func (db *DB) GetHostUptime(host models.Host, since time.Time) (*models.HostUptime, error) {
+	now := time.Now()
+	heartbeats, err := db.GetHostHeartbeats(host.ID, since)
+	if err != nil {
+		return nil, err
+	}
+	downtimes, err := db.GetHostDowntimes(host.ID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	uptime := &models.HostUptime{
+		HostID:        host.ID,
+		HostName:      host.Name,
+		Since:         since,
+		Until:         now,
+		UptimePercent: uptimePercent(downtimes, since, now),
+		Heartbeats:    heartbeats,
+		Downtimes:     downtimes,
+	}
+	for _, d := range downtimes {
+		if d.EndedAt == nil {
+			uptime.Down = true
+		}
+	}
+	return uptime, nil
+}
+
+// uptimePercent returns the share of the window between since and until not covered by downtimes
+func uptimePercent(downtimes []models.HostDowntime, since, until time.Time) float64 {
+	window := until.Sub(since)
+	if window <= 0 {
+		return 100
+	}
+	var down time.Duration
+	for _, d := range downtimes {
+		start, end := d.StartedAt, until
+		if d.EndedAt != nil && d.EndedAt.Before(until) {
+			end = *d.EndedAt
+		}
+		if start.Before(since) {
+			start = since
+		}
+		if end.After(start) {
+			down += end.Sub(start)
+		}
+	}
+	return 100 * float64(window-down) / float64(window)
+}
+
+// CleanupOldHostHeartbeats deletes heartbeats older than the given number of days. Downtimes are
+// kept, they are few and make up the long-term history.
+func (db *DB) CleanupOldHostHeartbeats(days int) (int64, error) {
+	cutoff := time.Now().AddDate(0, 0, -days)
+	result, err := db.conn.Exec(`DELETE FROM host_heartbeats WHERE timestamp < ?`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestRecordHostHeartbeat(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: "agent://nas:9876", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	start := time.Now().Add(-time.Hour)
+	beat := func(minute int, online bool) *models.HostStatusChange {
+		t.Helper()
+		hb := models.HostHeartbeat{HostID: hostID, Timestamp: start.Add(time.Duration(minute) * time.Minute), Online: online}
+		if !online {
+			hb.Error = "connection refused"
+		}
+		change, err := db.RecordHostHeartbeat(hb, "nas", 2, 2)
+		if err != nil {
+			t.Fatalf("RecordHostHeartbeat failed: %v", err)
+		}
+		return change
+	}
+
+	if change := beat(0, true); change != nil {
+		t.Errorf("Expected no change for an online host, got %+v", change)
+	}
+	// A single failed scan is a blip
+	if change := beat(5, false); change != nil {
+		t.Errorf("Expected no change after one failed scan, got %+v", change)
+	}
+	if change := beat(10, true); change != nil {
+		t.Errorf("Expected no change after a blip, got %+v", change)
+	}
+
+	beat(15, false)
+	change := beat(20, false)
+	if change == nil || change.EventType != models.EventTypeHostDown {
+		t.Fatalf("Expected host_down after two failed scans, got %+v", change)
+	}
+	if !change.Downtime.StartedAt.Equal(start.Add(15*time.Minute)) || change.Downtime.Error != "connection refused" {
+		t.Errorf("Expected the downtime to start at the first failed scan, got %+v", change.Downtime)
+	}
+	if change := beat(25, false); change != nil {
+		t.Errorf("Expected no second host_down, got %+v", change)
+	}
+
+	if change := beat(30, true); change != nil {
+		t.Errorf("Expected no recovery after one successful scan, got %+v", change)
+	}
+	change = beat(35, true)
+	if change == nil || change.EventType != models.EventTypeHostRecovered || change.Downtime.EndedAt == nil {
+		t.Fatalf("Expected host_recovered after two successful scans, got %+v", change)
+	}
+	if got := change.Downtime.Duration(time.Now()); got != 15*time.Minute {
+		t.Errorf("Expected 15 minutes of downtime, got %v", got)
+	}
+
+	host, err := db.GetHost(hostID)
+	if err != nil {
+		t.Fatalf("Failed to get host: %v", err)
+	}
+	uptime, err := db.GetHostUptime(*host, start)
+	if err != nil {
+		t.Fatalf("GetHostUptime failed: %v", err)
+	}
+	if len(uptime.Heartbeats) != 8 || len(uptime.Downtimes) != 1 || uptime.Down {
+		t.Errorf("Expected 8 heartbeats and one closed downtime, got %d, %d (down %v)", len(uptime.Heartbeats), len(uptime.Downtimes), uptime.Down)
+	}
+	if uptime.UptimePercent < 74 || uptime.UptimePercent > 76 {
+		t.Errorf("Expected about 75%% uptime over the hour, got %.2f", uptime.UptimePercent)
+	}
+}
+
+func TestUptimePercent(t *testing.T) {
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := since.Add(10 * time.Hour)
+	ended := since.Add(time.Hour)
+
+	downtimes := []models.HostDowntime{
+		// Started before the window, one hour of it counts
+		{StartedAt: since.Add(-time.Hour), EndedAt: &ended},
+		// Still ongoing, one hour until the end of the window
+		{StartedAt: until.Add(-time.Hour)},
+	}
+	if got := uptimePercent(downtimes, since, until); got != 80 {
+		t.Errorf("Expected 80%% uptime, got %.2f", got)
+	}
+	if got := uptimePercent(nil, since, until); got != 100 {
+		t.Errorf("Expected 100%% uptime without downtimes, got %.2f", got)
+	}
+}
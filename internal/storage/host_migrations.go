@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// SaveHostMigration creates a host migration (ID 0) or stores its current state
+func (db *DB) SaveHostMigration(m *models.HostMigration) error {
+	now := time.Now()
+	m.UpdatedAt = now
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	if m.ID == 0 {
+		res, err := db.conn.Exec(`INSERT INTO host_migrations (source_host_id, dest_host_id, status, migration, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			m.SourceHostID, m.DestHostID, m.Status, string(data), m.CreatedAt, now)
+		if err != nil {
+			return err
+		}
+		m.ID, err = res.LastInsertId()
+		return err
+	}
+
+	res, err := db.conn.Exec(`UPDATE host_migrations SET status = ?, migration = ?, updated_at = ? WHERE id = ?`,
+		m.Status, string(data), now, m.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetHostMigration returns one host migration; sql.ErrNoRows when it doesn't exist
+func (db *DB) GetHostMigration(id int64) (*models.HostMigration, error) {
+	return scanHostMigration(db.conn.QueryRow(`SELECT id, migration FROM host_migrations WHERE id = ?`, id))
+}
+
+// GetHostMigrations returns the latest host migrations, newest first
+func (db *DB) GetHostMigrations(limit int) ([]models.HostMigration, error) {
+	return db.queryHostMigrations(`SELECT id, migration FROM host_migrations ORDER BY created_at DESC, id DESC LIMIT ?`, limit)
+}
+
+// DeleteHostMigration removes a host migration; sql.ErrNoRows when it doesn't exist
+func (db *DB) DeleteHostMigration(id int64) error {
+	res, err := db.conn.Exec(`DELETE FROM host_migrations WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SyncHostMigrations marks the containers of open migrations migrated once the latest scans find
+// them running on the destination and no longer running on the source. Returns how many were.
+func (db *DB) SyncHostMigrations() (int, error) {
+	open, err := db.queryHostMigrations(`SELECT id, migration FROM host_migrations WHERE status IN (?, ?)`,
+		models.HostMigrationPlanned, models.HostMigrationInProgress)
+	if err != nil {
+		return 0, err
+	}
+
+	states := map[int64]map[string]string{}
+	hostStates := func(hostID int64) (map[string]string, error) {
+		if s, ok := states[hostID]; ok {
+			return s, nil
+		}
+		containers, err := db.GetContainersByHost(hostID)
+		if err != nil {
+			return nil, err
+		}
+		s := make(map[string]string, len(containers))
+		for _, c := range containers {
+			s[c.Name] = c.State
+		}
+		states[hostID] = s
+		return s, nil
+	}
+
+	migrated := 0
+	now := time.Now()
+	for i := range open {
+		m := &open[i]
+		source, err := hostStates(m.SourceHostID)
+		if err != nil {
+			return migrated, err
+		}
+		dest, err := hostStates(m.DestHostID)
+		if err != nil {
+			return migrated, err
+		}
+
+		changed := false
+		for j := range m.Items {
+			item := &m.Items[j]
+			if item.Finished() || dest[item.ContainerName] != "running" || source[item.ContainerName] == "running" {
+				continue
+			}
+			item.Status = models.MigrationItemMigrated
+			item.UpdatedAt = &now
+			changed = true
+			migrated++
+		}
+		if changed {
+			m.UpdateStatus()
+			if err := db.SaveHostMigration(m); err != nil {
+				return migrated, err
+			}
+		}
+	}
+	return migrated, nil
+}
+
+func (db *DB) queryHostMigrations(query string, args ...interface{}) ([]models.HostMigration, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var migrations []models.HostMigration
+	for rows.Next() {
+		m, err := scanHostMigration(rows)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, *m)
+	}
+	return migrations, rows.Err()
+}
+
+func scanHostMigration(row interface{ Scan(...interface{}) error }) (*models.HostMigration, error) {
+	var id int64
+	var data string
+	if err := row.Scan(&id, &data); err != nil {
+		return nil, err
+	}
+
+	var m models.HostMigration
+	if err := json.Unmarshal([]byte(data), &m); err != nil {
+		return nil, fmt.Errorf("failed to decode host migration %d: %w", id, err)
+	}
+	m.ID = id
+	return &m, nil
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestHostMigrations(t *testing.T) {
+	db := setupTestDB(t)
+
+	oldID, err := db.AddHost(models.Host{Name: "old-nas", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	newID, err := db.AddHost(models.Host{Name: "new-nas", Address: "tcp://new-nas:2376", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	migration := &models.HostMigration{
+		SourceHostID: oldID, SourceHostName: "old-nas",
+		DestHostID: newID, DestHostName: "new-nas",
+		Status:    models.HostMigrationPlanned,
+		CreatedAt: time.Now(),
+		Items: []models.MigrationItem{
+			{ContainerName: "web", Image: "nginx:latest", Status: models.MigrationItemPending},
+			{ContainerName: "db", Image: "postgres:16", Status: models.MigrationItemPending},
+			{ContainerName: "legacy", Image: "busybox", Status: models.MigrationItemSkipped},
+		},
+	}
+	if err := db.SaveHostMigration(migration); err != nil {
+		t.Fatalf("SaveHostMigration failed: %v", err)
+	}
+	if migration.ID == 0 {
+		t.Fatal("Expected the migration to get an ID")
+	}
+
+	// web runs on the new host and is stopped on the old one; db is still on the old one only
+	now := time.Now()
+	if err := db.SaveContainers([]models.Container{
+		{ID: "w1", Name: "web", Image: "nginx:latest", State: "exited", HostID: oldID, HostName: "old-nas", ScannedAt: now},
+		{ID: "d1", Name: "db", Image: "postgres:16", State: "running", HostID: oldID, HostName: "old-nas", ScannedAt: now},
+	}); err != nil {
+		t.Fatalf("Failed to save containers: %v", err)
+	}
+	if err := db.SaveContainers([]models.Container{
+		{ID: "w2", Name: "web", Image: "nginx:latest", State: "running", HostID: newID, HostName: "new-nas", ScannedAt: now},
+		{ID: "d2", Name: "db", Image: "postgres:16", State: "running", HostID: newID, HostName: "new-nas", ScannedAt: now},
+	}); err != nil {
+		t.Fatalf("Failed to save containers: %v", err)
+	}
+
+	n, err := db.SyncHostMigrations()
+	if err != nil || n != 1 {
+		t.Fatalf("SyncHostMigrations = %d, %v; want 1 container", n, err)
+	}
+	got, err := db.GetHostMigration(migration.ID)
+	if err != nil {
+		t.Fatalf("GetHostMigration failed: %v", err)
+	}
+	if got.Items[0].Status != models.MigrationItemMigrated || got.Items[0].UpdatedAt == nil {
+		t.Errorf("Expected web to be migrated, got %+v", got.Items[0])
+	}
+	if got.Items[1].Status != models.MigrationItemPending {
+		t.Errorf("Expected db, still running on the source, to stay pending, got %s", got.Items[1].Status)
+	}
+	if got.Status != models.HostMigrationInProgress {
+		t.Errorf("Expected the migration in progress, got %s", got.Status)
+	}
+
+	// A completed migration is left alone
+	got.Items[1].Status = models.MigrationItemMigrated
+	got.UpdateStatus()
+	if err := db.SaveHostMigration(got); err != nil {
+		t.Fatalf("SaveHostMigration failed: %v", err)
+	}
+	if got.Status != models.HostMigrationCompleted {
+		t.Errorf("Expected the migration completed, got %s", got.Status)
+	}
+	if n, err := db.SyncHostMigrations(); err != nil || n != 0 {
+		t.Errorf("SyncHostMigrations = %d, %v; want nothing to do", n, err)
+	}
+
+	migrations, err := db.GetHostMigrations(10)
+	if err != nil || len(migrations) != 1 || migrations[0].ID != migration.ID {
+		t.Fatalf("GetHostMigrations = %+v, %v", migrations, err)
+	}
+	if err := db.DeleteHostMigration(migration.ID); err != nil {
+		t.Fatalf("DeleteHostMigration failed: %v", err)
+	}
+	if _, err := db.GetHostMigration(migration.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows for a deleted migration, got %v", err)
+	}
+	if err := db.DeleteHostMigration(migration.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows deleting it twice, got %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// GetHostTemplates returns all host templates, ordered by name
+func (db *DB) GetHostTemplates() ([]models.HostTemplate, error) {
+	rows, err := db.conn.Query(`SELECT id, name, template, created_at, updated_at FROM host_templates ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []models.HostTemplate
+	for rows.Next() {
+		t, err := scanHostTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *t)
+	}
+	return templates, rows.Err()
+}
+
+// GetHostTemplate returns one host template; sql.ErrNoRows when it doesn't exist
+func (db *DB) GetHostTemplate(id int64) (*models.HostTemplate, error) {
+	return scanHostTemplate(db.conn.QueryRow(`SELECT id, name, template, created_at, updated_at FROM host_templates WHERE id = ?`, id))
+}
+
+func scanHostTemplate(row interface{ Scan(...interface{}) error }) (*models.HostTemplate, error) {
+	var id int64
+	var name, data string
+	var createdAt, updatedAt time.Time
+	if err := row.Scan(&id, &name, &data, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+
+	var t models.HostTemplate
+	if err := json.Unmarshal([]byte(data), &t); err != nil {
+		return nil, fmt.Errorf("failed to decode host template %s: %w", name, err)
+	}
+	t.ID, t.Name, t.CreatedAt, t.UpdatedAt = id, name, createdAt, updatedAt
+	if t.NotificationRules == nil {
+		t.NotificationRules = []models.NotificationRule{}
+	}
+	return &t, nil
+}
+
+// SaveHostTemplate creates a host template (ID 0) or replaces an existing one
+func (db *DB) SaveHostTemplate(t *models.HostTemplate) error {
+	if t.NotificationRules == nil {
+		t.NotificationRules = []models.NotificationRule{}
+	}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if t.ID == 0 {
+		res, err := db.conn.Exec(`INSERT INTO host_templates (name, template, created_at, updated_at) VALUES (?, ?, ?, ?)`,
+			t.Name, string(data), now, now)
+		if err != nil {
+			return err
+		}
+		t.ID, err = res.LastInsertId()
+		t.CreatedAt, t.UpdatedAt = now, now
+		return err
+	}
+
+	res, err := db.conn.Exec(`UPDATE host_templates SET name = ?, template = ?, updated_at = ? WHERE id = ?`,
+		t.Name, string(data), now, t.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	t.UpdatedAt = now
+	return nil
+}
+
+// DeleteHostTemplate deletes a host template; hosts created from it keep their settings and rules
+func (db *DB) DeleteHostTemplate(id int64) error {
+	res, err := db.conn.Exec(`DELETE FROM host_templates WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// HostTemplateFromHost takes an unsaved template from a host: its settings and the notification
+// rules scoped to it
+func (db *DB) HostTemplateFromHost(hostID int64) (*models.HostTemplate, error) {
+	host, err := db.GetHost(hostID)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := db.GetNotificationRules(false)
+	if err != nil {
+		return nil, err
+	}
+	t := &models.HostTemplate{
+		Description:       host.Description,
+		CollectStats:      host.CollectStats,
+		RegistryMirror:    host.RegistryMirror,
+		Site:              host.Site,
+		NotificationRules: []models.NotificationRule{},
+		SourceHost:        host.Name,
+	}
+	for _, rule := range rules {
+		if rule.HostID != nil && *rule.HostID == hostID {
+			rule.ID, rule.HostID = 0, nil
+			t.NotificationRules = append(t.NotificationRules, rule)
+		}
+	}
+	return t, nil
+}
+
+// CreateHostTemplateRules gives a host its copies of a template's notification rules. Channels
+// deleted since the template was saved are left out. Returns the number of rules created.
+func (db *DB) CreateHostTemplateRules(t *models.HostTemplate, host models.Host) (int, error) {
+	if len(t.NotificationRules) == 0 {
+		return 0, nil
+	}
+
+	channels, err := db.GetNotificationChannels()
+	if err != nil {
+		return 0, err
+	}
+	exists := make(map[int64]bool, len(channels))
+	for _, channel := range channels {
+		exists[channel.ID] = true
+	}
+
+	created := 0
+	for _, rule := range t.RulesFor(host) {
+		channelIDs := rule.ChannelIDs[:0]
+		for _, id := range rule.ChannelIDs {
+			if exists[id] {
+				channelIDs = append(channelIDs, id)
+			}
+		}
+		rule.ChannelIDs = channelIDs
+		if err := db.SaveNotificationRule(&rule); err != nil {
+			return created, fmt.Errorf("failed to create rule %s: %w", rule.Name, err)
+		}
+		created++
+	}
+	return created, nil
+}
+
+// ApplyHostTemplate applies a template to an existing host (see HostTemplate.ApplyTo) and gives
+// it the template's notification rules. Returns the number of rules created.
+func (db *DB) ApplyHostTemplate(t *models.HostTemplate, hostID int64) (int, error) {
+	host, err := db.GetHost(hostID)
+	if err != nil {
+		return 0, err
+	}
+	t.ApplyTo(host)
+	if err := db.UpdateHost(*host); err != nil {
+		return 0, err
+	}
+	return db.CreateHostTemplateRules(t, *host)
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestHostTemplates(t *testing.T) {
+	db := setupTestDB(t)
+
+	web := &models.NotificationChannel{Name: "web", Type: "webhook", Config: map[string]interface{}{"url": "http://example.com"}, Enabled: true}
+	gone := &models.NotificationChannel{Name: "gone", Type: "webhook", Config: map[string]interface{}{"url": "http://example.com"}, Enabled: true}
+	for _, ch := range []*models.NotificationChannel{web, gone} {
+		if err := db.SaveNotificationChannel(ch); err != nil {
+			t.Fatalf("Failed to save channel: %v", err)
+		}
+	}
+
+	sourceID, err := db.AddHost(models.Host{Name: "nas1", Address: "agent://nas1:9876", Enabled: true, CollectStats: true, Site: "home", RegistryMirror: "mirror.local"})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	otherID, err := db.AddHost(models.Host{Name: "other", Address: "agent://other:9876", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	rules := []models.NotificationRule{
+		{Name: "nas1 stopped", Enabled: true, EventTypes: []string{"state_change"}, HostID: &sourceID, ChannelIDs: []int64{web.ID, gone.ID}},
+		{Name: "other stopped", Enabled: true, EventTypes: []string{"state_change"}, HostID: &otherID, ChannelIDs: []int64{web.ID}},
+		{Name: "everywhere", Enabled: true, EventTypes: []string{"new_image"}, ChannelIDs: []int64{web.ID}},
+	}
+	for i := range rules {
+		if err := db.SaveNotificationRule(&rules[i]); err != nil {
+			t.Fatalf("Failed to save rule: %v", err)
+		}
+	}
+
+	// Only the rules scoped to the host are taken
+	template, err := db.HostTemplateFromHost(sourceID)
+	if err != nil {
+		t.Fatalf("HostTemplateFromHost failed: %v", err)
+	}
+	if !template.CollectStats || template.Site != "home" || template.RegistryMirror != "mirror.local" || template.SourceHost != "nas1" {
+		t.Errorf("Unexpected settings taken from the host: %+v", template)
+	}
+	if len(template.NotificationRules) != 1 || template.NotificationRules[0].Name != "nas1 stopped" {
+		t.Fatalf("Expected the rule scoped to the host, got %+v", template.NotificationRules)
+	}
+
+	template.Name = "nas"
+	if err := db.SaveHostTemplate(template); err != nil {
+		t.Fatalf("SaveHostTemplate failed: %v", err)
+	}
+	if err := db.SaveHostTemplate(&models.HostTemplate{Name: "nas"}); err == nil {
+		t.Error("Expected an error saving a second template with the same name")
+	}
+	saved, err := db.GetHostTemplate(template.ID)
+	if err != nil {
+		t.Fatalf("GetHostTemplate failed: %v", err)
+	}
+	if saved.Name != "nas" || saved.Site != "home" || len(saved.NotificationRules) != 1 {
+		t.Errorf("Template didn't round trip: %+v", saved)
+	}
+
+	// Applying it to a new host copies the rule, renamed for the host, without the deleted channel
+	if err := db.DeleteNotificationChannel(gone.ID); err != nil {
+		t.Fatalf("Failed to delete channel: %v", err)
+	}
+	newID, err := db.AddHost(models.Host{Name: "nas2", Address: "agent://nas2:9876", Enabled: true, Site: "office"})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	created, err := db.ApplyHostTemplate(saved, newID)
+	if err != nil {
+		t.Fatalf("ApplyHostTemplate failed: %v", err)
+	}
+	if created != 1 {
+		t.Errorf("Expected 1 rule created, got %d", created)
+	}
+
+	host, err := db.GetHost(newID)
+	if err != nil {
+		t.Fatalf("Failed to get host: %v", err)
+	}
+	if !host.CollectStats || host.RegistryMirror != "mirror.local" || host.Site != "office" {
+		t.Errorf("Expected stats and the mirror from the template and the host's own site, got %+v", host)
+	}
+
+	all, err := db.GetNotificationRules(false)
+	if err != nil {
+		t.Fatalf("Failed to get rules: %v", err)
+	}
+	var copied *models.NotificationRule
+	for i := range all {
+		if all[i].HostID != nil && *all[i].HostID == newID {
+			copied = &all[i]
+		}
+	}
+	if copied == nil {
+		t.Fatal("Expected a rule scoped to the new host")
+	}
+	if copied.Name != "nas2 stopped" {
+		t.Errorf("Expected the rule name to follow the host, got %q", copied.Name)
+	}
+	if len(copied.ChannelIDs) != 1 || copied.ChannelIDs[0] != web.ID {
+		t.Errorf("Expected only the remaining channel, got %v", copied.ChannelIDs)
+	}
+
+	if err := db.DeleteHostTemplate(template.ID); err != nil {
+		t.Fatalf("DeleteHostTemplate failed: %v", err)
+	}
+	if templates, _ := db.GetHostTemplates(); len(templates) != 0 {
+		t.Errorf("Expected no templates after deleting, got %d", len(templates))
+	}
+}
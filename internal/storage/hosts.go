@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"strings"
+
+	"github.com/container-census/container-census/internal/broker"
+	"github.com/container-census/container-census/internal/incus"
+	"github.com/container-census/container-census/internal/models"
+)
+
+// hostNameTables keep the host's name next to its ID in current state (history tables such
+// as scan_results and notification_log keep the name the host had at the time)
+var hostNameTables = []string{
+	"containers",
+	"container_stats_aggregates",
+	"container_configs",
+}
+
+// hostTypeForAddress derives the type of a host from its address
+func hostTypeForAddress(address string) string {
+	switch {
+	case address == "" || address == "local":
+		return models.HostTypeUnix
+	case strings.HasPrefix(address, "agent://"), strings.HasPrefix(address, "http://"), strings.HasPrefix(address, "https://"), broker.IsAddress(address):
+		return models.HostTypeAgent
+	case strings.HasPrefix(address, "unix://"):
+		return models.HostTypeUnix
+	case strings.HasPrefix(address, "tcp://"):
+		return "tcp"
+	case strings.HasPrefix(address, "ssh://"):
+		return "ssh"
+	case incus.IsAddress(address):
+		return models.HostTypeIncus
+	default:
+		return "unknown"
+	}
+}
+
+// withHostType fills in the type of a host saved without one
+func withHostType(host models.Host) models.Host {
+	if host.HostType == "" || host.HostType == "unknown" {
+		host.HostType = hostTypeForAddress(host.Address)
+	}
+	return host
+}
+
+// backfillHostTypes sets the type of hosts created without one (the local host of the first
+// run and hosts imported from config.yaml), which kept the local host from being recognized
+func (db *DB) backfillHostTypes() error {
+	rows, err := db.conn.Query(`SELECT id, address FROM hosts WHERE host_type IS NULL OR host_type IN ('', 'unknown')`)
+	if err != nil {
+		return err
+	}
+	types := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var address string
+		if err := rows.Scan(&id, &address); err != nil {
+			rows.Close()
+			return err
+		}
+		types[id] = hostTypeForAddress(address)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, hostType := range types {
+		if _, err := db.conn.Exec(`UPDATE hosts SET host_type = ? WHERE id = ?`, hostType, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenameHost renames a host, along with the host name stored with its containers and stats
+func (db *DB) RenameHost(id int64, name string) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE hosts SET name = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, name, id); err != nil {
+		return err
+	}
+	for _, table := range hostNameTables {
+		if _, err := tx.Exec(`UPDATE `+table+` SET host_name = ? WHERE host_id = ?`, name, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetHostByName returns the host with the given name, or nil
+func (db *DB) GetHostByName(name string) (*models.Host, error) {
+	hosts, err := db.GetHosts()
+	if err != nil {
+		return nil, err
+	}
+	for i := range hosts {
+		if hosts[i].Name == name {
+			return &hosts[i], nil
+		}
+	}
+	return nil, nil
+}
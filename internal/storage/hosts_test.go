@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestHostTypeBackfill(t *testing.T) {
+	db := setupTestDB(t)
+
+	// Hosts saved without a type get it from their address
+	id, err := db.AddHost(models.Host{Name: models.LocalHostName, Address: models.DefaultLocalAddress, Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	host, err := db.GetHost(id)
+	if err != nil {
+		t.Fatalf("Failed to get host: %v", err)
+	}
+	if host.HostType != models.HostTypeUnix {
+		t.Errorf("Expected host type unix, got %q", host.HostType)
+	}
+
+	// Hosts created by earlier versions without a type are fixed by the migration
+	if _, err := db.conn.Exec(`INSERT INTO hosts (name, address, description, host_type, enabled) VALUES ('remote', 'tcp://10.0.0.2:2376', '', '', 1)`); err != nil {
+		t.Fatalf("Failed to insert host: %v", err)
+	}
+	if err := db.backfillHostTypes(); err != nil {
+		t.Fatalf("backfillHostTypes failed: %v", err)
+	}
+	remote, err := db.GetHostByName("remote")
+	if err != nil || remote == nil {
+		t.Fatalf("Failed to get host: %v", err)
+	}
+	if remote.HostType != "tcp" {
+		t.Errorf("Expected host type tcp, got %q", remote.HostType)
+	}
+}
+
+func TestRenameHost(t *testing.T) {
+	db := setupTestDB(t)
+
+	id, err := db.AddHost(models.Host{Name: models.LocalHostName, Address: models.DefaultLocalAddress, Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	if _, err := db.AddHost(models.Host{Name: "nas", Address: "agent://nas:9876", Enabled: true}); err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	container := models.Container{ID: "abc123", Name: "web", Image: "nginx:latest", State: "running", HostID: id, HostName: models.LocalHostName, ScannedAt: time.Now()}
+	if err := db.SaveContainers([]models.Container{container}); err != nil {
+		t.Fatalf("Failed to save container: %v", err)
+	}
+
+	if err := db.RenameHost(id, "nas"); err == nil {
+		t.Error("Expected an error renaming to a taken name")
+	}
+	if err := db.RenameHost(id, "homelab"); err != nil {
+		t.Fatalf("RenameHost failed: %v", err)
+	}
+
+	host, err := db.GetHost(id)
+	if err != nil || host.Name != "homelab" {
+		t.Fatalf("Expected the host to be renamed, got %+v (%v)", host, err)
+	}
+	containers, err := db.GetContainersByHost(id)
+	if err != nil || len(containers) != 1 || containers[0].HostName != "homelab" {
+		t.Errorf("Expected the container to follow the host, got %+v (%v)", containers, err)
+	}
+}
@@ -0,0 +1,220 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// DefaultIdleContainerCriteria returns the criteria used when none are given
+func DefaultIdleContainerCriteria() models.IdleContainerCriteria {
+	return models.IdleContainerCriteria{
+		WindowHours:      168, // 1 week
+		CPUThreshold:     0.5,
+		PeakCPUThreshold: 5,
+	}
+}
+
+// idleStats accumulates stats samples for one container name on one host
+type idleStats struct {
+	cpuSum      float64
+	maxCPU      float64
+	memorySum   float64
+	samples     int
+	firstSample time.Time
+	restarts    int
+}
+
+// GetIdleContainers reports running containers that look unused over the criteria window:
+// near-zero CPU, no published ports (unless included), and no recreation or restart. Restarts are
+// the increases of Docker's restart count between scans, so a manual stop and start doesn't count.
+// Containers are tracked by name so history survives recreation, and stats must cover at
+// least half of the window so recently added hosts don't flag everything.
+func (db *DB) GetIdleContainers(criteria models.IdleContainerCriteria) (*models.IdleContainerReport, error) {
+	now := time.Now()
+	windowStart := now.Add(-time.Duration(criteria.WindowHours) * time.Hour)
+
+	report := &models.IdleContainerReport{
+		Criteria:    criteria,
+		GeneratedAt: now,
+		Containers:  make([]models.IdleContainer, 0),
+	}
+
+	containers, err := db.GetLatestContainers()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make(map[string]*idleStats)
+	key := func(name string, hostID int64) string {
+		return fmt.Sprintf("%d/%s", hostID, name)
+	}
+	get := func(name string, hostID int64) *idleStats {
+		k := key(name, hostID)
+		if stats[k] == nil {
+			stats[k] = &idleStats{}
+		}
+		return stats[k]
+	}
+	trackFirst := func(st *idleStats, raw interface{}) {
+		var t time.Time
+		switch v := raw.(type) {
+		case time.Time:
+			t = v
+		case string:
+			t, _ = parseTimestamp(v)
+		}
+		if !t.IsZero() && (st.firstSample.IsZero() || t.Before(st.firstSample)) {
+			st.firstSample = t
+		}
+	}
+
+	// Hourly aggregates (older data)
+	aggRows, err := db.conn.Query(`
+		SELECT container_name, host_id,
+		       SUM(avg_cpu_percent * sample_count), MAX(max_cpu_percent),
+		       SUM(avg_memory_usage * sample_count), SUM(sample_count),
+		       MIN(timestamp_hour)
+		FROM container_stats_aggregates
+		WHERE timestamp_hour >= ?
+		GROUP BY container_name, host_id
+	`, windowStart)
+	if err != nil {
+		return nil, err
+	}
+	defer aggRows.Close()
+
+	for aggRows.Next() {
+		var name string
+		var hostID int64
+		var cpuSum, maxCPU, memorySum sql.NullFloat64
+		var samples sql.NullInt64
+		var first interface{}
+		if err := aggRows.Scan(&name, &hostID, &cpuSum, &maxCPU, &memorySum, &samples, &first); err != nil {
+			return nil, err
+		}
+		st := get(name, hostID)
+		st.cpuSum += cpuSum.Float64
+		st.memorySum += memorySum.Float64
+		st.samples += int(samples.Int64)
+		if maxCPU.Float64 > st.maxCPU {
+			st.maxCPU = maxCPU.Float64
+		}
+		trackFirst(st, first)
+	}
+	if err := aggRows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Granular scan rows (recent data, plus restarts between consecutive scans of a container)
+	rows, err := db.conn.Query(`
+		SELECT name, host_id,
+		       SUM(cpu_percent), MAX(cpu_percent), SUM(memory_usage), COUNT(cpu_percent),
+		       MIN(CASE WHEN cpu_percent IS NOT NULL THEN scanned_at END),
+		       SUM(CASE WHEN restart_count > previous_restart_count THEN restart_count - previous_restart_count ELSE 0 END)
+		FROM (
+			SELECT name, host_id, cpu_percent, memory_usage, scanned_at, restart_count,
+			       LAG(restart_count) OVER (PARTITION BY id, host_id ORDER BY scanned_at) AS previous_restart_count
+			FROM containers
+			WHERE scanned_at >= ?
+		)
+		GROUP BY name, host_id
+	`, windowStart)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var hostID int64
+		var cpuSum, maxCPU, memorySum sql.NullFloat64
+		var samples, restarts int
+		var first interface{}
+		if err := rows.Scan(&name, &hostID, &cpuSum, &maxCPU, &memorySum, &samples, &first, &restarts); err != nil {
+			return nil, err
+		}
+		st := get(name, hostID)
+		st.cpuSum += cpuSum.Float64
+		st.memorySum += memorySum.Float64
+		st.samples += samples
+		st.restarts = restarts
+		if maxCPU.Float64 > st.maxCPU {
+			st.maxCPU = maxCPU.Float64
+		}
+		if samples > 0 {
+			trackFirst(st, first)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	coverageStart := windowStart.Add(time.Duration(criteria.WindowHours) * time.Hour / 2)
+
+	for _, c := range containers {
+		if c.State != "running" {
+			continue
+		}
+		if criteria.HostID > 0 && c.HostID != criteria.HostID {
+			continue
+		}
+		// Recreated (e.g. updated) during the window
+		if c.Created.After(windowStart) {
+			continue
+		}
+
+		published := 0
+		for _, p := range c.Ports {
+			if p.PublicPort > 0 {
+				published++
+			}
+		}
+		if published > 0 && !criteria.IncludePublished {
+			continue
+		}
+
+		st := stats[key(c.Name, c.HostID)]
+		if st == nil || st.samples == 0 || st.firstSample.After(coverageStart) {
+			continue
+		}
+		// Restarted by Docker (crashed or flapping) during the window
+		if st.restarts > 0 {
+			continue
+		}
+		// No memory use at all means stats collection isn't working, not an idle container
+		if st.memorySum == 0 {
+			continue
+		}
+
+		avgCPU := st.cpuSum / float64(st.samples)
+		if avgCPU > criteria.CPUThreshold || st.maxCPU > criteria.PeakCPUThreshold {
+			continue
+		}
+
+		report.Containers = append(report.Containers, models.IdleContainer{
+			ContainerID:    c.ID,
+			ContainerName:  c.Name,
+			Image:          c.Image,
+			HostID:         c.HostID,
+			HostName:       c.HostName,
+			ComposeProject: c.ComposeProject,
+			Created:        c.Created,
+			AvgCPUPercent:  avgCPU,
+			MaxCPUPercent:  st.maxCPU,
+			AvgMemoryUsage: int64(st.memorySum / float64(st.samples)),
+			SampleCount:    st.samples,
+			PublishedPorts: published,
+		})
+	}
+
+	// Longest-lived first: the oldest idle containers are the most likely to be forgotten
+	sort.Slice(report.Containers, func(i, j int) bool {
+		return report.Containers[i].Created.Before(report.Containers[j].Created)
+	})
+
+	return report, nil
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestGetIdleContainers(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "idle.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	hostID, err := db.AddHost(models.Host{Name: "host1", Address: "unix:///var/run/docker.sock", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	now := time.Now()
+	oldCreated := now.Add(-30 * 24 * time.Hour)
+	container := func(id, name, state string, scannedAt time.Time, cpu float64) models.Container {
+		return models.Container{
+			ID: id, Name: name, Image: name + ":latest", State: state,
+			HostID: hostID, HostName: "host1", Created: oldCreated, ScannedAt: scannedAt,
+			CPUPercent: cpu, MemoryUsage: 64 * 1024 * 1024, MemoryLimit: 1024 * 1024 * 1024,
+		}
+	}
+
+	// Earlier scan: Docker restarted "flappy" twice since, "manual" was stopped by hand and started again
+	earlier := now.Add(-2 * time.Hour)
+	flappyBefore := container("f1", "flappy", "running", earlier, 0.1)
+	flappyBefore.RestartCount = 3
+	if err := db.SaveContainers([]models.Container{flappyBefore, container("m1", "manual", "exited", earlier, 0)}); err != nil {
+		t.Fatalf("Failed to save containers: %v", err)
+	}
+
+	web := container("w1", "web", "running", now, 0.1)
+	web.Ports = []models.PortMapping{{PrivatePort: 80, PublicPort: 8080, Type: "tcp"}}
+	fresh := container("n1", "fresh", "running", now, 0.1)
+	fresh.Created = now.Add(-24 * time.Hour)
+	flappy := container("f1", "flappy", "running", now, 0.1)
+	flappy.RestartCount = 5
+	latest := []models.Container{
+		container("i1", "idle", "running", now, 0.1),
+		container("b1", "busy", "running", now, 0.1),
+		flappy,
+		container("m1", "manual", "running", now, 0.1),
+		container("u1", "uncovered", "running", now, 0.1),
+		web,
+		fresh,
+	}
+	if err := db.SaveContainers(latest); err != nil {
+		t.Fatalf("Failed to save containers: %v", err)
+	}
+
+	// Hourly aggregates covering most of the window (uncovered only has recent data)
+	aggregate := func(id, name string, at time.Time, avgCPU, maxCPU float64) {
+		_, err := db.conn.Exec(`
+			INSERT INTO container_stats_aggregates
+			(container_id, container_name, host_id, host_name, timestamp_hour, avg_cpu_percent, avg_memory_usage, max_cpu_percent, max_memory_usage, sample_count)
+			VALUES (?, ?, ?, 'host1', ?, ?, ?, ?, ?, 12)
+		`, id, name, hostID, at, avgCPU, 64*1024*1024, maxCPU, 64*1024*1024)
+		if err != nil {
+			t.Fatalf("Failed to insert aggregate: %v", err)
+		}
+	}
+	sixDaysAgo := now.Add(-6 * 24 * time.Hour).Truncate(time.Hour)
+	for id, name := range map[string]string{"i1": "idle", "f1": "flappy", "m1": "manual", "w1": "web", "n1": "fresh"} {
+		aggregate(id, name, sixDaysAgo, 0.2, 1.5)
+	}
+	aggregate("b1", "busy", sixDaysAgo, 12, 80)
+	aggregate("u1", "uncovered", now.Add(-6*time.Hour).Truncate(time.Hour), 0.1, 0.2)
+
+	report, err := db.GetIdleContainers(DefaultIdleContainerCriteria())
+	if err != nil {
+		t.Fatalf("GetIdleContainers failed: %v", err)
+	}
+	reported := make(map[string]models.IdleContainer)
+	for _, c := range report.Containers {
+		reported[c.ContainerName] = c
+	}
+	if len(reported) != 2 || reported["idle"].ContainerID == "" || reported["manual"].ContainerID == "" {
+		t.Fatalf("Expected idle and manual (stopped by hand, never restarted by Docker) to be reported, got %+v", report.Containers)
+	}
+	if reported["idle"].SampleCount != 13 {
+		t.Errorf("Expected 13 samples (12 aggregated + 1 granular), got %d", reported["idle"].SampleCount)
+	}
+
+	// Published ports are only excluded by default
+	criteria := DefaultIdleContainerCriteria()
+	criteria.IncludePublished = true
+	report, err = db.GetIdleContainers(criteria)
+	if err != nil {
+		t.Fatalf("GetIdleContainers failed: %v", err)
+	}
+	names := make(map[string]bool)
+	for _, c := range report.Containers {
+		names[c.ContainerName] = true
+	}
+	if len(names) != 3 || !names["idle"] || !names["manual"] || !names["web"] {
+		t.Errorf("Expected idle, manual and web with include_published, got %v", names)
+	}
+}
@@ -0,0 +1,322 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// imageRunExtend moves the latest image run of a container forward when the container still
+// runs the same image (args: last seen, scan ID, image, host ID, container name, image ID).
+// A container that runs another image gets a new run, so going back to an earlier image is a
+// run of its own too.
+const imageRunExtend = `
+	UPDATE container_image_history
+	SET last_seen = MAX(last_seen, ?), last_scan_id = ?, image = ?
+	WHERE id = (
+		SELECT id FROM container_image_history
+		WHERE host_id = ? AND container_name = ?
+		ORDER BY last_seen DESC, id DESC LIMIT 1
+	) AND image_id = ?
+`
+
+const imageRunInsert = `
+	INSERT INTO container_image_history (host_id, container_name, image, image_id, first_seen, last_seen, first_scan_id, last_scan_id)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+// recordImageRun records that a scan saw a container with its image
+func recordImageRun(extend, insert *sql.Stmt, c models.Container) error {
+	res, err := extend.Exec(c.ScannedAt, c.ScanID, c.Image, c.HostID, c.Name, c.ImageID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+	_, err = insert.Exec(c.HostID, c.Name, c.Image, c.ImageID, c.ScannedAt, c.ScannedAt, c.ScanID, c.ScanID)
+	return err
+}
+
+// backfillImageHistory builds the image runs of an empty container_image_history from the
+// scans still in containers, once, after the table was added to an existing database
+func (db *DB) backfillImageHistory() error {
+	var runs int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM container_image_history`).Scan(&runs); err != nil || runs > 0 {
+		return err
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT host_id, name, image, image_id, scanned_at, COALESCE(scan_id, '')
+		FROM containers
+		WHERE image_id != ''
+		ORDER BY host_id, name, scanned_at
+	`)
+	if err != nil {
+		return err
+	}
+	var scans []models.Container
+	for rows.Next() {
+		var c models.Container
+		if err := rows.Scan(&c.HostID, &c.Name, &c.Image, &c.ImageID, &c.ScannedAt, &c.ScanID); err != nil {
+			rows.Close()
+			return err
+		}
+		scans = append(scans, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(scans) == 0 {
+		return err
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	extend, err := tx.Prepare(imageRunExtend)
+	if err != nil {
+		return err
+	}
+	defer extend.Close()
+	insert, err := tx.Prepare(imageRunInsert)
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+
+	for _, c := range scans {
+		if err := recordImageRun(extend, insert, c); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetContainerImageHistory returns the image runs of a container on a host, oldest first
+func (db *DB) GetContainerImageHistory(hostID int64, containerName string) ([]models.ImageRun, error) {
+	return db.queryImageRuns(`WHERE r.host_id = ? AND r.container_name = ?`, []interface{}{hostID, containerName}, nil)
+}
+
+// GetSupplyChainTimeline returns the image runs matching a filter, oldest first, with when the
+// first and last of them ran. Vulnerability and package filters match the latest scan of each
+// image, whose details are kept for the vulnerability scanner's detailed retention.
+func (db *DB) GetSupplyChainTimeline(filter models.SupplyChainFilter) (*models.SupplyChainTimeline, error) {
+	timeline := &models.SupplyChainTimeline{Runs: []models.ImageRun{}}
+	var conditions []string
+	var args []interface{}
+
+	if filter.HostIDs != nil {
+		if len(filter.HostIDs) == 0 {
+			return timeline, nil
+		}
+		conditions = append(conditions, `r.host_id IN (`+strings.TrimSuffix(strings.Repeat("?,", len(filter.HostIDs)), ",")+`)`)
+		for _, id := range filter.HostIDs {
+			args = append(args, id)
+		}
+	}
+	if filter.ContainerName != "" {
+		conditions = append(conditions, `r.container_name = ?`)
+		args = append(args, filter.ContainerName)
+	}
+	if filter.Image != "" {
+		conditions = append(conditions, `r.image LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(filter.Image)+"%")
+	}
+	if filter.ImageID != "" {
+		imageID := strings.TrimPrefix(filter.ImageID, "sha256:")
+		conditions = append(conditions, `(r.image_id LIKE ? ESCAPE '\' OR r.image_id LIKE ? ESCAPE '\')`)
+		args = append(args, escapeLike(imageID)+"%", "sha256:"+escapeLike(imageID)+"%")
+	}
+
+	// Matching vulnerabilities are listed with each run, and runs without any are left out
+	var matchCondition string
+	var matchArgs []interface{}
+	if filter.Vulnerability != "" || filter.Package != "" {
+		matchCondition = `v.image_id = r.image_id AND (? = '' OR v.vulnerability_id = ? COLLATE NOCASE) AND (? = '' OR v.pkg_name = ? COLLATE NOCASE)`
+		matchArgs = []interface{}{filter.Vulnerability, filter.Vulnerability, filter.Package, filter.Package}
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM vulnerabilities v WHERE `+matchCondition+`)`)
+		args = append(args, matchArgs...)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = `WHERE ` + strings.Join(conditions, ` AND `)
+	}
+	runs, err := db.queryImageRuns(where, args, &imageRunMatches{condition: matchCondition, args: matchArgs})
+	if err != nil {
+		return nil, err
+	}
+
+	timeline.Runs = runs
+	containers := make(map[string]bool)
+	images := make(map[string]bool)
+	for i := range runs {
+		run := &runs[i]
+		if timeline.FirstSeen == nil || run.FirstSeen.Before(*timeline.FirstSeen) {
+			timeline.FirstSeen = &run.FirstSeen
+		}
+		if timeline.LastSeen == nil || run.LastSeen.After(*timeline.LastSeen) {
+			timeline.LastSeen = &run.LastSeen
+		}
+		containers[run.HostName+"/"+run.ContainerName] = true
+		images[run.ImageID] = true
+		if run.Current {
+			timeline.Current++
+		}
+	}
+	timeline.Containers = len(containers)
+	timeline.Images = len(images)
+	return timeline, nil
+}
+
+// SearchImageUsage returns the containers seen with an image since the search's start, one per
+// container and image ID, current ones first. The image matches part of the reference the
+// container was created from (e.g. "nginx", "nginx:1.25" or "nginx@sha256:..."), part of an image
+// tag or version, or the start of the image ID with or without "sha256:".
+func (db *DB) SearchImageUsage(search models.ImageSearch) (*models.ImageSearchResult, error) {
+	result := &models.ImageSearchResult{Image: search.Image, Since: search.Since, Containers: []models.ImageSearchContainer{}}
+
+	imageID := escapeLike(strings.TrimPrefix(search.Image, "sha256:"))
+	pattern := "%" + escapeLike(search.Image) + "%"
+	conditions := `scanned_at >= ? AND image_id != '' AND (image LIKE ? ESCAPE '\' OR image_tags LIKE ? ESCAPE '\'
+		OR image_id LIKE ? ESCAPE '\' OR image_id LIKE ? ESCAPE '\')`
+	args := []interface{}{search.Since, pattern, pattern, imageID + "%", "sha256:" + imageID + "%"}
+	if search.HostIDs != nil {
+		if len(search.HostIDs) == 0 {
+			return result, nil
+		}
+		conditions += ` AND host_id IN (` + strings.TrimSuffix(strings.Repeat("?,", len(search.HostIDs)), ",") + `)`
+		for _, id := range search.HostIDs {
+			args = append(args, id)
+		}
+	}
+
+	rows, err := db.conn.Query(`
+		WITH latest AS (
+			SELECT host_id, MAX(scanned_at) AS scanned_at FROM containers GROUP BY host_id
+		), matched AS (
+			SELECT host_id, name, image_id, MIN(scanned_at) AS first_seen, MAX(scanned_at) AS last_seen
+			FROM containers
+			WHERE `+conditions+`
+			GROUP BY host_id, name, image_id
+		)
+		SELECT m.host_id, COALESCE(h.name, c.host_name), c.id, m.name, c.image, m.image_id, c.image_tags, c.state, c.status,
+			COALESCE((SELECT MIN(r.first_seen) FROM container_image_history r
+				WHERE r.host_id = m.host_id AND r.container_name = m.name AND r.image_id = m.image_id), m.first_seen),
+			m.last_seen, m.last_seen >= COALESCE(l.scanned_at, m.last_seen) AS current
+		FROM matched m
+		JOIN containers c ON c.host_id = m.host_id AND c.name = m.name AND c.image_id = m.image_id AND c.scanned_at = m.last_seen
+		LEFT JOIN hosts h ON h.id = m.host_id
+		LEFT JOIN latest l ON l.host_id = m.host_id
+		GROUP BY m.host_id, m.name, m.image_id
+		ORDER BY current DESC, COALESCE(h.name, c.host_name), m.name, m.last_seen DESC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hosts := make(map[int64]bool)
+	for rows.Next() {
+		var c models.ImageSearchContainer
+		var imageTagsJSON sql.NullString
+		var firstSeen, lastSeen string
+		if err := rows.Scan(&c.HostID, &c.HostName, &c.ContainerID, &c.ContainerName, &c.Image, &c.ImageID, &imageTagsJSON,
+			&c.State, &c.Status, &firstSeen, &lastSeen, &c.Current); err != nil {
+			return nil, err
+		}
+		if c.FirstSeen, err = parseTimestamp(firstSeen); err != nil {
+			return nil, err
+		}
+		if c.LastSeen, err = parseTimestamp(lastSeen); err != nil {
+			return nil, err
+		}
+		if imageTagsJSON.Valid && imageTagsJSON.String != "" && imageTagsJSON.String != "null" {
+			if err := json.Unmarshal([]byte(imageTagsJSON.String), &c.ImageTags); err != nil {
+				return nil, err
+			}
+		}
+		hosts[c.HostID] = true
+		if c.Current {
+			result.Current++
+			if c.State == "running" {
+				result.Running++
+			}
+		}
+		result.Containers = append(result.Containers, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	result.Hosts = len(hosts)
+	return result, nil
+}
+
+// imageRunMatches lists the vulnerabilities of a run's image matching a timeline's filter
+type imageRunMatches struct {
+	condition string
+	args      []interface{}
+}
+
+func (db *DB) queryImageRuns(where string, args []interface{}, matches *imageRunMatches) ([]models.ImageRun, error) {
+	matchesColumn := `''`
+	var queryArgs []interface{}
+	if matches != nil && matches.condition != "" {
+		matchesColumn = `COALESCE((SELECT GROUP_CONCAT(DISTINCT v.vulnerability_id) FROM vulnerabilities v WHERE ` + matches.condition + `), '')`
+		queryArgs = append(queryArgs, matches.args...)
+	}
+	queryArgs = append(queryArgs, args...)
+
+	// A run is current when it was seen in its host's latest scan
+	rows, err := db.conn.Query(`
+		WITH latest AS (
+			SELECT host_id, MAX(scanned_at) AS scanned_at FROM containers GROUP BY host_id
+		)
+		SELECT r.host_id, COALESCE(h.name, ''), r.container_name, r.image, r.image_id, r.first_seen, r.last_seen,
+			r.first_scan_id, r.last_scan_id, r.last_seen >= COALESCE(l.scanned_at, r.last_seen),
+			vs.scanned_at, COALESCE(vs.success, 0), COALESCE(vs.total_vulnerabilities, 0),
+			COALESCE(vs.critical_count, 0), COALESCE(vs.high_count, 0), COALESCE(vs.medium_count, 0), COALESCE(vs.low_count, 0),
+			`+matchesColumn+`
+		FROM container_image_history r
+		LEFT JOIN hosts h ON h.id = r.host_id
+		LEFT JOIN latest l ON l.host_id = r.host_id
+		LEFT JOIN vulnerability_scans vs ON vs.image_id = r.image_id
+		`+where+`
+		ORDER BY r.first_seen, r.id
+	`, queryArgs...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := make([]models.ImageRun, 0)
+	for rows.Next() {
+		var run models.ImageRun
+		var scannedAt sql.NullTime
+		var vulns models.ImageRunVulnerabilities
+		var matched string
+		if err := rows.Scan(&run.HostID, &run.HostName, &run.ContainerName, &run.Image, &run.ImageID, &run.FirstSeen, &run.LastSeen,
+			&run.FirstScanID, &run.LastScanID, &run.Current,
+			&scannedAt, &vulns.Success, &vulns.Total, &vulns.Critical, &vulns.High, &vulns.Medium, &vulns.Low,
+			&matched); err != nil {
+			return nil, err
+		}
+		if scannedAt.Valid {
+			vulns.ScannedAt = scannedAt.Time
+			if matched != "" {
+				vulns.Matches = strings.Split(matched, ",")
+			}
+			run.Vulnerabilities = &vulns
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern (with ESCAPE '\')
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/vulnerability"
+)
+
+func TestContainerImageHistory(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	// postgres runs 16.1, is updated to 16.2 and rolled back; redis runs one image throughout
+	start := time.Now().Add(-10 * 24 * time.Hour).Truncate(time.Second)
+	scans := []string{"sha256:pg161", "sha256:pg161", "sha256:pg162", "sha256:pg162", "sha256:pg161"}
+	for i, imageID := range scans {
+		scannedAt := start.Add(time.Duration(i) * 24 * time.Hour)
+		scanID := "scan" + string(rune('a'+i))
+		if err := db.SaveContainers([]models.Container{
+			{ID: "pg", Name: "postgres", Image: "postgres:16", ImageID: imageID, State: "running", HostID: hostID, HostName: "nas", ScannedAt: scannedAt, ScanID: scanID},
+			{ID: "rd", Name: "redis", Image: "redis:7", ImageID: "sha256:redis7", State: "running", HostID: hostID, HostName: "nas", ScannedAt: scannedAt, ScanID: scanID},
+		}); err != nil {
+			t.Fatalf("Failed to save containers: %v", err)
+		}
+	}
+
+	runs, err := db.GetContainerImageHistory(hostID, "postgres")
+	if err != nil {
+		t.Fatalf("GetContainerImageHistory failed: %v", err)
+	}
+	if len(runs) != 3 {
+		t.Fatalf("Expected 3 image runs (update and rollback), got %+v", runs)
+	}
+	if runs[0].ImageID != "sha256:pg161" || !runs[0].FirstSeen.Equal(start) || !runs[0].LastSeen.Equal(start.Add(24*time.Hour)) ||
+		runs[0].FirstScanID != "scana" || runs[0].LastScanID != "scanb" || runs[0].Current {
+		t.Errorf("Unexpected first run %+v", runs[0])
+	}
+	if runs[1].ImageID != "sha256:pg162" || runs[2].ImageID != "sha256:pg161" || !runs[2].Current || runs[2].HostName != "nas" {
+		t.Errorf("Expected the update and the current rollback, got %+v", runs[1:])
+	}
+	if runs[0].Vulnerabilities != nil {
+		t.Errorf("Expected no vulnerability scan, got %+v", runs[0].Vulnerabilities)
+	}
+
+	// The 16.2 image turns out to ship a compromised xz
+	if err := db.SaveVulnerabilityScan(&vulnerability.VulnerabilityScan{
+		ImageID: "sha256:pg162", ImageName: "postgres:16", ScannedAt: time.Now(), Success: true,
+		TotalVulnerabilities: 2, SeverityCounts: vulnerability.SeverityCounts{Critical: 1, Low: 1},
+	}, []vulnerability.Vulnerability{
+		{ImageID: "sha256:pg162", VulnerabilityID: "CVE-2024-3094", PkgName: "xz-utils", Severity: "CRITICAL"},
+		{ImageID: "sha256:pg162", VulnerabilityID: "CVE-2023-0001", PkgName: "zlib", Severity: "LOW"},
+	}); err != nil {
+		t.Fatalf("Failed to save vulnerability scan: %v", err)
+	}
+
+	for name, filter := range map[string]models.SupplyChainFilter{
+		"vulnerability": {Vulnerability: "cve-2024-3094"},
+		"package":       {Package: "xz-utils"},
+		"image ID":      {ImageID: "pg162"},
+	} {
+		timeline, err := db.GetSupplyChainTimeline(filter)
+		if err != nil {
+			t.Fatalf("%s: GetSupplyChainTimeline failed: %v", name, err)
+		}
+		if len(timeline.Runs) != 1 || timeline.FirstSeen == nil || !timeline.FirstSeen.Equal(start.Add(2*24*time.Hour)) ||
+			timeline.Current != 0 || timeline.Containers != 1 || timeline.Images != 1 {
+			t.Errorf("%s: expected the 16.2 run first seen on day 2, got %+v", name, timeline)
+			continue
+		}
+		vulns := timeline.Runs[0].Vulnerabilities
+		if vulns == nil || vulns.Critical != 1 {
+			t.Errorf("%s: expected the image's scan, got %+v", name, vulns)
+		}
+		if filter.ImageID == "" && (len(vulns.Matches) != 1 || vulns.Matches[0] != "CVE-2024-3094") {
+			t.Errorf("%s: expected the matching vulnerability, got %+v", name, vulns.Matches)
+		}
+	}
+
+	timeline, err := db.GetSupplyChainTimeline(models.SupplyChainFilter{Image: "redis"})
+	if err != nil {
+		t.Fatalf("GetSupplyChainTimeline failed: %v", err)
+	}
+	if len(timeline.Runs) != 1 || timeline.Current != 1 || !timeline.LastSeen.Equal(start.Add(4*24*time.Hour)) {
+		t.Errorf("Expected the current redis run, got %+v", timeline)
+	}
+	if timeline, _ := db.GetSupplyChainTimeline(models.SupplyChainFilter{Image: "redis", HostIDs: []int64{}}); len(timeline.Runs) != 0 {
+		t.Errorf("Expected no runs without visible hosts, got %+v", timeline.Runs)
+	}
+}
+
+func TestBackfillImageHistory(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for i, imageID := range []string{"sha256:a", "sha256:a", "sha256:b"} {
+		if _, err := db.conn.Exec(`
+			INSERT INTO containers (id, name, image, image_id, state, status, created, host_id, host_name, scanned_at, scan_id)
+			VALUES ('c1', 'app', 'app:latest', ?, 'running', 'Up', ?, ?, 'nas', ?, ?)
+		`, imageID, start, hostID, start.Add(time.Duration(i)*time.Minute), "s"+string(rune('0'+i))); err != nil {
+			t.Fatalf("Failed to insert scan: %v", err)
+		}
+	}
+
+	if err := db.backfillImageHistory(); err != nil {
+		t.Fatalf("backfillImageHistory failed: %v", err)
+	}
+	runs, err := db.GetContainerImageHistory(hostID, "app")
+	if err != nil {
+		t.Fatalf("GetContainerImageHistory failed: %v", err)
+	}
+	if len(runs) != 2 || runs[0].LastScanID != "s1" || runs[1].FirstScanID != "s2" || !runs[1].Current {
+		t.Errorf("Expected two runs from the scans, got %+v", runs)
+	}
+
+	// It only runs on an empty history
+	if err := db.backfillImageHistory(); err != nil {
+		t.Fatalf("backfillImageHistory failed: %v", err)
+	}
+	if runs, _ := db.GetContainerImageHistory(hostID, "app"); len(runs) != 2 {
+		t.Errorf("Expected the backfill not to repeat, got %d runs", len(runs))
+	}
+}
+
+func TestSearchImageUsage(t *testing.T) {
+	db := setupTestDB(t)
+
+	nas, err := db.AddHost(models.Host{Name: "nas", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	vps, err := db.AddHost(models.Host{Name: "vps", Address: "tcp://vps:2376", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	// proxy on nas moves off the bad build, web on vps still runs it (stopped), db never did
+	start := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	for i, proxyImage := range []string{"sha256:bad0001", "sha256:good001"} {
+		scannedAt := start.Add(time.Duration(i) * 24 * time.Hour)
+		if err := db.SaveContainers([]models.Container{
+			{ID: "p1", Name: "proxy", Image: "nginx:latest", ImageID: proxyImage, State: "running", HostID: nas, HostName: "nas", ScannedAt: scannedAt},
+			{ID: "d1", Name: "db", Image: "postgres:16", ImageID: "sha256:pg16", State: "running", HostID: nas, HostName: "nas", ScannedAt: scannedAt},
+		}); err != nil {
+			t.Fatalf("Failed to save containers: %v", err)
+		}
+		if err := db.SaveContainers([]models.Container{
+			{ID: "w1", Name: "web", Image: "registry.local/web:2", ImageID: "sha256:bad0001", ImageTags: []string{"registry.local/web:2", "1.27.0"},
+				State: []string{"running", "exited"}[i], HostID: vps, HostName: "vps", ScannedAt: scannedAt},
+		}); err != nil {
+			t.Fatalf("Failed to save containers: %v", err)
+		}
+	}
+
+	result, err := db.SearchImageUsage(models.ImageSearch{Image: "sha256:bad0001", Since: start.Add(-time.Hour)})
+	if err != nil {
+		t.Fatalf("SearchImageUsage failed: %v", err)
+	}
+	if len(result.Containers) != 2 || result.Hosts != 2 || result.Current != 1 || result.Running != 0 {
+		t.Fatalf("Expected web (current) and proxy (earlier), got %+v", result)
+	}
+	web, proxy := result.Containers[0], result.Containers[1]
+	if web.ContainerName != "web" || !web.Current || web.State != "exited" || web.HostName != "vps" || len(web.ImageTags) != 2 {
+		t.Errorf("Unexpected current container %+v", web)
+	}
+	if proxy.ContainerName != "proxy" || proxy.Current || !proxy.FirstSeen.Equal(start) || !proxy.LastSeen.Equal(start) {
+		t.Errorf("Unexpected earlier container %+v", proxy)
+	}
+
+	for query, want := range map[string]int{"nginx": 2, "1.27": 1, "bad0": 2, "POSTGRES": 1, "%": 0} {
+		result, err := db.SearchImageUsage(models.ImageSearch{Image: query, Since: start.Add(-time.Hour)})
+		if err != nil {
+			t.Fatalf("SearchImageUsage(%q) failed: %v", query, err)
+		}
+		if len(result.Containers) != want {
+			t.Errorf("SearchImageUsage(%q): expected %d containers, got %+v", query, want, result.Containers)
+		}
+	}
+
+	result, _ = db.SearchImageUsage(models.ImageSearch{Image: "bad0001", Since: start.Add(time.Hour)})
+	if len(result.Containers) != 1 || !result.Containers[0].FirstSeen.Equal(start) {
+		t.Errorf("Expected web only, first seen before the window, got %+v", result.Containers)
+	}
+	result, _ = db.SearchImageUsage(models.ImageSearch{Image: "bad0001", Since: start.Add(-time.Hour), HostIDs: []int64{nas}})
+	if len(result.Containers) != 1 || result.Containers[0].ContainerName != "proxy" {
+		t.Errorf("Expected proxy only on nas, got %+v", result.Containers)
+	}
+}
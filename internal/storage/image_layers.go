@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// GetCachedImageLayers returns the cached layer breakdown of an image, or nil if it has not
+// been fetched yet. Image IDs may be given with or without the sha256: prefix and shortened.
+func (db *DB) GetCachedImageLayers(hostID int64, imageID string) (*models.ImageLayers, error) {
+	imageID = strings.TrimPrefix(imageID, "sha256:")
+	if imageID == "" {
+		return nil, nil
+	}
+
+	var layersJSON string
+	err := db.conn.QueryRow(`
+		SELECT layers
+		FROM image_layers_cache
+		WHERE host_id = ? AND image_id LIKE ?
+		ORDER BY collected_at DESC
+		LIMIT 1
+	`, hostID, "sha256:"+imageID+"%").Scan(&layersJSON)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var layers models.ImageLayers
+	if err := json.Unmarshal([]byte(layersJSON), &layers); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal image layers: %w", err)
+	}
+	layers.Cached = true
+
+	return &layers, nil
+}
+
+// SaveImageLayers caches the layer breakdown of an image
+func (db *DB) SaveImageLayers(layers *models.ImageLayers) error {
+	layersJSON, err := json.Marshal(layers)
+	if err != nil {
+		return fmt.Errorf("failed to marshal image layers: %w", err)
+	}
+
+	_, err = db.conn.Exec(`
+		INSERT INTO image_layers_cache (image_id, host_id, layers, collected_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(image_id, host_id) DO UPDATE SET
+			layers = excluded.layers,
+			collected_at = excluded.collected_at
+	`, layers.ImageID, layers.HostID, string(layersJSON), layers.CollectedAt)
+	return err
+}
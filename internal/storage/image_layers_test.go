@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestImageLayersCache(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "host1", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	layers := &models.ImageLayers{
+		ImageID:  "sha256:0123456789abcdef0123456789abcdef",
+		HostID:   hostID,
+		RepoTags: []string{"app:1"},
+		Size:     300,
+		Layers: []models.ImageLayer{
+			{CreatedBy: "ADD file:abc in /", Size: 300, DiffID: "sha256:base"},
+			{CreatedBy: `CMD ["app"]`, Empty: true},
+		},
+		CollectedAt: time.Now(),
+	}
+	if err := db.SaveImageLayers(layers); err != nil {
+		t.Fatalf("SaveImageLayers failed: %v", err)
+	}
+	// Saving again must update in place rather than fail
+	if err := db.SaveImageLayers(layers); err != nil {
+		t.Fatalf("SaveImageLayers upsert failed: %v", err)
+	}
+
+	for _, id := range []string{layers.ImageID, "0123456789ab", "sha256:0123456789ab"} {
+		cached, err := db.GetCachedImageLayers(hostID, id)
+		if err != nil {
+			t.Fatalf("GetCachedImageLayers(%q) failed: %v", id, err)
+		}
+		if cached == nil {
+			t.Fatalf("Expected cached layers for %q", id)
+		}
+		if !cached.Cached || len(cached.Layers) != 2 || cached.Layers[0].DiffID != "sha256:base" {
+			t.Errorf("Unexpected cached layers for %q: %+v", id, cached)
+		}
+	}
+
+	missing, err := db.GetCachedImageLayers(hostID, "nginx:latest")
+	if err != nil {
+		t.Fatalf("GetCachedImageLayers failed: %v", err)
+	}
+	if missing != nil {
+		t.Errorf("Expected no cached layers for an image name, got %+v", missing)
+	}
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// imageUsageUpsert records that an image was seen on a host, moving last_used forward
+// only when a running container used it more recently
+const imageUsageUpsert = `
+	INSERT INTO image_usage (image_id, host_id, first_seen, last_used)
+	VALUES (?, ?, ?, ?)
+	ON CONFLICT(image_id, host_id) DO UPDATE SET
+		last_used = CASE
+			WHEN excluded.last_used IS NOT NULL AND (image_usage.last_used IS NULL OR excluded.last_used > image_usage.last_used)
+			THEN excluded.last_used
+			ELSE image_usage.last_used
+		END
+`
+
+// RecordImagesSeen notes images present on a host that may never have had a container,
+// so their unused age can be measured from when census first saw them
+func (db *DB) RecordImagesSeen(hostID int64, imageIDs []string, seenAt time.Time) error {
+	if len(imageIDs) == 0 {
+		return nil
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(imageUsageUpsert)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, imageID := range imageIDs {
+		if _, err := stmt.Exec(imageID, hostID, seenAt, nil); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetImageUsage returns the usage history of every image census has seen on a host, keyed by image ID
+func (db *DB) GetImageUsage(hostID int64) (map[string]models.ImageUsageRecord, error) {
+	rows, err := db.conn.Query(`
+		SELECT image_id, first_seen, last_used
+		FROM image_usage
+		WHERE host_id = ?
+	`, hostID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usage := make(map[string]models.ImageUsageRecord)
+	for rows.Next() {
+		var imageID string
+		var record models.ImageUsageRecord
+		var lastUsed sql.NullTime
+		if err := rows.Scan(&imageID, &record.FirstSeen, &lastUsed); err != nil {
+			return nil, err
+		}
+		if lastUsed.Valid {
+			t := lastUsed.Time
+			record.LastUsed = &t
+		}
+		usage[imageID] = record
+	}
+
+	return usage, rows.Err()
+}
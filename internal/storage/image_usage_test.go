@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestImageUsageTracking(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "host1", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	scan := func(at time.Time, state string) {
+		container := models.Container{
+			ID: "abc123", Name: "web", Image: "nginx:1.25", ImageID: "sha256:nginx",
+			State: state, HostID: hostID, HostName: "host1", ScannedAt: at,
+		}
+		if err := db.SaveContainers([]models.Container{container}); err != nil {
+			t.Fatalf("Failed to save container: %v", err)
+		}
+	}
+
+	start := time.Now().Add(-48 * time.Hour).UTC()
+	scan(start, "exited")
+	scan(start.Add(time.Hour), "running")
+	scan(start.Add(2*time.Hour), "exited")
+
+	if err := db.RecordImagesSeen(hostID, []string{"sha256:nginx", "sha256:redis"}, start.Add(3*time.Hour)); err != nil {
+		t.Fatalf("RecordImagesSeen failed: %v", err)
+	}
+
+	usage, err := db.GetImageUsage(hostID)
+	if err != nil {
+		t.Fatalf("GetImageUsage failed: %v", err)
+	}
+	if len(usage) != 2 {
+		t.Fatalf("Expected 2 images, got %d", len(usage))
+	}
+
+	nginx := usage["sha256:nginx"]
+	if !nginx.FirstSeen.Equal(start) {
+		t.Errorf("Expected first seen %v, got %v", start, nginx.FirstSeen)
+	}
+	// Only the running scan counts as use, and later stopped scans must not clear it
+	if nginx.LastUsed == nil || !nginx.LastUsed.Equal(start.Add(time.Hour)) {
+		t.Errorf("Expected last used at the running scan, got %v", nginx.LastUsed)
+	}
+
+	redis := usage["sha256:redis"]
+	if redis.LastUsed != nil || !redis.FirstSeen.Equal(start.Add(3*time.Hour)) {
+		t.Errorf("Expected never-used image with first seen time, got %+v", redis)
+	}
+}
@@ -0,0 +1,96 @@
+//go:build integration
+
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/integration"
+	"github.com/container-census/container-census/internal/models"
+	"github.com/container-census/container-census/internal/scanner"
+)
+
+// TestIntegrationScanRoundTrip saves real scans and reads them back, covering the timestamp
+// parsing and stats persistence that mocks don't exercise
+func TestIntegrationScanRoundTrip(t *testing.T) {
+	h := integration.New(t)
+	c := h.Run(integration.ContainerOptions{Name: "db"})
+	db := setupTestDB(t)
+	s := scanner.New(30)
+
+	host := h.Host()
+	hostID, err := db.AddHost(host)
+	if err != nil {
+		t.Fatalf("AddHost failed: %v", err)
+	}
+	host.ID = hostID
+
+	var scanned models.Container
+	for i := 0; i < 2; i++ {
+		if i > 0 {
+			time.Sleep(1100 * time.Millisecond) // scans within a second share a timestamp
+		}
+		containers, err := s.ScanHost(context.Background(), host)
+		if err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		for i := range containers {
+			containers[i].HostID = hostID
+			if containers[i].Name == c.Name {
+				scanned = containers[i]
+			}
+		}
+		if err := db.SaveContainers(containers); err != nil {
+			t.Fatalf("SaveContainers failed: %v", err)
+		}
+	}
+	if scanned.ID == "" {
+		t.Fatalf("Expected %s in the scan", c.Name)
+	}
+
+	saved, err := db.GetContainersByHost(hostID)
+	if err != nil {
+		t.Fatalf("GetContainersByHost failed: %v", err)
+	}
+	var got *models.Container
+	for i := range saved {
+		if saved[i].Name == c.Name {
+			got = &saved[i]
+		}
+	}
+	if got == nil {
+		t.Fatalf("Expected %s saved, got %d containers", c.Name, len(saved))
+	}
+	if !got.Created.Equal(scanned.Created.Truncate(time.Second)) && !got.Created.Equal(scanned.Created) {
+		t.Errorf("Expected the creation time %v, got %v", scanned.Created, got.Created)
+	}
+	if d := got.ScannedAt.Sub(scanned.ScannedAt); d < -time.Second || d > time.Second {
+		t.Errorf("Expected the scan time %v, got %v", scanned.ScannedAt, got.ScannedAt)
+	}
+	if got.State != "running" || got.MemoryUsage <= 0 {
+		t.Errorf("Expected a running container with memory stats, got %s %d", got.State, got.MemoryUsage)
+	}
+
+	points, err := db.GetContainerStats(scanned.ID, hostID, 1)
+	if err != nil {
+		t.Fatalf("GetContainerStats failed: %v", err)
+	}
+	var measured int
+	for _, p := range points {
+		if p.Gap {
+			continue
+		}
+		measured++
+		if p.Timestamp.After(time.Now().Add(time.Minute)) || p.Timestamp.Before(time.Now().Add(-time.Hour)) {
+			t.Errorf("Expected stats from the last hour, got one at %v", p.Timestamp)
+		}
+		if p.MemoryUsage <= 0 {
+			t.Errorf("Expected memory usage persisted, got %+v", p)
+		}
+	}
+	if measured != 2 {
+		t.Errorf("Expected the stats of both scans, got %d of %d points", measured, len(points))
+	}
+}
@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// Integration names in the integration_settings table
+const (
+	integrationUptimeKuma = "uptime_kuma"
+	integrationProxmox    = "proxmox"
+	integrationTraefik    = "traefik"
+)
+
+// getIntegrationConfig loads an integration's stored JSON configuration into v. It returns false
+// (leaving v untouched) when the integration has never been configured.
+func (db *DB) getIntegrationConfig(name string, v interface{}) (bool, error) {
+	var config string
+	err := db.conn.QueryRow(`SELECT config FROM integration_settings WHERE name = ?`, name).Scan(&config)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := json.Unmarshal([]byte(config), v); err != nil {
+		return false, fmt.Errorf("failed to parse %s settings: %w", name, err)
+	}
+	return true, nil
+}
+
+// saveIntegrationConfig stores an integration's configuration as JSON
+func (db *DB) saveIntegrationConfig(name string, v interface{}) error {
+	config, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s settings: %w", name, err)
+	}
+
+	_, err = db.conn.Exec(`
+		INSERT INTO integration_settings (name, config, updated_at)
+		VALUES (?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(name) DO UPDATE SET config=excluded.config, updated_at=CURRENT_TIMESTAMP
+	`, name, string(config))
+	return err
+}
+
+// GetUptimeKumaSettings returns the Uptime Kuma integration settings, or defaults if unset
+func (db *DB) GetUptimeKumaSettings() (*models.UptimeKumaSettings, error) {
+	settings := models.DefaultUptimeKumaSettings()
+	if _, err := db.getIntegrationConfig(integrationUptimeKuma, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// SaveUptimeKumaSettings validates and saves the Uptime Kuma integration settings
+func (db *DB) SaveUptimeKumaSettings(settings *models.UptimeKumaSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	return db.saveIntegrationConfig(integrationUptimeKuma, settings)
+}
+
+// SaveUptimeStatuses records the monitor status of containers from one sync
+func (db *DB) SaveUptimeStatuses(statuses []models.UptimeMonitorStatus) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO uptime_checks (host_id, container_name, monitor_name, url, status, response_time_ms, checked_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, s := range statuses {
+		if _, err := stmt.Exec(s.HostID, s.ContainerName, s.MonitorName, s.URL, s.Status, s.ResponseTimeMS, s.CheckedAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetUptimeStatuses returns the latest monitor status of every monitored container with its
+// availability over the last 24 hours. Checks during maintenance don't count against availability.
+func (db *DB) GetUptimeStatuses() ([]models.UptimeMonitorStatus, error) {
+	since := time.Now().Add(-24 * time.Hour)
+
+	rows, err := db.conn.Query(`
+		SELECT u.host_id, h.name, u.container_name, u.monitor_name, u.url, u.status, u.response_time_ms, u.checked_at,
+			(SELECT 100.0 * SUM(CASE WHEN a.status = 'up' THEN 1 ELSE 0 END) / NULLIF(SUM(CASE WHEN a.status != 'maintenance' THEN 1 ELSE 0 END), 0)
+			 FROM uptime_checks a
+			 WHERE a.host_id = u.host_id AND a.container_name = u.container_name AND a.checked_at >= ?)
+		FROM uptime_checks u
+		INNER JOIN hosts h ON u.host_id = h.id
+		WHERE u.id IN (SELECT MAX(id) FROM uptime_checks GROUP BY host_id, container_name)
+		ORDER BY h.name, u.container_name
+	`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	statuses := make([]models.UptimeMonitorStatus, 0)
+	for rows.Next() {
+		var s models.UptimeMonitorStatus
+		var availability sql.NullFloat64
+		if err := rows.Scan(&s.HostID, &s.HostName, &s.ContainerName, &s.MonitorName, &s.URL, &s.Status,
+			&s.ResponseTimeMS, &s.CheckedAt, &availability); err != nil {
+			return nil, err
+		}
+		s.Availability = 100
+		if availability.Valid {
+			s.Availability = availability.Float64
+		}
+		statuses = append(statuses, s)
+	}
+
+	return statuses, rows.Err()
+}
+
+// GetProxmoxSettings returns the Proxmox VE integration settings, or defaults if unset
+func (db *DB) GetProxmoxSettings() (*models.ProxmoxSettings, error) {
+	settings := models.DefaultProxmoxSettings()
+	if _, err := db.getIntegrationConfig(integrationProxmox, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// SaveProxmoxSettings validates and saves the Proxmox VE integration settings
+func (db *DB) SaveProxmoxSettings(settings *models.ProxmoxSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	return db.saveIntegrationConfig(integrationProxmox, settings)
+}
+
+// GetTraefikSettings returns the Traefik integration settings; disabled if unset
+func (db *DB) GetTraefikSettings() (*models.TraefikSettings, error) {
+	settings := &models.TraefikSettings{}
+	if _, err := db.getIntegrationConfig(integrationTraefik, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// SaveTraefikSettings validates and saves the Traefik integration settings
+func (db *DB) SaveTraefikSettings(settings *models.TraefikSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	return db.saveIntegrationConfig(integrationTraefik, settings)
+}
+
+// ReplaceProxmoxGuests replaces the host to Proxmox guest mapping with the result of a sync
+func (db *DB) ReplaceProxmoxGuests(guests []models.ProxmoxGuest) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM proxmox_guests"); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO proxmox_guests (host_id, node, vmid, guest_type, name, status, cpus, max_memory, max_disk, uptime_seconds, matched_by, synced_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, g := range guests {
+		if _, err := stmt.Exec(g.HostID, g.Node, g.VMID, g.Type, g.Name, g.Status, g.CPUs, g.MaxMemory, g.MaxDisk,
+			g.UptimeSeconds, g.MatchedBy, g.SyncedAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetProxmoxGuests returns the Proxmox guest of every host mapped by the last sync
+func (db *DB) GetProxmoxGuests() ([]models.ProxmoxGuest, error) {
+	rows, err := db.conn.Query(`
+		SELECT p.host_id, h.name, p.node, p.vmid, p.guest_type, p.name, p.status, p.cpus, p.max_memory, p.max_disk,
+			p.uptime_seconds, p.matched_by, p.synced_at
+		FROM proxmox_guests p
+		INNER JOIN hosts h ON p.host_id = h.id
+		ORDER BY h.name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	guests := make([]models.ProxmoxGuest, 0)
+	for rows.Next() {
+		var g models.ProxmoxGuest
+		if err := rows.Scan(&g.HostID, &g.HostName, &g.Node, &g.VMID, &g.Type, &g.Name, &g.Status, &g.CPUs,
+			&g.MaxMemory, &g.MaxDisk, &g.UptimeSeconds, &g.MatchedBy, &g.SyncedAt); err != nil {
+			return nil, err
+		}
+		guests = append(guests, g)
+	}
+
+	return guests, rows.Err()
+}
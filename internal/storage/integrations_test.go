@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestUptimeKumaSettings(t *testing.T) {
+	db := setupTestDB(t)
+
+	settings, err := db.GetUptimeKumaSettings()
+	if err != nil {
+		t.Fatalf("Failed to get defaults: %v", err)
+	}
+	if settings.Enabled || settings.MonitorIntervalSeconds != 60 || settings.SyncIntervalMinutes != 5 {
+		t.Errorf("Unexpected defaults: %+v", settings)
+	}
+
+	settings.Enabled = true
+	settings.URL = "not a url"
+	if err := db.SaveUptimeKumaSettings(settings); err == nil {
+		t.Error("Expected invalid URL to be rejected")
+	}
+
+	settings.URL = "http://uptime-kuma:3001"
+	settings.Username = "admin"
+	settings.Password = "secret"
+	settings.APIKey = "uk1_key"
+	if err := db.SaveUptimeKumaSettings(settings); err != nil {
+		t.Fatalf("Failed to save settings: %v", err)
+	}
+
+	saved, err := db.GetUptimeKumaSettings()
+	if err != nil {
+		t.Fatalf("Failed to get settings: %v", err)
+	}
+	if *saved != *settings {
+		t.Errorf("Expected %+v, got %+v", settings, saved)
+	}
+}
+
+func TestUptimeStatuses(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: "agent://nas:9876", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	now := time.Now()
+	check := func(container, status string, at time.Time) models.UptimeMonitorStatus {
+		return models.UptimeMonitorStatus{
+			HostID:        hostID,
+			ContainerName: container,
+			MonitorName:   "census: nas/" + container,
+			URL:           "http://nas:80",
+			Status:        status,
+			CheckedAt:     at,
+		}
+	}
+
+	err = db.SaveUptimeStatuses([]models.UptimeMonitorStatus{
+		check("web", models.UptimeStatusDown, now.Add(-48*time.Hour)), // outside the availability window
+		check("web", models.UptimeStatusUp, now.Add(-3*time.Hour)),
+		check("web", models.UptimeStatusMaintenance, now.Add(-2*time.Hour)),
+		check("web", models.UptimeStatusDown, now.Add(-1*time.Hour)),
+		check("web", models.UptimeStatusUp, now),
+		check("api", models.UptimeStatusMaintenance, now),
+	})
+	if err != nil {
+		t.Fatalf("Failed to save statuses: %v", err)
+	}
+
+	statuses, err := db.GetUptimeStatuses()
+	if err != nil {
+		t.Fatalf("Failed to get statuses: %v", err)
+	}
+	if len(statuses) != 2 {
+		t.Fatalf("Expected latest status of 2 containers, got %+v", statuses)
+	}
+
+	byName := make(map[string]models.UptimeMonitorStatus)
+	for _, s := range statuses {
+		byName[s.ContainerName] = s
+	}
+	if web := byName["web"]; web.Status != models.UptimeStatusUp || web.HostName != "nas" || int(web.Availability+0.5) != 67 {
+		t.Errorf("Expected web up with 67%% availability, got %+v", web)
+	}
+	if api := byName["api"]; api.Availability != 100 {
+		t.Errorf("Expected maintenance not to count against availability, got %+v", api)
+	}
+}
+
+func TestProxmoxSettings(t *testing.T) {
+	db := setupTestDB(t)
+
+	settings, err := db.GetProxmoxSettings()
+	if err != nil {
+		t.Fatalf("Failed to get defaults: %v", err)
+	}
+	if settings.Enabled || settings.SyncIntervalMinutes != 15 {
+		t.Errorf("Unexpected defaults: %+v", settings)
+	}
+
+	settings.Enabled = true
+	settings.URL = "https://pve.local:8006"
+	settings.TokenID = "census"
+	settings.TokenSecret = "secret"
+	if err := db.SaveProxmoxSettings(settings); err == nil {
+		t.Error("Expected a token ID without user and realm to be rejected")
+	}
+
+	settings.TokenID = "census@pve!census"
+	if err := db.SaveProxmoxSettings(settings); err != nil {
+		t.Fatalf("Failed to save settings: %v", err)
+	}
+
+	saved, err := db.GetProxmoxSettings()
+	if err != nil {
+		t.Fatalf("Failed to get settings: %v", err)
+	}
+	if *saved != *settings {
+		t.Errorf("Expected %+v, got %+v", settings, saved)
+	}
+	if saved.Redacted().TokenSecret != models.MaskedSecret {
+		t.Error("Expected the token secret to be masked")
+	}
+}
+
+func TestTraefikSettings(t *testing.T) {
+	db := setupTestDB(t)
+
+	settings, err := db.GetTraefikSettings()
+	if err != nil {
+		t.Fatalf("Failed to get defaults: %v", err)
+	}
+	if settings.Enabled {
+		t.Errorf("Expected Traefik to be disabled by default, got %+v", settings)
+	}
+
+	settings.Enabled = true
+	settings.URL = "traefik:8080"
+	if err := db.SaveTraefikSettings(settings); err == nil {
+		t.Error("Expected a URL without scheme to be rejected")
+	}
+
+	settings.URL = "https://traefik.lan"
+	settings.Username = "admin"
+	settings.Password = "secret"
+	if err := db.SaveTraefikSettings(settings); err != nil {
+		t.Fatalf("Failed to save settings: %v", err)
+	}
+
+	saved, err := db.GetTraefikSettings()
+	if err != nil {
+		t.Fatalf("Failed to get settings: %v", err)
+	}
+	if *saved != *settings {
+		t.Errorf("Expected %+v, got %+v", settings, saved)
+	}
+	if saved.Redacted().Password != models.MaskedSecret {
+		t.Error("Expected the password to be masked")
+	}
+}
+
+func TestProxmoxGuests(t *testing.T) {
+	db := setupTestDB(t)
+
+	vmHost, err := db.AddHost(models.Host{Name: "docker-vm", Address: "agent://10.0.0.5:9876", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	nodeHost, err := db.AddHost(models.Host{Name: "pve1", Address: "tcp://10.0.0.2:2376", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	now := time.Now()
+	err = db.ReplaceProxmoxGuests([]models.ProxmoxGuest{
+		{HostID: vmHost, Node: "pve1", VMID: 101, Type: models.ProxmoxTypeQEMU, Name: "docker", Status: "running", CPUs: 4, MaxMemory: 8 << 30, MatchedBy: models.ProxmoxMatchIP, SyncedAt: now},
+		{HostID: nodeHost, Node: "pve1", Type: models.ProxmoxTypeNode, Name: "pve1", Status: "online", MatchedBy: models.ProxmoxMatchName, SyncedAt: now},
+	})
+	if err != nil {
+		t.Fatalf("Failed to save guests: %v", err)
+	}
+
+	// A later sync replaces the mapping
+	err = db.ReplaceProxmoxGuests([]models.ProxmoxGuest{
+		{HostID: vmHost, Node: "pve2", VMID: 101, Type: models.ProxmoxTypeQEMU, Name: "docker", Status: "running", CPUs: 4, MaxMemory: 8 << 30, MatchedBy: models.ProxmoxMatchIP, SyncedAt: now},
+	})
+	if err != nil {
+		t.Fatalf("Failed to replace guests: %v", err)
+	}
+
+	guests, err := db.GetProxmoxGuests()
+	if err != nil {
+		t.Fatalf("Failed to get guests: %v", err)
+	}
+	if len(guests) != 1 {
+		t.Fatalf("Expected 1 guest after the second sync, got %+v", guests)
+	}
+	if g := guests[0]; g.HostID != vmHost || g.HostName != "docker-vm" || g.Node != "pve2" || g.VMID != 101 || g.MaxMemory != 8<<30 {
+		t.Errorf("Unexpected guest: %+v", g)
+	}
+}
@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// hostScopedTables have a host_id column and belong to one host. Foreign keys cascade most of
+// them, but databases created before a table had its foreign key (and image_containers, which
+// never had one) keep rows of deleted hosts, so they are also deleted explicitly.
+var hostScopedTables = []string{
+	"containers",
+	"container_stats_aggregates",
+	"container_configs",
+	"container_baseline_stats",
+	"container_seasonal_baselines",
+	"notification_threshold_state",
+	"image_usage",
+	"image_layers_cache",
+	"image_containers",
+	"compliance_audits",
+	"uptime_checks",
+	"proxmox_guests",
+	"backup_runs",
+	"scan_results",
+	"plugin_results",
+	"container_renames",
+	"container_pins",
+	"notes",
+	"container_owners",
+	"host_heartbeats",
+	"host_downtimes",
+	"container_updates",
+	"daemon_events",
+	"docker_objects",
+	"agent_token_rotations",
+}
+
+// orphanCheck selects the orphaned rows of a table; the only parameter is the stale cutoff of
+// image_containers
+type orphanCheck struct {
+	table string
+	where string
+}
+
+// orphanChecks lists orphaned rows in deletion order (rows depending on others come first)
+func orphanChecks() []orphanCheck {
+	checks := make([]orphanCheck, 0, len(hostScopedTables)+7)
+	for _, table := range hostScopedTables {
+		where := `host_id NOT IN (SELECT id FROM hosts)`
+		if table == "image_containers" {
+			// Mappings of containers not seen for the stale period go too
+			where += ` OR last_seen < ?`
+		}
+		checks = append(checks, orphanCheck{table: table, where: where})
+	}
+	return append(checks,
+		// Per-container rows of containers that no longer have any scan history (rows of deleted
+		// hosts are counted above)
+		orphanCheck{"container_configs", `host_id IN (SELECT id FROM hosts) AND NOT EXISTS (SELECT 1 FROM containers c WHERE c.id = container_configs.container_id AND c.host_id = container_configs.host_id)`},
+		orphanCheck{"container_baseline_stats", `host_id IN (SELECT id FROM hosts) AND NOT EXISTS (SELECT 1 FROM containers c WHERE c.id = container_baseline_stats.container_id AND c.host_id = container_baseline_stats.host_id)`},
+		orphanCheck{"notification_threshold_state", `host_id IN (SELECT id FROM hosts) AND NOT EXISTS (SELECT 1 FROM containers c WHERE c.id = notification_threshold_state.container_id AND c.host_id = notification_threshold_state.host_id)`},
+		// Rules and silences for a specific host that was deleted
+		orphanCheck{"notification_rules", `host_id > 0 AND host_id NOT IN (SELECT id FROM hosts)`},
+		orphanCheck{"notification_silences", `host_id > 0 AND host_id NOT IN (SELECT id FROM hosts)`},
+		orphanCheck{"notification_rule_channels", `channel_id NOT IN (SELECT id FROM notification_channels) OR rule_id NOT IN (
+			SELECT id FROM notification_rules WHERE host_id IS NULL OR host_id <= 0 OR host_id IN (SELECT id FROM hosts))`},
+		// Vulnerabilities whose scan was deleted while foreign keys were off. Scans themselves
+		// are kept: they may have been pushed for images that don't run yet.
+		orphanCheck{"vulnerabilities", `image_id NOT IN (SELECT image_id FROM vulnerability_scans)`},
+	)
+}
+
+// DeleteHost deletes a host with everything recorded for it
+func (db *DB) DeleteHost(id int64) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range hostScopedTables {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE host_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete %s of host: %w", table, err)
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM hosts WHERE id = ?`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CollectOrphans counts, and unless dryRun deletes, rows left behind by deleted hosts and
+// containers: rows of hosts that no longer exist, per-container state of containers without
+// any scan history, image-container mappings not seen for staleDays, and vulnerabilities
+// without their scan. The notification log is kept as history.
+func (db *DB) CollectOrphans(staleDays int, dryRun bool) (*models.OrphanReport, error) {
+	report := &models.OrphanReport{
+		StaleDays: staleDays,
+		DryRun:    dryRun,
+		Orphans:   make(map[string]int64),
+	}
+	staleBefore := time.Now().Add(-time.Duration(staleDays) * 24 * time.Hour)
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for _, check := range orphanChecks() {
+		var args []interface{}
+		if check.table == "image_containers" {
+			args = append(args, staleBefore)
+		}
+
+		var count int64
+		if dryRun {
+			err = tx.QueryRow(`SELECT COUNT(*) FROM `+check.table+` WHERE `+check.where, args...).Scan(&count)
+		} else {
+			result, execErr := tx.Exec(`DELETE FROM `+check.table+` WHERE `+check.where, args...)
+			err = execErr
+			if err == nil {
+				count, err = result.RowsAffected()
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to collect orphaned %s: %w", check.table, err)
+		}
+		if count > 0 {
+			report.Orphans[check.table] += count
+			report.Total += count
+		}
+	}
+
+	if dryRun {
+		return report, nil
+	}
+	return report, tx.Commit()
+}
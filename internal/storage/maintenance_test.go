@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestDeleteHostAndCollectOrphans(t *testing.T) {
+	db := setupTestDB(t)
+
+	keep, err := db.AddHost(models.Host{Name: "keep", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	gone, err := db.AddHost(models.Host{Name: "gone", Address: "tcp://gone:2376", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	now := time.Now()
+	for _, hostID := range []int64{keep, gone} {
+		if err := db.SaveContainers([]models.Container{{
+			ID: "c1", Name: "web", Image: "nginx", ImageID: "sha256:1", State: "running", Status: "Up",
+			Created: now, HostID: hostID, HostName: "h", ScannedAt: now,
+		}}); err != nil {
+			t.Fatalf("SaveContainers failed: %v", err)
+		}
+		if err := db.UpdateImageContainer("sha256:1", "c1", int(hostID)); err != nil {
+			t.Fatalf("UpdateImageContainer failed: %v", err)
+		}
+	}
+
+	// Mappings have no foreign key; DeleteHost removes them explicitly
+	if err := db.DeleteHost(gone); err != nil {
+		t.Fatalf("DeleteHost failed: %v", err)
+	}
+	var count int
+	db.conn.QueryRow(`SELECT COUNT(*) FROM image_containers WHERE host_id = ?`, gone).Scan(&count)
+	if count != 0 {
+		t.Errorf("Expected the deleted host's image mappings to be removed, got %d", count)
+	}
+
+	// Leftovers of an old deletion and a mapping not seen for 60 days
+	if _, err := db.conn.Exec(`INSERT INTO image_containers (image_id, container_id, host_id, last_seen) VALUES ('sha256:2', 'c2', 999, ?), ('sha256:3', 'c3', ?, ?)`,
+		now, keep, now.Add(-60*24*time.Hour)); err != nil {
+		t.Fatalf("Failed to insert mappings: %v", err)
+	}
+
+	report, err := db.CollectOrphans(30, true)
+	if err != nil {
+		t.Fatalf("CollectOrphans failed: %v", err)
+	}
+	if report.Total != 2 || report.Orphans["image_containers"] != 2 {
+		t.Fatalf("Expected 2 orphaned mappings, got %+v", report)
+	}
+	db.conn.QueryRow(`SELECT COUNT(*) FROM image_containers`).Scan(&count)
+	if count != 3 {
+		t.Errorf("Expected a dry run to delete nothing, got %d mappings left", count)
+	}
+
+	if report, err = db.CollectOrphans(30, false); err != nil || report.Total != 2 {
+		t.Fatalf("Expected 2 rows removed, got %+v (%v)", report, err)
+	}
+	db.conn.QueryRow(`SELECT COUNT(*) FROM image_containers`).Scan(&count)
+	if count != 1 {
+		t.Errorf("Expected only the live mapping to remain, got %d", count)
+	}
+	if report, _ = db.CollectOrphans(30, true); report.Total != 0 {
+		t.Errorf("Expected nothing left to collect, got %+v", report)
+	}
+}
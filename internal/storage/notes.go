@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// SaveNotes stores the notes of a container, or of the host itself when containerName is
+// empty. Empty notes delete them.
+func (db *DB) SaveNotes(hostID int64, containerName string, notes models.Notes) error {
+	if notes.Empty() {
+		return db.DeleteNotes(hostID, containerName)
+	}
+
+	fields, err := json.Marshal(notes.Fields)
+	if err != nil {
+		return err
+	}
+	_, err = db.conn.Exec(`
+		INSERT INTO notes (host_id, container_name, text, fields, updated_at, updated_by)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(host_id, container_name) DO UPDATE SET
+			text = excluded.text, fields = excluded.fields, updated_at = excluded.updated_at, updated_by = excluded.updated_by
+	`, hostID, containerName, notes.Text, string(fields), notes.UpdatedAt, notes.UpdatedBy)
+	return err
+}
+
+// DeleteNotes removes the notes of a container, or of the host when containerName is empty;
+// deleting notes that don't exist is not an error
+func (db *DB) DeleteNotes(hostID int64, containerName string) error {
+	_, err := db.conn.Exec(`DELETE FROM notes WHERE host_id = ? AND container_name = ?`, hostID, containerName)
+	return err
+}
+
+// GetNotes returns the notes of a container, or of the host when containerName is empty; nil
+// when there are none
+func (db *DB) GetNotes(hostID int64, containerName string) (*models.Notes, error) {
+	var notes models.Notes
+	var fields string
+	err := db.conn.QueryRow(`SELECT text, fields, updated_at, updated_by FROM notes WHERE host_id = ? AND container_name = ?`,
+		hostID, containerName).Scan(&notes.Text, &fields, &notes.UpdatedAt, &notes.UpdatedBy)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(fields), &notes.Fields); err != nil {
+		return nil, fmt.Errorf("failed to parse note fields: %w", err)
+	}
+	return &notes, nil
+}
+
+// GetAllNotes returns the notes of every host and container, by host and container name with
+// each host's own notes first
+func (db *DB) GetAllNotes() ([]models.ContainerNotes, error) {
+	rows, err := db.conn.Query(`
+		SELECT n.host_id, COALESCE(h.name, ''), n.container_name, n.text, n.fields, n.updated_at, n.updated_by
+		FROM notes n
+		LEFT JOIN hosts h ON h.id = n.host_id
+		ORDER BY n.host_id, n.container_name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	all := make([]models.ContainerNotes, 0)
+	for rows.Next() {
+		var n models.ContainerNotes
+		var fields string
+		if err := rows.Scan(&n.HostID, &n.HostName, &n.ContainerName, &n.Text, &fields, &n.UpdatedAt, &n.UpdatedBy); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(fields), &n.Fields); err != nil {
+			return nil, fmt.Errorf("failed to parse note fields: %w", err)
+		}
+		all = append(all, n)
+	}
+	return all, rows.Err()
+}
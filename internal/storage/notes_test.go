@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestNotes(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	now := time.Now().Truncate(time.Second)
+	if err := db.SaveNotes(hostID, "", models.Notes{Text: "UPS in the closet", UpdatedAt: now}); err != nil {
+		t.Fatalf("Failed to save host notes: %v", err)
+	}
+	notes := models.Notes{
+		Text:      "Don't restart during backups (02:00-04:00)",
+		Fields:    map[string]string{"credentials": "vault:kv/postgres"},
+		UpdatedAt: now,
+		UpdatedBy: "admin",
+	}
+	if err := db.SaveNotes(hostID, "postgres", notes); err != nil {
+		t.Fatalf("Failed to save container notes: %v", err)
+	}
+
+	saved, err := db.GetNotes(hostID, "postgres")
+	if err != nil || saved == nil {
+		t.Fatalf("GetNotes failed: %v", err)
+	}
+	if saved.Text != notes.Text || saved.Fields["credentials"] != "vault:kv/postgres" || saved.UpdatedBy != "admin" {
+		t.Errorf("Unexpected notes %+v", saved)
+	}
+
+	all, err := db.GetAllNotes()
+	if err != nil {
+		t.Fatalf("GetAllNotes failed: %v", err)
+	}
+	if len(all) != 2 || all[0].ContainerName != "" || all[0].HostName != "nas" || all[1].ContainerName != "postgres" {
+		t.Errorf("Expected the host's notes before its containers', got %+v", all)
+	}
+
+	// Empty notes delete them
+	if err := db.SaveNotes(hostID, "postgres", models.Notes{UpdatedAt: now}); err != nil {
+		t.Fatalf("Failed to clear notes: %v", err)
+	}
+	if saved, _ = db.GetNotes(hostID, "postgres"); saved != nil {
+		t.Errorf("Expected cleared notes to be deleted, got %+v", saved)
+	}
+}
@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// RecordNotificationOutcome counts one notification of a rule to a channel in the hourly
+// counters (see models.NotificationOutcomeSent and friends)
+func (db *DB) RecordNotificationOutcome(ruleID, channelID int64, outcome string, at time.Time) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO notification_stats (bucket, rule_id, channel_id, outcome, count)
+		VALUES (?, ?, ?, ?, 1)
+		ON CONFLICT (bucket, rule_id, channel_id, outcome) DO UPDATE SET count = count + 1
+	`, at.UTC().Truncate(time.Hour), ruleID, channelID, outcome)
+	return err
+}
+
+// GetNotificationStatCounts returns the hourly counters from since on, oldest first
+func (db *DB) GetNotificationStatCounts(since time.Time) ([]models.NotificationStatCount, error) {
+	rows, err := db.conn.Query(`
+		SELECT bucket, rule_id, channel_id, outcome, count
+		FROM notification_stats
+		WHERE bucket >= ?
+		ORDER BY bucket
+	`, since.UTC().Truncate(time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []models.NotificationStatCount
+	for rows.Next() {
+		var c models.NotificationStatCount
+		if err := rows.Scan(&c.Bucket, &c.RuleID, &c.ChannelID, &c.Outcome, &c.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// CleanupNotificationStats removes the counters older than models.NotificationStatsRetentionDays
+func (db *DB) CleanupNotificationStats() error {
+	cutoff := time.Now().UTC().AddDate(0, 0, -models.NotificationStatsRetentionDays)
+	_, err := db.conn.Exec(`DELETE FROM notification_stats WHERE bucket < ?`, cutoff)
+	return err
+}
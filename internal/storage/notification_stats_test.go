@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestNotificationStats(t *testing.T) {
+	db := setupTestDB(t)
+
+	now := time.Now()
+	for _, outcome := range []string{models.NotificationOutcomeSent, models.NotificationOutcomeSent, models.NotificationOutcomeFailed} {
+		if err := db.RecordNotificationOutcome(1, 2, outcome, now); err != nil {
+			t.Fatalf("RecordNotificationOutcome failed: %v", err)
+		}
+	}
+	if err := db.RecordNotificationOutcome(1, 2, models.NotificationOutcomeSent, now.Add(-2*time.Hour)); err != nil {
+		t.Fatalf("RecordNotificationOutcome failed: %v", err)
+	}
+	old := now.AddDate(0, 0, -models.NotificationStatsRetentionDays-1)
+	if err := db.RecordNotificationOutcome(1, 2, models.NotificationOutcomeSuppressed, old); err != nil {
+		t.Fatalf("RecordNotificationOutcome failed: %v", err)
+	}
+
+	counts, err := db.GetNotificationStatCounts(now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("GetNotificationStatCounts failed: %v", err)
+	}
+	got := make(map[string]int)
+	for _, c := range counts {
+		got[c.Outcome] += c.Count
+	}
+	if len(counts) != 2 || got[models.NotificationOutcomeSent] != 2 || got[models.NotificationOutcomeFailed] != 1 {
+		t.Errorf("Expected the outcomes of this hour counted together, got %+v", counts)
+	}
+
+	if err := db.CleanupNotificationStats(); err != nil {
+		t.Fatalf("CleanupNotificationStats failed: %v", err)
+	}
+	counts, err = db.GetNotificationStatCounts(old.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("GetNotificationStatCounts failed: %v", err)
+	}
+	if len(counts) != 3 {
+		t.Errorf("Expected only the expired counter removed, got %+v", counts)
+	}
+}
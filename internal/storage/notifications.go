@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/container-census/container-census/internal/models"
@@ -14,7 +15,7 @@ import (
 // GetNotificationChannels retrieves all notification channels
 func (db *DB) GetNotificationChannels() ([]models.NotificationChannel, error) {
 	rows, err := db.conn.Query(`
-		SELECT id, name, type, config, enabled, created_at, updated_at
+		SELECT id, name, type, config, enabled, min_severity, quiet_hours, tenant_id, created_at, updated_at
 		FROM notification_channels
 		ORDER BY name
 	`)
@@ -27,8 +28,9 @@ func (db *DB) GetNotificationChannels() ([]models.NotificationChannel, error) {
 	for rows.Next() {
 		var ch models.NotificationChannel
 		var configJSON string
+		var quietHoursJSON sql.NullString
 
-		err := rows.Scan(&ch.ID, &ch.Name, &ch.Type, &configJSON, &ch.Enabled, &ch.CreatedAt, &ch.UpdatedAt)
+		err := rows.Scan(&ch.ID, &ch.Name, &ch.Type, &configJSON, &ch.Enabled, &ch.MinSeverity, &quietHoursJSON, &ch.TenantID, &ch.CreatedAt, &ch.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -36,6 +38,9 @@ func (db *DB) GetNotificationChannels() ([]models.NotificationChannel, error) {
 		if err := json.Unmarshal([]byte(configJSON), &ch.Config); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal channel config: %w", err)
 		}
+		if err := unmarshalQuietHours(quietHoursJSON, &ch); err != nil {
+			return nil, err
+		}
 
 		channels = append(channels, ch)
 	}
@@ -47,12 +52,13 @@ func (db *DB) GetNotificationChannels() ([]models.NotificationChannel, error) {
 func (db *DB) GetNotificationChannel(id int64) (*models.NotificationChannel, error) {
 	var ch models.NotificationChannel
 	var configJSON string
+	var quietHoursJSON sql.NullString
 
 	err := db.conn.QueryRow(`
-		SELECT id, name, type, config, enabled, created_at, updated_at
+		SELECT id, name, type, config, enabled, min_severity, quiet_hours, tenant_id, created_at, updated_at
 		FROM notification_channels
 		WHERE id = ?
-	`, id).Scan(&ch.ID, &ch.Name, &ch.Type, &configJSON, &ch.Enabled, &ch.CreatedAt, &ch.UpdatedAt)
+	`, id).Scan(&ch.ID, &ch.Name, &ch.Type, &configJSON, &ch.Enabled, &ch.MinSeverity, &quietHoursJSON, &ch.TenantID, &ch.CreatedAt, &ch.UpdatedAt)
 
 	if err != nil {
 		return nil, err
@@ -61,6 +67,9 @@ func (db *DB) GetNotificationChannel(id int64) (*models.NotificationChannel, err
 	if err := json.Unmarshal([]byte(configJSON), &ch.Config); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal channel config: %w", err)
 	}
+	if err := unmarshalQuietHours(quietHoursJSON, &ch); err != nil {
+		return nil, err
+	}
 
 	return &ch, nil
 }
@@ -72,29 +81,51 @@ func (db *DB) SaveNotificationChannel(ch *models.NotificationChannel) error {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
+	var quietHoursJSON sql.NullString
+	if ch.QuietHours != nil {
+		data, err := json.Marshal(ch.QuietHours)
+		if err != nil {
+			return fmt.Errorf("failed to marshal quiet hours: %w", err)
+		}
+		quietHoursJSON = sql.NullString{String: string(data), Valid: true}
+	}
+
 	if ch.ID == 0 {
 		// Insert
 		result, err := db.conn.Exec(`
-			INSERT INTO notification_channels (name, type, config, enabled)
-			VALUES (?, ?, ?, ?)
-		`, ch.Name, ch.Type, string(configJSON), ch.Enabled)
+			INSERT INTO notification_channels (name, type, config, enabled, min_severity, quiet_hours, tenant_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, ch.Name, ch.Type, string(configJSON), ch.Enabled, ch.MinSeverity, quietHoursJSON, ch.TenantID)
 		if err != nil {
 			return err
 		}
 		ch.ID, _ = result.LastInsertId()
 	} else {
-		// Update
+		// Update (the owning tenant never changes)
 		_, err := db.conn.Exec(`
 			UPDATE notification_channels
-			SET name = ?, type = ?, config = ?, enabled = ?, updated_at = CURRENT_TIMESTAMP
+			SET name = ?, type = ?, config = ?, enabled = ?, min_severity = ?, quiet_hours = ?, updated_at = CURRENT_TIMESTAMP
 			WHERE id = ?
-		`, ch.Name, ch.Type, string(configJSON), ch.Enabled, ch.ID)
+		`, ch.Name, ch.Type, string(configJSON), ch.Enabled, ch.MinSeverity, quietHoursJSON, ch.ID)
 		return err
 	}
 
 	return nil
 }
 
+// unmarshalQuietHours decodes a channel's stored quiet hours, if any
+func unmarshalQuietHours(data sql.NullString, ch *models.NotificationChannel) error {
+	if !data.Valid || data.String == "" {
+		return nil
+	}
+	var quietHours models.QuietHours
+	if err := json.Unmarshal([]byte(data.String), &quietHours); err != nil {
+		return fmt.Errorf("failed to unmarshal channel quiet hours: %w", err)
+	}
+	ch.QuietHours = &quietHours
+	return nil
+}
+
 // DeleteNotificationChannel deletes a notification channel
 func (db *DB) DeleteNotificationChannel(id int64) error {
 	_, err := db.conn.Exec("DELETE FROM notification_channels WHERE id = ?", id)
@@ -104,9 +135,9 @@ func (db *DB) DeleteNotificationChannel(id int64) error {
 // GetNotificationRules retrieves notification rules
 func (db *DB) GetNotificationRules(enabledOnly bool) ([]models.NotificationRule, error) {
 	query := `
-		SELECT r.id, r.name, r.enabled, r.event_types, r.host_id, r.container_pattern, r.image_pattern,
-		       r.cpu_threshold, r.memory_threshold, r.threshold_duration_seconds, r.cooldown_seconds,
-		       r.created_at, r.updated_at
+		SELECT r.id, r.name, r.enabled, r.event_types, r.host_id, r.container_pattern, r.image_pattern, r.owner_pattern,
+		       r.cpu_threshold, r.memory_threshold, r.leak_growth_threshold, COALESCE(r.leak_min_days, 0),
+		       r.threshold_duration_seconds, r.cooldown_seconds, r.tenant_id, r.created_at, r.updated_at
 		FROM notification_rules r
 	`
 	if enabledOnly {
@@ -126,12 +157,13 @@ func (db *DB) GetNotificationRules(enabledOnly bool) ([]models.NotificationRule,
 		var eventTypesJSON string
 		var hostID sql.NullInt64
 		var containerPattern, imagePattern sql.NullString
-		var cpuThreshold, memoryThreshold sql.NullFloat64
+		var cpuThreshold, memoryThreshold, leakGrowthThreshold sql.NullFloat64
 
 		err := rows.Scan(
 			&rule.ID, &rule.Name, &rule.Enabled, &eventTypesJSON, &hostID,
-			&containerPattern, &imagePattern, &cpuThreshold, &memoryThreshold,
-			&rule.ThresholdDurationSeconds, &rule.CooldownSeconds,
+			&containerPattern, &imagePattern, &rule.OwnerPattern, &cpuThreshold, &memoryThreshold,
+			&leakGrowthThreshold, &rule.LeakMinDays,
+			&rule.ThresholdDurationSeconds, &rule.CooldownSeconds, &rule.TenantID,
 			&rule.CreatedAt, &rule.UpdatedAt,
 		)
 		if err != nil {
@@ -160,6 +192,10 @@ func (db *DB) GetNotificationRules(enabledOnly bool) ([]models.NotificationRule,
 			threshold := memoryThreshold.Float64
 			rule.MemoryThreshold = &threshold
 		}
+		if leakGrowthThreshold.Valid {
+			threshold := leakGrowthThreshold.Float64
+			rule.LeakGrowthThreshold = &threshold
+		}
 
 		// Get associated channels
 		channelIDs, err := db.GetRuleChannels(rule.ID)
@@ -213,27 +249,29 @@ func (db *DB) SaveNotificationRule(rule *models.NotificationRule) error {
 		// Insert
 		result, err := tx.Exec(`
 			INSERT INTO notification_rules
-			(name, enabled, event_types, host_id, container_pattern, image_pattern,
-			 cpu_threshold, memory_threshold, threshold_duration_seconds, cooldown_seconds)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			(name, enabled, event_types, host_id, container_pattern, image_pattern, owner_pattern,
+			 cpu_threshold, memory_threshold, leak_growth_threshold, leak_min_days,
+			 threshold_duration_seconds, cooldown_seconds, tenant_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		`, rule.Name, rule.Enabled, string(eventTypesJSON), rule.HostID,
-			rule.ContainerPattern, rule.ImagePattern, rule.CPUThreshold, rule.MemoryThreshold,
-			rule.ThresholdDurationSeconds, rule.CooldownSeconds)
+			rule.ContainerPattern, rule.ImagePattern, rule.OwnerPattern, rule.CPUThreshold, rule.MemoryThreshold,
+			rule.LeakGrowthThreshold, rule.LeakMinDays, rule.ThresholdDurationSeconds, rule.CooldownSeconds, rule.TenantID)
 		if err != nil {
 			return err
 		}
 		rule.ID, _ = result.LastInsertId()
 	} else {
-		// Update
+		// Update (the owning tenant never changes)
 		_, err := tx.Exec(`
 			UPDATE notification_rules
 			SET name = ?, enabled = ?, event_types = ?, host_id = ?,
-			    container_pattern = ?, image_pattern = ?, cpu_threshold = ?, memory_threshold = ?,
+			    container_pattern = ?, image_pattern = ?, owner_pattern = ?, cpu_threshold = ?, memory_threshold = ?,
+			    leak_growth_threshold = ?, leak_min_days = ?,
 			    threshold_duration_seconds = ?, cooldown_seconds = ?, updated_at = CURRENT_TIMESTAMP
 			WHERE id = ?
 		`, rule.Name, rule.Enabled, string(eventTypesJSON), rule.HostID,
-			rule.ContainerPattern, rule.ImagePattern, rule.CPUThreshold, rule.MemoryThreshold,
-			rule.ThresholdDurationSeconds, rule.CooldownSeconds, rule.ID)
+			rule.ContainerPattern, rule.ImagePattern, rule.OwnerPattern, rule.CPUThreshold, rule.MemoryThreshold,
+			rule.LeakGrowthThreshold, rule.LeakMinDays, rule.ThresholdDurationSeconds, rule.CooldownSeconds, rule.ID)
 		if err != nil {
 			return err
 		}
@@ -265,12 +303,17 @@ func (db *DB) DeleteNotificationRule(id int64) error {
 
 // SaveNotificationLog saves a notification log entry
 func (db *DB) SaveNotificationLog(log models.NotificationLog) error {
+	return db.InsertNotificationLog(&log)
+}
+
+// InsertNotificationLog saves a notification log entry and sets its ID
+func (db *DB) InsertNotificationLog(log *models.NotificationLog) error {
 	metadataJSON, err := json.Marshal(log.Metadata)
 	if err != nil {
 		metadataJSON = []byte("{}")
 	}
 
-	_, err = db.conn.Exec(`
+	result, err := db.conn.Exec(`
 		INSERT INTO notification_log
 		(rule_id, channel_id, event_type, container_id, container_name, host_id, host_name,
 		 message, metadata, sent_at, success, error, read)
@@ -278,23 +321,29 @@ func (db *DB) SaveNotificationLog(log models.NotificationLog) error {
 	`, log.RuleID, log.ChannelID, log.EventType, log.ContainerID, log.ContainerName,
 		log.HostID, log.HostName, log.Message, string(metadataJSON), log.SentAt,
 		log.Success, log.Error, log.Read)
+	if err != nil {
+		return err
+	}
 
-	return err
+	log.ID, _ = result.LastInsertId()
+	return nil
 }
 
 // GetNotificationLogs retrieves notification logs
 func (db *DB) GetNotificationLogs(limit int, unreadOnly bool) ([]models.NotificationLog, error) {
+	return db.QueryNotificationLogs(models.NotificationLogFilter{UnreadOnly: unreadOnly}, limit)
+}
+
+// QueryNotificationLogs retrieves the most recent notification logs matching a filter
+func (db *DB) QueryNotificationLogs(filter models.NotificationLogFilter, limit int) ([]models.NotificationLog, error) {
+	where, args := notificationFilterClause(filter)
 	query := `
 		SELECT l.id, l.rule_id, l.channel_id, l.event_type, l.container_id, l.container_name,
 		       l.host_id, l.host_name, l.message, l.metadata, l.sent_at, l.success, l.error, l.read
 		FROM notification_log l
-	`
-	if unreadOnly {
-		query += " WHERE l.read = 0"
-	}
-	query += " ORDER BY l.sent_at DESC LIMIT ?"
+	` + where + " ORDER BY l.sent_at DESC LIMIT ?"
 
-	rows, err := db.conn.Query(query, limit)
+	rows, err := db.conn.Query(query, append(args, limit)...)
 	if err != nil {
 		return nil, err
 	}
@@ -352,6 +401,120 @@ func (db *DB) GetNotificationLogs(limit int, unreadOnly bool) ([]models.Notifica
 	return logs, rows.Err()
 }
 
+// GetNotificationGroups summarizes the notification log by container or event type, most recent
+// group first, with the total and unread count and the latest message of each group
+func (db *DB) GetNotificationGroups(groupBy string, filter models.NotificationLogFilter) ([]models.NotificationGroup, error) {
+	var columns string
+	switch groupBy {
+	case models.NotificationGroupByContainer:
+		columns = "COALESCE(l.host_id, 0), COALESCE(l.container_name, '')"
+	case models.NotificationGroupByEventType:
+		columns = "l.event_type"
+	default:
+		return nil, fmt.Errorf("unknown grouping: %s", groupBy)
+	}
+
+	// With a single MAX() aggregate, SQLite takes the bare host_name and message columns from the latest row
+	where, args := notificationFilterClause(filter)
+	rows, err := db.conn.Query(`
+		SELECT `+columns+`, COALESCE(l.host_name, ''), l.message,
+		       COUNT(*), SUM(CASE WHEN l.read = 0 THEN 1 ELSE 0 END), MAX(l.sent_at) AS latest
+		FROM notification_log l
+	`+where+`
+		GROUP BY `+columns+`
+		ORDER BY latest DESC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := make([]models.NotificationGroup, 0)
+	for rows.Next() {
+		var g models.NotificationGroup
+		var latest string
+		dest := []interface{}{&g.HostName, &g.LatestMessage, &g.Count, &g.UnreadCount, &latest}
+		if groupBy == models.NotificationGroupByContainer {
+			dest = append([]interface{}{&g.HostID, &g.ContainerName}, dest...)
+		} else {
+			dest = append([]interface{}{&g.EventType}, dest...)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		if groupBy == models.NotificationGroupByEventType {
+			// Host names only describe container groups
+			g.HostName = ""
+		}
+		if g.LatestAt, err = parseTimestamp(latest); err != nil {
+			return nil, fmt.Errorf("failed to parse notification time %q: %w", latest, err)
+		}
+		groups = append(groups, g)
+	}
+
+	return groups, rows.Err()
+}
+
+// MarkNotificationsRead marks every unread notification matching a filter as read
+func (db *DB) MarkNotificationsRead(filter models.NotificationLogFilter) (int64, error) {
+	filter.UnreadOnly = true
+	where, args := notificationFilterClause(filter)
+	result, err := db.conn.Exec("UPDATE notification_log AS l SET read = 1"+where, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DeleteNotifications deletes every notification matching a filter
+func (db *DB) DeleteNotifications(filter models.NotificationLogFilter) (int64, error) {
+	where, args := notificationFilterClause(filter)
+	result, err := db.conn.Exec("DELETE FROM notification_log AS l"+where, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// notificationFilterClause builds the WHERE clause (on alias l) for a notification log filter
+func notificationFilterClause(filter models.NotificationLogFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.EventType != "" {
+		conditions = append(conditions, "l.event_type = ?")
+		args = append(args, filter.EventType)
+	}
+	if filter.HostID != 0 {
+		conditions = append(conditions, "l.host_id = ?")
+		args = append(args, filter.HostID)
+	}
+	if filter.ContainerName != "" {
+		conditions = append(conditions, "l.container_name = ?")
+		args = append(args, filter.ContainerName)
+	}
+	if filter.UnreadOnly {
+		conditions = append(conditions, "l.read = 0")
+	}
+	if filter.TenantID != 0 {
+		conditions = append(conditions, "l.host_id IN (SELECT id FROM hosts WHERE tenant_id = ?)")
+		args = append(args, filter.TenantID)
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// CountNotifications returns the number of notifications matching a filter
+func (db *DB) CountNotifications(filter models.NotificationLogFilter) (int, error) {
+	where, args := notificationFilterClause(filter)
+	var count int
+	err := db.conn.QueryRow("SELECT COUNT(*) FROM notification_log l"+where, args...).Scan(&count)
+	return count, err
+}
+
 // MarkNotificationRead marks a notification as read
 func (db *DB) MarkNotificationRead(id int64) error {
 	_, err := db.conn.Exec("UPDATE notification_log SET read = 1 WHERE id = ?", id)
@@ -371,10 +534,15 @@ func (db *DB) GetUnreadNotificationCount() (int, error) {
 	return count, err
 }
 
-// CleanupOldNotifications removes notifications older than 7 days or beyond the 100 most recent
+// CleanupOldNotifications removes notifications older than the configured retention (default 7 days)
+// unless they are among the most recent entries kept regardless of age (default 100)
 func (db *DB) CleanupOldNotifications() error {
-	// Keep last 100 notifications OR notifications from last 7 days, whichever is larger
-	// This means: delete if (older than 7 days) AND (beyond the 100 most recent)
+	days, count := models.DefaultNotificationRetentionDays, models.DefaultNotificationRetentionCount
+	if settings, err := db.LoadSystemSettings(); err == nil {
+		days, count = settings.Notification.RetentionDays(), settings.Notification.RetentionCount()
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -days)
 
 	// Get total count first
 	var totalCount int
@@ -383,25 +551,22 @@ func (db *DB) CleanupOldNotifications() error {
 		return err
 	}
 
-	// If we have 100 or fewer, only delete those older than 7 days
-	if totalCount <= 100 {
-		_, err := db.conn.Exec(`
-			DELETE FROM notification_log
-			WHERE sent_at < datetime('now', '-7 days')
-		`)
+	// If we're within the count, only delete those older than the retention
+	if totalCount <= count {
+		_, err := db.conn.Exec(`DELETE FROM notification_log WHERE sent_at < ?`, cutoff)
 		return err
 	}
 
-	// If we have more than 100, delete records that are BOTH old AND beyond top 100
+	// Otherwise delete records that are BOTH old AND beyond the most recent ones
 	_, err = db.conn.Exec(`
 		DELETE FROM notification_log
-		WHERE sent_at < datetime('now', '-7 days')
+		WHERE sent_at < ?
 		  AND id NOT IN (
 			SELECT id FROM notification_log
 			ORDER BY sent_at DESC
-			LIMIT 100
+			LIMIT ?
 		  )
-	`)
+	`, cutoff, count)
 	return err
 }
 
@@ -558,6 +723,147 @@ func (db *DB) SaveContainerBaseline(baseline *models.ContainerBaselineStats) err
 	return err
 }
 
+// GetHourlyStatsSince returns hourly stats aggregates recorded since the given time
+func (db *DB) GetHourlyStatsSince(since time.Time) ([]models.HourlyContainerStats, error) {
+	rows, err := db.conn.Query(`
+		SELECT container_name, host_id, timestamp_hour, avg_cpu_percent, avg_memory_usage
+		FROM container_stats_aggregates
+		WHERE timestamp_hour >= ?
+		ORDER BY timestamp_hour
+	`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []models.HourlyContainerStats
+	for rows.Next() {
+		var s models.HourlyContainerStats
+		var avgCPU, avgMemory sql.NullFloat64
+		if err := rows.Scan(&s.ContainerName, &s.HostID, &s.Hour, &avgCPU, &avgMemory); err != nil {
+			return nil, err
+		}
+		s.AvgCPUPercent = avgCPU.Float64
+		s.AvgMemoryUsage = int64(avgMemory.Float64)
+		stats = append(stats, s)
+	}
+
+	return stats, rows.Err()
+}
+
+// GetDailyMemoryUsage returns per-container daily average memory usage for days in [since, until),
+// ordered by container and day. Containers are grouped by name so recreated containers keep their history.
+func (db *DB) GetDailyMemoryUsage(since, until time.Time) ([]models.DailyMemoryUsage, error) {
+	rows, err := db.conn.Query(`
+		SELECT MAX(container_id), container_name, host_id, MAX(host_name),
+		       date(timestamp_hour) AS day, AVG(avg_memory_usage)
+		FROM container_stats_aggregates
+		WHERE timestamp_hour >= ? AND timestamp_hour < ? AND avg_memory_usage IS NOT NULL
+		GROUP BY container_name, host_id, day
+		ORDER BY host_id, container_name, day
+	`, since.UTC(), until.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usage []models.DailyMemoryUsage
+	for rows.Next() {
+		var u models.DailyMemoryUsage
+		var avgMemory sql.NullFloat64
+		if err := rows.Scan(&u.ContainerID, &u.ContainerName, &u.HostID, &u.HostName, &u.Day, &avgMemory); err != nil {
+			return nil, err
+		}
+		u.AvgMemoryUsage = int64(avgMemory.Float64)
+		usage = append(usage, u)
+	}
+
+	return usage, rows.Err()
+}
+
+// ReplaceSeasonalBaselines replaces all seasonal baselines with the given set
+func (db *DB) ReplaceSeasonalBaselines(baselines []models.SeasonalBaseline) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM container_seasonal_baselines`); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO container_seasonal_baselines
+		(container_name, host_id, day_of_week, hour_of_day, avg_cpu_percent, stddev_cpu_percent,
+		 avg_memory_usage, stddev_memory_usage, sample_count, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, b := range baselines {
+		if _, err := stmt.Exec(b.ContainerName, b.HostID, b.DayOfWeek, b.HourOfDay,
+			b.AvgCPUPercent, b.StdDevCPUPercent, b.AvgMemoryUsage, b.StdDevMemoryUsage,
+			b.SampleCount, b.UpdatedAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetSeasonalBaselines returns the seasonal baselines for a container, ordered by slot
+func (db *DB) GetSeasonalBaselines(containerName string, hostID int64) ([]models.SeasonalBaseline, error) {
+	rows, err := db.conn.Query(`
+		SELECT container_name, host_id, day_of_week, hour_of_day, avg_cpu_percent, stddev_cpu_percent,
+		       avg_memory_usage, stddev_memory_usage, sample_count, updated_at
+		FROM container_seasonal_baselines
+		WHERE container_name = ? AND host_id = ?
+		ORDER BY day_of_week, hour_of_day
+	`, containerName, hostID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	baselines := make([]models.SeasonalBaseline, 0)
+	for rows.Next() {
+		var b models.SeasonalBaseline
+		if err := rows.Scan(&b.ContainerName, &b.HostID, &b.DayOfWeek, &b.HourOfDay,
+			&b.AvgCPUPercent, &b.StdDevCPUPercent, &b.AvgMemoryUsage, &b.StdDevMemoryUsage,
+			&b.SampleCount, &b.UpdatedAt); err != nil {
+			return nil, err
+		}
+		baselines = append(baselines, b)
+	}
+
+	return baselines, rows.Err()
+}
+
+// GetSeasonalBaseline returns the baseline for one slot, or nil if none exists
+func (db *DB) GetSeasonalBaseline(containerName string, hostID int64, dayOfWeek, hourOfDay int) (*models.SeasonalBaseline, error) {
+	var b models.SeasonalBaseline
+	err := db.conn.QueryRow(`
+		SELECT container_name, host_id, day_of_week, hour_of_day, avg_cpu_percent, stddev_cpu_percent,
+		       avg_memory_usage, stddev_memory_usage, sample_count, updated_at
+		FROM container_seasonal_baselines
+		WHERE container_name = ? AND host_id = ? AND day_of_week = ? AND hour_of_day = ?
+	`, containerName, hostID, dayOfWeek, hourOfDay).Scan(&b.ContainerName, &b.HostID, &b.DayOfWeek, &b.HourOfDay,
+		&b.AvgCPUPercent, &b.StdDevCPUPercent, &b.AvgMemoryUsage, &b.StdDevMemoryUsage,
+		&b.SampleCount, &b.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &b, nil
+}
+
 // GetNotificationStatus returns the current notification system status
 func (db *DB) GetNotificationStatus() (*models.NotificationStatus, error) {
 	var status models.NotificationStatus
@@ -151,6 +151,7 @@ func TestNotificationRuleCRUD(t *testing.T) {
 	// Create a rule
 	cpuThreshold := 80.0
 	memThreshold := 90.0
+	leakThreshold := 2.5
 	rule := &models.NotificationRule{
 		Name:                      "test-rule",
 		EventTypes:                []string{"container_stopped", "new_image"},
@@ -158,6 +159,8 @@ func TestNotificationRuleCRUD(t *testing.T) {
 		ImagePattern:              "nginx:*",
 		CPUThreshold:              &cpuThreshold,
 		MemoryThreshold:           &memThreshold,
+		LeakGrowthThreshold:       &leakThreshold,
+		LeakMinDays:               4,
 		ThresholdDurationSeconds:  120,
 		CooldownSeconds:           300,
 		Enabled:                   true,
@@ -202,6 +205,9 @@ func TestNotificationRuleCRUD(t *testing.T) {
 	if savedRule.CPUThreshold == nil || *savedRule.CPUThreshold != *rule.CPUThreshold {
 		t.Errorf("Expected CPU threshold %v, got %v", rule.CPUThreshold, savedRule.CPUThreshold)
 	}
+	if savedRule.LeakGrowthThreshold == nil || *savedRule.LeakGrowthThreshold != leakThreshold || savedRule.LeakMinDays != 4 {
+		t.Errorf("Expected leak sensitivity 2.5%%/day over 4 days, got %v over %d", savedRule.LeakGrowthThreshold, savedRule.LeakMinDays)
+	}
 	if len(savedRule.EventTypes) != 2 {
 		t.Errorf("Expected 2 event types, got %d", len(savedRule.EventTypes))
 	}
@@ -890,3 +896,140 @@ func TestGetLastNotificationTime(t *testing.T) {
 		t.Error("Expected no last notification time for non-existent container")
 	}
 }
+
+func TestGetDailyMemoryUsage(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "host1", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	day := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	insert := func(at time.Time, mem int64) {
+		_, err := db.conn.Exec(`
+			INSERT INTO container_stats_aggregates
+			(container_id, container_name, host_id, host_name, timestamp_hour, avg_cpu_percent, avg_memory_usage, max_cpu_percent, max_memory_usage, sample_count)
+			VALUES ('c1', 'app', ?, 'host1', ?, 1, ?, 1, ?, 12)
+		`, hostID, at, mem, mem)
+		if err != nil {
+			t.Fatalf("Failed to insert aggregate: %v", err)
+		}
+	}
+	insert(day.Add(1*time.Hour), 100)
+	insert(day.Add(2*time.Hour), 300)
+	insert(day.Add(25*time.Hour), 400)
+	insert(day.Add(49*time.Hour), 999) // outside the requested range
+
+	usage, err := db.GetDailyMemoryUsage(day, day.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatalf("GetDailyMemoryUsage failed: %v", err)
+	}
+	if len(usage) != 2 {
+		t.Fatalf("Expected 2 days, got %+v", usage)
+	}
+	if usage[0].Day != "2025-09-01" || usage[0].AvgMemoryUsage != 200 {
+		t.Errorf("Unexpected first day: %+v", usage[0])
+	}
+	if usage[1].Day != "2025-09-02" || usage[1].AvgMemoryUsage != 400 {
+		t.Errorf("Unexpected second day: %+v", usage[1])
+	}
+}
+
+// TestNotificationGroupsAndBulkOperations tests grouping, filtered bulk operations and configured retention
+func TestNotificationGroupsAndBulkOperations(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	now := time.Now()
+	add := func(eventType, container string, age time.Duration, read bool) {
+		entry := models.NotificationLog{
+			EventType:     eventType,
+			ContainerName: container,
+			HostID:        &hostID,
+			HostName:      "nas",
+			Message:       eventType + " " + container,
+			SentAt:        now.Add(-age),
+			Success:       true,
+			Read:          read,
+		}
+		if err := db.InsertNotificationLog(&entry); err != nil {
+			t.Fatalf("InsertNotificationLog failed: %v", err)
+		}
+		if entry.ID == 0 {
+			t.Fatal("Expected ID to be set")
+		}
+	}
+
+	add(models.EventTypeContainerStopped, "web", 3*time.Hour, true)
+	add(models.EventTypeContainerStarted, "web", 2*time.Hour, false)
+	add(models.EventTypeContainerStopped, "db", time.Hour, false)
+	add(models.EventTypeHighCPU, "db", 10*time.Minute, false)
+
+	groups, err := db.GetNotificationGroups(models.NotificationGroupByContainer, models.NotificationLogFilter{})
+	if err != nil {
+		t.Fatalf("GetNotificationGroups failed: %v", err)
+	}
+	if len(groups) != 2 || groups[0].ContainerName != "db" || groups[0].Count != 2 || groups[0].UnreadCount != 2 {
+		t.Fatalf("Unexpected container groups: %+v", groups)
+	}
+	if groups[0].LatestMessage != "high_cpu db" || groups[0].HostName != "nas" || groups[0].HostID != hostID {
+		t.Errorf("Expected the latest entry to describe the group, got %+v", groups[0])
+	}
+	if groups[1].ContainerName != "web" || groups[1].UnreadCount != 1 {
+		t.Errorf("Unexpected web group: %+v", groups[1])
+	}
+
+	groups, err = db.GetNotificationGroups(models.NotificationGroupByEventType, models.NotificationLogFilter{UnreadOnly: true})
+	if err != nil {
+		t.Fatalf("GetNotificationGroups failed: %v", err)
+	}
+	if len(groups) != 3 {
+		t.Fatalf("Expected 3 event types with unread entries, got %+v", groups)
+	}
+
+	if _, err := db.GetNotificationGroups("rule", models.NotificationLogFilter{}); err == nil {
+		t.Error("Expected an error for an unknown grouping")
+	}
+
+	// Bulk operations only touch matching entries
+	marked, err := db.MarkNotificationsRead(models.NotificationLogFilter{ContainerName: "db"})
+	if err != nil || marked != 2 {
+		t.Fatalf("Expected 2 entries marked read, got %d (%v)", marked, err)
+	}
+	unread, _ := db.GetUnreadNotificationCount()
+	if unread != 1 {
+		t.Errorf("Expected 1 unread entry left, got %d", unread)
+	}
+
+	deleted, err := db.DeleteNotifications(models.NotificationLogFilter{EventType: models.EventTypeContainerStopped})
+	if err != nil || deleted != 2 {
+		t.Fatalf("Expected 2 entries deleted, got %d (%v)", deleted, err)
+	}
+	logs, _ := db.QueryNotificationLogs(models.NotificationLogFilter{HostID: hostID}, 100)
+	if len(logs) != 2 {
+		t.Errorf("Expected 2 entries left, got %d", len(logs))
+	}
+
+	// Retention follows the notification settings
+	for i := 0; i < 20; i++ {
+		add(models.EventTypeNewImage, "app", time.Duration(48+i)*time.Hour, true)
+	}
+	settings := GetDefaultSettings()
+	settings.Notification.LogRetentionDays = 1
+	settings.Notification.LogRetentionCount = 10
+	if err := db.SaveSystemSettings(settings); err != nil {
+		t.Fatalf("SaveSystemSettings failed: %v", err)
+	}
+	if err := db.CleanupOldNotifications(); err != nil {
+		t.Fatalf("CleanupOldNotifications failed: %v", err)
+	}
+	logs, _ = db.GetNotificationLogs(100, false)
+	if len(logs) != 10 {
+		t.Errorf("Expected the 10 most recent entries to be kept, got %d", len(logs))
+	}
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"github.com/container-census/container-census/internal/models"
+)
+
+// SetContainerOwner assigns an owner to a container, replacing its previous owner
+func (db *DB) SetContainerOwner(a models.OwnerAssignment) error {
+	_, err := db.conn.Exec(`
+		INSERT OR REPLACE INTO container_owners (host_id, container_name, owner, contact, assigned_at, assigned_by)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, a.HostID, a.ContainerName, a.Owner, a.Contact, a.AssignedAt, a.AssignedBy)
+	return err
+}
+
+// DeleteContainerOwner removes a container's assigned owner; removing one that isn't assigned is
+// not an error
+func (db *DB) DeleteContainerOwner(hostID int64, containerName string) error {
+	_, err := db.conn.Exec(`DELETE FROM container_owners WHERE host_id = ? AND container_name = ?`, hostID, containerName)
+	return err
+}
+
+// SetStackOwner assigns an owner to a compose stack on every host, replacing its previous owner
+func (db *DB) SetStackOwner(a models.OwnerAssignment) error {
+	_, err := db.conn.Exec(`
+		INSERT OR REPLACE INTO stack_owners (stack, owner, contact, assigned_at, assigned_by)
+		VALUES (?, ?, ?, ?, ?)
+	`, a.Stack, a.Owner, a.Contact, a.AssignedAt, a.AssignedBy)
+	return err
+}
+
+// DeleteStackOwner removes a compose stack's assigned owner
+func (db *DB) DeleteStackOwner(stack string) error {
+	_, err := db.conn.Exec(`DELETE FROM stack_owners WHERE stack = ?`, stack)
+	return err
+}
+
+// GetOwnerAssignments returns the owners assigned to containers (keyed by models.PinKey) and to
+// compose stacks
+func (db *DB) GetOwnerAssignments() (models.OwnerAssignments, error) {
+	assignments := models.OwnerAssignments{
+		Containers: make(map[string]models.OwnerAssignment),
+		Stacks:     make(map[string]models.OwnerAssignment),
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT o.host_id, COALESCE(h.name, ''), o.container_name, o.owner, o.contact, o.assigned_at, o.assigned_by
+		FROM container_owners o
+		LEFT JOIN hosts h ON h.id = o.host_id
+	`)
+	if err != nil {
+		return assignments, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a models.OwnerAssignment
+		if err := rows.Scan(&a.HostID, &a.HostName, &a.ContainerName, &a.Owner, &a.Contact, &a.AssignedAt, &a.AssignedBy); err != nil {
+			return assignments, err
+		}
+		assignments.Containers[models.PinKey(a.HostID, a.ContainerName)] = a
+	}
+	if err := rows.Err(); err != nil {
+		return assignments, err
+	}
+
+	stackRows, err := db.conn.Query(`SELECT stack, owner, contact, assigned_at, assigned_by FROM stack_owners`)
+	if err != nil {
+		return assignments, err
+	}
+	defer stackRows.Close()
+	for stackRows.Next() {
+		var a models.OwnerAssignment
+		if err := stackRows.Scan(&a.Stack, &a.Owner, &a.Contact, &a.AssignedAt, &a.AssignedBy); err != nil {
+			return assignments, err
+		}
+		assignments.Stacks[a.Stack] = a
+	}
+	return assignments, stackRows.Err()
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestOwnerAssignments(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	now := time.Now()
+	if err := db.SetContainerOwner(models.OwnerAssignment{HostID: hostID, ContainerName: "postgres", Owner: "alice", AssignedAt: now}); err != nil {
+		t.Fatalf("SetContainerOwner failed: %v", err)
+	}
+	if err := db.SetContainerOwner(models.OwnerAssignment{HostID: hostID, ContainerName: "postgres", Owner: "dba-team", Contact: "dba@example.com", AssignedAt: now}); err != nil {
+		t.Fatalf("SetContainerOwner failed: %v", err)
+	}
+	if err := db.SetStackOwner(models.OwnerAssignment{Stack: "media", Owner: "bob", AssignedAt: now, AssignedBy: "admin"}); err != nil {
+		t.Fatalf("SetStackOwner failed: %v", err)
+	}
+
+	assignments, err := db.GetOwnerAssignments()
+	if err != nil {
+		t.Fatalf("GetOwnerAssignments failed: %v", err)
+	}
+	if a := assignments.Containers[models.PinKey(hostID, "postgres")]; len(assignments.Containers) != 1 || a.Owner != "dba-team" || a.HostName != "nas" {
+		t.Errorf("Expected the replaced container owner, got %+v", assignments.Containers)
+	}
+	if a := assignments.Stacks["media"]; len(assignments.Stacks) != 1 || a.Owner != "bob" || a.AssignedBy != "admin" {
+		t.Errorf("Expected the stack owner, got %+v", assignments.Stacks)
+	}
+
+	// The label wins over the container's owner, which wins over its stack's
+	c := models.Container{Name: "postgres", HostID: hostID, ComposeProject: "media"}
+	if o := models.OwnerFor(c, assignments); o == nil || o.Name != "dba-team" || o.Contact != "dba@example.com" || o.Source != models.OwnerSourceContainer {
+		t.Errorf("Expected the container owner, got %+v", o)
+	}
+	if o := models.OwnerFor(models.Container{Name: "jellyfin", HostID: hostID, ComposeProject: "media"}, assignments); o == nil || o.Name != "bob" || o.Source != models.OwnerSourceStack {
+		t.Errorf("Expected the stack owner, got %+v", o)
+	}
+	c.Labels = map[string]string{models.OwnerLabel: "carol"}
+	if o := models.OwnerFor(c, assignments); o == nil || o.Name != "carol" || o.Source != models.OwnerSourceLabel {
+		t.Errorf("Expected the label owner, got %+v", o)
+	}
+
+	if err := db.DeleteContainerOwner(hostID, "postgres"); err != nil {
+		t.Fatalf("DeleteContainerOwner failed: %v", err)
+	}
+	if err := db.DeleteStackOwner("media"); err != nil {
+		t.Fatalf("DeleteStackOwner failed: %v", err)
+	}
+	if assignments, _ := db.GetOwnerAssignments(); len(assignments.Containers) != 0 || len(assignments.Stacks) != 0 {
+		t.Errorf("Expected no owners, got %+v", assignments)
+	}
+}
@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"github.com/container-census/container-census/internal/models"
+)
+
+// SetContainerPin pins a container, replacing the reason of an existing pin
+func (db *DB) SetContainerPin(pin models.ContainerPin) error {
+	_, err := db.conn.Exec(`
+		INSERT OR REPLACE INTO container_pins (host_id, container_name, reason, pinned_at)
+		VALUES (?, ?, ?, ?)
+	`, pin.HostID, pin.ContainerName, pin.Reason, pin.PinnedAt)
+	return err
+}
+
+// DeleteContainerPin unpins a container; unpinning a container that isn't pinned is not an error
+func (db *DB) DeleteContainerPin(hostID int64, containerName string) error {
+	_, err := db.conn.Exec(`DELETE FROM container_pins WHERE host_id = ? AND container_name = ?`, hostID, containerName)
+	return err
+}
+
+// GetContainerPins returns the pins set through the API, keyed by models.PinKey
+func (db *DB) GetContainerPins() (map[string]models.ContainerPin, error) {
+	rows, err := db.conn.Query(`SELECT host_id, container_name, reason, pinned_at FROM container_pins`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pins := make(map[string]models.ContainerPin)
+	for rows.Next() {
+		pin := models.ContainerPin{Source: models.PinSourceAPI}
+		if err := rows.Scan(&pin.HostID, &pin.ContainerName, &pin.Reason, &pin.PinnedAt); err != nil {
+			return nil, err
+		}
+		pins[models.PinKey(pin.HostID, pin.ContainerName)] = pin
+	}
+	return pins, rows.Err()
+}
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestContainerPins(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	if err := db.SetContainerPin(models.ContainerPin{HostID: hostID, ContainerName: "postgres", Reason: "major upgrade needs a dump", PinnedAt: time.Now()}); err != nil {
+		t.Fatalf("SetContainerPin failed: %v", err)
+	}
+	if err := db.SetContainerPin(models.ContainerPin{HostID: hostID, ContainerName: "postgres", Reason: "waiting for 17.1", PinnedAt: time.Now()}); err != nil {
+		t.Fatalf("SetContainerPin failed: %v", err)
+	}
+
+	pins, err := db.GetContainerPins()
+	if err != nil {
+		t.Fatalf("GetContainerPins failed: %v", err)
+	}
+	pin, ok := pins[models.PinKey(hostID, "postgres")]
+	if len(pins) != 1 || !ok || pin.Reason != "waiting for 17.1" || pin.Source != models.PinSourceAPI {
+		t.Fatalf("Expected one updated pin, got %+v", pins)
+	}
+
+	// Pins follow the container name, so they survive recreation
+	c := models.Container{ID: "new-id", Name: "postgres", HostID: hostID}
+	if p := models.PinFor(c, pins); p == nil || p.Reason != "waiting for 17.1" {
+		t.Errorf("Expected the API pin, got %+v", p)
+	}
+	c.Labels = map[string]string{models.PinLabel: "true"}
+	if p := models.PinFor(c, pins); p == nil || p.Source != models.PinSourceLabel {
+		t.Errorf("Expected the label pin to win, got %+v", p)
+	}
+	if p := models.PinFor(models.Container{Name: "web", HostID: hostID, Labels: map[string]string{models.PinLabel: "no"}}, pins); p != nil {
+		t.Errorf("Expected no pin, got %+v", p)
+	}
+
+	if err := db.DeleteContainerPin(hostID, "postgres"); err != nil {
+		t.Fatalf("DeleteContainerPin failed: %v", err)
+	}
+	if pins, _ := db.GetContainerPins(); len(pins) != 0 {
+		t.Errorf("Expected no pins, got %+v", pins)
+	}
+}
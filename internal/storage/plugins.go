@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// SavePluginResults replaces everything a plugin reported for a host with the results of its latest run
+func (db *DB) SavePluginResults(plugin string, hostID int64, results []models.PluginResult, collectedAt time.Time) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM plugin_results WHERE plugin = ? AND host_id = ?`, plugin, hostID); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO plugin_results (host_id, container_name, plugin, key, value, collected_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range results {
+		if _, err := stmt.Exec(hostID, r.ContainerName, plugin, r.Key, r.Value, collectedAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SetPluginResult adds or updates a single result, leaving the plugin's other results in place
+func (db *DB) SetPluginResult(r models.PluginResult) error {
+	_, err := db.conn.Exec(`
+		INSERT OR REPLACE INTO plugin_results (host_id, container_name, plugin, key, value, collected_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, r.HostID, r.ContainerName, r.Plugin, r.Key, r.Value, r.CollectedAt)
+	return err
+}
+
+// GetPluginResults returns what plugins reported for a container, ordered by plugin and key
+func (db *DB) GetPluginResults(hostID int64, containerName string) ([]models.PluginResult, error) {
+	rows, err := db.conn.Query(`
+		SELECT plugin, host_id, container_name, key, value, collected_at
+		FROM plugin_results
+		WHERE host_id = ? AND container_name = ?
+		ORDER BY plugin, key
+	`, hostID, containerName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]models.PluginResult, 0)
+	for rows.Next() {
+		var r models.PluginResult
+		if err := rows.Scan(&r.Plugin, &r.HostID, &r.ContainerName, &r.Key, &r.Value, &r.CollectedAt); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+
+	return results, rows.Err()
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestPluginResults(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	first := time.Now().Add(-time.Hour)
+	if err := db.SavePluginResults("license", hostID, []models.PluginResult{
+		{ContainerName: "web", Key: "license", Value: "MIT"},
+		{ContainerName: "db", Key: "license", Value: "PostgreSQL"},
+	}, first); err != nil {
+		t.Fatalf("SavePluginResults failed: %v", err)
+	}
+	if err := db.SavePluginResults("version", hostID, []models.PluginResult{
+		{ContainerName: "web", Key: "app_version", Value: "2.1"},
+	}, first); err != nil {
+		t.Fatalf("SavePluginResults failed: %v", err)
+	}
+
+	results, err := db.GetPluginResults(hostID, "web")
+	if err != nil {
+		t.Fatalf("GetPluginResults failed: %v", err)
+	}
+	if len(results) != 2 || results[0].Plugin != "license" || results[1].Plugin != "version" {
+		t.Fatalf("Expected results from both plugins in order, got %+v", results)
+	}
+
+	// A new run replaces only that plugin's results for the host
+	if err := db.SavePluginResults("license", hostID, []models.PluginResult{
+		{ContainerName: "web", Key: "license", Value: "Apache-2.0"},
+	}, time.Now()); err != nil {
+		t.Fatalf("SavePluginResults failed: %v", err)
+	}
+
+	results, err = db.GetPluginResults(hostID, "web")
+	if err != nil {
+		t.Fatalf("GetPluginResults failed: %v", err)
+	}
+	if len(results) != 2 || results[0].Value != "Apache-2.0" || results[1].Value != "2.1" {
+		t.Errorf("Expected updated license and unchanged version, got %+v", results)
+	}
+
+	results, err = db.GetPluginResults(hostID, "db")
+	if err != nil {
+		t.Fatalf("GetPluginResults failed: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Expected db results to be removed by the new run, got %+v", results)
+	}
+}
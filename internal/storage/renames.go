@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// renameStatements move name-keyed rows of a renamed container to its new name (args: new
+// name, host ID, old name). OR IGNORE keeps rows the new name already has.
+var renameStatements = []string{
+	`UPDATE containers SET name = ? WHERE host_id = ? AND name = ?`,
+	`UPDATE container_stats_aggregates SET container_name = ? WHERE host_id = ? AND container_name = ?`,
+	`UPDATE container_baseline_stats SET container_name = ? WHERE host_id = ? AND container_name = ?`,
+	`UPDATE OR IGNORE container_seasonal_baselines SET container_name = ? WHERE host_id = ? AND container_name = ?`,
+	`UPDATE OR IGNORE container_pins SET container_name = ? WHERE host_id = ? AND container_name = ?`,
+	`UPDATE OR IGNORE notes SET container_name = ? WHERE host_id = ? AND container_name = ?`,
+	`UPDATE OR IGNORE container_owners SET container_name = ? WHERE host_id = ? AND container_name = ?`,
+	`UPDATE OR IGNORE backup_runs SET container_name = ? WHERE host_id = ? AND container_name = ?`,
+	`UPDATE uptime_checks SET container_name = ? WHERE host_id = ? AND container_name = ?`,
+	`UPDATE OR IGNORE plugin_results SET container_name = ? WHERE host_id = ? AND container_name = ?`,
+	`UPDATE container_updates SET container_name = ? WHERE host_id = ? AND container_name = ?`,
+	`UPDATE daemon_events SET container_name = ? WHERE host_id = ? AND container_name = ?`,
+	`UPDATE annotations SET container_name = ? WHERE host_id = ? AND container_name = ?`,
+	`UPDATE container_image_history SET container_name = ? WHERE host_id = ? AND container_name = ?`,
+	`UPDATE OR IGNORE update_lag SET container_name = ? WHERE host_id = ? AND container_name = ?`,
+}
+
+// applyContainerRenames finds containers of a scan whose ID had another name at the host's
+// previous scan, records the rename and moves the container's history to the new name, so
+// lifecycle history, baselines, pins and reports follow the container instead of showing it
+// as removed and new.
+func applyContainerRenames(tx *sql.Tx, containers []models.Container) error {
+	byHost := make(map[int64][]models.Container)
+	for _, c := range containers {
+		byHost[c.HostID] = append(byHost[c.HostID], c)
+	}
+
+	for hostID, scanned := range byHost {
+		previous, err := previousContainerNames(tx, hostID, scanned[0].ScannedAt)
+		if err != nil {
+			return err
+		}
+		if len(previous) == 0 {
+			continue
+		}
+
+		for _, c := range scanned {
+			oldName, ok := previous[c.ID]
+			if !ok || oldName == c.Name {
+				continue
+			}
+			if _, err := tx.Exec(`
+				INSERT INTO container_renames (host_id, container_id, old_name, new_name, renamed_at)
+				VALUES (?, ?, ?, ?, ?)
+			`, hostID, c.ID, oldName, c.Name, c.ScannedAt); err != nil {
+				return err
+			}
+			for _, stmt := range renameStatements {
+				if _, err := tx.Exec(stmt, c.Name, hostID, oldName); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// previousContainerNames returns container ID -> name of the host's last scan before the given time
+func previousContainerNames(tx *sql.Tx, hostID int64, before time.Time) (map[string]string, error) {
+	rows, err := tx.Query(`
+		SELECT id, name FROM containers
+		WHERE host_id = ? AND scanned_at = (
+			SELECT MAX(scanned_at) FROM containers WHERE host_id = ? AND scanned_at < ?
+		)
+	`, hostID, hostID, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make(map[string]string)
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		names[id] = name
+	}
+	return names, rows.Err()
+}
+
+// GetContainerRenames returns the renames that led to a container's current name on a host,
+// newest first; earlier names are followed back through chains of renames
+func (db *DB) GetContainerRenames(containerName string, hostID int64) ([]models.ContainerRename, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, host_id, container_id, old_name, new_name, renamed_at
+		FROM container_renames
+		WHERE host_id = ?
+		ORDER BY renamed_at DESC, id DESC
+	`, hostID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := map[string]bool{containerName: true}
+	renames := make([]models.ContainerRename, 0)
+	for rows.Next() {
+		var r models.ContainerRename
+		if err := rows.Scan(&r.ID, &r.HostID, &r.ContainerID, &r.OldName, &r.NewName, &r.RenamedAt); err != nil {
+			return nil, err
+		}
+		if names[r.NewName] {
+			names[r.OldName] = true
+			renames = append(renames, r)
+		}
+	}
+	return renames, rows.Err()
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestContainerRenames(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	scan := func(at time.Time, names map[string]string) {
+		t.Helper()
+		var containers []models.Container
+		for id, name := range names {
+			containers = append(containers, models.Container{
+				ID: id, Name: name, Image: "nginx:latest", ImageID: "sha256:1", State: "running",
+				Status: "Up", Created: at, HostID: hostID, HostName: "nas", ScannedAt: at,
+			})
+		}
+		if err := db.SaveContainers(containers); err != nil {
+			t.Fatalf("SaveContainers failed: %v", err)
+		}
+	}
+
+	start := time.Now().Add(-time.Hour).UTC()
+	scan(start, map[string]string{"abc": "web", "def": "db"})
+	if err := db.SetContainerPin(models.ContainerPin{HostID: hostID, ContainerName: "web", PinnedAt: start}); err != nil {
+		t.Fatalf("SetContainerPin failed: %v", err)
+	}
+	scan(start.Add(5*time.Minute), map[string]string{"abc": "frontend", "def": "db"})
+	scan(start.Add(10*time.Minute), map[string]string{"abc": "frontend", "def": "database"})
+
+	events, err := db.GetContainerLifecycleEvents("frontend", hostID)
+	if err != nil {
+		t.Fatalf("GetContainerLifecycleEvents failed: %v", err)
+	}
+	var types []string
+	for _, e := range events {
+		types = append(types, e.EventType)
+	}
+	if len(events) != 3 || events[0].EventType != "first_seen" || events[1].EventType != "renamed" ||
+		events[1].OldName != "web" || events[1].NewName != "frontend" || events[2].EventType != "last_seen" {
+		t.Fatalf("Expected first_seen, renamed, last_seen, got %v", types)
+	}
+	if events[2].Description != "Last observed (running) - seen 3 times total" {
+		t.Errorf("Expected the history under the old name to move, got %q", events[2].Description)
+	}
+
+	if old, _ := db.GetContainerLifecycleEvents("web", hostID); len(old) != 0 {
+		t.Errorf("Expected no history left under the old name, got %d events", len(old))
+	}
+
+	pins, err := db.GetContainerPins()
+	if err != nil {
+		t.Fatalf("GetContainerPins failed: %v", err)
+	}
+	if _, ok := pins[models.PinKey(hostID, "frontend")]; !ok {
+		t.Errorf("Expected the pin to follow the rename, got %v", pins)
+	}
+
+	renames, err := db.GetContainerRenames("database", hostID)
+	if err != nil || len(renames) != 1 || renames[0].OldName != "db" {
+		t.Errorf("Expected one rename of db, got %v (%v)", renames, err)
+	}
+}
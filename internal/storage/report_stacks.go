@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// stackUpdateGap is the longest gap between two image updates of a stack's services that still
+// counts as one update of the stack
+const stackUpdateGap = 30 * time.Minute
+
+// summarizeStackChanges groups the changes of a report's containers by compose project and host,
+// most recently changed stack first. Containers outside a compose project aren't in any stack.
+func summarizeStackChanges(report *models.ChangesReport) []models.StackChange {
+	type stackKey struct {
+		hostID  int64
+		project string
+	}
+	stacks := make(map[stackKey]*models.StackChange)
+	services := make(map[stackKey]map[string]bool)
+	updates := make(map[stackKey][]models.ImageUpdateChange)
+
+	stack := func(hostID int64, hostName, project, service string, at time.Time) *models.StackChange {
+		key := stackKey{hostID, project}
+		sc, ok := stacks[key]
+		if !ok {
+			sc = &models.StackChange{ComposeProject: project, HostID: hostID, HostName: hostName}
+			stacks[key] = sc
+			services[key] = make(map[string]bool)
+		}
+		services[key][service] = true
+		if at.After(sc.LastChangeAt) {
+			sc.LastChangeAt = at
+		}
+		return sc
+	}
+
+	for _, c := range report.NewContainers {
+		if c.ComposeProject != "" {
+			stack(c.HostID, c.HostName, c.ComposeProject, c.ContainerName, c.Timestamp).NewContainers++
+		}
+	}
+	for _, c := range report.RemovedContainers {
+		if c.ComposeProject != "" {
+			stack(c.HostID, c.HostName, c.ComposeProject, c.ContainerName, c.Timestamp).RemovedContainers++
+		}
+	}
+	for _, u := range report.ImageUpdates {
+		if u.ComposeProject != "" {
+			stack(u.HostID, u.HostName, u.ComposeProject, u.ContainerName, u.UpdatedAt).ImageUpdates++
+			key := stackKey{u.HostID, u.ComposeProject}
+			updates[key] = append(updates[key], u)
+		}
+	}
+	for _, s := range report.StateChanges {
+		if s.ComposeProject != "" {
+			stack(s.HostID, s.HostName, s.ComposeProject, s.ContainerName, s.ChangedAt).StateChanges++
+		}
+	}
+
+	result := make([]models.StackChange, 0, len(stacks))
+	for key, sc := range stacks {
+		for service := range services[key] {
+			sc.Services = append(sc.Services, service)
+		}
+		sort.Strings(sc.Services)
+		sc.Updates = groupStackUpdates(updates[key])
+		sc.Summary = stackSummary(*sc)
+		result = append(result, *sc)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].LastChangeAt.Equal(result[j].LastChangeAt) {
+			return result[i].LastChangeAt.After(result[j].LastChangeAt)
+		}
+		if result[i].ComposeProject != result[j].ComposeProject {
+			return result[i].ComposeProject < result[j].ComposeProject
+		}
+		return result[i].HostName < result[j].HostName
+	})
+	return result
+}
+
+// groupStackUpdates groups the image updates of one stack into stack updates: updates less than
+// stackUpdateGap apart belong together. Newest first; a service updated twice in one group is
+// listed once.
+func groupStackUpdates(changes []models.ImageUpdateChange) []models.StackUpdate {
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].UpdatedAt.Before(changes[j].UpdatedAt)
+	})
+
+	groups := make([]models.StackUpdate, 0)
+	var last time.Time
+	var seen map[string]bool
+	for _, change := range changes {
+		if len(groups) == 0 || change.UpdatedAt.Sub(last) > stackUpdateGap {
+			groups = append(groups, models.StackUpdate{UpdatedAt: change.UpdatedAt})
+			seen = make(map[string]bool)
+		}
+		group := &groups[len(groups)-1]
+		if !seen[change.ContainerName] {
+			seen[change.ContainerName] = true
+			group.Services = append(group.Services, change.ContainerName)
+		}
+		last = change.UpdatedAt
+	}
+
+	for i, j := 0, len(groups)-1; i < j; i, j = i+1, j-1 {
+		groups[i], groups[j] = groups[j], groups[i]
+	}
+	for i := range groups {
+		sort.Strings(groups[i].Services)
+	}
+	return groups
+}
+
+// stackSummary describes a stack's changes in one line, e.g. "immich stack updated (3 services)"
+func stackSummary(sc models.StackChange) string {
+	switch {
+	case len(sc.Updates) > 0:
+		updated := make(map[string]bool)
+		for _, update := range sc.Updates {
+			for _, service := range update.Services {
+				updated[service] = true
+			}
+		}
+		times := ""
+		if len(sc.Updates) > 1 {
+			times = fmt.Sprintf(" %d times", len(sc.Updates))
+		}
+		return fmt.Sprintf("%s stack updated%s (%s)", sc.ComposeProject, times, pluralServices(len(updated)))
+	case sc.NewContainers == len(sc.Services) && sc.RemovedContainers == 0:
+		return fmt.Sprintf("%s stack deployed (%s)", sc.ComposeProject, pluralServices(len(sc.Services)))
+	case sc.RemovedContainers == len(sc.Services) && sc.NewContainers == 0:
+		return fmt.Sprintf("%s stack removed (%s)", sc.ComposeProject, pluralServices(len(sc.Services)))
+	default:
+		return fmt.Sprintf("%s stack changed (%s)", sc.ComposeProject, pluralServices(len(sc.Services)))
+	}
+}
+
+func pluralServices(n int) string {
+	if n == 1 {
+		return "1 service"
+	}
+	return fmt.Sprintf("%d services", n)
+}
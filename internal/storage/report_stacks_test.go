@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestSummarizeStackChanges(t *testing.T) {
+	base := time.Date(2026, 10, 5, 3, 0, 0, 0, time.UTC)
+	update := func(name, project string, at time.Time) models.ImageUpdateChange {
+		return models.ImageUpdateChange{ContainerName: name, HostID: 1, HostName: "nas", ComposeProject: project, UpdatedAt: at}
+	}
+	report := &models.ChangesReport{
+		ImageUpdates: []models.ImageUpdateChange{
+			update("immich-server", "immich", base),
+			update("immich-ml", "immich", base.Add(10*time.Minute)),
+			update("immich-server", "immich", base.Add(20*time.Minute)),
+			update("immich-server", "immich", base.Add(48*time.Hour)),
+			update("traefik", "", base),
+		},
+		NewContainers: []models.ContainerChange{
+			{ContainerName: "paperless", HostID: 1, HostName: "nas", ComposeProject: "paperless", Timestamp: base.Add(time.Hour)},
+			{ContainerName: "paperless-redis", HostID: 1, HostName: "nas", ComposeProject: "paperless", Timestamp: base.Add(time.Hour)},
+		},
+		RemovedContainers: []models.ContainerChange{
+			{ContainerName: "old-db", HostID: 2, HostName: "pi", ComposeProject: "legacy", Timestamp: base},
+		},
+		StateChanges: []models.StateChange{
+			{ContainerName: "immich-redis", HostID: 1, HostName: "nas", ComposeProject: "immich", ChangedAt: base.Add(5 * time.Minute)},
+			{ContainerName: "grafana", HostID: 2, HostName: "pi", ComposeProject: "monitoring", ChangedAt: base},
+		},
+	}
+
+	stacks := summarizeStackChanges(report)
+	var summaries []string
+	for _, sc := range stacks {
+		summaries = append(summaries, sc.Summary)
+	}
+	// Most recently changed first; ties by project
+	want := []string{
+		"immich stack updated 2 times (2 services)",
+		"paperless stack deployed (2 services)",
+		"legacy stack removed (1 service)",
+		"monitoring stack changed (1 service)",
+	}
+	if len(summaries) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, summaries)
+	}
+	for i := range want {
+		if summaries[i] != want[i] {
+			t.Errorf("Stack %d: expected %q, got %q", i, want[i], summaries[i])
+		}
+	}
+
+	immich := stacks[0]
+	if len(immich.Services) != 3 || immich.ImageUpdates != 4 || immich.StateChanges != 1 {
+		t.Errorf("Unexpected immich stack: %+v", immich)
+	}
+	if !immich.LastChangeAt.Equal(base.Add(48 * time.Hour)) {
+		t.Errorf("Expected the last change at the second update, got %v", immich.LastChangeAt)
+	}
+	if len(immich.Updates) != 2 {
+		t.Fatalf("Expected 2 stack updates, got %+v", immich.Updates)
+	}
+	if latest := immich.Updates[0]; !latest.UpdatedAt.Equal(base.Add(48*time.Hour)) || len(latest.Services) != 1 {
+		t.Errorf("Expected the latest update first, got %+v", latest)
+	}
+	if first := immich.Updates[1]; !first.UpdatedAt.Equal(base) || len(first.Services) != 2 || first.Services[0] != "immich-ml" {
+		t.Errorf("Expected the first update of immich-ml and immich-server once each, got %+v", first)
+	}
+}
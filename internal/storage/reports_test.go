@@ -427,6 +427,88 @@ func TestGetChangesReport_SummaryAccuracy(t *testing.T) {
 	}
 }
 
+func TestGetChangesReport_Stacks(t *testing.T) {
+	dbPath := "/tmp/test_reports_stacks.db"
+	defer os.Remove(dbPath)
+
+	db, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	_, err = db.conn.Exec(`INSERT INTO hosts (id, name, address, enabled) VALUES (1, 'test-host', 'unix:///var/run/docker.sock', 1)`)
+	if err != nil {
+		t.Fatalf("Failed to insert host: %v", err)
+	}
+
+	// The three services of the immich stack are updated together two days ago; web-app, outside
+	// any stack, a day later
+	fiveDaysAgo := time.Now().Add(-5 * 24 * time.Hour)
+	twoDaysAgo := time.Now().Add(-2 * 24 * time.Hour)
+	containers := []struct {
+		name, project, imageID string
+		scannedAt              time.Time
+	}{
+		{"immich-server", "immich", "sha256:server1", fiveDaysAgo},
+		{"immich-ml", "immich", "sha256:ml1", fiveDaysAgo},
+		{"immich-redis", "immich", "sha256:redis1", fiveDaysAgo},
+		{"web-app", "", "sha256:web1", fiveDaysAgo},
+		{"immich-server", "immich", "sha256:server2", twoDaysAgo},
+		{"immich-ml", "immich", "sha256:ml2", twoDaysAgo.Add(2 * time.Minute)},
+		{"immich-redis", "immich", "sha256:redis2", twoDaysAgo.Add(5 * time.Minute)},
+		{"web-app", "", "sha256:web2", twoDaysAgo.Add(24 * time.Hour)},
+	}
+	for _, c := range containers {
+		_, err = db.conn.Exec(`
+			INSERT INTO containers (id, name, image, image_id, state, status, created, host_id, host_name, scanned_at, compose_project)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, c.imageID, c.name, c.name+":latest", c.imageID, "running", "Up", c.scannedAt, 1, "test-host", c.scannedAt, c.project)
+		if err != nil {
+			t.Fatalf("Failed to insert container: %v", err)
+		}
+	}
+
+	start := time.Now().Add(-3 * 24 * time.Hour)
+	end := time.Now()
+	report, err := db.GetChangesReport(start, end, 0)
+	if err != nil {
+		t.Fatalf("GetChangesReport failed: %v", err)
+	}
+	if len(report.ImageUpdates) != 4 {
+		t.Fatalf("Expected 4 image updates, got %d", len(report.ImageUpdates))
+	}
+	if report.Summary.Stacks != 1 || len(report.Stacks) != 1 {
+		t.Fatalf("Expected the immich stack only, got %+v", report.Stacks)
+	}
+	stack := report.Stacks[0]
+	if stack.Summary != "immich stack updated (3 services)" {
+		t.Errorf("Unexpected summary %q", stack.Summary)
+	}
+	if stack.ImageUpdates != 3 || len(stack.Updates) != 1 || len(stack.Updates[0].Services) != 3 {
+		t.Errorf("Expected one update of 3 services, got %+v", stack)
+	}
+	if stack.HostID != 1 || stack.HostName != "test-host" {
+		t.Errorf("Unexpected host of the stack: %+v", stack)
+	}
+
+	filtered, err := db.GetFilteredChangesReport(start, end, models.ChangesReportFilter{HostID: 1, ComposeProject: "immich"})
+	if err != nil {
+		t.Fatalf("GetFilteredChangesReport failed: %v", err)
+	}
+	if len(filtered.ImageUpdates) != 3 {
+		t.Errorf("Expected the 3 immich updates, got %+v", filtered.ImageUpdates)
+	}
+	for _, u := range filtered.ImageUpdates {
+		if u.ComposeProject != "immich" {
+			t.Errorf("Expected only immich containers, got %+v", u)
+		}
+	}
+	if filtered.Summary.TotalContainers != 3 {
+		t.Errorf("Expected the 3 immich containers in the totals, got %d", filtered.Summary.TotalContainers)
+	}
+}
+
 // Helper function to setup test data
 func setupReportTestData(t *testing.T, db *DB) {
 	// Create test hosts
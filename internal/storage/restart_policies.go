@@ -0,0 +1,215 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// restartPolicyKey identifies a container by name on a host, so policies carry over recreation
+type restartPolicyKey struct {
+	hostID int64
+	name   string
+}
+
+// restartPolicyState is the restart policy a container had at its latest stored configuration
+type restartPolicyState struct {
+	policy string
+	warned bool
+}
+
+// latestRestartPolicies returns the latest stored restart policy of each container name on the
+// hosts of a scan
+func latestRestartPolicies(tx *sql.Tx, containers []models.Container) (map[restartPolicyKey]restartPolicyState, error) {
+	policies := make(map[restartPolicyKey]restartPolicyState)
+	hosts := make(map[int64]bool)
+	for _, c := range containers {
+		if c.Config == nil || hosts[c.HostID] {
+			continue
+		}
+		hosts[c.HostID] = true
+
+		rows, err := tx.Query(`
+			SELECT container_name, restart_policy, restart_policy_warned FROM container_configs
+			WHERE host_id = ? AND restart_policy != ''
+			ORDER BY collected_at
+		`, c.HostID)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var name string
+			var state restartPolicyState
+			if err := rows.Scan(&name, &state.policy, &state.warned); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			policies[restartPolicyKey{c.HostID, name}] = state // the latest wins
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return policies, nil
+}
+
+// restartPolicyRow is a container of a host's latest scan with its restart policy state
+type restartPolicyRow struct {
+	finding models.RestartPolicyFinding
+	labels  map[string]string
+	config  models.ContainerConfig
+	warned  bool
+}
+
+// latestRestartPolicyRows returns the containers of the hosts' latest scans (one host, or all
+// with hostID 0) with their restart policies
+func (db *DB) latestRestartPolicyRows(hostID int64) ([]restartPolicyRow, error) {
+	rows, err := db.conn.Query(`
+		SELECT cc.container_id, cc.container_name, cc.host_id, cc.host_name, c.image, c.state, c.labels,
+		       cc.config, cc.collected_at, cc.restart_policy, cc.previous_restart_policy,
+		       cc.restart_policy_changed_at, cc.restart_policy_warned
+		FROM container_configs cc
+		INNER JOIN containers c ON c.id = cc.container_id AND c.host_id = cc.host_id
+		INNER JOIN (
+			SELECT host_id, MAX(scanned_at) as max_scan
+			FROM containers
+			WHERE (? = 0 OR host_id = ?)
+			GROUP BY host_id
+		) latest ON c.host_id = latest.host_id AND c.scanned_at = latest.max_scan
+		WHERE cc.collected_at = c.scanned_at
+	`, hostID, hostID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []restartPolicyRow
+	for rows.Next() {
+		var row restartPolicyRow
+		f := &row.finding
+		var labelsJSON, configJSON sql.NullString
+		var changedAt sql.NullTime
+		if err := rows.Scan(&f.ContainerID, &f.ContainerName, &f.HostID, &f.HostName, &f.Image, &f.State, &labelsJSON,
+			&configJSON, &f.CollectedAt, &f.RestartPolicy, &f.PreviousRestartPolicy, &changedAt, &row.warned); err != nil {
+			return nil, err
+		}
+		if labelsJSON.Valid && labelsJSON.String != "" {
+			json.Unmarshal([]byte(labelsJSON.String), &row.labels)
+		}
+		if err := json.Unmarshal([]byte(configJSON.String), &row.config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal container config: %w", err)
+		}
+		if changedAt.Valid {
+			f.ChangedAt = &changedAt.Time
+		}
+		if !row.config.StartedAt.IsZero() {
+			f.StartedAt = &row.config.StartedAt
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// restartPolicyMissing reports whether a container looks like a service that has been running
+// for at least minUptime without a restart policy. Compose one-off containers (docker compose
+// run) and containers with the ignore label are left out.
+func restartPolicyMissing(row restartPolicyRow, minUptime time.Duration) bool {
+	f := row.finding
+	if f.State != "running" || f.RestartPolicy != "no" || f.StartedAt == nil {
+		return false
+	}
+	if row.labels["com.docker.compose.oneoff"] == "True" {
+		return false
+	}
+	return f.CollectedAt.Sub(*f.StartedAt) >= minUptime
+}
+
+// GetRestartPolicyReport audits the restart policies of the containers in the hosts' latest scans
+// (one host, or all with hostID 0): running services without a restart policy, and policies that
+// changed within the last changedDays.
+func (db *DB) GetRestartPolicyReport(hostID int64, minUptimeHours, changedDays int) (*models.RestartPolicyReport, error) {
+	now := time.Now()
+	report := &models.RestartPolicyReport{
+		GeneratedAt:    now,
+		MinUptimeHours: minUptimeHours,
+		ChangedDays:    changedDays,
+		Findings:       make([]models.RestartPolicyFinding, 0),
+	}
+
+	rows, err := db.latestRestartPolicyRows(hostID)
+	if err != nil {
+		return nil, err
+	}
+	changedSince := now.AddDate(0, 0, -changedDays)
+	for _, row := range rows {
+		if row.labels[models.RestartPolicyIgnoreLabel] == "true" {
+			continue
+		}
+		f := row.finding
+		switch {
+		case restartPolicyMissing(row, time.Duration(minUptimeHours)*time.Hour):
+			f.Finding = models.RestartPolicyMissing
+			report.Missing++
+		case f.ChangedAt != nil && !f.ChangedAt.Before(changedSince):
+			f.Finding = models.RestartPolicyChanged
+			report.Changed++
+		default:
+			continue
+		}
+		report.Findings = append(report.Findings, f)
+	}
+
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		a, b := report.Findings[i], report.Findings[j]
+		if a.Finding != b.Finding {
+			return a.Finding == models.RestartPolicyMissing
+		}
+		if a.HostName != b.HostName {
+			return a.HostName < b.HostName
+		}
+		return a.ContainerName < b.ContainerName
+	})
+	return report, nil
+}
+
+// NewRestartPolicyWarnings returns the restart policy findings of a host's latest scan that
+// haven't been notified: policies that changed in that scan, and services without a restart
+// policy that haven't been warned about yet under their name. Services without a policy are
+// marked as warned, also when the change is what removed it.
+func (db *DB) NewRestartPolicyWarnings(hostID int64, minUptime time.Duration) ([]models.RestartPolicyFinding, error) {
+	rows, err := db.latestRestartPolicyRows(hostID)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []models.RestartPolicyFinding
+	for _, row := range rows {
+		if row.labels[models.RestartPolicyIgnoreLabel] == "true" {
+			continue
+		}
+		f := row.finding
+		missing := !row.warned && restartPolicyMissing(row, minUptime)
+		switch {
+		case f.ChangedAt != nil && f.ChangedAt.Equal(f.CollectedAt):
+			f.Finding = models.RestartPolicyChanged
+		case missing:
+			f.Finding = models.RestartPolicyMissing
+		default:
+			continue
+		}
+		if missing {
+			if _, err := db.conn.Exec(`UPDATE container_configs SET restart_policy_warned = 1 WHERE container_id = ? AND host_id = ?`,
+				f.ContainerID, f.HostID); err != nil {
+				return nil, err
+			}
+		}
+		findings = append(findings, f)
+	}
+	return findings, nil
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestRestartPolicyAudit(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "host1", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	start := time.Now().Add(-72 * time.Hour)
+	container := func(id, name, policy string, at time.Time, labels map[string]string) models.Container {
+		return models.Container{
+			ID: id, Name: name, Image: "app:latest", State: "running", Labels: labels,
+			HostID: hostID, HostName: "host1", ScannedAt: at,
+			Config: &models.ContainerConfig{RestartPolicy: policy, StartedAt: start},
+		}
+	}
+	scan := func(containers ...models.Container) {
+		t.Helper()
+		if err := db.SaveContainers(containers); err != nil {
+			t.Fatalf("Failed to save containers: %v", err)
+		}
+	}
+	warnings := func() map[string]string {
+		t.Helper()
+		findings, err := db.NewRestartPolicyWarnings(hostID, 24*time.Hour)
+		if err != nil {
+			t.Fatalf("NewRestartPolicyWarnings failed: %v", err)
+		}
+		result := make(map[string]string)
+		for _, f := range findings {
+			result[f.ContainerName] = f.Finding
+		}
+		return result
+	}
+
+	ignore := map[string]string{models.RestartPolicyIgnoreLabel: "true"}
+	at := start.Add(48 * time.Hour)
+	scan(container("web1", "web", "", at, nil), container("db1", "db", "always", at, nil), container("job1", "job", "no", at, ignore))
+
+	// web has run for 48 hours without a policy ("" from older engines is "no"); job is ignored
+	if w := warnings(); len(w) != 1 || w["web"] != models.RestartPolicyMissing {
+		t.Fatalf("Expected web to be missing a policy, got %v", w)
+	}
+	// Only warned once, also after recreation
+	scan(container("web2", "web", "no", at.Add(time.Minute), nil), container("db1", "db", "always", at.Add(time.Minute), nil))
+	if w := warnings(); len(w) != 0 {
+		t.Fatalf("Expected no new warnings, got %v", w)
+	}
+
+	// db is recreated without its policy
+	scan(container("web2", "web", "no", at.Add(2*time.Minute), nil), container("db2", "db", "no", at.Add(2*time.Minute), nil))
+	if w := warnings(); len(w) != 1 || w["db"] != models.RestartPolicyChanged {
+		t.Fatalf("Expected db's policy change, got %v", w)
+	}
+	scan(container("web2", "web", "no", at.Add(3*time.Minute), nil), container("db2", "db", "no", at.Add(3*time.Minute), nil))
+	if w := warnings(); len(w) != 0 {
+		t.Fatalf("Expected the change to be reported once, got %v", w)
+	}
+
+	report, err := db.GetRestartPolicyReport(hostID, 24, 7)
+	if err != nil {
+		t.Fatalf("GetRestartPolicyReport failed: %v", err)
+	}
+	if report.Missing != 2 || len(report.Findings) != 2 || report.Findings[0].ContainerName != "db" {
+		t.Fatalf("Expected db and web to be missing a policy, got %+v", report.Findings)
+	}
+	if report.Findings[0].PreviousRestartPolicy != "always" || report.Findings[0].ChangedAt == nil {
+		t.Errorf("Expected db's previous policy, got %+v", report.Findings[0])
+	}
+
+	// The audit only counts long-running services
+	if report, err := db.GetRestartPolicyReport(hostID, 24*7, 7); err != nil || report.Missing != 0 || report.Changed != 1 {
+		t.Errorf("Expected only db's change for a week of uptime, got %+v (%v)", report, err)
+	}
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/container-census/container-census/internal/inspect"
+	"github.com/container-census/container-census/internal/models"
+)
+
+// GetContainerSecurity returns the security posture of the containers in each host's latest
+// scan, riskiest first
+func (db *DB) GetContainerSecurity(filter models.ContainerSecurityFilter) ([]models.ContainerSecurity, error) {
+	rows, err := db.conn.Query(`
+		SELECT cc.container_id, cc.container_name, cc.host_id, cc.host_name, c.image, c.state,
+		       cc.config, cc.collected_at, cc.privileged, cc.privileged_since
+		FROM container_configs cc
+		INNER JOIN containers c ON c.id = cc.container_id AND c.host_id = cc.host_id
+		INNER JOIN (
+			SELECT host_id, MAX(scanned_at) as max_scan
+			FROM containers
+			GROUP BY host_id
+		) latest ON c.host_id = latest.host_id AND c.scanned_at = latest.max_scan
+		WHERE (? = 0 OR cc.host_id = ?) AND cc.risk_score >= ?
+		ORDER BY cc.risk_score DESC, cc.host_name, cc.container_name
+	`, filter.HostID, filter.HostID, filter.MinScore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]models.ContainerSecurity, 0)
+	for rows.Next() {
+		security, err := scanContainerSecurity(rows)
+		if err != nil {
+			return nil, err
+		}
+		if filter.Finding != "" && !security.Risk.HasFinding(filter.Finding) {
+			continue
+		}
+		results = append(results, *security)
+	}
+
+	return results, rows.Err()
+}
+
+// GetNewlyPrivilegedContainers returns the containers that became privileged in a host's latest
+// scan. Recreated containers whose previous instance with the same name was already privileged
+// are not included, so updating a privileged container doesn't count as a new one.
+func (db *DB) GetNewlyPrivilegedContainers(hostID int64) ([]models.ContainerSecurity, error) {
+	rows, err := db.conn.Query(`
+		SELECT cc.container_id, cc.container_name, cc.host_id, cc.host_name, COALESCE(c.image, ''), COALESCE(c.state, ''),
+		       cc.config, cc.collected_at, cc.privileged, cc.privileged_since
+		FROM container_configs cc
+		LEFT JOIN containers c ON c.id = cc.container_id AND c.host_id = cc.host_id AND c.scanned_at = cc.collected_at
+		WHERE cc.host_id = ?
+		  AND cc.privileged
+		  AND cc.privileged_since = cc.collected_at
+		  AND cc.collected_at = (SELECT MAX(collected_at) FROM container_configs WHERE host_id = ?)
+		  AND NOT EXISTS (
+			SELECT 1 FROM container_configs prev
+			WHERE prev.host_id = cc.host_id
+			  AND prev.container_name = cc.container_name
+			  AND prev.container_id != cc.container_id
+			  AND prev.privileged
+		  )
+		ORDER BY cc.container_name
+	`, hostID, hostID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]models.ContainerSecurity, 0)
+	for rows.Next() {
+		security, err := scanContainerSecurity(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *security)
+	}
+
+	return results, rows.Err()
+}
+
+func scanContainerSecurity(rows *sql.Rows) (*models.ContainerSecurity, error) {
+	var security models.ContainerSecurity
+	var configJSON string
+	var privilegedSince sql.NullTime
+
+	if err := rows.Scan(&security.ContainerID, &security.ContainerName, &security.HostID, &security.HostName,
+		&security.Image, &security.State, &configJSON, &security.CollectedAt, &security.Privileged, &privilegedSince); err != nil {
+		return nil, err
+	}
+
+	var config models.ContainerConfig
+	if err := json.Unmarshal([]byte(configJSON), &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal container config: %w", err)
+	}
+	security.Risk = inspect.AssessRisk(&config)
+	if privilegedSince.Valid {
+		security.PrivilegedSince = &privilegedSince.Time
+	}
+
+	return &security, nil
+}
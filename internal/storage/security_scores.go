@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// securityScoreRetentionDays is how long daily security scores are kept
+const securityScoreRetentionDays = 365
+
+// securityScoreDay is the day a score is recorded under, in server local time
+func securityScoreDay(t time.Time) string {
+	return t.Local().Format("2006-01-02")
+}
+
+// SaveSecurityScores records the fleet, host and stack scores of a scoreboard for the day of
+// its generation, replacing those already recorded that day, and removes scores older than a year
+func (db *DB) SaveSecurityScores(board *models.SecurityScoreboard) error {
+	day := securityScoreDay(board.GeneratedAt)
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM security_scores WHERE day = ? OR day < ?`,
+		day, securityScoreDay(board.GeneratedAt.AddDate(0, 0, -securityScoreRetentionDays))); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO security_scores (day, host_id, stack, score, critical, high, stale_fixes, privileged, containers)
+		VALUES (?, NULLIF(?, 0), ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	scores := append([]models.SecurityScore{board.Fleet}, board.Hosts...)
+	scores = append(scores, board.Stacks...)
+	for _, s := range scores {
+		if _, err := stmt.Exec(day, s.HostID, s.Stack, s.Score, s.Critical, s.High, s.StaleFixes, s.Privileged, s.Containers); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetSecurityScoreHistory returns the daily scores of the fleet (host 0), a host, or a stack on a
+// host since the given time, oldest first
+func (db *DB) GetSecurityScoreHistory(hostID int64, stack string, since time.Time) ([]models.SecurityScorePoint, error) {
+	rows, err := db.conn.Query(`
+		SELECT day, score, critical, high, stale_fixes, privileged, containers
+		FROM security_scores
+		WHERE COALESCE(host_id, 0) = ? AND stack = ? AND day >= ?
+		ORDER BY day
+	`, hostID, stack, securityScoreDay(since))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := make([]models.SecurityScorePoint, 0)
+	for rows.Next() {
+		var p models.SecurityScorePoint
+		if err := rows.Scan(&p.Day, &p.Score, &p.Critical, &p.High, &p.StaleFixes, &p.Privileged, &p.Containers); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+// GetSecurityScoresAsOf returns the latest score recorded on or before the day of the given time
+// for each fleet, host and stack, keyed by models.SecurityScoreKey
+func (db *DB) GetSecurityScoresAsOf(t time.Time) (map[string]int, error) {
+	rows, err := db.conn.Query(`
+		SELECT COALESCE(s.host_id, 0), s.stack, s.score
+		FROM security_scores s
+		INNER JOIN (
+			SELECT COALESCE(host_id, 0) as host, stack, MAX(day) as max_day
+			FROM security_scores
+			WHERE day <= ?
+			GROUP BY COALESCE(host_id, 0), stack
+		) latest ON COALESCE(s.host_id, 0) = latest.host AND s.stack = latest.stack AND s.day = latest.max_day
+	`, securityScoreDay(t))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	scores := make(map[string]int)
+	for rows.Next() {
+		var hostID int64
+		var stack string
+		var score int
+		if err := rows.Scan(&hostID, &stack, &score); err != nil {
+			return nil, err
+		}
+		scores[models.SecurityScoreKey(hostID, stack)] = score
+	}
+	return scores, rows.Err()
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestSecurityScores(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	board := func(at time.Time, fleet, host, stack int) *models.SecurityScoreboard {
+		return &models.SecurityScoreboard{
+			GeneratedAt: at,
+			Fleet:       models.SecurityScore{Score: fleet, Critical: 1},
+			Hosts:       []models.SecurityScore{{HostID: hostID, HostName: "nas", Score: host}},
+			Stacks:      []models.SecurityScore{{HostID: hostID, HostName: "nas", Stack: "media", Score: stack}},
+		}
+	}
+
+	now := time.Now()
+	for i, b := range []*models.SecurityScoreboard{
+		board(now.AddDate(-2, 0, 0), 10, 10, 10), // pruned
+		board(now.AddDate(0, 0, -7), 60, 55, 70),
+		board(now.AddDate(0, 0, -1), 70, 65, 80),
+		board(now, 75, 72, 90),
+		board(now, 80, 75, 95), // replaces today's
+	} {
+		if err := db.SaveSecurityScores(b); err != nil {
+			t.Fatalf("SaveSecurityScores %d failed: %v", i, err)
+		}
+	}
+
+	fleet, err := db.GetSecurityScoreHistory(0, "", now.AddDate(-3, 0, 0))
+	if err != nil {
+		t.Fatalf("GetSecurityScoreHistory failed: %v", err)
+	}
+	if len(fleet) != 3 || fleet[0].Score != 60 || fleet[2].Score != 80 || fleet[2].Critical != 1 || fleet[2].Day != now.Format("2006-01-02") {
+		t.Errorf("Expected three fleet scores ending with today's latest, got %+v", fleet)
+	}
+	stack, _ := db.GetSecurityScoreHistory(hostID, "media", now.AddDate(0, 0, -2))
+	if len(stack) != 2 || stack[0].Score != 80 || stack[1].Score != 95 {
+		t.Errorf("Expected the media scores of the last two days, got %+v", stack)
+	}
+
+	asOf, err := db.GetSecurityScoresAsOf(now.AddDate(0, 0, -3))
+	if err != nil {
+		t.Fatalf("GetSecurityScoresAsOf failed: %v", err)
+	}
+	if len(asOf) != 3 || asOf[models.SecurityScoreKey(0, "")] != 60 || asOf[models.SecurityScoreKey(hostID, "")] != 55 ||
+		asOf[models.SecurityScoreKey(hostID, "media")] != 70 {
+		t.Errorf("Expected the scores of a week ago, got %+v", asOf)
+	}
+
+	if err := db.DeleteHost(hostID); err != nil {
+		t.Fatalf("Failed to delete host: %v", err)
+	}
+	if asOf, _ := db.GetSecurityScoresAsOf(now); len(asOf) != 1 {
+		t.Errorf("Expected only the fleet scores after deleting the host, got %+v", asOf)
+	}
+}
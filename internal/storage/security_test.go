@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestContainerSecurity(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "host1", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	container := func(id, name string, at time.Time, privileged bool) models.Container {
+		return models.Container{
+			ID: id, Name: name, Image: "app:latest", State: "running",
+			HostID: hostID, HostName: "host1", ScannedAt: at,
+			Config: &models.ContainerConfig{Privileged: privileged, NetworkMode: "bridge"},
+		}
+	}
+	scan := func(containers ...models.Container) {
+		if err := db.SaveContainers(containers); err != nil {
+			t.Fatalf("Failed to save containers: %v", err)
+		}
+	}
+	newlyPrivileged := func() []string {
+		containers, err := db.GetNewlyPrivilegedContainers(hostID)
+		if err != nil {
+			t.Fatalf("GetNewlyPrivilegedContainers failed: %v", err)
+		}
+		names := make([]string, 0, len(containers))
+		for _, c := range containers {
+			names = append(names, c.ContainerName)
+		}
+		return names
+	}
+
+	start := time.Now().Add(-time.Hour)
+
+	// First scan: web is unprivileged
+	scan(container("web1", "web", start, false), container("db1", "db", start, false))
+	if names := newlyPrivileged(); len(names) != 0 {
+		t.Errorf("Expected no newly privileged containers, got %v", names)
+	}
+
+	// Second scan: web becomes privileged
+	scan(container("web1", "web", start.Add(time.Minute), true), container("db1", "db", start.Add(time.Minute), false))
+	if names := newlyPrivileged(); len(names) != 1 || names[0] != "web" {
+		t.Errorf("Expected web to be newly privileged, got %v", names)
+	}
+
+	// Third scan: still privileged, so it isn't new anymore
+	scan(container("web1", "web", start.Add(2*time.Minute), true), container("db1", "db", start.Add(2*time.Minute), false))
+	if names := newlyPrivileged(); len(names) != 0 {
+		t.Errorf("Expected no newly privileged containers on a rescan, got %v", names)
+	}
+
+	// Fourth scan: web is recreated with a new ID and stays privileged
+	scan(container("web2", "web", start.Add(3*time.Minute), true), container("db1", "db", start.Add(3*time.Minute), false))
+	if names := newlyPrivileged(); len(names) != 0 {
+		t.Errorf("Expected recreated privileged container not to count as new, got %v", names)
+	}
+
+	all, err := db.GetContainerSecurity(models.ContainerSecurityFilter{})
+	if err != nil {
+		t.Fatalf("GetContainerSecurity failed: %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("Expected the 2 containers of the latest scan, got %d", len(all))
+	}
+	if all[0].ContainerID != "web2" || all[0].Risk.Level != models.RiskLevelCritical || all[0].PrivilegedSince == nil {
+		t.Errorf("Expected privileged web2 first, got %+v", all[0])
+	}
+
+	privileged, err := db.GetContainerSecurity(models.ContainerSecurityFilter{HostID: hostID, Finding: "privileged"})
+	if err != nil {
+		t.Fatalf("GetContainerSecurity failed: %v", err)
+	}
+	if len(privileged) != 1 || privileged[0].ContainerName != "web" {
+		t.Errorf("Expected only web with the privileged finding, got %+v", privileged)
+	}
+
+	risky, err := db.GetContainerSecurity(models.ContainerSecurityFilter{MinScore: 1})
+	if err != nil {
+		t.Fatalf("GetContainerSecurity failed: %v", err)
+	}
+	if len(risky) != 1 {
+		t.Errorf("Expected only 1 container with a risk score, got %d", len(risky))
+	}
+}
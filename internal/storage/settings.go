@@ -25,6 +25,11 @@ func GetDefaultSettings() *models.SystemSettings {
 			RateLimitBatchInterval: 600, // 10 minutes
 			ThresholdDuration:      120, // 2 minutes
 			CooldownPeriod:         300, // 5 minutes
+			LogRetentionDays:       models.DefaultNotificationRetentionDays,
+			LogRetentionCount:      models.DefaultNotificationRetentionCount,
+
+			HostDownAfterFailures:       models.DefaultHostDownAfterFailures,
+			HostRecoveredAfterSuccesses: models.DefaultHostRecoveredAfterSuccesses,
 		},
 		UI: models.UISettings{
 			CardDesign: "material", // Default to Design 2 (Spacious Material)
@@ -66,11 +71,19 @@ func (db *DB) LoadSystemSettings() (*models.SystemSettings, error) {
 	if err := db.loadCategorySetting("scanner", "timeout_seconds", &settings.Scanner.TimeoutSeconds); err != nil {
 		settings.Scanner.TimeoutSeconds = 30 // Default
 	}
+	db.loadCategorySetting("scanner", "inventory_objects", &settings.Scanner.InventoryObjects)
 
 	// Load telemetry settings
 	if err := db.loadCategorySetting("telemetry", "interval_hours", &settings.Telemetry.IntervalHours); err != nil {
 		settings.Telemetry.IntervalHours = 168 // Default
 	}
+	// Opt-out flags default to false (share everything) when missing
+	db.loadCategorySetting("telemetry", "exclude_resource_stats", &settings.Telemetry.ExcludeResourceStats)
+	db.loadCategorySetting("telemetry", "exclude_image_list", &settings.Telemetry.ExcludeImageList)
+	db.loadCategorySetting("telemetry", "exclude_architecture_metrics", &settings.Telemetry.ExcludeArchitectureMetrics)
+	db.loadCategorySetting("telemetry", "exclude_timezone", &settings.Telemetry.ExcludeTimezone)
+	// Opt-in, defaults to false
+	db.loadCategorySetting("telemetry", "share_update_lag", &settings.Telemetry.ShareUpdateLag)
 
 	// Load notification settings
 	if err := db.loadCategorySetting("notification", "rate_limit_max", &settings.Notification.RateLimitMax); err != nil {
@@ -85,6 +98,18 @@ func (db *DB) LoadSystemSettings() (*models.SystemSettings, error) {
 	if err := db.loadCategorySetting("notification", "cooldown_period", &settings.Notification.CooldownPeriod); err != nil {
 		settings.Notification.CooldownPeriod = 300 // Default
 	}
+	if err := db.loadCategorySetting("notification", "log_retention_days", &settings.Notification.LogRetentionDays); err != nil {
+		settings.Notification.LogRetentionDays = models.DefaultNotificationRetentionDays
+	}
+	if err := db.loadCategorySetting("notification", "log_retention_count", &settings.Notification.LogRetentionCount); err != nil {
+		settings.Notification.LogRetentionCount = models.DefaultNotificationRetentionCount
+	}
+	if err := db.loadCategorySetting("notification", "host_down_after_failures", &settings.Notification.HostDownAfterFailures); err != nil {
+		settings.Notification.HostDownAfterFailures = models.DefaultHostDownAfterFailures
+	}
+	if err := db.loadCategorySetting("notification", "host_recovered_after_successes", &settings.Notification.HostRecoveredAfterSuccesses); err != nil {
+		settings.Notification.HostRecoveredAfterSuccesses = models.DefaultHostRecoveredAfterSuccesses
+	}
 
 	// Load UI settings
 	if err := db.loadCategorySetting("ui", "card_design", &settings.UI.CardDesign); err != nil {
@@ -143,11 +168,29 @@ func (db *DB) SaveSystemSettings(settings *models.SystemSettings) error {
 	if err := db.saveSetting(tx, "scanner", "timeout_seconds", settings.Scanner.TimeoutSeconds, "int", "Scan timeout in seconds", now); err != nil {
 		return err
 	}
+	if err := db.saveSetting(tx, "scanner", "inventory_objects", settings.Scanner.InventoryObjects, "bool", "List plugins, Swarm secrets and configs, and builder cache during scans", now); err != nil {
+		return err
+	}
 
 	// Save telemetry settings
 	if err := db.saveSetting(tx, "telemetry", "interval_hours", settings.Telemetry.IntervalHours, "int", "Telemetry submission interval in hours", now); err != nil {
 		return err
 	}
+	if err := db.saveSetting(tx, "telemetry", "exclude_resource_stats", settings.Telemetry.ExcludeResourceStats, "bool", "Exclude resource usage and restart stats from telemetry", now); err != nil {
+		return err
+	}
+	if err := db.saveSetting(tx, "telemetry", "exclude_image_list", settings.Telemetry.ExcludeImageList, "bool", "Exclude per-image list from telemetry", now); err != nil {
+		return err
+	}
+	if err := db.saveSetting(tx, "telemetry", "exclude_architecture_metrics", settings.Telemetry.ExcludeArchitectureMetrics, "bool", "Exclude compose/network/volume metrics from telemetry", now); err != nil {
+		return err
+	}
+	if err := db.saveSetting(tx, "telemetry", "exclude_timezone", settings.Telemetry.ExcludeTimezone, "bool", "Exclude timezone from telemetry", now); err != nil {
+		return err
+	}
+	if err := db.saveSetting(tx, "telemetry", "share_update_lag", settings.Telemetry.ShareUpdateLag, "bool", "Share update lag (containers behind upstream) in telemetry", now); err != nil {
+		return err
+	}
 
 	// Save notification settings
 	if err := db.saveSetting(tx, "notification", "rate_limit_max", settings.Notification.RateLimitMax, "int", "Maximum notifications per hour", now); err != nil {
@@ -162,6 +205,18 @@ func (db *DB) SaveSystemSettings(settings *models.SystemSettings) error {
 	if err := db.saveSetting(tx, "notification", "cooldown_period", settings.Notification.CooldownPeriod, "int", "Cooldown between alerts for same container in seconds", now); err != nil {
 		return err
 	}
+	if err := db.saveSetting(tx, "notification", "log_retention_days", settings.Notification.RetentionDays(), "int", "Days to keep notification log entries", now); err != nil {
+		return err
+	}
+	if err := db.saveSetting(tx, "notification", "log_retention_count", settings.Notification.RetentionCount(), "int", "Most recent notification log entries kept regardless of age", now); err != nil {
+		return err
+	}
+	if err := db.saveSetting(tx, "notification", "host_down_after_failures", settings.Notification.DownAfterFailures(), "int", "Failed scans in a row before a host is reported down", now); err != nil {
+		return err
+	}
+	if err := db.saveSetting(tx, "notification", "host_recovered_after_successes", settings.Notification.RecoveredAfterSuccesses(), "int", "Successful scans in a row before a down host is reported recovered", now); err != nil {
+		return err
+	}
 
 	// Save UI settings
 	if err := db.saveSetting(tx, "ui", "card_design", settings.UI.CardDesign, "string", "Container card design theme (compact, material, dashboard)", now); err != nil {
@@ -364,3 +419,28 @@ func (db *DB) DeleteTelemetryEndpoint(name string) error {
 
 	return nil
 }
+
+// GetReadOnlyMode reports whether read-only mode was switched on in the settings. It's kept
+// apart from SystemSettings so saving the other settings can't switch it off.
+func (db *DB) GetReadOnlyMode() (bool, error) {
+	var enabled bool
+	err := db.loadCategorySetting("safety", "read_only", &enabled)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return enabled, err
+}
+
+// SetReadOnlyMode switches read-only mode on or off
+func (db *DB) SetReadOnlyMode(enabled bool) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := db.saveSetting(tx, "safety", "read_only", enabled, "bool", "Disable Docker operations that change containers or images", time.Now()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,266 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// snapshotDateFormat is the layout of environment_snapshots.snapshot_date
+const snapshotDateFormat = "2006-01-02"
+
+// Environment snapshot operations
+
+// CreateEnvironmentSnapshot stores the current container inventory as the snapshot for the given day.
+// Taking a second snapshot on the same day replaces the earlier one.
+func (db *DB) CreateEnvironmentSnapshot(at time.Time) (*models.EnvironmentSnapshot, error) {
+	containers, err := db.GetLatestContainers()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &models.EnvironmentSnapshot{
+		SnapshotDate: at.UTC().Format(snapshotDateFormat),
+		CreatedAt:    at,
+		Containers:   make([]models.SnapshotContainer, 0, len(containers)),
+	}
+
+	hosts := make(map[int64]bool)
+	for _, c := range containers {
+		hosts[c.HostID] = true
+		snapshot.Containers = append(snapshot.Containers, models.SnapshotContainer{
+			ContainerID:   c.ID,
+			ContainerName: c.Name,
+			Image:         c.Image,
+			ImageID:       c.ImageID,
+			State:         c.State,
+			HostID:        c.HostID,
+			HostName:      c.HostName,
+		})
+	}
+	snapshot.HostCount = len(hosts)
+	snapshot.ContainerCount = len(snapshot.Containers)
+
+	containersJSON, err := json.Marshal(snapshot.Containers)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.conn.QueryRow(`
+		INSERT INTO environment_snapshots (snapshot_date, created_at, host_count, container_count, containers)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(snapshot_date) DO UPDATE SET
+			created_at = excluded.created_at,
+			host_count = excluded.host_count,
+			container_count = excluded.container_count,
+			containers = excluded.containers
+		RETURNING id
+	`, snapshot.SnapshotDate, snapshot.CreatedAt, snapshot.HostCount, snapshot.ContainerCount, string(containersJSON)).Scan(&snapshot.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// GetEnvironmentSnapshots returns snapshot metadata (without containers), newest first
+func (db *DB) GetEnvironmentSnapshots() ([]models.EnvironmentSnapshot, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, snapshot_date, created_at, host_count, container_count
+		FROM environment_snapshots
+		ORDER BY snapshot_date DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := make([]models.EnvironmentSnapshot, 0)
+	for rows.Next() {
+		var s models.EnvironmentSnapshot
+		if err := rows.Scan(&s.ID, &s.SnapshotDate, &s.CreatedAt, &s.HostCount, &s.ContainerCount); err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, s)
+	}
+
+	return snapshots, rows.Err()
+}
+
+// GetEnvironmentSnapshotAt returns the most recent snapshot taken on or before the given day.
+// Returns nil if no such snapshot exists.
+func (db *DB) GetEnvironmentSnapshotAt(at time.Time) (*models.EnvironmentSnapshot, error) {
+	var s models.EnvironmentSnapshot
+	var containersJSON string
+	err := db.conn.QueryRow(`
+		SELECT id, snapshot_date, created_at, host_count, container_count, containers
+		FROM environment_snapshots
+		WHERE snapshot_date <= ?
+		ORDER BY snapshot_date DESC
+		LIMIT 1
+	`, at.UTC().Format(snapshotDateFormat)).Scan(&s.ID, &s.SnapshotDate, &s.CreatedAt, &s.HostCount, &s.ContainerCount, &containersJSON)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(containersJSON), &s.Containers); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// CleanupOldEnvironmentSnapshots deletes snapshots older than the given number of days
+func (db *DB) CleanupOldEnvironmentSnapshots(olderThanDays int) (int64, error) {
+	cutoff := time.Now().UTC().AddDate(0, 0, -olderThanDays).Format(snapshotDateFormat)
+	result, err := db.conn.Exec(`DELETE FROM environment_snapshots WHERE snapshot_date < ?`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DiffEnvironmentSnapshots compares the snapshots in effect at the two dates and returns
+// the differences in the same shape as GetChangesReport. Returns nil if either date has no snapshot.
+func (db *DB) DiffEnvironmentSnapshots(from, to time.Time, hostFilter int64) (*models.ChangesReport, error) {
+	fromSnapshot, err := db.GetEnvironmentSnapshotAt(from)
+	if err != nil || fromSnapshot == nil {
+		return nil, err
+	}
+	toSnapshot, err := db.GetEnvironmentSnapshotAt(to)
+	if err != nil || toSnapshot == nil {
+		return nil, err
+	}
+
+	report := diffEnvironmentSnapshots(fromSnapshot, toSnapshot, hostFilter)
+	report.Annotations, err = db.GetAnnotations(reportAnnotationFilter(from, to, hostFilter))
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// diffEnvironmentSnapshots builds a changes report from two snapshots. Containers are matched
+// by host and name, since recreated containers get new IDs.
+func diffEnvironmentSnapshots(from, to *models.EnvironmentSnapshot, hostFilter int64) *models.ChangesReport {
+	report := &models.ChangesReport{
+		Period: models.ReportPeriod{
+			Start:         from.CreatedAt,
+			End:           to.CreatedAt,
+			DurationHours: int(to.CreatedAt.Sub(from.CreatedAt).Hours()),
+		},
+		NewContainers:     make([]models.ContainerChange, 0),
+		RemovedContainers: make([]models.ContainerChange, 0),
+		ImageUpdates:      make([]models.ImageUpdateChange, 0),
+		StateChanges:      make([]models.StateChange, 0),
+		TopRestarted:      make([]models.RestartSummary, 0),
+		Stacks:            make([]models.StackChange, 0), // snapshots don't record compose projects
+		Annotations:       make([]models.Annotation, 0),
+	}
+
+	key := func(c models.SnapshotContainer) string {
+		return strconv.FormatInt(c.HostID, 10) + "/" + c.ContainerName
+	}
+	index := func(s *models.EnvironmentSnapshot) map[string]models.SnapshotContainer {
+		m := make(map[string]models.SnapshotContainer, len(s.Containers))
+		for _, c := range s.Containers {
+			if hostFilter > 0 && c.HostID != hostFilter {
+				continue
+			}
+			m[key(c)] = c
+		}
+		return m
+	}
+	before := index(from)
+	after := index(to)
+
+	hosts := make(map[int64]bool)
+	for k, c := range after {
+		hosts[c.HostID] = true
+		old, existed := before[k]
+		if !existed {
+			report.NewContainers = append(report.NewContainers, models.ContainerChange{
+				ContainerID:   c.ContainerID,
+				ContainerName: c.ContainerName,
+				Image:         c.Image,
+				HostID:        c.HostID,
+				HostName:      c.HostName,
+				Timestamp:     to.CreatedAt,
+				State:         c.State,
+			})
+			continue
+		}
+
+		if old.ImageID != c.ImageID {
+			report.ImageUpdates = append(report.ImageUpdates, models.ImageUpdateChange{
+				ContainerID:   c.ContainerID,
+				ContainerName: c.ContainerName,
+				HostID:        c.HostID,
+				HostName:      c.HostName,
+				OldImage:      old.Image,
+				NewImage:      c.Image,
+				OldImageID:    old.ImageID,
+				NewImageID:    c.ImageID,
+				UpdatedAt:     to.CreatedAt,
+			})
+		}
+		if old.State != c.State {
+			report.StateChanges = append(report.StateChanges, models.StateChange{
+				ContainerID:   c.ContainerID,
+				ContainerName: c.ContainerName,
+				HostID:        c.HostID,
+				HostName:      c.HostName,
+				OldState:      old.State,
+				NewState:      c.State,
+				ChangedAt:     to.CreatedAt,
+			})
+		}
+	}
+
+	for k, c := range before {
+		if _, exists := after[k]; exists {
+			continue
+		}
+		report.RemovedContainers = append(report.RemovedContainers, models.ContainerChange{
+			ContainerID:   c.ContainerID,
+			ContainerName: c.ContainerName,
+			Image:         c.Image,
+			HostID:        c.HostID,
+			HostName:      c.HostName,
+			Timestamp:     from.CreatedAt,
+			State:         c.State,
+		})
+	}
+
+	// Map iteration order is random - sort for stable output
+	sort.Slice(report.NewContainers, func(i, j int) bool {
+		return report.NewContainers[i].HostName+report.NewContainers[i].ContainerName < report.NewContainers[j].HostName+report.NewContainers[j].ContainerName
+	})
+	sort.Slice(report.RemovedContainers, func(i, j int) bool {
+		return report.RemovedContainers[i].HostName+report.RemovedContainers[i].ContainerName < report.RemovedContainers[j].HostName+report.RemovedContainers[j].ContainerName
+	})
+	sort.Slice(report.ImageUpdates, func(i, j int) bool {
+		return report.ImageUpdates[i].HostName+report.ImageUpdates[i].ContainerName < report.ImageUpdates[j].HostName+report.ImageUpdates[j].ContainerName
+	})
+	sort.Slice(report.StateChanges, func(i, j int) bool {
+		return report.StateChanges[i].HostName+report.StateChanges[i].ContainerName < report.StateChanges[j].HostName+report.StateChanges[j].ContainerName
+	})
+
+	report.Summary = models.ReportSummary{
+		TotalHosts:        len(hosts),
+		TotalContainers:   len(after),
+		NewContainers:     len(report.NewContainers),
+		RemovedContainers: len(report.RemovedContainers),
+		ImageUpdates:      len(report.ImageUpdates),
+		StateChanges:      len(report.StateChanges),
+	}
+
+	return report
+}
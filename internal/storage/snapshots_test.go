@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestEnvironmentSnapshotDiff(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "snapshots.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	hostID, err := db.AddHost(models.Host{Name: "host1", Address: "unix:///var/run/docker.sock", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	day1 := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	day2 := time.Date(2025, 11, 1, 12, 0, 0, 0, time.UTC)
+
+	save := func(scannedAt time.Time, containers ...models.Container) {
+		for i := range containers {
+			containers[i].HostID = hostID
+			containers[i].HostName = "host1"
+			containers[i].ScannedAt = scannedAt
+			containers[i].Created = scannedAt
+		}
+		if err := db.SaveContainers(containers); err != nil {
+			t.Fatalf("Failed to save containers: %v", err)
+		}
+	}
+
+	save(day1,
+		models.Container{ID: "a1", Name: "web", Image: "nginx:1.25", ImageID: "sha256:old", State: "running"},
+		models.Container{ID: "b1", Name: "db", Image: "postgres:16", ImageID: "sha256:pg", State: "running"},
+		models.Container{ID: "c1", Name: "legacy", Image: "busybox", ImageID: "sha256:bb", State: "exited"},
+	)
+	first, err := db.CreateEnvironmentSnapshot(day1)
+	if err != nil {
+		t.Fatalf("Failed to create snapshot: %v", err)
+	}
+	if first.SnapshotDate != "2025-10-01" || first.ContainerCount != 3 || first.HostCount != 1 {
+		t.Errorf("Unexpected snapshot metadata: %+v", first)
+	}
+
+	// Recreated web (new ID and image), stopped db, removed legacy, added cache
+	save(day2,
+		models.Container{ID: "a2", Name: "web", Image: "nginx:1.27", ImageID: "sha256:new", State: "running"},
+		models.Container{ID: "b1", Name: "db", Image: "postgres:16", ImageID: "sha256:pg", State: "exited"},
+		models.Container{ID: "d1", Name: "cache", Image: "redis:7", ImageID: "sha256:redis", State: "running"},
+	)
+	if _, err := db.CreateEnvironmentSnapshot(day2); err != nil {
+		t.Fatalf("Failed to create snapshot: %v", err)
+	}
+
+	// Re-snapshotting the same day replaces the existing row
+	if _, err := db.CreateEnvironmentSnapshot(day2.Add(time.Hour)); err != nil {
+		t.Fatalf("Failed to replace snapshot: %v", err)
+	}
+	snapshots, err := db.GetEnvironmentSnapshots()
+	if err != nil {
+		t.Fatalf("Failed to list snapshots: %v", err)
+	}
+	if len(snapshots) != 2 || snapshots[0].SnapshotDate != "2025-11-01" {
+		t.Fatalf("Expected 2 snapshots newest first, got %+v", snapshots)
+	}
+
+	// Dates between snapshots resolve to the most recent earlier snapshot
+	report, err := db.DiffEnvironmentSnapshots(day1.AddDate(0, 0, 3), day2.AddDate(0, 0, 10), 0)
+	if err != nil {
+		t.Fatalf("Failed to diff snapshots: %v", err)
+	}
+	if report == nil {
+		t.Fatal("Expected a report")
+	}
+
+	if len(report.NewContainers) != 1 || report.NewContainers[0].ContainerName != "cache" {
+		t.Errorf("Expected cache to be new, got %+v", report.NewContainers)
+	}
+	if len(report.RemovedContainers) != 1 || report.RemovedContainers[0].ContainerName != "legacy" {
+		t.Errorf("Expected legacy to be removed, got %+v", report.RemovedContainers)
+	}
+	if len(report.ImageUpdates) != 1 || report.ImageUpdates[0].NewImage != "nginx:1.27" {
+		t.Errorf("Expected web image update, got %+v", report.ImageUpdates)
+	}
+	if len(report.StateChanges) != 1 || report.StateChanges[0].NewState != "exited" {
+		t.Errorf("Expected db state change, got %+v", report.StateChanges)
+	}
+	if report.Summary.TotalContainers != 3 {
+		t.Errorf("Expected 3 containers in summary, got %d", report.Summary.TotalContainers)
+	}
+
+	// Host filter excludes everything on other hosts
+	filtered, err := db.DiffEnvironmentSnapshots(day1, day2, hostID+1)
+	if err != nil {
+		t.Fatalf("Failed to diff snapshots: %v", err)
+	}
+	if filtered.Summary.TotalContainers != 0 || len(filtered.NewContainers) != 0 {
+		t.Errorf("Expected empty filtered diff, got %+v", filtered.Summary)
+	}
+
+	// No snapshot before the first one
+	missing, err := db.DiffEnvironmentSnapshots(day1.AddDate(0, 0, -1), day2, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if missing != nil {
+		t.Error("Expected nil report when no snapshot exists for the from date")
+	}
+}
@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// GetContainerStatsBatch returns the time-series stats of several containers as GetContainerStats
+// does for one, with one query per table for all of them. Containers are matched by the first 12
+// characters of their ID, so short and full IDs find the same rows. Every key is in the result,
+// with an empty slice when it has no stats.
+func (db *DB) GetContainerStatsBatch(keys []models.ContainerStatsKey, hoursBack int) (map[models.ContainerStatsKey][]models.ContainerStatsPoint, error) {
+	result := make(map[models.ContainerStatsKey][]models.ContainerStatsPoint, len(keys))
+	if len(keys) == 0 {
+		return result, nil
+	}
+
+	var startTime time.Time // zero gets all records
+	if hoursBack > 0 {
+		startTime = time.Now().Add(-time.Duration(hoursBack) * time.Hour)
+	}
+
+	type shortKey struct {
+		hostID int64
+		prefix string
+	}
+	owners := make(map[shortKey][]models.ContainerStatsKey)
+	hostIDs := make(map[int64]bool)
+	prefixes := make(map[string]bool)
+	for _, key := range keys {
+		if _, ok := result[key]; ok {
+			continue
+		}
+		result[key] = make([]models.ContainerStatsPoint, 0)
+		prefix := shortContainerID(key.ContainerID)
+		owners[shortKey{key.HostID, prefix}] = append(owners[shortKey{key.HostID, prefix}], key)
+		hostIDs[key.HostID] = true
+		prefixes[prefix] = true
+	}
+
+	var args []interface{}
+	for id := range hostIDs {
+		args = append(args, id)
+	}
+	hostPlaceholders := strings.TrimSuffix(strings.Repeat("?,", len(hostIDs)), ",")
+	for prefix := range prefixes {
+		args = append(args, prefix)
+	}
+	prefixPlaceholders := strings.TrimSuffix(strings.Repeat("?,", len(prefixes)), ",")
+	args = append(args, startTime)
+
+	add := func(hostID int64, prefix string, point models.ContainerStatsPoint) {
+		for _, key := range owners[shortKey{hostID, prefix}] {
+			result[key] = append(result[key], point)
+		}
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT host_id, substr(id, 1, 12), scanned_at, cpu_percent, memory_usage, memory_limit, memory_percent,
+		       network_rx_rate, network_tx_rate, block_read_rate, block_write_rate
+		FROM containers
+		WHERE host_id IN (`+hostPlaceholders+`) AND substr(id, 1, 12) IN (`+prefixPlaceholders+`) AND scanned_at >= ?
+		  AND (cpu_percent IS NOT NULL OR memory_usage IS NOT NULL)
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hostID int64
+		var prefix string
+		var point models.ContainerStatsPoint
+		var cpuPercent, memoryPercent sql.NullFloat64
+		var memoryUsage, memoryLimit sql.NullInt64
+		var networkRxRate, networkTxRate, blockReadRate, blockWriteRate sql.NullFloat64
+		if err := rows.Scan(&hostID, &prefix, &point.Timestamp, &cpuPercent, &memoryUsage, &memoryLimit, &memoryPercent,
+			&networkRxRate, &networkTxRate, &blockReadRate, &blockWriteRate); err != nil {
+			return nil, err
+		}
+		point.CPUPercent = cpuPercent.Float64
+		point.MemoryUsage = memoryUsage.Int64
+		point.MemoryLimit = memoryLimit.Int64
+		point.MemoryPercent = memoryPercent.Float64
+		point.NetworkRxRate = networkRxRate.Float64
+		point.NetworkTxRate = networkTxRate.Float64
+		point.BlockReadRate = blockReadRate.Float64
+		point.BlockWriteRate = blockWriteRate.Float64
+		add(hostID, prefix, point)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Hourly aggregates cover what is older than the granular data
+	if hoursBack == 0 || hoursBack > 1 {
+		aggRows, err := db.conn.Query(`
+			SELECT host_id, substr(container_id, 1, 12), timestamp_hour, avg_cpu_percent, avg_memory_usage,
+			       avg_network_rx_rate, avg_network_tx_rate, avg_block_read_rate, avg_block_write_rate,
+			       max_cpu_percent, p95_cpu_percent, p99_cpu_percent, max_memory_usage, p95_memory_usage, p99_memory_usage
+			FROM container_stats_aggregates
+			WHERE host_id IN (`+hostPlaceholders+`) AND substr(container_id, 1, 12) IN (`+prefixPlaceholders+`) AND timestamp_hour >= ?
+		`, args...)
+		if err != nil {
+			return nil, err
+		}
+		defer aggRows.Close()
+
+		for aggRows.Next() {
+			var hostID int64
+			var prefix string
+			var point models.ContainerStatsPoint
+			var avgCPU, avgMemory sql.NullFloat64
+			var networkRxRate, networkTxRate, blockReadRate, blockWriteRate sql.NullFloat64
+			var spread aggregateSpread
+			if err := aggRows.Scan(&hostID, &prefix, &point.Timestamp, &avgCPU, &avgMemory,
+				&networkRxRate, &networkTxRate, &blockReadRate, &blockWriteRate,
+				&spread.maxCPU, &spread.p95CPU, &spread.p99CPU, &spread.maxMemory, &spread.p95Memory, &spread.p99Memory); err != nil {
+				return nil, err
+			}
+			spread.apply(&point)
+			point.CPUPercent = avgCPU.Float64
+			point.MemoryUsage = int64(avgMemory.Float64)
+			point.NetworkRxRate = networkRxRate.Float64
+			point.NetworkTxRate = networkTxRate.Float64
+			point.BlockReadRate = blockReadRate.Float64
+			point.BlockWriteRate = blockWriteRate.Float64
+			add(hostID, prefix, point)
+		}
+		if err := aggRows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	for key, points := range result {
+		sort.SliceStable(points, func(i, j int) bool {
+			return points[i].Timestamp.Before(points[j].Timestamp)
+		})
+		result[key] = points
+	}
+	return result, nil
+}
+
+// shortContainerID returns the 12-character short form of a container ID
+func shortContainerID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
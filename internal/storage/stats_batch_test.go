@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestGetContainerStatsBatch(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostA, err := db.AddHost(models.Host{Name: "nas", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	hostB, err := db.AddHost(models.Host{Name: "vps", Address: "agent://vps:9876", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	// The same container ID on two hosts, plus a second container; some scans old enough to aggregate
+	oldScan := time.Now().Add(-3 * time.Hour).Truncate(time.Hour).Add(10 * time.Minute)
+	scans := []time.Time{oldScan, oldScan.Add(time.Minute), time.Now().Add(-10 * time.Minute), time.Now().Add(-5 * time.Minute)}
+	for i, scannedAt := range scans {
+		containers := []models.Container{
+			{ID: "aaaaaaaaaaaa1111", Name: "web", Image: "nginx", State: "running", HostID: hostA, ScannedAt: scannedAt, CPUPercent: float64(10 + i), MemoryUsage: 1000, MemoryLimit: 4000},
+			{ID: "bbbbbbbbbbbb2222", Name: "db", Image: "postgres", State: "running", HostID: hostA, ScannedAt: scannedAt, CPUPercent: float64(20 + i), MemoryUsage: 2000, MemoryLimit: 4000},
+		}
+		if err := db.SaveContainers(containers); err != nil {
+			t.Fatalf("Failed to save containers: %v", err)
+		}
+		other := models.Container{ID: "aaaaaaaaaaaa1111", Name: "web", Image: "nginx", State: "running", HostID: hostB, ScannedAt: scannedAt, CPUPercent: 99, MemoryUsage: 3000, MemoryLimit: 4000}
+		if err := db.SaveContainers([]models.Container{other}); err != nil {
+			t.Fatalf("Failed to save containers: %v", err)
+		}
+	}
+	if _, err := db.AggregateOldStats(); err != nil {
+		t.Fatalf("AggregateOldStats failed: %v", err)
+	}
+
+	keys := []models.ContainerStatsKey{
+		{HostID: hostA, ContainerID: "aaaaaaaaaaaa1111"},
+		{HostID: hostA, ContainerID: "bbbbbbbbbbbb"}, // short ID
+		{HostID: hostB, ContainerID: "aaaaaaaaaaaa1111"},
+		{HostID: hostB, ContainerID: "cccccccccccc3333"}, // no stats
+	}
+	for _, hoursBack := range []int{0, 1} {
+		batch, err := db.GetContainerStatsBatch(keys, hoursBack)
+		if err != nil {
+			t.Fatalf("GetContainerStatsBatch(%d) failed: %v", hoursBack, err)
+		}
+		if len(batch) != len(keys) {
+			t.Fatalf("Expected every key in the result, got %d", len(batch))
+		}
+		if len(batch[keys[0]]) < 2 || len(batch[keys[3]]) != 0 {
+			t.Errorf("hoursBack %d: expected points for web and none for the unknown container, got %+v", hoursBack, batch)
+		}
+		for _, key := range keys {
+			single, err := db.GetContainerStats(key.ContainerID, key.HostID, hoursBack)
+			if err != nil {
+				t.Fatalf("GetContainerStats failed: %v", err)
+			}
+			if !reflect.DeepEqual(batch[key], single) {
+				t.Errorf("hoursBack %d, %+v: batch %+v, single %+v", hoursBack, key, batch[key], single)
+			}
+		}
+	}
+
+	empty, err := db.GetContainerStatsBatch(nil, 0)
+	if err != nil || len(empty) != 0 {
+		t.Errorf("Expected an empty result for no keys, got %v, %v", empty, err)
+	}
+}
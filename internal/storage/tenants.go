@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// CreateTenant adds a tenant and returns its ID
+func (db *DB) CreateTenant(name string) (int64, error) {
+	result, err := db.conn.Exec(`INSERT INTO tenants (name, created_at) VALUES (?, ?)`, name, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// GetTenants returns every tenant with its users and hosts, ordered by name
+func (db *DB) GetTenants() ([]models.Tenant, error) {
+	rows, err := db.conn.Query(`SELECT id, name, created_at FROM tenants ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	tenants := make([]models.Tenant, 0)
+	index := make(map[int64]int)
+	for rows.Next() {
+		t := models.Tenant{Users: []models.TenantUser{}, HostIDs: []int64{}}
+		if err := rows.Scan(&t.ID, &t.Name, &t.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		index[t.ID] = len(tenants)
+		tenants = append(tenants, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	users, err := db.conn.Query(`SELECT id, tenant_id, username, created_at FROM tenant_users ORDER BY username`)
+	if err != nil {
+		return nil, err
+	}
+	for users.Next() {
+		var u models.TenantUser
+		if err := users.Scan(&u.ID, &u.TenantID, &u.Username, &u.CreatedAt); err != nil {
+			users.Close()
+			return nil, err
+		}
+		if i, ok := index[u.TenantID]; ok {
+			tenants[i].Users = append(tenants[i].Users, u)
+		}
+	}
+	users.Close()
+	if err := users.Err(); err != nil {
+		return nil, err
+	}
+
+	hosts, err := db.conn.Query(`SELECT id, tenant_id FROM hosts WHERE tenant_id != 0 ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer hosts.Close()
+	for hosts.Next() {
+		var hostID, tenantID int64
+		if err := hosts.Scan(&hostID, &tenantID); err != nil {
+			return nil, err
+		}
+		if i, ok := index[tenantID]; ok {
+			tenants[i].HostIDs = append(tenants[i].HostIDs, hostID)
+		}
+	}
+	return tenants, hosts.Err()
+}
+
+// TenantExists reports whether a tenant with the ID exists
+func (db *DB) TenantExists(id int64) (bool, error) {
+	var count int
+	err := db.conn.QueryRow(`SELECT COUNT(*) FROM tenants WHERE id = ?`, id).Scan(&count)
+	return count > 0, err
+}
+
+// DeleteTenant removes a tenant with its users, notification rules and channels. Its hosts
+// stay and go back to the administrator.
+func (db *DB) DeleteTenant(id int64) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		`DELETE FROM notification_rule_channels WHERE rule_id IN (SELECT id FROM notification_rules WHERE tenant_id = ?)`,
+		`DELETE FROM notification_rules WHERE tenant_id = ?`,
+		`DELETE FROM notification_rule_channels WHERE channel_id IN (SELECT id FROM notification_channels WHERE tenant_id = ?)`,
+		`DELETE FROM notification_channels WHERE tenant_id = ?`,
+		`DELETE FROM user_preference_overrides WHERE username IN (SELECT username FROM tenant_users WHERE tenant_id = ?)`,
+		`DELETE FROM tenant_users WHERE tenant_id = ?`,
+		`UPDATE hosts SET tenant_id = 0 WHERE tenant_id = ?`,
+		`DELETE FROM tenants WHERE id = ?`,
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// AddTenantUser adds a login to a tenant; passwordHash comes from auth.HashPassword
+func (db *DB) AddTenantUser(tenantID int64, username, passwordHash string) (int64, error) {
+	result, err := db.conn.Exec(`
+		INSERT INTO tenant_users (tenant_id, username, password_hash, created_at)
+		VALUES (?, ?, ?, ?)
+	`, tenantID, username, passwordHash, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// DeleteTenantUser removes a login of a tenant; it returns sql.ErrNoRows if the tenant has no such user
+func (db *DB) DeleteTenantUser(tenantID, userID int64) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		DELETE FROM user_preference_overrides
+		WHERE username = (SELECT username FROM tenant_users WHERE id = ? AND tenant_id = ?)
+	`, userID, tenantID); err != nil {
+		return err
+	}
+	result, err := tx.Exec(`DELETE FROM tenant_users WHERE id = ? AND tenant_id = ?`, userID, tenantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return tx.Commit()
+}
+
+// GetTenantUserLogin returns a tenant user and their password hash; ok is false for unknown usernames
+func (db *DB) GetTenantUserLogin(username string) (user models.TenantUser, passwordHash string, ok bool, err error) {
+	err = db.conn.QueryRow(`
+		SELECT id, tenant_id, username, created_at, password_hash FROM tenant_users WHERE username = ?
+	`, username).Scan(&user.ID, &user.TenantID, &user.Username, &user.CreatedAt, &passwordHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.TenantUser{}, "", false, nil
+	}
+	if err != nil {
+		return models.TenantUser{}, "", false, err
+	}
+	return user, passwordHash, true, nil
+}
+
+// GetNotificationLogTenant returns the tenant of the host a notification is about (0 for
+// notifications without a host or about the administrator's hosts)
+func (db *DB) GetNotificationLogTenant(id int64) (int64, error) {
+	var tenantID int64
+	err := db.conn.QueryRow(`
+		SELECT COALESCE(h.tenant_id, 0)
+		FROM notification_log l LEFT JOIN hosts h ON h.id = l.host_id
+		WHERE l.id = ?
+	`, id).Scan(&tenantID)
+	return tenantID, err
+}
@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestTenants(t *testing.T) {
+	db := setupTestDB(t)
+
+	tenantID, err := db.CreateTenant("family")
+	if err != nil {
+		t.Fatalf("CreateTenant failed: %v", err)
+	}
+	if _, err := db.AddTenantUser(tenantID, "kid", "hash"); err != nil {
+		t.Fatalf("AddTenantUser failed: %v", err)
+	}
+	if _, err := db.AddTenantUser(tenantID, "kid", "hash"); err == nil {
+		t.Error("Expected duplicate usernames to be rejected")
+	}
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	if err := db.SetHostTenant(hostID, tenantID); err != nil {
+		t.Fatalf("SetHostTenant failed: %v", err)
+	}
+	channel := models.NotificationChannel{Name: "family-ntfy", Type: models.ChannelTypeInApp, Config: map[string]interface{}{}, Enabled: true, TenantID: tenantID}
+	if err := db.SaveNotificationChannel(&channel); err != nil {
+		t.Fatalf("SaveNotificationChannel failed: %v", err)
+	}
+	rule := models.NotificationRule{Name: "stopped", Enabled: true, EventTypes: []string{models.EventTypeContainerStopped}, ChannelIDs: []int64{channel.ID}, TenantID: tenantID}
+	if err := db.SaveNotificationRule(&rule); err != nil {
+		t.Fatalf("SaveNotificationRule failed: %v", err)
+	}
+
+	tenants, err := db.GetTenants()
+	if err != nil {
+		t.Fatalf("GetTenants failed: %v", err)
+	}
+	if len(tenants) != 1 || len(tenants[0].Users) != 1 || len(tenants[0].HostIDs) != 1 || tenants[0].HostIDs[0] != hostID {
+		t.Fatalf("Unexpected tenants: %+v", tenants)
+	}
+	user, hash, ok, err := db.GetTenantUserLogin("kid")
+	if err != nil || !ok || hash != "hash" || user.TenantID != tenantID {
+		t.Errorf("Unexpected login: %+v %q %v %v", user, hash, ok, err)
+	}
+	if host, _ := db.GetHost(hostID); host.TenantID != tenantID {
+		t.Errorf("Expected host in tenant %d, got %d", tenantID, host.TenantID)
+	}
+
+	if err := db.DeleteTenant(tenantID); err != nil {
+		t.Fatalf("DeleteTenant failed: %v", err)
+	}
+	if _, _, ok, _ := db.GetTenantUserLogin("kid"); ok {
+		t.Error("Expected the tenant's users to be deleted")
+	}
+	if host, _ := db.GetHost(hostID); host == nil || host.TenantID != 0 {
+		t.Errorf("Expected the host to go back to the administrator, got %+v", host)
+	}
+	if rules, _ := db.GetNotificationRules(false); len(rules) != 0 {
+		t.Errorf("Expected the tenant's rules to be deleted, got %+v", rules)
+	}
+	if channels, _ := db.GetNotificationChannels(); len(channels) != 0 {
+		t.Errorf("Expected the tenant's channels to be deleted, got %+v", channels)
+	}
+}
+
+func TestUserPreferences(t *testing.T) {
+	db := setupTestDB(t)
+
+	if err := db.SetUserPreference("", "timezone", "UTC"); err != nil {
+		t.Fatalf("SetUserPreference failed: %v", err)
+	}
+	if err := db.SetUserPreference("alice", "timezone", "Europe/Berlin"); err != nil {
+		t.Fatalf("SetUserPreference failed: %v", err)
+	}
+
+	shared, err := db.GetUserPreferences("")
+	if err != nil || shared["timezone"] != "UTC" {
+		t.Errorf("Expected the shared timezone, got %v (%v)", shared, err)
+	}
+	alice, err := db.GetUserPreferences("alice")
+	if err != nil || alice["timezone"] != "Europe/Berlin" {
+		t.Errorf("Expected alice's own timezone, got %v (%v)", alice, err)
+	}
+	bob, err := db.GetUserPreferences("bob")
+	if err != nil || bob["timezone"] != "UTC" {
+		t.Errorf("Expected bob to fall back to the shared timezone, got %v (%v)", bob, err)
+	}
+
+	// Removing a tenant user removes their preferences
+	tenantID, _ := db.CreateTenant("team")
+	userID, _ := db.AddTenantUser(tenantID, "carol", "hash")
+	db.SetUserPreference("carol", "timezone", "Asia/Tokyo")
+	if err := db.DeleteTenantUser(tenantID, userID); err != nil {
+		t.Fatalf("DeleteTenantUser failed: %v", err)
+	}
+	if carol, _ := db.GetUserPreferences("carol"); carol["timezone"] != "UTC" {
+		t.Errorf("Expected carol's preferences to be removed, got %v", carol)
+	}
+}
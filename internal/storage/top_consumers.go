@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// GetTopConsumers ranks containers by average CPU, average memory and memory growth rate over
+// the last windowHours, using hourly aggregates plus not-yet-aggregated recent samples.
+// Containers are grouped by name so recreated containers keep their history.
+func (db *DB) GetTopConsumers(windowHours, limit int, hostFilter int64) (*models.TopConsumersReport, error) {
+	windowStart := time.Now().Add(-time.Duration(windowHours) * time.Hour)
+
+	// x = hours since window start, y = memory usage; the slope is computed with least squares
+	query := `
+		WITH samples AS (
+			SELECT container_id, container_name AS name, host_id, host_name, timestamp_hour AS ts,
+			       avg_cpu_percent AS cpu, max_cpu_percent AS max_cpu,
+			       avg_memory_usage AS mem, max_memory_usage AS max_mem
+			FROM container_stats_aggregates
+			WHERE timestamp_hour >= ?
+			UNION ALL
+			SELECT id, name, host_id, host_name, scanned_at,
+			       cpu_percent, cpu_percent, memory_usage, memory_usage
+			FROM containers
+			WHERE scanned_at >= ? AND cpu_percent IS NOT NULL
+		),
+		points AS (
+			SELECT *, (julianday(ts) - julianday(?)) * 24 AS x
+			FROM samples
+			WHERE (? = 0 OR host_id = ?)
+		)
+		SELECT MAX(container_id), name, host_id, MAX(host_name),
+		       AVG(cpu), MAX(max_cpu), AVG(mem), MAX(max_mem), COUNT(*),
+		       SUM(x), SUM(mem), SUM(x * x), SUM(x * mem)
+		FROM points
+		GROUP BY name, host_id
+	`
+
+	rows, err := db.conn.Query(query, windowStart, windowStart, windowStart, hostFilter, hostFilter)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var consumers []models.TopConsumer
+	for rows.Next() {
+		var c models.TopConsumer
+		var avgCPU, maxCPU, avgMem, maxMem sql.NullFloat64
+		var sumX, sumY, sumXX, sumXY sql.NullFloat64
+		if err := rows.Scan(&c.ContainerID, &c.ContainerName, &c.HostID, &c.HostName,
+			&avgCPU, &maxCPU, &avgMem, &maxMem, &c.SampleCount,
+			&sumX, &sumY, &sumXX, &sumXY); err != nil {
+			return nil, err
+		}
+
+		c.AvgCPUPercent = avgCPU.Float64
+		c.MaxCPUPercent = maxCPU.Float64
+		c.AvgMemoryUsage = int64(avgMem.Float64)
+		c.MaxMemoryUsage = int64(maxMem.Float64)
+
+		n := float64(c.SampleCount)
+		if denominator := n*sumXX.Float64 - sumX.Float64*sumX.Float64; c.SampleCount >= 3 && denominator > 0 {
+			c.MemoryGrowthPerHour = (n*sumXY.Float64 - sumX.Float64*sumY.Float64) / denominator
+			if c.AvgMemoryUsage > 0 {
+				c.MemoryGrowthPercentDay = c.MemoryGrowthPerHour * 24 / float64(c.AvgMemoryUsage) * 100
+			}
+		}
+
+		consumers = append(consumers, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	report := &models.TopConsumersReport{WindowHours: windowHours}
+	report.ByCPU = topConsumersBy(consumers, limit, func(c models.TopConsumer) float64 { return c.AvgCPUPercent })
+	report.ByMemory = topConsumersBy(consumers, limit, func(c models.TopConsumer) float64 { return float64(c.AvgMemoryUsage) })
+
+	// Only containers that are actually growing belong on the growth leaderboard
+	var growing []models.TopConsumer
+	for _, c := range consumers {
+		if c.MemoryGrowthPerHour > 0 {
+			growing = append(growing, c)
+		}
+	}
+	report.ByMemoryGrowth = topConsumersBy(growing, limit, func(c models.TopConsumer) float64 { return c.MemoryGrowthPerHour })
+
+	return report, nil
+}
+
+// topConsumersBy returns the top limit consumers sorted descending by the metric
+func topConsumersBy(consumers []models.TopConsumer, limit int, metric func(models.TopConsumer) float64) []models.TopConsumer {
+	sorted := make([]models.TopConsumer, len(consumers))
+	copy(sorted, consumers)
+	sort.SliceStable(sorted, func(i, j int) bool { return metric(sorted[i]) > metric(sorted[j]) })
+	if len(sorted) > limit {
+		sorted = sorted[:limit]
+	}
+	return sorted
+}
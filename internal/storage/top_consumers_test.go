@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestGetTopConsumers(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "top.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	hostID, err := db.AddHost(models.Host{Name: "host1", Address: "unix:///var/run/docker.sock", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	const mb = 1024 * 1024
+	aggregate := func(id, name string, at time.Time, cpu float64, mem int64) {
+		_, err := db.conn.Exec(`
+			INSERT INTO container_stats_aggregates
+			(container_id, container_name, host_id, host_name, timestamp_hour, avg_cpu_percent, avg_memory_usage, max_cpu_percent, max_memory_usage, sample_count)
+			VALUES (?, ?, ?, 'host1', ?, ?, ?, ?, ?, 12)
+		`, id, name, hostID, at.UTC(), cpu, mem, cpu*2, mem)
+		if err != nil {
+			t.Fatalf("Failed to insert aggregate: %v", err)
+		}
+	}
+
+	start := time.Now().Add(-12 * time.Hour).Truncate(time.Hour)
+	for i := 0; i < 10; i++ {
+		at := start.Add(time.Duration(i) * time.Hour)
+		aggregate("c1", "cpu-hog", at, 90, 100*mb)
+		aggregate("m1", "mem-hog", at, 5, 2000*mb)
+		aggregate("l1", "leaky", at, 10, int64(200+10*i)*mb) // grows 10MB/hour
+	}
+	// Outside the 1-day window and must be ignored
+	aggregate("o1", "old", time.Now().Add(-48*time.Hour), 99, 9000*mb)
+
+	report, err := db.GetTopConsumers(24, 2, 0)
+	if err != nil {
+		t.Fatalf("GetTopConsumers failed: %v", err)
+	}
+
+	if len(report.ByCPU) != 2 || report.ByCPU[0].ContainerName != "cpu-hog" || report.ByCPU[1].ContainerName != "leaky" {
+		t.Errorf("Unexpected CPU ranking: %+v", report.ByCPU)
+	}
+	if len(report.ByMemory) != 2 || report.ByMemory[0].ContainerName != "mem-hog" {
+		t.Errorf("Unexpected memory ranking: %+v", report.ByMemory)
+	}
+	if len(report.ByMemoryGrowth) != 1 || report.ByMemoryGrowth[0].ContainerName != "leaky" {
+		t.Fatalf("Expected only 'leaky' in growth ranking, got %+v", report.ByMemoryGrowth)
+	}
+	if growth := report.ByMemoryGrowth[0].MemoryGrowthPerHour; growth < 9.9*mb || growth > 10.1*mb {
+		t.Errorf("Expected ~10MB/hour growth, got %.0f", growth)
+	}
+	if report.ByCPU[0].SampleCount != 10 {
+		t.Errorf("Expected 10 samples, got %d", report.ByCPU[0].SampleCount)
+	}
+
+	// Host filter excludes everything on other hosts
+	report, err = db.GetTopConsumers(24, 2, hostID+1)
+	if err != nil {
+		t.Fatalf("GetTopConsumers failed: %v", err)
+	}
+	if len(report.ByCPU) != 0 {
+		t.Errorf("Expected no consumers for unknown host, got %+v", report.ByCPU)
+	}
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"strings"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// defaultContainerUpdatesLimit caps the updates returned when the filter sets no limit
+const defaultContainerUpdatesLimit = 500
+
+// SaveContainerUpdate records a performed container update
+func (db *DB) SaveContainerUpdate(u *models.ContainerUpdate) error {
+	res, err := db.conn.Exec(`
+		INSERT INTO container_updates (host_id, host_name, container_name, image, old_image_id, new_image_id,
+			triggered_by, trigger_type, status, error, health_status, started_at, finished_at, duration_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, u.HostID, u.HostName, u.ContainerName, u.Image, u.OldImageID, u.NewImageID,
+		u.TriggeredBy, u.Trigger, u.Status, u.Error, u.HealthStatus, u.StartedAt, u.FinishedAt, u.DurationMs)
+	if err != nil {
+		return err
+	}
+	u.ID, err = res.LastInsertId()
+	return err
+}
+
+// GetContainerUpdates returns the recorded container updates matching the filter, newest first
+func (db *DB) GetContainerUpdates(filter models.ContainerUpdateFilter) ([]models.ContainerUpdate, error) {
+	var where []string
+	var args []interface{}
+	if len(filter.HostIDs) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(filter.HostIDs)), ",")
+		where = append(where, "host_id IN ("+placeholders+")")
+		for _, id := range filter.HostIDs {
+			args = append(args, id)
+		}
+	}
+	if filter.ContainerName != "" {
+		where = append(where, "container_name = ?")
+		args = append(args, filter.ContainerName)
+	}
+	if filter.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if !filter.Start.IsZero() {
+		where = append(where, "started_at >= ?")
+		args = append(args, filter.Start)
+	}
+	if !filter.End.IsZero() {
+		where = append(where, "started_at <= ?")
+		args = append(args, filter.End)
+	}
+
+	query := `SELECT id, host_id, host_name, container_name, image, old_image_id, new_image_id, triggered_by,
+		trigger_type, status, error, health_status, started_at, finished_at, duration_ms FROM container_updates`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultContainerUpdatesLimit
+	}
+	query += " ORDER BY started_at DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	updates := make([]models.ContainerUpdate, 0)
+	for rows.Next() {
+		var u models.ContainerUpdate
+		if err := rows.Scan(&u.ID, &u.HostID, &u.HostName, &u.ContainerName, &u.Image, &u.OldImageID, &u.NewImageID,
+			&u.TriggeredBy, &u.Trigger, &u.Status, &u.Error, &u.HealthStatus, &u.StartedAt, &u.FinishedAt, &u.DurationMs); err != nil {
+			return nil, err
+		}
+		updates = append(updates, u)
+	}
+	return updates, rows.Err()
+}
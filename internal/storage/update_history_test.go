@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestContainerUpdates(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: "agent://nas:9876", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	otherID, err := db.AddHost(models.Host{Name: "vps", Address: "agent://vps:9876", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	saturday := time.Date(2026, 10, 10, 14, 0, 0, 0, time.UTC)
+	updates := []models.ContainerUpdate{
+		{HostID: hostID, HostName: "nas", ContainerName: "web", Image: "nginx:latest", OldImageID: "sha256:old", NewImageID: "sha256:new", TriggeredBy: "admin", Trigger: models.UpdateTriggerManual, Status: models.UpdateStatusSucceeded, StartedAt: saturday, FinishedAt: saturday.Add(20 * time.Second), DurationMs: 20000},
+		{HostID: hostID, HostName: "nas", ContainerName: "db", Image: "postgres:16", OldImageID: "sha256:pg", NewImageID: "sha256:pg", Trigger: models.UpdateTriggerBulk, Status: models.UpdateStatusRolledBack, Error: "container never became healthy", StartedAt: saturday.Add(time.Hour), FinishedAt: saturday.Add(time.Hour + time.Minute)},
+		{HostID: otherID, HostName: "vps", ContainerName: "web", Image: "nginx:latest", Trigger: models.UpdateTriggerManual, Status: models.UpdateStatusFailed, Error: "failed to pull image", StartedAt: saturday.AddDate(0, 0, 3), FinishedAt: saturday.AddDate(0, 0, 3)},
+	}
+	for i := range updates {
+		if err := db.SaveContainerUpdate(&updates[i]); err != nil {
+			t.Fatalf("SaveContainerUpdate failed: %v", err)
+		}
+	}
+
+	all, err := db.GetContainerUpdates(models.ContainerUpdateFilter{})
+	if err != nil {
+		t.Fatalf("GetContainerUpdates failed: %v", err)
+	}
+	if len(all) != 3 || all[0].HostName != "vps" || all[2].NewImageID != "sha256:new" || all[2].TriggeredBy != "admin" {
+		t.Errorf("Expected all updates newest first, got %+v", all)
+	}
+
+	// "What did I update last weekend"
+	weekend, err := db.GetContainerUpdates(models.ContainerUpdateFilter{Start: saturday.Add(-14 * time.Hour), End: saturday.Add(34 * time.Hour)})
+	if err != nil {
+		t.Fatalf("GetContainerUpdates failed: %v", err)
+	}
+	if len(weekend) != 2 {
+		t.Errorf("Expected the 2 weekend updates, got %d", len(weekend))
+	}
+
+	web, err := db.GetContainerUpdates(models.ContainerUpdateFilter{HostIDs: []int64{hostID}, ContainerName: "web"})
+	if err != nil {
+		t.Fatalf("GetContainerUpdates failed: %v", err)
+	}
+	if len(web) != 1 || web[0].ID != updates[0].ID {
+		t.Errorf("Expected only web on nas, got %+v", web)
+	}
+
+	rolledBack, err := db.GetContainerUpdates(models.ContainerUpdateFilter{Status: models.UpdateStatusRolledBack})
+	if err != nil {
+		t.Fatalf("GetContainerUpdates failed: %v", err)
+	}
+	if len(rolledBack) != 1 || rolledBack[0].ContainerName != "db" {
+		t.Errorf("Expected the rolled back update, got %+v", rolledBack)
+	}
+
+	// The history goes with its host
+	if err := db.DeleteHost(otherID); err != nil {
+		t.Fatalf("DeleteHost failed: %v", err)
+	}
+	if all, _ := db.GetContainerUpdates(models.ContainerUpdateFilter{}); len(all) != 2 {
+		t.Errorf("Expected the deleted host's updates removed, got %d", len(all))
+	}
+}
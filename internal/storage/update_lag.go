@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"strings"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// SaveUpdateLag records the result of an update check for the update lag of a container. While
+// updates are found for the same image, behind_since keeps the earliest of when one was first
+// found and the newer build's creation time (zero when the registry doesn't tell); a container
+// that is up to date, or was updated to another image, starts over.
+func (db *DB) SaveUpdateLag(hostID int64, containerName, imageID string, available bool, upstreamCreated, checkedAt time.Time) error {
+	if !available {
+		_, err := db.conn.Exec(`DELETE FROM update_lag WHERE host_id = ? AND container_name = ?`, hostID, containerName)
+		return err
+	}
+
+	behindSince := checkedAt
+	if !upstreamCreated.IsZero() && upstreamCreated.Before(checkedAt) {
+		behindSince = upstreamCreated
+	}
+	// Times are stored in UTC so MIN compares them as text
+	_, err := db.conn.Exec(`
+		INSERT INTO update_lag (host_id, container_name, image_id, behind_since, checked_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(host_id, container_name) DO UPDATE SET
+			behind_since = CASE WHEN update_lag.image_id = excluded.image_id
+				THEN MIN(update_lag.behind_since, excluded.behind_since)
+				ELSE excluded.behind_since END,
+			image_id = excluded.image_id,
+			checked_at = excluded.checked_at
+	`, hostID, containerName, imageID, behindSince.UTC(), checkedAt.UTC())
+	return err
+}
+
+// GetUpdateLag returns the update lag of the current containers still running the image the
+// update was found for, on the given hosts (nil for every host). DaysBehind is left to
+// models.BuildUpdateLagReport.
+func (db *DB) GetUpdateLag(hostIDs []int64) ([]models.UpdateLag, error) {
+	query := `
+		SELECT c.host_id, c.host_name, c.name, c.image, c.image_id, COALESCE(c.compose_project, ''), l.behind_since, l.checked_at
+		FROM containers c
+		INNER JOIN (
+			SELECT host_id, MAX(scanned_at) as max_scan
+			FROM containers
+			GROUP BY host_id
+		) latest ON c.host_id = latest.host_id AND c.scanned_at = latest.max_scan
+		INNER JOIN update_lag l ON l.host_id = c.host_id AND l.container_name = c.name AND l.image_id = c.image_id
+	`
+	var args []interface{}
+	if hostIDs != nil {
+		if len(hostIDs) == 0 {
+			return []models.UpdateLag{}, nil
+		}
+		query += ` WHERE c.host_id IN (` + strings.TrimSuffix(strings.Repeat("?,", len(hostIDs)), ",") + `)`
+		for _, id := range hostIDs {
+			args = append(args, id)
+		}
+	}
+	query += ` ORDER BY l.behind_since, c.host_name, c.name`
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lags := make([]models.UpdateLag, 0)
+	for rows.Next() {
+		var lag models.UpdateLag
+		if err := rows.Scan(&lag.HostID, &lag.HostName, &lag.ContainerName, &lag.Image, &lag.ImageID,
+			&lag.ComposeProject, &lag.BehindSince, &lag.CheckedAt); err != nil {
+			return nil, err
+		}
+		lags = append(lags, lag)
+	}
+	return lags, rows.Err()
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestUpdateLag(t *testing.T) {
+	db := setupTestDB(t)
+
+	hostID, err := db.AddHost(models.Host{Name: "nas", Address: "unix:///", Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+	now := time.Now().Truncate(time.Second)
+	if err := db.SaveContainers([]models.Container{
+		{ID: "w1", Name: "web", Image: "nginx:latest", ImageID: "sha256:web1", State: "running", ComposeProject: "site", HostID: hostID, HostName: "nas", ScannedAt: now},
+		{ID: "d1", Name: "db", Image: "postgres:latest", ImageID: "sha256:db1", State: "running", HostID: hostID, HostName: "nas", ScannedAt: now},
+	}); err != nil {
+		t.Fatalf("Failed to save containers: %v", err)
+	}
+
+	// web: first found 10 days ago without a build time, then a build published 3 days ago
+	tenDaysAgo := now.AddDate(0, 0, -10)
+	if err := db.SaveUpdateLag(hostID, "web", "sha256:web1", true, time.Time{}, tenDaysAgo); err != nil {
+		t.Fatalf("SaveUpdateLag failed: %v", err)
+	}
+	if err := db.SaveUpdateLag(hostID, "web", "sha256:web1", true, now.AddDate(0, 0, -3), now); err != nil {
+		t.Fatalf("SaveUpdateLag failed: %v", err)
+	}
+	// db: behind since the build published 20 days ago, on an image it no longer runs
+	if err := db.SaveUpdateLag(hostID, "db", "sha256:db0", true, now.AddDate(0, 0, -20), now); err != nil {
+		t.Fatalf("SaveUpdateLag failed: %v", err)
+	}
+
+	lags, err := db.GetUpdateLag(nil)
+	if err != nil {
+		t.Fatalf("GetUpdateLag failed: %v", err)
+	}
+	if len(lags) != 1 {
+		t.Fatalf("Expected web only, got %+v", lags)
+	}
+	if web := lags[0]; web.ContainerName != "web" || !web.BehindSince.Equal(tenDaysAgo) || !web.CheckedAt.Equal(now) || web.ComposeProject != "site" {
+		t.Errorf("Expected web behind since ten days ago, got %+v", web)
+	}
+
+	// Updated to another image that is behind too: starts over
+	if err := db.SaveUpdateLag(hostID, "db", "sha256:db1", true, now.AddDate(0, 0, -2), now); err != nil {
+		t.Fatalf("SaveUpdateLag failed: %v", err)
+	}
+	lags, _ = db.GetUpdateLag([]int64{hostID})
+	if len(lags) != 2 || lags[0].ContainerName != "web" || !lags[1].BehindSince.Equal(now.AddDate(0, 0, -2)) {
+		t.Errorf("Expected web then db behind since two days ago, got %+v", lags)
+	}
+
+	// Up to date
+	if err := db.SaveUpdateLag(hostID, "web", "sha256:web1", false, time.Time{}, now); err != nil {
+		t.Fatalf("SaveUpdateLag failed: %v", err)
+	}
+	if lags, _ := db.GetUpdateLag(nil); len(lags) != 1 || lags[0].ContainerName != "db" {
+		t.Errorf("Expected db only after web is up to date, got %+v", lags)
+	}
+	if lags, _ := db.GetUpdateLag([]int64{}); len(lags) != 0 {
+		t.Errorf("Expected nothing without visible hosts, got %+v", lags)
+	}
+}
@@ -431,6 +431,64 @@ func (db *DB) CleanupOldVulnerabilityData(retentionDays, detailedRetentionDays i
 	return nil
 }
 
+// GetVulnerabilityExceptions returns all vulnerability exceptions, including expired ones
+func (db *DB) GetVulnerabilityExceptions() ([]vulnerability.Exception, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, vulnerability_id, image_pattern, reason, created_at, expires_at
+		FROM vulnerability_exceptions
+		ORDER BY vulnerability_id, image_pattern
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query vulnerability exceptions: %w", err)
+	}
+	defer rows.Close()
+
+	exceptions := make([]vulnerability.Exception, 0)
+	for rows.Next() {
+		var e vulnerability.Exception
+		var expiresAt sql.NullTime
+		if err := rows.Scan(&e.ID, &e.VulnerabilityID, &e.ImagePattern, &e.Reason, &e.CreatedAt, &expiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan vulnerability exception: %w", err)
+		}
+		if expiresAt.Valid {
+			e.ExpiresAt = &expiresAt.Time
+		}
+		exceptions = append(exceptions, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating vulnerability exceptions: %w", err)
+	}
+
+	return exceptions, nil
+}
+
+// AddVulnerabilityException stores a new vulnerability exception and sets its ID
+func (db *DB) AddVulnerabilityException(e *vulnerability.Exception) error {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+
+	result, err := db.conn.Exec(`
+		INSERT INTO vulnerability_exceptions (vulnerability_id, image_pattern, reason, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, e.VulnerabilityID, e.ImagePattern, e.Reason, e.CreatedAt, e.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to add vulnerability exception: %w", err)
+	}
+
+	e.ID, err = result.LastInsertId()
+	return err
+}
+
+// DeleteVulnerabilityException removes a vulnerability exception
+func (db *DB) DeleteVulnerabilityException(id int64) error {
+	if _, err := db.conn.Exec("DELETE FROM vulnerability_exceptions WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete vulnerability exception: %w", err)
+	}
+	return nil
+}
+
 // GetVulnerabilitySettings retrieves vulnerability scanner settings from database
 func (db *DB) GetVulnerabilitySettings() (map[string]string, error) {
 	query := "SELECT key, value FROM vulnerability_settings"
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/vulnerability"
+)
+
+func TestVulnerabilityExceptions(t *testing.T) {
+	db := setupTestDB(t)
+
+	expires := time.Now().Add(30 * 24 * time.Hour).UTC().Truncate(time.Second)
+	exception := &vulnerability.Exception{
+		VulnerabilityID: "CVE-2024-0001",
+		ImagePattern:    "nginx:*",
+		Reason:          "Vulnerable module not loaded",
+		ExpiresAt:       &expires,
+	}
+	if err := db.AddVulnerabilityException(exception); err != nil {
+		t.Fatalf("AddVulnerabilityException failed: %v", err)
+	}
+	if exception.ID == 0 || exception.CreatedAt.IsZero() {
+		t.Errorf("Expected ID and creation time to be set: %+v", exception)
+	}
+	if err := db.AddVulnerabilityException(&vulnerability.Exception{VulnerabilityID: "CVE-2024-0002", Reason: "Accepted"}); err != nil {
+		t.Fatalf("AddVulnerabilityException failed: %v", err)
+	}
+
+	exceptions, err := db.GetVulnerabilityExceptions()
+	if err != nil {
+		t.Fatalf("GetVulnerabilityExceptions failed: %v", err)
+	}
+	if len(exceptions) != 2 {
+		t.Fatalf("Expected 2 exceptions, got %d", len(exceptions))
+	}
+	if exceptions[0].ImagePattern != "nginx:*" || exceptions[0].ExpiresAt == nil || !exceptions[0].ExpiresAt.Equal(expires) {
+		t.Errorf("Exception not round-tripped: %+v", exceptions[0])
+	}
+	if exceptions[1].ExpiresAt != nil {
+		t.Errorf("Expected exception without expiry, got %v", exceptions[1].ExpiresAt)
+	}
+
+	if err := db.DeleteVulnerabilityException(exception.ID); err != nil {
+		t.Fatalf("DeleteVulnerabilityException failed: %v", err)
+	}
+	exceptions, _ = db.GetVulnerabilityExceptions()
+	if len(exceptions) != 1 || exceptions[0].VulnerabilityID != "CVE-2024-0002" {
+		t.Errorf("Expected only the second exception to remain, got %+v", exceptions)
+	}
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// ZeroStatsScans is how many consecutive scans of a running container must report zero memory
+// usage and limit before it is flagged
+const ZeroStatsScans = 3
+
+// GetZeroStatsContainers returns the running containers (keyed by models.PinKey) whose last
+// ZeroStatsScans scans of the past day, on hosts collecting stats, all reported zero memory
+// usage and limit. A running process always uses memory, so these are stats collection
+// problems (e.g. an unreadable memory cgroup), not idle workloads.
+func (db *DB) GetZeroStatsContainers() (map[string]bool, error) {
+	rows, err := db.conn.Query(`
+		SELECT host_id, name FROM (
+			SELECT c.host_id, c.name, c.memory_usage, c.memory_limit,
+			       ROW_NUMBER() OVER (PARTITION BY c.host_id, c.name ORDER BY c.scanned_at DESC) AS scan
+			FROM containers c
+			JOIN hosts h ON h.id = c.host_id
+			WHERE c.state = 'running' AND h.collect_stats = 1 AND c.scanned_at >= ?
+		)
+		WHERE scan <= ?
+		GROUP BY host_id, name
+		HAVING COUNT(*) = ? AND MAX(COALESCE(memory_usage, 0)) = 0 AND MAX(COALESCE(memory_limit, 0)) = 0
+	`, time.Now().Add(-24*time.Hour), ZeroStatsScans, ZeroStatsScans)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	zero := make(map[string]bool)
+	for rows.Next() {
+		var hostID int64
+		var name string
+		if err := rows.Scan(&hostID, &name); err != nil {
+			return nil, err
+		}
+		zero[models.PinKey(hostID, name)] = true
+	}
+	return zero, rows.Err()
+}
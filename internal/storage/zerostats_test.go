@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestGetZeroStatsContainers(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "zero.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	hostID, err := db.AddHost(models.Host{Name: "pi", Address: "unix:///var/run/docker.sock", Enabled: true, CollectStats: true})
+	if err != nil {
+		t.Fatalf("Failed to add host: %v", err)
+	}
+
+	now := time.Now()
+	container := func(id, name string, scannedAt time.Time, memory int64) models.Container {
+		return models.Container{
+			ID: id, Name: name, Image: name + ":latest", State: "running",
+			HostID: hostID, HostName: "pi", ScannedAt: scannedAt,
+			MemoryUsage: memory, MemoryLimit: memory * 16,
+		}
+	}
+
+	// "broken" reads zero in all 3 scans, "recovered" only in the last 2, "healthy" never
+	for i := 0; i < ZeroStatsScans; i++ {
+		scannedAt := now.Add(time.Duration(i-ZeroStatsScans) * 5 * time.Minute)
+		var recovered int64
+		if i == 0 {
+			recovered = 32 << 20
+		}
+		scan := []models.Container{
+			container("b1", "broken", scannedAt, 0),
+			container("r1", "recovered", scannedAt, recovered),
+			container("h1", "healthy", scannedAt, 64<<20),
+		}
+		if err := db.SaveContainers(scan); err != nil {
+			t.Fatalf("Failed to save containers: %v", err)
+		}
+	}
+	// "new" reads zero too, but in too few scans to judge
+	if err := db.SaveContainers([]models.Container{container("n1", "new", now, 0)}); err != nil {
+		t.Fatalf("Failed to save containers: %v", err)
+	}
+
+	zero, err := db.GetZeroStatsContainers()
+	if err != nil {
+		t.Fatalf("GetZeroStatsContainers failed: %v", err)
+	}
+	if len(zero) != 1 || !zero[models.PinKey(hostID, "broken")] {
+		t.Errorf("Expected only 'broken' to be flagged, got %v", zero)
+	}
+}
@@ -0,0 +1,21 @@
+package sysinfo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMemTotal(t *testing.T) {
+	meminfo := "MemTotal:        3884328 kB\nMemFree:          246112 kB\nMemAvailable:    2211980 kB\n"
+	total, err := parseMemTotal(strings.NewReader(meminfo))
+	if err != nil {
+		t.Fatalf("parseMemTotal failed: %v", err)
+	}
+	if total != 3884328*1024 {
+		t.Errorf("Expected %d bytes, got %d", 3884328*1024, total)
+	}
+
+	if _, err := parseMemTotal(strings.NewReader("MemFree: 246112 kB\n")); err == nil {
+		t.Error("Expected an error without a MemTotal line")
+	}
+}
@@ -266,9 +266,65 @@ func (c *Collector) CollectReport(ctx context.Context, agentStats map[string]*mo
 		AvgConnectionsPerContainer:  avgConnectionsPerContainer,
 	}
 
+	// Update lag of the containers behind a newer upstream build
+	lags, err := c.db.GetUpdateLag(nil)
+	if err != nil {
+		log.Printf("Warning: failed to get update lag for telemetry: %v", err)
+	} else {
+		lagReport := models.BuildUpdateLagReport(lags, time.Now())
+		report.ContainersBehindUpstream = lagReport.Behind
+		report.AvgDaysBehindUpstream = lagReport.AverageDays
+		report.MaxDaysBehindUpstream = lagReport.MaxDays
+	}
+
+	// Honor per-category opt-outs and opt-ins from system settings
+	settings, err := c.db.LoadSystemSettings()
+	if err != nil {
+		log.Printf("Warning: failed to load telemetry settings, sharing defaults: %v", err)
+	} else {
+		applyPrivacySettings(report, settings.Telemetry)
+	}
+
 	return report, nil
 }
 
+// applyPrivacySettings clears report fields for categories the user opted out of
+func applyPrivacySettings(report *models.TelemetryReport, settings models.TelemetrySettings) {
+	if settings.ExcludeResourceStats {
+		report.AvgCPUPercent = 0
+		report.AvgMemoryBytes = 0
+		report.TotalMemoryLimit = 0
+		report.AvgRestarts = 0
+		report.HighRestartContainers = 0
+	}
+
+	if settings.ExcludeImageList {
+		// Keep UniqueImages and TotalImageSize so counts are still contributed
+		report.ImageStats = []models.ImageStat{}
+	}
+
+	if settings.ExcludeArchitectureMetrics {
+		report.ComposeProjectCount = 0
+		report.ContainersInCompose = 0
+		report.NetworkCount = 0
+		report.CustomNetworkCount = 0
+		report.SharedVolumeCount = 0
+		report.ContainersWithDeps = 0
+		report.TotalDependencies = 0
+		report.AvgConnectionsPerContainer = 0
+	}
+
+	if settings.ExcludeTimezone {
+		report.Timezone = ""
+	}
+
+	if !settings.ShareUpdateLag {
+		report.ContainersBehindUpstream = 0
+		report.AvgDaysBehindUpstream = 0
+		report.MaxDaysBehindUpstream = 0
+	}
+}
+
 // getOrCreateInstallationID gets or creates a unique installation ID
 func getOrCreateInstallationID() (string, error) {
 	// Try to read existing ID
@@ -292,6 +348,15 @@ func getOrCreateInstallationID() (string, error) {
 	return newID, nil
 }
 
+// RotateInstallationID replaces the persisted installation ID with a new random one
+func RotateInstallationID() (string, error) {
+	newID := uuid.New().String()
+	if err := os.WriteFile(installationIDFile, []byte(newID), 0644); err != nil {
+		return "", fmt.Errorf("failed to save installation ID: %w", err)
+	}
+	return newID, nil
+}
+
 // hashString creates a SHA256 hash of a string (for anonymization if needed)
 func hashString(s string) string {
 	hash := sha256.Sum256([]byte(s))
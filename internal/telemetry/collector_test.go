@@ -0,0 +1,143 @@
+package telemetry
+
+import (
+	"os"
+	"testing"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func newFullReport() *models.TelemetryReport {
+	return &models.TelemetryReport{
+		TotalContainers:            5,
+		ImageStats:                 []models.ImageStat{{Image: "nginx:latest", Count: 2}},
+		UniqueImages:               1,
+		TotalImageSize:             1024,
+		AvgCPUPercent:              12.5,
+		AvgMemoryBytes:             2048,
+		TotalMemoryLimit:           4096,
+		AvgRestarts:                1.5,
+		HighRestartContainers:      1,
+		Timezone:                   "Europe/Berlin",
+		ComposeProjectCount:        2,
+		ContainersInCompose:        4,
+		NetworkCount:               3,
+		CustomNetworkCount:         1,
+		SharedVolumeCount:          1,
+		ContainersWithDeps:         2,
+		TotalDependencies:          3,
+		AvgConnectionsPerContainer: 1.2,
+		ContainersBehindUpstream:   2,
+		AvgDaysBehindUpstream:      10.5,
+		MaxDaysBehindUpstream:      14,
+	}
+}
+
+// TestApplyPrivacySettings_Defaults verifies no opted-out category is stripped by default
+func TestApplyPrivacySettings_Defaults(t *testing.T) {
+	report := newFullReport()
+	applyPrivacySettings(report, models.TelemetrySettings{IntervalHours: 168})
+
+	if len(report.ImageStats) != 1 {
+		t.Errorf("Expected image stats to be kept, got %d", len(report.ImageStats))
+	}
+	if report.AvgCPUPercent != 12.5 {
+		t.Errorf("Expected CPU average to be kept, got %f", report.AvgCPUPercent)
+	}
+	if report.Timezone != "Europe/Berlin" {
+		t.Errorf("Expected timezone to be kept, got %q", report.Timezone)
+	}
+	if report.ComposeProjectCount != 2 {
+		t.Errorf("Expected compose project count to be kept, got %d", report.ComposeProjectCount)
+	}
+}
+
+// TestApplyPrivacySettings_ExcludeAll verifies each category is stripped while counts remain
+func TestApplyPrivacySettings_ExcludeAll(t *testing.T) {
+	report := newFullReport()
+	applyPrivacySettings(report, models.TelemetrySettings{
+		IntervalHours:              168,
+		ExcludeResourceStats:       true,
+		ExcludeImageList:           true,
+		ExcludeArchitectureMetrics: true,
+		ExcludeTimezone:            true,
+	})
+
+	if len(report.ImageStats) != 0 {
+		t.Errorf("Expected image stats to be stripped, got %d", len(report.ImageStats))
+	}
+	if report.ImageStats == nil {
+		t.Error("Expected image stats to be an empty slice, not nil")
+	}
+	if report.AvgCPUPercent != 0 || report.AvgMemoryBytes != 0 || report.TotalMemoryLimit != 0 {
+		t.Error("Expected resource stats to be stripped")
+	}
+	if report.AvgRestarts != 0 || report.HighRestartContainers != 0 {
+		t.Error("Expected restart stats to be stripped")
+	}
+	if report.ComposeProjectCount != 0 || report.NetworkCount != 0 || report.AvgConnectionsPerContainer != 0 {
+		t.Error("Expected architecture metrics to be stripped")
+	}
+	if report.Timezone != "" {
+		t.Errorf("Expected timezone to be stripped, got %q", report.Timezone)
+	}
+
+	// Counts are still contributed
+	if report.TotalContainers != 5 {
+		t.Errorf("Expected total containers to be kept, got %d", report.TotalContainers)
+	}
+	if report.UniqueImages != 1 {
+		t.Errorf("Expected unique image count to be kept, got %d", report.UniqueImages)
+	}
+}
+
+// TestApplyPrivacySettings_UpdateLagOptIn verifies the update lag is only sent when opted in
+func TestApplyPrivacySettings_UpdateLagOptIn(t *testing.T) {
+	report := newFullReport()
+	applyPrivacySettings(report, models.TelemetrySettings{IntervalHours: 168})
+	if report.ContainersBehindUpstream != 0 || report.AvgDaysBehindUpstream != 0 || report.MaxDaysBehindUpstream != 0 {
+		t.Errorf("Expected update lag to be stripped by default, got %+v", report)
+	}
+
+	report = newFullReport()
+	applyPrivacySettings(report, models.TelemetrySettings{IntervalHours: 168, ShareUpdateLag: true})
+	if report.ContainersBehindUpstream != 2 || report.AvgDaysBehindUpstream != 10.5 || report.MaxDaysBehindUpstream != 14 {
+		t.Errorf("Expected update lag to be kept when opted in, got %+v", report)
+	}
+}
+
+// TestRotateInstallationID verifies a rotated ID replaces the persisted one for later reports
+func TestRotateInstallationID(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.Mkdir("data", 0755); err != nil {
+		t.Fatalf("Failed to create data dir: %v", err)
+	}
+
+	original, err := getOrCreateInstallationID()
+	if err != nil {
+		t.Fatalf("getOrCreateInstallationID failed: %v", err)
+	}
+	if again, _ := getOrCreateInstallationID(); again != original {
+		t.Fatalf("Expected the ID to persist, got %q then %q", original, again)
+	}
+
+	rotated, err := RotateInstallationID()
+	if err != nil {
+		t.Fatalf("RotateInstallationID failed: %v", err)
+	}
+	if rotated == "" || rotated == original {
+		t.Fatalf("Expected a new ID, got %q (was %q)", rotated, original)
+	}
+
+	data, err := os.ReadFile(installationIDFile)
+	if err != nil || string(data) != rotated {
+		t.Errorf("Expected %q persisted, got %q (%v)", rotated, data, err)
+	}
+	collector, err := NewCollector(nil, 300)
+	if err != nil {
+		t.Fatalf("NewCollector failed: %v", err)
+	}
+	if collector.installationID != rotated {
+		t.Errorf("Expected new collectors to use %q, got %q", rotated, collector.installationID)
+	}
+}
@@ -133,6 +133,12 @@ func (s *Scheduler) SubmitNow(ctx context.Context) error {
 	return nil
 }
 
+// PreviewReport builds the report that the next submission would send, without submitting it
+func (s *Scheduler) PreviewReport(ctx context.Context) (*models.TelemetryReport, error) {
+	agentStats := s.collectAgentStats(ctx)
+	return s.collector.CollectReport(ctx, agentStats)
+}
+
 // collectAgentStats fetches telemetry data from all agents
 func (s *Scheduler) collectAgentStats(ctx context.Context) map[string]*models.AgentInfo {
 	hosts, err := s.db.GetHosts()
@@ -0,0 +1,189 @@
+package updatehooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// DefaultHealthTimeout is used when hooks request a health wait without a timeout
+const DefaultHealthTimeout = 60 * time.Second
+
+// healthPollInterval is how often the container is inspected while waiting for health
+var healthPollInterval = 2 * time.Second
+
+// HealthTimeout returns how long to wait for the updated container to become healthy
+func HealthTimeout(hooks *models.UpdateHooks) time.Duration {
+	if hooks == nil || hooks.HealthTimeoutSeconds <= 0 {
+		return DefaultHealthTimeout
+	}
+	return time.Duration(hooks.HealthTimeoutSeconds) * time.Second
+}
+
+// containerInspector is the subset of the Docker client needed to poll container health
+type containerInspector interface {
+	ContainerInspect(ctx context.Context, containerID string) (container.InspectResponse, error)
+}
+
+// RunPreUpdateCommand executes a command inside the running container and returns its combined output.
+// A non-zero exit code is reported as an error so the update can be aborted.
+func RunPreUpdateCommand(ctx context.Context, dockerClient *client.Client, containerID string, cmd []string) (string, error) {
+	execResp, err := dockerClient.ContainerExecCreate(ctx, containerID, container.ExecOptions{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create exec: %w", err)
+	}
+
+	attachResp, err := dockerClient.ContainerExecAttach(ctx, execResp.ID, container.ExecAttachOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to attach to exec: %w", err)
+	}
+	defer attachResp.Close()
+
+	var stdout, stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, attachResp.Reader); err != nil {
+		return "", fmt.Errorf("failed to read exec output: %w", err)
+	}
+	output := strings.TrimSpace(stdout.String() + stderr.String())
+
+	inspect, err := dockerClient.ContainerExecInspect(ctx, execResp.ID)
+	if err != nil {
+		return output, fmt.Errorf("failed to inspect exec: %w", err)
+	}
+	if inspect.ExitCode != 0 {
+		return output, fmt.Errorf("pre-update command exited with code %d", inspect.ExitCode)
+	}
+
+	return output, nil
+}
+
+// WaitForHealthy polls the container until its healthcheck reports healthy or the timeout expires.
+// Containers without a healthcheck are considered healthy once they are running.
+// It returns the last observed health status.
+func WaitForHealthy(ctx context.Context, inspector containerInspector, containerID string, timeout time.Duration) (string, error) {
+	if timeout <= 0 {
+		timeout = DefaultHealthTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(healthPollInterval)
+	defer ticker.Stop()
+
+	status := container.Starting
+	for {
+		info, err := inspector.ContainerInspect(ctx, containerID)
+		if err == nil && info.ContainerJSONBase != nil && info.State != nil {
+			if info.State.Health == nil {
+				if info.State.Running {
+					return container.NoHealthcheck, nil
+				}
+				status = info.State.Status
+			} else {
+				status = info.State.Health.Status
+				if status == container.Healthy {
+					return status, nil
+				}
+			}
+
+			// A container that exited will never become healthy
+			if !info.State.Running && !info.State.Restarting {
+				return status, fmt.Errorf("container is not running (state: %s)", info.State.Status)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return status, fmt.Errorf("container did not become healthy within %v (last status: %s)", timeout, status)
+		case <-ticker.C:
+		}
+	}
+}
+
+// Rollback removes the updated container and recreates it from the original inspect data.
+// The original image ID is re-tagged so the restored container keeps its image reference.
+func Rollback(ctx context.Context, dockerClient *client.Client, newContainerID string, original container.InspectResponse) (string, error) {
+	timeout := 10
+	if err := dockerClient.ContainerStop(ctx, newContainerID, container.StopOptions{Timeout: &timeout}); err != nil {
+		log.Printf("Warning: failed to stop updated container during rollback: %v", err)
+	}
+	if err := dockerClient.ContainerRemove(ctx, newContainerID, container.RemoveOptions{Force: true}); err != nil {
+		return "", fmt.Errorf("failed to remove updated container: %w", err)
+	}
+
+	// Point the tag back at the previous image (skip digest or ID references)
+	imageName := original.Config.Image
+	if imageName != "" && !strings.HasPrefix(imageName, "sha256:") && !strings.Contains(imageName, "@") {
+		if err := dockerClient.ImageTag(ctx, original.Image, imageName); err != nil {
+			return "", fmt.Errorf("failed to re-tag previous image: %w", err)
+		}
+	}
+
+	containerName := strings.TrimPrefix(original.Name, "/")
+	createResp, err := dockerClient.ContainerCreate(ctx, original.Config, original.HostConfig, nil, nil, containerName)
+	if err != nil {
+		return "", fmt.Errorf("failed to recreate previous container: %w", err)
+	}
+
+	for networkName, networkConfig := range original.NetworkSettings.Networks {
+		// Skip the default bridge network as it's handled by NetworkMode
+		if networkName == "bridge" && original.HostConfig.NetworkMode == "bridge" {
+			continue
+		}
+		if err := dockerClient.NetworkConnect(ctx, networkName, createResp.ID, networkConfig); err != nil {
+			log.Printf("Warning: failed to connect to network %s: %v", networkName, err)
+		}
+	}
+
+	if err := dockerClient.ContainerStart(ctx, createResp.ID, container.StartOptions{}); err != nil {
+		return createResp.ID, fmt.Errorf("failed to start previous container: %w", err)
+	}
+
+	return createResp.ID, nil
+}
+
+// VerifyUpdate applies the post-update hooks to a freshly recreated container.
+// On a failed health check the result is marked unsuccessful and, if requested, the
+// previous container is restored from its original inspect data.
+func VerifyUpdate(ctx context.Context, dockerClient *client.Client, hooks *models.UpdateHooks, original container.InspectResponse, result *models.ContainerRecreateResult) {
+	if hooks == nil || !hooks.WaitForHealthy {
+		return
+	}
+
+	status, err := WaitForHealthy(ctx, dockerClient, result.NewContainerID, HealthTimeout(hooks))
+	result.HealthStatus = status
+	if err == nil {
+		return
+	}
+
+	log.Printf("Updated container %s failed health check: %v", original.Name, err)
+	result.Success = false
+	result.Error = err.Error()
+
+	if !hooks.RollbackOnFailure {
+		return
+	}
+
+	restoredID, err := Rollback(ctx, dockerClient, result.NewContainerID, original)
+	if err != nil {
+		result.Error += "; rollback failed: " + err.Error()
+		return
+	}
+
+	log.Printf("Rolled back container %s to image %s", original.Name, original.Image)
+	result.RolledBack = true
+	result.NewContainerID = restoredID
+	result.NewImageID = original.Image
+}
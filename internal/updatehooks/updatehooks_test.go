@@ -0,0 +1,115 @@
+package updatehooks
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+	"github.com/docker/docker/api/types/container"
+)
+
+// fakeInspector returns a scripted sequence of container states
+type fakeInspector struct {
+	states []*container.State
+	calls  int
+}
+
+func (f *fakeInspector) ContainerInspect(ctx context.Context, containerID string) (container.InspectResponse, error) {
+	if len(f.states) == 0 {
+		return container.InspectResponse{}, errors.New("no such container")
+	}
+	idx := f.calls
+	if idx >= len(f.states) {
+		idx = len(f.states) - 1
+	}
+	f.calls++
+	return container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{State: f.states[idx]},
+	}, nil
+}
+
+func withFastPolling(t *testing.T) {
+	t.Helper()
+	orig := healthPollInterval
+	healthPollInterval = 5 * time.Millisecond
+	t.Cleanup(func() { healthPollInterval = orig })
+}
+
+func TestWaitForHealthy_BecomesHealthy(t *testing.T) {
+	withFastPolling(t)
+	inspector := &fakeInspector{states: []*container.State{
+		{Running: true, Status: "running", Health: &container.Health{Status: container.Starting}},
+		{Running: true, Status: "running", Health: &container.Health{Status: container.Healthy}},
+	}}
+
+	status, err := WaitForHealthy(context.Background(), inspector, "abc", time.Second)
+	if err != nil {
+		t.Fatalf("Expected healthy container, got error: %v", err)
+	}
+	if status != container.Healthy {
+		t.Errorf("Expected status %q, got %q", container.Healthy, status)
+	}
+	if inspector.calls != 2 {
+		t.Errorf("Expected 2 inspect calls, got %d", inspector.calls)
+	}
+}
+
+func TestWaitForHealthy_NoHealthcheck(t *testing.T) {
+	withFastPolling(t)
+	inspector := &fakeInspector{states: []*container.State{
+		{Running: true, Status: "running"},
+	}}
+
+	status, err := WaitForHealthy(context.Background(), inspector, "abc", time.Second)
+	if err != nil {
+		t.Fatalf("Expected running container without healthcheck to pass, got: %v", err)
+	}
+	if status != container.NoHealthcheck {
+		t.Errorf("Expected status %q, got %q", container.NoHealthcheck, status)
+	}
+}
+
+func TestWaitForHealthy_Timeout(t *testing.T) {
+	withFastPolling(t)
+	inspector := &fakeInspector{states: []*container.State{
+		{Running: true, Status: "running", Health: &container.Health{Status: container.Unhealthy}},
+	}}
+
+	status, err := WaitForHealthy(context.Background(), inspector, "abc", 50*time.Millisecond)
+	if err == nil {
+		t.Fatal("Expected timeout error for unhealthy container")
+	}
+	if status != container.Unhealthy {
+		t.Errorf("Expected status %q, got %q", container.Unhealthy, status)
+	}
+}
+
+func TestWaitForHealthy_Exited(t *testing.T) {
+	withFastPolling(t)
+	inspector := &fakeInspector{states: []*container.State{
+		{Running: false, Status: "exited", ExitCode: 1},
+	}}
+
+	start := time.Now()
+	_, err := WaitForHealthy(context.Background(), inspector, "abc", 10*time.Second)
+	if err == nil {
+		t.Fatal("Expected error for exited container")
+	}
+	if time.Since(start) > time.Second {
+		t.Error("Expected exited container to fail fast instead of waiting for timeout")
+	}
+}
+
+func TestHealthTimeout(t *testing.T) {
+	if got := HealthTimeout(nil); got != DefaultHealthTimeout {
+		t.Errorf("Expected default timeout without hooks, got %v", got)
+	}
+	if got := HealthTimeout(&models.UpdateHooks{WaitForHealthy: true}); got != DefaultHealthTimeout {
+		t.Errorf("Expected default timeout when unset, got %v", got)
+	}
+	if got := HealthTimeout(&models.UpdateHooks{HealthTimeoutSeconds: 90}); got != 90*time.Second {
+		t.Errorf("Expected 90s, got %v", got)
+	}
+}
@@ -0,0 +1,373 @@
+package uptimekuma
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Uptime Kuma has no REST API for managing monitors; its web UI talks Socket.IO. Client speaks
+// the small part of Socket.IO v5 (Engine.IO v4) the integration needs over HTTP long-polling,
+// which keeps it free of a websocket dependency.
+
+// Engine.IO payloads separate packets with the record separator
+const packetSeparator = "\x1e"
+
+// Monitor is a monitor as Uptime Kuma sends it in its monitor list. It is kept as a map so that
+// editing a monitor sends back every field, including ones this package doesn't know about.
+type Monitor map[string]interface{}
+
+// ID returns the monitor ID
+func (m Monitor) ID() int {
+	id, _ := m["id"].(float64)
+	return int(id)
+}
+
+// Name returns the monitor name
+func (m Monitor) Name() string {
+	name, _ := m["name"].(string)
+	return name
+}
+
+// URL returns the monitored URL
+func (m Monitor) URL() string {
+	u, _ := m["url"].(string)
+	return u
+}
+
+// Interval returns the check interval in seconds
+func (m Monitor) Interval() int {
+	interval, _ := m["interval"].(float64)
+	return int(interval)
+}
+
+// Client is a Socket.IO client for the Uptime Kuma web socket API
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	sid        string
+	nextAck    int
+	acks       map[int]json.RawMessage
+	events     map[string]json.RawMessage // latest payload of each event the server pushed
+}
+
+// NewClient creates a client for the Uptime Kuma instance at baseURL
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		// Long-polling requests are held open for up to the ping interval (25s by default)
+		httpClient = &http.Client{Timeout: 60 * time.Second}
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: httpClient,
+		acks:       make(map[int]json.RawMessage),
+		events:     make(map[string]json.RawMessage),
+	}
+}
+
+// Connect opens a session and connects to the default namespace
+func (c *Client) Connect(ctx context.Context) error {
+	body, err := c.get(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Uptime Kuma: %w", err)
+	}
+
+	open := strings.SplitN(body, packetSeparator, 2)[0]
+	if !strings.HasPrefix(open, "0") {
+		return fmt.Errorf("unexpected handshake from Uptime Kuma: %q", open)
+	}
+	var handshake struct {
+		SID string `json:"sid"`
+	}
+	if err := json.Unmarshal([]byte(open[1:]), &handshake); err != nil || handshake.SID == "" {
+		return fmt.Errorf("invalid handshake from Uptime Kuma: %q", open)
+	}
+	c.sid = handshake.SID
+
+	if err := c.post(ctx, "40"); err != nil {
+		return fmt.Errorf("failed to connect to Uptime Kuma: %w", err)
+	}
+	if _, err := c.waitEvent(ctx, "connect"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Login authenticates the session. Accounts with two-factor authentication can't be used.
+func (c *Client) Login(ctx context.Context, username, password string) error {
+	res, err := c.emit(ctx, "login", map[string]string{
+		"username": username,
+		"password": password,
+		"token":    "",
+	})
+	if err != nil {
+		return err
+	}
+
+	var login struct {
+		OK            bool   `json:"ok"`
+		Msg           string `json:"msg"`
+		TokenRequired bool   `json:"tokenRequired"`
+	}
+	if err := json.Unmarshal(res, &login); err != nil {
+		return fmt.Errorf("invalid login response from Uptime Kuma: %w", err)
+	}
+	if login.TokenRequired {
+		return fmt.Errorf("Uptime Kuma account has two-factor authentication enabled; use an account without it")
+	}
+	if !login.OK {
+		return fmt.Errorf("Uptime Kuma login failed: %s", login.Msg)
+	}
+	return nil
+}
+
+// Monitors returns the monitors Uptime Kuma sends after login
+func (c *Client) Monitors(ctx context.Context) ([]Monitor, error) {
+	raw, err := c.waitEvent(ctx, "monitorList")
+	if err != nil {
+		return nil, err
+	}
+
+	var list map[string]Monitor
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, fmt.Errorf("invalid monitor list from Uptime Kuma: %w", err)
+	}
+
+	monitors := make([]Monitor, 0, len(list))
+	for _, m := range list {
+		monitors = append(monitors, m)
+	}
+	return monitors, nil
+}
+
+// AddMonitor creates an HTTP monitor and returns its ID
+func (c *Client) AddMonitor(ctx context.Context, desired DesiredMonitor, intervalSeconds int) (int, error) {
+	monitor := Monitor{
+		"type":                 "http",
+		"name":                 desired.Name,
+		"url":                  desired.URL,
+		"method":               "GET",
+		"interval":             intervalSeconds,
+		"retryInterval":        intervalSeconds,
+		"maxretries":           1,
+		"maxredirects":         10,
+		"accepted_statuscodes": []string{"200-299"},
+		"notificationIDList":   map[string]bool{},
+		"ignoreTls":            true, // self-hosted services commonly use self-signed certificates
+		"upsideDown":           false,
+		"expiryNotification":   false,
+		"description":          fmt.Sprintf("Managed by Container Census for %s on %s", desired.ContainerName, desired.HostName),
+	}
+	return c.saveMonitor(ctx, "add", monitor)
+}
+
+// EditMonitor points an existing monitor at the desired URL and interval
+func (c *Client) EditMonitor(ctx context.Context, existing Monitor, desired DesiredMonitor, intervalSeconds int) error {
+	monitor := make(Monitor, len(existing))
+	for k, v := range existing {
+		monitor[k] = v
+	}
+	monitor["url"] = desired.URL
+	monitor["interval"] = intervalSeconds
+
+	_, err := c.saveMonitor(ctx, "editMonitor", monitor)
+	return err
+}
+
+// Close ends the session
+func (c *Client) Close(ctx context.Context) {
+	if c.sid == "" {
+		return
+	}
+	_ = c.post(ctx, "41")
+	c.sid = ""
+}
+
+func (c *Client) saveMonitor(ctx context.Context, event string, monitor Monitor) (int, error) {
+	res, err := c.emit(ctx, event, monitor)
+	if err != nil {
+		return 0, err
+	}
+
+	var saved struct {
+		OK        bool   `json:"ok"`
+		Msg       string `json:"msg"`
+		MonitorID int    `json:"monitorID"`
+	}
+	if err := json.Unmarshal(res, &saved); err != nil {
+		return 0, fmt.Errorf("invalid %s response from Uptime Kuma: %w", event, err)
+	}
+	if !saved.OK {
+		return 0, fmt.Errorf("Uptime Kuma rejected monitor %q: %s", monitor.Name(), saved.Msg)
+	}
+	return saved.MonitorID, nil
+}
+
+// emit sends an event and waits for the server to acknowledge it, returning the first ack argument
+func (c *Client) emit(ctx context.Context, event string, data interface{}) (json.RawMessage, error) {
+	payload, err := json.Marshal([]interface{}{event, data})
+	if err != nil {
+		return nil, err
+	}
+
+	id := c.nextAck
+	c.nextAck++
+	if err := c.post(ctx, "42"+strconv.Itoa(id)+string(payload)); err != nil {
+		return nil, fmt.Errorf("failed to send %s to Uptime Kuma: %w", event, err)
+	}
+
+	for {
+		if res, ok := c.acks[id]; ok {
+			delete(c.acks, id)
+			return res, nil
+		}
+		if err := c.poll(ctx); err != nil {
+			return nil, fmt.Errorf("no response to %s from Uptime Kuma: %w", event, err)
+		}
+	}
+}
+
+// waitEvent polls until the server has pushed the named event
+func (c *Client) waitEvent(ctx context.Context, event string) (json.RawMessage, error) {
+	for {
+		if data, ok := c.events[event]; ok {
+			return data, nil
+		}
+		if err := c.poll(ctx); err != nil {
+			return nil, fmt.Errorf("waiting for %s from Uptime Kuma: %w", event, err)
+		}
+	}
+}
+
+// poll fetches and handles the packets the server has queued
+func (c *Client) poll(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	body, err := c.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, packet := range strings.Split(body, packetSeparator) {
+		if packet == "" {
+			continue
+		}
+		switch packet[0] {
+		case '1': // close
+			c.sid = ""
+			return fmt.Errorf("session closed by server")
+		case '2': // ping
+			if err := c.post(ctx, "3"); err != nil {
+				return err
+			}
+		case '4': // Socket.IO message
+			if err := c.handleMessage(packet[1:]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// handleMessage records connects, events and acks from a Socket.IO packet
+func (c *Client) handleMessage(packet string) error {
+	if packet == "" {
+		return nil
+	}
+	kind, rest := packet[0], packet[1:]
+
+	switch kind {
+	case '0': // namespace connected
+		c.events["connect"] = json.RawMessage(`{}`)
+	case '4': // namespace connect error
+		return fmt.Errorf("connection refused by Uptime Kuma: %s", rest)
+	case '2': // event
+		// Server events don't request acks, so the payload follows directly
+		var args []json.RawMessage
+		if err := json.Unmarshal([]byte(rest), &args); err != nil || len(args) == 0 {
+			return nil
+		}
+		var name string
+		if err := json.Unmarshal(args[0], &name); err != nil {
+			return nil
+		}
+		if len(args) > 1 {
+			c.events[name] = args[1]
+		} else {
+			c.events[name] = json.RawMessage(`null`)
+		}
+	case '3': // ack
+		i := 0
+		for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+			i++
+		}
+		id, err := strconv.Atoi(rest[:i])
+		if err != nil {
+			return nil
+		}
+		var args []json.RawMessage
+		if err := json.Unmarshal([]byte(rest[i:]), &args); err != nil {
+			return fmt.Errorf("invalid ack from Uptime Kuma: %w", err)
+		}
+		if len(args) > 0 {
+			c.acks[id] = args[0]
+		} else {
+			c.acks[id] = json.RawMessage(`null`)
+		}
+	}
+	return nil
+}
+
+func (c *Client) pollingURL() string {
+	query := url.Values{}
+	query.Set("EIO", "4")
+	query.Set("transport", "polling")
+	query.Set("t", strconv.FormatInt(time.Now().UnixNano(), 36))
+	if c.sid != "" {
+		query.Set("sid", c.sid)
+	}
+	return c.baseURL + "/socket.io/?" + query.Encode()
+}
+
+func (c *Client) get(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.pollingURL(), nil)
+	if err != nil {
+		return "", err
+	}
+	return c.do(req)
+}
+
+func (c *Client) post(ctx context.Context, packet string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.pollingURL(), strings.NewReader(packet))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain;charset=UTF-8")
+	_, err = c.do(req)
+	return err
+}
+
+func (c *Client) do(req *http.Request) (string, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return string(body), nil
+}
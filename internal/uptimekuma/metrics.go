@@ -0,0 +1,164 @@
+package uptimekuma
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// MonitorMetric is the latest status of a monitor as exported on Uptime Kuma's /metrics endpoint
+type MonitorMetric struct {
+	Status         string
+	ResponseTimeMS float64
+}
+
+// monitorStatuses maps the monitor_status gauge to a status
+var monitorStatuses = map[float64]string{
+	0: models.UptimeStatusDown,
+	1: models.UptimeStatusUp,
+	2: models.UptimeStatusPending,
+	3: models.UptimeStatusMaintenance,
+}
+
+// FetchStatus reads the status of every monitor from Uptime Kuma's Prometheus endpoint, keyed by
+// monitor name. The endpoint uses basic auth: an API key goes in the password with an empty
+// username; instances without API keys accept the login credentials instead.
+func FetchStatus(ctx context.Context, httpClient *http.Client, baseURL, username, password string) (map[string]MonitorMetric, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(baseURL, "/")+"/metrics", nil)
+	if err != nil {
+		return nil, err
+	}
+	if username != "" || password != "" {
+		req.SetBasicAuth(username, password)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch Uptime Kuma metrics: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("Uptime Kuma rejected the API key for /metrics")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Uptime Kuma /metrics returned status %d", resp.StatusCode)
+	}
+
+	return parseMetrics(resp.Body)
+}
+
+// parseMetrics extracts monitor_status and monitor_response_time from Prometheus text format
+func parseMetrics(r io.Reader) (map[string]MonitorMetric, error) {
+	metrics := make(map[string]MonitorMetric)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, labels, value, ok := parseSample(line)
+		if !ok || (name != "monitor_status" && name != "monitor_response_time") {
+			continue
+		}
+		monitorName, ok := labels["monitor_name"]
+		if !ok {
+			continue
+		}
+
+		metric := metrics[monitorName]
+		if name == "monitor_status" {
+			status, known := monitorStatuses[value]
+			if !known {
+				status = models.UptimeStatusUnknown
+			}
+			metric.Status = status
+		} else {
+			metric.ResponseTimeMS = value
+		}
+		metrics[monitorName] = metric
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read Uptime Kuma metrics: %w", err)
+	}
+
+	return metrics, nil
+}
+
+// parseSample parses a line like `name{label="value",...} 1`
+func parseSample(line string) (string, map[string]string, float64, bool) {
+	labels := make(map[string]string)
+
+	nameEnd := strings.IndexAny(line, "{ ")
+	if nameEnd <= 0 {
+		return "", nil, 0, false
+	}
+	name, rest := line[:nameEnd], line[nameEnd:]
+
+	if strings.HasPrefix(rest, "{") {
+		rest = rest[1:]
+		for {
+			rest = strings.TrimLeft(rest, ", ")
+			if strings.HasPrefix(rest, "}") {
+				rest = rest[1:]
+				break
+			}
+
+			eq := strings.Index(rest, "=\"")
+			if eq <= 0 {
+				return "", nil, 0, false
+			}
+			key := rest[:eq]
+			rest = rest[eq+2:]
+
+			var value strings.Builder
+			closed := false
+			for i := 0; i < len(rest); i++ {
+				ch := rest[i]
+				if ch == '\\' && i+1 < len(rest) {
+					i++
+					switch rest[i] {
+					case 'n':
+						value.WriteByte('\n')
+					default:
+						value.WriteByte(rest[i])
+					}
+					continue
+				}
+				if ch == '"' {
+					rest = rest[i+1:]
+					closed = true
+					break
+				}
+				value.WriteByte(ch)
+			}
+			if !closed {
+				return "", nil, 0, false
+			}
+			labels[key] = value.String()
+		}
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return "", nil, 0, false
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return "", nil, 0, false
+	}
+	return name, labels, value, true
+}
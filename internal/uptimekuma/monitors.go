@@ -0,0 +1,137 @@
+// Package uptimekuma keeps Uptime Kuma monitors in sync with the containers Census discovers
+// and reads their status back so availability can be shown alongside resource stats.
+package uptimekuma
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/container-census/container-census/internal/exposure"
+	"github.com/container-census/container-census/internal/models"
+)
+
+// MonitorPrefix marks monitors managed by Census. Monitors are matched by name, so a container
+// keeps its monitor (and history) when it is recreated.
+const MonitorPrefix = "census: "
+
+// httpPorts are container ports that conventionally serve HTTP, in order of preference
+var httpPorts = []int{80, 443, 8080, 8443, 8000, 8008, 8081, 8088, 8888, 3000, 5000, 9000}
+
+// httpsPorts are the ports in httpPorts that serve HTTPS
+var httpsPorts = map[int]bool{443: true, 8443: true}
+
+// DesiredMonitor is an HTTP monitor that should exist in Uptime Kuma
+type DesiredMonitor struct {
+	Name          string
+	URL           string
+	HostID        int64
+	HostName      string
+	ContainerName string
+}
+
+// MonitorName returns the name of the monitor for a container
+func MonitorName(hostName, containerName string) string {
+	return MonitorPrefix + hostName + "/" + containerName
+}
+
+// DesiredMonitors returns one HTTP monitor for every running container that publishes a
+// well-known HTTP port, or that sets the census.uptime label
+func DesiredMonitors(containers []models.Container, hosts []models.Host) []DesiredMonitor {
+	hostAddresses := make(map[int64]string, len(hosts))
+	for _, host := range hosts {
+		hostAddresses[host.ID] = exposure.HostAddress(host)
+	}
+
+	seen := make(map[string]bool)
+	monitors := make([]DesiredMonitor, 0)
+	for _, c := range containers {
+		if c.State != "running" {
+			continue
+		}
+
+		monitorURL := containerURL(c, hostAddresses[c.HostID])
+		if monitorURL == "" {
+			continue
+		}
+
+		name := MonitorName(c.HostName, c.Name)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		monitors = append(monitors, DesiredMonitor{
+			Name:          name,
+			URL:           monitorURL,
+			HostID:        c.HostID,
+			HostName:      c.HostName,
+			ContainerName: c.Name,
+		})
+	}
+
+	sort.Slice(monitors, func(i, j int) bool {
+		return monitors[i].Name < monitors[j].Name
+	})
+	return monitors
+}
+
+// containerURL returns the URL to monitor a container at, or "" when it shouldn't be monitored
+func containerURL(c models.Container, hostAddress string) string {
+	label := strings.TrimSpace(c.Labels[models.UptimeLabel])
+	switch {
+	case strings.EqualFold(label, "false"):
+		return ""
+	case strings.HasPrefix(label, "http://") || strings.HasPrefix(label, "https://"):
+		return label
+	}
+	anyPort := strings.EqualFold(label, "true")
+
+	var best *models.PortMapping
+	bestRank := len(httpPorts)
+	for i := range c.Ports {
+		p := &c.Ports[i]
+		if p.PublicPort == 0 || (p.Type != "" && p.Type != "tcp") {
+			continue
+		}
+		if exposure.ClassifyBinding(p.IP) == models.BindingLoopback {
+			continue
+		}
+
+		rank := portRank(p.PrivatePort)
+		if rank == len(httpPorts) && !anyPort {
+			continue
+		}
+		if best == nil || rank < bestRank {
+			best, bestRank = p, rank
+		}
+	}
+	if best == nil {
+		return ""
+	}
+
+	address := hostAddress
+	if exposure.ClassifyBinding(best.IP) == models.BindingSpecific {
+		address = best.IP
+	}
+	if address == "" {
+		return ""
+	}
+
+	scheme := "http"
+	if httpsPorts[best.PrivatePort] {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(address, strconv.Itoa(best.PublicPort)))
+}
+
+func portRank(port int) int {
+	for i, p := range httpPorts {
+		if p == port {
+			return i
+		}
+	}
+	return len(httpPorts)
+}
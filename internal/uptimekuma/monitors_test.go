@@ -0,0 +1,69 @@
+package uptimekuma
+
+import (
+	"testing"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+func TestDesiredMonitors(t *testing.T) {
+	hosts := []models.Host{
+		{ID: 1, Name: "nas", Address: "agent://nas.lan:9876"},
+		{ID: 2, Name: "local", Address: "unix:///var/run/docker.sock"},
+	}
+	containers := []models.Container{
+		{Name: "nginx", HostID: 1, HostName: "nas", State: "running", Ports: []models.PortMapping{
+			{PrivatePort: 443, PublicPort: 8443, Type: "tcp", IP: "0.0.0.0"},
+			{PrivatePort: 80, PublicPort: 8080, Type: "tcp", IP: "0.0.0.0"},
+		}},
+		{Name: "proxy", HostID: 1, HostName: "nas", State: "running", Ports: []models.PortMapping{
+			{PrivatePort: 443, PublicPort: 8443, Type: "tcp"},
+		}},
+		{Name: "postgres", HostID: 1, HostName: "nas", State: "running", Ports: []models.PortMapping{
+			{PrivatePort: 5432, PublicPort: 5432, Type: "tcp"},
+		}},
+		{Name: "custom", HostID: 1, HostName: "nas", State: "running", Labels: map[string]string{models.UptimeLabel: "true"}, Ports: []models.PortMapping{
+			{PrivatePort: 7878, PublicPort: 7878, Type: "tcp"},
+		}},
+		{Name: "optout", HostID: 1, HostName: "nas", State: "running", Labels: map[string]string{models.UptimeLabel: "false"}, Ports: []models.PortMapping{
+			{PrivatePort: 80, PublicPort: 81, Type: "tcp"},
+		}},
+		{Name: "loopback", HostID: 1, HostName: "nas", State: "running", Ports: []models.PortMapping{
+			{PrivatePort: 80, PublicPort: 82, Type: "tcp", IP: "127.0.0.1"},
+		}},
+		{Name: "pinned", HostID: 1, HostName: "nas", State: "running", Ports: []models.PortMapping{
+			{PrivatePort: 3000, PublicPort: 3000, Type: "tcp", IP: "192.168.1.5"},
+		}},
+		{Name: "stopped", HostID: 1, HostName: "nas", State: "exited", Ports: []models.PortMapping{
+			{PrivatePort: 80, PublicPort: 83, Type: "tcp"},
+		}},
+		{Name: "socket", HostID: 2, HostName: "local", State: "running", Ports: []models.PortMapping{
+			{PrivatePort: 80, PublicPort: 80, Type: "tcp"},
+		}},
+		{Name: "labelled", HostID: 2, HostName: "local", State: "running", Labels: map[string]string{models.UptimeLabel: "https://app.example.com/health"}},
+	}
+
+	got := make(map[string]string)
+	for _, m := range DesiredMonitors(containers, hosts) {
+		got[m.ContainerName] = m.URL
+		if m.Name != MonitorName(m.HostName, m.ContainerName) {
+			t.Errorf("Unexpected monitor name %q", m.Name)
+		}
+	}
+
+	want := map[string]string{
+		"nginx":    "http://nas.lan:8080",
+		"proxy":    "https://nas.lan:8443",
+		"custom":   "http://nas.lan:7878",
+		"pinned":   "http://192.168.1.5:3000",
+		"labelled": "https://app.example.com/health",
+	}
+	if len(got) != len(want) {
+		t.Errorf("Expected %d monitors, got %v", len(want), got)
+	}
+	for name, url := range want {
+		if got[name] != url {
+			t.Errorf("Expected %s to be monitored at %s, got %q", name, url, got[name])
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package uptimekuma
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// Sync creates or updates a monitor for every container that should be monitored (when monitor
+// sync is enabled) and reads back the status of those monitors. Failures are collected in the
+// result so that one unreachable part of Uptime Kuma doesn't hide the rest.
+func Sync(ctx context.Context, settings *models.UptimeKumaSettings, containers []models.Container, hosts []models.Host, httpClient *http.Client) (*models.UptimeKumaSyncResult, []models.UptimeMonitorStatus) {
+	desired := DesiredMonitors(containers, hosts)
+	result := &models.UptimeKumaSyncResult{
+		Monitors: len(desired),
+		SyncedAt: time.Now(),
+	}
+
+	if settings.SyncMonitors {
+		client := NewClient(settings.URL, httpClient)
+		if err := syncMonitors(ctx, client, settings, desired, result); err != nil {
+			result.Errors = append(result.Errors, err.Error())
+		}
+		client.Close(ctx)
+	}
+
+	username, password := settings.Username, settings.Password
+	if settings.APIKey != "" {
+		username, password = "", settings.APIKey
+	}
+	metrics, err := FetchStatus(ctx, httpClient, settings.URL, username, password)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return result, nil
+	}
+
+	statuses := make([]models.UptimeMonitorStatus, 0, len(desired))
+	for _, d := range desired {
+		metric, ok := metrics[d.Name]
+		if !ok {
+			continue
+		}
+		statuses = append(statuses, models.UptimeMonitorStatus{
+			HostID:         d.HostID,
+			HostName:       d.HostName,
+			ContainerName:  d.ContainerName,
+			MonitorName:    d.Name,
+			URL:            d.URL,
+			Status:         metric.Status,
+			ResponseTimeMS: metric.ResponseTimeMS,
+			CheckedAt:      result.SyncedAt,
+		})
+	}
+	result.Statuses = len(statuses)
+
+	return result, statuses
+}
+
+// syncMonitors logs in and adds or edits monitors so they match the desired ones. Monitors of
+// containers that went away are left alone so their history isn't lost.
+func syncMonitors(ctx context.Context, client *Client, settings *models.UptimeKumaSettings, desired []DesiredMonitor, result *models.UptimeKumaSyncResult) error {
+	if err := client.Connect(ctx); err != nil {
+		return err
+	}
+	if err := client.Login(ctx, settings.Username, settings.Password); err != nil {
+		return err
+	}
+
+	monitors, err := client.Monitors(ctx)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]Monitor, len(monitors))
+	for _, m := range monitors {
+		existing[m.Name()] = m
+	}
+
+	for _, d := range desired {
+		m, ok := existing[d.Name]
+		switch {
+		case !ok:
+			if _, err := client.AddMonitor(ctx, d, settings.MonitorIntervalSeconds); err != nil {
+				result.Errors = append(result.Errors, err.Error())
+				continue
+			}
+			result.Created++
+		case m.URL() != d.URL || m.Interval() != settings.MonitorIntervalSeconds:
+			if err := client.EditMonitor(ctx, m, d, settings.MonitorIntervalSeconds); err != nil {
+				result.Errors = append(result.Errors, err.Error())
+				continue
+			}
+			result.Updated++
+		default:
+			result.Unchanged++
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,185 @@
+package uptimekuma
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/container-census/container-census/internal/models"
+)
+
+// fakeKuma implements enough of Uptime Kuma's Socket.IO long-polling API and /metrics endpoint
+// to exercise a sync
+type fakeKuma struct {
+	mu       sync.Mutex
+	queue    []string
+	monitors map[string]Monitor
+	added    []Monitor
+	edited   []Monitor
+	pongs    int
+	metrics  string
+}
+
+func (f *fakeKuma) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/metrics" {
+		if _, password, ok := r.BasicAuth(); !ok || password != "api-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		io.WriteString(w, f.metrics)
+		return
+	}
+
+	if r.URL.Query().Get("sid") == "" {
+		io.WriteString(w, `0{"sid":"abc","upgrades":["websocket"],"pingInterval":25000,"pingTimeout":20000}`)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		if len(f.queue) == 0 {
+			io.WriteString(w, "6") // noop
+			return
+		}
+		io.WriteString(w, strings.Join(f.queue, packetSeparator))
+		f.queue = nil
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	f.handlePacket(string(body))
+	io.WriteString(w, "ok")
+}
+
+func (f *fakeKuma) handlePacket(packet string) {
+	switch {
+	case packet == "40":
+		f.queue = append(f.queue, "2", `40{"sid":"ns"}`)
+	case packet == "3":
+		f.pongs++
+	case strings.HasPrefix(packet, "42"):
+		rest := packet[2:]
+		i := strings.Index(rest, "[")
+		id := rest[:i]
+		var args []json.RawMessage
+		json.Unmarshal([]byte(rest[i:]), &args)
+		var event string
+		json.Unmarshal(args[0], &event)
+
+		switch event {
+		case "login":
+			list, _ := json.Marshal(f.monitors)
+			f.queue = append(f.queue, "43"+id+`[{"ok":true,"token":"t"}]`, `42["monitorList",`+string(list)+`]`)
+		case "add", "editMonitor":
+			var m Monitor
+			json.Unmarshal(args[1], &m)
+			if event == "add" {
+				f.added = append(f.added, m)
+			} else {
+				f.edited = append(f.edited, m)
+			}
+			f.queue = append(f.queue, "43"+id+`[{"ok":true,"monitorID":`+strconv.Itoa(10+len(f.added))+`}]`)
+		}
+	}
+}
+
+func TestSync(t *testing.T) {
+	kuma := &fakeKuma{
+		monitors: map[string]Monitor{
+			"1": {"id": 1, "name": "census: nas/grafana", "url": "http://nas.lan:3000", "interval": 60, "accepted_statuscodes": []string{"200-299"}},
+			"2": {"id": 2, "name": "census: nas/nginx", "url": "http://nas.lan:8000", "interval": 60},
+			"3": {"id": 3, "name": "Router", "url": "http://192.168.1.1", "interval": 60},
+		},
+		metrics: `# HELP monitor_status Monitor Status (1 = UP, 0= DOWN, 2= PENDING, 3= MAINTENANCE)
+# TYPE monitor_status gauge
+monitor_status{monitor_name="census: nas/grafana",monitor_type="http",monitor_url="http://nas.lan:3000",monitor_hostname="null",monitor_port="null"} 1
+monitor_status{monitor_name="census: nas/nginx",monitor_type="http",monitor_url="http://nas.lan:8080",monitor_hostname="null",monitor_port="null"} 0
+monitor_status{monitor_name="Router",monitor_type="http",monitor_url="http://192.168.1.1",monitor_hostname="null",monitor_port="null"} 1
+monitor_response_time{monitor_name="census: nas/grafana",monitor_type="http",monitor_url="http://nas.lan:3000",monitor_hostname="null",monitor_port="null"} 42
+`,
+	}
+	server := httptest.NewServer(kuma)
+	defer server.Close()
+
+	settings := &models.UptimeKumaSettings{
+		Enabled:                true,
+		URL:                    server.URL,
+		Username:               "admin",
+		Password:               "secret",
+		APIKey:                 "api-key",
+		SyncMonitors:           true,
+		MonitorIntervalSeconds: 60,
+		SyncIntervalMinutes:    5,
+	}
+	hosts := []models.Host{{ID: 1, Name: "nas", Address: "agent://nas.lan:9876"}}
+	containers := []models.Container{
+		{Name: "grafana", HostID: 1, HostName: "nas", State: "running", Ports: []models.PortMapping{{PrivatePort: 3000, PublicPort: 3000, Type: "tcp"}}},
+		{Name: "nginx", HostID: 1, HostName: "nas", State: "running", Ports: []models.PortMapping{{PrivatePort: 80, PublicPort: 8080, Type: "tcp"}}},
+		{Name: "portainer", HostID: 1, HostName: "nas", State: "running", Ports: []models.PortMapping{{PrivatePort: 9000, PublicPort: 9000, Type: "tcp"}}},
+	}
+
+	result, statuses := Sync(context.Background(), settings, containers, hosts, server.Client())
+
+	if len(result.Errors) != 0 {
+		t.Fatalf("Unexpected errors: %v", result.Errors)
+	}
+	if result.Monitors != 3 || result.Created != 1 || result.Updated != 1 || result.Unchanged != 1 {
+		t.Errorf("Unexpected sync result: %+v", result)
+	}
+	if len(kuma.added) != 1 || kuma.added[0].Name() != "census: nas/portainer" || kuma.added[0].URL() != "http://nas.lan:9000" {
+		t.Errorf("Expected portainer monitor to be added, got %v", kuma.added)
+	}
+	if len(kuma.edited) != 1 || kuma.edited[0].ID() != 2 || kuma.edited[0].URL() != "http://nas.lan:8080" {
+		t.Errorf("Expected nginx monitor to be repointed, got %v", kuma.edited)
+	}
+	if kuma.pongs != 1 {
+		t.Errorf("Expected server ping to be answered, got %d pongs", kuma.pongs)
+	}
+
+	if len(statuses) != 2 {
+		t.Fatalf("Expected statuses for grafana and nginx, got %+v", statuses)
+	}
+	byName := make(map[string]models.UptimeMonitorStatus)
+	for _, s := range statuses {
+		byName[s.ContainerName] = s
+	}
+	if s := byName["grafana"]; s.Status != models.UptimeStatusUp || s.ResponseTimeMS != 42 || s.HostID != 1 {
+		t.Errorf("Unexpected grafana status: %+v", s)
+	}
+	if s := byName["nginx"]; s.Status != models.UptimeStatusDown {
+		t.Errorf("Unexpected nginx status: %+v", s)
+	}
+}
+
+func TestSyncReportsLoginFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	settings := &models.UptimeKumaSettings{Enabled: true, URL: server.URL, Username: "admin", Password: "x", SyncMonitors: true}
+	result, statuses := Sync(context.Background(), settings, nil, nil, server.Client())
+
+	if len(result.Errors) != 2 || statuses != nil {
+		t.Errorf("Expected connect and metrics errors, got %+v", result.Errors)
+	}
+}
+
+func TestParseSample(t *testing.T) {
+	name, labels, value, ok := parseSample(`monitor_status{monitor_name="say \"hi\", ok",monitor_type="http"} 3`)
+	if !ok || name != "monitor_status" || value != 3 || labels["monitor_name"] != `say "hi", ok` || labels["monitor_type"] != "http" {
+		t.Errorf("Unexpected sample: %s %v %v %v", name, labels, value, ok)
+	}
+
+	if _, _, _, ok := parseSample(`monitor_status{monitor_name="broken} 1`); ok {
+		t.Error("Expected unterminated label to be rejected")
+	}
+}
@@ -2,7 +2,9 @@ package vulnerability
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"strings"
 	"sync"
 	"time"
 )
@@ -23,6 +25,14 @@ type Config struct {
 	AlertOnCritical        bool          `json:"alert_on_critical"`
 	AlertOnHigh            bool          `json:"alert_on_high"`
 	MaxQueueSize           int           `json:"max_queue_size"`
+	// Trivy client/server mode: when ServerURL is set, scans run against a remote
+	// `trivy server` instead of a local vulnerability database
+	ServerURL              string        `json:"server_url"`
+	ServerToken            string        `json:"server_token,omitempty"`
+	ServerTokenHeader      string        `json:"server_token_header,omitempty"`
+	FallbackToLocal        bool          `json:"fallback_to_local"`
+	// Scan images on the census-agent hosting them instead of on the server
+	AgentScanning          bool          `json:"agent_scanning"`
 }
 
 // DefaultConfig returns the default vulnerability scanner configuration
@@ -47,6 +57,10 @@ func DefaultConfig() *Config {
 		AlertOnCritical:        true,
 		AlertOnHigh:            false,
 		MaxQueueSize:           100,
+		ServerURL:              os.Getenv("TRIVY_SERVER_URL"),
+		ServerToken:            os.Getenv("TRIVY_TOKEN"),
+		FallbackToLocal:        false,
+		AgentScanning:          false,
 	}
 }
 
@@ -220,6 +234,63 @@ func (c *Config) GetDetailedRetentionDays() int {
 	return c.DetailedRetentionDays
 }
 
+// GetServerURL returns the remote Trivy server URL, or empty for local scanning
+func (c *Config) GetServerURL() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.ServerURL
+}
+
+// UsesServer returns whether scans run against a remote Trivy server
+func (c *Config) UsesServer() bool {
+	return c.GetServerURL() != ""
+}
+
+// GetServerToken returns the token and header used to authenticate with the Trivy server
+func (c *Config) GetServerToken() (token, header string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.ServerToken, c.ServerTokenHeader
+}
+
+// GetFallbackToLocal returns whether to scan locally when the Trivy server is unreachable
+func (c *Config) GetFallbackToLocal() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.FallbackToLocal
+}
+
+// GetAgentScanning returns whether scans are delegated to the agents hosting the images
+func (c *Config) GetAgentScanning() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.AgentScanning
+}
+
+// validateServerURL checks that a Trivy server URL is an absolute http(s) URL
+func validateServerURL(serverURL string) error {
+	if serverURL == "" {
+		return nil
+	}
+	u, err := url.Parse(serverURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("trivy server URL must be an http:// or https:// URL")
+	}
+	return nil
+}
+
+// MaskedServerToken stands in for the Trivy server token in configs returned by Redacted
+const MaskedServerToken = "********"
+
+// Redacted returns a copy of the configuration that is safe to return from the API
+func (c *Config) Redacted() *Config {
+	clone := c.Clone()
+	if clone.ServerToken != "" {
+		clone.ServerToken = MaskedServerToken
+	}
+	return clone
+}
+
 // Clone creates a copy of the configuration
 func (c *Config) Clone() *Config {
 	c.mu.RLock()
@@ -238,6 +309,11 @@ func (c *Config) Clone() *Config {
 		AlertOnCritical:        c.AlertOnCritical,
 		AlertOnHigh:            c.AlertOnHigh,
 		MaxQueueSize:           c.MaxQueueSize,
+		ServerURL:              c.ServerURL,
+		ServerToken:            c.ServerToken,
+		ServerTokenHeader:      c.ServerTokenHeader,
+		FallbackToLocal:        c.FallbackToLocal,
+		AgentScanning:          c.AgentScanning,
 	}
 }
 
@@ -256,6 +332,10 @@ func (c *Config) Update(newConfig *Config) error {
 	if newConfig.RescanIntervalHours < 24 || newConfig.RescanIntervalHours > 720 {
 		return fmt.Errorf("rescan interval must be between 24 and 720 hours")
 	}
+	newConfig.ServerURL = strings.TrimRight(strings.TrimSpace(newConfig.ServerURL), "/")
+	if err := validateServerURL(newConfig.ServerURL); err != nil {
+		return err
+	}
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -273,6 +353,14 @@ func (c *Config) Update(newConfig *Config) error {
 	c.AlertOnCritical = newConfig.AlertOnCritical
 	c.AlertOnHigh = newConfig.AlertOnHigh
 	c.MaxQueueSize = newConfig.MaxQueueSize
+	c.ServerURL = newConfig.ServerURL
+	c.ServerTokenHeader = newConfig.ServerTokenHeader
+	c.FallbackToLocal = newConfig.FallbackToLocal
+	c.AgentScanning = newConfig.AgentScanning
+	// Redacted configs echo the mask back, which keeps the saved token
+	if newConfig.ServerToken != MaskedServerToken {
+		c.ServerToken = newConfig.ServerToken
+	}
 
 	return nil
 }
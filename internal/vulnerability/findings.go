@@ -0,0 +1,45 @@
+package vulnerability
+
+import "time"
+
+// NewFindings returns the vulnerabilities of current in the given severities (e.g. "CRITICAL"),
+// one per vulnerability ID and most severe first, that previous didn't have at that severity or
+// a higher one and that no exception covers: what a rescan of an image found after a
+// vulnerability database update, including vulnerabilities whose severity was raised
+func NewFindings(previous, current []Vulnerability, severities []string, exceptions []Exception, imageName string, now time.Time) []Vulnerability {
+	known := make(map[string]int, len(previous))
+	for _, v := range previous {
+		if rank, ok := known[v.VulnerabilityID]; !ok || severityRank(v.Severity) < rank {
+			known[v.VulnerabilityID] = severityRank(v.Severity)
+		}
+	}
+
+	var findings []Vulnerability
+	reported := make(map[string]bool)
+	for _, severity := range severities {
+		for _, v := range current {
+			if v.Severity != severity || reported[v.VulnerabilityID] {
+				continue
+			}
+			if rank, ok := known[v.VulnerabilityID]; ok && rank <= severityRank(severity) {
+				continue
+			}
+			if excepted(v, exceptions, imageName, now) {
+				continue
+			}
+			reported[v.VulnerabilityID] = true
+			findings = append(findings, v)
+		}
+	}
+	return findings
+}
+
+// excepted reports whether an exception covers a vulnerability of an image
+func excepted(v Vulnerability, exceptions []Exception, imageName string, now time.Time) bool {
+	for _, e := range exceptions {
+		if e.Matches(v.VulnerabilityID, imageName, now) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package vulnerability
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewFindings(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	previous := []Vulnerability{
+		{VulnerabilityID: "CVE-OLD", PkgName: "openssl", Severity: "CRITICAL"},
+		{VulnerabilityID: "CVE-RAISED", PkgName: "curl", Severity: "MEDIUM"},
+	}
+	current := []Vulnerability{
+		{VulnerabilityID: "CVE-OLD", PkgName: "openssl", Severity: "CRITICAL"},
+		{VulnerabilityID: "CVE-RAISED", PkgName: "curl", Severity: "CRITICAL"},
+		{VulnerabilityID: "CVE-HIGH", PkgName: "zlib", Severity: "HIGH"},
+		{VulnerabilityID: "CVE-NEW", PkgName: "xz-utils", Severity: "CRITICAL"},
+		{VulnerabilityID: "CVE-NEW", PkgName: "liblzma5", Severity: "CRITICAL"},
+		{VulnerabilityID: "CVE-ACCEPTED", PkgName: "bash", Severity: "CRITICAL"},
+		{VulnerabilityID: "CVE-LOW", PkgName: "tar", Severity: "LOW"},
+	}
+	exceptions := []Exception{{VulnerabilityID: "CVE-ACCEPTED", ImagePattern: "postgres:*"}}
+
+	ids := func(vulns []Vulnerability) []string {
+		var ids []string
+		for _, v := range vulns {
+			ids = append(ids, v.VulnerabilityID)
+		}
+		return ids
+	}
+
+	got := ids(NewFindings(previous, current, []string{"CRITICAL", "HIGH"}, exceptions, "postgres:16", now))
+	if len(got) != 3 || got[0] != "CVE-RAISED" || got[1] != "CVE-NEW" || got[2] != "CVE-HIGH" {
+		t.Errorf("Expected the raised and new critical, then the high, got %v", got)
+	}
+
+	got = ids(NewFindings(previous, current, []string{"CRITICAL"}, exceptions, "redis:7", now))
+	if len(got) != 3 || got[2] != "CVE-ACCEPTED" {
+		t.Errorf("Expected the exception not to apply to another image, got %v", got)
+	}
+
+	if got := NewFindings(current, current, []string{"CRITICAL", "HIGH"}, nil, "postgres:16", now); len(got) != 0 {
+		t.Errorf("Expected no new findings on an unchanged scan, got %v", ids(got))
+	}
+}
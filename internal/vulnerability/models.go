@@ -110,18 +110,7 @@ type TrivyResult struct {
 func CalculateSeverityCounts(vulns []Vulnerability) SeverityCounts {
 	counts := SeverityCounts{}
 	for _, v := range vulns {
-		switch v.Severity {
-		case "CRITICAL":
-			counts.Critical++
-		case "HIGH":
-			counts.High++
-		case "MEDIUM":
-			counts.Medium++
-		case "LOW":
-			counts.Low++
-		default:
-			counts.Unknown++
-		}
+		addSeverity(&counts, v.Severity)
 	}
 	return counts
 }
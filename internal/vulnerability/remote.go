@@ -0,0 +1,76 @@
+package vulnerability
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// RemoteScanner runs Trivy on the census-agent hosting an image, so the server doesn't need
+// a local copy of every image it scans
+type RemoteScanner interface {
+	// ScanRemote returns Trivy's JSON output for the image. handled is false when no agent
+	// can scan the image, in which case the scan runs on the server as usual.
+	ScanRemote(ctx context.Context, imageID, imageName string) (output []byte, handled bool, err error)
+}
+
+// AgentDBVersion is recorded as the Trivy DB version of scans that ran on an agent
+const AgentDBVersion = "agent"
+
+// SetRemoteScanner sets the scanner used for agent scans. It must be called before scanning starts.
+func (s *Scanner) SetRemoteScanner(remote RemoteScanner) {
+	s.remote = remote
+}
+
+// scanOnAgent delegates a scan to the image's agent when agent scanning is enabled. It returns
+// a nil result and no error when the scan should run on the server instead.
+func (s *Scanner) scanOnAgent(ctx context.Context, imageID, imageName string) (*TrivyResult, string, error) {
+	if s.remote == nil || !s.config.GetAgentScanning() {
+		return nil, "", nil
+	}
+
+	output, handled, err := s.remote.ScanRemote(ctx, imageID, imageName)
+	if !handled {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	result, err := ParseTrivyOutput(output)
+	if err != nil {
+		return nil, "", err
+	}
+	return result, AgentDBVersion, nil
+}
+
+// ParseTrivyOutput parses the JSON report of `trivy image --format json`
+func ParseTrivyOutput(output []byte) (*TrivyResult, error) {
+	var result TrivyResult
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse trivy output: %w", err)
+	}
+	return &result, nil
+}
+
+// IngestResult records a Trivy report produced outside the scan queue, such as one uploaded by
+// an agent. The image ID and name default to the ones in the report.
+func (s *Scanner) IngestResult(imageID, imageName string, trivyResult *TrivyResult, dbVersion string, duration time.Duration) (*VulnerabilityScanResult, error) {
+	if imageID == "" {
+		imageID = trivyResult.Metadata.ImageID
+	}
+	if imageName == "" {
+		imageName = trivyResult.ArtifactName
+	}
+	if imageID == "" {
+		return nil, fmt.Errorf("image ID is required")
+	}
+
+	result := s.newScanResult(imageID, imageName, trivyResult, dbVersion, duration)
+	if err := s.cache.Set(&result.Scan, result.Vulnerabilities); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,114 @@
+package vulnerability
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type fakeRemoteScanner struct {
+	output  []byte
+	handled bool
+	err     error
+	calls   int
+}
+
+func (f *fakeRemoteScanner) ScanRemote(ctx context.Context, imageID, imageName string) ([]byte, bool, error) {
+	f.calls++
+	return f.output, f.handled, f.err
+}
+
+const agentReport = `{
+	"ArtifactName": "nginx:latest",
+	"Metadata": {"ImageID": "sha256:nginx"},
+	"Results": [{
+		"Target": "debian",
+		"Vulnerabilities": [
+			{"VulnerabilityID": "CVE-2024-0001", "PkgName": "openssl", "Severity": "critical"},
+			{"VulnerabilityID": "CVE-2024-0002", "PkgName": "zlib", "Severity": "LOW"}
+		]
+	}]
+}`
+
+func TestScanImageOnAgent(t *testing.T) {
+	config := DefaultConfig()
+	config.AgentScanning = true
+	storage := newMockStorage()
+	scanner := NewScanner(config, storage)
+	remote := &fakeRemoteScanner{output: []byte(agentReport), handled: true}
+	scanner.SetRemoteScanner(remote)
+
+	result, err := scanner.ScanImage(context.Background(), "sha256:nginx", "nginx:latest")
+	if err != nil {
+		t.Fatalf("Expected agent scan to succeed: %v", err)
+	}
+	if result.Scan.TrivyDBVersion != AgentDBVersion {
+		t.Errorf("Expected DB version %q, got %q", AgentDBVersion, result.Scan.TrivyDBVersion)
+	}
+	if result.Scan.SeverityCounts.Critical != 1 || result.Scan.SeverityCounts.Low != 1 {
+		t.Errorf("Unexpected severity counts: %+v", result.Scan.SeverityCounts)
+	}
+	if storage.scans["sha256:nginx"] == nil {
+		t.Error("Expected agent scan to be saved")
+	}
+
+	// A failing agent scan fails the job instead of falling back to the server
+	remote.err = errors.New("image not available for scanning")
+	if _, err := scanner.ScanImage(context.Background(), "sha256:nginx", "nginx:latest"); err == nil {
+		t.Error("Expected agent scan error to be returned")
+	}
+	if storage.scans["sha256:nginx"].Success {
+		t.Error("Expected failed agent scan to be recorded")
+	}
+
+	// Disabled agent scanning never calls the agents
+	config.AgentScanning = false
+	calls := remote.calls
+	if trivyResult, _, err := scanner.scanOnAgent(context.Background(), "sha256:nginx", "nginx:latest"); trivyResult != nil || err != nil {
+		t.Errorf("Expected no agent scan when disabled, got %v, %v", trivyResult, err)
+	}
+	if remote.calls != calls {
+		t.Error("Expected agents not to be called when agent scanning is disabled")
+	}
+}
+
+func TestScanOnAgentNotHandled(t *testing.T) {
+	config := DefaultConfig()
+	config.AgentScanning = true
+	scanner := NewScanner(config, newMockStorage())
+	scanner.SetRemoteScanner(&fakeRemoteScanner{handled: false})
+
+	// Unhandled images are left to the server
+	trivyResult, _, err := scanner.scanOnAgent(context.Background(), "sha256:local", "local:latest")
+	if trivyResult != nil || err != nil {
+		t.Errorf("Expected unhandled scan to fall through, got %v, %v", trivyResult, err)
+	}
+}
+
+func TestIngestResult(t *testing.T) {
+	storage := newMockStorage()
+	scanner := NewScanner(DefaultConfig(), storage)
+
+	report, err := ParseTrivyOutput([]byte(agentReport))
+	if err != nil {
+		t.Fatalf("Failed to parse report: %v", err)
+	}
+
+	result, err := scanner.IngestResult("", "", report, "", 0)
+	if err != nil {
+		t.Fatalf("Expected ingest to succeed: %v", err)
+	}
+	if result.Scan.ImageID != "sha256:nginx" || result.Scan.ImageName != "nginx:latest" {
+		t.Errorf("Expected image identity from the report, got %s (%s)", result.Scan.ImageID, result.Scan.ImageName)
+	}
+	if scanner.NeedsScan("sha256:nginx") {
+		t.Error("Expected ingested scan to be cached")
+	}
+
+	if _, err := scanner.IngestResult("", "", &TrivyResult{}, "", 0); err == nil {
+		t.Error("Expected error for a report without an image ID")
+	}
+	if _, err := ParseTrivyOutput([]byte("not json")); err == nil {
+		t.Error("Expected error for invalid report")
+	}
+}
@@ -0,0 +1,252 @@
+package vulnerability
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Exception records an accepted risk: a vulnerability that is reviewed and deliberately not
+// fixed. Excepted findings stay in reports but are counted separately from open findings.
+type Exception struct {
+	ID              int64      `json:"id"`
+	VulnerabilityID string     `json:"vulnerability_id"`
+	ImagePattern    string     `json:"image_pattern"` // glob on the image name, e.g. "nginx:*"; empty matches every image
+	Reason          string     `json:"reason"`
+	CreatedAt       time.Time  `json:"created_at"`
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+}
+
+// Matches reports whether the exception covers a vulnerability in an image at the given time
+func (e Exception) Matches(vulnerabilityID, imageName string, now time.Time) bool {
+	if !strings.EqualFold(e.VulnerabilityID, vulnerabilityID) {
+		return false
+	}
+	if e.ExpiresAt != nil && !now.Before(*e.ExpiresAt) {
+		return false
+	}
+	if e.ImagePattern == "" {
+		return true
+	}
+	matched, err := path.Match(e.ImagePattern, imageName)
+	return err == nil && matched
+}
+
+// ReportImageInput is an image in scope of a report, with its latest scan if it has one
+type ReportImageInput struct {
+	ImageID         string
+	ImageName       string
+	Hosts           []string
+	Containers      int
+	Scan            *VulnerabilityScan
+	Vulnerabilities []Vulnerability
+}
+
+// ReportFinding is one vulnerability in one image
+type ReportFinding struct {
+	ImageName        string `json:"image_name"`
+	ImageID          string `json:"image_id"`
+	Hosts            string `json:"hosts"`
+	VulnerabilityID  string `json:"vulnerability_id"`
+	Severity         string `json:"severity"`
+	PkgName          string `json:"pkg_name"`
+	InstalledVersion string `json:"installed_version"`
+	FixedVersion     string `json:"fixed_version"`
+	FixAvailable     bool   `json:"fix_available"`
+	Title            string `json:"title"`
+	PrimaryURL       string `json:"primary_url"`
+	Excepted         bool   `json:"excepted"`
+	ExceptionReason  string `json:"exception_reason,omitempty"`
+}
+
+// ReportImage is the per-image rollup of a report. Counts only include open findings.
+type ReportImage struct {
+	ImageName    string         `json:"image_name"`
+	ImageID      string         `json:"image_id"`
+	Hosts        []string       `json:"hosts"`
+	Containers   int            `json:"containers"`
+	Scanned      bool           `json:"scanned"`
+	ScanError    string         `json:"scan_error,omitempty"`
+	ScannedAt    *time.Time     `json:"scanned_at,omitempty"`
+	Counts       SeverityCounts `json:"counts"`
+	Fixable      int            `json:"fixable"`
+	Excepted     int            `json:"excepted"`
+	Compliant    bool           `json:"compliant"`
+	TrivyVersion string         `json:"trivy_db_version,omitempty"`
+}
+
+// ReportSummary is the compliance rollup across all images in a report
+type ReportSummary struct {
+	Images          int            `json:"images"`
+	ScannedImages   int            `json:"scanned_images"`
+	UnscannedImages int            `json:"unscanned_images"`
+	CompliantImages int            `json:"compliant_images"` // scanned, with no open critical or high findings
+	OpenFindings    int            `json:"open_findings"`
+	Counts          SeverityCounts `json:"counts"`
+	FixableCounts   SeverityCounts `json:"fixable_counts"`
+	ExceptedCount   int            `json:"excepted_findings"`
+	OldestScan      *time.Time     `json:"oldest_scan,omitempty"`
+}
+
+// Report is a point-in-time vulnerability report for a host or the whole fleet
+type Report struct {
+	Scope       string          `json:"scope"`
+	GeneratedAt time.Time       `json:"generated_at"`
+	Summary     ReportSummary   `json:"summary"`
+	Images      []ReportImage   `json:"images"`
+	Findings    []ReportFinding `json:"findings"`
+	Exceptions  []Exception     `json:"exceptions"`
+}
+
+// BuildReport rolls up the latest scans of the images in scope. Findings covered by an active
+// exception are marked as excepted and left out of the open counts.
+func BuildReport(scope string, inputs []ReportImageInput, exceptions []Exception, now time.Time) *Report {
+	report := &Report{
+		Scope:       scope,
+		GeneratedAt: now,
+		Images:      make([]ReportImage, 0, len(inputs)),
+		Findings:    make([]ReportFinding, 0),
+		Exceptions:  make([]Exception, 0),
+	}
+
+	for _, input := range inputs {
+		image := ReportImage{
+			ImageName:  input.ImageName,
+			ImageID:    input.ImageID,
+			Hosts:      input.Hosts,
+			Containers: input.Containers,
+		}
+		report.Summary.Images++
+
+		if input.Scan == nil || !input.Scan.Success {
+			if input.Scan != nil {
+				image.ScanError = input.Scan.Error
+			}
+			report.Summary.UnscannedImages++
+			report.Images = append(report.Images, image)
+			continue
+		}
+
+		scannedAt := input.Scan.ScannedAt
+		image.Scanned = true
+		image.ScannedAt = &scannedAt
+		image.TrivyVersion = input.Scan.TrivyDBVersion
+		report.Summary.ScannedImages++
+		if report.Summary.OldestScan == nil || scannedAt.Before(*report.Summary.OldestScan) {
+			report.Summary.OldestScan = &scannedAt
+		}
+
+		hosts := strings.Join(input.Hosts, ", ")
+		for _, v := range input.Vulnerabilities {
+			finding := ReportFinding{
+				ImageName:        input.ImageName,
+				ImageID:          input.ImageID,
+				Hosts:            hosts,
+				VulnerabilityID:  v.VulnerabilityID,
+				Severity:         v.Severity,
+				PkgName:          v.PkgName,
+				InstalledVersion: v.InstalledVersion,
+				FixedVersion:     v.FixedVersion,
+				FixAvailable:     v.FixedVersion != "",
+				Title:            v.Title,
+				PrimaryURL:       v.PrimaryURL,
+			}
+
+			for _, e := range exceptions {
+				if e.Matches(v.VulnerabilityID, input.ImageName, now) {
+					finding.Excepted = true
+					finding.ExceptionReason = e.Reason
+					break
+				}
+			}
+
+			if finding.Excepted {
+				image.Excepted++
+				report.Summary.ExceptedCount++
+			} else {
+				addSeverity(&image.Counts, v.Severity)
+				addSeverity(&report.Summary.Counts, v.Severity)
+				report.Summary.OpenFindings++
+				if finding.FixAvailable {
+					image.Fixable++
+					addSeverity(&report.Summary.FixableCounts, v.Severity)
+				}
+			}
+
+			report.Findings = append(report.Findings, finding)
+		}
+
+		image.Compliant = image.Counts.Critical == 0 && image.Counts.High == 0
+		if image.Compliant {
+			report.Summary.CompliantImages++
+		}
+		report.Images = append(report.Images, image)
+	}
+
+	// Worst images first, then by name
+	sort.SliceStable(report.Images, func(i, j int) bool {
+		a, b := report.Images[i], report.Images[j]
+		if a.Counts.Critical != b.Counts.Critical {
+			return a.Counts.Critical > b.Counts.Critical
+		}
+		if a.Counts.High != b.Counts.High {
+			return a.Counts.High > b.Counts.High
+		}
+		return a.ImageName < b.ImageName
+	})
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		a, b := report.Findings[i], report.Findings[j]
+		if a.Excepted != b.Excepted {
+			return !a.Excepted
+		}
+		if severityRank(a.Severity) != severityRank(b.Severity) {
+			return severityRank(a.Severity) < severityRank(b.Severity)
+		}
+		if a.ImageName != b.ImageName {
+			return a.ImageName < b.ImageName
+		}
+		return a.VulnerabilityID < b.VulnerabilityID
+	})
+
+	// Only list exceptions that are still active
+	for _, e := range exceptions {
+		if e.ExpiresAt == nil || now.Before(*e.ExpiresAt) {
+			report.Exceptions = append(report.Exceptions, e)
+		}
+	}
+
+	return report
+}
+
+// addSeverity increments the count for a severity
+func addSeverity(counts *SeverityCounts, severity string) {
+	switch severity {
+	case "CRITICAL":
+		counts.Critical++
+	case "HIGH":
+		counts.High++
+	case "MEDIUM":
+		counts.Medium++
+	case "LOW":
+		counts.Low++
+	default:
+		counts.Unknown++
+	}
+}
+
+// severityRank orders severities from most to least severe
+func severityRank(severity string) int {
+	switch severity {
+	case "CRITICAL":
+		return 0
+	case "HIGH":
+		return 1
+	case "MEDIUM":
+		return 2
+	case "LOW":
+		return 3
+	default:
+		return 4
+	}
+}
@@ -0,0 +1,101 @@
+package vulnerability
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExceptionMatches(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	expired := now.Add(-time.Hour)
+
+	tests := []struct {
+		name      string
+		exception Exception
+		image     string
+		want      bool
+	}{
+		{"all images", Exception{VulnerabilityID: "CVE-1"}, "nginx:latest", true},
+		{"case insensitive id", Exception{VulnerabilityID: "cve-1"}, "nginx:latest", true},
+		{"other vulnerability", Exception{VulnerabilityID: "CVE-2"}, "nginx:latest", false},
+		{"matching pattern", Exception{VulnerabilityID: "CVE-1", ImagePattern: "nginx:*"}, "nginx:1.27", true},
+		{"other image", Exception{VulnerabilityID: "CVE-1", ImagePattern: "nginx:*"}, "redis:7", false},
+		{"expired", Exception{VulnerabilityID: "CVE-1", ExpiresAt: &expired}, "nginx:latest", false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.exception.Matches("CVE-1", tt.image, now); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	scannedAt := now.Add(-48 * time.Hour)
+	expired := now.Add(-time.Hour)
+
+	inputs := []ReportImageInput{
+		{
+			ImageID: "sha256:clean", ImageName: "alpine:3", Hosts: []string{"host-a"}, Containers: 1,
+			Scan: &VulnerabilityScan{Success: true, ScannedAt: now},
+		},
+		{
+			ImageID: "sha256:nginx", ImageName: "nginx:1.27", Hosts: []string{"host-a", "host-b"}, Containers: 2,
+			Scan: &VulnerabilityScan{Success: true, ScannedAt: scannedAt},
+			Vulnerabilities: []Vulnerability{
+				{VulnerabilityID: "CVE-LOW", Severity: "LOW"},
+				{VulnerabilityID: "CVE-CRIT", Severity: "CRITICAL", FixedVersion: "1.2"},
+				{VulnerabilityID: "CVE-ACCEPTED", Severity: "HIGH"},
+				{VulnerabilityID: "CVE-EXPIRED", Severity: "HIGH", FixedVersion: "2.0"},
+			},
+		},
+		{ImageID: "sha256:failed", ImageName: "private:1", Scan: &VulnerabilityScan{Success: false, Error: "image not available for scanning"}},
+		{ImageID: "sha256:new", ImageName: "new:1"},
+	}
+	exceptions := []Exception{
+		{VulnerabilityID: "CVE-ACCEPTED", ImagePattern: "nginx:*", Reason: "not reachable"},
+		{VulnerabilityID: "CVE-EXPIRED", Reason: "old", ExpiresAt: &expired},
+	}
+
+	report := BuildReport("All hosts", inputs, exceptions, now)
+	summary := report.Summary
+
+	if summary.Images != 4 || summary.ScannedImages != 2 || summary.UnscannedImages != 2 {
+		t.Errorf("Unexpected image counts: %+v", summary)
+	}
+	if summary.CompliantImages != 1 {
+		t.Errorf("Expected only the clean image to be compliant, got %d", summary.CompliantImages)
+	}
+	if summary.Counts.Critical != 1 || summary.Counts.High != 1 || summary.Counts.Low != 1 || summary.OpenFindings != 3 {
+		t.Errorf("Expected excepted finding to be left out of open counts: %+v", summary.Counts)
+	}
+	if summary.FixableCounts.Critical != 1 || summary.FixableCounts.High != 1 || summary.FixableCounts.Low != 0 {
+		t.Errorf("Unexpected fixable counts: %+v", summary.FixableCounts)
+	}
+	if summary.ExceptedCount != 1 {
+		t.Errorf("Expected 1 excepted finding, got %d", summary.ExceptedCount)
+	}
+	if summary.OldestScan == nil || !summary.OldestScan.Equal(scannedAt) {
+		t.Errorf("Expected oldest scan %v, got %v", scannedAt, summary.OldestScan)
+	}
+
+	if report.Images[0].ImageName != "nginx:1.27" || report.Images[0].Compliant {
+		t.Errorf("Expected the failing image first, got %+v", report.Images[0])
+	}
+	if report.Images[0].Fixable != 2 || report.Images[0].Excepted != 1 {
+		t.Errorf("Unexpected image rollup: %+v", report.Images[0])
+	}
+
+	first, last := report.Findings[0], report.Findings[len(report.Findings)-1]
+	if first.VulnerabilityID != "CVE-CRIT" || !first.FixAvailable || first.Hosts != "host-a, host-b" {
+		t.Errorf("Expected critical finding first, got %+v", first)
+	}
+	if !last.Excepted || last.ExceptionReason != "not reachable" {
+		t.Errorf("Expected excepted finding last, got %+v", last)
+	}
+
+	if len(report.Exceptions) != 1 || report.Exceptions[0].VulnerabilityID != "CVE-ACCEPTED" {
+		t.Errorf("Expected only active exceptions to be listed, got %+v", report.Exceptions)
+	}
+}
@@ -3,9 +3,9 @@ package vulnerability
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -20,15 +20,25 @@ type Scanner struct {
 	cache     *Cache
 	storage   VulnerabilityStorage
 	trivyLock sync.Mutex // Serialize Trivy DB access to prevent locks
+
+	healthMu     sync.Mutex
+	serverHealth ServerHealth
+	httpClient   *http.Client
+
+	remote RemoteScanner // runs scans on census-agents when agent scanning is enabled
 }
 
+// serverHealthTTL is how long a Trivy server health check result is reused between scans
+const serverHealthTTL = time.Minute
+
 // NewScanner creates a new vulnerability scanner
 func NewScanner(config *Config, storage VulnerabilityStorage) *Scanner {
 	cache := NewCache(storage, config.GetCacheTTL())
 	return &Scanner{
-		config:  config,
-		cache:   cache,
-		storage: storage,
+		config:     config,
+		cache:      cache,
+		storage:    storage,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
 	}
 }
 
@@ -46,8 +56,11 @@ func (s *Scanner) ScanImage(ctx context.Context, imageID string, imageName strin
 	scanCtx, cancel := context.WithTimeout(ctx, s.config.GetScanTimeout())
 	defer cancel()
 
-	// Run Trivy scan using the image name
-	trivyResult, err := s.runTrivy(scanCtx, imageName)
+	// Run Trivy scan using the image name, on the image's agent when possible
+	trivyResult, dbVersion, err := s.scanOnAgent(scanCtx, imageID, imageName)
+	if trivyResult == nil && err == nil {
+		trivyResult, dbVersion, err = s.scan(scanCtx, imageName)
+	}
 	if err != nil {
 		scanDuration := time.Since(startTime).Milliseconds()
 		// Save failed scan with the actual image ID
@@ -63,45 +76,72 @@ func (s *Scanner) ScanImage(ctx context.Context, imageID string, imageName strin
 		return nil, fmt.Errorf("trivy scan failed: %w", err)
 	}
 
-	// Parse results
-	vulnerabilities := s.parseTrivyResult(trivyResult, imageID)
-	severityCounts := CalculateSeverityCounts(vulnerabilities)
-	scanDuration := time.Since(startTime).Milliseconds()
-
-	// Create scan record with the actual image ID
-	scan := &VulnerabilityScan{
-		ImageID:              imageID,
-		ImageName:            imageName,
-		ScannedAt:            time.Now(),
-		ScanDurationMs:       scanDuration,
-		Success:              true,
-		TrivyDBVersion:       getTrivyDBVersion(),
-		TotalVulnerabilities: severityCounts.GetTotal(),
-		SeverityCounts:       severityCounts,
-	}
+	result := s.newScanResult(imageID, imageName, trivyResult, dbVersion, time.Since(startTime))
 
 	// Save to cache and database
-	err = s.cache.Set(scan, vulnerabilities)
+	err = s.cache.Set(&result.Scan, result.Vulnerabilities)
 	if err != nil {
 		log.Printf("Warning: Failed to cache scan results: %v", err)
 	}
 
 	log.Printf("Vulnerability scan completed for %s: %d vulnerabilities found (%d critical, %d high) in %dms",
-		imageName, severityCounts.GetTotal(), severityCounts.Critical, severityCounts.High, scanDuration)
+		imageName, result.Scan.TotalVulnerabilities, result.Scan.SeverityCounts.Critical, result.Scan.SeverityCounts.High, result.Scan.ScanDurationMs)
+
+	return result, nil
+}
+
+// newScanResult builds a successful scan record from Trivy output
+func (s *Scanner) newScanResult(imageID, imageName string, trivyResult *TrivyResult, dbVersion string, duration time.Duration) *VulnerabilityScanResult {
+	vulnerabilities := s.parseTrivyResult(trivyResult, imageID)
+	severityCounts := CalculateSeverityCounts(vulnerabilities)
 
 	return &VulnerabilityScanResult{
-		Scan:            *scan,
+		Scan: VulnerabilityScan{
+			ImageID:              imageID,
+			ImageName:            imageName,
+			ScannedAt:            time.Now(),
+			ScanDurationMs:       duration.Milliseconds(),
+			Success:              true,
+			TrivyDBVersion:       dbVersion,
+			TotalVulnerabilities: severityCounts.GetTotal(),
+			SeverityCounts:       severityCounts,
+		},
 		Vulnerabilities: vulnerabilities,
-	}, nil
+	}
 }
 
-// runTrivy executes the Trivy CLI and returns the results
-func (s *Scanner) runTrivy(ctx context.Context, imageRef string) (*TrivyResult, error) {
-	// Serialize Trivy DB access to prevent "database in use" errors
-	// Multiple workers can't access Trivy's vulnerability DB simultaneously
-	s.trivyLock.Lock()
-	defer s.trivyLock.Unlock()
+// scan runs Trivy against the configured server, falling back to a local scan when the server
+// is unhealthy and fallback is enabled. It returns the result and the DB version it used.
+func (s *Scanner) scan(ctx context.Context, imageRef string) (*TrivyResult, string, error) {
+	serverURL := s.config.GetServerURL()
+	if serverURL == "" {
+		result, err := s.runTrivy(ctx, imageRef, "")
+		return result, getTrivyDBVersion(), err
+	}
 
+	health := s.CheckServerHealth(ctx, false)
+	if health.Healthy {
+		result, err := s.runTrivy(ctx, imageRef, serverURL)
+		if err == nil {
+			return result, "server", nil
+		}
+		if !s.config.GetFallbackToLocal() || !isServerConnectionError(err) {
+			return nil, "", err
+		}
+		// The server went away mid-scan; remember that until the next health check
+		s.markServerUnhealthy(serverURL, err)
+		log.Printf("Trivy server %s failed, falling back to local scan: %v", serverURL, err)
+	} else if !s.config.GetFallbackToLocal() {
+		return nil, "", fmt.Errorf("trivy server %s is unavailable: %s", serverURL, health.Error)
+	}
+
+	result, err := s.runTrivy(ctx, imageRef, "")
+	return result, getTrivyDBVersion(), err
+}
+
+// runTrivy executes the Trivy CLI and returns the results. With a server URL, Trivy runs in
+// client mode and the remote server holds the vulnerability database.
+func (s *Scanner) runTrivy(ctx context.Context, imageRef string, serverURL string) (*TrivyResult, error) {
 	// Build Trivy command
 	args := []string{
 		"image",
@@ -110,12 +150,27 @@ func (s *Scanner) runTrivy(ctx context.Context, imageRef string) (*TrivyResult,
 		"--no-progress",
 	}
 
-	// Only skip DB updates if the database exists
-	// This prevents "cannot specify --skip-db-update on first run" errors
 	cacheDir := s.config.GetCacheDir()
-	dbPath := filepath.Join(cacheDir, "db", "trivy.db")
-	if _, err := os.Stat(dbPath); err == nil {
-		args = append(args, "--skip-db-update", "--skip-java-db-update")
+	if serverURL != "" {
+		args = append(args, "--server", serverURL)
+		if token, header := s.config.GetServerToken(); token != "" {
+			args = append(args, "--token", token)
+			if header != "" {
+				args = append(args, "--token-header", header)
+			}
+		}
+	} else {
+		// Serialize Trivy DB access to prevent "database in use" errors
+		// Multiple workers can't access Trivy's vulnerability DB simultaneously
+		s.trivyLock.Lock()
+		defer s.trivyLock.Unlock()
+
+		// Only skip DB updates if the database exists
+		// This prevents "cannot specify --skip-db-update on first run" errors
+		dbPath := filepath.Join(cacheDir, "db", "trivy.db")
+		if _, err := os.Stat(dbPath); err == nil {
+			args = append(args, "--skip-db-update", "--skip-java-db-update")
+		}
 	}
 
 	args = append(args,
@@ -149,16 +204,7 @@ func (s *Scanner) runTrivy(ctx context.Context, imageRef string) (*TrivyResult,
 		return nil, fmt.Errorf("trivy command failed: %w (stderr: %s)", err, stderrStr)
 	}
 
-	output := stdout.Bytes()
-
-	// Parse JSON output
-	var result TrivyResult
-	err = json.Unmarshal(output, &result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse trivy output: %w", err)
-	}
-
-	return &result, nil
+	return ParseTrivyOutput(stdout.Bytes())
 }
 
 // parseTrivyResult converts Trivy output to our vulnerability format
@@ -206,8 +252,14 @@ func (s *Scanner) InvalidateCache(imageID string) {
 	s.cache.Invalidate(imageID)
 }
 
-// UpdateTrivyDB updates the Trivy vulnerability database
+// UpdateTrivyDB updates the Trivy vulnerability database. In server mode the server keeps its
+// own database, so the local copy is only maintained when it is needed for fallback scans.
 func (s *Scanner) UpdateTrivyDB(ctx context.Context) error {
+	if s.config.UsesServer() && !s.config.GetFallbackToLocal() {
+		log.Println("Skipping local Trivy database update: scanning via Trivy server")
+		return nil
+	}
+
 	log.Println("Updating Trivy vulnerability database...")
 
 	cmd := exec.CommandContext(ctx, "trivy", "image", "--download-db-only", "--cache-dir", s.config.GetCacheDir())
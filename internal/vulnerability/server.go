@@ -0,0 +1,100 @@
+package vulnerability
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ServerHealth is the result of the last Trivy server health check
+type ServerHealth struct {
+	Configured bool      `json:"configured"`
+	ServerURL  string    `json:"server_url,omitempty"`
+	Healthy    bool      `json:"healthy"`
+	Error      string    `json:"error,omitempty"`
+	CheckedAt  time.Time `json:"checked_at,omitempty"`
+	Fallback   bool      `json:"fallback_to_local"`
+}
+
+// CheckServerHealth probes the Trivy server's /healthz endpoint. Results are reused for
+// serverHealthTTL unless force is set, so scans don't probe the server every time.
+func (s *Scanner) CheckServerHealth(ctx context.Context, force bool) ServerHealth {
+	serverURL := s.config.GetServerURL()
+	if serverURL == "" {
+		return ServerHealth{Configured: false}
+	}
+
+	s.healthMu.Lock()
+	cached := s.serverHealth
+	s.healthMu.Unlock()
+	if !force && cached.ServerURL == serverURL && time.Since(cached.CheckedAt) < serverHealthTTL {
+		cached.Fallback = s.config.GetFallbackToLocal()
+		return cached
+	}
+
+	health := ServerHealth{
+		Configured: true,
+		ServerURL:  serverURL,
+		CheckedAt:  time.Now(),
+		Fallback:   s.config.GetFallbackToLocal(),
+	}
+	if err := s.probeServer(ctx, serverURL); err != nil {
+		health.Error = err.Error()
+	} else {
+		health.Healthy = true
+	}
+
+	s.healthMu.Lock()
+	s.serverHealth = health
+	s.healthMu.Unlock()
+
+	return health
+}
+
+func (s *Scanner) probeServer(ctx context.Context, serverURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, serverURL+"/healthz", nil)
+	if err != nil {
+		return fmt.Errorf("invalid server URL: %w", err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach trivy server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("trivy server returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+// markServerUnhealthy records a failed scan against the server so later scans fall back
+// without waiting for the next health check
+func (s *Scanner) markServerUnhealthy(serverURL string, cause error) {
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+	s.serverHealth = ServerHealth{
+		Configured: true,
+		ServerURL:  serverURL,
+		Error:      cause.Error(),
+		CheckedAt:  time.Now(),
+	}
+}
+
+// isServerConnectionError reports whether a Trivy client-mode failure was caused by the
+// server being unreachable rather than by the image itself
+func isServerConnectionError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, marker := range []string{"connection refused", "no such host", "i/o timeout", "connect: ", "twirp error unavailable", "status code 5"} {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package vulnerability
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCheckServerHealth(t *testing.T) {
+	var probes int32
+	healthy := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&probes, 1)
+		if r.URL.Path != "/healthz" {
+			t.Errorf("Unexpected health check path %s", r.URL.Path)
+		}
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	scanner := NewScanner(config, newMockStorage())
+	ctx := context.Background()
+
+	if health := scanner.CheckServerHealth(ctx, false); health.Configured {
+		t.Error("Expected no server to be configured by default")
+	}
+
+	config.ServerURL = server.URL
+	if health := scanner.CheckServerHealth(ctx, false); !health.Healthy || health.Error != "" {
+		t.Errorf("Expected healthy server, got %+v", health)
+	}
+
+	// A recent result is reused instead of probing again
+	healthy = false
+	if health := scanner.CheckServerHealth(ctx, false); !health.Healthy {
+		t.Error("Expected cached healthy result")
+	}
+	if probes != 1 {
+		t.Errorf("Expected 1 probe, got %d", probes)
+	}
+
+	if health := scanner.CheckServerHealth(ctx, true); health.Healthy || health.Error == "" {
+		t.Errorf("Expected forced check to report the unhealthy server, got %+v", health)
+	}
+}
+
+func TestScanWithoutFallbackFailsWhenServerDown(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	config := DefaultConfig()
+	config.ServerURL = server.URL
+	scanner := NewScanner(config, newMockStorage())
+
+	_, _, err := scanner.scan(context.Background(), "nginx:latest")
+	if err == nil {
+		t.Fatal("Expected scan to fail when the server is down and fallback is disabled")
+	}
+}
+
+func TestConfigServerSettings(t *testing.T) {
+	config := DefaultConfig()
+	update := config.Clone()
+	update.ServerURL = "ftp://trivy:4954"
+	if err := config.Update(update); err == nil {
+		t.Error("Expected non-http server URL to be rejected")
+	}
+
+	update.ServerURL = "http://trivy:4954/ "
+	update.ServerToken = "secret"
+	if err := config.Update(update); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if config.GetServerURL() != "http://trivy:4954" {
+		t.Errorf("Expected trailing slash to be trimmed, got %q", config.GetServerURL())
+	}
+
+	redacted := config.Redacted()
+	if redacted.ServerToken != MaskedServerToken {
+		t.Errorf("Expected token to be masked, got %q", redacted.ServerToken)
+	}
+
+	// Saving the redacted config back keeps the real token
+	if err := config.Update(redacted); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if token, _ := config.GetServerToken(); token != "secret" {
+		t.Errorf("Expected saved token to be kept, got %q", token)
+	}
+}
+
+func TestIsServerConnectionError(t *testing.T) {
+	if !isServerConnectionError(errors.New("trivy command failed: dial tcp 10.0.0.5:4954: connect: connection refused")) {
+		t.Error("Expected connection refused to be a server error")
+	}
+	if isServerConnectionError(errors.New("image not available for scanning")) {
+		t.Error("Expected missing image not to be a server error")
+	}
+}
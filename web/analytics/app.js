@@ -30,6 +30,7 @@ const gradientColors = {
 document.addEventListener('DOMContentLoaded', () => {
     initCharts();
     loadVersion();
+    loadViewer();
     loadData();
 
     // Set up time range change handler
@@ -717,6 +718,26 @@ async function loadVersion() {
     }
 }
 
+// Show who is signed in, and the API Keys tab to admins
+async function loadViewer() {
+    try {
+        const response = await fetch('/auth/me');
+        if (!response.ok) throw new Error('Failed to fetch viewer');
+
+        const data = await response.json();
+        const info = document.getElementById('viewerInfo');
+        if (data.authenticated) {
+            info.innerHTML = escapeHtml(data.name) + (data.oidc ? ' · <a href="/auth/logout">Sign out</a>' : '');
+            info.style.display = '';
+        }
+        if (data.authenticated && data.scopes.includes('admin')) {
+            document.getElementById('apiKeysTabButton').style.display = '';
+        }
+    } catch (error) {
+        console.error('Error loading viewer:', error);
+    }
+}
+
 async function loadData() {
     const days = document.getElementById('timeRange').value;
 
@@ -1125,6 +1146,10 @@ function showTab(tabName, clickedButton) {
             document.querySelector('.tab-button[onclick*="charts"]') :
             tabName === 'images' ?
             document.querySelector('.tab-button[onclick*="images"]') :
+            tabName === 'releases' ?
+            document.querySelector('.tab-button[onclick*="releases"]') :
+            tabName === 'apikeys' ?
+            document.querySelector('.tab-button[onclick*="apikeys"]') :
             document.querySelector('.tab-button[onclick*="database"]');
         if (targetButton) {
             targetButton.classList.add('active');
@@ -1144,6 +1169,12 @@ function showTab(tabName, clickedButton) {
         if (imageDetailsData.length === 0) {
             loadImageDetails();
         }
+    } else if (tabName === 'apikeys') {
+        document.getElementById('apikeysTab').classList.add('active');
+        loadAPIKeys();
+    } else if (tabName === 'releases') {
+        document.getElementById('releasesTab').classList.add('active');
+        loadReleases();
     } else if (tabName === 'database') {
         document.getElementById('databaseTab').classList.add('active');
         // Load database view if not already loaded
@@ -1153,6 +1184,162 @@ function showTab(tabName, clickedButton) {
     }
 }
 
+// Load the named API keys with their usage
+let apiKeysData = [];
+
+async function loadAPIKeys() {
+    const tbody = document.getElementById('apiKeysBody');
+    try {
+        const response = await fetch('/api/keys');
+        if (!response.ok) throw new Error('Failed to fetch API keys');
+
+        const keys = await response.json();
+        apiKeysData = keys;
+        if (keys.length === 0) {
+            tbody.innerHTML = '<tr><td colspan="7" class="empty-cell">No API keys yet</td></tr>';
+            return;
+        }
+        tbody.innerHTML = keys.map(k => `
+            <tr class="${k.revoked_at ? 'revoked-key' : ''}">
+                <td>${escapeHtml(k.name)}<br><span class="muted">by ${escapeHtml(k.created_by || '-')}</span></td>
+                <td><code>${escapeHtml(k.prefix)}…</code></td>
+                <td>${k.scopes.map(escapeHtml).join(', ')}</td>
+                <td>${formatTimestamp(k.created_at)}</td>
+                <td>${k.last_used_at ? formatTimestamp(k.last_used_at) : 'Never'}</td>
+                <td class="number">${k.use_count.toLocaleString()}</td>
+                <td>${k.revoked_at ? 'Revoked' : `<button onclick="revokeAPIKey(${k.id})">Revoke</button>`}</td>
+            </tr>
+        `).join('');
+    } catch (error) {
+        console.error('Failed to load API keys:', error);
+        tbody.innerHTML = '<tr><td colspan="7" class="error-cell">Failed to load data</td></tr>';
+    }
+}
+
+async function createAPIKey() {
+    const name = document.getElementById('apiKeyName').value.trim();
+    const scopes = Array.from(document.querySelectorAll('.api-key-scope:checked')).map(cb => cb.value);
+    const result = document.getElementById('newAPIKey');
+
+    try {
+        const response = await fetch('/api/keys', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ name, scopes })
+        });
+        const data = await response.json();
+        if (!response.ok) throw new Error(data.error || 'Failed to create key');
+
+        result.innerHTML = `Key <strong>${escapeHtml(data.name)}</strong> created. Copy it now, it won't be shown again:<br><code>${escapeHtml(data.key)}</code>`;
+        result.style.display = '';
+        document.getElementById('apiKeyName').value = '';
+        loadAPIKeys();
+    } catch (error) {
+        result.textContent = error.message;
+        result.style.display = '';
+    }
+}
+
+async function revokeAPIKey(id) {
+    const key = apiKeysData.find(k => k.id === id);
+    if (!confirm(`Revoke the API key "${key ? key.name : id}"? Requests using it will be refused.`)) return;
+
+    try {
+        const response = await fetch(`/api/keys/${id}`, { method: 'DELETE' });
+        if (!response.ok) throw new Error((await response.json()).error || 'Failed to revoke key');
+        loadAPIKeys();
+    } catch (error) {
+        alert(error.message);
+    }
+}
+
+// Load the version adoption funnel and the retention cohorts for the selected time range
+async function loadReleases() {
+    const days = document.getElementById('timeRange').value;
+    const weeks = Math.max(1, Math.ceil(days / 7));
+
+    try {
+        const response = await fetch(`/api/stats/version-funnel?days=${days}`);
+        if (!response.ok) throw new Error('Failed to fetch version funnel');
+        renderVersionFunnel(await response.json());
+    } catch (error) {
+        console.error('Failed to load version funnel:', error);
+        document.getElementById('versionFunnelBody').innerHTML =
+            '<tr><td colspan="9" class="error-cell">Failed to load data</td></tr>';
+        document.getElementById('versionTransitionsBody').innerHTML =
+            '<tr><td colspan="3" class="error-cell">Failed to load data</td></tr>';
+    }
+
+    try {
+        const response = await fetch(`/api/stats/retention?weeks=${Math.min(weeks, 104)}`);
+        if (!response.ok) throw new Error('Failed to fetch retention');
+        renderRetention(await response.json());
+    } catch (error) {
+        console.error('Failed to load retention:', error);
+        document.getElementById('retentionBody').innerHTML =
+            '<tr><td class="error-cell">Failed to load data</td></tr>';
+    }
+}
+
+function renderVersionFunnel(data) {
+    const tbody = document.getElementById('versionFunnelBody');
+    if (data.versions.length === 0) {
+        tbody.innerHTML = '<tr><td colspan="9" class="empty-cell">No releases first seen in this period</td></tr>';
+    } else {
+        const pct = (n, total) => total > 0 ? ` <span class="muted">(${Math.round(n / total * 100)}%)</span>` : '';
+        tbody.innerHTML = data.versions.map(v => `
+            <tr>
+                <td>v${escapeHtml(v.version)}</td>
+                <td>${formatDate(v.first_seen)}</td>
+                <td class="number">${v.installations}</td>
+                <td class="number">${v.new_installs} / ${v.upgrades}</td>
+                <td class="number">${v.adopted_7d}${pct(v.adopted_7d, v.installations)}</td>
+                <td class="number">${v.adopted_30d}${pct(v.adopted_30d, v.installations)}</td>
+                <td class="number">${v.current}</td>
+                <td class="number">${v.moved_on}</td>
+                <td class="number">${v.churned}${pct(v.churned, v.installations)}</td>
+            </tr>
+        `).join('');
+    }
+
+    const transitions = document.getElementById('versionTransitionsBody');
+    if (data.transitions.length === 0) {
+        transitions.innerHTML = '<tr><td colspan="3" class="empty-cell">No version changes in this period</td></tr>';
+    } else {
+        transitions.innerHTML = data.transitions.map(t => `
+            <tr>
+                <td>v${escapeHtml(t.from)}</td>
+                <td>v${escapeHtml(t.to)}</td>
+                <td class="number">${t.installations}</td>
+            </tr>
+        `).join('');
+    }
+}
+
+function renderRetention(cohorts) {
+    const head = document.getElementById('retentionHead');
+    const tbody = document.getElementById('retentionBody');
+    if (cohorts.length === 0) {
+        head.innerHTML = '';
+        tbody.innerHTML = '<tr><td class="empty-cell">No installations first seen in this period</td></tr>';
+        return;
+    }
+
+    const maxWeeks = Math.max(...cohorts.map(c => c.retained.length));
+    const weekHeaders = Array.from({ length: maxWeeks - 1 }, (_, i) => `<th class="text-right">W${i + 1}</th>`).join('');
+    head.innerHTML = `<tr><th>Cohort</th><th class="text-right">Installations</th>${weekHeaders}</tr>`;
+
+    tbody.innerHTML = cohorts.map(c => {
+        const cells = Array.from({ length: maxWeeks - 1 }, (_, i) => {
+            const week = i + 1;
+            if (week >= c.retained.length) return '<td></td>';
+            const pct = c.retained_pct[week];
+            return `<td class="number retention-cell" style="background: rgba(102, 126, 234, ${(pct / 100).toFixed(2)})" title="${c.retained[week]} installations">${pct}%</td>`;
+        }).join('');
+        return `<tr><td>${formatDate(c.cohort + 'T00:00:00')}</td><td class="number">${c.installations}</td>${cells}</tr>`;
+    }).join('');
+}
+
 // Load image details from API
 async function loadImageDetails() {
     const days = document.getElementById('timeRange').value;
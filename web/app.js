@@ -3,10 +3,15 @@ let containers = [];
 let hosts = [];
 let activities = [];
 let images = {};
+let imageUsage = {}; // `${hostId}:${imageId}` -> usage from /api/images/host/{id}/usage
 let graphData = null;
 let cy = null; // Cytoscape instance
 let autoRefreshInterval = null;
 let currentTab = 'dashboard';
+let currentUser = null; // {username, admin, read_only, tenant_id} from /api/me
+let scanDiagnostics = {}; // host ID -> latest scan result from /api/scan/diagnostics
+let userTimezone = ''; // IANA timezone preference; empty uses the browser's
+let userLocale = ''; // BCP 47 locale preference; empty uses the browser's
 let lifecycles = [];
 let lastRefreshTime = null;
 let lastRefreshInterval = null;
@@ -15,6 +20,7 @@ let isSidebarOpen = false;
 let vulnerabilityCache = {}; // Cache vulnerability data by imageID
 let vulnerabilityScansMap = {}; // Pre-loaded map of all scans to avoid 404s
 let vulnerabilitySummary = null; // Cache overall summary
+let statsOverview = null; // Fleet totals and top consumers from /api/stats/overview
 let cardDesignTheme = 'material'; // Default card design theme (compact, material, dashboard)
 
 // Session-based authentication (cookies handle auth automatically)
@@ -24,7 +30,7 @@ async function fetchWithAuth(url, options = {}) {
 
     // Redirect to login if unauthorized
     if (response.status === 401) {
-        window.location.href = '/login.html';
+        window.location.href = appUrl('/login.html');
         throw new Error('Unauthorized - redirecting to login');
     }
 
@@ -38,12 +44,13 @@ async function logout() {
     } catch (error) {
         console.error('Logout error:', error);
     } finally {
-        window.location.href = '/login.html';
+        window.location.href = appUrl('/login.html');
     }
 }
 
 // Initialize
 document.addEventListener('DOMContentLoaded', () => {
+    loadCurrentUser();
     setupEventListeners();
     initializeRouting();
     loadVersion();
@@ -194,7 +201,7 @@ function updateNavigationBadges() {
     // Images badge
     const imagesBadge = document.getElementById('imagesBadge');
     if (imagesBadge && images) {
-        const totalImages = Object.values(images).reduce((sum, imgs) => sum + imgs.length, 0);
+        const totalImages = Object.values(images).reduce((sum, hostData) => sum + (hostData.images || []).length, 0);
         if (totalImages > 0) {
             imagesBadge.textContent = totalImages;
         }
@@ -232,6 +239,7 @@ function toggleSidebar() {
 function saveFilterState() {
     const state = {
         search: document.getElementById('searchInput')?.value || '',
+        siteFilter: document.getElementById('siteFilter')?.value || '',
         hostFilter: document.getElementById('hostFilter')?.value || '',
         stateFilter: document.getElementById('stateFilter')?.value || ''
     };
@@ -241,12 +249,14 @@ function saveFilterState() {
 function restoreFilterState() {
     const stateStr = sessionStorage.getItem(`filters_${currentTab}`);
 
+    const siteFilter = document.getElementById('siteFilter');
     const hostFilter = document.getElementById('hostFilter');
     const stateFilter = document.getElementById('stateFilter');
 
     if (!stateStr) {
         // Clear filters when switching tabs if no saved state
         // Note: searchInput is already cleared in switchTab()
+        if (siteFilter) siteFilter.value = '';
         if (hostFilter) hostFilter.value = '';
         if (stateFilter) stateFilter.value = '';
         return;
@@ -256,6 +266,8 @@ function restoreFilterState() {
         const state = JSON.parse(stateStr);
 
         // Note: searchInput is always cleared in switchTab(), so we don't restore it
+        if (siteFilter) siteFilter.value = state.siteFilter || '';
+        updateHostFilter();
         if (hostFilter) hostFilter.value = state.hostFilter || '';
         if (stateFilter) stateFilter.value = state.stateFilter || '';
 
@@ -348,6 +360,16 @@ function setupEventListeners() {
         });
     }
 
+    const siteFilter = document.getElementById('siteFilter');
+    if (siteFilter) {
+        siteFilter.addEventListener('change', () => {
+            // Only offer the hosts of the selected site
+            updateHostFilter();
+            applyCurrentFilters();
+            saveFilterState();
+        });
+    }
+
     if (hostFilter) {
         hostFilter.addEventListener('change', () => {
             applyCurrentFilters();
@@ -394,6 +416,21 @@ function setupEventListeners() {
     document.getElementById('logModal').addEventListener('click', (e) => {
         if (e.target.classList.contains('modal')) closeLogModal();
     });
+    document.getElementById('imageLayersModal').addEventListener('click', (e) => {
+        if (e.target.classList.contains('modal')) closeImageLayersModal();
+    });
+    document.getElementById('vulnReportModal').addEventListener('click', (e) => {
+        if (e.target.classList.contains('modal')) closeVulnReportModal();
+    });
+    document.getElementById('complianceModal').addEventListener('click', (e) => {
+        if (e.target.classList.contains('modal')) closeComplianceModal();
+    });
+    document.getElementById('hostUptimeModal').addEventListener('click', (e) => {
+        if (e.target.classList.contains('modal')) closeHostUptimeModal();
+    });
+    document.getElementById('inspectModal').addEventListener('click', (e) => {
+        if (e.target.classList.contains('modal')) closeInspectModal();
+    });
     document.getElementById('confirmModal').addEventListener('click', (e) => {
         if (e.target.classList.contains('modal')) closeConfirmModal();
     });
@@ -418,16 +455,40 @@ function setupEventListeners() {
         });
     }
 
+    document.getElementById('addLocalBtn')?.addEventListener('click', addLocalHost);
+    document.getElementById('importHostsBtn')?.addEventListener('click', openHostImportModal);
+    document.getElementById('hostTemplatesBtn')?.addEventListener('click', openHostTemplatesModal);
+    document.getElementById('agentStartFrom')?.addEventListener('change', () => applyHostStartFrom('agent'));
+    document.getElementById('incusStartFrom')?.addEventListener('change', () => applyHostStartFrom('incus'));
+    document.getElementById('hostImportFile')?.addEventListener('change', async (e) => {
+        const file = e.target.files[0];
+        if (file) document.getElementById('hostImportText').value = await file.text();
+    });
+
+    // Add Incus modal handlers
+    document.getElementById('addIncusBtn')?.addEventListener('click', openAddIncusModal);
+    document.getElementById('closeAddIncus')?.addEventListener('click', closeAddIncusModal);
+    document.getElementById('cancelIncusBtn')?.addEventListener('click', closeAddIncusModal);
+    document.getElementById('testIncusBtn')?.addEventListener('click', testIncusConnection);
+    document.getElementById('addIncusForm')?.addEventListener('submit', handleAddIncus);
+    document.getElementById('addIncusModal')?.addEventListener('click', (e) => {
+        if (e.target.classList.contains('modal')) closeAddIncusModal();
+    });
+
     // Graph filter handlers
     document.getElementById('showNetworks')?.addEventListener('change', applyGraphFilters);
     document.getElementById('showVolumes')?.addEventListener('change', applyGraphFilters);
     document.getElementById('showDepends')?.addEventListener('change', applyGraphFilters);
     document.getElementById('showLinks')?.addEventListener('change', applyGraphFilters);
+    document.getElementById('showCrossHost')?.addEventListener('change', applyGraphFilters);
 
     // Graph display option handlers
     document.getElementById('colorByProject')?.addEventListener('change', applyGraphFilters);
     document.getElementById('hideEdgeLabels')?.addEventListener('change', toggleEdgeLabels);
 
+    // Fleet-wide image usage search
+    document.getElementById('imageUsageForm')?.addEventListener('submit', searchImageUsage);
+
     // Activity log filter
     document.getElementById('activityTypeFilter')?.addEventListener('change', loadActivityLog);
 
@@ -438,6 +499,7 @@ function setupEventListeners() {
     document.getElementById('composeProjectSelect')?.addEventListener('change', handleComposeProjectChange);
     document.getElementById('networkSelect')?.addEventListener('change', handleNetworkChange);
     document.getElementById('layoutSelect')?.addEventListener('change', handleLayoutChange);
+    document.getElementById('graphViewSelect')?.addEventListener('change', loadGraph);
 
     // Graph search handler
     document.getElementById('graphSearch')?.addEventListener('input', handleGraphSearch);
@@ -455,7 +517,14 @@ function setupEventListeners() {
     document.getElementById('vulnerabilitySettingsBtn')?.addEventListener('click', openVulnerabilitySettingsModal);
     document.getElementById('securitySearchInput')?.addEventListener('input', filterSecurityScans);
     document.getElementById('securitySeverityFilter')?.addEventListener('change', filterSecurityScans);
+    document.getElementById('privilegeHostFilter')?.addEventListener('change', loadContainerPrivileges);
+    document.getElementById('privilegeLevelFilter')?.addEventListener('change', loadContainerPrivileges);
+    document.getElementById('privilegeFindingFilter')?.addEventListener('change', loadContainerPrivileges);
+    document.getElementById('portBindingFilter')?.addEventListener('change', renderPortExposure);
     document.getElementById('securityStatusFilter')?.addEventListener('change', filterSecurityScans);
+    document.getElementById('supplyChainForm')?.addEventListener('submit', searchSupplyChain);
+    document.getElementById('securityScoreScope')?.addEventListener('change', renderSecurityScores);
+    document.getElementById('securityScoreTrendDays')?.addEventListener('change', loadSecurityScores);
 
     // Vulnerability settings modal
     const vulnSettingsForm = document.getElementById('vulnerabilitySettingsForm');
@@ -472,6 +541,12 @@ function setupEventListeners() {
 
 // Tab Management
 function switchTab(tab, updateHistory = true) {
+    // Tenant users can't open administrator-only tabs (e.g. via keyboard shortcuts)
+    const targetNav = document.querySelector(`.nav-item[data-tab="${tab}"]`);
+    if (currentUser && !currentUser.admin && targetNav && targetNav.classList.contains('admin-only')) {
+        tab = 'dashboard';
+    }
+
     currentTab = tab;
 
     // Update URL hash
@@ -512,6 +587,11 @@ function switchTab(tab, updateHistory = true) {
         stopQueueStatusPolling();
     }
 
+    // The live fleet view samples every host while it streams; stop it when leaving
+    if (tab !== 'monitoring') {
+        stopFleetLiveStats();
+    }
+
     // Auto-refresh data when switching to a tab
     if (tab === 'dashboard') {
         loadDashboard();
@@ -524,7 +604,10 @@ function switchTab(tab, updateHistory = true) {
     } else if (tab === 'security') {
         loadSecurityTab();
     } else if (tab === 'hosts') {
-        loadHosts().then(() => renderHosts(hosts));
+        Promise.all([loadHosts(), loadScanDiagnostics()]).then(() => {
+            renderHosts(hosts);
+            loadSites();
+        });
     } else if (tab === 'graph') {
         loadGraph();
     } else if (tab === 'history') {
@@ -537,7 +620,17 @@ function switchTab(tab, updateHistory = true) {
         loadCollectors();
         loadScannerSettings();
         loadTelemetrySettings();
+        loadTelemetryPrivacy();
         loadImageUpdateSettings();
+        loadUptimeKumaSettings();
+        loadProxmoxSettings();
+        loadTraefikSettings();
+        loadEventSubscriptions();
+        if (currentUser && currentUser.admin) {
+            loadTenants();
+            loadAgentTokens();
+            loadReadOnlyMode();
+        }
     }
 
     // Add pulse animation to nav item briefly
@@ -593,12 +686,68 @@ async function loadMonitoringData() {
 
         // Apply filters if any are active (this will call filterMonitoring and render)
         applyCurrentFilters();
+
+        loadTopConsumers();
     } catch (error) {
         console.error('Error loading monitoring data:', error);
         document.getElementById('monitoringGrid').innerHTML = '<div class="error">Failed to load monitoring data</div>';
     }
 }
 
+// Load the top CPU, memory and memory growth consumers over the selected window
+async function loadTopConsumers() {
+    const grid = document.getElementById('topConsumersGrid');
+    if (!grid) return;
+
+    const range = document.getElementById('topConsumersRange')?.value || '24h';
+    const hostFilter = document.getElementById('hostFilter')?.value || '';
+    let url = `/api/stats/top-consumers?range=${range}&limit=5`;
+    if (hostFilter) {
+        url += `&host_id=${hostFilter}`;
+    }
+
+    try {
+        const response = await fetchWithAuth(url);
+        if (!response.ok) {
+            throw new Error(`HTTP ${response.status}`);
+        }
+        const report = await response.json();
+
+        const formatMB = bytes => (bytes / 1024 / 1024).toFixed(0) + ' MB';
+        grid.innerHTML = [
+            renderTopConsumersList('🔥 CPU', report.by_cpu, c => c.avg_cpu_percent.toFixed(1) + '%', c => `peak ${c.max_cpu_percent.toFixed(1)}%`),
+            renderTopConsumersList('💾 Memory', report.by_memory, c => formatMB(c.avg_memory_usage), c => `peak ${formatMB(c.max_memory_usage)}`),
+            renderTopConsumersList('📈 Memory Growth', report.by_memory_growth, c => '+' + formatMB(c.memory_growth_per_hour) + '/h', c => `${c.memory_growth_percent_day.toFixed(1)}%/day`)
+        ].join('');
+    } catch (error) {
+        console.error('Error loading top consumers:', error);
+        grid.innerHTML = '<div class="error">Failed to load top consumers</div>';
+    }
+}
+
+function renderTopConsumersList(title, consumers, valueFn, detailFn) {
+    const rows = (consumers || []).map((c, index) => `
+        <li class="top-consumer-item" onclick="openStatsModal(${c.host_id}, '${escapeHtml(c.container_id)}', '${escapeHtml(c.container_name)}')" title="View Stats & Timeline">
+            <span class="top-consumer-rank">${index + 1}</span>
+            <span class="top-consumer-name">
+                ${escapeHtml(c.container_name)}
+                <small>${escapeHtml(c.host_name)}</small>
+            </span>
+            <span class="top-consumer-value">
+                ${valueFn(c)}
+                <small>${detailFn(c)}</small>
+            </span>
+        </li>
+    `).join('');
+
+    return `
+        <div class="top-consumers-card">
+            <h4>${title}</h4>
+            ${rows ? `<ol class="top-consumers-list">${rows}</ol>` : '<p class="empty-message">No data for this window</p>'}
+        </div>
+    `;
+}
+
 function renderMonitoringGrid(containersToRender) {
     const grid = document.getElementById('monitoringGrid');
 
@@ -671,6 +820,7 @@ function renderMonitoringGrid(containersToRender) {
     }).join('');
 
     // Add event listeners to stats buttons and render mini charts
+    const miniCharts = [];
     containersToRender.forEach((container, index) => {
         const hasStats = container.memory_limit > 0;
         if (hasStats) {
@@ -682,25 +832,43 @@ function renderMonitoringGrid(containersToRender) {
                 });
             }
 
-            // Render mini sparkline chart
-            renderMiniChart(`monitoring-chart-${index}`, container.host_id, container.id);
+            miniCharts.push({ canvasId: `monitoring-chart-${index}`, hostId: container.host_id, containerId: container.id });
         }
     });
+    loadMiniCharts(miniCharts);
 }
 
-// Render mini sparkline chart for monitoring cards
-async function renderMiniChart(canvasId, hostId, containerId) {
+// Fetch the last hour of stats of all monitoring cards in one request and render their sparklines
+async function loadMiniCharts(charts) {
+    if (charts.length === 0) return;
     try {
-        // Fetch last hour of stats for sparkline
-        const url = `/api/containers/${hostId}/${containerId}/stats?range=1h`;
-        console.log(`Fetching stats from: ${url}`);
-        const response = await fetch(url);
+        const response = await fetch('/api/containers/stats/batch', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({
+                containers: charts.map(c => ({ host_id: c.hostId, container_id: c.containerId })),
+                range: '1h',
+                max_points: 20
+            })
+        });
         if (!response.ok) {
-            console.error(`Failed to fetch stats for ${canvasId}: ${response.status} ${response.statusText}`);
+            console.error(`Failed to fetch sparkline stats: ${response.status} ${response.statusText}`);
             return;
         }
 
-        const stats = await response.json();
+        const data = await response.json();
+        charts.forEach(c => {
+            const stats = (data.stats || {})[`${c.hostId}-${c.containerId}`];
+            renderMiniChart(c.canvasId, stats);
+        });
+    } catch (error) {
+        console.error('Error loading sparkline stats:', error);
+    }
+}
+
+// Render mini sparkline chart for monitoring cards
+function renderMiniChart(canvasId, stats) {
+    try {
         console.log(`Stats for ${canvasId}:`, stats ? stats.length : 'null', 'data points');
 
         const canvas = document.getElementById(canvasId);
@@ -732,8 +900,8 @@ async function renderMiniChart(canvasId, hostId, containerId) {
 
         const ctx = canvas.getContext('2d');
 
-        // Take last 20 points for sparkline
-        const recentStats = stats.slice(-20);
+        // The server downsamples the hour to at most 20 points
+        const recentStats = stats;
         const cpuData = recentStats.map(s => s.cpu_percent || 0);
         const memoryData = recentStats.map(s => (s.memory_usage || 0) / 1024 / 1024);
 
@@ -884,6 +1052,9 @@ async function loadVersion() {
                 badge.title = 'Current version';
                 badge.onclick = null;
             }
+            if (data.lite_mode) {
+                badge.innerHTML += ' <span class="badge badge-secondary" title="Low-resource profile: vulnerability scanning off, longer scan interval, shorter stats retention">lite</span>';
+            }
         }
     } catch (error) {
         console.error('Error loading version:', error);
@@ -1003,6 +1174,28 @@ async function loadHosts() {
     }
 }
 
+// Load the latest scan result of each host (with per-container collection warnings) for the hosts table
+async function loadScanDiagnostics() {
+    try {
+        const response = await fetch('/api/scan/diagnostics');
+        if (!response.ok) return;
+        const results = await response.json();
+        scanDiagnostics = {};
+        results.forEach(result => { scanDiagnostics[result.host_id] = result; });
+    } catch (error) {
+        console.error('Error loading scan diagnostics:', error);
+    }
+}
+
+// Badge for collection problems of a host's latest scan, listing them in its tooltip
+function renderScanWarningsBadge(hostId) {
+    const result = scanDiagnostics[hostId];
+    if (!result || !result.warnings || result.warnings.length === 0) return '';
+    const lines = result.warnings.map(w => `${w.container_name} (${w.stage}): ${w.message}`);
+    const title = `Latest scan ${formatDateTime(result.completed_at)}${result.scan_id ? ` (scan ${result.scan_id})` : ''}: values of these containers are missing, not zero\n` + lines.join('\n');
+    return ` <span class="badge badge-warning" title="${escapeHtml(title).replace(/"/g, '&quot;')}">⚠ ${result.warnings.length} collection ${result.warnings.length === 1 ? 'warning' : 'warnings'}</span>`;
+}
+
 async function loadContainers() {
     try {
         const response = await fetch('/api/containers');
@@ -1042,10 +1235,62 @@ async function loadContainers() {
     }
 }
 
+// Fleet-wide image usage search: every container seen with an image, for when a bad image is announced
+async function searchImageUsage(e) {
+    e.preventDefault();
+    const query = document.getElementById('imageUsageQuery').value.trim();
+    const days = document.getElementById('imageUsageDays').value;
+    const results = document.getElementById('imageUsageResults');
+    if (!query) {
+        results.innerHTML = '';
+        return;
+    }
+
+    results.innerHTML = '<div class="loading">Searching...</div>';
+    try {
+        const response = await fetch(`/api/images/usage?image=${encodeURIComponent(query)}&days=${days}`);
+        if (!response.ok) throw new Error(await response.text());
+        const result = await response.json();
+
+        if (result.containers.length === 0) {
+            results.innerHTML = `<p class="empty-state">No container ran an image matching "${escapeHtml(query)}" in this period.</p>`;
+            return;
+        }
+        results.innerHTML = `
+            <p><strong>${result.current}</strong> container${result.current !== 1 ? 's' : ''} still run a matching image
+                (${result.running} running) on ${result.hosts} host${result.hosts !== 1 ? 's' : ''}</p>
+            <table class="vuln-table">
+                <thead>
+                    <tr><th>Container</th><th>Host</th><th>Image</th><th>Versions</th><th>State</th><th>First Seen</th><th>Last Seen</th></tr>
+                </thead>
+                <tbody>
+                    ${result.containers.map(c => `
+                        <tr>
+                            <td>${escapeHtml(c.container_name)}</td>
+                            <td>${escapeHtml(c.host_name)}</td>
+                            <td><code>${escapeHtml(c.image)}</code> <small><code>${shortImageID(c.image_id)}</code></small></td>
+                            <td>${(c.image_tags || []).map(t => `<code>${escapeHtml(t)}</code>`).join(' ') || '-'}</td>
+                            <td>${c.current
+                                ? `<span class="badge ${c.state === 'running' ? 'badge-success' : 'badge-warning'}">${escapeHtml(c.state)}</span>`
+                                : '<span class="badge badge-secondary">No longer runs it</span>'}</td>
+                            <td>${formatDateTime(c.first_seen)}</td>
+                            <td>${formatDateTime(c.last_seen)}</td>
+                        </tr>
+                    `).join('')}
+                </tbody>
+            </table>
+        `;
+    } catch (error) {
+        console.error('Error searching image usage:', error);
+        results.innerHTML = '<p class="error">Failed to search image usage</p>';
+    }
+}
+
 async function loadImages() {
     try {
         const response = await fetch('/api/images');
         images = await response.json() || {};
+        imageUsage = await loadImageUsage(images);
 
         // Apply filters if any are active
         applyCurrentFilters();
@@ -1056,10 +1301,27 @@ async function loadImages() {
         console.error('Error loading images:', error);
         images = {};
         document.getElementById('imagesBody').innerHTML =
-            '<tr><td colspan="7" class="error">Failed to load images</td></tr>';
+            '<tr><td colspan="8" class="error">Failed to load images</td></tr>';
     }
 }
 
+// Fetch when each image last had a running container, for every host in the images list
+async function loadImageUsage(imagesData) {
+    const usage = {};
+    await Promise.all(Object.values(imagesData).filter(hostData => hostData.status !== 'error').map(async (hostData) => {
+        try {
+            const response = await fetch(`/api/images/host/${hostData.host_id}/usage`);
+            if (!response.ok) return;
+            (await response.json() || []).forEach(u => {
+                usage[`${hostData.host_id}:${u.image_id}`] = u;
+            });
+        } catch (error) {
+            console.error('Error loading image usage:', error);
+        }
+    }));
+    return usage;
+}
+
 async function loadActivityLog() {
     try {
         const activityType = document.getElementById('activityTypeFilter')?.value || 'all';
@@ -1347,6 +1609,222 @@ async function refreshLogs() {
     }
 }
 
+// Show the sanitized configuration collected for a container at its last scan
+async function viewInspect(hostId, containerId, displayName) {
+    document.getElementById('inspectContainerName').textContent = displayName || containerId;
+    const content = document.getElementById('inspectContent');
+    content.textContent = 'Loading configuration...';
+    document.getElementById('inspectModal').classList.add('show');
+
+    try {
+        const response = await fetch(`/api/containers/${hostId}/${encodeURIComponent(containerId)}/inspect`);
+
+        if (response.ok) {
+            const data = await response.json();
+            content.innerHTML = renderInspection(data) + await renderRecreateSpec(hostId, containerId) + await renderPluginResults(hostId, displayName);
+        } else {
+            const error = await response.json();
+            content.textContent = `Error: ${error.error}`;
+        }
+    } catch (error) {
+        console.error('Error loading container configuration:', error);
+        content.textContent = 'Failed to load configuration: ' + error.message;
+    }
+}
+
+function renderInspection(data) {
+    const cfg = data.config || {};
+    const joinArgs = (args) => args && args.length ? escapeHtml(args.join(' ')) : '<span class="inspect-empty">-</span>';
+    const row = (label, value) => `<tr><th>${label}</th><td>${value}</td></tr>`;
+
+    let restart = escapeHtml(cfg.restart_policy || 'no');
+    if (cfg.restart_max_retries) {
+        restart += ` (max ${cfg.restart_max_retries} retries)`;
+    }
+
+    let html = `
+        <p class="inspect-meta">Collected ${formatTimeAgo(new Date(data.collected_at))} from ${escapeHtml(data.host_name)}</p>
+        <h4>General</h4>
+        <table class="inspect-table">
+            ${row('Entrypoint', joinArgs(cfg.entrypoint))}
+            ${row('Command', joinArgs(cfg.cmd))}
+            ${row('Restart Policy', restart)}
+            ${row('User', cfg.user ? escapeHtml(cfg.user) : '<span class="inspect-empty">default</span>')}
+            ${row('Working Dir', cfg.working_dir ? escapeHtml(cfg.working_dir) : '<span class="inspect-empty">-</span>')}
+            ${row('Hostname', cfg.hostname ? escapeHtml(cfg.hostname) : '<span class="inspect-empty">-</span>')}
+            ${row('Privileged', cfg.privileged ? '<span class="inspect-warning">yes</span>' : 'no')}
+            ${cfg.cap_add && cfg.cap_add.length ? row('Capabilities Added', escapeHtml(cfg.cap_add.join(', '))) : ''}
+            ${cfg.cap_drop && cfg.cap_drop.length ? row('Capabilities Dropped', escapeHtml(cfg.cap_drop.join(', '))) : ''}
+        </table>`;
+
+    const env = cfg.env || [];
+    html += `<h4>Environment (${env.length})</h4>`;
+    if (env.length) {
+        html += '<table class="inspect-table">' + env.map(e => row(
+            escapeHtml(e.name),
+            e.masked ? `<span class="inspect-masked" title="Value hidden because it looks like a secret">${escapeHtml(e.value)}</span>` : escapeHtml(e.value)
+        )).join('') + '</table>';
+    } else {
+        html += '<p class="inspect-empty">No environment variables</p>';
+    }
+
+    const mounts = cfg.mounts || [];
+    html += `<h4>Mounts (${mounts.length})</h4>`;
+    if (mounts.length) {
+        html += '<table class="inspect-table">' + mounts.map(m => row(
+            escapeHtml(m.destination),
+            `${escapeHtml(m.source)} <span class="inspect-tag">${escapeHtml(m.type)}</span> <span class="inspect-tag">${m.rw ? 'rw' : 'ro'}</span>`
+        )).join('') + '</table>';
+    } else {
+        html += '<p class="inspect-empty">No mounts</p>';
+    }
+
+    const networks = cfg.networks || [];
+    html += `<h4>Networks</h4><table class="inspect-table">${row('Network Mode', escapeHtml(cfg.network_mode || 'default'))}`;
+    html += networks.map(n => {
+        const details = [n.ip_address, n.gateway ? `gw ${n.gateway}` : '', n.mac_address]
+            .filter(Boolean).map(escapeHtml).join(' · ');
+        const aliases = n.aliases && n.aliases.length ? ` <span class="inspect-tag">${escapeHtml(n.aliases.join(', '))}</span>` : '';
+        return row(escapeHtml(n.name), (details || '<span class="inspect-empty">-</span>') + aliases);
+    }).join('');
+    html += '</table>';
+
+    return html;
+}
+
+// Render the docker run command and compose service that recreate a container (empty when they can't be generated)
+async function renderRecreateSpec(hostId, containerId) {
+    const url = `/api/containers/${hostId}/${encodeURIComponent(containerId)}/recreate-spec`;
+    try {
+        const response = await fetch(url);
+        if (!response.ok) {
+            return '';
+        }
+        const spec = await response.json();
+        const masked = spec.masked_env && spec.masked_env.length
+            ? `<p class="inspect-meta">Masked secrets are taken from the shell or an .env file: ${escapeHtml(spec.masked_env.join(', '))}</p>`
+            : '';
+        return `
+            <h4>Recreate</h4>
+            ${masked}
+            <p><strong>docker run</strong> <a href="${url}?format=run" download="${escapeAttr(spec.container_name)}.sh">Download</a></p>
+            <pre>${escapeHtml(spec.run_command)}</pre>
+            <p><strong>Compose</strong> <a href="${url}?format=compose" download="${escapeAttr(spec.container_name)}.compose.yaml">Download</a></p>
+            <pre>${escapeHtml(spec.compose)}</pre>`;
+    } catch (error) {
+        console.error('Error loading recreate spec:', error);
+        return '';
+    }
+}
+
+// Render what collector plugins reported for a container, one section per plugin (empty when none did)
+async function renderPluginResults(hostId, containerName) {
+    try {
+        const response = await fetch(`/api/plugins/results?host_id=${hostId}&container_name=${encodeURIComponent(containerName)}`);
+        if (!response.ok) {
+            return '';
+        }
+        const results = await response.json();
+
+        const byPlugin = {};
+        results.forEach(r => (byPlugin[r.plugin] = byPlugin[r.plugin] || []).push(r));
+        return Object.entries(byPlugin).map(([plugin, rows]) => `
+            <h4>Plugin: ${escapeHtml(plugin)} <span class="inspect-tag">${formatTimeAgo(new Date(rows[0].collected_at))}</span></h4>
+            <table class="inspect-table">
+                ${rows.map(r => `<tr><th>${escapeHtml(r.key)}</th><td>${escapeHtml(r.value)}</td></tr>`).join('')}
+            </table>`).join('');
+    } catch (error) {
+        console.error('Error loading plugin results:', error);
+        return '';
+    }
+}
+
+function closeInspectModal() {
+    document.getElementById('inspectModal').classList.remove('show');
+}
+
+// Show the layer breakdown and build history of an image, optionally compared with another image
+async function viewImageLayers(hostId, imageId, displayName, compareId = '') {
+    document.getElementById('imageLayersName').textContent = displayName || imageId.replace('sha256:', '').substring(0, 12);
+    const content = document.getElementById('imageLayersContent');
+    content.textContent = 'Loading layers...';
+    document.getElementById('imageLayersModal').classList.add('show');
+
+    // Offer other images on the same host as comparison targets, same repository first
+    const repo = (displayName || '').split(':').slice(0, -1).join(':');
+    const candidates = currentImages
+        .filter(img => img.hostId === hostId && img.Id && img.Id !== imageId)
+        .map(img => ({ id: img.Id, name: (img.RepoTags && img.RepoTags[0]) || img.Id.replace('sha256:', '').substring(0, 12) }))
+        .sort((a, b) => (b.name.startsWith(repo + ':') - a.name.startsWith(repo + ':')) || a.name.localeCompare(b.name));
+    const select = document.getElementById('imageLayersCompare');
+    select.innerHTML = '<option value="">Compare with...</option>' + candidates.map(c =>
+        `<option value="${escapeHtml(c.id)}" ${c.id === compareId ? 'selected' : ''}>${escapeHtml(c.name)}</option>`
+    ).join('');
+    select.onchange = () => viewImageLayers(hostId, imageId, displayName, select.value);
+
+    try {
+        let url = `/api/images/${hostId}/${encodeURIComponent(imageId)}/layers`;
+        if (compareId) {
+            url += `?compare=${encodeURIComponent(compareId)}`;
+        }
+        const response = await fetch(url);
+
+        if (response.ok) {
+            const data = await response.json();
+            content.innerHTML = renderImageLayers(data);
+        } else {
+            const error = await response.json();
+            content.textContent = `Error: ${error.error}`;
+        }
+    } catch (error) {
+        console.error('Error loading image layers:', error);
+        content.textContent = 'Failed to load layers: ' + error.message;
+    }
+}
+
+function renderImageLayers(data) {
+    const layers = data.layers || [];
+    const compared = !!data.compared_to;
+    const maxSize = Math.max(1, ...layers.map(l => l.size));
+    const changedSize = layers.filter(l => !l.empty && !l.shared).reduce((sum, l) => sum + l.size, 0);
+
+    let summary = `${layers.filter(l => !l.empty).length} layers, ${formatBytes(data.size)} total`;
+    if (compared) {
+        summary += ` · ${formatBytes(changedSize)} in layers not present in the compared image`;
+    }
+
+    const rows = layers.map((layer, i) => {
+        let status = '';
+        if (compared && !layer.empty) {
+            status = layer.shared
+                ? '<span class="inspect-tag">shared</span>'
+                : '<span class="inspect-tag layer-changed">changed</span>';
+        }
+        const width = layer.empty ? 0 : Math.max(1, (layer.size / maxSize) * 100);
+        return `
+            <tr class="${layer.empty ? 'layer-empty' : ''}">
+                <td>${i + 1}</td>
+                <td class="layer-size">
+                    ${layer.empty ? '<span class="inspect-empty">0 B</span>' : formatBytes(layer.size)}
+                    <div class="layer-size-bar"><div style="width: ${width}%"></div></div>
+                </td>
+                <td><code class="layer-command">${escapeHtml(layer.created_by) || '<span class="inspect-empty">-</span>'}</code></td>
+                <td>${status}</td>
+            </tr>`;
+    }).join('');
+
+    return `
+        <p class="inspect-meta">${summary}${data.cached ? ` · cached ${formatTimeAgo(new Date(data.collected_at))}` : ''}</p>
+        <table class="inspect-table layers-table">
+            <thead><tr><th>#</th><th>Size</th><th>Step</th><th></th></tr></thead>
+            <tbody>${rows}</tbody>
+        </table>`;
+}
+
+function closeImageLayersModal() {
+    document.getElementById('imageLayersModal').classList.remove('show');
+}
+
 // Image Management Actions
 async function removeImage(hostId, imageId, imageName) {
     showConfirmDialog(
@@ -1418,6 +1896,73 @@ async function pruneImages(hostId, hostName) {
     );
 }
 
+function renderImageLastUsed(usage) {
+    if (!usage) return '-';
+    if (usage.in_use) return '<span class="image-in-use">In use</span>';
+    const label = usage.last_used ? formatTimeAgo(new Date(usage.last_used)) : 'Never run';
+    const title = usage.dangling ? 'Dangling image' : '';
+    const cls = usage.unused_days >= 30 ? 'image-unused-long' : '';
+    return `<span class="${cls}" title="${title}">${label}${usage.dangling ? ' · dangling' : ''}</span>`;
+}
+
+// Prune only images selected by the default policy, after showing what would be removed
+async function policyPruneImages(hostId, hostName) {
+    let preview;
+    try {
+        const response = await fetch(`/api/images/host/${hostId}/prune-policy`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ keep_tags_per_repo: 2, min_unused_days: 30, dry_run: true })
+        });
+        preview = await response.json();
+        if (!response.ok) {
+            showNotification(`Failed to plan prune: ${preview.error}`, 'error');
+            return;
+        }
+    } catch (error) {
+        console.error('Error planning image prune:', error);
+        showNotification('Failed to plan prune', 'error');
+        return;
+    }
+
+    const candidates = preview.candidates || [];
+    if (candidates.length === 0) {
+        showNotification(`No images on "${hostName}" match the prune policy`, 'info');
+        return;
+    }
+
+    const total = candidates.reduce((sum, c) => sum + c.size, 0);
+    const names = candidates.slice(0, 5).map(c => (c.repo_tags && c.repo_tags[0]) || c.image_id.replace('sha256:', '').substring(0, 12));
+    const more = candidates.length > names.length ? ` and ${candidates.length - names.length} more` : '';
+
+    showConfirmDialog(
+        'Smart Prune',
+        `Remove ${candidates.length} image(s) on "${escapeHtml(hostName)}" (${formatBytes(total)}) that are unused for 30+ days and not among the 2 newest tags of their repository: ${names.map(escapeHtml).join(', ')}${more}?`,
+        async () => {
+            try {
+                const response = await fetch(`/api/images/host/${hostId}/prune-policy`, {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({ keep_tags_per_repo: 2, min_unused_days: 30 })
+                });
+                const data = await response.json();
+
+                if (response.ok) {
+                    const failed = (data.errors || []).length;
+                    showNotification(`Removed ${data.removed} image(s), reclaimed ${formatBytes(data.space_reclaimed)}${failed ? `, ${failed} failed` : ''}`, failed ? 'warning' : 'success');
+                    await loadImages();
+                } else {
+                    showNotification(`Failed to prune images: ${data.error}`, 'error');
+                }
+            } catch (error) {
+                console.error('Error pruning images:', error);
+                showNotification('Failed to prune images', 'error');
+            }
+        },
+        'warning'
+    );
+}
+
 // Theme-specific card renderers
 function renderCompactCard(cont) {
     // Debug: Log image tags for first container only
@@ -1456,6 +2001,7 @@ function renderCompactCard(cont) {
                                 <span class="chip chip-state ${cont.state}">${cont.state}</span>
                                 <span class="chip chip-image" title="${escapeHtml(cont.image)}">🏷️ ${escapeHtml(extractImageTag(cont.image, cont.image_tags))}</span>
                                 <span class="chip chip-time">⏱️ ${createdTime}</span>
+                                ${renderUptimeBadge(cont)}${renderZeroStatsBadge(cont)}${renderServiceLinks(cont)}${renderOwnerBadge(cont)}${renderNotesBadge(cont)}
                             </div>
                         </div>
                     </div>
@@ -1475,16 +2021,7 @@ function renderCompactCard(cont) {
                         <code class="detail-value">${escapeHtml(cont.image)}</code>
                         ${cont.update_available ? '<span class="badge-update">⬆️ Update Available</span>' : ''}
                     </div>
-                    ${(cont.image.endsWith(':latest') || !cont.image.includes(':')) && isRunning ? `
-                        <button class="btn btn-xs btn-primary" onclick="checkContainerUpdate(${cont.host_id}, '${escapeAttr(cont.name)}', '${escapeAttr(cont.name)}')" title="Check for updates">
-                            🔍 Check
-                        </button>
-                    ` : ''}
-                    ${cont.update_available ? `
-                        <button class="btn btn-xs btn-success" onclick="updateContainer(${cont.host_id}, '${escapeAttr(cont.name)}', '${escapeAttr(cont.name)}', '${escapeAttr(cont.image)}')" title="Update image">
-                            ⬆️ Update
-                        </button>
-                    ` : ''}
+                    ${renderUpdateActions(cont, isRunning)}
                     ${cont.ports && cont.ports.length > 0 && cont.ports.some(p => p.public_port > 0) ? `
                     <div class="detail-inline">
                         <span class="detail-label">🔌 Ports:</span>
@@ -1522,6 +2059,7 @@ function renderCompactCard(cont) {
 
                 <div class="metro-footer">
                     <button class="btn-metro btn-sm" onclick="viewLogs(${cont.host_id}, '${escapeAttr(cont.name)}', '${escapeAttr(cont.name)}')">📋 Logs</button>
+                    <button class="btn-metro btn-sm" onclick="viewInspect(${cont.host_id}, '${escapeAttr(cont.id)}', '${escapeAttr(cont.name)}')">🔎 Inspect</button>
                     ${isRunning ? `
                         <button class="btn-metro btn-sm warning" onclick="restartContainer(${cont.host_id}, '${escapeAttr(cont.id)}', '${escapeAttr(cont.name)}')">🔄 Restart</button>
                         <button class="btn-metro btn-sm warning" onclick="stopContainer(${cont.host_id}, '${escapeAttr(cont.id)}', '${escapeAttr(cont.name)}')">⏹ Stop</button>
@@ -1569,6 +2107,7 @@ function renderMaterialCard(cont) {
                             <span class="material-meta-item" title="${escapeHtml(cont.image)}">🏷️ ${escapeHtml(extractImageTag(cont.image, cont.image_tags))}</span>
                             <span class="material-meta-separator">•</span>
                             <span class="material-meta-item">⏱️ ${createdTime}</span>
+                            ${renderUptimeBadge(cont)}${renderZeroStatsBadge(cont)}${renderServiceLinks(cont)}${renderOwnerBadge(cont)}${renderNotesBadge(cont)}
                         </div>
                     </div>
                 </div>
@@ -1589,16 +2128,7 @@ function renderMaterialCard(cont) {
                         <code>${escapeHtml(cont.image)}</code>
                         ${cont.update_available ? '<span class="material-chip update">⬆️ Update Available</span>' : ''}
                     </div>
-                    ${(cont.image.endsWith(':latest') || !cont.image.includes(':')) && isRunning ? `
-                        <button class="btn btn-xs btn-primary" onclick="checkContainerUpdate(${cont.host_id}, '${escapeAttr(cont.name)}', '${escapeAttr(cont.name)}')" title="Check for updates">
-                            🔍 Check
-                        </button>
-                    ` : ''}
-                    ${cont.update_available ? `
-                        <button class="btn btn-xs btn-success" onclick="updateContainer(${cont.host_id}, '${escapeAttr(cont.name)}', '${escapeAttr(cont.name)}', '${escapeAttr(cont.image)}')" title="Update image">
-                            ⬆️ Update
-                        </button>
-                    ` : ''}
+                    ${renderUpdateActions(cont, isRunning)}
                 </div>
 
                 ${cont.ports && cont.ports.length > 0 && cont.ports.some(p => p.public_port > 0) ? `
@@ -1651,6 +2181,7 @@ function renderMaterialCard(cont) {
 
             <div class="material-footer">
                 <button class="material-btn outlined" onclick="viewLogs(${cont.host_id}, '${escapeAttr(cont.name)}', '${escapeAttr(cont.name)}')">📋 View Logs</button>
+                <button class="material-btn outlined" onclick="viewInspect(${cont.host_id}, '${escapeAttr(cont.id)}', '${escapeAttr(cont.name)}')">🔎 Inspect</button>
                 ${isRunning ? `
                     <button class="material-btn outlined warning" onclick="restartContainer(${cont.host_id}, '${escapeAttr(cont.id)}', '${escapeAttr(cont.name)}')">🔄 Restart</button>
                     <button class="material-btn outlined warning" onclick="stopContainer(${cont.host_id}, '${escapeAttr(cont.id)}', '${escapeAttr(cont.name)}')">⏹ Stop</button>
@@ -1691,6 +2222,7 @@ function renderDashboardCard(cont) {
                     <span class="dashboard-tag" title="${escapeHtml(cont.image)}">🏷️ ${escapeHtml(extractImageTag(cont.image, cont.image_tags))}</span>
                     <span class="dashboard-tag time">${createdTime}</span>
                     ${cont.update_available ? '<span class="dashboard-tag alert">⬆️ Update</span>' : ''}
+                    ${renderUptimeBadge(cont)}${renderZeroStatsBadge(cont)}${renderServiceLinks(cont)}${renderOwnerBadge(cont)}${renderNotesBadge(cont)}
                 </div>
                 <div class="dashboard-actions-menu">
                     ${hasStats && isRunning ? `
@@ -1708,16 +2240,7 @@ function renderDashboardCard(cont) {
                         <span class="info-icon">🖼️</span>
                         <code class="info-code">${escapeHtml(cont.image)}</code>
                     </div>
-                    ${(cont.image.endsWith(':latest') || !cont.image.includes(':')) && isRunning ? `
-                        <button class="btn btn-xs btn-primary" onclick="checkContainerUpdate(${cont.host_id}, '${escapeAttr(cont.name)}', '${escapeAttr(cont.name)}')" title="Check for updates">
-                            🔍 Check
-                        </button>
-                    ` : ''}
-                    ${cont.update_available ? `
-                        <button class="btn btn-xs btn-success" onclick="updateContainer(${cont.host_id}, '${escapeAttr(cont.name)}', '${escapeAttr(cont.name)}', '${escapeAttr(cont.image)}')" title="Update image">
-                            ⬆️ Update
-                        </button>
-                    ` : ''}
+                    ${renderUpdateActions(cont, isRunning)}
                     ${cont.ports && cont.ports.length > 0 && cont.ports.some(p => p.public_port > 0) ? `
                     <div class="info-item">
                         <span class="info-icon">🔌</span>
@@ -1753,6 +2276,7 @@ function renderDashboardCard(cont) {
 
             <div class="dashboard-footer">
                 <button class="dashboard-btn" onclick="viewLogs(${cont.host_id}, '${escapeAttr(cont.name)}', '${escapeAttr(cont.name)}')">📋 Logs</button>
+                <button class="dashboard-btn" onclick="viewInspect(${cont.host_id}, '${escapeAttr(cont.id)}', '${escapeAttr(cont.name)}')">🔎 Inspect</button>
                 ${isRunning ? `
                     <button class="dashboard-btn" onclick="restartContainer(${cont.host_id}, '${escapeAttr(cont.id)}', '${escapeAttr(cont.name)}')">🔄 Restart</button>
                     <button class="dashboard-btn" onclick="stopContainer(${cont.host_id}, '${escapeAttr(cont.id)}', '${escapeAttr(cont.name)}')">⏹ Stop</button>
@@ -1793,6 +2317,9 @@ function renderContainers(containersToRender) {
     }).join('');
 }
 
+// Images from the last render, used to offer comparison targets in the layers view
+let currentImages = [];
+
 function renderImages(imagesData) {
     const tbody = document.getElementById('imagesBody');
 
@@ -1811,19 +2338,26 @@ function renderImages(imagesData) {
             });
         }
 
+        currentImages = allImages;
+        renderUnreachableHosts(images);
+
         if (allImages.length === 0) {
-            tbody.innerHTML = '<tr><td colspan="7" class="loading">No images found</td></tr>';
+            tbody.innerHTML = '<tr><td colspan="8" class="loading">No images found</td></tr>';
             return;
         }
 
     // Group by host to add prune button
     const hostButtons = {};
     for (const [hostName, hostData] of Object.entries(imagesData)) {
+        if (hostData.status === 'error') continue;
         const hostId = hostData.host_id;
         hostButtons[hostName] = `
             <button class="btn btn-sm btn-warning" onclick="pruneImages(${hostId}, '${escapeAttr(hostName)}')">
                 Prune Unused Images (${escapeHtml(hostName)})
             </button>
+            <button class="btn btn-sm btn-secondary" onclick="policyPruneImages(${hostId}, '${escapeAttr(hostName)}')" title="Keep the 2 newest tags per repository and anything used in the last 30 days">
+                Smart Prune (${escapeHtml(hostName)})
+            </button>
         `;
     }
 
@@ -1855,7 +2389,9 @@ function renderImages(imagesData) {
             <td><code>${imageId}</code></td>
             <td>${sizeMB} MB</td>
             <td class="time-ago">${formatDate(created.toISOString())}</td>
+            <td class="time-ago">${renderImageLastUsed(imageUsage[`${img.hostId}:${img.Id}`])}</td>
             <td class="actions">
+                <button class="btn-icon" onclick="viewImageLayers(${img.hostId}, '${escapeAttr(img.Id || '')}', '${escapeAttr(repoTags[0] || '')}')" title="Layers">🧱</button>
                 <button class="btn-icon btn-delete" onclick="removeImage(${img.hostId}, '${escapeAttr(img.Id || '')}', '${escapeAttr(repoTags[0] || '')}')" title="Remove">🗑</button>
             </td>
         </tr>
@@ -1863,10 +2399,29 @@ function renderImages(imagesData) {
     }).join('');
     } catch (error) {
         console.error('Error rendering images:', error);
-        tbody.innerHTML = '<tr><td colspan="7" class="error">Error rendering images. Check console for details.</td></tr>';
+        tbody.innerHTML = '<tr><td colspan="8" class="error">Error rendering images. Check console for details.</td></tr>';
     }
 }
 
+// Show the hosts that couldn't be reached, whose images are missing from the list
+function renderUnreachableHosts(imagesData) {
+    const imagesSection = document.querySelector('.images-section h2');
+    let notice = document.querySelector('.host-errors');
+    if (!notice) {
+        notice = document.createElement('div');
+        notice.className = 'host-errors';
+        imagesSection.parentNode.insertBefore(notice, imagesSection.nextSibling);
+    }
+
+    const failed = Object.values(imagesData || {}).filter(hostData => hostData.status === 'error');
+    notice.style.display = failed.length > 0 ? 'block' : 'none';
+    notice.innerHTML = failed.map(hostData => `
+        <div class="host-error" title="${escapeAttr(hostData.error || '')}">
+            ⚠️ Host <strong>${escapeHtml(hostData.host_name)}</strong> unreachable – its images are not shown (${escapeHtml(hostData.error || 'unknown error')})
+        </div>
+    `).join('');
+}
+
 function renderActivityLog(activities) {
     const tbody = document.getElementById('activityLogBody');
 
@@ -1912,7 +2467,7 @@ function renderHosts(hostsData) {
     const tbody = document.getElementById('hostsBody');
 
     if (!hostsData || hostsData.length === 0) {
-        tbody.innerHTML = '<tr><td colspan="8" class="loading">No hosts configured</td></tr>';
+        tbody.innerHTML = '<tr><td colspan="9" class="loading">No hosts configured</td></tr>';
         return;
     }
 
@@ -1928,6 +2483,12 @@ function renderHosts(hostsData) {
             } else {
                 statusBadge = '<span class="badge badge-warning">Offline</span>';
             }
+        } else if (host.agent_status === 'offline') {
+            const lastScan = scanDiagnostics[host.id];
+            const title = lastScan && lastScan.scan_id ? `The last scan (${lastScan.scan_id}) failed: ${lastScan.error || ''}` : 'The last scan failed';
+            statusBadge = `<span class="badge badge-warning" title="${escapeAttr(title)}">Offline</span>`;
+        } else if (host.agent_status === 'online') {
+            statusBadge = '<span class="badge badge-success">Online</span>';
         } else {
             statusBadge = '<span class="badge badge-success">Enabled</span>';
         }
@@ -1943,6 +2504,7 @@ function renderHosts(hostsData) {
             'unix': '🐳',
             'tcp': '🌐',
             'ssh': '🔐',
+            'incus': '📦',
             'unknown': '❓'
         }[hostType] || '❓';
 
@@ -1952,10 +2514,11 @@ function renderHosts(hostsData) {
 
         return `
         <tr>
-            <td><strong>${escapeHtml(host.name)}</strong></td>
+            <td><strong>${escapeHtml(host.name)}</strong>${renderProxmoxGuest(host.proxmox)}</td>
+            <td>${host.site ? `<span class="site-tag">📍 ${escapeHtml(host.site)}</span>` : '-'}</td>
             <td>${typeIcon} ${escapeHtml(hostType)}</td>
-            <td><code>${escapeHtml(host.address)}</code></td>
-            <td>${statusBadge}</td>
+            <td><code>${escapeHtml(host.address)}</code>${host.registry_mirror ? `<br><small title="Docker Hub pulls go through this mirror">🪞 ${escapeHtml(host.registry_mirror)}</small>` : ''}</td>
+            <td>${statusBadge}${renderScanWarningsBadge(host.id)}${renderAgentHealth(host.agent_health)}</td>
             <td>${statsCollectionBadge}</td>
             <td>${escapeHtml(host.description || '-')}</td>
             <td class="time-ago">${lastSeen}</td>
@@ -1964,6 +2527,15 @@ function renderHosts(hostsData) {
                     ? `<button class="btn-icon btn-warning" onclick="toggleHost(${host.id}, false)" title="Disable">⏸</button>`
                     : `<button class="btn-icon btn-success" onclick="toggleHost(${host.id}, true)" title="Enable">▶</button>`
                 }
+                ${host.host_type !== 'incus' ? `
+                    <button class="btn-icon" onclick="configureRegistryMirror(${host.id})" title="Registry mirror">🪞</button>
+                    <button class="btn-icon" onclick="showComplianceAudit(${host.id})" title="CIS Docker Benchmark">🛡️</button>
+                ` : ''}
+                <button class="btn-icon" onclick="showHostUptime(${host.id})" title="Uptime">📶</button>
+                <button class="btn-icon" onclick="editHostNotes(${host.id})" title="${escapeAttr(host.notes ? notesSummary(host.notes) : 'Notes')}">${host.notes ? '📝' : '🗒️'}</button>
+                <button class="btn-icon" onclick="renameHost(${host.id})" title="Rename">✏️</button>
+                <button class="btn-icon" onclick="configureSite(${host.id})" title="Site">📍</button>
+                <button class="btn-icon admin-only" onclick="saveHostAsTemplate(${host.id})" title="Save as template">🧬</button>
                 <button class="btn-icon btn-delete" onclick="deleteHost(${host.id}, '${escapeAttr(host.name)}')" title="Delete">🗑</button>
             </td>
         </tr>
@@ -2017,1726 +2589,3297 @@ async function toggleStatsCollection(hostId, enable) {
     }
 }
 
-async function deleteHost(hostId, hostName) {
-    if (!confirm(`Are you sure you want to delete host "${hostName}"?\n\nThis will remove all associated container history.`)) {
-        return;
-    }
+// Rename a host; its containers and stats keep their history under the new name
+async function renameHost(hostId) {
+    const host = hosts.find(h => h.id === hostId);
+    if (!host) return;
+
+    const input = prompt(`New name for host "${host.name}"`, host.name);
+    if (input === null || !input.trim() || input.trim() === host.name) return;
 
     try {
-        const response = await fetch(`/api/hosts/${hostId}`, {
-            method: 'DELETE'
+        const response = await fetch(`/api/hosts/${hostId}/name`, {
+            method: 'PUT',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ name: input.trim() })
         });
 
         if (response.ok) {
-            showNotification(`Host "${hostName}" deleted successfully`, 'success');
+            showNotification(`Host renamed to ${input.trim()}`, 'success');
             loadData();
         } else {
             const error = await response.json();
-            showNotification('Error: ' + (error.error || 'Failed to delete host'), 'error');
+            showNotification('Error: ' + (error.error || 'Failed to rename host'), 'error');
         }
     } catch (error) {
         showNotification('Error: ' + error.message, 'error');
     }
 }
 
-function updateStats() {
-    const safeHosts = hosts || [];
-    const safeContainers = containers || [];
-    const safeActivities = activities || [];
-
-    document.getElementById('totalHosts').textContent = safeHosts.length;
-    document.getElementById('totalContainers').textContent = safeContainers.length;
-
-    const running = safeContainers.filter(c => c.state === 'running').length;
-    document.getElementById('runningContainers').textContent = running;
+// Add the local Docker daemon (its socket must be mounted into the server container)
+async function addLocalHost() {
+    try {
+        const response = await fetch('/api/hosts/local', { method: 'POST' });
 
-    // Find most recent scan activity
-    const scanActivities = safeActivities.filter(a => a.type === 'scan');
-    if (scanActivities.length > 0) {
-        const lastScan = new Date(scanActivities[0].timestamp);
-        document.getElementById('lastScan').textContent = formatTimeAgo(lastScan);
-    } else {
-        document.getElementById('lastScan').textContent = 'Never';
+        if (response.ok) {
+            showNotification('Local Docker host added', 'success');
+            loadData();
+        } else {
+            const error = await response.json();
+            showNotification('Error: ' + (error.error || 'Failed to add local host'), 'error');
+        }
+    } catch (error) {
+        showNotification('Error: ' + error.message, 'error');
     }
+}
 
-    // Update vulnerability stats (if available)
-    updateVulnerabilityStats();
+// Bulk host import from a CSV or YAML list
+function openHostImportModal() {
+    document.getElementById('hostImportResult').innerHTML = '';
+    document.getElementById('hostImportModal').classList.add('show');
 }
 
-// Update vulnerability statistics in sidebar
-async function updateVulnerabilityStats() {
-    const criticalElem = document.getElementById('criticalVulns');
+function closeHostImportModal() {
+    document.getElementById('hostImportModal').classList.remove('show');
+}
 
-    if (!criticalElem) return;
+async function runHostImport(dryRun) {
+    const text = document.getElementById('hostImportText').value;
+    const result = document.getElementById('hostImportResult');
+    if (!text.trim()) {
+        result.innerHTML = '<div class="error">Paste a host list or choose a file first.</div>';
+        return;
+    }
 
-    // If we don't have summary yet, fetch it
-    if (!vulnerabilitySummary) {
-        try {
-            vulnerabilitySummary = await loadVulnerabilitySummary();
-        } catch (error) {
-            console.error('Error loading vulnerability summary:', error);
-            criticalElem.textContent = '-';
-            return;
+    const params = new URLSearchParams();
+    if (dryRun) params.set('dry_run', 'true');
+    if (!document.getElementById('hostImportTest').checked) params.set('test', 'false');
+    result.innerHTML = `<div class="loading">${dryRun ? 'Checking' : 'Importing'} hosts...</div>`;
+
+    try {
+        const response = await fetch(`/api/hosts/import?${params}`, { method: 'POST', body: text });
+        const report = await response.json();
+        if (!response.ok) {
+            throw new Error(report.error || `HTTP ${response.status}`);
         }
-    }
 
-    if (vulnerabilitySummary && vulnerabilitySummary.summary) {
-        const s = vulnerabilitySummary.summary;
-        const critical = s.severity_counts?.critical || 0;
+        const statusBadge = {
+            'added': '<span class="badge badge-success">Added</span>',
+            'ready': '<span class="badge badge-success">Ready</span>',
+            'invalid': '<span class="badge badge-error">Invalid</span>',
+            'unreachable': '<span class="badge badge-warning">Unreachable</span>',
+            'failed': '<span class="badge badge-error">Failed</span>'
+        };
+        const ready = report.rows.filter(row => row.status === 'ready').length;
+        result.innerHTML = `
+            <p>${report.dry_run
+                ? `<strong>${ready}</strong> of ${report.total} hosts can be imported.`
+                : `<strong>${report.added}</strong> of ${report.total} hosts imported.`}
+               ${report.failed ? `${report.failed} can't be.` : ''}</p>
+            <table class="vuln-table">
+                <thead>
+                    <tr><th>Line</th><th>Name</th><th>Address</th><th>Type</th><th>Status</th><th>Error</th></tr>
+                </thead>
+                <tbody>
+                    ${report.rows.map(row => `
+                        <tr>
+                            <td>${row.line}</td>
+                            <td>${escapeHtml(row.name || '-')}</td>
+                            <td><code>${escapeHtml(row.address || '-')}</code></td>
+                            <td>${escapeHtml(row.type || '-')}</td>
+                            <td>${statusBadge[row.status] || escapeHtml(row.status)}</td>
+                            <td><small>${escapeHtml(row.error || '')}</small></td>
+                        </tr>
+                    `).join('')}
+                </tbody>
+            </table>
+        `;
+        if (report.added > 0) {
+            showNotification(`${report.added} hosts imported`, 'success');
+            loadData();
+        }
+    } catch (error) {
+        result.innerHTML = `<div class="error">Import failed: ${escapeHtml(error.message)}</div>`;
+    }
+}
 
-        criticalElem.textContent = critical;
+// Host templates: settings and notification rules new hosts start from
+let hostTemplates = [];
 
-        // Add visual indication for high counts
-        if (critical > 0) {
-            criticalElem.style.fontWeight = 'bold';
-        } else {
-            criticalElem.style.fontWeight = 'normal';
-        }
+async function loadHostTemplates() {
+    if (!currentUser || !currentUser.admin) {
+        hostTemplates = [];
+        return hostTemplates;
+    }
+    try {
+        const response = await fetch('/api/host-templates');
+        hostTemplates = response.ok ? await response.json() : [];
+    } catch (error) {
+        hostTemplates = [];
     }
+    return hostTemplates;
 }
 
-function updateHostFilter() {
-    // Update both the main host filter and the monitoring tab host filter
-    const selects = ['hostFilter', 'monitoringHostFilter'];
+// Fill the "Start from" select of an add-host dialog with the templates and existing hosts
+async function loadHostStartOptions(prefix) {
+    const select = document.getElementById(`${prefix}StartFrom`);
+    if (!select) return;
 
-    selects.forEach(selectId => {
-        const select = document.getElementById(selectId);
-        if (select) {
-            const currentValue = select.value;
+    await loadHostTemplates();
+    select.innerHTML = `
+        <option value="">Nothing (blank host)</option>
+        ${hostTemplates.length ? `<optgroup label="Templates">
+            ${hostTemplates.map(t => `<option value="template:${t.id}">${escapeHtml(t.name)}</option>`).join('')}
+        </optgroup>` : ''}
+        ${hosts.length ? `<optgroup label="Copy of host">
+            ${hosts.map(h => `<option value="host:${h.id}">${escapeHtml(h.name)}</option>`).join('')}
+        </optgroup>` : ''}
+    `;
+}
 
-            select.innerHTML = '<option value="">All Hosts</option>' +
-                hosts.map(host => `<option value="${host.id}">${escapeHtml(host.name)}</option>`).join('');
+// Prefill an add-host dialog from the chosen template or host
+function applyHostStartFrom(prefix) {
+    const [kind, id] = (document.getElementById(`${prefix}StartFrom`).value || '').split(':');
+    const source = kind === 'template'
+        ? hostTemplates.find(t => t.id === parseInt(id))
+        : kind === 'host' ? hosts.find(h => h.id === parseInt(id)) : null;
+    if (!source) return;
 
-            select.value = currentValue;
-        }
-    });
+    document.getElementById(`${prefix}CollectStats`).checked = !!source.collect_stats;
+    const site = document.getElementById(`${prefix}Site`);
+    if (!site.value.trim()) site.value = source.site || '';
+    const description = document.getElementById(`${prefix}Description`);
+    if (!description.value.trim()) description.value = source.description || '';
 }
 
-// Filtering
-function filterContainers() {
-    const searchTerm = document.getElementById('searchInput')?.value.toLowerCase() || '';
-    const hostFilter = document.getElementById('hostFilter')?.value || '';
-    const stateFilter = document.getElementById('stateFilter')?.value || '';
+// The template_id or clone_host_id to send with an add-host request
+function hostStartFromPayload(prefix) {
+    const [kind, id] = (document.getElementById(`${prefix}StartFrom`)?.value || '').split(':');
+    if (kind === 'template') return { template_id: parseInt(id) };
+    if (kind === 'host') return { clone_host_id: parseInt(id) };
+    return {};
+}
 
-    const filtered = containers.filter(container => {
-        const matchesSearch = searchTerm === '' ||
-            container.name.toLowerCase().includes(searchTerm) ||
-            container.image.toLowerCase().includes(searchTerm) ||
-            container.host_name.toLowerCase().includes(searchTerm);
+async function saveHostAsTemplate(hostId) {
+    const host = hosts.find(h => h.id === hostId);
+    if (!host) return;
 
-        const matchesHost = hostFilter === '' || container.host_id.toString() === hostFilter;
-        const matchesState = stateFilter === '' || container.state === stateFilter;
+    const name = prompt(`Save the settings and notification rules of "${host.name}" as a template named`, host.site || host.name);
+    if (name === null || !name.trim()) return;
 
-        return matchesSearch && matchesHost && matchesState;
-    });
+    try {
+        const response = await fetch(`/api/host-templates?from_host=${hostId}`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ name: name.trim() })
+        });
+        const data = await response.json();
+        if (response.ok) {
+            showNotification(`Template ${data.name} saved with ${data.notification_rules.length} notification rules`, 'success');
+        } else {
+            showNotification('Error: ' + (data.error || 'Failed to save template'), 'error');
+        }
+    } catch (error) {
+        showNotification('Error: ' + error.message, 'error');
+    }
+}
 
-    renderContainers(filtered);
+async function openHostTemplatesModal() {
+    document.getElementById('hostTemplatesModal').classList.add('show');
+    await renderHostTemplates();
+}
 
-    // Load vulnerability badges asynchronously
-    loadAllVulnerabilityBadges();
+function closeHostTemplatesModal() {
+    document.getElementById('hostTemplatesModal').classList.remove('show');
 }
 
-function filterImages() {
-    const searchTerm = document.getElementById('searchInput')?.value.toLowerCase() || '';
-    const hostFilter = document.getElementById('hostFilter')?.value || '';
+async function renderHostTemplates() {
+    const list = document.getElementById('hostTemplatesList');
+    list.innerHTML = '<div class="loading">Loading templates...</div>';
+    await loadHostTemplates();
 
-    if (!images || Object.keys(images).length === 0) {
+    if (hostTemplates.length === 0) {
+        list.innerHTML = '<p><em>No templates yet.</em></p>';
         return;
     }
+    list.innerHTML = `
+        <table class="vuln-table">
+            <thead>
+                <tr><th>Name</th><th>Stats</th><th>Site</th><th>Registry mirror</th><th>Notification rules</th><th>Taken from</th><th></th></tr>
+            </thead>
+            <tbody>
+                ${hostTemplates.map(t => `
+                    <tr>
+                        <td><strong>${escapeHtml(t.name)}</strong>${t.description ? `<br><small>${escapeHtml(t.description)}</small>` : ''}</td>
+                        <td>${t.collect_stats ? '<span class="badge badge-success">On</span>' : '<span class="badge badge-secondary">Off</span>'}</td>
+                        <td>${escapeHtml(t.site || '-')}</td>
+                        <td>${escapeHtml(t.registry_mirror || '-')}</td>
+                        <td>${t.notification_rules.map(rule => escapeHtml(rule.name)).join('<br>') || '-'}</td>
+                        <td>${escapeHtml(t.source_host || '-')}</td>
+                        <td><button class="btn-icon btn-delete" onclick="deleteHostTemplate(${t.id}, '${escapeAttr(t.name)}')" title="Delete">🗑</button></td>
+                    </tr>
+                `).join('')}
+            </tbody>
+        </table>
+    `;
+}
 
-    // Filter images data structure
-    const filteredImages = {};
-    for (const [hostName, hostData] of Object.entries(images)) {
-        const hostId = hostData.host_id;
-        const matchesHost = hostFilter === '' || hostId?.toString() === hostFilter;
+async function deleteHostTemplate(id, name) {
+    if (!confirm(`Delete the host template "${name}"? Hosts created from it keep their settings.`)) return;
 
-        if (matchesHost) {
-            const filteredHostImages = hostData.images.filter(img => {
-                const matchesSearch = searchTerm === '' ||
-                    (img.repository && img.repository.toLowerCase().includes(searchTerm)) ||
-                    (img.tag && img.tag.toLowerCase().includes(searchTerm)) ||
-                    (img.id && img.id.toLowerCase().includes(searchTerm));
+    try {
+        const response = await fetch(`/api/host-templates/${id}`, { method: 'DELETE' });
+        if (response.ok) {
+            showNotification('Host template deleted', 'success');
+            renderHostTemplates();
+        } else {
+            const error = await response.json();
+            showNotification('Error: ' + (error.error || 'Failed to delete template'), 'error');
+        }
+    } catch (error) {
+        showNotification('Error: ' + error.message, 'error');
+    }
+}
 
-                return matchesSearch;
-            });
+// Assign a host to a site such as "home" or "vps"
+async function configureSite(hostId) {
+    const host = hosts.find(h => h.id === hostId);
+    if (!host) return;
 
-            if (filteredHostImages.length > 0) {
-                filteredImages[hostName] = {
-                    ...hostData,
-                    images: filteredHostImages
-                };
-            }
+    const sites = siteNames();
+    const input = prompt(
+        `Site of "${host.name}"${sites.length ? ` (existing: ${sites.join(', ')})` : ''}.\nLeave empty to remove it from its site.`,
+        host.site || ''
+    );
+    if (input === null) return;
+
+    try {
+        const response = await fetch(`/api/hosts/${hostId}`, {
+            method: 'PUT',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ ...host, site: input.trim() })
+        });
+
+        if (response.ok) {
+            showNotification(input.trim() ? `Host moved to site ${input.trim()}` : 'Host removed from its site', 'success');
+            await loadHosts();
+            renderHosts(hosts);
+            loadSites();
+        } else {
+            const error = await response.json();
+            showNotification('Error: ' + (error.error || 'Failed to update host'), 'error');
         }
+    } catch (error) {
+        showNotification('Error: ' + error.message, 'error');
     }
+}
 
-    renderImages(filteredImages);
+// Site whose hosts, stacks and containers are expanded in the sites overview
+let expandedSite = null;
+
+// Load per-site health and stats for the Hosts tab. Nothing is shown until hosts have sites.
+async function loadSites() {
+    const el = document.getElementById('sitesSummary');
+    if (!el) return;
+
+    try {
+        const [response] = await Promise.all([fetch('/api/sites'), loadContainers()]);
+        const sites = await response.json();
+        if (!response.ok || !Array.isArray(sites) || !sites.some(site => site.name)) {
+            el.innerHTML = '';
+            return;
+        }
+        renderSites(sites);
+    } catch (error) {
+        console.error('Error loading sites:', error);
+        el.innerHTML = '';
+    }
 }
 
-function filterMonitoring() {
-    const searchTerm = document.getElementById('searchInput')?.value.toLowerCase() || '';
-    const hostFilter = document.getElementById('hostFilter')?.value || '';
+function renderSites(sites) {
+    const el = document.getElementById('sitesSummary');
+    const healthBadge = {
+        'healthy': '<span class="badge badge-success">Healthy</span>',
+        'degraded': '<span class="badge badge-warning">Degraded</span>',
+        'down': '<span class="badge badge-error">Down</span>'
+    };
 
-    // Get running containers from enabled hosts
-    const enabledHostIds = new Set(hosts.filter(h => h.enabled).map(h => h.id));
-    let runningContainers = containers.filter(c =>
-        c.state === 'running' && enabledHostIds.has(c.host_id)
-    );
+    el.innerHTML = `
+        <div class="site-cards">
+            ${sites.map(site => `
+                <div class="site-card ${expandedSite === site.name ? 'expanded' : ''}" onclick="toggleSite('${escapeAttr(site.name)}')" title="Show hosts, stacks and containers">
+                    <div class="site-card-header">
+                        <strong>📍 ${escapeHtml(site.name || 'No site')}</strong>
+                        ${healthBadge[site.health] || ''}
+                    </div>
+                    <div class="site-card-stats">
+                        <span>${site.hosts_online}/${site.hosts - site.hosts_disabled} hosts online</span>
+                        <span>${site.running}/${site.containers} running</span>
+                        <span>${site.stacks} stacks</span>
+                        ${site.updates_available ? `<span>⬆️ ${site.updates_available} updates</span>` : ''}
+                        <span>CPU ${site.cpu_percent.toFixed(1)}%</span>
+                        <span>RAM ${formatBytes(site.memory_usage)}</span>
+                    </div>
+                </div>
+            `).join('')}
+        </div>
+        ${expandedSite !== null ? renderSiteTree(expandedSite) : ''}
+    `;
+    el.dataset.sites = JSON.stringify(sites);
+}
 
-    // Apply filters
-    runningContainers = runningContainers.filter(container => {
-        const matchesSearch = searchTerm === '' ||
-            container.name.toLowerCase().includes(searchTerm) ||
-            container.image.toLowerCase().includes(searchTerm) ||
-            container.host_name.toLowerCase().includes(searchTerm);
+function toggleSite(name) {
+    expandedSite = expandedSite === name ? null : name;
+    const el = document.getElementById('sitesSummary');
+    renderSites(JSON.parse(el.dataset.sites || '[]'));
+}
 
-        const matchesHost = hostFilter === '' || container.host_id.toString() === hostFilter;
+// Hierarchical view of a site: host → compose stack → container
+function renderSiteTree(siteName) {
+    const siteHosts = hosts.filter(h => (h.site || '') === siteName);
+    if (siteHosts.length === 0) return '';
 
-        return matchesSearch && matchesHost;
-    });
+    const stateIcon = state => state === 'running' ? '🟢' : (state === 'paused' ? '🟡' : '🔴');
+    const renderContainer = c => `<li>${stateIcon(c.state)} ${escapeHtml(c.name)} <small class="site-tree-image">${escapeHtml(c.image)}</small></li>`;
 
-    renderMonitoringGrid(runningContainers);
+    return `
+        <div class="site-tree">
+            ${siteHosts.map(host => {
+                const hostContainers = containers.filter(c => c.host_id === host.id);
+                const stacks = {};
+                const standalone = [];
+                hostContainers.forEach(c => {
+                    if (c.compose_project) {
+                        (stacks[c.compose_project] = stacks[c.compose_project] || []).push(c);
+                    } else {
+                        standalone.push(c);
+                    }
+                });
+
+                return `
+                    <details open>
+                        <summary>🖥️ <strong>${escapeHtml(host.name)}</strong> <small>${hostContainers.filter(c => c.state === 'running').length}/${hostContainers.length} running${host.enabled ? '' : ', disabled'}</small></summary>
+                        ${Object.keys(stacks).sort().map(stack => `
+                            <details>
+                                <summary>📚 ${escapeHtml(stack)} <small>${stacks[stack].filter(c => c.state === 'running').length}/${stacks[stack].length} running</small></summary>
+                                <ul>${stacks[stack].map(renderContainer).join('')}</ul>
+                            </details>
+                        `).join('')}
+                        ${standalone.length ? `<ul>${standalone.map(renderContainer).join('')}</ul>` : ''}
+                    </details>
+                `;
+            }).join('')}
+        </div>
+    `;
 }
 
-function filterHistory() {
-    const searchTerm = document.getElementById('searchInput')?.value.toLowerCase() || '';
-    const hostFilter = document.getElementById('hostFilter')?.value || '';
+// Set the pull-through cache used for Docker Hub images on a host, validating it serves images first
+async function configureRegistryMirror(hostId) {
+    const host = hosts.find(h => h.id === hostId);
+    if (!host) return;
 
-    if (!lifecycles || lifecycles.length === 0) {
-        return;
+    const input = prompt(
+        `Registry mirror for "${host.name}" (e.g. harbor.local/dockerhub).\nDocker Hub images pulled during updates go through it. Leave empty to pull directly.`,
+        host.registry_mirror || ''
+    );
+    if (input === null) return;
+    const mirror = input.trim();
+
+    if (mirror) {
+        try {
+            const response = await fetch(`/api/hosts/${hostId}/registry-mirror/test`, {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ mirror })
+            });
+            const result = await response.json();
+            if (!response.ok) {
+                showNotification('Error: ' + (result.error || 'Failed to test mirror'), 'error');
+                return;
+            }
+            if (!result.available &&
+                !confirm(`The mirror did not serve ${result.reference}:\n${result.error}\n\nSave it anyway? Pulls fall back to the upstream registry when the mirror fails.`)) {
+                return;
+            }
+        } catch (error) {
+            showNotification('Error: ' + error.message, 'error');
+            return;
+        }
     }
 
-    // Apply filters to lifecycles
-    const filteredLifecycles = lifecycles.filter(lifecycle => {
-        const matchesSearch = searchTerm === '' ||
-            lifecycle.container_name.toLowerCase().includes(searchTerm) ||
-            lifecycle.image.toLowerCase().includes(searchTerm) ||
-            lifecycle.host_name.toLowerCase().includes(searchTerm);
+    try {
+        const response = await fetch(`/api/hosts/${hostId}`, {
+            method: 'PUT',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ ...host, registry_mirror: mirror })
+        });
 
-        const matchesHost = hostFilter === '' || lifecycle.host_id.toString() === hostFilter;
+        if (response.ok) {
+            showNotification(mirror ? 'Registry mirror saved' : 'Registry mirror removed', 'success');
+            loadData();
+        } else {
+            const error = await response.json();
+            showNotification('Error: ' + (error.error || 'Failed to update host'), 'error');
+        }
+    } catch (error) {
+        showNotification('Error: ' + error.message, 'error');
+    }
+}
 
-        return matchesSearch && matchesHost;
-    });
+// Show the latest CIS Docker Benchmark audit and audit history of a host
+async function showComplianceAudit(hostId) {
+    const host = hosts.find(h => h.id === hostId);
+    if (!host) return;
 
-    renderContainerHistory(filteredLifecycles);
-    updateHistoryStats(filteredLifecycles);
+    document.getElementById('complianceModalTitle').textContent = `🛡️ CIS Docker Benchmark - ${host.name}`;
+    document.getElementById('runComplianceAuditBtn').onclick = () => runComplianceAudit(hostId);
+    document.getElementById('complianceModal').classList.add('show');
+    await loadComplianceAudit(hostId);
 }
 
-// Modal Functions
-function closeLogModal() {
-    document.getElementById('logModal').classList.remove('show');
-    clearLogSearch();
+function closeComplianceModal() {
+    document.getElementById('complianceModal').classList.remove('show');
 }
 
-// Log Search Functionality
-let logSearchMatches = [];
-let currentMatchIndex = -1;
-let originalLogContent = '';
+// Show a host's reachability timeline: one heartbeat per scan and its downtimes
+async function showHostUptime(hostId) {
+    const host = hosts.find(h => h.id === hostId);
+    if (!host) return;
 
-function searchLogs(direction) {
-    const searchInput = document.getElementById('logSearchInput');
-    const logContent = document.getElementById('logContent');
-    const searchStatus = document.getElementById('logSearchStatus');
-    const searchTerm = searchInput.value.trim();
+    document.getElementById('hostUptimeModalTitle').textContent = `📶 Uptime - ${host.name}`;
+    const windowSelect = document.getElementById('hostUptimeWindow');
+    windowSelect.onchange = () => loadHostUptime(hostId, windowSelect.value);
+    document.getElementById('hostUptimeModal').classList.add('show');
+    await loadHostUptime(hostId, windowSelect.value);
+}
 
-    if (!searchTerm) {
-        searchStatus.textContent = '';
-        return;
-    }
+function closeHostUptimeModal() {
+    document.getElementById('hostUptimeModal').classList.remove('show');
+}
 
-    // If this is a new search, find all matches
-    if (originalLogContent === '') {
-        originalLogContent = logContent.textContent;
+async function loadHostUptime(hostId, hours) {
+    const content = document.getElementById('hostUptimeContent');
+    content.innerHTML = '<div class="loading">Loading uptime...</div>';
+
+    try {
+        const response = await fetch(`/api/hosts/${hostId}/uptime?hours=${hours}`);
+        if (!response.ok) {
+            throw new Error(`HTTP ${response.status}`);
+        }
+        const uptime = await response.json();
+
+        const heartbeats = uptime.heartbeats || [];
+        const downtimes = uptime.downtimes || [];
+        const timeline = heartbeats.length === 0
+            ? '<p class="empty-message">No heartbeats in this window yet. Every periodic scan adds one.</p>'
+            : `<div class="uptime-timeline">${heartbeats.map(hb => `
+                <span class="uptime-beat ${hb.online ? 'up' : 'down'}"
+                      title="${escapeAttr(formatDateTime(hb.timestamp) + (hb.online ? ' - online' : ' - ' + (hb.error || 'offline')))}"></span>
+            `).join('')}</div>`;
+
+        content.innerHTML = `
+            <p>
+                <strong>${uptime.uptime_percent.toFixed(2)}%</strong> uptime
+                ${uptime.down ? '<span class="badge badge-error">Down</span>' : '<span class="badge badge-success">Up</span>'}
+                <small>${heartbeats.length} heartbeats, ${downtimes.length} downtime(s)</small>
+            </p>
+            ${timeline}
+            <h4>Downtimes</h4>
+            ${downtimes.length === 0 ? '<p class="empty-message">No downtime in this window.</p>' : `
+                <table class="vuln-table">
+                    <thead>
+                        <tr><th>Down since</th><th>Recovered</th><th>Duration</th><th>Error</th></tr>
+                    </thead>
+                    <tbody>
+                        ${downtimes.slice().reverse().map(d => `
+                            <tr>
+                                <td>${formatDateTime(d.started_at)}</td>
+                                <td>${d.ended_at ? formatDateTime(d.ended_at) : '<span class="badge badge-error">Ongoing</span>'}</td>
+                                <td>${formatDowntimeDuration(d)}</td>
+                                <td><small>${escapeHtml(d.error || '-')}</small></td>
+                            </tr>
+                        `).join('')}
+                    </tbody>
+                </table>
+            `}
+        `;
+    } catch (error) {
+        console.error('Error loading host uptime:', error);
+        content.innerHTML = `<div class="error">Failed to load uptime: ${escapeHtml(error.message)}</div>`;
     }
+}
 
-    // Find all matches (case-insensitive)
-    const lines = originalLogContent.split('\n');
-    logSearchMatches = [];
+function formatDowntimeDuration(downtime) {
+    const end = downtime.ended_at ? new Date(downtime.ended_at) : new Date();
+    const minutes = Math.max(0, Math.round((end - new Date(downtime.started_at)) / 60000));
+    if (minutes < 60) return `${minutes}m`;
+    if (minutes < 1440) return `${Math.floor(minutes / 60)}h ${minutes % 60}m`;
+    return `${Math.floor(minutes / 1440)}d ${Math.floor((minutes % 1440) / 60)}h`;
+}
 
-    lines.forEach((line, lineIndex) => {
-        const lowerLine = line.toLowerCase();
-        const lowerTerm = searchTerm.toLowerCase();
-        let index = 0;
+async function loadComplianceAudit(hostId) {
+    const content = document.getElementById('complianceAuditContent');
+    const historyContent = document.getElementById('complianceHistoryContent');
+    content.innerHTML = '<div class="loading">Loading audit...</div>';
+    historyContent.innerHTML = '';
 
-        while ((index = lowerLine.indexOf(lowerTerm, index)) !== -1) {
-            logSearchMatches.push({ lineIndex, charIndex: index, length: searchTerm.length });
-            index += searchTerm.length;
+    try {
+        const response = await fetch(`/api/compliance/hosts/${hostId}/history`);
+        if (!response.ok) {
+            throw new Error(`HTTP ${response.status}`);
         }
-    });
+        const audits = await response.json();
 
-    if (logSearchMatches.length === 0) {
-        searchStatus.textContent = 'No matches';
-        logContent.innerHTML = escapeHtml(originalLogContent);
-        return;
-    }
+        if (audits.length === 0) {
+            content.innerHTML = '<p class="empty-message">This host has not been audited yet. Click "Run Audit" to check it now.</p>';
+            return;
+        }
 
-    // Navigate through matches
-    if (direction === 'next') {
-        currentMatchIndex = (currentMatchIndex + 1) % logSearchMatches.length;
-    } else if (direction === 'prev') {
-        currentMatchIndex = currentMatchIndex <= 0 ? logSearchMatches.length - 1 : currentMatchIndex - 1;
-    } else {
-        currentMatchIndex = 0;
+        renderComplianceAudit(audits[0]);
+        historyContent.innerHTML = `
+            <table class="vuln-table">
+                <thead>
+                    <tr><th>Audited</th><th>Passed</th><th>Failed</th><th>Skipped</th></tr>
+                </thead>
+                <tbody>
+                    ${audits.map(a => `
+                        <tr>
+                            <td>${formatDateTime(a.audited_at)}</td>
+                            <td>${a.passed}</td>
+                            <td>${a.failed}</td>
+                            <td>${a.skipped}</td>
+                        </tr>
+                    `).join('')}
+                </tbody>
+            </table>
+        `;
+    } catch (error) {
+        console.error('Error loading compliance audit:', error);
+        content.innerHTML = `<div class="error">Failed to load audit: ${escapeHtml(error.message)}</div>`;
     }
+}
 
-    // Update status
-    searchStatus.textContent = `${currentMatchIndex + 1} of ${logSearchMatches.length}`;
-
-    // Highlight all matches and mark current one
-    highlightMatches(lines, searchTerm);
+function renderComplianceAudit(audit) {
+    const statusBadge = {
+        'pass': '<span class="badge badge-success">Pass</span>',
+        'fail': '<span class="badge badge-error">Fail</span>',
+        'skip': '<span class="badge badge-secondary">Skip</span>'
+    };
 
-    // Scroll to current match
-    scrollToCurrentMatch();
+    document.getElementById('complianceAuditContent').innerHTML = `
+        <p>
+            <strong>${escapeHtml(audit.benchmark)}</strong> &middot; audited ${formatDate(audit.audited_at)} &middot;
+            ${audit.passed} passed, ${audit.failed} failed, ${audit.skipped} skipped
+        </p>
+        <table class="vuln-table">
+            <thead>
+                <tr><th>Section</th><th>Recommendation</th><th>Status</th><th>Detail</th></tr>
+            </thead>
+            <tbody>
+                ${audit.checks.map(c => `
+                    <tr>
+                        <td>${escapeHtml(c.id)}</td>
+                        <td>${escapeHtml(c.title)}</td>
+                        <td>${statusBadge[c.status] || escapeHtml(c.status)}</td>
+                        <td>${escapeHtml(c.detail || '')}</td>
+                    </tr>
+                `).join('')}
+            </tbody>
+        </table>
+    `;
 }
 
-function highlightMatches(lines, searchTerm) {
-    const logContent = document.getElementById('logContent');
-    const lowerTerm = searchTerm.toLowerCase();
-
-    let html = '';
-    let globalMatchIndex = 0;
+async function runComplianceAudit(hostId) {
+    const button = document.getElementById('runComplianceAuditBtn');
+    button.disabled = true;
+    button.textContent = 'Auditing...';
 
-    lines.forEach((line, lineIndex) => {
-        let highlightedLine = '';
-        let lastIndex = 0;
-        const lowerLine = line.toLowerCase();
+    try {
+        const response = await fetch(`/api/compliance/hosts/${hostId}/audit`, { method: 'POST' });
+        if (!response.ok) {
+            const error = await response.json();
+            throw new Error(error.error || 'Audit failed');
+        }
+        showNotification('Compliance audit completed', 'success');
+        await loadComplianceAudit(hostId);
+    } catch (error) {
+        showNotification('Error: ' + error.message, 'error');
+    } finally {
+        button.disabled = false;
+        button.textContent = 'Run Audit';
+    }
+}
 
-        let index = 0;
-        while ((index = lowerLine.indexOf(lowerTerm, index)) !== -1) {
-            // Add text before match
-            highlightedLine += escapeHtml(line.substring(lastIndex, index));
+async function deleteHost(hostId, hostName) {
+    if (!confirm(`Are you sure you want to delete host "${hostName}"?\n\nThis will remove all associated container history.`)) {
+        return;
+    }
 
-            // Add highlighted match
-            const isCurrent = globalMatchIndex === currentMatchIndex;
-            const matchClass = isCurrent ? 'current-match' : '';
-            const matchId = isCurrent ? ' id="current-log-match"' : '';
-            highlightedLine += `<mark class="${matchClass}"${matchId}>${escapeHtml(line.substring(index, index + searchTerm.length))}</mark>`;
+    try {
+        const response = await fetch(`/api/hosts/${hostId}`, {
+            method: 'DELETE'
+        });
 
-            lastIndex = index + searchTerm.length;
-            index = lastIndex;
-            globalMatchIndex++;
+        if (response.ok) {
+            showNotification(`Host "${hostName}" deleted successfully`, 'success');
+            loadData();
+        } else {
+            const error = await response.json();
+            showNotification('Error: ' + (error.error || 'Failed to delete host'), 'error');
         }
+    } catch (error) {
+        showNotification('Error: ' + error.message, 'error');
+    }
+}
 
-        // Add remaining text
-        highlightedLine += escapeHtml(line.substring(lastIndex));
-        html += highlightedLine + '\n';
-    });
+function updateStats() {
+    const safeHosts = hosts || [];
+    const safeContainers = containers || [];
+    const safeActivities = activities || [];
 
-    logContent.innerHTML = html;
-}
+    document.getElementById('totalHosts').textContent = safeHosts.length;
+    document.getElementById('totalContainers').textContent = safeContainers.length;
 
-function scrollToCurrentMatch() {
-    const currentMatch = document.getElementById('current-log-match');
-    if (currentMatch) {
-        currentMatch.scrollIntoView({ behavior: 'smooth', block: 'center' });
+    const running = safeContainers.filter(c => c.state === 'running').length;
+    document.getElementById('runningContainers').textContent = running;
+
+    // Find most recent scan activity
+    const scanActivities = safeActivities.filter(a => a.type === 'scan');
+    if (scanActivities.length > 0) {
+        const lastScan = new Date(scanActivities[0].timestamp);
+        document.getElementById('lastScan').textContent = formatTimeAgo(lastScan);
+    } else {
+        document.getElementById('lastScan').textContent = 'Never';
     }
+
+    // Update vulnerability stats (if available)
+    updateVulnerabilityStats();
 }
 
-function clearLogSearch() {
-    const searchInput = document.getElementById('logSearchInput');
-    const logContent = document.getElementById('logContent');
-    const searchStatus = document.getElementById('logSearchStatus');
+// Update vulnerability statistics in sidebar
+async function updateVulnerabilityStats() {
+    const criticalElem = document.getElementById('criticalVulns');
 
-    searchInput.value = '';
-    searchStatus.textContent = '';
+    if (!criticalElem) return;
 
-    if (originalLogContent) {
-        logContent.textContent = originalLogContent;
+    // If we don't have summary yet, fetch it
+    if (!vulnerabilitySummary) {
+        try {
+            vulnerabilitySummary = await loadVulnerabilitySummary();
+        } catch (error) {
+            console.error('Error loading vulnerability summary:', error);
+            criticalElem.textContent = '-';
+            return;
+        }
     }
 
-    logSearchMatches = [];
-    currentMatchIndex = -1;
-    originalLogContent = '';
-}
+    if (vulnerabilitySummary && vulnerabilitySummary.summary) {
+        const s = vulnerabilitySummary.summary;
+        const critical = s.severity_counts?.critical || 0;
 
-// Add event listener for Enter key in search input
-document.addEventListener('DOMContentLoaded', function() {
-    const searchInput = document.getElementById('logSearchInput');
-    if (searchInput) {
-        searchInput.addEventListener('keydown', function(e) {
-            if (e.key === 'Enter') {
-                e.preventDefault();
-                searchLogs(e.shiftKey ? 'prev' : 'next');
-            } else if (e.key === 'Escape') {
-                clearLogSearch();
-            }
-        });
+        criticalElem.textContent = critical;
 
-        // Trigger new search when input changes
-        searchInput.addEventListener('input', function() {
-            originalLogContent = '';
-            currentMatchIndex = -1;
-            if (this.value.trim()) {
-                searchLogs('next');
-            } else {
-                clearLogSearch();
-            }
-        });
+        // Add visual indication for high counts
+        if (critical > 0) {
+            criticalElem.style.fontWeight = 'bold';
+        } else {
+            criticalElem.style.fontWeight = 'normal';
+        }
     }
-});
-
-function showConfirmDialog(title, message, onConfirm, type = 'warning') {
-    document.getElementById('confirmTitle').textContent = title;
-    document.getElementById('confirmMessage').innerHTML = message;
-    document.getElementById('confirmModal').classList.add('show');
+}
 
-    const okBtn = document.getElementById('confirmOkBtn');
-    okBtn.className = type === 'danger' ? 'btn btn-danger' : 'btn btn-warning';
+// Value of the site filter option for hosts without a site (site names are trimmed, so no site is a space)
+const UNASSIGNED_SITE = ' ';
 
-    okBtn.onclick = () => {
-        closeConfirmModal();
-        onConfirm();
-    };
+// Sorted names of the sites hosts belong to
+function siteNames() {
+    return [...new Set(hosts.map(h => h.site).filter(Boolean))].sort();
 }
 
-function closeConfirmModal() {
-    document.getElementById('confirmModal').classList.remove('show');
+// Whether a host belongs to the site selected in the site filter
+function hostInSiteFilter(hostId) {
+    const siteFilter = document.getElementById('siteFilter')?.value || '';
+    if (siteFilter === '') return true;
+    const host = hosts.find(h => h.id === hostId);
+    const site = host ? (host.site || '') : '';
+    return siteFilter === UNASSIGNED_SITE ? site === '' : site === siteFilter;
 }
 
-// Notification
-function showNotification(message, type = 'info') {
-    // Create notification element
-    const notification = document.createElement('div');
-    notification.className = `notification notification-${type}`;
-    notification.textContent = message;
+function updateHostFilter() {
+    // The site filter is only shown once hosts have been assigned to sites
+    const sites = siteNames();
+    const siteSelect = document.getElementById('siteFilter');
+    if (siteSelect) {
+        const currentSite = siteSelect.value;
+        siteSelect.innerHTML = '<option value="">All Sites</option>' +
+            sites.map(site => `<option value="${escapeAttr(site)}">${escapeHtml(site)}</option>`).join('') +
+            (hosts.some(h => !h.site) ? `<option value="${UNASSIGNED_SITE}">No site</option>` : '');
+        siteSelect.value = currentSite;
+        if (siteSelect.value !== currentSite) siteSelect.value = '';
+        siteSelect.style.display = sites.length > 0 ? 'block' : 'none';
+    }
 
-    // Add to page
-    document.body.appendChild(notification);
+    const siteOptions = document.getElementById('siteOptions');
+    if (siteOptions) {
+        siteOptions.innerHTML = sites.map(site => `<option value="${escapeAttr(site)}">`).join('');
+    }
 
-    // Show with animation
-    setTimeout(() => notification.classList.add('show'), 10);
+    // Update both the main host filter and the monitoring tab host filter
+    const selects = ['hostFilter', 'monitoringHostFilter'];
 
-    // Remove after 5 seconds
-    setTimeout(() => {
-        notification.classList.remove('show');
-        setTimeout(() => notification.remove(), 300);
-    }, 5000);
-}
+    selects.forEach(selectId => {
+        const select = document.getElementById(selectId);
+        if (select) {
+            const currentValue = select.value;
 
-// Formatting Helpers
-function formatPorts(ports) {
-    if (!ports || ports.length === 0) return '-';
+            select.innerHTML = '<option value="">All Hosts</option>' +
+                hosts.filter(host => hostInSiteFilter(host.id))
+                    .map(host => `<option value="${host.id}">${escapeHtml(host.name)}</option>`).join('');
 
-    return ports
-        .filter(p => p.public_port > 0)
-        .map(p => `${p.public_port}:${p.private_port}/${p.type}`)
-        .join('<br>') || '-';
+            select.value = currentValue;
+            if (select.value !== currentValue) select.value = '';
+        }
+    });
 }
 
-function formatDate(dateStr) {
-    if (!dateStr) return '-';
+// Filtering
+function filterContainers() {
+    const searchTerm = document.getElementById('searchInput')?.value.toLowerCase() || '';
+    const hostFilter = document.getElementById('hostFilter')?.value || '';
+    const stateFilter = document.getElementById('stateFilter')?.value || '';
 
-    const date = new Date(dateStr);
+    const filtered = containers.filter(container => {
+        const matchesSearch = searchTerm === '' ||
+            container.name.toLowerCase().includes(searchTerm) ||
+            container.image.toLowerCase().includes(searchTerm) ||
+            container.host_name.toLowerCase().includes(searchTerm);
 
-    // Check if date is valid
-    if (isNaN(date.getTime())) return '-';
+        const matchesHost = (hostFilter === '' || container.host_id.toString() === hostFilter) && hostInSiteFilter(container.host_id);
+        const matchesState = stateFilter === '' || container.state === stateFilter;
 
-    // Check if date is zero/epoch or in the far future/past (invalid)
-    const year = date.getFullYear();
-    if (year < 1970 || year > 2100) return '-';
+        return matchesSearch && matchesHost && matchesState;
+    });
 
-    const now = new Date();
-    const diffMs = now - date;
+    renderContainers(filtered);
 
-    // If date is in the future, return '-'
-    if (diffMs < 0) return '-';
+    // Load vulnerability badges asynchronously
+    loadAllVulnerabilityBadges();
+}
 
-    const diffDays = Math.floor(diffMs / (1000 * 60 * 60 * 24));
+function filterImages() {
+    const searchTerm = document.getElementById('searchInput')?.value.toLowerCase() || '';
+    const hostFilter = document.getElementById('hostFilter')?.value || '';
 
-    if (diffDays === 0) return 'Today';
-    if (diffDays === 1) return 'Yesterday';
-    if (diffDays < 7) return `${diffDays} days ago`;
-    if (diffDays < 30) return `${Math.floor(diffDays / 7)} weeks ago`;
-    if (diffDays < 365) return `${Math.floor(diffDays / 30)} months ago`;
-    return `${Math.floor(diffDays / 365)} years ago`;
-}
+    if (!images || Object.keys(images).length === 0) {
+        return;
+    }
 
-function formatDateTime(dateStr) {
-    const date = new Date(dateStr);
-    return date.toLocaleString();
-}
+    // Filter images data structure
+    const filteredImages = {};
+    for (const [hostName, hostData] of Object.entries(images)) {
+        const hostId = hostData.host_id;
+        const matchesHost = (hostFilter === '' || hostId?.toString() === hostFilter) && hostInSiteFilter(hostId);
 
-function formatTimeAgo(date) {
-    const now = new Date();
-    const diffMs = now - date;
-    const diffMins = Math.floor(diffMs / (1000 * 60));
+        if (matchesHost) {
+            const filteredHostImages = hostData.images.filter(img => {
+                const matchesSearch = searchTerm === '' ||
+                    (img.repository && img.repository.toLowerCase().includes(searchTerm)) ||
+                    (img.tag && img.tag.toLowerCase().includes(searchTerm)) ||
+                    (img.id && img.id.toLowerCase().includes(searchTerm));
 
-    if (diffMins < 1) return 'Just now';
-    if (diffMins === 1) return '1 min ago';
-    if (diffMins < 60) return `${diffMins} mins ago`;
+                return matchesSearch;
+            });
 
-    const diffHours = Math.floor(diffMins / 60);
-    if (diffHours === 1) return '1 hour ago';
-    if (diffHours < 24) return `${diffHours} hours ago`;
+            if (filteredHostImages.length > 0) {
+                filteredImages[hostName] = {
+                    ...hostData,
+                    images: filteredHostImages
+                };
+            }
+        }
+    }
 
-    const diffDays = Math.floor(diffHours / 24);
-    if (diffDays === 1) return '1 day ago';
-    return `${diffDays} days ago`;
+    renderImages(filteredImages);
 }
 
-function escapeHtml(text) {
-    const div = document.createElement('div');
-    div.textContent = text;
-    return div.innerHTML;
-}
+function filterMonitoring() {
+    const searchTerm = document.getElementById('searchInput')?.value.toLowerCase() || '';
+    const hostFilter = document.getElementById('hostFilter')?.value || '';
 
-function escapeAttr(text) {
-    return text.replace(/'/g, "\\'").replace(/"/g, '&quot;');
-}
+    // Get running containers from enabled hosts
+    const enabledHostIds = new Set(hosts.filter(h => h.enabled).map(h => h.id));
+    let runningContainers = containers.filter(c =>
+        c.state === 'running' && enabledHostIds.has(c.host_id)
+    );
 
-function extractImageTag(imageName, allTags) {
-    // If we have all tags for this image, show them (excluding the one already displayed)
-    // This helps when an image is tagged as both 'latest' and a version number
-    if (allTags && allTags.length > 0) {
-        // Extract just the tags from full image names (remove registry/repo parts)
-        const tags = allTags.map(fullTag => {
-            const parts = fullTag.split(':');
-            return parts[parts.length - 1];
-        }).filter(tag => tag && tag !== '<none>');
+    // Apply filters
+    runningContainers = runningContainers.filter(container => {
+        const matchesSearch = searchTerm === '' ||
+            container.name.toLowerCase().includes(searchTerm) ||
+            container.image.toLowerCase().includes(searchTerm) ||
+            container.host_name.toLowerCase().includes(searchTerm);
 
-        // Remove duplicates
-        const uniqueTags = [...new Set(tags)];
+        const matchesHost = (hostFilter === '' || container.host_id.toString() === hostFilter) && hostInSiteFilter(container.host_id);
 
-        // If we have multiple tags, prioritize showing version number over 'latest'
-        if (uniqueTags.length > 1) {
-            // Filter out 'latest' if we have other tags
-            const nonLatestTags = uniqueTags.filter(t => t !== 'latest');
-            if (nonLatestTags.length > 0) {
-                // Show non-latest tags first, then indicate it's also 'latest'
-                return nonLatestTags.join(', ') + (uniqueTags.includes('latest') ? ' (latest)' : '');
-            }
-            return uniqueTags.join(', ');
-        } else if (uniqueTags.length === 1) {
-            return uniqueTags[0];
-        }
-    }
+        return matchesSearch && matchesHost;
+    });
 
-    // Fallback to extracting tag from the image name
-    if (!imageName || !imageName.includes(':')) {
-        return 'latest';
-    }
-    const parts = imageName.split(':');
-    return parts[parts.length - 1]; // Get last part after colon
+    renderMonitoringGrid(runningContainers);
 }
 
-// Add Agent Host Modal Functions
-
-function openAddAgentModal() {
-    console.log('Opening add agent modal...');
-    const modal = document.getElementById('addAgentModal');
-    const form = document.getElementById('addAgentForm');
-    const result = document.getElementById('agentTestResult');
+function filterHistory() {
+    const searchTerm = document.getElementById('searchInput')?.value.toLowerCase() || '';
+    const hostFilter = document.getElementById('hostFilter')?.value || '';
 
-    if (!modal) {
-        console.error('Modal element not found!');
+    if (!lifecycles || lifecycles.length === 0) {
         return;
     }
 
-    modal.classList.add('show');
-    form.reset();
-    result.style.display = 'none';
-    console.log('Modal opened');
+    // Apply filters to lifecycles
+    const filteredLifecycles = lifecycles.filter(lifecycle => {
+        const matchesSearch = searchTerm === '' ||
+            lifecycle.container_name.toLowerCase().includes(searchTerm) ||
+            lifecycle.image.toLowerCase().includes(searchTerm) ||
+            lifecycle.host_name.toLowerCase().includes(searchTerm);
+
+        const matchesHost = (hostFilter === '' || lifecycle.host_id.toString() === hostFilter) && hostInSiteFilter(lifecycle.host_id);
+
+        return matchesSearch && matchesHost;
+    });
+
+    renderContainerHistory(filteredLifecycles);
+    updateHistoryStats(filteredLifecycles);
 }
 
-function closeAddAgentModal() {
-    const modal = document.getElementById('addAgentModal');
-    if (modal) {
-        modal.classList.remove('show');
-    }
+// Modal Functions
+function closeLogModal() {
+    document.getElementById('logModal').classList.remove('show');
+    clearLogSearch();
 }
 
-async function testAgentConnection() {
-    const address = document.getElementById('agentAddress').value;
-    const token = document.getElementById('agentToken').value;
-    const testBtn = document.getElementById('testAgentBtn');
-    const result = document.getElementById('agentTestResult');
+// Log Search Functionality
+let logSearchMatches = [];
+let currentMatchIndex = -1;
+let originalLogContent = '';
 
-    if (!address || !token) {
-        result.className = 'alert alert-error';
-        result.textContent = 'Please enter both address and token';
-        result.style.display = 'block';
+function searchLogs(direction) {
+    const searchInput = document.getElementById('logSearchInput');
+    const logContent = document.getElementById('logContent');
+    const searchStatus = document.getElementById('logSearchStatus');
+    const searchTerm = searchInput.value.trim();
+
+    if (!searchTerm) {
+        searchStatus.textContent = '';
         return;
     }
 
-    testBtn.disabled = true;
-    testBtn.textContent = 'Testing...';
+    // If this is a new search, find all matches
+    if (originalLogContent === '') {
+        originalLogContent = logContent.textContent;
+    }
 
-    try {
-        const response = await fetch('/api/hosts/agent/test', {
-            method: 'POST',
-            headers: { 'Content-Type': 'application/json' },
-            body: JSON.stringify({ address, agent_token: token })
-        });
+    // Find all matches (case-insensitive)
+    const lines = originalLogContent.split('\n');
+    logSearchMatches = [];
 
-        const data = await response.json();
+    lines.forEach((line, lineIndex) => {
+        const lowerLine = line.toLowerCase();
+        const lowerTerm = searchTerm.toLowerCase();
+        let index = 0;
 
-        if (data.success) {
-            result.className = 'alert alert-success';
-            result.textContent = '✓ Connection successful! Agent is reachable.';
-        } else {
-            result.className = 'alert alert-error';
-            result.textContent = '✗ Connection failed: ' + (data.error || 'Unknown error');
+        while ((index = lowerLine.indexOf(lowerTerm, index)) !== -1) {
+            logSearchMatches.push({ lineIndex, charIndex: index, length: searchTerm.length });
+            index += searchTerm.length;
         }
-        result.style.display = 'block';
-    } catch (error) {
-        result.className = 'alert alert-error';
-        result.textContent = '✗ Error: ' + error.message;
-        result.style.display = 'block';
-    } finally {
-        testBtn.disabled = false;
-        testBtn.textContent = 'Test Connection';
-    }
-}
-
-async function handleAddAgent(e) {
-    e.preventDefault();
-
-    const addressInput = document.getElementById('agentAddress');
-    const address = addressInput.value.trim();
+    });
 
-    // Validate address format
-    const validProtocols = /^(https?|agent):\/\/.+/;
-    if (!validProtocols.test(address)) {
-        const result = document.getElementById('agentTestResult');
-        result.className = 'alert alert-error';
-        result.textContent = 'Invalid address format. Must start with http://, https://, or agent:// followed by hostname/IP and optional port (e.g., http://192.168.1.100:9876)';
-        result.style.display = 'block';
-        addressInput.focus();
+    if (logSearchMatches.length === 0) {
+        searchStatus.textContent = 'No matches';
+        logContent.innerHTML = escapeHtml(originalLogContent);
         return;
     }
 
-    const data = {
-        name: document.getElementById('agentName').value,
-        address: address,
-        agent_token: document.getElementById('agentToken').value,
-        description: document.getElementById('agentDescription').value,
-        collect_stats: document.getElementById('agentCollectStats').checked
-    };
+    // Navigate through matches
+    if (direction === 'next') {
+        currentMatchIndex = (currentMatchIndex + 1) % logSearchMatches.length;
+    } else if (direction === 'prev') {
+        currentMatchIndex = currentMatchIndex <= 0 ? logSearchMatches.length - 1 : currentMatchIndex - 1;
+    } else {
+        currentMatchIndex = 0;
+    }
 
-    const saveBtn = document.getElementById('saveAgentBtn');
-    const result = document.getElementById('agentTestResult');
+    // Update status
+    searchStatus.textContent = `${currentMatchIndex + 1} of ${logSearchMatches.length}`;
 
-    saveBtn.disabled = true;
-    saveBtn.textContent = 'Adding...';
+    // Highlight all matches and mark current one
+    highlightMatches(lines, searchTerm);
 
-    try {
-        const response = await fetch('/api/hosts/agent', {
-            method: 'POST',
-            headers: { 'Content-Type': 'application/json' },
-            body: JSON.stringify(data)
-        });
+    // Scroll to current match
+    scrollToCurrentMatch();
+}
 
-        if (response.ok) {
-            showNotification('Agent host added successfully!', 'success');
-            closeAddAgentModal();
-            loadData(); // Refresh the data
-        } else {
-            const error = await response.json();
-            result.className = 'alert alert-error';
-            result.textContent = 'Error: ' + (error.error || 'Failed to add agent');
-            result.style.display = 'block';
+function highlightMatches(lines, searchTerm) {
+    const logContent = document.getElementById('logContent');
+    const lowerTerm = searchTerm.toLowerCase();
+
+    let html = '';
+    let globalMatchIndex = 0;
+
+    lines.forEach((line, lineIndex) => {
+        let highlightedLine = '';
+        let lastIndex = 0;
+        const lowerLine = line.toLowerCase();
+
+        let index = 0;
+        while ((index = lowerLine.indexOf(lowerTerm, index)) !== -1) {
+            // Add text before match
+            highlightedLine += escapeHtml(line.substring(lastIndex, index));
+
+            // Add highlighted match
+            const isCurrent = globalMatchIndex === currentMatchIndex;
+            const matchClass = isCurrent ? 'current-match' : '';
+            const matchId = isCurrent ? ' id="current-log-match"' : '';
+            highlightedLine += `<mark class="${matchClass}"${matchId}>${escapeHtml(line.substring(index, index + searchTerm.length))}</mark>`;
+
+            lastIndex = index + searchTerm.length;
+            index = lastIndex;
+            globalMatchIndex++;
         }
-    } catch (error) {
-        result.className = 'alert alert-error';
-        result.textContent = 'Error: ' + error.message;
-        result.style.display = 'block';
-    } finally {
-        saveBtn.disabled = false;
-        saveBtn.textContent = 'Add Agent';
+
+        // Add remaining text
+        highlightedLine += escapeHtml(line.substring(lastIndex));
+        html += highlightedLine + '\n';
+    });
+
+    logContent.innerHTML = html;
+}
+
+function scrollToCurrentMatch() {
+    const currentMatch = document.getElementById('current-log-match');
+    if (currentMatch) {
+        currentMatch.scrollIntoView({ behavior: 'smooth', block: 'center' });
     }
 }
 
-// Settings Management
-async function loadTelemetrySettings() {
-    try {
-        // Load from new database-first settings API
-        const response = await fetch('/api/settings');
-        const settings = await response.json();
+function clearLogSearch() {
+    const searchInput = document.getElementById('logSearchInput');
+    const logContent = document.getElementById('logContent');
+    const searchStatus = document.getElementById('logSearchStatus');
 
-        const intervalHours = settings.telemetry?.interval_hours || 168;
-        const dropdown = document.getElementById('telemetryFrequency');
-        if (dropdown) {
-            dropdown.value = intervalHours.toString();
-            console.log('Loaded telemetry frequency from database:', intervalHours, 'hours');
-        }
-    } catch (error) {
-        console.error('Failed to load telemetry settings:', error);
+    searchInput.value = '';
+    searchStatus.textContent = '';
+
+    if (originalLogContent) {
+        logContent.textContent = originalLogContent;
     }
+
+    logSearchMatches = [];
+    currentMatchIndex = -1;
+    originalLogContent = '';
 }
 
-async function loadScannerSettings() {
-    try {
-        // Load from new database-first settings API
-        const response = await fetch('/api/settings');
-        const settings = await response.json();
+// Add event listener for Enter key in search input
+document.addEventListener('DOMContentLoaded', function() {
+    const searchInput = document.getElementById('logSearchInput');
+    if (searchInput) {
+        searchInput.addEventListener('keydown', function(e) {
+            if (e.key === 'Enter') {
+                e.preventDefault();
+                searchLogs(e.shiftKey ? 'prev' : 'next');
+            } else if (e.key === 'Escape') {
+                clearLogSearch();
+            }
+        });
 
-        const intervalSeconds = settings.scanner?.interval_seconds || 300;
-        const dropdown = document.getElementById('scanInterval');
-        if (dropdown) {
-            dropdown.value = intervalSeconds.toString();
-            console.log('Loaded scanner interval from database:', intervalSeconds, 'seconds');
-        }
-    } catch (error) {
-        console.error('Failed to load scanner settings:', error);
+        // Trigger new search when input changes
+        searchInput.addEventListener('input', function() {
+            originalLogContent = '';
+            currentMatchIndex = -1;
+            if (this.value.trim()) {
+                searchLogs('next');
+            } else {
+                clearLogSearch();
+            }
+        });
     }
-}
+});
 
-async function saveScanInterval() {
-    const status = document.getElementById('scanIntervalSaveStatus');
-    const intervalSeconds = parseInt(document.getElementById('scanInterval').value);
+function showConfirmDialog(title, message, onConfirm, type = 'warning') {
+    document.getElementById('confirmTitle').textContent = title;
+    document.getElementById('confirmMessage').innerHTML = message;
+    document.getElementById('confirmModal').classList.add('show');
 
-    status.textContent = 'Saving...';
-    status.className = 'save-status-inline saving';
+    const okBtn = document.getElementById('confirmOkBtn');
+    okBtn.className = type === 'danger' ? 'btn btn-danger' : 'btn btn-warning';
 
-    try {
-        // Load current settings first
-        const currentResponse = await fetchWithAuth('/api/settings');
-        const currentSettings = await currentResponse.json();
+    okBtn.onclick = () => {
+        closeConfirmModal();
+        onConfirm();
+    };
+}
 
-        // Update only the scanner interval, preserve other settings
-        const updatedSettings = {
-            scanner: {
-                interval_seconds: intervalSeconds,
-                timeout_seconds: currentSettings.scanner?.timeout_seconds || 30
-            },
-            telemetry: {
-                interval_hours: currentSettings.telemetry?.interval_hours || 168
-            },
-            notification: currentSettings.notification || {
-                rate_limit_max: 100,
-                rate_limit_batch_interval: 600,
-                threshold_duration: 120,
-                cooldown_period: 300
-            },
-            ui: currentSettings.ui || {
-                card_design: 'material'
-            }
-        };
+function closeConfirmModal() {
+    document.getElementById('confirmModal').classList.remove('show');
+}
 
-        const response = await fetchWithAuth('/api/settings', {
-            method: 'PUT',
-            headers: { 'Content-Type': 'application/json' },
-            body: JSON.stringify(updatedSettings)
-        });
+// Notification
+function showNotification(message, type = 'info') {
+    // Create notification element
+    const notification = document.createElement('div');
+    notification.className = `notification notification-${type}`;
+    notification.textContent = message;
 
-        if (response.ok) {
-            status.textContent = '✓ Saved & Reloaded';
-            status.className = 'save-status-inline success';
-            showNotification('Scan interval updated successfully (hot-reloaded)', 'success');
-        } else {
-            const error = await response.json();
-            status.textContent = '✗ Failed';
-            status.className = 'save-status-inline error';
-            showNotification('Failed to update scan interval: ' + (error.error || 'Unknown error'), 'error');
-        }
-    } catch (error) {
-        status.textContent = '✗ Error';
-        status.className = 'save-status-inline error';
-        console.error('Failed to save scan interval:', error);
-    }
+    // Add to page
+    document.body.appendChild(notification);
+
+    // Show with animation
+    setTimeout(() => notification.classList.add('show'), 10);
 
+    // Remove after 5 seconds
     setTimeout(() => {
-        status.textContent = '';
-        status.className = 'save-status-inline';
-    }, 3000);
+        notification.classList.remove('show');
+        setTimeout(() => notification.remove(), 300);
+    }, 5000);
 }
 
-async function saveTelemetryFrequency() {
-    const status = document.getElementById('frequencySaveStatus');
-    const intervalHours = parseInt(document.getElementById('telemetryFrequency').value);
+// Formatting Helpers
+function formatBytes(bytes) {
+    if (!bytes) return '0 B';
+    const units = ['B', 'KB', 'MB', 'GB', 'TB'];
+    const i = Math.min(Math.floor(Math.log(bytes) / Math.log(1024)), units.length - 1);
+    return (bytes / Math.pow(1024, i)).toFixed(i === 0 ? 0 : 1) + ' ' + units[i];
+}
 
-    status.textContent = 'Saving...';
-    status.className = 'save-status-inline saving';
+// Render the Uptime Kuma status and 24h availability of a monitored container
+function renderUptimeBadge(cont) {
+    if (!cont.uptime) return '';
 
-    try {
-        // Load current settings first
-        const currentResponse = await fetchWithAuth('/api/settings');
-        const currentSettings = await currentResponse.json();
+    const uptime = cont.uptime;
+    const title = `Uptime Kuma: ${uptime.status} · ${uptime.url}` +
+        (uptime.response_time_ms > 0 ? ` · ${Math.round(uptime.response_time_ms)}ms` : '') +
+        ` · checked ${formatDateTime(uptime.checked_at)}`;
 
-        // Update only the telemetry interval, preserve other settings
-        const updatedSettings = {
-            scanner: {
-                interval_seconds: currentSettings.scanner?.interval_seconds || 300,
-                timeout_seconds: currentSettings.scanner?.timeout_seconds || 30
-            },
-            telemetry: {
-                interval_hours: intervalHours
-            },
-            notification: currentSettings.notification || {
-                rate_limit_max: 100,
-                rate_limit_batch_interval: 600,
-                threshold_duration: 120,
-                cooldown_period: 300
-            },
-            ui: currentSettings.ui || {
-                card_design: 'material'
-            }
-        };
+    return `<span class="uptime-badge uptime-${escapeAttr(uptime.status)}" title="${escapeAttr(title)}">● ${uptime.availability.toFixed(1)}% up</span>`;
+}
 
-        const response = await fetchWithAuth('/api/settings', {
-            method: 'PUT',
-            headers: { 'Content-Type': 'application/json' },
-            body: JSON.stringify(updatedSettings)
-        });
+// Badge for containers whose stats read as zero scan after scan: a collection problem, not an idle container
+function renderZeroStatsBadge(cont) {
+    if (!cont.zero_stats) return '';
+    return ` <span class="badge badge-warning" title="The last scans all reported 0 MB memory usage and limit. Stats collection isn't working for this container (e.g. the memory cgroup can't be read); run an agent on the host for its cgroup fallback.">⚠ No stats</span>`;
+}
 
-        if (response.ok) {
-            status.textContent = '✓ Saved & Reloaded';
-            status.className = 'save-status-inline success';
-            showNotification('Telemetry frequency updated successfully (hot-reloaded)', 'success');
-        } else {
-            const error = await response.json();
-            status.textContent = '✗ Failed';
-            status.className = 'save-status-inline error';
-            showNotification('Failed to update telemetry frequency: ' + (error.error || 'Unknown error'), 'error');
-        }
-    } catch (error) {
-        status.textContent = '✗ Error';
-        status.className = 'save-status-inline error';
-        console.error('Failed to save telemetry frequency:', error);
-    }
+// Icon and links from the container's Homepage, Traefik and image labels
+function renderServiceLinks(cont) {
+    const service = cont.service;
+    if (!service) return '';
+    const link = (href, text, title) => /^https?:\/\//.test(href)
+        ? `<a href="${escapeAttr(href)}" target="_blank" rel="noopener noreferrer" title="${escapeAttr(title)}" onclick="event.stopPropagation()">${text}</a>`
+        : '';
+    const links = [
+        service.icon_url ? `<img class="service-icon" src="${escapeAttr(service.icon_url)}" alt="" loading="lazy">` : '',
+        service.url ? link(service.url, `🔗 ${escapeHtml(service.url.replace(/^https?:\/\//, ''))}`, service.description || service.url) : '',
+        service.docs_url ? link(service.docs_url, '📖', 'Documentation') : '',
+        service.source_url ? link(service.source_url, '💻', 'Source') : ''
+    ].filter(Boolean);
+    return links.length ? ` <span class="service-links">${links.join(' ')}</span>` : '';
+}
 
-    setTimeout(() => {
-        status.textContent = '';
-        status.className = 'save-status-inline';
-    }, 3000);
+// Render a container's owner chip; owners not set by label can be changed by clicking it
+function renderOwnerBadge(cont) {
+    if (!cont.owner) {
+        return ` <button class="notes-add" onclick="event.stopPropagation(); editContainerOwner(${cont.host_id}, '${escapeAttr(cont.name)}')" title="Assign an owner">👤</button>`;
+    }
+    const source = { label: 'set by the census.owner label', container: 'assigned to the container', stack: `assigned to the ${cont.compose_project} stack` }[cont.owner.source] || cont.owner.source;
+    const title = `Owner: ${cont.owner.name}${cont.owner.contact ? ' (' + cont.owner.contact + ')' : ''}, ${source}`;
+    const onclick = cont.owner.source === 'label' ? '' : `onclick="event.stopPropagation(); editContainerOwner(${cont.host_id}, '${escapeAttr(cont.name)}')"`;
+    return ` <span class="chip chip-owner" ${onclick} title="${escapeAttr(title)}">👤 ${escapeHtml(cont.owner.name)}</span>`;
 }
 
-async function saveCardDesign() {
-    const status = document.getElementById('cardDesignSaveStatus');
-    const cardDesign = document.getElementById('cardDesignTheme').value;
+// Assign or remove a container's owner, or its compose stack's
+async function editContainerOwner(hostId, containerName) {
+    const cont = containers.find(c => c.host_id === hostId && c.name === containerName);
+    const current = cont && cont.owner ? cont.owner : null;
+    const owner = prompt(`Owner of ${containerName}: a user, team or email address.\n\nLeave empty to remove the owner.`, current ? current.name : '');
+    if (owner === null) return;
 
-    console.log('Saving card design:', cardDesign);
-    status.textContent = 'Saving...';
-    status.className = 'save-status-inline saving';
+    const stack = cont && cont.compose_project;
+    let url = `/api/containers/${hostId}/${encodeURIComponent(containerName)}/owner`;
+    if (stack && (current && current.source === 'stack'
+        ? confirm(`${containerName}'s owner is assigned to the ${stack} stack. Change it for the whole stack?`)
+        : owner.trim() && confirm(`Assign ${owner.trim()} to every container of the ${stack} stack?\n\nCancel assigns it to ${containerName} only.`))) {
+        url = `/api/stacks/${encodeURIComponent(stack)}/owner`;
+    }
 
     try {
-        // Load current settings first
-        const currentResponse = await fetchWithAuth('/api/settings');
-        const currentSettings = await currentResponse.json();
-        console.log('Current settings:', currentSettings);
+        const response = owner.trim()
+            ? await fetch(url, {
+                method: 'PUT',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ owner: owner.trim(), contact: current && current.name === owner.trim() ? current.contact || '' : '' })
+            })
+            : await fetch(url, { method: 'DELETE' });
+        const result = await response.json();
+        if (!response.ok) {
+            showNotification('Failed to update owner: ' + (result.error || 'Unknown error'), 'error');
+            return;
+        }
+        showNotification(owner.trim() ? `Owner set to ${owner.trim()}` : 'Owner removed', 'success');
+        await loadData();
+    } catch (error) {
+        console.error('Error updating owner:', error);
+        showNotification('Error updating owner: ' + error.message, 'error');
+    }
+}
 
-        // Update only the card design, preserve other settings
-        const updatedSettings = {
-            scanner: {
-                interval_seconds: currentSettings.scanner?.interval_seconds || 300,
-                timeout_seconds: currentSettings.scanner?.timeout_seconds || 30
-            },
-            telemetry: {
-                interval_hours: currentSettings.telemetry?.interval_hours || 168
-            },
-            notification: currentSettings.notification || {
-                rate_limit_max: 100,
-                rate_limit_batch_interval: 600,
-                threshold_duration: 120,
-                cooldown_period: 300
-            },
-            ui: {
-                card_design: cardDesign
-            }
-        };
+// Render a container's notes chip: the notes on hover, or a faint button to add some
+function renderNotesBadge(cont) {
+    const onclick = `event.stopPropagation(); editContainerNotes(${cont.host_id}, '${escapeAttr(cont.name)}')`;
+    if (!cont.notes) {
+        return ` <button class="notes-add" onclick="${onclick}" title="Add notes">🗒️</button>`;
+    }
+    return ` <span class="chip chip-notes" onclick="${onclick}" title="${escapeAttr(notesSummary(cont.notes))}">📝 Notes</span>`;
+}
 
-        console.log('Sending updated settings:', updatedSettings);
-        const response = await fetchWithAuth('/api/settings', {
-            method: 'PUT',
-            headers: { 'Content-Type': 'application/json' },
-            body: JSON.stringify(updatedSettings)
-        });
+// Notes as plain text: the text, then one line per field
+function notesSummary(notes) {
+    const fields = Object.entries(notes.fields || {}).map(([key, value]) => `${key}: ${value}`);
+    return [notes.text || '', ...fields].filter(Boolean).join('\n');
+}
 
-        console.log('Response status:', response.status, response.statusText);
-        if (response.ok) {
-            console.log('Settings saved successfully');
-            status.textContent = '✓ Saved';
-            status.className = 'save-status-inline success';
-            showNotification('Card design updated successfully', 'success');
+// The notes being edited in the notes modal: their API URL and what they belong to
+let notesTarget = null;
 
-            // Update the global theme variable and re-render containers
-            cardDesignTheme = cardDesign;
-            if (currentTab === 'containers') {
-                filterContainers(); // This will re-render with the new theme
-            }
-        } else {
-            const error = await response.json();
-            status.textContent = '✗ Failed';
-            status.className = 'save-status-inline error';
-            showNotification('Failed to update card design: ' + (error.error || 'Unknown error'), 'error');
-        }
-    } catch (error) {
-        status.textContent = '✗ Error';
-        status.className = 'save-status-inline error';
-        console.error('Failed to save card design:', error);
-    }
+function editContainerNotes(hostId, containerName) {
+    openNotesModal(`/api/containers/${hostId}/${encodeURIComponent(containerName)}/notes`, containerName);
+}
 
-    setTimeout(() => {
-        status.textContent = '';
-        status.className = 'save-status-inline';
-    }, 3000);
+function editHostNotes(hostId) {
+    const host = hosts.find(h => h.id === hostId);
+    openNotesModal(`/api/hosts/${hostId}/notes`, host ? host.name : `host ${hostId}`);
 }
 
-async function loadUISettings() {
-    try {
-        const response = await fetchWithAuth('/api/settings');
-        const settings = await response.json();
+async function openNotesModal(url, name) {
+    notesTarget = { url, name };
+    document.getElementById('notesModalTitle').textContent = `📝 Notes - ${name}`;
+    document.getElementById('notesText').value = '';
+    document.getElementById('notesFields').innerHTML = '';
+    document.getElementById('notesUpdated').textContent = '';
+    document.getElementById('notesModal').classList.add('show');
 
-        // Update global variable (with fallback to default)
-        if (settings.ui && settings.ui.card_design) {
-            cardDesignTheme = settings.ui.card_design;
-        } else {
-            cardDesignTheme = 'material'; // default if not set
+    try {
+        const response = await fetch(url);
+        const notes = await response.json();
+        if (!response.ok) {
+            throw new Error(notes.error || `HTTP ${response.status}`);
         }
-
-        // Update dropdown if on settings page
-        const dropdown = document.getElementById('cardDesignTheme');
-        if (dropdown) {
-            dropdown.value = cardDesignTheme;
+        document.getElementById('notesText').value = notes.text || '';
+        Object.entries(notes.fields || {}).forEach(([key, value]) => addNotesField(key, value));
+        if (notes.updated_by || notes.text || notes.fields) {
+            document.getElementById('notesUpdated').textContent =
+                `Last edited ${formatDateTime(notes.updated_at)}${notes.updated_by ? ' by ' + notes.updated_by : ''}`;
         }
     } catch (error) {
-        console.log('Error loading UI settings:', error);
-        cardDesignTheme = 'material'; // fallback to default
+        console.error('Error loading notes:', error);
+        showNotification('Error loading notes: ' + error.message, 'error');
     }
+    addNotesField('', '');
 }
 
-// Initialize settings when switching to settings tab
-document.addEventListener('DOMContentLoaded', () => {
-    // Load settings immediately on page load
-    loadScannerSettings();
-    loadTelemetrySettings();
-    loadUISettings();
+function closeNotesModal() {
+    document.getElementById('notesModal').classList.remove('show');
+    notesTarget = null;
+}
 
-    // Load settings when settings tab is clicked
-    const settingsTab = document.querySelector('[data-tab="settings"]');
-    if (settingsTab) {
-        settingsTab.addEventListener('click', () => {
-            setTimeout(() => {
-                loadScannerSettings();
-                loadTelemetrySettings();
-                loadUISettings();
-                loadCollectors();
-            }, 100);
-        });
-    }
-});
+// Add a field row to the notes modal
+function addNotesField(key, value) {
+    const row = document.createElement('div');
+    row.className = 'notes-field';
+    row.innerHTML = `
+        <input type="text" class="notes-field-key" placeholder="Field, e.g. owner" maxlength="64" value="${escapeAttr(key)}">
+        <input type="text" class="notes-field-value" placeholder="Value" maxlength="2000" value="${escapeAttr(value)}">
+        <button type="button" class="btn-icon" title="Remove field" onclick="this.parentElement.remove()">✕</button>
+    `;
+    document.getElementById('notesFields').appendChild(row);
+}
 
-// Custom Collectors Management
+// Save the notes in the modal; clearing the text and fields deletes them
+async function saveNotes() {
+    if (!notesTarget) return;
+    const fields = {};
+    document.querySelectorAll('#notesFields .notes-field').forEach(row => {
+        const key = row.querySelector('.notes-field-key').value.trim();
+        const value = row.querySelector('.notes-field-value').value.trim();
+        if (key && value) fields[key] = value;
+    });
 
-async function loadCollectors() {
     try {
-        // Fetch telemetry status which includes all endpoints with status info
-        const [statusResponse, debugResponse] = await Promise.all([
-            fetch('/api/telemetry/status'),
-            fetch('/api/telemetry/debug-enabled')
-        ]);
-
-        if (!statusResponse.ok) {
-            console.error('Failed to fetch telemetry status, status:', statusResponse.status);
-            throw new Error('Failed to load collectors');
+        const response = await fetch(notesTarget.url, {
+            method: 'PUT',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ text: document.getElementById('notesText').value, fields })
+        });
+        const result = await response.json();
+        if (!response.ok) {
+            showNotification('Failed to save notes: ' + (result.error || 'Unknown error'), 'error');
+            return;
         }
-
-        const collectors = await statusResponse.json();
-        const debugInfo = debugResponse.ok ? await debugResponse.json() : { debug_enabled: false };
-
-        console.log('Loaded collectors with status:', collectors);
-        console.log('Debug mode:', debugInfo.debug_enabled);
-        renderCollectors(collectors, debugInfo.debug_enabled);
+        showNotification(`Notes for ${notesTarget.name} saved`, 'success');
+        closeNotesModal();
+        await loadData();
     } catch (error) {
-        console.error('Error loading collectors:', error);
-        showNotification('Failed to load collectors', 'error');
+        console.error('Error saving notes:', error);
+        showNotification('Error saving notes: ' + error.message, 'error');
     }
 }
 
-function renderCollectors(collectors, debugEnabled = false) {
-    const collectorsList = document.getElementById('collectorsList');
+function formatPorts(ports) {
+    if (!ports || ports.length === 0) return '-';
 
-    // Separate community and custom collectors
-    const communityCollector = collectors.find(c => c.name === 'community');
-    const customCollectors = collectors.filter(c => c.name !== 'community');
+    return ports
+        .filter(p => p.public_port > 0)
+        .map(p => `${p.public_port}:${p.private_port}/${p.type}`)
+        .join('<br>') || '-';
+}
 
-    let html = '';
+function formatDate(dateStr) {
+    if (!dateStr) return '-';
 
-    // Render community collector if exists
-    if (communityCollector) {
-        const lastSuccess = communityCollector.last_success ? new Date(communityCollector.last_success) : null;
-        const lastFailure = communityCollector.last_failure ? new Date(communityCollector.last_failure) : null;
-        const statusText = formatTelemetryStatus(lastSuccess, lastFailure);
-        const statusClass = getStatusClass(lastSuccess, lastFailure);
+    const date = new Date(dateStr);
 
-        html += `
-            <div class="collector-item community-collector" style="background: #f8f9fa; border: 2px solid #667eea; margin-bottom: 20px; padding: 20px;">
-                <div style="display: flex; justify-content: space-between; align-items: flex-start;">
-                    <div style="flex: 1;">
-                        <div class="collector-name" style="font-size: 16px; margin-bottom: 8px;">
-                            <strong>📊 Community Collector</strong>
-                            <span class="collector-status ${communityCollector.enabled ? 'enabled' : 'disabled'}">
-                                ${communityCollector.enabled ? 'Enabled' : 'Disabled'}
-                            </span>
-                        </div>
-                        <div class="collector-url" style="margin: 8px 0; color: #666; font-size: 13px;">${escapeHtml(communityCollector.url)}</div>
-                        <p style="margin: 10px 0; color: #555; font-size: 14px;">
-                            Help improve Container Census by sharing anonymous usage statistics.
-                        </p>
+    // Check if date is valid
+    if (isNaN(date.getTime())) return '-';
 
-                        <div class="telemetry-info" style="display: grid; grid-template-columns: 1fr 1fr; gap: 15px; margin: 15px 0; padding: 15px; background: white; border-radius: 6px;">
-                            <div class="info-column">
-                                <h4 style="margin: 0 0 8px 0; font-size: 13px; color: #2e7d32;">✓ What gets shared:</h4>
-                                <ul style="margin: 0; padding-left: 20px; font-size: 12px; color: #666;">
-                                    <li>Container Census version</li>
-                                    <li>Number of containers and hosts</li>
-                                    <li>Popular container images (names only)</li>
-                                    <li>Container registry distribution</li>
-                                    <li>Geographic region (timezone-based)</li>
-                                </ul>
-                            </div>
-                            <div class="info-column">
-                                <h4 style="margin: 0 0 8px 0; font-size: 13px; color: #c62828;">✗ What is NOT shared:</h4>
-                                <ul style="margin: 0; padding-left: 20px; font-size: 12px; color: #666;">
-                                    <li>Host names or IP addresses</li>
-                                    <li>Container names or env variables</li>
-                                    <li>Any credentials or secrets</li>
-                                    <li>Personal information</li>
-                                </ul>
-                            </div>
-                        </div>
+    // Check if date is zero/epoch or in the far future/past (invalid)
+    const year = date.getFullYear();
+    if (year < 1970 || year > 2100) return '-';
 
-                        ${statusText ? `<div class="telemetry-status ${statusClass}">${statusText}</div>` : ''}
-                        ${lastFailure && communityCollector.last_failure_reason ?
-                            `<div class="telemetry-error" title="${escapeHtml(communityCollector.last_failure_reason)}">
-                                ⚠ ${escapeHtml(communityCollector.last_failure_reason.substring(0, 80))}${communityCollector.last_failure_reason.length > 80 ? '...' : ''}
-                            </div>` : ''}
-                        ${debugEnabled && lastFailure ?
-                            `<div style="margin-top: 10px;">
-                                <button class="btn btn-sm btn-secondary" onclick="resetCircuitBreaker('${escapeAttr(communityCollector.name)}')" style="font-size: 12px;">
-                                    🔧 Reset Circuit Breaker
-                                </button>
-                            </div>` : ''}
-                    </div>
-                    <div style="margin-left: 20px;">
-                        <button class="btn ${communityCollector.enabled ? 'btn-warning' : 'btn-primary'}"
-                                onclick="toggleCollector('${escapeAttr(communityCollector.name)}', ${!communityCollector.enabled})"
-                                style="min-width: 100px; white-space: nowrap;">
-                            ${communityCollector.enabled ? 'Disable' : 'Enable'}
-                        </button>
-                    </div>
-                </div>
-            </div>
-        `;
-    }
+    const now = new Date();
+    const diffMs = now - date;
 
-    // Add separator before custom collectors
-    if (customCollectors.length > 0) {
-        html += '<h4 style="margin: 30px 0 15px 0; color: #666;">Custom Collectors</h4>';
-    }
+    // If date is in the future, return '-'
+    if (diffMs < 0) return '-';
 
-    if (customCollectors.length === 0) {
-        html += '<p style="color: #666; font-style: italic; margin-top: 20px;">No custom collectors configured.</p>';
-    } else {
-        html += customCollectors.map(collector => {
-            const lastSuccess = collector.last_success ? new Date(collector.last_success) : null;
-            const lastFailure = collector.last_failure ? new Date(collector.last_failure) : null;
-            const statusText = formatTelemetryStatus(lastSuccess, lastFailure);
-            const statusClass = getStatusClass(lastSuccess, lastFailure);
+    const diffDays = Math.floor(diffMs / (1000 * 60 * 60 * 24));
 
-            return `
-            <div class="collector-item">
-                <div class="collector-info">
-                    <div class="collector-name">
-                        ${escapeHtml(collector.name)}
-                        <span class="collector-status ${collector.enabled ? 'enabled' : 'disabled'}">
-                            ${collector.enabled ? 'Enabled' : 'Disabled'}
-                        </span>
-                    </div>
-                    <div class="collector-url">${escapeHtml(collector.url)}</div>
-                    ${collector.api_key ? '<div style="font-size: 12px; color: #999;">🔑 API Key configured</div>' : ''}
-                    ${statusText ? `<div class="telemetry-status ${statusClass}">${statusText}</div>` : ''}
-                    ${lastFailure && collector.last_failure_reason ?
-                        `<div class="telemetry-error" title="${escapeHtml(collector.last_failure_reason)}">
-                            ⚠ ${escapeHtml(collector.last_failure_reason.substring(0, 60))}${collector.last_failure_reason.length > 60 ? '...' : ''}
-                        </div>` : ''}
-                    ${debugEnabled && lastFailure ?
-                        `<div style="margin-top: 8px;">
-                            <button class="btn btn-sm" onclick="resetCircuitBreaker('${escapeAttr(collector.name)}')" style="font-size: 11px; padding: 4px 8px;">
-                                🔧 Reset Circuit Breaker
-                            </button>
-                        </div>` : ''}
-                </div>
-                <div class="collector-actions">
-                    <button class="btn btn-sm btn-secondary" onclick="toggleCollector('${escapeAttr(collector.name)}', ${!collector.enabled})">
-                        ${collector.enabled ? 'Disable' : 'Enable'}
-                    </button>
-                    <button class="btn btn-sm btn-danger" onclick="deleteCollector('${escapeAttr(collector.name)}')">
-                        Delete
-                    </button>
-                </div>
-            </div>
-            `;
-        }).join('');
-    }
+    if (diffDays === 0) return 'Today';
+    if (diffDays === 1) return 'Yesterday';
+    if (diffDays < 7) return `${diffDays} days ago`;
+    if (diffDays < 30) return `${Math.floor(diffDays / 7)} weeks ago`;
+    if (diffDays < 365) return `${Math.floor(diffDays / 30)} months ago`;
+    return `${Math.floor(diffDays / 365)} years ago`;
+}
 
-    collectorsList.innerHTML = html;
+function formatDateTime(dateStr) {
+    const date = new Date(dateStr);
+    return date.toLocaleString(dateLocale(), dateOptions());
 }
 
-function formatTelemetryStatus(lastSuccess, lastFailure) {
-    if (!lastSuccess && !lastFailure) {
-        return 'No telemetry submitted yet';
-    }
+function formatTimeAgo(date) {
+    const now = new Date();
+    const diffMs = now - date;
+    const diffMins = Math.floor(diffMs / (1000 * 60));
 
-    if (!lastFailure || (lastSuccess && lastSuccess > lastFailure)) {
-        return `✓ Last success: ${formatTimeAgo(lastSuccess)}`;
-    } else {
-        return `✗ Last failure: ${formatTimeAgo(lastFailure)}`;
-    }
+    if (diffMins < 1) return 'Just now';
+    if (diffMins === 1) return '1 min ago';
+    if (diffMins < 60) return `${diffMins} mins ago`;
+
+    const diffHours = Math.floor(diffMins / 60);
+    if (diffHours === 1) return '1 hour ago';
+    if (diffHours < 24) return `${diffHours} hours ago`;
+
+    const diffDays = Math.floor(diffHours / 24);
+    if (diffDays === 1) return '1 day ago';
+    return `${diffDays} days ago`;
 }
 
-function getStatusClass(lastSuccess, lastFailure) {
-    if (!lastSuccess && !lastFailure) {
-        return 'status-unknown';
+function escapeHtml(text) {
+    const div = document.createElement('div');
+    div.textContent = text;
+    return div.innerHTML;
+}
+
+function escapeAttr(text) {
+    return text.replace(/'/g, "\\'").replace(/"/g, '&quot;');
+}
+
+function extractImageTag(imageName, allTags) {
+    // If we have all tags for this image, show them (excluding the one already displayed)
+    // This helps when an image is tagged as both 'latest' and a version number
+    if (allTags && allTags.length > 0) {
+        // Extract just the tags from full image names (remove registry/repo parts)
+        const tags = allTags.map(fullTag => {
+            const parts = fullTag.split(':');
+            return parts[parts.length - 1];
+        }).filter(tag => tag && tag !== '<none>');
+
+        // Remove duplicates
+        const uniqueTags = [...new Set(tags)];
+
+        // If we have multiple tags, prioritize showing version number over 'latest'
+        if (uniqueTags.length > 1) {
+            // Filter out 'latest' if we have other tags
+            const nonLatestTags = uniqueTags.filter(t => t !== 'latest');
+            if (nonLatestTags.length > 0) {
+                // Show non-latest tags first, then indicate it's also 'latest'
+                return nonLatestTags.join(', ') + (uniqueTags.includes('latest') ? ' (latest)' : '');
+            }
+            return uniqueTags.join(', ');
+        } else if (uniqueTags.length === 1) {
+            return uniqueTags[0];
+        }
     }
 
-    if (!lastFailure || (lastSuccess && lastSuccess > lastFailure)) {
-        return 'status-success';
-    } else {
-        return 'status-error';
+    // Fallback to extracting tag from the image name
+    if (!imageName || !imageName.includes(':')) {
+        return 'latest';
     }
+    const parts = imageName.split(':');
+    return parts[parts.length - 1]; // Get last part after colon
 }
 
-async function testCollector() {
-    const url = document.getElementById('collectorURL').value.trim();
-    const apiKey = document.getElementById('collectorAPIKey').value.trim();
-    const status = document.getElementById('collectorSaveStatus');
+// Add Agent Host Modal Functions
 
-    if (!url) {
-        status.textContent = '✗ URL is required to test';
-        status.className = 'save-status-inline error';
-        setTimeout(() => status.textContent = '', 3000);
+function openAddAgentModal() {
+    console.log('Opening add agent modal...');
+    const modal = document.getElementById('addAgentModal');
+    const form = document.getElementById('addAgentForm');
+    const result = document.getElementById('agentTestResult');
+
+    if (!modal) {
+        console.error('Modal element not found!');
         return;
     }
 
-    status.textContent = 'Testing connection...';
-    status.className = 'save-status-inline saving';
+    modal.classList.add('show');
+    form.reset();
+    result.style.display = 'none';
+    loadHostStartOptions('agent');
+    console.log('Modal opened');
+}
 
-    const testData = { url };
-    if (apiKey) {
-        testData.api_key = apiKey;
+function closeAddAgentModal() {
+    const modal = document.getElementById('addAgentModal');
+    if (modal) {
+        modal.classList.remove('show');
     }
+}
+
+async function testAgentConnection() {
+    const address = document.getElementById('agentAddress').value;
+    const token = document.getElementById('agentToken').value;
+    const testBtn = document.getElementById('testAgentBtn');
+    const result = document.getElementById('agentTestResult');
+
+    if (!address || !token) {
+        result.className = 'alert alert-error';
+        result.textContent = 'Please enter both address and token';
+        result.style.display = 'block';
+        return;
+    }
+
+    testBtn.disabled = true;
+    testBtn.textContent = 'Testing...';
 
     try {
-        const response = await fetch('/api/telemetry/test-endpoint', {
+        const response = await fetch('/api/hosts/agent/test', {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
-            body: JSON.stringify(testData)
+            body: JSON.stringify({ address, agent_token: token })
         });
 
-        const result = await response.json();
+        const data = await response.json();
 
-        if (response.ok) {
-            status.textContent = '✓ Connection successful!';
-            status.className = 'save-status-inline success';
+        if (data.success) {
+            result.className = 'alert alert-success';
+            result.textContent = '✓ Connection successful! Agent is reachable.';
         } else {
-            status.textContent = '✗ ' + (result.error || 'Connection failed');
-            status.className = 'save-status-inline error';
+            result.className = 'alert alert-error';
+            result.textContent = '✗ Connection failed: ' + (data.error || 'Unknown error');
         }
+        result.style.display = 'block';
     } catch (error) {
-        status.textContent = '✗ Connection failed: ' + error.message;
-        status.className = 'save-status-inline error';
+        result.className = 'alert alert-error';
+        result.textContent = '✗ Error: ' + error.message;
+        result.style.display = 'block';
+    } finally {
+        testBtn.disabled = false;
+        testBtn.textContent = 'Test Connection';
     }
-
-    setTimeout(() => status.textContent = '', 5000);
 }
 
-async function addCollector() {
-    const name = document.getElementById('collectorName').value.trim();
-    const url = document.getElementById('collectorURL').value.trim();
-    const apiKey = document.getElementById('collectorAPIKey').value.trim();
-    const enabled = document.getElementById('collectorEnabled').checked;
-    const status = document.getElementById('collectorSaveStatus');
+async function handleAddAgent(e) {
+    e.preventDefault();
 
-    // Validate inputs
-    if (!name) {
-        status.textContent = '✗ Name is required';
-        status.className = 'save-status-inline error';
-        setTimeout(() => status.textContent = '', 3000);
-        return;
-    }
+    const addressInput = document.getElementById('agentAddress');
+    const address = addressInput.value.trim();
 
-    if (!url) {
-        status.textContent = '✗ URL is required';
-        status.className = 'save-status-inline error';
-        setTimeout(() => status.textContent = '', 3000);
+    // Validate address format
+    const validProtocols = /^(https?|agent|nats|tls|mqtts?):\/\/.+/;
+    if (!validProtocols.test(address)) {
+        const result = document.getElementById('agentTestResult');
+        result.className = 'alert alert-error';
+        result.textContent = 'Invalid address format. Must start with http://, https://, or agent:// followed by hostname/IP and optional port (e.g., http://192.168.1.100:9876), or be a broker address (tls:// or mqtts://broker:port/agent-id)';
+        result.style.display = 'block';
+        addressInput.focus();
         return;
     }
 
-    // Show saving status
-    status.textContent = 'Saving...';
-    status.className = 'save-status-inline saving';
-
-    const endpoint = {
-        name,
-        url,
-        enabled
+    const data = {
+        name: document.getElementById('agentName').value,
+        address: address,
+        agent_token: document.getElementById('agentToken').value,
+        description: document.getElementById('agentDescription').value,
+        site: document.getElementById('agentSite').value.trim(),
+        collect_stats: document.getElementById('agentCollectStats').checked,
+        ...hostStartFromPayload('agent')
     };
 
-    if (apiKey) {
-        endpoint.api_key = apiKey;
-    }
+    const saveBtn = document.getElementById('saveAgentBtn');
+    const result = document.getElementById('agentTestResult');
+
+    saveBtn.disabled = true;
+    saveBtn.textContent = 'Adding...';
 
     try {
-        const response = await fetch('/api/telemetry/endpoints', {
+        const response = await fetch('/api/hosts/agent', {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
-            body: JSON.stringify(endpoint)
+            body: JSON.stringify(data)
         });
 
         if (response.ok) {
-            status.textContent = '✓ Collector added successfully';
-            status.className = 'save-status-inline success';
-
-            // Clear form
-            document.getElementById('collectorName').value = '';
-            document.getElementById('collectorURL').value = '';
-            document.getElementById('collectorAPIKey').value = '';
-            document.getElementById('collectorEnabled').checked = true;
-
-            // Reload collectors list
-            await loadCollectors();
-
-            showNotification('Collector added successfully', 'success');
+            showNotification('Agent host added successfully!', 'success');
+            closeAddAgentModal();
+            loadData(); // Refresh the data
         } else {
             const error = await response.json();
-            status.textContent = '✗ Failed: ' + (error.error || 'Unknown error');
-            status.className = 'save-status-inline error';
-            showNotification('Failed to add collector', 'error');
+            result.className = 'alert alert-error';
+            result.textContent = 'Error: ' + (error.error || 'Failed to add agent');
+            result.style.display = 'block';
         }
     } catch (error) {
-        status.textContent = '✗ Error: ' + error.message;
-        status.className = 'save-status-inline error';
-        showNotification('Error adding collector', 'error');
+        result.className = 'alert alert-error';
+        result.textContent = 'Error: ' + error.message;
+        result.style.display = 'block';
+    } finally {
+        saveBtn.disabled = false;
+        saveBtn.textContent = 'Add Agent';
     }
+}
 
-    // Clear status after 3 seconds
-    setTimeout(() => {
-        status.textContent = '';
-        status.className = 'save-status-inline';
-    }, 3000);
-}
+// Add Incus Host Modal Functions
 
-async function toggleCollector(name, enabled) {
-    try {
-        const response = await fetch(`/api/telemetry/endpoints/${encodeURIComponent(name)}`, {
-            method: 'PUT',
-            headers: { 'Content-Type': 'application/json' },
-            body: JSON.stringify({ enabled })
-        });
+async function openAddIncusModal() {
+    const modal = document.getElementById('addIncusModal');
+    document.getElementById('addIncusForm').reset();
+    document.getElementById('incusTestResult').style.display = 'none';
+    modal.classList.add('show');
+    loadHostStartOptions('incus');
 
-        if (response.ok) {
-            await loadCollectors();
-            showNotification(`Collector ${enabled ? 'enabled' : 'disabled'} successfully`, 'success');
-        } else {
-            const error = await response.json();
-            showNotification('Failed to update collector: ' + (error.error || 'Unknown error'), 'error');
-        }
+    const certificate = document.getElementById('incusCertificate');
+    try {
+        const response = await fetch('/api/hosts/incus/certificate');
+        const data = await response.json();
+        certificate.value = response.ok ? data.certificate : (data.error || 'Certificate not available');
     } catch (error) {
-        showNotification('Error updating collector', 'error');
+        certificate.value = 'Failed to load certificate: ' + error.message;
     }
 }
 
-async function deleteCollector(name) {
-    if (!confirm(`Are you sure you want to delete the collector "${name}"?`)) {
+function closeAddIncusModal() {
+    document.getElementById('addIncusModal')?.classList.remove('show');
+}
+
+function showIncusResult(success, message) {
+    const result = document.getElementById('incusTestResult');
+    result.className = success ? 'alert alert-success' : 'alert alert-error';
+    result.textContent = message;
+    result.style.display = 'block';
+}
+
+async function testIncusConnection() {
+    const address = document.getElementById('incusAddress').value.trim();
+    const testBtn = document.getElementById('testIncusBtn');
+
+    if (!address) {
+        showIncusResult(false, 'Please enter an address');
         return;
     }
 
+    testBtn.disabled = true;
+    testBtn.textContent = 'Testing...';
+
     try {
-        const response = await fetch(`/api/telemetry/endpoints/${encodeURIComponent(name)}`, {
-            method: 'DELETE'
+        const response = await fetch('/api/hosts/incus/test', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ address })
         });
+        const data = await response.json();
 
-        if (response.ok) {
-            await loadCollectors();
-            showNotification('Collector deleted successfully', 'success');
+        if (data.success) {
+            showIncusResult(true, '✓ ' + data.message);
         } else {
-            const error = await response.json();
-            showNotification('Failed to delete collector: ' + (error.error || 'Unknown error'), 'error');
+            showIncusResult(false, '✗ Connection failed: ' + (data.error || 'Unknown error'));
         }
     } catch (error) {
-        showNotification('Error deleting collector', 'error');
+        showIncusResult(false, '✗ Error: ' + error.message);
+    } finally {
+        testBtn.disabled = false;
+        testBtn.textContent = 'Test Connection';
     }
 }
 
-async function resetCircuitBreaker(name) {
+async function handleAddIncus(e) {
+    e.preventDefault();
+
+    const data = {
+        name: document.getElementById('incusName').value,
+        address: document.getElementById('incusAddress').value.trim(),
+        description: document.getElementById('incusDescription').value,
+        site: document.getElementById('incusSite').value.trim(),
+        collect_stats: document.getElementById('incusCollectStats').checked,
+        ...hostStartFromPayload('incus')
+    };
+
+    const saveBtn = document.getElementById('saveIncusBtn');
+    saveBtn.disabled = true;
+    saveBtn.textContent = 'Adding...';
+
     try {
-        const response = await fetch(`/api/telemetry/reset-circuit-breaker/${encodeURIComponent(name)}`, {
-            method: 'POST'
+        const response = await fetch('/api/hosts/incus', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(data)
         });
 
         if (response.ok) {
-            await loadCollectors();
-            showNotification('Circuit breaker reset successfully - endpoint will retry on next submission', 'success');
+            showNotification('Incus host added successfully!', 'success');
+            closeAddIncusModal();
+            loadData();
         } else {
             const error = await response.json();
-            showNotification('Failed to reset circuit breaker: ' + (error.error || 'Unknown error'), 'error');
+            showIncusResult(false, 'Error: ' + (error.error || 'Failed to add host'));
         }
     } catch (error) {
-        showNotification('Error resetting circuit breaker', 'error');
+        showIncusResult(false, 'Error: ' + error.message);
+    } finally {
+        saveBtn.disabled = false;
+        saveBtn.textContent = 'Add Host';
     }
 }
 
-// Container History Functions
+// isIncusHost reports whether a host is an Incus/LXD host, whose containers have no Docker images
+function isIncusHost(hostId) {
+    return hosts.some(h => h.id === hostId && h.host_type === 'incus');
+}
 
-async function loadContainerHistory() {
+// Settings Management
+async function loadTelemetrySettings() {
     try {
-        const response = await fetch('/api/containers/lifecycle?limit=200');
-        lifecycles = await response.json() || [];
+        // Load from new database-first settings API
+        const response = await fetch('/api/settings');
+        const settings = await response.json();
 
-        // Apply filters if any are active
-        applyCurrentFilters();
+        const intervalHours = settings.telemetry?.interval_hours || 168;
+        const dropdown = document.getElementById('telemetryFrequency');
+        if (dropdown) {
+            dropdown.value = intervalHours.toString();
+            console.log('Loaded telemetry frequency from database:', intervalHours, 'hours');
+        }
     } catch (error) {
-        console.error('Error loading container history:', error);
-        document.getElementById('historyBody').innerHTML =
-            '<div class="error">Failed to load container history</div>';
+        console.error('Failed to load telemetry settings:', error);
     }
 }
 
-function updateHistoryHostFilter() {
-    const select = document.getElementById('historyHostFilter');
-    if (!select || hosts.length === 0) return;
+async function loadScannerSettings() {
+    try {
+        // Load from new database-first settings API
+        const response = await fetch('/api/settings');
+        const settings = await response.json();
 
-    const currentValue = select.value;
-    select.innerHTML = '<option value="">All Hosts</option>' +
-        hosts.map(host => `<option value="${host.id}">${escapeHtml(host.name)}</option>`).join('');
-    select.value = currentValue;
+        const intervalSeconds = settings.scanner?.interval_seconds || 300;
+        const dropdown = document.getElementById('scanInterval');
+        if (dropdown) {
+            dropdown.value = intervalSeconds.toString();
+            console.log('Loaded scanner interval from database:', intervalSeconds, 'seconds');
+        }
+        const inventoryObjects = document.getElementById('inventoryObjects');
+        if (inventoryObjects) {
+            inventoryObjects.checked = !!settings.scanner?.inventory_objects;
+        }
+    } catch (error) {
+        console.error('Failed to load scanner settings:', error);
+    }
 }
 
-function updateHistoryStats(lifecycles) {
-    const total = lifecycles.length;
-    const active = lifecycles.filter(l => l.is_active).length;
-    const inactive = total - active;
+async function saveScanInterval() {
+    const status = document.getElementById('scanIntervalSaveStatus');
+    const intervalSeconds = parseInt(document.getElementById('scanInterval').value);
 
-    document.getElementById('historyTotalContainers').textContent = total;
-    document.getElementById('historyActiveContainers').textContent = active;
-    document.getElementById('historyInactiveContainers').textContent = inactive;
-}
+    status.textContent = 'Saving...';
+    status.className = 'save-status-inline saving';
 
-function renderContainerHistory(lifecycles) {
-    const container = document.getElementById('historyBody');
+    try {
+        // Load current settings first
+        const currentResponse = await fetchWithAuth('/api/settings');
+        const currentSettings = await currentResponse.json();
 
-    if (!lifecycles || lifecycles.length === 0) {
-        container.innerHTML = '<div class="loading">No container history available</div>';
-        return;
+        // Update only the scanner interval, preserve other settings
+        const updatedSettings = {
+            scanner: {
+                interval_seconds: intervalSeconds,
+                timeout_seconds: currentSettings.scanner?.timeout_seconds || 30,
+                inventory_objects: document.getElementById('inventoryObjects').checked
+            },
+            telemetry: {
+                interval_hours: currentSettings.telemetry?.interval_hours || 168,
+                ...telemetryPrivacyFlags(currentSettings.telemetry)
+            },
+            notification: currentSettings.notification || {
+                rate_limit_max: 100,
+                rate_limit_batch_interval: 600,
+                threshold_duration: 120,
+                cooldown_period: 300
+            },
+            ui: currentSettings.ui || {
+                card_design: 'material'
+            }
+        };
+
+        const response = await fetchWithAuth('/api/settings', {
+            method: 'PUT',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(updatedSettings)
+        });
+
+        if (response.ok) {
+            status.textContent = '✓ Saved & Reloaded';
+            status.className = 'save-status-inline success';
+            showNotification('Scan interval updated successfully (hot-reloaded)', 'success');
+        } else {
+            const error = await response.json();
+            status.textContent = '✗ Failed';
+            status.className = 'save-status-inline error';
+            showNotification('Failed to update scan interval: ' + (error.error || 'Unknown error'), 'error');
+        }
+    } catch (error) {
+        status.textContent = '✗ Error';
+        status.className = 'save-status-inline error';
+        console.error('Failed to save scan interval:', error);
     }
 
-    container.innerHTML = lifecycles.map(lifecycle => {
-        const firstSeen = new Date(lifecycle.first_seen);
-        const lastSeen = new Date(lifecycle.last_seen);
-        const lifetime = formatDuration(lastSeen - firstSeen);
+    setTimeout(() => {
+        status.textContent = '';
+        status.className = 'save-status-inline';
+    }, 3000);
+}
 
-        const statusBadge = lifecycle.is_active
-            ? '<span class="state-badge state-running">Active</span>'
-            : '<span class="state-badge state-exited">Inactive</span>';
+async function saveTelemetryFrequency() {
+    const status = document.getElementById('frequencySaveStatus');
+    const intervalHours = parseInt(document.getElementById('telemetryFrequency').value);
 
-        // State changes includes the initial detection (first_seen) + actual state changes
-        const stateChanges = 1 + (lifecycle.state_changes || 0);
-        const imageUpdates = lifecycle.image_updates || 0;
-        const restartEvents = lifecycle.restart_events || 0;
+    status.textContent = 'Saving...';
+    status.className = 'save-status-inline saving';
 
-        return `
-        <div class="history-card-modern ${lifecycle.is_active ? 'active' : 'inactive'}">
-            <div class="history-card-header-modern">
-                <div class="history-card-left">
-                    <div class="history-status-indicator ${lifecycle.is_active ? 'active' : 'inactive'}">
-                        ${lifecycle.is_active ? '✅' : '⏸️'}
-                    </div>
-                    <div class="history-card-info">
-                        <div class="history-card-name">${escapeHtml(lifecycle.container_name)}</div>
-                        <div class="history-card-meta">
-                            <span class="meta-item">📍 ${escapeHtml(lifecycle.host_name)}</span>
-                            <span class="meta-item">⏱️ ${lifetime}</span>
-                        </div>
-                    </div>
-                </div>
-                <button class="btn btn-primary btn-timeline" onclick="viewContainerTimeline(${lifecycle.host_id}, '${escapeAttr(lifecycle.container_id)}', '${escapeAttr(lifecycle.container_name)}')" title="View detailed timeline">
-                    <span class="timeline-icon">📅</span>
-                    <span class="timeline-text">View Timeline</span>
-                </button>
-            </div>
+    try {
+        // Load current settings first
+        const currentResponse = await fetchWithAuth('/api/settings');
+        const currentSettings = await currentResponse.json();
 
-            <div class="history-card-content">
-                <div class="history-detail-row">
-                    <span class="detail-label">🖼️ Image</span>
-                    <code class="detail-value image-value" title="${escapeHtml(lifecycle.image)}">${escapeHtml(lifecycle.image)}</code>
-                </div>
+        // Update only the telemetry interval, preserve other settings
+        const updatedSettings = {
+            scanner: {
+                interval_seconds: currentSettings.scanner?.interval_seconds || 300,
+                timeout_seconds: currentSettings.scanner?.timeout_seconds || 30,
+                inventory_objects: currentSettings.scanner?.inventory_objects || false
+            },
+            telemetry: {
+                interval_hours: intervalHours,
+                ...telemetryPrivacyFlags(currentSettings.telemetry)
+            },
+            notification: currentSettings.notification || {
+                rate_limit_max: 100,
+                rate_limit_batch_interval: 600,
+                threshold_duration: 120,
+                cooldown_period: 300
+            },
+            ui: currentSettings.ui || {
+                card_design: 'material'
+            }
+        };
 
-                <div class="history-metrics-grid">
-                    <div class="metric-box">
-                        <div class="metric-icon">👁️</div>
-                        <div class="metric-content">
-                            <div class="metric-label">First Seen</div>
-                            <div class="metric-value">${formatTimeAgo(firstSeen)}</div>
-                            <div class="metric-subtext">${formatDateTime(lifecycle.first_seen)}</div>
-                        </div>
-                    </div>
+        const response = await fetchWithAuth('/api/settings', {
+            method: 'PUT',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(updatedSettings)
+        });
 
-                    <div class="metric-box">
-                        <div class="metric-icon">🕐</div>
-                        <div class="metric-content">
-                            <div class="metric-label">Last Seen</div>
-                            <div class="metric-value">${formatTimeAgo(lastSeen)}</div>
-                            <div class="metric-subtext">${formatDateTime(lifecycle.last_seen)}</div>
-                        </div>
-                    </div>
-
-                    <div class="metric-box ${stateChanges > 5 ? 'metric-warning' : ''}">
-                        <div class="metric-icon">🔄</div>
-                        <div class="metric-content">
-                            <div class="metric-label">State Changes</div>
-                            <div class="metric-value">${stateChanges}</div>
-                        </div>
-                    </div>
-
-                    <div class="metric-box ${imageUpdates > 0 ? 'metric-info' : ''}">
-                        <div class="metric-icon">⬆️</div>
-                        <div class="metric-content">
-                            <div class="metric-label">Image Updates</div>
-                            <div class="metric-value">${imageUpdates}</div>
-                        </div>
-                    </div>
+        if (response.ok) {
+            status.textContent = '✓ Saved & Reloaded';
+            status.className = 'save-status-inline success';
+            showNotification('Telemetry frequency updated successfully (hot-reloaded)', 'success');
+        } else {
+            const error = await response.json();
+            status.textContent = '✗ Failed';
+            status.className = 'save-status-inline error';
+            showNotification('Failed to update telemetry frequency: ' + (error.error || 'Unknown error'), 'error');
+        }
+    } catch (error) {
+        status.textContent = '✗ Error';
+        status.className = 'save-status-inline error';
+        console.error('Failed to save telemetry frequency:', error);
+    }
 
-                    <div class="metric-box ${restartEvents > 10 ? 'metric-alert' : restartEvents > 0 ? 'metric-warning' : ''}">
-                        <div class="metric-icon">🔁</div>
-                        <div class="metric-content">
-                            <div class="metric-label">Restarts</div>
-                            <div class="metric-value">${restartEvents}</div>
-                        </div>
-                    </div>
-                </div>
-            </div>
-        </div>
-        `;
-    }).join('');
+    setTimeout(() => {
+        status.textContent = '';
+        status.className = 'save-status-inline';
+    }, 3000);
 }
 
-async function viewContainerTimeline(hostId, containerId, containerName) {
-    document.getElementById('timelineContainerName').textContent = containerName;
-    document.getElementById('timelineContent').innerHTML = '<div class="loading">Loading timeline...</div>';
-    document.getElementById('timelineModal').classList.add('show');
+// telemetryPrivacyFlags extracts the per-category telemetry opt-outs and opt-ins from settings
+function telemetryPrivacyFlags(telemetry) {
+    return {
+        exclude_resource_stats: telemetry?.exclude_resource_stats || false,
+        exclude_image_list: telemetry?.exclude_image_list || false,
+        exclude_architecture_metrics: telemetry?.exclude_architecture_metrics || false,
+        exclude_timezone: telemetry?.exclude_timezone || false,
+        share_update_lag: telemetry?.share_update_lag || false
+    };
+}
 
+async function loadTelemetryPrivacy() {
     try {
-        const response = await fetch(`/api/containers/lifecycle/${hostId}/${encodeURIComponent(containerName)}`);
-        const events = await response.json();
-
-        if (!events || events.length === 0) {
-            document.getElementById('timelineContent').innerHTML = '<p>No lifecycle events found for this container.</p>';
-            return;
+        const response = await fetch('/api/settings');
+        const settings = await response.json();
+        const flags = telemetryPrivacyFlags(settings.telemetry);
+
+        const fields = {
+            telemetryExcludeResourceStats: flags.exclude_resource_stats,
+            telemetryExcludeImageList: flags.exclude_image_list,
+            telemetryExcludeArchitecture: flags.exclude_architecture_metrics,
+            telemetryExcludeTimezone: flags.exclude_timezone,
+            telemetryShareUpdateLag: flags.share_update_lag
+        };
+        for (const [id, value] of Object.entries(fields)) {
+            const checkbox = document.getElementById(id);
+            if (checkbox) {
+                checkbox.checked = value;
+            }
         }
-
-        renderTimeline(events);
     } catch (error) {
-        console.error('Error loading timeline:', error);
-        document.getElementById('timelineContent').innerHTML = '<p class="error">Failed to load timeline events</p>';
+        console.error('Failed to load telemetry privacy settings:', error);
     }
 }
 
-function renderTimeline(events) {
-    if (!events || events.length === 0) {
-        document.getElementById('timelineContent').innerHTML = '<p>No lifecycle events found.</p>';
-        return;
-    }
-
-    // Calculate summary statistics
-    const firstEvent = events[0];
-    const lastEvent = events[events.length - 1];
-    // State changes includes first_seen + actual state transitions
-    const actualStateChanges = events.filter(e => e.event_type === 'started' || e.event_type === 'stopped' || e.event_type === 'state_change').length;
-    const stateChanges = 1 + actualStateChanges; // +1 for first_seen
-    const imageUpdates = events.filter(e => e.event_type === 'image_updated').length;
-
-    // Extract scan count from last_seen event if present
-    let totalScans = 'N/A';
-    if (lastEvent && lastEvent.event_type === 'last_seen') {
-        const match = lastEvent.description.match(/seen (\d+) times/);
-        if (match) {
-            totalScans = parseInt(match[1]);
-        }
-    }
+async function saveTelemetryPrivacy() {
+    const status = document.getElementById('telemetryPrivacySaveStatus');
 
-    // Calculate duration
-    const firstTime = new Date(firstEvent.timestamp);
-    const lastTime = new Date(lastEvent.timestamp);
-    const durationMs = lastTime - firstTime;
-    const durationDays = Math.floor(durationMs / (1000 * 60 * 60 * 24));
-    const durationText = durationDays > 0 ? `${durationDays} days` : 'same day';
+    status.textContent = 'Saving...';
+    status.className = 'save-status-inline saving';
 
-    // Determine status
-    const isActive = lastEvent.new_state === 'running';
-    const statusText = isActive ? 'Active (running)' : lastEvent.new_state === 'exited' ? 'Inactive (stopped)' : 'Unknown';
-    const statusClass = isActive ? 'badge-success' : 'badge-warning';
+    try {
+        // Load current settings first
+        const currentResponse = await fetchWithAuth('/api/settings');
+        const currentSettings = await currentResponse.json();
 
-    // Build summary banner
-    const summaryHTML = `
-        <div class="timeline-summary" style="background: #f8f9fa; border: 1px solid #dee2e6; border-radius: 8px; padding: 16px; margin-bottom: 20px;">
-            <div style="display: flex; align-items: center; margin-bottom: 12px;">
-                <span style="font-size: 24px; margin-right: 10px;">📊</span>
-                <div>
-                    <strong style="font-size: 16px;">Container History Summary</strong>
-                    <div style="color: #666; font-size: 13px; margin-top: 4px;">
-                        ${formatDate(firstEvent.timestamp)} to ${formatDate(lastEvent.timestamp)} (${durationText})
-                    </div>
-                </div>
-            </div>
-            <div style="display: grid; grid-template-columns: repeat(auto-fit, minmax(150px, 1fr)); gap: 12px; font-size: 14px;">
-                <div><strong>Total Observations:</strong> ${totalScans}</div>
-                <div><strong>State Changes:</strong> ${stateChanges}</div>
-                <div><strong>Image Updates:</strong> ${imageUpdates}</div>
-                <div><strong>Current Status:</strong> <span class="badge ${statusClass}">${statusText}</span></div>
-            </div>
-        </div>
-    `;
+        // Update only the telemetry opt-outs, preserve other settings
+        const updatedSettings = {
+            scanner: {
+                interval_seconds: currentSettings.scanner?.interval_seconds || 300,
+                timeout_seconds: currentSettings.scanner?.timeout_seconds || 30,
+                inventory_objects: currentSettings.scanner?.inventory_objects || false
+            },
+            telemetry: {
+                interval_hours: currentSettings.telemetry?.interval_hours || 168,
+                exclude_resource_stats: document.getElementById('telemetryExcludeResourceStats').checked,
+                exclude_image_list: document.getElementById('telemetryExcludeImageList').checked,
+                exclude_architecture_metrics: document.getElementById('telemetryExcludeArchitecture').checked,
+                exclude_timezone: document.getElementById('telemetryExcludeTimezone').checked,
+                share_update_lag: document.getElementById('telemetryShareUpdateLag').checked
+            },
+            notification: currentSettings.notification || {
+                rate_limit_max: 100,
+                rate_limit_batch_interval: 600,
+                threshold_duration: 120,
+                cooldown_period: 300
+            },
+            ui: currentSettings.ui || {
+                card_design: 'material'
+            }
+        };
 
-    const timelineHTML = events.map(event => {
-        const eventIcon = getEventIcon(event.event_type);
-        const eventClass = getEventClass(event.event_type);
+        const response = await fetchWithAuth('/api/settings', {
+            method: 'PUT',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(updatedSettings)
+        });
 
-        let details = '';
-        if (event.old_state && event.new_state) {
-            details = `<span class="state-badge state-${event.old_state}">${event.old_state}</span> → <span class="state-badge state-${event.new_state}">${event.new_state}</span>`;
-        } else if (event.old_image_tag && event.new_image_tag) {
-            // New format: show both tag and SHA
-            details = `<code>${event.old_image_tag}</code> <span class="text-muted">(${event.old_image_sha})</span> → <code>${event.new_image_tag}</code> <span class="text-muted">(${event.new_image_sha})</span>`;
-        } else if (event.old_image && event.new_image) {
-            // Fallback to old format for backward compatibility
-            details = `<code>${event.old_image}</code> → <code>${event.new_image}</code>`;
-        } else if (event.restart_count) {
-            details = `<strong>${event.restart_count} restart(s)</strong>`;
+        if (response.ok) {
+            status.textContent = '✓ Saved';
+            status.className = 'save-status-inline success';
+            showNotification('Telemetry privacy settings updated successfully', 'success');
+        } else {
+            const error = await response.json();
+            status.textContent = '✗ Failed';
+            status.className = 'save-status-inline error';
+            showNotification('Failed to update telemetry privacy settings: ' + (error.error || 'Unknown error'), 'error');
         }
+    } catch (error) {
+        status.textContent = '✗ Error';
+        status.className = 'save-status-inline error';
+        console.error('Failed to save telemetry privacy settings:', error);
+    }
 
-        return `
-        <div class="timeline-event ${eventClass}">
-            <div class="timeline-marker">${eventIcon}</div>
-            <div class="timeline-content-box">
-                <div class="timeline-time">${formatDateTime(event.timestamp)}</div>
-                <div class="timeline-description">
-                    <strong>${event.description}</strong>
-                    ${details ? `<div class="timeline-details">${details}</div>` : ''}
-                </div>
-            </div>
-        </div>
-        `;
-    }).join('');
-
-    document.getElementById('timelineContent').innerHTML = `${summaryHTML}<div class="timeline">${timelineHTML}</div>`;
+    setTimeout(() => {
+        status.textContent = '';
+        status.className = 'save-status-inline';
+    }, 3000);
 }
 
-function getEventIcon(eventType) {
-    const icons = {
-        'first_seen': '🎉',
-        'started': '▶️',
-        'stopped': '⏹️',
-        'paused': '⏸️',
-        'resumed': '▶️',
-        'restarted': '⟳',
-        'image_updated': '📦',
-        'disappeared': '👻',
-        'reappeared': '✨',
-        'state_change': '🔄',
-        'last_seen': '📍'
-    };
-    return icons[eventType] || '•';
+// previewTelemetry opens the exact payload the next telemetry submission would send
+function previewTelemetry() {
+    window.open(appUrl('/api/telemetry/preview'), '_blank');
 }
 
-function getEventClass(eventType) {
-    const classes = {
-        'first_seen': 'event-success',
-        'started': 'event-success',
-        'stopped': 'event-warning',
-        'paused': 'event-info',
-        'resumed': 'event-success',
-        'restarted': 'event-warning',
-        'image_updated': 'event-info',
-        'disappeared': 'event-error',
-        'reappeared': 'event-success',
-        'state_change': 'event-info',
-        'last_seen': 'event-info'
-    };
-    return classes[eventType] || 'event-default';
-}
+async function rotateInstallationID() {
+    if (!confirm('Generate a new anonymous installation ID? Future telemetry will no longer be linked to previous submissions.')) {
+        return;
+    }
 
-function formatDuration(ms) {
-    const seconds = Math.floor(ms / 1000);
-    const minutes = Math.floor(seconds / 60);
-    const hours = Math.floor(minutes / 60);
-    const days = Math.floor(hours / 24);
+    try {
+        const response = await fetchWithAuth('/api/telemetry/rotate-installation-id', { method: 'POST' });
+        const result = await response.json();
 
-    if (days > 0) {
-        return `${days}d ${hours % 24}h`;
-    } else if (hours > 0) {
-        return `${hours}h ${minutes % 60}m`;
-    } else if (minutes > 0) {
-        return `${minutes}m`;
-    } else {
-        return `${seconds}s`;
+        if (response.ok) {
+            showNotification('Installation ID rotated: ' + result.installation_id, 'success');
+        } else {
+            showNotification('Failed to rotate installation ID: ' + (result.error || 'Unknown error'), 'error');
+        }
+    } catch (error) {
+        console.error('Failed to rotate installation ID:', error);
+        showNotification('Failed to rotate installation ID', 'error');
     }
 }
 
-function closeTimelineModal() {
-    document.getElementById('timelineModal').classList.remove('show');
-}
+async function saveCardDesign() {
+    const status = document.getElementById('cardDesignSaveStatus');
+    const cardDesign = document.getElementById('cardDesignTheme').value;
 
-// Graph Visualization Functions
+    console.log('Saving card design:', cardDesign);
+    status.textContent = 'Saving...';
+    status.className = 'save-status-inline saving';
 
-async function loadGraph() {
-    const container = document.getElementById('graphContainer');
-    container.innerHTML = '<div class="graph-loading">Loading graph...</div>';
+    try {
+        // Load current settings first
+        const currentResponse = await fetchWithAuth('/api/settings');
+        const currentSettings = await currentResponse.json();
+        console.log('Current settings:', currentSettings);
+
+        // Update only the card design, preserve other settings
+        const updatedSettings = {
+            scanner: {
+                interval_seconds: currentSettings.scanner?.interval_seconds || 300,
+                timeout_seconds: currentSettings.scanner?.timeout_seconds || 30,
+                inventory_objects: currentSettings.scanner?.inventory_objects || false
+            },
+            telemetry: {
+                interval_hours: currentSettings.telemetry?.interval_hours || 168,
+                ...telemetryPrivacyFlags(currentSettings.telemetry)
+            },
+            notification: currentSettings.notification || {
+                rate_limit_max: 100,
+                rate_limit_batch_interval: 600,
+                threshold_duration: 120,
+                cooldown_period: 300
+            },
+            ui: {
+                card_design: cardDesign
+            }
+        };
+
+        console.log('Sending updated settings:', updatedSettings);
+        const response = await fetchWithAuth('/api/settings', {
+            method: 'PUT',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(updatedSettings)
+        });
+
+        console.log('Response status:', response.status, response.statusText);
+        if (response.ok) {
+            console.log('Settings saved successfully');
+            status.textContent = '✓ Saved';
+            status.className = 'save-status-inline success';
+            showNotification('Card design updated successfully', 'success');
+
+            // Update the global theme variable and re-render containers
+            cardDesignTheme = cardDesign;
+            if (currentTab === 'containers') {
+                filterContainers(); // This will re-render with the new theme
+            }
+        } else {
+            const error = await response.json();
+            status.textContent = '✗ Failed';
+            status.className = 'save-status-inline error';
+            showNotification('Failed to update card design: ' + (error.error || 'Unknown error'), 'error');
+        }
+    } catch (error) {
+        status.textContent = '✗ Error';
+        status.className = 'save-status-inline error';
+        console.error('Failed to save card design:', error);
+    }
+
+    setTimeout(() => {
+        status.textContent = '';
+        status.className = 'save-status-inline';
+    }, 3000);
+}
+
+// Date formatting options for the user's timezone preference
+function dateOptions(options = {}) {
+    return userTimezone ? { ...options, timeZone: userTimezone } : options;
+}
+
+// Locale for date formatting; undefined lets the browser pick
+function dateLocale() {
+    return userLocale || undefined;
+}
 
+// Load the user's timezone and locale preferences and fill the settings form
+async function loadDatePreferences() {
     try {
-        const response = await fetch('/api/containers/graph');
-        graphData = await response.json();
-        renderGraph(graphData);
+        const response = await fetchWithAuth('/api/preferences');
+        const prefs = await response.json();
+        userTimezone = prefs.timezone || '';
+        userLocale = prefs.locale || '';
     } catch (error) {
-        console.error('Error loading graph:', error);
-        container.innerHTML = '<div class="graph-error">Failed to load container graph</div>';
+        console.log('Error loading date preferences:', error);
+    }
+
+    const select = document.getElementById('prefTimezone');
+    if (select) {
+        if (select.options.length === 1 && typeof Intl.supportedValuesOf === 'function') {
+            Intl.supportedValuesOf('timeZone').forEach(zone => select.add(new Option(zone, zone)));
+        }
+        if (userTimezone && !Array.from(select.options).some(o => o.value === userTimezone)) {
+            select.add(new Option(userTimezone, userTimezone));
+        }
+        select.value = userTimezone;
+    }
+    const localeInput = document.getElementById('prefLocale');
+    if (localeInput) {
+        localeInput.value = userLocale;
     }
 }
 
-function renderGraph(data) {
-    const container = document.getElementById('graphContainer');
+async function saveDatePreferences() {
+    const status = document.getElementById('datePrefsSaveStatus');
+    const timezone = document.getElementById('prefTimezone').value;
+    const locale = document.getElementById('prefLocale').value.trim();
 
-    if (!data.nodes || data.nodes.length === 0) {
-        container.innerHTML = '<div class="graph-empty">No containers to display</div>';
-        return;
+    status.textContent = 'Saving...';
+    status.className = 'save-status-inline saving';
+
+    try {
+        const response = await fetchWithAuth('/api/preferences', {
+            method: 'PUT',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ timezone, locale })
+        });
+        if (response.ok) {
+            userTimezone = timezone;
+            userLocale = locale;
+            status.textContent = '✓ Saved';
+            status.className = 'save-status-inline success';
+            loadData();
+        } else {
+            const error = await response.json();
+            status.textContent = '✗ ' + (error.error || 'Failed');
+            status.className = 'save-status-inline error';
+        }
+    } catch (error) {
+        status.textContent = '✗ Error';
+        status.className = 'save-status-inline error';
+        console.error('Failed to save date preferences:', error);
     }
 
-    // Clear loading message
-    container.innerHTML = '';
+    setTimeout(() => {
+        status.textContent = '';
+        status.className = 'save-status-inline';
+    }, 3000);
+}
 
-    // Build lists for dropdowns
-    buildGraphDropdowns(data);
+async function loadUISettings() {
+    await loadDatePreferences();
 
-    // Count edge types
-    updateEdgeCounts(data.edges);
+    try {
+        const response = await fetchWithAuth('/api/settings');
+        const settings = await response.json();
 
-    // Assign colors to compose projects
-    const composeProjects = [...new Set(data.nodes.map(n => n.compose_project).filter(p => p))];
-    const projectColors = {};
-    const colors = ['#3498db', '#9b59b6', '#e67e22', '#1abc9c', '#e74c3c', '#f39c12', '#2ecc71', '#34495e'];
-    composeProjects.forEach((project, i) => {
-        projectColors[project] = colors[i % colors.length];
-    });
+        // Update global variable (with fallback to default)
+        if (settings.ui && settings.ui.card_design) {
+            cardDesignTheme = settings.ui.card_design;
+        } else {
+            cardDesignTheme = 'material'; // default if not set
+        }
 
-    // Build Cytoscape elements
-    const elements = {
-        nodes: data.nodes.map(node => ({
-            data: {
-                id: node.id,
-                label: node.name,
-                nodeType: node.node_type || 'container',
-                state: node.state,
-                image: node.image,
-                host: node.host_name,
-                composeProject: node.compose_project || '',
-                projectColor: projectColors[node.compose_project] || null
-            }
-        })),
-        edges: data.edges.map(edge => ({
-            data: {
-                id: `${edge.source}-${edge.target}-${edge.type}`,
-                source: edge.source,
-                target: edge.target,
-                label: edge.label,
-                type: edge.type
-            }
-        }))
-    };
+        // Update dropdown if on settings page
+        const dropdown = document.getElementById('cardDesignTheme');
+        if (dropdown) {
+            dropdown.value = cardDesignTheme;
+        }
+    } catch (error) {
+        console.log('Error loading UI settings:', error);
+        cardDesignTheme = 'material'; // fallback to default
+    }
+}
 
-    // Initialize Cytoscape
-    cy = cytoscape({
-        container: container,
-        elements: elements,
-        style: [
-            // Node styles
-            {
-                selector: 'node',
-                style: {
-                    'label': 'data(label)',
-                    'text-valign': 'center',
-                    'text-halign': 'center',
-                    'background-color': '#95a5a6',
-                    'color': '#fff',
-                    'text-outline-color': '#2c3e50',
-                    'text-outline-width': 2,
-                    'font-size': '12px',
-                    'width': 50,
-                    'height': 50,
-                    'shape': 'ellipse'
-                }
-            },
-            // Network nodes - different shape and color
-            {
-                selector: 'node[nodeType="network"]',
-                style: {
-                    'shape': 'diamond',
-                    'background-color': '#3498db',
-                    'border-width': 3,
-                    'border-color': '#2980b9',
-                    'width': 60,
-                    'height': 60,
-                    'font-size': '11px',
-                    'font-weight': 'bold'
-                }
-            },
-            {
-                selector: 'node[state="running"]',
-                style: {
-                    'background-color': '#2ecc71',
-                    'border-width': 3,
-                    'border-color': '#27ae60'
-                }
-            },
-            {
-                selector: 'node[state="exited"]',
-                style: {
-                    'background-color': '#95a5a6',
-                    'border-width': 3,
-                    'border-color': '#7f8c8d'
-                }
-            },
-            {
-                selector: 'node[state="paused"]',
-                style: {
-                    'background-color': '#f39c12',
-                    'border-width': 3,
-                    'border-color': '#e67e22'
-                }
-            },
-            {
-                selector: 'node.project-colored',
-                style: {
-                    'background-color': 'data(projectColor)',
-                    'border-color': 'data(projectColor)',
-                    'border-width': 4
-                }
-            },
-            {
-                selector: 'node.dimmed',
-                style: {
-                    'opacity': 0.2
-                }
-            },
-            {
-                selector: 'node.highlighted',
-                style: {
-                    'border-width': 6,
-                    'border-color': '#f1c40f',
-                    'z-index': 999
-                }
-            },
-            {
-                selector: 'node:selected',
-                style: {
-                    'border-width': 5,
-                    'border-color': '#3498db'
-                }
-            },
-            // Edge styles
-            {
-                selector: 'edge',
-                style: {
-                    'curve-style': 'bezier',
-                    'target-arrow-shape': 'none',
+// Initialize settings when switching to settings tab
+document.addEventListener('DOMContentLoaded', () => {
+    // Load settings immediately on page load
+    loadScannerSettings();
+    loadTelemetrySettings();
+    loadTelemetryPrivacy();
+    loadUISettings();
+
+    // Load settings when settings tab is clicked
+    const settingsTab = document.querySelector('[data-tab="settings"]');
+    if (settingsTab) {
+        settingsTab.addEventListener('click', () => {
+            setTimeout(() => {
+                loadScannerSettings();
+                loadTelemetrySettings();
+                loadTelemetryPrivacy();
+                loadUISettings();
+                loadCollectors();
+            }, 100);
+        });
+    }
+});
+
+// Custom Collectors Management
+
+async function loadCollectors() {
+    try {
+        // Fetch telemetry status which includes all endpoints with status info
+        const [statusResponse, debugResponse] = await Promise.all([
+            fetch('/api/telemetry/status'),
+            fetch('/api/telemetry/debug-enabled')
+        ]);
+
+        if (!statusResponse.ok) {
+            console.error('Failed to fetch telemetry status, status:', statusResponse.status);
+            throw new Error('Failed to load collectors');
+        }
+
+        const collectors = await statusResponse.json();
+        const debugInfo = debugResponse.ok ? await debugResponse.json() : { debug_enabled: false };
+
+        console.log('Loaded collectors with status:', collectors);
+        console.log('Debug mode:', debugInfo.debug_enabled);
+        renderCollectors(collectors, debugInfo.debug_enabled);
+    } catch (error) {
+        console.error('Error loading collectors:', error);
+        showNotification('Failed to load collectors', 'error');
+    }
+}
+
+function renderCollectors(collectors, debugEnabled = false) {
+    const collectorsList = document.getElementById('collectorsList');
+
+    // Separate community and custom collectors
+    const communityCollector = collectors.find(c => c.name === 'community');
+    const customCollectors = collectors.filter(c => c.name !== 'community');
+
+    let html = '';
+
+    // Render community collector if exists
+    if (communityCollector) {
+        const lastSuccess = communityCollector.last_success ? new Date(communityCollector.last_success) : null;
+        const lastFailure = communityCollector.last_failure ? new Date(communityCollector.last_failure) : null;
+        const statusText = formatTelemetryStatus(lastSuccess, lastFailure);
+        const statusClass = getStatusClass(lastSuccess, lastFailure);
+
+        html += `
+            <div class="collector-item community-collector" style="background: #f8f9fa; border: 2px solid #667eea; margin-bottom: 20px; padding: 20px;">
+                <div style="display: flex; justify-content: space-between; align-items: flex-start;">
+                    <div style="flex: 1;">
+                        <div class="collector-name" style="font-size: 16px; margin-bottom: 8px;">
+                            <strong>📊 Community Collector</strong>
+                            <span class="collector-status ${communityCollector.enabled ? 'enabled' : 'disabled'}">
+                                ${communityCollector.enabled ? 'Enabled' : 'Disabled'}
+                            </span>
+                        </div>
+                        <div class="collector-url" style="margin: 8px 0; color: #666; font-size: 13px;">${escapeHtml(communityCollector.url)}</div>
+                        <p style="margin: 10px 0; color: #555; font-size: 14px;">
+                            Help improve Container Census by sharing anonymous usage statistics.
+                        </p>
+
+                        <div class="telemetry-info" style="display: grid; grid-template-columns: 1fr 1fr; gap: 15px; margin: 15px 0; padding: 15px; background: white; border-radius: 6px;">
+                            <div class="info-column">
+                                <h4 style="margin: 0 0 8px 0; font-size: 13px; color: #2e7d32;">✓ What gets shared:</h4>
+                                <ul style="margin: 0; padding-left: 20px; font-size: 12px; color: #666;">
+                                    <li>Container Census version</li>
+                                    <li>Number of containers and hosts</li>
+                                    <li>Popular container images (names only)</li>
+                                    <li>Container registry distribution</li>
+                                    <li>Geographic region (timezone-based)</li>
+                                </ul>
+                            </div>
+                            <div class="info-column">
+                                <h4 style="margin: 0 0 8px 0; font-size: 13px; color: #c62828;">✗ What is NOT shared:</h4>
+                                <ul style="margin: 0; padding-left: 20px; font-size: 12px; color: #666;">
+                                    <li>Host names or IP addresses</li>
+                                    <li>Container names or env variables</li>
+                                    <li>Any credentials or secrets</li>
+                                    <li>Personal information</li>
+                                </ul>
+                            </div>
+                        </div>
+
+                        ${statusText ? `<div class="telemetry-status ${statusClass}">${statusText}</div>` : ''}
+                        ${lastFailure && communityCollector.last_failure_reason ?
+                            `<div class="telemetry-error" title="${escapeHtml(communityCollector.last_failure_reason)}">
+                                ⚠ ${escapeHtml(communityCollector.last_failure_reason.substring(0, 80))}${communityCollector.last_failure_reason.length > 80 ? '...' : ''}
+                            </div>` : ''}
+                        ${debugEnabled && lastFailure ?
+                            `<div style="margin-top: 10px;">
+                                <button class="btn btn-sm btn-secondary" onclick="resetCircuitBreaker('${escapeAttr(communityCollector.name)}')" style="font-size: 12px;">
+                                    🔧 Reset Circuit Breaker
+                                </button>
+                            </div>` : ''}
+                    </div>
+                    <div style="margin-left: 20px;">
+                        <button class="btn ${communityCollector.enabled ? 'btn-warning' : 'btn-primary'}"
+                                onclick="toggleCollector('${escapeAttr(communityCollector.name)}', ${!communityCollector.enabled})"
+                                style="min-width: 100px; white-space: nowrap;">
+                            ${communityCollector.enabled ? 'Disable' : 'Enable'}
+                        </button>
+                    </div>
+                </div>
+            </div>
+        `;
+    }
+
+    // Add separator before custom collectors
+    if (customCollectors.length > 0) {
+        html += '<h4 style="margin: 30px 0 15px 0; color: #666;">Custom Collectors</h4>';
+    }
+
+    if (customCollectors.length === 0) {
+        html += '<p style="color: #666; font-style: italic; margin-top: 20px;">No custom collectors configured.</p>';
+    } else {
+        html += customCollectors.map(collector => {
+            const lastSuccess = collector.last_success ? new Date(collector.last_success) : null;
+            const lastFailure = collector.last_failure ? new Date(collector.last_failure) : null;
+            const statusText = formatTelemetryStatus(lastSuccess, lastFailure);
+            const statusClass = getStatusClass(lastSuccess, lastFailure);
+
+            return `
+            <div class="collector-item">
+                <div class="collector-info">
+                    <div class="collector-name">
+                        ${escapeHtml(collector.name)}
+                        <span class="collector-status ${collector.enabled ? 'enabled' : 'disabled'}">
+                            ${collector.enabled ? 'Enabled' : 'Disabled'}
+                        </span>
+                    </div>
+                    <div class="collector-url">${escapeHtml(collector.url)}</div>
+                    ${collector.api_key ? '<div style="font-size: 12px; color: #999;">🔑 API Key configured</div>' : ''}
+                    ${statusText ? `<div class="telemetry-status ${statusClass}">${statusText}</div>` : ''}
+                    ${lastFailure && collector.last_failure_reason ?
+                        `<div class="telemetry-error" title="${escapeHtml(collector.last_failure_reason)}">
+                            ⚠ ${escapeHtml(collector.last_failure_reason.substring(0, 60))}${collector.last_failure_reason.length > 60 ? '...' : ''}
+                        </div>` : ''}
+                    ${debugEnabled && lastFailure ?
+                        `<div style="margin-top: 8px;">
+                            <button class="btn btn-sm" onclick="resetCircuitBreaker('${escapeAttr(collector.name)}')" style="font-size: 11px; padding: 4px 8px;">
+                                🔧 Reset Circuit Breaker
+                            </button>
+                        </div>` : ''}
+                </div>
+                <div class="collector-actions">
+                    <button class="btn btn-sm btn-secondary" onclick="toggleCollector('${escapeAttr(collector.name)}', ${!collector.enabled})">
+                        ${collector.enabled ? 'Disable' : 'Enable'}
+                    </button>
+                    <button class="btn btn-sm btn-danger" onclick="deleteCollector('${escapeAttr(collector.name)}')">
+                        Delete
+                    </button>
+                </div>
+            </div>
+            `;
+        }).join('');
+    }
+
+    collectorsList.innerHTML = html;
+}
+
+function formatTelemetryStatus(lastSuccess, lastFailure) {
+    if (!lastSuccess && !lastFailure) {
+        return 'No telemetry submitted yet';
+    }
+
+    if (!lastFailure || (lastSuccess && lastSuccess > lastFailure)) {
+        return `✓ Last success: ${formatTimeAgo(lastSuccess)}`;
+    } else {
+        return `✗ Last failure: ${formatTimeAgo(lastFailure)}`;
+    }
+}
+
+function getStatusClass(lastSuccess, lastFailure) {
+    if (!lastSuccess && !lastFailure) {
+        return 'status-unknown';
+    }
+
+    if (!lastFailure || (lastSuccess && lastSuccess > lastFailure)) {
+        return 'status-success';
+    } else {
+        return 'status-error';
+    }
+}
+
+async function testCollector() {
+    const url = document.getElementById('collectorURL').value.trim();
+    const apiKey = document.getElementById('collectorAPIKey').value.trim();
+    const status = document.getElementById('collectorSaveStatus');
+
+    if (!url) {
+        status.textContent = '✗ URL is required to test';
+        status.className = 'save-status-inline error';
+        setTimeout(() => status.textContent = '', 3000);
+        return;
+    }
+
+    status.textContent = 'Testing connection...';
+    status.className = 'save-status-inline saving';
+
+    const testData = { url };
+    if (apiKey) {
+        testData.api_key = apiKey;
+    }
+
+    try {
+        const response = await fetch('/api/telemetry/test-endpoint', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(testData)
+        });
+
+        const result = await response.json();
+
+        if (response.ok) {
+            status.textContent = '✓ Connection successful!';
+            status.className = 'save-status-inline success';
+        } else {
+            status.textContent = '✗ ' + (result.error || 'Connection failed');
+            status.className = 'save-status-inline error';
+        }
+    } catch (error) {
+        status.textContent = '✗ Connection failed: ' + error.message;
+        status.className = 'save-status-inline error';
+    }
+
+    setTimeout(() => status.textContent = '', 5000);
+}
+
+async function addCollector() {
+    const name = document.getElementById('collectorName').value.trim();
+    const url = document.getElementById('collectorURL').value.trim();
+    const apiKey = document.getElementById('collectorAPIKey').value.trim();
+    const enabled = document.getElementById('collectorEnabled').checked;
+    const status = document.getElementById('collectorSaveStatus');
+
+    // Validate inputs
+    if (!name) {
+        status.textContent = '✗ Name is required';
+        status.className = 'save-status-inline error';
+        setTimeout(() => status.textContent = '', 3000);
+        return;
+    }
+
+    if (!url) {
+        status.textContent = '✗ URL is required';
+        status.className = 'save-status-inline error';
+        setTimeout(() => status.textContent = '', 3000);
+        return;
+    }
+
+    // Show saving status
+    status.textContent = 'Saving...';
+    status.className = 'save-status-inline saving';
+
+    const endpoint = {
+        name,
+        url,
+        enabled
+    };
+
+    if (apiKey) {
+        endpoint.api_key = apiKey;
+    }
+
+    try {
+        const response = await fetch('/api/telemetry/endpoints', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(endpoint)
+        });
+
+        if (response.ok) {
+            status.textContent = '✓ Collector added successfully';
+            status.className = 'save-status-inline success';
+
+            // Clear form
+            document.getElementById('collectorName').value = '';
+            document.getElementById('collectorURL').value = '';
+            document.getElementById('collectorAPIKey').value = '';
+            document.getElementById('collectorEnabled').checked = true;
+
+            // Reload collectors list
+            await loadCollectors();
+
+            showNotification('Collector added successfully', 'success');
+        } else {
+            const error = await response.json();
+            status.textContent = '✗ Failed: ' + (error.error || 'Unknown error');
+            status.className = 'save-status-inline error';
+            showNotification('Failed to add collector', 'error');
+        }
+    } catch (error) {
+        status.textContent = '✗ Error: ' + error.message;
+        status.className = 'save-status-inline error';
+        showNotification('Error adding collector', 'error');
+    }
+
+    // Clear status after 3 seconds
+    setTimeout(() => {
+        status.textContent = '';
+        status.className = 'save-status-inline';
+    }, 3000);
+}
+
+async function toggleCollector(name, enabled) {
+    try {
+        const response = await fetch(`/api/telemetry/endpoints/${encodeURIComponent(name)}`, {
+            method: 'PUT',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ enabled })
+        });
+
+        if (response.ok) {
+            await loadCollectors();
+            showNotification(`Collector ${enabled ? 'enabled' : 'disabled'} successfully`, 'success');
+        } else {
+            const error = await response.json();
+            showNotification('Failed to update collector: ' + (error.error || 'Unknown error'), 'error');
+        }
+    } catch (error) {
+        showNotification('Error updating collector', 'error');
+    }
+}
+
+async function deleteCollector(name) {
+    if (!confirm(`Are you sure you want to delete the collector "${name}"?`)) {
+        return;
+    }
+
+    try {
+        const response = await fetch(`/api/telemetry/endpoints/${encodeURIComponent(name)}`, {
+            method: 'DELETE'
+        });
+
+        if (response.ok) {
+            await loadCollectors();
+            showNotification('Collector deleted successfully', 'success');
+        } else {
+            const error = await response.json();
+            showNotification('Failed to delete collector: ' + (error.error || 'Unknown error'), 'error');
+        }
+    } catch (error) {
+        showNotification('Error deleting collector', 'error');
+    }
+}
+
+async function resetCircuitBreaker(name) {
+    try {
+        const response = await fetch(`/api/telemetry/reset-circuit-breaker/${encodeURIComponent(name)}`, {
+            method: 'POST'
+        });
+
+        if (response.ok) {
+            await loadCollectors();
+            showNotification('Circuit breaker reset successfully - endpoint will retry on next submission', 'success');
+        } else {
+            const error = await response.json();
+            showNotification('Failed to reset circuit breaker: ' + (error.error || 'Unknown error'), 'error');
+        }
+    } catch (error) {
+        showNotification('Error resetting circuit breaker', 'error');
+    }
+}
+
+// Container History Functions
+
+async function loadContainerHistory() {
+    try {
+        const response = await fetch('/api/containers/lifecycle?limit=200');
+        lifecycles = await response.json() || [];
+
+        // Apply filters if any are active
+        applyCurrentFilters();
+    } catch (error) {
+        console.error('Error loading container history:', error);
+        document.getElementById('historyBody').innerHTML =
+            '<div class="error">Failed to load container history</div>';
+    }
+}
+
+function updateHistoryHostFilter() {
+    const select = document.getElementById('historyHostFilter');
+    if (!select || hosts.length === 0) return;
+
+    const currentValue = select.value;
+    select.innerHTML = '<option value="">All Hosts</option>' +
+        hosts.map(host => `<option value="${host.id}">${escapeHtml(host.name)}</option>`).join('');
+    select.value = currentValue;
+}
+
+function updateHistoryStats(lifecycles) {
+    const total = lifecycles.length;
+    const active = lifecycles.filter(l => l.is_active).length;
+    const inactive = total - active;
+
+    document.getElementById('historyTotalContainers').textContent = total;
+    document.getElementById('historyActiveContainers').textContent = active;
+    document.getElementById('historyInactiveContainers').textContent = inactive;
+}
+
+function renderContainerHistory(lifecycles) {
+    const container = document.getElementById('historyBody');
+
+    if (!lifecycles || lifecycles.length === 0) {
+        container.innerHTML = '<div class="loading">No container history available</div>';
+        return;
+    }
+
+    container.innerHTML = lifecycles.map(lifecycle => {
+        const firstSeen = new Date(lifecycle.first_seen);
+        const lastSeen = new Date(lifecycle.last_seen);
+        const lifetime = formatDuration(lastSeen - firstSeen);
+
+        const statusBadge = lifecycle.is_active
+            ? '<span class="state-badge state-running">Active</span>'
+            : '<span class="state-badge state-exited">Inactive</span>';
+
+        // State changes includes the initial detection (first_seen) + actual state changes
+        const stateChanges = 1 + (lifecycle.state_changes || 0);
+        const imageUpdates = lifecycle.image_updates || 0;
+        const restartEvents = lifecycle.restart_events || 0;
+
+        return `
+        <div class="history-card-modern ${lifecycle.is_active ? 'active' : 'inactive'}">
+            <div class="history-card-header-modern">
+                <div class="history-card-left">
+                    <div class="history-status-indicator ${lifecycle.is_active ? 'active' : 'inactive'}">
+                        ${lifecycle.is_active ? '✅' : '⏸️'}
+                    </div>
+                    <div class="history-card-info">
+                        <div class="history-card-name">${escapeHtml(lifecycle.container_name)}</div>
+                        <div class="history-card-meta">
+                            <span class="meta-item">📍 ${escapeHtml(lifecycle.host_name)}</span>
+                            <span class="meta-item">⏱️ ${lifetime}</span>
+                        </div>
+                    </div>
+                </div>
+                <button class="btn btn-primary btn-timeline" onclick="viewContainerTimeline(${lifecycle.host_id}, '${escapeAttr(lifecycle.container_id)}', '${escapeAttr(lifecycle.container_name)}')" title="View detailed timeline">
+                    <span class="timeline-icon">📅</span>
+                    <span class="timeline-text">View Timeline</span>
+                </button>
+            </div>
+
+            <div class="history-card-content">
+                <div class="history-detail-row">
+                    <span class="detail-label">🖼️ Image</span>
+                    <code class="detail-value image-value" title="${escapeHtml(lifecycle.image)}">${escapeHtml(lifecycle.image)}</code>
+                </div>
+
+                <div class="history-metrics-grid">
+                    <div class="metric-box">
+                        <div class="metric-icon">👁️</div>
+                        <div class="metric-content">
+                            <div class="metric-label">First Seen</div>
+                            <div class="metric-value">${formatTimeAgo(firstSeen)}</div>
+                            <div class="metric-subtext">${formatDateTime(lifecycle.first_seen)}</div>
+                        </div>
+                    </div>
+
+                    <div class="metric-box">
+                        <div class="metric-icon">🕐</div>
+                        <div class="metric-content">
+                            <div class="metric-label">Last Seen</div>
+                            <div class="metric-value">${formatTimeAgo(lastSeen)}</div>
+                            <div class="metric-subtext">${formatDateTime(lifecycle.last_seen)}</div>
+                        </div>
+                    </div>
+
+                    <div class="metric-box ${stateChanges > 5 ? 'metric-warning' : ''}">
+                        <div class="metric-icon">🔄</div>
+                        <div class="metric-content">
+                            <div class="metric-label">State Changes</div>
+                            <div class="metric-value">${stateChanges}</div>
+                        </div>
+                    </div>
+
+                    <div class="metric-box ${imageUpdates > 0 ? 'metric-info' : ''}">
+                        <div class="metric-icon">⬆️</div>
+                        <div class="metric-content">
+                            <div class="metric-label">Image Updates</div>
+                            <div class="metric-value">${imageUpdates}</div>
+                        </div>
+                    </div>
+
+                    <div class="metric-box ${restartEvents > 10 ? 'metric-alert' : restartEvents > 0 ? 'metric-warning' : ''}">
+                        <div class="metric-icon">🔁</div>
+                        <div class="metric-content">
+                            <div class="metric-label">Restarts</div>
+                            <div class="metric-value">${restartEvents}</div>
+                        </div>
+                    </div>
+                </div>
+            </div>
+        </div>
+        `;
+    }).join('');
+}
+
+let currentTimelineContainer = null;
+
+async function viewContainerTimeline(hostId, containerId, containerName) {
+    currentTimelineContainer = { hostId, containerId, containerName };
+    document.getElementById('timelineContainerName').textContent = containerName;
+    document.getElementById('timelineContent').innerHTML = '<div class="loading">Loading timeline...</div>';
+    document.getElementById('timelineModal').classList.add('show');
+
+    try {
+        const [response, updatesResponse, historyResponse] = await Promise.all([
+            fetch(`/api/containers/lifecycle/${hostId}/${encodeURIComponent(containerName)}`),
+            fetch(`/api/updates?host_id=${hostId}&container_name=${encodeURIComponent(containerName)}&limit=20`),
+            fetch(`/api/containers/image-history/${hostId}/${encodeURIComponent(containerName)}`)
+        ]);
+        const events = await response.json();
+        const updates = updatesResponse.ok ? await updatesResponse.json() : [];
+        const imageRuns = historyResponse.ok ? await historyResponse.json() : [];
+
+        if (!events || events.length === 0) {
+            document.getElementById('timelineContent').innerHTML = '<p>No lifecycle events found for this container.</p>';
+        } else {
+            renderTimeline(events);
+        }
+        if (updates.length > 0) {
+            document.getElementById('timelineContent').insertAdjacentHTML('afterbegin', `
+                <h4>📦 Updates</h4>
+                ${renderContainerUpdatesTable(updates, false)}
+            `);
+        }
+        if (imageRuns.length > 1) {
+            document.getElementById('timelineContent').insertAdjacentHTML('afterbegin', `
+                <h4>🧬 Image History</h4>
+                ${renderImageRunsTable(imageRuns.slice().reverse())}
+            `);
+        }
+    } catch (error) {
+        console.error('Error loading timeline:', error);
+        document.getElementById('timelineContent').innerHTML = '<p class="error">Failed to load timeline events</p>';
+    }
+}
+
+// Update history: every update performed from census with its result
+const updateStatusBadges = {
+    'succeeded': '<span class="badge badge-success">Succeeded</span>',
+    'failed': '<span class="badge badge-error">Failed</span>',
+    'rolled_back': '<span class="badge badge-warning">Rolled back</span>'
+};
+
+function shortImageID(id) {
+    return id ? id.replace('sha256:', '').substring(0, 12) : '-';
+}
+
+function imageRunVulnerabilitiesCell(run) {
+    const v = run.vulnerabilities;
+    if (!v) return '<span class="badge badge-secondary">Not scanned</span>';
+    if (!v.success) return '<span class="badge badge-secondary">Scan failed</span>';
+    const counts = v.critical > 0 ? `<span class="badge badge-error">${v.critical} critical</span>`
+        : v.high > 0 ? `<span class="badge badge-warning">${v.high} high</span>`
+        : v.total > 0 ? `<span class="badge badge-secondary">${v.total}</span>`
+        : '<span class="badge badge-success">Clean</span>';
+    const matches = (v.matches || []).map(id => `<code>${escapeHtml(id)}</code>`).join(' ');
+    return matches ? `${counts} ${matches}` : counts;
+}
+
+// Image runs of a container, newest first: the periods in which it ran one image build
+function renderImageRunsTable(runs) {
+    return `
+        <table class="vuln-table">
+            <thead>
+                <tr>
+                    <th>Image</th><th>Image ID</th><th>First Seen</th><th>Last Seen</th><th>Vulnerabilities</th>
+                </tr>
+            </thead>
+            <tbody>
+                ${runs.map(run => `
+                    <tr>
+                        <td><code>${escapeHtml(run.image)}</code></td>
+                        <td><code>${shortImageID(run.image_id)}</code></td>
+                        <td>${formatDateTime(run.first_seen)}</td>
+                        <td>${run.current ? '<span class="badge badge-success">Running</span>' : formatDateTime(run.last_seen)}</td>
+                        <td>${imageRunVulnerabilitiesCell(run)}</td>
+                    </tr>
+                `).join('')}
+            </tbody>
+        </table>
+    `;
+}
+
+function renderContainerUpdatesTable(updates, showContainer) {
+    return `
+        <table class="vuln-table">
+            <thead>
+                <tr>
+                    <th>When</th>
+                    ${showContainer ? '<th>Container</th><th>Host</th>' : ''}
+                    <th>Image</th><th>From → To</th><th>By</th><th>Duration</th><th>Result</th>
+                </tr>
+            </thead>
+            <tbody>
+                ${updates.map(u => `
+                    <tr>
+                        <td>${formatDateTime(u.started_at)}</td>
+                        ${showContainer ? `<td>${escapeHtml(u.container_name)}</td><td>${escapeHtml(u.host_name)}</td>` : ''}
+                        <td><code>${escapeHtml(u.image)}</code></td>
+                        <td><code>${shortImageID(u.old_image_id)}</code> → <code>${shortImageID(u.new_image_id)}</code></td>
+                        <td>${escapeHtml(u.triggered_by || '-')} <small>(${escapeHtml(u.trigger)})</small></td>
+                        <td>${(u.duration_ms / 1000).toFixed(1)}s</td>
+                        <td>${updateStatusBadges[u.status] || escapeHtml(u.status)}${u.error ? `<br><small>${escapeHtml(u.error)}</small>` : ''}</td>
+                    </tr>
+                `).join('')}
+            </tbody>
+        </table>
+    `;
+}
+
+function openUpdateHistoryModal() {
+    document.getElementById('updateHistoryModal').classList.add('show');
+    loadUpdateHistory();
+    loadHostEngines();
+    if (!currentUser || currentUser.admin) {
+        loadCanaryImages();
+        loadCanaryRollouts();
+    }
+}
+
+function closeUpdateHistoryModal() {
+    document.getElementById('updateHistoryModal').classList.remove('show');
+    clearTimeout(canaryRefreshTimer);
+}
+
+// Start and end of the chosen period; "weekend" is the last full Saturday-Sunday
+function updateHistoryRange(period) {
+    const now = new Date();
+    if (period === 'weekend') {
+        const start = new Date(now.getFullYear(), now.getMonth(), now.getDate());
+        start.setDate(start.getDate() - ((start.getDay() + 1) % 7) - (start.getDay() === 6 ? 7 : 0));
+        const end = new Date(start);
+        end.setDate(end.getDate() + 2);
+        return { start, end };
+    }
+    if (period) {
+        return { start: new Date(now.getTime() - parseInt(period) * 24 * 60 * 60 * 1000), end: null };
+    }
+    return { start: null, end: null };
+}
+
+async function loadUpdateHistory() {
+    const content = document.getElementById('updateHistoryContent');
+    const { start, end } = updateHistoryRange(document.getElementById('updateHistoryPeriod').value);
+    const params = new URLSearchParams();
+    if (start) params.set('start', start.toISOString());
+    if (end) params.set('end', end.toISOString());
+    const status = document.getElementById('updateHistoryStatus').value;
+    if (status) params.set('status', status);
+
+    content.innerHTML = '<div class="loading">Loading updates...</div>';
+    try {
+        const response = await fetch(`/api/updates?${params}`);
+        const updates = await response.json();
+        if (!response.ok) throw new Error(updates.error || `HTTP ${response.status}`);
+
+        if (updates.length === 0) {
+            content.innerHTML = '<p><em>No updates in this period.</em></p>';
+            return;
+        }
+        const failed = updates.filter(u => u.status !== 'succeeded').length;
+        content.innerHTML = `
+            <p><strong>${updates.length}</strong> updates${failed ? `, ${failed} failed or rolled back` : ''}.</p>
+            ${renderContainerUpdatesTable(updates, true)}
+        `;
+    } catch (error) {
+        content.innerHTML = `<div class="error">Failed to load updates: ${escapeHtml(error.message)}</div>`;
+    }
+}
+
+const engineWarningBadges = {
+    'reboot_required': '<span class="badge badge-warning">Reboot pending</span>',
+    'daemon_restart_required': '<span class="badge badge-warning">Daemon restart pending</span>',
+    'outdated_engine': '<span class="badge badge-error">Outdated engine</span>'
+};
+
+async function loadHostEngines() {
+    const content = document.getElementById('hostEnginesContent');
+    content.innerHTML = '<div class="loading">Loading engines...</div>';
+    try {
+        const response = await fetch('/api/hosts/engines');
+        const report = await response.json();
+        if (!response.ok) throw new Error(report.error || `HTTP ${response.status}`);
+        renderHostEngines(report);
+    } catch (error) {
+        content.innerHTML = `<div class="error">Failed to load engines: ${escapeHtml(error.message)}</div>`;
+    }
+}
+
+function renderHostEngines(report) {
+    const content = document.getElementById('hostEnginesContent');
+    if (report.hosts.length === 0) {
+        content.innerHTML = '<p><em>No Docker hosts.</em></p>';
+        return;
+    }
+    const versions = Object.entries(report.versions)
+        .sort(([a], [b]) => b.localeCompare(a, undefined, { numeric: true }))
+        .map(([version, count]) => `<code>${escapeHtml(version)}</code> × ${count}`)
+        .join(', ');
+    const warned = report.hosts.filter(h => h.warnings.length > 0).length;
+    const rows = report.hosts.map(h => {
+        const sys = h.system;
+        if (!sys) {
+            return `<tr><td>${escapeHtml(h.host_name)}</td><td colspan="4"><em>Not scanned yet</em></td></tr>`;
+        }
+        const details = [];
+        if (h.majors_behind > 0) details.push(`${h.majors_behind} major version(s) behind ${escapeHtml(report.latest_version)}`);
+        (sys.reboot_reasons || []).forEach(r => details.push(escapeHtml(r)));
+        if (sys.daemon_restart_required) details.push(`dockerd ${escapeHtml(sys.installed_docker_version)} installed`);
+        return `
+            <tr>
+                <td>${escapeHtml(h.host_name)}</td>
+                <td><code>${escapeHtml(sys.docker_version || '-')}</code>${sys.api_version ? ` <small>(API ${escapeHtml(sys.api_version)})</small>` : ''}</td>
+                <td>${escapeHtml(sys.operating_system || '-')}<br><small>${escapeHtml(sys.kernel_version || '')}</small></td>
+                <td>${h.warnings.map(w => engineWarningBadges[w] || escapeHtml(w)).join(' ') || '<span class="badge badge-success">OK</span>'}</td>
+                <td><small>${details.join('<br>')}</small></td>
+            </tr>
+        `;
+    }).join('');
+    content.innerHTML = `
+        <p>Newest engine: <strong>${escapeHtml(report.latest_version || '-')}</strong>${versions ? ` &middot; ${versions}` : ''}${warned ? ` &middot; <strong>${warned}</strong> host(s) need attention` : ''}</p>
+        <div class="table-container">
+            <table class="report-table">
+                <thead><tr><th>Host</th><th>Engine</th><th>OS / Kernel</th><th>Status</th><th>Details</th></tr></thead>
+                <tbody>${rows}</tbody>
+            </table>
+        </div>
+    `;
+}
+
+const canaryStatusBadges = {
+    'updating': '<span class="badge badge-warning">Updating canary</span>',
+    'soaking': '<span class="badge badge-warning">Soaking</span>',
+    'rolling_out': '<span class="badge badge-warning">Rolling out</span>',
+    'completed': '<span class="badge badge-success">Completed</span>',
+    'halted': '<span class="badge badge-error">Halted</span>',
+    'interrupted': '<span class="badge badge-secondary">Interrupted</span>'
+};
+
+const canaryTargetBadges = {
+    'pending': '<span class="badge badge-secondary">Pending</span>',
+    'succeeded': '<span class="badge badge-success">Updated</span>',
+    'failed': '<span class="badge badge-error">Failed</span>',
+    'skipped': '<span class="badge badge-secondary">Skipped</span>'
+};
+
+let canaryRefreshTimer = null;
+
+// Containers of each image running on more than one Docker container, for a canary rollout
+function canaryImageGroups() {
+    const groups = {};
+    containers.forEach(c => {
+        const host = hosts.find(h => h.id === c.host_id);
+        if (!host || host.host_type === 'incus') return;
+        const image = (c.image_tags && c.image_tags.length > 0 && c.image.startsWith('sha256:')) ? c.image_tags[0] : c.image;
+        (groups[image] = groups[image] || []).push(c);
+    });
+    return Object.entries(groups)
+        .filter(([, list]) => list.length > 1)
+        .sort(([a], [b]) => a.localeCompare(b));
+}
+
+function loadCanaryImages() {
+    const select = document.getElementById('canaryImage');
+    const groups = canaryImageGroups();
+    select.innerHTML = groups.length === 0
+        ? '<option value="">No image runs on several containers</option>'
+        : groups.map(([image, list]) => {
+            const updates = list.filter(c => c.update_available).length;
+            return `<option value="${escapeAttr(image)}">${escapeHtml(image)} (${list.length} containers${updates ? ', update available' : ''})</option>`;
+        }).join('');
+    loadCanaryTargets();
+}
+
+function loadCanaryTargets() {
+    const image = document.getElementById('canaryImage').value;
+    const group = canaryImageGroups().find(([name]) => name === image);
+    const list = group ? group[1] : [];
+    document.getElementById('canaryTarget').innerHTML = '<option value="">Canary: first by host name</option>' +
+        list.map(c => `<option value="${c.host_id}|${escapeAttr(c.name)}">Canary: ${escapeHtml(c.name)} on ${escapeHtml(c.host_name)}</option>`).join('');
+}
+
+async function startCanaryRollout() {
+    const image = document.getElementById('canaryImage').value;
+    if (!image) return;
+    const body = {
+        image,
+        soak_minutes: parseInt(document.getElementById('canarySoakMinutes').value) || 0,
+        max_restarts: parseInt(document.getElementById('canaryMaxRestarts').value) || 0
+    };
+    const canary = document.getElementById('canaryTarget').value;
+    if (canary) {
+        const [hostID, name] = canary.split('|');
+        body.canary = { host_id: parseInt(hostID), container_name: name };
+    }
+    if (!confirm(`Update ${image} on all containers running it, starting with a canary watched for ${body.soak_minutes} minutes?`)) return;
+
+    try {
+        const response = await fetch('/api/updates/canary', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(body)
+        });
+        const result = await response.json();
+        if (!response.ok) throw new Error(result.error || `HTTP ${response.status}`);
+        showNotification(`Canary rollout of ${image} started`, 'success');
+        loadCanaryRollouts();
+    } catch (error) {
+        showNotification('Failed to start canary rollout: ' + error.message, 'error');
+    }
+}
+
+async function cancelCanaryRollout(id) {
+    if (!confirm('Halt this rollout? A container being updated finishes its update; the rest are skipped.')) return;
+
+    try {
+        const response = await fetch(`/api/updates/canary/${id}/cancel`, { method: 'POST' });
+        const result = await response.json();
+        if (!response.ok) throw new Error(result.error || `HTTP ${response.status}`);
+        showNotification('Canary rollout cancelled', 'success');
+        loadCanaryRollouts();
+    } catch (error) {
+        showNotification('Failed to cancel canary rollout: ' + error.message, 'error');
+    }
+}
+
+async function loadCanaryRollouts() {
+    const content = document.getElementById('canaryRollouts');
+    clearTimeout(canaryRefreshTimer);
+    try {
+        const response = await fetch('/api/updates/canary?limit=20');
+        const rollouts = await response.json();
+        if (!response.ok) throw new Error(rollouts.error || `HTTP ${response.status}`);
+
+        if (rollouts.length === 0) {
+            content.innerHTML = '<p><em>No canary rollouts yet.</em></p>';
+            return;
+        }
+        const running = ['updating', 'soaking', 'rolling_out'];
+        content.innerHTML = `
+            <table class="vuln-table">
+                <thead>
+                    <tr><th>Started</th><th>Image</th><th>By</th><th>Soak</th><th>Containers</th><th>Status</th><th></th></tr>
+                </thead>
+                <tbody>
+                    ${rollouts.map(c => `
+                        <tr>
+                            <td>${formatDateTime(c.created_at)}</td>
+                            <td><code>${escapeHtml(c.image)}</code></td>
+                            <td>${escapeHtml(c.triggered_by || '-')}</td>
+                            <td>${c.soak_minutes} min${c.status === 'soaking' && c.soak_until ? `<br><small>until ${formatDateTime(c.soak_until)}</small>` : ''}</td>
+                            <td>${c.targets.map((t, i) => `${i === 0 ? '🐤 ' : ''}${escapeHtml(t.container_name)} on ${escapeHtml(t.host_name)} ${canaryTargetBadges[t.status] || escapeHtml(t.status)}${t.error ? `<br><small>${escapeHtml(t.error)}</small>` : ''}`).join('<br>')}</td>
+                            <td>${canaryStatusBadges[c.status] || escapeHtml(c.status)}${c.reason ? `<br><small>${escapeHtml(c.reason)}</small>` : ''}</td>
+                            <td>${running.includes(c.status) ? `<button class="btn btn-secondary btn-sm" onclick="cancelCanaryRollout(${c.id})">Cancel</button>` : ''}</td>
+                        </tr>
+                    `).join('')}
+                </tbody>
+            </table>
+        `;
+
+        // Follow running rollouts while the dialog is open
+        if (rollouts.some(c => running.includes(c.status)) && document.getElementById('updateHistoryModal').classList.contains('show')) {
+            canaryRefreshTimer = setTimeout(() => {
+                loadCanaryRollouts();
+                loadUpdateHistory();
+            }, 15000);
+        }
+    } catch (error) {
+        content.innerHTML = `<div class="error">Failed to load canary rollouts: ${escapeHtml(error.message)}</div>`;
+    }
+}
+
+function renderTimeline(events) {
+    if (!events || events.length === 0) {
+        document.getElementById('timelineContent').innerHTML = '<p>No lifecycle events found.</p>';
+        return;
+    }
+
+    // Calculate summary statistics
+    const firstEvent = events[0];
+    const lastEvent = events[events.length - 1];
+    // State changes includes first_seen + actual state transitions
+    const actualStateChanges = events.filter(e => e.event_type === 'started' || e.event_type === 'stopped' || e.event_type === 'state_change').length;
+    const stateChanges = 1 + actualStateChanges; // +1 for first_seen
+    const imageUpdates = events.filter(e => e.event_type === 'image_updated').length;
+
+    // Extract scan count from last_seen event if present
+    let totalScans = 'N/A';
+    if (lastEvent && lastEvent.event_type === 'last_seen') {
+        const match = lastEvent.description.match(/seen (\d+) times/);
+        if (match) {
+            totalScans = parseInt(match[1]);
+        }
+    }
+
+    // Calculate duration
+    const firstTime = new Date(firstEvent.timestamp);
+    const lastTime = new Date(lastEvent.timestamp);
+    const durationMs = lastTime - firstTime;
+    const durationDays = Math.floor(durationMs / (1000 * 60 * 60 * 24));
+    const durationText = durationDays > 0 ? `${durationDays} days` : 'same day';
+
+    // Determine status
+    const isActive = lastEvent.new_state === 'running';
+    const statusText = isActive ? 'Active (running)' : lastEvent.new_state === 'exited' ? 'Inactive (stopped)' : 'Unknown';
+    const statusClass = isActive ? 'badge-success' : 'badge-warning';
+
+    // Build summary banner
+    const summaryHTML = `
+        <div class="timeline-summary" style="background: #f8f9fa; border: 1px solid #dee2e6; border-radius: 8px; padding: 16px; margin-bottom: 20px;">
+            <div style="display: flex; align-items: center; margin-bottom: 12px;">
+                <span style="font-size: 24px; margin-right: 10px;">📊</span>
+                <div>
+                    <strong style="font-size: 16px;">Container History Summary</strong>
+                    <div style="color: #666; font-size: 13px; margin-top: 4px;">
+                        ${formatDate(firstEvent.timestamp)} to ${formatDate(lastEvent.timestamp)} (${durationText})
+                    </div>
+                </div>
+            </div>
+            <div style="display: grid; grid-template-columns: repeat(auto-fit, minmax(150px, 1fr)); gap: 12px; font-size: 14px;">
+                <div><strong>Total Observations:</strong> ${totalScans}</div>
+                <div><strong>State Changes:</strong> ${stateChanges}</div>
+                <div><strong>Image Updates:</strong> ${imageUpdates}</div>
+                <div><strong>Current Status:</strong> <span class="badge ${statusClass}">${statusText}</span></div>
+            </div>
+        </div>
+    `;
+
+    const timelineHTML = events.map(event => {
+        const eventIcon = getEventIcon(event.event_type);
+        const eventClass = getEventClass(event.event_type);
+
+        let details = '';
+        if (event.old_state && event.new_state) {
+            details = `<span class="state-badge state-${event.old_state}">${event.old_state}</span> → <span class="state-badge state-${event.new_state}">${event.new_state}</span>`;
+        } else if (event.old_image_tag && event.new_image_tag) {
+            // New format: show both tag and SHA
+            details = `<code>${event.old_image_tag}</code> <span class="text-muted">(${event.old_image_sha})</span> → <code>${event.new_image_tag}</code> <span class="text-muted">(${event.new_image_sha})</span>`;
+        } else if (event.old_image && event.new_image) {
+            // Fallback to old format for backward compatibility
+            details = `<code>${event.old_image}</code> → <code>${event.new_image}</code>`;
+        } else if (event.restart_count) {
+            details = `<strong>${event.restart_count} restart(s)</strong>`;
+        } else if (event.annotation_id) {
+            details = `<button class="btn btn-xs btn-secondary admin-only" onclick="deleteAnnotation(${event.annotation_id}, 'timeline')">Delete annotation</button>`;
+        }
+        // Annotations are free text typed by users
+        const description = event.event_type === 'annotation' ? escapeHtml(event.description) : event.description;
+
+        return `
+        <div class="timeline-event ${eventClass}">
+            <div class="timeline-marker">${eventIcon}</div>
+            <div class="timeline-content-box">
+                <div class="timeline-time">${formatDateTime(event.timestamp)}</div>
+                <div class="timeline-description">
+                    <strong>${description}</strong>
+                    ${details ? `<div class="timeline-details">${details}</div>` : ''}
+                </div>
+            </div>
+        </div>
+        `;
+    }).join('');
+
+    document.getElementById('timelineContent').innerHTML = `${summaryHTML}<div class="timeline">${timelineHTML}</div>`;
+}
+
+function getEventIcon(eventType) {
+    const icons = {
+        'first_seen': '🎉',
+        'started': '▶️',
+        'stopped': '⏹️',
+        'paused': '⏸️',
+        'resumed': '▶️',
+        'restarted': '⟳',
+        'image_updated': '📦',
+        'disappeared': '👻',
+        'reappeared': '✨',
+        'state_change': '🔄',
+        'renamed': '✏️',
+        'oom_killed': '💥',
+        'annotation': '📝',
+        'last_seen': '📍'
+    };
+    return icons[eventType] || '•';
+}
+
+function getEventClass(eventType) {
+    const classes = {
+        'first_seen': 'event-success',
+        'started': 'event-success',
+        'stopped': 'event-warning',
+        'paused': 'event-info',
+        'resumed': 'event-success',
+        'restarted': 'event-warning',
+        'image_updated': 'event-info',
+        'disappeared': 'event-error',
+        'reappeared': 'event-success',
+        'state_change': 'event-info',
+        'renamed': 'event-info',
+        'oom_killed': 'event-error',
+        'annotation': 'event-annotation',
+        'last_seen': 'event-info'
+    };
+    return classes[eventType] || 'event-default';
+}
+
+function formatDuration(ms) {
+    const seconds = Math.floor(ms / 1000);
+    const minutes = Math.floor(seconds / 60);
+    const hours = Math.floor(minutes / 60);
+    const days = Math.floor(hours / 24);
+
+    if (days > 0) {
+        return `${days}d ${hours % 24}h`;
+    } else if (hours > 0) {
+        return `${hours}h ${minutes % 60}m`;
+    } else if (minutes > 0) {
+        return `${minutes}m`;
+    } else {
+        return `${seconds}s`;
+    }
+}
+
+function closeTimelineModal() {
+    document.getElementById('timelineModal').classList.remove('show');
+}
+
+// Graph Visualization Functions
+
+async function loadGraph() {
+    const container = document.getElementById('graphContainer');
+    container.innerHTML = '<div class="graph-loading">Loading graph...</div>';
+
+    try {
+        const view = document.getElementById('graphViewSelect')?.value || 'containers';
+        const response = await fetch('/api/containers/graph?view=' + encodeURIComponent(view));
+        graphData = await response.json();
+        renderGraph(graphData);
+    } catch (error) {
+        console.error('Error loading graph:', error);
+        container.innerHTML = '<div class="graph-error">Failed to load container graph</div>';
+    }
+}
+
+// exportGraph downloads the graph in the selected format, honoring the compose project filter
+function exportGraph(select) {
+    const format = select.value;
+    if (!format) {
+        return;
+    }
+
+    const params = new URLSearchParams({ format });
+    const view = document.getElementById('graphViewSelect')?.value;
+    if (view) {
+        params.set('view', view);
+    }
+    const project = document.getElementById('composeProjectSelect')?.value;
+    if (project) {
+        params.set('compose_project', project);
+    }
+
+    window.location.href = appUrl('/api/containers/graph?' + params.toString());
+    select.value = '';
+}
+
+function renderGraph(data) {
+    const container = document.getElementById('graphContainer');
+
+    if (!data.nodes || data.nodes.length === 0) {
+        container.innerHTML = '<div class="graph-empty">No containers to display</div>';
+        return;
+    }
+
+    // Clear loading message
+    container.innerHTML = '';
+
+    // Build lists for dropdowns
+    buildGraphDropdowns(data);
+
+    // Count edge types
+    updateEdgeCounts(data.edges);
+
+    // Assign colors to compose projects
+    const composeProjects = [...new Set(data.nodes.map(n => n.compose_project).filter(p => p))];
+    const projectColors = {};
+    const colors = ['#3498db', '#9b59b6', '#e67e22', '#1abc9c', '#e74c3c', '#f39c12', '#2ecc71', '#34495e'];
+    composeProjects.forEach((project, i) => {
+        projectColors[project] = colors[i % colors.length];
+    });
+
+    // Build Cytoscape elements
+    const elements = {
+        nodes: data.nodes.map(node => ({
+            data: {
+                id: node.id,
+                label: node.name,
+                nodeType: node.node_type || 'container',
+                state: node.state,
+                image: node.image,
+                host: node.host_name,
+                composeProject: node.compose_project || '',
+                projectColor: projectColors[node.compose_project] || null
+            }
+        })),
+        edges: data.edges.map(edge => ({
+            data: {
+                id: `${edge.source}-${edge.target}-${edge.type}`,
+                source: edge.source,
+                target: edge.target,
+                label: edge.label,
+                type: edge.type
+            }
+        }))
+    };
+
+    // Initialize Cytoscape
+    cy = cytoscape({
+        container: container,
+        elements: elements,
+        style: [
+            // Node styles
+            {
+                selector: 'node',
+                style: {
+                    'label': 'data(label)',
+                    'text-valign': 'center',
+                    'text-halign': 'center',
+                    'background-color': '#95a5a6',
+                    'color': '#fff',
+                    'text-outline-color': '#2c3e50',
+                    'text-outline-width': 2,
+                    'font-size': '12px',
+                    'width': 50,
+                    'height': 50,
+                    'shape': 'ellipse'
+                }
+            },
+            // Network nodes - different shape and color
+            {
+                selector: 'node[nodeType="network"]',
+                style: {
+                    'shape': 'diamond',
+                    'background-color': '#3498db',
+                    'border-width': 3,
+                    'border-color': '#2980b9',
+                    'width': 60,
+                    'height': 60,
+                    'font-size': '11px',
+                    'font-weight': 'bold'
+                }
+            },
+            // Host nodes (service map view)
+            {
+                selector: 'node[nodeType="host"]',
+                style: {
+                    'shape': 'round-rectangle',
+                    'background-color': '#34495e',
+                    'border-width': 3,
+                    'border-color': '#2c3e50',
+                    'width': 90,
+                    'height': 50,
+                    'font-weight': 'bold'
+                }
+            },
+            {
+                selector: 'node[state="running"]',
+                style: {
+                    'background-color': '#2ecc71',
+                    'border-width': 3,
+                    'border-color': '#27ae60'
+                }
+            },
+            {
+                selector: 'node[state="exited"]',
+                style: {
+                    'background-color': '#95a5a6',
+                    'border-width': 3,
+                    'border-color': '#7f8c8d'
+                }
+            },
+            {
+                selector: 'node[state="paused"]',
+                style: {
+                    'background-color': '#f39c12',
+                    'border-width': 3,
+                    'border-color': '#e67e22'
+                }
+            },
+            {
+                selector: 'node.project-colored',
+                style: {
+                    'background-color': 'data(projectColor)',
+                    'border-color': 'data(projectColor)',
+                    'border-width': 4
+                }
+            },
+            {
+                selector: 'node.dimmed',
+                style: {
+                    'opacity': 0.2
+                }
+            },
+            {
+                selector: 'node.highlighted',
+                style: {
+                    'border-width': 6,
+                    'border-color': '#f1c40f',
+                    'z-index': 999
+                }
+            },
+            {
+                selector: 'node:selected',
+                style: {
+                    'border-width': 5,
+                    'border-color': '#3498db'
+                }
+            },
+            // Edge styles
+            {
+                selector: 'edge',
+                style: {
+                    'curve-style': 'bezier',
+                    'target-arrow-shape': 'none',
                     'line-color': '#bdc3c7',
                     'width': 2,
                     'label': 'data(label)',
@@ -3781,6 +5924,25 @@ function renderGraph(data) {
                     'target-arrow-shape': 'triangle'
                 }
             },
+            {
+                selector: 'edge[type="cross_host"]',
+                style: {
+                    'line-color': '#e67e22',
+                    'line-style': 'dashed',
+                    'width': 3,
+                    'target-arrow-shape': 'triangle',
+                    'target-arrow-color': '#e67e22'
+                }
+            },
+            {
+                selector: 'edge[type="host"]',
+                style: {
+                    'line-color': '#e67e22',
+                    'width': 4,
+                    'target-arrow-shape': 'triangle',
+                    'target-arrow-color': '#e67e22'
+                }
+            },
             {
                 selector: 'edge:selected',
                 style: {
@@ -3800,685 +5962,1434 @@ function renderGraph(data) {
                     'label': ''
                 }
             }
-        ],
-        layout: {
-            name: 'cose',
-            animate: true,
-            animationDuration: 1000,
-            idealEdgeLength: 100,
-            nodeOverlap: 20,
-            refresh: 20,
-            fit: true,
-            padding: 30,
-            randomize: false,
-            componentSpacing: 100,
-            nodeRepulsion: 400000,
-            edgeElasticity: 100,
-            nestingFactor: 5,
-            gravity: 80,
-            numIter: 1000,
-            initialTemp: 200,
-            coolingFactor: 0.95,
-            minTemp: 1.0
-        },
-        minZoom: 0.1,
-        maxZoom: 5,
-        wheelSensitivity: 0.1  // Slower, more controlled zoom with mouse wheel
+        ],
+        layout: {
+            name: 'cose',
+            animate: true,
+            animationDuration: 1000,
+            idealEdgeLength: 100,
+            nodeOverlap: 20,
+            refresh: 20,
+            fit: true,
+            padding: 30,
+            randomize: false,
+            componentSpacing: 100,
+            nodeRepulsion: 400000,
+            edgeElasticity: 100,
+            nestingFactor: 5,
+            gravity: 80,
+            numIter: 1000,
+            initialTemp: 200,
+            coolingFactor: 0.95,
+            minTemp: 1.0
+        },
+        minZoom: 0.1,
+        maxZoom: 5,
+        wheelSensitivity: 0.1  // Slower, more controlled zoom with mouse wheel
+    });
+
+    // Add event handlers
+    cy.on('tap', 'node', function(evt) {
+        const node = evt.target;
+        const data = node.data();
+
+        if (data.nodeType === 'host') {
+            const outgoing = cy.edges(`[source="${data.id}"]`).length;
+            const incoming = cy.edges(`[target="${data.id}"]`).length;
+            showGraphInfo(`
+                <strong>🖥️ Host: ${data.label}</strong><br>
+                Connects to: ${outgoing} host(s)<br>
+                Used by: ${incoming} host(s)
+            `);
+        } else if (data.nodeType === 'network') {
+            // Show network node info
+            const connectedContainers = cy.edges(`[source="${data.id}"], [target="${data.id}"]`).length;
+            showGraphInfo(`
+                <strong>🌐 Network: ${data.label}</strong><br>
+                Host: ${data.host}<br>
+                Connected Containers: ${connectedContainers}
+            `);
+        } else {
+            // Show container node info
+            showGraphInfo(`
+                <strong>${data.label}</strong><br>
+                Host: ${data.host}<br>
+                Image: ${data.image}<br>
+                State: <span class="state-badge state-${data.state}">${data.state}</span><br>
+                ${data.composeProject ? `Compose Project: ${data.composeProject}<br>` : ''}
+            `);
+        }
+    });
+
+    cy.on('tap', 'edge', function(evt) {
+        const edge = evt.target;
+        const data = edge.data();
+        const sourceNode = cy.getElementById(data.source).data();
+        const targetNode = cy.getElementById(data.target).data();
+
+        let typeDescription = '';
+        switch(data.type) {
+            case 'network': typeDescription = 'Network Connection'; break;
+            case 'volume': typeDescription = 'Shared Volume'; break;
+            case 'depends': typeDescription = 'Dependency'; break;
+            case 'link': typeDescription = 'Container Link'; break;
+            case 'cross_host': typeDescription = 'Cross-Host Connection'; break;
+            case 'host': typeDescription = 'Host Service Connection'; break;
+            default: typeDescription = 'Connection';
+        }
+
+        showGraphInfo(`
+            <strong>${typeDescription}</strong><br>
+            From: ${sourceNode.label}<br>
+            To: ${targetNode.label}<br>
+            ${data.label}
+        `);
+    });
+
+    cy.on('tap', function(evt) {
+        if (evt.target === cy) {
+            showGraphInfo('<p>Click on containers or connections to see details</p>');
+        }
+    });
+
+    // Apply initial filters
+    applyGraphFilters();
+}
+
+function applyGraphFilters() {
+    if (!cy) return;
+
+    const showNetworks = document.getElementById('showNetworks').checked;
+    const showVolumes = document.getElementById('showVolumes').checked;
+    const showDepends = document.getElementById('showDepends').checked;
+    const showLinks = document.getElementById('showLinks').checked;
+    const showCrossHost = document.getElementById('showCrossHost').checked;
+    const colorByProject = document.getElementById('colorByProject').checked;
+
+    // Apply color-by-project styling
+    if (colorByProject) {
+        cy.nodes().forEach(node => {
+            if (node.data('projectColor')) {
+                node.addClass('project-colored');
+            }
+        });
+    } else {
+        cy.nodes().removeClass('project-colored');
+    }
+
+    // Show/hide edges based on filters
+    cy.edges().forEach(edge => {
+        const type = edge.data('type');
+
+        // Check if edge should be visible based on type filters
+        let visibleByType = true;
+        if (type === 'network' && !showNetworks) visibleByType = false;
+        if (type === 'volume' && !showVolumes) visibleByType = false;
+        if (type === 'depends' && !showDepends) visibleByType = false;
+        if (type === 'link' && !showLinks) visibleByType = false;
+        if ((type === 'cross_host' || type === 'host') && !showCrossHost) visibleByType = false;
+
+        // Check if edge is dimmed by project/network selector
+        const isDimmed = edge.hasClass('dimmed');
+
+        // Show edge if it passes type filter and is not dimmed
+        // OR if we're re-enabling a type (even if dimmed, show it)
+        if (visibleByType && !isDimmed) {
+            edge.show();
+        } else if (!visibleByType) {
+            edge.hide();
+        } else if (visibleByType && isDimmed) {
+            // Show but keep dimmed
+            edge.show();
+        }
+    });
+}
+
+function showGraphInfo(html) {
+    const infoDiv = document.getElementById('graphInfo');
+    infoDiv.innerHTML = html;
+}
+
+// Graph zoom control functions
+function zoomIn() {
+    if (!cy) return;
+    const currentZoom = cy.zoom();
+    const newZoom = currentZoom * 1.2; // 20% increase
+    cy.zoom({
+        level: newZoom,
+        renderedPosition: {
+            x: cy.width() / 2,
+            y: cy.height() / 2
+        }
+    });
+}
+
+function zoomOut() {
+    if (!cy) return;
+    const currentZoom = cy.zoom();
+    const newZoom = currentZoom * 0.8; // 20% decrease
+    cy.zoom({
+        level: newZoom,
+        renderedPosition: {
+            x: cy.width() / 2,
+            y: cy.height() / 2
+        }
+    });
+}
+
+function zoomReset() {
+    if (!cy) return;
+    cy.zoom(1);
+    cy.center();
+}
+
+function fitGraph() {
+    if (!cy) return;
+    cy.fit(null, 30); // Fit all elements with 30px padding
+}
+
+// Helper functions for graph enhancements
+
+function buildGraphDropdowns(data) {
+    // Build compose project dropdown
+    const composeProjects = [...new Set(data.nodes.map(n => n.compose_project).filter(p => p))].sort();
+    const composeSelect = document.getElementById('composeProjectSelect');
+    composeSelect.innerHTML = '<option value="">Compose: All Projects</option>';
+    composeProjects.forEach(project => {
+        const optGroup1 = document.createElement('optgroup');
+        optGroup1.label = project;
+        optGroup1.innerHTML = `
+            <option value="highlight:${project}">Highlight: ${project}</option>
+            <option value="isolate:${project}">Isolate: ${project}</option>
+        `;
+        composeSelect.appendChild(optGroup1);
+    });
+
+    // Build network dropdown - get network names from network nodes
+    const networks = [...new Set(data.nodes.filter(n => n.node_type === 'network').map(n => n.name))].sort();
+    const networkSelect = document.getElementById('networkSelect');
+    networkSelect.innerHTML = '<option value="">Networks: Show All</option>';
+    networks.forEach(network => {
+        const optGroup = document.createElement('optgroup');
+        optGroup.label = network;
+        optGroup.innerHTML = `
+            <option value="highlight:${network}">Highlight: ${network}</option>
+            <option value="isolate:${network}">Isolate: ${network}</option>
+        `;
+        networkSelect.appendChild(optGroup);
+    });
+}
+
+function updateEdgeCounts(edges) {
+    const counts = {
+        network: 0,
+        volume: 0,
+        depends: 0,
+        link: 0,
+        cross_host: 0
+    };
+
+    edges.forEach(edge => {
+        if (counts.hasOwnProperty(edge.type)) {
+            counts[edge.type]++;
+        } else if (edge.type === 'host') {
+            counts.cross_host++;
+        }
+    });
+
+    document.getElementById('networkCount').textContent = `(${counts.network})`;
+    document.getElementById('volumeCount').textContent = `(${counts.volume})`;
+    document.getElementById('dependsCount').textContent = `(${counts.depends})`;
+    document.getElementById('linksCount').textContent = `(${counts.link})`;
+    document.getElementById('crossHostCount').textContent = `(${counts.cross_host})`;
+}
+
+function handleComposeProjectChange(event) {
+    if (!cy) return;
+
+    const value = event.target.value;
+
+    // Reset all nodes and edges
+    cy.nodes().removeClass('dimmed highlighted').show();
+    cy.edges().removeClass('dimmed').show();
+
+    if (!value) {
+        applyGraphFilters();
+        return;
+    }
+
+    const [mode, project] = value.split(':');
+
+    if (mode === 'highlight') {
+        // Dim non-matching nodes
+        cy.nodes().forEach(node => {
+            if (node.data('composeProject') !== project) {
+                node.addClass('dimmed');
+            }
+        });
+        // Dim edges not connected to this project
+        cy.edges().forEach(edge => {
+            const source = cy.getElementById(edge.data('source'));
+            const target = cy.getElementById(edge.data('target'));
+            if (source.data('composeProject') !== project && target.data('composeProject') !== project) {
+                edge.addClass('dimmed');
+            }
+        });
+    } else if (mode === 'isolate') {
+        // Hide non-matching nodes
+        cy.nodes().forEach(node => {
+            if (node.data('composeProject') !== project) {
+                node.hide();
+            }
+        });
+        // Hide edges where both ends are not in project
+        cy.edges().forEach(edge => {
+            const source = cy.getElementById(edge.data('source'));
+            const target = cy.getElementById(edge.data('target'));
+            if (source.data('composeProject') !== project || target.data('composeProject') !== project) {
+                edge.hide();
+            }
+        });
+        // Fit to show isolated project
+        setTimeout(() => cy.fit(null, 30), 100);
+    }
+
+    applyGraphFilters();
+}
+
+function handleNetworkChange(event) {
+    if (!cy) return;
+
+    const value = event.target.value;
+
+    // Reset all
+    cy.nodes().removeClass('dimmed highlighted').show();
+    cy.edges().removeClass('dimmed').show();
+
+    if (!value) {
+        applyGraphFilters();
+        return;
+    }
+
+    const [mode, network] = value.split(':');
+
+    // Find the network node with this name
+    let networkNodeId = null;
+    cy.nodes().forEach(node => {
+        if (node.data('nodeType') === 'network' && node.data('label') === network) {
+            networkNodeId = node.id();
+        }
     });
 
-    // Add event handlers
-    cy.on('tap', 'node', function(evt) {
-        const node = evt.target;
-        const data = node.data();
+    if (!networkNodeId) {
+        applyGraphFilters();
+        return;
+    }
 
-        if (data.nodeType === 'network') {
-            // Show network node info
-            const connectedContainers = cy.edges(`[source="${data.id}"], [target="${data.id}"]`).length;
-            showGraphInfo(`
-                <strong>🌐 Network: ${data.label}</strong><br>
-                Host: ${data.host}<br>
-                Connected Containers: ${connectedContainers}
-            `);
-        } else {
-            // Show container node info
-            showGraphInfo(`
-                <strong>${data.label}</strong><br>
-                Host: ${data.host}<br>
-                Image: ${data.image}<br>
-                State: <span class="state-badge state-${data.state}">${data.state}</span><br>
-                ${data.composeProject ? `Compose Project: ${data.composeProject}<br>` : ''}
-            `);
+    // Find all container nodes connected to this network node
+    const connectedContainerIds = new Set();
+    cy.edges().forEach(edge => {
+        if (edge.data('type') === 'network' &&
+            (edge.data('source') === networkNodeId || edge.data('target') === networkNodeId)) {
+            // Add the other end (the container)
+            const containerId = edge.data('source') === networkNodeId ?
+                edge.data('target') : edge.data('source');
+            connectedContainerIds.add(containerId);
         }
     });
 
-    cy.on('tap', 'edge', function(evt) {
-        const edge = evt.target;
-        const data = edge.data();
-        const sourceNode = cy.getElementById(data.source).data();
-        const targetNode = cy.getElementById(data.target).data();
+    // Also add the network node itself to the set of nodes to keep visible
+    connectedContainerIds.add(networkNodeId);
 
-        let typeDescription = '';
-        switch(data.type) {
-            case 'network': typeDescription = 'Network Connection'; break;
-            case 'volume': typeDescription = 'Shared Volume'; break;
-            case 'depends': typeDescription = 'Dependency'; break;
-            case 'link': typeDescription = 'Container Link'; break;
-            default: typeDescription = 'Connection';
-        }
+    if (mode === 'highlight') {
+        // Dim nodes not connected to this network
+        cy.nodes().forEach(node => {
+            if (!connectedContainerIds.has(node.id())) {
+                node.addClass('dimmed');
+            }
+        });
+        // Dim edges not connected to this network node
+        cy.edges().forEach(edge => {
+            if (!(edge.data('source') === networkNodeId || edge.data('target') === networkNodeId)) {
+                edge.addClass('dimmed');
+            }
+        });
+    } else if (mode === 'isolate') {
+        // Hide nodes not connected to this network
+        cy.nodes().forEach(node => {
+            if (!connectedContainerIds.has(node.id())) {
+                node.hide();
+            }
+        });
+        // Hide edges not connected to this network node
+        cy.edges().forEach(edge => {
+            if (!(edge.data('source') === networkNodeId || edge.data('target') === networkNodeId)) {
+                edge.hide();
+            }
+        });
+        setTimeout(() => cy.fit(null, 30), 100);
+    }
 
-        showGraphInfo(`
-            <strong>${typeDescription}</strong><br>
-            From: ${sourceNode.label}<br>
-            To: ${targetNode.label}<br>
-            ${data.label}
-        `);
+    applyGraphFilters();
+}
+
+function handleLayoutChange(event) {
+    if (!cy) return;
+
+    const layoutName = event.target.value;
+    let layoutOptions = { name: layoutName, animate: true, animationDuration: 500 };
+
+    // Customize options for different layouts
+    if (layoutName === 'dagre') {
+        layoutOptions.rankDir = 'TB'; // Top to bottom
+        layoutOptions.nodeSep = 50;
+        layoutOptions.rankSep = 100;
+    } else if (layoutName === 'cose') {
+        layoutOptions.idealEdgeLength = 100;
+        layoutOptions.nodeOverlap = 20;
+        layoutOptions.refresh = 20;
+        layoutOptions.fit = true;
+        layoutOptions.padding = 30;
+        layoutOptions.randomize = false;
+        layoutOptions.componentSpacing = 100;
+        layoutOptions.nodeRepulsion = 400000;
+        layoutOptions.edgeElasticity = 100;
+        layoutOptions.nestingFactor = 5;
+        layoutOptions.gravity = 80;
+        layoutOptions.numIter = 1000;
+        layoutOptions.initialTemp = 200;
+        layoutOptions.coolingFactor = 0.95;
+        layoutOptions.minTemp = 1.0;
+    } else if (layoutName === 'circle') {
+        layoutOptions.radius = 250;
+    } else if (layoutName === 'grid') {
+        layoutOptions.rows = Math.ceil(Math.sqrt(cy.nodes().length));
+    } else if (layoutName === 'concentric') {
+        layoutOptions.concentric = node => node.degree();
+        layoutOptions.levelWidth = () => 2;
+    }
+
+    const layout = cy.layout(layoutOptions);
+    layout.run();
+}
+
+function handleGraphSearch(event) {
+    if (!cy) return;
+
+    const searchTerm = event.target.value.toLowerCase().trim();
+
+    // Reset all highlighting
+    cy.nodes().removeClass('highlighted');
+
+    if (!searchTerm) {
+        return;
+    }
+
+    // Find matching nodes
+    const matchingNodes = cy.nodes().filter(node => {
+        return node.data('label').toLowerCase().includes(searchTerm);
     });
 
-    cy.on('tap', function(evt) {
-        if (evt.target === cy) {
-            showGraphInfo('<p>Click on containers or connections to see details</p>');
+    if (matchingNodes.length > 0) {
+        // Highlight matches
+        matchingNodes.addClass('highlighted');
+
+        // Center on first match
+        const firstMatch = matchingNodes[0];
+        cy.animate({
+            center: { eles: firstMatch },
+            zoom: Math.max(cy.zoom(), 1.5)
+        }, {
+            duration: 500
+        });
+
+        // Update info
+        if (matchingNodes.length === 1) {
+            showGraphInfo(`Found: <strong>${firstMatch.data('label')}</strong>`);
+        } else {
+            showGraphInfo(`Found ${matchingNodes.length} containers matching "${searchTerm}"`);
         }
+    } else {
+        showGraphInfo(`No containers found matching "${searchTerm}"`);
+    }
+}
+
+function toggleEdgeLabels() {
+    if (!cy) return;
+
+    const hideLabels = document.getElementById('hideEdgeLabels').checked;
+
+    if (hideLabels) {
+        cy.edges().addClass('no-label');
+    } else {
+        cy.edges().removeClass('no-label');
+    }
+}
+
+// Stats Modal
+let statsCharts = { cpu: null, memory: null, network: null, disk: null };
+let currentStatsContainer = null;
+let statsAnnotations = [];
+let currentStatsRange = '1h';
+
+// Server-side buckets per range, so charts look the same whatever the scan interval;
+// missing buckets come back as nulls and show as breaks in the lines
+const STATS_RANGE_RESOLUTION = { '24h': '5m', '7d': '1h', 'all': '1h' };
+
+function openStatsModal(hostId, containerId, containerName) {
+    console.log('openStatsModal called with:', { hostId, containerId, containerName });
+
+    currentStatsContainer = { hostId, containerId, containerName };
+    currentStatsRange = '1h';
+
+    const modal = document.getElementById('statsModal');
+    const nameElement = document.getElementById('statsContainerName');
+
+    if (!modal) {
+        console.error('Stats modal element not found!');
+        return;
+    }
+
+    if (!nameElement) {
+        console.error('Stats container name element not found!');
+        return;
+    }
+
+    nameElement.textContent = containerName;
+
+    // Use the 'show' class instead of style.display
+    modal.classList.add('show');
+    modal.style.display = ''; // Clear any inline style
+
+    console.log('Modal displayed with show class');
+
+    // Reset range buttons
+    document.querySelectorAll('.stats-range-btn').forEach(btn => {
+        btn.classList.toggle('active', btn.dataset.range === '1h');
     });
 
-    // Apply initial filters
-    applyGraphFilters();
+    // Add click handlers for range buttons
+    document.querySelectorAll('.stats-range-btn').forEach(btn => {
+        btn.onclick = () => changeStatsRange(btn.dataset.range);
+    });
+
+    loadStatsData();
+}
+
+function closeStatsModal() {
+    const modal = document.getElementById('statsModal');
+    if (modal) {
+        modal.classList.remove('show');
+    }
+
+    stopLiveStats();
+
+    // Destroy charts
+    if (statsCharts.cpu) {
+        statsCharts.cpu.destroy();
+        statsCharts.cpu = null;
+    }
+    if (statsCharts.memory) {
+        statsCharts.memory.destroy();
+        statsCharts.memory = null;
+    }
+    if (statsCharts.network) {
+        statsCharts.network.destroy();
+        statsCharts.network = null;
+    }
+    if (statsCharts.disk) {
+        statsCharts.disk.destroy();
+        statsCharts.disk = null;
+    }
+
+    currentStatsContainer = null;
+}
+
+function changeStatsRange(range) {
+    currentStatsRange = range;
+
+    // Update active button
+    document.querySelectorAll('.stats-range-btn').forEach(btn => {
+        btn.classList.toggle('active', btn.dataset.range === range);
+    });
+
+    stopLiveStats();
+    if (range === 'live') {
+        startLiveStats();
+        return;
+    }
+
+    loadStatsData();
 }
 
-function applyGraphFilters() {
-    if (!cy) return;
+// Live stats mode: streams samples over server-sent events while the stats panel is open
+const LIVE_STATS_MAX_POINTS = 120;
+let liveStatsSource = null;
+let liveStatsSamples = [];
 
-    const showNetworks = document.getElementById('showNetworks').checked;
-    const showVolumes = document.getElementById('showVolumes').checked;
-    const showDepends = document.getElementById('showDepends').checked;
-    const showLinks = document.getElementById('showLinks').checked;
-    const colorByProject = document.getElementById('colorByProject').checked;
+function startLiveStats() {
+    if (!currentStatsContainer) return;
 
-    // Apply color-by-project styling
-    if (colorByProject) {
-        cy.nodes().forEach(node => {
-            if (node.data('projectColor')) {
-                node.addClass('project-colored');
-            }
-        });
-    } else {
-        cy.nodes().removeClass('project-colored');
-    }
+    const { hostId, containerId } = currentStatsContainer;
+    liveStatsSamples = [];
+    statsAnnotations = [];
+    renderStatsAnnotations();
 
-    // Show/hide edges based on filters
-    cy.edges().forEach(edge => {
-        const type = edge.data('type');
+    document.getElementById('statsMessage').textContent = 'Connecting to live stats...';
+    document.getElementById('statsMessage').className = 'loading';
+    document.getElementById('statsMessage').style.display = 'block';
+    document.getElementById('statsChartArea').style.display = 'none';
 
-        // Check if edge should be visible based on type filters
-        let visibleByType = true;
-        if (type === 'network' && !showNetworks) visibleByType = false;
-        if (type === 'volume' && !showVolumes) visibleByType = false;
-        if (type === 'depends' && !showDepends) visibleByType = false;
-        if (type === 'link' && !showLinks) visibleByType = false;
+    liveStatsSource = new EventSource(appUrl(`/api/containers/${hostId}/${containerId}/stats/live?interval=1`));
 
-        // Check if edge is dimmed by project/network selector
-        const isDimmed = edge.hasClass('dimmed');
+    liveStatsSource.onmessage = (event) => {
+        const sample = JSON.parse(event.data);
+        liveStatsSamples.push(sample);
+        if (liveStatsSamples.length > LIVE_STATS_MAX_POINTS) {
+            liveStatsSamples.shift();
+        }
 
-        // Show edge if it passes type filter and is not dimmed
-        // OR if we're re-enabling a type (even if dimmed, show it)
-        if (visibleByType && !isDimmed) {
-            edge.show();
-        } else if (!visibleByType) {
-            edge.hide();
-        } else if (visibleByType && isDimmed) {
-            // Show but keep dimmed
-            edge.show();
+        if (liveStatsSamples.length === 1) {
+            document.getElementById('statsMessage').style.display = 'none';
+            document.getElementById('statsChartArea').style.display = 'block';
+            renderStatsCharts(liveStatsSamples);
+        } else {
+            appendLiveStatsSample(sample);
         }
-    });
+        updateStatsSummary(liveStatsSamples);
+    };
+
+    liveStatsSource.onerror = () => {
+        // Stop instead of letting EventSource reconnect forever (e.g. container stopped)
+        stopLiveStats();
+        if (liveStatsSamples.length === 0) {
+            document.getElementById('statsMessage').textContent = 'Live stats unavailable for this container.';
+            document.getElementById('statsMessage').className = 'error';
+            document.getElementById('statsMessage').style.display = 'block';
+        }
+    };
 }
 
-function showGraphInfo(html) {
-    const infoDiv = document.getElementById('graphInfo');
-    infoDiv.innerHTML = html;
+function stopLiveStats() {
+    if (liveStatsSource) {
+        liveStatsSource.close();
+        liveStatsSource = null;
+    }
 }
 
-// Graph zoom control functions
-function zoomIn() {
-    if (!cy) return;
-    const currentZoom = cy.zoom();
-    const newZoom = currentZoom * 1.2; // 20% increase
-    cy.zoom({
-        level: newZoom,
-        renderedPosition: {
-            x: cy.width() / 2,
-            y: cy.height() / 2
+function appendLiveStatsSample(sample) {
+    if (!statsCharts.cpu || !statsCharts.memory || !statsCharts.network || !statsCharts.disk) return;
+
+    const label = new Date(sample.timestamp).toLocaleTimeString(dateLocale(), dateOptions());
+    const charts = [
+        [statsCharts.cpu, [sample.cpu_percent || 0]],
+        [statsCharts.memory, [(sample.memory_usage || 0) / 1024 / 1024, (sample.memory_limit || 0) / 1024 / 1024]],
+        [statsCharts.network, [(sample.network_rx_rate || 0) / 1024, (sample.network_tx_rate || 0) / 1024]],
+        [statsCharts.disk, [(sample.block_read_rate || 0) / 1024, (sample.block_write_rate || 0) / 1024]]
+    ];
+
+    charts.forEach(([chart, values]) => {
+        chart.data.labels.push(label);
+        chart.data.datasets.forEach((dataset, i) => dataset.data.push(values[i]));
+        if (chart.data.labels.length > LIVE_STATS_MAX_POINTS) {
+            chart.data.labels.shift();
+            chart.data.datasets.forEach(dataset => dataset.data.shift());
         }
+        chart.update('none');
     });
 }
 
-function zoomOut() {
-    if (!cy) return;
-    const currentZoom = cy.zoom();
-    const newZoom = currentZoom * 0.8; // 20% decrease
-    cy.zoom({
-        level: newZoom,
-        renderedPosition: {
-            x: cy.width() / 2,
-            y: cy.height() / 2
+async function loadStatsData() {
+    if (!currentStatsContainer) {
+        console.error('No current stats container set');
+        return;
+    }
+
+    const { hostId, containerId } = currentStatsContainer;
+    if (currentStatsRange === 'live') return;
+    let url = `/api/containers/${hostId}/${containerId}/stats?range=${currentStatsRange}`;
+    const resolution = STATS_RANGE_RESOLUTION[currentStatsRange];
+    if (resolution) {
+        const agg = document.getElementById('statsAggregation')?.value || 'avg';
+        url += `&resolution=${resolution}&agg=${agg}&fill=null`;
+    }
+
+    console.log('Loading stats from:', url);
+
+    try {
+        const response = await fetch(url);
+        console.log('Stats response status:', response.status);
+
+        if (!response.ok) {
+            const errorText = await response.text();
+            console.error('Stats API error:', errorText);
+            throw new Error(`Failed to load stats: ${response.status} ${errorText}`);
         }
-    });
-}
 
-function zoomReset() {
-    if (!cy) return;
-    cy.zoom(1);
-    cy.center();
-}
+        const stats = await response.json();
+        console.log('Stats data received:', stats);
 
-function fitGraph() {
-    if (!cy) return;
-    cy.fit(null, 30); // Fit all elements with 30px padding
+        if (!stats || !Array.isArray(stats) || stats.every(s => s.cpu_percent === null)) {
+            document.getElementById('statsMessage').textContent = 'No stats data available for this time range. Stats collection may need more time to gather data.';
+            document.getElementById('statsMessage').className = 'loading';
+            document.getElementById('statsMessage').style.display = 'block';
+            document.getElementById('statsChartArea').style.display = 'none';
+            return;
+        }
+
+        statsAnnotations = await fetchAnnotations({
+            hostId,
+            containerName: currentStatsContainer.containerName,
+            start: stats[0].timestamp,
+            end: stats[stats.length - 1].timestamp
+        });
+
+        // Hide message and show charts
+        document.getElementById('statsMessage').style.display = 'none';
+        document.getElementById('statsChartArea').style.display = 'block';
+
+        renderStatsCharts(stats);
+        updateStatsSummary(stats);
+        renderStatsAnnotations();
+    } catch (error) {
+        console.error('Error loading stats:', error);
+        document.getElementById('statsMessage').textContent = `Failed to load stats data: ${error.message}`;
+        document.getElementById('statsMessage').className = 'error';
+        document.getElementById('statsMessage').style.display = 'block';
+        document.getElementById('statsChartArea').style.display = 'none';
+    }
 }
 
-// Helper functions for graph enhancements
+function renderStatsCharts(stats) {
+    // Destroy existing charts
+    if (statsCharts.cpu) statsCharts.cpu.destroy();
+    if (statsCharts.memory) statsCharts.memory.destroy();
+    if (statsCharts.network) statsCharts.network.destroy();
+    if (statsCharts.disk) statsCharts.disk.destroy();
 
-function buildGraphDropdowns(data) {
-    // Build compose project dropdown
-    const composeProjects = [...new Set(data.nodes.map(n => n.compose_project).filter(p => p))].sort();
-    const composeSelect = document.getElementById('composeProjectSelect');
-    composeSelect.innerHTML = '<option value="">Compose: All Projects</option>';
-    composeProjects.forEach(project => {
-        const optGroup1 = document.createElement('optgroup');
-        optGroup1.label = project;
-        optGroup1.innerHTML = `
-            <option value="highlight:${project}">Highlight: ${project}</option>
-            <option value="isolate:${project}">Isolate: ${project}</option>
-        `;
-        composeSelect.appendChild(optGroup1);
+    // Prepare data
+    const labels = stats.map(s => new Date(s.timestamp).toLocaleString(dateLocale(), dateOptions()));
+    const cpuData = stats.map(s => statsValue(s.cpu_percent, 1));
+    const memoryData = stats.map(s => statsValue(s.memory_usage, 1024 * 1024)); // Convert to MB
+    const memoryLimitData = stats.map(s => statsValue(s.memory_limit, 1024 * 1024));
+    const annotationMarks = { marks: statsAnnotationMarks(stats) };
+
+    // CPU Chart
+    const cpuCanvas = document.getElementById('cpuChart');
+    const cpuCtx = cpuCanvas.getContext('2d');
+    statsCharts.cpu = new Chart(cpuCtx, {
+        type: 'line',
+        data: {
+            labels: labels,
+            datasets: [{
+                label: 'CPU %',
+                data: cpuData,
+                borderColor: 'rgb(75, 192, 192)',
+                backgroundColor: 'rgba(75, 192, 192, 0.2)',
+                tension: 0.4,
+                fill: true
+            }]
+        },
+        plugins: [annotationMarksPlugin],
+        options: {
+            responsive: true,
+            maintainAspectRatio: false,
+            plugins: {
+                title: {
+                    display: true,
+                    text: 'CPU Usage Over Time'
+                },
+                legend: {
+                    display: false
+                },
+                annotationMarks: annotationMarks
+            },
+            scales: {
+                y: {
+                    beginAtZero: true,
+                    max: 100,
+                    title: {
+                        display: true,
+                        text: 'CPU %'
+                    }
+                },
+                x: {
+                    ticks: {
+                        maxTicksLimit: 10
+                    }
+                }
+            }
+        }
     });
 
-    // Build network dropdown - get network names from network nodes
-    const networks = [...new Set(data.nodes.filter(n => n.node_type === 'network').map(n => n.name))].sort();
-    const networkSelect = document.getElementById('networkSelect');
-    networkSelect.innerHTML = '<option value="">Networks: Show All</option>';
-    networks.forEach(network => {
-        const optGroup = document.createElement('optgroup');
-        optGroup.label = network;
-        optGroup.innerHTML = `
-            <option value="highlight:${network}">Highlight: ${network}</option>
-            <option value="isolate:${network}">Isolate: ${network}</option>
-        `;
-        networkSelect.appendChild(optGroup);
+    // Memory Chart
+    const memoryCanvas = document.getElementById('memoryChart');
+    const memoryCtx = memoryCanvas.getContext('2d');
+    const datasets = [{
+        label: 'Memory Usage (MB)',
+        data: memoryData,
+        borderColor: 'rgb(255, 99, 132)',
+        backgroundColor: 'rgba(255, 99, 132, 0.2)',
+        tension: 0.4,
+        fill: true
+    }];
+
+    // Add memory limit line if available
+    const hasLimit = memoryLimitData.some(l => l > 0);
+    if (hasLimit) {
+        datasets.push({
+            label: 'Memory Limit (MB)',
+            data: memoryLimitData,
+            borderColor: 'rgb(255, 159, 64)',
+            backgroundColor: 'rgba(255, 159, 64, 0.1)',
+            borderDash: [5, 5],
+            tension: 0,
+            fill: false
+        });
+    }
+
+    statsCharts.memory = new Chart(memoryCtx, {
+        type: 'line',
+        data: {
+            labels: labels,
+            datasets: datasets
+        },
+        plugins: [annotationMarksPlugin],
+        options: {
+            responsive: true,
+            maintainAspectRatio: false,
+            plugins: {
+                title: {
+                    display: true,
+                    text: 'Memory Usage Over Time'
+                },
+                annotationMarks: annotationMarks
+            },
+            scales: {
+                y: {
+                    beginAtZero: true,
+                    title: {
+                        display: true,
+                        text: 'Memory (MB)'
+                    }
+                },
+                x: {
+                    ticks: {
+                        maxTicksLimit: 10
+                    }
+                }
+            }
+        }
     });
+
+    // Network and disk I/O charts (KB/s)
+    statsCharts.network = renderIORateChart('networkChart', 'Network I/O Over Time', labels, [
+        { label: 'Received (KB/s)', data: stats.map(s => statsValue(s.network_rx_rate, 1024)), color: '54, 162, 235' },
+        { label: 'Sent (KB/s)', data: stats.map(s => statsValue(s.network_tx_rate, 1024)), color: '153, 102, 255' }
+    ], annotationMarks);
+    statsCharts.disk = renderIORateChart('diskChart', 'Disk I/O Over Time', labels, [
+        { label: 'Read (KB/s)', data: stats.map(s => statsValue(s.block_read_rate, 1024)), color: '75, 192, 75' },
+        { label: 'Write (KB/s)', data: stats.map(s => statsValue(s.block_write_rate, 1024)), color: '255, 159, 64' }
+    ], annotationMarks);
 }
 
-function updateEdgeCounts(edges) {
-    const counts = {
-        network: 0,
-        volume: 0,
-        depends: 0,
-        link: 0
-    };
+// Scale a stats value for a chart; gap buckets (null) stay null so the line breaks
+function statsValue(value, divisor) {
+    if (value === null) return null;
+    return (value || 0) / divisor;
+}
 
-    edges.forEach(edge => {
-        if (counts.hasOwnProperty(edge.type)) {
-            counts[edge.type]++;
+function renderIORateChart(canvasId, title, labels, series, annotationMarks = { marks: [] }) {
+    const ctx = document.getElementById(canvasId).getContext('2d');
+    return new Chart(ctx, {
+        type: 'line',
+        data: {
+            labels: labels,
+            datasets: series.map(s => ({
+                label: s.label,
+                data: s.data,
+                borderColor: `rgb(${s.color})`,
+                backgroundColor: `rgba(${s.color}, 0.2)`,
+                tension: 0.4,
+                fill: false
+            }))
+        },
+        plugins: [annotationMarksPlugin],
+        options: {
+            responsive: true,
+            maintainAspectRatio: false,
+            plugins: {
+                title: {
+                    display: true,
+                    text: title
+                },
+                annotationMarks: annotationMarks
+            },
+            scales: {
+                y: {
+                    beginAtZero: true,
+                    title: {
+                        display: true,
+                        text: 'KB/s'
+                    }
+                },
+                x: {
+                    ticks: {
+                        maxTicksLimit: 10
+                    }
+                }
+            }
         }
     });
-
-    document.getElementById('networkCount').textContent = `(${counts.network})`;
-    document.getElementById('volumeCount').textContent = `(${counts.volume})`;
-    document.getElementById('dependsCount').textContent = `(${counts.depends})`;
-    document.getElementById('linksCount').textContent = `(${counts.link})`;
 }
 
-function handleComposeProjectChange(event) {
-    if (!cy) return;
+// Annotations: notes on a point in time or a period ("power outage", "migrated to new NAS") that
+// explain anomalies, shown on the stats charts, lifecycle timelines and changes reports
+
+// Chart.js plugin drawing annotations as dashed vertical lines; its options are
+// {marks: [{index, text}]} with the index of the label each one is drawn at
+const annotationMarksPlugin = {
+    id: 'annotationMarks',
+    afterDatasetsDraw(chart, args, options) {
+        const marks = (options && options.marks) || [];
+        if (marks.length === 0) return;
+        const { ctx, chartArea, scales } = chart;
+        ctx.save();
+        ctx.strokeStyle = 'rgba(111, 66, 193, 0.8)';
+        ctx.fillStyle = 'rgb(111, 66, 193)';
+        ctx.font = '11px sans-serif';
+        ctx.setLineDash([4, 4]);
+        marks.forEach(mark => {
+            const x = scales.x.getPixelForValue(mark.index);
+            if (x < chartArea.left || x > chartArea.right) return;
+            ctx.beginPath();
+            ctx.moveTo(x, chartArea.top);
+            ctx.lineTo(x, chartArea.bottom);
+            ctx.stroke();
+            const text = mark.text.length > 30 ? mark.text.slice(0, 29) + '…' : mark.text;
+            ctx.fillText('📝 ' + text, x + 3, chartArea.top + 12);
+        });
+        ctx.restore();
+    }
+};
 
-    const value = event.target.value;
+// Place the loaded annotations on the samples of a stats chart: each at the first sample at or
+// after its time, periods that began earlier at the first sample
+function statsAnnotationMarks(stats) {
+    if (statsAnnotations.length === 0 || stats.length === 0) return [];
+    const times = stats.map(s => new Date(s.timestamp).getTime());
+    return statsAnnotations.map(a => {
+        const at = new Date(a.at).getTime();
+        const index = times.findIndex(t => t >= at);
+        return index === -1 ? null : { index, text: a.text };
+    }).filter(Boolean);
+}
+
+// Fetch the annotations overlapping a period for a host (and container); they are an extra, so
+// failures only leave them out
+async function fetchAnnotations({ hostId, containerName, start, end } = {}) {
+    const params = new URLSearchParams();
+    if (hostId) params.set('host_id', hostId);
+    if (containerName) params.set('container_name', containerName);
+    if (start) params.set('start', new Date(start).toISOString());
+    if (end) params.set('end', new Date(end).toISOString());
+    try {
+        const response = await fetch(`/api/annotations?${params}`);
+        return response.ok ? await response.json() : [];
+    } catch (error) {
+        console.error('Error loading annotations:', error);
+        return [];
+    }
+}
 
-    // Reset all nodes and edges
-    cy.nodes().removeClass('dimmed highlighted').show();
-    cy.edges().removeClass('dimmed').show();
+// What is redrawn after an annotation is added or deleted, by where it was done
+const annotationReloads = {
+    stats: () => loadStatsData(),
+    timeline: () => currentTimelineContainer && viewContainerTimeline(
+        currentTimelineContainer.hostId, currentTimelineContainer.containerId, currentTimelineContainer.containerName),
+    report: () => generateReport()
+};
 
-    if (!value) {
-        applyGraphFilters();
-        return;
+// List annotations with their time, scope and a delete button for the administrator
+function renderAnnotationList(annotations, reload) {
+    if (annotations.length === 0) {
+        return '<p class="empty-message">No annotations in this period</p>';
+    }
+    return `<ul class="annotation-list">${annotations.map(a => {
+        const scope = a.container_name
+            ? `${escapeHtml(a.host_name)} / ${escapeHtml(a.container_name)}`
+            : a.host_id ? escapeHtml(a.host_name) : 'All hosts';
+        const when = a.end_at ? `${formatDateTime(a.at)} – ${formatDateTime(a.end_at)}` : formatDateTime(a.at);
+        return `
+            <li class="annotation-item">
+                <span class="annotation-time">📝 ${when}</span>
+                <span class="annotation-text">${escapeHtml(a.text)}</span>
+                <span class="badge badge-secondary">${scope}</span>
+                ${a.created_by ? `<span class="text-muted">by ${escapeHtml(a.created_by)}</span>` : ''}
+                <button class="btn btn-xs btn-secondary admin-only" onclick="deleteAnnotation(${a.id}, '${reload}')" title="Delete annotation">✕</button>
+            </li>`;
+    }).join('')}</ul>`;
+}
+
+function renderStatsAnnotations() {
+    const element = document.getElementById('statsAnnotations');
+    if (!element) return;
+    element.innerHTML = statsAnnotations.length > 0 ? renderAnnotationList(statsAnnotations, 'stats') : '';
+}
+
+// Ask for an annotation's text and time and save it for a container, a host or (hostId 0) every
+// host, then redraw the view it was added from
+async function addAnnotation(hostId, containerName, reload) {
+    const scope = containerName || (hostId ? 'this host' : 'all hosts');
+    const text = prompt(`Annotation for ${scope}, e.g. "migrated to new NAS" or "power outage":`, '');
+    if (text === null || !text.trim()) return;
+    const when = prompt('When? As YYYY-MM-DD HH:MM in local time; leave empty for now.', '');
+    if (when === null) return;
+
+    let at = new Date();
+    if (when.trim()) {
+        at = new Date(when.trim().replace(' ', 'T'));
+        if (isNaN(at.getTime())) {
+            showNotification(`Invalid time "${when}"`, 'error');
+            return;
+        }
     }
 
-    const [mode, project] = value.split(':');
-
-    if (mode === 'highlight') {
-        // Dim non-matching nodes
-        cy.nodes().forEach(node => {
-            if (node.data('composeProject') !== project) {
-                node.addClass('dimmed');
-            }
-        });
-        // Dim edges not connected to this project
-        cy.edges().forEach(edge => {
-            const source = cy.getElementById(edge.data('source'));
-            const target = cy.getElementById(edge.data('target'));
-            if (source.data('composeProject') !== project && target.data('composeProject') !== project) {
-                edge.addClass('dimmed');
-            }
-        });
-    } else if (mode === 'isolate') {
-        // Hide non-matching nodes
-        cy.nodes().forEach(node => {
-            if (node.data('composeProject') !== project) {
-                node.hide();
-            }
-        });
-        // Hide edges where both ends are not in project
-        cy.edges().forEach(edge => {
-            const source = cy.getElementById(edge.data('source'));
-            const target = cy.getElementById(edge.data('target'));
-            if (source.data('composeProject') !== project || target.data('composeProject') !== project) {
-                edge.hide();
-            }
+    try {
+        const response = await fetch('/api/annotations', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ at: at.toISOString(), text, host_id: hostId || 0, container_name: containerName || '' })
         });
-        // Fit to show isolated project
-        setTimeout(() => cy.fit(null, 30), 100);
+        const result = await response.json();
+        if (!response.ok) {
+            showNotification('Failed to save annotation: ' + (result.error || 'Unknown error'), 'error');
+            return;
+        }
+        showNotification('Annotation saved', 'success');
+        await annotationReloads[reload]();
+    } catch (error) {
+        console.error('Error saving annotation:', error);
+        showNotification('Error saving annotation: ' + error.message, 'error');
     }
+}
 
-    applyGraphFilters();
+async function deleteAnnotation(id, reload) {
+    if (!confirm('Delete this annotation?')) return;
+    try {
+        const response = await fetch(`/api/annotations/${id}`, { method: 'DELETE' });
+        const result = await response.json();
+        if (!response.ok) {
+            showNotification('Failed to delete annotation: ' + (result.error || 'Unknown error'), 'error');
+            return;
+        }
+        showNotification('Annotation deleted', 'success');
+        await annotationReloads[reload]();
+    } catch (error) {
+        console.error('Error deleting annotation:', error);
+        showNotification('Error deleting annotation: ' + error.message, 'error');
+    }
 }
 
-function handleNetworkChange(event) {
-    if (!cy) return;
+// Annotate the container of the open stats panel or lifecycle timeline
+function annotateStatsContainer() {
+    if (!currentStatsContainer) return;
+    addAnnotation(currentStatsContainer.hostId, currentStatsContainer.containerName, 'stats');
+}
 
-    const value = event.target.value;
+function annotateTimelineContainer() {
+    if (!currentTimelineContainer) return;
+    addAnnotation(currentTimelineContainer.hostId, currentTimelineContainer.containerName, 'timeline');
+}
 
-    // Reset all
-    cy.nodes().removeClass('dimmed highlighted').show();
-    cy.edges().removeClass('dimmed').show();
+// Annotate the host of the report's host filter, or every host
+function annotateReport() {
+    const hostFilter = document.getElementById('reportHostFilter').value;
+    addAnnotation(hostFilter ? parseInt(hostFilter) : 0, '', 'report');
+}
 
-    if (!value) {
-        applyGraphFilters();
-        return;
-    }
+function updateStatsSummary(stats) {
+    const cpuValues = stats.map(s => s.cpu_percent || 0).filter(v => v > 0);
+    const memoryValues = stats.map(s => s.memory_usage || 0).filter(v => v > 0);
 
-    const [mode, network] = value.split(':');
+    // CPU stats
+    const avgCpu = cpuValues.length > 0 ? cpuValues.reduce((a, b) => a + b, 0) / cpuValues.length : 0;
+    const maxCpu = cpuValues.length > 0 ? Math.max(...cpuValues) : 0;
 
-    // Find the network node with this name
-    let networkNodeId = null;
-    cy.nodes().forEach(node => {
-        if (node.data('nodeType') === 'network' && node.data('label') === network) {
-            networkNodeId = node.id();
-        }
-    });
+    document.getElementById('avgCpu').textContent = avgCpu.toFixed(1) + '%';
+    document.getElementById('maxCpu').textContent = maxCpu.toFixed(1) + '%';
 
-    if (!networkNodeId) {
-        applyGraphFilters();
-        return;
-    }
+    // Memory stats
+    const avgMemory = memoryValues.length > 0 ? memoryValues.reduce((a, b) => a + b, 0) / memoryValues.length : 0;
+    const maxMemory = memoryValues.length > 0 ? Math.max(...memoryValues) : 0;
 
-    // Find all container nodes connected to this network node
-    const connectedContainerIds = new Set();
-    cy.edges().forEach(edge => {
-        if (edge.data('type') === 'network' &&
-            (edge.data('source') === networkNodeId || edge.data('target') === networkNodeId)) {
-            // Add the other end (the container)
-            const containerId = edge.data('source') === networkNodeId ?
-                edge.data('target') : edge.data('source');
-            connectedContainerIds.add(containerId);
+    const formatMemory = (bytes) => {
+        const mb = bytes / 1024 / 1024;
+        if (mb > 1024) {
+            return (mb / 1024).toFixed(2) + ' GB';
         }
-    });
+        return mb.toFixed(0) + ' MB';
+    };
 
-    // Also add the network node itself to the set of nodes to keep visible
-    connectedContainerIds.add(networkNodeId);
+    document.getElementById('avgMemory').textContent = formatMemory(avgMemory);
+    document.getElementById('maxMemory').textContent = formatMemory(maxMemory);
+}
 
-    if (mode === 'highlight') {
-        // Dim nodes not connected to this network
-        cy.nodes().forEach(node => {
-            if (!connectedContainerIds.has(node.id())) {
-                node.addClass('dimmed');
-            }
-        });
-        // Dim edges not connected to this network node
-        cy.edges().forEach(edge => {
-            if (!(edge.data('source') === networkNodeId || edge.data('target') === networkNodeId)) {
-                edge.addClass('dimmed');
-            }
-        });
-    } else if (mode === 'isolate') {
-        // Hide nodes not connected to this network
-        cy.nodes().forEach(node => {
-            if (!connectedContainerIds.has(node.id())) {
-                node.hide();
-            }
-        });
-        // Hide edges not connected to this network node
-        cy.edges().forEach(edge => {
-            if (!(edge.data('source') === networkNodeId || edge.data('target') === networkNodeId)) {
-                edge.hide();
-            }
-        });
-        setTimeout(() => cy.fit(null, 30), 100);
-    }
+// Close modal when clicking outside
+document.getElementById('statsModal')?.addEventListener('click', (e) => {
+    if (e.target.classList.contains('modal')) closeStatsModal();
+});
 
-    applyGraphFilters();
-}
+// ==================== REPORTS TAB ====================
 
-function handleLayoutChange(event) {
-    if (!cy) return;
+let currentReport = null;
+let changesTimelineChart = null;
 
-    const layoutName = event.target.value;
-    let layoutOptions = { name: layoutName, animate: true, animationDuration: 500 };
+// Initialize reports tab
+function initializeReportsTab() {
+    // Set default date range to last 7 days
+    const end = new Date();
+    const start = new Date(end - 7 * 24 * 60 * 60 * 1000);
 
-    // Customize options for different layouts
-    if (layoutName === 'dagre') {
-        layoutOptions.rankDir = 'TB'; // Top to bottom
-        layoutOptions.nodeSep = 50;
-        layoutOptions.rankSep = 100;
-    } else if (layoutName === 'cose') {
-        layoutOptions.idealEdgeLength = 100;
-        layoutOptions.nodeOverlap = 20;
-        layoutOptions.refresh = 20;
-        layoutOptions.fit = true;
-        layoutOptions.padding = 30;
-        layoutOptions.randomize = false;
-        layoutOptions.componentSpacing = 100;
-        layoutOptions.nodeRepulsion = 400000;
-        layoutOptions.edgeElasticity = 100;
-        layoutOptions.nestingFactor = 5;
-        layoutOptions.gravity = 80;
-        layoutOptions.numIter = 1000;
-        layoutOptions.initialTemp = 200;
-        layoutOptions.coolingFactor = 0.95;
-        layoutOptions.minTemp = 1.0;
-    } else if (layoutName === 'circle') {
-        layoutOptions.radius = 250;
-    } else if (layoutName === 'grid') {
-        layoutOptions.rows = Math.ceil(Math.sqrt(cy.nodes().length));
-    } else if (layoutName === 'concentric') {
-        layoutOptions.concentric = node => node.degree();
-        layoutOptions.levelWidth = () => 2;
-    }
+    document.getElementById('reportStartDate').value = formatDateTimeLocal(start);
+    document.getElementById('reportEndDate').value = formatDateTimeLocal(end);
 
-    const layout = cy.layout(layoutOptions);
-    layout.run();
+    // Load hosts and stacks for filter
+    loadHostsForReportFilter();
+    loadStacksForReportFilter();
+
+    // Set up event listeners
+    setupReportEventListeners();
 }
 
-function handleGraphSearch(event) {
-    if (!cy) return;
-
-    const searchTerm = event.target.value.toLowerCase().trim();
+// Set up event listeners for reports tab
+function setupReportEventListeners() {
+    document.getElementById('generateReportBtn').addEventListener('click', generateReport);
+    document.getElementById('report7d').addEventListener('click', () => setReportRange(7));
+    document.getElementById('report30d').addEventListener('click', () => setReportRange(30));
+    document.getElementById('report90d').addEventListener('click', () => setReportRange(90));
+    document.getElementById('exportReportBtn').addEventListener('click', exportReport);
+    document.getElementById('findIdleBtn')?.addEventListener('click', loadIdleContainers);
+    document.getElementById('auditRestartPoliciesBtn')?.addEventListener('click', loadRestartPolicyReport);
+    document.getElementById('loadUpdateLagBtn')?.addEventListener('click', loadUpdateLagReport);
+    document.getElementById('loadDockerObjectsBtn')?.addEventListener('click', loadDockerObjects);
+    document.getElementById('loadBindMountsBtn')?.addEventListener('click', loadBindMountReport);
+    document.getElementById('loadProxyRoutesBtn')?.addEventListener('click', loadProxyRouteReport);
+    document.getElementById('loadEndpointChecksBtn')?.addEventListener('click', () => loadEndpointChecks(false));
+    document.getElementById('runEndpointChecksBtn')?.addEventListener('click', () => loadEndpointChecks(true));
+    document.getElementById('planMigrationBtn')?.addEventListener('click', planHostMigration);
+    document.getElementById('loadMigrationsBtn')?.addEventListener('click', loadHostMigrations);
+    document.getElementById('checkBackupsBtn')?.addEventListener('click', loadBackupJobs);
+}
 
-    // Reset all highlighting
-    cy.nodes().removeClass('highlighted');
+// Navigate to History tab with container filter
+function goToContainerHistory(containerName, hostId) {
+    // Switch to history tab
+    switchTab('history');
 
-    if (!searchTerm) {
-        return;
+    // Set the search filter to the container name
+    const searchInput = document.getElementById('searchInput');
+    if (searchInput) {
+        searchInput.value = containerName;
     }
 
-    // Find matching nodes
-    const matchingNodes = cy.nodes().filter(node => {
-        return node.data('label').toLowerCase().includes(searchTerm);
-    });
-
-    if (matchingNodes.length > 0) {
-        // Highlight matches
-        matchingNodes.addClass('highlighted');
+    // Set the host filter if provided
+    const hostFilter = document.getElementById('hostFilter');
+    if (hostFilter && hostId) {
+        hostFilter.value = hostId.toString();
+    }
 
-        // Center on first match
-        const firstMatch = matchingNodes[0];
-        cy.animate({
-            center: { eles: firstMatch },
-            zoom: Math.max(cy.zoom(), 1.5)
-        }, {
-            duration: 500
-        });
+    // Apply the filters
+    setTimeout(() => {
+        applyCurrentFilters();
+    }, 100);
+}
 
-        // Update info
-        if (matchingNodes.length === 1) {
-            showGraphInfo(`Found: <strong>${firstMatch.data('label')}</strong>`);
-        } else {
-            showGraphInfo(`Found ${matchingNodes.length} containers matching "${searchTerm}"`);
-        }
-    } else {
-        showGraphInfo(`No containers found matching "${searchTerm}"`);
-    }
+// Format date for datetime-local input
+function formatDateTimeLocal(date) {
+    const year = date.getFullYear();
+    const month = String(date.getMonth() + 1).padStart(2, '0');
+    const day = String(date.getDate()).padStart(2, '0');
+    const hours = String(date.getHours()).padStart(2, '0');
+    const minutes = String(date.getMinutes()).padStart(2, '0');
+    return `${year}-${month}-${day}T${hours}:${minutes}`;
 }
 
-function toggleEdgeLabels() {
-    if (!cy) return;
+// Load hosts for report filter dropdown
+async function loadHostsForReportFilter() {
+    try {
+        const response = await fetch('/api/hosts');
+        const data = await response.json();
 
-    const hideLabels = document.getElementById('hideEdgeLabels').checked;
+        const select = document.getElementById('reportHostFilter');
+        select.innerHTML = '<option value="">All Hosts</option>';
 
-    if (hideLabels) {
-        cy.edges().addClass('no-label');
-    } else {
-        cy.edges().removeClass('no-label');
+        // Group hosts by site once sites are in use
+        const grouped = data.some(host => host.site);
+        const groups = {};
+        data.forEach(host => {
+            const option = document.createElement('option');
+            option.value = host.id;
+            option.textContent = host.name;
+            if (!grouped) {
+                select.appendChild(option);
+                return;
+            }
+            const site = host.site || 'No site';
+            if (!groups[site]) {
+                groups[site] = document.createElement('optgroup');
+                groups[site].label = '📍 ' + site;
+            }
+            groups[site].appendChild(option);
+        });
+        Object.keys(groups).sort().forEach(site => select.appendChild(groups[site]));
+
+        // The host migration card picks its source and destination from the same hosts
+        ['migrationSource', 'migrationDest'].forEach((id, i) => {
+            const hostSelect = document.getElementById(id);
+            if (!hostSelect) return;
+            hostSelect.innerHTML = data.map(host => `<option value="${host.id}">${escapeHtml(host.name)}</option>`).join('');
+            if (data.length > i) hostSelect.selectedIndex = i;
+        });
+    } catch (error) {
+        console.error('Failed to load hosts for report filter:', error);
     }
 }
 
-// Stats Modal
-let statsCharts = { cpu: null, memory: null };
-let currentStatsContainer = null;
-let currentStatsRange = '1h';
-
-function openStatsModal(hostId, containerId, containerName) {
-    console.log('openStatsModal called with:', { hostId, containerId, containerName });
-
-    currentStatsContainer = { hostId, containerId, containerName };
-    currentStatsRange = '1h';
+// Set report date range preset
+function setReportRange(days) {
+    const end = new Date();
+    const start = new Date(end - days * 24 * 60 * 60 * 1000);
 
-    const modal = document.getElementById('statsModal');
-    const nameElement = document.getElementById('statsContainerName');
+    document.getElementById('reportStartDate').value = formatDateTimeLocal(start);
+    document.getElementById('reportEndDate').value = formatDateTimeLocal(end);
+}
 
-    if (!modal) {
-        console.error('Stats modal element not found!');
-        return;
-    }
+// Generate report
+async function generateReport() {
+    const startInput = document.getElementById('reportStartDate').value;
+    const endInput = document.getElementById('reportEndDate').value;
+    const hostFilter = document.getElementById('reportHostFilter').value;
+    const stackFilter = document.getElementById('reportStackFilter')?.value.trim() || '';
+    const source = document.getElementById('reportSource')?.value || 'history';
 
-    if (!nameElement) {
-        console.error('Stats container name element not found!');
+    if (!startInput || !endInput) {
+        alert('Please select both start and end dates');
         return;
     }
 
-    nameElement.textContent = containerName;
-
-    // Use the 'show' class instead of style.display
-    modal.classList.add('show');
-    modal.style.display = ''; // Clear any inline style
-
-    console.log('Modal displayed with show class');
+    const start = new Date(startInput).toISOString();
+    const end = new Date(endInput).toISOString();
 
-    // Reset range buttons
-    document.querySelectorAll('.stats-range-btn').forEach(btn => {
-        btn.classList.toggle('active', btn.dataset.range === '1h');
-    });
+    // Show loading, hide results and empty state
+    document.getElementById('reportLoading').style.display = 'block';
+    document.getElementById('reportResults').style.display = 'none';
+    document.getElementById('reportEmptyState').style.display = 'none';
 
-    // Add click handlers for range buttons
-    document.querySelectorAll('.stats-range-btn').forEach(btn => {
-        btn.onclick = () => changeStatsRange(btn.dataset.range);
-    });
+    try {
+        let url = `/api/reports/changes?start=${encodeURIComponent(start)}&end=${encodeURIComponent(end)}`;
+        if (source === 'snapshots') {
+            // Snapshot diffs compare whole days (the snapshot in effect on each date)
+            url = `/api/reports/snapshots/diff?from=${startInput.slice(0, 10)}&to=${endInput.slice(0, 10)}`;
+        } else if (stackFilter) {
+            // Snapshots don't record compose projects
+            url += `&stack=${encodeURIComponent(stackFilter)}`;
+        }
+        if (hostFilter) {
+            url += `&host_id=${hostFilter}`;
+        }
 
-    loadStatsData();
-}
+        const response = await fetch(url);
+        if (!response.ok) {
+            throw new Error(`HTTP ${response.status}: ${await response.text()}`);
+        }
 
-function closeStatsModal() {
-    const modal = document.getElementById('statsModal');
-    if (modal) {
-        modal.classList.remove('show');
-    }
+        currentReport = await response.json();
+        renderReport(currentReport);
 
-    // Destroy charts
-    if (statsCharts.cpu) {
-        statsCharts.cpu.destroy();
-        statsCharts.cpu = null;
-    }
-    if (statsCharts.memory) {
-        statsCharts.memory.destroy();
-        statsCharts.memory = null;
+        // Hide loading, show results
+        document.getElementById('reportLoading').style.display = 'none';
+        document.getElementById('reportResults').style.display = 'block';
+    } catch (error) {
+        console.error('Failed to generate report:', error);
+        alert('Failed to generate report: ' + error.message);
+        document.getElementById('reportLoading').style.display = 'none';
+        document.getElementById('reportEmptyState').style.display = 'block';
     }
-
-    currentStatsContainer = null;
 }
 
-function changeStatsRange(range) {
-    currentStatsRange = range;
+// Render report
+function renderReport(report) {
+    // Render summary cards
+    renderReportSummary(report.summary);
 
-    // Update active button
-    document.querySelectorAll('.stats-range-btn').forEach(btn => {
-        btn.classList.toggle('active', btn.dataset.range === range);
-    });
+    // Render timeline chart
+    renderTimelineChart(report);
 
-    loadStatsData();
+    // Render details sections
+    renderStackChanges(report.stacks || []);
+    renderReportAnnotations(report.annotations || []);
+    renderNewContainers(report.new_containers);
+    renderRemovedContainers(report.removed_containers);
+    renderImageUpdates(report.image_updates);
+    renderStateChanges(report.state_changes);
+    renderTopRestarted(report.top_restarted);
 }
 
-async function loadStatsData() {
-    if (!currentStatsContainer) {
-        console.error('No current stats container set');
-        return;
-    }
-
-    const { hostId, containerId } = currentStatsContainer;
-    const url = `/api/containers/${hostId}/${containerId}/stats?range=${currentStatsRange}`;
+// Render summary cards
+function renderReportSummary(summary) {
+    const cardsHTML = `
+        <div class="stat-card">
+            <div class="stat-icon">🖥️</div>
+            <div class="stat-content">
+                <div class="stat-value">${summary.total_hosts}</div>
+                <div class="stat-label">Total Hosts</div>
+            </div>
+        </div>
+        <div class="stat-card">
+            <div class="stat-icon">📦</div>
+            <div class="stat-content">
+                <div class="stat-value">${summary.total_containers}</div>
+                <div class="stat-label">Total Containers</div>
+            </div>
+        </div>
+        <div class="stat-card">
+            <div class="stat-icon">🆕</div>
+            <div class="stat-content">
+                <div class="stat-value">${summary.new_containers}</div>
+                <div class="stat-label">New Containers</div>
+            </div>
+        </div>
+        <div class="stat-card">
+            <div class="stat-icon">❌</div>
+            <div class="stat-content">
+                <div class="stat-value">${summary.removed_containers}</div>
+                <div class="stat-label">Removed</div>
+            </div>
+        </div>
+        <div class="stat-card">
+            <div class="stat-icon">🔄</div>
+            <div class="stat-content">
+                <div class="stat-value">${summary.image_updates}</div>
+                <div class="stat-label">Image Updates</div>
+            </div>
+        </div>
+        <div class="stat-card">
+            <div class="stat-icon">🔀</div>
+            <div class="stat-content">
+                <div class="stat-value">${summary.state_changes}</div>
+                <div class="stat-label">State Changes</div>
+            </div>
+        </div>
+        <div class="stat-card">
+            <div class="stat-icon">📚</div>
+            <div class="stat-content">
+                <div class="stat-value">${summary.stacks || 0}</div>
+                <div class="stat-label">Stacks Changed</div>
+            </div>
+        </div>
+    `;
 
-    console.log('Loading stats from:', url);
+    document.getElementById('reportSummaryCards').innerHTML = cardsHTML;
+}
 
-    try {
-        const response = await fetch(url);
-        console.log('Stats response status:', response.status);
+// Render timeline chart
+function renderTimelineChart(report) {
+    // Destroy existing chart if it exists
+    if (changesTimelineChart) {
+        changesTimelineChart.destroy();
+    }
 
-        if (!response.ok) {
-            const errorText = await response.text();
-            console.error('Stats API error:', errorText);
-            throw new Error(`Failed to load stats: ${response.status} ${errorText}`);
-        }
+    // Aggregate changes by day
+    const changesByDay = {};
 
-        const stats = await response.json();
-        console.log('Stats data received:', stats);
+    // Helper to get day key
+    const getDayKey = (timestamp) => {
+        const date = new Date(timestamp);
+        return date.toISOString().split('T')[0];
+    };
 
-        if (!stats || !Array.isArray(stats) || stats.length === 0) {
-            document.getElementById('statsMessage').textContent = 'No stats data available for this time range. Stats collection may need more time to gather data.';
-            document.getElementById('statsMessage').className = 'loading';
-            document.getElementById('statsMessage').style.display = 'block';
-            document.getElementById('statsChartArea').style.display = 'none';
-            return;
-        }
+    // Count new containers
+    report.new_containers.forEach(c => {
+        const day = getDayKey(c.timestamp);
+        if (!changesByDay[day]) changesByDay[day] = { new: 0, removed: 0, imageUpdates: 0, stateChanges: 0 };
+        changesByDay[day].new++;
+    });
 
-        // Hide message and show charts
-        document.getElementById('statsMessage').style.display = 'none';
-        document.getElementById('statsChartArea').style.display = 'block';
+    // Count removed containers
+    report.removed_containers.forEach(c => {
+        const day = getDayKey(c.timestamp);
+        if (!changesByDay[day]) changesByDay[day] = { new: 0, removed: 0, imageUpdates: 0, stateChanges: 0 };
+        changesByDay[day].removed++;
+    });
 
-        renderStatsCharts(stats);
-        updateStatsSummary(stats);
-    } catch (error) {
-        console.error('Error loading stats:', error);
-        document.getElementById('statsMessage').textContent = `Failed to load stats data: ${error.message}`;
-        document.getElementById('statsMessage').className = 'error';
-        document.getElementById('statsMessage').style.display = 'block';
-        document.getElementById('statsChartArea').style.display = 'none';
-    }
-}
+    // Count image updates
+    report.image_updates.forEach(u => {
+        const day = getDayKey(u.updated_at);
+        if (!changesByDay[day]) changesByDay[day] = { new: 0, removed: 0, imageUpdates: 0, stateChanges: 0 };
+        changesByDay[day].imageUpdates++;
+    });
 
-function renderStatsCharts(stats) {
-    // Destroy existing charts
-    if (statsCharts.cpu) statsCharts.cpu.destroy();
-    if (statsCharts.memory) statsCharts.memory.destroy();
+    // Count state changes
+    report.state_changes.forEach(s => {
+        const day = getDayKey(s.changed_at);
+        if (!changesByDay[day]) changesByDay[day] = { new: 0, removed: 0, imageUpdates: 0, stateChanges: 0 };
+        changesByDay[day].stateChanges++;
+    });
 
-    // Prepare data
-    const labels = stats.map(s => new Date(s.timestamp).toLocaleString());
-    const cpuData = stats.map(s => s.cpu_percent || 0);
-    const memoryData = stats.map(s => (s.memory_usage || 0) / 1024 / 1024); // Convert to MB
-    const memoryLimitData = stats.map(s => (s.memory_limit || 0) / 1024 / 1024);
+    // Sort days
+    const days = Object.keys(changesByDay).sort();
 
-    // CPU Chart
-    const cpuCanvas = document.getElementById('cpuChart');
-    const cpuCtx = cpuCanvas.getContext('2d');
-    statsCharts.cpu = new Chart(cpuCtx, {
+    const ctx = document.getElementById('changesTimelineChart').getContext('2d');
+    changesTimelineChart = new Chart(ctx, {
         type: 'line',
         data: {
-            labels: labels,
-            datasets: [{
-                label: 'CPU %',
-                data: cpuData,
-                borderColor: 'rgb(75, 192, 192)',
-                backgroundColor: 'rgba(75, 192, 192, 0.2)',
-                tension: 0.4,
-                fill: true
-            }]
-        },
-        options: {
-            responsive: true,
-            maintainAspectRatio: false,
-            plugins: {
-                title: {
-                    display: true,
-                    text: 'CPU Usage Over Time'
+            labels: days.map(d => new Date(d).toLocaleDateString(dateLocale(), dateOptions())),
+            datasets: [
+                {
+                    label: 'New Containers',
+                    data: days.map(d => changesByDay[d].new),
+                    borderColor: '#2ecc71',
+                    backgroundColor: 'rgba(46, 204, 113, 0.1)',
+                    tension: 0.4
                 },
-                legend: {
-                    display: false
-                }
-            },
-            scales: {
-                y: {
-                    beginAtZero: true,
-                    max: 100,
-                    title: {
-                        display: true,
-                        text: 'CPU %'
-                    }
+                {
+                    label: 'Removed Containers',
+                    data: days.map(d => changesByDay[d].removed),
+                    borderColor: '#e74c3c',
+                    backgroundColor: 'rgba(231, 76, 60, 0.1)',
+                    tension: 0.4
                 },
-                x: {
-                    ticks: {
-                        maxTicksLimit: 10
-                    }
+                {
+                    label: 'Image Updates',
+                    data: days.map(d => changesByDay[d].imageUpdates),
+                    borderColor: '#3498db',
+                    backgroundColor: 'rgba(52, 152, 219, 0.1)',
+                    tension: 0.4
+                },
+                {
+                    label: 'State Changes',
+                    data: days.map(d => changesByDay[d].stateChanges),
+                    borderColor: '#f39c12',
+                    backgroundColor: 'rgba(243, 156, 18, 0.1)',
+                    tension: 0.4
                 }
-            }
-        }
-    });
-
-    // Memory Chart
-    const memoryCanvas = document.getElementById('memoryChart');
-    const memoryCtx = memoryCanvas.getContext('2d');
-    const datasets = [{
-        label: 'Memory Usage (MB)',
-        data: memoryData,
-        borderColor: 'rgb(255, 99, 132)',
-        backgroundColor: 'rgba(255, 99, 132, 0.2)',
-        tension: 0.4,
-        fill: true
-    }];
-
-    // Add memory limit line if available
-    const hasLimit = memoryLimitData.some(l => l > 0);
-    if (hasLimit) {
-        datasets.push({
-            label: 'Memory Limit (MB)',
-            data: memoryLimitData,
-            borderColor: 'rgb(255, 159, 64)',
-            backgroundColor: 'rgba(255, 159, 64, 0.1)',
-            borderDash: [5, 5],
-            tension: 0,
-            fill: false
-        });
-    }
-
-    statsCharts.memory = new Chart(memoryCtx, {
-        type: 'line',
-        data: {
-            labels: labels,
-            datasets: datasets
+            ]
         },
         options: {
             responsive: true,
-            maintainAspectRatio: false,
+            maintainAspectRatio: true,
             plugins: {
-                title: {
+                legend: {
                     display: true,
-                    text: 'Memory Usage Over Time'
+                    position: 'bottom'
                 }
             },
             scales: {
                 y: {
                     beginAtZero: true,
-                    title: {
-                        display: true,
-                        text: 'Memory (MB)'
-                    }
-                },
-                x: {
                     ticks: {
-                        maxTicksLimit: 10
+                        stepSize: 1
                     }
                 }
             }
@@ -4486,151 +7397,340 @@ function renderStatsCharts(stats) {
     });
 }
 
-function updateStatsSummary(stats) {
-    const cpuValues = stats.map(s => s.cpu_percent || 0).filter(v => v > 0);
-    const memoryValues = stats.map(s => s.memory_usage || 0).filter(v => v > 0);
+// Render the changes grouped by compose project, one row per stack
+function renderStackChanges(stacks) {
+    document.getElementById('stacksCount').textContent = stacks.length;
 
-    // CPU stats
-    const avgCpu = cpuValues.length > 0 ? cpuValues.reduce((a, b) => a + b, 0) / cpuValues.length : 0;
-    const maxCpu = cpuValues.length > 0 ? Math.max(...cpuValues) : 0;
+    if (stacks.length === 0) {
+        document.getElementById('stacksTable').innerHTML = '<p class="empty-message">No stack changes in this period</p>';
+        return;
+    }
 
-    document.getElementById('avgCpu').textContent = avgCpu.toFixed(1) + '%';
-    document.getElementById('maxCpu').textContent = maxCpu.toFixed(1) + '%';
+    const counts = s => [
+        s.image_updates ? `${s.image_updates} updated` : '',
+        s.new_containers ? `${s.new_containers} new` : '',
+        s.removed_containers ? `${s.removed_containers} removed` : '',
+        s.state_changes ? `${s.state_changes} state changes` : ''
+    ].filter(Boolean).join(' · ');
 
-    // Memory stats
-    const avgMemory = memoryValues.length > 0 ? memoryValues.reduce((a, b) => a + b, 0) / memoryValues.length : 0;
-    const maxMemory = memoryValues.length > 0 ? Math.max(...memoryValues) : 0;
+    document.getElementById('stacksTable').innerHTML = `
+        <table class="report-table">
+            <thead>
+                <tr>
+                    <th>Stack</th>
+                    <th>Host</th>
+                    <th>Services</th>
+                    <th>Changes</th>
+                    <th>Last Change</th>
+                </tr>
+            </thead>
+            <tbody>
+                ${stacks.map(s => `
+                    <tr>
+                        <td>
+                            <strong class="container-link" onclick="filterReportByStack('${escapeAttr(s.compose_project)}')" title="Report this stack only">${escapeHtml(s.summary)}</strong>
+                            ${s.updates.map(u => `<div class="stack-update">🔄 ${formatDateTime(u.updated_at)}: ${u.services.map(escapeHtml).join(', ')}</div>`).join('')}
+                        </td>
+                        <td>${escapeHtml(s.host_name)}</td>
+                        <td>${s.services.map(name => `<code>${escapeHtml(name)}</code>`).join(' ')}</td>
+                        <td>${counts(s)}</td>
+                        <td>${formatDateTime(s.last_change_at)}</td>
+                    </tr>
+                `).join('')}
+            </tbody>
+        </table>
+    `;
+}
 
-    const formatMemory = (bytes) => {
-        const mb = bytes / 1024 / 1024;
-        if (mb > 1024) {
-            return (mb / 1024).toFixed(2) + ' GB';
+// Render the annotations of the report's period
+function renderReportAnnotations(annotations) {
+    document.getElementById('reportAnnotationsCount').textContent = annotations.length;
+    document.getElementById('reportAnnotationsTable').innerHTML = renderAnnotationList(annotations, 'report');
+}
+
+// Regenerate the report for one stack
+function filterReportByStack(project) {
+    document.getElementById('reportStackFilter').value = project;
+    generateReport();
+}
+
+// Offer the compose projects of the current containers in the report's stack filter
+async function loadStacksForReportFilter() {
+    try {
+        let current = containers;
+        if (!current || current.length === 0) {
+            const response = await fetchWithAuth('/api/containers');
+            current = await response.json();
         }
-        return mb.toFixed(0) + ' MB';
-    };
+        const projects = [...new Set(current.map(c => c.compose_project).filter(Boolean))].sort();
+        document.getElementById('reportStackOptions').innerHTML = projects.map(p => `<option value="${escapeAttr(p)}">`).join('');
+    } catch (error) {
+        console.error('Failed to load stacks for report filter:', error);
+    }
+}
+
+// Render new containers table
+function renderNewContainers(containers) {
+    document.getElementById('newContainersCount').textContent = containers.length;
+
+    if (containers.length === 0) {
+        document.getElementById('newContainersTable').innerHTML = '<p class="empty-message">No new containers in this period</p>';
+        return;
+    }
+
+    const tableHTML = `
+        <table class="report-table">
+            <thead>
+                <tr>
+                    <th>Container Name</th>
+                    <th>Image</th>
+                    <th>Host</th>
+                    <th>First Seen</th>
+                    <th>State</th>
+                    <th>Actions</th>
+                </tr>
+            </thead>
+            <tbody>
+                ${containers.map(c => `
+                    <tr>
+                        <td>
+                            <code class="container-link" onclick="goToContainerHistory('${escapeHtml(c.container_name)}', ${c.host_id})" title="View in History">
+                                ${escapeHtml(c.container_name)} 🔗
+                            </code>
+                            ${c.is_transient ? '<span class="transient-badge" title="This container appeared and disappeared within the reporting period">⚡ Transient</span>' : ''}
+                        </td>
+                        <td>${escapeHtml(c.image)}</td>
+                        <td>${escapeHtml(c.host_name)}</td>
+                        <td>${formatDateTime(c.timestamp)}</td>
+                        <td><span class="status-badge status-${c.state}">${c.state}</span></td>
+                        <td>
+                            <button class="btn-icon" onclick="openStatsModal(${c.host_id}, '${escapeHtml(c.container_id)}', '${escapeHtml(c.container_name)}')" title="View Stats & Timeline">
+                                📊
+                            </button>
+                            <button class="btn-icon" onclick="viewContainerTimeline(${c.host_id}, '${escapeHtml(c.container_id)}', '${escapeHtml(c.container_name)}')" title="View Lifecycle Timeline">
+                                📜
+                            </button>
+                        </td>
+                    </tr>
+                `).join('')}
+            </tbody>
+        </table>
+    `;
 
-    document.getElementById('avgMemory').textContent = formatMemory(avgMemory);
-    document.getElementById('maxMemory').textContent = formatMemory(maxMemory);
+    document.getElementById('newContainersTable').innerHTML = tableHTML;
 }
 
-// Close modal when clicking outside
-document.getElementById('statsModal')?.addEventListener('click', (e) => {
-    if (e.target.classList.contains('modal')) closeStatsModal();
-});
+// Render removed containers table
+function renderRemovedContainers(containers) {
+    document.getElementById('removedContainersCount').textContent = containers.length;
 
-// ==================== REPORTS TAB ====================
+    if (containers.length === 0) {
+        document.getElementById('removedContainersTable').innerHTML = '<p class="empty-message">No removed containers in this period</p>';
+        return;
+    }
 
-let currentReport = null;
-let changesTimelineChart = null;
+    const tableHTML = `
+        <table class="report-table">
+            <thead>
+                <tr>
+                    <th>Container Name</th>
+                    <th>Image</th>
+                    <th>Host</th>
+                    <th>Last Seen</th>
+                    <th>Final State</th>
+                    <th>Actions</th>
+                </tr>
+            </thead>
+            <tbody>
+                ${containers.map(c => `
+                    <tr>
+                        <td>
+                            <code class="container-link" onclick="goToContainerHistory('${escapeHtml(c.container_name)}', ${c.host_id})" title="View in History">
+                                ${escapeHtml(c.container_name)} 🔗
+                            </code>
+                            ${c.is_transient ? '<span class="transient-badge" title="This container appeared and disappeared within the reporting period">⚡ Transient</span>' : ''}
+                        </td>
+                        <td>${escapeHtml(c.image)}</td>
+                        <td>${escapeHtml(c.host_name)}</td>
+                        <td>${formatDateTime(c.timestamp)}</td>
+                        <td><span class="status-badge status-${c.state}">${c.state}</span></td>
+                        <td>
+                            <button class="btn-icon" onclick="openStatsModal(${c.host_id}, '${escapeHtml(c.container_id)}', '${escapeHtml(c.container_name)}')" title="View Stats & Timeline">
+                                📊
+                            </button>
+                            <button class="btn-icon" onclick="viewContainerTimeline(${c.host_id}, '${escapeHtml(c.container_id)}', '${escapeHtml(c.container_name)}')" title="View Lifecycle Timeline">
+                                📜
+                            </button>
+                        </td>
+                    </tr>
+                `).join('')}
+            </tbody>
+        </table>
+    `;
 
-// Initialize reports tab
-function initializeReportsTab() {
-    // Set default date range to last 7 days
-    const end = new Date();
-    const start = new Date(end - 7 * 24 * 60 * 60 * 1000);
+    document.getElementById('removedContainersTable').innerHTML = tableHTML;
+}
 
-    document.getElementById('reportStartDate').value = formatDateTimeLocal(start);
-    document.getElementById('reportEndDate').value = formatDateTimeLocal(end);
+// Render image updates table
+function renderImageUpdates(updates) {
+    document.getElementById('imageUpdatesCount').textContent = updates.length;
 
-    // Load hosts for filter
-    loadHostsForReportFilter();
+    if (updates.length === 0) {
+        document.getElementById('imageUpdatesTable').innerHTML = '<p class="empty-message">No image updates in this period</p>';
+        return;
+    }
 
-    // Set up event listeners
-    setupReportEventListeners();
-}
+    const tableHTML = `
+        <table class="report-table">
+            <thead>
+                <tr>
+                    <th>Container Name</th>
+                    <th>Host</th>
+                    <th>Old Image</th>
+                    <th>New Image</th>
+                    <th>Updated At</th>
+                    <th>Actions</th>
+                </tr>
+            </thead>
+            <tbody>
+                ${updates.map(u => `
+                    <tr>
+                        <td>
+                            <code class="container-link" onclick="goToContainerHistory('${escapeHtml(u.container_name)}', ${u.host_id})" title="View in History">
+                                ${escapeHtml(u.container_name)} 🔗
+                            </code>
+                        </td>
+                        <td>${escapeHtml(u.host_name)}</td>
+                        <td>${escapeHtml(u.old_image)}<br><small>${u.old_image_id.substring(0, 12)}</small></td>
+                        <td>${escapeHtml(u.new_image)}<br><small>${u.new_image_id.substring(0, 12)}</small></td>
+                        <td>${formatDateTime(u.updated_at)}</td>
+                        <td>
+                            <button class="btn-icon" onclick="openStatsModal(${u.host_id}, '${escapeHtml(u.container_id)}', '${escapeHtml(u.container_name)}')" title="View Stats & Timeline">
+                                📊
+                            </button>
+                            <button class="btn-icon" onclick="viewContainerTimeline(${u.host_id}, '${escapeHtml(u.container_id)}', '${escapeHtml(u.container_name)}')" title="View Lifecycle Timeline">
+                                📜
+                            </button>
+                        </td>
+                    </tr>
+                `).join('')}
+            </tbody>
+        </table>
+    `;
 
-// Set up event listeners for reports tab
-function setupReportEventListeners() {
-    document.getElementById('generateReportBtn').addEventListener('click', generateReport);
-    document.getElementById('report7d').addEventListener('click', () => setReportRange(7));
-    document.getElementById('report30d').addEventListener('click', () => setReportRange(30));
-    document.getElementById('report90d').addEventListener('click', () => setReportRange(90));
-    document.getElementById('exportReportBtn').addEventListener('click', exportReport);
+    document.getElementById('imageUpdatesTable').innerHTML = tableHTML;
 }
 
-// Navigate to History tab with container filter
-function goToContainerHistory(containerName, hostId) {
-    // Switch to history tab
-    switchTab('history');
-
-    // Set the search filter to the container name
-    const searchInput = document.getElementById('searchInput');
-    if (searchInput) {
-        searchInput.value = containerName;
-    }
+// Render state changes table
+function renderStateChanges(changes) {
+    document.getElementById('stateChangesCount').textContent = changes.length;
 
-    // Set the host filter if provided
-    const hostFilter = document.getElementById('hostFilter');
-    if (hostFilter && hostId) {
-        hostFilter.value = hostId.toString();
+    if (changes.length === 0) {
+        document.getElementById('stateChangesTable').innerHTML = '<p class="empty-message">No state changes in this period</p>';
+        return;
     }
 
-    // Apply the filters
-    setTimeout(() => {
-        applyCurrentFilters();
-    }, 100);
-}
+    const tableHTML = `
+        <table class="report-table">
+            <thead>
+                <tr>
+                    <th>Container Name</th>
+                    <th>Host</th>
+                    <th>Old State</th>
+                    <th>New State</th>
+                    <th>Changed At</th>
+                    <th>Actions</th>
+                </tr>
+            </thead>
+            <tbody>
+                ${changes.map(s => `
+                    <tr>
+                        <td>
+                            <code class="container-link" onclick="goToContainerHistory('${escapeHtml(s.container_name)}', ${s.host_id})" title="View in History">
+                                ${escapeHtml(s.container_name)} 🔗
+                            </code>
+                        </td>
+                        <td>${escapeHtml(s.host_name)}</td>
+                        <td><span class="status-badge status-${s.old_state}">${s.old_state}</span></td>
+                        <td><span class="status-badge status-${s.new_state}">${s.new_state}</span></td>
+                        <td>${formatDateTime(s.changed_at)}</td>
+                        <td>
+                            <button class="btn-icon" onclick="openStatsModal(${s.host_id}, '${escapeHtml(s.container_id)}', '${escapeHtml(s.container_name)}')" title="View Stats & Timeline">
+                                📊
+                            </button>
+                            <button class="btn-icon" onclick="viewContainerTimeline(${s.host_id}, '${escapeHtml(s.container_id)}', '${escapeHtml(s.container_name)}')" title="View Lifecycle Timeline">
+                                📜
+                            </button>
+                        </td>
+                    </tr>
+                `).join('')}
+            </tbody>
+        </table>
+    `;
 
-// Format date for datetime-local input
-function formatDateTimeLocal(date) {
-    const year = date.getFullYear();
-    const month = String(date.getMonth() + 1).padStart(2, '0');
-    const day = String(date.getDate()).padStart(2, '0');
-    const hours = String(date.getHours()).padStart(2, '0');
-    const minutes = String(date.getMinutes()).padStart(2, '0');
-    return `${year}-${month}-${day}T${hours}:${minutes}`;
+    document.getElementById('stateChangesTable').innerHTML = tableHTML;
 }
 
-// Load hosts for report filter dropdown
-async function loadHostsForReportFilter() {
-    try {
-        const response = await fetch('/api/hosts');
-        const data = await response.json();
-
-        const select = document.getElementById('reportHostFilter');
-        select.innerHTML = '<option value="">All Hosts</option>';
+// Render top restarted containers table
+function renderTopRestarted(containers) {
+    document.getElementById('topRestartedCount').textContent = containers.length;
 
-        data.forEach(host => {
-            const option = document.createElement('option');
-            option.value = host.id;
-            option.textContent = host.name;
-            select.appendChild(option);
-        });
-    } catch (error) {
-        console.error('Failed to load hosts for report filter:', error);
+    if (containers.length === 0) {
+        document.getElementById('topRestartedTable').innerHTML = '<p class="empty-message">No active containers in this period</p>';
+        return;
     }
-}
 
-// Set report date range preset
-function setReportRange(days) {
-    const end = new Date();
-    const start = new Date(end - days * 24 * 60 * 60 * 1000);
+    const tableHTML = `
+        <table class="report-table">
+            <thead>
+                <tr>
+                    <th>Container Name</th>
+                    <th>Image</th>
+                    <th>Host</th>
+                    <th>Activity Count</th>
+                    <th>Current State</th>
+                    <th>Actions</th>
+                </tr>
+            </thead>
+            <tbody>
+                ${containers.map(r => `
+                    <tr>
+                        <td>
+                            <code class="container-link" onclick="goToContainerHistory('${escapeHtml(r.container_name)}', ${r.host_id})" title="View in History">
+                                ${escapeHtml(r.container_name)} 🔗
+                            </code>
+                        </td>
+                        <td>${escapeHtml(r.image)}</td>
+                        <td>${escapeHtml(r.host_name)}</td>
+                        <td>${r.restart_count}</td>
+                        <td><span class="status-badge status-${r.current_state}">${r.current_state}</span></td>
+                        <td>
+                            <button class="btn-icon" onclick="openStatsModal(${r.host_id}, '${escapeHtml(r.container_id)}', '${escapeHtml(r.container_name)}')" title="View Stats & Timeline">
+                                📊
+                            </button>
+                            <button class="btn-icon" onclick="viewContainerTimeline(${r.host_id}, '${escapeHtml(r.container_id)}', '${escapeHtml(r.container_name)}')" title="View Lifecycle Timeline">
+                                📜
+                            </button>
+                        </td>
+                    </tr>
+                `).join('')}
+            </tbody>
+        </table>
+    `;
 
-    document.getElementById('reportStartDate').value = formatDateTimeLocal(start);
-    document.getElementById('reportEndDate').value = formatDateTimeLocal(end);
+    document.getElementById('topRestartedTable').innerHTML = tableHTML;
 }
 
-// Generate report
-async function generateReport() {
-    const startInput = document.getElementById('reportStartDate').value;
-    const endInput = document.getElementById('reportEndDate').value;
+// Load likely idle containers for the selected window
+async function loadIdleContainers() {
+    const windowHours = document.getElementById('idleWindowHours').value;
+    const includePublished = document.getElementById('idleIncludePublished').checked;
     const hostFilter = document.getElementById('reportHostFilter').value;
-
-    if (!startInput || !endInput) {
-        alert('Please select both start and end dates');
-        return;
-    }
-
-    const start = new Date(startInput).toISOString();
-    const end = new Date(endInput).toISOString();
-
-    // Show loading, hide results and empty state
-    document.getElementById('reportLoading').style.display = 'block';
-    document.getElementById('reportResults').style.display = 'none';
-    document.getElementById('reportEmptyState').style.display = 'none';
+    const table = document.getElementById('idleContainersTable');
+    table.innerHTML = '<div class="loading">Analyzing stats history...</div>';
 
     try {
-        let url = `/api/reports/changes?start=${encodeURIComponent(start)}&end=${encodeURIComponent(end)}`;
+        let url = `/api/reports/idle?window_hours=${windowHours}&include_published=${includePublished}`;
         if (hostFilter) {
             url += `&host_id=${hostFilter}`;
         }
@@ -4639,197 +7739,94 @@ async function generateReport() {
         if (!response.ok) {
             throw new Error(`HTTP ${response.status}: ${await response.text()}`);
         }
-
-        currentReport = await response.json();
-        renderReport(currentReport);
-
-        // Hide loading, show results
-        document.getElementById('reportLoading').style.display = 'none';
-        document.getElementById('reportResults').style.display = 'block';
+        const report = await response.json();
+        renderIdleContainers(report.containers);
     } catch (error) {
-        console.error('Failed to generate report:', error);
-        alert('Failed to generate report: ' + error.message);
-        document.getElementById('reportLoading').style.display = 'none';
-        document.getElementById('reportEmptyState').style.display = 'block';
+        console.error('Failed to load idle containers:', error);
+        table.innerHTML = `<p class="empty-message">Failed to load idle containers: ${escapeHtml(error.message)}</p>`;
     }
 }
 
-// Render report
-function renderReport(report) {
-    // Render summary cards
-    renderReportSummary(report.summary);
-
-    // Render timeline chart
-    renderTimelineChart(report);
+// Render idle containers table
+function renderIdleContainers(containers) {
+    document.getElementById('idleContainersCount').textContent = containers.length;
 
-    // Render details sections
-    renderNewContainers(report.new_containers);
-    renderRemovedContainers(report.removed_containers);
-    renderImageUpdates(report.image_updates);
-    renderStateChanges(report.state_changes);
-    renderTopRestarted(report.top_restarted);
-}
+    if (containers.length === 0) {
+        document.getElementById('idleContainersTable').innerHTML = '<p class="empty-message">No idle containers found (stats must cover at least half of the window)</p>';
+        return;
+    }
 
-// Render summary cards
-function renderReportSummary(summary) {
-    const cardsHTML = `
-        <div class="stat-card">
-            <div class="stat-icon">🖥️</div>
-            <div class="stat-content">
-                <div class="stat-value">${summary.total_hosts}</div>
-                <div class="stat-label">Total Hosts</div>
-            </div>
-        </div>
-        <div class="stat-card">
-            <div class="stat-icon">📦</div>
-            <div class="stat-content">
-                <div class="stat-value">${summary.total_containers}</div>
-                <div class="stat-label">Total Containers</div>
-            </div>
-        </div>
-        <div class="stat-card">
-            <div class="stat-icon">🆕</div>
-            <div class="stat-content">
-                <div class="stat-value">${summary.new_containers}</div>
-                <div class="stat-label">New Containers</div>
-            </div>
-        </div>
-        <div class="stat-card">
-            <div class="stat-icon">❌</div>
-            <div class="stat-content">
-                <div class="stat-value">${summary.removed_containers}</div>
-                <div class="stat-label">Removed</div>
-            </div>
-        </div>
-        <div class="stat-card">
-            <div class="stat-icon">🔄</div>
-            <div class="stat-content">
-                <div class="stat-value">${summary.image_updates}</div>
-                <div class="stat-label">Image Updates</div>
-            </div>
-        </div>
-        <div class="stat-card">
-            <div class="stat-icon">🔀</div>
-            <div class="stat-content">
-                <div class="stat-value">${summary.state_changes}</div>
-                <div class="stat-label">State Changes</div>
-            </div>
-        </div>
+    const tableHTML = `
+        <table class="report-table">
+            <thead>
+                <tr>
+                    <th>Container Name</th>
+                    <th>Image</th>
+                    <th>Host</th>
+                    <th>Created</th>
+                    <th>Avg / Peak CPU</th>
+                    <th>Avg Memory</th>
+                    <th>Actions</th>
+                </tr>
+            </thead>
+            <tbody>
+                ${containers.map(c => `
+                    <tr>
+                        <td>
+                            <code class="container-link" onclick="goToContainerHistory('${escapeHtml(c.container_name)}', ${c.host_id})" title="View in History">
+                                ${escapeHtml(c.container_name)} 🔗
+                            </code>
+                        </td>
+                        <td>${escapeHtml(c.image)}</td>
+                        <td>${escapeHtml(c.host_name)}</td>
+                        <td>${formatDateTime(c.created)}</td>
+                        <td>${c.avg_cpu_percent.toFixed(2)}% / ${c.max_cpu_percent.toFixed(2)}%</td>
+                        <td>${(c.avg_memory_usage / 1024 / 1024).toFixed(0)} MB</td>
+                        <td>
+                            <button class="btn-icon" onclick="openStatsModal(${c.host_id}, '${escapeHtml(c.container_id)}', '${escapeHtml(c.container_name)}')" title="View Stats & Timeline">
+                                📊
+                            </button>
+                        </td>
+                    </tr>
+                `).join('')}
+            </tbody>
+        </table>
     `;
 
-    document.getElementById('reportSummaryCards').innerHTML = cardsHTML;
+    document.getElementById('idleContainersTable').innerHTML = tableHTML;
 }
 
-// Render timeline chart
-function renderTimelineChart(report) {
-    // Destroy existing chart if it exists
-    if (changesTimelineChart) {
-        changesTimelineChart.destroy();
-    }
-
-    // Aggregate changes by day
-    const changesByDay = {};
-
-    // Helper to get day key
-    const getDayKey = (timestamp) => {
-        const date = new Date(timestamp);
-        return date.toISOString().split('T')[0];
-    };
-
-    // Count new containers
-    report.new_containers.forEach(c => {
-        const day = getDayKey(c.timestamp);
-        if (!changesByDay[day]) changesByDay[day] = { new: 0, removed: 0, imageUpdates: 0, stateChanges: 0 };
-        changesByDay[day].new++;
-    });
-
-    // Count removed containers
-    report.removed_containers.forEach(c => {
-        const day = getDayKey(c.timestamp);
-        if (!changesByDay[day]) changesByDay[day] = { new: 0, removed: 0, imageUpdates: 0, stateChanges: 0 };
-        changesByDay[day].removed++;
-    });
-
-    // Count image updates
-    report.image_updates.forEach(u => {
-        const day = getDayKey(u.updated_at);
-        if (!changesByDay[day]) changesByDay[day] = { new: 0, removed: 0, imageUpdates: 0, stateChanges: 0 };
-        changesByDay[day].imageUpdates++;
-    });
-
-    // Count state changes
-    report.state_changes.forEach(s => {
-        const day = getDayKey(s.changed_at);
-        if (!changesByDay[day]) changesByDay[day] = { new: 0, removed: 0, imageUpdates: 0, stateChanges: 0 };
-        changesByDay[day].stateChanges++;
-    });
+// Load the restart policy audit of the latest scans
+async function loadRestartPolicyReport() {
+    const minUptime = document.getElementById('restartPolicyMinUptime').value;
+    const hostFilter = document.getElementById('reportHostFilter').value;
+    const table = document.getElementById('restartPoliciesTable');
+    table.innerHTML = '<div class="loading">Auditing restart policies...</div>';
 
-    // Sort days
-    const days = Object.keys(changesByDay).sort();
+    try {
+        let url = `/api/reports/restart-policies?min_uptime_hours=${minUptime}`;
+        if (hostFilter) {
+            url += `&host_id=${hostFilter}`;
+        }
 
-    const ctx = document.getElementById('changesTimelineChart').getContext('2d');
-    changesTimelineChart = new Chart(ctx, {
-        type: 'line',
-        data: {
-            labels: days.map(d => new Date(d).toLocaleDateString()),
-            datasets: [
-                {
-                    label: 'New Containers',
-                    data: days.map(d => changesByDay[d].new),
-                    borderColor: '#2ecc71',
-                    backgroundColor: 'rgba(46, 204, 113, 0.1)',
-                    tension: 0.4
-                },
-                {
-                    label: 'Removed Containers',
-                    data: days.map(d => changesByDay[d].removed),
-                    borderColor: '#e74c3c',
-                    backgroundColor: 'rgba(231, 76, 60, 0.1)',
-                    tension: 0.4
-                },
-                {
-                    label: 'Image Updates',
-                    data: days.map(d => changesByDay[d].imageUpdates),
-                    borderColor: '#3498db',
-                    backgroundColor: 'rgba(52, 152, 219, 0.1)',
-                    tension: 0.4
-                },
-                {
-                    label: 'State Changes',
-                    data: days.map(d => changesByDay[d].stateChanges),
-                    borderColor: '#f39c12',
-                    backgroundColor: 'rgba(243, 156, 18, 0.1)',
-                    tension: 0.4
-                }
-            ]
-        },
-        options: {
-            responsive: true,
-            maintainAspectRatio: true,
-            plugins: {
-                legend: {
-                    display: true,
-                    position: 'bottom'
-                }
-            },
-            scales: {
-                y: {
-                    beginAtZero: true,
-                    ticks: {
-                        stepSize: 1
-                    }
-                }
-            }
+        const response = await fetch(url);
+        if (!response.ok) {
+            throw new Error(`HTTP ${response.status}: ${await response.text()}`);
         }
-    });
+        const report = await response.json();
+        renderRestartPolicyReport(report);
+    } catch (error) {
+        console.error('Failed to audit restart policies:', error);
+        table.innerHTML = `<p class="empty-message">Failed to audit restart policies: ${escapeHtml(error.message)}</p>`;
+    }
 }
 
-// Render new containers table
-function renderNewContainers(containers) {
-    document.getElementById('newContainersCount').textContent = containers.length;
+// Render the restart policy findings table
+function renderRestartPolicyReport(report) {
+    document.getElementById('restartPoliciesCount').textContent = report.findings.length;
 
-    if (containers.length === 0) {
-        document.getElementById('newContainersTable').innerHTML = '<p class="empty-message">No new containers in this period</p>';
+    if (report.findings.length === 0) {
+        document.getElementById('restartPoliciesTable').innerHTML = '<p class="empty-message">All long-running containers have a restart policy</p>';
         return;
     }
 
@@ -4838,200 +7835,669 @@ function renderNewContainers(containers) {
             <thead>
                 <tr>
                     <th>Container Name</th>
-                    <th>Image</th>
                     <th>Host</th>
-                    <th>First Seen</th>
-                    <th>State</th>
-                    <th>Actions</th>
+                    <th>Finding</th>
+                    <th>Restart Policy</th>
+                    <th>Since</th>
                 </tr>
             </thead>
             <tbody>
-                ${containers.map(c => `
+                ${report.findings.map(f => `
                     <tr>
                         <td>
-                            <code class="container-link" onclick="goToContainerHistory('${escapeHtml(c.container_name)}', ${c.host_id})" title="View in History">
-                                ${escapeHtml(c.container_name)} 🔗
+                            <code class="container-link" onclick="goToContainerHistory('${escapeHtml(f.container_name)}', ${f.host_id})" title="View in History">
+                                ${escapeHtml(f.container_name)} 🔗
                             </code>
-                            ${c.is_transient ? '<span class="transient-badge" title="This container appeared and disappeared within the reporting period">⚡ Transient</span>' : ''}
-                        </td>
-                        <td>${escapeHtml(c.image)}</td>
-                        <td>${escapeHtml(c.host_name)}</td>
-                        <td>${formatDateTime(c.timestamp)}</td>
-                        <td><span class="status-badge status-${c.state}">${c.state}</span></td>
-                        <td>
-                            <button class="btn-icon" onclick="openStatsModal(${c.host_id}, '${escapeHtml(c.container_id)}', '${escapeHtml(c.container_name)}')" title="View Stats & Timeline">
-                                📊
-                            </button>
-                            <button class="btn-icon" onclick="viewContainerTimeline(${c.host_id}, '${escapeHtml(c.container_id)}', '${escapeHtml(c.container_name)}')" title="View Lifecycle Timeline">
-                                📜
-                            </button>
                         </td>
+                        <td>${escapeHtml(f.host_name)}</td>
+                        <td>${f.finding === 'missing'
+                            ? '<span class="risk-badge risk-high">No restart policy</span>'
+                            : '<span class="risk-badge risk-medium">Changed</span>'}</td>
+                        <td>${f.finding === 'changed'
+                            ? `<code>${escapeHtml(f.previous_restart_policy)}</code> → <code>${escapeHtml(f.restart_policy)}</code>`
+                            : `<code>${escapeHtml(f.restart_policy)}</code>`}</td>
+                        <td>${f.finding === 'changed' ? formatDateTime(f.changed_at) : (f.started_at ? `Up since ${formatDateTime(f.started_at)}` : '-')}</td>
                     </tr>
                 `).join('')}
             </tbody>
         </table>
     `;
 
-    document.getElementById('newContainersTable').innerHTML = tableHTML;
+    document.getElementById('restartPoliciesTable').innerHTML = tableHTML;
 }
 
-// Render removed containers table
-function renderRemovedContainers(containers) {
-    document.getElementById('removedContainersCount').textContent = containers.length;
+// Load how far behind upstream the containers run
+async function loadUpdateLagReport() {
+    const hostFilter = document.getElementById('reportHostFilter').value;
+    const table = document.getElementById('updateLagTable');
+    table.innerHTML = '<div class="loading">Loading update lag...</div>';
 
-    if (containers.length === 0) {
-        document.getElementById('removedContainersTable').innerHTML = '<p class="empty-message">No removed containers in this period</p>';
+    try {
+        const params = new URLSearchParams();
+        if (hostFilter) params.set('host_id', hostFilter);
+
+        const response = await fetch(`/api/reports/update-lag?${params}`);
+        if (!response.ok) {
+            throw new Error(`HTTP ${response.status}: ${await response.text()}`);
+        }
+        renderUpdateLagReport(await response.json());
+    } catch (error) {
+        console.error('Failed to load update lag:', error);
+        table.innerHTML = `<p class="empty-message">Failed to load update lag: ${escapeHtml(error.message)}</p>`;
+    }
+}
+
+function renderUpdateLagReport(report) {
+    document.getElementById('updateLagCount').textContent = report.behind;
+
+    if (report.behind === 0) {
+        document.getElementById('updateLagTable').innerHTML = '<p class="empty-message">No container is known to run behind its image\'s upstream build</p>';
         return;
     }
 
     const tableHTML = `
+        <p><strong>${report.behind}</strong> container${report.behind !== 1 ? 's' : ''} behind upstream,
+            ${report.average_days.toFixed(1)} days on average, at most ${report.max_days}.</p>
+        <table class="report-table">
+            <thead>
+                <tr>
+                    <th>Host</th>
+                    <th>Behind</th>
+                    <th>Average Days</th>
+                    <th>Total Days</th>
+                    <th>Furthest Behind</th>
+                </tr>
+            </thead>
+            <tbody>
+                ${report.hosts.map(h => `
+                    <tr>
+                        <td>${escapeHtml(h.host_name)}</td>
+                        <td>${h.behind}</td>
+                        <td>${h.average_days.toFixed(1)}</td>
+                        <td>${h.total_days}</td>
+                        <td>${escapeHtml(h.oldest_behind)} (${h.max_days} days)</td>
+                    </tr>
+                `).join('')}
+            </tbody>
+        </table>
         <table class="report-table">
             <thead>
                 <tr>
                     <th>Container Name</th>
                     <th>Image</th>
                     <th>Host</th>
-                    <th>Last Seen</th>
-                    <th>Final State</th>
-                    <th>Actions</th>
+                    <th>Days Behind</th>
+                    <th>Behind Since</th>
+                    <th>Last Checked</th>
                 </tr>
             </thead>
             <tbody>
-                ${containers.map(c => `
+                ${report.containers.map(c => `
                     <tr>
                         <td>
                             <code class="container-link" onclick="goToContainerHistory('${escapeHtml(c.container_name)}', ${c.host_id})" title="View in History">
                                 ${escapeHtml(c.container_name)} 🔗
                             </code>
-                            ${c.is_transient ? '<span class="transient-badge" title="This container appeared and disappeared within the reporting period">⚡ Transient</span>' : ''}
                         </td>
-                        <td>${escapeHtml(c.image)}</td>
+                        <td><code>${escapeHtml(c.image)}</code></td>
                         <td>${escapeHtml(c.host_name)}</td>
-                        <td>${formatDateTime(c.timestamp)}</td>
-                        <td><span class="status-badge status-${c.state}">${c.state}</span></td>
-                        <td>
-                            <button class="btn-icon" onclick="openStatsModal(${c.host_id}, '${escapeHtml(c.container_id)}', '${escapeHtml(c.container_name)}')" title="View Stats & Timeline">
-                                📊
-                            </button>
-                            <button class="btn-icon" onclick="viewContainerTimeline(${c.host_id}, '${escapeHtml(c.container_id)}', '${escapeHtml(c.container_name)}')" title="View Lifecycle Timeline">
-                                📜
-                            </button>
-                        </td>
+                        <td><span class="risk-badge ${c.days_behind >= 90 ? 'risk-high' : c.days_behind >= 30 ? 'risk-medium' : 'risk-low'}">${c.days_behind} days</span></td>
+                        <td>${formatDateTime(c.behind_since)}</td>
+                        <td>${formatDateTime(c.checked_at)}</td>
                     </tr>
                 `).join('')}
             </tbody>
         </table>
     `;
 
-    document.getElementById('removedContainersTable').innerHTML = tableHTML;
+    document.getElementById('updateLagTable').innerHTML = tableHTML;
 }
 
-// Render image updates table
-function renderImageUpdates(updates) {
-    document.getElementById('imageUpdatesCount').textContent = updates.length;
+// Load the bind mount index of the latest scans
+async function loadBindMountReport() {
+    const flagged = document.getElementById('bindMountsFlagged').value;
+    const hostFilter = document.getElementById('reportHostFilter').value;
+    const table = document.getElementById('bindMountsTable');
+    table.innerHTML = '<div class="loading">Indexing bind mounts...</div>';
 
-    if (updates.length === 0) {
-        document.getElementById('imageUpdatesTable').innerHTML = '<p class="empty-message">No image updates in this period</p>';
+    try {
+        const params = new URLSearchParams();
+        if (flagged) params.set('flagged', flagged);
+        if (hostFilter) params.set('host_id', hostFilter);
+
+        const response = await fetch(`/api/reports/bind-mounts?${params}`);
+        if (!response.ok) {
+            throw new Error(`HTTP ${response.status}: ${await response.text()}`);
+        }
+        renderBindMountReport(await response.json());
+    } catch (error) {
+        console.error('Failed to index bind mounts:', error);
+        table.innerHTML = `<p class="empty-message">Failed to index bind mounts: ${escapeHtml(error.message)}</p>`;
+    }
+}
+
+// Render the bind mount paths with their containers and findings
+function renderBindMountReport(report) {
+    document.getElementById('bindMountsCount').textContent = report.overlaps + report.sensitive;
+
+    if (report.paths.length === 0) {
+        document.getElementById('bindMountsTable').innerHTML = '<p class="empty-message">No overlapping or sensitive bind mounts</p>';
         return;
     }
 
-    const tableHTML = `
+    const finding = p => {
+        const badges = [];
+        if (p.overlap) badges.push(`<span class="risk-badge risk-high" title="Written by ${escapeAttr(p.writers.join(', '))}">Overlap</span>`);
+        if (p.sensitive === 'docker_socket') badges.push('<span class="risk-badge risk-critical">Docker socket</span>');
+        else if (p.sensitive) badges.push(`<span class="risk-badge risk-medium">Sensitive (${escapeHtml(p.sensitive)})</span>`);
+        return badges.join(' ') || '-';
+    };
+
+    document.getElementById('bindMountsTable').innerHTML = `
+        <p>${report.overlaps} overlapping, ${report.sensitive} sensitive.</p>
         <table class="report-table">
             <thead>
                 <tr>
-                    <th>Container Name</th>
                     <th>Host</th>
-                    <th>Old Image</th>
-                    <th>New Image</th>
-                    <th>Updated At</th>
-                    <th>Actions</th>
+                    <th>Host Path</th>
+                    <th>Containers</th>
+                    <th>Finding</th>
                 </tr>
             </thead>
             <tbody>
-                ${updates.map(u => `
+                ${report.paths.map(p => `
                     <tr>
-                        <td>
-                            <code class="container-link" onclick="goToContainerHistory('${escapeHtml(u.container_name)}', ${u.host_id})" title="View in History">
-                                ${escapeHtml(u.container_name)} 🔗
-                            </code>
-                        </td>
-                        <td>${escapeHtml(u.host_name)}</td>
-                        <td>${escapeHtml(u.old_image)}<br><small>${u.old_image_id.substring(0, 12)}</small></td>
-                        <td>${escapeHtml(u.new_image)}<br><small>${u.new_image_id.substring(0, 12)}</small></td>
-                        <td>${formatDateTime(u.updated_at)}</td>
-                        <td>
-                            <button class="btn-icon" onclick="openStatsModal(${u.host_id}, '${escapeHtml(u.container_id)}', '${escapeHtml(u.container_name)}')" title="View Stats & Timeline">
-                                📊
-                            </button>
-                            <button class="btn-icon" onclick="viewContainerTimeline(${u.host_id}, '${escapeHtml(u.container_id)}', '${escapeHtml(u.container_name)}')" title="View Lifecycle Timeline">
-                                📜
-                            </button>
-                        </td>
+                        <td>${escapeHtml(p.host_name)}</td>
+                        <td><code>${escapeHtml(p.source)}</code></td>
+                        <td>${p.mounts.map(m => `
+                            <code class="container-link" onclick="goToContainerHistory('${escapeHtml(m.container_name)}', ${p.host_id})" title="View in History">${escapeHtml(m.container_name)}</code>
+                            → <code>${escapeHtml(m.destination)}</code> <small>${m.rw ? 'rw' : 'ro'}${m.state !== 'running' ? `, ${escapeHtml(m.state)}` : ''}</small>
+                        `).join('<br>')}</td>
+                        <td>${finding(p)}</td>
+                    </tr>
+                `).join('')}
+            </tbody>
+        </table>
+    `;
+}
+
+// Load the map of reverse proxy routes to containers
+async function loadProxyRouteReport() {
+    const flagged = document.getElementById('proxyRoutesFlagged').value;
+    const hostFilter = document.getElementById('reportHostFilter').value;
+    const table = document.getElementById('proxyRoutesTable');
+    table.innerHTML = '<div class="loading">Mapping routes...</div>';
+
+    try {
+        const params = new URLSearchParams();
+        if (flagged) params.set('flagged', flagged);
+        if (hostFilter) params.set('host_id', hostFilter);
+
+        const response = await fetch(`/api/reports/proxy-routes?${params}`);
+        if (!response.ok) {
+            throw new Error(`HTTP ${response.status}: ${await response.text()}`);
+        }
+        renderProxyRouteReport(await response.json());
+    } catch (error) {
+        console.error('Failed to map proxy routes:', error);
+        table.innerHTML = `<p class="empty-message">Failed to map proxy routes: ${escapeHtml(error.message)}</p>`;
+    }
+}
+
+// Render the proxy routes with their containers, flagging routes to stopped containers
+function renderProxyRouteReport(report) {
+    document.getElementById('proxyRoutesCount').textContent = report.stopped + report.unmatched;
+
+    const errors = (report.errors || []).map(e => `<p class="empty-message">⚠️ ${escapeHtml(e)}</p>`).join('');
+    if (report.routes.length === 0) {
+        document.getElementById('proxyRoutesTable').innerHTML = errors + '<p class="empty-message">No proxy routes found</p>';
+        return;
+    }
+
+    const status = r => {
+        if (r.status === 'stopped') return `<span class="risk-badge risk-high">Container ${escapeHtml(r.container_state)}</span>`;
+        if (r.status === 'unmatched') return '<span class="risk-badge risk-medium">No container</span>';
+        return '<span class="risk-badge risk-low">OK</span>';
+    };
+    const source = r => {
+        if (r.source === 'traefik_api') return `Traefik API${r.router ? ` <small>(${escapeHtml(r.router)})</small>` : ''}`;
+        if (r.source === 'env') return 'nginx-proxy <small>(VIRTUAL_HOST)</small>';
+        return `Traefik labels${r.router ? ` <small>(${escapeHtml(r.router)})</small>` : ''}`;
+    };
+
+    document.getElementById('proxyRoutesTable').innerHTML = `
+        ${errors}
+        <p>${report.routes.length} routes, ${report.stopped} to stopped containers, ${report.unmatched} unmatched.${report.traefik_enabled ? '' : ' Enable the Traefik integration to include routes from Traefik\'s API.'}</p>
+        <table class="report-table">
+            <thead>
+                <tr>
+                    <th>URL</th>
+                    <th>Container</th>
+                    <th>Source</th>
+                    <th>Backend</th>
+                    <th>Status</th>
+                </tr>
+            </thead>
+            <tbody>
+                ${report.routes.map(r => `
+                    <tr>
+                        <td><a href="${escapeAttr(r.url)}" target="_blank" rel="noopener noreferrer">${escapeHtml(r.url)}</a></td>
+                        <td>${r.container_name ? `
+                            <code class="container-link" onclick="goToContainerHistory('${escapeHtml(r.container_name)}', ${r.host_id})" title="View in History">${escapeHtml(r.container_name)}</code>
+                            <small>on ${escapeHtml(r.host_name)}</small>` : '-'}</td>
+                        <td>${source(r)}</td>
+                        <td>${r.backend ? `<code>${escapeHtml(r.backend)}</code>${r.backend_status ? ` <small>${escapeHtml(r.backend_status)}</small>` : ''}` : '-'}</td>
+                        <td>${status(r)}</td>
+                    </tr>
+                `).join('')}
+            </tbody>
+        </table>
+    `;
+}
+
+const endpointStatuses = {
+    ok: ['risk-low', 'OK'],
+    expiring: ['risk-medium', 'Expiring'],
+    expired: ['risk-critical', 'Expired'],
+    cert_error: ['risk-high', 'Certificate error'],
+    unreachable: ['risk-high', 'Unreachable'],
+    dns_error: ['risk-high', 'DNS error']
+};
+
+// Load the latest DNS and certificate checks, or check the hostnames now
+async function loadEndpointChecks(runNow) {
+    const table = document.getElementById('endpointChecksTable');
+    table.innerHTML = `<div class="loading">${runNow ? 'Checking hostnames...' : 'Loading...'}</div>`;
+
+    try {
+        const response = runNow
+            ? await fetch('/api/reports/endpoints/check', { method: 'POST' })
+            : await fetch('/api/reports/endpoints');
+        if (!response.ok) {
+            throw new Error(`HTTP ${response.status}: ${await response.text()}`);
+        }
+        renderEndpointChecks(await response.json());
+    } catch (error) {
+        console.error('Failed to load endpoint checks:', error);
+        table.innerHTML = `<p class="empty-message">Failed to load endpoint checks: ${escapeHtml(error.message)}</p>`;
+    }
+}
+
+// Render the endpoint checks, soonest expiring certificate first
+function renderEndpointChecks(checks) {
+    document.getElementById('endpointChecksCount').textContent = checks.filter(c => c.status !== 'ok').length;
+
+    if (checks.length === 0) {
+        document.getElementById('endpointChecksTable').innerHTML = '<p class="empty-message">No hostnames checked yet. Hostnames come from the Proxy Routes report.</p>';
+        return;
+    }
+
+    const expiry = c => {
+        if (!c.cert_not_after) return c.port ? '-' : '<small>http only</small>';
+        const days = c.days_remaining < 0 ? 'expired' : `${c.days_remaining} days`;
+        return `${formatDateTime(c.cert_not_after)} <small>(${days})</small>`;
+    };
+    const status = c => {
+        const [cls, label] = endpointStatuses[c.status] || ['risk-low', c.status];
+        const detail = c.dns_error || c.cert_error || '';
+        return `<span class="risk-badge ${cls}" title="${escapeAttr(detail)}">${escapeHtml(label)}</span>`;
+    };
+
+    document.getElementById('endpointChecksTable').innerHTML = `
+        <table class="report-table">
+            <thead>
+                <tr>
+                    <th>Hostname</th>
+                    <th>Container</th>
+                    <th>Addresses</th>
+                    <th>Certificate Expires</th>
+                    <th>Issuer</th>
+                    <th>Status</th>
+                    <th>Checked</th>
+                </tr>
+            </thead>
+            <tbody>
+                ${checks.map(c => `
+                    <tr>
+                        <td><a href="${escapeAttr(c.url)}" target="_blank" rel="noopener noreferrer">${escapeHtml(c.hostname)}</a></td>
+                        <td>${c.container_name ? `
+                            <code class="container-link" onclick="goToContainerHistory('${escapeHtml(c.container_name)}', ${c.host_id})" title="View in History">${escapeHtml(c.container_name)}</code>
+                            <small>on ${escapeHtml(c.host_name)}</small>` : '-'}</td>
+                        <td>${(c.addresses || []).map(a => `<code>${escapeHtml(a)}</code>`).join(' ') || '-'}</td>
+                        <td>${expiry(c)}</td>
+                        <td>${escapeHtml(c.cert_issuer || '-')}</td>
+                        <td>${status(c)}</td>
+                        <td>${formatDateTime(c.checked_at)}</td>
                     </tr>
                 `).join('')}
             </tbody>
         </table>
     `;
+}
+
+const migrationItemStatuses = {
+    pending: 'Pending',
+    in_progress: 'In progress',
+    migrated: 'Migrated',
+    skipped: 'Skipped',
+    failed: 'Failed'
+};
+
+// The host migration shown in the card, once saved
+let currentHostMigration = null;
+
+// Read the migration form: source, destination and the containers to move
+function hostMigrationRequest() {
+    const containers = document.getElementById('migrationContainers').value
+        .split(',').map(name => name.trim()).filter(name => name);
+    return {
+        source_host_id: parseInt(document.getElementById('migrationSource').value),
+        dest_host_id: parseInt(document.getElementById('migrationDest').value),
+        containers
+    };
+}
+
+// Plan a migration without saving it
+async function planHostMigration() {
+    const table = document.getElementById('hostMigrationsTable');
+    table.innerHTML = '<div class="loading">Planning migration...</div>';
+
+    try {
+        const response = await fetch('/api/migrations/plan', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(hostMigrationRequest())
+        });
+        if (!response.ok) {
+            throw new Error(await response.text());
+        }
+        currentHostMigration = null;
+        renderHostMigration(await response.json());
+    } catch (error) {
+        console.error('Failed to plan migration:', error);
+        table.innerHTML = `<p class="empty-message">Failed to plan migration: ${escapeHtml(error.message)}</p>`;
+    }
+}
+
+// Save the planned migration to track its progress
+async function saveHostMigration() {
+    try {
+        const response = await fetch('/api/migrations', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(hostMigrationRequest())
+        });
+        if (!response.ok) {
+            throw new Error(await response.text());
+        }
+        const migration = await response.json();
+        showNotification(`Migration ${migration.id} saved`, 'success');
+        openHostMigration(migration.id);
+    } catch (error) {
+        console.error('Failed to save migration:', error);
+        showNotification('Failed to save migration: ' + error.message, 'error');
+    }
+}
+
+// List the saved migrations
+async function loadHostMigrations() {
+    const table = document.getElementById('hostMigrationsTable');
+    table.innerHTML = '<div class="loading">Loading migrations...</div>';
+
+    try {
+        const response = await fetch('/api/migrations');
+        if (!response.ok) {
+            throw new Error(`HTTP ${response.status}: ${await response.text()}`);
+        }
+        const migrations = await response.json();
+        document.getElementById('hostMigrationsCount').textContent = migrations.length;
+        if (migrations.length === 0) {
+            table.innerHTML = '<p class="empty-message">No saved migrations</p>';
+            return;
+        }
+
+        const done = m => m.items.filter(i => i.status === 'migrated' || i.status === 'skipped').length;
+        table.innerHTML = `
+            <table class="report-table">
+                <thead>
+                    <tr>
+                        <th>From</th>
+                        <th>To</th>
+                        <th>Progress</th>
+                        <th>Status</th>
+                        <th>Created</th>
+                        <th></th>
+                    </tr>
+                </thead>
+                <tbody>
+                    ${migrations.map(m => `
+                        <tr>
+                            <td>${escapeHtml(m.source_host_name)}</td>
+                            <td>${escapeHtml(m.dest_host_name)}</td>
+                            <td>${done(m)} / ${m.items.length}</td>
+                            <td>${escapeHtml(m.status.replace('_', ' '))}</td>
+                            <td>${formatDateTime(m.created_at)}${m.created_by ? ` by ${escapeHtml(m.created_by)}` : ''}</td>
+                            <td>
+                                <button class="btn btn-sm btn-primary" onclick="openHostMigration(${m.id})">Open</button>
+                                <button class="btn btn-sm btn-danger" onclick="deleteHostMigration(${m.id})">Delete</button>
+                            </td>
+                        </tr>
+                    `).join('')}
+                </tbody>
+            </table>
+        `;
+    } catch (error) {
+        console.error('Failed to load migrations:', error);
+        table.innerHTML = `<p class="empty-message">Failed to load migrations: ${escapeHtml(error.message)}</p>`;
+    }
+}
+
+// Show a saved migration with the containers' current state on both hosts
+async function openHostMigration(id) {
+    try {
+        const response = await fetch(`/api/migrations/${id}`);
+        if (!response.ok) {
+            throw new Error(`HTTP ${response.status}: ${await response.text()}`);
+        }
+        currentHostMigration = await response.json();
+        renderHostMigration(currentHostMigration);
+    } catch (error) {
+        console.error('Failed to load migration:', error);
+        showNotification('Failed to load migration: ' + error.message, 'error');
+    }
+}
+
+async function deleteHostMigration(id) {
+    if (!confirm('Delete this migration? Containers already moved are left as they are.')) return;
+    try {
+        const response = await fetch(`/api/migrations/${id}`, { method: 'DELETE' });
+        if (!response.ok) {
+            throw new Error(await response.text());
+        }
+        loadHostMigrations();
+    } catch (error) {
+        console.error('Failed to delete migration:', error);
+        showNotification('Failed to delete migration: ' + error.message, 'error');
+    }
+}
+
+// Record the progress of one container of the open migration
+async function updateHostMigrationItem(name, status) {
+    if (!currentHostMigration) return;
+    try {
+        const response = await fetch(`/api/migrations/${currentHostMigration.id}/items/${encodeURIComponent(name)}`, {
+            method: 'PUT',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ status })
+        });
+        if (!response.ok) {
+            throw new Error(await response.text());
+        }
+        openHostMigration(currentHostMigration.id);
+    } catch (error) {
+        console.error('Failed to update migration:', error);
+        showNotification('Failed to update migration: ' + error.message, 'error');
+    }
+}
+
+// Render a planned or saved migration: networks to create first, then each container's steps
+function renderHostMigration(m) {
+    const saved = currentHostMigration !== null;
+    const stepLines = steps => steps.map(step => `# on ${step.host === 'source' ? m.source_host_name : m.dest_host_name}\n${step.command}`).join('\n');
+    const state = s => s ? escapeHtml(s) : '<em>absent</em>';
+
+    const header = `
+        <p>
+            ${escapeHtml(m.source_host_name)} → ${escapeHtml(m.dest_host_name)}: ${m.items.length} container(s)
+            ${saved ? `, ${escapeHtml(m.status.replace('_', ' '))}` : ` <button class="btn btn-sm btn-primary" onclick="saveHostMigration()">Save Migration</button>`}
+        </p>
+        <pre>DEST=${escapeHtml(m.ssh_target || 'user@' + m.dest_host_name)}${m.networks && m.networks.length ? '\n' + escapeHtml(stepLines(m.networks)) : ''}</pre>
+        ${(m.warnings || []).map(w => `<p><span class="risk-badge risk-medium">Warning</span> ${escapeHtml(w)}</p>`).join('')}
+    `;
+
+    const items = m.items.map(item => `
+        <div class="card" style="margin-top: 10px;">
+            <div class="card-body">
+                <h4>${escapeHtml(item.container_name)} <small><code>${escapeHtml(item.image)}</code>${item.compose_project ? ` (${escapeHtml(item.compose_project)})` : ''}</small></h4>
+                ${saved ? `
+                    <p>
+                        <select class="filter-select" onchange="updateHostMigrationItem('${escapeAttr(item.container_name)}', this.value)">
+                            ${Object.entries(migrationItemStatuses).map(([value, label]) => `<option value="${value}" ${item.status === value ? 'selected' : ''}>${label}</option>`).join('')}
+                        </select>
+                        Source: ${state(item.source_state)} · Destination: ${state(item.dest_state)}
+                    </p>
+                ` : ''}
+                ${(item.warnings || []).map(w => `<p><span class="risk-badge risk-medium">Warning</span> ${escapeHtml(w)}</p>`).join('')}
+                <pre>${escapeHtml(stepLines(item.steps))}</pre>
+                ${item.compose_service ? `<details><summary>Compose service</summary><pre>${escapeHtml(item.compose_service)}</pre></details>` : ''}
+            </div>
+        </div>
+    `).join('');
+
+    document.getElementById('hostMigrationsTable').innerHTML = header + (items || '<p class="empty-message">No containers to migrate</p>');
+}
+
+const dockerObjectKinds = {
+    plugin: 'Plugin',
+    secret: 'Secret',
+    config: 'Config',
+    build_cache: 'Builder cache'
+};
 
-    document.getElementById('imageUpdatesTable').innerHTML = tableHTML;
+// Load the object inventory of the latest scans
+async function loadDockerObjects() {
+    const kind = document.getElementById('dockerObjectsKind').value;
+    const hostFilter = document.getElementById('reportHostFilter').value;
+    const table = document.getElementById('dockerObjectsTable');
+    table.innerHTML = '<div class="loading">Loading objects...</div>';
+
+    try {
+        const params = new URLSearchParams();
+        if (kind) params.set('kind', kind);
+        if (hostFilter) params.set('host_id', hostFilter);
+
+        const response = await fetch(`/api/docker-objects?${params}`);
+        if (!response.ok) {
+            throw new Error(`HTTP ${response.status}: ${await response.text()}`);
+        }
+        renderDockerObjects(await response.json());
+    } catch (error) {
+        console.error('Failed to load Docker objects:', error);
+        table.innerHTML = `<p class="empty-message">Failed to load Docker objects: ${escapeHtml(error.message)}</p>`;
+    }
 }
 
-// Render state changes table
-function renderStateChanges(changes) {
-    document.getElementById('stateChangesCount').textContent = changes.length;
+// Render the per-host counts and the objects table
+function renderDockerObjects(inventory) {
+    document.getElementById('dockerObjectsCount').textContent = inventory.objects.length;
+    const table = document.getElementById('dockerObjectsTable');
 
-    if (changes.length === 0) {
-        document.getElementById('stateChangesTable').innerHTML = '<p class="empty-message">No state changes in this period</p>';
+    if (inventory.objects.length === 0) {
+        table.innerHTML = inventory.enabled
+            ? '<p class="empty-message">No plugins, secrets, configs or builder cache found</p>'
+            : '<p class="empty-message">The object inventory is off; turn it on in the scanner settings</p>';
         return;
     }
 
-    const tableHTML = `
+    table.innerHTML = `
         <table class="report-table">
             <thead>
-                <tr>
-                    <th>Container Name</th>
-                    <th>Host</th>
-                    <th>Old State</th>
-                    <th>New State</th>
-                    <th>Changed At</th>
-                    <th>Actions</th>
-                </tr>
+                <tr><th>Host</th><th>Plugins</th><th>Secrets</th><th>Configs</th><th>Builder Cache</th><th>Reclaimable</th><th>Scanned</th></tr>
             </thead>
             <tbody>
-                ${changes.map(s => `
+                ${inventory.hosts.map(h => `
                     <tr>
-                        <td>
-                            <code class="container-link" onclick="goToContainerHistory('${escapeHtml(s.container_name)}', ${s.host_id})" title="View in History">
-                                ${escapeHtml(s.container_name)} 🔗
-                            </code>
-                        </td>
-                        <td>${escapeHtml(s.host_name)}</td>
-                        <td><span class="status-badge status-${s.old_state}">${s.old_state}</span></td>
-                        <td><span class="status-badge status-${s.new_state}">${s.new_state}</span></td>
-                        <td>${formatDateTime(s.changed_at)}</td>
-                        <td>
-                            <button class="btn-icon" onclick="openStatsModal(${s.host_id}, '${escapeHtml(s.container_id)}', '${escapeHtml(s.container_name)}')" title="View Stats & Timeline">
-                                📊
-                            </button>
-                            <button class="btn-icon" onclick="viewContainerTimeline(${s.host_id}, '${escapeHtml(s.container_id)}', '${escapeHtml(s.container_name)}')" title="View Lifecycle Timeline">
-                                📜
-                            </button>
-                        </td>
+                        <td>${escapeHtml(h.host_name)}</td>
+                        <td>${h.plugins}</td>
+                        <td>${h.secrets}</td>
+                        <td>${h.configs}</td>
+                        <td>${formatBytes(h.build_cache_bytes)}</td>
+                        <td>${formatBytes(h.build_cache_reclaimable_bytes)}</td>
+                        <td>${formatDateTime(h.scanned_at)}</td>
+                    </tr>
+                `).join('')}
+            </tbody>
+        </table>
+        <table class="report-table" style="margin-top: 15px;">
+            <thead>
+                <tr><th>Host</th><th>Kind</th><th>Name</th><th>Details</th><th>Size</th><th>Created / Last Used</th></tr>
+            </thead>
+            <tbody>
+                ${inventory.objects.map(o => `
+                    <tr>
+                        <td>${escapeHtml(o.host_name)}</td>
+                        <td>${dockerObjectKinds[o.kind] || escapeHtml(o.kind)}</td>
+                        <td><code>${escapeHtml(o.name)}</code>${o.kind === 'plugin' ? (o.in_use ? ' <span class="risk-badge risk-none">Enabled</span>' : ' <span class="risk-badge risk-low">Disabled</span>') : ''}</td>
+                        <td>${o.kind === 'build_cache'
+                            ? `${o.count} records${o.in_use ? ', in use' : ''}`
+                            : escapeHtml(o.detail || '-')}</td>
+                        <td>${o.kind === 'build_cache' ? `${formatBytes(o.size_bytes)} (${formatBytes(o.reclaimable_bytes)} reclaimable)` : '-'}</td>
+                        <td>${o.last_used_at ? formatDateTime(o.last_used_at) : (o.created_at ? formatDateTime(o.created_at) : '-')}</td>
                     </tr>
                 `).join('')}
             </tbody>
         </table>
     `;
+}
 
-    document.getElementById('stateChangesTable').innerHTML = tableHTML;
+// Badge class for each backup job status
+const backupStatusClasses = {
+    ok: 'risk-none',
+    running: 'risk-low',
+    pending: 'risk-low',
+    failed: 'risk-high',
+    overdue: 'risk-critical'
+};
+
+// Load backup jobs and their status
+async function loadBackupJobs() {
+    const hostFilter = document.getElementById('reportHostFilter').value;
+    const table = document.getElementById('backupJobsTable');
+    table.innerHTML = '<div class="loading">Checking backups...</div>';
+
+    try {
+        let url = '/api/backups';
+        if (hostFilter) {
+            url += `?host_id=${hostFilter}`;
+        }
+
+        const response = await fetch(url);
+        if (!response.ok) {
+            throw new Error(`HTTP ${response.status}: ${await response.text()}`);
+        }
+        renderBackupJobs(await response.json());
+    } catch (error) {
+        console.error('Failed to load backup jobs:', error);
+        table.innerHTML = `<p class="empty-message">Failed to load backups: ${escapeHtml(error.message)}</p>`;
+    }
 }
 
-// Render top restarted containers table
-function renderTopRestarted(containers) {
-    document.getElementById('topRestartedCount').textContent = containers.length;
+// Render backup jobs table
+function renderBackupJobs(jobs) {
+    document.getElementById('backupJobsCount').textContent = jobs.length;
 
-    if (containers.length === 0) {
-        document.getElementById('topRestartedTable').innerHTML = '<p class="empty-message">No active containers in this period</p>';
+    if (jobs.length === 0) {
+        document.getElementById('backupJobsTable').innerHTML = '<p class="empty-message">No backup containers found</p>';
         return;
     }
 
@@ -5040,40 +8506,84 @@ function renderTopRestarted(containers) {
             <thead>
                 <tr>
                     <th>Container Name</th>
-                    <th>Image</th>
+                    <th>Tool</th>
                     <th>Host</th>
-                    <th>Activity Count</th>
-                    <th>Current State</th>
+                    <th>Last Run</th>
+                    <th>Last Success</th>
+                    <th>Due</th>
+                    <th>Status</th>
                     <th>Actions</th>
                 </tr>
             </thead>
             <tbody>
-                ${containers.map(r => `
+                ${jobs.map((job, i) => `
                     <tr>
                         <td>
-                            <code class="container-link" onclick="goToContainerHistory('${escapeHtml(r.container_name)}', ${r.host_id})" title="View in History">
-                                ${escapeHtml(r.container_name)} 🔗
-                            </code>
+                            <code>${escapeHtml(job.container_name)}</code>
+                            ${job.state === 'removed' ? '<span class="text-muted">(removed)</span>' : ''}
                         </td>
-                        <td>${escapeHtml(r.image)}</td>
-                        <td>${escapeHtml(r.host_name)}</td>
-                        <td>${r.restart_count}</td>
-                        <td><span class="status-badge status-${r.current_state}">${r.current_state}</span></td>
+                        <td>${escapeHtml(job.tool)}${job.kind === 'service' ? ' <span class="text-muted">(service)</span>' : ''}</td>
+                        <td>${escapeHtml(job.host_name)}</td>
+                        <td>${job.last_run ? `${formatDateTime(job.last_run.finished_at)} (exit ${job.last_run.exit_code})` : '-'}</td>
+                        <td>${job.last_success_at ? formatDateTime(job.last_success_at) : '-'}</td>
+                        <td>${formatDateTime(job.due_at)}</td>
                         <td>
-                            <button class="btn-icon" onclick="openStatsModal(${r.host_id}, '${escapeHtml(r.container_id)}', '${escapeHtml(r.container_name)}')" title="View Stats & Timeline">
-                                📊
-                            </button>
-                            <button class="btn-icon" onclick="viewContainerTimeline(${r.host_id}, '${escapeHtml(r.container_id)}', '${escapeHtml(r.container_name)}')" title="View Lifecycle Timeline">
-                                📜
-                            </button>
+                            <span class="risk-badge ${backupStatusClasses[job.status] || 'risk-low'}" title="${escapeAttr(job.detail)}">${escapeHtml(job.status)}</span>
+                        </td>
+                        <td>
+                            ${job.kind === 'job' ? `<button class="btn btn-sm btn-secondary" onclick="toggleBackupRuns(${job.host_id}, '${escapeAttr(job.container_name)}', 'backupRuns${i}')">Runs</button>` : ''}
                         </td>
                     </tr>
+                    <tr id="backupRuns${i}" style="display: none;"><td colspan="8"></td></tr>
                 `).join('')}
             </tbody>
         </table>
     `;
 
-    document.getElementById('topRestartedTable').innerHTML = tableHTML;
+    document.getElementById('backupJobsTable').innerHTML = tableHTML;
+}
+
+// Show or hide the recorded runs of a backup job below its row
+async function toggleBackupRuns(hostId, containerName, rowId) {
+    const row = document.getElementById(rowId);
+    if (row.style.display !== 'none') {
+        row.style.display = 'none';
+        return;
+    }
+
+    const cell = row.querySelector('td');
+    cell.innerHTML = '<div class="loading">Loading runs...</div>';
+    row.style.display = '';
+
+    try {
+        const response = await fetch(`/api/backups/runs?host_id=${hostId}&container_name=${encodeURIComponent(containerName)}&limit=20`);
+        if (!response.ok) {
+            throw new Error(`HTTP ${response.status}: ${await response.text()}`);
+        }
+        const runs = await response.json();
+        if (runs.length === 0) {
+            cell.innerHTML = '<p class="empty-message">No completed runs recorded yet</p>';
+            return;
+        }
+        cell.innerHTML = `
+            <table class="report-table">
+                <thead><tr><th>Started</th><th>Finished</th><th>Duration</th><th>Exit Code</th></tr></thead>
+                <tbody>
+                    ${runs.map(run => `
+                        <tr>
+                            <td>${formatDateTime(run.started_at)}</td>
+                            <td>${formatDateTime(run.finished_at)}</td>
+                            <td>${Math.max(0, Math.round((new Date(run.finished_at) - new Date(run.started_at)) / 60000))} min</td>
+                            <td><span class="risk-badge ${run.success ? 'risk-none' : 'risk-high'}">${run.exit_code}</span></td>
+                        </tr>
+                    `).join('')}
+                </tbody>
+            </table>
+        `;
+    } catch (error) {
+        console.error('Failed to load backup runs:', error);
+        cell.innerHTML = `<p class="empty-message">Failed to load runs: ${escapeHtml(error.message)}</p>`;
+    }
 }
 
 // Toggle report section visibility
@@ -5122,7 +8632,7 @@ function escapeHtml(text) {
 function formatDateTime(timestamp) {
     if (!timestamp) return '-';
     const date = new Date(timestamp);
-    return date.toLocaleString();
+    return date.toLocaleString(dateLocale(), dateOptions());
 }
 
 // ===== Vulnerability Scanning =====
@@ -5237,101 +8747,389 @@ async function addVulnerabilityBadge(containerElement, imageID) {
     const scan = await getVulnerabilityScan(imageID);
     const badgeHTML = getVulnerabilityBadgeHTML(scan, imageID);
 
-    // Find the image row in the container card
-    const imageRow = containerElement.querySelector('.detail-value.image-value');
-    if (imageRow && imageRow.parentElement) {
-        // Add badge after the image name
-        const badgeContainer = document.createElement('span');
-        badgeContainer.innerHTML = badgeHTML;
-        const badge = badgeContainer.firstChild;
+    // Find the image row in the container card
+    const imageRow = containerElement.querySelector('.detail-value.image-value');
+    if (imageRow && imageRow.parentElement) {
+        // Add badge after the image name
+        const badgeContainer = document.createElement('span');
+        badgeContainer.innerHTML = badgeHTML;
+        const badge = badgeContainer.firstChild;
+
+        // Make badge clickable if it has vulnerabilities
+        if (scan && scan.scan && scan.scan.success) {
+            const imageName = scan.scan.image_name || imageID;
+            badge.style.cursor = 'pointer';
+            badge.onclick = () => viewVulnerabilityDetails(imageID, imageName);
+        }
+
+        imageRow.parentElement.appendChild(badge);
+    }
+}
+
+// Load vulnerability badges for all visible containers
+async function loadAllVulnerabilityBadges() {
+    const containerCards = document.querySelectorAll('.container-card-modern');
+
+    // Get the current filtered containers being displayed
+    const searchTerm = document.getElementById('searchInput')?.value.toLowerCase() || '';
+    const hostFilter = document.getElementById('hostFilter')?.value || '';
+    const stateFilter = document.getElementById('stateFilter')?.value || '';
+
+    const filtered = containers.filter(container => {
+        const matchesSearch = searchTerm === '' ||
+            container.name.toLowerCase().includes(searchTerm) ||
+            container.image.toLowerCase().includes(searchTerm) ||
+            container.host_name.toLowerCase().includes(searchTerm);
+
+        const matchesHost = hostFilter === '' || container.host_id.toString() === hostFilter;
+        const matchesState = stateFilter === '' || container.state === stateFilter;
+
+        return matchesSearch && matchesHost && matchesState;
+    });
+
+    // Pre-load all vulnerability scans to avoid 404 errors
+    await preloadVulnerabilityScans();
+
+    // Now add badges to each card
+    containerCards.forEach((card, index) => {
+        if (filtered[index] && filtered[index].image_id) {
+            addVulnerabilityBadge(card, filtered[index].image_id);
+        }
+    });
+}
+
+// ===== Security Tab =====
+
+let allVulnerabilityScans = [];
+let securityChart = null;
+let scanningImages = new Set(); // Track images currently being scanned
+
+// Load the security tab
+async function loadSecurityTab() {
+    try {
+        // Load summary and all scans in parallel
+        const [summary, scans] = await Promise.all([
+            loadVulnerabilitySummary(),
+            loadAllVulnerabilityScans()
+        ]);
+
+        allVulnerabilityScans = scans || [];
+
+        // Update summary cards
+        updateSecuritySummaryCards(summary, allVulnerabilityScans);
+
+        // Render security chart
+        renderSecurityChart(summary);
+
+        // Render vulnerability trends chart
+        renderVulnerabilityTrendsChart(allVulnerabilityScans);
+
+        // Update queue status
+        updateQueueStatus(summary?.queue_status);
+
+        // Update scan count badge (use allVulnerabilityScans for total, not filtered)
+        const scanCountBadge = document.getElementById('scanCountBadge');
+        if (scanCountBadge && allVulnerabilityScans) {
+            scanCountBadge.textContent = `${allVulnerabilityScans.length} scan${allVulnerabilityScans.length !== 1 ? 's' : ''}`;
+        }
+
+        // Render scans table
+        filterSecurityScans();
+
+        // Render security scores and container privilege audit
+        loadSecurityScores();
+        loadContainerPrivileges();
+        loadPortExposure(false);
+
+        // Start periodic queue status updates (every 3 seconds)
+        startQueueStatusPolling();
+
+    } catch (error) {
+        console.error('Error loading security tab:', error);
+    }
+}
+
+// Security scores of the fleet, hosts and stacks, worst first
+let securityScoreboard = null;
+let securityScoreChart = null;
+
+async function loadSecurityScores() {
+    const tbody = document.getElementById('securityScoreBody');
+    if (!tbody) return;
+
+    const trendDays = document.getElementById('securityScoreTrendDays').value;
+    try {
+        const response = await fetch('/api/security/scores?trend_days=' + encodeURIComponent(trendDays));
+        if (!response.ok) {
+            throw new Error(`HTTP ${response.status}`);
+        }
+        securityScoreboard = await response.json();
+
+        const fleet = securityScoreboard.fleet;
+        document.getElementById('securityScoreFleet').textContent =
+            `Fleet: ${fleet.score} (${fleet.grade})${securityScoreChangeText(fleet.change)}`;
+        renderSecurityScores();
+        loadSecurityScoreHistory(0, '', 'Fleet');
+    } catch (error) {
+        console.error('Error loading security scores:', error);
+        tbody.innerHTML = `<tr><td colspan="8" class="error">Failed to load security scores: ${escapeHtml(error.message)}</td></tr>`;
+    }
+}
+
+function renderSecurityScores() {
+    const tbody = document.getElementById('securityScoreBody');
+    if (!tbody || !securityScoreboard) return;
+
+    const stacks = document.getElementById('securityScoreScope').value === 'stacks';
+    const scores = stacks ? securityScoreboard.stacks : securityScoreboard.hosts;
+    if (scores.length === 0) {
+        tbody.innerHTML = `<tr><td colspan="8" class="loading">No running ${stacks ? 'compose stacks' : 'containers'}</td></tr>`;
+        return;
+    }
+
+    tbody.innerHTML = scores.map(s => `
+        <tr data-host-id="${s.host_id}" data-stack="${escapeAttr(s.stack || '')}"
+            data-label="${escapeAttr(s.stack ? `${s.stack} (${s.host_name})` : s.host_name)}">
+            <td><strong>${escapeHtml(s.stack || s.host_name)}</strong>${s.stack ? `<br><small>${escapeHtml(s.host_name)}</small>` : ''}</td>
+            <td><span class="risk-badge risk-${securityGradeLevel(s.grade)}">${s.score} ${escapeHtml(s.grade)}</span></td>
+            <td>${s.change === undefined ? '-' : securityScoreChangeText(s.change).trim()}</td>
+            <td>${s.critical}</td>
+            <td>${s.high}</td>
+            <td>${s.stale_fixes}</td>
+            <td>${s.privileged}</td>
+            <td>${s.containers}${s.unscanned_images > 0 ? `<br><small>${s.unscanned_images} unscanned image${s.unscanned_images !== 1 ? 's' : ''}</small>` : ''}</td>
+        </tr>
+    `).join('');
+
+    tbody.querySelectorAll('tr[data-host-id]').forEach(row => {
+        row.addEventListener('click', () =>
+            loadSecurityScoreHistory(row.dataset.hostId, row.dataset.stack, row.dataset.label));
+    });
+}
+
+function securityGradeLevel(grade) {
+    return { A: 'none', B: 'low', C: 'medium', D: 'high' }[grade] || 'critical';
+}
+
+function securityScoreChangeText(change) {
+    if (change === undefined || change === null) return '';
+    if (change === 0) return ' ±0';
+    return change > 0 ? ` ▲ ${change}` : ` ▼ ${-change}`;
+}
+
+// Daily scores of the fleet, a host or a stack over the last 90 days
+async function loadSecurityScoreHistory(hostId, stack, label) {
+    const ctx = document.getElementById('securityScoreChart');
+    if (!ctx) return;
+
+    const params = new URLSearchParams({ days: '90' });
+    if (hostId && hostId !== '0') params.set('host_id', hostId);
+    if (stack) params.set('stack', stack);
+    try {
+        const response = await fetch('/api/security/scores/history?' + params.toString());
+        if (!response.ok) {
+            throw new Error(`HTTP ${response.status}`);
+        }
+        const points = await response.json();
+
+        if (securityScoreChart) {
+            securityScoreChart.destroy();
+        }
+        securityScoreChart = new Chart(ctx, {
+            type: 'line',
+            data: {
+                labels: points.map(p => new Date(p.day + 'T00:00:00').toLocaleDateString(userLocale || 'en-US', dateOptions({ month: 'short', day: 'numeric' }))),
+                datasets: [{
+                    label: `${label} score`,
+                    data: points.map(p => p.score),
+                    borderColor: '#667eea',
+                    backgroundColor: 'rgba(102, 126, 234, 0.1)',
+                    borderWidth: 2,
+                    fill: true,
+                    tension: 0.3
+                }]
+            },
+            options: {
+                responsive: true,
+                maintainAspectRatio: false,
+                plugins: {
+                    legend: { position: 'bottom' }
+                },
+                scales: {
+                    y: { min: 0, max: 100 }
+                }
+            }
+        });
+    } catch (error) {
+        console.error('Error loading security score history:', error);
+    }
+}
+
+// Supply-chain timeline: every container that ran an image matching a vulnerability,
+// package or image, and when
+async function searchSupplyChain(e) {
+    e.preventDefault();
+    const field = document.getElementById('supplyChainField').value;
+    const query = document.getElementById('supplyChainQuery').value.trim();
+    const body = document.getElementById('supplyChainBody');
+    const summary = document.getElementById('supplyChainSummary');
+    if (!query) return;
+
+    body.innerHTML = '<tr><td colspan="6" class="loading">Searching...</td></tr>';
+    try {
+        const response = await fetch(`/api/supply-chain/timeline?${field}=${encodeURIComponent(query)}`);
+        if (!response.ok) throw new Error(await response.text());
+        const timeline = await response.json();
 
-        // Make badge clickable if it has vulnerabilities
-        if (scan && scan.scan && scan.scan.success) {
-            const imageName = scan.scan.image_name || imageID;
-            badge.style.cursor = 'pointer';
-            badge.onclick = () => viewVulnerabilityDetails(imageID, imageName);
+        if (timeline.runs.length === 0) {
+            summary.textContent = 'No matching images';
+            body.innerHTML = '<tr><td colspan="6" class="empty-state">No container ran a matching image</td></tr>';
+            return;
         }
-
-        imageRow.parentElement.appendChild(badge);
+        summary.textContent = `${timeline.containers} container${timeline.containers !== 1 ? 's' : ''}, ` +
+            `${timeline.images} image${timeline.images !== 1 ? 's' : ''}, first seen ${formatDateTime(timeline.first_seen)}, ` +
+            (timeline.current > 0 ? `${timeline.current} still running` : `last seen ${formatDateTime(timeline.last_seen)}`);
+        body.innerHTML = timeline.runs.map(run => `
+            <tr>
+                <td>${escapeHtml(run.container_name)}</td>
+                <td>${escapeHtml(run.host_name)}</td>
+                <td><code>${escapeHtml(run.image)}</code> <small><code>${shortImageID(run.image_id)}</code></small></td>
+                <td>${formatDateTime(run.first_seen)}</td>
+                <td>${run.current ? '<span class="badge badge-success">Running</span>' : formatDateTime(run.last_seen)}</td>
+                <td>${imageRunVulnerabilitiesCell(run)}</td>
+            </tr>
+        `).join('');
+    } catch (error) {
+        console.error('Error searching supply chain:', error);
+        summary.textContent = 'Search failed';
+        body.innerHTML = '<tr><td colspan="6" class="error">Failed to load the supply-chain timeline</td></tr>';
     }
 }
 
-// Load vulnerability badges for all visible containers
-async function loadAllVulnerabilityBadges() {
-    const containerCards = document.querySelectorAll('.container-card-modern');
-
-    // Get the current filtered containers being displayed
-    const searchTerm = document.getElementById('searchInput')?.value.toLowerCase() || '';
-    const hostFilter = document.getElementById('hostFilter')?.value || '';
-    const stateFilter = document.getElementById('stateFilter')?.value || '';
+// Load the privilege settings and risk scores of current containers
+async function loadContainerPrivileges() {
+    const tbody = document.getElementById('containerPrivilegesBody');
+    if (!tbody) return;
 
-    const filtered = containers.filter(container => {
-        const matchesSearch = searchTerm === '' ||
-            container.name.toLowerCase().includes(searchTerm) ||
-            container.image.toLowerCase().includes(searchTerm) ||
-            container.host_name.toLowerCase().includes(searchTerm);
+    // Keep the host filter in sync with the configured hosts
+    const hostFilter = document.getElementById('privilegeHostFilter');
+    const selectedHost = hostFilter.value;
+    hostFilter.innerHTML = '<option value="">All Hosts</option>' +
+        hosts.map(h => `<option value="${h.id}">${escapeHtml(h.name)}</option>`).join('');
+    hostFilter.value = selectedHost;
 
-        const matchesHost = hostFilter === '' || container.host_id.toString() === hostFilter;
-        const matchesState = stateFilter === '' || container.state === stateFilter;
+    const params = new URLSearchParams();
+    if (hostFilter.value) params.set('host_id', hostFilter.value);
+    const level = document.getElementById('privilegeLevelFilter').value;
+    if (level) params.set('level', level);
+    const finding = document.getElementById('privilegeFindingFilter').value;
+    if (finding) params.set('finding', finding);
 
-        return matchesSearch && matchesHost && matchesState;
-    });
+    try {
+        const response = await fetch('/api/security/containers?' + params.toString());
+        if (!response.ok) {
+            throw new Error(`HTTP ${response.status}`);
+        }
+        const containers = await response.json();
 
-    // Pre-load all vulnerability scans to avoid 404 errors
-    await preloadVulnerabilityScans();
+        document.getElementById('privilegeCountBadge').textContent =
+            `${containers.length} container${containers.length !== 1 ? 's' : ''}`;
 
-    // Now add badges to each card
-    containerCards.forEach((card, index) => {
-        if (filtered[index] && filtered[index].image_id) {
-            addVulnerabilityBadge(card, filtered[index].image_id);
+        if (containers.length === 0) {
+            tbody.innerHTML = '<tr><td colspan="4" class="loading">No containers match these filters</td></tr>';
+            return;
         }
-    });
-}
 
-// ===== Security Tab =====
+        tbody.innerHTML = containers.map(c => `
+            <tr>
+                <td><strong>${escapeHtml(c.container_name)}</strong><br><small>${escapeHtml(c.image)}</small></td>
+                <td>${escapeHtml(c.host_name)}</td>
+                <td><span class="risk-badge risk-${escapeAttr(c.risk.level)}">${c.risk.score} ${escapeHtml(c.risk.level)}</span></td>
+                <td>${c.risk.findings.length > 0
+                    ? c.risk.findings.map(f => escapeHtml(f.description)).join('<br>')
+                    : '-'}</td>
+            </tr>
+        `).join('');
+    } catch (error) {
+        console.error('Error loading container privileges:', error);
+        tbody.innerHTML = `<tr><td colspan="4" class="error">Failed to load container privileges: ${escapeHtml(error.message)}</td></tr>`;
+    }
+}
 
-let allVulnerabilityScans = [];
-let securityChart = null;
-let scanningImages = new Set(); // Track images currently being scanned
+// Published ports report; probing connects to each port from the server and takes a few seconds
+let portExposureReport = null;
 
-// Load the security tab
-async function loadSecurityTab() {
-    try {
-        // Load summary and all scans in parallel
-        const [summary, scans] = await Promise.all([
-            loadVulnerabilitySummary(),
-            loadAllVulnerabilityScans()
-        ]);
+async function loadPortExposure(probe) {
+    const tbody = document.getElementById('portExposureBody');
+    if (!tbody) return;
 
-        allVulnerabilityScans = scans || [];
+    const button = document.getElementById('probePortsBtn');
+    if (probe) {
+        button.disabled = true;
+        button.textContent = 'Probing...';
+    }
 
-        // Update summary cards
-        updateSecuritySummaryCards(summary, allVulnerabilityScans);
+    try {
+        const response = await fetch('/api/security/ports' + (probe ? '?probe=true' : ''));
+        if (!response.ok) {
+            throw new Error(`HTTP ${response.status}`);
+        }
+        portExposureReport = await response.json();
+        renderPortExposure();
 
-        // Render security chart
-        renderSecurityChart(summary);
+        if (probe) {
+            const summary = portExposureReport.summary;
+            showNotification(`${summary.reachable} of ${summary.total} published ports are reachable from the server`,
+                summary.reachable > 0 ? 'warning' : 'success');
+        }
+    } catch (error) {
+        console.error('Error loading port exposure:', error);
+        tbody.innerHTML = `<tr><td colspan="5" class="error">Failed to load published ports: ${escapeHtml(error.message)}</td></tr>`;
+    } finally {
+        if (probe) {
+            button.disabled = false;
+            button.textContent = '🔌 Probe Reachability';
+        }
+    }
+}
 
-        // Render vulnerability trends chart
-        renderVulnerabilityTrendsChart(allVulnerabilityScans);
+function renderPortExposure() {
+    const tbody = document.getElementById('portExposureBody');
+    if (!tbody || !portExposureReport) return;
 
-        // Update queue status
-        updateQueueStatus(summary?.queue_status);
+    const bindingFilter = document.getElementById('portBindingFilter').value;
+    const ports = portExposureReport.ports.filter(p => !bindingFilter || p.binding === bindingFilter);
 
-        // Update scan count badge (use allVulnerabilityScans for total, not filtered)
-        const scanCountBadge = document.getElementById('scanCountBadge');
-        if (scanCountBadge && allVulnerabilityScans) {
-            scanCountBadge.textContent = `${allVulnerabilityScans.length} scan${allVulnerabilityScans.length !== 1 ? 's' : ''}`;
-        }
+    document.getElementById('portExposureCountBadge').textContent =
+        `${ports.length} port${ports.length !== 1 ? 's' : ''}`;
 
-        // Render scans table
-        filterSecurityScans();
+    if (ports.length === 0) {
+        tbody.innerHTML = '<tr><td colspan="5" class="loading">No published ports</td></tr>';
+        return;
+    }
 
-        // Start periodic queue status updates (every 3 seconds)
-        startQueueStatusPolling();
+    const bindingBadge = {
+        'all_interfaces': '<span class="risk-badge risk-high" title="Reachable from any network the host is on">All interfaces</span>',
+        'specific': '<span class="risk-badge risk-medium">Specific IP</span>',
+        'loopback': '<span class="risk-badge risk-none">Loopback</span>'
+    };
+    const probeBadge = {
+        'reachable': '<span class="risk-badge risk-critical">Reachable</span>',
+        'unreachable': '<span class="risk-badge risk-none">Unreachable</span>',
+        'skipped': '<span class="risk-badge risk-low">Skipped</span>'
+    };
 
-    } catch (error) {
-        console.error('Error loading security tab:', error);
-    }
+    tbody.innerHTML = ports.map(p => `
+        <tr>
+            <td><strong>${escapeHtml(p.container_name)}</strong><br><small>${escapeHtml(p.image)}</small></td>
+            <td>${escapeHtml(p.host_name)}</td>
+            <td><code>${escapeHtml(p.ip)}:${p.public_port} → ${p.private_port}/${escapeHtml(p.protocol)}</code></td>
+            <td>${bindingBadge[p.binding] || escapeHtml(p.binding)}</td>
+            <td>${p.probe
+                ? `${probeBadge[p.probe] || escapeHtml(p.probe)}${p.probe_detail ? `<br><small>${escapeHtml(p.probe_detail)}</small>` : ''}`
+                : '-'}</td>
+        </tr>
+    `).join('');
 }
 
 // Poll queue status periodically to update button states
@@ -5506,7 +9304,7 @@ function renderVulnerabilityTrendsChart(scans) {
         const sortedDates = Object.keys(dailyData).sort();
         const labels = sortedDates.map(date => {
             const d = new Date(date);
-            return d.toLocaleDateString('en-US', { month: 'short', day: 'numeric' });
+            return d.toLocaleDateString(userLocale || 'en-US', dateOptions({ month: 'short', day: 'numeric' }));
         });
 
         const criticalData = sortedDates.map(date => dailyData[date].critical);
@@ -5884,7 +9682,8 @@ async function updateTrivyDB() {
             headers: { 'Authorization': 'Basic ' + btoa(authUsername + ':' + authPassword) }
         });
         if (response.ok) {
-            showNotification('Trivy database updated successfully', 'success');
+            const result = await response.json();
+            showNotification(result.message || 'Trivy database updated successfully', 'success');
         } else {
             const error = await response.json();
             showNotification(`Failed to update database: ${error.error}`, 'error');
@@ -5930,6 +9729,7 @@ function renderVulnerabilityDetails(data) {
     }
 
     const vulns = data.vulnerabilities;
+    const imageName = (data.scan && data.scan.image_name) || '';
 
     // Group by severity
     const bySeverity = {
@@ -5971,6 +9771,7 @@ function renderVulnerabilityDetails(data) {
                                 <th>Installed</th>
                                 <th>Fixed In</th>
                                 <th>Title</th>
+                                <th></th>
                             </tr>
                         </thead>
                         <tbody>
@@ -5985,6 +9786,7 @@ function renderVulnerabilityDetails(data) {
                                     <td><code>${escapeHtml(v.installed_version || 'N/A')}</code></td>
                                     <td><code>${escapeHtml(v.fixed_version || 'Not Fixed')}</code></td>
                                     <td class="vuln-title">${escapeHtml(v.title || 'No description')}</td>
+                                    <td><button class="btn btn-xs btn-secondary" onclick="acceptVulnerabilityRisk('${escapeAttr(v.vulnerability_id)}', '${escapeAttr(imageName)}')" title="Accept this risk for this image">Accept</button></td>
                                 </tr>
                             `).join('')}
                         </tbody>
@@ -5997,10 +9799,146 @@ function renderVulnerabilityDetails(data) {
     document.getElementById('vulnDetailsContent').innerHTML = html;
 }
 
-// Export vulnerabilities (placeholder)
+// Open the vulnerability report and exceptions modal
 function exportVulnerabilities() {
-    showNotification('Export functionality coming soon!', 'info');
-    // TODO: Export vulnerability data as CSV/JSON
+    const select = document.getElementById('vulnReportHost');
+    select.innerHTML = '<option value="">All hosts</option>' +
+        hosts.map(h => `<option value="${h.id}">${escapeHtml(h.name)}</option>`).join('');
+
+    document.getElementById('vulnReportModal').classList.add('show');
+    loadVulnerabilityExceptions();
+}
+
+function closeVulnReportModal() {
+    document.getElementById('vulnReportModal').classList.remove('show');
+}
+
+// Download or open a vulnerability report (HTML opens in a new tab for printing to PDF)
+function downloadVulnerabilityReport(format) {
+    const params = new URLSearchParams({ format });
+    const hostId = document.getElementById('vulnReportHost').value;
+    if (hostId) {
+        params.set('host_id', hostId);
+    }
+
+    const url = appUrl('/api/vulnerabilities/report?' + params.toString());
+    if (format === 'csv') {
+        window.location.href = url;
+    } else {
+        window.open(url, '_blank');
+    }
+}
+
+async function loadVulnerabilityExceptions() {
+    const list = document.getElementById('vulnExceptionsList');
+    list.innerHTML = '<div class="loading">Loading exceptions...</div>';
+
+    try {
+        const response = await fetch('/api/vulnerabilities/exceptions');
+        if (!response.ok) {
+            throw new Error(`HTTP ${response.status}`);
+        }
+        const exceptions = await response.json();
+
+        if (exceptions.length === 0) {
+            list.innerHTML = '<p class="empty-message">No exceptions recorded.</p>';
+            return;
+        }
+
+        const now = new Date();
+        list.innerHTML = `
+            <table class="vuln-table">
+                <thead>
+                    <tr><th>CVE ID</th><th>Images</th><th>Reason</th><th>Expires</th><th></th></tr>
+                </thead>
+                <tbody>
+                    ${exceptions.map(e => {
+                        const expired = e.expires_at && new Date(e.expires_at) <= now;
+                        return `
+                            <tr class="${expired ? 'vuln-exception-expired' : ''}">
+                                <td>${escapeHtml(e.vulnerability_id)}</td>
+                                <td><code>${escapeHtml(e.image_pattern || 'All images')}</code></td>
+                                <td>${escapeHtml(e.reason)}</td>
+                                <td>${e.expires_at ? formatDate(e.expires_at) + (expired ? ' (expired)' : '') : 'Never'}</td>
+                                <td><button class="btn btn-xs btn-danger" onclick="deleteVulnerabilityException(${e.id})">Remove</button></td>
+                            </tr>
+                        `;
+                    }).join('')}
+                </tbody>
+            </table>
+        `;
+    } catch (error) {
+        console.error('Error loading vulnerability exceptions:', error);
+        list.innerHTML = `<div class="error">Failed to load exceptions: ${escapeHtml(error.message)}</div>`;
+    }
+}
+
+async function saveVulnerabilityException(exception) {
+    const response = await fetch('/api/vulnerabilities/exceptions', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(exception)
+    });
+    if (!response.ok) {
+        const error = await response.json();
+        throw new Error(error.error);
+    }
+}
+
+async function addVulnerabilityException(event) {
+    event.preventDefault();
+
+    const exception = {
+        vulnerability_id: document.getElementById('vulnExceptionCVE').value.trim(),
+        image_pattern: document.getElementById('vulnExceptionImage').value.trim(),
+        reason: document.getElementById('vulnExceptionReason').value.trim()
+    };
+    const expires = document.getElementById('vulnExceptionExpires').value;
+    if (expires) {
+        exception.expires_at = new Date(expires + 'T23:59:59').toISOString();
+    }
+
+    try {
+        await saveVulnerabilityException(exception);
+        document.getElementById('vulnExceptionForm').reset();
+        showNotification(`Exception added for ${exception.vulnerability_id}`, 'success');
+        loadVulnerabilityExceptions();
+    } catch (error) {
+        showNotification(`Failed to add exception: ${error.message}`, 'error');
+    }
+}
+
+// Accept a vulnerability for one image from the vulnerability details modal
+async function acceptVulnerabilityRisk(vulnerabilityId, imageName) {
+    const reason = prompt(`Reason for accepting ${vulnerabilityId} in ${imageName || 'this image'}:`);
+    if (!reason || !reason.trim()) {
+        return;
+    }
+
+    try {
+        await saveVulnerabilityException({
+            vulnerability_id: vulnerabilityId,
+            image_pattern: imageName,
+            reason: reason.trim()
+        });
+        showNotification(`${vulnerabilityId} accepted for ${imageName}`, 'success');
+    } catch (error) {
+        showNotification(`Failed to accept risk: ${error.message}`, 'error');
+    }
+}
+
+async function deleteVulnerabilityException(id) {
+    try {
+        const response = await fetch(`/api/vulnerabilities/exceptions/${id}`, { method: 'DELETE' });
+        if (!response.ok) {
+            const error = await response.json();
+            throw new Error(error.error);
+        }
+        showNotification('Exception removed', 'success');
+        loadVulnerabilityExceptions();
+    } catch (error) {
+        showNotification(`Failed to remove exception: ${error.message}`, 'error');
+    }
 }
 
 // ===== Vulnerability Settings Modal =====
@@ -6037,6 +9975,7 @@ function closeVulnerabilitySettingsModal() {
 function populateVulnerabilitySettingsForm(settings) {
     document.getElementById('vulnEnabled').checked = settings.enabled || false;
     document.getElementById('vulnAutoScan').checked = settings.auto_scan_new_images || false;
+    document.getElementById('vulnAgentScanning').checked = settings.agent_scanning || false;
     document.getElementById('vulnWorkerPoolSize').value = settings.worker_pool_size || 5;
     document.getElementById('vulnScanTimeout').value = settings.scan_timeout_minutes || 10;
     document.getElementById('vulnMaxQueueSize').value = settings.max_queue_size || 100;
@@ -6048,6 +9987,39 @@ function populateVulnerabilitySettingsForm(settings) {
     document.getElementById('vulnAlertCritical').checked = settings.alert_on_critical || false;
     document.getElementById('vulnAlertHigh').checked = settings.alert_on_high || false;
     document.getElementById('vulnCacheDir').value = settings.cache_dir || '/app/data/.trivy';
+    document.getElementById('vulnServerURL').value = settings.server_url || '';
+    document.getElementById('vulnServerToken').value = settings.server_token || '';
+    document.getElementById('vulnServerTokenHeader').value = settings.server_token_header || '';
+    document.getElementById('vulnFallbackToLocal').checked = settings.fallback_to_local || false;
+    document.getElementById('vulnServerHealth').textContent = '';
+}
+
+// Check connectivity to the configured Trivy server (uses saved settings)
+async function checkTrivyServerHealth() {
+    const status = document.getElementById('vulnServerHealth');
+    status.textContent = 'Checking...';
+
+    try {
+        const response = await fetch('/api/vulnerabilities/server/health');
+        if (!response.ok) {
+            const error = await response.json();
+            status.textContent = `Health check failed: ${error.error}`;
+            return;
+        }
+
+        const health = await response.json();
+        if (!health.configured) {
+            status.textContent = 'No Trivy server configured - scans run locally. Save settings first to test a new URL.';
+        } else if (health.healthy) {
+            status.textContent = `✅ ${health.server_url} is reachable`;
+        } else {
+            const fallback = health.fallback_to_local ? 'scans will fall back to local Trivy' : 'scans will fail until it is reachable';
+            status.textContent = `❌ ${health.server_url} is unreachable (${health.error || 'unknown error'}) - ${fallback}`;
+        }
+    } catch (error) {
+        console.error('Error checking Trivy server health:', error);
+        status.textContent = 'Health check failed';
+    }
 }
 
 // Save vulnerability settings
@@ -6057,6 +10029,7 @@ async function saveVulnerabilitySettings(event) {
     const settings = {
         enabled: document.getElementById('vulnEnabled').checked,
         auto_scan_new_images: document.getElementById('vulnAutoScan').checked,
+        agent_scanning: document.getElementById('vulnAgentScanning').checked,
         worker_pool_size: parseInt(document.getElementById('vulnWorkerPoolSize').value),
         scan_timeout_minutes: parseInt(document.getElementById('vulnScanTimeout').value),
         max_queue_size: parseInt(document.getElementById('vulnMaxQueueSize').value),
@@ -6067,7 +10040,11 @@ async function saveVulnerabilitySettings(event) {
         detailed_retention_days: parseInt(document.getElementById('vulnDetailedRetentionDays').value),
         alert_on_critical: document.getElementById('vulnAlertCritical').checked,
         alert_on_high: document.getElementById('vulnAlertHigh').checked,
-        cache_dir: document.getElementById('vulnCacheDir').value
+        cache_dir: document.getElementById('vulnCacheDir').value,
+        server_url: document.getElementById('vulnServerURL').value.trim(),
+        server_token: document.getElementById('vulnServerToken').value,
+        server_token_header: document.getElementById('vulnServerTokenHeader').value.trim(),
+        fallback_to_local: document.getElementById('vulnFallbackToLocal').checked
     };
 
     try {
@@ -6225,13 +10202,14 @@ async function loadDashboard() {
         const results = await Promise.allSettled([
             loadContainers(),
             loadHosts(),
-            loadVulnerabilitySummary()
+            loadVulnerabilitySummary(),
+            loadStatsOverview()
         ]);
 
         // Log any failures but don't stop rendering
         results.forEach((result, index) => {
             if (result.status === 'rejected') {
-                const names = ['loadContainers', 'loadHosts', 'loadVulnerabilitySummary'];
+                const names = ['loadContainers', 'loadHosts', 'loadVulnerabilitySummary', 'loadStatsOverview'];
                 console.error(`${names[index]} failed:`, result.reason);
             }
         });
@@ -6251,7 +10229,34 @@ async function loadDashboard() {
     }
 }
 
+// Load fleet totals, per-host rollups and top consumers of the latest scan
+async function loadStatsOverview() {
+    statsOverview = null;
+    const response = await fetchWithAuth('/api/stats/overview?limit=3');
+    if (!response.ok) {
+        throw new Error(`HTTP ${response.status}`);
+    }
+    statsOverview = await response.json();
+    return statsOverview;
+}
+
 function renderDashboardMetrics() {
+    if (statsOverview) {
+        const totals = statsOverview.totals;
+        const running = totals.states.running || 0;
+        document.getElementById('dashTotalHosts').textContent = totals.hosts;
+        document.getElementById('dashHostsChange').textContent = `${totals.hosts_online} online`;
+        document.getElementById('dashRunningContainers').textContent = running;
+        document.getElementById('dashRunningChange').textContent = totals.memory_limit > 0
+            ? `${totals.cpu_percent.toFixed(1)}% CPU · ${formatBytes(totals.memory_usage)} of ${formatBytes(totals.memory_limit)}`
+            : '';
+        document.getElementById('dashTotalContainers').textContent = totals.containers;
+        document.getElementById('dashContainersChange').textContent = totals.containers > running
+            ? `${totals.containers - running} not running`
+            : '';
+        return;
+    }
+
     const safeHosts = hosts || [];
     const safeContainers = containers || [];
 
@@ -6290,13 +10295,13 @@ function renderDashboardHostStatus() {
 
     // Local host status
     if (localHost) {
-        const isOnline = localHost.enabled !== false; // Local host is online if enabled
+        const isOnline = localHost.enabled !== false && localHost.agent_status !== 'offline';
         html += `
             <div>
                 <div style="font-size: 0.75rem; font-weight: 600; color: var(--text-secondary); text-transform: uppercase; margin-bottom: 0.5rem;">Local Host</div>
                 <div class="status-indicator ${isOnline ? 'online' : 'offline'}">
                     <span style="width: 8px; height: 8px; background: currentColor; border-radius: 50%; display: inline-block;"></span>
-                    <span>${isOnline ? 'Online' : 'Offline'}</span>
+                    <span>${escapeHtml(localHost.name)}: ${localHost.enabled === false ? 'Disabled' : isOnline ? 'Online' : 'Offline'}</span>
                 </div>
             </div>
         `;
@@ -6537,18 +10542,22 @@ async function toggleSecurityScanning(newState) {
 function renderDashboardResourceStatus() {
     const container = document.getElementById('dashResourceStatus');
 
-    const runningContainers = containers.filter(c => c.state === 'running');
-    const containersWithStats = runningContainers.filter(c => c.memory_limit > 0);
+    // Top consumers come from the stats overview, else from the loaded containers
+    let topCPU, topMemory;
+    if (statsOverview) {
+        topCPU = statsOverview.top_cpu.map(c => ({ ...c, name: c.container_name }));
+        topMemory = statsOverview.top_memory.map(c => ({ ...c, name: c.container_name }));
+    } else {
+        const containersWithStats = containers.filter(c => c.state === 'running' && c.memory_limit > 0);
+        topCPU = [...containersWithStats].sort((a, b) => b.cpu_percent - a.cpu_percent).slice(0, 3);
+        topMemory = [...containersWithStats].sort((a, b) => b.memory_usage - a.memory_usage).slice(0, 3);
+    }
 
-    if (containersWithStats.length === 0) {
+    if (topCPU.length === 0) {
         container.innerHTML = '<p class="text-secondary">No resource stats available. Enable stats collection in host settings.</p>';
         return;
     }
 
-    // Find top consumers
-    const topCPU = [...containersWithStats].sort((a, b) => b.cpu_percent - a.cpu_percent).slice(0, 3);
-    const topMemory = [...containersWithStats].sort((a, b) => b.memory_percent - a.memory_percent).slice(0, 3);
-
     container.innerHTML = `
         <div style="display: flex; flex-direction: column; gap: 1rem;">
             <div>
@@ -6601,7 +10610,7 @@ async function renderDashboardRecentActivity() {
             const icon = activity.type === 'scan' ? '🔄' : '📡';
             const status = activity.success ? 'Success' : 'Failed';
             const statusColor = activity.success ? 'var(--success)' : 'var(--danger)';
-            const timestamp = new Date(activity.timestamp).toLocaleString();
+            const timestamp = new Date(activity.timestamp).toLocaleString(dateLocale(), dateOptions());
 
             return `
                 <div style="display: flex; align-items: flex-start; gap: 1rem; padding: 0.75rem 0; border-bottom: 1px solid var(--border-light);">
@@ -6660,7 +10669,7 @@ async function renderDashboardTelemetry() {
                     </div>
                     <div style="display: flex; justify-content: space-between; align-items: center;">
                         <span style="color: var(--text-secondary);">Next Submission</span>
-                        <span style="font-weight: 600;">${schedule.next_submission ? new Date(schedule.next_submission).toLocaleString() : 'Unknown'}</span>
+                        <span style="font-weight: 600;">${schedule.next_submission ? new Date(schedule.next_submission).toLocaleString(dateLocale(), dateOptions()) : 'Unknown'}</span>
                     </div>
                     <div style="display: flex; justify-content: space-between; align-items: center;">
                         <span style="color: var(--text-secondary);">Frequency</span>
@@ -6813,50 +10822,162 @@ async function handleImportFile(event) {
     const file = event.target.files[0];
     if (!file) return;
 
-    const statusEl = document.getElementById('importStatus');
-    statusEl.textContent = '⏳ Importing...';
-    statusEl.className = 'save-status-inline';
+    const statusEl = document.getElementById('importStatus');
+    statusEl.textContent = '⏳ Importing...';
+    statusEl.className = 'save-status-inline';
+
+    try {
+        const formData = new FormData();
+        formData.append('file', file);
+
+        const response = await fetchWithAuth('/api/settings/import', {
+            method: 'POST',
+            body: formData
+        });
+
+        const result = await response.json();
+
+        if (response.ok) {
+            statusEl.textContent = '✓ Import successful';
+            statusEl.className = 'save-status-inline success';
+            showToast('Success', 'Settings imported successfully. Reloading...', 'success');
+
+            // Reload the page after 2 seconds to apply new settings
+            setTimeout(() => {
+                window.location.reload();
+            }, 2000);
+        } else {
+            statusEl.textContent = '✗ Import failed';
+            statusEl.className = 'save-status-inline error';
+            showToast('Error', 'Failed to import settings: ' + (result.error || 'Unknown error'), 'error');
+        }
+    } catch (error) {
+        console.error('Error importing settings:', error);
+        statusEl.textContent = '✗ Error';
+        statusEl.className = 'save-status-inline error';
+        showToast('Error', 'Error importing settings: ' + error.message, 'error');
+    }
+
+    // Clear the file input
+    event.target.value = '';
+
+    // Clear status after 5 seconds
+    setTimeout(() => {
+        statusEl.textContent = '';
+        statusEl.className = 'save-status-inline';
+    }, 5000);
+}
+
+// Bundle being imported, kept between the dry run and applying it
+let pendingBundle = null;
+
+async function exportBundle() {
+    const includeSecrets = document.getElementById('bundleIncludeSecrets').checked;
+    try {
+        const response = await fetchWithAuth('/api/settings/bundle' + (includeSecrets ? '?include_secrets=true' : ''));
+        if (!response.ok) {
+            throw new Error(`HTTP ${response.status}`);
+        }
+
+        const blob = await response.blob();
+        const url = window.URL.createObjectURL(blob);
+        const a = document.createElement('a');
+        a.href = url;
+        a.download = `container-census-bundle-${new Date().toISOString().slice(0, 10)}.json`;
+        document.body.appendChild(a);
+        a.click();
+        window.URL.revokeObjectURL(url);
+        document.body.removeChild(a);
+
+        showToast('Success', 'Configuration bundle exported', 'success');
+    } catch (error) {
+        console.error('Error exporting bundle:', error);
+        showToast('Error', 'Failed to export bundle: ' + error.message, 'error');
+    }
+}
+
+// previewBundleImport runs a dry run of the chosen bundle and shows what would change per section
+async function previewBundleImport(event) {
+    const file = event.target.files[0];
+    event.target.value = '';
+    if (!file) return;
+
+    try {
+        pendingBundle = await file.text();
+        const report = await importBundle([], true);
+        renderBundlePreview(report);
+    } catch (error) {
+        pendingBundle = null;
+        showToast('Error', 'Failed to read bundle: ' + error.message, 'error');
+    }
+}
+
+async function importBundle(sections, dryRun) {
+    const params = new URLSearchParams();
+    if (sections.length > 0) params.set('sections', sections.join(','));
+    if (dryRun) params.set('dry_run', 'true');
 
-    try {
-        const formData = new FormData();
-        formData.append('file', file);
+    const response = await fetchWithAuth('/api/settings/bundle/import?' + params.toString(), {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: pendingBundle
+    });
+    const result = await response.json();
+    if (!response.ok) {
+        throw new Error(result.error || `HTTP ${response.status}`);
+    }
+    return result;
+}
 
-        const response = await fetchWithAuth('/api/settings/import', {
-            method: 'POST',
-            body: formData
-        });
+function renderBundlePreview(report) {
+    const container = document.getElementById('bundleImportPreview');
+    const actionLabels = { create: '➕ create', update: '✏️ update', unchanged: 'unchanged', skip: '⚠️ skip' };
 
-        const result = await response.json();
+    const sections = report.sections.map(section => {
+        const changes = report.changes.filter(c => c.section === section);
+        const pending = changes.filter(c => c.action === 'create' || c.action === 'update').length;
+        const rows = changes.map(c => {
+            const detail = c.reason || (c.fields || []).join(', ');
+            return `<li>${actionLabels[c.action] || escapeHtml(c.action)} <strong>${escapeHtml(c.name)}</strong>${detail ? ` <span style="color: var(--text-secondary);">(${escapeHtml(detail)})</span>` : ''}</li>`;
+        }).join('');
+        return `
+            <div style="margin-bottom: 10px;">
+                <label><input type="checkbox" class="bundle-section" value="${escapeAttr(section)}" ${pending > 0 ? 'checked' : ''}>
+                    <strong>${escapeHtml(section.replace(/_/g, ' '))}</strong> - ${pending} change${pending === 1 ? '' : 's'}</label>
+                ${rows ? `<ul style="font-size: 13px; margin: 5px 0 0 20px;">${rows}</ul>` : ''}
+            </div>`;
+    }).join('');
 
-        if (response.ok) {
-            statusEl.textContent = '✓ Import successful';
-            statusEl.className = 'save-status-inline success';
-            showToast('Success', 'Settings imported successfully. Reloading...', 'success');
+    container.innerHTML = `
+        <div style="padding: 12px; border: 1px solid var(--border); border-radius: 4px;">
+            ${sections}
+            <button onclick="applyBundleImport()" class="btn btn-primary">Import Selected Sections</button>
+            <button onclick="cancelBundleImport()" class="btn btn-secondary">Cancel</button>
+        </div>`;
+}
 
-            // Reload the page after 2 seconds to apply new settings
-            setTimeout(() => {
-                window.location.reload();
-            }, 2000);
-        } else {
-            statusEl.textContent = '✗ Import failed';
-            statusEl.className = 'save-status-inline error';
-            showToast('Error', 'Failed to import settings: ' + (result.error || 'Unknown error'), 'error');
-        }
-    } catch (error) {
-        console.error('Error importing settings:', error);
-        statusEl.textContent = '✗ Error';
-        statusEl.className = 'save-status-inline error';
-        showToast('Error', 'Error importing settings: ' + error.message, 'error');
+async function applyBundleImport() {
+    const sections = Array.from(document.querySelectorAll('.bundle-section:checked')).map(cb => cb.value);
+    if (sections.length === 0) {
+        showToast('Info', 'Select at least one section to import', 'info');
+        return;
     }
 
-    // Clear the file input
-    event.target.value = '';
+    try {
+        const report = await importBundle(sections, false);
+        const changed = report.changes.filter(c => c.action === 'create' || c.action === 'update').length;
+        showToast('Success', `Bundle imported: ${changed} change${changed === 1 ? '' : 's'} applied. Reloading...`, 'success');
+        cancelBundleImport();
+        setTimeout(() => window.location.reload(), 2000);
+    } catch (error) {
+        console.error('Error importing bundle:', error);
+        showToast('Error', 'Failed to import bundle: ' + error.message, 'error');
+    }
+}
 
-    // Clear status after 5 seconds
-    setTimeout(() => {
-        statusEl.textContent = '';
-        statusEl.className = 'save-status-inline';
-    }, 5000);
+function cancelBundleImport() {
+    pendingBundle = null;
+    document.getElementById('bundleImportPreview').innerHTML = '';
 }
 
 // ======= DANGER ZONE FUNCTIONS =======
@@ -6891,6 +11012,46 @@ async function resetAllSettings() {
     }
 }
 
+// Summarize an orphan report as "12 rows (containers: 10, image_containers: 2)"
+function describeOrphans(report) {
+    const parts = Object.entries(report.orphans).map(([table, count]) => `${table}: ${count}`);
+    return parts.length ? `${report.total} rows (${parts.join(', ')})` : 'no orphaned rows';
+}
+
+async function checkOrphans() {
+    const statusEl = document.getElementById('orphansStatus');
+    try {
+        const response = await fetch('/api/maintenance/orphans');
+        const report = await response.json();
+        if (!response.ok) {
+            throw new Error(report.error || 'Unknown error');
+        }
+        statusEl.textContent = 'Found ' + describeOrphans(report);
+        statusEl.style.color = '';
+    } catch (error) {
+        console.error('Error checking orphaned data:', error);
+        statusEl.textContent = '✗ ' + error.message;
+        statusEl.style.color = 'red';
+    }
+}
+
+async function cleanupOrphans() {
+    const statusEl = document.getElementById('orphansStatus');
+    try {
+        const response = await fetch('/api/maintenance/orphans/cleanup', { method: 'POST' });
+        const report = await response.json();
+        if (!response.ok) {
+            throw new Error(report.error || 'Unknown error');
+        }
+        statusEl.textContent = '✓ Removed ' + describeOrphans(report);
+        statusEl.style.color = 'green';
+    } catch (error) {
+        console.error('Error cleaning up orphaned data:', error);
+        statusEl.textContent = '✗ ' + error.message;
+        statusEl.style.color = 'red';
+    }
+}
+
 async function clearContainerHistory() {
     if (!confirm('⚠️ Are you sure you want to clear container history?\n\nThis will:\n- Delete all historical container scan data\n- Keep only the most recent snapshot\n- Clear historical charts and trends\n\nThis action cannot be undone.')) {
         return;
@@ -7051,12 +11212,126 @@ async function checkContainerUpdate(hostId, containerId, containerName) {
     }
 }
 
+// Render the update check/update buttons, or the pinned badge for containers pinned against updates
+function renderUpdateActions(cont, isRunning) {
+    if (cont.operation) {
+        const since = new Date(cont.operation.started_at).toLocaleTimeString(dateLocale(), dateOptions());
+        return `<span class="badge-operation" title="Started ${escapeAttr(since)}">⏳ ${escapeHtml(cont.operation.action)} in progress</span>`;
+    }
+
+    if (isIncusHost(cont.host_id)) {
+        return '';
+    }
+
+    if (cont.pin) {
+        const reason = cont.pin.reason ? `Pinned: ${cont.pin.reason}` : 'Pinned';
+        return `
+            <span class="badge-pinned" title="${escapeAttr(reason)} – excluded from update checks and updates">📌 Pinned</span>
+            ${cont.pin.source === 'api' ? `
+                <button class="btn btn-xs btn-secondary" onclick="unpinContainer(${cont.host_id}, '${escapeAttr(cont.name)}')" title="Allow updates again">Unpin</button>
+            ` : ''}
+        `;
+    }
+
+    return `
+        ${(cont.image.endsWith(':latest') || !cont.image.includes(':')) && isRunning ? `
+            <button class="btn btn-xs btn-primary" onclick="checkContainerUpdate(${cont.host_id}, '${escapeAttr(cont.name)}', '${escapeAttr(cont.name)}')" title="Check for updates">
+                🔍 Check
+            </button>
+        ` : ''}
+        ${cont.update_available ? `
+            <button class="btn btn-xs btn-success" onclick="updateContainer(${cont.host_id}, '${escapeAttr(cont.name)}', '${escapeAttr(cont.name)}', '${escapeAttr(cont.image)}')" title="Update image">
+                ⬆️ Update
+            </button>
+        ` : ''}
+        <button class="btn btn-xs btn-secondary" onclick="pinContainer(${cont.host_id}, '${escapeAttr(cont.name)}')" title="Exclude from update checks and updates">📌 Pin</button>
+    `;
+}
+
+// Pin a container so it is skipped by update checks and can't be updated
+async function pinContainer(hostId, containerName) {
+    const reason = prompt(`Pin ${containerName} against updates.\n\nReason (optional):`, '');
+    if (reason === null) return;
+
+    try {
+        const response = await fetch(`/api/containers/${hostId}/${encodeURIComponent(containerName)}/pin`, {
+            method: 'PUT',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ reason })
+        });
+        const result = await response.json();
+        if (!response.ok) {
+            showNotification('Failed to pin container: ' + (result.error || 'Unknown error'), 'error');
+            return;
+        }
+        showNotification(`${containerName} pinned`, 'success');
+        await loadData();
+    } catch (error) {
+        console.error('Error pinning container:', error);
+        showNotification('Error pinning container: ' + error.message, 'error');
+    }
+}
+
+// Remove a container's pin so it is checked and can be updated again
+async function unpinContainer(hostId, containerName) {
+    try {
+        const response = await fetch(`/api/containers/${hostId}/${encodeURIComponent(containerName)}/pin`, {
+            method: 'DELETE'
+        });
+        const result = await response.json();
+        if (!response.ok) {
+            showNotification('Failed to unpin container: ' + (result.error || 'Unknown error'), 'error');
+            return;
+        }
+        showNotification(`${containerName} unpinned`, 'success');
+        await loadData();
+    } catch (error) {
+        console.error('Error unpinning container:', error);
+        showNotification('Error unpinning container: ' + error.message, 'error');
+    }
+}
+
+// Fetch the upstream release notes for a container's image (null when unknown)
+async function fetchContainerChangelog(hostId, containerId) {
+    try {
+        const response = await fetch(`/api/containers/${hostId}/${encodeURIComponent(containerId)}/changelog`);
+        if (!response.ok) return null;
+        const data = await response.json();
+        return data.changelog;
+    } catch (error) {
+        console.error('Error fetching changelog:', error);
+        return null;
+    }
+}
+
+// Render release notes as a "What's new" block for the update dialog
+function renderChangelog(changelog) {
+    if (!changelog) return '';
+
+    if (!changelog.url) {
+        return `
+            <p><strong>Source:</strong> <a href="${escapeAttr(changelog.source)}" target="_blank" rel="noopener">${escapeHtml(changelog.source)}</a> (no releases published)</p>
+        `;
+    }
+
+    const title = changelog.name && changelog.name !== changelog.version
+        ? `${changelog.version} – ${changelog.name}`
+        : changelog.version;
+    const published = changelog.published_at ? ` <span style="color: #666;">(${formatDate(changelog.published_at)})</span>` : '';
+    return `
+        <div style="margin-top: 15px; padding: 10px; background-color: #f1f5f9; border-radius: 4px;">
+            <p><strong>📝 What's new:</strong> <a href="${escapeAttr(changelog.url)}" target="_blank" rel="noopener">${escapeHtml(title)}</a>${published}</p>
+            ${changelog.summary ? `<pre style="white-space: pre-wrap; margin: 8px 0 0; font-size: 0.85em; max-height: 200px; overflow-y: auto;">${escapeHtml(changelog.summary)}</pre>` : ''}
+        </div>
+    `;
+}
+
 // Check all :latest containers for updates
 async function checkAllUpdates() {
-    // Get all containers with :latest tag
-    const latestContainers = containers.filter(c =>
+    // Get all unpinned containers with :latest tag
+    const latestContainers = containers.filter(c => !c.pin && (
         c.image.endsWith(':latest') || (!c.image.includes(':') && c.state === 'running')
-    );
+    ));
 
     if (latestContainers.length === 0) {
         showNotification('No containers with :latest tag found', 'info');
@@ -7145,6 +11420,9 @@ async function checkAllUpdates() {
 
 // Update a single container (pull new image and recreate)
 async function updateContainer(hostId, containerId, containerName, imageName) {
+    // Show what's new upstream, when the image declares its source repository
+    const changelogHtml = renderChangelog(await fetchContainerChangelog(hostId, containerId));
+
     // Show confirmation dialog with dry-run preview
     showConfirmDialog(
         'Update Container',
@@ -7152,6 +11430,7 @@ async function updateContainer(hostId, containerId, containerName, imageName) {
         <div style="text-align: left;">
             <p><strong>Container:</strong> ${escapeHtml(containerName)}</p>
             <p><strong>Image:</strong> ${escapeHtml(imageName)}</p>
+            ${changelogHtml}
             <p style="margin-top: 15px;">This will:</p>
             <ul style="margin: 10px 0;">
                 <li>Pull the latest <code>${escapeHtml(imageName)}</code> image</li>
@@ -7192,14 +11471,31 @@ async function updateContainer(hostId, containerId, containerName, imageName) {
                 // Now perform the actual update
                 updateProgressModal(`Pulling latest ${imageName} image...`);
 
-                const response = await fetch(`/api/containers/${hostId}/${containerId}/update`, {
+                let response = await fetch(`/api/containers/${hostId}/${containerId}/update`, {
                     method: 'POST',
                     headers: {
                         'Content-Type': 'application/json'
                     }
                 });
 
-                const result = await response.json();
+                let result = await response.json();
+
+                // The vulnerability gate warns about an image with more critical vulnerabilities
+                const gate = result.vulnerability_gate;
+                if (response.status === 409 && gate && gate.mode === 'warn') {
+                    if (!confirm(`${result.error}.\n\nUpdate ${containerName} anyway?`)) {
+                        hideProgressModal();
+                        return;
+                    }
+                    updateProgressModal('Recreating container...');
+                    response = await fetch(`/api/containers/${hostId}/${containerId}/update?accept_vulnerabilities=true`, {
+                        method: 'POST',
+                        headers: {
+                            'Content-Type': 'application/json'
+                        }
+                    });
+                    result = await response.json();
+                }
 
                 if (response.ok && result.success) {
                     updateProgressModal('Container updated! Refreshing data...');
@@ -7223,35 +11519,242 @@ async function updateContainer(hostId, containerId, containerName, imageName) {
     );
 }
 
-// Load image update settings
-async function loadImageUpdateSettings() {
+// Load image update settings
+async function loadImageUpdateSettings() {
+    try {
+        const response = await fetch('/api/image-updates/settings');
+        const settings = await response.json();
+
+        if (response.ok) {
+            // Populate settings form
+            document.getElementById('autoCheckEnabled').checked = settings.auto_check_enabled;
+            document.getElementById('checkIntervalHours').value = settings.check_interval_hours;
+            document.getElementById('onlyCheckLatestTags').checked = settings.only_check_latest_tags;
+            document.getElementById('vulnerabilityGate').value = settings.vulnerability_gate || 'off';
+        }
+    } catch (error) {
+        console.error('Error loading image update settings:', error);
+    }
+}
+
+// Save image update settings
+async function saveImageUpdateSettings() {
+    const settings = {
+        auto_check_enabled: document.getElementById('autoCheckEnabled').checked,
+        check_interval_hours: parseInt(document.getElementById('checkIntervalHours').value),
+        only_check_latest_tags: document.getElementById('onlyCheckLatestTags').checked,
+        vulnerability_gate: document.getElementById('vulnerabilityGate').value
+    };
+
+    const statusEl = document.getElementById('imageUpdateSaveStatus');
+
+    try {
+        const response = await fetch('/api/image-updates/settings', {
+            method: 'PUT',
+            headers: {
+                'Content-Type': 'application/json'
+            },
+            body: JSON.stringify(settings)
+        });
+
+        const result = await response.json();
+
+        if (response.ok) {
+            statusEl.textContent = '✓ Settings saved successfully';
+            statusEl.style.color = 'green';
+            setTimeout(() => { statusEl.textContent = ''; }, 3000);
+        } else {
+            statusEl.textContent = '✗ Failed to save: ' + (result.error || 'Unknown error');
+            statusEl.style.color = 'red';
+        }
+    } catch (error) {
+        console.error('Error saving settings:', error);
+        statusEl.textContent = '✗ Error: ' + error.message;
+        statusEl.style.color = 'red';
+    }
+}
+
+// Load Uptime Kuma integration settings
+async function loadUptimeKumaSettings() {
+    try {
+        const response = await fetch('/api/integrations/uptime-kuma/settings');
+        const settings = await response.json();
+
+        if (response.ok) {
+            document.getElementById('uptimeKumaEnabled').checked = settings.enabled;
+            document.getElementById('uptimeKumaSyncMonitors').checked = settings.sync_monitors;
+            document.getElementById('uptimeKumaURL').value = settings.url || '';
+            document.getElementById('uptimeKumaUsername').value = settings.username || '';
+            document.getElementById('uptimeKumaPassword').value = settings.password || '';
+            document.getElementById('uptimeKumaAPIKey').value = settings.api_key || '';
+            document.getElementById('uptimeKumaMonitorInterval').value = settings.monitor_interval_seconds;
+            document.getElementById('uptimeKumaSyncInterval').value = settings.sync_interval_minutes;
+        }
+    } catch (error) {
+        console.error('Error loading Uptime Kuma settings:', error);
+    }
+}
+
+// Save Uptime Kuma integration settings
+async function saveUptimeKumaSettings() {
+    const settings = {
+        enabled: document.getElementById('uptimeKumaEnabled').checked,
+        sync_monitors: document.getElementById('uptimeKumaSyncMonitors').checked,
+        url: document.getElementById('uptimeKumaURL').value.trim(),
+        username: document.getElementById('uptimeKumaUsername').value.trim(),
+        password: document.getElementById('uptimeKumaPassword').value,
+        api_key: document.getElementById('uptimeKumaAPIKey').value.trim(),
+        monitor_interval_seconds: parseInt(document.getElementById('uptimeKumaMonitorInterval').value),
+        sync_interval_minutes: parseInt(document.getElementById('uptimeKumaSyncInterval').value)
+    };
+
+    const statusEl = document.getElementById('uptimeKumaSaveStatus');
+
+    try {
+        const response = await fetch('/api/integrations/uptime-kuma/settings', {
+            method: 'PUT',
+            headers: {
+                'Content-Type': 'application/json'
+            },
+            body: JSON.stringify(settings)
+        });
+
+        const result = await response.json();
+
+        if (response.ok) {
+            statusEl.textContent = '✓ Settings saved successfully';
+            statusEl.style.color = 'green';
+            setTimeout(() => { statusEl.textContent = ''; }, 3000);
+        } else {
+            statusEl.textContent = '✗ Failed to save: ' + (result.error || 'Unknown error');
+            statusEl.style.color = 'red';
+        }
+    } catch (error) {
+        console.error('Error saving Uptime Kuma settings:', error);
+        statusEl.textContent = '✗ Error: ' + error.message;
+        statusEl.style.color = 'red';
+    }
+}
+
+// Sync monitors with Uptime Kuma and refresh container availability
+async function syncUptimeKuma() {
+    const statusEl = document.getElementById('uptimeKumaSaveStatus');
+    statusEl.textContent = 'Syncing...';
+    statusEl.style.color = '';
+
+    try {
+        const response = await fetch('/api/integrations/uptime-kuma/sync', { method: 'POST' });
+        const result = await response.json();
+
+        if (!response.ok) {
+            statusEl.textContent = '✗ ' + (result.error || 'Sync failed');
+            statusEl.style.color = 'red';
+            return;
+        }
+
+        statusEl.textContent = `✓ ${result.monitors} monitors: ${result.created} created, ${result.updated} updated, ${result.unchanged} unchanged, ${result.statuses} statuses read`;
+        statusEl.style.color = 'green';
+        if (result.errors && result.errors.length > 0) {
+            showNotification('Uptime Kuma sync finished with errors: ' + result.errors.join('; '), 'warning');
+        }
+        loadContainers();
+    } catch (error) {
+        console.error('Error syncing Uptime Kuma:', error);
+        statusEl.textContent = '✗ Error: ' + error.message;
+        statusEl.style.color = 'red';
+    }
+}
+
+// Load Proxmox VE integration settings
+async function loadProxmoxSettings() {
+    try {
+        const response = await fetch('/api/integrations/proxmox/settings');
+        const settings = await response.json();
+
+        if (response.ok) {
+            document.getElementById('proxmoxEnabled').checked = settings.enabled;
+            document.getElementById('proxmoxSkipTLSVerify').checked = settings.skip_tls_verify;
+            document.getElementById('proxmoxURL').value = settings.url || '';
+            document.getElementById('proxmoxTokenID').value = settings.token_id || '';
+            document.getElementById('proxmoxTokenSecret').value = settings.token_secret || '';
+            document.getElementById('proxmoxSyncInterval').value = settings.sync_interval_minutes;
+        }
+    } catch (error) {
+        console.error('Error loading Proxmox settings:', error);
+    }
+}
+
+// Save Proxmox VE integration settings
+async function saveProxmoxSettings() {
+    const settings = {
+        enabled: document.getElementById('proxmoxEnabled').checked,
+        skip_tls_verify: document.getElementById('proxmoxSkipTLSVerify').checked,
+        url: document.getElementById('proxmoxURL').value.trim(),
+        token_id: document.getElementById('proxmoxTokenID').value.trim(),
+        token_secret: document.getElementById('proxmoxTokenSecret').value.trim(),
+        sync_interval_minutes: parseInt(document.getElementById('proxmoxSyncInterval').value)
+    };
+
+    const statusEl = document.getElementById('proxmoxSaveStatus');
+
+    try {
+        const response = await fetch('/api/integrations/proxmox/settings', {
+            method: 'PUT',
+            headers: {
+                'Content-Type': 'application/json'
+            },
+            body: JSON.stringify(settings)
+        });
+
+        const result = await response.json();
+
+        if (response.ok) {
+            statusEl.textContent = '✓ Settings saved successfully';
+            statusEl.style.color = 'green';
+            setTimeout(() => { statusEl.textContent = ''; }, 3000);
+        } else {
+            statusEl.textContent = '✗ Failed to save: ' + (result.error || 'Unknown error');
+            statusEl.style.color = 'red';
+        }
+    } catch (error) {
+        console.error('Error saving Proxmox settings:', error);
+        statusEl.textContent = '✗ Error: ' + error.message;
+        statusEl.style.color = 'red';
+    }
+}
+
+// Load Traefik integration settings
+async function loadTraefikSettings() {
     try {
-        const response = await fetch('/api/image-updates/settings');
+        const response = await fetch('/api/integrations/traefik/settings');
         const settings = await response.json();
 
         if (response.ok) {
-            // Populate settings form
-            document.getElementById('autoCheckEnabled').checked = settings.auto_check_enabled;
-            document.getElementById('checkIntervalHours').value = settings.check_interval_hours;
-            document.getElementById('onlyCheckLatestTags').checked = settings.only_check_latest_tags;
+            document.getElementById('traefikEnabled').checked = settings.enabled;
+            document.getElementById('traefikSkipTLSVerify').checked = settings.skip_tls_verify;
+            document.getElementById('traefikURL').value = settings.url || '';
+            document.getElementById('traefikUsername').value = settings.username || '';
+            document.getElementById('traefikPassword').value = settings.password || '';
         }
     } catch (error) {
-        console.error('Error loading image update settings:', error);
+        console.error('Error loading Traefik settings:', error);
     }
 }
 
-// Save image update settings
-async function saveImageUpdateSettings() {
+// Save Traefik integration settings
+async function saveTraefikSettings() {
     const settings = {
-        auto_check_enabled: document.getElementById('autoCheckEnabled').checked,
-        check_interval_hours: parseInt(document.getElementById('checkIntervalHours').value),
-        only_check_latest_tags: document.getElementById('onlyCheckLatestTags').checked
+        enabled: document.getElementById('traefikEnabled').checked,
+        skip_tls_verify: document.getElementById('traefikSkipTLSVerify').checked,
+        url: document.getElementById('traefikURL').value.trim(),
+        username: document.getElementById('traefikUsername').value.trim(),
+        password: document.getElementById('traefikPassword').value
     };
 
-    const statusEl = document.getElementById('imageUpdateSaveStatus');
+    const statusEl = document.getElementById('traefikSaveStatus');
 
     try {
-        const response = await fetch('/api/image-updates/settings', {
+        const response = await fetch('/api/integrations/traefik/settings', {
             method: 'PUT',
             headers: {
                 'Content-Type': 'application/json'
@@ -7270,12 +11773,252 @@ async function saveImageUpdateSettings() {
             statusEl.style.color = 'red';
         }
     } catch (error) {
-        console.error('Error saving settings:', error);
+        console.error('Error saving Traefik settings:', error);
+        statusEl.textContent = '✗ Error: ' + error.message;
+        statusEl.style.color = 'red';
+    }
+}
+
+// Event subscriptions loaded for editing, by ID
+let eventSubscriptions = new Map();
+
+// Load the event bus subscriptions
+async function loadEventSubscriptions() {
+    const listEl = document.getElementById('eventSubscriptionsList');
+    try {
+        const response = await fetch('/api/event-subscriptions');
+        if (!response.ok) {
+            throw new Error('Failed to load event subscriptions');
+        }
+        const subs = await response.json();
+        eventSubscriptions = new Map(subs.map(sub => [sub.id, sub]));
+        renderEventSubscriptions(subs);
+    } catch (error) {
+        console.error('Error loading event subscriptions:', error);
+        listEl.innerHTML = `<p style="color: red;">${escapeHtml(error.message)}</p>`;
+    }
+}
+
+function renderEventSubscriptions(subs) {
+    const listEl = document.getElementById('eventSubscriptionsList');
+    if (subs.length === 0) {
+        listEl.innerHTML = '<p style="color: var(--text-secondary);">No event subscriptions yet.</p>';
+        return;
+    }
+
+    listEl.innerHTML = subs.map(sub => {
+        const types = sub.event_types && sub.event_types.length > 0 ? sub.event_types.join(', ') : 'all events';
+        const delivery = sub.last_delivery_at
+            ? `Last delivery ${formatTimeAgo(new Date(sub.last_delivery_at))}`
+            : 'Nothing delivered yet';
+        return `
+        <div class="event-subscription-item${sub.enabled ? '' : ' disabled'}">
+            <div class="event-subscription-header">
+                <strong>${escapeHtml(sub.name)}${sub.enabled ? '' : ' (disabled)'}</strong>
+                <button onclick="editEventSubscription(${sub.id})" class="btn btn-secondary btn-sm">Edit</button>
+                <button onclick="replayEventSubscription(${sub.id})" class="btn btn-secondary btn-sm">Replay</button>
+                <button onclick="deleteEventSubscription(${sub.id})" class="btn btn-danger btn-sm">Delete</button>
+            </div>
+            <div class="event-subscription-detail">${escapeHtml(sub.url)} · ${escapeHtml(types)}</div>
+            <div class="event-subscription-detail">
+                ${delivery} · delivered up to event #${sub.cursor}
+                ${sub.last_error ? `<div class="event-subscription-error">⚠️ ${escapeHtml(sub.last_error)}</div>` : ''}
+            </div>
+        </div>`;
+    }).join('');
+}
+
+// Fill the form with a subscription to edit it
+function editEventSubscription(id) {
+    const sub = eventSubscriptions.get(id);
+    if (!sub) return;
+
+    document.getElementById('eventSubID').value = sub.id;
+    document.getElementById('eventSubName').value = sub.name;
+    document.getElementById('eventSubURL').value = sub.url;
+    document.getElementById('eventSubSecret').value = sub.secret || '';
+    document.getElementById('eventSubEnabled').checked = sub.enabled;
+    const types = new Set(sub.event_types || []);
+    Array.from(document.getElementById('eventSubTypes').options).forEach(o => { o.selected = types.has(o.value); });
+    document.getElementById('eventSubSaveBtn').textContent = 'Save Subscription';
+}
+
+function resetEventSubscriptionForm() {
+    document.getElementById('eventSubID').value = '';
+    document.getElementById('eventSubName').value = '';
+    document.getElementById('eventSubURL').value = '';
+    document.getElementById('eventSubSecret').value = '';
+    document.getElementById('eventSubEnabled').checked = true;
+    Array.from(document.getElementById('eventSubTypes').options).forEach(o => { o.selected = false; });
+    document.getElementById('eventSubSaveBtn').textContent = 'Add Subscription';
+}
+
+// Create or update the subscription in the form
+async function saveEventSubscription() {
+    const id = document.getElementById('eventSubID').value;
+    const sub = {
+        name: document.getElementById('eventSubName').value.trim(),
+        url: document.getElementById('eventSubURL').value.trim(),
+        secret: document.getElementById('eventSubSecret').value,
+        enabled: document.getElementById('eventSubEnabled').checked,
+        event_types: Array.from(document.getElementById('eventSubTypes').selectedOptions).map(o => o.value)
+    };
+
+    const statusEl = document.getElementById('eventSubSaveStatus');
+
+    try {
+        const response = await fetch(id ? `/api/event-subscriptions/${id}` : '/api/event-subscriptions', {
+            method: id ? 'PUT' : 'POST',
+            headers: {
+                'Content-Type': 'application/json'
+            },
+            body: JSON.stringify(sub)
+        });
+
+        const result = await response.json();
+
+        if (response.ok) {
+            statusEl.textContent = '✓ Subscription saved';
+            statusEl.style.color = 'green';
+            setTimeout(() => { statusEl.textContent = ''; }, 3000);
+            resetEventSubscriptionForm();
+            loadEventSubscriptions();
+        } else {
+            statusEl.textContent = '✗ Failed to save: ' + (result.error || 'Unknown error');
+            statusEl.style.color = 'red';
+        }
+    } catch (error) {
+        console.error('Error saving event subscription:', error);
+        statusEl.textContent = '✗ Error: ' + error.message;
+        statusEl.style.color = 'red';
+    }
+}
+
+// Redeliver a subscription's events from an event ID
+async function replayEventSubscription(id) {
+    const sub = eventSubscriptions.get(id);
+    if (!sub) return;
+
+    const from = prompt(`Redeliver events to "${sub.name}" starting from event ID:`, Math.max(1, sub.cursor - 99));
+    if (from === null) return;
+    const fromEventID = parseInt(from, 10);
+    if (!(fromEventID > 0)) {
+        showNotification('Enter a positive event ID', 'error');
+        return;
+    }
+
+    try {
+        const response = await fetch(`/api/event-subscriptions/${id}/replay`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ from_event_id: fromEventID })
+        });
+        const result = await response.json();
+        if (!response.ok) {
+            throw new Error(result.error || 'Replay failed');
+        }
+        showNotification(`Replaying events to ${sub.name} from #${fromEventID}`, 'success');
+        setTimeout(loadEventSubscriptions, 2000);
+    } catch (error) {
+        console.error('Error replaying events:', error);
+        showNotification('Failed to replay events: ' + error.message, 'error');
+    }
+}
+
+async function deleteEventSubscription(id) {
+    const sub = eventSubscriptions.get(id);
+    if (!sub || !confirm(`Delete the event subscription "${sub.name}"?`)) return;
+
+    try {
+        const response = await fetch(`/api/event-subscriptions/${id}`, { method: 'DELETE' });
+        if (!response.ok) {
+            const result = await response.json();
+            throw new Error(result.error || 'Delete failed');
+        }
+        loadEventSubscriptions();
+    } catch (error) {
+        console.error('Error deleting event subscription:', error);
+        showNotification('Failed to delete event subscription: ' + error.message, 'error');
+    }
+}
+
+// Map hosts to Proxmox guests now and refresh the host list
+async function syncProxmox() {
+    const statusEl = document.getElementById('proxmoxSaveStatus');
+    statusEl.textContent = 'Syncing...';
+    statusEl.style.color = '';
+
+    try {
+        const response = await fetch('/api/integrations/proxmox/sync', { method: 'POST' });
+        const result = await response.json();
+
+        if (!response.ok) {
+            statusEl.textContent = '✗ ' + (result.error || 'Sync failed');
+            statusEl.style.color = 'red';
+            return;
+        }
+
+        statusEl.textContent = `✓ ${result.matched} hosts mapped to ${result.guests} Proxmox VMs, containers and nodes`;
+        statusEl.style.color = 'green';
+        if (result.errors && result.errors.length > 0) {
+            showNotification('Proxmox sync finished with errors: ' + result.errors.join('; '), 'warning');
+        }
+        loadHosts();
+    } catch (error) {
+        console.error('Error syncing Proxmox:', error);
         statusEl.textContent = '✗ Error: ' + error.message;
         statusEl.style.color = 'red';
     }
 }
 
+// Describe the Proxmox VM, container or node a host runs on, for the host list
+function renderProxmoxGuest(guest) {
+    if (!guest) return '';
+
+    const kind = { 'qemu': 'VM', 'lxc': 'CT', 'node': 'Node' }[guest.type] || guest.type;
+    const label = guest.type === 'node'
+        ? `${kind} ${guest.node}`
+        : `${kind} ${guest.vmid} (${guest.name}) on ${guest.node}`;
+    const resources = [];
+    if (guest.cpus) resources.push(`${guest.cpus} vCPU`);
+    if (guest.max_memory) resources.push(`${formatBytes(guest.max_memory)} RAM`);
+    if (guest.max_disk) resources.push(`${formatBytes(guest.max_disk)} disk`);
+
+    const title = `Proxmox ${guest.status}, matched by ${guest.matched_by}, synced ${formatDate(guest.synced_at)}`;
+    return `<br><small class="proxmox-guest" title="${escapeAttr(title)}">🖥️ ${escapeHtml(label)}${resources.length ? ' · ' + escapeHtml(resources.join(' · ')) : ''}</small>`;
+}
+
+// Agent health tells a misbehaving agent apart from a misbehaving Docker daemon
+function renderAgentHealth(health) {
+    if (!health) return '';
+
+    if (health.status === 'agent_error') {
+        return `<br><span class="badge badge-error" title="${escapeAttr(health.error || 'The agent did not report its health')}">Agent error</span>`;
+    }
+
+    const details = [`Agent ${health.version || 'unknown'}`];
+    if (health.avg_scan_seconds) details.push(`avg scan ${health.avg_scan_seconds.toFixed(2)}s`);
+    if (health.memory_sys_bytes) details.push(`${formatBytes(health.memory_sys_bytes)} memory`);
+    const dockerErrors = Object.values(health.docker_api_errors || {}).reduce((sum, n) => sum + n, 0);
+    if (dockerErrors) details.push(`${dockerErrors} Docker API errors`);
+
+    if (health.status === 'docker_error') {
+        const title = `${health.last_docker_error || 'Docker is unreachable'} (${details.join(', ')})`;
+        return `<br><span class="badge badge-error" title="${escapeAttr(title)}">Docker error</span>`;
+    }
+    return `<br><small class="agent-health" title="${escapeAttr(details.join(', '))}">🩺 ${escapeHtml(details.slice(1).join(' · ') || 'healthy')}</small>${renderAgentPermissions(health.permissions)}`;
+}
+
+// Badge for agents that refuse some Docker operations (-allow or -read-only); agents before
+// -allow don't report permissions
+function renderAgentPermissions(permissions) {
+    if (!Array.isArray(permissions) || permissions.length === 4) return '';
+    if (permissions.length === 0) {
+        return ' <span class="badge badge-secondary" title="The agent refuses all Docker operations">🔒 Read-only agent</span>';
+    }
+    return ` <span class="badge badge-secondary" title="The agent only allows these Docker operations">🔒 ${escapeHtml(permissions.join(', '))}</span>`;
+}
+
 // Show progress modal
 function showProgressModal(title, message) {
     const modal = document.getElementById('progressModal');
@@ -7357,7 +12100,7 @@ function displayUpdateResults(containers) {
             if (!dateStr || dateStr === '0001-01-01T00:00:00Z') return 'Unknown';
             const date = new Date(dateStr);
             if (isNaN(date.getTime())) return 'Invalid Date';
-            return date.toLocaleString();
+            return date.toLocaleString(dateLocale(), dateOptions());
         };
 
         row.className = 'update-row-card';
@@ -7566,7 +12309,7 @@ function renderUpdateContainersList() {
 // Add log entry
 function addUpdateLog(message, color = '#d4d4d4') {
     const logsDiv = document.getElementById('updateLogs');
-    const timestamp = new Date().toLocaleTimeString();
+    const timestamp = new Date().toLocaleTimeString(dateLocale(), dateOptions());
     const logEntry = document.createElement('div');
     logEntry.style.color = color;
     logEntry.textContent = `[${timestamp}] ${message}`;
@@ -7633,3 +12376,348 @@ async function finishBatchUpdate() {
     showNotification(`Update complete: ${updateProgressData.total - updateProgressData.failed} successful, ${updateProgressData.failed} failed`,
                     updateProgressData.failed > 0 ? 'warning' : 'success');
 }
+
+// Load who is logged in; tenant users get administrator-only areas hidden
+async function loadCurrentUser() {
+    try {
+        const response = await fetch('/api/me');
+        if (!response.ok) return;
+        currentUser = await response.json();
+        document.body.classList.toggle('tenant-user', !currentUser.admin);
+        document.body.classList.toggle('read-only', !!currentUser.read_only);
+        if (!currentUser.admin && currentTab !== 'dashboard') {
+            const nav = document.querySelector(`.nav-item[data-tab="${currentTab}"]`);
+            if (nav && nav.classList.contains('admin-only')) {
+                switchTab('dashboard');
+            }
+        }
+    } catch (error) {
+        console.error('Error loading current user:', error);
+    }
+}
+
+// Load the read-only mode toggle; it can't be switched off when READ_ONLY forces it
+async function loadReadOnlyMode() {
+    try {
+        const response = await fetch('/api/settings/read-only');
+        if (!response.ok) return;
+        const mode = await response.json();
+        const checkbox = document.getElementById('readOnlyMode');
+        checkbox.checked = mode.read_only;
+        checkbox.disabled = mode.forced_by_env;
+        if (mode.forced_by_env) {
+            document.getElementById('readOnlyStatus').textContent = 'Forced by READ_ONLY';
+        }
+    } catch (error) {
+        console.error('Error loading read-only mode:', error);
+    }
+}
+
+async function saveReadOnlyMode() {
+    const checkbox = document.getElementById('readOnlyMode');
+    const statusEl = document.getElementById('readOnlyStatus');
+    try {
+        const response = await fetch('/api/settings/read-only', {
+            method: 'PUT',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ read_only: checkbox.checked })
+        });
+        const result = await response.json();
+        if (!response.ok) {
+            throw new Error(result.error || 'Failed to save read-only mode');
+        }
+        document.body.classList.toggle('read-only', result.read_only);
+        statusEl.textContent = result.read_only ? '✓ Docker operations disabled' : '✓ Docker operations enabled';
+        statusEl.style.color = 'green';
+    } catch (error) {
+        checkbox.checked = !checkbox.checked;
+        statusEl.textContent = '✗ ' + error.message;
+        statusEl.style.color = 'red';
+    }
+    setTimeout(() => { statusEl.textContent = ''; }, 3000);
+}
+
+// Load tenants with their users and hosts into the Tenants settings card
+async function loadTenants() {
+    const listEl = document.getElementById('tenantsList');
+    try {
+        const [tenantsResponse, hostsResponse] = await Promise.all([
+            fetch('/api/tenants'),
+            fetch('/api/hosts')
+        ]);
+        if (!tenantsResponse.ok || !hostsResponse.ok) {
+            throw new Error('Failed to load tenants');
+        }
+        const tenants = await tenantsResponse.json();
+        const allHosts = await hostsResponse.json();
+        renderTenants(tenants, allHosts);
+    } catch (error) {
+        console.error('Error loading tenants:', error);
+        listEl.innerHTML = `<p style="color: red;">${escapeHtml(error.message)}</p>`;
+    }
+}
+
+function renderTenants(tenants, allHosts) {
+    const listEl = document.getElementById('tenantsList');
+    if (tenants.length === 0) {
+        listEl.innerHTML = '<p style="color: var(--text-secondary);">No tenants yet. All hosts are only visible to the administrator.</p>';
+        return;
+    }
+
+    const hostNames = new Map(allHosts.map(h => [h.id, h.name]));
+    const unassigned = allHosts.filter(h => !h.tenant_id);
+
+    listEl.innerHTML = tenants.map(t => `
+        <div class="tenant-item">
+            <div class="tenant-item-header">
+                <strong>${escapeHtml(t.name)}</strong>
+                <button onclick="deleteTenant(${t.id}, '${escapeHtml(t.name).replace(/'/g, "\\'")}')" class="btn btn-danger btn-sm">Delete</button>
+            </div>
+            <div class="tenant-item-section">
+                <span>Users:</span>
+                ${t.users.map(u => `<span class="tenant-chip">${escapeHtml(u.username)}<button onclick="deleteTenantUser(${t.id}, ${u.id})" title="Remove user">✕</button></span>`).join('') || '<em>none</em>'}
+                <input type="text" id="tenantUser-${t.id}" placeholder="username" class="form-input" style="max-width: 140px;">
+                <input type="password" id="tenantPassword-${t.id}" placeholder="password (8+ chars)" class="form-input" style="max-width: 170px;">
+                <button onclick="addTenantUser(${t.id})" class="btn btn-secondary btn-sm">Add User</button>
+            </div>
+            <div class="tenant-item-section">
+                <span>Hosts:</span>
+                ${t.host_ids.map(id => `<span class="tenant-chip">${escapeHtml(hostNames.get(id) || ('#' + id))}<button onclick="setHostTenant(${id}, 0)" title="Return host to the administrator">✕</button></span>`).join('') || '<em>none</em>'}
+                <select id="tenantHost-${t.id}" class="form-input" style="max-width: 200px;">
+                    ${unassigned.map(h => `<option value="${h.id}">${escapeHtml(h.name)}</option>`).join('')}
+                </select>
+                <button onclick="assignTenantHost(${t.id})" class="btn btn-secondary btn-sm" ${unassigned.length === 0 ? 'disabled' : ''}>Assign Host</button>
+            </div>
+        </div>
+    `).join('');
+}
+
+function showTenantStatus(message, ok) {
+    const statusEl = document.getElementById('tenantSaveStatus');
+    statusEl.textContent = (ok ? '✓ ' : '✗ ') + message;
+    statusEl.style.color = ok ? 'green' : 'red';
+    setTimeout(() => { statusEl.textContent = ''; }, 3000);
+}
+
+// Send a tenant admin request and reload the list; returns whether it succeeded
+async function tenantRequest(url, method, body, successMessage) {
+    try {
+        const options = { method, headers: { 'Content-Type': 'application/json' } };
+        if (body) {
+            options.body = JSON.stringify(body);
+        }
+        const response = await fetch(url, options);
+        if (!response.ok) {
+            const result = await response.json().catch(() => ({}));
+            showTenantStatus(result.error || 'Request failed', false);
+            return false;
+        }
+        showTenantStatus(successMessage, true);
+        await loadTenants();
+        return true;
+    } catch (error) {
+        console.error('Error updating tenants:', error);
+        showTenantStatus(error.message, false);
+        return false;
+    }
+}
+
+async function createTenant() {
+    const input = document.getElementById('newTenantName');
+    const name = input.value.trim();
+    if (!name) {
+        showTenantStatus('Tenant name is required', false);
+        return;
+    }
+    if (await tenantRequest('/api/tenants', 'POST', { name }, 'Tenant created')) {
+        input.value = '';
+    }
+}
+
+async function deleteTenant(id, name) {
+    if (!confirm(`Delete tenant "${name}"? Its users, notification channels and rules are deleted; its hosts go back to the administrator.`)) {
+        return;
+    }
+    await tenantRequest(`/api/tenants/${id}`, 'DELETE', null, 'Tenant deleted');
+}
+
+async function addTenantUser(tenantId) {
+    const username = document.getElementById(`tenantUser-${tenantId}`).value.trim();
+    const password = document.getElementById(`tenantPassword-${tenantId}`).value;
+    await tenantRequest(`/api/tenants/${tenantId}/users`, 'POST', { username, password }, 'User added');
+}
+
+async function deleteTenantUser(tenantId, userId) {
+    if (!confirm('Remove this user? They are logged out on their next request.')) {
+        return;
+    }
+    await tenantRequest(`/api/tenants/${tenantId}/users/${userId}`, 'DELETE', null, 'User removed');
+}
+
+async function assignTenantHost(tenantId) {
+    const select = document.getElementById(`tenantHost-${tenantId}`);
+    if (!select.value) return;
+    await setHostTenant(parseInt(select.value), tenantId);
+}
+
+async function setHostTenant(hostId, tenantId) {
+    await tenantRequest(`/api/hosts/${hostId}/tenant`, 'PUT', { tenant_id: tenantId }, tenantId ? 'Host assigned' : 'Host returned to administrator');
+}
+
+// Agent token ages with a Rotate button per agent
+async function loadAgentTokens() {
+    const listEl = document.getElementById('agentTokensList');
+    try {
+        const response = await fetch('/api/hosts/agent-tokens');
+        if (!response.ok) {
+            throw new Error('Failed to load agent tokens');
+        }
+        const data = await response.json();
+        document.getElementById('agentTokenPolicy').textContent = data.max_age_days > 0
+            ? `Tokens older than ${data.max_age_days} days are rotated automatically; the previous token works for ${data.overlap_minutes} minutes after a rotation.`
+            : `Tokens are only rotated by hand; the previous token works for ${data.overlap_minutes} minutes after a rotation.`;
+        renderAgentTokens(data.hosts);
+    } catch (error) {
+        console.error('Error loading agent tokens:', error);
+        listEl.innerHTML = `<p style="color: red;">${escapeHtml(error.message)}</p>`;
+    }
+}
+
+function renderAgentTokens(hosts) {
+    const listEl = document.getElementById('agentTokensList');
+    if (hosts.length === 0) {
+        listEl.innerHTML = '<p style="color: var(--text-secondary);">No agent hosts.</p>';
+        return;
+    }
+
+    listEl.innerHTML = hosts.map(h => {
+        const last = h.last_rotation;
+        let lastText = 'never rotated';
+        if (last) {
+            lastText = `last rotation ${formatTimeAgo(new Date(last.rotated_at))} (${escapeHtml(last.source)})`;
+            if (!last.success) {
+                lastText += ` failed: <span class="agent-token-error">${escapeHtml(last.error)}</span>`;
+            }
+        }
+        return `
+            <div class="agent-token-item${h.expired ? ' expired' : ''}${h.enabled ? '' : ' disabled'}">
+                <div>
+                    <strong>${escapeHtml(h.host_name)}</strong>
+                    <span class="agent-token-age">${h.age_days} day${h.age_days === 1 ? '' : 's'} old${h.expired ? ' · expired' : ''}</span>
+                    <div class="agent-token-last">${lastText}</div>
+                </div>
+                <button onclick="rotateAgentToken(${h.host_id}, '${escapeHtml(h.host_name).replace(/'/g, "\\'")}')" class="btn btn-secondary btn-sm">Rotate</button>
+            </div>
+        `;
+    }).join('');
+}
+
+async function rotateAgentToken(hostId, hostName) {
+    if (!confirm(`Rotate the agent token of ${hostName}? Other servers or scripts using the current token must be updated before the overlap ends.`)) {
+        return;
+    }
+    const statusEl = document.getElementById('agentTokenStatus');
+    try {
+        const response = await fetch(`/api/hosts/${hostId}/agent-token/rotate`, { method: 'POST' });
+        const result = await response.json().catch(() => ({}));
+        if (!response.ok) {
+            if (result.token) {
+                // The agent switched but the server couldn't save the token: it must be entered by hand
+                alert(`${result.error}\n\nNew token: ${result.token}`);
+            }
+            throw new Error(result.error || 'Rotation failed');
+        }
+        statusEl.textContent = `✓ Rotated the token of ${hostName}`;
+        statusEl.style.color = 'green';
+        showToast('Agent Token Rotated', `New token of ${hostName}: ${result.token}`, 'success');
+    } catch (error) {
+        console.error('Error rotating agent token:', error);
+        statusEl.textContent = '✗ ' + error.message;
+        statusEl.style.color = 'red';
+    }
+    setTimeout(() => { statusEl.textContent = ''; }, 5000);
+    await loadAgentTokens();
+}
+
+// Live fleet view: CPU and memory of every running container, streamed from /api/stats/live
+// while it is open. The server samples all hosts every few seconds for as long as it streams.
+let fleetLiveSource = null;
+
+function toggleFleetLiveStats() {
+    if (fleetLiveSource) {
+        stopFleetLiveStats();
+    } else {
+        startFleetLiveStats();
+    }
+}
+
+function startFleetLiveStats() {
+    stopFleetLiveStats();
+
+    const hostFilter = document.getElementById('monitoringHostFilter')?.value || '';
+    const url = '/api/stats/live' + (hostFilter ? `?host_id=${encodeURIComponent(hostFilter)}` : '');
+    document.getElementById('fleetLiveTable').innerHTML = '<div class="loading">Sampling hosts...</div>';
+    document.getElementById('fleetLiveToggle').textContent = '⏸ Stop';
+
+    fleetLiveSource = new EventSource(appUrl(url));
+    fleetLiveSource.onmessage = (event) => renderFleetLiveStats(JSON.parse(event.data));
+    fleetLiveSource.onerror = () => {
+        stopFleetLiveStats();
+        document.getElementById('fleetLiveTable').innerHTML = '<div class="error">Live fleet view disconnected</div>';
+    };
+}
+
+function stopFleetLiveStats() {
+    if (fleetLiveSource) {
+        fleetLiveSource.close();
+        fleetLiveSource = null;
+    }
+    const toggle = document.getElementById('fleetLiveToggle');
+    if (toggle) {
+        toggle.textContent = '▶ Start';
+    }
+}
+
+function renderFleetLiveStats(sample) {
+    const failed = sample.hosts.filter(h => h.error);
+    document.getElementById('fleetLiveSummary').innerHTML = `
+        <span><strong>${sample.containers.length}</strong> running</span>
+        <span>CPU <strong>${sample.cpu_percent.toFixed(1)}%</strong></span>
+        <span>Memory <strong>${formatBytes(sample.memory_usage)}</strong></span>
+        <span>${sample.hosts.length - failed.length}/${sample.hosts.length} hosts</span>
+        <span class="fleet-live-time">${new Date(sample.timestamp).toLocaleTimeString(dateLocale(), dateOptions())}</span>
+        ${failed.map(h => `<span class="badge badge-error" title="${escapeAttr(h.error)}">${escapeHtml(h.host_name)} unreachable</span>`).join('')}
+    `;
+
+    if (sample.containers.length === 0) {
+        document.getElementById('fleetLiveTable').innerHTML = '<p class="empty-message">No running containers</p>';
+        return;
+    }
+
+    const rows = sample.containers.map(c => `
+        <tr onclick="openStatsModal(${c.host_id}, '${escapeHtml(c.container_id)}', '${escapeHtml(c.name)}')" title="View Stats & Timeline">
+            <td>${escapeHtml(c.name)}</td>
+            <td>${escapeHtml(c.host_name)}</td>
+            <td class="fleet-live-image">${escapeHtml(c.image)}</td>
+            <td class="fleet-live-num">
+                <div class="fleet-live-bar"><div style="width: ${Math.min(c.cpu_percent, 100)}%"></div></div>
+                ${c.cpu_percent.toFixed(1)}%
+            </td>
+            <td class="fleet-live-num">
+                <div class="fleet-live-bar fleet-live-bar-memory"><div style="width: ${Math.min(c.memory_percent, 100)}%"></div></div>
+                ${formatBytes(c.memory_usage)}
+            </td>
+            <td class="fleet-live-num">↓ ${formatBytes(c.network_rx_rate)}/s ↑ ${formatBytes(c.network_tx_rate)}/s</td>
+            <td class="fleet-live-num">R ${formatBytes(c.block_read_rate)}/s W ${formatBytes(c.block_write_rate)}/s</td>
+        </tr>
+    `).join('');
+
+    document.getElementById('fleetLiveTable').innerHTML = `
+        <table class="fleet-live-table">
+            <thead>
+                <tr><th>Container</th><th>Host</th><th>Image</th><th>CPU</th><th>Memory</th><th>Network</th><th>Disk</th></tr>
+            </thead>
+            <tbody>${rows}</tbody>
+        </table>
+    `;
+}
@@ -0,0 +1,16 @@
+// Base path support for running behind a reverse proxy under a sub-path (e.g. /census/).
+// The pages are served from the root of that path, so it is taken from the page URL, and
+// absolute URLs such as /api/... are resolved against it.
+const BASE_PATH = window.location.pathname.replace(/\/[^/]*$/, '');
+
+// Prefix an absolute path with the base path; other URLs are returned unchanged
+function appUrl(path) {
+    if (typeof path === 'string' && path.startsWith('/') && !path.startsWith('//')) {
+        return BASE_PATH + path;
+    }
+    return path;
+}
+
+// Resolve fetch('/api/...') calls against the base path
+const nativeFetch = window.fetch.bind(window);
+window.fetch = (input, init) => nativeFetch(appUrl(input), init);
@@ -26,7 +26,7 @@ document.getElementById('loginForm').addEventListener('submit', async (e) => {
 
         if (response.ok) {
             // Successful login - redirect to main app
-            window.location.href = '/';
+            window.location.href = appUrl('/');
         } else {
             // Failed login - show error
             const data = await response.json().catch(() => ({ error: 'Invalid credentials' }));
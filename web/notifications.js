@@ -9,20 +9,118 @@ let silences = [];
 let unreadCount = 0;
 let currentNotifTab = 'inbox';
 let showUnreadOnly = false;
+let notifGroupBy = '';
+let notifFilter = {}; // event_type, host_id, container_name
+let notificationStream = null;
+let notificationStreamConnected = false;
 
 // Initialize notification system
 function initNotifications() {
     setupNotificationEventListeners();
+    populateEventTypeFilter();
     loadNotificationData();
+    loadNotificationRetention();
+    startNotificationStream();
 
-    // Refresh notifications every 30 seconds
+    // Refresh notifications every 30 seconds while the live stream is down
     setInterval(() => {
+        if (notificationStreamConnected) return;
         if (currentTab === 'notifications' || document.getElementById('notificationDropdown').classList.contains('show')) {
             loadNotifications();
         }
     }, 30000);
 }
 
+// Receive new notifications as they are logged (Server-Sent Events); EventSource reconnects on its own
+function startNotificationStream() {
+    if (typeof EventSource === 'undefined') return;
+
+    notificationStream = new EventSource(appUrl('/api/notifications/stream'));
+    notificationStream.onopen = () => {
+        notificationStreamConnected = true;
+    };
+    notificationStream.onerror = () => {
+        notificationStreamConnected = false;
+    };
+    notificationStream.onmessage = (event) => {
+        const notif = JSON.parse(event.data);
+
+        if (!notif.read) {
+            unreadCount++;
+            updateNotificationBadge();
+        }
+        if (notificationMatchesFilter(notif)) {
+            notifications.unshift(notif);
+            if (notifications.length > 100) {
+                notifications.pop();
+            }
+        }
+
+        if (document.getElementById('notificationDropdown').classList.contains('show')) {
+            renderNotificationDropdown();
+        }
+        if (currentTab === 'notifications' && currentNotifTab === 'inbox') {
+            renderNotificationInbox();
+        }
+    };
+}
+
+// Whether a notification belongs in the currently filtered list
+function notificationMatchesFilter(notif) {
+    if (notifFilter.event_type && notif.event_type !== notifFilter.event_type) return false;
+    if (notifFilter.host_id && notif.host_id !== notifFilter.host_id) return false;
+    if (notifFilter.container_name && notif.container_name !== notifFilter.container_name) return false;
+    return true;
+}
+
+// Query string for the active inbox filter
+function notificationFilterQuery() {
+    const params = new URLSearchParams();
+    if (notifFilter.event_type) params.set('event_type', notifFilter.event_type);
+    if (notifFilter.host_id) params.set('host_id', notifFilter.host_id);
+    if (notifFilter.container_name) params.set('container_name', notifFilter.container_name);
+    return params.toString();
+}
+
+function hasNotificationFilter() {
+    return Boolean(notifFilter.event_type || notifFilter.host_id || notifFilter.container_name);
+}
+
+// Fill the event type filter from the known event types
+function populateEventTypeFilter() {
+    const select = document.getElementById('notifEventTypeFilter');
+    if (!select) return;
+    select.innerHTML = '<option value="">All event types</option>' + Object.keys(eventTypeNames).map(type =>
+        `<option value="${type}">${eventTypeNames[type]}</option>`
+    ).join('');
+}
+
+// Apply a new inbox filter and reload
+function setNotificationFilter(filter) {
+    notifFilter = filter;
+    document.getElementById('notifEventTypeFilter').value = filter.event_type || '';
+    renderActiveNotificationFilter();
+    loadNotifications();
+}
+
+// Show which container or event type the inbox is narrowed to
+function renderActiveNotificationFilter() {
+    const el = document.getElementById('notifActiveFilter');
+    if (!notifFilter.container_name && !notifFilter.host_id) {
+        el.style.display = 'none';
+        el.innerHTML = '';
+        return;
+    }
+
+    const host = notifFilter.host_name ? ` on ${escapeHtml(notifFilter.host_name)}` : '';
+    const name = notifFilter.container_name ? `📦 ${escapeHtml(notifFilter.container_name)}` : 'Host-wide notifications';
+    el.innerHTML = `
+        <span>Showing ${name}${host}</span>
+        <button class="btn btn-sm btn-secondary" onclick="setNotificationFilter({event_type: notifFilter.event_type})">Show all</button>
+    `;
+    el.style.display = 'flex';
+}
+
 // Setup event listeners
 function setupNotificationEventListeners() {
     // Modern toggle switches
@@ -67,6 +165,8 @@ function setupNotificationEventListeners() {
     });
     document.getElementById('clearNotifications').addEventListener('click', clearAllNotifications);
 
+    document.getElementById('notifStatsHours')?.addEventListener('change', loadNotificationStats);
+
     // Notification tab switching
     document.querySelectorAll('.notification-tab-btn').forEach(btn => {
         btn.addEventListener('click', () => {
@@ -90,9 +190,20 @@ function setupNotificationEventListeners() {
         renderNotificationInbox();
     });
 
-    // Inbox actions
-    document.getElementById('markAllReadBtn').addEventListener('click', markAllNotificationsRead);
-    document.getElementById('clearAllNotificationsBtn').addEventListener('click', clearAllNotifications);
+    document.getElementById('notifEventTypeFilter').addEventListener('change', (e) => {
+        setNotificationFilter({ ...notifFilter, event_type: e.target.value });
+    });
+
+    document.getElementById('notifGroupBy').addEventListener('change', (e) => {
+        notifGroupBy = e.target.value;
+        renderNotificationInbox();
+    });
+
+    // Inbox actions (apply to the filtered notifications)
+    document.getElementById('markAllReadBtn').addEventListener('click', () => bulkNotificationAction('read'));
+    document.getElementById('clearAllNotificationsBtn').addEventListener('click', () => bulkNotificationAction('delete'));
+    document.getElementById('saveNotifRetentionBtn').addEventListener('click', saveNotificationRetention);
+    document.getElementById('saveNotifHostStatusBtn').addEventListener('click', saveNotificationHostStatus);
 
     // Channel actions
     document.getElementById('addChannelBtn').addEventListener('click', openAddChannelModal);
@@ -138,9 +249,13 @@ async function loadNotificationData() {
 // Load notifications
 async function loadNotifications(limit = 100) {
     try {
-        const url = showUnreadOnly
+        const filterQuery = notificationFilterQuery();
+        let url = showUnreadOnly
             ? `/api/notifications/logs?limit=${limit}&unread=true`
             : `/api/notifications/logs?limit=${limit}`;
+        if (filterQuery) {
+            url += `&${filterQuery}`;
+        }
 
         const response = await fetch(url);
         if (!response.ok) {
@@ -152,8 +267,12 @@ async function loadNotifications(limit = 100) {
         const data = await response.json();
         notifications = Array.isArray(data) ? data : [];
         console.log('Loaded notifications:', notifications.length, 'notifications');
-        unreadCount = notifications.filter(n => !n.read).length;
-        updateNotificationBadge();
+        if (hasNotificationFilter()) {
+            await refreshUnreadCount();
+        } else {
+            unreadCount = notifications.filter(n => !n.read).length;
+            updateNotificationBadge();
+        }
 
         if (currentNotifTab === 'inbox') {
             renderNotificationInbox();
@@ -249,10 +368,208 @@ function renderNotificationDropdown() {
     `).join('');
 }
 
+// Unread count across all notifications, for when the loaded list is filtered
+async function refreshUnreadCount() {
+    try {
+        const response = await fetch('/api/notifications/status');
+        if (!response.ok) return;
+        const status = await response.json();
+        unreadCount = status.unread_count || 0;
+        updateNotificationBadge();
+    } catch (error) {
+        console.error('Error loading unread count:', error);
+    }
+}
+
+// Load and render notification groups for the current grouping
+async function loadNotificationGroups() {
+    const list = document.getElementById('notificationInboxList');
+    try {
+        let url = `/api/notifications/groups?by=${notifGroupBy}`;
+        if (showUnreadOnly) url += '&unread=true';
+        const filterQuery = notificationFilterQuery();
+        if (filterQuery) url += `&${filterQuery}`;
+
+        const response = await fetch(url);
+        if (!response.ok) {
+            throw new Error(`HTTP ${response.status}`);
+        }
+        renderNotificationGroups(await response.json());
+    } catch (error) {
+        console.error('Error loading notification groups:', error);
+        list.innerHTML = '<div class="notification-empty">Failed to load notification groups</div>';
+    }
+}
+
+// Render notification groups; clicking a group shows its notifications
+function renderNotificationGroups(groups) {
+    const list = document.getElementById('notificationInboxList');
+
+    if (groups.length === 0) {
+        list.innerHTML = '<div class="notification-empty">No notifications</div>';
+        return;
+    }
+
+    list.innerHTML = groups.map((group, i) => {
+        const title = notifGroupBy === 'event_type'
+            ? `${getEventTypeIcon(group.event_type)} ${escapeHtml(getEventTypeName(group.event_type))}`
+            : `📦 ${escapeHtml(group.container_name || 'Host-wide')}${group.host_name ? ` <span class="text-muted">on ${escapeHtml(group.host_name)}</span>` : ''}`;
+        return `
+            <div class="notification-group-item ${group.unread_count > 0 ? 'unread' : ''}" data-group-index="${i}">
+                <div>
+                    <div class="notification-group-title">${title}</div>
+                    <div class="notification-group-message">${escapeHtml(group.latest_message)} · ${formatTimeAgo(group.latest_at)}</div>
+                </div>
+                <div class="notification-group-counts">
+                    ${group.unread_count > 0 ? `<span class="notification-group-unread">${group.unread_count} unread</span>` : ''}
+                    <span>${group.count} total</span>
+                </div>
+            </div>
+        `;
+    }).join('');
+
+    list.querySelectorAll('.notification-group-item').forEach(item => {
+        item.addEventListener('click', () => openNotificationGroup(groups[item.dataset.groupIndex]));
+    });
+}
+
+// Show the notifications of one group
+function openNotificationGroup(group) {
+    notifGroupBy = '';
+    document.getElementById('notifGroupBy').value = '';
+    if (group.event_type) {
+        setNotificationFilter({ ...notifFilter, event_type: group.event_type });
+    } else {
+        setNotificationFilter({
+            event_type: notifFilter.event_type,
+            host_id: group.host_id,
+            host_name: group.host_name,
+            container_name: group.container_name
+        });
+    }
+}
+
+// Mark read or delete every notification matching the current filter
+async function bulkNotificationAction(action) {
+    const scope = hasNotificationFilter() ? 'the filtered notifications' : 'all notifications';
+    if (action === 'delete' && !confirm(`Are you sure you want to clear ${scope}?`)) return;
+
+    try {
+        const response = await fetch('/api/notifications/logs/bulk', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({
+                action: action,
+                event_type: notifFilter.event_type || '',
+                host_id: notifFilter.host_id || 0,
+                container_name: notifFilter.container_name || ''
+            })
+        });
+        if (!response.ok) {
+            throw new Error(`HTTP ${response.status}`);
+        }
+
+        const result = await response.json();
+        await loadNotifications();
+        await refreshUnreadCount();
+        renderNotificationInbox();
+        showToast('Success', `${result.affected} notification(s) ${action === 'read' ? 'marked as read' : 'cleared'}`, 'success');
+    } catch (error) {
+        console.error('Error updating notifications:', error);
+        showToast('Error', 'Failed to update notifications', 'error');
+    }
+}
+
+// Load the notification log retention settings
+async function loadNotificationRetention() {
+    try {
+        const response = await fetch('/api/settings');
+        if (!response.ok) return;
+        const settings = await response.json();
+        document.getElementById('notifRetentionDays').value = settings.notification?.log_retention_days || 7;
+        document.getElementById('notifRetentionCount').value = settings.notification?.log_retention_count || 100;
+        document.getElementById('notifHostDownAfter').value = settings.notification?.host_down_after_failures || 2;
+        document.getElementById('notifHostRecoveredAfter').value = settings.notification?.host_recovered_after_successes || 1;
+    } catch (error) {
+        console.error('Error loading notification retention:', error);
+    }
+}
+
+// Save the notification log retention settings, preserving all other settings
+async function saveNotificationRetention() {
+    const days = parseInt(document.getElementById('notifRetentionDays').value);
+    const count = parseInt(document.getElementById('notifRetentionCount').value);
+    if (!(days >= 1 && days <= 365) || !(count >= 10 && count <= 10000)) {
+        showToast('Error', 'Retention must be 1-365 days and 10-10000 notifications', 'error');
+        return;
+    }
+
+    try {
+        const currentResponse = await fetch('/api/settings');
+        const settings = await currentResponse.json();
+        settings.notification = {
+            ...settings.notification,
+            log_retention_days: days,
+            log_retention_count: count
+        };
+
+        const response = await fetch('/api/settings', {
+            method: 'PUT',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(settings)
+        });
+        if (!response.ok) {
+            throw new Error(await response.text());
+        }
+        showToast('Success', 'Notification retention saved', 'success');
+    } catch (error) {
+        console.error('Error saving notification retention:', error);
+        showToast('Error', 'Failed to save notification retention', 'error');
+    }
+}
+
+// Save how many scans in a row take a host down or back up, preserving all other settings
+async function saveNotificationHostStatus() {
+    const downAfter = parseInt(document.getElementById('notifHostDownAfter').value);
+    const recoveredAfter = parseInt(document.getElementById('notifHostRecoveredAfter').value);
+    if (!(downAfter >= 1 && downAfter <= 20) || !(recoveredAfter >= 1 && recoveredAfter <= 20)) {
+        showToast('Error', 'Host down and recovery thresholds must be 1-20 scans', 'error');
+        return;
+    }
+
+    try {
+        const currentResponse = await fetch('/api/settings');
+        const settings = await currentResponse.json();
+        settings.notification = {
+            ...settings.notification,
+            host_down_after_failures: downAfter,
+            host_recovered_after_successes: recoveredAfter
+        };
+
+        const response = await fetch('/api/settings', {
+            method: 'PUT',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(settings)
+        });
+        if (!response.ok) {
+            throw new Error(await response.text());
+        }
+        showToast('Success', 'Host down alert thresholds saved', 'success');
+    } catch (error) {
+        console.error('Error saving host down thresholds:', error);
+        showToast('Error', 'Failed to save host down thresholds', 'error');
+    }
+}
+
 // Render notification inbox
 function renderNotificationInbox() {
     const list = document.getElementById('notificationInboxList');
 
+    if (notifGroupBy) {
+        loadNotificationGroups();
+        return;
+    }
+
     const filteredNotifs = showUnreadOnly
         ? notifications.filter(n => !n.read)
         : notifications;
@@ -312,7 +629,77 @@ function renderCurrentNotificationTab() {
         case 'silences':
             renderSilencesList();
             break;
+        case 'stats':
+            loadNotificationStats();
+            break;
+    }
+}
+
+// Load and render the per-rule and per-channel counters
+async function loadNotificationStats() {
+    const content = document.getElementById('notifStatsContent');
+    const hours = document.getElementById('notifStatsHours').value;
+    try {
+        const response = await fetch(`/api/notifications/stats?hours=${hours}`);
+        if (!response.ok) throw new Error('Failed to load notification statistics');
+        renderNotificationStats(await response.json());
+    } catch (error) {
+        content.innerHTML = `<div class="notification-empty">${escapeHtml(error.message)}</div>`;
+    }
+}
+
+function renderNotificationStats(stats) {
+    const content = document.getElementById('notifStatsContent');
+    if (stats.totals.total === 0) {
+        content.innerHTML = '<div class="notification-empty">No notifications in this period</div>';
+        return;
     }
+
+    const countCells = c => `
+        <td>${c.sent}</td>
+        <td>${c.failed ? `<span class="badge badge-error">${c.failed}</span>` : 0}</td>
+        <td>${c.suppressed}</td>
+        <td>${c.rate_limited ? `<span class="badge badge-warning">${c.rate_limited}</span>` : 0}</td>
+        <td><strong>${c.total}</strong></td>`;
+    const countHeaders = '<th>Sent</th><th>Failed</th><th>Suppressed</th><th>Rate limited</th><th>Total</th>';
+    const peak = Math.max(1, ...stats.series.map(p => p.total));
+
+    content.innerHTML = `
+        <p>
+            <strong>${stats.totals.total}</strong> notifications:
+            ${stats.totals.sent} sent, ${stats.totals.failed} failed,
+            ${stats.totals.suppressed} suppressed (silenced, cooldown or below the channel's severity),
+            ${stats.totals.rate_limited} rate limited
+        </p>
+        <div class="notif-stats-series" title="Notifications per ${stats.interval}">
+            ${stats.series.map(p => `
+                <div class="notif-stats-bar" style="height: ${Math.round(p.total * 100 / peak)}%"
+                     title="${formatTimestamp(p.time)}: ${p.sent} sent, ${p.failed} failed, ${p.suppressed} suppressed, ${p.rate_limited} rate limited"></div>
+            `).join('')}
+        </div>
+        <h3>Rules</h3>
+        <table class="vuln-table">
+            <thead><tr><th>Rule</th>${countHeaders}</tr></thead>
+            <tbody>
+                ${stats.rules.map(r => `
+                    <tr><td>${r.rule_name ? escapeHtml(r.rule_name) : `<em>deleted rule #${r.rule_id}</em>`}</td>${countCells(r)}</tr>
+                `).join('')}
+            </tbody>
+        </table>
+        <h3>Channels</h3>
+        <table class="vuln-table">
+            <thead><tr><th>Channel</th>${countHeaders}<th>Failure rate</th></tr></thead>
+            <tbody>
+                ${stats.channels.map(c => `
+                    <tr>
+                        <td>${c.channel_name ? `${escapeHtml(c.channel_name)} <small>(${escapeHtml(c.channel_type)})</small>` : `<em>deleted channel #${c.channel_id}</em>`}</td>
+                        ${countCells(c)}
+                        <td>${c.failure_rate >= 10 ? `<span class="badge badge-error">${c.failure_rate.toFixed(1)}%</span>` : `${c.failure_rate.toFixed(1)}%`}</td>
+                    </tr>
+                `).join('')}
+            </tbody>
+        </table>
+    `;
 }
 
 // Render channels list
@@ -347,6 +734,24 @@ function renderChannelsList() {
 
 // Render channel details based on type
 function renderChannelDetails(channel) {
+    return renderChannelConfig(channel) + renderChannelRouting(channel);
+}
+
+// Render severity threshold and quiet hours of a channel
+function renderChannelRouting(channel) {
+    let html = '';
+    if (channel.min_severity) {
+        html += `<div class="channel-detail"><span class="detail-label">Min Severity:</span> <span class="detail-value">${escapeHtml(channel.min_severity)}</span></div>`;
+    }
+    if (channel.quiet_hours) {
+        const quiet = channel.quiet_hours;
+        html += `<div class="channel-detail"><span class="detail-label">Quiet Hours:</span> <span class="detail-value">${escapeHtml(quiet.start)}–${escapeHtml(quiet.end)} (min ${escapeHtml(quiet.min_severity)})</span></div>`;
+    }
+    return html;
+}
+
+// Render type-specific channel configuration
+function renderChannelConfig(channel) {
     const config = channel.config || {};
 
     switch(channel.type) {
@@ -354,6 +759,8 @@ function renderChannelDetails(channel) {
             return `
                 <div class="channel-detail"><span class="detail-label">URL:</span> <span class="detail-value">${config.url || 'N/A'}</span></div>
                 ${config.headers ? `<div class="channel-detail"><span class="detail-label">Headers:</span> <span class="detail-value">Configured</span></div>` : ''}
+                ${config.secret ? `<div class="channel-detail"><span class="detail-label">Signing:</span> <span class="detail-value">HMAC-SHA256</span></div>` : ''}
+                <div class="channel-detail"><span class="detail-label">Attempts:</span> <span class="detail-value">${config.max_attempts || 3}</span></div>
             `;
         case 'ntfy':
             return `
@@ -411,6 +818,7 @@ function renderRulesList() {
                 </div>
                 ${rule.container_pattern ? `<div class="rule-detail"><span class="detail-label">📦 Container Pattern:</span> <span class="detail-value">${rule.container_pattern}</span></div>` : ''}
                 ${rule.image_pattern ? `<div class="rule-detail"><span class="detail-label">🖼️ Image Pattern:</span> <span class="detail-value">${rule.image_pattern}</span></div>` : ''}
+                ${rule.owner_pattern ? `<div class="rule-detail"><span class="detail-label">👤 Owner Pattern:</span> <span class="detail-value">${escapeHtml(rule.owner_pattern)}</span></div>` : ''}
                 ${rule.cpu_threshold || rule.memory_threshold ? `<div class="rule-detail"><span class="detail-label">📊 Thresholds:</span> <span class="detail-value">${rule.cpu_threshold ? 'CPU: ' + rule.cpu_threshold + '%' : ''}${rule.cpu_threshold && rule.memory_threshold ? ', ' : ''}${rule.memory_threshold ? 'Memory: ' + rule.memory_threshold + '%' : ''}</span></div>` : ''}
                 <div class="rule-detail"><span class="detail-label">⏱️ Cooldown:</span> <span class="detail-value">${rule.cooldown_seconds}s</span></div>
             </div>
@@ -542,6 +950,20 @@ function updateChannelConfigFields() {
     document.getElementById('ntfyConfig').style.display = type === 'ntfy' ? 'block' : 'none';
 }
 
+// Read the quiet hours window from the channel form (null when not set)
+function readQuietHours() {
+    const start = document.getElementById('channelQuietStart').value;
+    const end = document.getElementById('channelQuietEnd').value;
+    if (!start || !end) {
+        return null;
+    }
+    return {
+        start: start,
+        end: end,
+        min_severity: document.getElementById('channelQuietSeverity').value
+    };
+}
+
 async function handleChannelSubmit(e) {
     e.preventDefault();
 
@@ -567,6 +989,15 @@ async function handleAddChannel() {
                 return;
             }
         }
+        config.secret = document.getElementById('webhookSecret').value || '';
+        const maxAttempts = parseInt(document.getElementById('webhookMaxAttempts').value);
+        if (maxAttempts > 0) {
+            config.max_attempts = maxAttempts;
+        }
+        const retryBackoff = parseInt(document.getElementById('webhookRetryBackoff').value);
+        if (retryBackoff > 0) {
+            config.retry_backoff_seconds = retryBackoff;
+        }
     } else if (type === 'ntfy') {
         config.server_url = document.getElementById('ntfyServerURL').value || 'https://ntfy.sh';
         config.topic = document.getElementById('ntfyTopic').value;
@@ -577,7 +1008,9 @@ async function handleAddChannel() {
         name: document.getElementById('channelName').value,
         type: type,
         config: config,
-        enabled: document.getElementById('channelEnabled').checked
+        enabled: document.getElementById('channelEnabled').checked,
+        min_severity: document.getElementById('channelMinSeverity').value,
+        quiet_hours: readQuietHours()
     };
 
     try {
@@ -719,6 +1152,7 @@ async function handleAddRule() {
         event_types: eventTypes,
         container_pattern: document.getElementById('ruleContainerPattern').value || '',
         image_pattern: document.getElementById('ruleImagePattern').value || '',
+        owner_pattern: document.getElementById('ruleOwnerPattern').value.trim(),
         threshold_duration_seconds: parseInt(document.getElementById('ruleThresholdDuration').value) || 120,
         cooldown_seconds: parseInt(document.getElementById('ruleCooldown').value) || 300,
         channel_ids: channelIds
@@ -733,6 +1167,12 @@ async function handleAddRule() {
     const memThreshold = document.getElementById('ruleMemoryThreshold').value;
     if (memThreshold) rule.memory_threshold = parseFloat(memThreshold);
 
+    const leakGrowth = document.getElementById('ruleLeakGrowthThreshold').value;
+    if (leakGrowth) rule.leak_growth_threshold = parseFloat(leakGrowth);
+
+    const leakMinDays = document.getElementById('ruleLeakMinDays').value;
+    if (leakMinDays) rule.leak_min_days = parseInt(leakMinDays);
+
     try {
         const response = await fetch('/api/notifications/rules', {
             method: 'POST',
@@ -892,12 +1332,21 @@ function editChannel(id) {
         if (channel.config.headers) {
             document.getElementById('webhookHeaders').value = JSON.stringify(channel.config.headers, null, 2);
         }
+        document.getElementById('webhookSecret').value = channel.config.secret || '';
+        document.getElementById('webhookMaxAttempts').value = channel.config.max_attempts || '';
+        document.getElementById('webhookRetryBackoff').value = channel.config.retry_backoff_seconds || '';
     } else if (channel.type === 'ntfy') {
         document.getElementById('ntfyServerURL').value = channel.config.server_url || 'https://ntfy.sh';
         document.getElementById('ntfyTopic').value = channel.config.topic || '';
         document.getElementById('ntfyToken').value = channel.config.token || '';
     }
 
+    document.getElementById('channelMinSeverity').value = channel.min_severity || '';
+    const quietHours = channel.quiet_hours || {};
+    document.getElementById('channelQuietStart').value = quietHours.start || '';
+    document.getElementById('channelQuietEnd').value = quietHours.end || '';
+    document.getElementById('channelQuietSeverity').value = quietHours.min_severity || 'critical';
+
     // Change modal title
     document.querySelector('#addChannelModal .modal-header h3').textContent = 'Edit Channel';
     document.querySelector('#addChannelModal .btn-primary').textContent = 'Update Channel';
@@ -920,6 +1369,15 @@ async function handleUpdateChannel(id) {
                 return;
             }
         }
+        config.secret = document.getElementById('webhookSecret').value || '';
+        const maxAttempts = parseInt(document.getElementById('webhookMaxAttempts').value);
+        if (maxAttempts > 0) {
+            config.max_attempts = maxAttempts;
+        }
+        const retryBackoff = parseInt(document.getElementById('webhookRetryBackoff').value);
+        if (retryBackoff > 0) {
+            config.retry_backoff_seconds = retryBackoff;
+        }
     } else if (type === 'ntfy') {
         config.server_url = document.getElementById('ntfyServerURL').value || 'https://ntfy.sh';
         config.topic = document.getElementById('ntfyTopic').value;
@@ -931,7 +1389,9 @@ async function handleUpdateChannel(id) {
         name: document.getElementById('channelName').value,
         type: type,
         config: config,
-        enabled: document.getElementById('channelEnabled').checked
+        enabled: document.getElementById('channelEnabled').checked,
+        min_severity: document.getElementById('channelMinSeverity').value,
+        quiet_hours: readQuietHours()
     };
 
     try {
@@ -976,8 +1436,11 @@ function editRule(id) {
     document.getElementById('ruleHost').value = rule.host_id || '';
     document.getElementById('ruleContainerPattern').value = rule.container_pattern || '';
     document.getElementById('ruleImagePattern').value = rule.image_pattern || '';
+    document.getElementById('ruleOwnerPattern').value = rule.owner_pattern || '';
     document.getElementById('ruleCPUThreshold').value = rule.cpu_threshold || '';
     document.getElementById('ruleMemoryThreshold').value = rule.memory_threshold || '';
+    document.getElementById('ruleLeakGrowthThreshold').value = rule.leak_growth_threshold || '';
+    document.getElementById('ruleLeakMinDays').value = rule.leak_min_days || '';
     document.getElementById('ruleThresholdDuration').value = rule.threshold_duration_seconds || 120;
     document.getElementById('ruleCooldown').value = rule.cooldown_seconds || 300;
 
@@ -1018,6 +1481,7 @@ async function handleUpdateRule(id) {
         event_types: eventTypes,
         container_pattern: document.getElementById('ruleContainerPattern').value || '',
         image_pattern: document.getElementById('ruleImagePattern').value || '',
+        owner_pattern: document.getElementById('ruleOwnerPattern').value.trim(),
         threshold_duration_seconds: parseInt(document.getElementById('ruleThresholdDuration').value) || 120,
         cooldown_seconds: parseInt(document.getElementById('ruleCooldown').value) || 300,
         channel_ids: channelIds
@@ -1032,6 +1496,12 @@ async function handleUpdateRule(id) {
     const memThreshold = document.getElementById('ruleMemoryThreshold').value;
     if (memThreshold) rule.memory_threshold = parseFloat(memThreshold);
 
+    const leakGrowth = document.getElementById('ruleLeakGrowthThreshold').value;
+    if (leakGrowth) rule.leak_growth_threshold = parseFloat(leakGrowth);
+
+    const leakMinDays = document.getElementById('ruleLeakMinDays').value;
+    if (leakMinDays) rule.leak_min_days = parseInt(leakMinDays);
+
     try {
         const response = await fetch(`/api/notifications/rules/${id}`, {
             method: 'PUT',
@@ -1064,24 +1534,45 @@ function getEventTypeIcon(type) {
         container_resumed: '▶️',
         high_cpu: '📈',
         high_memory: '💾',
-        anomalous_behavior: '⚠️'
+        anomalous_behavior: '⚠️',
+        idle_containers: '💤',
+        memory_leak: '💧',
+        privileged_container: '🛡️',
+        backup_overdue: '💾',
+        script_alert: '📜',
+        host_down: '🔴',
+        host_recovered: '🟢',
+        oom_kill: '💥',
+        daemon_error: '🐳',
+        restart_policy: '🔁'
     };
     return icons[type] || '📬';
 }
 
+const eventTypeNames = {
+    new_image: 'New Image',
+    state_change: 'State Change',
+    container_started: 'Started',
+    container_stopped: 'Stopped',
+    container_paused: 'Paused',
+    container_resumed: 'Resumed',
+    high_cpu: 'High CPU',
+    high_memory: 'High Memory',
+    anomalous_behavior: 'Anomaly',
+    idle_containers: 'Idle Digest',
+    memory_leak: 'Memory Leak',
+    privileged_container: 'Privileged Container',
+    backup_overdue: 'Backup Overdue',
+    script_alert: 'Script Alert',
+    host_down: 'Host Down',
+    host_recovered: 'Host Recovered',
+    oom_kill: 'OOM Kill',
+    daemon_error: 'Daemon Error',
+    restart_policy: 'Restart Policy'
+};
+
 function getEventTypeName(type) {
-    const names = {
-        new_image: 'New Image',
-        state_change: 'State Change',
-        container_started: 'Started',
-        container_stopped: 'Stopped',
-        container_paused: 'Paused',
-        container_resumed: 'Resumed',
-        high_cpu: 'High CPU',
-        high_memory: 'High Memory',
-        anomalous_behavior: 'Anomaly'
-    };
-    return names[type] || type;
+    return eventTypeNames[type] || type;
 }
 
 function formatTimeAgo(timestamp) {
@@ -1119,7 +1610,7 @@ function formatTimestamp(timestamp) {
 
     if (isNaN(date.getTime())) return 'Invalid date';
 
-    return date.toLocaleString();
+    return date.toLocaleString(dateLocale(), dateOptions());
 }
 
 // Make functions globally available